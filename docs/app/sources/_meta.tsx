@@ -1,6 +1,9 @@
 export default {
     'kafka': '',
     'otlp': '',
+    'pulsar': '',
+    'http': '',
+    'mysql': '',
     'airbyte': '',
     'aws-msk': '',
     'azure-event-hubs': '',
@@ -19,7 +22,6 @@ export default {
     'logstash': '',
     'mariadb': '',
     'mongodb': '',
-    'mysql': '',
     'neon': '',
     'posthog': '',
     'postgresql': '',
@@ -1,6 +1,10 @@
 export default {
+    'debezium-unwrap': '',
     'deduplication': '',
+    'enrichment': '',
+    'explode': '',
     'filter': '',
     'join': '',
+    'sql-query': '',
     'stateless-transformation': ''
 }
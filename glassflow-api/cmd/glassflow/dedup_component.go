@@ -16,6 +16,8 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	badgerDeduplication "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/deduplication/badger"
+	domainDeduplication "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/deduplication/domain"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/enrichment"
 	filterJSON "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/filter/json"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/processor"
@@ -33,9 +35,9 @@ func mainDeduplicatorV2(
 	log *slog.Logger,
 ) error {
 
-	pipelineCfg, err := getPipelineConfigFromJSON(cfg.PipelineConfig)
+	pipelineCfg, err := loadPipelineConfig(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get pipeline config: %w", err)
+		return err
 	}
 
 	if pipelineCfg.ID == "" {
@@ -43,6 +45,16 @@ func mainDeduplicatorV2(
 	}
 
 	observability.SetPipelineID(pipelineCfg.ID)
+	observability.SetComponentLabels(observability.ComponentLabels{
+		PipelineName: pipelineCfg.Name,
+		Component:    internal.RoleDeduplicator,
+		Topic:        cfg.DedupTopic,
+	})
+
+	err = announceComponent(ctx, nc, pipelineCfg, internal.RoleDeduplicator, log)
+	if err != nil {
+		return err
+	}
 
 	dedupCfg, err := getDeduplicationCfgFromPipelineConfig(pipelineCfg, cfg.DedupTopic)
 	if err != nil {
@@ -96,6 +108,7 @@ func mainDeduplicatorV2(
 		slog.String("output_subject_prefix", outputRouter.Config().OutputSubject),
 		slog.String("dlq_subject", dlqSubjectRouter.Config().OutputSubject),
 		slog.Duration("ttl", dedupCfg.Window.Duration()),
+		slog.String("mode", dedupCfg.Mode),
 		slog.Int("batch_size", batchSize),
 		slog.Duration("max_wait", maxWait),
 		slog.Int("pending_publishes_limit", pendingPublishesLimit),
@@ -142,6 +155,7 @@ func mainDeduplicatorV2(
 
 	component, err := NewDedupComponent(
 		ctx,
+		nc,
 		batchReader,
 		batchWriter,
 		dlqWriter,
@@ -167,6 +181,7 @@ func mainDeduplicatorV2(
 
 func NewDedupComponent(
 	ctx context.Context,
+	nc *client.NATSClient,
 	reader batch.BatchReader,
 	writer batch.BatchWriter,
 	dlqWriter batch.BatchWriter,
@@ -177,10 +192,14 @@ func NewDedupComponent(
 ) (*processor.StreamingComponent, error) {
 	role := internal.RoleDeduplicator
 
-	dedupProcessor, err := dedupProcessorFromConfig(pipelineConfig, cfg)
+	dedupProcessorBase, err := dedupProcessorFromConfig(ctx, nc, pipelineConfig, cfg, log)
 	if err != nil {
 		return nil, fmt.Errorf("dedupProcessorFromConfig: %w", err)
 	}
+	dedupProcessor := processor.ChainProcessors(
+		processor.ChainMiddlewares(processor.DLQMiddleware(dlqWriter, role, observability.DLQReasonParseError)),
+		dedupProcessorBase,
+	)
 
 	statelessTransformerProcessorBase, err := statelessTransformerProcessorFromConfig(
 		ctx,
@@ -207,6 +226,20 @@ func NewDedupComponent(
 		filterProcessorBase,
 	)
 
+	explodeProcessor := processor.ChainProcessors(
+		processor.ChainMiddlewares(processor.DLQMiddleware(dlqWriter, role, observability.DLQReasonDedupOverflow)),
+		explodeProcessorFromConfig(pipelineConfig),
+	)
+
+	enrichmentProcessorBase, err := enrichmentProcessorFromConfig(ctx, pipelineConfig, log)
+	if err != nil {
+		return nil, err
+	}
+	enrichmentProcessor := processor.ChainProcessors(
+		processor.ChainMiddlewares(processor.DLQMiddleware(dlqWriter, role, observability.DLQReasonDedupOverflow)),
+		enrichmentProcessorBase,
+	)
+
 	return processor.NewStreamingComponent(
 		reader,
 		writer,
@@ -220,6 +253,8 @@ func NewDedupComponent(
 			filterProcessor,
 			dedupProcessor,
 			statelessTransformerProcessor,
+			explodeProcessor,
+			enrichmentProcessor,
 		},
 	), nil
 }
@@ -235,29 +270,70 @@ func statelessTransformerProcessorFromConfig(
 		return &processor.NoopProcessor{}, nil
 	}
 
-	encryptionKey, err := loadEncryptionKey(cfg, log)
+	kek, err := loadKeyEncryptionKey(cfg, log)
 	if err != nil {
 		return nil, fmt.Errorf("load encryption key: %w", err)
 	}
 
-	db, err := storage.NewPipelineStore(ctx, cfg.DatabaseURL, log, encryptionKey, internal.RoleDeduplicator)
+	db, err := storage.NewPipelineStore(ctx, cfg.DatabaseURL, log, kek, internal.RoleDeduplicator, postgresPoolConfig(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("create postgres store for pipelines: %w", err)
 	}
+	var opts []versioned.Option
+	if udfCfg := config.StatelessTransformation.Config.UDF; udfCfg != nil {
+		log.Info("stateless transformation runs a user-defined function", "runtime", udfCfg.Runtime, "function", udfCfg.Function)
+		opts = append(opts, versioned.WithUDF(*udfCfg))
+	}
+	if salt := config.StatelessTransformation.Config.Salt; salt != "" {
+		opts = append(opts, versioned.WithSalt(salt))
+	}
 	transformer := versioned.New(
 		db,
 		componentSignalPublisher,
 		config.ID,
 		config.StatelessTransformation.SourceID,
+		opts...,
 	)
 
 	return processor.NewStatelessTransformerProcessor(transformer), nil
 }
 
+func explodeProcessorFromConfig(config models.PipelineConfig) processor.Processor {
+	if !config.Explode.Enabled {
+		return &processor.NoopProcessor{}
+	}
+
+	return processor.NewExplodeProcessor(config.Explode)
+}
+
+func enrichmentProcessorFromConfig(
+	ctx context.Context,
+	config models.PipelineConfig,
+	log *slog.Logger,
+) (processor.Processor, error) {
+	if !config.Enrichment.Enabled {
+		return &processor.NoopProcessor{}, nil
+	}
+
+	chClient, err := client.NewClickHouseClient(ctx, config.Sink.ClickHouseConnectionParams)
+	if err != nil {
+		return nil, fmt.Errorf("create clickhouse client for enrichment: %w", err)
+	}
+
+	loader := enrichment.NewClickHouseLoader(chClient, chClient.GetDatabase(), config.Enrichment)
+	enricher := enrichment.New(config.Enrichment, loader, log)
+	if err := enricher.Start(ctx); err != nil {
+		_ = chClient.Close()
+		return nil, fmt.Errorf("start enrichment: %w", err)
+	}
+
+	return processor.NewEnrichmentProcessor(enricher, chClient.Close), nil
+}
+
 func filterProcessorFromConfig(
 	config models.PipelineConfig,
 ) (processor.Processor, error) {
-	if !config.Filter.Enabled {
+	if !config.TransformFilterEnabled() {
 		return &processor.NoopProcessor{}, nil
 	}
 
@@ -270,15 +346,22 @@ func filterProcessorFromConfig(
 }
 
 func dedupProcessorFromConfig(
+	ctx context.Context,
+	nc *client.NATSClient,
 	config models.PipelineConfig,
 	cfg *config,
+	log *slog.Logger,
 ) (processor.Processor, error) {
 	dedupCfg, err := getDeduplicationCfgFromPipelineConfig(config, cfg.DedupTopic)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deduplication config from pipeline config: %w", err)
 	}
 
-	badgerOpts := badger.DefaultOptions("/data/badger").
+	if dedupCfg.KeepsLast() {
+		return processor.NewKeepLastProcessor(dedupCfg.VersionField), nil
+	}
+
+	badgerOpts := badger.DefaultOptions(cfg.DedupDataDir).
 		WithLogger(nil)
 
 	db, err := badger.Open(badgerOpts)
@@ -286,12 +369,54 @@ func dedupProcessorFromConfig(
 		return nil, fmt.Errorf("open BadgerDB: %w", err)
 	}
 
+	opts := []badgerDeduplication.Option{
+		badgerDeduplication.WithLogger(log),
+		badgerDeduplication.WithValueLogGC(cfg.DedupGCInterval),
+	}
+	if cfg.DedupSnapshotInterval > 0 {
+		bucket := models.GetDedupSnapshotBucketName(config.ID)
+		store, storeErr := nc.CreateOrUpdateObjectStore(ctx, bucket, "Snapshots of the deduplicator state")
+		if storeErr != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("create dedup snapshot store: %w", storeErr)
+		}
+		opts = append(opts, badgerDeduplication.WithSnapshots(
+			badgerDeduplication.NewNATSSnapshotStore(store),
+			dedupSnapshotName(cfg.DedupTopic),
+			cfg.DedupSnapshotInterval,
+		))
+	}
+
 	ttl := dedupCfg.Window.Duration()
-	badgerDedup := badgerDeduplication.NewDeduplicator(db, ttl)
+	badgerDedup := badgerDeduplication.NewDeduplicator(db, ttl, opts...)
+
+	err = badgerDedup.Start(ctx)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("start deduplicator: %w", err)
+	}
+
+	if dedupCfg.Domain != "" {
+		claims, kvErr := nc.CreateOrUpdateDedupDomainStore(ctx, dedupCfg.Domain, ttl)
+		if kvErr != nil {
+			_ = badgerDedup.Close(ctx)
+			return nil, fmt.Errorf("create dedup domain store: %w", kvErr)
+		}
+		log.InfoContext(ctx, "deduplicator shares its window with the dedup domain",
+			slog.String("domain", dedupCfg.Domain),
+			slog.String("bucket", models.GetDedupDomainBucketName(dedupCfg.Domain)))
+		return processor.NewDedupProcessor(domainDeduplication.NewDeduplicator(badgerDedup, claims, config.ID)), nil
+	}
 
 	return processor.NewDedupProcessor(badgerDedup), nil
 }
 
+// dedupSnapshotName names the snapshot of a deduplicator replica, which
+// keeps the keys of the stream it reads.
+func dedupSnapshotName(topicName string) string {
+	return fmt.Sprintf("%s-%s", sourceLabel(topicName), os.Getenv("GLASSFLOW_POD_INDEX"))
+}
+
 // getOutputRouterFromEnv builds a subject router from NATS_SUBJECT_PREFIX, GLASSFLOW_POD_INDEX,
 // and optional NATS_SUBJECT_TOTAL_COUNT / NATS_PUBLISHER_REPLICA_COUNT.
 // When multiple subjects are assigned to this pod, a round-robin router is returned.
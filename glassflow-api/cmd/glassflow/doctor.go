@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/doctor"
+)
+
+// doctorCommand checks the installation instead of running a role:
+// glassflow doctor [-output text|json]
+const doctorCommand = "doctor"
+
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet(doctorCommand, flag.ContinueOnError)
+	output := fs.String("output", "text", "Report format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("parse doctor flags: %w", err)
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("invalid output %q, must be text or json", *output)
+	}
+
+	var cfg config
+	if err := envconfig.Process("glassflow", &cfg); err != nil {
+		return fmt.Errorf("unable to parse config: %w", err)
+	}
+
+	doctorCfg := doctor.Config{
+		NATSServer:         cfg.NATSServer,
+		NATSMaxStreamBytes: cfg.NATSMaxStreamBytes,
+		DatabaseURL:        cfg.DatabaseURL,
+	}
+	if !cfg.RunLocal {
+		doctorCfg.Kubernetes = &doctor.KubernetesConfig{
+			Namespace: cfg.K8sNamespace,
+			APIGroup:  cfg.K8sAPIGroup,
+			Version:   cfg.K8sAPIGroupVersion,
+			Resource:  cfg.K8sResourceName,
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	report := doctor.Run(ctx, doctorCfg)
+
+	var err error
+	if *output == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d doctor checks failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/httpingest"
+	configFetcher "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/config/fetcher"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/natshealth"
+	otlp_processor "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/processor"
+)
+
+func mainHTTPIngest(
+	ctx context.Context,
+	nc *client.NATSClient,
+	cfg *config,
+	log *slog.Logger,
+) error {
+	fetcher := configFetcher.New(cfg.HTTPIngestConfigFetcherBaseURL)
+
+	signalPublisher, err := componentsignals.NewPublisher(nc)
+	if err != nil {
+		return err
+	}
+
+	eventProcessor := otlp_processor.NewProcessor(fetcher, nc, cfg.HTTPIngestMaxConcurrentRequests, cfg.OTLPNatsChunkSize, signalPublisher)
+
+	probe := natshealth.NewProbe(
+		nc.JetStream(),
+		internal.OTLPReceiverNATSHealthInterval,
+		internal.OTLPReceiverNATSHealthTimeout,
+		internal.OTLPReceiverNATSHealthStaleAfter,
+		log,
+	)
+
+	s := httpingest.NewServer(cfg.HTTPIngestAddr, log, eventProcessor, probe)
+
+	usageStatsClient := newUsageStatsClient(cfg, log, nil)
+
+	return runWithGracefulShutdown(
+		ctx,
+		s,
+		log,
+		internal.RoleHTTPIngest,
+		usageStatsClient,
+	)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path"
@@ -16,19 +17,32 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kelseyhightower/envconfig"
-	_ "go.uber.org/automaxprocs"
+	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/api"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componenthandshake"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/dlq"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/featureflags"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/orchestrator"
+	otlp_processor "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/processor"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/runtimelimits"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/server"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/sink"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres/datamigrations"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres/schemamigrations"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/usagestats"
 )
@@ -48,6 +62,21 @@ type config struct {
 	OtelPipelineID        string `default:"" split_words:"true"`
 	OtelServiceInstanceID string `default:"" split_words:"true"`
 
+	// Traces follow an event from the Kafka fetch to the ClickHouse insert,
+	// the ingestor samples the given share of events
+	OtelTracesEnabled     bool    `default:"false" split_words:"true"`
+	OtelTracesSampleRatio float64 `default:"0.1" split_words:"true"`
+
+	// Prometheus scrape endpoint, served by every role
+	PrometheusEnabled bool   `default:"false" split_words:"true"`
+	PrometheusAddr    string `default:":9090" split_words:"true"`
+
+	// Access log of the API, the sample rate is the share of successful
+	// requests logged, errors are always logged
+	AccessLogSampleRate float64  `default:"1" split_words:"true"`
+	AccessLogSkipPaths  []string `split_words:"true"`
+	AccessLogOtelExport bool     `default:"false" split_words:"true"`
+
 	ServerAddr            string        `default:":8081" split_words:"true"`
 	ServerWriteTimeout    time.Duration `default:"15s" split_words:"true"`
 	ServerReadTimeout     time.Duration `default:"15s" split_words:"true"`
@@ -56,12 +85,24 @@ type config struct {
 
 	RunLocal bool `default:"false" split_words:"true"`
 
+	// A read-only API is a standby reading a Postgres replica, it serves the
+	// GET endpoints and rejects changes with a pointer to the primary API.
+	ReadOnly           bool   `default:"false" split_words:"true"`
+	ReadOnlyPrimaryURL string `default:"" split_words:"true"`
+
 	PipelineConfig string `default:"pipeline.json" split_words:"true"`
 
 	IngestorTopic string `default:"" split_words:"true"`
 
 	DedupTopic string `default:"" split_words:"true"`
 
+	// Local store of the deduplicator, its expired keys are compacted at
+	// every GC interval. A non-zero snapshot interval also backs it up to
+	// NATS, to restore it when the volume is lost.
+	DedupDataDir          string        `default:"/data/badger" split_words:"true"`
+	DedupGCInterval       time.Duration `default:"5m" split_words:"true"`
+	DedupSnapshotInterval time.Duration `default:"0" split_words:"true"`
+
 	JoinType string `default:"temporal" split_words:"true"`
 
 	NATSServer         string        `default:"localhost:4222" split_words:"true"`
@@ -69,12 +110,30 @@ type config struct {
 	NATSMaxStreamBytes int64         `default:"107374182400" split_words:"true"` // 100GB in bytes
 	NATSPipelineKV     string        `default:"glassflow-pipelines" split_words:"true"`
 
-	// Database configuration
-	DatabaseURL string `default:"" split_words:"true"`
-
-	// Encryption configuration
-	EncryptionKeyPath string `default:"/etc/glassflow/secrets/encryption-key" split_words:"true"`
-	EncryptionKey     string `default:"" split_words:"true"`
+	// Database configuration, zero pool sizes and lifetime keep the defaults
+	// of the role
+	DatabaseURL              string        `default:"" split_words:"true"`
+	DatabaseMaxConns         int32         `default:"0" split_words:"true"`
+	DatabaseMinConns         int32         `default:"0" split_words:"true"`
+	DatabaseMaxConnLifetime  time.Duration `default:"0" split_words:"true"`
+	DatabaseMaxConnIdleTime  time.Duration `default:"0" split_words:"true"`
+	DatabaseStatementTimeout time.Duration `default:"0" split_words:"true"`
+	DatabaseMaxRetries       int           `default:"3" split_words:"true"`
+	DatabaseRetryDelay       time.Duration `default:"100ms" split_words:"true"`
+	// Whether the API applies the pending schema migrations before it
+	// starts, the migrate command applies them otherwise
+	DatabaseMigrateOnStartup bool `default:"true" split_words:"true"`
+
+	// Encryption configuration. The connection credentials are encrypted
+	// with a data key wrapped by the local key or a Vault transit key,
+	// depending on the key provider: local or vault-transit.
+	EncryptionKeyPath           string `default:"/etc/glassflow/secrets/encryption-key" split_words:"true"`
+	EncryptionKey               string `default:"" split_words:"true"`
+	EncryptionKeyProvider       string `default:"local" split_words:"true"`
+	EncryptionVaultAddr         string `split_words:"true"`
+	EncryptionVaultToken        string `split_words:"true"`
+	EncryptionVaultTransitMount string `default:"transit" split_words:"true"`
+	EncryptionVaultTransitKey   string `split_words:"true"`
 
 	K8sNamespace       string `default:"glassflow" split_words:"true"`
 	K8sResourceKind    string `default:"Pipeline" split_words:"true"`
@@ -87,13 +146,97 @@ type config struct {
 	UsageStatsUsername       string `default:"" split_words:"true"`
 	UsageStatsPassword       string `default:"" split_words:"true"`
 	UsageStatsInstallationID string `default:"" split_words:"true"`
+	// Topology reported in the pipeline events: none or a comma separated
+	// list of component_types, counts and batch_sizes
+	UsageStatsTopology string `default:"component_types,counts,batch_sizes" split_words:"true"`
+	usageStatsTopology []usagestats.TopologyField
+
+	// Webhooks notified about every pipeline lifecycle event, pipelines can
+	// add their own through metadata. A zero DLQ threshold disables the check.
+	NotificationWebhookURLs   []string      `split_words:"true"`
+	NotificationWebhookSecret string        `default:"" split_words:"true"`
+	NotificationDLQThreshold  uint64        `default:"0" split_words:"true"`
+	NotificationCheckInterval time.Duration `default:"30s" split_words:"true"`
+	// Share of the NATS stream limits a pipeline is degraded at, before its
+	// streams discard messages. Zero disables the check.
+	NotificationStorageThreshold float64 `default:"0.8" split_words:"true"`
+
+	// Interval of the retries of orchestrator actions the API did not complete
+	OutboxReconcileInterval time.Duration `default:"15s" split_words:"true"`
+
+	// Interval at which the start and stop schedules of pipelines are checked
+	PipelineScheduleInterval time.Duration `default:"30s" split_words:"true"`
+
+	// Pipelines stopped for longer than the archive age are archived and
+	// their NATS resources deleted, checked every archive interval. A zero
+	// age disables the archival, pipelines can still be archived by hand.
+	PipelineArchiveAfter    time.Duration `default:"0" split_words:"true"`
+	PipelineArchiveInterval time.Duration `default:"1h" split_words:"true"`
+
+	// Health checks of the ClickHouse servers and Kafka clusters of the
+	// stored connections. A zero interval disables them, a check slower than
+	// the degraded latency reports the endpoint degraded.
+	ConnectionHealthInterval        time.Duration `default:"1m" split_words:"true"`
+	ConnectionHealthDegradedLatency time.Duration `default:"2s" split_words:"true"`
+	ConnectionHealthRetention       time.Duration `default:"168h" split_words:"true"`
+
+	// Whether components older than their pipeline config schema are
+	// rejected or only logged: warn or reject
+	ComponentVersionPolicy string `default:"warn" split_words:"true"`
+
+	// Feature flags of the installation as name=true|false pairs, the
+	// settings API overrides them
+	FeatureFlags []string `split_words:"true"`
+
+	// Store the secretRef: credentials of the pipelines are resolved from:
+	// env, kubernetes or vault. The Kubernetes Secrets are read from the
+	// secrets namespace, the K8s namespace when empty.
+	SecretsBackend    string `default:"env" split_words:"true"`
+	SecretsNamespace  string `split_words:"true"`
+	SecretsVaultAddr  string `split_words:"true"`
+	SecretsVaultToken string `split_words:"true"`
+	SecretsVaultMount string `default:"secret" split_words:"true"`
+	// Mount of the Vault database secrets engine the sinks of pipelines with
+	// a vault_role get their ClickHouse credentials from, with the Vault
+	// address and token above
+	SecretsVaultDatabaseMount string `default:"database" split_words:"true"`
+
+	// Signed license file of the enterprise edition, the open source edition
+	// runs without one
+	LicenseFile string `default:"" split_words:"true"`
+
+	// Ownership fields of the pipeline metadata, owner, team and contact,
+	// required to create a pipeline
+	PipelineRequiredOwnership []string `split_words:"true"`
+
+	// Authentication of the API. The admin key bootstraps the API keys, the
+	// API keys and the OIDC tokens require the rbac license feature.
+	AuthEnabled        bool   `default:"false" split_words:"true"`
+	AuthAdminKey       string `default:"" split_words:"true"`
+	AuthOIDCIssuer     string `default:"" split_words:"true"`
+	AuthOIDCAudience   string `default:"" split_words:"true"`
+	AuthOIDCRoleClaim  string `default:"glassflow_role" split_words:"true"`
+	AuthOIDCTeamsClaim string `default:"groups" split_words:"true"`
 
 	OTLPConfigFetcherBaseURL  string `default:"" split_words:"true"`
 	OTLPMaxConcurrentRequests int    `default:"50" split_words:"true"`
 	OTLPNatsChunkSize         int    `default:"1000" split_words:"true"`
+
+	// HTTP push ingestion, served by the API and the http-ingest role. The
+	// http-ingest role fetches pipeline configs from the API.
+	HTTPIngestAddr                  string `default:":8082" split_words:"true"`
+	HTTPIngestConfigFetcherBaseURL  string `default:"" split_words:"true"`
+	HTTPIngestMaxConcurrentRequests int    `default:"50" split_words:"true"`
+
+	// GOMAXPROCS and GOMEMLIMIT env vars take precedence over the pod limits
+	AutoMaxProcs     bool    `default:"true" split_words:"true"`
+	MemoryLimitRatio float64 `default:"0.9" split_words:"true"`
 }
 
-var version = "dev"
+var (
+	version = "dev"
+	commit  = ""
+)
 
 func main() {
 	if err := run(); err != nil {
@@ -103,13 +246,18 @@ func main() {
 }
 
 func run() error {
-	// automaxprocs is used to set the number of CPU cores to use for the application.
-
 	var cfg config
 
 	roleStr := flag.String("role", "", "Role to run: sink, join, ingester or empty for pipeline manager")
 	flag.Parse()
 
+	switch flag.Arg(0) {
+	case doctorCommand:
+		return runDoctor(flag.Args()[1:])
+	case migrateCommand:
+		return runMigrate(flag.Args()[1:])
+	}
+
 	role := models.Role(*roleStr)
 	if !role.Valid() {
 		return fmt.Errorf("invalid role specified: %s, valid roles are: %v", role, models.AllRoles())
@@ -120,6 +268,11 @@ func run() error {
 		return fmt.Errorf("unable to parse config: %w", err)
 	}
 
+	cfg.usageStatsTopology, err = usagestats.ParseTopologyFields(cfg.UsageStatsTopology)
+	if err != nil {
+		return fmt.Errorf("unable to parse config: %w", err)
+	}
+
 	return mainErr(&cfg, role)
 }
 
@@ -158,10 +311,22 @@ func mainErr(cfg *config, role models.Role) error {
 		ServiceNamespace:  cfg.OtelServiceNamespace,
 		PipelineID:        cfg.OtelPipelineID,
 		ServiceInstanceID: cfg.OtelServiceInstanceID,
+		PrometheusEnabled: cfg.PrometheusEnabled,
+		TracesEnabled:     cfg.OtelTracesEnabled,
+		TraceSampleRatio:  cfg.OtelTracesSampleRatio,
+		TraceRoot:         role.ReadsSource(),
 	}
 	log := observability.ConfigureLogger(obsConfig, logOut)
 
-	log.Info("Starting App", slog.String("version", version))
+	log.Info("Starting App", slog.String("version", version), slog.String("commit", commit))
+
+	err = runtimelimits.Apply(runtimelimits.Config{
+		AutoMaxProcs:     cfg.AutoMaxProcs,
+		MemoryLimitRatio: cfg.MemoryLimitRatio,
+	}, log)
+	if err != nil {
+		return fmt.Errorf("unable to apply runtime limits: %w", err)
+	}
 
 	if role == internal.RoleMigrateData {
 		if cfg.DatabaseURL == "" {
@@ -181,6 +346,17 @@ func mainErr(cfg *config, role models.Role) error {
 		return fmt.Errorf("init metrics: %w", err)
 	}
 
+	if err := observability.InitTracing(obsConfig); err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ServerShutdownTimeout)
+		defer shutdownCancel()
+		if err := observability.ShutdownTracing(shutdownCtx); err != nil {
+			log.Error("failed to shutdown tracing", slog.Any("error", err))
+		}
+	}()
+
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
@@ -193,6 +369,10 @@ func mainErr(cfg *config, role models.Role) error {
 		cancel()
 	}()
 
+	if cfg.PrometheusEnabled {
+		startPrometheusServer(ctx, cfg, log)
+	}
+
 	nc, err := client.NewNATSClient(
 		ctx,
 		cfg.NATSServer,
@@ -209,12 +389,20 @@ func mainErr(cfg *config, role models.Role) error {
 		return fmt.Errorf("database URL is required: set GLASSFLOW_DATABASE_URL environment variable")
 	}
 
-	encryptionKey, err := loadEncryptionKey(cfg, log)
+	// The primary owns the schema, the components wait for the API
+	if role == internal.RoleETL && !cfg.ReadOnly && cfg.DatabaseMigrateOnStartup {
+		err = schemamigrations.NewMigrator(cfg.DatabaseURL, log).Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate database schema: %w", err)
+		}
+	}
+
+	kek, err := loadKeyEncryptionKey(cfg, log)
 	if err != nil {
 		return fmt.Errorf("load encryption key: %w", err)
 	}
 
-	db, err := storage.NewPipelineStore(ctx, cfg.DatabaseURL, log, encryptionKey, role)
+	db, err := storage.NewPipelineStore(ctx, cfg.DatabaseURL, log, kek, role, postgresPoolConfig(cfg))
 	if err != nil {
 		return fmt.Errorf("create postgres store for pipelines: %w", err)
 	}
@@ -227,11 +415,14 @@ func mainErr(cfg *config, role models.Role) error {
 	case internal.RoleIngestor:
 		return mainIngestor(ctx, nc, cfg, db, log)
 	case internal.RoleETL:
-		return mainEtl(ctx, nc, cfg, db, log)
+		accessLog := observability.ConfigureAccessLogger(obsConfig, log, logOut, cfg.AccessLogOtelExport)
+		return mainEtl(ctx, nc, cfg, db, log, accessLog)
 	case internal.RoleDeduplicator:
 		return mainDeduplicatorV2(ctx, nc, cfg, log)
 	case internal.RoleOLTPReceiver:
 		return mainOLTPReceiver(ctx, nc, cfg, log)
+	case internal.RoleHTTPIngest:
+		return mainHTTPIngest(ctx, nc, cfg, log)
 	default:
 		return fmt.Errorf("unknown role: %s", role)
 	}
@@ -243,27 +434,55 @@ func mainEtl(
 	cfg *config,
 	db service.PipelineStore,
 	log *slog.Logger,
+	accessLog *slog.Logger,
 ) error {
-	// Run data migration from NATS KV to Postgres
-	kvStoreName := cfg.NATSPipelineKV
+	var err error
 
-	err := storage.MigratePipelinesFromNATSKV(ctx, nc, db, kvStoreName, log)
+	if cfg.ComponentVersionPolicy != internal.ComponentVersionPolicyWarn &&
+		cfg.ComponentVersionPolicy != internal.ComponentVersionPolicyReject {
+		return fmt.Errorf("invalid component version policy %q, must be %q or %q",
+			cfg.ComponentVersionPolicy, internal.ComponentVersionPolicyWarn, internal.ComponentVersionPolicyReject)
+	}
+
+	lic, err := license.Load(cfg.LicenseFile, license.PublicKey())
 	if err != nil {
-		// Log error but don't fail startup (data migration failures shouldn't block API)
-		log.Error("data migration from NATS KV failed",
-			slog.String("error", err.Error()),
-			slog.String("kv_store_name", kvStoreName))
+		return fmt.Errorf("load license: %w", err)
+	}
+	log.Info("license loaded", slog.String("edition", string(lic.Edition)), slog.Any("features", lic.State(time.Now()).Features))
+	if cfg.ReadOnly && !lic.Allows(license.ReadOnlyStandby, time.Now()) {
+		return fmt.Errorf("read-only standby: %w", license.ErrFeatureNotLicensed)
+	}
+
+	// The primary owns the writes of startup, a read-only API skips them
+	if cfg.ReadOnly {
+		log.Info("API is read-only", slog.String("primary_url", cfg.ReadOnlyPrimaryURL))
 	} else {
-		log.Info("data migration from NATS KV completed",
-			slog.String("kv_store_name", kvStoreName))
+		// Run data migration from NATS KV to Postgres
+		kvStoreName := cfg.NATSPipelineKV
+
+		err = storage.MigratePipelinesFromNATSKV(ctx, nc, db, kvStoreName, log)
+		if err != nil {
+			// Log error but don't fail startup (data migration failures shouldn't block API)
+			log.Error("data migration from NATS KV failed",
+				slog.String("error", err.Error()),
+				slog.String("kv_store_name", kvStoreName))
+		} else {
+			log.Info("data migration from NATS KV completed",
+				slog.String("kv_store_name", kvStoreName))
+		}
 	}
 
 	dlq := dlq.NewClient(nc)
 
+	secretStore, err := newSecretStore(cfg)
+	if err != nil {
+		return err
+	}
+
 	var orch service.Orchestrator
 
 	if cfg.RunLocal {
-		orch = orchestrator.NewLocalOrchestrator(nc, db, log)
+		orch = orchestrator.NewLocalOrchestrator(nc, db, secretStore, log)
 	} else {
 		orch, err = orchestrator.NewK8sOrchestrator(log, cfg.K8sNamespace, orchestrator.CustomResourceAPIGroupVersion{
 			Kind:     cfg.K8sResourceKind,
@@ -278,14 +497,123 @@ func mainEtl(
 
 	usageStatsClient := newUsageStatsClient(cfg, log, db)
 
-	pipelineSvc := service.NewPipelineService(orch, db, log)
+	slaEvaluator := service.NewSLAEvaluator(nc, dlq, secretStore, log)
+	storageMonitor := service.NewStorageMonitor(nc, cfg.NotificationStorageThreshold, log)
+	discardTracker := service.NewDiscardTracker(nc)
+	partitionMonitor := service.NewPartitionMonitor(secretStore, log)
+
+	svcOpts := []service.PipelineServiceOption{
+		service.WithInFlightReader(nc),
+		service.WithSLAEvaluator(slaEvaluator),
+		service.WithStorageMonitor(storageMonitor),
+		service.WithDiscardTracker(discardTracker),
+		service.WithLicense(lic),
+		service.WithSecretStore(secretStore),
+		service.WithKafkaClusterCheck(secretStore),
+	}
+	requiredOwnership, err := models.ParseOwnershipFields(cfg.PipelineRequiredOwnership)
+	if err != nil {
+		return fmt.Errorf("parse required pipeline ownership: %w", err)
+	}
+	svcOpts = append(svcOpts, service.WithRequiredOwnership(requiredOwnership))
+	if cfg.RunLocal {
+		svcOpts = append(svcOpts, service.WithStreamMaxAge(cfg.NATSMaxStreamAge))
+	}
+	outbox, hasOutbox := db.(service.OutboxStore)
+	if hasOutbox {
+		svcOpts = append(svcOpts, service.WithOutbox(outbox))
+	}
+
+	featureFlagStore, hasFeatureFlags := db.(featureflags.Store)
+	var flags *featureflags.Flags
+	if hasFeatureFlags {
+		envFlags, err := featureflags.ParseEnv(cfg.FeatureFlags)
+		if err != nil {
+			return fmt.Errorf("parse feature flags: %w", err)
+		}
+		flags = featureflags.New(envFlags, featureFlagStore)
+		svcOpts = append(svcOpts, service.WithFeatureFlags(flags))
+	}
+
+	pipelineSvc := service.NewPipelineService(orch, db, log, svcOpts...)
+
+	if !cfg.ReadOnly {
+		summary, err := pipelineSvc.ReconcilePipelines(ctx)
+		if err != nil {
+			log.Error("failed to reconcile pipelines on startup", slog.Any("error", err))
+		} else {
+			log.Info("reconciled pipelines on startup",
+				slog.Int("checked", summary.Checked),
+				slog.Any("restarted", summary.Restarted),
+				slog.Any("stopped", summary.Stopped),
+				slog.Any("synced", summary.Synced),
+				slog.Any("orphaned", summary.Orphaned),
+				slog.Any("failed", summary.Failed))
+		}
+	}
+
+	notifier := notification.NewNotifier(
+		cfg.NotificationWebhookURLs,
+		cfg.NotificationWebhookSecret,
+		cfg.NotificationDLQThreshold,
+		log,
+		db,
+	)
+
+	signalPublisher, err := componentsignals.NewPublisher(nc)
+	if err != nil {
+		return fmt.Errorf("create component signal publisher: %w", err)
+	}
+	eventProcessor := otlp_processor.NewProcessor(pipelineSvc, nc, cfg.HTTPIngestMaxConcurrentRequests, cfg.OTLPNatsChunkSize, signalPublisher)
 
-	err = pipelineSvc.CleanUpPipelines(ctx)
+	//nolint:exhaustruct // optional config
+	reportsKV, err := nc.JetStream().CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      internal.ComponentReportsBucket,
+		Description: "Config hashes reported by the pipeline components",
+	})
 	if err != nil {
-		log.Error("failed to clean up pipelines on startup", slog.Any("error", err))
+		return fmt.Errorf("create component reports bucket: %w", err)
+	}
+	componentReports := componenthandshake.NewReports(reportsKV)
+
+	var routerOpts []api.RouterOption
+	routerOpts = append(routerOpts, api.WithComponentReports(componentReports))
+	routerOpts = append(routerOpts, api.WithLicense(lic))
+	routerOpts = append(routerOpts, api.WithAccessLog(api.AccessLogConfig{
+		SampleRate: cfg.AccessLogSampleRate,
+		SkipPaths:  cfg.AccessLogSkipPaths,
+		Logger:     accessLog,
+	}))
+	if cfg.ReadOnly {
+		routerOpts = append(routerOpts, api.WithReadOnly(cfg.ReadOnlyPrimaryURL))
+	}
+	if cfg.AuthEnabled {
+		authenticator, err := newAuthenticator(cfg, lic, db)
+		if err != nil {
+			return fmt.Errorf("configure authentication: %w", err)
+		}
+		routerOpts = append(routerOpts, api.WithAuth(authenticator))
+	}
+	if auditLog, ok := db.(audit.Store); ok {
+		routerOpts = append(routerOpts, api.WithAuditLog(auditLog))
+	}
+	if reader, ok := db.(api.ConnectionHealthReader); ok && cfg.ConnectionHealthInterval > 0 {
+		routerOpts = append(routerOpts, api.WithConnectionHealth(reader))
+	}
+	routerOpts = append(routerOpts, api.WithSchemaMigrations(schemamigrations.NewMigrator(cfg.DatabaseURL, log)))
+	var archiver *service.PipelineArchiver
+	if store, ok := db.(service.ArchiveStore); ok {
+		archiver = service.NewPipelineArchiver(pipelineSvc, store, log, cfg.PipelineArchiveInterval, cfg.PipelineArchiveAfter)
+		routerOpts = append(routerOpts, api.WithPipelineArchive(archiver))
+	}
+	if checker, ok := db.(api.HealthChecker); ok {
+		routerOpts = append(routerOpts, api.WithStorageHealthCheck(checker))
+	}
+	if hasFeatureFlags {
+		routerOpts = append(routerOpts, api.WithFeatureFlags(flags))
 	}
 
-	handler := api.NewRouter(log, pipelineSvc, dlq, usageStatsClient)
+	handler := api.NewRouter(log, pipelineSvc, dlq, usageStatsClient, notifier, eventProcessor, routerOpts...)
 
 	apiServer := server.NewHTTPServer(
 		cfg.ServerAddr,
@@ -311,6 +639,61 @@ func mainEtl(
 		usageStatsCollector.Start(ctx)
 	}()
 
+	// The periodic jobs below run on the API replica holding their lease, the
+	// other replicas would run them twice
+	jobLeases, _ := db.(service.JobLeaseStore)
+	jobLeaseHolder := uuid.NewString()
+	jobLease := func(job string, interval time.Duration) *service.JobLease {
+		if jobLeases == nil {
+			return nil
+		}
+		return service.NewJobLease(jobLeases, job, jobLeaseHolder, interval, log)
+	}
+
+	// The primary notifies about the pipelines, a standby would notify twice
+	if !cfg.ReadOnly {
+		go func() {
+			notificationWatcher := service.NewNotificationWatcher(db, dlq, slaEvaluator, storageMonitor, discardTracker, partitionMonitor, notifier, log, cfg.NotificationCheckInterval)
+			notificationWatcher.UseLease(jobLease(service.JobNotificationWatcher, cfg.NotificationCheckInterval))
+			notificationWatcher.Start(ctx)
+		}()
+	}
+
+	// The local orchestrator runs the pipelines in this process, they do
+	// not survive the crash the outbox recovers from
+	if !cfg.ReadOnly && hasOutbox && !cfg.RunLocal {
+		go func() {
+			reconciler := service.NewOutboxReconciler(orch, db, outbox, log, cfg.OutboxReconcileInterval)
+			reconciler.Start(ctx)
+		}()
+	}
+
+	if !cfg.ReadOnly {
+		go func() {
+			scheduler := service.NewPipelineScheduler(pipelineSvc, log, cfg.PipelineScheduleInterval)
+			scheduler.UseLease(jobLease(service.JobPipelineScheduler, cfg.PipelineScheduleInterval))
+			scheduler.Start(ctx)
+		}()
+	}
+
+	if archiver != nil && !cfg.ReadOnly && cfg.PipelineArchiveAfter > 0 {
+		archiver.UseLease(jobLease(service.JobPipelineArchiver, cfg.PipelineArchiveInterval))
+		go archiver.Start(ctx)
+	}
+
+	if store, ok := db.(service.ConnectionHealthStore); ok && !cfg.ReadOnly && cfg.ConnectionHealthInterval > 0 {
+		go func() {
+			monitor := service.NewConnectionMonitor(store, secretStore, cfg.ConnectionHealthInterval,
+				cfg.ConnectionHealthDegradedLatency, cfg.ConnectionHealthRetention, log)
+			monitor.Start(ctx)
+		}()
+	}
+
+	err = componenthandshake.NewServer(nc, cfg.ComponentVersionPolicy, componentReports, log).Start(ctx)
+	if err != nil {
+		return fmt.Errorf("start component handshake server: %w", err)
+	}
+
 	select {
 	case err := <-serverErr:
 		if err != nil {
@@ -329,7 +712,7 @@ func mainEtl(
 		wg.Go(func() {
 			switch o := orch.(type) {
 			case *orchestrator.LocalOrchestrator:
-				err := orch.StopPipeline(ctx, o.ActivePipelineID())
+				err := orch.StopPipeline(ctx, o.ActivePipelineID(), models.StopOptions{})
 				if err != nil && !errors.Is(err, service.ErrPipelineNotFound) {
 					log.Error("pipeline stop error", slog.Any("error", err))
 				}
@@ -342,9 +725,9 @@ func mainEtl(
 }
 
 func mainSink(ctx context.Context, nc *client.NATSClient, cfg *config, db service.PipelineStore, log *slog.Logger) error {
-	pipelineCfg, err := getPipelineConfigFromJSON(cfg.PipelineConfig)
+	pipelineCfg, err := loadPipelineConfig(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get pipeline config: %w", err)
+		return err
 	}
 
 	if pipelineCfg.ID == "" {
@@ -352,16 +735,38 @@ func mainSink(ctx context.Context, nc *client.NATSClient, cfg *config, db servic
 	}
 
 	observability.SetPipelineID(pipelineCfg.ID)
+	observability.SetComponentLabels(observability.ComponentLabels{
+		PipelineName: pipelineCfg.Name,
+		Component:    internal.RoleSink,
+	})
+
+	err = announceComponent(ctx, nc, pipelineCfg, internal.RoleSink, log)
+	if err != nil {
+		return err
+	}
 
 	if pipelineCfg.Sink.SourceID == "" {
 		return fmt.Errorf("stream_id in sink config cannot be empty")
 	}
 
+	var credentials sink.CredentialsSource
+	if role := pipelineCfg.Sink.ClickHouseConnectionParams.VaultRole; role != "" {
+		vault, err := secrets.NewVaultClient(cfg.SecretsVaultAddr, cfg.SecretsVaultToken)
+		if err != nil {
+			return fmt.Errorf("create vault database credentials: %w", err)
+		}
+		credentials, err = secrets.NewVaultDatabaseCredentials(vault, cfg.SecretsVaultDatabaseMount, role)
+		if err != nil {
+			return fmt.Errorf("create vault database credentials: %w", err)
+		}
+	}
+
 	sinkRunner := service.NewSinkRunner(
 		log,
 		nc,
 		pipelineCfg,
 		db,
+		credentials,
 	)
 
 	usageStatsClient := newUsageStatsClient(cfg, log, nil)
@@ -380,9 +785,9 @@ func mainJoin(ctx context.Context, nc *client.NATSClient, cfg *config, db servic
 		return fmt.Errorf("join type must be specified")
 	}
 
-	pipelineCfg, err := getPipelineConfigFromJSON(cfg.PipelineConfig)
+	pipelineCfg, err := loadPipelineConfig(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get pipeline config: %w", err)
+		return err
 	}
 
 	if pipelineCfg.ID == "" {
@@ -390,6 +795,15 @@ func mainJoin(ctx context.Context, nc *client.NATSClient, cfg *config, db servic
 	}
 
 	observability.SetPipelineID(pipelineCfg.ID)
+	observability.SetComponentLabels(observability.ComponentLabels{
+		PipelineName: pipelineCfg.Name,
+		Component:    internal.RoleJoin,
+	})
+
+	err = announceComponent(ctx, nc, pipelineCfg, internal.RoleJoin, log)
+	if err != nil {
+		return err
+	}
 
 	if !pipelineCfg.Join.Enabled {
 		return fmt.Errorf("join is not enabled in pipeline config")
@@ -417,9 +831,9 @@ func mainIngestor(ctx context.Context, nc *client.NATSClient, cfg *config, db se
 		return fmt.Errorf("ingestor topic must be specified")
 	}
 
-	pipelineCfg, err := getPipelineConfigFromJSON(cfg.PipelineConfig)
+	pipelineCfg, err := loadPipelineConfig(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get pipeline config: %w", err)
+		return err
 	}
 
 	if pipelineCfg.ID == "" {
@@ -427,6 +841,16 @@ func mainIngestor(ctx context.Context, nc *client.NATSClient, cfg *config, db se
 	}
 
 	observability.SetPipelineID(pipelineCfg.ID)
+	observability.SetComponentLabels(observability.ComponentLabels{
+		PipelineName: pipelineCfg.Name,
+		Component:    internal.RoleIngestor,
+		Topic:        cfg.IngestorTopic,
+	})
+
+	err = announceComponent(ctx, nc, pipelineCfg, internal.RoleIngestor, log)
+	if err != nil {
+		return err
+	}
 
 	topicCfg, err := getIngestorTopicConfig(pipelineCfg, cfg.IngestorTopic)
 	if err != nil {
@@ -491,6 +915,7 @@ func getIngestorRuntimeConfigFromEnv(dedupEnabled bool) (models.IngestorRuntimeC
 		OutputSubjectPrefix: prefix,
 		TotalSubjectCount:   totalSubjects,
 		DedupSubjectPrefix:  prefix,
+		ReplicaIndex:        podIndex,
 	}
 
 	if !dedupEnabled {
@@ -551,6 +976,53 @@ func runWithGracefulShutdown(
 	}
 }
 
+// loadPipelineConfig reads the pipeline config of a component and resolves
+// the secret references of its credentials.
+func loadPipelineConfig(ctx context.Context, cfg *config) (zero models.PipelineConfig, _ error) {
+	pipelineCfg, err := getPipelineConfigFromJSON(cfg.PipelineConfig)
+	if err != nil {
+		return zero, fmt.Errorf("failed to get pipeline config: %w", err)
+	}
+
+	secretStore, err := newSecretStore(cfg)
+	if err != nil {
+		return zero, err
+	}
+
+	err = secrets.ResolvePipeline(ctx, secretStore, &pipelineCfg)
+	if err != nil {
+		return zero, fmt.Errorf("resolve pipeline secrets: %w", err)
+	}
+
+	return pipelineCfg, nil
+}
+
+func newSecretStore(cfg *config) (secrets.Store, error) {
+	switch cfg.SecretsBackend {
+	case secrets.BackendEnv:
+		return secrets.NewEnvStore(), nil
+	case secrets.BackendKubernetes:
+		namespace := cfg.SecretsNamespace
+		if namespace == "" {
+			namespace = cfg.K8sNamespace
+		}
+		store, err := secrets.NewKubernetesStore(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("create kubernetes secret store: %w", err)
+		}
+		return store, nil
+	case secrets.BackendVault:
+		vault, err := secrets.NewVaultClient(cfg.SecretsVaultAddr, cfg.SecretsVaultToken)
+		if err != nil {
+			return nil, fmt.Errorf("create vault secret store: %w", err)
+		}
+		return secrets.NewVaultStore(vault, cfg.SecretsVaultMount), nil
+	default:
+		return nil, fmt.Errorf("invalid secrets backend %q, must be %q, %q or %q",
+			cfg.SecretsBackend, secrets.BackendEnv, secrets.BackendKubernetes, secrets.BackendVault)
+	}
+}
+
 func getPipelineConfigFromJSON(cfgPath string) (zero models.PipelineConfig, _ error) {
 	var pipelineCfg models.PipelineConfig
 
@@ -567,6 +1039,34 @@ func getPipelineConfigFromJSON(cfgPath string) (zero models.PipelineConfig, _ er
 	return pipelineCfg, nil
 }
 
+// startPrometheusServer serves the /metrics scrape endpoint until ctx is cancelled.
+func startPrometheusServer(ctx context.Context, cfg *config, log *slog.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", observability.PrometheusHandler())
+
+	metricsServer := server.NewHTTPServer(
+		cfg.PrometheusAddr,
+		cfg.ServerReadTimeout,
+		cfg.ServerWriteTimeout,
+		cfg.ServerIdleTimeout,
+		log,
+		mux,
+	)
+
+	go func() {
+		if err := metricsServer.Start(); err != nil {
+			log.Error("prometheus metrics server failed", slog.Any("error", err))
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		if err := metricsServer.Shutdown(context.Background(), cfg.ServerShutdownTimeout); err != nil {
+			log.Error("failed to stop prometheus metrics server", slog.Any("error", err))
+		}
+	}()
+}
+
 func newUsageStatsClient(cfg *config, log *slog.Logger, db service.PipelineStore) *usagestats.Client {
 	return usagestats.NewClient(
 		cfg.UsageStatsEndpoint,
@@ -576,9 +1076,42 @@ func newUsageStatsClient(cfg *config, log *slog.Logger, db service.PipelineStore
 		cfg.UsageStatsEnabled,
 		log,
 		db,
+		usagestats.WithTopologyFields(cfg.usageStatsTopology),
 	)
 }
 
+// newAuthenticator accepts the admin key and, when licensed, the API keys of
+// the store and the tokens of the OIDC issuer.
+func newAuthenticator(cfg *config, lic *license.License, db service.PipelineStore) (*auth.Authenticator, error) {
+	var opts []auth.Option
+	if cfg.AuthAdminKey != "" {
+		opts = append(opts, auth.WithAdminKey(cfg.AuthAdminKey))
+	}
+
+	if !lic.Allows(license.RBAC, time.Now()) {
+		if cfg.AuthOIDCIssuer != "" {
+			return nil, fmt.Errorf("oidc: %w", license.ErrFeatureNotLicensed)
+		}
+		if cfg.AuthAdminKey == "" {
+			return nil, fmt.Errorf("no admin key set and API keys require the %s license feature", license.RBAC)
+		}
+		return auth.New(opts...), nil
+	}
+
+	if keys, ok := db.(auth.KeyStore); ok {
+		opts = append(opts, auth.WithKeyStore(keys))
+	}
+	if cfg.AuthOIDCIssuer != "" {
+		opts = append(opts, auth.WithOIDC(auth.NewOIDCVerifier(auth.OIDCConfig{
+			Issuer:     cfg.AuthOIDCIssuer,
+			Audience:   cfg.AuthOIDCAudience,
+			RoleClaim:  cfg.AuthOIDCRoleClaim,
+			TeamsClaim: cfg.AuthOIDCTeamsClaim,
+		})))
+	}
+	return auth.New(opts...), nil
+}
+
 func cleanUp(nc *client.NATSClient, log *slog.Logger) {
 	if nc != nil {
 		err := nc.Close()
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres/schemamigrations"
+)
+
+// migrateCommand manages the schema of the database instead of running a
+// role: glassflow migrate [-output text|json] up|down [N]|goto V|force V|status
+const migrateCommand = "migrate"
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	output := fs.String("output", "text", "Status format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("parse migrate flags: %w", err)
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("invalid output %q, must be text or json", *output)
+	}
+
+	var cfg config
+	if err := envconfig.Process("glassflow", &cfg); err != nil {
+		return fmt.Errorf("unable to parse config: %w", err)
+	}
+	if cfg.DatabaseURL == "" {
+		return fmt.Errorf("database URL is required: set GLASSFLOW_DATABASE_URL environment variable")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	migrator := schemamigrations.NewMigrator(cfg.DatabaseURL, slog.Default())
+
+	switch fs.Arg(0) {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil {
+				return fmt.Errorf("invalid number of steps %q: %w", fs.Arg(1), err)
+			}
+			steps = n
+		}
+		return migrator.Down(ctx, steps)
+	case "goto":
+		version, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", fs.Arg(1), err)
+		}
+		return migrator.Goto(ctx, uint(version))
+	case "force":
+		version, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", fs.Arg(1), err)
+		}
+		return migrator.Force(ctx, version)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if *output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+		return writeMigrationStatus(os.Stdout, status)
+	default:
+		return fmt.Errorf("unknown migrate command %q, must be up, down, goto, force or status", fs.Arg(0))
+	}
+}
+
+func writeMigrationStatus(w io.Writer, status schemamigrations.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, m := range status.Migrations {
+		state := "pending"
+		switch {
+		case m.Applied:
+			state = "applied"
+		case status.Dirty && m.Version == status.Version:
+			state = "dirty"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\nschema version %d of %d, %d pending\n", status.Version, status.LatestVersion, status.Pending)
+	return err
+}
@@ -1,11 +1,95 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componenthandshake"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/encryption"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres"
 )
 
+func postgresPoolConfig(cfg *config) postgres.Option {
+	return postgres.WithPoolConfig(postgres.PoolConfig{
+		MaxConns:         cfg.DatabaseMaxConns,
+		MinConns:         cfg.DatabaseMinConns,
+		MaxConnLifetime:  cfg.DatabaseMaxConnLifetime,
+		MaxConnIdleTime:  cfg.DatabaseMaxConnIdleTime,
+		StatementTimeout: cfg.DatabaseStatementTimeout,
+		MaxRetries:       cfg.DatabaseMaxRetries,
+		RetryDelay:       cfg.DatabaseRetryDelay,
+	})
+}
+
+// announceComponent reports the build of the component and the schema and
+// hash of its pipeline config to the API, which refuses components too old
+// for it and records the hash for the drift check.
+func announceComponent(ctx context.Context, nc *client.NATSClient, pipelineCfg models.PipelineConfig, role string, log *slog.Logger) error {
+	err := componenthandshake.Announce(ctx, nc, models.ComponentHello{
+		PipelineID:             pipelineCfg.ID,
+		Component:              role,
+		Instance:               componentInstance(),
+		Version:                version,
+		Commit:                 commit,
+		SupportedSchemaVersion: models.PipelineConfigSchemaVersion,
+		ConfigSchemaVersion:    pipelineCfg.ConfigSchemaVersion,
+		ConfigHash:             pipelineCfg.ConfigHash,
+	}, log)
+	if err != nil {
+		return fmt.Errorf("announce %s component: %w", role, err)
+	}
+	return nil
+}
+
+// componentInstance names the replica of a component by its pod index, which
+// a restarted pod keeps, or by its host name.
+func componentInstance() string {
+	if podIndex := os.Getenv("GLASSFLOW_POD_INDEX"); podIndex != "" {
+		return podIndex
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// loadKeyEncryptionKey returns the key wrapping the data key of the
+// connection credentials, nil when encryption is disabled.
+func loadKeyEncryptionKey(cfg *config, log *slog.Logger) (encryption.KeyEncryptionKey, error) {
+	switch cfg.EncryptionKeyProvider {
+	case "vault-transit":
+		vault, err := secrets.NewVaultClient(cfg.EncryptionVaultAddr, cfg.EncryptionVaultToken)
+		if err != nil {
+			return nil, err
+		}
+		kek, err := encryption.NewVaultTransitKEK(vault, cfg.EncryptionVaultTransitMount, cfg.EncryptionVaultTransitKey)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("encryption data key wrapped by vault transit key",
+			slog.String("key", cfg.EncryptionVaultTransitKey))
+		return kek, nil
+	case "local":
+		key, err := loadEncryptionKey(cfg, log)
+		if err != nil || key == nil {
+			return nil, err
+		}
+		kek, err := encryption.NewLocalKEK(key)
+		if err != nil {
+			return nil, err
+		}
+		return kek, nil
+	default:
+		return nil, fmt.Errorf("invalid encryption key provider %q, must be \"local\" or \"vault-transit\"", cfg.EncryptionKeyProvider)
+	}
+}
+
 func loadEncryptionKey(cfg *config, log *slog.Logger) ([]byte, error) {
 	if cfg.EncryptionKey != "" {
 		key := []byte(cfg.EncryptionKey)
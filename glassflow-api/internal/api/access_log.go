@@ -0,0 +1,74 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request, kept from the client or the
+// ingress when set and generated otherwise.
+const RequestIDHeader = "X-Request-Id"
+
+// AccessLogConfig controls the access log of the API. SampleRate is the
+// share of successful requests logged, errors are always logged. Requests
+// to SkipPaths, such as the health probes, are not logged. Logger replaces
+// the logger of the router for the access log, to export it as OTel logs.
+type AccessLogConfig struct {
+	SampleRate float64
+	SkipPaths  []string
+	Logger     *slog.Logger
+}
+
+// WithAccessLog configures the access log, by default every request is
+// logged.
+func WithAccessLog(cfg AccessLogConfig) RouterOption {
+	return func(o *routerOptions) {
+		o.accessLog = &cfg
+	}
+}
+
+func defaultAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{SampleRate: 1}
+}
+
+// sampled reports whether the request is written to the access log.
+func (c AccessLogConfig) sampled(path string, status int) bool {
+	if slices.Contains(c.SkipPaths, path) {
+		return false
+	}
+	if status >= http.StatusBadRequest || c.SampleRate >= 1 {
+		return true
+	}
+	return c.SampleRate > 0 && rand.Float64() < c.SampleRate
+}
+
+// requestID returns the ID of the request, generating one when the client
+// did not send it.
+func requestID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(RequestIDHeader)); id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
+
+// apiKeyID identifies the API key of the request without logging it, the
+// key itself is checked by the gateway in front of the API or by WithAuth.
+func apiKeyID(r *http.Request) string {
+	return keyFingerprint(credentials(r.Header.Get))
+}
+
+// keyFingerprint identifies a key without revealing it.
+func keyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	status := http.StatusOK
+	handler := RequestLogging(log, AccessLogConfig{SampleRate: 0, SkipPaths: []string{"/api/v1/healthz"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Successful requests are sampled out, the ID is still returned
+	rec := serve("/api/v1/pipeline", nil)
+	assert.NotEmpty(t, rec.Header().Get(RequestIDHeader))
+	assert.Empty(t, buf.String())
+
+	// Errors are always logged, with the ID of the client and its key hashed
+	status = http.StatusNotFound
+	rec = serve("/api/v1/pipeline/p-1", http.Header{
+		RequestIDHeader: {"req-1"},
+		"Authorization": {"Bearer secret-key"},
+	})
+	assert.Equal(t, "req-1", rec.Header().Get(RequestIDHeader))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/api/v1/pipeline/p-1", entry["path"])
+	assert.EqualValues(t, http.StatusNotFound, entry["status"])
+	assert.EqualValues(t, 2, entry["bytes"])
+	assert.Len(t, entry["api_key_id"], 12)
+	assert.NotContains(t, buf.String(), "secret-key")
+
+	// Skipped paths are never logged
+	buf.Reset()
+	status = http.StatusInternalServerError
+	serve("/api/v1/healthz", nil)
+	assert.Empty(t, strings.TrimSpace(buf.String()))
+}
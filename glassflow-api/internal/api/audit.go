@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// WithAuditLog records the control-plane actions of the API in store and
+// serves them at /api/v1/audit. A read-only API serves the log without
+// recording, it rejects the actions.
+func WithAuditLog(store audit.Store) RouterOption {
+	return func(o *routerOptions) {
+		o.auditLog = store
+	}
+}
+
+// auditedOperation reports whether an operation is recorded in the audit
+// log: every operation changing state, except the ingestion of events which
+// is data-plane traffic.
+func auditedOperation(op *huma.Operation) bool {
+	return mutatingOperation(op) && op.OperationID != "ingest-pipeline-events"
+}
+
+// auditMiddleware records the audited operations once they completed,
+// whether they succeeded or not. The operations complete the entry of the
+// context with their pipeline and changes. It must be added to the API
+// after authMiddleware, which resolves the actor.
+func (h *handler) auditMiddleware(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	if !auditedOperation(op) {
+		next(ctx)
+		return
+	}
+
+	entry := &audit.Entry{
+		Time:     time.Now().UTC(),
+		Actor:    auditActor(ctx),
+		SourceIP: sourceIP(ctx.RemoteAddr()),
+		Action:   op.OperationID,
+	}
+	if strings.HasPrefix(op.Path, "/api/v1/pipeline/{id}") {
+		entry.PipelineID = ctx.Param("id")
+	}
+
+	next(huma.WithContext(ctx, audit.WithEntry(ctx.Context(), entry)))
+
+	entry.Status = ctx.Status()
+	if entry.Status == 0 {
+		entry.Status = http.StatusOK
+	}
+	// The action happened, a canceled request must not lose its entry
+	err := h.auditLog.InsertAuditEntry(context.WithoutCancel(ctx.Context()), *entry)
+	if err != nil {
+		h.log.ErrorContext(ctx.Context(), "failed to record audit entry",
+			slog.String("action", entry.Action),
+			slog.String("pipeline_id", entry.PipelineID),
+			slog.Any("error", err))
+	}
+}
+
+// auditActor returns the principal of the request or, when the API does not
+// authenticate requests, the fingerprint of the key checked by the gateway.
+func auditActor(ctx huma.Context) string {
+	if principal, ok := auth.PrincipalFrom(ctx.Context()); ok {
+		return principal.Subject
+	}
+	if fingerprint := keyFingerprint(credentials(ctx.Header)); fingerprint != "" {
+		return "key:" + fingerprint
+	}
+	return audit.Anonymous
+}
+
+// auditedConfig returns the config of a pipeline before an audited
+// operation changes it, for auditChanges.
+func (h *handler) auditedConfig(ctx context.Context, pipelineID string) (models.PipelineConfig, bool) {
+	if !audit.Enabled(ctx) {
+		return models.PipelineConfig{}, false
+	}
+	cfg, err := h.pipelineService.GetPipeline(ctx, pipelineID, nil)
+	if err != nil {
+		return models.PipelineConfig{}, false
+	}
+	return cfg, true
+}
+
+// auditChanges records the fields of the pipeline config an operation
+// changed.
+func (h *handler) auditChanges(ctx context.Context, before, after models.PipelineConfig) {
+	changes, err := audit.Diff(toJSON(before), toJSON(after))
+	if err != nil {
+		h.log.WarnContext(ctx, "failed to diff pipeline config for the audit log",
+			slog.String("pipeline_id", after.ID),
+			slog.Any("error", err))
+		return
+	}
+	audit.SetChanges(ctx, changes)
+}
+
+func sourceIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func ListAuditEntriesDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "list-audit-entries",
+		Method:      http.MethodGet,
+		Summary:     "List audit log entries",
+		Description: "Returns the control-plane actions recorded in the audit log, the latest first: who did what, when, with which outcome and the config fields it changed",
+	}
+}
+
+type ListAuditEntriesInput struct {
+	Actor      string    `query:"actor" doc:"Only return the actions of the actor, for example api-key:1a2b3c4d or oidc:jane"`
+	Action     string    `query:"action" doc:"Only return the actions of the operation, for example edit-pipeline"`
+	PipelineID string    `query:"pipeline_id" doc:"Only return the actions on the pipeline"`
+	Since      time.Time `query:"since" doc:"Only return the actions at or after the time, in RFC 3339 format"`
+	Until      time.Time `query:"until" doc:"Only return the actions before the time, in RFC 3339 format"`
+	Limit      int       `query:"limit" minimum:"0" maximum:"1000" doc:"Maximum number of entries to return (default: 100)"`
+}
+
+type ListAuditEntriesResponse struct {
+	Body struct {
+		Entries []audit.Entry `json:"entries" doc:"Entries of the audit log, the latest first"`
+	}
+}
+
+func (h *handler) listAuditEntries(ctx context.Context, input *ListAuditEntriesInput) (*ListAuditEntriesResponse, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = audit.DefaultLimit
+	}
+
+	entries, err := h.auditLog.ListAuditEntries(ctx, audit.Filter{
+		Actor:      input.Actor,
+		Action:     input.Action,
+		PipelineID: input.PipelineID,
+		Since:      input.Since,
+		Until:      input.Until,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list audit log entries",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	resp := &ListAuditEntriesResponse{}
+	resp.Body.Entries = entries
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/api/mocks"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+type memAuditLog struct {
+	entries []audit.Entry
+}
+
+func (m *memAuditLog) InsertAuditEntry(_ context.Context, entry audit.Entry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memAuditLog) ListAuditEntries(_ context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	var entries []audit.Entry
+	for _, e := range m.entries {
+		if filter.PipelineID == "" || e.PipelineID == filter.PipelineID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func TestAuditLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPipelineService := mocks.NewMockPipelineService(ctrl)
+	auditLog := &memAuditLog{}
+	router := NewRouter(slog.Default(), mockPipelineService, nil, nil, nil, nil, WithAuditLog(auditLog))
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	mockPipelineService.EXPECT().StopPipeline(gomock.Any(), "p-1", gomock.Any()).Return(nil)
+	do(httptest.NewRequest(http.MethodPost, "/api/v1/pipeline/p-1/stop", nil))
+
+	// Failed actions are recorded with their status
+	mockPipelineService.EXPECT().StopPipeline(gomock.Any(), "p-2", gomock.Any()).Return(service.ErrPipelineNotExists)
+	rec := do(httptest.NewRequest(http.MethodPost, "/api/v1/pipeline/p-2/stop", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	mockPipelineService.EXPECT().GetPipeline(gomock.Any(), "p-1", gomock.Any()).
+		Return(models.PipelineConfig{ID: "p-1", Name: "orders"}, nil)
+	mockPipelineService.EXPECT().UpdatePipelineName(gomock.Any(), "p-1", "orders-v2").Return(nil)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/pipeline/p-1", strings.NewReader(`{"name":"orders-v2"}`))
+	req.Header.Set("X-Api-Key", "gateway-key")
+	do(req)
+
+	// Reads are not recorded
+	mockPipelineService.EXPECT().GetPipeline(gomock.Any(), "p-1", gomock.Any()).Return(models.PipelineConfig{ID: "p-1"}, nil)
+	do(httptest.NewRequest(http.MethodGet, "/api/v1/pipeline/p-1", nil))
+
+	require.Len(t, auditLog.entries, 3)
+	assert.Equal(t, "stop-pipeline", auditLog.entries[0].Action)
+	assert.Equal(t, "p-1", auditLog.entries[0].PipelineID)
+	assert.Equal(t, audit.Anonymous, auditLog.entries[0].Actor)
+	assert.Equal(t, http.StatusNotFound, auditLog.entries[1].Status)
+
+	renamed := auditLog.entries[2]
+	assert.Equal(t, "update-pipeline-name", renamed.Action)
+	assert.Equal(t, "key:"+keyFingerprint("gateway-key"), renamed.Actor)
+	assert.Equal(t, []audit.Change{{Path: "name", Old: "orders", New: "orders-v2"}}, renamed.Changes)
+
+	rec = do(httptest.NewRequest(http.MethodGet, "/api/v1/audit?pipeline_id=p-2", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Entries, 1)
+	assert.Equal(t, "p-2", resp.Entries[0].PipelineID)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+)
+
+// WithAuth requires the credentials of a principal whose role allows the
+// operation on every request, except for the health probes and the
+// endpoints called by the components.
+func WithAuth(authenticator *auth.Authenticator) RouterOption {
+	return func(o *routerOptions) {
+		o.auth = authenticator
+	}
+}
+
+// publicOperations are served without credentials.
+var publicOperations = map[string]bool{
+	"get-healthz":     true,
+	"get-readyz":      true,
+	"get-otlp-config": true,
+}
+
+// adminOperations manage the settings of the installation, its access and
+// its audit log.
+var adminOperations = map[string]bool{
+	"set-feature-flag":            true,
+	"reset-feature-flag":          true,
+	"set-pipeline-feature-flag":   true,
+	"reset-pipeline-feature-flag": true,
+	"create-api-key":              true,
+	"list-api-keys":               true,
+	"delete-api-key":              true,
+	"list-audit-entries":          true,
+}
+
+// requiredRole returns the role an operation requires: admin for the
+// settings, operator for the changes and viewer for the reads.
+func requiredRole(op *huma.Operation) auth.Role {
+	switch {
+	case adminOperations[op.OperationID]:
+		return auth.RoleAdmin
+	case mutatingOperation(op):
+		return auth.RoleOperator
+	default:
+		return auth.RoleViewer
+	}
+}
+
+// credentials returns the API key or the bearer token of a request.
+func credentials(header func(string) string) string {
+	if key := header("X-Api-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if token, ok := strings.CutPrefix(header("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// authMiddleware authenticates the request, checks the role of the
+// principal and, for the operations on a pipeline, that the pipeline is
+// owned by one of the teams of a scoped principal. The principal is added
+// to the context of the request.
+func (h *handler) authMiddleware(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	if publicOperations[op.OperationID] {
+		next(ctx)
+		return
+	}
+
+	principal, err := h.auth.Authenticate(ctx.Context(), credentials(ctx.Header))
+	if err != nil {
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			ctx.SetHeader("WWW-Authenticate", "Bearer")
+			writeErrorDetail(ctx, &ErrorDetail{
+				Status:  http.StatusUnauthorized,
+				Code:    "unauthenticated",
+				Message: "missing or invalid API key or bearer token",
+			})
+			return
+		}
+		h.log.ErrorContext(ctx.Context(), "failed to authenticate request", "error", err)
+		writeErrorDetail(ctx, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to authenticate request",
+		})
+		return
+	}
+
+	required := requiredRole(op)
+	if !principal.Role.Allows(required) {
+		writeErrorDetail(ctx, &ErrorDetail{
+			Status:  http.StatusForbidden,
+			Code:    "forbidden",
+			Message: "the role of the caller does not allow this operation",
+			Details: map[string]any{
+				"role":          principal.Role,
+				"required_role": required,
+			},
+		})
+		return
+	}
+
+	if principal.Scoped() && strings.Contains(op.Path, "{id}") {
+		id := ctx.Param("id")
+		// Unknown pipelines are left to the operation, e.g. to return 404
+		pipeline, err := h.pipelineService.GetPipeline(ctx.Context(), id, nil)
+		if err == nil && !principal.CanAccess(pipeline.Metadata.Team) {
+			writeErrorDetail(ctx, pipelineForbidden(id))
+			return
+		}
+	}
+
+	next(huma.WithContext(ctx, auth.WithPrincipal(ctx.Context(), principal)))
+}
+
+func pipelineForbidden(pipelineID string) *ErrorDetail {
+	return &ErrorDetail{
+		Status:  http.StatusForbidden,
+		Code:    "forbidden",
+		Message: "the pipeline is not owned by a team of the caller",
+		Details: map[string]any{
+			"pipeline_id": pipelineID,
+		},
+	}
+}
+
+// authorizeTeam rejects a pipeline config that a scoped caller would hand
+// to a team it is not part of.
+func authorizeTeam(ctx context.Context, pipelineID, team string) *ErrorDetail {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if ok && !principal.CanAccess(team) {
+		return pipelineForbidden(pipelineID)
+	}
+	return nil
+}
+
+// authorizePipelines rejects operations on several pipelines when a scoped
+// caller does not own one of them.
+func (h *handler) authorizePipelines(ctx context.Context, ids []string) *ErrorDetail {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !ok || !principal.Scoped() {
+		return nil
+	}
+	for _, id := range ids {
+		pipeline, err := h.pipelineService.GetPipeline(ctx, id, nil)
+		if err == nil && !principal.CanAccess(pipeline.Metadata.Team) {
+			return pipelineForbidden(id)
+		}
+	}
+	return nil
+}
+
+func WhoAmIDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-auth-principal",
+		Method:      http.MethodGet,
+		Summary:     "Get the caller",
+		Description: "Returns the principal of the credentials of the request, with its role and teams",
+	}
+}
+
+type WhoAmIResponse struct {
+	Body auth.Principal
+}
+
+func (h *handler) whoAmI(ctx context.Context, _ *struct{}) (*WhoAmIResponse, error) {
+	principal, _ := auth.PrincipalFrom(ctx)
+	return &WhoAmIResponse{Body: principal}, nil
+}
+
+func CreateAPIKeyDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "create-api-key",
+		Method:      http.MethodPost,
+		Summary:     "Create an API key",
+		Description: "Creates an API key with a role, optionally scoped to the pipelines of teams. The key is only returned once",
+	}
+}
+
+func ListAPIKeysDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "list-api-keys",
+		Method:      http.MethodGet,
+		Summary:     "List API keys",
+		Description: "Returns the API keys without the keys themselves",
+	}
+}
+
+func DeleteAPIKeyDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "delete-api-key",
+		Method:      http.MethodDelete,
+		Summary:     "Revoke an API key",
+		Description: "Deletes an API key, requests with it are rejected at once",
+	}
+}
+
+type CreateAPIKeyInput struct {
+	Body struct {
+		Name  string    `json:"name" minLength:"1" doc:"Name of the key, e.g. the client using it"`
+		Role  auth.Role `json:"role" enum:"viewer,operator,admin" doc:"Role of the key"`
+		Teams []string  `json:"teams,omitempty" doc:"Teams whose pipelines the key acts on, all pipelines when empty"`
+	}
+}
+
+type CreateAPIKeyResponse struct {
+	Body struct {
+		auth.APIKey
+		Key string `json:"key" doc:"The API key, send it in the X-Api-Key header"`
+	}
+}
+
+type ListAPIKeysResponse struct {
+	Body struct {
+		Keys []auth.APIKey `json:"keys"`
+	}
+}
+
+type DeleteAPIKeyInput struct {
+	ID string `path:"id" minLength:"1" doc:"API key ID"`
+}
+
+type DeleteAPIKeyResponse struct {
+	Body struct{} `json:"-"`
+}
+
+func (h *handler) createAPIKey(ctx context.Context, input *CreateAPIKeyInput) (*CreateAPIKeyResponse, error) {
+	key, token, err := h.auth.CreateKey(ctx, input.Body.Name, input.Body.Role, input.Body.Teams)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidKey) {
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "invalid api key",
+				Details: map[string]any{
+					"error": err.Error(),
+				},
+			}
+		}
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to create api key",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	principal, _ := auth.PrincipalFrom(ctx)
+	h.log.InfoContext(ctx, "api key created", "key_id", key.ID, "role", key.Role, "teams", key.Teams, "created_by", principal.Subject)
+
+	resp := &CreateAPIKeyResponse{}
+	resp.Body.APIKey = key
+	resp.Body.Key = token
+	return resp, nil
+}
+
+func (h *handler) listAPIKeys(ctx context.Context, _ *struct{}) (*ListAPIKeysResponse, error) {
+	keys, err := h.auth.ListKeys(ctx)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list api keys",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	resp := &ListAPIKeysResponse{}
+	resp.Body.Keys = keys
+	return resp, nil
+}
+
+func (h *handler) deleteAPIKey(ctx context.Context, input *DeleteAPIKeyInput) (*DeleteAPIKeyResponse, error) {
+	err := h.auth.DeleteKey(ctx, input.ID)
+	if err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no api key with given id found",
+				Details: map[string]any{
+					"key_id": input.ID,
+				},
+			}
+		}
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to delete api key",
+			Details: map[string]any{
+				"key_id": input.ID,
+				"error":  err.Error(),
+			},
+		}
+	}
+
+	principal, _ := auth.PrincipalFrom(ctx)
+	h.log.InfoContext(ctx, "api key revoked", "key_id", input.ID, "revoked_by", principal.Subject)
+
+	return &DeleteAPIKeyResponse{}, nil
+}
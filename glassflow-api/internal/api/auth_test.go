@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/api/mocks"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type memKeyStore map[string]auth.APIKey
+
+func (m memKeyStore) CreateAPIKey(_ context.Context, key auth.APIKey) error {
+	m[key.ID] = key
+	return nil
+}
+
+func (m memKeyStore) GetAPIKey(_ context.Context, id string) (auth.APIKey, error) {
+	key, ok := m[id]
+	if !ok {
+		return auth.APIKey{}, auth.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (m memKeyStore) ListAPIKeys(_ context.Context) ([]auth.APIKey, error) {
+	keys := make([]auth.APIKey, 0, len(m))
+	for _, key := range m {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m memKeyStore) DeleteAPIKey(_ context.Context, id string) error {
+	delete(m, id)
+	return nil
+}
+
+func TestAuthRouter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPipelineService := mocks.NewMockPipelineService(ctrl)
+	authenticator := auth.New(auth.WithAdminKey("bootstrap-secret"), auth.WithKeyStore(memKeyStore{}))
+	router := NewRouter(slog.Default(), mockPipelineService, nil, nil, nil, nil, WithAuth(authenticator))
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	createKey := func(role auth.Role, teams ...string) string {
+		body, err := json.Marshal(map[string]any{"name": "test", "role": role, "teams": teams})
+		require.NoError(t, err)
+		rec := do(http.MethodPost, "/api/v1/api-keys", "bootstrap-secret", string(body))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp struct {
+			Key string `json:"key"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp.Key
+	}
+
+	// Probes are public
+	rec := do(http.MethodGet, "/api/v1/readyz", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(http.MethodGet, "/api/v1/pipeline/p-1", "", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+	viewer := createKey(auth.RoleViewer)
+	rec = do(http.MethodPost, "/api/v1/pipeline/p-1/stop", viewer, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	var body ErrorDetail
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "forbidden", body.Code)
+	assert.Equal(t, "operator", body.Details["required_role"])
+
+	rec = do(http.MethodGet, "/api/v1/auth/whoami", viewer, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var principal auth.Principal
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &principal))
+	assert.Equal(t, auth.RoleViewer, principal.Role)
+
+	// Only admins manage keys
+	rec = do(http.MethodGet, "/api/v1/api-keys", viewer, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// A scoped key does not act on the pipelines of other teams
+	payments := createKey(auth.RoleOperator, "payments")
+	mockPipelineService.EXPECT().GetPipeline(gomock.Any(), "p-1", gomock.Any()).
+		Return(models.PipelineConfig{ID: "p-1", Metadata: models.PipelineMetadata{Team: "search"}}, nil)
+	rec = do(http.MethodPost, "/api/v1/pipeline/p-1/stop", payments, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "p-1", body.Details["pipeline_id"])
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+func ResumePipelinesDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "resume-pipelines",
+		Method:      http.MethodPost,
+		Summary:     "Resume pipelines",
+		Description: "Resumes the pipelines in dependency order, a pipeline once the pipelines it depends on are running",
+	}
+}
+
+func StopPipelinesDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "stop-pipelines",
+		Method:      http.MethodPost,
+		Summary:     "Stop pipelines",
+		Description: "Stops the pipelines in reverse dependency order, a pipeline after the pipelines depending on it",
+	}
+}
+
+type BulkPipelinesInput struct {
+	Body struct {
+		PipelineIDs []string `json:"pipeline_ids" minItems:"1" doc:"IDs of the pipelines"`
+	}
+}
+
+type BulkPipelinesResponse struct {
+	Body struct {
+		Results []models.BulkPipelineResult `json:"results"`
+	}
+}
+
+func (h *handler) resumePipelines(ctx context.Context, input *BulkPipelinesInput) (*BulkPipelinesResponse, error) {
+	if errDetail := h.authorizePipelines(ctx, input.Body.PipelineIDs); errDetail != nil {
+		return nil, errDetail
+	}
+	audit.SetDetail(ctx, "pipeline_ids", input.Body.PipelineIDs)
+
+	results, err := h.pipelineService.ResumePipelines(ctx, input.Body.PipelineIDs)
+	if err != nil {
+		return nil, bulkPipelinesError(err, "resume")
+	}
+
+	h.log.InfoContext(ctx, "pipelines resumed", slog.Any("pipeline_ids", input.Body.PipelineIDs))
+
+	resp := &BulkPipelinesResponse{}
+	resp.Body.Results = results
+	return resp, nil
+}
+
+func (h *handler) stopPipelines(ctx context.Context, input *BulkPipelinesInput) (*BulkPipelinesResponse, error) {
+	if errDetail := h.authorizePipelines(ctx, input.Body.PipelineIDs); errDetail != nil {
+		return nil, errDetail
+	}
+	audit.SetDetail(ctx, "pipeline_ids", input.Body.PipelineIDs)
+
+	results, err := h.pipelineService.StopPipelines(ctx, input.Body.PipelineIDs)
+	if err != nil {
+		return nil, bulkPipelinesError(err, "stop")
+	}
+
+	h.log.InfoContext(ctx, "pipelines stopped", slog.Any("pipeline_ids", input.Body.PipelineIDs))
+
+	resp := &BulkPipelinesResponse{}
+	resp.Body.Results = results
+	return resp, nil
+}
+
+func bulkPipelinesError(err error, operation string) *ErrorDetail {
+	switch {
+	case errors.Is(err, service.ErrPipelineNotExists):
+		return &ErrorDetail{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "no pipeline with given id exists",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	case errors.Is(err, service.ErrInvalidDependencies):
+		return &ErrorDetail{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "unprocessable_entity",
+			Message: "invalid pipeline dependencies",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	default:
+		return &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to " + operation + " pipelines",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+}
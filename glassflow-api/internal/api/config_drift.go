@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// ComponentReports lists the config hashes the components of a pipeline
+// reported at startup.
+type ComponentReports interface {
+	List(ctx context.Context, pipelineID string) ([]models.ComponentConfigReport, error)
+	Purge(ctx context.Context, pipelineID string) error
+}
+
+// WithComponentReports serves the config drift check of the pipelines.
+func WithComponentReports(reports ComponentReports) RouterOption {
+	return func(o *routerOptions) {
+		o.componentReports = reports
+	}
+}
+
+func GetConfigDriftDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-pipeline-config-drift",
+		Method:      http.MethodGet,
+		Summary:     "Get pipeline config drift",
+		Description: "Compares the stored config hash of a pipeline with the hashes its components reported at startup",
+	}
+}
+
+type GetConfigDriftInput struct {
+	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+}
+
+type GetConfigDriftResponse struct {
+	Body models.ConfigDrift
+}
+
+func (h *handler) getConfigDrift(ctx context.Context, input *GetConfigDriftInput) (*GetConfigDriftResponse, error) {
+	pipeline, err := h.pipelineService.GetPipeline(ctx, input.ID, nil)
+	if err != nil {
+		if errors.Is(err, service.ErrPipelineNotExists) {
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no pipeline with given id found",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+				},
+			}
+		}
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to get pipeline",
+			Details: map[string]any{
+				"pipeline_id": input.ID,
+				"error":       err.Error(),
+			},
+		}
+	}
+
+	reports, err := h.componentReports.List(ctx, input.ID)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list component reports",
+			Details: map[string]any{
+				"pipeline_id": input.ID,
+				"error":       err.Error(),
+			},
+		}
+	}
+
+	return &GetConfigDriftResponse{
+		Body: models.NewConfigDrift(input.ID, pipeline.ConfigHash, reports),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+func ConfirmStagingDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "confirm-pipeline-staging",
+		Method:      http.MethodPost,
+		Summary:     "Confirm pipeline staging",
+		Description: "Confirms the data written to the staging table and switches the sink to the sink table",
+	}
+}
+
+type ConfirmStagingInput struct {
+	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+}
+
+type ConfirmStagingResponse struct {
+	Body struct{} `json:"-"`
+}
+
+func (h *handler) confirmStaging(ctx context.Context, input *ConfirmStagingInput) (*ConfirmStagingResponse, error) {
+	err := h.pipelineService.ConfirmStaging(ctx, input.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPipelineNotExists):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no pipeline with given id found",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrPipelineNotStaging):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "not_staging",
+				Message: "pipeline sink is not writing to a staging table",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		default:
+			return nil, &ErrorDetail{
+				Status:  http.StatusInternalServerError,
+				Code:    "internal_error",
+				Message: "failed to confirm pipeline staging",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		}
+	}
+
+	h.log.InfoContext(ctx, "pipeline staging confirmed", slog.String("pipeline_id", input.ID))
+
+	return &ConfirmStagingResponse{}, nil
+}
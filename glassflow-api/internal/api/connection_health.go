@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ConnectionHealthReader returns the health the connection monitor recorded
+// for the endpoints the pipelines connect to.
+type ConnectionHealthReader interface {
+	ListConnectionHealth(ctx context.Context) ([]models.ConnectionHealth, error)
+	GetConnectionHealth(ctx context.Context, id string) (models.ConnectionHealth, error)
+	ListConnectionHealthChecks(ctx context.Context, id string, since time.Time, limit int) ([]models.ConnectionHealthCheck, error)
+}
+
+// defaultConnectionHealthChecks is the number of checks of the history
+// returned by default
+const defaultConnectionHealthChecks = 100
+
+// WithConnectionHealth serves the health of the ClickHouse servers and Kafka
+// clusters of the stored connections.
+func WithConnectionHealth(reader ConnectionHealthReader) RouterOption {
+	return func(o *routerOptions) {
+		o.connectionHealth = reader
+	}
+}
+
+func ListConnectionHealthDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "list-connection-health",
+		Method:      http.MethodGet,
+		Summary:     "List connection health",
+		Description: "Returns the health of the ClickHouse servers and Kafka clusters the pipelines connect to, with the time they entered their status and the pipelines using them",
+	}
+}
+
+type ListConnectionHealthResponse struct {
+	Body struct {
+		Connections []models.ConnectionHealth `json:"connections" doc:"Endpoints of the stored connections, sorted by type and address"`
+	}
+}
+
+func (h *handler) listConnectionHealth(ctx context.Context, _ *struct{}) (*ListConnectionHealthResponse, error) {
+	connections, err := h.connectionHealth.ListConnectionHealth(ctx)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list connection health",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	resp := &ListConnectionHealthResponse{}
+	resp.Body.Connections = connections
+	return resp, nil
+}
+
+func GetConnectionHealthHistoryDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-connection-health-history",
+		Method:      http.MethodGet,
+		Summary:     "Get connection health history",
+		Description: "Returns the current health of a ClickHouse server or Kafka cluster and its health checks, the latest first",
+	}
+}
+
+type GetConnectionHealthHistoryInput struct {
+	ID    string    `path:"id" minLength:"1" doc:"Connection ID, as returned by the connection health list"`
+	Since time.Time `query:"since" doc:"Only return the checks at or after the time, in RFC 3339 format"`
+	Limit int       `query:"limit" minimum:"0" maximum:"1000" doc:"Maximum number of checks to return (default: 100)"`
+}
+
+type GetConnectionHealthHistoryResponse struct {
+	Body struct {
+		models.ConnectionHealth
+		Checks []models.ConnectionHealthCheck `json:"checks" doc:"Health checks of the endpoint, the latest first"`
+	}
+}
+
+func (h *handler) getConnectionHealthHistory(ctx context.Context, input *GetConnectionHealthHistoryInput) (*GetConnectionHealthHistoryResponse, error) {
+	current, err := h.connectionHealth.GetConnectionHealth(ctx, input.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no connection with the given id is monitored",
+				Details: map[string]any{
+					"connection_id": input.ID,
+				},
+			}
+		}
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to get connection health",
+			Details: map[string]any{
+				"connection_id": input.ID,
+				"error":         err.Error(),
+			},
+		}
+	}
+
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultConnectionHealthChecks
+	}
+	checks, err := h.connectionHealth.ListConnectionHealthChecks(ctx, input.ID, input.Since, limit)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list connection health checks",
+			Details: map[string]any{
+				"connection_id": input.ID,
+				"error":         err.Error(),
+			},
+		}
+	}
+
+	resp := &GetConnectionHealthHistoryResponse{}
+	resp.Body.ConnectionHealth = current
+	resp.Body.Checks = checks
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeConnectionHealth struct {
+	health []models.ConnectionHealth
+	checks []models.ConnectionHealthCheck
+}
+
+func (f fakeConnectionHealth) ListConnectionHealth(_ context.Context) ([]models.ConnectionHealth, error) {
+	return f.health, nil
+}
+
+func (f fakeConnectionHealth) GetConnectionHealth(_ context.Context, id string) (models.ConnectionHealth, error) {
+	for _, h := range f.health {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return models.ConnectionHealth{}, models.ErrRecordNotFound
+}
+
+func (f fakeConnectionHealth) ListConnectionHealthChecks(_ context.Context, id string, _ time.Time, limit int) ([]models.ConnectionHealthCheck, error) {
+	var checks []models.ConnectionHealthCheck
+	for _, c := range f.checks {
+		if c.ConnectionID == id && len(checks) < limit {
+			checks = append(checks, c)
+		}
+	}
+	return checks, nil
+}
+
+func TestConnectionHealth(t *testing.T) {
+	since := time.Date(2026, 10, 16, 10, 32, 0, 0, time.UTC)
+	reader := fakeConnectionHealth{
+		health: []models.ConnectionHealth{{
+			ConnectionEndpoint: models.ConnectionEndpoint{
+				ID:        "a1b2c3d4e5f60718",
+				Type:      models.ConnectionTypeClickHouse,
+				Address:   "ch.prod-eu:9000",
+				Pipelines: []string{"orders"},
+			},
+			Status:    models.ConnectionDegraded,
+			Since:     since,
+			CheckedAt: since.Add(time.Minute),
+			LatencyMs: 2500,
+		}},
+		checks: []models.ConnectionHealthCheck{
+			{ConnectionID: "a1b2c3d4e5f60718", CheckedAt: since.Add(time.Minute), Status: models.ConnectionDegraded, LatencyMs: 2500},
+			{ConnectionID: "a1b2c3d4e5f60718", CheckedAt: since, Status: models.ConnectionDegraded, LatencyMs: 3100},
+		},
+	}
+	router := NewRouter(slog.Default(), nil, nil, nil, nil, nil, WithConnectionHealth(reader))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/connections/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Connections []models.ConnectionHealth `json:"connections"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Connections, 1)
+	assert.Equal(t, models.ConnectionDegraded, list.Connections[0].Status)
+	assert.Equal(t, since, list.Connections[0].Since)
+	assert.Equal(t, []string{"orders"}, list.Connections[0].Pipelines)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/connections/a1b2c3d4e5f60718/health?limit=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var history struct {
+		Address string                         `json:"address"`
+		Checks  []models.ConnectionHealthCheck `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	assert.Equal(t, "ch.prod-eu:9000", history.Address)
+	assert.Len(t, history.Checks, 1)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/connections/unknown/health", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
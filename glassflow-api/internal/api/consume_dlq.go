@@ -9,6 +9,7 @@ import (
 	"github.com/danielgtaylor/huma/v2"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
@@ -78,6 +79,9 @@ func (h *handler) consumeDLQ(ctx context.Context, input *ConsumeDLQInput) (*Cons
 		}
 	}
 
+	// The consumed messages are acknowledged, the audit log keeps how many
+	audit.SetDetail(ctx, "messages", len(msgs))
+
 	dlqMsgsRes := make([]DLQConsumeMessage, 0, len(msgs))
 	for _, msg := range msgs {
 		dlqMsgsRes = append(dlqMsgsRes, DLQConsumeMessage{
@@ -8,8 +8,10 @@ import (
 
 	"github.com/danielgtaylor/huma/v2"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
 )
 
 func CreatePipelineDocs() huma.Operation {
@@ -42,6 +44,11 @@ func (h *handler) createPipeline(ctx context.Context, input *CreatePipelineInput
 		}
 	}
 
+	if errDetail := authorizeTeam(ctx, pipeline.ID, pipeline.Metadata.Team); errDetail != nil {
+		return nil, errDetail
+	}
+	audit.SetPipelineID(ctx, pipeline.ID)
+
 	err = h.pipelineService.CreatePipeline(ctx, &pipeline)
 	if err != nil {
 		var pErr models.PipelineConfigError
@@ -66,6 +73,76 @@ func (h *handler) createPipeline(ctx context.Context, input *CreatePipelineInput
 					"error":       err.Error(),
 				},
 			}
+		case errors.Is(err, service.ErrNotImplemented):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotImplemented,
+				Code:    "not_implemented",
+				Message: "feature not implemented for this version",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrInvalidDependencies):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "pipeline creation failed, invalid pipeline dependencies",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrDependencyNotRunning):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "dependency_not_running",
+				Message: "pipeline creation failed, a pipeline it depends on is not running",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrDedupDomainConflict):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "dedup_domain_conflict",
+				Message: "pipeline creation failed, its dedup domain uses another time window",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrOwnershipRequired):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "ownership_required",
+				Message: "pipeline creation failed, its metadata is missing required ownership",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrFeatureNotLicensed):
+			return nil, &ErrorDetail{
+				Status:  http.StatusForbidden,
+				Code:    "feature_not_licensed",
+				Message: "pipeline creation failed, it uses an unlicensed feature",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrFeatureDisabled):
+			return nil, &ErrorDetail{
+				Status:  http.StatusForbidden,
+				Code:    "feature_disabled",
+				Message: "pipeline creation failed, it uses a disabled feature",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
 		case errors.As(err, &pErr):
 			return nil, &ErrorDetail{
 				Status:  http.StatusUnprocessableEntity,
@@ -75,6 +152,26 @@ func (h *handler) createPipeline(ctx context.Context, input *CreatePipelineInput
 					"error": err.Error(),
 				},
 			}
+		case errors.Is(err, service.ErrCreateSinkTable):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "pipeline creation failed, could not create the sink table",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrSinkColumnTypes):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "pipeline creation failed, could not resolve the sink column types",
+				Details: map[string]any{
+					"pipeline_id": pipeline.ID,
+					"error":       err.Error(),
+				},
+			}
 		case errors.Is(err, service.ErrPipelineResourcesValidation):
 			return nil, &ErrorDetail{
 				Status:  http.StatusUnprocessableEntity,
@@ -103,5 +200,7 @@ func (h *handler) createPipeline(ctx context.Context, input *CreatePipelineInput
 		}
 	}
 
+	h.notifier.NotifyPipeline(pipeline, notification.EventPipelineCreated, nil)
+
 	return &CreatePipelineResponse{}, nil
 }
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+func GetDashboardDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-grafana-dashboard",
+		Method:      http.MethodGet,
+		Summary:     "Get a Grafana dashboard",
+		Description: "Returns a Grafana dashboard generated from the metrics this build emits, the pipeline dashboard shows one pipeline by component and the system dashboard all pipelines and the API",
+	}
+}
+
+type GetDashboardInput struct {
+	Name         string `path:"name" enum:"pipeline,system" doc:"Dashboard to generate"`
+	MetricPrefix string `query:"metric_prefix" doc:"Prefix of the metric names in Prometheus, for example the namespace prefix added by the OTel collector such as glassflow_"`
+}
+
+type GetDashboardResponse struct {
+	Body observability.GrafanaDashboard
+}
+
+func (h *handler) getDashboard(_ context.Context, input *GetDashboardInput) (*GetDashboardResponse, error) {
+	dashboard, err := observability.NewGrafanaDashboard(input.Name, input.MetricPrefix)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "no dashboard with given name",
+			Details: map[string]any{
+				"name":  input.Name,
+				"error": err.Error(),
+			},
+		}
+	}
+
+	return &GetDashboardResponse{Body: dashboard}, nil
+}
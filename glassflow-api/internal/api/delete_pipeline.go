@@ -130,6 +130,13 @@ func (h *handler) deletePipeline(ctx context.Context, input *DeletePipelineInput
 		}
 	}
 
+	if h.componentReports != nil {
+		err = h.componentReports.Purge(ctx, input.ID)
+		if err != nil {
+			h.log.WarnContext(ctx, "failed to purge component reports", "pipeline_id", input.ID, "error", err)
+		}
+	}
+
 	h.log.InfoContext(ctx, "pipeline deleted")
 
 	return &DeletePipelineResponse{}, nil
@@ -8,6 +8,7 @@ import (
 
 type DLQ interface {
 	FetchDLQMessages(ctx context.Context, stream string, batchSize int) ([]models.DLQMessage, error)
+	ListDLQMessages(ctx context.Context, stream string, offset, limit int) (zero models.DLQPage, _ error)
 	GetDLQState(ctx context.Context, stream string) (zero models.DLQState, _ error)
 	PurgeDLQ(ctx context.Context, stream string) (err error)
 }
@@ -56,6 +56,12 @@ func (h *handler) editPipeline(ctx context.Context, input *EditPipelineInput) (*
 		}
 	}
 
+	if errDetail := authorizeTeam(ctx, pipeline.ID, pipeline.Metadata.Team); errDetail != nil {
+		return nil, errDetail
+	}
+
+	before, audited := h.auditedConfig(ctx, input.ID)
+
 	err = h.pipelineService.EditPipeline(ctx, input.ID, &pipeline)
 	if err != nil {
 		switch {
@@ -79,6 +85,86 @@ func (h *handler) editPipeline(ctx context.Context, input *EditPipelineInput) (*
 					"error":       err.Error(),
 				},
 			}
+		case errors.Is(err, service.ErrSinkColumnTypes):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "pipeline edit failed, could not resolve the sink column types",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrInvalidDependencies):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "invalid pipeline dependencies",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrKafkaClusterChanged):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "kafka_cluster_changed",
+				Message: "kafka brokers point at a different cluster than at pipeline creation, resume the pipeline with force to accept it",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrDedupDomainConflict):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "dedup_domain_conflict",
+				Message: "pipeline edit failed, its dedup domain uses another time window",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrOwnershipRequired):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "ownership_required",
+				Message: "pipeline metadata is missing required ownership",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrFeatureNotLicensed):
+			return nil, &ErrorDetail{
+				Status:  http.StatusForbidden,
+				Code:    "feature_not_licensed",
+				Message: "pipeline uses an unlicensed feature",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrFeatureDisabled):
+			return nil, &ErrorDetail{
+				Status:  http.StatusForbidden,
+				Code:    "feature_disabled",
+				Message: "pipeline uses a disabled feature",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrPipelineNotDrained):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "conflict",
+				Message: "pipeline still has in-flight messages, retry once it is drained",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
 		case errors.Is(err, service.ErrPipelineResourcesValidation):
 			return nil, &ErrorDetail{
 				Status:  http.StatusUnprocessableEntity,
@@ -120,5 +206,9 @@ func (h *handler) editPipeline(ctx context.Context, input *EditPipelineInput) (*
 		}
 	}
 
+	if audited {
+		h.auditChanges(ctx, before, pipeline)
+	}
+
 	return &EditPipelineResponse{}, nil
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer"
+	jsonTransformer "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/json"
 )
 
 func EvaluateTransformDocs() huma.Operation {
@@ -53,7 +54,12 @@ func (h *handler) evaluateTransform(
 	}
 
 	// Evaluate transformations using transformer package
-	resultJSON, err := transformer.Evaluate(ctx, input.Body.Config.Transform, input.Body.Sample)
+	resultJSON, err := transformer.Evaluate(
+		ctx,
+		input.Body.Config.Transform,
+		input.Body.Sample,
+		jsonTransformer.WithSalt(input.Body.Config.Salt),
+	)
 	if err != nil {
 		return nil, &ErrorDetail{
 			Status:  http.StatusBadRequest,
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/featureflags"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// FeatureFlags resolves and overrides the feature flags of the installation,
+// for an empty pipeline ID, and of its pipelines.
+type FeatureFlags interface {
+	Resolve(ctx context.Context, pipelineID string) ([]featureflags.State, error)
+	Set(ctx context.Context, pipelineID, name string, enabled bool) error
+	Reset(ctx context.Context, pipelineID, name string) error
+}
+
+// WithFeatureFlags serves the feature flag settings.
+func WithFeatureFlags(flags FeatureFlags) RouterOption {
+	return func(o *routerOptions) {
+		o.featureFlags = flags
+	}
+}
+
+func GetFeatureFlagsDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-feature-flags",
+		Method:      http.MethodGet,
+		Summary:     "Get feature flags",
+		Description: "Returns the feature flags of the installation and where their value comes from",
+	}
+}
+
+func SetFeatureFlagDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "set-feature-flag",
+		Method:      http.MethodPut,
+		Summary:     "Set a feature flag",
+		Description: "Enables or disables a feature for the installation, overriding the environment",
+	}
+}
+
+func ResetFeatureFlagDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "reset-feature-flag",
+		Method:      http.MethodDelete,
+		Summary:     "Reset a feature flag",
+		Description: "Removes the installation setting of a feature flag",
+	}
+}
+
+func GetPipelineFeatureFlagsDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-pipeline-feature-flags",
+		Method:      http.MethodGet,
+		Summary:     "Get pipeline feature flags",
+		Description: "Returns the feature flags of a pipeline and where their value comes from",
+	}
+}
+
+func SetPipelineFeatureFlagDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "set-pipeline-feature-flag",
+		Method:      http.MethodPut,
+		Summary:     "Set a pipeline feature flag",
+		Description: "Enables or disables a feature for a pipeline, overriding the installation",
+	}
+}
+
+func ResetPipelineFeatureFlagDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "reset-pipeline-feature-flag",
+		Method:      http.MethodDelete,
+		Summary:     "Reset a pipeline feature flag",
+		Description: "Removes the pipeline setting of a feature flag",
+	}
+}
+
+type FeatureFlagsResponse struct {
+	Body struct {
+		Flags []featureflags.State `json:"flags"`
+	}
+}
+
+type PipelineFeatureFlagsInput struct {
+	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+}
+
+type FeatureFlagInput struct {
+	Name string `path:"name" minLength:"1" doc:"Feature flag name"`
+	Body struct {
+		Enabled bool `json:"enabled" doc:"Whether the feature is enabled"`
+	}
+}
+
+type ResetFeatureFlagInput struct {
+	Name string `path:"name" minLength:"1" doc:"Feature flag name"`
+}
+
+type PipelineFeatureFlagInput struct {
+	ID   string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Name string `path:"name" minLength:"1" doc:"Feature flag name"`
+	Body struct {
+		Enabled bool `json:"enabled" doc:"Whether the feature is enabled"`
+	}
+}
+
+type ResetPipelineFeatureFlagInput struct {
+	ID   string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Name string `path:"name" minLength:"1" doc:"Feature flag name"`
+}
+
+func (h *handler) getFeatureFlags(ctx context.Context, _ *struct{}) (*FeatureFlagsResponse, error) {
+	return h.resolveFeatureFlags(ctx, "")
+}
+
+func (h *handler) setFeatureFlag(ctx context.Context, input *FeatureFlagInput) (*FeatureFlagsResponse, error) {
+	err := h.featureFlags.Set(ctx, "", input.Name, input.Body.Enabled)
+	if err != nil {
+		return nil, featureFlagError(err, "", input.Name)
+	}
+	return h.resolveFeatureFlags(ctx, "")
+}
+
+func (h *handler) resetFeatureFlag(ctx context.Context, input *ResetFeatureFlagInput) (*FeatureFlagsResponse, error) {
+	err := h.featureFlags.Reset(ctx, "", input.Name)
+	if err != nil {
+		return nil, featureFlagError(err, "", input.Name)
+	}
+	return h.resolveFeatureFlags(ctx, "")
+}
+
+func (h *handler) getPipelineFeatureFlags(ctx context.Context, input *PipelineFeatureFlagsInput) (*FeatureFlagsResponse, error) {
+	_, err := h.pipelineService.GetPipeline(ctx, input.ID, nil)
+	if err != nil {
+		return nil, featureFlagError(err, input.ID, "")
+	}
+	return h.resolveFeatureFlags(ctx, input.ID)
+}
+
+func (h *handler) setPipelineFeatureFlag(ctx context.Context, input *PipelineFeatureFlagInput) (*FeatureFlagsResponse, error) {
+	err := h.featureFlags.Set(ctx, input.ID, input.Name, input.Body.Enabled)
+	if err != nil {
+		return nil, featureFlagError(err, input.ID, input.Name)
+	}
+	return h.resolveFeatureFlags(ctx, input.ID)
+}
+
+func (h *handler) resetPipelineFeatureFlag(ctx context.Context, input *ResetPipelineFeatureFlagInput) (*FeatureFlagsResponse, error) {
+	err := h.featureFlags.Reset(ctx, input.ID, input.Name)
+	if err != nil {
+		return nil, featureFlagError(err, input.ID, input.Name)
+	}
+	return h.resolveFeatureFlags(ctx, input.ID)
+}
+
+func (h *handler) resolveFeatureFlags(ctx context.Context, pipelineID string) (*FeatureFlagsResponse, error) {
+	flags, err := h.featureFlags.Resolve(ctx, pipelineID)
+	if err != nil {
+		return nil, featureFlagError(err, pipelineID, "")
+	}
+
+	resp := &FeatureFlagsResponse{}
+	resp.Body.Flags = flags
+	return resp, nil
+}
+
+func featureFlagError(err error, pipelineID, name string) *ErrorDetail {
+	switch {
+	case errors.Is(err, featureflags.ErrUnknownFlag):
+		return &ErrorDetail{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "unknown feature flag",
+			Details: map[string]any{
+				"name": name,
+			},
+		}
+	case errors.Is(err, service.ErrPipelineNotExists):
+		return &ErrorDetail{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "no pipeline with given id found",
+			Details: map[string]any{
+				"pipeline_id": pipelineID,
+			},
+		}
+	default:
+		return &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to update feature flags",
+			Details: map[string]any{
+				"pipeline_id": pipelineID,
+				"error":       err.Error(),
+			},
+		}
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -14,29 +15,73 @@ func GetPipelinesDocs() huma.Operation {
 		OperationID: "get-pipelines",
 		Method:      http.MethodGet,
 		Summary:     "Get all pipelines",
-		Description: "Returns a list of all pipelines",
+		Description: "Returns a list of all pipelines, or of the pipelines carrying all the given tags",
 	}
 }
 
 type GetPipelinesInput struct {
+	Tags []string `query:"tag,explode" doc:"Only list the pipelines carrying the tag. Repeat this parameter to require several tags, for example: ?tag=team:payments&tag=prod"`
 }
 
 type GetPipelinesResponse struct {
 	Body []models.ListPipelineConfig
 }
 
-func (h *handler) getPipelines(ctx context.Context, _ *GetPipelinesInput) (*GetPipelinesResponse, error) {
-	pipelines, err := h.pipelineService.GetPipelines(ctx)
+func (h *handler) getPipelines(ctx context.Context, input *GetPipelinesInput) (*GetPipelinesResponse, error) {
+	pipelines, err := h.pipelineService.GetPipelines(ctx, input.Tags)
 	if err != nil {
-		return nil, &ErrorDetail{
-			Status:  http.StatusInternalServerError,
-			Code:    "internal_error",
-			Message: "Unable to list pipelines",
+		return nil, tagsErrorDetail(err, input.Tags, "Unable to list pipelines")
+	}
+
+	return &GetPipelinesResponse{Body: pipelines}, nil
+}
+
+func GetTagsHealthDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-tags-health",
+		Method:      http.MethodGet,
+		Summary:     "Get the health of the pipelines by tag",
+		Description: "Returns for every tag the number of pipelines carrying it by status and how many of them are unhealthy",
+	}
+}
+
+type GetTagsHealthInput struct {
+	Tags []string `query:"tag,explode" doc:"Only report the tag. Repeat this parameter for several tags"`
+}
+
+type GetTagsHealthResponse struct {
+	Body []models.TagHealth
+}
+
+func (h *handler) getTagsHealth(ctx context.Context, input *GetTagsHealthInput) (*GetTagsHealthResponse, error) {
+	health, err := h.pipelineService.GetTagsHealth(ctx, input.Tags)
+	if err != nil {
+		return nil, tagsErrorDetail(err, input.Tags, "Unable to get the health of the tags")
+	}
+
+	return &GetTagsHealthResponse{Body: health}, nil
+}
+
+func tagsErrorDetail(err error, tags []string, message string) *ErrorDetail {
+	var pErr models.PipelineConfigError
+	if errors.As(err, &pErr) {
+		return &ErrorDetail{
+			Status:  http.StatusBadRequest,
+			Code:    "bad_request",
+			Message: "invalid tag filter",
 			Details: map[string]any{
+				"tags":  tags,
 				"error": err.Error(),
 			},
 		}
 	}
 
-	return &GetPipelinesResponse{Body: pipelines}, nil
+	return &ErrorDetail{
+		Status:  http.StatusInternalServerError,
+		Code:    "internal_error",
+		Message: message,
+		Details: map[string]any{
+			"error": err.Error(),
+		},
+	}
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -31,3 +32,54 @@ func (*handler) healthz(w http.ResponseWriter, _ *http.Request) {
 func (h *handler) healthzV2(_ context.Context, _ *struct{}) (*HealthResponse, error) {
 	return &HealthResponse{Body: HealthStatus{Status: "ok"}}, nil
 }
+
+// HealthChecker reports whether a dependency of the API is usable.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// WithStorageHealthCheck makes the readiness probe check the pipeline
+// storage, the API is not ready while it fails.
+func WithStorageHealthCheck(checker HealthChecker) RouterOption {
+	return func(o *routerOptions) {
+		o.storageHealth = checker
+	}
+}
+
+type ReadinessResponse struct {
+	Status int
+	Body   ReadinessStatus
+}
+
+type ReadinessStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func ReadyzSwaggerDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-readyz",
+		Method:      http.MethodGet,
+		Summary:     "Readiness check endpoint",
+		Description: "Returns 200 OK if the service and its storage are ready to serve requests, 503 otherwise",
+	}
+}
+
+func (h *handler) readyz(ctx context.Context, _ *struct{}) (*ReadinessResponse, error) {
+	resp := &ReadinessResponse{
+		Status: http.StatusOK,
+		Body:   ReadinessStatus{Status: "ok"},
+	}
+	if h.storageHealth == nil {
+		return resp, nil
+	}
+
+	resp.Body.Checks = map[string]string{"storage": "ok"}
+	if err := h.storageHealth.HealthCheck(ctx); err != nil {
+		h.log.WarnContext(ctx, "readyz: storage unhealthy", slog.Any("error", err))
+		resp.Status = http.StatusServiceUnavailable
+		resp.Body.Status = "unavailable"
+		resp.Body.Checks["storage"] = err.Error()
+	}
+	return resp, nil
+}
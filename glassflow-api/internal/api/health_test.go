@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHealthChecker struct {
+	err error
+}
+
+func (f *fakeHealthChecker) HealthCheck(context.Context) error {
+	return f.err
+}
+
+func TestReadyz(t *testing.T) {
+	checker := &fakeHealthChecker{}
+	router := NewRouter(slog.Default(), nil, nil, nil, nil, nil, WithStorageHealthCheck(checker))
+
+	readyz := func() (int, ReadinessStatus) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/readyz", nil))
+		var body ReadinessStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ReadinessStatus{Status: "ok", Checks: map[string]string{"storage": "ok"}}, body)
+
+	checker.err = errors.New("ping postgres: connection refused")
+	code, body = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, "ping postgres: connection refused", body.Checks["storage"])
+}
+
+func TestReadyzWithoutStorageCheck(t *testing.T) {
+	router := NewRouter(slog.Default(), nil, nil, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
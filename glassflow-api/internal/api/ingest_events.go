@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/httpingest"
+)
+
+func IngestEventsDocs() huma.Operation {
+	return huma.Operation{
+		OperationID:   "ingest-pipeline-events",
+		Method:        http.MethodPost,
+		Summary:       "Ingest events",
+		Description:   "Publishes JSON events to a pipeline with an http source. The body is a JSON object, an array of objects or NDJSON with the application/x-ndjson content type",
+		DefaultStatus: http.StatusAccepted,
+		MaxBodyBytes:  internal.HTTPIngestMaxBodyBytes,
+	}
+}
+
+type IngestEventsInput struct {
+	ID          string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	ContentType string `header:"Content-Type"`
+	RawBody     []byte
+}
+
+type IngestEventsResponse struct {
+	Body struct {
+		Accepted int `json:"accepted"`
+	}
+}
+
+func (h *handler) ingestEvents(ctx context.Context, input *IngestEventsInput) (*IngestEventsResponse, error) {
+	if h.eventProcessor == nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "unavailable",
+			Message: "http ingestion is not enabled",
+		}
+	}
+
+	accepted, err := httpingest.Ingest(ctx, h.eventProcessor, input.ID, input.ContentType, input.RawBody)
+	if err != nil {
+		status := httpingest.StatusCode(err)
+		var code string
+		switch status {
+		case http.StatusBadRequest:
+			code = "bad_request"
+		case http.StatusNotFound:
+			code = "not_found"
+		case http.StatusTooManyRequests:
+			code = "too_many_requests"
+		default:
+			code = "internal_error"
+		}
+		return nil, &ErrorDetail{
+			Status:  status,
+			Code:    code,
+			Message: "failed to ingest events",
+			Details: map[string]any{
+				"pipeline_id": input.ID,
+				"error":       err.Error(),
+			},
+		}
+	}
+
+	resp := &IngestEventsResponse{}
+	resp.Body.Accepted = accepted
+	return resp, nil
+}
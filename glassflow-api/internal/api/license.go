@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
+)
+
+// WithLicense serves the edition and the enterprise features of the
+// license.
+func WithLicense(l *license.License) RouterOption {
+	return func(o *routerOptions) {
+		o.license = l
+	}
+}
+
+func GetLicenseDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-license",
+		Method:      http.MethodGet,
+		Summary:     "Get license",
+		Description: "Returns the edition of the installation and the enterprise features its license grants",
+	}
+}
+
+type LicenseResponse struct {
+	Body license.State
+}
+
+func (h *handler) getLicense(_ context.Context, _ *struct{}) (*LicenseResponse, error) {
+	return &LicenseResponse{Body: h.license.State(time.Now())}, nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func ListDLQMessagesDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "list-pipeline-dlq-messages",
+		Method:      http.MethodGet,
+		Summary:     "List DLQ messages for a pipeline",
+		Description: "Returns a page of messages from the Dead Letter Queue for the specified pipeline without consuming them",
+	}
+}
+
+type ListDLQMessagesInput struct {
+	ID     string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Offset int    `query:"offset" minimum:"0" doc:"Offset of the first message, as returned in next_offset; 0 starts at the oldest message (default: 0)"`
+	Limit  int    `query:"limit" minimum:"0" maximum:"1000" doc:"Maximum number of messages to return (default: 100)"`
+}
+
+type ListDLQMessagesResponse struct {
+	Body DLQMessagesPage
+}
+
+type DLQMessagesPage struct {
+	Messages   []DLQMessageInfo `json:"messages" doc:"Messages in the page, oldest first"`
+	Offset     int              `json:"offset" doc:"Offset the page was requested from"`
+	Limit      int              `json:"limit" doc:"Maximum number of messages in the page"`
+	Total      uint64           `json:"total" doc:"Number of messages currently in the DLQ"`
+	NextOffset *uint64          `json:"next_offset,omitempty" doc:"Offset after the last message in the page, absent on the last page"`
+}
+
+type DLQMessageInfo struct {
+	Offset          uint64    `json:"offset" doc:"DLQ stream sequence of the message, stable while messages are consumed"`
+	Component       string    `json:"component" doc:"The component where the error occurred"`
+	Error           string    `json:"error" doc:"The error message"`
+	Reason          string    `json:"reason,omitempty" doc:"The failure reason category"`
+	Timestamp       time.Time `json:"timestamp" doc:"Time the message was written to the DLQ"`
+	OriginalMessage string    `json:"original_message" doc:"The original message that failed processing"`
+}
+
+func (h *handler) listDLQMessages(ctx context.Context, input *ListDLQMessagesInput) (*ListDLQMessagesResponse, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = internal.DLQDefaultPageLimit
+	}
+
+	dlqStream := models.GetDLQStreamName(input.ID)
+	page, err := h.dlqSvc.ListDLQMessages(ctx, dlqStream, input.Offset, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, internal.ErrDLQNotExists):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: fmt.Sprintf("dlq for pipeline_id %q does not exist", input.ID),
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+				},
+			}
+		default:
+			return nil, &ErrorDetail{
+				Status:  http.StatusInternalServerError,
+				Code:    "internal_error",
+				Message: "Listing DLQ messages failed",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		}
+	}
+
+	res := DLQMessagesPage{
+		Messages: make([]DLQMessageInfo, 0, len(page.Entries)),
+		Offset:   input.Offset,
+		Limit:    limit,
+		Total:    page.Total,
+	}
+	for _, entry := range page.Entries {
+		res.Messages = append(res.Messages, DLQMessageInfo{
+			Offset:          entry.Offset,
+			Component:       entry.Message.Component,
+			Error:           entry.Message.Error,
+			Reason:          entry.Message.Reason,
+			Timestamp:       entry.Timestamp,
+			OriginalMessage: entry.Message.OriginalMessage.String(),
+		})
+	}
+
+	if page.NextOffset != 0 {
+		res.NextOffset = &page.NextOffset
+	}
+
+	return &ListDLQMessagesResponse{Body: res}, nil
+}
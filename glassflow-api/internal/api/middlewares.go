@@ -29,6 +29,7 @@ func Recovery(log *slog.Logger) func(http.Handler) http.Handler {
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	status int
+	bytes  int
 }
 
 func newLoggingResponseWriter(w http.ResponseWriter) *loggingResponseWriter {
@@ -40,22 +41,41 @@ func (w *loggingResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
-func RequestLogging(log *slog.Logger) func(http.Handler) http.Handler {
+func (w *loggingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func RequestLogging(log *slog.Logger, cfg AccessLogConfig) func(http.Handler) http.Handler {
+	if cfg.Logger != nil {
+		log = cfg.Logger
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
 			lw := newLoggingResponseWriter(w)
 			defer func() {
+				if !cfg.sampled(r.URL.Path, lw.status) {
+					return
+				}
+
 				logger := log.With(
 					slog.String("component", "glassflow_api"),
+					slog.String("request_id", id),
 					slog.Duration("latency", time.Since(start)),
 					slog.String("remote_ip", r.RemoteAddr),
 					slog.String("host", r.Host),
 					slog.String("method", r.Method),
 					slog.String("path", r.URL.Path),
+					slog.String("route", extractRoute(r)),
 					slog.String("referer", r.Referer()),
 					slog.String("user_agent", r.UserAgent()),
-					slog.Int("status", lw.status))
+					slog.String("api_key_id", apiKeyID(r)),
+					slog.Int("status", lw.status),
+					slog.Int("bytes", lw.bytes))
 
 				var level slog.Level
 				if lw.status >= http.StatusInternalServerError {
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
+)
+
+func MigrateTopicDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "migrate-pipeline-topic",
+		Method:      http.MethodPost,
+		Summary:     "Migrate a pipeline to a renamed Kafka topic",
+		Description: "Maps the committed offsets of the stopped pipeline's consumer group from a Kafka topic to a topic carrying the same records by timestamp, then switches the pipeline to the new topic and restarts it. The migration is at-least-once: records sharing a millisecond with the position the pipeline stopped at are read again. A dry run only returns the mapped offsets.",
+	}
+}
+
+type MigrateTopicInput struct {
+	ID   string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Body struct {
+		From   string `json:"from" minLength:"1" doc:"Kafka topic the pipeline reads"`
+		To     string `json:"to" minLength:"1" doc:"Kafka topic carrying the same records under the new name"`
+		DryRun bool   `json:"dry_run,omitempty" doc:"Return the mapped offsets without committing them or editing the pipeline"`
+	}
+}
+
+type MigrateTopicResponse struct {
+	Body models.TopicMigration
+}
+
+func (h *handler) migrateTopic(ctx context.Context, input *MigrateTopicInput) (*MigrateTopicResponse, error) {
+	before, audited := h.auditedConfig(ctx, input.ID)
+
+	migration, err := h.pipelineService.MigrateTopic(ctx, input.ID, input.Body.From, input.Body.To, input.Body.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPipelineNotExists):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no pipeline with given id found",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrTopicMigrationSource),
+			errors.Is(err, service.ErrTopicMigrationTarget),
+			errors.Is(err, models.ErrNoCommittedPosition):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "pipeline cannot be migrated to the topic",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"from":        input.Body.From,
+					"to":          input.Body.To,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrPipelineNotDrained):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "conflict",
+				Message: "pipeline still has in-flight messages, retry once it is drained",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrConsumerGroupActive):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "conflict",
+				Message: "consumer group of the pipeline still has active members, retry once they left",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		default:
+			if statusErr, ok := status.GetStatusValidationError(err); ok {
+				return nil, &ErrorDetail{
+					Status:  statusErr.HTTPStatus(),
+					Code:    statusErr.Code,
+					Message: statusErr.Message,
+					Details: map[string]any{
+						"pipeline_id":    input.ID,
+						"current_status": string(statusErr.CurrentStatus),
+						"error":          err.Error(),
+					},
+				}
+			}
+			return nil, &ErrorDetail{
+				Status:  http.StatusInternalServerError,
+				Code:    "internal_error",
+				Message: "failed to migrate pipeline topic",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		}
+	}
+
+	if audited && !migration.DryRun {
+		if after, err := h.pipelineService.GetPipeline(ctx, input.ID, nil); err == nil {
+			h.auditChanges(ctx, before, after)
+		}
+	}
+
+	h.log.InfoContext(ctx, "pipeline topic migrated",
+		slog.String("pipeline_id", input.ID),
+		slog.String("from", migration.From),
+		slog.String("to", migration.To),
+		slog.Bool("dry_run", migration.DryRun))
+
+	return &MigrateTopicResponse{Body: migration}, nil
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+func PauseComponentDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "pause-pipeline-component",
+		Method:      http.MethodPost,
+		Summary:     "Pause a pipeline component",
+		Description: "Pauses a single component of the pipeline, the other components keep running and events wait in the pipeline streams",
+	}
+}
+
+func ResumeComponentDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "resume-pipeline-component",
+		Method:      http.MethodPost,
+		Summary:     "Resume a pipeline component",
+		Description: "Resumes a component of the pipeline paused on its own",
+	}
+}
+
+type PipelineComponentInput struct {
+	ID        string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Component string `path:"component" minLength:"1" doc:"Pipeline component, only sink can be paused on its own"`
+}
+
+type PipelineComponentResponse struct {
+	Body struct{} `json:"-"`
+}
+
+func (h *handler) pauseComponent(ctx context.Context, input *PipelineComponentInput) (*PipelineComponentResponse, error) {
+	err := h.pipelineService.PauseComponent(ctx, input.ID, input.Component)
+	if err != nil {
+		return nil, componentErrorDetail(err, input, "failed to pause pipeline component")
+	}
+
+	h.log.InfoContext(ctx, "pipeline component paused",
+		slog.String("pipeline_id", input.ID),
+		slog.String("component", input.Component))
+
+	return &PipelineComponentResponse{}, nil
+}
+
+func (h *handler) resumeComponent(ctx context.Context, input *PipelineComponentInput) (*PipelineComponentResponse, error) {
+	err := h.pipelineService.ResumeComponent(ctx, input.ID, input.Component)
+	if err != nil {
+		return nil, componentErrorDetail(err, input, "failed to resume pipeline component")
+	}
+
+	h.log.InfoContext(ctx, "pipeline component resumed",
+		slog.String("pipeline_id", input.ID),
+		slog.String("component", input.Component))
+
+	return &PipelineComponentResponse{}, nil
+}
+
+func componentErrorDetail(err error, input *PipelineComponentInput, message string) *ErrorDetail {
+	details := map[string]any{
+		"pipeline_id": input.ID,
+		"component":   input.Component,
+		"error":       err.Error(),
+	}
+
+	var pErr models.PipelineConfigError
+	switch {
+	case errors.Is(err, service.ErrPipelineNotExists):
+		return &ErrorDetail{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "no pipeline with given id found",
+			Details: details,
+		}
+	case errors.As(err, &pErr):
+		return &ErrorDetail{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "invalid_component",
+			Message: "component cannot be paused on its own",
+			Details: details,
+		}
+	default:
+		return &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: message,
+			Details: details,
+		}
+	}
+}
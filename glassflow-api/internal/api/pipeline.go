@@ -13,16 +13,23 @@ type PipelineService interface { //nolint:interfacebloat //important interface
 	CreatePipeline(ctx context.Context, cfg *models.PipelineConfig) error
 	DeletePipeline(ctx context.Context, pid string) error
 	TerminatePipeline(ctx context.Context, pid string) error
-	ResumePipeline(ctx context.Context, pid string) error
-	StopPipeline(ctx context.Context, pid string) error
+	ResumePipeline(ctx context.Context, pid string, opts models.ResumeOptions) error
+	StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error
+	ResumePipelines(ctx context.Context, ids []string) ([]models.BulkPipelineResult, error)
+	StopPipelines(ctx context.Context, ids []string) ([]models.BulkPipelineResult, error)
 	EditPipeline(ctx context.Context, pid string, newCfg *models.PipelineConfig) error
 	GetPipeline(ctx context.Context, pid string, sourceSchemaVersions map[string]string) (models.PipelineConfig, error)
-	GetPipelines(ctx context.Context) ([]models.ListPipelineConfig, error)
+	GetPipelines(ctx context.Context, tags []string) ([]models.ListPipelineConfig, error)
+	GetTagsHealth(ctx context.Context, tags []string) ([]models.TagHealth, error)
 	UpdatePipelineName(ctx context.Context, id string, name string) error
 	UpdatePipelineMetadata(ctx context.Context, id string, metadata models.PipelineMetadata) error
+	ConfirmStaging(ctx context.Context, pid string) error
+	MigrateTopic(ctx context.Context, pid, from, to string, dryRun bool) (models.TopicMigration, error)
+	PauseComponent(ctx context.Context, pid, component string) error
+	ResumeComponent(ctx context.Context, pid, component string) error
 	GetPipelineHealth(ctx context.Context, pid string) (models.PipelineHealth, error)
 	GetOrchestratorType() string
-	CleanUpPipelines(ctx context.Context) error
+	ReconcilePipelines(ctx context.Context) (models.ReconciliationSummary, error)
 	GetPipelineResources(ctx context.Context, pid string) (models.PipelineResourcesWithPolicy, error)
 	UpdatePipelineResources(ctx context.Context, pid string, resources models.PipelineResources) (models.PipelineResourcesWithPolicy, error)
 	GetPipelineResourcesValidation(ctx context.Context, pid string) ([]string, error)
@@ -198,26 +205,54 @@ type tableMappingEntryV2 struct {
 // pipelineJSON is the API-layer representation of a pipeline.
 // Wire format is JSON.
 type pipelineJSON struct {
-	Version    string                  `json:"version"`
-	PipelineID string                  `json:"pipeline_id"`
-	Name       string                  `json:"name"`
-	Sources    []source                `json:"sources"`
-	Transforms []pipelineTransform     `json:"transforms,omitempty"`
-	Join       *join                   `json:"join,omitempty"`
-	Sink       sink                    `json:"sink"`
-	Metadata   models.PipelineMetadata `json:"metadata,omitempty"`
-	Resources  resources               `json:"resources,omitempty"`
+	Version    string              `json:"version"`
+	PipelineID string              `json:"pipeline_id"`
+	Name       string              `json:"name"`
+	Sources    []source            `json:"sources"`
+	Transforms []pipelineTransform `json:"transforms,omitempty"`
+	// Query defines the filter, the stateless transformation and the sink
+	// mapping as one SQL-like statement, expanded before conversion
+	Query     string                  `json:"query,omitempty"`
+	Join      *join                   `json:"join,omitempty"`
+	Sink      sink                    `json:"sink"`
+	Metadata  models.PipelineMetadata `json:"metadata,omitempty"`
+	Resources resources               `json:"resources,omitempty"`
 }
 
 type source struct {
 	Type                       string                       `json:"type"`
 	SourceID                   string                       `json:"source_id"`
 	ConnectionParams           *kafkaConnectionParams       `json:"connection_params,omitempty"`
+	PulsarConnectionParams     *pulsarConnectionParams      `json:"pulsar_connection_params,omitempty"`
+	MySQLConnectionParams      *mysqlConnectionParams       `json:"mysql_connection_params,omitempty"`
 	Topic                      string                       `json:"topic,omitempty"`
+	Database                   string                       `json:"database,omitempty"`
+	Table                      string                       `json:"table,omitempty"`
+	Subscription               string                       `json:"subscription,omitempty"`
+	SubscriptionType           string                       `json:"subscription_type,omitempty"`
 	SchemaVersion              string                       `json:"schema_version,omitempty"`
 	SchemaRegistry             *models.SchemaRegistryConfig `json:"schema_registry,omitempty"`
 	SchemaFields               []models.Field               `json:"schema_fields,omitempty"`
 	ConsumerGroupInitialOffset string                       `json:"consumer_group_initial_offset,omitempty"`
+	PartitionAssignment        string                       `json:"partition_assignment,omitempty"`
+	RebalanceStrategy          string                       `json:"rebalance_strategy,omitempty"`
+	StaticMembership           bool                         `json:"static_membership,omitempty"`
+	DecodeErrorPolicy          string                       `json:"decode_error_policy,omitempty"`
+	PublishDedup               *publishDedup                `json:"publish_dedup,omitempty"`
+	FlowControl                *flowControl                 `json:"flow_control,omitempty"`
+}
+
+// flowControl pauses the polling of a Kafka source while its NATS streams
+// or their consumers are over the thresholds.
+type flowControl struct {
+	MaxStreamBytes     int64 `json:"max_stream_bytes,omitempty"`
+	MaxConsumerPending int64 `json:"max_consumer_pending,omitempty"`
+}
+
+// publishDedup tunes the JetStream duplicate detection of an ingestor source.
+type publishDedup struct {
+	DuplicateWindow models.JSONDuration `json:"duplicate_window,omitzero"`
+	MsgIDStrategy   string              `json:"msg_id_strategy,omitempty"`
 }
 
 type kafkaConnectionParams struct {
@@ -237,6 +272,25 @@ type kafkaConnectionParams struct {
 	KerberosConfig      string   `json:"kerberos_config,omitempty"`
 }
 
+type pulsarConnectionParams struct {
+	ServiceURL                 string `json:"service_url"`
+	AuthToken                  string `json:"auth_token,omitempty"`
+	TLSAllowInsecureConnection bool   `json:"tls_allow_insecure_connection,omitempty"`
+	TLSRoot                    string `json:"root_ca,omitempty"`
+	TLSCert                    string `json:"client_cert,omitempty"`
+	TLSKey                     string `json:"client_key,omitempty"`
+}
+
+type mysqlConnectionParams struct {
+	Host                string `json:"host"`
+	Port                int    `json:"port,omitempty"`
+	Username            string `json:"username"`
+	Password            string `json:"password,omitempty"`
+	ServerID            uint32 `json:"server_id,omitempty"`
+	TLSRoot             string `json:"root_ca,omitempty"`
+	SkipTLSVerification bool   `json:"skip_tls_verification,omitempty"`
+}
+
 type pipelineTransform struct {
 	Type     string          `json:"type"`
 	SourceID string          `json:"source_id"`
@@ -244,9 +298,12 @@ type pipelineTransform struct {
 }
 
 const (
-	transformTypeDedup     = "dedup"
-	transformTypeFilter    = "filter"
-	transformTypeStateless = "stateless"
+	transformTypeDedup          = "dedup"
+	transformTypeFilter         = "filter"
+	transformTypeStateless      = "stateless"
+	transformTypeDebeziumUnwrap = "debezium_unwrap"
+	transformTypeEnrichment     = "enrichment"
+	transformTypeExplode        = "explode"
 )
 
 // transformParams is a flat union of all transform config fields.
@@ -256,6 +313,41 @@ type transformParams struct {
 	TimeWindow models.JSONDuration `json:"time_window,omitempty"`
 	Expression string              `json:"expression,omitempty"`
 	Transforms []models.Transform  `json:"transforms,omitempty"`
+
+	// Stateless settings, a udf replaces the expressions of the transforms
+	UDF *models.UDFConfig `json:"udf,omitempty"`
+	// Salt keys the tokenize function of the stateless expressions
+	Salt string `json:"salt,omitempty"`
+
+	// Dedup settings, mode is keep_first or keep_last which adds the version
+	// of the events to version_field. Enrichment shares the mode field.
+	// Domain shares the window with the pipelines of the same domain.
+	VersionField string `json:"version_field,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+
+	// Debezium unwrap settings
+	OpField        string `json:"op_field,omitempty"`
+	TimestampField string `json:"ts_field,omitempty"`
+	DeleteHandling string `json:"delete_handling,omitempty"`
+
+	// Explode settings, field is the array emitted one element per event
+	// under output_name, fields declares the fields of object elements
+	Field       string         `json:"field,omitempty"`
+	OutputName  string         `json:"output_name,omitempty"`
+	Fields      []models.Field `json:"fields,omitempty"`
+	ElementType string         `json:"element_type,omitempty"`
+	IndexField  string         `json:"index_field,omitempty"`
+
+	// Enrichment settings, key is the event field matched against the
+	// key_column of the ClickHouse table
+	Database        string                    `json:"database,omitempty"`
+	Table           string                    `json:"table,omitempty"`
+	KeyColumn       string                    `json:"key_column,omitempty"`
+	Columns         []models.EnrichmentColumn `json:"columns,omitempty"`
+	Mode            string                    `json:"mode,omitempty"`
+	RefreshInterval models.JSONDuration       `json:"refresh_interval,omitzero"`
+	CacheTTL        models.JSONDuration       `json:"cache_ttl,omitzero"`
+	CacheMaxEntries int                       `json:"cache_max_entries,omitempty"`
 }
 
 type join struct {
@@ -264,12 +356,63 @@ type join struct {
 	LeftSource   joinSource        `json:"left_source"`
 	RightSource  joinSource        `json:"right_source"`
 	OutputFields []joinOutputField `json:"output_fields,omitempty"`
+	RightCache   *joinCache        `json:"right_cache,omitempty"`
+	Tiering      *joinTiering      `json:"tiering,omitempty"`
+	Unmatched    *joinUnmatched    `json:"unmatched,omitempty"`
+	Ordering     *joinOrdering     `json:"ordering,omitempty"`
+	RightMatch   string            `json:"right_match,omitempty"`
+	// JoinType is inner, left, right or full_outer, the unmatched events of
+	// the outer sides are emitted grace after their window
+	JoinType string              `json:"join_type,omitempty"`
+	Grace    models.JSONDuration `json:"grace,omitzero"`
+	// Chain joins more sources in order to the output of the join so far
+	Chain []joinChainSource `json:"chain,omitempty"`
+}
+
+// joinChainSource joins one more source, its key matching the field
+// left_key of left_source_id, a source joined before it.
+type joinChainSource struct {
+	SourceID     string              `json:"source_id"`
+	Key          string              `json:"key"`
+	TimeWindow   models.JSONDuration `json:"time_window"`
+	LeftSourceID string              `json:"left_source_id"`
+	LeftKey      string              `json:"left_key"`
 }
 
 type joinSource struct {
 	SourceID   string              `json:"source_id"`
 	Key        string              `json:"key"`
 	TimeWindow models.JSONDuration `json:"time_window"`
+	TimeField  string              `json:"time_field,omitempty"`
+}
+
+type joinCache struct {
+	Enabled  bool  `json:"enabled"`
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// joinTiering keeps the events of the hot window in memory and compacts the
+// older ones into one KV entry per join key.
+type joinTiering struct {
+	Enabled      bool                `json:"enabled"`
+	HotWindow    models.JSONDuration `json:"hot_window,omitzero"`
+	HotMaxBytes  int64               `json:"hot_max_bytes,omitempty"`
+	CompactAfter models.JSONDuration `json:"compact_after,omitzero"`
+}
+
+// joinOrdering processes the join events in event-time order, held back for
+// up to max_skew.
+type joinOrdering struct {
+	Enabled bool                `json:"enabled"`
+	MaxSkew models.JSONDuration `json:"max_skew,omitzero"`
+}
+
+// joinUnmatched routes the left events without a match in the window, the
+// table policy maps fields of the left source to columns of table.
+type joinUnmatched struct {
+	Policy  string             `json:"policy"`
+	Table   string             `json:"table,omitempty"`
+	Mapping []sinkMappingEntry `json:"mapping,omitempty"`
 }
 
 type joinOutputField struct {
@@ -279,12 +422,24 @@ type joinOutputField struct {
 }
 
 type sink struct {
-	Type             string                     `json:"type"`
-	ConnectionParams clickhouseConnectionParams `json:"connection_params"`
-	Table            string                     `json:"table"`
-	MaxBatchSize     int                        `json:"max_batch_size"`
-	MaxDelayTime     models.JSONDuration        `json:"max_delay_time"`
-	Mapping          []sinkMappingEntry         `json:"mapping,omitempty"`
+	Type                string                     `json:"type"`
+	ConnectionParams    clickhouseConnectionParams `json:"connection_params"`
+	Table               string                     `json:"table"`
+	MaxBatchSize        int                        `json:"max_batch_size"`
+	MaxDelayTime        models.JSONDuration        `json:"max_delay_time"`
+	Mapping             []sinkMappingEntry         `json:"mapping,omitempty"`
+	AutoCreateTable     bool                       `json:"auto_create_table,omitempty"`
+	TableEngine         string                     `json:"table_engine,omitempty"`
+	TableOrderBy        string                     `json:"table_order_by,omitempty"`
+	ErrorTable          string                     `json:"error_table,omitempty"`
+	InsertDeduplication bool                       `json:"insert_deduplication,omitempty"`
+	Staging             bool                       `json:"staging,omitempty"`
+	StagingTableSuffix  string                     `json:"staging_table_suffix,omitempty"`
+	StagingDuration     models.JSONDuration        `json:"staging_duration,omitzero"`
+	AsyncInsert         bool                       `json:"async_insert,omitempty"`
+	WaitForAsyncInsert  *bool                      `json:"wait_for_async_insert,omitempty"`
+	WriterConcurrency   int                        `json:"writer_concurrency,omitempty"`
+	WarmUp              models.JSONDuration        `json:"warm_up,omitzero"`
 }
 
 type clickhouseConnectionParams struct {
@@ -296,12 +451,18 @@ type clickhouseConnectionParams struct {
 	Password                    string `json:"password"`
 	Secure                      bool   `json:"secure"`
 	SkipCertificateVerification bool   `json:"skip_certificate_verification,omitempty"`
+	VaultRole                   string `json:"vault_role,omitempty"`
+	TLSRoot                     string `json:"root_ca,omitempty"`
+	TLSCert                     string `json:"client_cert,omitempty"`
+	TLSKey                      string `json:"client_key,omitempty"`
 }
 
 type sinkMappingEntry struct {
 	Name       string `json:"name"`
 	ColumnName string `json:"column_name"`
-	ColumnType string `json:"column_type"`
+	// ColumnType may be omitted when the sink table exists, it is then
+	// learned from ClickHouse on create and edit.
+	ColumnType string `json:"column_type,omitempty"`
 }
 
 type resources struct {
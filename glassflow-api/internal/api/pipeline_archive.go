@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// PipelineArchive archives stopped pipelines and restores them.
+type PipelineArchive interface {
+	Archive(ctx context.Context, pid string) error
+	Restore(ctx context.Context, pid string) error
+	List(ctx context.Context) ([]models.ArchivedPipeline, error)
+}
+
+// WithPipelineArchive serves the pipeline archive.
+func WithPipelineArchive(archive PipelineArchive) RouterOption {
+	return func(o *routerOptions) {
+		o.pipelineArchive = archive
+	}
+}
+
+func ListArchivedPipelinesDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "list-archived-pipelines",
+		Method:      http.MethodGet,
+		Summary:     "List archived pipelines",
+		Description: "Returns the pipelines archived after being stopped, the last archived first",
+	}
+}
+
+type ListArchivedPipelinesResponse struct {
+	Body struct {
+		Pipelines []models.ArchivedPipeline `json:"pipelines" doc:"Archived pipelines"`
+	}
+}
+
+func (h *handler) listArchivedPipelines(ctx context.Context, _ *struct{}) (*ListArchivedPipelinesResponse, error) {
+	pipelines, err := h.pipelineArchive.List(ctx)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to list archived pipelines",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	resp := &ListArchivedPipelinesResponse{}
+	resp.Body.Pipelines = pipelines
+	return resp, nil
+}
+
+func ArchivePipelineDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "archive-pipeline",
+		Method:      http.MethodPost,
+		Summary:     "Archive a pipeline",
+		Description: "Stores the definition of a stopped pipeline in the archive and deletes the pipeline with its NATS resources. Pipelines other pipelines depend on cannot be archived.",
+	}
+}
+
+type ArchivePipelineInput struct {
+	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+}
+
+type ArchivePipelineResponse struct {
+	Body struct{} `json:"-"`
+}
+
+func (h *handler) archivePipeline(ctx context.Context, input *ArchivePipelineInput) (*ArchivePipelineResponse, error) {
+	err := h.pipelineArchive.Archive(ctx, input.ID)
+	if err != nil {
+		details := map[string]any{
+			"pipeline_id": input.ID,
+			"error":       err.Error(),
+		}
+		switch {
+		case errors.Is(err, service.ErrPipelineNotExists):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: fmt.Sprintf("pipeline with id %q does not exist", input.ID),
+				Details: details,
+			}
+		case errors.Is(err, service.ErrPipelineNotStopped):
+			return nil, &ErrorDetail{
+				Status:  http.StatusBadRequest,
+				Code:    "bad_request",
+				Message: "pipeline can only be archived if it's stopped",
+				Details: details,
+			}
+		case errors.Is(err, service.ErrPipelineHasDependents):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "has_dependents",
+				Message: "pipeline cannot be archived while other pipelines depend on it",
+				Details: details,
+			}
+		case errors.Is(err, service.ErrPipelineNotDrained):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "not_drained",
+				Message: "pipeline components still hold in-flight messages",
+				Details: details,
+			}
+		default:
+			return nil, &ErrorDetail{
+				Status:  http.StatusInternalServerError,
+				Code:    "internal_error",
+				Message: "failed to archive pipeline",
+				Details: details,
+			}
+		}
+	}
+
+	if h.componentReports != nil {
+		err = h.componentReports.Purge(ctx, input.ID)
+		if err != nil {
+			h.log.WarnContext(ctx, "failed to purge component reports", "pipeline_id", input.ID, "error", err)
+		}
+	}
+
+	h.log.InfoContext(ctx, "pipeline archived", "pipeline_id", input.ID)
+
+	return &ArchivePipelineResponse{}, nil
+}
+
+func RestorePipelineDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "restore-pipeline",
+		Method:      http.MethodPost,
+		Summary:     "Restore an archived pipeline",
+		Description: "Creates an archived pipeline again from its definition and removes it from the archive. The restored pipeline starts running.",
+	}
+}
+
+type RestorePipelineInput struct {
+	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+}
+
+type RestorePipelineResponse struct {
+	Body struct{} `json:"-"`
+}
+
+func (h *handler) restorePipeline(ctx context.Context, input *RestorePipelineInput) (*RestorePipelineResponse, error) {
+	err := h.pipelineArchive.Restore(ctx, input.ID)
+	if err != nil {
+		details := map[string]any{
+			"pipeline_id": input.ID,
+			"error":       err.Error(),
+		}
+		var pErr models.PipelineConfigError
+		switch {
+		case errors.Is(err, service.ErrPipelineNotArchived):
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: fmt.Sprintf("no archived pipeline with id %q", input.ID),
+				Details: details,
+			}
+		case errors.Is(err, service.ErrPipelineForbidden):
+			return nil, pipelineForbidden(input.ID)
+		case errors.Is(err, service.ErrIDExists):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "conflict",
+				Message: "a pipeline with the archived pipeline's id already exists",
+				Details: details,
+			}
+		case errors.As(err, &pErr),
+			errors.Is(err, service.ErrInvalidDependencies),
+			errors.Is(err, service.ErrDependencyNotRunning),
+			errors.Is(err, service.ErrFeatureDisabled),
+			errors.Is(err, service.ErrFeatureNotLicensed),
+			errors.Is(err, service.ErrOwnershipRequired):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "archived pipeline cannot be created again",
+				Details: details,
+			}
+		default:
+			return nil, &ErrorDetail{
+				Status:  http.StatusInternalServerError,
+				Code:    "internal_error",
+				Message: "failed to restore pipeline",
+				Details: details,
+			}
+		}
+	}
+
+	h.log.InfoContext(ctx, "pipeline restored", "pipeline_id", input.ID)
+
+	return &RestorePipelineResponse{}, nil
+}
@@ -27,8 +27,10 @@ func toJSON(p models.PipelineConfig) pipelineJSON {
 
 func buildSources(p models.PipelineConfig) []source {
 	switch {
-	case p.SourceType.IsKafka():
+	case p.SourceType.UsesIngestor():
 		conn := kafkaConnectionParamsFromModel(p.Ingestor.KafkaConnectionParams)
+		pulsarConn := pulsarConnectionParamsFromModel(p.Ingestor.PulsarConnectionParams)
+		mysqlConn := mysqlConnectionParamsFromModel(p.Ingestor.MySQLConnectionParams)
 		sources := make([]source, 0, len(p.Ingestor.KafkaTopics))
 		for _, t := range p.Ingestor.KafkaTopics {
 			sourceID := t.ID
@@ -38,9 +40,37 @@ func buildSources(p models.PipelineConfig) []source {
 			src := source{
 				Type:                       string(p.SourceType),
 				SourceID:                   sourceID,
-				ConnectionParams:           &conn,
 				Topic:                      t.Name,
 				ConsumerGroupInitialOffset: t.ConsumerGroupInitialOffset,
+				DecodeErrorPolicy:          t.DecodeErrorPolicy,
+			}
+			// The offset strategy is the default, only overrides are returned
+			if t.PublishDedup.DuplicateWindow.Duration() > 0 || t.PublishDedup.MsgIDStrategy == internal.MsgIDStrategyContentHash {
+				src.PublishDedup = &publishDedup{
+					DuplicateWindow: t.PublishDedup.DuplicateWindow,
+					MsgIDStrategy:   t.PublishDedup.MsgIDStrategy,
+				}
+			}
+			if t.FlowControl.Enabled() {
+				src.FlowControl = &flowControl{
+					MaxStreamBytes:     t.FlowControl.MaxStreamBytes,
+					MaxConsumerPending: t.FlowControl.MaxConsumerPending,
+				}
+			}
+			switch {
+			case p.SourceType.IsPulsar():
+				src.PulsarConnectionParams = &pulsarConn
+				src.Subscription = t.ConsumerGroupName
+				src.SubscriptionType = t.SubscriptionType
+			case p.SourceType.IsMySQL():
+				src.MySQLConnectionParams = &mysqlConn
+				src.Topic = ""
+				src.Database, src.Table, _ = strings.Cut(t.Name, ".")
+			default:
+				src.ConnectionParams = &conn
+				src.PartitionAssignment = t.PartitionAssignment
+				src.RebalanceStrategy = t.RebalanceStrategy
+				src.StaticMembership = t.StaticMembership
 			}
 			if t.SchemaRegistryConfig != (models.SchemaRegistryConfig{}) {
 				sr := t.SchemaRegistryConfig
@@ -55,6 +85,13 @@ func buildSources(p models.PipelineConfig) []source {
 		return sources
 	case p.SourceType.IsOTLP():
 		return []source{{Type: string(p.SourceType), SourceID: p.OTLPSource.ID}}
+	case p.SourceType.IsHTTP():
+		src := source{Type: string(p.SourceType), SourceID: p.OTLPSource.ID}
+		if sv, ok := p.SchemaVersions[p.OTLPSource.ID]; ok {
+			src.SchemaVersion = sv.VersionID
+			src.SchemaFields = sv.Fields
+		}
+		return []source{src}
 	default:
 		return nil
 	}
@@ -63,7 +100,7 @@ func buildSources(p models.PipelineConfig) []source {
 func buildTransforms(p models.PipelineConfig) []pipelineTransform {
 	var transformations []pipelineTransform
 
-	// Dedup transforms: one per Kafka topic that has it enabled, plus OTLP.
+	// Dedup transforms: one per Kafka topic that has it enabled, plus OTLP and HTTP.
 	for _, t := range p.Ingestor.KafkaTopics {
 		if !t.Deduplication.Enabled {
 			continue
@@ -72,16 +109,40 @@ func buildTransforms(p models.PipelineConfig) []pipelineTransform {
 		if sourceID == "" {
 			sourceID = t.Name
 		}
+		params := transformParams{
+			Key:        t.Deduplication.ID,
+			TimeWindow: t.Deduplication.Window,
+			Domain:     t.Deduplication.Domain,
+		}
+		if t.Deduplication.KeepsLast() {
+			params.Mode = t.Deduplication.Mode
+			params.VersionField = t.Deduplication.VersionField
+		}
 		transformations = append(transformations, pipelineTransform{
 			Type:     transformTypeDedup,
 			SourceID: sourceID,
+			Config:   params,
+		})
+	}
+	for _, t := range p.Ingestor.KafkaTopics {
+		if !t.DebeziumUnwrap.Enabled {
+			continue
+		}
+		sourceID := t.ID
+		if sourceID == "" {
+			sourceID = t.Name
+		}
+		transformations = append(transformations, pipelineTransform{
+			Type:     transformTypeDebeziumUnwrap,
+			SourceID: sourceID,
 			Config: transformParams{
-				Key:        t.Deduplication.ID,
-				TimeWindow: t.Deduplication.Window,
+				OpField:        t.DebeziumUnwrap.OpField,
+				TimestampField: t.DebeziumUnwrap.TimestampField,
+				DeleteHandling: t.DebeziumUnwrap.DeleteHandling,
 			},
 		})
 	}
-	if p.SourceType.IsOTLP() && p.OTLPSource.Deduplication.Enabled {
+	if p.SourceType.UsesReceiver() && p.OTLPSource.Deduplication.Enabled {
 		transformations = append(transformations, pipelineTransform{
 			Type:     transformTypeDedup,
 			SourceID: p.OTLPSource.ID,
@@ -109,6 +170,40 @@ func buildTransforms(p models.PipelineConfig) []pipelineTransform {
 			SourceID: p.StatelessTransformation.SourceID,
 			Config: transformParams{
 				Transforms: p.StatelessTransformation.Config.Transform,
+				UDF:        p.StatelessTransformation.Config.UDF,
+				Salt:       p.StatelessTransformation.Config.Salt,
+			},
+		})
+	}
+
+	if p.Explode.Enabled {
+		transformations = append(transformations, pipelineTransform{
+			Type:     transformTypeExplode,
+			SourceID: p.Explode.SourceID,
+			Config: transformParams{
+				Field:       p.Explode.Field,
+				OutputName:  p.Explode.OutputName,
+				Fields:      p.Explode.Fields,
+				ElementType: p.Explode.ElementType,
+				IndexField:  p.Explode.IndexField,
+			},
+		})
+	}
+
+	if p.Enrichment.Enabled {
+		transformations = append(transformations, pipelineTransform{
+			Type:     transformTypeEnrichment,
+			SourceID: p.Enrichment.SourceID,
+			Config: transformParams{
+				Key:             p.Enrichment.KeyField,
+				Database:        p.Enrichment.Database,
+				Table:           p.Enrichment.Table,
+				KeyColumn:       p.Enrichment.KeyColumn,
+				Columns:         p.Enrichment.Columns,
+				Mode:            p.Enrichment.Mode,
+				RefreshInterval: p.Enrichment.RefreshInterval,
+				CacheTTL:        p.Enrichment.CacheTTL,
+				CacheMaxEntries: p.Enrichment.CacheMaxEntries,
 			},
 		})
 	}
@@ -127,7 +222,7 @@ func buildJoin(p models.PipelineConfig) *join {
 		j.Type = internal.TemporalJoinType
 	}
 	for _, s := range p.Join.Sources {
-		js := joinSource{SourceID: s.SourceID, Key: s.JoinKey, TimeWindow: s.Window}
+		js := joinSource{SourceID: s.SourceID, Key: s.JoinKey, TimeWindow: s.Window, TimeField: s.TimeField}
 		switch strings.ToLower(s.Orientation) {
 		case internal.JoinLeft:
 			j.LeftSource = js
@@ -142,6 +237,55 @@ func buildJoin(p models.PipelineConfig) *join {
 			OutputName: r.OutputName,
 		})
 	}
+	if p.Join.RightCache.Enabled {
+		j.RightCache = &joinCache{
+			Enabled:  true,
+			MaxBytes: p.Join.RightCache.MaxBytes,
+		}
+	}
+	if p.Join.Tiering.Enabled {
+		j.Tiering = &joinTiering{
+			Enabled:      true,
+			HotWindow:    p.Join.Tiering.HotWindow,
+			HotMaxBytes:  p.Join.Tiering.HotMaxBytes,
+			CompactAfter: p.Join.Tiering.CompactAfter,
+		}
+	}
+	if p.Join.Unmatched.Enabled() {
+		j.Unmatched = &joinUnmatched{
+			Policy: p.Join.Unmatched.Policy,
+			Table:  p.Join.Unmatched.Table,
+		}
+		for _, m := range p.Join.Unmatched.Mapping {
+			j.Unmatched.Mapping = append(j.Unmatched.Mapping, sinkMappingEntry{
+				Name:       m.SourceField,
+				ColumnName: m.DestinationField,
+				ColumnType: m.DestinationType,
+			})
+		}
+	}
+	if p.Join.Ordering.Enabled {
+		j.Ordering = &joinOrdering{
+			Enabled: true,
+			MaxSkew: p.Join.Ordering.MaxSkew,
+		}
+	}
+	if p.Join.RightMatchAll() {
+		j.RightMatch = internal.JoinRightMatchAll
+	}
+	if p.Join.OuterLeft() || p.Join.OuterRight() {
+		j.JoinType = p.Join.JoinType
+		j.Grace = p.Join.Grace
+	}
+	for _, c := range p.Join.Chain {
+		j.Chain = append(j.Chain, joinChainSource{
+			SourceID:     c.SourceID,
+			Key:          c.JoinKey,
+			TimeWindow:   c.Window,
+			LeftSourceID: c.LeftSourceID,
+			LeftKey:      c.LeftJoinKey,
+		})
+	}
 	return j
 }
 
@@ -154,7 +298,7 @@ func buildSink(p models.PipelineConfig) sink {
 			ColumnType: m.DestinationType,
 		})
 	}
-	return sink{
+	out := sink{
 		Type: internal.ClickHouseSinkType,
 		ConnectionParams: clickhouseConnectionParams{
 			Host:                        p.Sink.ClickHouseConnectionParams.Host,
@@ -165,12 +309,36 @@ func buildSink(p models.PipelineConfig) sink {
 			Password:                    p.Sink.ClickHouseConnectionParams.Password,
 			Secure:                      p.Sink.ClickHouseConnectionParams.Secure,
 			SkipCertificateVerification: p.Sink.ClickHouseConnectionParams.SkipCertificateCheck,
+			VaultRole:                   p.Sink.ClickHouseConnectionParams.VaultRole,
+			TLSRoot:                     p.Sink.ClickHouseConnectionParams.TLSRoot,
+			TLSCert:                     p.Sink.ClickHouseConnectionParams.TLSCert,
+			TLSKey:                      p.Sink.ClickHouseConnectionParams.TLSKey,
 		},
-		Table:        p.Sink.ClickHouseConnectionParams.Table,
-		MaxBatchSize: p.Sink.Batch.MaxBatchSize,
-		MaxDelayTime: p.Sink.Batch.MaxDelayTime,
-		Mapping:      mapping,
+		Table:               p.Sink.ClickHouseConnectionParams.Table,
+		MaxBatchSize:        p.Sink.Batch.MaxBatchSize,
+		MaxDelayTime:        p.Sink.Batch.MaxDelayTime,
+		Mapping:             mapping,
+		ErrorTable:          p.Sink.ErrorTable,
+		InsertDeduplication: p.Sink.InsertDeduplication,
+		WriterConcurrency:   p.Sink.WriterConcurrency,
+		WarmUp:              p.Sink.Batch.WarmUp,
 	}
+	if p.Sink.CreateTable != nil {
+		out.AutoCreateTable = true
+		out.TableEngine = p.Sink.CreateTable.Engine
+		out.TableOrderBy = p.Sink.CreateTable.OrderBy
+	}
+	if p.Sink.Staging != nil {
+		out.Staging = true
+		out.StagingTableSuffix = p.Sink.Staging.TableSuffix
+		out.StagingDuration = p.Sink.Staging.Duration
+	}
+	if p.Sink.AsyncInsert != nil {
+		out.AsyncInsert = true
+		wait := p.Sink.AsyncInsert.Wait
+		out.WaitForAsyncInsert = &wait
+	}
+	return out
 }
 
 func buildResources(p models.PipelineConfig) resources {
@@ -208,7 +376,7 @@ func buildResources(p models.PipelineConfig) resources {
 // transformResourceSourceIDs returns the source_ids that should carry a
 // resources.transform[] entry when converting model -> v3. Dedup sources come
 // first (one per topic with dedup enabled, or the OTLP source), followed by
-// the stateless transform source if distinct.
+// the stateless transform and enrichment sources if distinct.
 func transformResourceSourceIDs(p models.PipelineConfig) []string {
 	seen := make(map[string]struct{})
 	var ids []string
@@ -233,12 +401,18 @@ func transformResourceSourceIDs(p models.PipelineConfig) []string {
 		}
 		add(sourceID)
 	}
-	if p.SourceType.IsOTLP() && p.OTLPSource.Deduplication.Enabled {
+	if p.SourceType.UsesReceiver() && p.OTLPSource.Deduplication.Enabled {
 		add(p.OTLPSource.ID)
 	}
 	if p.StatelessTransformation.Enabled {
 		add(p.StatelessTransformation.SourceID)
 	}
+	if p.Explode.Enabled {
+		add(p.Explode.SourceID)
+	}
+	if p.Enrichment.Enabled {
+		add(p.Enrichment.SourceID)
+	}
 	return ids
 }
 
@@ -270,8 +444,31 @@ func kafkaConnectionParamsFromModel(conn models.KafkaConnectionParamsConfig) kaf
 	}
 }
 
+func pulsarConnectionParamsFromModel(conn models.PulsarConnectionParamsConfig) pulsarConnectionParams {
+	return pulsarConnectionParams{
+		ServiceURL:                 conn.ServiceURL,
+		AuthToken:                  conn.AuthToken,
+		TLSAllowInsecureConnection: conn.TLSAllowInsecureConnection,
+		TLSRoot:                    conn.TLSRoot,
+		TLSCert:                    conn.TLSCert,
+		TLSKey:                     conn.TLSKey,
+	}
+}
+
+func mysqlConnectionParamsFromModel(conn models.MySQLConnectionParamsConfig) mysqlConnectionParams {
+	return mysqlConnectionParams{
+		Host:                conn.Host,
+		Port:                conn.Port,
+		Username:            conn.Username,
+		Password:            conn.Password,
+		ServerID:            conn.ServerID,
+		TLSRoot:             conn.TLSRoot,
+		SkipTLSVerification: conn.SkipTLSVerification,
+	}
+}
+
 func firstSourceID(p models.PipelineConfig) string {
-	if p.SourceType.IsOTLP() {
+	if p.SourceType.UsesReceiver() {
 		return p.OTLPSource.ID
 	}
 	if len(p.Ingestor.KafkaTopics) > 0 {
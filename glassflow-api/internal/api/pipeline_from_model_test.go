@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
@@ -93,6 +94,59 @@ func TestRoundTrip_JSONToModelToJSON_Join(t *testing.T) {
 	}
 }
 
+func TestRoundTrip_JSONToModelToJSON_JoinRightCache(t *testing.T) {
+	cfg := mustParseJSON(t, kafkaJoinJSON)
+	cfg.Join.RightCache = &joinCache{Enabled: true}
+
+	m, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !m.Join.RightCache.Enabled || m.Join.RightCache.MaxBytes != internal.DefaultJoinCacheMaxBytes {
+		t.Errorf("Join.RightCache = %+v; want enabled with the default size", m.Join.RightCache)
+	}
+
+	pipeline := toJSON(m)
+	if pipeline.Join.RightCache == nil || pipeline.Join.RightCache.MaxBytes != internal.DefaultJoinCacheMaxBytes {
+		t.Errorf("v3.Join.RightCache = %+v; want the default size", pipeline.Join.RightCache)
+	}
+}
+
+func TestRoundTrip_JSONToModelToJSON_JoinUnmatched(t *testing.T) {
+	cfg := mustParseJSON(t, kafkaJoinJSON)
+	cfg.Join.Unmatched = &joinUnmatched{
+		Policy: internal.JoinUnmatchedPolicyTable,
+		Table:  "order_misses",
+		Mapping: []sinkMappingEntry{
+			{Name: "order_id", ColumnName: "order_id", ColumnType: "String"},
+		},
+	}
+
+	m, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if len(m.Join.Unmatched.Mapping) != 1 || m.Join.Unmatched.Mapping[0].SourceType != "string" {
+		t.Errorf("Join.Unmatched.Mapping = %+v; want order_id resolved from the left source", m.Join.Unmatched.Mapping)
+	}
+	if m.Join.LeftBufferTTL.Duration() != 30*time.Second+internal.JoinUnmatchedGrace {
+		t.Errorf("Join.LeftBufferTTL = %s; want the window extended by the grace", m.Join.LeftBufferTTL.Duration())
+	}
+
+	pipeline := toJSON(m)
+	if pipeline.Join.Unmatched == nil || pipeline.Join.Unmatched.Table != "order_misses" || len(pipeline.Join.Unmatched.Mapping) != 1 {
+		t.Errorf("v3.Join.Unmatched = %+v; want the table policy back", pipeline.Join.Unmatched)
+	}
+	if pipeline.Join.LeftSource.TimeWindow.Duration() != 30*time.Second {
+		t.Errorf("v3.Join.LeftSource.TimeWindow = %s; want the configured window", pipeline.Join.LeftSource.TimeWindow.Duration())
+	}
+
+	cfg.Join.Unmatched.Mapping[0].Name = "missing"
+	if _, err := cfg.toModel(); err == nil {
+		t.Error("toModel with an unknown unmatched field: want error")
+	}
+}
+
 func TestRoundTrip_JSONToModelToJSON_OTLP(t *testing.T) {
 	cfg := mustParseJSON(t, otlpJSON)
 	m1, err := cfg.toModel()
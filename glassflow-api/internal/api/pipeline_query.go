@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/query"
+	jsonTransformer "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/json"
+)
+
+// expandQuery replaces the query of the pipeline with the filter, the
+// stateless transformation and the sink mapping it stands for. The WHERE
+// clause becomes the filter, the selected columns the sink mapping. Columns
+// that are computed or renamed run through a stateless transformation whose
+// output holds all the selected columns.
+func (p pipelineJSON) expandQuery() (zero pipelineJSON, _ error) {
+	if strings.TrimSpace(p.Query) == "" {
+		return p, nil
+	}
+
+	if p.Join != nil && p.Join.Enabled {
+		return zero, fmt.Errorf("query is not supported with join")
+	}
+	if len(p.Sources) != 1 {
+		return zero, fmt.Errorf("query requires exactly one source")
+	}
+	for _, t := range p.Transforms {
+		if t.Type == transformTypeFilter || t.Type == transformTypeStateless {
+			return zero, fmt.Errorf("query cannot be combined with a %s transform", t.Type)
+		}
+	}
+	if len(p.Sink.Mapping) > 0 {
+		return zero, fmt.Errorf("query cannot be combined with a sink mapping")
+	}
+
+	stmt, err := query.Parse(p.Query)
+	if err != nil {
+		return zero, fmt.Errorf("query: %w", err)
+	}
+
+	src := p.Sources[0]
+	if stmt.From != src.SourceID && stmt.From != src.Topic {
+		return zero, fmt.Errorf("query: FROM %s does not match the source_id or topic of the source", stmt.From)
+	}
+	if len(src.SchemaFields) == 0 {
+		return zero, fmt.Errorf("query: source %q must declare its schema_fields", src.SourceID)
+	}
+
+	columns, err := expandQueryColumns(stmt.Columns, src.SchemaFields)
+	if err != nil {
+		return zero, fmt.Errorf("query: %w", err)
+	}
+
+	transforms := slices.Clone(p.Transforms)
+	if stmt.Where != "" {
+		transforms = append(transforms, pipelineTransform{
+			Type:     transformTypeFilter,
+			SourceID: src.SourceID,
+			Config:   transformParams{Expression: stmt.Where},
+		})
+	}
+
+	// Plain fields map straight from the source, anything else needs the
+	// stateless transformation
+	stateless := slices.ContainsFunc(columns, func(c query.Column) bool {
+		return c.Field == "" || c.Field != c.Name
+	})
+	if stateless {
+		statelessTransforms := make([]models.Transform, 0, len(columns))
+		for _, c := range columns {
+			outputType, err := queryColumnType(c, src.SchemaFields)
+			if err != nil {
+				return zero, fmt.Errorf("query: column %s: %w", c.Name, err)
+			}
+			statelessTransforms = append(statelessTransforms, models.Transform{
+				Expression: c.Expression,
+				OutputName: c.Name,
+				OutputType: outputType,
+			})
+		}
+		transforms = append(transforms, pipelineTransform{
+			Type:     transformTypeStateless,
+			SourceID: src.SourceID,
+			Config:   transformParams{Transforms: statelessTransforms},
+		})
+	}
+
+	mapping := make([]sinkMappingEntry, 0, len(columns))
+	for _, c := range columns {
+		mapping = append(mapping, sinkMappingEntry{
+			Name:       c.Name,
+			ColumnName: strings.ReplaceAll(c.Name, ".", "_"),
+		})
+	}
+
+	p.Transforms = transforms
+	p.Sink.Mapping = mapping
+	p.Query = ""
+	return p, nil
+}
+
+// expandQueryColumns replaces the star columns with the fields of the
+// source.
+func expandQueryColumns(columns []query.Column, fields []models.Field) ([]query.Column, error) {
+	var out []query.Column
+	seen := make(map[string]bool)
+	for _, c := range columns {
+		expanded := []query.Column{c}
+		if c.Star {
+			expanded = expanded[:0]
+			for _, f := range fields {
+				expanded = append(expanded, query.Column{Field: f.Name, Expression: f.Name, Name: f.Name})
+			}
+		}
+		for _, e := range expanded {
+			if seen[e.Name] {
+				return nil, fmt.Errorf("column %q is selected twice", e.Name)
+			}
+			seen[e.Name] = true
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// queryColumnType returns the type of a selected column, the type of the
+// schema field for plain fields and the inferred type of expressions.
+func queryColumnType(c query.Column, fields []models.Field) (string, error) {
+	if c.Field != "" {
+		for _, f := range fields {
+			if f.Name == c.Field {
+				return f.Type, nil
+			}
+		}
+		return "", fmt.Errorf("field %q not found in the source schema", c.Field)
+	}
+
+	outputType, err := jsonTransformer.InferOutputType(c.Expression, fields)
+	if errors.Is(err, jsonTransformer.ErrUnknownOutputType) {
+		return "", fmt.Errorf("%w, add a CAST(... AS type)", err)
+	}
+	return outputType, err
+}
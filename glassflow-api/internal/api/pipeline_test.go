@@ -401,10 +401,10 @@ func TestCreatePipeline_JoinEnabledRejectsIncompatibleComponents(t *testing.T) {
 					},
 				}
 			},
-			wantContain: "filter/stateless transforms are not supported with join",
+			wantContain: "stateless transforms are not supported with join",
 		},
 		{
-			name: "filter enabled",
+			name: "filter on a source field",
 			modify: func(b map[string]interface{}) {
 				b["transforms"] = []map[string]interface{}{
 					{
@@ -416,7 +416,7 @@ func TestCreatePipeline_JoinEnabledRejectsIncompatibleComponents(t *testing.T) {
 					},
 				}
 			},
-			wantContain: "filter/stateless transforms are not supported with join",
+			wantContain: "filter validation against the join output fields",
 		},
 	}
 
@@ -485,10 +485,10 @@ func TestEditPipeline_JoinEnabledRejectsIncompatibleComponents(t *testing.T) {
 					},
 				}
 			},
-			wantContain: "filter/stateless transforms are not supported with join",
+			wantContain: "stateless transforms are not supported with join",
 		},
 		{
-			name: "filter enabled",
+			name: "filter on a source field",
 			modify: func(b map[string]interface{}) {
 				b["transforms"] = []map[string]interface{}{
 					{
@@ -500,7 +500,7 @@ func TestEditPipeline_JoinEnabledRejectsIncompatibleComponents(t *testing.T) {
 					},
 				}
 			},
-			wantContain: "filter/stateless transforms are not supported with join",
+			wantContain: "filter validation against the join output fields",
 		},
 	}
 
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,12 +13,18 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	jsonTransformer "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/json"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/udf"
 )
 
 // toModel converts a v3 API config into the internal domain model.
 // All structural validation happens here; downstream code can assume the
 // returned config is well-formed.
 func (p pipelineJSON) toModel() (zero models.PipelineConfig, _ error) {
+	p, err := p.expandQuery()
+	if err != nil {
+		return zero, err
+	}
+
 	if err := p.validate(); err != nil {
 		return zero, err
 	}
@@ -31,14 +39,15 @@ func (p pipelineJSON) toModel() (zero models.PipelineConfig, _ error) {
 
 	otlpSource := p.newOTLPSourceConfig()
 
-	filterCfg, err := p.newFilterConfig(schemaVersions)
+	join, err := p.newJoinComponentConfig(schemaVersions)
 	if err != nil {
-		return zero, fmt.Errorf("create filter config: %w", err)
+		return zero, fmt.Errorf("create join component config: %w", err)
 	}
 
-	join, err := p.newJoinComponentConfig(schemaVersions)
+	// The filter of a join pipeline reads the output seeded by the join
+	filterCfg, err := p.newFilterConfig(schemaVersions)
 	if err != nil {
-		return zero, fmt.Errorf("create join component config: %w", err)
+		return zero, fmt.Errorf("create filter config: %w", err)
 	}
 
 	stateless, err := p.newStatelessTransformationConfig(schemaVersions)
@@ -46,12 +55,22 @@ func (p pipelineJSON) toModel() (zero models.PipelineConfig, _ error) {
 		return zero, fmt.Errorf("create stateless transformation config: %w", err)
 	}
 
-	sink, err := p.newSinkComponentConfig(schemaVersions)
+	explode, err := p.newExplodeConfig(schemaVersions)
+	if err != nil {
+		return zero, fmt.Errorf("create explode config: %w", err)
+	}
+
+	enrichment, err := p.newEnrichmentConfig(schemaVersions, explode)
+	if err != nil {
+		return zero, fmt.Errorf("create enrichment config: %w", err)
+	}
+
+	sink, err := p.newSinkComponentConfig(schemaVersions, explode, enrichment)
 	if err != nil {
 		return zero, fmt.Errorf("create sink component config: %w", err)
 	}
 
-	return models.NewPipelineConfig(
+	cfg := models.NewPipelineConfig(
 		strings.TrimSpace(p.PipelineID),
 		p.Name,
 		sourceType,
@@ -64,7 +83,10 @@ func (p pipelineJSON) toModel() (zero models.PipelineConfig, _ error) {
 		p.Metadata,
 		p.toPipelineResources(),
 		schemaVersions,
-	), nil
+	)
+	cfg.Explode = explode
+	cfg.Enrichment = enrichment
+	return cfg, nil
 }
 
 // validate enforces v3 structural constraints before any conversion runs.
@@ -90,6 +112,9 @@ func (p pipelineJSON) validate() error {
 	if err := p.validateResourcesRefs(); err != nil {
 		return err
 	}
+	if err := p.Metadata.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -97,12 +122,16 @@ func (p pipelineJSON) validateSources() error {
 	if len(p.Sources) == 0 {
 		return fmt.Errorf("pipeline must have at least one source")
 	}
-	if len(p.Sources) > internal.MaxStreamsSupportedWithJoin {
-		return fmt.Errorf("pipeline must have at most %d sources", internal.MaxStreamsSupportedWithJoin)
+	maxSources := internal.MaxStreamsSupportedWithJoin
+	if p.Join != nil && len(p.Join.Chain) > 0 {
+		maxSources = internal.MaxSourcesSupportedWithJoinChain
+	}
+	if len(p.Sources) > maxSources {
+		return fmt.Errorf("pipeline must have at most %d sources", maxSources)
 	}
 
 	seen := make(map[string]struct{}, len(p.Sources))
-	var kafkaCount, otlpCount int
+	var kafkaCount, pulsarCount, mysqlCount, httpCount, otlpCount int
 	for i, s := range p.Sources {
 		id := strings.TrimSpace(s.SourceID)
 		if id == "" {
@@ -123,14 +152,86 @@ func (p pipelineJSON) validateSources() error {
 			if s.ConnectionParams == nil {
 				return fmt.Errorf("source %q: kafka source must declare connection_params", id)
 			}
+			if s.PulsarConnectionParams != nil || s.Subscription != "" || s.SubscriptionType != "" {
+				return fmt.Errorf("source %q: kafka source must not declare pulsar settings", id)
+			}
+			if s.MySQLConnectionParams != nil || s.Database != "" || s.Table != "" {
+				return fmt.Errorf("source %q: kafka source must not declare mysql settings", id)
+			}
 			if strings.TrimSpace(s.Topic) == "" {
 				return fmt.Errorf("source %q: kafka source must declare topic", id)
 			}
 			if len(s.SchemaFields) == 0 {
 				return fmt.Errorf("source %q: kafka source must declare schema_fields", id)
 			}
+		case st.IsPulsar():
+			pulsarCount++
+			if s.FlowControl != nil {
+				return fmt.Errorf("source %q: flow_control is only supported for kafka sources", id)
+			}
+			if s.PulsarConnectionParams == nil {
+				return fmt.Errorf("source %q: pulsar source must declare pulsar_connection_params", id)
+			}
+			if s.ConnectionParams != nil {
+				return fmt.Errorf("source %q: pulsar source must not declare connection_params", id)
+			}
+			if s.MySQLConnectionParams != nil || s.Database != "" || s.Table != "" {
+				return fmt.Errorf("source %q: pulsar source must not declare mysql settings", id)
+			}
+			if strings.TrimSpace(s.Topic) == "" {
+				return fmt.Errorf("source %q: pulsar source must declare topic", id)
+			}
+			if len(s.SchemaFields) == 0 {
+				return fmt.Errorf("source %q: pulsar source must declare schema_fields", id)
+			}
+		case st.IsMySQL():
+			mysqlCount++
+			if s.FlowControl != nil {
+				return fmt.Errorf("source %q: flow_control is only supported for kafka sources", id)
+			}
+			if s.MySQLConnectionParams == nil {
+				return fmt.Errorf("source %q: mysql source must declare mysql_connection_params", id)
+			}
+			if s.ConnectionParams != nil || s.PulsarConnectionParams != nil || s.Subscription != "" || s.SubscriptionType != "" {
+				return fmt.Errorf("source %q: mysql source must not declare kafka or pulsar settings", id)
+			}
+			if s.Topic != "" {
+				return fmt.Errorf("source %q: mysql source must not declare topic", id)
+			}
+			if strings.TrimSpace(s.Database) == "" || strings.TrimSpace(s.Table) == "" {
+				return fmt.Errorf("source %q: mysql source must declare database and table", id)
+			}
+			if s.SchemaRegistry != nil {
+				return fmt.Errorf("source %q: mysql source must not declare schema_registry", id)
+			}
+			if len(s.SchemaFields) == 0 {
+				return fmt.Errorf("source %q: mysql source must declare schema_fields", id)
+			}
+		case st.IsHTTP():
+			httpCount++
+			if s.FlowControl != nil {
+				return fmt.Errorf("source %q: flow_control is only supported for kafka sources", id)
+			}
+			if s.PublishDedup != nil {
+				return fmt.Errorf("source %q: http source must not declare publish_dedup", id)
+			}
+			if s.ConnectionParams != nil || s.PulsarConnectionParams != nil || s.MySQLConnectionParams != nil {
+				return fmt.Errorf("source %q: http source must not declare connection params", id)
+			}
+			if s.Topic != "" {
+				return fmt.Errorf("source %q: http source must not declare topic", id)
+			}
+			if s.SchemaRegistry != nil {
+				return fmt.Errorf("source %q: http source must not declare schema_registry", id)
+			}
+			if len(s.SchemaFields) == 0 {
+				return fmt.Errorf("source %q: http source must declare schema_fields", id)
+			}
 		case st.IsOTLP():
 			otlpCount++
+			if s.FlowControl != nil {
+				return fmt.Errorf("source %q: flow_control is only supported for kafka sources", id)
+			}
 			if s.ConnectionParams != nil {
 				return fmt.Errorf("source %q: OTLP source must not declare connection_params", id)
 			}
@@ -140,6 +241,9 @@ func (p pipelineJSON) validateSources() error {
 			if len(s.SchemaFields) > 0 {
 				return fmt.Errorf("source %q: OTLP source must not declare schema_fields", id)
 			}
+			if s.PublishDedup != nil {
+				return fmt.Errorf("source %q: OTLP source must not declare publish_dedup", id)
+			}
 			if s.SchemaRegistry != nil {
 				return fmt.Errorf("source %q: OTLP source must not declare schema_registry", id)
 			}
@@ -149,29 +253,56 @@ func (p pipelineJSON) validateSources() error {
 	if kafkaCount > 0 && otlpCount > 0 {
 		return fmt.Errorf("mixed kafka and OTLP sources are not supported")
 	}
+	if pulsarCount > 0 && (kafkaCount > 0 || otlpCount > 0) {
+		return fmt.Errorf("pulsar sources cannot be mixed with other source types")
+	}
+	if mysqlCount > 0 && (kafkaCount > 0 || pulsarCount > 0 || otlpCount > 0) {
+		return fmt.Errorf("mysql sources cannot be mixed with other source types")
+	}
+	if httpCount > 0 && (kafkaCount > 0 || pulsarCount > 0 || mysqlCount > 0 || otlpCount > 0) {
+		return fmt.Errorf("http sources cannot be mixed with other source types")
+	}
 	if otlpCount > 1 {
 		return fmt.Errorf("at most one OTLP source is supported")
 	}
+	if httpCount > 1 {
+		return fmt.Errorf("at most one http source is supported")
+	}
+	if mysqlCount > 1 {
+		return fmt.Errorf("at most one mysql source is supported")
+	}
 
-	// Two kafka sources require the same connection_params.
-	// TODO: if we add support for more than two sources in the future, we need to store it separately
-	if kafkaCount == 2 {
-		if !reflect.DeepEqual(p.Sources[0].ConnectionParams, p.Sources[1].ConnectionParams) {
+	// Joined kafka sources require the same connection_params.
+	// TODO: store the connection params per source to join sources of different clusters
+	for _, s := range p.Sources[1:] {
+		if kafkaCount > 1 && !reflect.DeepEqual(p.Sources[0].ConnectionParams, s.ConnectionParams) {
 			return fmt.Errorf("kafka sources must share identical connection_params")
 		}
+		if pulsarCount > 1 && !reflect.DeepEqual(p.Sources[0].PulsarConnectionParams, s.PulsarConnectionParams) {
+			return fmt.Errorf("pulsar sources must share identical pulsar_connection_params")
+		}
 	}
 
 	// Join presence mirrors source count.
 	joinEnabled := p.Join != nil && p.Join.Enabled
-	if kafkaCount == 2 && !joinEnabled {
-		return fmt.Errorf("two kafka sources require join.enabled=true")
+	if kafkaCount > 1 && !joinEnabled {
+		return fmt.Errorf("multiple kafka sources require join.enabled=true")
+	}
+	if pulsarCount > 1 && !joinEnabled {
+		return fmt.Errorf("multiple pulsar sources require join.enabled=true")
 	}
-	if (kafkaCount <= 1) && joinEnabled {
-		return fmt.Errorf("join.enabled=true requires exactly two kafka sources")
+	if kafkaCount <= 1 && pulsarCount <= 1 && joinEnabled {
+		return fmt.Errorf("join.enabled=true requires at least two kafka or pulsar sources")
 	}
 	if otlpCount > 0 && joinEnabled {
 		return fmt.Errorf("join is not supported for OTLP pipelines")
 	}
+	if httpCount > 0 && joinEnabled {
+		return fmt.Errorf("join is not supported for http pipelines")
+	}
+	if mysqlCount > 0 && joinEnabled {
+		return fmt.Errorf("join is not supported for mysql pipelines")
+	}
 
 	return nil
 }
@@ -180,7 +311,8 @@ func (p pipelineJSON) validateTransforms() error {
 	sourceIDs := p.sourceIDSet()
 
 	var dedupPerSource = make(map[string]int)
-	var filterCount, statelessCount int
+	var unwrapPerSource = make(map[string]int)
+	var filterCount, statelessCount, explodeCount, enrichmentCount int
 
 	for i, t := range p.Transforms {
 		if _, ok := sourceIDs[t.SourceID]; !ok {
@@ -192,6 +324,25 @@ func (p pipelineJSON) validateTransforms() error {
 			if dedupPerSource[t.SourceID] > 1 {
 				return fmt.Errorf("source %q has more than one dedup transform", t.SourceID)
 			}
+			switch strings.ToLower(t.Config.Mode) {
+			case "", internal.DedupModeKeepFirst:
+			case internal.DedupModeKeepLast:
+				s := p.sourceByID(t.SourceID)
+				if models.SourceType(strings.ToLower(strings.TrimSpace(s.Type))).UsesReceiver() {
+					return fmt.Errorf("transform at index %d: dedup mode keep_last is not supported for %s sources", i, s.Type)
+				}
+				if p.Join != nil && p.Join.Enabled {
+					return fmt.Errorf("transform at index %d: dedup mode keep_last is not supported with join", i)
+				}
+			default:
+				return fmt.Errorf("transform at index %d: invalid dedup mode %q; allowed values: keep_first or keep_last", i, t.Config.Mode)
+			}
+			if t.Config.Domain != "" {
+				s := p.sourceByID(t.SourceID)
+				if models.SourceType(strings.ToLower(strings.TrimSpace(s.Type))).UsesReceiver() {
+					return fmt.Errorf("transform at index %d: dedup domain is not supported for %s sources", i, s.Type)
+				}
+			}
 		case transformTypeFilter:
 			filterCount++
 			if filterCount > 1 {
@@ -202,13 +353,44 @@ func (p pipelineJSON) validateTransforms() error {
 			if statelessCount > 1 {
 				return fmt.Errorf("at most one stateless transform is supported")
 			}
+		case transformTypeExplode:
+			explodeCount++
+			if explodeCount > 1 {
+				return fmt.Errorf("at most one explode transform is supported")
+			}
+		case transformTypeEnrichment:
+			enrichmentCount++
+			if enrichmentCount > 1 {
+				return fmt.Errorf("at most one enrichment transform is supported")
+			}
+		case transformTypeDebeziumUnwrap:
+			unwrapPerSource[t.SourceID]++
+			if unwrapPerSource[t.SourceID] > 1 {
+				return fmt.Errorf("source %q has more than one debezium_unwrap transform", t.SourceID)
+			}
+			s := p.sourceByID(t.SourceID)
+			st := models.SourceType(strings.ToLower(strings.TrimSpace(s.Type)))
+			if !st.IsKafka() && !st.IsPulsar() {
+				return fmt.Errorf("transform at index %d: debezium_unwrap is only supported for kafka and pulsar sources", i)
+			}
+			// The envelope is unwrapped before the schema validation, which
+			// needs the plain JSON payload
+			if s.SchemaRegistry != nil {
+				return fmt.Errorf("transform at index %d: debezium_unwrap is not supported with schema_registry", i)
+			}
 		default:
 			return fmt.Errorf("transform at index %d has unsupported type %q", i, t.Type)
 		}
 	}
 
-	if (filterCount > 0 || statelessCount > 0) && p.Join != nil && p.Join.Enabled {
-		return fmt.Errorf("filter/stateless transforms are not supported with join")
+	if statelessCount > 0 && p.Join != nil && p.Join.Enabled {
+		return fmt.Errorf("stateless transforms are not supported with join")
+	}
+	if explodeCount > 0 && p.Join != nil && p.Join.Enabled {
+		return fmt.Errorf("explode transforms are not supported with join")
+	}
+	if enrichmentCount > 0 && p.Join != nil && p.Join.Enabled {
+		return fmt.Errorf("enrichment transforms are not supported with join")
 	}
 	return nil
 }
@@ -227,6 +409,27 @@ func (p pipelineJSON) validateJoinRefs() error {
 	if p.Join.LeftSource.SourceID == p.Join.RightSource.SourceID {
 		return fmt.Errorf("join.left_source and join.right_source must differ")
 	}
+	joined := map[string]struct{}{
+		p.Join.LeftSource.SourceID:  {},
+		p.Join.RightSource.SourceID: {},
+	}
+	for i, c := range p.Join.Chain {
+		if _, ok := ids[c.SourceID]; !ok {
+			return fmt.Errorf("join.chain[%d].source_id %q does not match any source", i, c.SourceID)
+		}
+		if _, ok := joined[c.SourceID]; ok {
+			return fmt.Errorf("join.chain[%d].source_id %q is already joined", i, c.SourceID)
+		}
+		if _, ok := joined[c.LeftSourceID]; !ok {
+			return fmt.Errorf("join.chain[%d].left_source_id %q is not joined by an earlier stage", i, c.LeftSourceID)
+		}
+		joined[c.SourceID] = struct{}{}
+	}
+	for _, s := range p.Sources {
+		if _, ok := joined[s.SourceID]; !ok {
+			return fmt.Errorf("source %q is not joined, add it to join.chain", s.SourceID)
+		}
+	}
 	for i, f := range p.Join.OutputFields {
 		if _, ok := ids[f.SourceID]; !ok {
 			return fmt.Errorf("join.output_fields[%d].source_id %q does not match any source", i, f.SourceID)
@@ -240,10 +443,10 @@ func (p pipelineJSON) validateJoinRefs() error {
 
 func (p pipelineJSON) validateResourcesRefs() error {
 	ids := p.sourceIDSet()
-	otlpIDs := make(map[string]struct{})
+	receiverTypes := make(map[string]string)
 	for _, s := range p.Sources {
-		if models.SourceType(strings.ToLower(s.Type)).IsOTLP() {
-			otlpIDs[s.SourceID] = struct{}{}
+		if models.SourceType(strings.ToLower(s.Type)).UsesReceiver() {
+			receiverTypes[s.SourceID] = s.Type
 		}
 	}
 
@@ -260,9 +463,9 @@ func (p pipelineJSON) validateResourcesRefs() error {
 			return fmt.Errorf("resources.sources[%d].source_id %q does not match any source", i, sr.SourceID)
 		}
 
-		_, isOTLP := otlpIDs[sr.SourceID]
-		if isOTLP {
-			return fmt.Errorf("resources.sources[%d]: per-source resources are not supported for OTLP source %q", i, sr.SourceID)
+		sourceType, usesReceiver := receiverTypes[sr.SourceID]
+		if usesReceiver {
+			return fmt.Errorf("resources.sources[%d]: per-source resources are not supported for %s source %q", i, sourceType, sr.SourceID)
 		}
 	}
 	for i, tr := range p.Resources.Transform {
@@ -280,6 +483,15 @@ func (p pipelineJSON) validateResourcesRefs() error {
 	return nil
 }
 
+func (p pipelineJSON) sourceByID(id string) source {
+	for _, s := range p.Sources {
+		if s.SourceID == id {
+			return s
+		}
+	}
+	return source{}
+}
+
 func (p pipelineJSON) sourceIDSet() map[string]struct{} {
 	ids := make(map[string]struct{}, len(p.Sources))
 	for _, s := range p.Sources {
@@ -302,7 +514,7 @@ func (p pipelineJSON) seedSchemaVersions() map[string]models.SchemaVersion {
 	for _, s := range p.Sources {
 		st := models.SourceType(strings.ToLower(strings.TrimSpace(s.Type)))
 		switch {
-		case st.IsKafka():
+		case st.UsesIngestor(), st.IsHTTP():
 			if len(s.SchemaFields) == 0 {
 				continue
 			}
@@ -332,10 +544,50 @@ func (p pipelineJSON) seedSchemaVersions() map[string]models.SchemaVersion {
 
 func (p pipelineJSON) newIngestorComponentConfig() (zero models.IngestorComponentConfig, _ error) {
 	st := p.resolveSourceType()
-	if !st.IsKafka() {
+	if !st.UsesIngestor() {
 		return zero, nil
 	}
 
+	topics, err := p.ingestorTopics()
+	if err != nil {
+		return zero, err
+	}
+
+	if st.IsPulsar() {
+		conn := p.Sources[0].PulsarConnectionParams
+		pulsarConn := models.PulsarConnectionParamsConfig{
+			ServiceURL:                 conn.ServiceURL,
+			AuthToken:                  conn.AuthToken,
+			TLSAllowInsecureConnection: conn.TLSAllowInsecureConnection,
+			TLSRoot:                    conn.TLSRoot,
+			TLSCert:                    conn.TLSCert,
+			TLSKey:                     conn.TLSKey,
+		}
+		cfg, err := models.NewPulsarIngestorComponentConfig(pulsarConn, topics)
+		if err != nil {
+			return zero, fmt.Errorf("create ingestor config: %w", err)
+		}
+		return cfg, nil
+	}
+
+	if st.IsMySQL() {
+		conn := p.Sources[0].MySQLConnectionParams
+		mysqlConn := models.MySQLConnectionParamsConfig{
+			Host:                conn.Host,
+			Port:                conn.Port,
+			Username:            conn.Username,
+			Password:            conn.Password,
+			ServerID:            conn.ServerID,
+			TLSRoot:             conn.TLSRoot,
+			SkipTLSVerification: conn.SkipTLSVerification,
+		}
+		cfg, err := models.NewMySQLIngestorComponentConfig(mysqlConn, topics)
+		if err != nil {
+			return zero, fmt.Errorf("create ingestor config: %w", err)
+		}
+		return cfg, nil
+	}
+
 	conn := p.Sources[0].ConnectionParams
 	kafkaConn := models.KafkaConnectionParamsConfig{
 		Brokers:             conn.Brokers,
@@ -354,11 +606,22 @@ func (p pipelineJSON) newIngestorComponentConfig() (zero models.IngestorComponen
 		KerberosConfig:      conn.KerberosConfig,
 	}
 
+	cfg, err := models.NewIngestorComponentConfig("", kafkaConn, topics)
+	if err != nil {
+		return zero, fmt.Errorf("create ingestor config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ingestorTopics builds the topic config of each Kafka, Pulsar or MySQL
+// source. MySQL tables are named by their qualified `database.table` name.
+func (p pipelineJSON) ingestorTopics() ([]models.KafkaTopicsConfig, error) {
 	dedupBySource, err := p.dedupConfigsBySourceID()
 	if err != nil {
-		return zero, err
+		return nil, err
 	}
 	replicasBySource := p.ingestorReplicasBySourceID()
+	unwrapBySource := p.debeziumUnwrapConfigsBySourceID()
 
 	topics := make([]models.KafkaTopicsConfig, 0, len(p.Sources))
 	for _, s := range p.Sources {
@@ -372,37 +635,70 @@ func (p pipelineJSON) newIngestorComponentConfig() (zero models.IngestorComponen
 			srConfig = &models.SchemaRegistryConfig{}
 		}
 
+		consumerGroupName := models.GetKafkaConsumerGroupName(p.PipelineID)
+		if s.Subscription != "" {
+			consumerGroupName = s.Subscription
+		}
+
+		topicName := s.Topic
+		if s.Database != "" || s.Table != "" {
+			topicName = strings.TrimSpace(s.Database) + "." + strings.TrimSpace(s.Table)
+		}
+
 		topic := models.KafkaTopicsConfig{
-			Name:                       s.Topic,
+			Name:                       topicName,
 			ID:                         s.SourceID,
-			ConsumerGroupName:          models.GetKafkaConsumerGroupName(p.PipelineID),
+			ConsumerGroupName:          consumerGroupName,
+			SubscriptionType:           s.SubscriptionType,
 			ConsumerGroupInitialOffset: s.ConsumerGroupInitialOffset,
 			Replicas:                   replicas,
+			PartitionAssignment:        s.PartitionAssignment,
+			RebalanceStrategy:          s.RebalanceStrategy,
+			StaticMembership:           s.StaticMembership,
+			DecodeErrorPolicy:          s.DecodeErrorPolicy,
 			SchemaRegistryConfig:       *srConfig,
 		}
+		if s.PublishDedup != nil {
+			topic.PublishDedup = models.PublishDedupConfig{
+				DuplicateWindow: s.PublishDedup.DuplicateWindow,
+				MsgIDStrategy:   s.PublishDedup.MsgIDStrategy,
+			}
+		}
+		if s.FlowControl != nil {
+			topic.FlowControl = models.FlowControlConfig{
+				MaxStreamBytes:     s.FlowControl.MaxStreamBytes,
+				MaxConsumerPending: s.FlowControl.MaxConsumerPending,
+			}
+		}
+		if u, ok := unwrapBySource[s.SourceID]; ok {
+			topic.DebeziumUnwrap = models.DebeziumUnwrapConfig{
+				Enabled:        true,
+				OpField:        u.OpField,
+				TimestampField: u.TimestampField,
+				DeleteHandling: u.DeleteHandling,
+			}
+		}
 		if d, ok := dedupBySource[s.SourceID]; ok {
 			// Validate the dedup key against the source schema.
 			if len(s.SchemaFields) > 0 && !hasFieldNamed(s.SchemaFields, d.Key) {
-				return zero, fmt.Errorf("dedup key %q not found in schema_fields for source %q", d.Key, s.SourceID)
+				return nil, fmt.Errorf("dedup key %q not found in schema_fields for source %q", d.Key, s.SourceID)
 			}
 			topic.Deduplication = models.DeduplicationConfig{
-				Enabled: true,
-				ID:      d.Key,
-				Window:  d.TimeWindow,
+				Enabled:      true,
+				ID:           d.Key,
+				Window:       d.TimeWindow,
+				Mode:         d.Mode,
+				VersionField: d.VersionField,
+				Domain:       d.Domain,
 			}
 		}
 		topics = append(topics, topic)
 	}
-
-	cfg, err := models.NewIngestorComponentConfig("", kafkaConn, topics)
-	if err != nil {
-		return zero, fmt.Errorf("create ingestor config: %w", err)
-	}
-	return cfg, nil
+	return topics, nil
 }
 
 func (p pipelineJSON) newOTLPSourceConfig() models.OTLPSourceConfig {
-	if !p.resolveSourceType().IsOTLP() {
+	if !p.resolveSourceType().UsesReceiver() {
 		return models.OTLPSourceConfig{}
 	}
 	s := p.Sources[0]
@@ -438,12 +734,14 @@ func (p pipelineJSON) newJoinComponentConfig(schemaVersions map[string]models.Sc
 			JoinKey:     p.Join.LeftSource.Key,
 			Window:      p.Join.LeftSource.TimeWindow,
 			Orientation: internal.JoinLeft,
+			TimeField:   p.Join.LeftSource.TimeField,
 		},
 		{
 			SourceID:    p.Join.RightSource.SourceID,
 			JoinKey:     p.Join.RightSource.Key,
 			Window:      p.Join.RightSource.TimeWindow,
 			Orientation: internal.JoinRight,
+			TimeField:   p.Join.RightSource.TimeField,
 		},
 	}
 
@@ -456,6 +754,30 @@ func (p pipelineJSON) newJoinComponentConfig(schemaVersions map[string]models.Sc
 		if !sv.HasField(js.JoinKey) {
 			return zero, fmt.Errorf("join key %q not found in schema_fields for source %q", js.JoinKey, js.SourceID)
 		}
+		if js.TimeField != "" && !sv.HasField(js.TimeField) {
+			return zero, fmt.Errorf("join time field %q not found in schema_fields for source %q", js.TimeField, js.SourceID)
+		}
+	}
+
+	chain := make([]models.JoinChainSourceConfig, 0, len(p.Join.Chain))
+	for _, c := range p.Join.Chain {
+		chain = append(chain, models.JoinChainSourceConfig{
+			SourceID:     c.SourceID,
+			JoinKey:      c.Key,
+			Window:       c.TimeWindow,
+			LeftSourceID: c.LeftSourceID,
+			LeftJoinKey:  c.LeftKey,
+		})
+	}
+
+	// Validate chain join keys against schemas.
+	for _, c := range chain {
+		if sv, ok := schemaVersions[c.SourceID]; ok && !sv.HasField(c.JoinKey) {
+			return zero, fmt.Errorf("join key %q not found in schema_fields for source %q", c.JoinKey, c.SourceID)
+		}
+		if sv, ok := schemaVersions[c.LeftSourceID]; ok && !sv.HasField(c.LeftJoinKey) {
+			return zero, fmt.Errorf("join key %q not found in schema_fields for source %q", c.LeftJoinKey, c.LeftSourceID)
+		}
 	}
 
 	rules := make([]models.JoinRule, 0, len(p.Join.OutputFields))
@@ -467,21 +789,65 @@ func (p pipelineJSON) newJoinComponentConfig(schemaVersions map[string]models.Sc
 		})
 	}
 
-	cfg, err := models.NewJoinComponentConfig(kind, joinID, sources, rules)
+	var rightCache models.JoinCacheConfig
+	if p.Join.RightCache != nil {
+		rightCache = models.JoinCacheConfig{
+			Enabled:  p.Join.RightCache.Enabled,
+			MaxBytes: p.Join.RightCache.MaxBytes,
+		}
+	}
+
+	var tiering models.JoinTieringConfig
+	if p.Join.Tiering != nil {
+		tiering = models.JoinTieringConfig{
+			Enabled:      p.Join.Tiering.Enabled,
+			HotWindow:    p.Join.Tiering.HotWindow,
+			HotMaxBytes:  p.Join.Tiering.HotMaxBytes,
+			CompactAfter: p.Join.Tiering.CompactAfter,
+		}
+	}
+
+	unmatched, err := p.newJoinUnmatchedConfig(schemaVersions)
+	if err != nil {
+		return zero, err
+	}
+
+	var ordering models.JoinOrderingConfig
+	if p.Join.Ordering != nil {
+		ordering = models.JoinOrderingConfig{
+			Enabled: p.Join.Ordering.Enabled,
+			MaxSkew: p.Join.Ordering.MaxSkew,
+		}
+	}
+
+	cfg, err := models.NewJoinComponentConfig(kind, joinID, sources, rules, rightCache, unmatched, ordering, p.Join.RightMatch, p.Join.JoinType, p.Join.Grace, chain, tiering)
 	if err != nil {
 		return zero, fmt.Errorf("create join config: %w", err)
 	}
 
-	// Seed the join output schema
+	// Seed the output schema of every stage, a stage reads the fields of the
+	// intermediate output seeded for the stage before it
+	for _, stage := range cfg.Stages() {
+		err = seedJoinOutputSchema(stage.ID, stage.Config, schemaVersions)
+		if err != nil {
+			return zero, err
+		}
+	}
+	return cfg, nil
+}
+
+// seedJoinOutputSchema seeds the output schema of join joinID from the fields
+// its rules read.
+func seedJoinOutputSchema(joinID string, rules []models.JoinRule, schemaVersions map[string]models.SchemaVersion) error {
 	joinFields := make([]models.Field, 0, len(rules))
 	for _, r := range rules {
 		sv, ok := schemaVersions[r.SourceID]
 		if !ok {
-			return zero, fmt.Errorf("schema version for join source_id %q not found", r.SourceID)
+			return fmt.Errorf("schema version for join source_id %q not found", r.SourceID)
 		}
 		sourceField, found := sv.GetField(r.SourceName)
 		if !found {
-			return zero, fmt.Errorf("join output field %q not found in schema for source %q", r.SourceName, r.SourceID)
+			return fmt.Errorf("join output field %q not found in schema for source %q", r.SourceName, r.SourceID)
 		}
 		outputName := r.OutputName
 		if outputName == "" {
@@ -495,6 +861,48 @@ func (p pipelineJSON) newJoinComponentConfig(schemaVersions map[string]models.Sc
 			Fields:   joinFields,
 		}
 	}
+	return nil
+}
+
+// newJoinUnmatchedConfig resolves the unmatched mapping against the schema of
+// the left source, whose events are the ones routed.
+func (p pipelineJSON) newJoinUnmatchedConfig(schemaVersions map[string]models.SchemaVersion) (zero models.JoinUnmatchedConfig, _ error) {
+	if p.Join.Unmatched == nil {
+		return zero, nil
+	}
+
+	cfg := models.JoinUnmatchedConfig{
+		Policy: p.Join.Unmatched.Policy,
+		Table:  p.Join.Unmatched.Table,
+	}
+	if len(p.Join.Unmatched.Mapping) == 0 {
+		return cfg, nil
+	}
+
+	leftSourceID := p.Join.LeftSource.SourceID
+	sv, found := schemaVersions[leftSourceID]
+	if !found {
+		return zero, fmt.Errorf("schema version for join left source_id %q not found", leftSourceID)
+	}
+	for _, m := range p.Join.Unmatched.Mapping {
+		sourceField, ok := sv.GetField(m.Name)
+		if !ok {
+			return zero, fmt.Errorf("unmatched mapping field %q not found in schema for source_id %q", m.Name, leftSourceID)
+		}
+		if m.ColumnType != "" {
+			err := mapper.ValidateClickHouseColumnType(m.ColumnType)
+			if err != nil {
+				return zero, fmt.Errorf("unmatched field %q (column %q): %w", m.Name, m.ColumnName, err)
+			}
+		}
+		cfg.Mapping = append(cfg.Mapping, models.Mapping{
+			SourceField:      sourceField.Name,
+			SourceType:       sourceField.Type,
+			DestinationField: m.ColumnName,
+			DestinationType:  m.ColumnType,
+		})
+	}
+
 	return cfg, nil
 }
 
@@ -507,7 +915,17 @@ func (p pipelineJSON) newFilterConfig(schemaVersions map[string]models.SchemaVer
 		return models.FilterComponentConfig{}, nil
 	}
 
-	if sv, has := schemaVersions[t.SourceID]; has {
+	// A join pipeline filters the joined events, the expression reads the
+	// output fields of the join instead of the fields of the source
+	if p.Join != nil && p.Join.Enabled {
+		sv, has := schemaVersions[p.sinkSourceID()]
+		if !has {
+			return models.FilterComponentConfig{}, fmt.Errorf("filter needs the join output schema")
+		}
+		if err := filter.ValidateFilterExpressionV2(t.Config.Expression, sv.Fields); err != nil {
+			return models.FilterComponentConfig{}, fmt.Errorf("filter validation against the join output fields: %w", err)
+		}
+	} else if sv, has := schemaVersions[t.SourceID]; has {
 		if err := filter.ValidateFilterExpressionV2(t.Config.Expression, sv.Fields); err != nil {
 			return models.FilterComponentConfig{}, fmt.Errorf("filter validation against schema: %w", err)
 		}
@@ -528,16 +946,31 @@ func (p pipelineJSON) newStatelessTransformationConfig(schemaVersions map[string
 		return models.StatelessTransformation{}, nil
 	}
 
-	if _, err := jsonTransformer.NewTransformer(t.Config.Transforms); err != nil {
-		return models.StatelessTransformation{}, fmt.Errorf("stateless transformation: %w", err)
+	if t.Config.Salt != "" && len(t.Config.Salt) < internal.MinTransformSaltLength {
+		return models.StatelessTransformation{}, models.PipelineConfigError{
+			Msg: fmt.Sprintf("stateless transformation salt must be at least %d characters", internal.MinTransformSaltLength),
+		}
 	}
 
-	sv, found := schemaVersions[t.SourceID]
-	if !found {
-		return models.StatelessTransformation{}, fmt.Errorf("schema version for stateless transformation source_id %q not found", t.SourceID)
-	}
-	if err := jsonTransformer.ValidateTransformationAgainstSchema(t.Config.Transforms, sv.Fields); err != nil {
-		return models.StatelessTransformation{}, fmt.Errorf("validate stateless transformation: %w", err)
+	var udfCfg *models.UDFConfig
+	if t.Config.UDF != nil {
+		cfg, err := newUDFConfig(*t.Config.UDF, t.Config.Transforms)
+		if err != nil {
+			return models.StatelessTransformation{}, fmt.Errorf("stateless transformation: %w", err)
+		}
+		udfCfg = &cfg
+	} else {
+		if _, err := jsonTransformer.NewTransformer(t.Config.Transforms, jsonTransformer.WithSalt(t.Config.Salt)); err != nil {
+			return models.StatelessTransformation{}, fmt.Errorf("stateless transformation: %w", err)
+		}
+
+		sv, found := schemaVersions[t.SourceID]
+		if !found {
+			return models.StatelessTransformation{}, fmt.Errorf("schema version for stateless transformation source_id %q not found", t.SourceID)
+		}
+		if err := jsonTransformer.ValidateTransformationAgainstSchema(t.Config.Transforms, sv.Fields); err != nil {
+			return models.StatelessTransformation{}, fmt.Errorf("validate stateless transformation: %w", err)
+		}
 	}
 
 	statelessID := p.PipelineID + internal.StatelessIDSuffix
@@ -558,11 +991,126 @@ func (p pipelineJSON) newStatelessTransformationConfig(schemaVersions map[string
 		SourceID: t.SourceID,
 		Config: models.StatelessTransformationsConfig{
 			Transform: t.Config.Transforms,
+			UDF:       udfCfg,
+			Salt:      t.Config.Salt,
 		},
 	}, nil
 }
 
-func (p pipelineJSON) newSinkComponentConfig(schemaVersions map[string]models.SchemaVersion) (zero models.SinkComponentConfig, _ error) {
+// newUDFConfig validates a user-defined function by compiling its module.
+// Its transforms only declare the output fields and have no expression.
+func newUDFConfig(cfg models.UDFConfig, transforms []models.Transform) (zero models.UDFConfig, _ error) {
+	cfg, err := models.NewUDFConfig(cfg)
+	if err != nil {
+		return zero, err
+	}
+
+	for _, tr := range transforms {
+		if tr.Expression != "" {
+			return zero, models.PipelineConfigError{Msg: fmt.Sprintf("transform %s of a udf cannot have an expression", tr.OutputName)}
+		}
+		if tr.OutputName == "" || tr.OutputType == "" {
+			return zero, models.PipelineConfigError{Msg: "transforms of a udf must declare output_name and output_type"}
+		}
+	}
+
+	err = udf.Validate(context.Background(), cfg)
+	if err != nil {
+		return zero, models.PipelineConfigError{Msg: fmt.Sprintf("invalid udf module: %s", err)}
+	}
+	return cfg, nil
+}
+
+// newExplodeConfig validates the array of the explode against the fields of
+// the events it runs on, the output of the stateless transformation when
+// there is one.
+func (p pipelineJSON) newExplodeConfig(schemaVersions map[string]models.SchemaVersion) (zero models.ExplodeConfig, _ error) {
+	t, ok := p.findExplodeTransform()
+	if !ok {
+		return zero, nil
+	}
+
+	cfg, err := models.NewExplodeConfig(models.ExplodeConfig{
+		SourceID:    t.SourceID,
+		Field:       t.Config.Field,
+		OutputName:  t.Config.OutputName,
+		Fields:      t.Config.Fields,
+		ElementType: t.Config.ElementType,
+		IndexField:  t.Config.IndexField,
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	inputID := p.sinkSourceID()
+	sv, found := schemaVersions[inputID]
+	if !found {
+		return zero, fmt.Errorf("schema version for explode input %q not found", inputID)
+	}
+	field, ok := sv.GetField(cfg.Field)
+	if !ok {
+		return zero, fmt.Errorf("explode field %q not found in schema for source_id %q", cfg.Field, inputID)
+	}
+	if internal.NormalizeToBasicKafkaType(field.Type) != internal.KafkaTypeArray {
+		return zero, fmt.Errorf("explode field %q must be an array, got %q", cfg.Field, field.Type)
+	}
+	if cfg.IndexField != "" {
+		if _, ok := sv.GetField(cfg.IndexField); ok {
+			return zero, fmt.Errorf("explode index field %q already exists in schema for source_id %q", cfg.IndexField, inputID)
+		}
+	}
+
+	return cfg, nil
+}
+
+// newEnrichmentConfig validates the key of the enrichment against the fields
+// of the events it runs on, the output of the stateless transformation when
+// there is one, with the fields added by the explode.
+func (p pipelineJSON) newEnrichmentConfig(schemaVersions map[string]models.SchemaVersion, explode models.ExplodeConfig) (zero models.EnrichmentConfig, _ error) {
+	t, ok := p.findEnrichmentTransform()
+	if !ok {
+		return zero, nil
+	}
+
+	cfg, err := models.NewEnrichmentConfig(models.EnrichmentConfig{
+		SourceID:        t.SourceID,
+		Database:        t.Config.Database,
+		Table:           t.Config.Table,
+		KeyField:        t.Config.Key,
+		KeyColumn:       t.Config.KeyColumn,
+		Columns:         t.Config.Columns,
+		Mode:            t.Config.Mode,
+		RefreshInterval: t.Config.RefreshInterval,
+		CacheTTL:        t.Config.CacheTTL,
+		CacheMaxEntries: t.Config.CacheMaxEntries,
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	inputID := p.sinkSourceID()
+	sv, found := schemaVersions[inputID]
+	if !found {
+		return zero, fmt.Errorf("schema version for enrichment input %q not found", inputID)
+	}
+	sv.Fields = append(slices.Clone(sv.Fields), explode.OutputFields()...)
+	if _, ok := sv.GetField(cfg.KeyField); !ok {
+		return zero, fmt.Errorf("enrichment key %q not found in schema for source_id %q", cfg.KeyField, inputID)
+	}
+	for _, f := range cfg.OutputFields() {
+		if _, ok := sv.GetField(f.Name); ok {
+			return zero, fmt.Errorf("enrichment output field %q already exists in schema for source_id %q", f.Name, inputID)
+		}
+	}
+
+	return cfg, nil
+}
+
+func (p pipelineJSON) newSinkComponentConfig(
+	schemaVersions map[string]models.SchemaVersion,
+	explode models.ExplodeConfig,
+	enrichment models.EnrichmentConfig,
+) (zero models.SinkComponentConfig, _ error) {
 	sinkSourceID := p.sinkSourceID()
 
 	mappings := make([]models.Mapping, 0, len(p.Sink.Mapping))
@@ -571,15 +1119,35 @@ func (p pipelineJSON) newSinkComponentConfig(schemaVersions map[string]models.Sc
 		if !found {
 			return zero, fmt.Errorf("schema version for sink source_id %q not found", sinkSourceID)
 		}
+		// The explode, the enrichment and a keep_last dedup add their fields
+		// to the events the sink reads
+		sv.Fields = append(slices.Clone(sv.Fields), explode.OutputFields()...)
+		sv.Fields = append(sv.Fields, enrichment.OutputFields()...)
+		sv.Fields = append(sv.Fields, p.dedupVersionFields(sinkSourceID)...)
 		for _, m := range p.Sink.Mapping {
+			if m.Name == internal.SinkEventField {
+				if m.ColumnType != "" && !internal.IsJSONType(m.ColumnType) && m.ColumnType != internal.CHTypeObjectJSON {
+					return zero, fmt.Errorf("field %q (column %q): the whole event can only be mapped to a JSON column", m.Name, m.ColumnName)
+				}
+				mappings = append(mappings, models.Mapping{
+					SourceField:      internal.SinkEventField,
+					SourceType:       internal.KafkaTypeMap,
+					DestinationField: m.ColumnName,
+					DestinationType:  m.ColumnType,
+				})
+				continue
+			}
+
 			sourceField, ok := sv.GetField(m.Name)
 			if !ok {
 				return zero, fmt.Errorf("mapping field %q not found in schema for source_id %q", m.Name, sinkSourceID)
 			}
 
-			err := mapper.ValidateClickHouseColumnType(m.ColumnType)
-			if err != nil {
-				return zero, fmt.Errorf("field %q (column %q): %w", m.Name, m.ColumnName, err)
+			if m.ColumnType != "" {
+				err := mapper.ValidateClickHouseColumnType(m.ColumnType)
+				if err != nil {
+					return zero, fmt.Errorf("field %q (column %q): %w", m.Name, m.ColumnName, err)
+				}
 			}
 			mappings = append(mappings, models.Mapping{
 				SourceField:      sourceField.Name,
@@ -604,10 +1172,26 @@ func (p pipelineJSON) newSinkComponentConfig(schemaVersions map[string]models.Sc
 		Password:             p.Sink.ConnectionParams.Password,
 		Secure:               p.Sink.ConnectionParams.Secure,
 		SkipCertificateCheck: p.Sink.ConnectionParams.SkipCertificateVerification,
+		VaultRole:            p.Sink.ConnectionParams.VaultRole,
+		TLSRoot:              p.Sink.ConnectionParams.TLSRoot,
+		TLSCert:              p.Sink.ConnectionParams.TLSCert,
+		TLSKey:               p.Sink.ConnectionParams.TLSKey,
 		Table:                p.Sink.Table,
 		MaxBatchSize:         p.Sink.MaxBatchSize,
 		MaxDelayTime:         maxDelay,
 		Mappings:             mappings,
+		AutoCreateTable:      p.Sink.AutoCreateTable,
+		TableEngine:          p.Sink.TableEngine,
+		TableOrderBy:         p.Sink.TableOrderBy,
+		ErrorTable:           p.Sink.ErrorTable,
+		InsertDeduplication:  p.Sink.InsertDeduplication,
+		Staging:              p.Sink.Staging,
+		StagingTableSuffix:   p.Sink.StagingTableSuffix,
+		StagingDuration:      p.Sink.StagingDuration,
+		AsyncInsert:          p.Sink.AsyncInsert,
+		WaitForAsyncInsert:   p.Sink.WaitForAsyncInsert,
+		WriterConcurrency:    p.Sink.WriterConcurrency,
+		WarmUp:               p.Sink.WarmUp,
 	})
 	if err != nil {
 		return zero, fmt.Errorf("create sink config: %w", err)
@@ -692,6 +1276,32 @@ func (p pipelineJSON) dedupConfigsBySourceID() (map[string]transformParams, erro
 	return out, nil
 }
 
+// dedupVersionFields returns the version field a keep_last dedup adds to the
+// events of a source.
+func (p pipelineJSON) dedupVersionFields(sourceID string) []models.Field {
+	for _, t := range p.Transforms {
+		if t.Type != transformTypeDedup || t.SourceID != sourceID || !strings.EqualFold(t.Config.Mode, internal.DedupModeKeepLast) {
+			continue
+		}
+		name := strings.TrimSpace(t.Config.VersionField)
+		if name == "" {
+			name = internal.DefaultDedupVersionField
+		}
+		return []models.Field{{Name: name, Type: internal.KafkaTypeUint}}
+	}
+	return nil
+}
+
+func (p pipelineJSON) debeziumUnwrapConfigsBySourceID() map[string]transformParams {
+	out := make(map[string]transformParams)
+	for _, t := range p.Transforms {
+		if t.Type == transformTypeDebeziumUnwrap {
+			out[t.SourceID] = t.Config
+		}
+	}
+	return out
+}
+
 func (p pipelineJSON) findFilterTransform() (pipelineTransform, bool, error) {
 	for i, t := range p.Transforms {
 		if t.Type != transformTypeFilter {
@@ -715,6 +1325,24 @@ func (p pipelineJSON) findStatelessTransform() (pipelineTransform, bool, error)
 	return pipelineTransform{}, false, nil
 }
 
+func (p pipelineJSON) findExplodeTransform() (pipelineTransform, bool) {
+	for _, t := range p.Transforms {
+		if t.Type == transformTypeExplode {
+			return t, true
+		}
+	}
+	return pipelineTransform{}, false
+}
+
+func (p pipelineJSON) findEnrichmentTransform() (pipelineTransform, bool) {
+	for _, t := range p.Transforms {
+		if t.Type == transformTypeEnrichment {
+			return t, true
+		}
+	}
+	return pipelineTransform{}, false
+}
+
 func hasFieldNamed(fields []models.Field, name string) bool {
 	for _, f := range fields {
 		if f.Name == name {
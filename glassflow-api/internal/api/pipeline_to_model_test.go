@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -99,6 +100,249 @@ func TestToModel_KafkaSingleDedup(t *testing.T) {
 	}
 }
 
+func TestToModel_KafkaPublishDedup(t *testing.T) {
+	cfg := mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"topic": "orders",`,
+		`"topic": "orders", "publish_dedup": {"duplicate_window": "24h", "msg_id_strategy": "content_hash"},`, 1))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	topic := model.Ingestor.KafkaTopics[0]
+	if got := topic.PublishDedup.MsgIDStrategy; got != "content_hash" {
+		t.Errorf("PublishDedup.MsgIDStrategy = %q; want content_hash", got)
+	}
+	if got := topic.StreamDuplicateWindow(0); got != 24*time.Hour {
+		t.Errorf("StreamDuplicateWindow = %s; want 24h", got)
+	}
+
+	src := buildSources(model)[0]
+	if src.PublishDedup == nil || src.PublishDedup.MsgIDStrategy != "content_hash" || src.PublishDedup.DuplicateWindow.Duration() != 24*time.Hour {
+		t.Errorf("buildSources PublishDedup = %+v; want content_hash with 24h window", src.PublishDedup)
+	}
+}
+
+func TestToModel_DedupKeepLast(t *testing.T) {
+	cfg := mustParseJSON(t, strings.NewReplacer(
+		`"config": {"key": "order_id", "time_window": "1h"}`,
+		`"config": {"key": "order_id", "time_window": "1h", "mode": "keep_last"}`,
+		`{"name": "amount",   "column_name": "amount",   "column_type": "Int32"}`,
+		`{"name": "amount",   "column_name": "amount",   "column_type": "Int32"},
+      {"name": "_version", "column_name": "version",  "column_type": "UInt64"}`,
+	).Replace(kafkaSingleDedupJSON))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	dedup := model.Ingestor.KafkaTopics[0].Deduplication
+	if !dedup.KeepsLast() || dedup.VersionField != "_version" {
+		t.Errorf("Deduplication = %+v; want keep_last with the _version field", dedup)
+	}
+	if got := model.Ingestor.KafkaTopics[0].StreamDuplicateWindow(0); got != 0 {
+		t.Errorf("StreamDuplicateWindow = %s; want 0", got)
+	}
+	if len(model.Sink.Config) != 3 || model.Sink.Config[2].SourceType != "uint" {
+		t.Errorf("Sink.Config = %+v; want the version field mapped", model.Sink.Config)
+	}
+
+	tr := buildTransforms(model)[0]
+	if tr.Config.Mode != "keep_last" || tr.Config.VersionField != "_version" {
+		t.Errorf("buildTransforms dedup = %+v; want keep_last", tr.Config)
+	}
+}
+
+func TestToModel_DedupInvalidMode(t *testing.T) {
+	cfg := mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"config": {"key": "order_id", "time_window": "1h"}`,
+		`"config": {"key": "order_id", "time_window": "1h", "mode": "keep_any"}`, 1))
+
+	_, err := cfg.toModel()
+	if err == nil || !strings.Contains(err.Error(), "invalid dedup mode") {
+		t.Fatalf("toModel error = %v; want invalid dedup mode", err)
+	}
+}
+
+func TestToModel_DedupDomain(t *testing.T) {
+	cfg := mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"config": {"key": "order_id", "time_window": "1h"}`,
+		`"config": {"key": "order_id", "time_window": "1h", "domain": "orders"}`, 1))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	if got := model.Ingestor.KafkaTopics[0].Deduplication.Domain; got != "orders" {
+		t.Errorf("Deduplication.Domain = %q; want orders", got)
+	}
+	if tr := buildTransforms(model)[0]; tr.Config.Domain != "orders" {
+		t.Errorf("buildTransforms dedup = %+v; want the orders domain", tr.Config)
+	}
+
+	cfg = mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"config": {"key": "order_id", "time_window": "1h"}`,
+		`"config": {"key": "order_id", "time_window": "1h", "domain": "orders.eu"}`, 1))
+	_, err = cfg.toModel()
+	if err == nil || !strings.Contains(err.Error(), "invalid deduplication domain") {
+		t.Fatalf("toModel error = %v; want invalid deduplication domain", err)
+	}
+}
+
+func TestToModel_StatelessUDFWithExpression(t *testing.T) {
+	cfg := mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"transforms": [`,
+		`"transforms": [
+    {"type": "stateless", "source_id": "orders", "config": {
+      "udf": {"runtime": "wasm", "module": "AGFzbQEAAAA="},
+      "transforms": [{"expression": "order_id", "output_name": "order_id", "output_type": "string"}]
+    }},`, 1))
+
+	_, err := cfg.toModel()
+	if err == nil || !strings.Contains(err.Error(), "cannot have an expression") {
+		t.Fatalf("toModel error = %v; want udf transforms without expression", err)
+	}
+}
+
+func TestToModel_KafkaDebeziumUnwrap(t *testing.T) {
+	cfg := mustParseJSON(t, strings.Replace(kafkaSingleDedupJSON,
+		`"transforms": [`,
+		`"transforms": [
+    {"type": "debezium_unwrap", "source_id": "orders", "config": {"delete_handling": "drop"}},`, 1))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	unwrap := model.Ingestor.KafkaTopics[0].DebeziumUnwrap
+	if !unwrap.Enabled || unwrap.DeleteHandling != "drop" {
+		t.Errorf("DebeziumUnwrap = %+v; want enabled with drop delete handling", unwrap)
+	}
+	if unwrap.OpField != "_op" || unwrap.TimestampField != "_ts" {
+		t.Errorf("DebeziumUnwrap fields = %q, %q; want _op, _ts", unwrap.OpField, unwrap.TimestampField)
+	}
+
+	var found bool
+	for _, tr := range buildTransforms(model) {
+		if tr.Type == transformTypeDebeziumUnwrap && tr.SourceID == "orders" {
+			found = tr.Config.DeleteHandling == "drop"
+		}
+	}
+	if !found {
+		t.Error("buildTransforms should return the debezium_unwrap transform")
+	}
+}
+
+func TestToModel_KafkaEnrichment(t *testing.T) {
+	withEnrichment := strings.Replace(kafkaSingleDedupJSON,
+		`"transforms": [`,
+		`"transforms": [
+    {"type": "enrichment", "source_id": "orders", "config": {
+      "table": "customers", "key": "order_id", "key_column": "id", "mode": "lookup",
+      "columns": [{"column": "name", "output_name": "customer_name", "output_type": "string"}]
+    }},`, 1)
+	cfg := mustParseJSON(t, strings.Replace(withEnrichment,
+		`"mapping": [`,
+		`"mapping": [
+      {"name": "customer_name", "column_name": "customer_name", "column_type": "String"},`, 1))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	enrichment := model.Enrichment
+	if !enrichment.Enabled || enrichment.SourceID != "orders" || enrichment.KeyField != "order_id" {
+		t.Errorf("Enrichment = %+v; want enabled on orders with key order_id", enrichment)
+	}
+	if enrichment.CacheTTL.Duration() != time.Minute || enrichment.CacheMaxEntries != 100_000 {
+		t.Errorf("Enrichment cache = %s, %d; want the lookup defaults", enrichment.CacheTTL.Duration(), enrichment.CacheMaxEntries)
+	}
+	if len(model.Sink.Config) != 3 || model.Sink.Config[0].SourceField != "customer_name" {
+		t.Errorf("Sink.Config = %+v; want the enrichment field mapped", model.Sink.Config)
+	}
+	if len(model.SchemaVersions["orders"].Fields) != 2 {
+		t.Error("enrichment fields should not be added to the source schema")
+	}
+
+	var found bool
+	for _, tr := range buildTransforms(model) {
+		if tr.Type == transformTypeEnrichment {
+			found = tr.Config.Key == "order_id" && tr.Config.Table == "customers"
+		}
+	}
+	if !found {
+		t.Error("buildTransforms should return the enrichment transform")
+	}
+
+	for name, input := range map[string]string{
+		"unknown_key":      strings.Replace(withEnrichment, `"key": "order_id", "key_column"`, `"key": "customer_id", "key_column"`, 1),
+		"existing_field":   strings.Replace(withEnrichment, `"output_name": "customer_name"`, `"output_name": "amount"`, 1),
+		"unsupported_mode": strings.Replace(withEnrichment, `"mode": "lookup"`, `"mode": "stream"`, 1),
+	} {
+		if _, err := mustParseJSON(t, input).toModel(); err == nil {
+			t.Errorf("%s: toModel should fail", name)
+		}
+	}
+}
+
+func TestToModel_KafkaExplode(t *testing.T) {
+	withLines := strings.Replace(kafkaSingleDedupJSON,
+		`{"name": "amount",   "type": "int"}`,
+		`{"name": "amount",   "type": "int"},
+        {"name": "lines",    "type": "array"}`, 1)
+	withExplode := strings.Replace(withLines,
+		`"transforms": [`,
+		`"transforms": [
+    {"type": "explode", "source_id": "orders", "config": {
+      "field": "lines", "output_name": "line", "index_field": "line_number",
+      "fields": [{"name": "sku", "type": "string"}]
+    }},`, 1)
+	cfg := mustParseJSON(t, strings.Replace(withExplode,
+		`"mapping": [`,
+		`"mapping": [
+      {"name": "line.sku", "column_name": "sku", "column_type": "String"},
+      {"name": "line_number", "column_name": "line_number", "column_type": "UInt32"},`, 1))
+
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+
+	explode := model.Explode
+	if !explode.Enabled || explode.Field != "lines" || explode.OutputName != "line" {
+		t.Errorf("Explode = %+v; want enabled on lines with output line", explode)
+	}
+	if len(model.Sink.Config) != 4 || model.Sink.Config[0].SourceField != "line.sku" {
+		t.Errorf("Sink.Config = %+v; want the element fields mapped", model.Sink.Config)
+	}
+
+	var found bool
+	for _, tr := range buildTransforms(model) {
+		if tr.Type == transformTypeExplode {
+			found = tr.Config.Field == "lines" && tr.Config.IndexField == "line_number" && len(tr.Config.Fields) == 1
+		}
+	}
+	if !found {
+		t.Error("buildTransforms should return the explode transform")
+	}
+
+	for name, input := range map[string]string{
+		"unknown_field":   strings.Replace(withExplode, `"field": "lines"`, `"field": "items"`, 1),
+		"not_an_array":    strings.Replace(withExplode, `"field": "lines"`, `"field": "amount"`, 1),
+		"existing_index":  strings.Replace(withExplode, `"index_field": "line_number"`, `"index_field": "amount"`, 1),
+		"no_element_type": strings.Replace(withExplode, `"fields": [{"name": "sku", "type": "string"}]`, `"fields": []`, 1),
+	} {
+		if _, err := mustParseJSON(t, input).toModel(); err == nil {
+			t.Errorf("%s: toModel should fail", name)
+		}
+	}
+}
+
 const kafkaJoinJSON = `{
   "version": "v3",
   "pipeline_id": "join-pipeline",
@@ -188,6 +432,187 @@ func TestToModel_KafkaJoin(t *testing.T) {
 	}
 }
 
+func TestToModel_KafkaJoinFilter(t *testing.T) {
+	withFilter := func(expression string) string {
+		return strings.Replace(kafkaJoinJSON,
+			`"join": {`,
+			`"transforms": [
+    {"type": "filter", "source_id": "orders", "config": {"expression": "`+expression+`"}}
+  ],
+  "join": {`, 1)
+	}
+
+	model, err := mustParseJSON(t, withFilter(`ORDER_ID != '' && email != ''`)).toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.Filter.Enabled || model.Filter.Expression != `ORDER_ID != '' && email != ''` {
+		t.Errorf("Filter = %+v; want the expression over the joined fields", model.Filter)
+	}
+	if model.TransformFilterEnabled() {
+		t.Error("TransformFilterEnabled() = true; want the filter on the join output")
+	}
+
+	// The fields of the sources are not in the joined events
+	if _, err := mustParseJSON(t, withFilter(`customer_id != ''`)).toModel(); err == nil {
+		t.Error("toModel should fail on a filter over a source field")
+	}
+}
+
+func TestToModel_KafkaQuery(t *testing.T) {
+	withoutMapping := strings.Replace(kafkaSingleDedupJSON,
+		`"mapping": [
+      {"name": "order_id", "column_name": "order_id", "column_type": "String"},
+      {"name": "amount",   "column_name": "amount",   "column_type": "Int32"}
+    ]`,
+		`"mapping": []`, 1)
+	withQuery := func(query string) string {
+		return strings.Replace(withoutMapping, `"transforms": [`, `"query": "`+query+`",
+  "transforms": [`, 1)
+	}
+
+	model, err := mustParseJSON(t, withQuery(`SELECT order_id, amount * 100 AS cents FROM orders WHERE amount > 10`)).toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.Filter.Enabled || model.Filter.Expression != "amount > 10" {
+		t.Errorf("Filter = %+v; want the WHERE clause", model.Filter)
+	}
+	transforms := model.StatelessTransformation.Config.Transform
+	if len(transforms) != 2 || transforms[1].Expression != "amount * 100" || transforms[1].OutputType != "int" {
+		t.Errorf("Transform = %+v; want the selected columns with their types", transforms)
+	}
+	if model.Sink.SourceID != model.StatelessTransformation.ID {
+		t.Errorf("Sink.SourceID = %q; want the stateless output", model.Sink.SourceID)
+	}
+	if len(model.Sink.Config) != 2 || model.Sink.Config[1].DestinationField != "cents" {
+		t.Errorf("Sink.Config = %+v; want the columns mapped by alias", model.Sink.Config)
+	}
+
+	// Plain fields map straight from the source
+	model, err = mustParseJSON(t, withQuery(`SELECT * FROM orders`)).toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if model.StatelessTransformation.Enabled || model.Filter.Enabled {
+		t.Error("SELECT * without WHERE should need no filter nor transformation")
+	}
+	if len(model.Sink.Config) != 2 || model.Sink.SourceID != "orders" {
+		t.Errorf("Sink.Config = %+v; want the source fields", model.Sink.Config)
+	}
+
+	for _, query := range []string{
+		`SELECT order_id FROM payments`,
+		`SELECT order_id FROM orders WHERE missing > 1`,
+		`SELECT amount + 1 FROM orders`,
+	} {
+		if _, err := mustParseJSON(t, withQuery(query)).toModel(); err == nil {
+			t.Errorf("toModel should fail on query %q", query)
+		}
+	}
+}
+
+const kafkaChainedJoinJSON = `{
+  "version": "v3",
+  "pipeline_id": "chain-pipeline",
+  "name": "Chained Join Pipeline",
+  "sources": [
+    {
+      "type": "kafka", "source_id": "orders",
+      "connection_params": {"brokers": ["localhost:9092"], "protocol": "PLAINTEXT", "mechanism": "NO_AUTH"},
+      "topic": "orders",
+      "schema_fields": [
+        {"name": "order_id",    "type": "string"},
+        {"name": "customer_id", "type": "string"}
+      ]
+    },
+    {
+      "type": "kafka", "source_id": "users",
+      "connection_params": {"brokers": ["localhost:9092"], "protocol": "PLAINTEXT", "mechanism": "NO_AUTH"},
+      "topic": "users",
+      "schema_fields": [
+        {"name": "user_id", "type": "string"},
+        {"name": "email",   "type": "string"}
+      ]
+    },
+    {
+      "type": "kafka", "source_id": "payments",
+      "connection_params": {"brokers": ["localhost:9092"], "protocol": "PLAINTEXT", "mechanism": "NO_AUTH"},
+      "topic": "payments",
+      "schema_fields": [
+        {"name": "order_ref", "type": "string"},
+        {"name": "amount",    "type": "float64"}
+      ]
+    }
+  ],
+  "join": {
+    "enabled": true,
+    "type": "temporal",
+    "left_source":  {"source_id": "orders", "key": "customer_id", "time_window": "30s"},
+    "right_source": {"source_id": "users",  "key": "user_id",     "time_window": "30s"},
+    "chain": [
+      {"source_id": "payments", "key": "order_ref", "time_window": "1m", "left_source_id": "orders", "left_key": "order_id"}
+    ],
+    "output_fields": [
+      {"source_id": "orders",   "name": "order_id", "output_name": "ORDER_ID"},
+      {"source_id": "users",    "name": "email"},
+      {"source_id": "payments", "name": "amount"}
+    ]
+  },
+  "sink": {
+    "type": "clickhouse",
+    "connection_params": {"host": "localhost", "port": "9000", "http_port": "8123", "database": "db", "username": "u", "password": "p", "secure": false},
+    "table": "joined",
+    "max_batch_size": 500,
+    "max_delay_time": "2s",
+    "mapping": [
+      {"name": "ORDER_ID", "column_name": "order_id", "column_type": "String"},
+      {"name": "email",    "column_name": "email",    "column_type": "String"},
+      {"name": "amount",   "column_name": "amount",   "column_type": "Float64"}
+    ]
+  }
+}`
+
+func TestToModel_KafkaChainedJoin(t *testing.T) {
+	cfg := mustParseJSON(t, kafkaChainedJoinJSON)
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if len(model.Join.Chain) != 1 || model.Join.Chain[0].LeftJoinKey != "order_id" {
+		t.Fatalf("Join.Chain = %+v; want payments joined on orders.order_id", model.Join.Chain)
+	}
+
+	// The intermediate output carries the prefixed fields of the first stage
+	stageID := "chain-pipeline-join-1"
+	sv, ok := model.SchemaVersions[stageID]
+	if !ok {
+		t.Fatalf("SchemaVersions missing intermediate join output key %q", stageID)
+	}
+	for _, name := range []string{"orders.order_id", "users.email"} {
+		if !sv.HasField(name) {
+			t.Errorf("SchemaVersions[%s] missing field %q", stageID, name)
+		}
+	}
+
+	joinID := "chain-pipeline-join"
+	out, ok := model.SchemaVersions[joinID]
+	if !ok {
+		t.Fatalf("SchemaVersions missing join output key %q", joinID)
+	}
+	if field, found := out.GetField("amount"); !found || field.Type != "float64" {
+		t.Errorf("join output field amount = %+v, %v; want float64", field, found)
+	}
+	if model.Sink.SourceID != joinID {
+		t.Errorf("Sink.SourceID = %q; want %q", model.Sink.SourceID, joinID)
+	}
+
+	back := buildJoin(model)
+	if len(back.Chain) != 1 || back.Chain[0].LeftKey != "order_id" {
+		t.Errorf("buildJoin chain = %+v; want the chained payments source", back.Chain)
+	}
+}
+
 const otlpJSON = `{
   "version": "v3",
   "pipeline_id": "otlp-pipeline",
@@ -230,6 +655,236 @@ func TestToModel_OTLPTracesDedup(t *testing.T) {
 	}
 }
 
+const pulsarJSON = `{
+  "version": "v3",
+  "pipeline_id": "my-pipeline",
+  "name": "My Pipeline",
+  "sources": [
+    {
+      "type": "pulsar",
+      "source_id": "orders",
+      "pulsar_connection_params": {
+        "service_url": "pulsar+ssl://pulsar:6651",
+        "auth_token": "token"
+      },
+      "topic": "persistent://public/default/orders",
+      "subscription_type": "key_shared",
+      "schema_fields": [
+        {"name": "order_id", "type": "string"}
+      ]
+    }
+  ],
+  "transforms": [
+    {
+      "type": "dedup",
+      "source_id": "orders",
+      "config": {"key": "order_id", "time_window": "1h"}
+    }
+  ],
+  "sink": {
+    "type": "clickhouse",
+    "connection_params": {
+      "host": "localhost", "port": "9000", "http_port": "8123",
+      "database": "db", "username": "default", "password": "secret",
+      "secure": false
+    },
+    "table": "orders",
+    "max_batch_size": 1000,
+    "max_delay_time": "1s",
+    "mapping": [
+      {"name": "order_id", "column_name": "order_id", "column_type": "String"}
+    ]
+  }
+}`
+
+func TestToModel_PulsarSource(t *testing.T) {
+	cfg := mustParseJSON(t, pulsarJSON)
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.SourceType.IsPulsar() {
+		t.Errorf("SourceType = %q; want pulsar", model.SourceType)
+	}
+	if model.Ingestor.Type != "pulsar" {
+		t.Errorf("Ingestor.Type = %q; want pulsar", model.Ingestor.Type)
+	}
+	if model.Ingestor.PulsarConnectionParams.AuthToken != "token" {
+		t.Errorf("AuthToken = %q; want token", model.Ingestor.PulsarConnectionParams.AuthToken)
+	}
+	if got := len(model.Ingestor.KafkaTopics); got != 1 {
+		t.Fatalf("KafkaTopics len = %d; want 1", got)
+	}
+	topic := model.Ingestor.KafkaTopics[0]
+	if topic.SubscriptionType != "key_shared" {
+		t.Errorf("SubscriptionType = %q; want key_shared", topic.SubscriptionType)
+	}
+	if topic.ConsumerGroupName == "" {
+		t.Error("ConsumerGroupName should default to the pipeline consumer group")
+	}
+	if !topic.Deduplication.Enabled || topic.Deduplication.ID != "order_id" {
+		t.Errorf("Deduplication = %+v; want enabled, id=order_id", topic.Deduplication)
+	}
+
+	back := toJSON(model)
+	if len(back.Sources) != 1 || back.Sources[0].PulsarConnectionParams == nil {
+		t.Fatalf("toJSON sources = %+v; want one pulsar source", back.Sources)
+	}
+	if back.Sources[0].ConnectionParams != nil {
+		t.Error("pulsar source should not have kafka connection_params")
+	}
+	if back.Sources[0].Subscription != topic.ConsumerGroupName {
+		t.Errorf("Subscription = %q; want %q", back.Sources[0].Subscription, topic.ConsumerGroupName)
+	}
+}
+
+const mysqlJSON = `{
+  "version": "v3",
+  "pipeline_id": "my-pipeline",
+  "name": "My Pipeline",
+  "sources": [
+    {
+      "type": "mysql",
+      "source_id": "orders",
+      "mysql_connection_params": {
+        "host": "mysql",
+        "username": "repl",
+        "password": "secret"
+      },
+      "database": "shop",
+      "table": "orders",
+      "schema_fields": [
+        {"name": "order_id", "type": "string"},
+        {"name": "__op", "type": "string"}
+      ]
+    }
+  ],
+  "sink": {
+    "type": "clickhouse",
+    "connection_params": {
+      "host": "localhost", "port": "9000", "http_port": "8123",
+      "database": "db", "username": "default", "password": "secret",
+      "secure": false
+    },
+    "table": "orders",
+    "max_batch_size": 1000,
+    "max_delay_time": "1s",
+    "mapping": [
+      {"name": "order_id", "column_name": "order_id", "column_type": "String"}
+    ]
+  }
+}`
+
+func TestToModel_MySQLSource(t *testing.T) {
+	cfg := mustParseJSON(t, mysqlJSON)
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.SourceType.IsMySQL() {
+		t.Errorf("SourceType = %q; want mysql", model.SourceType)
+	}
+	if model.Ingestor.Type != "mysql" {
+		t.Errorf("Ingestor.Type = %q; want mysql", model.Ingestor.Type)
+	}
+	if model.Ingestor.MySQLConnectionParams.Port != 3306 {
+		t.Errorf("Port = %d; want default 3306", model.Ingestor.MySQLConnectionParams.Port)
+	}
+	if got := len(model.Ingestor.KafkaTopics); got != 1 {
+		t.Fatalf("KafkaTopics len = %d; want 1", got)
+	}
+	topic := model.Ingestor.KafkaTopics[0]
+	if topic.Name != "shop.orders" {
+		t.Errorf("topic Name = %q; want shop.orders", topic.Name)
+	}
+	if topic.ConsumerGroupInitialOffset != "latest" {
+		t.Errorf("ConsumerGroupInitialOffset = %q; want latest", topic.ConsumerGroupInitialOffset)
+	}
+
+	back := toJSON(model)
+	if len(back.Sources) != 1 || back.Sources[0].MySQLConnectionParams == nil {
+		t.Fatalf("toJSON sources = %+v; want one mysql source", back.Sources)
+	}
+	src := back.Sources[0]
+	if src.ConnectionParams != nil || src.Topic != "" {
+		t.Errorf("mysql source should not have kafka connection_params or topic, got %+v", src)
+	}
+	if src.Database != "shop" || src.Table != "orders" {
+		t.Errorf("Database, Table = %q, %q; want shop, orders", src.Database, src.Table)
+	}
+}
+
+const httpJSON = `{
+  "version": "v3",
+  "pipeline_id": "my-pipeline",
+  "name": "My Pipeline",
+  "sources": [
+    {
+      "type": "http",
+      "source_id": "events",
+      "schema_fields": [
+        {"name": "event_id", "type": "string"}
+      ]
+    }
+  ],
+  "transforms": [
+    {
+      "type": "dedup",
+      "source_id": "events",
+      "config": {"key": "event_id", "time_window": "1h"}
+    }
+  ],
+  "sink": {
+    "type": "clickhouse",
+    "connection_params": {
+      "host": "localhost", "port": "9000", "http_port": "8123",
+      "database": "db", "username": "default", "password": "secret",
+      "secure": false
+    },
+    "table": "events",
+    "max_batch_size": 1000,
+    "max_delay_time": "1s",
+    "mapping": [
+      {"name": "event_id", "column_name": "event_id", "column_type": "String"}
+    ]
+  }
+}`
+
+func TestToModel_HTTPSource(t *testing.T) {
+	cfg := mustParseJSON(t, httpJSON)
+	model, err := cfg.toModel()
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.SourceType.IsHTTP() {
+		t.Errorf("SourceType = %q; want http", model.SourceType)
+	}
+	if len(model.Ingestor.KafkaTopics) != 0 {
+		t.Errorf("KafkaTopics len = %d; want 0", len(model.Ingestor.KafkaTopics))
+	}
+	if model.OTLPSource.ID != "events" {
+		t.Errorf("source ID = %q; want events", model.OTLPSource.ID)
+	}
+	if !model.OTLPSource.Deduplication.Enabled || model.OTLPSource.Deduplication.ID != "event_id" {
+		t.Errorf("Deduplication = %+v; want enabled, id=event_id", model.OTLPSource.Deduplication)
+	}
+	sv, ok := model.SchemaVersions["events"]
+	if !ok || sv.VersionID != "1" || len(sv.Fields) != 1 {
+		t.Errorf("SchemaVersions[events] = %+v; want version 1 with the declared fields", sv)
+	}
+
+	back := toJSON(model)
+	if len(back.Sources) != 1 || back.Sources[0].Type != "http" {
+		t.Fatalf("toJSON sources = %+v; want one http source", back.Sources)
+	}
+	if len(back.Sources[0].SchemaFields) != 1 {
+		t.Errorf("SchemaFields = %+v; want the declared fields", back.Sources[0].SchemaFields)
+	}
+	if len(back.Transforms) != 1 || back.Transforms[0].Type != transformTypeDedup {
+		t.Errorf("Transforms = %+v; want one dedup transform", back.Transforms)
+	}
+}
+
 func TestToModel_ValidationErrors(t *testing.T) {
 	const sinkJSON = `"sink": {"type": "clickhouse", "connection_params": {"host": "h", "port": "9000", "http_port": "8123", "database": "d", "username": "u", "password": "p", "secure": false}, "table": "t", "max_batch_size": 1, "max_delay_time": "1s", "mapping": [{"name": "x", "column_name": "x", "column_type": "String"}]}`
 	const kafkaConn = `"connection_params": {"brokers": ["b"], "protocol": "PLAINTEXT", "mechanism": "NO_AUTH"}`
@@ -278,6 +933,66 @@ func TestToModel_ValidationErrors(t *testing.T) {
   ],
   "join": {"enabled": true, "type": "temporal", "left_source": {"source_id": "a", "key": "k", "time_window": "1s"}, "right_source": {"source_id": "b", "key": "k", "time_window": "1s"}},
   ` + sinkJSON + `
+}`,
+		"pulsar_without_connection_params": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "pulsar", "source_id": "a", "topic": "t1"}],
+  ` + sinkJSON + `
+}`,
+		"mixed_kafka_and_pulsar": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [
+    {"type": "kafka", "source_id": "a", ` + kafkaConn + `, "topic": "t1"},
+    {"type": "pulsar", "source_id": "b", "pulsar_connection_params": {"service_url": "pulsar://p:6650"}, "topic": "t2"}
+  ],
+  ` + sinkJSON + `
+}`,
+		"mysql_without_table": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "mysql", "source_id": "a", "mysql_connection_params": {"host": "m", "username": "u"}, "database": "shop", "schema_fields": [{"name": "x", "type": "string"}]}],
+  ` + sinkJSON + `
+}`,
+		"mixed_kafka_and_mysql": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [
+    {"type": "kafka", "source_id": "a", ` + kafkaConn + `, "topic": "t1"},
+    {"type": "mysql", "source_id": "b", "mysql_connection_params": {"host": "m", "username": "u"}, "database": "shop", "table": "orders"}
+  ],
+  ` + sinkJSON + `
+}`,
+		"http_with_topic": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "http", "source_id": "a", "topic": "t1", "schema_fields": [{"name": "x", "type": "string"}]}],
+  ` + sinkJSON + `
+}`,
+		"http_without_schema_fields": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "http", "source_id": "a"}],
+  ` + sinkJSON + `
+}`,
+		"mixed_kafka_and_http": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [
+    {"type": "kafka", "source_id": "a", ` + kafkaConn + `, "topic": "t1"},
+    {"type": "http", "source_id": "b", "schema_fields": [{"name": "x", "type": "string"}]}
+  ],
+  ` + sinkJSON + `
+}`,
+		"http_with_publish_dedup": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "http", "source_id": "a", "publish_dedup": {"duplicate_window": "1h"}, "schema_fields": [{"name": "x", "type": "string"}]}],
+  ` + sinkJSON + `
+}`,
+		"http_with_debezium_unwrap": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "http", "source_id": "a", "schema_fields": [{"name": "x", "type": "string"}]}],
+  "transforms": [{"type": "debezium_unwrap", "source_id": "a", "config": {}}],
+  ` + sinkJSON + `
+}`,
+		"kafka_invalid_msg_id_strategy": `{
+  "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
+  "sources": [{"type": "kafka", "source_id": "a", ` + kafkaConn + `, "topic": "t1", "publish_dedup": {"msg_id_strategy": "random"}, "schema_fields": [{"name": "x", "type": "string"}]}],
+  ` + sinkJSON + `
 }`,
 		"otlp_with_connection_params": `{
   "version": "v3", "pipeline_id": "my-pipeline", "name": "x",
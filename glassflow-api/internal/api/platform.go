@@ -23,6 +23,9 @@ type PlatformResponse struct {
 type PlatformInfo struct {
 	Orchestrator string `json:"orchestrator" doc:"Type of orchestrator being used"`
 	APIVersion   string `json:"api_version,omitempty" doc:"API version"`
+	ReadOnly     bool   `json:"read_only,omitempty" doc:"Whether this API instance rejects changes"`
+	PrimaryURL   string `json:"primary_url,omitempty" doc:"URL of the primary API of a read-only instance"`
+	Edition      string `json:"edition,omitempty" doc:"Edition the license grants, open_source or enterprise"`
 }
 
 func (h *handler) platform(_ context.Context, _ *struct{}) (*PlatformResponse, error) {
@@ -34,8 +37,13 @@ func (h *handler) platform(_ context.Context, _ *struct{}) (*PlatformResponse, e
 	resp := &PlatformResponse{
 		Body: PlatformInfo{
 			Orchestrator: orchType,
+			ReadOnly:     h.readOnly,
+			PrimaryURL:   h.primaryURL,
 		},
 	}
+	if h.license != nil {
+		resp.Body.Edition = string(h.license.Edition)
+	}
 	// API version is not currently available, so we'll skip it
 	// resp.Body.APIVersion = "v1"
 
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
+)
+
+// RouterOption configures optional behaviour of the router.
+type RouterOption func(*routerOptions)
+
+type routerOptions struct {
+	readOnly         bool
+	primaryURL       string
+	storageHealth    HealthChecker
+	featureFlags     FeatureFlags
+	componentReports ComponentReports
+	license          *license.License
+	accessLog        *AccessLogConfig
+	auth             *auth.Authenticator
+	auditLog         audit.Store
+	connectionHealth ConnectionHealthReader
+	schemaMigrations SchemaMigrations
+	pipelineArchive  PipelineArchive
+}
+
+// WithReadOnly serves the API of a standby instance reading a Postgres
+// replica. Requests that would change state are rejected with 503 and point
+// to the primary API at primaryURL.
+func WithReadOnly(primaryURL string) RouterOption {
+	return func(o *routerOptions) {
+		o.readOnly = true
+		o.primaryURL = primaryURL
+	}
+}
+
+// mutatingOperation reports whether an operation changes state, a read-only
+// API rejects them.
+func mutatingOperation(op *huma.Operation) bool {
+	switch op.OperationID {
+	case "consume-pipeline-dlq":
+		// Consuming acknowledges the returned DLQ messages
+		return true
+	case "validate-filter-expression", "evaluate-transformation-expression", "migrate-pipeline-preview":
+		return false
+	}
+	return op.Method != http.MethodGet && op.Method != http.MethodHead
+}
+
+// readOnlyMiddleware rejects the mutating operations. It must be added to
+// the API before the operations are registered.
+func readOnlyMiddleware(primaryURL string) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if !mutatingOperation(ctx.Operation()) {
+			next(ctx)
+			return
+		}
+
+		details := map[string]any{}
+		if primaryURL != "" {
+			details["primary_url"] = primaryURL
+		}
+
+		writeErrorDetail(ctx, &ErrorDetail{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "read_only",
+			Message: "this API instance is read-only, send changes to the primary",
+			Details: details,
+		})
+	}
+}
+
+// writeErrorDetail responds with detail from a middleware, which cannot
+// return errors like the operations do.
+func writeErrorDetail(ctx huma.Context, detail *ErrorDetail) {
+	ctx.SetHeader("Content-Type", "application/json")
+	ctx.SetStatus(detail.Status)
+	_ = json.NewEncoder(ctx.BodyWriter()).Encode(detail)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/api/mocks"
+)
+
+func TestReadOnlyRouter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mutations must not reach the service
+	mockPipelineService := mocks.NewMockPipelineService(ctrl)
+	mockPipelineService.EXPECT().GetOrchestratorType().Return("k8s")
+
+	router := NewRouter(slog.Default(), mockPipelineService, nil, nil, nil, nil, WithReadOnly("https://primary.example.com"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/platform", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var platform PlatformInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &platform))
+	assert.True(t, platform.ReadOnly)
+	assert.Equal(t, "https://primary.example.com", platform.PrimaryURL)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/v1/pipeline/p-1/stop", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/pipeline/p-1", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/pipeline/p-1/dlq/consume", nil),
+	} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, "%s %s", req.Method, req.URL.Path)
+
+		var body ErrorDetail
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "read_only", body.Code)
+		assert.Equal(t, "https://primary.example.com", body.Details["primary_url"])
+	}
+
+	// Validations do not change state
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/filter/validate",
+		strings.NewReader(`{"expression":"age > 18","fields":[{"field_name":"age","field_type":"int"}]}`)))
+	assert.NotEqual(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/danielgtaylor/huma/v2"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
 )
@@ -16,12 +17,13 @@ func ResumePipelineDocs() huma.Operation {
 		OperationID: "resume-pipeline",
 		Method:      http.MethodPost,
 		Summary:     "Resume a pipeline",
-		Description: "Resumes a paused or stopped pipeline",
+		Description: "Resumes a paused or stopped pipeline. A Kafka pipeline whose brokers point at another cluster than the one recorded at its creation is refused unless forced",
 	}
 }
 
 type ResumePipelineInput struct {
-	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	ID    string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Force bool   `query:"force" doc:"Accept the Kafka cluster the brokers now point at and record it for the pipeline. Its consumer group offsets are read from that cluster"`
 }
 
 type ResumePipelineResponse struct {
@@ -29,7 +31,7 @@ type ResumePipelineResponse struct {
 }
 
 func (h *handler) resumePipeline(ctx context.Context, input *ResumePipelineInput) (*ResumePipelineResponse, error) {
-	err := h.pipelineService.ResumePipeline(ctx, input.ID)
+	err := h.pipelineService.ResumePipeline(ctx, input.ID, models.ResumeOptions{Force: input.Force})
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrPipelineNotExists):
@@ -42,6 +44,36 @@ func (h *handler) resumePipeline(ctx context.Context, input *ResumePipelineInput
 					"error":       err.Error(),
 				},
 			}
+		case errors.Is(err, service.ErrInvalidDependencies):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "invalid pipeline dependencies",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrDependencyNotRunning):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "dependency_not_running",
+				Message: "a pipeline this pipeline depends on is not running",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrKafkaClusterChanged):
+			return nil, &ErrorDetail{
+				Status:  http.StatusConflict,
+				Code:    "kafka_cluster_changed",
+				Message: "kafka brokers point at a different cluster than at pipeline creation, resume with force to accept it",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
 		case errors.Is(err, service.ErrNotImplemented):
 			return nil, &ErrorDetail{
 				Status:  http.StatusNotImplemented,
@@ -12,6 +12,11 @@ import (
 	"github.com/danielgtaylor/huma/v2/adapters/humamux"
 	"github.com/gorilla/mux"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/httpingest"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/usagestats"
 )
 
@@ -32,6 +37,19 @@ type handler struct {
 	dlqSvc           DLQ
 	api              huma.API
 	usageStatsClient *usagestats.Client
+	notifier         *notification.Notifier
+	eventProcessor   httpingest.EventProcessor
+	readOnly         bool
+	primaryURL       string
+	storageHealth    HealthChecker
+	featureFlags     FeatureFlags
+	componentReports ComponentReports
+	license          *license.License
+	auth             *auth.Authenticator
+	auditLog         audit.Store
+	connectionHealth ConnectionHealthReader
+	schemaMigrations SchemaMigrations
+	pipelineArchive  PipelineArchive
 }
 
 func NewRouter(
@@ -39,7 +57,15 @@ func NewRouter(
 	pipelineService PipelineService,
 	dlqService DLQ,
 	usageStatsClient *usagestats.Client,
+	notifier *notification.Notifier,
+	eventProcessor httpingest.EventProcessor,
+	opts ...RouterOption,
 ) http.Handler {
+	var options routerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	r := mux.NewRouter()
 
 	config := huma.DefaultConfig("GlassFlow API", "1.0.0")
@@ -63,6 +89,9 @@ func NewRouter(
 	}
 
 	humaAPI := humamux.New(r, config)
+	if options.readOnly {
+		humaAPI.UseMiddleware(readOnlyMiddleware(options.primaryURL))
+	}
 
 	h := handler{
 		log:              log,
@@ -70,21 +99,53 @@ func NewRouter(
 		dlqSvc:           dlqService,
 		api:              humaAPI,
 		usageStatsClient: usageStatsClient,
+		notifier:         notifier,
+		eventProcessor:   eventProcessor,
+		readOnly:         options.readOnly,
+		primaryURL:       options.primaryURL,
+		storageHealth:    options.storageHealth,
+		featureFlags:     options.featureFlags,
+		componentReports: options.componentReports,
+		license:          options.license,
+		auth:             options.auth,
+		auditLog:         options.auditLog,
+		connectionHealth: options.connectionHealth,
+		schemaMigrations: options.schemaMigrations,
+		pipelineArchive:  options.pipelineArchive,
+	}
+
+	if h.auth != nil {
+		humaAPI.UseMiddleware(h.authMiddleware)
+	}
+	if h.auditLog != nil && !h.readOnly {
+		humaAPI.UseMiddleware(h.auditMiddleware)
 	}
 
 	// we need to support v1 and v2 for healthz since it's backward incompatible
 	// TODO delete v1 when Vlad migrates to v2 on FE
 	registerHumaHandler("/api/v2/healthz", h.healthzV2, log, HealthzSwaggerDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/readyz", h.readyz, log, ReadyzSwaggerDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/platform", h.platform, log, PlatformSwaggerDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/dashboards/{name}", h.getDashboard, log, GetDashboardDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/dlq/purge", h.purgeDLQ, log, PurgeDLQDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/dlq/consume", h.consumeDLQ, log, ConsumeDLQDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/dlq/state", h.getDLQState, log, GetDLQStateDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/dlq/messages", h.listDLQMessages, log, ListDLQMessagesDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/migrate-preview", h.migratePipelinePreview, log, MigratePreviewDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipelines/resume", h.resumePipelines, log, ResumePipelinesDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipelines/stop", h.stopPipelines, log, StopPipelinesDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipelines/tags/health", h.getTagsHealth, log, GetTagsHealthDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline", h.createPipeline, log, CreatePipelineDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/stop", h.stopPipeline, log, StopPipelineDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/terminate", h.terminatePipeline, log, TerminatePipelineDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/metadata", h.updatePipelineMetadata, log, UpdatePipelineMetadataDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/staging/confirm", h.confirmStaging, log, ConfirmStagingDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/migrate-topic", h.migrateTopic, log, MigrateTopicDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/components/{component}/pause", h.pauseComponent, log, PauseComponentDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/components/{component}/resume", h.resumeComponent, log, ResumeComponentDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler(httpingest.IngestPath, h.ingestEvents, log, IngestEventsDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline/{id}/health", h.getPipelineHealth, log, GetPipelineHealthDocs(), humaAPI, h.usageStatsClient)
+	registerHumaHandler("/api/v1/pipeline/{id}/usage-stats/preview", h.previewUsageStats, log, PreviewUsageStatsDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/filter/validate", h.validateFilter, log, ValidateFilterDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/transform/expression/evaluate", h.evaluateTransform, log, EvaluateTransformDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/api/v1/pipeline", h.getPipelines, log, GetPipelinesDocs(), humaAPI, h.usageStatsClient)
@@ -98,12 +159,61 @@ func NewRouter(
 	registerHumaHandler("/api/v1/pipeline/{id}/edit", h.editPipeline, log, EditPipelineDocs(), humaAPI, h.usageStatsClient)
 	registerHumaHandler("/internal/pipelines/{id}/otlp-config", h.getOTLPConfig, log, GetOTLPConfigDocs(), humaAPI, h.usageStatsClient)
 
+	if h.featureFlags != nil {
+		registerHumaHandler("/api/v1/feature-flags", h.getFeatureFlags, log, GetFeatureFlagsDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/feature-flags/{name}", h.setFeatureFlag, log, SetFeatureFlagDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/feature-flags/{name}", h.resetFeatureFlag, log, ResetFeatureFlagDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/pipeline/{id}/feature-flags", h.getPipelineFeatureFlags, log, GetPipelineFeatureFlagsDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/pipeline/{id}/feature-flags/{name}", h.setPipelineFeatureFlag, log, SetPipelineFeatureFlagDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/pipeline/{id}/feature-flags/{name}", h.resetPipelineFeatureFlag, log, ResetPipelineFeatureFlagDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.license != nil {
+		registerHumaHandler("/api/v1/license", h.getLicense, log, GetLicenseDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.auth != nil {
+		registerHumaHandler("/api/v1/auth/whoami", h.whoAmI, log, WhoAmIDocs(), humaAPI, h.usageStatsClient)
+		if h.auth.ManagesKeys() {
+			registerHumaHandler("/api/v1/api-keys", h.createAPIKey, log, CreateAPIKeyDocs(), humaAPI, h.usageStatsClient)
+			registerHumaHandler("/api/v1/api-keys", h.listAPIKeys, log, ListAPIKeysDocs(), humaAPI, h.usageStatsClient)
+			registerHumaHandler("/api/v1/api-keys/{id}", h.deleteAPIKey, log, DeleteAPIKeyDocs(), humaAPI, h.usageStatsClient)
+		}
+	}
+
+	if h.auditLog != nil {
+		registerHumaHandler("/api/v1/audit", h.listAuditEntries, log, ListAuditEntriesDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.connectionHealth != nil {
+		registerHumaHandler("/api/v1/connections/health", h.listConnectionHealth, log, ListConnectionHealthDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/connections/{id}/health", h.getConnectionHealthHistory, log, GetConnectionHealthHistoryDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.schemaMigrations != nil {
+		registerHumaHandler("/api/v1/migrations", h.getSchemaMigrations, log, GetSchemaMigrationsDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.pipelineArchive != nil {
+		registerHumaHandler("/api/v1/archive/pipelines", h.listArchivedPipelines, log, ListArchivedPipelinesDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/archive/pipelines/{id}/restore", h.restorePipeline, log, RestorePipelineDocs(), humaAPI, h.usageStatsClient)
+		registerHumaHandler("/api/v1/pipeline/{id}/archive", h.archivePipeline, log, ArchivePipelineDocs(), humaAPI, h.usageStatsClient)
+	}
+
+	if h.componentReports != nil {
+		registerHumaHandler("/api/v1/pipeline/{id}/drift", h.getConfigDrift, log, GetConfigDriftDocs(), humaAPI, h.usageStatsClient)
+	}
+
 	r.HandleFunc("/api/v1/docs", h.docs)
 	r.HandleFunc("/api/v1/openapi.json", h.swaggerDocsJSON)
 
 	r.HandleFunc("/api/v1/healthz", h.healthz).Methods("GET")
 
-	r.Use(Recovery(log), RequestLogging(log), RequestMetrics())
+	accessLog := defaultAccessLogConfig()
+	if options.accessLog != nil {
+		accessLog = *options.accessLog
+	}
+	r.Use(Recovery(log), RequestLogging(log, accessLog), RequestMetrics())
 
 	return r
 }
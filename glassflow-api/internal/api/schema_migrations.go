@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres/schemamigrations"
+)
+
+// SchemaMigrations reads the version of the Postgres schema.
+type SchemaMigrations interface {
+	Status(ctx context.Context) (schemamigrations.Status, error)
+}
+
+// WithSchemaMigrations serves the status of the schema migrations.
+func WithSchemaMigrations(migrations SchemaMigrations) RouterOption {
+	return func(o *routerOptions) {
+		o.schemaMigrations = migrations
+	}
+}
+
+func GetSchemaMigrationsDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "get-schema-migrations",
+		Method:      http.MethodGet,
+		Summary:     "Get schema migrations",
+		Description: "Returns the version of the Postgres schema and the migrations of this release, applied or pending",
+	}
+}
+
+type SchemaMigrationsResponse struct {
+	Body schemamigrations.Status
+}
+
+func (h *handler) getSchemaMigrations(ctx context.Context, _ *struct{}) (*SchemaMigrationsResponse, error) {
+	status, err := h.schemaMigrations.Status(ctx)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to read schema migrations",
+			Details: map[string]any{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	return &SchemaMigrationsResponse{Body: status}, nil
+}
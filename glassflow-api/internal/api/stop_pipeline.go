@@ -3,11 +3,14 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
 )
@@ -17,12 +20,14 @@ func StopPipelineDocs() huma.Operation {
 		OperationID: "stop-pipeline",
 		Method:      http.MethodPost,
 		Summary:     "Stop a pipeline",
-		Description: "Stops the pipeline",
+		Description: "Stops the pipeline. With drain, the ingestors stop first and the join and sink keep running until their streams are empty or the drain timeout passed",
 	}
 }
 
 type StopPipelineInput struct {
-	ID string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	ID           string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Drain        bool   `query:"drain" doc:"Process the events buffered in the pipeline streams before the pipeline resources are deleted"`
+	DrainTimeout string `query:"drain_timeout" doc:"Longest time to wait for the streams to drain, for example 10m (default: 10m, maximum: 1h)"`
 }
 
 type StopPipelineResponse struct {
@@ -30,7 +35,20 @@ type StopPipelineResponse struct {
 }
 
 func (h *handler) stopPipeline(ctx context.Context, input *StopPipelineInput) (*StopPipelineResponse, error) {
-	err := h.pipelineService.StopPipeline(ctx, input.ID)
+	opts, err := stopOptions(input)
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusBadRequest,
+			Code:    "bad_request",
+			Message: "invalid stop options",
+			Details: map[string]any{
+				"pipeline_id": input.ID,
+				"error":       err.Error(),
+			},
+		}
+	}
+
+	err = h.pipelineService.StopPipeline(ctx, input.ID, opts)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrPipelineNotExists):
@@ -80,7 +98,20 @@ func (h *handler) stopPipeline(ctx context.Context, input *StopPipelineInput) (*
 		}
 	}
 
-	h.log.InfoContext(ctx, "pipeline stop", slog.String("pipeline_id", input.ID))
+	h.log.InfoContext(ctx, "pipeline stop", slog.String("pipeline_id", input.ID), slog.Bool("drain", opts.Drain))
 
 	return &StopPipelineResponse{}, nil
 }
+
+func stopOptions(input *StopPipelineInput) (models.StopOptions, error) {
+	var drainTimeout time.Duration
+	if input.DrainTimeout != "" {
+		var err error
+		drainTimeout, err = time.ParseDuration(input.DrainTimeout)
+		if err != nil {
+			return models.StopOptions{}, fmt.Errorf("parse drain_timeout: %w", err)
+		}
+	}
+
+	return models.NewStopOptions(input.Drain, drainTimeout)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
 )
 
 func TerminatePipelineDocs() huma.Operation {
@@ -81,6 +82,7 @@ func (h *handler) terminatePipeline(ctx context.Context, input *TerminatePipelin
 	}
 
 	h.log.InfoContext(ctx, "pipeline terminated", slog.String("pipeline_id", input.ID))
+	h.notifier.Notify(input.ID, notification.EventPipelineTerminated, nil)
 
 	return &TerminatePipelineResponse{}, nil
 }
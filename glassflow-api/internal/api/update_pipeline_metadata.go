@@ -33,7 +33,26 @@ type UpdatePipelineMetadataResponse struct {
 }
 
 func (h *handler) updatePipelineMetadata(ctx context.Context, input *UpdatePipelineMetadataInput) (*UpdatePipelineMetadataResponse, error) {
-	err := h.pipelineService.UpdatePipelineMetadata(ctx, input.ID, input.Body.Metadata)
+	err := input.Body.Metadata.Validate()
+	if err != nil {
+		return nil, &ErrorDetail{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "unprocessable_entity",
+			Message: "invalid pipeline metadata",
+			Details: map[string]any{
+				"pipeline_id": input.ID,
+				"error":       err.Error(),
+			},
+		}
+	}
+
+	if errDetail := authorizeTeam(ctx, input.ID, input.Body.Metadata.Team); errDetail != nil {
+		return nil, errDetail
+	}
+
+	before, audited := h.auditedConfig(ctx, input.ID)
+
+	err = h.pipelineService.UpdatePipelineMetadata(ctx, input.ID, input.Body.Metadata)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrPipelineNotExists):
@@ -46,6 +65,26 @@ func (h *handler) updatePipelineMetadata(ctx context.Context, input *UpdatePipel
 					"error":       err.Error(),
 				},
 			}
+		case errors.Is(err, service.ErrOwnershipRequired):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "ownership_required",
+				Message: "pipeline metadata is missing required ownership",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
+		case errors.Is(err, service.ErrInvalidDependencies):
+			return nil, &ErrorDetail{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "unprocessable_entity",
+				Message: "invalid pipeline dependencies",
+				Details: map[string]any{
+					"pipeline_id": input.ID,
+					"error":       err.Error(),
+				},
+			}
 		default:
 			return nil, &ErrorDetail{
 				Status:  http.StatusInternalServerError,
@@ -61,5 +100,11 @@ func (h *handler) updatePipelineMetadata(ctx context.Context, input *UpdatePipel
 
 	h.log.InfoContext(ctx, "pipeline metadata updated", slog.String("pipeline_id", input.ID))
 
+	if audited {
+		after := before
+		after.Metadata = input.Body.Metadata
+		h.auditChanges(ctx, before, after)
+	}
+
 	return &UpdatePipelineMetadataResponse{}, nil
 }
@@ -32,6 +32,8 @@ type UpdatePipelineNameResponse struct {
 }
 
 func (h *handler) updatePipelineName(ctx context.Context, input *UpdatePipelineNameInput) (*UpdatePipelineNameResponse, error) {
+	before, audited := h.auditedConfig(ctx, input.ID)
+
 	err := h.pipelineService.UpdatePipelineName(ctx, input.ID, input.Body.Name)
 	if err != nil {
 		switch {
@@ -58,5 +60,11 @@ func (h *handler) updatePipelineName(ctx context.Context, input *UpdatePipelineN
 		}
 	}
 
+	if audited {
+		after := before
+		after.Name = input.Body.Name
+		h.auditChanges(ctx, before, after)
+	}
+
 	return &UpdatePipelineNameResponse{}, nil
 }
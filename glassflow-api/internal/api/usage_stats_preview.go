@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/usagestats"
+)
+
+func PreviewUsageStatsDocs() huma.Operation {
+	return huma.Operation{
+		OperationID: "preview-pipeline-usage-stats",
+		Method:      http.MethodGet,
+		Summary:     "Preview the usage stats of a pipeline",
+		Description: "Returns the exact usage stats event sent for the pipeline, with the topology fields the installation is configured to report. Nothing is sent.",
+	}
+}
+
+type PreviewUsageStatsInput struct {
+	ID    string `path:"id" minLength:"1" doc:"Pipeline ID"`
+	Event string `query:"event" default:"create-pipeline" enum:"create-pipeline,edit-pipeline,resume-pipeline,stop-pipeline,terminate-pipeline,delete-pipeline" doc:"Pipeline event to preview"`
+}
+
+type PreviewUsageStatsResponse struct {
+	Body usagestats.Preview
+}
+
+func (h *handler) previewUsageStats(ctx context.Context, input *PreviewUsageStatsInput) (*PreviewUsageStatsResponse, error) {
+	preview, err := h.usageStatsClient.PreviewPipelineEvent(ctx, input.ID, input.Event)
+	if err != nil {
+		details := map[string]any{
+			"pipeline_id": input.ID,
+			"error":       err.Error(),
+		}
+		if errors.Is(err, service.ErrPipelineNotExists) {
+			return nil, &ErrorDetail{
+				Status:  http.StatusNotFound,
+				Code:    "not_found",
+				Message: "no pipeline with given id found",
+				Details: details,
+			}
+		}
+		return nil, &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: "failed to preview usage stats",
+			Details: details,
+		}
+	}
+
+	return &PreviewUsageStatsResponse{Body: preview}, nil
+}
@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Actor of the actions of callers without credentials, when the API does
+// not require them.
+const Anonymous = "anonymous"
+
+// DefaultLimit is the number of entries returned by a query without a
+// limit.
+const DefaultLimit = 100
+
+// redacted replaces the values of secrets in the changes.
+const redacted = "[redacted]"
+
+// secretFields are the config fields whose values are never recorded, only
+// that they changed.
+var secretFields = []string{
+	"password",
+	"client_key",
+	"kerberos_keytab",
+	"auth_token",
+	"api_key",
+	"api_secret",
+	"tls_key",
+	"secret",
+}
+
+// Entry records a control-plane action: who did it, when, on which
+// pipeline, with which outcome and what it changed.
+type Entry struct {
+	ID         int64          `json:"id"`
+	Time       time.Time      `json:"time"`
+	Actor      string         `json:"actor"`
+	SourceIP   string         `json:"source_ip,omitempty"`
+	Action     string         `json:"action"`
+	PipelineID string         `json:"pipeline_id,omitempty"`
+	Status     int            `json:"status"`
+	Changes    []Change       `json:"changes,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// Change is a config field changed by an action, Path is its JSON path,
+// e.g. sink.batch.max_batch_size or sources[0].topic.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Filter selects the entries of a query, zero fields match every entry.
+type Filter struct {
+	Actor      string
+	Action     string
+	PipelineID string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// Store keeps the audit log. Entries are only appended, never changed.
+type Store interface {
+	InsertAuditEntry(ctx context.Context, entry Entry) error
+	// ListAuditEntries returns the entries matching the filter, the latest
+	// first.
+	ListAuditEntries(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type entryKey struct{}
+
+// WithEntry adds the entry of the action of a request to its context, for
+// the operation to complete it with SetPipelineID, SetChanges and
+// SetDetail.
+func WithEntry(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// Enabled reports whether the action of the request is recorded, e.g. to
+// skip loading the config a diff would need.
+func Enabled(ctx context.Context) bool {
+	_, ok := ctx.Value(entryKey{}).(*Entry)
+	return ok
+}
+
+func SetPipelineID(ctx context.Context, pipelineID string) {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		entry.PipelineID = pipelineID
+	}
+}
+
+func SetChanges(ctx context.Context, changes []Change) {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		entry.Changes = changes
+	}
+}
+
+func SetDetail(ctx context.Context, key string, value any) {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		if entry.Details == nil {
+			entry.Details = map[string]any{}
+		}
+		entry.Details[key] = value
+	}
+}
+
+// Diff returns the fields that differ between the JSON of before and after,
+// sorted by path. The values of secrets are redacted.
+func Diff(before, after any) ([]Change, error) {
+	beforeValue, err := toJSONValue(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	afterValue, err := toJSONValue(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	var changes []Change
+	diff("", "", beforeValue, afterValue, &changes)
+	return changes, nil
+}
+
+func toJSONValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	err = json.Unmarshal(raw, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// diff appends the changes between before and after at path, field is the
+// name of the innermost object field of path.
+func diff(path, field string, before, after any, changes *[]Change) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	if slices.Contains(secretFields, field) {
+		*changes = append(*changes, Change{Path: path, Old: redact(before), New: redact(after)})
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if beforeIsMap && afterIsMap {
+		keys := make([]string, 0, len(beforeMap)+len(afterMap))
+		for k := range beforeMap {
+			keys = append(keys, k)
+		}
+		for k := range afterMap {
+			if _, ok := beforeMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			diff(join(path, k), k, beforeMap[k], afterMap[k], changes)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]any)
+	afterList, afterIsList := after.([]any)
+	if beforeIsList && afterIsList && len(beforeList) == len(afterList) {
+		for i := range beforeList {
+			diff(path+"["+strconv.Itoa(i)+"]", field, beforeList[i], afterList[i], changes)
+		}
+		return
+	}
+
+	*changes = append(*changes, Change{Path: path, Old: scrub(before), New: scrub(after)})
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func redact(v any) any {
+	if v == nil || v == "" {
+		return v
+	}
+	return redacted
+}
+
+// scrub redacts the secrets of a value recorded as a whole, e.g. a source
+// added by an edit.
+func scrub(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		scrubbed := make(map[string]any, len(v))
+		for k, item := range v {
+			if slices.Contains(secretFields, k) {
+				scrubbed[k] = redact(item)
+				continue
+			}
+			scrubbed[k] = scrub(item)
+		}
+		return scrubbed
+	case []any:
+		scrubbed := make([]any, len(v))
+		for i, item := range v {
+			scrubbed[i] = scrub(item)
+		}
+		return scrubbed
+	default:
+		return v
+	}
+}
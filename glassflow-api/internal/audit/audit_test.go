@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{
+		"name": "orders",
+		"sources": []any{
+			map[string]any{"topic": "orders", "connection_params": map[string]any{"username": "etl", "password": "old-secret"}},
+		},
+		"sink": map[string]any{"batch": map[string]any{"max_batch_size": 1000}},
+	}
+	after := map[string]any{
+		"name": "orders",
+		"sources": []any{
+			map[string]any{"topic": "orders-v2", "connection_params": map[string]any{"username": "etl", "password": "new-secret"}},
+		},
+		"sink":     map[string]any{"batch": map[string]any{"max_batch_size": 5000}},
+		"metadata": map[string]any{"team": "payments"},
+	}
+
+	changes, err := Diff(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "metadata", New: map[string]any{"team": "payments"}},
+		{Path: "sink.batch.max_batch_size", Old: float64(1000), New: float64(5000)},
+		{Path: "sources[0].connection_params.password", Old: redacted, New: redacted},
+		{Path: "sources[0].topic", Old: "orders", New: "orders-v2"},
+	}, changes)
+
+	changes, err = Diff(before, before)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiff_ScrubsAddedValues(t *testing.T) {
+	before := map[string]any{"sources": []any{}}
+	after := map[string]any{"sources": []any{
+		map[string]any{"topic": "orders", "connection_params": map[string]any{"password": "secret"}},
+	}}
+
+	changes, err := Diff(before, after)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, []any{
+		map[string]any{"topic": "orders", "connection_params": map[string]any{"password": redacted}},
+	}, changes[0].New)
+}
+
+func TestEntryContext(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, Enabled(ctx))
+	// Without an entry the setters do nothing
+	SetPipelineID(ctx, "p-1")
+
+	entry := &Entry{Action: "resume-pipelines"}
+	ctx = WithEntry(ctx, entry)
+	assert.True(t, Enabled(ctx))
+
+	SetPipelineID(ctx, "p-1")
+	SetDetail(ctx, "pipeline_ids", []string{"p-1", "p-2"})
+	assert.Equal(t, "p-1", entry.PipelineID)
+	assert.Equal(t, map[string]any{"pipeline_ids": []string{"p-1", "p-2"}}, entry.Details)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// keyPrefix starts every API key, the ID of the key follows it so that the
+// key is looked up without scanning the store.
+const keyPrefix = "gfk_"
+
+// APIKey is a stored API key. Only the hash of the key is kept, the key is
+// returned once when it is created.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	Teams     []string  `json:"teams,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Hash      string    `json:"-"`
+}
+
+// KeyStore keeps the API keys. GetAPIKey and DeleteAPIKey return
+// ErrKeyNotFound for an unknown ID.
+type KeyStore interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAPIKey(ctx context.Context, id string) (APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+// ManagesKeys reports whether API keys can be created and revoked.
+func (a *Authenticator) ManagesKeys() bool {
+	return a.keys != nil
+}
+
+// CreateKey stores a new API key and returns it with the key, which is not
+// kept.
+func (a *Authenticator) CreateKey(ctx context.Context, name string, role Role, teams []string) (APIKey, string, error) {
+	if _, err := ParseRole(string(role)); err != nil {
+		return APIKey{}, "", fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	if strings.TrimSpace(name) == "" {
+		return APIKey{}, "", fmt.Errorf("%w: name must not be empty", ErrInvalidKey)
+	}
+	for _, team := range teams {
+		if strings.TrimSpace(team) == "" {
+			return APIKey{}, "", fmt.Errorf("%w: teams must not be empty", ErrInvalidKey)
+		}
+	}
+
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return APIKey{}, "", fmt.Errorf("generate api key id: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	key := APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Role:      role,
+		Teams:     teams,
+		CreatedAt: time.Now().UTC(),
+	}
+	token := keyPrefix + key.ID + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = hashKey(token)
+
+	err := a.keys.CreateAPIKey(ctx, key)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("create api key: %w", err)
+	}
+	return key, token, nil
+}
+
+func (a *Authenticator) ListKeys(ctx context.Context) ([]APIKey, error) {
+	return a.keys.ListAPIKeys(ctx)
+}
+
+// DeleteKey revokes an API key, requests with it are rejected at once.
+func (a *Authenticator) DeleteKey(ctx context.Context, id string) error {
+	return a.keys.DeleteAPIKey(ctx, id)
+}
+
+// parseKeyID returns the ID of an API key.
+func parseKeyID(token string) (string, bool) {
+	rest, ok := strings.CutPrefix(token, keyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	return id, ok && id != ""
+}
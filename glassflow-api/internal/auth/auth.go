@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Role grants the operations of the roles below it: a viewer reads, an
+// operator also changes pipelines and an admin also manages the
+// installation settings and the API keys.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var roles = []Role{RoleViewer, RoleOperator, RoleAdmin}
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("operation not allowed for the role")
+	ErrKeyNotFound     = errors.New("api key not found")
+	ErrInvalidKey      = errors.New("invalid api key")
+)
+
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.TrimSpace(s))
+	if !slices.Contains(roles, role) {
+		return "", fmt.Errorf("unknown role %q, expected %s, %s or %s", s, RoleViewer, RoleOperator, RoleAdmin)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants the operations of required.
+func (r Role) Allows(required Role) bool {
+	return slices.Index(roles, r) >= slices.Index(roles, required)
+}
+
+// Principal is the caller of a request. A principal with teams only acts on
+// the pipelines owned by these teams, see CanAccess.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Role    Role     `json:"role"`
+	Teams   []string `json:"teams,omitempty"`
+}
+
+// Scoped reports whether the principal is limited to the pipelines of its
+// teams. Admins are never scoped.
+func (p Principal) Scoped() bool {
+	return p.Role != RoleAdmin && len(p.Teams) > 0
+}
+
+// CanAccess reports whether the principal may act on a pipeline owned by
+// team. Pipelines without a team are only accessible to unscoped
+// principals.
+func (p Principal) CanAccess(team string) bool {
+	return !p.Scoped() || slices.Contains(p.Teams, team)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of an authenticated request.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Authenticator resolves the principal of the credentials of a request: the
+// admin key, an API key of the key store or an OIDC bearer token.
+type Authenticator struct {
+	adminKeyHash string
+	keys         KeyStore
+	oidc         *OIDCVerifier
+}
+
+type Option func(*Authenticator)
+
+// WithAdminKey accepts key as the credentials of an admin, e.g. to create
+// the first API keys.
+func WithAdminKey(key string) Option {
+	return func(a *Authenticator) {
+		a.adminKeyHash = hashKey(key)
+	}
+}
+
+// WithKeyStore accepts the API keys of the store.
+func WithKeyStore(store KeyStore) Option {
+	return func(a *Authenticator) {
+		a.keys = store
+	}
+}
+
+// WithOIDC accepts the bearer tokens verified by the verifier.
+func WithOIDC(verifier *OIDCVerifier) Option {
+	return func(a *Authenticator) {
+		a.oidc = verifier
+	}
+}
+
+func New(opts ...Option) *Authenticator {
+	a := &Authenticator{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authenticate returns the principal of token, an API key or a bearer
+// token.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (Principal, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+
+	if a.adminKeyHash != "" && subtle.ConstantTimeCompare([]byte(hashKey(token)), []byte(a.adminKeyHash)) == 1 {
+		return Principal{Subject: "admin-key", Role: RoleAdmin}, nil
+	}
+
+	if id, ok := parseKeyID(token); ok && a.keys != nil {
+		key, err := a.keys.GetAPIKey(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				return Principal{}, ErrUnauthenticated
+			}
+			return Principal{}, fmt.Errorf("get api key: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(hashKey(token)), []byte(key.Hash)) != 1 {
+			return Principal{}, ErrUnauthenticated
+		}
+		return Principal{Subject: "api-key:" + key.ID, Role: key.Role, Teams: key.Teams}, nil
+	}
+
+	if a.oidc != nil && strings.Count(token, ".") == 2 {
+		return a.oidc.Verify(ctx, token)
+	}
+
+	return Principal{}, ErrUnauthenticated
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
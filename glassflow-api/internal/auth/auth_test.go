@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKeyStore map[string]APIKey
+
+func (f fakeKeyStore) CreateAPIKey(_ context.Context, key APIKey) error {
+	f[key.ID] = key
+	return nil
+}
+
+func (f fakeKeyStore) GetAPIKey(_ context.Context, id string) (APIKey, error) {
+	key, ok := f[id]
+	if !ok {
+		return APIKey{}, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (f fakeKeyStore) ListAPIKeys(_ context.Context) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(f))
+	for _, key := range f {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (f fakeKeyStore) DeleteAPIKey(_ context.Context, id string) error {
+	if _, ok := f[id]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(f, id)
+	return nil
+}
+
+func TestRole_Allows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleOperator))
+	assert.True(t, RoleOperator.Allows(RoleOperator))
+	assert.False(t, RoleViewer.Allows(RoleOperator))
+	assert.False(t, Role("").Allows(RoleViewer))
+
+	_, err := ParseRole("owner")
+	require.Error(t, err)
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	unscoped := Principal{Role: RoleOperator}
+	assert.True(t, unscoped.CanAccess("payments"))
+	assert.True(t, unscoped.CanAccess(""))
+
+	scoped := Principal{Role: RoleOperator, Teams: []string{"payments"}}
+	assert.True(t, scoped.CanAccess("payments"))
+	assert.False(t, scoped.CanAccess("search"))
+	assert.False(t, scoped.CanAccess(""))
+
+	admin := Principal{Role: RoleAdmin, Teams: []string{"payments"}}
+	assert.True(t, admin.CanAccess("search"))
+}
+
+func TestAuthenticator_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	store := fakeKeyStore{}
+	a := New(WithAdminKey("bootstrap-secret"), WithKeyStore(store))
+
+	p, err := a.Authenticate(ctx, "bootstrap-secret")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, p.Role)
+
+	key, token, err := a.CreateKey(ctx, "ci", RoleOperator, []string{"payments"})
+	require.NoError(t, err)
+	assert.NotContains(t, key.Hash, token, "the key is not stored")
+
+	p, err = a.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "api-key:" + key.ID, Role: RoleOperator, Teams: []string{"payments"}}, p)
+
+	// A key of a known ID with another secret is rejected
+	_, err = a.Authenticate(ctx, keyPrefix+key.ID+"_guessed")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	require.NoError(t, a.DeleteKey(ctx, key.ID))
+	_, err = a.Authenticate(ctx, token)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	for _, token := range []string{"", "wrong", "a.b.c"} {
+		_, err = a.Authenticate(ctx, token)
+		require.ErrorIs(t, err, ErrUnauthenticated, token)
+	}
+
+	_, _, err = a.CreateKey(ctx, "ci", "owner", nil)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, _, err = a.CreateKey(ctx, " ", RoleViewer, nil)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+type failingKeyStore struct{ fakeKeyStore }
+
+func (failingKeyStore) GetAPIKey(context.Context, string) (APIKey, error) {
+	return APIKey{}, errors.New("connection refused")
+}
+
+func TestAuthenticator_StoreError(t *testing.T) {
+	a := New(WithKeyStore(failingKeyStore{}))
+	_, err := a.Authenticate(context.Background(), keyPrefix+"0011_secret")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnauthenticated)
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// clockSkew is tolerated on the expiry and not-before times of tokens
+	clockSkew = time.Minute
+	// jwksRefreshInterval limits how often a token signed by an unknown key
+	// refreshes the keys of the issuer
+	jwksRefreshInterval = time.Minute
+	// oidcHTTPTimeout bounds the discovery and key requests to the issuer
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+// oidcSigningMethods are the asymmetric algorithms accepted for tokens, the
+// keys of the issuer are public so symmetric algorithms are never valid.
+var oidcSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// OIDCConfig configures the verification of the bearer tokens of an OIDC
+// issuer. The role of the caller is read from RoleClaim and its teams from
+// TeamsClaim.
+type OIDCConfig struct {
+	Issuer     string
+	Audience   string
+	RoleClaim  string
+	TeamsClaim string
+}
+
+// OIDCVerifier verifies signed ID and access tokens against the keys the
+// issuer publishes. The keys are discovered on the first token and
+// refreshed in the background.
+type OIDCVerifier struct {
+	cfg        OIDCConfig
+	httpClient *http.Client
+	now        func() time.Time
+	// unknownKeyRefresh limits the refreshes of the keys for tokens signed
+	// by an unknown key, e.g. after the issuer rotated them
+	unknownKeyRefresh *rate.Limiter
+
+	mu   sync.Mutex
+	keys keyfunc.Keyfunc
+}
+
+func NewOIDCVerifier(cfg OIDCConfig) *OIDCVerifier {
+	return &OIDCVerifier{
+		cfg:               cfg,
+		httpClient:        &http.Client{Timeout: oidcHTTPTimeout},
+		now:               time.Now,
+		unknownKeyRefresh: rate.NewLimiter(rate.Every(jwksRefreshInterval), 1),
+	}
+}
+
+// Verify checks the signature, issuer, audience and lifetime of token and
+// returns its principal.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	keys, err := v.keyfunc(ctx)
+	if err != nil {
+		return Principal{}, fmt.Errorf("fetch oidc keys: %w", err)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(v.now),
+	}
+	if v.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	var claims jwt.MapClaims
+	_, err = jwt.ParseWithClaims(token, &claims, keys.KeyfuncCtx(ctx), opts...)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+
+	roleClaim, _ := claims[v.cfg.RoleClaim].(string)
+	role, err := ParseRole(roleClaim)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: claim %s: %w", ErrUnauthenticated, v.cfg.RoleClaim, err)
+	}
+	subject, _ := claims["sub"].(string)
+
+	return Principal{
+		Subject: "oidc:" + subject,
+		Role:    role,
+		Teams:   stringsClaim(claims[v.cfg.TeamsClaim]),
+	}, nil
+}
+
+// keyfunc returns the keys of the issuer, discovering them on the first call.
+func (v *OIDCVerifier) keyfunc(ctx context.Context) (keyfunc.Keyfunc, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys != nil {
+		return v.keys, nil
+	}
+
+	jwksURL, err := v.discoverJWKS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := keyfunc.NewDefaultOverrideCtx(context.Background(), []string{jwksURL}, keyfunc.Override{
+		Client:            v.httpClient,
+		HTTPTimeout:       oidcHTTPTimeout,
+		RateLimitWaitMax:  oidcHTTPTimeout,
+		RefreshUnknownKID: v.unknownKeyRefresh,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create jwks client: %w", err)
+	}
+	// The keys were just fetched
+	v.unknownKeyRefresh.Allow()
+	v.keys = keys
+
+	return keys, nil
+}
+
+func (v *OIDCVerifier) discoverJWKS(ctx context.Context) (string, error) {
+	url := strings.TrimSuffix(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("discover issuer: %w", err)
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("discover issuer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discover issuer: GET %s: status %d", url, resp.StatusCode)
+	}
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&discovery)
+	if err != nil {
+		return "", fmt.Errorf("discover issuer: %w", err)
+	}
+	if discovery.JWKSURI == "" {
+		return "", fmt.Errorf("issuer %s publishes no jwks_uri", v.cfg.Issuer)
+	}
+
+	return discovery.JWKSURI, nil
+}
+
+// stringsClaim reads a claim holding a string or a list of strings.
+func stringsClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+type testIssuer struct {
+	server   *httptest.Server
+	rsaKey   *rsa.PrivateKey
+	ecKey    *ecdsa.PrivateKey
+	jwksHits atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": issuer.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		issuer.jwksHits.Add(1)
+		enc := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": enc(ecKey.X.FillBytes(make([]byte, 32))), "y": enc(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) token(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), jwt.MapClaims(claims))
+	token.Header["kid"] = kid
+
+	var key any = i.rsaKey
+	if alg == "ES256" {
+		key = i.ecKey
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	v := NewOIDCVerifier(OIDCConfig{
+		Issuer:     issuer.server.URL,
+		Audience:   "glassflow",
+		RoleClaim:  "glassflow_role",
+		TeamsClaim: "groups",
+	})
+	v.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":            issuer.server.URL,
+			"aud":            []string{"glassflow", "other"},
+			"sub":            "jane",
+			"exp":            now.Add(time.Hour).Unix(),
+			"glassflow_role": "operator",
+			"groups":         []string{"payments"},
+		}
+		for k, val := range overrides {
+			c[k] = val
+		}
+		return c
+	}
+	ctx := context.Background()
+
+	for _, alg := range []struct{ alg, kid string }{{"RS256", "rsa-1"}, {"ES256", "ec-1"}} {
+		p, err := v.Verify(ctx, issuer.token(t, alg.alg, alg.kid, claims(nil)))
+		require.NoError(t, err, alg.alg)
+		assert.Equal(t, Principal{Subject: "oidc:jane", Role: RoleOperator, Teams: []string{"payments"}}, p)
+	}
+	assert.Equal(t, int32(1), issuer.jwksHits.Load(), "keys are cached")
+
+	rejected := map[string]string{
+		"expired":        issuer.token(t, "RS256", "rsa-1", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})),
+		"other issuer":   issuer.token(t, "RS256", "rsa-1", claims(map[string]any{"iss": "https://evil.example.com"})),
+		"other audience": issuer.token(t, "RS256", "rsa-1", claims(map[string]any{"aud": "other"})),
+		"no role":        issuer.token(t, "RS256", "rsa-1", claims(map[string]any{"glassflow_role": nil})),
+		"wrong alg":      issuer.token(t, "ES256", "rsa-1", claims(nil)),
+	}
+	for name, token := range rejected {
+		_, err := v.Verify(ctx, token)
+		require.ErrorIs(t, err, ErrUnauthenticated, name)
+	}
+
+	// A payload raising the role does not match the signature
+	valid := issuer.token(t, "RS256", "rsa-1", claims(nil))
+	forged := issuer.token(t, "RS256", "rsa-1", claims(map[string]any{"glassflow_role": "admin"}))
+	_, err := v.Verify(ctx, forged[:strings.LastIndex(forged, ".")]+valid[strings.LastIndex(valid, "."):])
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	// Symmetric algorithms are rejected, e.g. a token signed with the public key
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims(nil))).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = v.Verify(ctx, hmac)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	// Unknown keys refresh the keys at most once a minute
+	_, err = v.Verify(ctx, issuer.token(t, "RS256", "rotated", claims(nil)))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(1), issuer.jwksHits.Load())
+	// As if the minute passed
+	v.unknownKeyRefresh.SetLimit(rate.Inf)
+	_, err = v.Verify(ctx, issuer.token(t, "RS256", "rotated", claims(nil)))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(2), issuer.jwksHits.Load())
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
@@ -16,6 +17,11 @@ type Batch interface {
 	Reload(ctx context.Context) error
 	Size() int
 	Append(id uint64, data ...any) error
+	// AppendShardRow appends a row of a shard, the row is copied from the
+	// columns of the shard when it has the layout of the batch
+	AppendShardRow(id uint64, shard *Shard, row int) error
+	// Layout returns the layout of the columns of the batch
+	Layout() (*ShardLayout, error)
 	Send(ctx context.Context) error
 }
 
@@ -24,6 +30,10 @@ type ClickHouseBatch struct {
 	query        string
 	currentBatch driver.Batch
 	cache        map[uint64]struct{}
+	// layout is built on the first call of Layout after a reload
+	layout *ShardLayout
+	// rowBuf is reused to copy the rows of shards
+	rowBuf []any
 }
 
 func NewClickHouseBatch(ctx context.Context, chClient client.DatabaseClient, query string) (Batch, error) {
@@ -49,10 +59,36 @@ func (b *ClickHouseBatch) Reload(ctx context.Context) error {
 	}
 
 	b.currentBatch = batch
+	b.layout = nil
 
 	return nil
 }
 
+func (b *ClickHouseBatch) Layout() (*ShardLayout, error) {
+	if b.layout != nil {
+		return b.layout, nil
+	}
+
+	timezone, err := b.client.Timezone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server timezone: %w", err)
+	}
+
+	columns := b.currentBatch.Columns()
+	layout := &ShardLayout{
+		names:    make([]string, len(columns)),
+		types:    make([]column.Type, len(columns)),
+		timezone: timezone,
+	}
+	for i, col := range columns {
+		layout.names[i] = col.Name()
+		layout.types[i] = col.Type()
+	}
+	b.layout = layout
+
+	return layout, nil
+}
+
 func (b *ClickHouseBatch) Size() int {
 	return len(b.cache)
 }
@@ -79,6 +115,19 @@ func (b *ClickHouseBatch) Append(id uint64, data ...any) (err error) {
 	return nil
 }
 
+func (b *ClickHouseBatch) AppendShardRow(id uint64, shard *Shard, row int) error {
+	layout, err := b.Layout()
+	if err != nil || !layout.equal(shard.layout) {
+		return b.Append(id, shard.rows[row]...)
+	}
+
+	b.rowBuf = shard.row(row, b.rowBuf)
+	err = b.Append(id, b.rowBuf...)
+	clear(b.rowBuf)
+
+	return err
+}
+
 func (b *ClickHouseBatch) Send(ctx context.Context) error {
 	err := b.currentBatch.Send()
 	if err != nil {
@@ -0,0 +1,124 @@
+package clickhouse
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+)
+
+// ShardLayout describes the columns of a batch. Rows can be appended into
+// shards of the layout concurrently and be copied into the batch later.
+type ShardLayout struct {
+	names    []string
+	types    []column.Type
+	timezone *time.Location
+}
+
+func (l *ShardLayout) equal(other *ShardLayout) bool {
+	if l == other {
+		return true
+	}
+	if l == nil || other == nil {
+		return false
+	}
+	return slices.Equal(l.names, other.names) &&
+		slices.Equal(l.types, other.types) &&
+		l.timezone.String() == other.timezone.String()
+}
+
+// Shard holds rows in columns of the types of a batch. A shard is not safe
+// for concurrent use, each decode worker appends into shards of its own.
+type Shard struct {
+	layout  *ShardLayout
+	columns []column.Interface
+	// rows keeps the values of the appended rows, they rebuild the columns
+	// after a failed append and are appended instead when the layout of the
+	// batch changed
+	rows [][]any
+}
+
+func (l *ShardLayout) NewShard() (*Shard, error) {
+	s := &Shard{layout: l}
+	err := s.reset()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Shard) reset() error {
+	s.columns = make([]column.Interface, len(s.layout.names))
+	for i, name := range s.layout.names {
+		col, err := s.layout.types[i].Column(name, s.layout.timezone)
+		if err != nil {
+			return fmt.Errorf("create column %s: %w", name, err)
+		}
+		s.columns[i] = col
+	}
+
+	return nil
+}
+
+// Append appends a row and returns its index in the shard. A row that
+// fails to append leaves the shard unchanged.
+func (s *Shard) Append(data ...any) (row int, err error) {
+	if len(data) != len(s.columns) {
+		return 0, fmt.Errorf("append failed: expected %d values, got %d", len(s.columns), len(data))
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("append failed: %v", recovered)
+		}
+		if err != nil {
+			err = s.rebuild(err)
+		}
+	}()
+
+	for i, v := range data {
+		err = s.columns[i].AppendRow(v)
+		if err != nil {
+			return 0, fmt.Errorf("append failed: column %s: %w", s.columns[i].Name(), err)
+		}
+	}
+	s.rows = append(s.rows, data)
+
+	return len(s.rows) - 1, nil
+}
+
+// rebuild drops the partially appended row of a failed append by appending
+// the previous rows into new columns.
+func (s *Shard) rebuild(appendErr error) error {
+	err := s.reset()
+	if err != nil {
+		return fmt.Errorf("%w, rebuild shard: %w", appendErr, err)
+	}
+	for _, data := range s.rows {
+		for i, v := range data {
+			err = s.columns[i].AppendRow(v)
+			if err != nil {
+				return fmt.Errorf("%w, rebuild shard: %w", appendErr, err)
+			}
+		}
+	}
+
+	return appendErr
+}
+
+// Rows returns the number of rows in the shard.
+func (s *Shard) Rows() int {
+	return len(s.rows)
+}
+
+// row returns the values of a row as stored in the columns of the shard.
+func (s *Shard) row(i int, dst []any) []any {
+	dst = dst[:0]
+	for _, col := range s.columns {
+		dst = append(dst, col.Row(i, false))
+	}
+
+	return dst
+}
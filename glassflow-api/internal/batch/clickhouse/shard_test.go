@@ -0,0 +1,65 @@
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/stretchr/testify/require"
+)
+
+func testLayout() *ShardLayout {
+	return &ShardLayout{
+		names:    []string{"id", "count", "note", "created_at"},
+		types:    []column.Type{"String", "Int64", "Nullable(String)", "DateTime"},
+		timezone: time.UTC,
+	}
+}
+
+func TestShardAppend(t *testing.T) {
+	shard, err := testLayout().NewShard()
+	require.NoError(t, err)
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	row, err := shard.Append("a", int64(1), nil, createdAt)
+	require.NoError(t, err)
+	require.Equal(t, 0, row)
+
+	note := "second"
+	row, err = shard.Append("b", int64(2), &note, createdAt)
+	require.NoError(t, err)
+	require.Equal(t, 1, row)
+
+	// The partially appended row of a failed append is dropped
+	_, err = shard.Append("c", "not a number", nil, createdAt)
+	require.Error(t, err)
+	_, err = shard.Append("d", int64(4))
+	require.Error(t, err)
+	require.Equal(t, 2, shard.Rows())
+	for _, col := range shard.columns {
+		require.Equal(t, 2, col.Rows())
+	}
+
+	values := shard.row(1, nil)
+	require.Equal(t, "b", values[0])
+	require.Equal(t, int64(2), values[1])
+	require.Equal(t, &note, values[2])
+	require.True(t, createdAt.Equal(values[3].(time.Time)))
+
+	values = shard.row(0, values)
+	require.Nil(t, values[2])
+}
+
+func TestShardLayoutEqual(t *testing.T) {
+	require.True(t, testLayout().equal(testLayout()))
+
+	other := testLayout()
+	other.types[1] = "Int32"
+	require.False(t, testLayout().equal(other))
+
+	other = testLayout()
+	other.timezone = time.FixedZone("UTC+1", 3600)
+	require.False(t, testLayout().equal(other))
+
+	require.False(t, testLayout().equal(nil))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
@@ -18,6 +19,7 @@ type DatabaseClient interface {
 	AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error
 	GetDatabase() string
 	GetTableName() string
+	Timezone() (*time.Location, error)
 	Close() error
 }
 
@@ -31,6 +33,9 @@ type ClickHouseClient struct {
 	tableName            string
 	secure               bool
 	skipCertificateCheck bool
+	tlsRoot              string
+	tlsCert              string
+	tlsKey               string
 }
 
 func NewClickHouseClient(
@@ -46,6 +51,9 @@ func NewClickHouseClient(
 		tableName:            cfg.Table,
 		secure:               cfg.Secure,
 		skipCertificateCheck: cfg.SkipCertificateCheck,
+		tlsRoot:              cfg.TLSRoot,
+		tlsCert:              cfg.TLSCert,
+		tlsKey:               cfg.TLSKey,
 	}
 	err := client.connect(ctx)
 	if err != nil {
@@ -72,10 +80,22 @@ func (c *ClickHouseClient) connect(ctx context.Context) error {
 		return fmt.Errorf("failed to close existing connection: %w", err)
 	}
 
+	chConn, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.conn = chConn
+	return nil
+}
+
+func (c *ClickHouseClient) open(ctx context.Context) (driver.Conn, error) {
 	var tlsConfig *tls.Config
 	if c.secure {
-		tlsConfig = &tls.Config{ //nolint:exhaustruct //optionals
-			MinVersion: tls.VersionTLS12,
+		var err error
+		tlsConfig, err = kafka.MakeTLSConfigFromStrings(c.tlsCert, c.tlsKey, c.tlsRoot)
+		if err != nil {
+			return nil, fmt.Errorf("make tls config: %w", err)
 		}
 
 		if c.skipCertificateCheck {
@@ -93,7 +113,7 @@ func (c *ClickHouseClient) connect(ctx context.Context) error {
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to open clickhouse connection: %w", err)
+		return nil, fmt.Errorf("failed to open clickhouse connection: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -101,10 +121,32 @@ func (c *ClickHouseClient) connect(ctx context.Context) error {
 
 	err = chConn.Ping(ctx)
 	if err != nil {
-		return fmt.Errorf("ping failed: %w", err)
+		_ = chConn.Close()
+		return nil, fmt.Errorf("ping failed: %w", err)
 	}
 
+	return chConn, nil
+}
+
+// UpdateCredentials connects with new credentials, then closes the
+// connection of the previous ones. The client keeps its connection when the
+// new credentials are rejected. No batch must be in flight.
+func (c *ClickHouseClient) UpdateCredentials(ctx context.Context, username, password string) error {
+	prevUsername, prevPassword := c.username, c.password
+	c.username, c.password = username, password
+
+	chConn, err := c.open(ctx)
+	if err != nil {
+		c.username, c.password = prevUsername, prevPassword
+		return fmt.Errorf("failed to connect to ClickHouse with new credentials: %w", err)
+	}
+
+	err = c.Close()
 	c.conn = chConn
+	if err != nil {
+		return fmt.Errorf("failed to close previous connection: %w", err)
+	}
+
 	return nil
 }
 
@@ -130,6 +172,21 @@ func (c *ClickHouseClient) PrepareBatch(ctx context.Context, query string) (driv
 	return batch, nil
 }
 
+// Timezone returns the timezone of the server, DateTime values without a
+// timezone are parsed in it.
+func (c *ClickHouseClient) Timezone() (*time.Location, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("clickhouse client is not connected")
+	}
+
+	version, err := c.conn.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	return version.Timezone, nil
+}
+
 func (c *ClickHouseClient) GetDatabase() string {
 	return c.database
 }
@@ -146,3 +203,117 @@ func (c *ClickHouseClient) AsyncInsert(
 ) error {
 	return c.conn.AsyncInsert(ctx, query, wait, args...)
 }
+
+// InsertSettings are the ClickHouse settings of a batch insert, zero values
+// are not sent.
+type InsertSettings struct {
+	DeduplicationToken string
+	// AsyncInsert makes the server buffer the insert with the inserts of other
+	// clients of the table, WaitForAsyncInsert returns only once it flushed it.
+	AsyncInsert        bool
+	WaitForAsyncInsert bool
+}
+
+// WithInsertSettings returns a context that makes batches prepared with it use
+// the given settings. The settings replace the ones of ctx, so they are all
+// set by a single call.
+func WithInsertSettings(ctx context.Context, s InsertSettings) context.Context {
+	settings := clickhouse.Settings{}
+	if s.DeduplicationToken != "" {
+		settings["insert_deduplication_token"] = s.DeduplicationToken
+	}
+	if s.AsyncInsert {
+		settings["async_insert"] = 1
+		settings["wait_for_async_insert"] = 0
+		if s.WaitForAsyncInsert {
+			settings["wait_for_async_insert"] = 1
+		}
+	}
+	if len(settings) == 0 {
+		return ctx
+	}
+
+	return clickhouse.Context(ctx, clickhouse.WithSettings(settings))
+}
+
+// WithQueryID returns a context that makes batches prepared with it use the
+// given query_id.
+func WithQueryID(ctx context.Context, queryID string) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithQueryID(queryID))
+}
+
+// WithProfileEvents returns a context that makes batches prepared with it pass
+// the ProfileEvents counters sent by the server, by event name, to fn. Only
+// the rows of thread 0 are used, they hold the totals of the whole query.
+func WithProfileEvents(ctx context.Context, fn func(map[string]int64)) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithProfileEvents(func(events []clickhouse.ProfileEvent) {
+		counters := make(map[string]int64, len(events))
+		for _, event := range events {
+			if event.Type != "increment" || event.ThreadID != 0 {
+				continue
+			}
+			counters[event.Name] += event.Value
+		}
+		fn(counters)
+	}))
+}
+
+// ColumnTypes returns the column types of the client table by column name,
+// the map is empty when the table does not exist.
+func (c *ClickHouseClient) ColumnTypes(ctx context.Context) (map[string]string, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("clickhouse client is not connected")
+	}
+
+	rows, err := c.conn.Query(ctx,
+		"SELECT name, type FROM system.columns WHERE database = ? AND table = ?",
+		c.database, c.tableName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query column types: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes := make(map[string]string)
+	for rows.Next() {
+		var name, columnType string
+		err = rows.Scan(&name, &columnType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan column type: %w", err)
+		}
+		columnTypes[name] = columnType
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column types: %w", err)
+	}
+
+	return columnTypes, nil
+}
+
+// Query runs a query returning rows, the caller closes them.
+func (c *ClickHouseClient) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("clickhouse client is not connected")
+	}
+
+	rows, err := c.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+
+	return rows, nil
+}
+
+func (c *ClickHouseClient) Exec(ctx context.Context, query string, args ...any) error {
+	if c.conn == nil {
+		return fmt.Errorf("clickhouse client is not connected")
+	}
+
+	err := c.conn.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
 type NATSClientOption func(*NATSClient)
@@ -120,6 +121,12 @@ func (n *NATSClient) CleanupOldResources(ctx context.Context) error {
 	return nil
 }
 
+// MaxAge returns the time the streams created by the client keep their
+// messages, zero when they do not expire.
+func (n *NATSClient) MaxAge() time.Duration {
+	return n.maxAge
+}
+
 func (n *NATSClient) CreateOrUpdateStream(ctx context.Context, name, subject string, dedupWindow time.Duration) error {
 	//nolint:exhaustruct // readability
 	sc := jetstream.StreamConfig{
@@ -162,6 +169,25 @@ func (n *NATSClient) CreateOrUpdateJoinKeyValueStore(ctx context.Context, storeN
 	return nil
 }
 
+// CreateOrUpdateDedupDomainStore creates or updates the KV bucket of a
+// dedup domain, whose claims expire after the window of the domain.
+func (n *NATSClient) CreateOrUpdateDedupDomainStore(ctx context.Context, domain string, window time.Duration) (jetstream.KeyValue, error) {
+	//nolint:exhaustruct // optional config
+	cfg := jetstream.KeyValueConfig{
+		Bucket:      models.GetDedupDomainBucketName(domain),
+		TTL:         window,
+		Description: "Event IDs claimed by the pipelines of dedup domain " + domain,
+		Storage:     jetstream.FileStorage,
+	}
+
+	kv, err := n.JetStream().CreateOrUpdateKeyValue(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create nats key value store %s: %w", cfg.Bucket, err)
+	}
+
+	return kv, nil
+}
+
 // GetKeyValueStore gets an existing NATS KeyValue store
 func (n *NATSClient) GetKeyValueStore(ctx context.Context, storeName string) (jetstream.KeyValue, error) {
 	kv, err := n.JetStream().KeyValue(ctx, storeName)
@@ -194,6 +220,10 @@ func (n *NATSClient) JetStream() jetstream.JetStream {
 	return n.js
 }
 
+func (n *NATSClient) Conn() *nats.Conn {
+	return n.nc
+}
+
 func (n *NATSClient) DeleteStream(ctx context.Context, streamName string) error {
 	err := n.js.DeleteStream(ctx, streamName)
 	if err != nil {
@@ -219,6 +249,34 @@ func (n *NATSClient) DeleteKeyValueStore(ctx context.Context, storeName string)
 	return nil
 }
 
+// CreateOrUpdateObjectStore creates or updates a NATS object store
+func (n *NATSClient) CreateOrUpdateObjectStore(ctx context.Context, bucket, description string) (jetstream.ObjectStore, error) {
+	//nolint:exhaustruct // optional config
+	cfg := jetstream.ObjectStoreConfig{
+		Bucket:      bucket,
+		Description: description,
+	}
+
+	store, err := n.js.CreateOrUpdateObjectStore(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create nats object store %s: %w", bucket, err)
+	}
+
+	return store, nil
+}
+
+func (n *NATSClient) DeleteObjectStore(ctx context.Context, bucket string) error {
+	err := n.js.DeleteObjectStore(ctx, bucket)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrBucketNotFound) {
+			// Object store already deleted, this is not an error
+			return nil
+		}
+		return fmt.Errorf("delete object store %s: %w", bucket, err)
+	}
+	return nil
+}
+
 // CheckConsumerPendingMessages checks if a consumer has any pending or unacknowledged messages
 // Returns: hasPending (bool), pendingCount (int), unacknowledgedCount (int), error
 func (n *NATSClient) CheckConsumerPendingMessages(ctx context.Context, streamName, consumerName string) (bool, int, int, error) {
@@ -250,6 +308,155 @@ func (n *NATSClient) CheckConsumerPendingMessages(ctx context.Context, streamNam
 	return hasPending, pending, unacknowledged, nil
 }
 
+// ConsumersInFlight returns the pending and unacknowledged messages of every
+// consumer on the pipeline's streams. The DLQ is skipped, its messages wait
+// for the user rather than for a component.
+func (n *NATSClient) ConsumersInFlight(ctx context.Context, pipelineID string) ([]models.ComponentInFlight, error) {
+	var inFlight []models.ComponentInFlight
+	err := n.forEachPipelineConsumer(ctx, pipelineID, func(_ jetstream.Stream, c *jetstream.ConsumerInfo) error {
+		inFlight = append(inFlight, models.ComponentInFlight{
+			Component: models.GetNATSConsumerComponent(c.Name),
+			Stream:    c.Stream,
+			Consumer:  c.Name,
+			Pending:   c.NumPending,
+			Unacked:   c.NumAckPending,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inFlight, nil
+}
+
+// PipelineLatency returns the age of the oldest message a component of the
+// pipeline has not processed yet, zero when every consumer is caught up.
+// The DLQ is skipped like in ConsumersInFlight.
+func (n *NATSClient) PipelineLatency(ctx context.Context, pipelineID string) (time.Duration, error) {
+	var latency time.Duration
+	err := n.forEachPipelineConsumer(ctx, pipelineID, func(stream jetstream.Stream, c *jetstream.ConsumerInfo) error {
+		if c.NumPending == 0 && c.NumAckPending == 0 {
+			return nil
+		}
+
+		// The first message past the ack floor is the oldest one the
+		// consumer did not acknowledge, unless the stream dropped it already
+		seq := c.AckFloor.Stream + 1
+		if first := stream.CachedInfo().State.FirstSeq; first > seq {
+			seq = first
+		}
+
+		msg, err := stream.GetMsg(ctx, seq)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgNotFound) {
+				return nil
+			}
+			return fmt.Errorf("get message %d of stream %s: %w", seq, c.Stream, err)
+		}
+
+		latency = max(latency, time.Since(msg.Time))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return latency, nil
+}
+
+// forEachPipelineConsumer calls fn with every consumer on the pipeline's
+// streams, except the DLQ.
+func (n *NATSClient) forEachPipelineConsumer(ctx context.Context, pipelineID string, fn func(jetstream.Stream, *jetstream.ConsumerInfo) error) error {
+	streamPrefix := fmt.Sprintf("%s-%s-", internal.PipelineStreamPrefix, models.GenerateStreamHash(pipelineID))
+	dlqStreamName := models.GetDLQStreamName(pipelineID)
+
+	streamIterator := n.js.ListStreams(ctx)
+	for s := range streamIterator.Info() {
+		name := s.Config.Name
+		if !strings.HasPrefix(name, streamPrefix) || name == dlqStreamName {
+			continue
+		}
+
+		stream, err := n.js.Stream(ctx, name)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrStreamNotFound) {
+				continue
+			}
+			return fmt.Errorf("get stream %s: %w", name, err)
+		}
+
+		// The iterators are drained even after fn failed, so that they
+		// don't block on their channels
+		var fnErr error
+		consumerIterator := stream.ListConsumers(ctx)
+		for c := range consumerIterator.Info() {
+			if fnErr == nil {
+				fnErr = fn(stream, c)
+			}
+		}
+		if err := consumerIterator.Err(); err != nil {
+			return fmt.Errorf("list consumers of stream %s: %w", name, err)
+		}
+		if fnErr != nil {
+			return fnErr
+		}
+	}
+	if err := streamIterator.Err(); err != nil {
+		return fmt.Errorf("list streams: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumerPositions returns the position of every consumer on the
+// pipeline's streams in its stream. The DLQ is skipped like in
+// ConsumersInFlight.
+func (n *NATSClient) ConsumerPositions(ctx context.Context, pipelineID string) ([]models.ConsumerPosition, error) {
+	var positions []models.ConsumerPosition
+	err := n.forEachPipelineConsumer(ctx, pipelineID, func(stream jetstream.Stream, c *jetstream.ConsumerInfo) error {
+		state := stream.CachedInfo().State
+		positions = append(positions, models.ConsumerPosition{
+			Component: models.GetNATSConsumerComponent(c.Name),
+			Stream:    c.Stream,
+			Consumer:  c.Name,
+			FirstSeq:  state.FirstSeq,
+			LastSeq:   state.LastSeq,
+			AckFloor:  c.AckFloor.Stream,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// StreamsStorage returns the storage the pipeline's streams use and their
+// limits. The DLQ is included, it discards messages like the other streams.
+func (n *NATSClient) StreamsStorage(ctx context.Context, pipelineID string) ([]models.StreamStorage, error) {
+	streamPrefix := fmt.Sprintf("%s-%s-", internal.PipelineStreamPrefix, models.GenerateStreamHash(pipelineID))
+
+	var storage []models.StreamStorage
+	streamIterator := n.js.ListStreams(ctx)
+	for s := range streamIterator.Info() {
+		if !strings.HasPrefix(s.Config.Name, streamPrefix) {
+			continue
+		}
+		storage = append(storage, models.NewStreamStorage(
+			s.Config.Name,
+			s.State.Bytes, s.Config.MaxBytes,
+			s.State.Msgs, s.Config.MaxMsgs,
+		))
+	}
+	if err := streamIterator.Err(); err != nil {
+		return nil, fmt.Errorf("list streams: %w", err)
+	}
+
+	return storage, nil
+}
+
 func (n *NATSClient) Close() error {
 	n.nc.Close()
 	return nil
@@ -9,7 +9,9 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/ingestor"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mysql"
 	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
 )
@@ -35,28 +37,36 @@ func NewIngestorComponent(
 	dlqStreamPublisher stream.Publisher,
 	schema *schemav2.Schema,
 	signalPublisher *componentsignals.ComponentSignalPublisher,
+	mysqlCheckpoints mysql.CheckpointStore,
+	flowGate kafka.FlowGate,
 	doneCh chan struct{},
 	log *slog.Logger,
 ) (*IngestorComponent, error) {
-	if config.Ingestor.Type != internal.KafkaIngestorType {
-		return nil, fmt.Errorf("unknown ingestor type")
-	}
-
-	ingestor, err := ingestor.NewKafkaIngestor(
-		config,
-		topicName,
-		runtimeCfg,
-		streamPublisher,
-		dlqStreamPublisher,
-		schema,
-		signalPublisher,
-		log,
+	var (
+		source Ingestor
+		err    error
 	)
-	if err != nil {
-		return nil, fmt.Errorf("error creating kafka source ingestor: %w", err)
+	switch config.Ingestor.Type {
+	case internal.KafkaIngestorType:
+		source, err = ingestor.NewKafkaIngestor(config, topicName, runtimeCfg, streamPublisher, dlqStreamPublisher, schema, signalPublisher, flowGate, log)
+		if err != nil {
+			return nil, fmt.Errorf("error creating kafka source ingestor: %w", err)
+		}
+	case internal.PulsarIngestorType:
+		source, err = ingestor.NewPulsarIngestor(config, topicName, runtimeCfg, streamPublisher, dlqStreamPublisher, schema, signalPublisher, log)
+		if err != nil {
+			return nil, fmt.Errorf("error creating pulsar source ingestor: %w", err)
+		}
+	case internal.MySQLIngestorType:
+		source, err = ingestor.NewMySQLIngestor(config, topicName, runtimeCfg, streamPublisher, dlqStreamPublisher, schema, signalPublisher, mysqlCheckpoints, log)
+		if err != nil {
+			return nil, fmt.Errorf("error creating mysql source ingestor: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown ingestor type")
 	}
 	return &IngestorComponent{
-		ingestor:  ingestor,
+		ingestor:  source,
 		log:       log,
 		topicName: topicName,
 		wg:        sync.WaitGroup{},
@@ -2,9 +2,11 @@ package component
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go/jetstream"
 
@@ -16,12 +18,44 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 )
 
+// unmatchedSweeper is implemented by executors that route or emit the events
+// which found no match, it is nil when they are dropped on expiry.
+type unmatchedSweeper interface {
+	SweepUnmatched(ctx context.Context) error
+}
+
+// JoinOption configures optional behaviour of a join component.
+type JoinOption func(*JoinComponent)
+
+// WithJoinCheckpoints checkpoints the buffer indexes and consumer positions
+// of the join under name in store, and restores them on start.
+func WithJoinCheckpoints(store join.CheckpointStore, name string) JoinOption {
+	return func(j *JoinComponent) {
+		j.checkpoints = store
+		j.checkpointName = name
+	}
+}
+
 type JoinComponent struct {
 	leftStreamSubsriber   stream.Subscriber
 	rightStreamSubscriber stream.Subscriber
+	leftConsumerCreated   time.Time
+	rightConsumerCreated  time.Time
+	checkpoints           join.CheckpointStore
+	checkpointName        string
+	leftProgress          *join.ConsumerProgress
+	rightProgress         *join.ConsumerProgress
 	executor              join.Executor
+	sweeper               unmatchedSweeper
+	leftBuffer            *join.WindowBuffer
+	rightBuffer           *join.WindowBuffer
+	compaction            bool
+	reorderer             *join.EventReorderer
+	leftEventTime         *join.EventTimeExtractor
+	rightEventTime        *join.EventTimeExtractor
 	handleMu              sync.Mutex
 	wg                    sync.WaitGroup
 	once                  sync.Once
@@ -39,11 +73,13 @@ func NewJoinComponent(
 	leftSchema, rightSchema *schemav2.Schema,
 	cfgStore configs.ConfigStoreInterface,
 	leftKVStore, rightKVStore kv.KeyValueStore,
+	unmatched join.UnmatchedHandler,
 	leftSourceName, rightSourceName, leftKey, rightKey string,
 	doneCh chan struct{},
 	log *slog.Logger,
 	pipelineID string,
 	signalPublisher *componentsignals.ComponentSignalPublisher,
+	opts ...JoinOption,
 ) (Component, error) {
 	if cfg.Type != internal.TemporalJoinType {
 		return nil, fmt.Errorf("unsupported join type")
@@ -53,27 +89,95 @@ func NewJoinComponent(
 	// but we need a context to use our Stop function
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var (
+		leftCache, rightCache *join.RecordCache
+		bufferOpts            []join.BufferOption
+	)
+	if cfg.RightCache.Enabled {
+		rightCache = join.NewRecordCache(cfg.RightCache.MaxBytes, cfg.RightBufferTTL.Duration())
+	}
+	// Tiered buffers keep the events of the hot window in memory and compact
+	// the older ones
+	if cfg.Tiering.Enabled {
+		leftCache = join.NewRecordCache(cfg.Tiering.HotMaxBytes, cfg.Tiering.HotWindow.Duration())
+		rightCache = join.NewRecordCache(cfg.Tiering.HotMaxBytes, cfg.Tiering.HotWindow.Duration())
+		bufferOpts = append(bufferOpts, join.WithCompaction(cfg.Tiering.CompactAfter.Duration()))
+	}
+
+	// Left events stay buffered until a right event joins them, right events
+	// are kept for every later left event of their key
+	leftBuffer := join.NewWindowBuffer(leftKVStore, leftCache, internal.JoinLeft, cfg.LeftBufferTTL.Duration(), false, log, bufferOpts...)
+	rightBuffer := join.NewWindowBuffer(rightKVStore, rightCache, internal.JoinRight, cfg.RightBufferTTL.Duration(), !cfg.RightMatchAll(), log, bufferOpts...)
+
+	outer := join.OuterJoin{Left: cfg.OuterLeft(), Right: cfg.OuterRight(), Grace: cfg.Grace.Duration()}
+
 	executor := join.NewTemporalJoinExecutor(
 		resultsPublisher,
 		leftSchema, rightSchema,
 		cfgStore,
-		leftKVStore, rightKVStore,
+		leftBuffer, rightBuffer,
+		unmatched,
+		outer,
+		cfg.LeftWindow(), cfg.RightWindow(),
 		leftSourceName, rightSourceName, leftKey, rightKey,
 		log,
 		pipelineID,
 		signalPublisher,
 	)
-	return &JoinComponent{
+
+	var sweeper unmatchedSweeper
+	if unmatched != nil || outer.Enabled() {
+		sweeper = executor
+	}
+
+	var (
+		reorderer                     *join.EventReorderer
+		leftEventTime, rightEventTime *join.EventTimeExtractor
+	)
+	if cfg.Ordering.Enabled {
+		reorderer = join.NewEventReorderer(cfg.Ordering.MaxSkew.Duration())
+		for _, source := range cfg.Sources {
+			switch source.Orientation {
+			case internal.JoinLeft:
+				leftEventTime = join.NewEventTimeExtractor(leftSchema, source.TimeField)
+			case internal.JoinRight:
+				rightEventTime = join.NewEventTimeExtractor(rightSchema, source.TimeField)
+			}
+		}
+	}
+
+	j := &JoinComponent{
 		leftStreamSubsriber:   stream.NewNATSSubscriber(leftStreamConsumer, log),
 		rightStreamSubscriber: stream.NewNATSSubscriber(rightStreamConsumer, log),
+		leftConsumerCreated:   consumerCreated(leftStreamConsumer),
+		rightConsumerCreated:  consumerCreated(rightStreamConsumer),
 		executor:              executor,
+		sweeper:               sweeper,
+		leftBuffer:            leftBuffer,
+		rightBuffer:           rightBuffer,
+		compaction:            cfg.Tiering.Enabled,
+		reorderer:             reorderer,
+		leftEventTime:         leftEventTime,
+		rightEventTime:        rightEventTime,
 		handleMu:              sync.Mutex{},
 		wg:                    sync.WaitGroup{},
 		ctx:                   ctx,
 		cancelFunc:            cancel,
 		doneCh:                doneCh,
 		log:                   log,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j, nil
+}
+
+func consumerCreated(consumer jetstream.Consumer) time.Time {
+	info := consumer.CachedInfo()
+	if info == nil {
+		return time.Time{}
+	}
+	return info.Created
 }
 
 func (j *JoinComponent) Start(ctx context.Context, errChan chan<- error) {
@@ -84,19 +188,24 @@ func (j *JoinComponent) Start(ctx context.Context, errChan chan<- error) {
 
 	j.log.Info("Join component is starting...")
 
-	err := j.leftStreamSubsriber.Subscribe(func(msg jetstream.Msg) {
+	err := j.restore(ctx)
+	if err != nil {
+		errChan <- err
+		return
+	}
+
+	err = j.leftStreamSubsriber.Subscribe(func(msg jetstream.Msg) {
 		j.handleMu.Lock()
 		defer j.handleMu.Unlock()
 
-		err := j.executor.HandleLeftStreamEvents(ctx, msg)
-		if err != nil {
-			j.log.Error("failed to handle left stream event", slog.Any("error", err))
+		if j.skipHandled(msg, false) {
 			return
 		}
-		err = msg.Ack()
-		if err != nil {
-			j.log.Error("failed to ack left stream message", slog.Any("error", err))
+		if j.reorderer != nil {
+			j.holdEvent(ctx, msg, false)
+			return
 		}
+		j.handleEvent(ctx, msg, false)
 	})
 	if err != nil {
 		errChan <- fmt.Errorf("failed to start left stream consumer: %w", err)
@@ -107,21 +216,43 @@ func (j *JoinComponent) Start(ctx context.Context, errChan chan<- error) {
 		j.handleMu.Lock()
 		defer j.handleMu.Unlock()
 
-		err := j.executor.HandleRightStreamEvents(ctx, msg)
-		if err != nil {
-			j.log.Error("failed to handle right stream event", slog.Any("error", err))
+		if j.skipHandled(msg, true) {
 			return
 		}
-		err = msg.Ack()
-		if err != nil {
-			j.log.Error("failed to ack right stream message", slog.Any("error", err))
+		if j.reorderer != nil {
+			j.holdEvent(ctx, msg, true)
+			return
 		}
+		j.handleEvent(ctx, msg, true)
 	})
 	if err != nil {
 		errChan <- fmt.Errorf("failed to start right stream consumer: %w", err)
 		return
 	}
 
+	j.wg.Add(1)
+	go j.evictBuffers(ctx)
+
+	if j.sweeper != nil {
+		j.wg.Add(1)
+		go j.sweepUnmatched(ctx)
+	}
+
+	if j.reorderer != nil {
+		j.wg.Add(1)
+		go j.releaseHeldEvents(ctx)
+	}
+
+	if j.checkpoints != nil {
+		j.wg.Add(1)
+		go j.checkpointPeriodically(ctx)
+	}
+
+	if j.compaction {
+		j.wg.Add(1)
+		go j.compactBuffers(ctx)
+	}
+
 	j.log.Info("Join component was started successfully!")
 
 	select {
@@ -137,6 +268,7 @@ func (j *JoinComponent) Start(ctx context.Context, errChan chan<- error) {
 		}
 		<-j.leftStreamSubsriber.Closed()
 		<-j.rightStreamSubscriber.Closed()
+		j.finalCheckpoint()
 		return
 
 	case <-j.leftStreamSubsriber.Closed():
@@ -157,6 +289,289 @@ func (j *JoinComponent) Start(ctx context.Context, errChan chan<- error) {
 	}
 }
 
+// handleEvent joins an event and acknowledges it. Must be called with handleMu held.
+func (j *JoinComponent) handleEvent(ctx context.Context, msg jetstream.Msg, right bool) {
+	if right {
+		err := j.executor.HandleRightStreamEvents(ctx, msg)
+		if err != nil {
+			j.log.Error("failed to handle right stream event", slog.Any("error", err))
+			return
+		}
+		j.recordHandled(msg, true)
+		err = msg.Ack()
+		if err != nil {
+			j.log.Error("failed to ack right stream message", slog.Any("error", err))
+		}
+		return
+	}
+
+	err := j.executor.HandleLeftStreamEvents(ctx, msg)
+	if err != nil {
+		j.log.Error("failed to handle left stream event", slog.Any("error", err))
+		return
+	}
+	j.recordHandled(msg, false)
+	err = msg.Ack()
+	if err != nil {
+		j.log.Error("failed to ack left stream message", slog.Any("error", err))
+	}
+}
+
+// holdEvent hands an event of an ordered join to the reorderer and joins the
+// events it releases. Held events are acknowledged once joined, after a
+// restart they are redelivered and ordered again. Must be called with
+// handleMu held.
+func (j *JoinComponent) holdEvent(ctx context.Context, msg jetstream.Msg, right bool) {
+	eventTime := j.leftEventTime
+	orientation := internal.JoinLeft
+	if right {
+		eventTime = j.rightEventTime
+		orientation = internal.JoinRight
+	}
+
+	t, err := eventTime.EventTime(ctx, msg)
+	if err != nil {
+		j.log.Error("failed to get join event time", slog.String("orientation", orientation), slog.Any("error", err))
+		return
+	}
+
+	if !j.reorderer.Push(join.OrderedEvent{EventTime: t, Right: right, Msg: msg}) {
+		observability.RecordJoinLateEvent(ctx, orientation)
+		j.handleEvent(ctx, msg, right)
+		return
+	}
+
+	for _, ev := range j.reorderer.Ready() {
+		j.handleEvent(ctx, ev.Msg, ev.Right)
+	}
+}
+
+// releaseHeldEvents joins the events held for the max skew while the
+// sources are idle, until the component stops.
+func (j *JoinComponent) releaseHeldEvents(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(internal.JoinOrderingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case now := <-ticker.C:
+			j.handleMu.Lock()
+			for _, ev := range j.reorderer.Expired(now) {
+				j.handleEvent(ctx, ev.Msg, ev.Right)
+			}
+			j.handleMu.Unlock()
+		}
+	}
+}
+
+// sweepUnmatched periodically routes or emits the unmatched events until the
+// component stops. Sweeps hold handleMu so they do not race the handlers on
+// the buffers.
+func (j *JoinComponent) sweepUnmatched(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(internal.JoinUnmatchedSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			j.handleMu.Lock()
+			err := j.sweeper.SweepUnmatched(ctx)
+			j.handleMu.Unlock()
+			if err != nil {
+				j.log.Error("failed to sweep unmatched events", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// evictBuffers periodically drops the events older than their window from
+// the join buffers until the component stops.
+func (j *JoinComponent) evictBuffers(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(internal.JoinBufferEvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			j.handleMu.Lock()
+			j.leftBuffer.Evict(ctx)
+			j.rightBuffer.Evict(ctx)
+			j.handleMu.Unlock()
+		}
+	}
+}
+
+// compactBuffers periodically moves the events older than the compaction
+// delay into the segments of their key until the component stops.
+func (j *JoinComponent) compactBuffers(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(internal.JoinCompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			j.handleMu.Lock()
+			err := j.leftBuffer.Compact(ctx)
+			if err != nil {
+				j.log.Error("failed to compact left join buffer", slog.Any("error", err))
+			}
+			err = j.rightBuffer.Compact(ctx)
+			if err != nil {
+				j.log.Error("failed to compact right join buffer", slog.Any("error", err))
+			}
+			j.handleMu.Unlock()
+		}
+	}
+}
+
+// restore indexes the join buffers, from the checkpoint of the stage when
+// there is one, and restores the consumer positions.
+func (j *JoinComponent) restore(ctx context.Context) error {
+	if j.checkpoints == nil {
+		err := j.leftBuffer.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load left join buffer: %w", err)
+		}
+		err = j.rightBuffer.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load right join buffer: %w", err)
+		}
+		return nil
+	}
+
+	cp, err := j.checkpoints.Load(ctx, j.checkpointName)
+	if err != nil && !errors.Is(err, join.ErrCheckpointNotFound) {
+		return fmt.Errorf("failed to load join checkpoint: %w", err)
+	}
+
+	err = j.leftBuffer.Restore(ctx, cp.Left.Entries)
+	if err != nil {
+		return fmt.Errorf("failed to restore left join buffer: %w", err)
+	}
+	err = j.rightBuffer.Restore(ctx, cp.Right.Entries)
+	if err != nil {
+		return fmt.Errorf("failed to restore right join buffer: %w", err)
+	}
+
+	j.leftProgress = join.NewConsumerProgress(j.leftConsumerCreated, cp.Left)
+	j.rightProgress = join.NewConsumerProgress(j.rightConsumerCreated, cp.Right)
+	return nil
+}
+
+// skipHandled acknowledges and skips a redelivered event that was handled
+// before the restored checkpoint, otherwise it records the delivery. Must be
+// called with handleMu held.
+func (j *JoinComponent) skipHandled(msg jetstream.Msg, right bool) bool {
+	progress := j.progress(right)
+	if progress == nil {
+		return false
+	}
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		j.log.Error("failed to get join message metadata", slog.Any("error", err))
+		return false
+	}
+
+	if progress.AlreadyHandled(meta.Sequence.Stream) {
+		err = msg.Ack()
+		if err != nil {
+			j.log.Error("failed to ack handled join message", slog.Any("error", err))
+		}
+		return true
+	}
+
+	progress.Delivered(meta.Sequence.Stream)
+	return false
+}
+
+// recordHandled records a handled event in the consumer positions. Must be
+// called with handleMu held.
+func (j *JoinComponent) recordHandled(msg jetstream.Msg, right bool) {
+	progress := j.progress(right)
+	if progress == nil {
+		return
+	}
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		return
+	}
+	progress.Handled(meta.Sequence.Stream)
+}
+
+func (j *JoinComponent) progress(right bool) *join.ConsumerProgress {
+	if right {
+		return j.rightProgress
+	}
+	return j.leftProgress
+}
+
+// checkpointPeriodically saves the checkpoint of the stage until the
+// component stops.
+func (j *JoinComponent) checkpointPeriodically(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(internal.JoinCheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			err := j.saveCheckpoint(ctx)
+			if err != nil {
+				j.log.Error("failed to save join checkpoint", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// finalCheckpoint saves the checkpoint once the subscribers are closed, so
+// that a restart skips every event handled before the stop.
+func (j *JoinComponent) finalCheckpoint() {
+	if j.checkpoints == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), internal.DefaultComponentShutdownTimeout)
+	defer cancel()
+
+	err := j.saveCheckpoint(ctx)
+	if err != nil {
+		j.log.Error("failed to save final join checkpoint", slog.Any("error", err))
+	}
+}
+
+func (j *JoinComponent) saveCheckpoint(ctx context.Context) error {
+	j.handleMu.Lock()
+	cp := join.Checkpoint{
+		TakenAt: time.Now().UTC(),
+		Left:    j.leftProgress.Checkpoint(j.leftBuffer.Checkpoint()),
+		Right:   j.rightProgress.Checkpoint(j.rightBuffer.Checkpoint()),
+	}
+	j.handleMu.Unlock()
+
+	return j.checkpoints.Save(ctx, j.checkpointName, cp)
+}
+
 func (j *JoinComponent) Stop(opts ...StopOption) {
 	j.once.Do(func() {
 		options := &StopOptions{
@@ -10,7 +10,6 @@ import (
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
-	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/sink"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
@@ -32,12 +31,17 @@ type SinkComponent struct {
 func NewSinkComponent(
 	sinkConfig models.SinkComponentConfig,
 	streamCon jetstream.Consumer,
-	mapper *mapper.KafkaToClickHouseMapper,
+	mapper sink.FieldMapper,
 	cfgStore *configs.ConfigStore,
 	doneCh chan struct{},
 	log *slog.Logger,
 	dlqPublisher stream.Publisher,
+	pipelineID string,
 	streamSourceID string,
+	decodeWorkers int,
+	profileEvents bool,
+	stagingStore sink.StagingStore,
+	credentials sink.CredentialsSource,
 ) (Component, error) {
 	if sinkConfig.Type != internal.ClickHouseSinkType {
 		return nil, fmt.Errorf("unsupported sink type: %s", sinkConfig.Type)
@@ -52,8 +56,13 @@ func NewSinkComponent(
 		dlqPublisher,
 		models.ClickhouseQueryConfig{
 			WaitForAsyncInsert: true,
+			ProfileEvents:      profileEvents,
 		},
+		pipelineID,
 		streamSourceID,
+		decodeWorkers,
+		stagingStore,
+		credentials,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sink: %w", err)
@@ -0,0 +1,151 @@
+package componenthandshake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+var ErrComponentRejected = errors.New("component rejected by the API")
+
+// Announce reports the build of a component to the API and fails when the
+// API rejects it. A component whose API does not answer, because the API
+// predates the handshake or is down, starts with a warning.
+func Announce(ctx context.Context, nc *client.NATSClient, hello models.ComponentHello, log *slog.Logger) error {
+	data, err := json.Marshal(hello)
+	if err != nil {
+		return fmt.Errorf("marshal component hello: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, internal.ComponentHandshakeTimeout)
+	defer cancel()
+
+	msg, err := nc.Conn().RequestWithContext(reqCtx, models.ComponentHandshakeSubject, data)
+	if err != nil {
+		if errors.Is(err, nats.ErrNoResponders) || errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			log.WarnContext(ctx, "no answer to the component handshake, starting anyway", "error", err)
+			return nil
+		}
+		return fmt.Errorf("component handshake: %w", err)
+	}
+
+	var reply models.ComponentHelloReply
+	err = json.Unmarshal(msg.Data, &reply)
+	if err != nil {
+		return fmt.Errorf("unmarshal component handshake reply: %w", err)
+	}
+
+	if !reply.Accepted {
+		return fmt.Errorf("%w: %s", ErrComponentRejected, reply.Reason)
+	}
+	if reply.Reason != "" {
+		log.WarnContext(ctx, "component accepted with a warning", "reason", reply.Reason)
+	}
+
+	return nil
+}
+
+// Server answers the handshakes of the components. API replicas share the
+// subscription through a queue group, so each handshake is answered once.
+// With reports, the config hash of the accepted components is recorded.
+type Server struct {
+	nc      *client.NATSClient
+	policy  string
+	reports *Reports
+	log     *slog.Logger
+}
+
+func NewServer(nc *client.NATSClient, policy string, reports *Reports, log *slog.Logger) *Server {
+	return &Server{
+		nc:      nc,
+		policy:  policy,
+		reports: reports,
+		log:     log,
+	}
+}
+
+// Start answers the handshakes until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	sub, err := s.nc.Conn().QueueSubscribe(models.ComponentHandshakeSubject, internal.ComponentHandshakeQueue, s.handle)
+	if err != nil {
+		return fmt.Errorf("subscribe to component handshakes: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		//nolint: errcheck // the connection is closing
+		sub.Unsubscribe()
+	}()
+
+	return nil
+}
+
+func (s *Server) handle(msg *nats.Msg) {
+	var hello models.ComponentHello
+	reply := models.ComponentHelloReply{Accepted: false, Reason: "invalid component handshake"}
+
+	err := json.Unmarshal(msg.Data, &hello)
+	if err != nil {
+		s.log.Warn("failed to unmarshal component handshake", "error", err)
+	} else {
+		reply = s.check(hello)
+	}
+
+	if reply.Accepted && s.reports != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), internal.ComponentHandshakeTimeout)
+		err = s.reports.Record(ctx, hello)
+		cancel()
+		if err != nil {
+			s.log.Warn("failed to record component config hash", "pipeline_id", hello.PipelineID, "component", hello.Component, "error", err)
+		}
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		s.log.Error("failed to marshal component handshake reply", "error", err)
+		return
+	}
+
+	err = msg.Respond(data)
+	if err != nil {
+		s.log.Warn("failed to answer component handshake", "pipeline_id", hello.PipelineID, "component", hello.Component, "error", err)
+	}
+}
+
+// check accepts a component unless its build is older than the schema of
+// the pipeline config it was given, which it would partly ignore. Such a
+// component is rejected or only warned about, depending on the policy.
+func (s *Server) check(hello models.ComponentHello) models.ComponentHelloReply {
+	log := s.log.With(
+		"pipeline_id", hello.PipelineID,
+		"component", hello.Component,
+		"version", hello.Version,
+		"commit", hello.Commit,
+		"config_hash", hello.ConfigHash,
+	)
+
+	if hello.ConfigSchemaVersion <= hello.SupportedSchemaVersion {
+		log.Info("component started")
+		return models.ComponentHelloReply{Accepted: true}
+	}
+
+	reason := fmt.Sprintf(
+		"component %s supports pipeline config schema %d but was given schema %d, upgrade its image",
+		hello.Version, hello.SupportedSchemaVersion, hello.ConfigSchemaVersion,
+	)
+	if s.policy == internal.ComponentVersionPolicyReject {
+		log.Error("rejected component older than its pipeline config", "reason", reason)
+		return models.ComponentHelloReply{Accepted: false, Reason: reason}
+	}
+
+	log.Warn("component is older than its pipeline config", "reason", reason)
+	return models.ComponentHelloReply{Accepted: true, Reason: reason}
+}
@@ -0,0 +1,110 @@
+package componenthandshake
+
+import (
+	"log/slog"
+	"testing"
+
+	natsServer "github.com/nats-io/nats-server/v2/server"
+	natsTest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func newNATSClient(t *testing.T) *client.NATSClient {
+	t.Helper()
+
+	ns := natsTest.RunServer(&natsServer.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	t.Cleanup(ns.Shutdown)
+
+	nc, err := client.NewNATSClient(t.Context(), ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = nc.Close() })
+
+	return nc
+}
+
+func hello(supported, given int) models.ComponentHello {
+	return models.ComponentHello{
+		PipelineID:             "pipeline-1",
+		Component:              internal.RoleSink,
+		Version:                "v2.10.0",
+		SupportedSchemaVersion: supported,
+		ConfigSchemaVersion:    given,
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	nc := newNATSClient(t)
+	require.NoError(t, NewServer(nc, internal.ComponentVersionPolicyReject, nil, slog.Default()).Start(t.Context()))
+
+	assert.NoError(t, Announce(t.Context(), nc, hello(2, 2), slog.Default()))
+	assert.NoError(t, Announce(t.Context(), nc, hello(2, 0), slog.Default()), "configs without a schema version are accepted")
+
+	err := Announce(t.Context(), nc, hello(1, 2), slog.Default())
+	require.ErrorIs(t, err, ErrComponentRejected)
+	assert.Contains(t, err.Error(), "supports pipeline config schema 1 but was given schema 2")
+}
+
+func TestAnnounce_WarnPolicy(t *testing.T) {
+	nc := newNATSClient(t)
+	require.NoError(t, NewServer(nc, internal.ComponentVersionPolicyWarn, nil, slog.Default()).Start(t.Context()))
+
+	assert.NoError(t, Announce(t.Context(), nc, hello(1, 2), slog.Default()))
+}
+
+func TestAnnounce_WithoutAPI(t *testing.T) {
+	nc := newNATSClient(t)
+
+	assert.NoError(t, Announce(t.Context(), nc, hello(1, 2), slog.Default()))
+}
+
+func TestAnnounce_RecordsReports(t *testing.T) {
+	nc := newNATSClient(t)
+	//nolint:exhaustruct // optional config
+	kv, err := nc.JetStream().CreateOrUpdateKeyValue(t.Context(), jetstream.KeyValueConfig{Bucket: internal.ComponentReportsBucket})
+	require.NoError(t, err)
+	reports := NewReports(kv)
+	require.NoError(t, NewServer(nc, internal.ComponentVersionPolicyReject, reports, slog.Default()).Start(t.Context()))
+
+	sink := hello(2, 2)
+	sink.Instance = "0"
+	sink.ConfigHash = "old"
+	require.NoError(t, Announce(t.Context(), nc, sink, slog.Default()))
+	sink.ConfigHash = "new"
+	require.NoError(t, Announce(t.Context(), nc, sink, slog.Default()))
+
+	rejected := hello(1, 2)
+	rejected.Instance = "1"
+	require.Error(t, Announce(t.Context(), nc, rejected, slog.Default()))
+
+	other := hello(2, 2)
+	other.PipelineID = "pipeline-2"
+	require.NoError(t, Announce(t.Context(), nc, other, slog.Default()))
+
+	list, err := reports.List(t.Context(), "pipeline-1")
+	require.NoError(t, err)
+	require.Len(t, list, 1, "a restarted instance replaces its report, rejected ones are not recorded")
+	assert.Equal(t, "new", list[0].ConfigHash)
+	assert.Equal(t, "0", list[0].Instance)
+
+	require.NoError(t, reports.Purge(t.Context(), "pipeline-1"))
+	list, err = reports.List(t.Context(), "pipeline-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	list, err = reports.List(t.Context(), "pipeline-2")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
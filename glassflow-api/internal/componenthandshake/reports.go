@@ -0,0 +1,133 @@
+package componenthandshake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// invalidKeyChars are the characters a NATS key-value key cannot hold.
+var invalidKeyChars = regexp.MustCompile(`[^-/_=a-zA-Z0-9]`)
+
+// Reports keeps the config hash every component instance reported at its
+// last start, in a key-value bucket shared by the API replicas. An instance
+// that restarts replaces its report.
+type Reports struct {
+	kv jetstream.KeyValue
+}
+
+func NewReports(kv jetstream.KeyValue) *Reports {
+	return &Reports{kv: kv}
+}
+
+// Record stores the report of a component instance.
+func (r *Reports) Record(ctx context.Context, hello models.ComponentHello) error {
+	data, err := json.Marshal(models.ComponentConfigReport{
+		PipelineID: hello.PipelineID,
+		Component:  hello.Component,
+		Instance:   hello.Instance,
+		Version:    hello.Version,
+		ConfigHash: hello.ConfigHash,
+		ReportedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal component config report: %w", err)
+	}
+
+	_, err = r.kv.Put(ctx, reportKey(hello.PipelineID, hello.Component, hello.Instance), data)
+	if err != nil {
+		return fmt.Errorf("put component config report: %w", err)
+	}
+	return nil
+}
+
+// List returns the reports of a pipeline's component instances, ordered by
+// component and instance.
+func (r *Reports) List(ctx context.Context, pipelineID string) ([]models.ComponentConfigReport, error) {
+	keys, err := r.keys(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]models.ComponentConfigReport, 0, len(keys))
+	for _, key := range keys {
+		entry, err := r.kv.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("get component config report %s: %w", key, err)
+		}
+
+		var report models.ComponentConfigReport
+		err = json.Unmarshal(entry.Value(), &report)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal component config report %s: %w", key, err)
+		}
+		reports = append(reports, report)
+	}
+
+	slices.SortFunc(reports, func(a, b models.ComponentConfigReport) int {
+		if c := strings.Compare(a.Component, b.Component); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Instance, b.Instance)
+	})
+	return reports, nil
+}
+
+// Purge removes the reports of a deleted pipeline.
+func (r *Reports) Purge(ctx context.Context, pipelineID string) error {
+	keys, err := r.keys(ctx, pipelineID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = r.kv.Purge(ctx, key)
+		if err != nil {
+			return fmt.Errorf("purge component config report %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (r *Reports) keys(ctx context.Context, pipelineID string) ([]string, error) {
+	lister, err := r.kv.ListKeysFiltered(ctx, keyToken(pipelineID)+".>")
+	if err != nil {
+		return nil, fmt.Errorf("list component config reports: %w", err)
+	}
+	//nolint: errcheck // the lister is drained
+	defer lister.Stop()
+
+	// Keys written during the listing can be reported twice
+	var keys []string
+	for key := range lister.Keys() {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func reportKey(pipelineID, component, instance string) string {
+	return strings.Join([]string{keyToken(pipelineID), keyToken(component), keyToken(instance)}, ".")
+}
+
+// keyToken replaces the characters of a key token that NATS rejects, dots
+// included as they separate the tokens.
+func keyToken(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return invalidKeyChars.ReplaceAllString(s, "_")
+}
@@ -14,6 +14,8 @@ const (
 
 	// Component types
 	KafkaIngestorType        = "kafka"
+	PulsarIngestorType       = "pulsar"
+	MySQLIngestorType        = "mysql"
 	TemporalJoinType         = "temporal"
 	SchemaMapperJSONToCHType = "jsonToClickhouse"
 	ClickHouseSinkType       = "clickhouse"
@@ -23,6 +25,7 @@ const (
 	OTLPLogsSourceType    = "otlp.logs"
 	OTLPTracesSourceType  = "otlp.traces"
 	OTLPMetricsSourceType = "otlp.metrics"
+	HTTPSourceType        = "http"
 
 	// Stream naming constants
 	MaxStreamNameLength  = 32
@@ -41,10 +44,65 @@ const (
 	InitialOffsetEarliest = "earliest"
 	InitialOffsetLatest   = "latest"
 
+	// Kafka partition assignment strategies. "group" relies on consumer-group
+	// rebalancing; "static" pins partitions to replicas by ordinal
+	// (partition % replicas == pod index) and never joins the group.
+	PartitionAssignmentGroup  = "group"
+	PartitionAssignmentStatic = "static"
+
+	// Kafka consumer-group rebalance strategies
+	RebalanceStrategyCooperativeSticky = "cooperative-sticky"
+	RebalanceStrategyEager             = "eager"
+
+	// Pulsar subscription types
+	PulsarSubscriptionShared    = "shared"
+	PulsarSubscriptionKeyShared = "key_shared"
+	PulsarSubscriptionFailover  = "failover"
+	PulsarSubscriptionExclusive = "exclusive"
+
+	// Ingestor policies for messages that cannot be decoded (malformed wire
+	// format header or payload not matching its writer schema)
+	DecodeErrorPolicyDLQ  = "dlq"
+	DecodeErrorPolicyFail = "fail"
+
+	// Nats-Msg-Id strategies of ingested records without a dedup key. JetStream
+	// drops a publish whose Msg-Id it has seen within the duplicate window.
+	MsgIDStrategyOffset      = "offset"
+	MsgIDStrategyContentHash = "content_hash"
+
+	// Dedup modes: keep_first drops the later events of a key within the
+	// window, keep_last emits every event with a version superseding the
+	// earlier ones in a ReplacingMergeTree table
+	DedupModeKeepFirst       = "keep_first"
+	DedupModeKeepLast        = "keep_last"
+	DefaultDedupVersionField = "_version"
+
+	// Debezium envelope unwrap: metadata fields added to the extracted row
+	// and the handling of delete events
+	DefaultDebeziumOpField        = "_op"
+	DefaultDebeziumTimestampField = "_ts"
+	DebeziumDeleteHandlingRewrite = "rewrite"
+	DebeziumDeleteHandlingDrop    = "drop"
+
 	// Join orientation constants
 	JoinLeft  = "left"
 	JoinRight = "right"
 
+	// Join unmatched event policies
+	JoinUnmatchedPolicyDrop  = "drop"
+	JoinUnmatchedPolicyDLQ   = "dlq"
+	JoinUnmatchedPolicyTable = "table"
+
+	// Join right match modes
+	JoinRightMatchLatest = "latest"
+	JoinRightMatchAll    = "all"
+
+	// Join types, outer joins emit the unmatched events of their outer sides
+	JoinTypeInner     = "inner"
+	JoinTypeLeft      = "left"
+	JoinTypeRight     = "right"
+	JoinTypeFullOuter = "full_outer"
+
 	// Transformation type constants
 	JoinTransformation      = "Join"
 	DedupJoinTransformation = "Join & Deduplication"
@@ -58,11 +116,13 @@ const (
 	RoleDeduplicator = "dedup"
 	RoleETL          = ""
 	RoleOLTPReceiver = "oltp-receiver"
+	RoleHTTPIngest   = "http-ingest"
 	RoleMigrateData  = "migrate-data"
 
 	// DLQ constants
 	DLQDefaultBatchSize = 1
 	DLQMaxBatchSize     = 1000
+	DLQDefaultPageLimit = 100
 	DLQSuffix           = "DLQ"
 	DLQSubjectName      = "failed"
 
@@ -116,6 +176,20 @@ const (
 	CHTypeLCString   = "LowCardinality(String)"
 	CHTypeLCFString  = "LowCardinality(FixedString)"
 	CHTypeLCDateTime = "LowCardinality(DateTime)"
+	CHTypeDate       = "Date"
+	CHTypeDate32     = "Date32"
+	CHTypeIPv4       = "IPv4"
+	CHTypeIPv6       = "IPv6"
+	CHTypeJSON       = "JSON"
+	// CHTypeObjectJSON is the experimental JSON type that JSON replaces
+	CHTypeObjectJSON = "Object('json')"
+	CHTypePoint      = "Point"
+	CHTypeRing       = "Ring"
+	CHTypePolygon    = "Polygon"
+
+	// SinkEventField is the mapping source field that maps the whole event,
+	// it can only be written to a JSON column.
+	SinkEventField = "$"
 
 	// Stream publisher constants
 	PublisherSyncInitialRetryDelay = 100 * time.Millisecond
@@ -154,6 +228,9 @@ const (
 	OTLPReceiverNATSHealthTimeout    = 2 * time.Second
 	OTLPReceiverNATSHealthStaleAfter = 15 * time.Second
 
+	// HTTP ingestion accepts at most HTTPIngestMaxBodyBytes per request
+	HTTPIngestMaxBodyBytes = 10 << 20
+
 	// Pipeline consumer retry config — applied to sink, join, and dedup consumers.
 	// MaxDeliver caps total delivery attempts; AckWait is the per-attempt timeout before
 	// NATS considers the message un-acked and redelivers it.
@@ -172,6 +249,13 @@ const (
 	PostgresMaxConnectionWait = 2 * time.Minute
 	PostgresConnectionTimeout = 1 * time.Minute
 
+	// Statements failing with a transient error, like a dropped connection,
+	// are retried with a jittered exponential backoff
+	PostgresQueryRetries       = 3
+	PostgresQueryRetryDelay    = 100 * time.Millisecond
+	PostgresQueryMaxRetryDelay = 5 * time.Second
+	PostgresHealthTimeout      = 2 * time.Second
+
 	// Kafka consumer constants
 	ConsumerGroupNamePrefix = "glassflow-consumer-group"
 	ClientID                = "glassflow-consumer"
@@ -186,6 +270,7 @@ const (
 	MechanismKerberos = "GSSAPI"
 	MechanismPlain    = "PLAIN"
 	MechanismNoAuth   = "NO_AUTH"
+	MechanismAWSIAM   = "AWS_MSK_IAM"
 
 	// kafka security protocols
 	SASLProtocolPlaintext     = "PLAINTEXT"
@@ -207,11 +292,120 @@ const (
 	// gfm_stream_depth and gfm_stream_depth_ratio gauges.
 	IngestorStreamDepthSampleInterval = 10 * time.Second
 
+	// Flow control checks the output streams of the ingestor and the
+	// consumers reading them every check interval. Polling is paused above
+	// a threshold and resumed once below the resume ratio of the threshold.
+	FlowControlCheckInterval = 5 * time.Second
+	FlowControlResumeRatio   = 0.8
+
+	// The SLA DLQ rate is averaged over the DLQ samples of the window
+	SLADLQRateWindow = 5 * time.Minute
+
 	// Orchestrator constants
 	ShutdownTimeout = 30 * time.Second
 
 	// Join constants
 	MaxStreamsSupportedWithJoin = 2
+	DefaultJoinCacheMaxBytes    = 64 << 20
+	// A chained join joins up to this many sources, two in its first stage
+	// and one more in every stage after it
+	MaxSourcesSupportedWithJoinChain = 6
+
+	// Unmatched left events are swept from the left buffer once they are
+	// older than the join window. The buffer TTL is extended by the grace
+	// period so that events are not expired by NATS before a sweep saw them.
+	JoinUnmatchedSweepInterval = 30 * time.Second
+	JoinUnmatchedGrace         = 5 * time.Minute
+
+	// Ordered joins hold events back for the max skew before processing
+	// them in event-time order. Held events are not acknowledged yet, so the
+	// skew has to stay below the ack wait of the join consumers.
+	DefaultJoinOrderingMaxSkew = 5 * time.Second
+	JoinOrderingMaxSkewLimit   = NatsConsumerAckWait / 2
+	JoinOrderingCheckInterval  = time.Second
+
+	// The join buffers drop the events older than their window from memory
+	// on every eviction pass, NATS expires their stored copy with the TTL.
+	JoinBufferEvictInterval = 10 * time.Second
+
+	// A join stage checkpoints its buffer index and consumer positions on
+	// this interval and when it stops. Positions track the sequences that
+	// were delivered but not handled up to the limit, the oldest are dropped.
+	JoinCheckpointInterval   = 30 * time.Second
+	JoinCheckpointMaxPending = 100_000
+
+	// Tiered join buffers keep the events of the hot window in memory and
+	// compact the events older than compact_after into one KV entry per join
+	// key, a segment, on every compaction pass. Segments stay below the NATS
+	// max payload, a pass moves a bounded number of events.
+	DefaultJoinHotWindow     = time.Hour
+	DefaultJoinHotMaxBytes   = 256 << 20
+	MinJoinCompactAfter      = time.Minute
+	JoinCompactInterval      = time.Minute
+	JoinSegmentMaxBytes      = 512 << 10
+	JoinCompactMaxEventsPass = 10_000
+
+	// Enrichment with a ClickHouse dimension table. The refresh mode holds
+	// the whole table in memory, the lookup mode caches the looked up rows.
+	EnrichmentModeRefresh            = "refresh"
+	EnrichmentModeLookup             = "lookup"
+	DefaultEnrichmentRefreshInterval = 5 * time.Minute
+	MinEnrichmentRefreshInterval     = time.Second
+	DefaultEnrichmentCacheTTL        = time.Minute
+	DefaultEnrichmentCacheMaxEntries = 100_000
+	MaxEnrichmentTableRows           = 1_000_000
+	EnrichmentQueryTimeout           = 30 * time.Second
+
+	// User-defined functions of the stateless transformation. A WASM module
+	// runs every event within the timeout and a memory of at most the limit.
+	UDFRuntimeWASM          = "wasm"
+	DefaultUDFFunction      = "transform"
+	DefaultUDFTimeout       = 100 * time.Millisecond
+	MaxUDFTimeout           = 5 * time.Second
+	DefaultUDFMemoryLimitMB = 16
+	MaxUDFMemoryLimitMB     = 256
+	MaxUDFModuleSize        = 8 << 20
+
+	// MinTransformSaltLength is the shortest salt keying the tokenize
+	// function of the stateless transformation.
+	MinTransformSaltLength = 16
+
+	// Bulk resumes start a group of pipelines once the pipelines of the
+	// previous groups, which they depend on, are running.
+	PipelineDependencyStartTimeout = 5 * time.Minute
+	PipelineDependencyPollInterval = 2 * time.Second
+
+	// A stop waits for the join and sink to process the messages left in
+	// their streams. A draining stop waits up to its drain timeout.
+	PipelineStopWaitTimeout     = time.Minute
+	DefaultPipelineDrainTimeout = 10 * time.Minute
+	MaxPipelineDrainTimeout     = time.Hour
+
+	// Pipelines carry at most MaxPipelineTags tags, filtered on in the
+	// pipeline list and aggregated in the tag health
+	MaxPipelineTags = 20
+
+	// Orchestrator actions are written to the outbox with the pipeline change
+	// and run by the API right away. The reconciler retries the actions still
+	// in the outbox after the lease, and marks the pipeline Failed after the
+	// last attempt.
+	OutboxActionSetup  = "setup"
+	OutboxActionEdit   = "edit"
+	OutboxActionDelete = "delete"
+	OutboxActionLease  = time.Minute
+	OutboxMaxAttempts  = 5
+	OutboxClaimLimit   = 20
+
+	// Component handshake constants. Components wait for the API answer to
+	// their build report up to the timeout, the policy decides whether a
+	// component older than its config is rejected or only logged.
+	ComponentHandshakeTimeout    = 5 * time.Second
+	ComponentHandshakeQueue      = "glassflow-api"
+	ComponentVersionPolicyWarn   = "warn"
+	ComponentVersionPolicyReject = "reject"
+	// ComponentReportsBucket is the NATS KV bucket holding the config hash
+	// every component instance reported at its last start
+	ComponentReportsBucket = "glassflow-component-reports"
 
 	// RunnersWatcher constants
 	RunnerWatcherInterval = 5 * time.Second
@@ -233,6 +427,15 @@ const (
 	PipelineEditAnnotation          = "pipeline.etl.glassflow.io/edit"
 	PipelineHelmUninstallAnnotation = "pipeline.etl.glassflow.io/helm-uninstall"
 	PipelinePauseAnnotation         = "pipeline.etl.glassflow.io/pause"
+	PipelineOrphanedAnnotation      = "pipeline.etl.glassflow.io/orphaned"
+	// PipelineDrainTimeoutAnnotation is set with the stop annotation for a
+	// draining stop, the operator stops the ingestors first and keeps the join
+	// and sink running until their streams are empty or the timeout passed
+	PipelineDrainTimeoutAnnotation = "pipeline.etl.glassflow.io/drain-timeout"
+
+	// PipelineConfigHashLabel carries the config hash on the pipeline resource,
+	// for the operator to copy onto the component pods
+	PipelineConfigHashLabel = "etl.glassflow.io/config-hash"
 
 	// SinkDefaultBatchMaxDelayTime is the maximum time to wait before flushing a partial batch to ClickHouse.
 	SinkDefaultBatchMaxDelayTime = 60 * time.Second
@@ -240,11 +443,55 @@ const (
 	SinkDefaultShutdownTimeout      = 5 * time.Second
 	DefaultComponentShutdownTimeout = 5 * time.Second
 
+	// The sink sheds load by pulling a single batch at a time while memory
+	// use relative to GOMEMLIMIT or CPU pressure (cgroup PSI some avg10) is
+	// above the high mark, and recovers once both are below the low marks.
+	SinkSheddingCheckInterval = 5 * time.Second
+	SinkSheddingMemoryHigh    = 0.85
+	SinkSheddingMemoryLow     = 0.70
+	SinkSheddingCPUHigh       = 0.50
+	SinkSheddingCPULow        = 0.20
+
+	// SinkStagingCheckInterval is how often a staging sink checks whether the
+	// pipeline staging was confirmed.
+	SinkStagingCheckInterval = 10 * time.Second
+
+	// SinkPauseCheckInterval is how often a sink checks whether it was paused
+	// or resumed through the API.
+	SinkPauseCheckInterval = 5 * time.Second
+
+	// SinkCredentialsRefreshRatio is the share of the lease of short-lived
+	// ClickHouse credentials after which the sink connects with new ones, a
+	// failed refresh is retried every SinkCredentialsRetryInterval.
+	SinkCredentialsRefreshRatio  = 0.67
+	SinkCredentialsRetryInterval = 10 * time.Second
+
+	// SinkMaxWriterConcurrency bounds the parallel writers of a sink, each of
+	// them can hold a ClickHouse connection while inserting.
+	SinkMaxWriterConcurrency = 16
+
+	// A warming up sink starts with the initial ratio of its batch and pull
+	// sizes and ramps them to the maximum over the warm-up window. Each writer
+	// sends at most a batch per flush interval meanwhile.
+	SinkWarmUpInitialRatio  = 0.1
+	SinkWarmUpFlushInterval = time.Second
+
 	DefaultDedupComponentBatchSize = 50000
 	DefaultDedupMaxWaitTime        = 100 * time.Millisecond
 
+	// MaxStreamDuplicateWindow caps the JetStream duplicate window derived
+	// from a dedup window. JetStream keeps the Msg-Ids of the window in
+	// memory, longer dedup windows are served by the deduplicator's store.
+	MaxStreamDuplicateWindow = 24 * time.Hour
+	// DedupValueLogGCRatio is the share of stale data from which a badger
+	// value log file is rewritten.
+	DedupValueLogGCRatio = 0.5
+
 	// Kafka session timeout in milliseconds
 	KafkaSessionTimeout = 30000 * time.Millisecond
+	// KafkaStaticMemberSessionTimeout is the session timeout used with static
+	// group membership; it must outlast a pod restart to avoid a rebalance.
+	KafkaStaticMemberSessionTimeout = 120 * time.Second
 	// Kafka heartbeat interval in milliseconds
 	KafkaHeartbeatInterval = 10000 * time.Millisecond
 	// Kafka MinFetchBytes is the minimum amount of data the server should return for a fetch request.
@@ -257,6 +504,38 @@ const (
 	DefaultKafkaBatchTimeout = 1 * time.Second
 	// KafkaMaxWait is the maximum time to wait for messages from Kafka
 	KafkaMaxWait = 750 * time.Millisecond
+	// KafkaPartitionCheckInterval is how often the Kafka ingestor checks
+	// its topic for added partitions
+	KafkaPartitionCheckInterval = 1 * time.Minute
+
+	// PulsarMaxBatchSize is the maximum number of messages the Pulsar
+	// ingestor collects into one batch
+	PulsarMaxBatchSize = 10000
+	// DefaultPulsarBatchTimeout is the delay of batch collection in the Pulsar ingestor
+	DefaultPulsarBatchTimeout = 1 * time.Second
+	// PulsarOperationTimeout bounds the Pulsar client lookups and acknowledgements
+	PulsarOperationTimeout = 30 * time.Second
+
+	// MySQLMaxBatchSize is the maximum number of row changes the MySQL
+	// ingestor collects into one batch
+	MySQLMaxBatchSize = 10000
+	// DefaultMySQLBatchTimeout is the delay of batch collection in the MySQL ingestor
+	DefaultMySQLBatchTimeout = 1 * time.Second
+	// DefaultMySQLPort is the port of a MySQL connection without an explicit port
+	DefaultMySQLPort = 3306
+	// MySQLCheckpointBucket is the NATS KV bucket holding the executed GTID
+	// set of every MySQL source, keyed by pipeline and table
+	MySQLCheckpointBucket = "gf-mysql-checkpoints"
+
+	// Metadata fields added to every row change event of a CDC source
+	CDCOpField        = "__op"
+	CDCGTIDField      = "__gtid"
+	CDCTimestampField = "__ts_ms"
+
+	// CDC row change operations
+	CDCOpCreate = "c"
+	CDCOpUpdate = "u"
+	CDCOpDelete = "d"
 
 	// Kafka message processor modes
 	SyncMode             ProcessorMode = "sync"
@@ -269,6 +548,10 @@ const (
 	// Schema version id NATS header
 	SchemaVersionIDHeader = "Schema-Version-Id"
 
+	// IngestSequenceHeader carries the Kafka origin (topic/partition/offset)
+	// of an ingested message.
+	IngestSequenceHeader = "Glassflow-Ingest-Seq"
+
 	OTLPPipelineIDHeader = "x-glassflow-pipeline-id"
 
 	PipelineVersion     = "v3"
@@ -277,6 +560,13 @@ const (
 	StatelessIDSuffix   = "-stateless"
 )
 
+// IsJSONType reports whether t is the ClickHouse JSON type, with or without
+// parameters such as JSON(max_dynamic_paths=N).
+func IsJSONType(t string) bool {
+	t = strings.TrimSpace(t)
+	return t == CHTypeJSON || (strings.HasPrefix(t, CHTypeJSON+"(") && strings.HasSuffix(t, ")"))
+}
+
 // IsFixedStringType reports whether t is a ClickHouse FixedString type (with or without length).
 // It matches FixedString, FixedString(N), LowCardinality(FixedString), and LowCardinality(FixedString(N)).
 // No validation of actual data length is performed; all are treated like FixedString.
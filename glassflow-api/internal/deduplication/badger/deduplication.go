@@ -4,25 +4,135 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
 
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
 type Deduplicator struct {
 	db  *badger.DB
 	ttl time.Duration
+	log *slog.Logger
+
+	gcInterval time.Duration
+
+	snapshots        SnapshotStore
+	snapshotName     string
+	snapshotInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type Option func(*Deduplicator)
+
+// WithValueLogGC rewrites the value log files holding mostly expired keys at
+// every interval, so that the store of a long window does not only grow.
+func WithValueLogGC(interval time.Duration) Option {
+	return func(d *Deduplicator) {
+		d.gcInterval = interval
+	}
+}
+
+// WithSnapshots backs the store up under name at every interval and on
+// close, and restores the last backup when the store starts empty.
+func WithSnapshots(store SnapshotStore, name string, interval time.Duration) Option {
+	return func(d *Deduplicator) {
+		d.snapshots = store
+		d.snapshotName = name
+		d.snapshotInterval = interval
+	}
+}
+
+func WithLogger(log *slog.Logger) Option {
+	return func(d *Deduplicator) {
+		d.log = log
+	}
 }
 
 func NewDeduplicator(
 	db *badger.DB,
 	ttl time.Duration,
+	opts ...Option,
 ) *Deduplicator {
-	return &Deduplicator{
+	d := &Deduplicator{
 		db:  db,
 		ttl: ttl,
+		log: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Start restores the last snapshot into an empty store and runs the value
+// log GC and the snapshots until Close.
+func (d *Deduplicator) Start(ctx context.Context) error {
+	if d.snapshots != nil {
+		err := d.restore(ctx)
+		if err != nil {
+			return fmt.Errorf("restore dedup snapshot: %w", err)
+		}
+	}
+
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	if d.gcInterval > 0 {
+		d.runEvery(ctx, d.gcInterval, func(context.Context) {
+			err := d.collectGarbage()
+			if err != nil {
+				d.log.Warn("dedup value log GC failed", "error", err)
+			}
+		})
+	}
+
+	if d.snapshots != nil && d.snapshotInterval > 0 {
+		d.runEvery(ctx, d.snapshotInterval, func(ctx context.Context) {
+			err := d.snapshot(ctx)
+			if err != nil {
+				d.log.WarnContext(ctx, "dedup snapshot failed", "error", err)
+			}
+		})
+	}
+
+	return nil
+}
+
+func (d *Deduplicator) runEvery(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
+
+// collectGarbage rewrites value log files until none is worth rewriting.
+func (d *Deduplicator) collectGarbage() error {
+	for {
+		err := d.db.RunValueLogGC(internal.DedupValueLogGCRatio)
+		if errors.Is(err, badger.ErrNoRewrite) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
 }
 
@@ -102,6 +212,20 @@ func (d *Deduplicator) SaveKeys(
 	})
 }
 
-func (d *Deduplicator) Close(_ context.Context) error {
+// Close stops the background work, takes a last snapshot and closes the
+// store.
+func (d *Deduplicator) Close(ctx context.Context) error {
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
+	}
+
+	if d.snapshots != nil {
+		err := d.snapshot(ctx)
+		if err != nil {
+			d.log.WarnContext(ctx, "final dedup snapshot failed", "error", err)
+		}
+	}
+
 	return d.db.Close()
 }
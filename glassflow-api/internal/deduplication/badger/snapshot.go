@@ -0,0 +1,119 @@
+package badger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// snapshotLoadPendingWrites bounds the batches in flight while loading a
+// snapshot.
+const snapshotLoadPendingWrites = 256
+
+var ErrSnapshotNotFound = errors.New("dedup snapshot not found")
+
+// SnapshotStore keeps the snapshots of deduplicators by name, a snapshot
+// replacing the previous one of the same name.
+type SnapshotStore interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// NATSSnapshotStore keeps the snapshots in a NATS object store.
+type NATSSnapshotStore struct {
+	store jetstream.ObjectStore
+}
+
+func NewNATSSnapshotStore(store jetstream.ObjectStore) *NATSSnapshotStore {
+	return &NATSSnapshotStore{store: store}
+}
+
+func (s *NATSSnapshotStore) Put(ctx context.Context, name string, r io.Reader) error {
+	//nolint:exhaustruct // optional metadata
+	_, err := s.store.Put(ctx, jetstream.ObjectMeta{Name: name}, r)
+	if err != nil {
+		return fmt.Errorf("put object %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *NATSSnapshotStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	obj, err := s.store.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrObjectNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("get object %s: %w", name, err)
+	}
+	return obj, nil
+}
+
+// snapshot streams a backup of the live keys to the snapshot store. The
+// backup keeps the expiry of the keys, so a restored key expires when it
+// would have in the original store.
+func (d *Deduplicator) snapshot(ctx context.Context) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := d.db.Backup(pw, 0)
+		pw.CloseWithError(err)
+	}()
+
+	err := d.snapshots.Put(ctx, d.snapshotName, pr)
+	// unblocks the backup when the store stopped reading early
+	pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+
+	d.log.DebugContext(ctx, "dedup snapshot taken", "name", d.snapshotName)
+	return nil
+}
+
+// restore loads the last snapshot when the store is empty, as after the
+// loss of the volume holding it. A store with keys is more recent than any
+// snapshot and is kept.
+func (d *Deduplicator) restore(ctx context.Context) error {
+	empty, err := d.isEmpty()
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return nil
+	}
+
+	r, err := d.snapshots.Get(ctx, d.snapshotName)
+	if err != nil {
+		if errors.Is(err, ErrSnapshotNotFound) {
+			return nil
+		}
+		return err
+	}
+	defer r.Close()
+
+	err = d.db.Load(r, snapshotLoadPendingWrites)
+	if err != nil {
+		return fmt.Errorf("load snapshot %s: %w", d.snapshotName, err)
+	}
+
+	d.log.InfoContext(ctx, "dedup state restored from snapshot", "name", d.snapshotName)
+	return nil
+}
+
+func (d *Deduplicator) isEmpty() (bool, error) {
+	empty := true
+	err := d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		it.Rewind()
+		empty = !it.Valid()
+		return nil
+	})
+	return empty, err
+}
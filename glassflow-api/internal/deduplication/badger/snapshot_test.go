@@ -0,0 +1,96 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type memorySnapshotStore struct {
+	snapshots map[string][]byte
+}
+
+func (s *memorySnapshotStore) Put(_ context.Context, name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.snapshots[name] = data
+	return nil
+}
+
+func (s *memorySnapshotStore) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	data, ok := s.snapshots[name]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func openDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	return db
+}
+
+func message(id string) models.Message {
+	return models.NewNatsMessage(nil, map[string][]string{"Nats-Msg-Id": {id}})
+}
+
+func TestDeduplicator_SnapshotRestore(t *testing.T) {
+	store := &memorySnapshotStore{snapshots: map[string][]byte{}}
+
+	first := NewDeduplicator(openDB(t), time.Hour, WithSnapshots(store, "orders-0", 0))
+	require.NoError(t, first.Start(t.Context()))
+	require.NoError(t, first.SaveKeys(t.Context(), []models.Message{message("a"), message("b")}))
+	require.NoError(t, first.Close(t.Context()))
+	require.Contains(t, store.snapshots, "orders-0", "close takes a snapshot")
+
+	second := NewDeduplicator(openDB(t), time.Hour, WithSnapshots(store, "orders-0", 0))
+	require.NoError(t, second.Start(t.Context()))
+	t.Cleanup(func() { _ = second.Close(context.Background()) })
+
+	filtered, err := second.FilterDuplicates(t.Context(), []models.Message{message("a"), message("c")})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "c", filtered[0].GetHeader("Nats-Msg-Id"))
+}
+
+func TestDeduplicator_RestoreKeepsLocalState(t *testing.T) {
+	store := &memorySnapshotStore{snapshots: map[string][]byte{}}
+
+	old := NewDeduplicator(openDB(t), time.Hour, WithSnapshots(store, "orders-0", 0))
+	require.NoError(t, old.Start(t.Context()))
+	require.NoError(t, old.SaveKeys(t.Context(), []models.Message{message("a")}))
+	require.NoError(t, old.Close(t.Context()))
+
+	db := openDB(t)
+	require.NoError(t, NewDeduplicator(db, time.Hour).SaveKeys(t.Context(), []models.Message{message("b")}))
+
+	dedup := NewDeduplicator(db, time.Hour, WithSnapshots(store, "orders-0", 0))
+	require.NoError(t, dedup.Start(t.Context()))
+	t.Cleanup(func() { _ = dedup.Close(context.Background()) })
+
+	filtered, err := dedup.FilterDuplicates(t.Context(), []models.Message{message("a"), message("b")})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1, "a store with keys is not overwritten by a snapshot")
+	assert.Equal(t, "a", filtered[0].GetHeader("Nats-Msg-Id"))
+}
+
+func TestDeduplicator_StartWithoutSnapshot(t *testing.T) {
+	store := &memorySnapshotStore{snapshots: map[string][]byte{}}
+
+	dedup := NewDeduplicator(openDB(t), time.Hour, WithSnapshots(store, "orders-0", 0), WithValueLogGC(time.Minute))
+	require.NoError(t, dedup.Start(t.Context()))
+	require.NoError(t, dedup.Close(t.Context()))
+}
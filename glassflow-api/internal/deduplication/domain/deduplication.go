@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ClaimStore is the KV bucket of a dedup domain, whose TTL is the window of
+// the domain.
+type ClaimStore interface {
+	Create(ctx context.Context, key string, value []byte, opts ...jetstream.KVCreateOpt) (uint64, error)
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+}
+
+// LocalDeduplicator drops the duplicates within a pipeline.
+type LocalDeduplicator interface {
+	FilterDuplicates(ctx context.Context, messages []models.Message) ([]models.Message, error)
+	SaveKeys(ctx context.Context, messages []models.Message) error
+	Close(ctx context.Context) error
+}
+
+// Deduplicator drops the events another pipeline of the domain ingested
+// within the window. The first pipeline to see an event claims its ID in
+// the domain bucket, the others drop it. A pipeline keeps the events it
+// claimed, so that a batch it failed to write is written when redelivered;
+// the duplicates within the pipeline are dropped by its local store.
+type Deduplicator struct {
+	local  LocalDeduplicator
+	claims ClaimStore
+	owner  []byte
+}
+
+// NewDeduplicator creates a deduplicator claiming the events in claims on
+// behalf of pipelineID.
+func NewDeduplicator(local LocalDeduplicator, claims ClaimStore, pipelineID string) *Deduplicator {
+	return &Deduplicator{
+		local:  local,
+		claims: claims,
+		owner:  []byte(pipelineID),
+	}
+}
+
+// FilterDuplicates returns the messages neither seen by the pipeline nor
+// claimed by another pipeline of the domain, and claims them.
+func (d *Deduplicator) FilterDuplicates(
+	ctx context.Context,
+	messages []models.Message,
+) ([]models.Message, error) {
+	messages, err := d.local.FilterDuplicates(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]models.Message, 0, len(messages))
+	for _, msg := range messages {
+		msgID := msg.GetHeader("Nats-Msg-Id")
+		if msgID == "" {
+			filtered = append(filtered, msg)
+			continue
+		}
+
+		owned, err := d.claim(ctx, msgID)
+		if err != nil {
+			return nil, err
+		}
+		if owned {
+			filtered = append(filtered, msg)
+		}
+	}
+
+	return filtered, nil
+}
+
+// claim claims the message ID for the pipeline and reports whether the
+// pipeline owns it.
+func (d *Deduplicator) claim(ctx context.Context, msgID string) (bool, error) {
+	key := claimKey(msgID)
+	_, err := d.claims.Create(ctx, key, d.owner)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, jetstream.ErrKeyExists) {
+		return false, fmt.Errorf("claim message id: %w", err)
+	}
+
+	entry, err := d.claims.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		// The claim expired in between, the window has passed
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get message id claim: %w", err)
+	}
+	return string(entry.Value()) == string(d.owner), nil
+}
+
+// SaveKeys marks the message IDs as seen by the pipeline, they are already
+// claimed in the domain.
+func (d *Deduplicator) SaveKeys(ctx context.Context, messages []models.Message) error {
+	return d.local.SaveKeys(ctx, messages)
+}
+
+func (d *Deduplicator) Close(ctx context.Context) error {
+	return d.local.Close(ctx)
+}
+
+// claimKey maps a message ID to a valid KV key, message IDs may hold any
+// character.
+func claimKey(msgID string) string {
+	sum := sha256.Sum256([]byte(msgID))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	badgerDeduplication "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/deduplication/badger"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type memoryClaimStore struct {
+	mu     sync.Mutex
+	claims map[string][]byte
+}
+
+func (s *memoryClaimStore) Create(_ context.Context, key string, value []byte, _ ...jetstream.KVCreateOpt) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.claims[key]; ok {
+		return 0, jetstream.ErrKeyExists
+	}
+	s.claims[key] = value
+	return uint64(len(s.claims)), nil
+}
+
+func (s *memoryClaimStore) Get(_ context.Context, key string) (jetstream.KeyValueEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.claims[key]
+	if !ok {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return claimEntry{value: value}, nil
+}
+
+type claimEntry struct {
+	jetstream.KeyValueEntry
+	value []byte
+}
+
+func (e claimEntry) Value() []byte { return e.value }
+
+func newLocal(t *testing.T) *badgerDeduplication.Deduplicator {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	dedup := badgerDeduplication.NewDeduplicator(db, time.Hour)
+	t.Cleanup(func() { _ = dedup.Close(context.Background()) })
+	return dedup
+}
+
+func message(id string) models.Message {
+	return models.NewNatsMessage(nil, map[string][]string{"Nats-Msg-Id": {id}})
+}
+
+func ids(messages []models.Message) []string {
+	var out []string
+	for _, msg := range messages {
+		out = append(out, msg.GetHeader("Nats-Msg-Id"))
+	}
+	return out
+}
+
+func TestDeduplicator_SharedDomain(t *testing.T) {
+	claims := &memoryClaimStore{claims: map[string][]byte{}}
+	orders := NewDeduplicator(newLocal(t), claims, "orders")
+	backfill := NewDeduplicator(newLocal(t), claims, "orders-backfill")
+
+	filtered, err := orders.FilterDuplicates(t.Context(), []models.Message{message("a"), message("b")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(filtered))
+
+	// The batch failed to be written, the redelivered events are kept
+	filtered, err = orders.FilterDuplicates(t.Context(), []models.Message{message("a"), message("b")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(filtered))
+	require.NoError(t, orders.SaveKeys(t.Context(), filtered))
+
+	// Events ingested by another pipeline of the domain are dropped
+	filtered, err = backfill.FilterDuplicates(t.Context(), []models.Message{message("a"), message("c")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(filtered))
+
+	// Duplicates within the pipeline are dropped by its local store
+	filtered, err = orders.FilterDuplicates(t.Context(), []models.Message{message("a"), message("d")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids(filtered))
+}
+
+func TestDeduplicator_MessagesWithoutID(t *testing.T) {
+	claims := &memoryClaimStore{claims: map[string][]byte{}}
+	dedup := NewDeduplicator(newLocal(t), claims, "orders")
+
+	msg := models.NewNatsMessage([]byte(`{}`), nil)
+	filtered, err := dedup.FilterDuplicates(t.Context(), []models.Message{msg})
+	require.NoError(t, err)
+	assert.Len(t, filtered, 1)
+	assert.Empty(t, claims.claims)
+}
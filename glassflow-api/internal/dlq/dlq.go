@@ -104,6 +104,81 @@ func (c *Client) FetchDLQMessages(ctx context.Context, streamName string, batchS
 	return dlqMsgs, nil
 }
 
+// ListDLQMessages reads up to limit messages starting at the DLQ stream sequence
+// offset, or at the oldest message when offset is before it. Offsets are stream
+// sequences so pages stay stable while messages are consumed or purged. Unlike
+// FetchDLQMessages it does not consume the messages.
+func (c *Client) ListDLQMessages(ctx context.Context, streamName string, offset, limit int) (zero models.DLQPage, _ error) {
+	if streamName == "" {
+		return zero, fmt.Errorf("stream name cannot be empty")
+	}
+	if offset < 0 {
+		return zero, fmt.Errorf("offset cannot be negative")
+	}
+	if limit <= 0 {
+		return zero, fmt.Errorf("limit must be positive")
+	}
+	if limit > internal.DLQMaxBatchSize {
+		return zero, models.ErrDLQMaxPageLimit
+	}
+
+	stream, err := c.jetstreamClient.Stream(ctx, streamName)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrStreamNotFound) {
+			return zero, internal.ErrDLQNotExists
+		}
+		return zero, fmt.Errorf("get dlq stream: %w", err)
+	}
+
+	subject := streamName + ".failed"
+	streamInfo, err := stream.Info(ctx, jetstream.WithSubjectFilter(subject))
+	if err != nil {
+		return zero, fmt.Errorf("get dlq stream info: %w", err)
+	}
+
+	page := models.DLQPage{
+		Entries: make([]models.DLQEntry, 0, min(uint64(limit), streamInfo.State.Msgs)),
+		Total:   streamInfo.State.Msgs,
+	}
+
+	seq := max(uint64(offset), streamInfo.State.FirstSeq)
+	for len(page.Entries) < limit && seq <= streamInfo.State.LastSeq {
+		msg, err := stream.GetMsg(ctx, seq, jetstream.WithGetMsgSubject(subject))
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgNotFound) {
+				break
+			}
+			return zero, fmt.Errorf("get dlq message %d: %w", seq, err)
+		}
+
+		var dlqMsg models.DLQMessage
+		err = json.Unmarshal(msg.Data, &dlqMsg)
+		if err != nil {
+			// return messages that are not DLQ envelopes as raw payloads instead of failing the page
+			dlqMsg = models.DLQMessage{OriginalMessage: models.NewOriginalMessage(msg.Data)}
+		}
+
+		timestamp := dlqMsg.Timestamp
+		if timestamp.IsZero() {
+			timestamp = msg.Time
+		}
+
+		page.Entries = append(page.Entries, models.DLQEntry{
+			Offset:    msg.Sequence,
+			Timestamp: timestamp,
+			Message:   dlqMsg,
+		})
+		seq = msg.Sequence + 1
+	}
+
+	// the next page starts after the last delivered message
+	if len(page.Entries) > 0 && seq <= streamInfo.State.LastSeq {
+		page.NextOffset = seq
+	}
+
+	return page, nil
+}
+
 func (c *Client) GetDLQState(ctx context.Context, streamName string) (zero models.DLQState, _ error) {
 	if streamName == "" {
 		return zero, fmt.Errorf("stream name cannot be empty")
@@ -140,6 +215,7 @@ func (c *Client) GetDLQState(ctx context.Context, streamName string) (zero model
 		LastConsumedAt:     consumerInfo.Delivered.Last,
 		TotalMessages:      streamInfo.State.Msgs,
 		UnconsumedMessages: consumerInfo.NumPending,
+		LastSequence:       streamInfo.State.LastSeq,
 	}, nil
 }
 
@@ -614,3 +614,132 @@ func TestClient_FetchDLQMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ListDLQMessages(t *testing.T) {
+	ns := natsTest.RunServer(&natsServer.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	defer ns.Shutdown()
+
+	natsClient, err := client.NewNATSClient(context.Background(), ns.ClientURL())
+	require.NoError(t, err)
+	defer natsClient.Close()
+
+	js := natsClient.JetStream()
+	c := &Client{jetstreamClient: js}
+
+	streamName := "test-stream-list-messages"
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{streamName + ".failed"},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		data, err := models.NewDLQMessage(
+			fmt.Sprintf("component%d", i),
+			fmt.Sprintf("error%d", i),
+			[]byte(fmt.Sprintf("data%d", i)),
+		).WithReason("parse_error").ToJSON()
+		require.NoError(t, err)
+		_, err = js.Publish(context.Background(), streamName+".failed", data)
+		require.NoError(t, err)
+	}
+	_, err = js.Publish(context.Background(), streamName+".failed", []byte("not an envelope"))
+	require.NoError(t, err)
+
+	t.Run("first page", func(t *testing.T) {
+		page, err := c.ListDLQMessages(context.Background(), streamName, 0, 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(6), page.Total)
+		require.Len(t, page.Entries, 2)
+		assert.Equal(t, uint64(1), page.Entries[0].Offset)
+		assert.Equal(t, "component0", page.Entries[0].Message.Component)
+		assert.Equal(t, "error0", page.Entries[0].Message.Error)
+		assert.Equal(t, "parse_error", page.Entries[0].Message.Reason)
+		assert.Equal(t, models.NewOriginalMessage([]byte("data0")), page.Entries[0].Message.OriginalMessage)
+		assert.False(t, page.Entries[0].Timestamp.IsZero())
+		assert.Equal(t, uint64(2), page.Entries[1].Offset)
+		assert.Equal(t, uint64(3), page.NextOffset)
+	})
+
+	t.Run("last page keeps raw payloads", func(t *testing.T) {
+		page, err := c.ListDLQMessages(context.Background(), streamName, 5, 10)
+		require.NoError(t, err)
+
+		require.Len(t, page.Entries, 2)
+		assert.Equal(t, uint64(5), page.Entries[0].Offset)
+		assert.Equal(t, uint64(6), page.Entries[1].Offset)
+		assert.Zero(t, page.NextOffset)
+		assert.Empty(t, page.Entries[1].Message.Component)
+		assert.Equal(t, models.NewOriginalMessage([]byte("not an envelope")), page.Entries[1].Message.OriginalMessage)
+		assert.False(t, page.Entries[1].Timestamp.IsZero())
+	})
+
+	t.Run("does not consume messages", func(t *testing.T) {
+		_, err := c.ListDLQMessages(context.Background(), streamName, 0, 6)
+		require.NoError(t, err)
+
+		state, err := c.GetDLQState(context.Background(), streamName)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), state.UnconsumedMessages)
+	})
+
+	t.Run("offset past the end", func(t *testing.T) {
+		page, err := c.ListDLQMessages(context.Background(), streamName, 10, 10)
+		require.NoError(t, err)
+
+		assert.Empty(t, page.Entries)
+		assert.Equal(t, uint64(6), page.Total)
+	})
+
+	t.Run("next offset stays valid after the oldest messages are removed", func(t *testing.T) {
+		page, err := c.ListDLQMessages(context.Background(), streamName, 0, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), page.NextOffset)
+
+		stream, err := js.Stream(context.Background(), streamName)
+		require.NoError(t, err)
+		require.NoError(t, stream.DeleteMsg(context.Background(), 1))
+		require.NoError(t, stream.DeleteMsg(context.Background(), 2))
+
+		page, err = c.ListDLQMessages(context.Background(), streamName, int(page.NextOffset), 10)
+		require.NoError(t, err)
+
+		require.Len(t, page.Entries, 3)
+		assert.Equal(t, uint64(4), page.Entries[0].Offset)
+		assert.Equal(t, "component3", page.Entries[0].Message.Component)
+	})
+
+	t.Run("offsets are not reused after purge", func(t *testing.T) {
+		require.NoError(t, c.PurgeDLQ(context.Background(), streamName))
+
+		data, err := models.NewDLQMessage("sink", "boom", []byte("after")).ToJSON()
+		require.NoError(t, err)
+		_, err = js.Publish(context.Background(), streamName+".failed", data)
+		require.NoError(t, err)
+
+		page, err := c.ListDLQMessages(context.Background(), streamName, 0, 10)
+		require.NoError(t, err)
+
+		require.Len(t, page.Entries, 1)
+		assert.Equal(t, uint64(7), page.Entries[0].Offset)
+		assert.Equal(t, "sink", page.Entries[0].Message.Component)
+	})
+
+	t.Run("stream does not exist", func(t *testing.T) {
+		_, err := c.ListDLQMessages(context.Background(), "non-existent-stream-list", 0, 10)
+		assert.ErrorIs(t, err, internal.ErrDLQNotExists)
+	})
+
+	t.Run("limit too large", func(t *testing.T) {
+		_, err := c.ListDLQMessages(context.Background(), streamName, 0, internal.DLQMaxBatchSize+1)
+		assert.ErrorIs(t, err, models.ErrDLQMaxPageLimit)
+	})
+}
@@ -0,0 +1,137 @@
+// Package doctor checks that an installation can reach and use the services
+// GlassFlow depends on: NATS, Postgres and Kubernetes. Most installation
+// issues are one of these, the report names the failing check and a hint.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// checkTimeout bounds the checks of a service, doctor reports a service it
+// cannot reach instead of retrying like the API does on startup.
+const checkTimeout = 10 * time.Second
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip is a check that depends on a failed one, or on a service
+	// the installation does not use.
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of a check with what to do about it.
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Report is the results of the checks of an installation.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Config is the part of the API configuration the checks use.
+type Config struct {
+	NATSServer         string
+	NATSMaxStreamBytes int64
+	DatabaseURL        string
+	// Kubernetes is nil when the pipelines run in the API process
+	Kubernetes *KubernetesConfig
+}
+
+// KubernetesConfig is the namespace and custom resource of the pipelines.
+type KubernetesConfig struct {
+	Namespace string
+	APIGroup  string
+	Version   string
+	Resource  string
+}
+
+// Run checks the services of the installation one after the other.
+func Run(ctx context.Context, cfg Config) Report {
+	var report Report
+
+	report.Results = append(report.Results, checkNATS(ctx, cfg.NATSServer, cfg.NATSMaxStreamBytes)...)
+	report.Results = append(report.Results, checkPostgres(ctx, cfg.DatabaseURL)...)
+
+	if cfg.Kubernetes == nil {
+		report.Results = append(report.Results, Result{
+			Check:   checkKubernetesConnection,
+			Status:  StatusSkip,
+			Message: "pipelines run locally",
+		})
+	} else {
+		report.Results = append(report.Results, checkKubernetes(ctx, *cfg.Kubernetes)...)
+	}
+
+	return report
+}
+
+// Failed returns the number of failed checks.
+func (r Report) Failed() int {
+	var failed int
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteText writes the report as a table, with the hints of the checks that
+// did not pass under it.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tMESSAGE")
+	for _, result := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Check, result.Status, result.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	for _, result := range r.Results {
+		if result.Hint == "" || result.Status == StatusOK {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n%s: %s\n", result.Check, result.Hint); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes the report as JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// skipped returns the results of checks that depend on a failed one.
+func skipped(after string, checks ...string) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, Result{
+			Check:   check,
+			Status:  StatusSkip,
+			Message: "requires " + after,
+		})
+	}
+	return results
+}
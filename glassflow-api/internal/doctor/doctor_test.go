@@ -0,0 +1,84 @@
+package doctor
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var latest int
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.SplitN(filepath.Base(file), "_", 2)[0])
+		require.NoError(t, err, file)
+		latest = max(latest, version)
+	}
+
+	assert.Equal(t, latest, SchemaVersion, "bump SchemaVersion with the migrations")
+}
+
+func TestCheckMigrations(t *testing.T) {
+	assert.Equal(t, StatusOK, checkMigrations(SchemaVersion, false).Status)
+	assert.Equal(t, StatusFail, checkMigrations(SchemaVersion, true).Status)
+	assert.Equal(t, StatusFail, checkMigrations(SchemaVersion-1, false).Status)
+	assert.Equal(t, StatusWarn, checkMigrations(SchemaVersion+1, false).Status)
+}
+
+func TestCheckLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		limits         jetstream.AccountLimits
+		maxStreamBytes int64
+		expected       Status
+	}{
+		{"unlimited", jetstream.AccountLimits{MaxStore: -1}, 100, StatusOK},
+		{"within limits", jetstream.AccountLimits{MaxStore: 1000, StoreMaxStreamBytes: 500}, 100, StatusOK},
+		{"above stream limit", jetstream.AccountLimits{StoreMaxStreamBytes: 50}, 100, StatusFail},
+		{"above account storage", jetstream.AccountLimits{MaxStore: 50}, 100, StatusWarn},
+		{"size required", jetstream.AccountLimits{MaxBytesRequired: true}, 0, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkLimits(tt.limits, tt.maxStreamBytes).Status)
+		})
+	}
+}
+
+func TestRequiredAccess(t *testing.T) {
+	required := requiredAccess(KubernetesConfig{Namespace: "glassflow", APIGroup: "etl.glassflow.io", Version: "v1alpha1", Resource: "pipelines"})
+
+	assert.Contains(t, required, access{Group: "etl.glassflow.io", Resource: "pipelines", Verb: "update"})
+	assert.Contains(t, required, access{Resource: "secrets", Verb: "delete"})
+	assert.Equal(t, "list pipelines.etl.glassflow.io", access{Group: "etl.glassflow.io", Resource: "pipelines", Verb: "list"}.String())
+}
+
+func TestReport(t *testing.T) {
+	report := Report{Results: []Result{
+		{Check: checkNATSConnection, Status: StatusOK, Message: "connected", Hint: "not shown"},
+		{Check: checkPostgresMigrations, Status: StatusFail, Message: "no migrations applied", Hint: migrationsHint},
+		{Check: checkKubernetesConnection, Status: StatusSkip, Message: "pipelines run locally"},
+	}}
+
+	assert.Equal(t, 1, report.Failed())
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "postgres.migrations    fail    no migrations applied")
+	assert.Contains(t, text.String(), "postgres.migrations: "+migrationsHint)
+	assert.NotContains(t, text.String(), "not shown")
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	assert.Contains(t, out.String(), `"status": "fail"`)
+}
@@ -0,0 +1,175 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	checkKubernetesConnection = "kubernetes.connection"
+	checkKubernetesCRD        = "kubernetes.crd"
+	checkKubernetesRBAC       = "kubernetes.rbac"
+)
+
+// access is a verb on a resource the API needs in its namespace.
+type access struct {
+	Group    string
+	Resource string
+	Verb     string
+}
+
+func (a access) String() string {
+	if a.Group == "" {
+		return a.Verb + " " + a.Resource
+	}
+	return a.Verb + " " + a.Resource + "." + a.Group
+}
+
+// requiredAccess returns the access the orchestrator uses: it manages the
+// pipeline resources and the Secrets holding their configs.
+func requiredAccess(cfg KubernetesConfig) []access {
+	var required []access
+	for _, verb := range []string{"create", "get", "list", "update"} {
+		required = append(required, access{Group: cfg.APIGroup, Resource: cfg.Resource, Verb: verb})
+	}
+	for _, verb := range []string{"create", "get", "update", "delete"} {
+		required = append(required, access{Resource: "secrets", Verb: verb})
+	}
+	return required
+}
+
+func checkKubernetes(ctx context.Context, cfg KubernetesConfig) []Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	kcfg, err := config.GetConfig()
+	if err != nil {
+		return append([]Result{{
+			Check:   checkKubernetesConnection,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("get kubeconfig: %v", err),
+			Hint:    "Run doctor in the API pod, or set GLASSFLOW_RUN_LOCAL when the pipelines run without Kubernetes.",
+		}}, skipped(checkKubernetesConnection, checkKubernetesCRD, checkKubernetesRBAC)...)
+	}
+	kcfg.Timeout = checkTimeout
+
+	clientSet, err := kubernetes.NewForConfig(kcfg)
+	if err != nil {
+		return append([]Result{{
+			Check:   checkKubernetesConnection,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("create clientset: %v", err),
+		}}, skipped(checkKubernetesConnection, checkKubernetesCRD, checkKubernetesRBAC)...)
+	}
+
+	version, err := clientSet.Discovery().ServerVersion()
+	if err != nil {
+		return append([]Result{{
+			Check:   checkKubernetesConnection,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("connect to %s: %v", kcfg.Host, err),
+			Hint:    "Check that the API server is reachable from this pod.",
+		}}, skipped(checkKubernetesConnection, checkKubernetesCRD, checkKubernetesRBAC)...)
+	}
+
+	return []Result{
+		{
+			Check:   checkKubernetesConnection,
+			Status:  StatusOK,
+			Message: fmt.Sprintf("connected to %s (Kubernetes %s)", kcfg.Host, version.GitVersion),
+		},
+		checkCRD(clientSet, cfg),
+		checkRBAC(ctx, clientSet, cfg),
+	}
+}
+
+// checkCRD checks that the API server serves the pipeline resource.
+func checkCRD(clientSet kubernetes.Interface, cfg KubernetesConfig) Result {
+	const hint = "Install the GlassFlow operator, it installs the Pipeline CRD."
+	groupVersion := cfg.APIGroup + "/" + cfg.Version
+
+	resources, err := clientSet.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if apierrors.IsNotFound(err) {
+		return Result{
+			Check:   checkKubernetesCRD,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s is not served", groupVersion),
+			Hint:    hint,
+		}
+	}
+	if err != nil {
+		return Result{
+			Check:   checkKubernetesCRD,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("discover %s: %v", groupVersion, err),
+		}
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == cfg.Resource {
+			return Result{
+				Check:   checkKubernetesCRD,
+				Status:  StatusOK,
+				Message: fmt.Sprintf("%s.%s installed", cfg.Resource, groupVersion),
+			}
+		}
+	}
+
+	return Result{
+		Check:   checkKubernetesCRD,
+		Status:  StatusFail,
+		Message: fmt.Sprintf("%s has no %s resource", groupVersion, cfg.Resource),
+		Hint:    hint + " Check GLASSFLOW_K8S_RESOURCE_NAME.",
+	}
+}
+
+// checkRBAC asks the API server whether the service account of the API has
+// the access the orchestrator needs in its namespace.
+func checkRBAC(ctx context.Context, clientSet kubernetes.Interface, cfg KubernetesConfig) Result {
+	var missing []string
+	for _, required := range requiredAccess(cfg) {
+		//nolint:exhaustruct // optional config
+		review, err := clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: cfg.Namespace,
+					Verb:      required.Verb,
+					Group:     required.Group,
+					Resource:  required.Resource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return Result{
+				Check:   checkKubernetesRBAC,
+				Status:  StatusFail,
+				Message: fmt.Sprintf("review access to %s: %v", required, err),
+			}
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, required.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return Result{
+			Check:   checkKubernetesRBAC,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("missing in namespace %s: %s", cfg.Namespace, strings.Join(missing, ", ")),
+			Hint:    "Bind a Role with these verbs to the service account of the API.",
+		}
+	}
+
+	return Result{
+		Check:   checkKubernetesRBAC,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("%d permissions granted in namespace %s", len(requiredAccess(cfg)), cfg.Namespace),
+	}
+}
@@ -0,0 +1,108 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	checkNATSConnection = "nats.connection"
+	checkNATSJetStream  = "nats.jetstream"
+	checkNATSLimits     = "nats.limits"
+)
+
+func checkNATS(ctx context.Context, url string, maxStreamBytes int64) []Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	nc, err := nats.Connect(url, nats.Timeout(checkTimeout))
+	if err != nil {
+		return append([]Result{{
+			Check:   checkNATSConnection,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("connect to %s: %v", url, err),
+			Hint:    "Check GLASSFLOW_NATS_SERVER and that the NATS service is reachable from this pod.",
+		}}, skipped(checkNATSConnection, checkNATSJetStream, checkNATSLimits)...)
+	}
+	defer nc.Close()
+
+	results := []Result{{
+		Check:   checkNATSConnection,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("connected to %s (server %s)", nc.ConnectedUrlRedacted(), nc.ConnectedServerVersion()),
+	}}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		results = append(results, Result{
+			Check:   checkNATSJetStream,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("create JetStream context: %v", err),
+		})
+		return append(results, skipped(checkNATSJetStream, checkNATSLimits)...)
+	}
+
+	info, err := js.AccountInfo(ctx)
+	if err != nil {
+		result := Result{
+			Check:   checkNATSJetStream,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("get account info: %v", err),
+		}
+		if errors.Is(err, jetstream.ErrJetStreamNotEnabled) || errors.Is(err, jetstream.ErrJetStreamNotEnabledForAccount) {
+			result.Message = "JetStream is not enabled"
+			result.Hint = "Run the NATS server with JetStream enabled (nats.config.jetstream.enabled in the NATS chart)."
+		}
+		results = append(results, result)
+		return append(results, skipped(checkNATSJetStream, checkNATSLimits)...)
+	}
+
+	results = append(results, Result{
+		Check:   checkNATSJetStream,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("enabled, %d streams using %d bytes", info.Streams, info.Store),
+	})
+
+	return append(results, checkLimits(info.Limits, maxStreamBytes))
+}
+
+// checkLimits compares the JetStream limits of the account with the maximum
+// size of the pipeline streams. The server rejects streams larger than its
+// stream limit, the account limit is shared by all the streams.
+func checkLimits(limits jetstream.AccountLimits, maxStreamBytes int64) Result {
+	const hint = "Lower GLASSFLOW_NATS_MAX_STREAM_BYTES or raise the JetStream storage limits of the NATS server."
+
+	switch {
+	case limits.MaxBytesRequired && maxStreamBytes <= 0:
+		return Result{
+			Check:   checkNATSLimits,
+			Status:  StatusFail,
+			Message: "the account requires a stream size limit, GLASSFLOW_NATS_MAX_STREAM_BYTES is not set",
+			Hint:    hint,
+		}
+	case limits.StoreMaxStreamBytes > 0 && maxStreamBytes > limits.StoreMaxStreamBytes:
+		return Result{
+			Check:   checkNATSLimits,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("stream size %d bytes exceeds the stream limit of %d bytes", maxStreamBytes, limits.StoreMaxStreamBytes),
+			Hint:    hint,
+		}
+	case limits.MaxStore > 0 && maxStreamBytes > limits.MaxStore:
+		return Result{
+			Check:   checkNATSLimits,
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("stream size %d bytes exceeds the account storage of %d bytes", maxStreamBytes, limits.MaxStore),
+			Hint:    hint,
+		}
+	}
+
+	return Result{
+		Check:   checkNATSLimits,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("stream size %d bytes within the account limits", maxStreamBytes),
+	}
+}
@@ -0,0 +1,113 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SchemaVersion is the version of the latest migration in migrations/, the
+// schema this release requires. It must be bumped with every migration.
+const SchemaVersion = 20
+
+const (
+	checkPostgresConnection = "postgres.connection"
+	checkPostgresMigrations = "postgres.migrations"
+)
+
+// pgUndefinedTable is the error code of a query on a missing table.
+const pgUndefinedTable = "42P01"
+
+const migrationsHint = "Run the database migrations with the migrate command, or start the API with GLASSFLOW_DATABASE_MIGRATE_ON_STARTUP."
+
+func checkPostgres(ctx context.Context, dsn string) []Result {
+	if dsn == "" {
+		return append([]Result{{
+			Check:   checkPostgresConnection,
+			Status:  StatusFail,
+			Message: "database URL is not set",
+			Hint:    "Set GLASSFLOW_DATABASE_URL.",
+		}}, skipped(checkPostgresConnection, checkPostgresMigrations)...)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return append([]Result{{
+			Check:   checkPostgresConnection,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("connect: %v", err),
+			Hint:    "Check GLASSFLOW_DATABASE_URL, the credentials and that Postgres is reachable from this pod.",
+		}}, skipped(checkPostgresConnection, checkPostgresMigrations)...)
+	}
+	defer conn.Close(context.Background())
+
+	results := []Result{{
+		Check:   checkPostgresConnection,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("connected to %s/%s", conn.Config().Host, conn.Config().Database),
+	}}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err = conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable, errors.Is(err, pgx.ErrNoRows):
+		return append(results, Result{
+			Check:   checkPostgresMigrations,
+			Status:  StatusFail,
+			Message: "no migrations applied",
+			Hint:    migrationsHint,
+		})
+	case err != nil:
+		return append(results, Result{
+			Check:   checkPostgresMigrations,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("read schema version: %v", err),
+		})
+	}
+
+	return append(results, checkMigrations(version, dirty))
+}
+
+// checkMigrations compares the schema version golang-migrate recorded with
+// the one of this release.
+func checkMigrations(version int64, dirty bool) Result {
+	switch {
+	case dirty:
+		return Result{
+			Check:   checkPostgresMigrations,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("migration %d failed and left the schema dirty", version),
+			Hint:    "Fix the schema by hand, then force the version with migrate force and run the migrations again.",
+		}
+	case version < SchemaVersion:
+		return Result{
+			Check:   checkPostgresMigrations,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("schema version %d, this release requires %d", version, SchemaVersion),
+			Hint:    migrationsHint,
+		}
+	case version > SchemaVersion:
+		return Result{
+			Check:   checkPostgresMigrations,
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("schema version %d is newer than the %d of this release", version, SchemaVersion),
+			Hint:    "The database was migrated by a newer release, upgrade the API.",
+		}
+	}
+
+	return Result{
+		Check:   checkPostgresMigrations,
+		Status:  StatusOK,
+		Message: fmt.Sprintf("schema version %d", version),
+	}
+}
@@ -12,9 +12,31 @@ import (
 
 type Service struct {
 	aead cipher.AEAD
+	// previous decrypt the data encrypted before a key change
+	previous []cipher.AEAD
 }
 
-func NewService(key []byte) (*Service, error) {
+// NewService creates a service encrypting with key. The data encrypted with
+// one of the previousKeys is still decrypted.
+func NewService(key []byte, previousKeys ...[]byte) (*Service, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{aead: aead}
+	for _, previousKey := range previousKeys {
+		previous, err := newAEAD(previousKey)
+		if err != nil {
+			return nil, fmt.Errorf("previous key: %w", err)
+		}
+		s.previous = append(s.previous, previous)
+	}
+
+	return s, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
 	if len(key) != internal.AESKeySize {
 		return nil, fmt.Errorf("%w: got %d bytes", internal.ErrInvalidKeySize, len(key))
 	}
@@ -29,7 +51,7 @@ func NewService(key []byte) (*Service, error) {
 		return nil, fmt.Errorf("create GCM: %w", err)
 	}
 
-	return &Service{aead: aead}, nil
+	return aead, nil
 }
 
 func (s *Service) Encrypt(plaintext []byte) ([]byte, error) {
@@ -51,8 +73,22 @@ func (s *Service) Decrypt(ciphertext []byte) ([]byte, error) {
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
+		for _, previous := range s.previous {
+			if plaintext, perr := previous.Open(nil, nonce, ciphertext, nil); perr == nil {
+				return plaintext, nil
+			}
+		}
 		return nil, fmt.Errorf("%w: %v", internal.ErrDecryptionFailed, err)
 	}
 
 	return plaintext, nil
 }
+
+// NewDataKey generates a random data key.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, internal.AESKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	return key, nil
+}
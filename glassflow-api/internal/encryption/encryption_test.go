@@ -181,3 +181,44 @@ func TestService_WrongKey(t *testing.T) {
 		t.Error("Decrypt() should fail when using wrong key")
 	}
 }
+
+func TestService_PreviousKeys(t *testing.T) {
+	oldKey := make([]byte, 32)
+	newKey := make([]byte, 32)
+	rand.Read(oldKey)
+	rand.Read(newKey)
+
+	oldService, err := NewService(oldKey)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	ciphertext, err := oldService.Encrypt([]byte("stored before the key change"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	service, err := NewService(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	decrypted, err := service.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(decrypted) != "stored before the key change" {
+		t.Errorf("Decrypt() = %v, want the data encrypted with the previous key", string(decrypted))
+	}
+
+	// New data is encrypted with the new key only
+	ciphertext, err = service.Encrypt([]byte("stored after the key change"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if _, err := oldService.Decrypt(ciphertext); err == nil {
+		t.Error("Decrypt() with the previous key should fail for new data")
+	}
+
+	if _, err := NewService(newKey, make([]byte, 16)); err == nil {
+		t.Error("NewService() should fail for an invalid previous key")
+	}
+}
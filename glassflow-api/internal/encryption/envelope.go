@@ -0,0 +1,120 @@
+package encryption
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+)
+
+// KeyEncryptionKey wraps the data key the credentials are encrypted with,
+// only the wrapped data key is stored with the data.
+type KeyEncryptionKey interface {
+	// ID identifies the key, a data key is unwrapped with the key that
+	// wrapped it
+	ID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// LocalKEK wraps the data key with a key read from a local file or the
+// environment.
+type LocalKEK struct {
+	key     []byte
+	service *Service
+}
+
+func NewLocalKEK(key []byte) (*LocalKEK, error) {
+	service, err := NewService(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKEK{key: key, service: service}, nil
+}
+
+func (k *LocalKEK) ID() string {
+	sum := sha256.Sum256(k.key)
+	return "local:" + hex.EncodeToString(sum[:4])
+}
+
+func (k *LocalKEK) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	return k.service.Encrypt(dataKey)
+}
+
+func (k *LocalKEK) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	return k.service.Decrypt(wrapped)
+}
+
+// Key returns the local key. The credentials stored before envelope
+// encryption were encrypted with it directly.
+func (k *LocalKEK) Key() []byte {
+	return k.key
+}
+
+// VaultTransitKEK wraps the data key with a key of a HashiCorp Vault transit
+// secrets engine, the key never leaves Vault. Rotating the transit key keeps
+// the wrapped data key readable.
+type VaultTransitKEK struct {
+	vault *secrets.VaultClient
+	mount string
+	name  string
+}
+
+func NewVaultTransitKEK(vault *secrets.VaultClient, mount, name string) (*VaultTransitKEK, error) {
+	if name == "" {
+		return nil, fmt.Errorf("vault transit key name is required")
+	}
+
+	return &VaultTransitKEK{
+		vault: vault,
+		mount: strings.Trim(mount, "/"),
+		name:  name,
+	}, nil
+}
+
+func (k *VaultTransitKEK) ID() string {
+	return "vault-transit:" + k.mount + "/" + k.name
+}
+
+func (k *VaultTransitKEK) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err := k.do(ctx, "encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (k *VaultTransitKEK) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	err := k.do(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decode data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func (k *VaultTransitKEK) do(ctx context.Context, op string, body map[string]string, out any) error {
+	err := k.vault.Post(ctx, k.mount+"/"+op+"/"+k.name, body, out)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+	return nil
+}
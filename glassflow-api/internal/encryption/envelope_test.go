@@ -0,0 +1,125 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+)
+
+func TestLocalKEK_WrapUnwrap(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	kek, err := NewLocalKEK(key)
+	if err != nil {
+		t.Fatalf("NewLocalKEK() error = %v", err)
+	}
+
+	dataKey, err := NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+	wrapped, err := kek.Wrap(context.Background(), dataKey)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if bytes.Contains(wrapped, dataKey) {
+		t.Error("wrapped data key should not contain the data key")
+	}
+
+	unwrapped, err := kek.Unwrap(context.Background(), wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap() error = %v", err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Error("Unwrap() should return the data key")
+	}
+
+	other := make([]byte, 32)
+	rand.Read(other)
+	otherKEK, err := NewLocalKEK(other)
+	if err != nil {
+		t.Fatalf("NewLocalKEK() error = %v", err)
+	}
+	if otherKEK.ID() == kek.ID() {
+		t.Error("different keys should have different IDs")
+	}
+	if _, err := otherKEK.Unwrap(context.Background(), wrapped); err == nil {
+		t.Error("Unwrap() should fail with another key")
+	}
+}
+
+func TestVaultTransitKEK_WrapUnwrap(t *testing.T) {
+	// The fake transit engine "encrypts" by prefixing the plaintext
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/glassflow":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}})
+		case "/v1/transit/decrypt/glassflow":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	vault, err := secrets.NewVaultClient(srv.URL, "token")
+	if err != nil {
+		t.Fatalf("NewVaultClient() error = %v", err)
+	}
+	kek, err := NewVaultTransitKEK(vault, "transit", "glassflow")
+	if err != nil {
+		t.Fatalf("NewVaultTransitKEK() error = %v", err)
+	}
+	if kek.ID() != "vault-transit:transit/glassflow" {
+		t.Errorf("ID() = %v", kek.ID())
+	}
+
+	dataKey, err := NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+	wrapped, err := kek.Wrap(context.Background(), dataKey)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if !strings.HasPrefix(string(wrapped), "vault:v1:") {
+		t.Errorf("Wrap() = %v, want the transit ciphertext", string(wrapped))
+	}
+
+	unwrapped, err := kek.Unwrap(context.Background(), wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap() error = %v", err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Error("Unwrap() should return the data key")
+	}
+
+	wrongToken, err := secrets.NewVaultClient(srv.URL, "wrong")
+	if err != nil {
+		t.Fatalf("NewVaultClient() error = %v", err)
+	}
+	unauthorized, err := NewVaultTransitKEK(wrongToken, "transit", "glassflow")
+	if err != nil {
+		t.Fatalf("NewVaultTransitKEK() error = %v", err)
+	}
+	if _, err := unauthorized.Wrap(context.Background(), dataKey); err == nil {
+		t.Error("Wrap() should fail when vault rejects the token")
+	}
+}
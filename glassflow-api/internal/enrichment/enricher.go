@@ -0,0 +1,295 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// table returns the rows of the dimension table found for keys.
+type table interface {
+	lookup(ctx context.Context, keys []string) (map[string]Row, error)
+}
+
+// Enricher adds the columns of the dimension table row matching the key
+// field of an event to the event. Events without the key field or without a
+// matching row are passed on unchanged, their enrichment fields are missing.
+type Enricher struct {
+	keyField string
+	columns  []models.EnrichmentColumn
+	table    table
+	refresh  *refreshTable
+	log      *slog.Logger
+}
+
+func New(cfg models.EnrichmentConfig, loader Loader, log *slog.Logger) *Enricher {
+	e := &Enricher{
+		keyField: cfg.KeyField,
+		columns:  cfg.Columns,
+		log:      log,
+	}
+
+	if cfg.Mode == internal.EnrichmentModeLookup {
+		e.table = newLookupTable(loader, cfg.CacheTTL.Duration(), cfg.CacheMaxEntries)
+	} else {
+		e.refresh = newRefreshTable(loader, cfg.RefreshInterval.Duration(), log)
+		e.table = e.refresh
+	}
+
+	return e
+}
+
+// Start loads the table of the refresh mode and keeps refreshing it until
+// ctx is done or the enricher is closed.
+func (e *Enricher) Start(ctx context.Context) error {
+	if e.refresh == nil {
+		return nil
+	}
+	return e.refresh.start(ctx)
+}
+
+func (e *Enricher) Close() {
+	if e.refresh != nil {
+		e.refresh.stop()
+	}
+}
+
+// Enrich returns the enriched payloads, or the error of the payloads that
+// could not be enriched. An error looking the keys up fails the whole batch.
+func (e *Enricher) Enrich(ctx context.Context, payloads [][]byte) ([][]byte, []error, error) {
+	keys := make([]string, len(payloads))
+	found := make([]bool, len(payloads))
+	unique := make([]string, 0, len(payloads))
+	seen := make(map[string]struct{}, len(payloads))
+	for i, payload := range payloads {
+		value := fieldValue(gjson.ParseBytes(payload), e.keyField)
+		if !value.Exists() || value.Type == gjson.Null {
+			continue
+		}
+		keys[i], found[i] = value.String(), true
+		if _, ok := seen[keys[i]]; !ok {
+			seen[keys[i]] = struct{}{}
+			unique = append(unique, keys[i])
+		}
+	}
+
+	rows, err := e.table.lookup(ctx, unique)
+	if err != nil {
+		return nil, nil, fmt.Errorf("look up enrichment rows: %w", err)
+	}
+
+	enriched := make([][]byte, len(payloads))
+	errs := make([]error, len(payloads))
+	for i, payload := range payloads {
+		enriched[i] = payload
+		if !found[i] {
+			continue
+		}
+		row, ok := rows[keys[i]]
+		if !ok {
+			continue
+		}
+
+		for _, c := range e.columns {
+			payload, err = sjson.SetBytes(payload, escapePath(c.Name()), row[c.Name()])
+			if err != nil {
+				errs[i] = fmt.Errorf("set enrichment field %s: %w", c.Name(), err)
+				break
+			}
+		}
+		if errs[i] == nil {
+			enriched[i] = payload
+		}
+	}
+
+	return enriched, errs, nil
+}
+
+// fieldValue reads a literal dotted key before a nested path, like the
+// schema validation.
+func fieldValue(parsed gjson.Result, name string) gjson.Result {
+	if strings.Contains(name, ".") {
+		if value := parsed.Get(escapePath(name)); value.Exists() {
+			return value
+		}
+	}
+	return parsed.Get(name)
+}
+
+func escapePath(name string) string {
+	return strings.ReplaceAll(name, ".", `\.`)
+}
+
+// refreshTable holds the whole dimension table in memory and reloads it
+// every interval. A failed reload keeps the rows loaded before.
+type refreshTable struct {
+	loader   Loader
+	interval time.Duration
+	rows     atomic.Pointer[map[string]Row]
+	log      *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newRefreshTable(loader Loader, interval time.Duration, log *slog.Logger) *refreshTable {
+	return &refreshTable{
+		loader:   loader,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (t *refreshTable) start(ctx context.Context) error {
+	if err := t.load(ctx); err != nil {
+		return err
+	}
+
+	ctx, t.cancel = context.WithCancel(ctx)
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := t.load(ctx); err != nil && ctx.Err() == nil {
+					t.log.WarnContext(ctx, "failed to refresh enrichment table, keeping the loaded rows", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (t *refreshTable) stop() {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	<-t.done
+}
+
+func (t *refreshTable) load(ctx context.Context) error {
+	rows, err := t.loader.Load(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("load enrichment table: %w", err)
+	}
+	t.rows.Store(&rows)
+	t.log.InfoContext(ctx, "enrichment table loaded", slog.Int("rows", len(rows)))
+	return nil
+}
+
+func (t *refreshTable) lookup(_ context.Context, _ []string) (map[string]Row, error) {
+	rows := t.rows.Load()
+	if rows == nil {
+		return nil, fmt.Errorf("enrichment table is not loaded")
+	}
+	return *rows, nil
+}
+
+type cacheEntry struct {
+	row     Row // nil when the table has no row for the key
+	expires time.Time
+}
+
+// lookupTable queries the keys that are not cached. Keys without a row are
+// cached too, so that they are not queried for every event.
+type lookupTable struct {
+	loader     Loader
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newLookupTable(loader Loader, ttl time.Duration, maxEntries int) *lookupTable {
+	return &lookupTable{
+		loader:     loader,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+func (t *lookupTable) lookup(ctx context.Context, keys []string) (map[string]Row, error) {
+	rows := make(map[string]Row, len(keys))
+	var missing []string
+
+	now := t.now()
+	t.mu.Lock()
+	for _, key := range keys {
+		entry, ok := t.entries[key]
+		if !ok || now.After(entry.expires) {
+			missing = append(missing, key)
+			continue
+		}
+		if entry.row != nil {
+			rows[key] = entry.row
+		}
+	}
+	t.mu.Unlock()
+
+	if len(missing) == 0 {
+		return rows, nil
+	}
+
+	loaded, err := t.loader.Load(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("load enrichment rows: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.makeRoom(now, len(missing))
+	expires := now.Add(t.ttl)
+	for _, key := range missing {
+		row := loaded[key]
+		if row != nil {
+			rows[key] = row
+		}
+		if len(t.entries) < t.maxEntries {
+			t.entries[key] = cacheEntry{row: row, expires: expires}
+		}
+	}
+
+	return rows, nil
+}
+
+// makeRoom evicts the expired entries, then arbitrary ones, until n more
+// entries fit in the cache.
+func (t *lookupTable) makeRoom(now time.Time, n int) {
+	if len(t.entries)+n <= t.maxEntries {
+		return
+	}
+	for key, entry := range t.entries {
+		if now.After(entry.expires) {
+			delete(t.entries, key)
+		}
+	}
+	for key := range t.entries {
+		if len(t.entries)+n <= t.maxEntries {
+			return
+		}
+		delete(t.entries, key)
+	}
+}
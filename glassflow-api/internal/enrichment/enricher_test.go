@@ -0,0 +1,129 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeLoader struct {
+	rows  map[string]Row
+	err   error
+	calls [][]string
+}
+
+func (f *fakeLoader) Load(_ context.Context, keys []string) (map[string]Row, error) {
+	f.calls = append(f.calls, keys)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if keys == nil {
+		return f.rows, nil
+	}
+	rows := make(map[string]Row)
+	for _, key := range keys {
+		if row, ok := f.rows[key]; ok {
+			rows[key] = row
+		}
+	}
+	return rows, nil
+}
+
+func enrichmentConfig(mode string) models.EnrichmentConfig {
+	cfg, err := models.NewEnrichmentConfig(models.EnrichmentConfig{
+		Table:     "customers",
+		KeyField:  "customer.id",
+		KeyColumn: "id",
+		Columns: []models.EnrichmentColumn{
+			{Column: "name", OutputName: "customer_name", OutputType: "string"},
+			{Column: "tier", OutputType: "int"},
+		},
+		Mode: mode,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func TestEnricher_Refresh(t *testing.T) {
+	loader := &fakeLoader{rows: map[string]Row{
+		"42": {"customer_name": "Ada", "tier": uint8(2)},
+	}}
+	e := New(enrichmentConfig(internal.EnrichmentModeRefresh), loader, slog.Default())
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Close()
+
+	enriched, errs, err := e.Enrich(context.Background(), [][]byte{
+		[]byte(`{"customer":{"id":42},"amount":10}`),
+		[]byte(`{"customer.id":"42"}`),
+		[]byte(`{"customer":{"id":7}}`),
+		[]byte(`{"amount":1}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	assert.JSONEq(t, `{"customer":{"id":42},"amount":10,"customer_name":"Ada","tier":2}`, string(enriched[0]))
+	assert.JSONEq(t, `{"customer.id":"42","customer_name":"Ada","tier":2}`, string(enriched[1]))
+	assert.JSONEq(t, `{"customer":{"id":7}}`, string(enriched[2]), "events without a row are unchanged")
+	assert.JSONEq(t, `{"amount":1}`, string(enriched[3]), "events without the key are unchanged")
+
+	assert.Equal(t, [][]string{nil}, loader.calls, "the refresh mode reads the whole table")
+}
+
+func TestEnricher_RefreshFailsWithoutTable(t *testing.T) {
+	loader := &fakeLoader{err: errors.New("table does not exist")}
+	e := New(enrichmentConfig(internal.EnrichmentModeRefresh), loader, slog.Default())
+	require.Error(t, e.Start(context.Background()))
+}
+
+func TestEnricher_Lookup(t *testing.T) {
+	loader := &fakeLoader{rows: map[string]Row{
+		"42": {"customer_name": "Ada", "tier": 2},
+	}}
+	e := New(enrichmentConfig(internal.EnrichmentModeLookup), loader, slog.Default())
+	require.NoError(t, e.Start(context.Background()))
+
+	payloads := [][]byte{
+		[]byte(`{"customer":{"id":42}}`),
+		[]byte(`{"customer":{"id":"42"}}`),
+		[]byte(`{"customer":{"id":7}}`),
+	}
+	enriched, _, err := e.Enrich(context.Background(), payloads)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"customer":{"id":42},"customer_name":"Ada","tier":2}`, string(enriched[0]))
+	assert.JSONEq(t, `{"customer":{"id":7}}`, string(enriched[2]))
+	assert.Equal(t, [][]string{{"42", "7"}}, loader.calls, "keys are queried once per batch")
+
+	// Found and missing keys are both cached
+	_, _, err = e.Enrich(context.Background(), payloads)
+	require.NoError(t, err)
+	assert.Len(t, loader.calls, 1)
+
+	loader.err = errors.New("connection refused")
+	lt := e.table.(*lookupTable)
+	lt.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, _, err = e.Enrich(context.Background(), payloads)
+	require.Error(t, err, "a failed lookup fails the batch")
+}
+
+func TestLookupTable_BoundedCache(t *testing.T) {
+	loader := &fakeLoader{rows: map[string]Row{}}
+	lt := newLookupTable(loader, time.Hour, 2)
+
+	_, err := lt.lookup(context.Background(), []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Len(t, lt.entries, 2)
+
+	_, err = lt.lookup(context.Background(), []string{"d"})
+	require.NoError(t, err)
+	assert.Len(t, lt.entries, 2)
+	assert.Contains(t, lt.entries, "d")
+}
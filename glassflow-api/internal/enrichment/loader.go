@@ -0,0 +1,127 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// Row holds the selected columns of a dimension table row by the name of
+// their event field.
+type Row map[string]any
+
+// Loader reads the rows of the dimension table by key. A nil keys slice
+// reads the whole table.
+type Loader interface {
+	Load(ctx context.Context, keys []string) (map[string]Row, error)
+}
+
+type querier interface {
+	Query(ctx context.Context, query string, args ...any) (driver.Rows, error)
+}
+
+// ClickHouseLoader reads the dimension table from ClickHouse. Keys are
+// compared as strings, so any key column type matches the event field.
+type ClickHouseLoader struct {
+	client  querier
+	query   string
+	keyExpr string
+	columns []models.EnrichmentColumn
+	maxRows int
+}
+
+// NewClickHouseLoader returns a loader of the table of cfg, in database unless
+// cfg names its own.
+func NewClickHouseLoader(client querier, database string, cfg models.EnrichmentConfig) *ClickHouseLoader {
+	if cfg.Database != "" {
+		database = cfg.Database
+	}
+
+	keyExpr := "toString(" + quoteIdentifier(cfg.KeyColumn) + ")"
+	selected := make([]string, 0, len(cfg.Columns)+1)
+	selected = append(selected, keyExpr)
+	for _, c := range cfg.Columns {
+		selected = append(selected, quoteIdentifier(c.Column))
+	}
+
+	return &ClickHouseLoader{
+		client: client,
+		query: fmt.Sprintf("SELECT %s FROM %s.%s",
+			strings.Join(selected, ", "),
+			quoteIdentifier(database),
+			quoteIdentifier(cfg.Table),
+		),
+		keyExpr: keyExpr,
+		columns: cfg.Columns,
+		maxRows: internal.MaxEnrichmentTableRows,
+	}
+}
+
+func (l *ClickHouseLoader) Load(ctx context.Context, keys []string) (map[string]Row, error) {
+	query := l.query
+	var args []any
+	if keys != nil {
+		query += " WHERE has(?, " + l.keyExpr + ")"
+		args = append(args, keys)
+	} else {
+		query += fmt.Sprintf(" LIMIT %d", l.maxRows+1)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, internal.EnrichmentQueryTimeout)
+	defer cancel()
+
+	rows, err := l.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query dimension table: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes := rows.ColumnTypes()
+	if len(columnTypes) != len(l.columns)+1 {
+		return nil, fmt.Errorf("query dimension table: got %d columns, want %d", len(columnTypes), len(l.columns)+1)
+	}
+
+	loaded := make(map[string]Row)
+	var count int
+	for rows.Next() {
+		count++
+		if keys == nil && count > l.maxRows {
+			return nil, fmt.Errorf("dimension table has more than %d rows, use the %s mode", l.maxRows, internal.EnrichmentModeLookup)
+		}
+
+		dest := make([]any, len(columnTypes))
+		for i, ct := range columnTypes {
+			dest[i] = reflect.New(ct.ScanType()).Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan dimension table row: %w", err)
+		}
+
+		key, ok := dest[0].(*string)
+		if !ok {
+			return nil, fmt.Errorf("scan dimension table row: key is %T, not a string", dest[0])
+		}
+		row := make(Row, len(l.columns))
+		for i, c := range l.columns {
+			row[c.Name()] = reflect.ValueOf(dest[i+1]).Elem().Interface()
+		}
+		loaded[*key] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read dimension table: %w", err)
+	}
+
+	return loaded, nil
+}
+
+// quoteIdentifier wraps a ClickHouse identifier in backticks, escaping any
+// existing backticks within the name.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,189 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+const (
+	JoinChain       = "join_chain"
+	OuterJoin       = "outer_join"
+	Enrichment      = "enrichment"
+	AutoCreateTable = "auto_create_table"
+)
+
+// Sources of a resolved flag, from the lowest priority to the highest
+const (
+	SourceDefault      = "default"
+	SourceEnv          = "env"
+	SourceInstallation = "installation"
+	SourcePipeline     = "pipeline"
+)
+
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// registry lists the flags gating pipeline capabilities. A capability still
+// rolling out defaults to disabled, shipped ones default to enabled so that
+// they can be turned off without a release.
+var registry = []Flag{
+	{Name: JoinChain, Description: "Joins of more than two sources", Default: true},
+	{Name: OuterJoin, Description: "Left, right and full outer joins", Default: true},
+	{Name: Enrichment, Description: "ClickHouse lookup-table enrichment", Default: true},
+	{Name: AutoCreateTable, Description: "Creating the sink table from the sink mapping", Default: true},
+}
+
+func Known(name string) bool {
+	return slices.ContainsFunc(registry, func(f Flag) bool { return f.Name == name })
+}
+
+// Used returns the flags gating the capabilities a pipeline config uses.
+func Used(cfg models.PipelineConfig) []string {
+	var used []string
+	if len(cfg.Join.Chain) > 0 {
+		used = append(used, JoinChain)
+	}
+	if cfg.Join.Enabled && cfg.Join.JoinType != "" && cfg.Join.JoinType != internal.JoinTypeInner {
+		used = append(used, OuterJoin)
+	}
+	if cfg.Enrichment.Enabled {
+		used = append(used, Enrichment)
+	}
+	if cfg.Sink.CreateTable != nil {
+		used = append(used, AutoCreateTable)
+	}
+	return used
+}
+
+// Store keeps the flags set through the API, by flag name. An empty pipeline
+// ID is the scope of the installation.
+type Store interface {
+	GetFeatureFlags(ctx context.Context, pipelineID string) (map[string]bool, error)
+	SetFeatureFlag(ctx context.Context, pipelineID, name string, enabled bool) error
+	DeleteFeatureFlag(ctx context.Context, pipelineID, name string) error
+}
+
+type State struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// Flags resolves the flags of the installation and its pipelines. A flag is
+// set by, from the lowest priority to the highest: its default, the
+// environment, the installation setting and the pipeline setting.
+type Flags struct {
+	env   map[string]bool
+	store Store
+}
+
+func New(env map[string]bool, store Store) *Flags {
+	return &Flags{
+		env:   env,
+		store: store,
+	}
+}
+
+// ParseEnv parses name=true|false pairs.
+func ParseEnv(pairs []string) (map[string]bool, error) {
+	env := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("feature flag %q must be name=true or name=false", pair)
+		}
+		if !Known(name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFlag, name)
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("feature flag %q: %w", name, err)
+		}
+		env[name] = enabled
+	}
+	return env, nil
+}
+
+// Resolve returns the state of every flag for a pipeline, or for the
+// installation when pipelineID is empty.
+func (f *Flags) Resolve(ctx context.Context, pipelineID string) ([]State, error) {
+	installation, err := f.store.GetFeatureFlags(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("get installation feature flags: %w", err)
+	}
+
+	var pipeline map[string]bool
+	if pipelineID != "" {
+		pipeline, err = f.store.GetFeatureFlags(ctx, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("get pipeline feature flags: %w", err)
+		}
+	}
+
+	states := make([]State, 0, len(registry))
+	for _, flag := range registry {
+		state := State{Name: flag.Name, Description: flag.Description, Enabled: flag.Default, Source: SourceDefault}
+		if enabled, ok := f.env[flag.Name]; ok {
+			state.Enabled, state.Source = enabled, SourceEnv
+		}
+		if enabled, ok := installation[flag.Name]; ok {
+			state.Enabled, state.Source = enabled, SourceInstallation
+		}
+		if enabled, ok := pipeline[flag.Name]; ok {
+			state.Enabled, state.Source = enabled, SourcePipeline
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// Disabled returns the flags among names that are disabled for a pipeline.
+func (f *Flags) Disabled(ctx context.Context, pipelineID string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	states, err := f.Resolve(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	var disabled []string
+	for _, state := range states {
+		if !state.Enabled && slices.Contains(names, state.Name) {
+			disabled = append(disabled, state.Name)
+		}
+	}
+	return disabled, nil
+}
+
+// Set overrides a flag for a pipeline, or for the installation when
+// pipelineID is empty.
+func (f *Flags) Set(ctx context.Context, pipelineID, name string, enabled bool) error {
+	if !Known(name) {
+		return fmt.Errorf("%w: %q", ErrUnknownFlag, name)
+	}
+	return f.store.SetFeatureFlag(ctx, pipelineID, name, enabled)
+}
+
+// Reset removes the override of a flag, which falls back to the next source.
+func (f *Flags) Reset(ctx context.Context, pipelineID, name string) error {
+	if !Known(name) {
+		return fmt.Errorf("%w: %q", ErrUnknownFlag, name)
+	}
+	return f.store.DeleteFeatureFlag(ctx, pipelineID, name)
+}
@@ -0,0 +1,98 @@
+package featureflags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeStore map[string]map[string]bool
+
+func (f fakeStore) GetFeatureFlags(_ context.Context, pipelineID string) (map[string]bool, error) {
+	return f[pipelineID], nil
+}
+
+func (f fakeStore) SetFeatureFlag(_ context.Context, pipelineID, name string, enabled bool) error {
+	if f[pipelineID] == nil {
+		f[pipelineID] = make(map[string]bool)
+	}
+	f[pipelineID][name] = enabled
+	return nil
+}
+
+func (f fakeStore) DeleteFeatureFlag(_ context.Context, pipelineID, name string) error {
+	delete(f[pipelineID], name)
+	return nil
+}
+
+func stateOf(t *testing.T, states []State, name string) State {
+	t.Helper()
+	for _, state := range states {
+		if state.Name == name {
+			return state
+		}
+	}
+	t.Fatalf("flag %s not resolved", name)
+	return State{}
+}
+
+func TestResolve(t *testing.T) {
+	env, err := ParseEnv([]string{"join_chain=false", "enrichment=false"})
+	require.NoError(t, err)
+
+	store := fakeStore{}
+	flags := New(env, store)
+	ctx := context.Background()
+
+	require.NoError(t, flags.Set(ctx, "", Enrichment, true))
+	require.NoError(t, flags.Set(ctx, "pipeline-1", JoinChain, true))
+	require.NoError(t, flags.Set(ctx, "pipeline-1", OuterJoin, false))
+
+	states, err := flags.Resolve(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, State{Name: JoinChain, Description: "Joins of more than two sources", Enabled: false, Source: SourceEnv}, stateOf(t, states, JoinChain))
+	assert.Equal(t, SourceInstallation, stateOf(t, states, Enrichment).Source)
+	assert.True(t, stateOf(t, states, Enrichment).Enabled)
+	assert.Equal(t, SourceDefault, stateOf(t, states, OuterJoin).Source)
+
+	states, err = flags.Resolve(ctx, "pipeline-1")
+	require.NoError(t, err)
+	assert.Equal(t, State{Name: JoinChain, Description: "Joins of more than two sources", Enabled: true, Source: SourcePipeline}, stateOf(t, states, JoinChain))
+	assert.False(t, stateOf(t, states, OuterJoin).Enabled)
+
+	require.NoError(t, flags.Reset(ctx, "pipeline-1", OuterJoin))
+	disabled, err := flags.Disabled(ctx, "pipeline-1", []string{JoinChain, OuterJoin})
+	require.NoError(t, err)
+	assert.Empty(t, disabled)
+
+	assert.ErrorIs(t, flags.Set(ctx, "", "columnar", true), ErrUnknownFlag)
+}
+
+func TestParseEnv(t *testing.T) {
+	_, err := ParseEnv([]string{"join_chain"})
+	require.Error(t, err)
+
+	_, err = ParseEnv([]string{"columnar=true"})
+	require.ErrorIs(t, err, ErrUnknownFlag)
+
+	_, err = ParseEnv([]string{"join_chain=maybe"})
+	require.Error(t, err)
+}
+
+func TestUsed(t *testing.T) {
+	cfg := models.PipelineConfig{
+		Join: models.JoinComponentConfig{
+			Enabled:  true,
+			JoinType: internal.JoinTypeLeft,
+		},
+		Enrichment: models.EnrichmentConfig{Enabled: true},
+	}
+
+	assert.Equal(t, []string{OuterJoin, Enrichment}, Used(cfg))
+	assert.Empty(t, Used(models.PipelineConfig{}))
+}
@@ -0,0 +1,92 @@
+package httpingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+var ndjsonContentTypes = map[string]struct{}{
+	"application/x-ndjson": {},
+	"application/ndjson":   {},
+	"application/jsonl":    {},
+}
+
+// DecodeEvents splits a request body into JSON events. The body holds a
+// single JSON object or an array of objects, or one object per line for
+// NDJSON content types.
+func DecodeEvents(contentType string, body []byte) ([][]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	var events [][]byte
+	if _, ok := ndjsonContentTypes[mediaType]; ok {
+		events, err = decodeNDJSON(body)
+	} else {
+		events, err = decodeJSON(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: no events in request body", models.ErrInvalidEventPayload)
+	}
+
+	return events, nil
+}
+
+func decodeJSON(body []byte) ([][]byte, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	if body[0] != '[' {
+		if !isJSONObject(body) {
+			return nil, fmt.Errorf("%w: body must be a JSON object or an array of objects", models.ErrInvalidEventPayload)
+		}
+		return [][]byte{body}, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidEventPayload, err)
+	}
+
+	events := make([][]byte, len(raw))
+	for i, event := range raw {
+		if !isJSONObject(event) {
+			return nil, fmt.Errorf("%w: event %d is not a JSON object", models.ErrInvalidEventPayload, i)
+		}
+		events[i] = event
+	}
+
+	return events, nil
+}
+
+func decodeNDJSON(body []byte) ([][]byte, error) {
+	var events [][]byte
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !isJSONObject(line) {
+			return nil, fmt.Errorf("%w: line %d is not a JSON object", models.ErrInvalidEventPayload, i+1)
+		}
+		events = append(events, line)
+	}
+
+	return events, nil
+}
+
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{' && json.Valid(data)
+}
@@ -0,0 +1,79 @@
+package httpingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestDecodeEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{
+			name:        "single object",
+			contentType: "application/json",
+			body:        ` {"id": 1} `,
+			want:        []string{`{"id": 1}`},
+		},
+		{
+			name:        "array of objects",
+			contentType: "application/json; charset=utf-8",
+			body:        `[{"id": 1}, {"id": 2}]`,
+			want:        []string{`{"id": 1}`, `{"id": 2}`},
+		},
+		{
+			name: "missing content type",
+			body: `{"id": 1}`,
+			want: []string{`{"id": 1}`},
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			body:        "{\"id\": 1}\n\n{\"id\": 2}\r\n",
+			want:        []string{`{"id": 1}`, `{"id": 2}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := DecodeEvents(tt.contentType, []byte(tt.body))
+			require.NoError(t, err)
+
+			got := make([]string, len(events))
+			for i, event := range events {
+				got[i] = string(event)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodeEvents_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "empty body", contentType: "application/json", body: "  "},
+		{name: "empty array", contentType: "application/json", body: "[]"},
+		{name: "scalar", contentType: "application/json", body: "42"},
+		{name: "array with scalar", contentType: "application/json", body: `[{"id": 1}, 2]`},
+		{name: "invalid json", contentType: "application/json", body: `{"id": `},
+		{name: "ndjson with invalid line", contentType: "application/x-ndjson", body: "{\"id\": 1}\n[1]"},
+		{name: "ndjson sent as json", contentType: "application/json", body: "{\"id\": 1}\n{\"id\": 2}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeEvents(tt.contentType, []byte(tt.body))
+			require.ErrorIs(t, err, models.ErrInvalidEventPayload)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package httpingest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// EventProcessor publishes decoded events into the ingest stream of a pipeline.
+type EventProcessor interface {
+	ProcessEvents(ctx context.Context, pipelineID string, events [][]byte) error
+}
+
+// Ingest decodes a request body and publishes its events, it returns the
+// number of accepted events.
+func Ingest(ctx context.Context, processor EventProcessor, pipelineID, contentType string, body []byte) (int, error) {
+	start := time.Now()
+	component := observability.MetricComponentHTTPIngest.String()
+
+	observability.RecordBytesProcessedByPipelineID(ctx, component, "in", pipelineID, int64(len(body)))
+
+	events, err := DecodeEvents(contentType, body)
+	if err == nil {
+		err = processor.ProcessEvents(ctx, pipelineID, events)
+	}
+
+	status := "ok"
+	if StatusCode(err) >= http.StatusInternalServerError {
+		status = "error"
+	}
+	observability.RecordReceiverRequest(ctx, component, "http", status, pipelineID, time.Since(start).Seconds())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// StatusCode maps an ingestion error to the HTTP status returned to producers.
+func StatusCode(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusAccepted
+	case errors.Is(err, models.ErrInvalidEventPayload), errors.Is(err, models.ErrNotHTTPSource):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPipelineNotFound), errors.Is(err, service.ErrPipelineNotExists):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrReceiverOverloaded), errors.Is(err, models.ErrStreamBackpressure):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,156 @@
+package httpingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/natshealth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/server"
+)
+
+// IngestPath is served by the API and by the standalone ingest server, so
+// producers can point to either.
+const IngestPath = "/api/v1/pipeline/{id}/ingest"
+
+type ingestResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// Server is the standalone HTTP ingestion server, it publishes pushed events
+// without going through the API.
+type Server struct {
+	ready      atomic.Bool
+	httpServer *server.Server
+	processor  EventProcessor
+	natsProbe  *natshealth.Probe
+	log        *slog.Logger
+	done       chan struct{}
+}
+
+func NewServer(addr string, log *slog.Logger, processor EventProcessor, natsProbe *natshealth.Probe) *Server {
+	s := &Server{
+		processor: processor,
+		natsProbe: natsProbe,
+		log:       log,
+		done:      make(chan struct{}),
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc(IngestPath, s.ingest).Methods(http.MethodPost)
+	r.HandleFunc("/healthz", s.healthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", s.readyz).Methods(http.MethodGet)
+
+	s.httpServer = server.NewHTTPServer(
+		addr,
+		15*time.Second,
+		15*time.Second,
+		5*time.Minute,
+		log,
+		r,
+	)
+
+	return s
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	defer close(s.done)
+
+	s.natsProbe.Start(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.httpServer.Start()
+	}()
+
+	s.ready.Store(true)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (s *Server) Shutdown() {
+	s.ready.Store(false)
+
+	err := s.httpServer.Shutdown(context.Background(), 5*time.Second)
+	if err != nil {
+		s.log.Error("failed to shutdown server", slog.Any("error", err))
+	}
+}
+
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Server) ingest(w http.ResponseWriter, r *http.Request) {
+	pipelineID := mux.Vars(r)["id"]
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, internal.HTTPIngestMaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accepted, err := Ingest(r.Context(), s.processor, pipelineID, r.Header.Get("Content-Type"), body)
+	if err != nil {
+		status := StatusCode(err)
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		if status >= http.StatusInternalServerError {
+			s.log.ErrorContext(r.Context(), "failed to ingest events",
+				slog.String("pipeline_id", pipelineID),
+				slog.Any("error", err))
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, ingestResponse{Accepted: accepted})
+}
+
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	if ok, lastGood := s.natsProbe.Healthy(); !ok {
+		s.log.Warn("healthz: NATS unhealthy", slog.Time("last_good", lastGood))
+		writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: "nats unhealthy"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+func (s *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	if !s.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: "not ready"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ready"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
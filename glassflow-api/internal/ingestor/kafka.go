@@ -31,6 +31,7 @@ func NewKafkaIngestor(
 	natsPub, dlqPub stream.Publisher,
 	schema *schemav2.Schema,
 	signalPublisher *componentsignals.ComponentSignalPublisher,
+	flowGate kafka.FlowGate,
 	log *slog.Logger,
 ) (*KafkaIngestor, error) {
 	var topic models.KafkaTopicsConfig
@@ -56,7 +57,7 @@ func NewKafkaIngestor(
 		return nil, fmt.Errorf("topic %s not found in ingestor config", topicName)
 	}
 
-	consumer, err := kafka.NewConsumer(config.Ingestor.KafkaConnectionParams, topic, log)
+	consumer, err := kafka.NewConsumer(config.Ingestor.KafkaConnectionParams, topic, runtimeCfg.ReplicaIndex, flowGate, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
 	}
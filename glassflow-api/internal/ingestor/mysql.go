@@ -0,0 +1,103 @@
+package ingestor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mysql"
+	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+)
+
+// MySQLIngestor follows the binlog of a MySQL table and publishes its row
+// changes to NATS through the Kafka message processor, so the streams have
+// the same layout as for Kafka sources.
+type MySQLIngestor struct {
+	consumer  KafkaConsumer
+	processor kafka.MessageProcessor
+	topic     models.KafkaTopicsConfig
+	log       *slog.Logger
+}
+
+func NewMySQLIngestor(
+	config models.PipelineConfig,
+	topicName string,
+	runtimeCfg models.IngestorRuntimeConfig,
+	natsPub, dlqPub stream.Publisher,
+	schema *schemav2.Schema,
+	signalPublisher *componentsignals.ComponentSignalPublisher,
+	checkpoints mysql.CheckpointStore,
+	log *slog.Logger,
+) (*MySQLIngestor, error) {
+	var topic models.KafkaTopicsConfig
+
+	if topicName == "" {
+		return nil, fmt.Errorf("table not found")
+	}
+
+	found := false
+	for _, t := range config.Ingestor.KafkaTopics {
+		if t.Name == topicName {
+			log.Debug("Found table for MySQL ingestor", slog.String("table", t.Name), slog.String("id", t.ID))
+			topic = t
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("table %s not found in ingestor config", topicName)
+	}
+
+	consumer, err := mysql.NewConsumer(config.Ingestor.MySQLConnectionParams, topic, config.ID, checkpoints, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MySQL binlog consumer: %w", err)
+	}
+
+	msgProcessor, err := NewKafkaMsgProcessor(
+		config.ID,
+		natsPub,
+		dlqPub,
+		schema,
+		topic,
+		runtimeCfg,
+		signalPublisher,
+		log,
+	)
+	if err != nil {
+		consumer.Close()
+		return nil, fmt.Errorf("failed to create mysql message processor: %w", err)
+	}
+
+	return &MySQLIngestor{
+		consumer:  consumer,
+		processor: msgProcessor,
+		topic:     topic,
+		log:       log,
+	}, nil
+}
+
+// Start starts the MySQL ingestor
+func (m *MySQLIngestor) Start(ctx context.Context) error {
+	m.log.Info("Starting MySQL ingestor", slog.String("table", m.topic.Name))
+
+	err := m.consumer.Start(ctx, m.processor)
+	if err != nil {
+		return fmt.Errorf("mysql binlog consumer failed: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the MySQL ingestor
+func (m *MySQLIngestor) Stop() {
+	m.log.Info("Stopping MySQL ingestor", slog.String("table", m.topic.Name))
+	err := m.consumer.Close()
+	if err != nil {
+		m.log.Error("Failed to close mysql binlog consumer", slog.Any("error", err), slog.String("table", m.topic.Name))
+	}
+}
@@ -2,6 +2,8 @@ package ingestor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash/fnv"
@@ -13,6 +15,8 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 
@@ -20,6 +24,7 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/debezium"
 )
 
 // SchemaValidator is the subset of schema_v2.Schema that the ingestor uses.
@@ -28,7 +33,7 @@ import (
 type SchemaValidator interface {
 	Validate(ctx context.Context, data []byte) (string, error)
 	Get(ctx context.Context, versionID, key string, data []byte) (any, error)
-	IsExternal() bool
+	Decode(ctx context.Context, data []byte) ([]byte, error)
 }
 
 type KafkaMsgProcessor struct {
@@ -40,6 +45,9 @@ type KafkaMsgProcessor struct {
 	signalPublisher *componentsignals.ComponentSignalPublisher
 	log             *slog.Logger
 
+	// unwrapper extracts the row of Debezium change events, nil when disabled
+	unwrapper *debezium.Unwrapper
+
 	outputSubject       string
 	outputSubjectPrefix string
 	totalSubjectCount   int
@@ -90,6 +98,11 @@ func NewKafkaMsgProcessor(
 		}
 	}
 
+	var unwrapper *debezium.Unwrapper
+	if topic.DebeziumUnwrap.Enabled {
+		unwrapper = debezium.NewUnwrapper(topic.DebeziumUnwrap)
+	}
+
 	pendingPublishesLimit := min(internal.PublisherMaxPendingAcks, internal.NATSMaxBufferedMsgs/topic.Replicas)
 	return &KafkaMsgProcessor{
 		pipelineID:            pipelineID,
@@ -106,13 +119,14 @@ func NewKafkaMsgProcessor(
 		pendingPublishesLimit: pendingPublishesLimit,
 		signalPublisher:       signalPublisher,
 		log:                   log,
+		unwrapper:             unwrapper,
 	}, nil
 }
 
 func (k *KafkaMsgProcessor) pushMsgToDLQ(ctx context.Context, orgMsg []byte, err error, reason string) error {
 	k.log.Error("Pushing message to DLQ", slog.Any("error", err), slog.String("topic", k.topic.Name))
 
-	data, err := models.NewDLQMessage(internal.RoleIngestor, err.Error(), orgMsg).ToJSON()
+	data, err := models.NewDLQMessage(internal.RoleIngestor, err.Error(), orgMsg).WithReason(reason).ToJSON()
 	if err != nil {
 		k.log.Error("Failed to convert DLQ message to JSON", slog.Any("error", err), slog.String("topic", k.topic.Name))
 		return fmt.Errorf("failed to convert DLQ message to JSON: %w", err)
@@ -129,13 +143,58 @@ func (k *KafkaMsgProcessor) pushMsgToDLQ(ctx context.Context, orgMsg []byte, err
 	return nil
 }
 
+// handleDecodeError applies the topic's decode error policy to a message that
+// could not be decoded. With the fail policy the error is returned so the
+// ingestor stops on the message; otherwise the message is pushed to the DLQ
+// and processing continues.
+func (k *KafkaMsgProcessor) handleDecodeError(ctx context.Context, msg *kgo.Record, err error) error {
+	if k.topic.DecodeErrorPolicy != internal.DecodeErrorPolicyFail {
+		if dlqErr := k.pushMsgToDLQ(ctx, msg.Value, err, observability.DLQReasonParseError); dlqErr != nil {
+			return fmt.Errorf("failed to push to DLQ: %w", dlqErr)
+		}
+		return nil
+	}
+
+	if k.signalPublisher != nil {
+		sigErr := k.signalPublisher.SendSignal(ctx, models.ComponentSignal{
+			Component:  internal.RoleIngestor,
+			PipelineID: k.pipelineID,
+			Reason:     err.Error(),
+			Text:       fmt.Sprintf("message %s could not be decoded", ingestSequence(msg)),
+		})
+		if sigErr != nil {
+			return fmt.Errorf("failed to send component signal: %w", sigErr)
+		}
+	}
+
+	return err
+}
+
 // setDedupHeader sets the Nats-Msg-Id header from a pre-resolved dedup key string.
-func (k *KafkaMsgProcessor) setDedupHeader(headers nats.Header, dedupKeyStr string) {
-	if dedupKeyStr == "" {
+// Without a dedup key the topic's Msg-Id strategy applies. By default the
+// ingest sequence is used, so JetStream drops republishes of the same Kafka
+// record (consumer redeliveries after a rebalance or a failed commit) within
+// the stream's duplicate window. The content hash strategy also drops records
+// that were produced twice under different offsets. A dedup keeping the last
+// event of a key only routes by the key, its later events must not be dropped.
+func (k *KafkaMsgProcessor) setDedupHeader(headers nats.Header, dedupKeyStr, ingestSeq string, data []byte) {
+	if dedupKeyStr != "" && !k.topic.Deduplication.KeepsLast() {
+		headers.Set(jetstream.MsgIDHeader, dedupKeyStr)
+		return
+	}
+
+	if k.topic.PublishDedup.MsgIDStrategy == internal.MsgIDStrategyContentHash {
+		sum := sha256.Sum256(data)
+		headers.Set(jetstream.MsgIDHeader, hex.EncodeToString(sum[:]))
 		return
 	}
 
-	headers.Set("Nats-Msg-Id", dedupKeyStr)
+	headers.Set(jetstream.MsgIDHeader, ingestSeq)
+}
+
+// ingestSequence identifies a Kafka record by its origin.
+func ingestSequence(msg *kgo.Record) string {
+	return fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
 }
 
 // getSubject returns the NATS subject for publishing.
@@ -176,7 +235,25 @@ func (k *KafkaMsgProcessor) getSubjectAndDedupKey(ctx context.Context, version s
 }
 
 func (k *KafkaMsgProcessor) prepareMesssage(ctx context.Context, msg *kgo.Record) (*nats.Msg, error) {
-	version, err := k.schema.Validate(ctx, msg.Value)
+	value := msg.Value
+	if k.unwrapper != nil {
+		row, err := k.unwrapper.Unwrap(msg.Value)
+		if err != nil {
+			k.log.Error("Failed to unwrap debezium envelope",
+				slog.Any("error", err), slog.String("topic", k.topic.Name),
+				slog.Int64("offset", msg.Offset),
+				slog.String("partition", strconv.Itoa(int(msg.Partition))))
+
+			return nil, k.handleDecodeError(ctx, msg, err)
+		}
+		if row == nil {
+			// Tombstones and events without a row are skipped
+			return nil, nil
+		}
+		value = row
+	}
+
+	version, err := k.schema.Validate(ctx, value)
 	if err != nil {
 		if models.IsIncompatibleSchemaError(err) || errors.Is(err, models.ErrSchemaNotFound) {
 			k.log.Error("Schema validation error has been detected for message",
@@ -205,21 +282,31 @@ func (k *KafkaMsgProcessor) prepareMesssage(ctx context.Context, msg *kgo.Record
 			slog.Int64("offset", msg.Offset),
 			slog.String("partition", strconv.Itoa(int(msg.Partition))))
 
-		validationErr := fmt.Errorf("%w: %w", models.ErrValidateSchema, err)
 		if errors.Is(err, models.ErrFailedToParseSchemaID) || errors.Is(err, models.ErrMessageIsTooShort) {
-			validationErr = err
+			return nil, k.handleDecodeError(ctx, msg, err)
 		}
 
+		validationErr := fmt.Errorf("%w: %w", models.ErrValidateSchema, err)
 		if dlqErr := k.pushMsgToDLQ(ctx, msg.Value, validationErr, observability.DLQReasonParseError); dlqErr != nil {
 			return nil, fmt.Errorf("failed to push to DLQ: %w", dlqErr)
 		}
 		return nil, nil
 	}
 
-	msgData := msg.Value
-	if k.schema.IsExternal() {
-		msgData = msgData[5:] // Remove magic byte and schema version bytes for external schemas before publishing to NATS
+	msgData, err := k.schema.Decode(ctx, value)
+	if err != nil {
+		if !errors.Is(err, models.ErrDecodePayload) {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+
+		k.log.Error("Failed to decode data",
+			slog.Any("error", err), slog.String("topic", k.topic.Name),
+			slog.Int64("offset", msg.Offset),
+			slog.String("partition", strconv.Itoa(int(msg.Partition))))
+
+		return nil, k.handleDecodeError(ctx, msg, err)
 	}
+
 	subject, dedupKeyStr, err := k.getSubjectAndDedupKey(ctx, version, msgData)
 	if err != nil {
 		if dlqErr := k.pushMsgToDLQ(ctx, msg.Value, fmt.Errorf("%w: %w", models.ErrDeduplicateData, err), observability.DLQReasonParseError); dlqErr != nil {
@@ -233,7 +320,10 @@ func (k *KafkaMsgProcessor) prepareMesssage(ctx context.Context, msg *kgo.Record
 
 	nMsg.Header.Set(internal.SchemaVersionIDHeader, version) // Set schema version header
 
-	k.setDedupHeader(nMsg.Header, dedupKeyStr)
+	ingestSeq := ingestSequence(msg)
+	nMsg.Header.Set(internal.IngestSequenceHeader, ingestSeq)
+
+	k.setDedupHeader(nMsg.Header, dedupKeyStr, ingestSeq, msgData)
 
 	return nMsg, nil
 }
@@ -305,7 +395,7 @@ func (k *KafkaMsgProcessor) ProcessBatch(ctx context.Context, batch []*kgo.Recor
 
 func (k *KafkaMsgProcessor) processBatchSync(ctx context.Context, batch []*kgo.Record) (*kgo.Record, error) {
 	var lastProcessed *kgo.Record
-	var outBytes int64
+	var outBytes, published int64
 
 	for _, msg := range batch {
 		natsMsg, err := k.prepareMesssage(ctx, msg)
@@ -323,7 +413,9 @@ func (k *KafkaMsgProcessor) processBatchSync(ctx context.Context, batch []*kgo.R
 			continue
 		}
 
+		span := k.startPublishSpan(ctx, msg, natsMsg)
 		err = k.publisher.PublishNatsMsg(ctx, natsMsg, stream.WithUntilAck())
+		observability.EndSpan(span, err)
 		if err != nil {
 			k.log.Error("Failed to publish message to NATS",
 				slog.Any("error", err),
@@ -337,16 +429,39 @@ func (k *KafkaMsgProcessor) processBatchSync(ctx context.Context, batch []*kgo.R
 					slog.Int("partition", int(msg.Partition)))
 				return lastProcessed, fmt.Errorf("failed to publish to NATS: %w", err)
 			}
+		} else {
+			published++
 		}
 		outBytes += int64(len(natsMsg.Data))
 		lastProcessed = msg
 	}
 
 	observability.RecordBytesProcessed(ctx, "ingestor", "out", outBytes)
+	observability.RecordMessagesPublished(ctx, "ingestor", published)
 
 	return lastProcessed, nil
 }
 
+// startPublishSpan starts the nats.publish span of a record as a child of its
+// kafka.fetch span and writes the span context to the NATS message headers.
+func (k *KafkaMsgProcessor) startPublishSpan(ctx context.Context, record *kgo.Record, msg *nats.Msg) trace.Span {
+	if record.Context != nil {
+		ctx = record.Context
+	}
+
+	ctx, span := observability.StartSpan(ctx, observability.SpanNATSPublish,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("component", internal.RoleIngestor),
+		),
+	)
+	observability.InjectTraceContext(ctx, msg.Header)
+
+	return span
+}
+
 // pendingPublish pairs an in-flight PubAck future with the index of its
 // originating record in the original batch.
 type pendingPublish struct {
@@ -359,14 +474,16 @@ type pendingPublish struct {
 // driven to completion (or to a fatal/ctx-cancel cleanup) within one call.
 type asyncBatchState struct {
 	batch        []*kgo.Record
-	cachedMsgs   []*nats.Msg // index → prepared *nats.Msg (nil = not prepared yet OR DLQ-at-prepare)
-	completed    []bool      // index → true when record is acked or DLQ-accepted
-	backpressure []int       // indices waiting for retry (their cachedMsgs entry is non-nil)
-	dlqOnExit    []int       // indices to DLQ on cleanup (fatal classification)
-	cursor       int         // next batch index to publish from
-	lastAckedIdx int         // -1 means nothing acked yet
-	savedErr     error       // first non-backpressure error; triggers cleanup path
+	cachedMsgs   []*nats.Msg  // index → prepared *nats.Msg (nil = not prepared yet OR DLQ-at-prepare)
+	spans        []trace.Span // index → open nats.publish span (nil = none or ended)
+	completed    []bool       // index → true when record is acked or DLQ-accepted
+	backpressure []int        // indices waiting for retry (their cachedMsgs entry is non-nil)
+	dlqOnExit    []int        // indices to DLQ on cleanup (fatal classification)
+	cursor       int          // next batch index to publish from
+	lastAckedIdx int          // -1 means nothing acked yet
+	savedErr     error        // first non-backpressure error; triggers cleanup path
 	outBytes     int64
+	published    int64
 	retries      map[int]int // hook for a future per-record retry cap (currently uncapped)
 }
 
@@ -387,6 +504,7 @@ func (k *KafkaMsgProcessor) processBatchAsync(ctx context.Context, batch []*kgo.
 	s := &asyncBatchState{
 		batch:        batch,
 		cachedMsgs:   make([]*nats.Msg, len(batch)),
+		spans:        make([]trace.Span, len(batch)),
 		completed:    make([]bool, len(batch)),
 		cursor:       0,
 		lastAckedIdx: -1,
@@ -423,6 +541,7 @@ func (k *KafkaMsgProcessor) processBatchAsync(ctx context.Context, batch []*kgo.
 		if s.cursor == len(s.batch) && len(s.backpressure) == 0 {
 			k.bpStop(ctx)
 			observability.RecordBytesProcessed(ctx, "ingestor", "out", s.outBytes)
+			observability.RecordMessagesPublished(ctx, "ingestor", s.published)
 			return s.batch[len(s.batch)-1], nil
 		}
 
@@ -468,6 +587,7 @@ func (k *KafkaMsgProcessor) publishBackpressureSlice(ctx context.Context, s *asy
 			// to DLQ.
 			s.savedErr = err
 			s.dlqOnExit = append(s.dlqOnExit, idx)
+			s.endSpan(idx, err)
 			continue
 		}
 		futures = append(futures, pendingPublish{future: fut, idx: idx})
@@ -502,6 +622,7 @@ func (k *KafkaMsgProcessor) publishFromCursor(ctx context.Context, s *asyncBatch
 				continue
 			}
 			s.cachedMsgs[s.cursor] = prepared
+			s.spans[s.cursor] = k.startPublishSpan(ctx, rec, prepared)
 			msg = prepared
 		}
 
@@ -514,6 +635,7 @@ func (k *KafkaMsgProcessor) publishFromCursor(ctx context.Context, s *asyncBatch
 			}
 			s.savedErr = err
 			s.dlqOnExit = append(s.dlqOnExit, s.cursor)
+			s.endSpan(s.cursor, err)
 			s.cursor++
 			return futures
 		}
@@ -530,6 +652,8 @@ func (k *KafkaMsgProcessor) classifyFutures(ctx context.Context, s *asyncBatchSt
 		select {
 		case <-p.future.Ok():
 			s.completed[p.idx] = true
+			s.published++
+			s.endSpan(p.idx, nil)
 			if msg := s.cachedMsgs[p.idx]; msg != nil {
 				s.outBytes += int64(len(msg.Data))
 			}
@@ -547,10 +671,20 @@ func (k *KafkaMsgProcessor) classifyFutures(ctx context.Context, s *asyncBatchSt
 				s.savedErr = err
 			}
 			s.dlqOnExit = append(s.dlqOnExit, p.idx)
+			s.endSpan(p.idx, err)
 		}
 	}
 }
 
+// endSpan ends the open nats.publish span of the record at idx, if any.
+func (s *asyncBatchState) endSpan(idx int, err error) {
+	if s.spans[idx] == nil {
+		return
+	}
+	observability.EndSpan(s.spans[idx], err)
+	s.spans[idx] = nil
+}
+
 func (k *KafkaMsgProcessor) advanceLastAckedIdx(s *asyncBatchState) {
 	for i := s.lastAckedIdx + 1; i < len(s.batch); i++ {
 		if !s.completed[i] {
@@ -590,6 +724,11 @@ func (k *KafkaMsgProcessor) cleanupAndReturn(ctx context.Context, s *asyncBatchS
 
 	k.bpStop(cleanupCtx)
 
+	// Throttled records are either DLQ'd or re-consumed, their publish never completed
+	for idx := range s.spans {
+		s.endSpan(idx, cause)
+	}
+
 	dlqIdxs := s.dlqOnExit
 	if !isCtxErr(cause) {
 		dlqIdxs = append(dlqIdxs, s.backpressure...)
@@ -599,6 +738,7 @@ func (k *KafkaMsgProcessor) cleanupAndReturn(ctx context.Context, s *asyncBatchS
 	k.advanceLastAckedIdx(s)
 
 	observability.RecordBytesProcessed(cleanupCtx, "ingestor", "out", s.outBytes)
+	observability.RecordMessagesPublished(cleanupCtx, "ingestor", s.published)
 
 	var lastProcessed *kgo.Record
 	if s.lastAckedIdx >= 0 {
@@ -1,8 +1,10 @@
 package ingestor
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"sync"
@@ -30,7 +32,7 @@ func (fakeSchema) Validate(_ context.Context, _ []byte) (string, error) { return
 func (fakeSchema) Get(_ context.Context, _, _ string, _ []byte) (any, error) {
 	return nil, errors.New("not used")
 }
-func (fakeSchema) IsExternal() bool { return false }
+func (fakeSchema) Decode(_ context.Context, data []byte) ([]byte, error) { return data, nil }
 
 // fakeFuture mirrors jetstream.PubAckFuture with controllable Ok/Err
 // channels. Exactly one of okCh / errCh is signalled at construction time so
@@ -399,3 +401,185 @@ func TestProcessBatchAsync_BackpressureFromAckNak(t *testing.T) {
 		"NAK-driven episode must close after the retried record acks")
 	require.Equal(t, int32(0), pub.dlqCalls.Load())
 }
+
+// Without dedup, every published message carries its Kafka origin as both the
+// ingest sequence header and the JetStream Msg-Id, so republishing the same
+// record is dropped by the stream's duplicate window.
+func TestProcessBatch_SetsIngestSequenceMsgID(t *testing.T) {
+	pub := newFakePublisher("out")
+	var mu sync.Mutex
+	var published []*nats.Msg
+	pub.setPublish(func(_ int, msg *nats.Msg) (jetstream.PubAckFuture, error) {
+		mu.Lock()
+		published = append(published, msg)
+		mu.Unlock()
+		return newOkFuture(msg), nil
+	})
+	p := newProcessor(t, pub)
+
+	batch := makeBatch(3)
+	batch[2].Partition = 7
+	_, err := p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+
+	require.Len(t, published, 3)
+	require.Equal(t, "test/0/0", published[0].Header.Get(internal.IngestSequenceHeader))
+	require.Equal(t, "test/0/1", published[1].Header.Get(jetstream.MsgIDHeader))
+	require.Equal(t, "test/7/2", published[2].Header.Get(internal.IngestSequenceHeader))
+	require.Equal(t, "test/7/2", published[2].Header.Get(jetstream.MsgIDHeader))
+}
+
+// With the content hash strategy, records with the same payload share a
+// Msg-Id regardless of their offset, so the stream drops reproduced records.
+func TestProcessBatch_ContentHashMsgID(t *testing.T) {
+	pub := newFakePublisher("out")
+	var mu sync.Mutex
+	var published []*nats.Msg
+	pub.setPublish(func(_ int, msg *nats.Msg) (jetstream.PubAckFuture, error) {
+		mu.Lock()
+		published = append(published, msg)
+		mu.Unlock()
+		return newOkFuture(msg), nil
+	})
+	p, err := NewKafkaMsgProcessor(
+		"pipeline-test",
+		pub,
+		pub,
+		fakeSchema{},
+		models.KafkaTopicsConfig{
+			Name:         "test",
+			Replicas:     1,
+			PublishDedup: models.PublishDedupConfig{MsgIDStrategy: internal.MsgIDStrategyContentHash},
+		},
+		models.IngestorRuntimeConfig{
+			OutputSubject:     "out",
+			TotalSubjectCount: 1,
+		},
+		nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	require.NoError(t, err)
+
+	batch := makeBatch(3)
+	batch[2].Value = []byte(`{"k":"other"}`)
+	_, err = p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+
+	require.Len(t, published, 3)
+	require.Equal(t, "test/0/1", published[1].Header.Get(internal.IngestSequenceHeader))
+	require.Len(t, published[0].Header.Get(jetstream.MsgIDHeader), 64)
+	require.Equal(t, published[0].Header.Get(jetstream.MsgIDHeader), published[1].Header.Get(jetstream.MsgIDHeader))
+	require.NotEqual(t, published[0].Header.Get(jetstream.MsgIDHeader), published[2].Header.Get(jetstream.MsgIDHeader))
+}
+
+func TestProcessBatch_DebeziumUnwrap(t *testing.T) {
+	pub := newFakePublisher("out")
+	var mu sync.Mutex
+	var published []*nats.Msg
+	pub.setPublish(func(_ int, msg *nats.Msg) (jetstream.PubAckFuture, error) {
+		mu.Lock()
+		published = append(published, msg)
+		mu.Unlock()
+		return newOkFuture(msg), nil
+	})
+	p, err := NewKafkaMsgProcessor(
+		"pipeline-test",
+		pub,
+		pub,
+		fakeSchema{},
+		models.KafkaTopicsConfig{
+			Name:     "test",
+			Replicas: 1,
+			DebeziumUnwrap: models.DebeziumUnwrapConfig{
+				Enabled:        true,
+				OpField:        "_op",
+				TimestampField: "_ts",
+				DeleteHandling: internal.DebeziumDeleteHandlingRewrite,
+			},
+		},
+		models.IngestorRuntimeConfig{
+			OutputSubject:     "out",
+			TotalSubjectCount: 1,
+		},
+		nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	require.NoError(t, err)
+
+	batch := makeBatch(3)
+	batch[0].Value = []byte(`{"before":null,"after":{"id":1},"op":"c","ts_ms":10}`)
+	batch[1].Value = []byte(`{"before":{"id":1},"after":null,"op":"d","ts_ms":20}`)
+	batch[2].Value = nil // tombstone
+	last, err := p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Equal(t, batch[2], last)
+
+	require.Len(t, published, 2)
+	require.JSONEq(t, `{"id":1,"_op":"c","_ts":10}`, string(published[0].Data))
+	require.JSONEq(t, `{"id":1,"_op":"d","_ts":20}`, string(published[1].Data))
+}
+
+// undecodableSchema fails to decode any payload equal to bad.
+type undecodableSchema struct {
+	fakeSchema
+	bad []byte
+}
+
+func (s undecodableSchema) Decode(_ context.Context, data []byte) ([]byte, error) {
+	if bytes.Equal(data, s.bad) {
+		return nil, fmt.Errorf("%w: truncated payload", models.ErrDecodePayload)
+	}
+	return data, nil
+}
+
+func TestProcessBatch_DecodeErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		expectErr   bool
+		expectDLQ   int32
+		expectAcked int
+	}{
+		{name: "default routes to DLQ", policy: "", expectDLQ: 1, expectAcked: 2},
+		{name: "dlq routes to DLQ", policy: internal.DecodeErrorPolicyDLQ, expectDLQ: 1, expectAcked: 2},
+		{name: "fail stops on the message", policy: internal.DecodeErrorPolicyFail, expectErr: true, expectAcked: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := newFakePublisher("out")
+			pub.setPublish(func(_ int, msg *nats.Msg) (jetstream.PubAckFuture, error) {
+				return newOkFuture(msg), nil
+			})
+
+			p, err := NewKafkaMsgProcessor(
+				"pipeline-test",
+				pub,
+				pub,
+				undecodableSchema{bad: []byte("bad")},
+				models.KafkaTopicsConfig{Name: "test", Replicas: 1, DecodeErrorPolicy: tt.policy},
+				models.IngestorRuntimeConfig{
+					OutputSubject:     "out",
+					TotalSubjectCount: 1,
+				},
+				nil,
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+			)
+			require.NoError(t, err)
+
+			batch := makeBatch(3)
+			batch[1].Value = []byte("bad")
+			last, err := p.ProcessBatch(context.Background(), batch)
+
+			if tt.expectErr {
+				require.ErrorIs(t, err, models.ErrDecodePayload)
+				require.Same(t, batch[0], last)
+			} else {
+				require.NoError(t, err)
+				require.Same(t, batch[2], last)
+			}
+			require.Equal(t, tt.expectDLQ, pub.dlqCalls.Load())
+			require.Equal(t, int32(tt.expectAcked), pub.publishCalls.Load())
+		})
+	}
+}
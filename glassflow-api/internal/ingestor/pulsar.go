@@ -0,0 +1,102 @@
+package ingestor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/pulsar"
+	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+)
+
+// PulsarIngestor reads a Pulsar topic and publishes its messages to NATS
+// through the Kafka message processor, so the streams have the same layout
+// as for Kafka sources.
+type PulsarIngestor struct {
+	consumer  KafkaConsumer
+	processor kafka.MessageProcessor
+	topic     models.KafkaTopicsConfig
+	log       *slog.Logger
+}
+
+func NewPulsarIngestor(
+	config models.PipelineConfig,
+	topicName string,
+	runtimeCfg models.IngestorRuntimeConfig,
+	natsPub, dlqPub stream.Publisher,
+	schema *schemav2.Schema,
+	signalPublisher *componentsignals.ComponentSignalPublisher,
+	log *slog.Logger,
+) (*PulsarIngestor, error) {
+	var topic models.KafkaTopicsConfig
+
+	if topicName == "" {
+		return nil, fmt.Errorf("topic not found")
+	}
+
+	found := false
+	for _, t := range config.Ingestor.KafkaTopics {
+		if t.Name == topicName {
+			log.Debug("Found topic for Pulsar ingestor", slog.String("topic", t.Name), slog.String("id", t.ID))
+			topic = t
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("topic %s not found in ingestor config", topicName)
+	}
+
+	consumer, err := pulsar.NewConsumer(config.Ingestor.PulsarConnectionParams, topic, runtimeCfg.ReplicaIndex, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pulsar consumer: %w", err)
+	}
+
+	msgProcessor, err := NewKafkaMsgProcessor(
+		config.ID,
+		natsPub,
+		dlqPub,
+		schema,
+		topic,
+		runtimeCfg,
+		signalPublisher,
+		log,
+	)
+	if err != nil {
+		consumer.Close()
+		return nil, fmt.Errorf("failed to create pulsar message processor: %w", err)
+	}
+
+	return &PulsarIngestor{
+		consumer:  consumer,
+		processor: msgProcessor,
+		topic:     topic,
+		log:       log,
+	}, nil
+}
+
+// Start starts the Pulsar ingestor
+func (p *PulsarIngestor) Start(ctx context.Context) error {
+	p.log.Info("Starting Pulsar ingestor", slog.String("topic", p.topic.Name))
+
+	err := p.consumer.Start(ctx, p.processor)
+	if err != nil {
+		return fmt.Errorf("pulsar consumer failed: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the Pulsar ingestor
+func (p *PulsarIngestor) Stop() {
+	p.log.Info("Stopping Pulsar ingestor", slog.String("topic", p.topic.Name))
+	err := p.consumer.Close()
+	if err != nil {
+		p.log.Error("Failed to close pulsar consumer", slog.Any("error", err), slog.String("topic", p.topic.Name))
+	}
+}
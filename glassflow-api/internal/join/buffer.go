@@ -0,0 +1,589 @@
+package join
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kv"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// storeKeyPrefix starts the store keys of buffered events, followed by the
+// base64 encoded join key and a UUID. Buffers written by earlier versions
+// hold the right events under their join key and the left events under a
+// bare UUID, indexed by join key entries listing them.
+const storeKeyPrefix = "e.k"
+
+// matchedKeyPrefix starts the markers of events that were joined, followed
+// by their store key. Outer joins keep them so a restart does not emit
+// joined events as unmatched.
+const matchedKeyPrefix = "m."
+
+// WindowBuffer holds the events of one join source for the join window. The
+// events are stored in a JetStream KV bucket, which keeps them across
+// restarts and expires them with its TTL, while an in-memory index of the
+// store keys by join key serves the lookups. A key holds every event stored
+// within the window, or only the latest one when the buffer is latestOnly.
+//
+// With compaction, the events older than the compaction delay are moved
+// into segments holding the events of a join key in one entry.
+//
+// The buffer is not safe for concurrent use; the join component serialises
+// the stream handlers, the sweep and the eviction.
+type WindowBuffer struct {
+	store        kv.KeyValueStore
+	cache        *RecordCache
+	orientation  string
+	window       time.Duration
+	latestOnly   bool
+	compactAfter time.Duration
+	log          *slog.Logger
+	now          func() time.Time
+
+	index map[string][]*bufferEntry
+	// order holds the entries by store time, oldest first
+	order *list.List
+	// openSegments are the segments of join keys compaction appends to
+	openSegments map[string]string
+}
+
+type BufferOption func(*WindowBuffer)
+
+type bufferEntry struct {
+	key      string
+	storeKey string
+	storedAt time.Time
+	// indexed is false for events of the legacy left layout whose index
+	// entry is gone, they are only reachable by the unmatched sweep
+	indexed bool
+	// matched is set once the event was joined, outer joins only emit the
+	// events that were not
+	matched bool
+	// segment is the key of the segment holding the event once compacted
+	segment string
+	elem    *list.Element
+}
+
+// BufferedEvent is an event read from a WindowBuffer.
+type BufferedEvent struct {
+	Key             string
+	SchemaVersionID string
+	Data            []byte
+	StoredAt        time.Time
+
+	entry *bufferEntry
+}
+
+// NewWindowBuffer creates a buffer of the events stored in store for window.
+// cache, when not nil, keeps the data of the events this buffer stored so
+// lookups do not read them back from the store.
+func NewWindowBuffer(
+	store kv.KeyValueStore,
+	cache *RecordCache,
+	orientation string,
+	window time.Duration,
+	latestOnly bool,
+	log *slog.Logger,
+	opts ...BufferOption,
+) *WindowBuffer {
+	b := &WindowBuffer{
+		store:        store,
+		cache:        cache,
+		orientation:  orientation,
+		window:       window,
+		latestOnly:   latestOnly,
+		log:          log,
+		now:          time.Now,
+		index:        make(map[string][]*bufferEntry),
+		order:        list.New(),
+		openSegments: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Len returns the number of buffered events.
+func (b *WindowBuffer) Len() int {
+	return b.order.Len()
+}
+
+// Add stores the event under its join key. A latestOnly buffer drops the
+// older events of the key, they are counted as replaced.
+func (b *WindowBuffer) Add(ctx context.Context, key any, schemaVersionID string, data []byte) error {
+	return b.add(ctx, key, schemaVersionID, data, false)
+}
+
+// AddMatched stores an event that was joined on arrival, like Add, and marks
+// it matched.
+func (b *WindowBuffer) AddMatched(ctx context.Context, key any, schemaVersionID string, data []byte) error {
+	return b.add(ctx, key, schemaVersionID, data, true)
+}
+
+func (b *WindowBuffer) add(ctx context.Context, key any, schemaVersionID string, data []byte, matched bool) error {
+	k := cacheKey(key)
+	storeKey := newStoreKey(k)
+
+	err := b.store.PutMessage(ctx, storeKey, schemaVersionID, data)
+	if err != nil {
+		return fmt.Errorf("failed to store %s buffer event: %w", b.orientation, err)
+	}
+	if matched {
+		err = b.store.PutString(ctx, matchedKeyPrefix+storeKey, "")
+		if err != nil {
+			return fmt.Errorf("failed to mark %s buffer event matched: %w", b.orientation, err)
+		}
+	}
+
+	if b.latestOnly {
+		replaced := b.index[k]
+		for _, old := range replaced {
+			b.remove(old)
+			err = b.deleteStored(ctx, old)
+			if err != nil {
+				b.log.WarnContext(ctx, "failed to delete replaced join buffer event, it expires with the buffer TTL",
+					"orientation", b.orientation, "store_key", old.storeKey, "error", err)
+			}
+		}
+		if len(replaced) > 0 {
+			observability.RecordJoinBufferEvictions(ctx, b.orientation, observability.JoinBufferEvictionReplaced, int64(len(replaced)))
+		}
+	}
+
+	entry := &bufferEntry{key: k, storeKey: storeKey, storedAt: b.now(), indexed: true, matched: matched}
+	entry.elem = b.order.PushBack(entry)
+	b.index[k] = append(b.index[k], entry)
+
+	if b.cache != nil {
+		b.cache.Put(storeKey, schemaVersionID, data)
+		observability.RecordJoinCacheBytes(ctx, b.cache.Size())
+	}
+
+	return nil
+}
+
+// Get returns the events of the join key stored within the window, oldest
+// first. Events the store no longer holds are dropped from the index.
+func (b *WindowBuffer) Get(ctx context.Context, key any) ([]BufferedEvent, error) {
+	entries := b.index[cacheKey(key)]
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	cutoff := b.now().Add(-b.window)
+	events := make([]BufferedEvent, 0, len(entries))
+	reads := make(segmentReads)
+	var gone []*bufferEntry
+	for _, entry := range entries {
+		if !entry.storedAt.After(cutoff) {
+			continue
+		}
+
+		ev, err := b.read(ctx, entry, reads)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				gone = append(gone, entry)
+				continue
+			}
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	for _, entry := range gone {
+		b.remove(entry)
+	}
+
+	return events, nil
+}
+
+// Remove deletes a joined or routed event from the buffer.
+func (b *WindowBuffer) Remove(ctx context.Context, ev BufferedEvent) error {
+	err := b.deleteStored(ctx, ev.entry)
+	if err != nil {
+		return err
+	}
+
+	b.remove(ev.entry)
+	return nil
+}
+
+// deleteStored deletes the stored event of an entry, from its segment once
+// it was compacted.
+func (b *WindowBuffer) deleteStored(ctx context.Context, entry *bufferEntry) error {
+	if entry.segment != "" {
+		return b.removeFromSegment(ctx, entry)
+	}
+
+	err := b.store.Delete(ctx, entry.storeKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s buffer event %s: %w", b.orientation, entry.storeKey, err)
+	}
+	return nil
+}
+
+// MarkMatched records that the event was joined, marking an event twice is
+// a no-op. Events of the legacy layout are only marked in memory.
+func (b *WindowBuffer) MarkMatched(ctx context.Context, ev BufferedEvent) error {
+	if ev.entry.matched {
+		return nil
+	}
+	if !isStoreKey(ev.entry.storeKey) {
+		ev.entry.matched = true
+		return nil
+	}
+
+	err := b.store.PutString(ctx, matchedKeyPrefix+ev.entry.storeKey, "")
+	if err != nil {
+		return fmt.Errorf("failed to mark %s buffer event %s matched: %w", b.orientation, ev.entry.storeKey, err)
+	}
+	ev.entry.matched = true
+
+	return nil
+}
+
+// Expired returns the events stored for longer than olderThan, oldest first.
+func (b *WindowBuffer) Expired(ctx context.Context, olderThan time.Duration) ([]BufferedEvent, error) {
+	return b.expired(ctx, olderThan, false)
+}
+
+// Unmatched returns the events stored for longer than olderThan that were
+// never joined, oldest first. The matched ones are dropped from the index
+// like evicted events, they cannot be joined anymore.
+func (b *WindowBuffer) Unmatched(ctx context.Context, olderThan time.Duration) ([]BufferedEvent, error) {
+	return b.expired(ctx, olderThan, true)
+}
+
+func (b *WindowBuffer) expired(ctx context.Context, olderThan time.Duration, unmatchedOnly bool) ([]BufferedEvent, error) {
+	cutoff := b.now().Add(-olderThan)
+
+	var (
+		events        []BufferedEvent
+		gone, matched []*bufferEntry
+		reads         = make(segmentReads)
+	)
+	for el := b.order.Front(); el != nil; el = el.Next() {
+		entry := el.Value.(*bufferEntry) //nolint:forcetypeassert // only entries are stored
+		if entry.storedAt.After(cutoff) {
+			break
+		}
+		if unmatchedOnly && entry.matched {
+			matched = append(matched, entry)
+			continue
+		}
+
+		ev, err := b.read(ctx, entry, reads)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				gone = append(gone, entry)
+				continue
+			}
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	for _, entry := range gone {
+		b.remove(entry)
+	}
+	for _, entry := range matched {
+		b.remove(entry)
+	}
+	if len(matched) > 0 {
+		observability.RecordJoinBufferEvictions(ctx, b.orientation, observability.JoinBufferEvictionExpired, int64(len(matched)))
+	}
+
+	return events, nil
+}
+
+// Evict drops the events older than the window from the index, they were
+// not joined in time. Their stored copy expires with the bucket TTL.
+func (b *WindowBuffer) Evict(ctx context.Context) {
+	cutoff := b.now().Add(-b.window)
+
+	var evicted int64
+	for el := b.order.Front(); el != nil; el = b.order.Front() {
+		entry := el.Value.(*bufferEntry) //nolint:forcetypeassert // only entries are stored
+		if entry.storedAt.After(cutoff) {
+			break
+		}
+		b.remove(entry)
+		evicted++
+	}
+
+	if evicted > 0 {
+		observability.RecordJoinBufferEvictions(ctx, b.orientation, observability.JoinBufferEvictionExpired, evicted)
+		if b.cache != nil {
+			observability.RecordJoinCacheBytes(ctx, b.cache.Size())
+		}
+	}
+	observability.RecordJoinBufferEvents(ctx, b.orientation, int64(b.order.Len()))
+}
+
+// Load rebuilds the index from the events in the store, including the ones
+// written by earlier versions. It must be called before the buffer is used.
+func (b *WindowBuffer) Load(ctx context.Context) error {
+	return b.load(ctx, nil)
+}
+
+// load rebuilds the index, the events of checkpointed are indexed without
+// reading them from the store. Segments are always read, compaction may
+// have moved events into them after the checkpoint.
+func (b *WindowBuffer) load(ctx context.Context, checkpointed map[string]CheckpointEntry) error {
+	clear(b.index)
+	clear(b.openSegments)
+	b.order.Init()
+
+	storeKeys, err := b.store.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list %s buffer keys: %w", b.orientation, err)
+	}
+
+	entries, compacted, err := b.loadSegments(ctx, storeKeys)
+	if err != nil {
+		return err
+	}
+
+	var (
+		// legacyKeys maps the UUID keys of legacy left events to their join key
+		legacyKeys = make(map[string]string)
+		matched    = make(map[string]struct{})
+		restored   int
+	)
+	for _, storeKey := range storeKeys {
+		if eventKey, ok := strings.CutPrefix(storeKey, matchedKeyPrefix); ok && isStoreKey(eventKey) {
+			matched[eventKey] = struct{}{}
+			continue
+		}
+		if _, ok := parseSegmentKey(storeKey); ok {
+			continue
+		}
+		// A compaction stopped before it deleted the events it moved
+		if _, ok := compacted[storeKey]; ok {
+			err = b.store.Delete(ctx, storeKey)
+			if err != nil {
+				b.log.WarnContext(ctx, "failed to delete compacted join buffer event, it expires with the buffer TTL",
+					"orientation", b.orientation, "store_key", storeKey, "error", err)
+			}
+			continue
+		}
+
+		if known, ok := checkpointed[storeKey]; ok {
+			entries = append(entries, &bufferEntry{
+				key:      known.Key,
+				storeKey: storeKey,
+				storedAt: known.StoredAt,
+				indexed:  known.Indexed,
+				matched:  known.Matched,
+			})
+			restored++
+			continue
+		}
+
+		_, _, created, err := b.store.GetMessageCreated(ctx, storeKey)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+			if !errors.Is(err, kv.ErrNotMessage) {
+				return fmt.Errorf("failed to get %s buffer event %s: %w", b.orientation, storeKey, err)
+			}
+
+			// Legacy left index entries are left to expire with the bucket TTL
+			uuids, err := b.store.GetString(ctx, storeKey)
+			if err != nil {
+				if errors.Is(err, jetstream.ErrKeyNotFound) {
+					continue
+				}
+				return fmt.Errorf("failed to get %s buffer index %s: %w", b.orientation, storeKey, err)
+			}
+			for _, id := range strings.Fields(uuids) {
+				legacyKeys[id] = storeKey
+			}
+			continue
+		}
+
+		entry := &bufferEntry{storeKey: storeKey, storedAt: created}
+		if key, ok := parseStoreKey(storeKey); ok {
+			entry.key, entry.indexed = key, true
+		} else if b.orientation == internal.JoinRight {
+			entry.key, entry.indexed = storeKey, true
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortStableFunc(entries, func(x, y *bufferEntry) int {
+		return x.storedAt.Compare(y.storedAt)
+	})
+
+	for _, entry := range entries {
+		if key, ok := legacyKeys[entry.storeKey]; ok && !entry.indexed {
+			entry.key, entry.indexed = key, true
+		}
+		if _, ok := matched[entry.storeKey]; ok {
+			entry.matched = true
+		}
+
+		if entry.indexed {
+			if b.latestOnly {
+				for _, old := range b.index[entry.key] {
+					b.order.Remove(old.elem)
+				}
+				b.index[entry.key] = nil
+			}
+			b.index[entry.key] = append(b.index[entry.key], entry)
+		}
+		entry.elem = b.order.PushBack(entry)
+	}
+
+	b.log.InfoContext(ctx, "loaded join buffer", "orientation", b.orientation, "events", b.order.Len(),
+		"from_checkpoint", restored, "compacted", len(compacted))
+	b.Evict(ctx)
+
+	return nil
+}
+
+// loadSegments returns the entries of the compacted events by store key and
+// sets the segments of the keys compaction appends to.
+func (b *WindowBuffer) loadSegments(ctx context.Context, storeKeys []string) ([]*bufferEntry, map[string]struct{}, error) {
+	var (
+		entries   []*bufferEntry
+		compacted = make(map[string]struct{})
+	)
+	for _, segKey := range storeKeys {
+		key, ok := parseSegmentKey(segKey)
+		if !ok {
+			continue
+		}
+
+		seg, err := b.readSegment(ctx, segKey, nil)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		for _, ev := range seg.Events {
+			entries = append(entries, &bufferEntry{
+				key:      key,
+				storeKey: ev.StoreKey,
+				storedAt: ev.StoredAt,
+				indexed:  true,
+				segment:  segKey,
+			})
+			compacted[ev.StoreKey] = struct{}{}
+		}
+		if seg.size() < internal.JoinSegmentMaxBytes {
+			b.openSegments[key] = segKey
+		}
+	}
+	return entries, compacted, nil
+}
+
+// read returns the event of an entry, from the cache when it holds it.
+// Segments read during a call are kept in reads when it is not nil.
+func (b *WindowBuffer) read(ctx context.Context, entry *bufferEntry, reads segmentReads) (BufferedEvent, error) {
+	ev := BufferedEvent{Key: entry.key, StoredAt: entry.storedAt, entry: entry}
+
+	if b.cache != nil {
+		if schemaVersionID, data, ok := b.cache.Get(entry.storeKey); ok {
+			observability.RecordJoinCacheLookup(ctx, observability.JoinCacheResultHit)
+			ev.SchemaVersionID, ev.Data = schemaVersionID, data
+			return ev, nil
+		}
+		observability.RecordJoinCacheLookup(ctx, observability.JoinCacheResultMiss)
+	}
+
+	if entry.segment != "" {
+		event, err := b.readFromSegment(ctx, entry, reads)
+		if err != nil {
+			return ev, err
+		}
+		ev.SchemaVersionID, ev.Data = event.SchemaVersionID, event.Data
+		return ev, nil
+	}
+
+	schemaVersionID, data, err := b.store.GetMessage(ctx, entry.storeKey)
+	if err != nil {
+		return ev, fmt.Errorf("failed to get %s buffer event %s: %w", b.orientation, entry.storeKey, err)
+	}
+	ev.SchemaVersionID, ev.Data = schemaVersionID, data
+
+	return ev, nil
+}
+
+// remove drops an entry from the index and the cache, removing an entry
+// twice is a no-op.
+func (b *WindowBuffer) remove(entry *bufferEntry) {
+	if entry.elem == nil {
+		return
+	}
+	b.order.Remove(entry.elem)
+	entry.elem = nil
+
+	if entry.indexed {
+		entries := slices.DeleteFunc(b.index[entry.key], func(e *bufferEntry) bool { return e == entry })
+		if len(entries) == 0 {
+			delete(b.index, entry.key)
+		} else {
+			b.index[entry.key] = entries
+		}
+	}
+
+	if b.cache != nil {
+		b.cache.Delete(entry.storeKey)
+	}
+}
+
+// newStoreKey returns a new store key for an event of the join key. The join
+// key is encoded as it may hold characters KV keys do not allow.
+func newStoreKey(key string) string {
+	return storeKeyPrefix + base64.RawURLEncoding.EncodeToString([]byte(key)) + "." + uuid.NewString()
+}
+
+// parseStoreKey returns the join key of a store key made by newStoreKey.
+func parseStoreKey(storeKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(storeKey, storeKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	return parseEncodedKey(rest)
+}
+
+// parseEncodedKey returns the join key of the base64 encoded join key and
+// UUID following the prefix of a store or segment key.
+func parseEncodedKey(rest string) (string, bool) {
+	encoded, id, ok := strings.Cut(rest, ".")
+	if !ok {
+		return "", false
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", false
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+
+	return string(key), true
+}
+
+// isStoreKey reports whether storeKey was made by newStoreKey.
+func isStoreKey(storeKey string) bool {
+	_, ok := parseStoreKey(storeKey)
+	return ok
+}
@@ -0,0 +1,210 @@
+package join
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func eventData(events []BufferedEvent) []string {
+	data := make([]string, 0, len(events))
+	for _, ev := range events {
+		data = append(data, string(ev.Data))
+	}
+	return data
+}
+
+func TestWindowBuffer_MultipleEventsPerKey(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+	b := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":1}`)))
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":2}`)))
+	require.NoError(t, b.Add(ctx, 42, "1", []byte(`{"order":3}`)))
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":1}`, `{"order":2}`}, eventData(events))
+
+	// Keys are formatted like the KV store keys
+	events, err = b.Get(ctx, "42")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":3}`}, eventData(events))
+
+	events, err = b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, b.Remove(ctx, events[0]))
+	assert.Len(t, store.entries, 2)
+	assert.Equal(t, 2, b.Len())
+
+	events, err = b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":2}`}, eventData(events))
+}
+
+func TestWindowBuffer_LatestOnlyReplaces(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+	b := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, true, slog.Default())
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+	require.NoError(t, b.Add(ctx, "user-1", "2", []byte(`{"name":"b"}`)))
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "2", events[0].SchemaVersionID)
+	assert.Equal(t, `{"name":"b"}`, string(events[0].Data))
+	assert.Len(t, store.entries, 1, "replaced events are deleted from the store")
+	assert.Equal(t, 1, b.Len())
+}
+
+func TestWindowBuffer_EvictsEventsOutsideWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	b := NewWindowBuffer(newMemoryKVStore(), nil, internal.JoinLeft, time.Minute, false, slog.Default())
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":1}`)))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":2}`)))
+	now = now.Add(45 * time.Second)
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":2}`}, eventData(events))
+
+	expired, err := b.Expired(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":1}`}, eventData(expired))
+
+	b.Evict(ctx)
+	assert.Equal(t, 1, b.Len())
+}
+
+func TestWindowBuffer_DropsEventsGoneFromStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+	b := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{}`)))
+	clear(store.entries)
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestWindowBuffer_ReadsCachedEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+	cache := NewRecordCache(1<<20, time.Hour)
+	b := NewWindowBuffer(store, cache, internal.JoinRight, time.Hour, true, slog.Default())
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+	for key, e := range store.entries {
+		e.value = `{"name":"stale"}`
+		store.entries[key] = e
+	}
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"name":"a"}`}, eventData(events))
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"b"}`)))
+	assert.Less(t, cache.Size(), int64(2*recordCacheEntryOverhead), "replaced events leave the cache")
+}
+
+func TestWindowBuffer_Load(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	written := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	require.NoError(t, written.Add(ctx, "user 1", "1", []byte(`{"order":1}`)))
+	require.NoError(t, written.Add(ctx, "user 1", "1", []byte(`{"order":2}`)))
+
+	// Left events of the legacy layout, indexed by join key
+	legacy := uuid.NewString()
+	require.NoError(t, store.PutMessage(ctx, legacy, "1", []byte(`{"order":0}`)))
+	require.NoError(t, store.PutString(ctx, "user-2", legacy))
+	e := store.entries[legacy]
+	e.created = time.Now().Add(-time.Minute)
+	store.entries[legacy] = e
+
+	b := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	require.NoError(t, b.Load(ctx))
+	assert.Equal(t, 3, b.Len())
+
+	events, err := b.Get(ctx, "user 1")
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	events, err = b.Get(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":0}`}, eventData(events))
+}
+
+func TestWindowBuffer_LoadLegacyRightLayout(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	// The legacy right layout stores the last event under the join key
+	require.NoError(t, store.PutMessage(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+
+	b := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, true, slog.Default())
+	require.NoError(t, b.Load(ctx))
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"name":"a"}`}, eventData(events))
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"b"}`)))
+	assert.NotContains(t, store.entries, "user-1", "the legacy event is replaced")
+}
+
+func TestStoreKey(t *testing.T) {
+	for _, key := range []string{"", "user-1", "a b.c*>"} {
+		storeKey := newStoreKey(key)
+		assert.NotContains(t, storeKey, " ")
+		got, ok := parseStoreKey(storeKey)
+		require.True(t, ok)
+		assert.Equal(t, key, got)
+	}
+
+	_, ok := parseStoreKey(uuid.NewString())
+	assert.False(t, ok)
+	_, ok = parseStoreKey("user-1")
+	assert.False(t, ok)
+}
+
+func TestWindowBuffer_MatchedSurvivesLoad(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	written := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, false, slog.Default())
+	require.NoError(t, written.Add(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+	require.NoError(t, written.Add(ctx, "user-2", "1", []byte(`{"name":"b"}`)))
+	events, err := written.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, written.MarkMatched(ctx, events[0]))
+	require.NoError(t, written.MarkMatched(ctx, events[0]))
+	assert.Len(t, store.entries, 3)
+
+	b := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, false, slog.Default())
+	require.NoError(t, b.Load(ctx))
+	assert.Equal(t, 2, b.Len(), "markers are not loaded as events")
+
+	unmatched, err := b.Unmatched(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"name":"b"}`}, eventData(unmatched))
+	assert.Equal(t, 1, b.Len(), "expired matched events are dropped")
+}
@@ -0,0 +1,116 @@
+package join
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+)
+
+// recordCacheEntryOverhead approximates the memory of an entry besides its
+// key and data: the list element, the map slot and the entry itself.
+const recordCacheEntryOverhead = 128
+
+// RecordCache is a size bounded LRU cache of the records stored in the right
+// buffer. Entries expire with the TTL of the buffer, so a cached record is
+// never joined after the buffer itself has dropped it.
+//
+// The cache is not safe for concurrent use; the join component serialises
+// the stream handlers.
+type RecordCache struct {
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List
+	now      func() time.Time
+}
+
+type recordCacheEntry struct {
+	key             string
+	schemaVersionID string
+	data            []byte
+	expiresAt       time.Time
+}
+
+func (e *recordCacheEntry) size() int64 {
+	return int64(len(e.key)+len(e.schemaVersionID)+len(e.data)) + recordCacheEntryOverhead
+}
+
+// NewRecordCache creates a cache holding up to maxBytes of records for the
+// given TTL, a zero TTL keeps records until they are evicted.
+func NewRecordCache(maxBytes int64, ttl time.Duration) *RecordCache {
+	return &RecordCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached record of the key.
+func (c *RecordCache) Get(key any) (schemaVersionID string, data []byte, ok bool) {
+	el, found := c.entries[cacheKey(key)]
+	if !found {
+		return "", nil, false
+	}
+
+	entry := el.Value.(*recordCacheEntry) //nolint:forcetypeassert // only entries are stored
+	if c.ttl > 0 && !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return "", nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return entry.schemaVersionID, entry.data, true
+}
+
+// Put stores the record of the key, replacing an older one, and evicts the
+// least recently used records beyond the size bound.
+func (c *RecordCache) Put(key any, schemaVersionID string, data []byte) {
+	k := cacheKey(key)
+	if el, found := c.entries[k]; found {
+		c.remove(el)
+	}
+
+	entry := &recordCacheEntry{
+		key:             k,
+		schemaVersionID: schemaVersionID,
+		data:            data,
+		expiresAt:       c.now().Add(c.ttl),
+	}
+	if entry.size() > c.maxBytes {
+		return
+	}
+
+	c.entries[k] = c.lru.PushFront(entry)
+	c.size += entry.size()
+
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Delete drops the record of the key.
+func (c *RecordCache) Delete(key any) {
+	if el, found := c.entries[cacheKey(key)]; found {
+		c.remove(el)
+	}
+}
+
+// Size returns the approximate memory held by the cached records.
+func (c *RecordCache) Size() int64 {
+	return c.size
+}
+
+func (c *RecordCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*recordCacheEntry) //nolint:forcetypeassert // only entries are stored
+	delete(c.entries, entry.key)
+	c.size -= entry.size()
+}
+
+// cacheKey formats join keys like the KV store does, so keys that share a
+// KV entry share a cache entry.
+func cacheKey(key any) string {
+	return fmt.Sprintf("%v", key)
+}
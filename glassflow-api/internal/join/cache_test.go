@@ -0,0 +1,79 @@
+package join
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordCache_GetPut(t *testing.T) {
+	c := NewRecordCache(1<<20, time.Hour)
+
+	_, _, ok := c.Get("user-1")
+	assert.False(t, ok)
+
+	c.Put("user-1", "1", []byte(`{"name":"a"}`))
+	c.Put(42, "2", []byte(`{"name":"b"}`))
+
+	version, data, ok := c.Get("user-1")
+	require.True(t, ok)
+	assert.Equal(t, "1", version)
+	assert.Equal(t, `{"name":"a"}`, string(data))
+
+	// Keys are formatted like the KV store keys
+	version, _, ok = c.Get("42")
+	require.True(t, ok)
+	assert.Equal(t, "2", version)
+
+	c.Put("user-1", "3", []byte(`{"name":"c"}`))
+	version, data, ok = c.Get("user-1")
+	require.True(t, ok)
+	assert.Equal(t, "3", version)
+	assert.Equal(t, `{"name":"c"}`, string(data))
+}
+
+func TestRecordCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	data := make([]byte, 100)
+	entrySize := int64(len("k1")+len("1")+len(data)) + recordCacheEntryOverhead
+	c := NewRecordCache(2*entrySize, time.Hour)
+
+	c.Put("k1", "1", data)
+	c.Put("k2", "1", data)
+	_, _, ok := c.Get("k1")
+	require.True(t, ok)
+
+	c.Put("k3", "1", data)
+
+	_, _, ok = c.Get("k2")
+	assert.False(t, ok, "k2 was the least recently used entry")
+	_, _, ok = c.Get("k1")
+	assert.True(t, ok)
+	_, _, ok = c.Get("k3")
+	assert.True(t, ok)
+	assert.Equal(t, 2*entrySize, c.Size())
+
+	// Records larger than the cache are not cached
+	c.Put("k4", "1", make([]byte, 3*entrySize))
+	_, _, ok = c.Get("k4")
+	assert.False(t, ok)
+	assert.Equal(t, 2*entrySize, c.Size())
+}
+
+func TestRecordCache_Expiry(t *testing.T) {
+	now := time.Now()
+	c := NewRecordCache(1<<20, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Put("k1", "1", []byte("{}"))
+
+	now = now.Add(59 * time.Second)
+	_, _, ok := c.Get("k1")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, _, ok = c.Get("k1")
+	assert.False(t, ok)
+	assert.Zero(t, c.Size())
+}
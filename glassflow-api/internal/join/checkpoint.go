@@ -0,0 +1,200 @@
+package join
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// Checkpoint is the progress of a join stage. It lets a restarted stage
+// index its buffers without reading every buffered event back, and skip the
+// redelivered events it had already handled.
+type Checkpoint struct {
+	TakenAt time.Time      `json:"taken_at"`
+	Left    SideCheckpoint `json:"left"`
+	Right   SideCheckpoint `json:"right"`
+}
+
+// SideCheckpoint is the progress of one join source. The positions only
+// apply to the consumer created at ConsumerCreated, a consumer created again
+// reads a stream whose sequences start over.
+type SideCheckpoint struct {
+	ConsumerCreated time.Time `json:"consumer_created"`
+	// HandledSeq is the highest stream sequence handled, Pending the lower
+	// sequences that were delivered but not handled
+	HandledSeq uint64            `json:"handled_seq"`
+	Pending    []uint64          `json:"pending,omitempty"`
+	Entries    []CheckpointEntry `json:"entries"`
+}
+
+// CheckpointEntry is an event of a buffer index.
+type CheckpointEntry struct {
+	StoreKey string    `json:"store_key"`
+	Key      string    `json:"key,omitempty"`
+	StoredAt time.Time `json:"stored_at"`
+	Indexed  bool      `json:"indexed,omitempty"`
+	Matched  bool      `json:"matched,omitempty"`
+}
+
+var ErrCheckpointNotFound = errors.New("join checkpoint not found")
+
+// CheckpointStore keeps the checkpoints of join stages by name, a checkpoint
+// replacing the previous one of the same name.
+type CheckpointStore interface {
+	Save(ctx context.Context, name string, cp Checkpoint) error
+	Load(ctx context.Context, name string) (Checkpoint, error)
+}
+
+// NATSCheckpointStore keeps the checkpoints in a NATS object store, which
+// holds the index of long windows that exceed a message.
+type NATSCheckpointStore struct {
+	store jetstream.ObjectStore
+}
+
+func NewNATSCheckpointStore(store jetstream.ObjectStore) *NATSCheckpointStore {
+	return &NATSCheckpointStore{store: store}
+}
+
+func (s *NATSCheckpointStore) Save(ctx context.Context, name string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal join checkpoint: %w", err)
+	}
+
+	_, err = s.store.PutBytes(ctx, name, data)
+	if err != nil {
+		return fmt.Errorf("put object %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *NATSCheckpointStore) Load(ctx context.Context, name string) (Checkpoint, error) {
+	data, err := s.store.GetBytes(ctx, name)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrObjectNotFound) {
+			return Checkpoint{}, ErrCheckpointNotFound
+		}
+		return Checkpoint{}, fmt.Errorf("get object %s: %w", name, err)
+	}
+
+	var cp Checkpoint
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("unmarshal join checkpoint %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// Checkpoint returns the index of the buffer, oldest first. Compacted events
+// are left out, they are indexed from their segments.
+func (b *WindowBuffer) Checkpoint() []CheckpointEntry {
+	entries := make([]CheckpointEntry, 0, b.order.Len())
+	for el := b.order.Front(); el != nil; el = el.Next() {
+		entry := el.Value.(*bufferEntry) //nolint:forcetypeassert // only entries are stored
+		if entry.segment != "" {
+			continue
+		}
+		entries = append(entries, CheckpointEntry{
+			StoreKey: entry.storeKey,
+			Key:      entry.key,
+			StoredAt: entry.storedAt,
+			Indexed:  entry.indexed,
+			Matched:  entry.matched,
+		})
+	}
+	return entries
+}
+
+// Restore rebuilds the index like Load, reading from the store only the
+// events missing from the checkpointed entries. Entries whose event is no
+// longer stored are dropped.
+func (b *WindowBuffer) Restore(ctx context.Context, entries []CheckpointEntry) error {
+	checkpointed := make(map[string]CheckpointEntry, len(entries))
+	for _, entry := range entries {
+		checkpointed[entry.StoreKey] = entry
+	}
+	return b.load(ctx, checkpointed)
+}
+
+// ConsumerProgress tracks the stream sequences a join source delivered and
+// handled. It is not safe for concurrent use, like the buffers.
+type ConsumerProgress struct {
+	consumerCreated time.Time
+	handled         uint64
+	pending         map[uint64]struct{}
+
+	// restored are the positions of the checkpoint, the events at or below
+	// restoredHandled and not restoredPending were handled before a restart
+	restoredHandled uint64
+	restoredPending map[uint64]struct{}
+}
+
+// NewConsumerProgress starts tracking the consumer created at created, from
+// the checkpoint of its source when it was taken on the same consumer.
+func NewConsumerProgress(created time.Time, cp SideCheckpoint) *ConsumerProgress {
+	p := &ConsumerProgress{
+		consumerCreated: created,
+		pending:         make(map[uint64]struct{}),
+		restoredPending: make(map[uint64]struct{}),
+	}
+	if !cp.ConsumerCreated.Equal(created) {
+		return p
+	}
+
+	p.handled = cp.HandledSeq
+	p.restoredHandled = cp.HandledSeq
+	for _, seq := range cp.Pending {
+		p.pending[seq] = struct{}{}
+		p.restoredPending[seq] = struct{}{}
+	}
+	return p
+}
+
+// AlreadyHandled reports whether a redelivered event was handled before the
+// checkpoint the progress was restored from.
+func (p *ConsumerProgress) AlreadyHandled(seq uint64) bool {
+	if seq > p.restoredHandled {
+		return false
+	}
+	_, pending := p.restoredPending[seq]
+	return !pending
+}
+
+// Delivered records an event that is not handled yet.
+func (p *ConsumerProgress) Delivered(seq uint64) {
+	p.pending[seq] = struct{}{}
+}
+
+// Handled records a handled event.
+func (p *ConsumerProgress) Handled(seq uint64) {
+	delete(p.pending, seq)
+	delete(p.restoredPending, seq)
+	p.handled = max(p.handled, seq)
+}
+
+// Checkpoint returns the positions of the progress. Only the pending events
+// below the highest handled one are kept, the others are not skipped
+// anyway, up to internal.JoinCheckpointMaxPending of the most recent ones.
+func (p *ConsumerProgress) Checkpoint(entries []CheckpointEntry) SideCheckpoint {
+	pending := slices.Sorted(maps.Keys(p.pending))
+	cut, _ := slices.BinarySearch(pending, p.handled)
+	pending = pending[:cut]
+	if len(pending) > internal.JoinCheckpointMaxPending {
+		pending = pending[len(pending)-internal.JoinCheckpointMaxPending:]
+	}
+
+	return SideCheckpoint{
+		ConsumerCreated: p.consumerCreated,
+		HandledSeq:      p.handled,
+		Pending:         pending,
+		Entries:         entries,
+	}
+}
@@ -0,0 +1,101 @@
+package join
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// countingKVStore counts the events read back from the store.
+type countingKVStore struct {
+	*memoryKVStore
+	reads int
+}
+
+func (s *countingKVStore) GetMessageCreated(ctx context.Context, key any) (string, []byte, time.Time, error) {
+	s.reads++
+	return s.memoryKVStore.GetMessageCreated(ctx, key)
+}
+
+func TestWindowBuffer_RestoreReadsOnlyNewEvents(t *testing.T) {
+	ctx := context.Background()
+	store := &countingKVStore{memoryKVStore: newMemoryKVStore()}
+
+	b := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":1}`)))
+	require.NoError(t, b.Add(ctx, "user-2", "1", []byte(`{"order":2}`)))
+	entries := b.Checkpoint()
+	require.Len(t, entries, 2)
+
+	// stored after the checkpoint was taken
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":3}`)))
+
+	restored := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	store.reads = 0
+	require.NoError(t, restored.Restore(ctx, entries))
+	assert.Equal(t, 1, store.reads, "only the event missing from the checkpoint is read")
+	assert.Equal(t, 3, restored.Len())
+
+	events, err := restored.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":1}`, `{"order":3}`}, eventData(events))
+}
+
+func TestWindowBuffer_RestoreDropsEventsGoneFromStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	b := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, false, slog.Default())
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+	require.NoError(t, b.Add(ctx, "user-2", "1", []byte(`{"name":"b"}`)))
+	entries := b.Checkpoint()
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, b.Remove(ctx, events[0]))
+
+	restored := NewWindowBuffer(store, nil, internal.JoinRight, time.Hour, false, slog.Default())
+	require.NoError(t, restored.Restore(ctx, entries))
+	assert.Equal(t, 1, restored.Len())
+}
+
+func TestConsumerProgress_SkipsHandledEvents(t *testing.T) {
+	created := time.Now()
+
+	p := NewConsumerProgress(created, SideCheckpoint{})
+	for seq := uint64(1); seq <= 5; seq++ {
+		assert.False(t, p.AlreadyHandled(seq))
+		p.Delivered(seq)
+	}
+	p.Handled(1)
+	p.Handled(2)
+	p.Handled(4)
+
+	cp := p.Checkpoint(nil)
+	assert.Equal(t, uint64(4), cp.HandledSeq)
+	assert.Equal(t, []uint64{3}, cp.Pending, "only pending events below the handled ones are kept")
+
+	restored := NewConsumerProgress(created, cp)
+	assert.True(t, restored.AlreadyHandled(1))
+	assert.True(t, restored.AlreadyHandled(4))
+	assert.False(t, restored.AlreadyHandled(3), "a pending event is handled again")
+	assert.False(t, restored.AlreadyHandled(5))
+
+	restored.Delivered(3)
+	restored.Handled(3)
+	assert.Empty(t, restored.Checkpoint(nil).Pending)
+}
+
+func TestConsumerProgress_IgnoresCheckpointOfOtherConsumer(t *testing.T) {
+	cp := SideCheckpoint{ConsumerCreated: time.Now().Add(-time.Hour), HandledSeq: 10}
+
+	p := NewConsumerProgress(time.Now(), cp)
+	assert.False(t, p.AlreadyHandled(3), "a recreated consumer reads the stream again")
+	assert.Equal(t, uint64(0), p.Checkpoint(nil).HandledSeq)
+}
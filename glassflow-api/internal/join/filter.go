@@ -0,0 +1,74 @@
+package join
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+type filter interface {
+	Matches([]byte) (bool, error)
+}
+
+// FilteredPublisher publishes the joined events that match the filter of the
+// pipeline, its expression reads the output fields of the join. Events the
+// filter fails to evaluate are published to the DLQ.
+type FilteredPublisher struct {
+	stream.Publisher
+	filter       filter
+	dlqPublisher stream.Publisher
+}
+
+func NewFilteredPublisher(publisher stream.Publisher, filter filter, dlqPublisher stream.Publisher) *FilteredPublisher {
+	return &FilteredPublisher{
+		Publisher:    publisher,
+		filter:       filter,
+		dlqPublisher: dlqPublisher,
+	}
+}
+
+func (p *FilteredPublisher) PublishNatsMsg(ctx context.Context, msg *nats.Msg, opts ...stream.PublishOpt) error {
+	if msg == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+
+	matched, err := p.filter.Matches(msg.Data)
+	if err != nil {
+		observability.RecordProcessorMessages(ctx, "filter", "error", 1)
+		return p.publishToDLQ(ctx, msg.Data, fmt.Errorf("filter evaluation error: %w", err))
+	}
+	if !matched {
+		observability.RecordProcessorMessages(ctx, "filter", "filtered", 1)
+		return nil
+	}
+
+	err = p.Publisher.PublishNatsMsg(ctx, msg, opts...)
+	if err != nil {
+		return err
+	}
+	observability.RecordProcessorMessages(ctx, "filter", "success", 1)
+	return nil
+}
+
+func (p *FilteredPublisher) publishToDLQ(ctx context.Context, data []byte, cause error) error {
+	dlqMsg := models.NewDLQMessage(internal.RoleJoin, cause.Error(), data).
+		WithReason(observability.DLQReasonUnrecoverable)
+	msg, err := dlqMsg.ToJSON()
+	if err != nil {
+		return fmt.Errorf("convert DLQ message to JSON: %w", err)
+	}
+
+	err = p.dlqPublisher.Publish(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("publish to DLQ: %w", err)
+	}
+
+	observability.RecordDLQWrite(ctx, internal.RoleJoin, observability.DLQReasonUnrecoverable, 1)
+	return nil
+}
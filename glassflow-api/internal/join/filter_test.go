@@ -0,0 +1,58 @@
+package join
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// amountFilter matches the events with an amount over 10.
+type amountFilter struct{}
+
+func (amountFilter) Matches(data []byte) (bool, error) {
+	var event struct {
+		Amount int `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return false, errors.New("unmarshal json")
+	}
+	return event.Amount > 10, nil
+}
+
+// dlqRecordingPublisher keeps the messages published with Publish.
+type dlqRecordingPublisher struct {
+	recordingPublisher
+	messages [][]byte
+}
+
+func (p *dlqRecordingPublisher) Publish(_ context.Context, msg []byte) error {
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func TestFilteredPublisher_PublishesMatchingEvents(t *testing.T) {
+	ctx := context.Background()
+	results := &recordingPublisher{}
+	dlq := &dlqRecordingPublisher{}
+	p := NewFilteredPublisher(results, amountFilter{}, dlq)
+
+	require.NoError(t, p.PublishNatsMsg(ctx, &nats.Msg{Data: []byte(`{"order_id":"1","amount":20}`)}))
+	require.NoError(t, p.PublishNatsMsg(ctx, &nats.Msg{Data: []byte(`{"order_id":"2","amount":5}`)}))
+	assert.Equal(t, []string{`{"order_id":"1","amount":20}`}, results.published)
+	assert.Empty(t, dlq.messages)
+	assert.Equal(t, "joined", p.GetSubject())
+
+	// An event the filter cannot evaluate goes to the DLQ
+	require.NoError(t, p.PublishNatsMsg(ctx, &nats.Msg{Data: []byte(`not json`)}))
+	assert.Len(t, results.published, 1)
+	require.Len(t, dlq.messages, 1)
+
+	var dlqMsg map[string]any
+	require.NoError(t, json.Unmarshal(dlq.messages[0], &dlqMsg))
+	assert.Equal(t, "join", dlqMsg["component"])
+}
@@ -0,0 +1,183 @@
+package join
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
+	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
+)
+
+// EventTimeExtractor reads the event time of the messages of a join source,
+// from the configured field or from the time the message was stored in NATS.
+type EventTimeExtractor struct {
+	schema    *schemav2.Schema
+	timeField string
+}
+
+func NewEventTimeExtractor(schema *schemav2.Schema, timeField string) *EventTimeExtractor {
+	return &EventTimeExtractor{
+		schema:    schema,
+		timeField: timeField,
+	}
+}
+
+func (e *EventTimeExtractor) EventTime(ctx context.Context, msg jetstream.Msg) (zero time.Time, _ error) {
+	if e.timeField == "" {
+		meta, err := msg.Metadata()
+		if err != nil {
+			return zero, fmt.Errorf("get message metadata: %w", err)
+		}
+		return meta.Timestamp, nil
+	}
+
+	value, err := e.schema.Get(ctx, msg.Headers().Get(internal.SchemaVersionIDHeader), e.timeField, msg.Data())
+	if err != nil {
+		return zero, fmt.Errorf("get event time field: %w", err)
+	}
+
+	switch value.(type) {
+	case string:
+		return mapper.ParseDateTimeFromString(value) //nolint:wrapcheck // already describes the value
+	case float64:
+		return mapper.ParseDateTimeFromFloat64(value) //nolint:wrapcheck // already describes the value
+	default:
+		return zero, fmt.Errorf("event time field %s is neither a string nor a number", e.timeField)
+	}
+}
+
+// OrderedEvent is a join event held back by the EventReorderer.
+type OrderedEvent struct {
+	EventTime time.Time
+	Right     bool
+	Msg       jetstream.Msg
+
+	sequence uint64
+	heldAt   time.Time
+}
+
+// EventReorderer holds back the events of both join sources and releases them
+// in event-time order. An event is released once an event at least max skew
+// later arrived, or once it was held for max skew. Events with the same event
+// time are released right ones first and then by stream sequence, so the
+// order does not depend on arrival.
+//
+// The reorderer is not safe for concurrent use, the join component calls it
+// under its handler mutex.
+type EventReorderer struct {
+	maxSkew time.Duration
+	events  eventHeap
+
+	// latest is the latest event time pushed
+	latest time.Time
+	// released is the event time of the last released event
+	released time.Time
+}
+
+func NewEventReorderer(maxSkew time.Duration) *EventReorderer {
+	return &EventReorderer{maxSkew: maxSkew}
+}
+
+// Push holds back ev and reports whether it did. Events older than the last
+// released event are late, they are not held and the caller processes them
+// right away.
+func (r *EventReorderer) Push(ev OrderedEvent) bool {
+	if ev.EventTime.Before(r.released) {
+		return false
+	}
+
+	if meta, err := ev.Msg.Metadata(); err == nil {
+		ev.sequence = meta.Sequence.Stream
+	}
+	ev.heldAt = time.Now()
+	heap.Push(&r.events, ev)
+
+	if ev.EventTime.After(r.latest) {
+		r.latest = ev.EventTime
+	}
+	return true
+}
+
+// Ready releases, in order, the events at least max skew older than the
+// latest event time.
+func (r *EventReorderer) Ready() []OrderedEvent {
+	watermark := r.latest.Add(-r.maxSkew)
+
+	var ready []OrderedEvent
+	for r.events.Len() > 0 && !r.events[0].EventTime.After(watermark) {
+		ready = append(ready, r.pop())
+	}
+	return ready
+}
+
+// Expired releases, in order, the events up to the last one that was held
+// for max skew, so that no event is held longer than the ack wait of the
+// join consumers while its source is idle.
+func (r *EventReorderer) Expired(now time.Time) []OrderedEvent {
+	var last *OrderedEvent
+	for i := range r.events {
+		ev := &r.events[i]
+		if now.Sub(ev.heldAt) < r.maxSkew {
+			continue
+		}
+		if last == nil || last.less(ev) {
+			last = ev
+		}
+	}
+	if last == nil {
+		return nil
+	}
+
+	until := *last
+	var ready []OrderedEvent
+	for r.events.Len() > 0 && !until.less(&r.events[0]) {
+		ready = append(ready, r.pop())
+	}
+	return ready
+}
+
+// Len returns the number of held events.
+func (r *EventReorderer) Len() int {
+	return r.events.Len()
+}
+
+func (r *EventReorderer) pop() OrderedEvent {
+	ev := heap.Pop(&r.events).(OrderedEvent) //nolint:forcetypeassert // the heap only holds OrderedEvent
+	r.released = ev.EventTime
+	return ev
+}
+
+// less orders the events by event time, right before left and stream sequence.
+func (ev *OrderedEvent) less(other *OrderedEvent) bool {
+	if !ev.EventTime.Equal(other.EventTime) {
+		return ev.EventTime.Before(other.EventTime)
+	}
+	if ev.Right != other.Right {
+		return ev.Right
+	}
+	return ev.sequence < other.sequence
+}
+
+type eventHeap []OrderedEvent
+
+func (h eventHeap) Len() int           { return len(h) }
+func (h eventHeap) Less(i, j int) bool { return h[i].less(&h[j]) }
+func (h eventHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *eventHeap) Push(x any) {
+	*h = append(*h, x.(OrderedEvent)) //nolint:forcetypeassert // the heap only holds OrderedEvent
+}
+
+func (h *eventHeap) Pop() any {
+	old := *h
+	n := len(old)
+	ev := old[n-1]
+	old[n-1] = OrderedEvent{}
+	*h = old[:n-1]
+	return ev
+}
@@ -0,0 +1,83 @@
+package join
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceMsg only carries the stream sequence used to order equal event times.
+type sequenceMsg struct {
+	jetstream.Msg
+	sequence uint64
+}
+
+func (m *sequenceMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: m.sequence}}, nil
+}
+
+func orderedEvent(eventTime time.Time, right bool, sequence uint64) OrderedEvent {
+	return OrderedEvent{EventTime: eventTime, Right: right, Msg: &sequenceMsg{sequence: sequence}}
+}
+
+func sequences(events []OrderedEvent) []uint64 {
+	out := make([]uint64, 0, len(events))
+	for _, ev := range events {
+		out = append(out, ev.Msg.(*sequenceMsg).sequence) //nolint:forcetypeassert // test messages
+	}
+	return out
+}
+
+func TestEventReorderer_ReleasesInEventTimeOrder(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewEventReorderer(10 * time.Second)
+
+	require.True(t, r.Push(orderedEvent(base.Add(3*time.Second), false, 1)))
+	require.True(t, r.Push(orderedEvent(base.Add(time.Second), false, 2)))
+	require.True(t, r.Push(orderedEvent(base.Add(2*time.Second), true, 1)))
+	assert.Empty(t, r.Ready(), "events within the skew are held")
+
+	require.True(t, r.Push(orderedEvent(base.Add(13*time.Second), false, 3)))
+	assert.Equal(t, []uint64{2, 1, 1}, sequences(r.Ready()))
+	assert.Equal(t, 1, r.Len())
+
+	assert.False(t, r.Push(orderedEvent(base, true, 2)), "events older than the released ones are late")
+}
+
+func TestEventReorderer_SameEventTimeOrder(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Arrival order differs between runs, the release order does not
+	arrivals := [][]OrderedEvent{
+		{orderedEvent(base, false, 5), orderedEvent(base, true, 9), orderedEvent(base, false, 4)},
+		{orderedEvent(base, false, 4), orderedEvent(base, false, 5), orderedEvent(base, true, 9)},
+	}
+	for _, events := range arrivals {
+		r := NewEventReorderer(time.Second)
+		for _, ev := range events {
+			require.True(t, r.Push(ev))
+		}
+		require.True(t, r.Push(orderedEvent(base.Add(time.Minute), false, 6)))
+
+		released := r.Ready()
+		require.Len(t, released, 3)
+		assert.True(t, released[0].Right, "right events go first on equal event times")
+		assert.Equal(t, []uint64{9, 4, 5}, sequences(released))
+	}
+}
+
+func TestEventReorderer_Expired(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewEventReorderer(time.Second)
+
+	require.True(t, r.Push(orderedEvent(base.Add(time.Second), false, 1)))
+	require.True(t, r.Push(orderedEvent(base, false, 2)))
+	assert.Empty(t, r.Expired(time.Now()))
+
+	released := r.Expired(time.Now().Add(2 * time.Second))
+	assert.Equal(t, []uint64{2, 1}, sequences(released))
+	assert.Zero(t, r.Len())
+}
@@ -0,0 +1,122 @@
+package join
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs/mocks"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+)
+
+// recordingPublisher keeps the data of the published messages.
+type recordingPublisher struct {
+	published []string
+}
+
+func (p *recordingPublisher) Publish(context.Context, []byte) error { return nil }
+func (p *recordingPublisher) GetSubject() string                    { return "joined" }
+func (p *recordingPublisher) PublishNatsMsg(_ context.Context, msg *nats.Msg, _ ...stream.PublishOpt) error {
+	p.published = append(p.published, string(msg.Data))
+	return nil
+}
+func (p *recordingPublisher) PublishNatsMsgAsync(context.Context, *nats.Msg, int) (jetstream.PubAckFuture, error) {
+	return nil, nil
+}
+func (p *recordingPublisher) WaitForAsyncPublishAcks() <-chan struct{} { return nil }
+
+func newOuterConfigStore() *configs.ConfigStore {
+	db := mocks.NewMockDBClient()
+	db.GetJoinConfigsFunc = func(context.Context, string, string, string, string, string) ([]models.JoinConfig, error) {
+		return []models.JoinConfig{
+			{SourceID: "orders", OutputSchemaVersionID: "9", Config: []models.JoinRule{
+				{SourceID: "orders", SourceName: "user_id", OutputName: "user_id"},
+				{SourceID: "orders", SourceName: "amount", OutputName: "amount"},
+			}},
+			{SourceID: "users", OutputSchemaVersionID: "9", Config: []models.JoinRule{
+				{SourceID: "users", SourceName: "name", OutputName: "name"},
+			}},
+		}, nil
+	}
+	return configs.NewConfigStore(db, "pipeline-1", "orders")
+}
+
+func TestSweepUnmatched_EmitsOuterEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	leftBuffer := NewWindowBuffer(newMemoryKVStore(), nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	rightStore := newMemoryKVStore()
+	rightBuffer := NewWindowBuffer(rightStore, nil, internal.JoinRight, time.Hour, false, slog.Default())
+	leftBuffer.now, rightBuffer.now = clock, clock
+
+	publisher := &recordingPublisher{}
+	executor := NewTemporalJoinExecutor(
+		publisher, nil, nil, newOuterConfigStore(),
+		leftBuffer, rightBuffer,
+		nil,
+		OuterJoin{Left: true, Right: true, Grace: time.Minute},
+		time.Minute, time.Minute,
+		"orders", "users", "user_id", "id",
+		slog.Default(),
+		"pipeline-1",
+		nil,
+	)
+	executor.leftSchemaVersionID, executor.rightSchemaVersionID = "1", "1"
+
+	require.NoError(t, leftBuffer.Add(ctx, "u1", "1", []byte(`{"user_id":"u1","amount":5}`)))
+	require.NoError(t, rightBuffer.Add(ctx, "u2", "1", []byte(`{"id":"u2","name":"b"}`)))
+	require.NoError(t, rightBuffer.AddMatched(ctx, "u3", "1", []byte(`{"id":"u3","name":"c"}`)))
+
+	// Events within the window and the grace period are kept
+	now = now.Add(90 * time.Second)
+	require.NoError(t, executor.SweepUnmatched(ctx))
+	assert.Empty(t, publisher.published)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, leftBuffer.Add(ctx, "u4", "1", []byte(`{"user_id":"u4","amount":1}`)))
+	require.NoError(t, executor.SweepUnmatched(ctx))
+
+	assert.Equal(t, []string{
+		`{"amount":5,"name":null,"user_id":"u1"}`,
+		`{"amount":null,"name":"b","user_id":null}`,
+	}, publisher.published)
+	assert.Equal(t, 1, leftBuffer.Len(), "the fresh left event stays buffered")
+	assert.Equal(t, 0, rightBuffer.Len(), "matched right events are dropped without being emitted")
+
+	events, err := rightBuffer.Get(ctx, "u3")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Len(t, rightStore.entries, 2, "the matched event and its marker expire with the bucket TTL")
+}
+
+func TestBuildOuterJoinedMessage(t *testing.T) {
+	config := &models.JoinAuxConfig{
+		OutputSchemaVersionID: "9",
+		SourceJoinRules: map[string]map[string]string{
+			"orders": {"user_id": "user_id", "amount": "amount"},
+			"users":  {"id": "user_id", "name": "name"},
+		},
+	}
+
+	buf := joinedDataPool.Get(context.Background())
+	defer joinedDataPool.Put(buf)
+
+	msg, err := buildOuterJoinedMessage(buf, "joined", "orders", []byte(`{"user_id":"u1","amount":5}`), "users", config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user_id":"u1","amount":5,"name":null}`, string(msg.Data), "fields of the event are not overwritten by NULLs")
+	assert.Equal(t, "9", msg.Header.Get(internal.SchemaVersionIDHeader))
+
+	_, err = buildOuterJoinedMessage(buf, "joined", "orders", []byte(`{"user_id":"u1","amount":5}`), "accounts", config)
+	require.Error(t, err)
+}
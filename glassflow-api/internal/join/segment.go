@@ -0,0 +1,305 @@
+package join
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// segmentKeyPrefix starts the store keys of segments, followed by the base64
+// encoded join key and a UUID like the store keys of events.
+const segmentKeyPrefix = "s.k"
+
+// segmentEventOverhead approximates the encoded size of an event besides its
+// key, schema version and data.
+const segmentEventOverhead = 64
+
+// segment holds compacted events of one join key in a single KV entry.
+type segment struct {
+	Events []segmentEvent `json:"events"`
+}
+
+type segmentEvent struct {
+	StoreKey        string    `json:"k"`
+	SchemaVersionID string    `json:"v"`
+	StoredAt        time.Time `json:"t"`
+	Data            []byte    `json:"d"`
+}
+
+func (e segmentEvent) size() int {
+	return len(e.StoreKey) + len(e.SchemaVersionID) + base64.StdEncoding.EncodedLen(len(e.Data)) + segmentEventOverhead
+}
+
+func (s segment) size() int {
+	var size int
+	for _, ev := range s.Events {
+		size += ev.size()
+	}
+	return size
+}
+
+// segmentReads keeps the segments read while serving one call, so the
+// events of a key read their segment once.
+type segmentReads map[string]segment
+
+// WithCompaction compacts the events stored for longer than after into
+// segments on every Compact.
+func WithCompaction(after time.Duration) BufferOption {
+	return func(b *WindowBuffer) {
+		b.compactAfter = after
+	}
+}
+
+// Compact moves the events stored for longer than the compaction delay into
+// the segments of their join key, so the store holds one entry per key
+// instead of one per event. The last segment of a key is rewritten with the
+// new events, without the events that left the window, until it reaches
+// the size limit. A pass moves a bounded number of events.
+func (b *WindowBuffer) Compact(ctx context.Context) error {
+	if b.compactAfter <= 0 {
+		return nil
+	}
+	cutoff := b.now().Add(-b.compactAfter)
+
+	var (
+		keys  []string
+		byKey = make(map[string][]*bufferEntry)
+		count int
+	)
+	for el := b.order.Front(); el != nil && count < internal.JoinCompactMaxEventsPass; el = el.Next() {
+		entry := el.Value.(*bufferEntry) //nolint:forcetypeassert // only entries are stored
+		if entry.storedAt.After(cutoff) {
+			break
+		}
+		// Events of the legacy layouts are left to expire
+		if entry.segment != "" || !entry.indexed || !isStoreKey(entry.storeKey) {
+			continue
+		}
+		if _, ok := byKey[entry.key]; !ok {
+			keys = append(keys, entry.key)
+		}
+		byKey[entry.key] = append(byKey[entry.key], entry)
+		count++
+	}
+
+	var compacted int64
+	for _, key := range keys {
+		moved, err := b.compactKey(ctx, key, byKey[key])
+		compacted += moved
+		if err != nil {
+			observability.RecordJoinCompactedEvents(ctx, b.orientation, compacted)
+			return err
+		}
+	}
+
+	if compacted > 0 {
+		observability.RecordJoinCompactedEvents(ctx, b.orientation, compacted)
+		b.log.DebugContext(ctx, "compacted join buffer", "orientation", b.orientation, "events", compacted, "keys", len(keys))
+	}
+	return nil
+}
+
+// compactKey appends the events of a join key to its last segment, starting
+// a new segment once the last one is full.
+func (b *WindowBuffer) compactKey(ctx context.Context, key string, entries []*bufferEntry) (int64, error) {
+	segKey := b.openSegments[key]
+	var seg segment
+	if segKey != "" {
+		var err error
+		seg, err = b.readSegment(ctx, segKey, nil)
+		if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+			return 0, err
+		}
+		if err != nil {
+			segKey = ""
+		}
+		seg = b.liveEvents(seg, "")
+	}
+	if segKey == "" {
+		segKey = newSegmentKey(key)
+	}
+
+	var (
+		compacted int64
+		moved     []*bufferEntry
+		size      = seg.size()
+	)
+	for _, entry := range entries {
+		ev, err := b.read(ctx, entry, nil)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				b.remove(entry)
+				continue
+			}
+			return compacted, err
+		}
+
+		event := segmentEvent{
+			StoreKey:        entry.storeKey,
+			SchemaVersionID: ev.SchemaVersionID,
+			StoredAt:        entry.storedAt,
+			Data:            ev.Data,
+		}
+		if size+event.size() > internal.JoinSegmentMaxBytes && len(seg.Events) > 0 {
+			err = b.writeSegment(ctx, segKey, seg, moved)
+			if err != nil {
+				return compacted, err
+			}
+			compacted += int64(len(moved))
+			segKey, seg, size, moved = newSegmentKey(key), segment{}, 0, nil
+		}
+
+		seg.Events = append(seg.Events, event)
+		size += event.size()
+		moved = append(moved, entry)
+	}
+
+	if len(moved) == 0 {
+		return compacted, nil
+	}
+	err := b.writeSegment(ctx, segKey, seg, moved)
+	if err != nil {
+		return compacted, err
+	}
+	b.openSegments[key] = segKey
+
+	return compacted + int64(len(moved)), nil
+}
+
+// writeSegment stores a segment and then deletes the stored copies of the
+// events moved into it. A restart between the two finds an event in both
+// places and keeps the segment.
+func (b *WindowBuffer) writeSegment(ctx context.Context, segKey string, seg segment, moved []*bufferEntry) error {
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s buffer segment %s: %w", b.orientation, segKey, err)
+	}
+	err = b.store.PutString(ctx, segKey, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store %s buffer segment %s: %w", b.orientation, segKey, err)
+	}
+
+	for _, entry := range moved {
+		storeKey := entry.storeKey
+		entry.segment = segKey
+		err = b.store.Delete(ctx, storeKey)
+		if err != nil {
+			b.log.WarnContext(ctx, "failed to delete compacted join buffer event, it expires with the buffer TTL",
+				"orientation", b.orientation, "store_key", storeKey, "error", err)
+		}
+	}
+	return nil
+}
+
+// removeFromSegment rewrites the segment of an entry without its event, a
+// segment left without events is deleted.
+func (b *WindowBuffer) removeFromSegment(ctx context.Context, entry *bufferEntry) error {
+	seg, err := b.readSegment(ctx, entry.segment, nil)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	seg = b.liveEvents(seg, entry.storeKey)
+	if len(seg.Events) == 0 {
+		if b.openSegments[entry.key] == entry.segment {
+			delete(b.openSegments, entry.key)
+		}
+		err = b.store.Delete(ctx, entry.segment)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s buffer segment %s: %w", b.orientation, entry.segment, err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s buffer segment %s: %w", b.orientation, entry.segment, err)
+	}
+	err = b.store.PutString(ctx, entry.segment, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store %s buffer segment %s: %w", b.orientation, entry.segment, err)
+	}
+	return nil
+}
+
+// liveEvents drops the events that left the window from a segment, and the
+// event of the store key when it is not empty.
+func (b *WindowBuffer) liveEvents(seg segment, without string) segment {
+	cutoff := b.now().Add(-b.window)
+
+	events := make([]segmentEvent, 0, len(seg.Events))
+	for _, ev := range seg.Events {
+		if !ev.StoredAt.After(cutoff) || ev.StoreKey == without {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return segment{Events: events}
+}
+
+// readSegment returns the segment of the key, from reads when it was already
+// read during the call.
+func (b *WindowBuffer) readSegment(ctx context.Context, segKey string, reads segmentReads) (segment, error) {
+	if seg, ok := reads[segKey]; ok {
+		return seg, nil
+	}
+
+	data, err := b.store.GetString(ctx, segKey)
+	if err != nil {
+		return segment{}, fmt.Errorf("failed to get %s buffer segment %s: %w", b.orientation, segKey, err)
+	}
+
+	var seg segment
+	err = json.Unmarshal([]byte(data), &seg)
+	if err != nil {
+		return segment{}, fmt.Errorf("failed to decode %s buffer segment %s: %w", b.orientation, segKey, err)
+	}
+
+	if reads != nil {
+		reads[segKey] = seg
+	}
+	return seg, nil
+}
+
+// readFromSegment returns the event of a compacted entry.
+func (b *WindowBuffer) readFromSegment(ctx context.Context, entry *bufferEntry, reads segmentReads) (segmentEvent, error) {
+	seg, err := b.readSegment(ctx, entry.segment, reads)
+	if err != nil {
+		return segmentEvent{}, err
+	}
+
+	for _, ev := range seg.Events {
+		if ev.StoreKey == entry.storeKey {
+			return ev, nil
+		}
+	}
+	return segmentEvent{}, fmt.Errorf("%s buffer event %s not in segment %s: %w", b.orientation, entry.storeKey, entry.segment, jetstream.ErrKeyNotFound)
+}
+
+// newSegmentKey returns a new segment key of the join key.
+func newSegmentKey(key string) string {
+	return segmentKeyPrefix + base64.RawURLEncoding.EncodeToString([]byte(key)) + "." + uuid.NewString()
+}
+
+// parseSegmentKey returns the join key of a segment key made by
+// newSegmentKey.
+func parseSegmentKey(segKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(segKey, segmentKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	return parseEncodedKey(rest)
+}
@@ -0,0 +1,93 @@
+package join
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func segmentKeys(store *memoryKVStore) []string {
+	var keys []string
+	for key := range store.entries {
+		if strings.HasPrefix(key, segmentKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestWindowBuffer_CompactMovesOldEventsIntoSegments(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := newMemoryKVStore()
+	b := NewWindowBuffer(store, nil, internal.JoinLeft, 24*time.Hour, false, slog.Default(), WithCompaction(time.Hour))
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":1}`)))
+	require.NoError(t, b.Add(ctx, "user-1", "2", []byte(`{"order":2}`)))
+	require.NoError(t, b.Add(ctx, "user-2", "1", []byte(`{"order":3}`)))
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"order":4}`)))
+
+	require.NoError(t, b.Compact(ctx))
+	assert.Len(t, segmentKeys(store), 2, "one segment per join key")
+	assert.Len(t, store.entries, 3, "the compacted events are deleted")
+
+	events, err := b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":1}`, `{"order":2}`, `{"order":4}`}, eventData(events))
+	assert.Equal(t, "2", events[1].SchemaVersionID)
+
+	// Later events of a key are appended to its segment
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, b.Compact(ctx))
+	assert.Len(t, segmentKeys(store), 2)
+	assert.Len(t, store.entries, 2)
+
+	events, err = b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, b.Remove(ctx, events[0]))
+	events, err = b.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"order":2}`, `{"order":4}`}, eventData(events))
+
+	events, err = b.Get(ctx, "user-2")
+	require.NoError(t, err)
+	require.NoError(t, b.Remove(ctx, events[0]))
+	assert.Len(t, segmentKeys(store), 1, "a segment without events is deleted")
+}
+
+func TestWindowBuffer_LoadCompactedEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+	b := NewWindowBuffer(store, nil, internal.JoinRight, 24*time.Hour, false, slog.Default(), WithCompaction(time.Minute))
+
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"a"}`)))
+	require.NoError(t, b.Add(ctx, "user-1", "1", []byte(`{"name":"b"}`)))
+	entries := b.Checkpoint()
+
+	now := time.Now().Add(time.Hour)
+	b.now = func() time.Time { return now }
+	require.NoError(t, b.Compact(ctx))
+
+	// a compaction stopped before it deleted the moved events
+	leftover := entries[0].StoreKey
+	require.NoError(t, store.PutMessage(ctx, leftover, "1", []byte(`{"name":"a"}`)))
+
+	loaded := NewWindowBuffer(store, nil, internal.JoinRight, 24*time.Hour, false, slog.Default(), WithCompaction(time.Minute))
+	require.NoError(t, loaded.Restore(ctx, entries))
+	assert.Equal(t, 2, loaded.Len())
+	assert.NotContains(t, store.entries, leftover)
+
+	events, err := loaded.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"name":"a"}`, `{"name":"b"}`}, eventData(events))
+	assert.Empty(t, loaded.Checkpoint(), "compacted events are indexed from their segments")
+}
@@ -2,34 +2,51 @@ package join
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
-	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kv"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 )
 
 const backpressureSignalCooldown = 5 * time.Minute
 
+// OuterJoin sets the outer sides of a join. Their events that found no match
+// within their window and the grace period are emitted with NULLs for the
+// fields of the other side.
+type OuterJoin struct {
+	Left  bool
+	Right bool
+	Grace time.Duration
+}
+
+// Enabled reports whether a side of the join is outer.
+func (o OuterJoin) Enabled() bool {
+	return o.Left || o.Right
+}
+
 type TemporalJoinExecutor struct {
 	resultsPublisher stream.Publisher
 	leftSchema       *schemav2.Schema
 	rightSchema      *schemav2.Schema
 	cfgStore         configs.ConfigStoreInterface
-	leftKVStore      kv.KeyValueStore
-	rightKVStore     kv.KeyValueStore
+	leftBuffer       *WindowBuffer
+	rightBuffer      *WindowBuffer
+	unmatched        UnmatchedHandler
+	outer            OuterJoin
+	leftWindow       time.Duration
+	rightWindow      time.Duration
 	leftSourceName   string
 	rightSourceName  string
 	leftKey          string
@@ -39,13 +56,21 @@ type TemporalJoinExecutor struct {
 	signalPublisher  *componentsignals.ComponentSignalPublisher
 
 	lastBackpressureSignal time.Time
+
+	// The schema versions of the last events, the NULL fields of a missing
+	// side are emitted with them
+	leftSchemaVersionID  string
+	rightSchemaVersionID string
 }
 
 func NewTemporalJoinExecutor(
 	resultsPublisher stream.Publisher,
 	leftSchema, rightSchema *schemav2.Schema,
 	cfgStore configs.ConfigStoreInterface,
-	leftKVStore, rightKVStore kv.KeyValueStore,
+	leftBuffer, rightBuffer *WindowBuffer,
+	unmatched UnmatchedHandler,
+	outer OuterJoin,
+	leftWindow, rightWindow time.Duration,
 	leftSourceName, rightSourceName, leftKey, rightKey string,
 	log *slog.Logger,
 	pipelineID string,
@@ -56,8 +81,12 @@ func NewTemporalJoinExecutor(
 		leftSchema:       leftSchema,
 		rightSchema:      rightSchema,
 		cfgStore:         cfgStore,
-		leftKVStore:      leftKVStore,
-		rightKVStore:     rightKVStore,
+		leftBuffer:       leftBuffer,
+		rightBuffer:      rightBuffer,
+		unmatched:        unmatched,
+		outer:            outer,
+		leftWindow:       leftWindow,
+		rightWindow:      rightWindow,
 		leftSourceName:   leftSourceName,
 		rightSourceName:  rightSourceName,
 		leftKey:          leftKey,
@@ -74,12 +103,25 @@ func NewTemporalJoinExecutor(
 // elapse and trigger a redelivery. Both input subscribers stay paused for
 // the duration because the JoinComponent serialises handlers behind
 // handleMu — pausing one side pauses the whole component, which is what
-// the join's temporal-window semantics require.
-func (t *TemporalJoinExecutor) publishJoinedMsg(ctx context.Context, inflight jetstream.Msg, msg *nats.Msg) error {
+// the join's temporal-window semantics require. Publishes of the sweep have
+// no in-flight message.
+func (t *TemporalJoinExecutor) publishJoinedMsg(ctx context.Context, inflight jetstream.Msg, msg *nats.Msg) (err error) {
+	ctx, span := observability.StartSpan(ctx, observability.SpanNATSPublish,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("component", internal.RoleJoin),
+		),
+	)
+	observability.InjectTraceContext(ctx, msg.Header)
+	defer func() { observability.EndSpan(span, err) }()
+
 	backoff := internal.IngestorBackpressureInitialDelay
 	for {
-		err := t.resultsPublisher.PublishNatsMsg(ctx, msg)
+		err = t.resultsPublisher.PublishNatsMsg(ctx, msg)
 		if err == nil {
+			observability.RecordMessagesPublished(ctx, internal.RoleJoin, 1)
 			return nil
 		}
 		if ctx.Err() != nil {
@@ -88,8 +130,10 @@ func (t *TemporalJoinExecutor) publishJoinedMsg(ctx context.Context, inflight je
 		if !stream.IsBackpressureErr(err) {
 			return err
 		}
-		if ipErr := inflight.InProgress(); ipErr != nil {
-			t.log.WarnContext(ctx, "failed to extend ack-wait while waiting on back-pressure", "error", ipErr)
+		if inflight != nil {
+			if ipErr := inflight.InProgress(); ipErr != nil {
+				t.log.WarnContext(ctx, "failed to extend ack-wait while waiting on back-pressure", "error", ipErr)
+			}
 		}
 		t.log.WarnContext(ctx, "results stream back-pressure, retrying publish", "backoff", backoff)
 
@@ -116,138 +160,122 @@ func (t *TemporalJoinExecutor) publishJoinedMsg(ctx context.Context, inflight je
 	}
 }
 
-func (t *TemporalJoinExecutor) storeToLeftStreamBuffer(ctx context.Context, key any, schemaVersionID string, value []byte) error {
-	keys := ""
-	keys, err := t.leftKVStore.GetString(ctx, key)
-	if err != nil {
-		if !errors.Is(err, jetstream.ErrKeyNotFound) {
-			t.log.ErrorContext(ctx, "failed to get left stream data", "key", key, "error", err)
-			return fmt.Errorf("failed to get left stream data: %w", err)
-		}
-	}
-
-	uuidKey := uuid.New().String()
-	err = t.leftKVStore.PutMessage(ctx, uuidKey, schemaVersionID, value)
-	if err != nil {
-		t.log.ErrorContext(ctx, "failed to store left stream data", "uuid_key", uuidKey, "error", err)
-		return fmt.Errorf("failed to store left stream data: %w", err)
-	}
+func (t *TemporalJoinExecutor) HandleLeftStreamEvents(ctx context.Context, msg jetstream.Msg) error {
+	ctx = observability.ExtractTraceContext(ctx, msg.Headers())
+	data := msg.Data()
 
-	if keys != "" {
-		keys += " "
-	}
+	leftSchemaVersionID := msg.Headers().Get(internal.SchemaVersionIDHeader)
 
-	err = t.leftKVStore.PutString(ctx, key, keys+uuidKey)
+	key, err := t.leftSchema.Get(ctx, leftSchemaVersionID, t.leftKey, data)
 	if err != nil {
-		t.log.ErrorContext(ctx, "failed to put left stream data in KV store", "key", key, "keys", keys+uuidKey, "error", err)
-		return fmt.Errorf("failed to put left stream data in KV store: %w", err)
+		t.log.ErrorContext(ctx, "failed to get join key from left stream message", "left_source", t.leftSourceName, "error", err)
+		return fmt.Errorf("failed to get join key from left stream message: %w", err)
 	}
+	t.leftSchemaVersionID = leftSchemaVersionID
 
-	return nil
-}
-
-func (t *TemporalJoinExecutor) getFromleftStreamBuffer(ctx context.Context, inflight jetstream.Msg, key any, rightSchemaVersionID string, rightStreamData []byte) error {
-	rawUUIDs, err := t.leftKVStore.GetString(ctx, key)
+	lookupCtx, span := startBufferLookupSpan(ctx, t.rightSourceName)
+	rightEvents, err := t.rightBuffer.Get(lookupCtx, key)
+	endBufferLookupSpan(span, len(rightEvents) > 0, err)
 	if err != nil {
-		if !errors.Is(err, jetstream.ErrKeyNotFound) {
-			t.log.ErrorContext(ctx, "failed to get left stream data", "key", key, "error", err)
-			return fmt.Errorf("failed to get left stream data: %w", err)
-		}
-		return nil
+		t.log.ErrorContext(ctx, "failed to get right stream events from buffer", "key", key, "error", err)
+		return fmt.Errorf("failed to get right stream events from buffer: %w", err)
 	}
 
-	uuids := strings.Split(rawUUIDs, " ")
-	if len(uuids) == 0 {
-		t.log.DebugContext(ctx, "no left stream data found for the key", "key", fmt.Sprintf("%v", key))
+	if len(rightEvents) == 0 {
+		// key not yet found in the right stream, store the left data
+		err = t.leftBuffer.Add(ctx, key, leftSchemaVersionID, data)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to put left stream message in buffer", "key", key, "error", err)
+			return fmt.Errorf("failed to put left stream message in buffer: %w", err)
+		}
 		return nil
 	}
 
-	// Split the keys by space
-	for _, uuidKey := range uuids {
-		if uuidKey == "" {
-			continue
-		}
-		leftSchemaVersionID, leftStreamData, err := t.leftKVStore.GetMessage(ctx, uuidKey)
-		if err != nil {
-			if !errors.Is(err, jetstream.ErrKeyNotFound) {
-				t.log.ErrorContext(ctx, "failed to get left stream data with key", "uuid_key", uuidKey, "error", err)
-				return fmt.Errorf("failed to get left stream data with key %s: %w", uuidKey, err)
+	for _, right := range rightEvents {
+		if t.outer.Right {
+			err = t.rightBuffer.MarkMatched(ctx, right)
+			if err != nil {
+				t.log.ErrorContext(ctx, "failed to mark right stream event matched", "key", key, "error", err)
+				return fmt.Errorf("failed to mark right stream event matched: %w", err)
 			}
-			continue
-		}
-
-		config, err := t.cfgStore.GetJoinConfig(ctx, t.leftSourceName, leftSchemaVersionID, t.rightSourceName, rightSchemaVersionID)
-		if err != nil {
-			return fmt.Errorf("failed to get join config: %w", err)
-		}
-
-		msg, err := buildJoinedMessage(
-			t.resultsPublisher.GetSubject(),
-			t.leftSourceName, leftStreamData,
-			t.rightSourceName, rightStreamData,
-			config,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to join data: %w", err)
-		}
-
-		err = t.publishJoinedMsg(ctx, inflight, msg)
-		if err != nil {
-			t.log.ErrorContext(ctx, "failed to publish joined data", "left_source", t.leftSourceName, "right_source", t.rightSourceName, "error", err)
-			return fmt.Errorf("failed to publish joined data: %w", err)
 		}
 
-		err = t.leftKVStore.Delete(ctx, uuidKey)
+		err = t.publishJoined(ctx, msg, leftSchemaVersionID, data, right.SchemaVersionID, right.Data)
 		if err != nil {
-			t.log.ErrorContext(ctx, "failed to delete left stream data with key", "uuid_key", uuidKey, "error", err)
-			return fmt.Errorf("failed to delete left stream data with key %s: %w", uuidKey, err)
+			return err
 		}
 	}
 
-	err = t.leftKVStore.Delete(ctx, key)
-	if err != nil {
-		t.log.ErrorContext(ctx, "failed to delete key data from left KV store", "error", err, "key", fmt.Sprintf("%v", key))
-	}
-
 	return nil
 }
 
-func (t *TemporalJoinExecutor) HandleLeftStreamEvents(ctx context.Context, msg jetstream.Msg) error {
+func (t *TemporalJoinExecutor) HandleRightStreamEvents(ctx context.Context, msg jetstream.Msg) error {
+	ctx = observability.ExtractTraceContext(ctx, msg.Headers())
 	data := msg.Data()
 
-	leftSchemaVersionID := msg.Headers().Get(internal.SchemaVersionIDHeader)
+	schemaVersionID := msg.Headers().Get(internal.SchemaVersionIDHeader)
 
-	key, err := t.leftSchema.Get(ctx, leftSchemaVersionID, t.leftKey, data)
+	key, err := t.rightSchema.Get(ctx, schemaVersionID, t.rightKey, data)
 	if err != nil {
-		t.log.ErrorContext(ctx, "failed to get join key from left stream message", "left_source", t.leftSourceName, "error", err)
-		return fmt.Errorf("failed to get join key from left stream message: %w", err)
+		t.log.ErrorContext(ctx, "failed to get join key from right stream message", "right_stream", t.rightSourceName, "schema_version_id", schemaVersionID, "error", err)
+		return fmt.Errorf("failed to get join key from right stream message: %w", err)
 	}
+	t.rightSchemaVersionID = schemaVersionID
 
-	rightSchemaVersionID, rightData, err := t.rightKVStore.GetMessage(ctx, key)
+	lookupCtx, span := startBufferLookupSpan(ctx, t.leftSourceName)
+	leftEvents, err := t.leftBuffer.Get(lookupCtx, key)
+	endBufferLookupSpan(span, len(leftEvents) > 0, err)
 	if err != nil {
-		if !errors.Is(err, jetstream.ErrKeyNotFound) {
-			t.log.ErrorContext(ctx, "failed to get right stream message from KV store", "key", key, "error", err)
-			return fmt.Errorf("failed to get right stream message from KV store: %w", err)
-		}
+		t.log.ErrorContext(ctx, "failed to get left stream events from buffer", "key", key, "error", err)
+		return fmt.Errorf("failed to get left stream events from buffer: %w", err)
+	}
 
-		// key not yet found in the right stream, store the left data
-		err = t.storeToLeftStreamBuffer(ctx, key, msg.Headers().Get(internal.SchemaVersionIDHeader), data)
+	// A right outer join keeps track of the right events joined on arrival
+	if t.outer.Right && len(leftEvents) > 0 {
+		err = t.rightBuffer.AddMatched(ctx, key, schemaVersionID, data)
+	} else {
+		err = t.rightBuffer.Add(ctx, key, schemaVersionID, data)
+	}
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to put right stream message in buffer", "key", key, "error", err)
+		return fmt.Errorf("failed to put right stream message in buffer: %w", err)
+	}
+
+	for _, left := range leftEvents {
+		err = t.publishJoined(ctx, msg, left.SchemaVersionID, left.Data, schemaVersionID, data)
 		if err != nil {
-			t.log.ErrorContext(ctx, "failed to put left stream message in KV store", "key", key, "error", err)
-			return fmt.Errorf("failed to put left stream message in KV store: %w", err)
+			return err
 		}
 
-		return nil
+		err = t.leftBuffer.Remove(ctx, left)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to delete joined left stream event", "key", key, "error", err)
+			return fmt.Errorf("failed to delete joined left stream event: %w", err)
+		}
 	}
 
+	return nil
+}
+
+// publishJoined joins a left and a right event and publishes the result.
+func (t *TemporalJoinExecutor) publishJoined(
+	ctx context.Context,
+	inflight jetstream.Msg,
+	leftSchemaVersionID string, leftData []byte,
+	rightSchemaVersionID string, rightData []byte,
+) error {
 	config, err := t.cfgStore.GetJoinConfig(ctx, t.leftSourceName, leftSchemaVersionID, t.rightSourceName, rightSchemaVersionID)
 	if err != nil {
 		return fmt.Errorf("failed to get join config: %w", err)
 	}
 
-	outputMsg, err := buildJoinedMessage(
+	buf := joinedDataPool.Get(ctx)
+	defer joinedDataPool.Put(buf)
+
+	msg, err := buildJoinedMessage(
+		buf,
 		t.resultsPublisher.GetSubject(),
-		t.leftSourceName, data,
+		t.leftSourceName, leftData,
 		t.rightSourceName, rightData,
 		config,
 	)
@@ -255,7 +283,7 @@ func (t *TemporalJoinExecutor) HandleLeftStreamEvents(ctx context.Context, msg j
 		return fmt.Errorf("failed to join data: %w", err)
 	}
 
-	err = t.publishJoinedMsg(ctx, msg, outputMsg)
+	err = t.publishJoinedMsg(ctx, inflight, msg)
 	if err != nil {
 		t.log.ErrorContext(ctx, "failed to publish joined data", "left_source", t.leftSourceName, "right_source", t.rightSourceName, "error", err)
 		return fmt.Errorf("failed to publish joined data: %w", err)
@@ -264,28 +292,170 @@ func (t *TemporalJoinExecutor) HandleLeftStreamEvents(ctx context.Context, msg j
 	return nil
 }
 
-func (t *TemporalJoinExecutor) HandleRightStreamEvents(ctx context.Context, msg jetstream.Msg) error {
-	data := msg.Data()
+// SweepUnmatched handles the events that found no match. The left events
+// buffered for longer than the join window go to the unmatched handler, the
+// events of the outer sides are emitted once the grace period passed too.
+func (t *TemporalJoinExecutor) SweepUnmatched(ctx context.Context) error {
+	if t.unmatched != nil {
+		err := t.routeUnmatched(ctx)
+		if err != nil {
+			return err
+		}
+	}
 
-	schemaVersionID := msg.Headers().Get(internal.SchemaVersionIDHeader)
+	if t.outer.Left {
+		err := t.emitUnmatched(ctx, false)
+		if err != nil {
+			return err
+		}
+	}
 
-	key, err := t.rightSchema.Get(ctx, schemaVersionID, t.rightKey, data)
+	if t.outer.Right {
+		err := t.emitUnmatched(ctx, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// routeUnmatched hands the left events buffered for longer than the join
+// window to the unmatched handler and removes them from the left buffer.
+// Events the handler fails to take stay buffered and are retried by the next
+// sweep until the buffer window evicts them.
+func (t *TemporalJoinExecutor) routeUnmatched(ctx context.Context) error {
+	expired, err := t.leftBuffer.Expired(ctx, t.leftWindow)
 	if err != nil {
-		t.log.ErrorContext(ctx, "failed to get join key from right stream message", "right_stream", t.rightSourceName, "schema_version_id", schemaVersionID, "error", err)
-		return fmt.Errorf("failed to get join key from right stream message: %w", err)
+		return fmt.Errorf("failed to get expired left stream events: %w", err)
+	}
+
+	var swept []BufferedEvent
+	for _, ev := range expired {
+		err = t.unmatched.Add(ctx, ev.SchemaVersionID, ev.Data)
+		if err != nil {
+			t.log.WarnContext(ctx, "failed to handle unmatched left event", "key", ev.Key, "error", err)
+			continue
+		}
+		swept = append(swept, ev)
+	}
+
+	err = t.unmatched.Flush(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to flush unmatched left events: %w", err)
+	}
+
+	for _, ev := range swept {
+		err = t.leftBuffer.Remove(ctx, ev)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to delete unmatched left stream event", "key", ev.Key, "error", err)
+		}
+	}
+
+	if len(swept) > 0 {
+		t.log.DebugContext(ctx, "swept unmatched left events", "count", len(swept))
+	}
+
+	return nil
+}
+
+// emitUnmatched publishes the events of an outer side that found no match
+// within their window and the grace period, with NULLs for the fields of the
+// other side, and removes them from their buffer. Events that cannot be
+// emitted stay buffered and are retried by the next sweep until the buffer
+// window evicts them.
+func (t *TemporalJoinExecutor) emitUnmatched(ctx context.Context, right bool) error {
+	buffer, window := t.leftBuffer, t.leftWindow
+	orientation, sourceName, missingSourceName := internal.JoinLeft, t.leftSourceName, t.rightSourceName
+	if right {
+		buffer, window = t.rightBuffer, t.rightWindow
+		orientation, sourceName, missingSourceName = internal.JoinRight, t.rightSourceName, t.leftSourceName
 	}
 
-	err = t.rightKVStore.PutMessage(ctx, key, schemaVersionID, data)
+	events, err := buffer.Unmatched(ctx, window+t.outer.Grace)
 	if err != nil {
-		t.log.ErrorContext(ctx, "failed to put right stream message in KV store", "key", key, "error", err)
-		return fmt.Errorf("failed to put right stream message in KV store: %w", err)
+		return fmt.Errorf("failed to get unmatched %s stream events: %w", orientation, err)
+	}
+	if len(events) == 0 {
+		return nil
 	}
 
-	err = t.getFromleftStreamBuffer(ctx, msg, key, schemaVersionID, data)
+	missingSchemaVersionID, err := t.missingSchemaVersionID(ctx, !right)
 	if err != nil {
-		t.log.ErrorContext(ctx, "failed to get left stream data from buffer", "key", key, "error", err)
-		return fmt.Errorf("failed to get left stream data from buffer: %w", err)
+		return fmt.Errorf("failed to get %s schema version: %w", missingSourceName, err)
+	}
+
+	var emitted int
+	for _, ev := range events {
+		leftSchemaVersionID, rightSchemaVersionID := ev.SchemaVersionID, missingSchemaVersionID
+		if right {
+			leftSchemaVersionID, rightSchemaVersionID = missingSchemaVersionID, ev.SchemaVersionID
+		}
+
+		config, err := t.cfgStore.GetJoinConfig(ctx, t.leftSourceName, leftSchemaVersionID, t.rightSourceName, rightSchemaVersionID)
+		if err != nil {
+			return fmt.Errorf("failed to get join config: %w", err)
+		}
+
+		buf := joinedDataPool.Get(ctx)
+		msg, err := buildOuterJoinedMessage(buf, t.resultsPublisher.GetSubject(), sourceName, ev.Data, missingSourceName, config)
+		if err != nil {
+			joinedDataPool.Put(buf)
+			t.log.WarnContext(ctx, "failed to build unmatched event output", "orientation", orientation, "key", ev.Key, "error", err)
+			continue
+		}
+
+		err = t.publishJoinedMsg(ctx, nil, msg)
+		joinedDataPool.Put(buf)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to publish unmatched event", "orientation", orientation, "key", ev.Key, "error", err)
+			return fmt.Errorf("failed to publish unmatched %s stream event: %w", orientation, err)
+		}
+
+		err = buffer.Remove(ctx, ev)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to delete emitted unmatched event", "orientation", orientation, "key", ev.Key, "error", err)
+		}
+		emitted++
+	}
+
+	if emitted > 0 {
+		t.log.DebugContext(ctx, "emitted unmatched events", "orientation", orientation, "count", emitted)
 	}
 
 	return nil
 }
+
+// missingSchemaVersionID returns the schema version the NULL fields of a
+// missing side are emitted with, the one of its last event or the latest
+// version of its source when no event arrived since the start.
+func (t *TemporalJoinExecutor) missingSchemaVersionID(ctx context.Context, right bool) (string, error) {
+	if right {
+		if t.rightSchemaVersionID != "" {
+			return t.rightSchemaVersionID, nil
+		}
+		return t.rightSchema.LatestVersionID(ctx)
+	}
+
+	if t.leftSchemaVersionID != "" {
+		return t.leftSchemaVersionID, nil
+	}
+	return t.leftSchema.LatestVersionID(ctx)
+}
+
+// startBufferLookupSpan starts the span of a lookup in the buffer of the given
+// source, made when an event of the other source arrives.
+func startBufferLookupSpan(ctx context.Context, bufferSource string) (context.Context, trace.Span) {
+	return observability.StartSpan(ctx, observability.SpanJoinBufferLookup,
+		trace.WithAttributes(attribute.String("join.buffer_source", bufferSource)),
+	)
+}
+
+// endBufferLookupSpan ends the lookup span, hit tells whether the buffer held
+// events of the key.
+func endBufferLookupSpan(span trace.Span, hit bool, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.Bool("join.buffer_hit", hit))
+	}
+	observability.EndSpan(span, err)
+}
@@ -0,0 +1,135 @@
+package join
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// UnmatchedHandler receives the left events that found no match within the
+// join window. Events are added during a sweep and flushed at its end, they
+// are removed from the left buffer only once the flush succeeded.
+type UnmatchedHandler interface {
+	Add(ctx context.Context, schemaVersionID string, data []byte) error
+	Flush(ctx context.Context) error
+}
+
+// DLQUnmatchedHandler publishes unmatched events to the pipeline DLQ.
+type DLQUnmatchedHandler struct {
+	dlqPublisher stream.Publisher
+}
+
+func NewDLQUnmatchedHandler(dlqPublisher stream.Publisher) *DLQUnmatchedHandler {
+	return &DLQUnmatchedHandler{dlqPublisher: dlqPublisher}
+}
+
+func (h *DLQUnmatchedHandler) Add(ctx context.Context, _ string, data []byte) error {
+	dlqMsg := models.NewDLQMessage(internal.RoleJoin, "no match within the join window", data).
+		WithReason(observability.DLQReasonJoinUnmatched)
+	msg, err := dlqMsg.ToJSON()
+	if err != nil {
+		return fmt.Errorf("convert DLQ message to JSON: %w", err)
+	}
+
+	err = h.dlqPublisher.Publish(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("publish to DLQ: %w", err)
+	}
+
+	observability.RecordDLQWrite(ctx, internal.RoleJoin, observability.DLQReasonJoinUnmatched, 1)
+	observability.RecordJoinUnmatched(ctx, internal.JoinUnmatchedPolicyDLQ, 1)
+	return nil
+}
+
+// Flush is a no-op, events are published as they are added.
+func (h *DLQUnmatchedHandler) Flush(context.Context) error {
+	return nil
+}
+
+type unmatchedTableClient interface {
+	PrepareBatch(ctx context.Context, query string) (driver.Batch, error)
+}
+
+// unmatchedMappingVersion keys the mapper metadata, the unmatched mapping
+// does not change with the schema version of the left events.
+const unmatchedMappingVersion = "unmatched"
+
+// TableUnmatchedHandler maps unmatched events with the unmatched mapping and
+// inserts them into the unmatched table in one batch per sweep.
+type TableUnmatchedHandler struct {
+	client      unmatchedTableClient
+	insertQuery string
+	mapping     map[string]models.Mapping
+	mapper      *mapper.KafkaToClickHouseMapper
+
+	mu   sync.Mutex
+	rows [][]any
+}
+
+func NewTableUnmatchedHandler(chClient unmatchedTableClient, cfg models.JoinUnmatchedConfig, database string) *TableUnmatchedHandler {
+	mapping := make(map[string]models.Mapping, len(cfg.Mapping))
+	for _, m := range cfg.Mapping {
+		mapping[m.DestinationField] = m
+	}
+
+	return &TableUnmatchedHandler{
+		client:      chClient,
+		insertQuery: cfg.InsertQuery(database),
+		mapping:     mapping,
+		mapper:      mapper.NewKafkaToClickHouseMapper(),
+	}
+}
+
+func (h *TableUnmatchedHandler) Add(_ context.Context, _ string, data []byte) error {
+	values, err := h.mapper.Map(data, unmatchedMappingVersion, h.mapping)
+	if err != nil {
+		return fmt.Errorf("map unmatched event: %w", err)
+	}
+
+	h.mu.Lock()
+	h.rows = append(h.rows, values)
+	h.mu.Unlock()
+	return nil
+}
+
+// Flush inserts the collected rows. Rows are dropped on failure, their events
+// stay in the left buffer and are added again by the next sweep.
+func (h *TableUnmatchedHandler) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	rows := h.rows
+	h.rows = nil
+	h.mu.Unlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	batch, err := h.client.PrepareBatch(ctx, h.insertQuery)
+	if err != nil {
+		return fmt.Errorf("prepare unmatched table batch: %w", err)
+	}
+
+	for _, row := range rows {
+		err = batch.Append(row...)
+		if err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("append to unmatched table batch: %w", err)
+		}
+	}
+
+	err = batch.Send()
+	if err != nil {
+		return fmt.Errorf("send unmatched table batch: %w", err)
+	}
+
+	observability.RecordJoinUnmatched(ctx, internal.JoinUnmatchedPolicyTable, int64(len(rows)))
+	return nil
+}
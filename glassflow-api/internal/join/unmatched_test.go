@@ -0,0 +1,193 @@
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kv"
+)
+
+type memoryEntry struct {
+	value     string
+	version   string
+	isMessage bool
+	created   time.Time
+}
+
+// memoryKVStore keeps the buffer in memory, with the put time of each value.
+type memoryKVStore struct {
+	entries map[string]memoryEntry
+}
+
+func newMemoryKVStore() *memoryKVStore {
+	return &memoryKVStore{entries: make(map[string]memoryEntry)}
+}
+
+func (m *memoryKVStore) PutString(_ context.Context, key any, value string) error {
+	m.entries[fmt.Sprintf("%v", key)] = memoryEntry{value: value, created: time.Now()}
+	return nil
+}
+
+func (m *memoryKVStore) PutMessage(_ context.Context, key any, schemaVersionID string, data []byte) error {
+	m.entries[fmt.Sprintf("%v", key)] = memoryEntry{value: string(data), version: schemaVersionID, isMessage: true, created: time.Now()}
+	return nil
+}
+
+func (m *memoryKVStore) GetString(_ context.Context, key any) (string, error) {
+	e, ok := m.entries[fmt.Sprintf("%v", key)]
+	if !ok {
+		return "", jetstream.ErrKeyNotFound
+	}
+	return e.value, nil
+}
+
+func (m *memoryKVStore) GetMessage(ctx context.Context, key any) (string, []byte, error) {
+	version, data, _, err := m.GetMessageCreated(ctx, key)
+	return version, data, err
+}
+
+func (m *memoryKVStore) GetMessageCreated(_ context.Context, key any) (string, []byte, time.Time, error) {
+	e, ok := m.entries[fmt.Sprintf("%v", key)]
+	if !ok {
+		return "", nil, time.Time{}, jetstream.ErrKeyNotFound
+	}
+	if !e.isMessage {
+		return "", nil, time.Time{}, kv.ErrNotMessage
+	}
+	return e.version, []byte(e.value), e.created, nil
+}
+
+func (m *memoryKVStore) Delete(_ context.Context, key any) error {
+	delete(m.entries, fmt.Sprintf("%v", key))
+	return nil
+}
+
+func (m *memoryKVStore) Keys(context.Context) ([]string, error) {
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+type recordingUnmatchedHandler struct {
+	added    []string
+	flushed  []string
+	failAdd  string
+	flushErr error
+}
+
+func (h *recordingUnmatchedHandler) Add(_ context.Context, _ string, data []byte) error {
+	if string(data) == h.failAdd {
+		return errors.New("cannot map event")
+	}
+	h.added = append(h.added, string(data))
+	return nil
+}
+
+func (h *recordingUnmatchedHandler) Flush(context.Context) error {
+	if h.flushErr != nil {
+		h.added = nil
+		return h.flushErr
+	}
+	h.flushed = append(h.flushed, h.added...)
+	h.added = nil
+	return nil
+}
+
+func newSweepExecutor(t *testing.T, store kv.KeyValueStore, handler UnmatchedHandler) *TemporalJoinExecutor {
+	t.Helper()
+
+	leftBuffer := NewWindowBuffer(store, nil, internal.JoinLeft, time.Hour, false, slog.Default())
+	require.NoError(t, leftBuffer.Load(context.Background()))
+
+	return NewTemporalJoinExecutor(
+		nil, nil, nil, nil,
+		leftBuffer, NewWindowBuffer(newMemoryKVStore(), nil, internal.JoinRight, time.Hour, true, slog.Default()),
+		handler,
+		OuterJoin{},
+		time.Minute, time.Minute,
+		"orders", "users", "user_id", "id",
+		slog.Default(),
+		"pipeline-1",
+		nil,
+	)
+}
+
+func TestSweepUnmatched_RoutesExpiredLeftEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	expired, fresh, unmappable := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	require.NoError(t, store.PutMessage(ctx, expired, "1", []byte(`{"id":"expired"}`)))
+	require.NoError(t, store.PutMessage(ctx, fresh, "1", []byte(`{"id":"fresh"}`)))
+	require.NoError(t, store.PutMessage(ctx, unmappable, "1", []byte(`{"id":"unmappable"}`)))
+	// Buffers written by earlier versions index the events by join key, join
+	// keys that are UUIDs are not events themselves
+	indexKey := uuid.NewString()
+	require.NoError(t, store.PutString(ctx, indexKey, expired+" "+unmappable))
+
+	for _, key := range []string{expired, unmappable, indexKey} {
+		e := store.entries[key]
+		e.created = time.Now().Add(-2 * time.Minute)
+		store.entries[key] = e
+	}
+
+	handler := &recordingUnmatchedHandler{failAdd: `{"id":"unmappable"}`}
+	executor := newSweepExecutor(t, store, handler)
+
+	require.NoError(t, executor.SweepUnmatched(ctx))
+
+	assert.Equal(t, []string{`{"id":"expired"}`}, handler.flushed)
+	assert.NotContains(t, store.entries, expired)
+	assert.Contains(t, store.entries, fresh)
+	assert.Contains(t, store.entries, unmappable, "events the handler rejects stay until the buffer TTL")
+	assert.Contains(t, store.entries, indexKey)
+}
+
+func TestSweepUnmatched_KeepsEventsWhenFlushFails(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	key := uuid.NewString()
+	require.NoError(t, store.PutMessage(ctx, key, "1", []byte(`{"id":"expired"}`)))
+	e := store.entries[key]
+	e.created = time.Now().Add(-2 * time.Minute)
+	store.entries[key] = e
+
+	handler := &recordingUnmatchedHandler{flushErr: errors.New("clickhouse unavailable")}
+	executor := newSweepExecutor(t, store, handler)
+
+	require.Error(t, executor.SweepUnmatched(ctx))
+	assert.Contains(t, store.entries, key)
+
+	handler.flushErr = nil
+	require.NoError(t, executor.SweepUnmatched(ctx))
+	assert.Equal(t, []string{`{"id":"expired"}`}, handler.flushed)
+	assert.NotContains(t, store.entries, key)
+}
+
+func TestSweepUnmatched_DisabledIsNoop(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryKVStore()
+
+	key := uuid.NewString()
+	require.NoError(t, store.PutMessage(ctx, key, "1", []byte(`{}`)))
+	e := store.entries[key]
+	e.created = time.Now().Add(-time.Hour)
+	store.entries[key] = e
+
+	executor := newSweepExecutor(t, store, nil)
+	require.NoError(t, executor.SweepUnmatched(ctx))
+	assert.Contains(t, store.entries, key)
+}
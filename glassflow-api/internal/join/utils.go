@@ -1,6 +1,7 @@
 package join
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -8,8 +9,13 @@ import (
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/pool"
 )
 
+// joinedDataPool holds the buffers joined messages are encoded into. A buffer
+// is returned once its message has been published.
+var joinedDataPool = pool.NewBufferPool("join", "joined_data")
+
 // prepareData extracts fields from data according to join rules and maps them to output names.
 // sourceID identifies which source's rules to use from the JoinAuxConfig.
 // Returns a map with output field names as keys and extracted values.
@@ -39,9 +45,9 @@ func prepareData(sourceID string, data []byte, config *models.JoinAuxConfig) (ma
 	return result, nil
 }
 
-// joinData merges two prepared data maps into a single JSON byte slice.
+// joinData merges two prepared data maps and encodes them as JSON into buf.
 // Fields from both maps are combined; if there are duplicate keys, right overwrites left.
-func joinData(leftData, rightData map[string]any) ([]byte, error) {
+func joinData(buf *bytes.Buffer, leftData, rightData map[string]any) error {
 	result := make(map[string]any, len(leftData)+len(rightData))
 
 	for k, v := range leftData {
@@ -51,17 +57,21 @@ func joinData(leftData, rightData map[string]any) ([]byte, error) {
 		result[k] = v
 	}
 
-	joined, err := json.Marshal(result)
+	err := json.NewEncoder(buf).Encode(result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal joined data: %w", err)
+		return fmt.Errorf("failed to marshal joined data: %w", err)
 	}
+	// Encode terminates the value with a newline, Marshal does not
+	buf.Truncate(buf.Len() - 1)
 
-	return joined, nil
+	return nil
 }
 
 // buildJoinedMessage prepares and joins left and right data according to the config,
 // then creates a nats.Msg with the output schema version ID in the header.
+// The message data is backed by buf, which must outlive the publish.
 func buildJoinedMessage(
+	buf *bytes.Buffer,
 	subject string,
 	leftSourceID string,
 	leftData []byte,
@@ -79,13 +89,62 @@ func buildJoinedMessage(
 		return nil, fmt.Errorf("failed to prepare right data: %w", err)
 	}
 
-	joinedData, err := joinData(leftPart, rightPart)
+	err = joinData(buf, leftPart, rightPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join data: %w", err)
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = buf.Bytes()
+	msg.Header.Set(internal.SchemaVersionIDHeader, config.OutputSchemaVersionID)
+
+	return msg, nil
+}
+
+// nullData returns the output fields of the source's join rules set to NULL.
+func nullData(sourceID string, config *models.JoinAuxConfig) (map[string]any, error) {
+	rules, ok := config.SourceJoinRules[sourceID]
+	if !ok {
+		return nil, fmt.Errorf("no join rules found for source %s", sourceID)
+	}
+
+	result := make(map[string]any, len(rules))
+	for _, outputName := range rules {
+		result[outputName] = nil
+	}
+
+	return result, nil
+}
+
+// buildOuterJoinedMessage prepares the data of an event without a match like
+// buildJoinedMessage, with the output fields of the missing source set to
+// NULL.
+func buildOuterJoinedMessage(
+	buf *bytes.Buffer,
+	subject string,
+	sourceID string,
+	data []byte,
+	missingSourceID string,
+	config *models.JoinAuxConfig,
+) (*nats.Msg, error) {
+	part, err := prepareData(sourceID, data, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare %s data: %w", sourceID, err)
+	}
+
+	missing, err := nullData(missingSourceID, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare %s data: %w", missingSourceID, err)
+	}
+
+	// The fields of the event win over NULLs of the same output name
+	err = joinData(buf, missing, part)
 	if err != nil {
 		return nil, fmt.Errorf("failed to join data: %w", err)
 	}
 
 	msg := nats.NewMsg(subject)
-	msg.Data = joinedData
+	msg.Data = buf.Bytes()
 	msg.Header.Set(internal.SchemaVersionIDHeader, config.OutputSchemaVersionID)
 
 	return msg, nil
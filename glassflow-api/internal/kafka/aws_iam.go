@@ -0,0 +1,260 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/sasl/aws"
+)
+
+// ErrNoAWSCredentials is returned for AWS_MSK_IAM when the pod environment
+// provides no AWS credentials.
+var ErrNoAWSCredentials = errors.New("no AWS credentials in the environment")
+
+const (
+	// awsCredentialsRefreshWindow is how long before they expire cached
+	// credentials are renewed, a SASL session must not outlive them.
+	awsCredentialsRefreshWindow = 5 * time.Minute
+	awsCredentialsTimeout       = 10 * time.Second
+	// awsContainerCredentialsHost serves the relative URI of the container
+	// credentials.
+	awsContainerCredentialsHost = "http://169.254.170.2"
+)
+
+// awsContainerCredentialsAddrs are the addresses besides loopback a full
+// container credentials URI may point to: the ECS agent and the EKS Pod
+// Identity agent over IPv4 and IPv6. Any other host could be sent the
+// authorization token of the pod.
+var awsContainerCredentialsAddrs = []netip.Addr{
+	netip.MustParseAddr("169.254.170.2"),
+	netip.MustParseAddr("169.254.170.23"),
+	netip.MustParseAddr("fd00:ec2::23"),
+}
+
+// podAWSCredentials is shared by the Kafka clients of the process, they
+// authenticate with the same role.
+var podAWSCredentials = newAWSCredentials(os.Getenv)
+
+// awsCredentials resolves the AWS credentials of the pod for AWS_MSK_IAM,
+// like the AWS SDKs do: from the access key environment variables, the web
+// identity token of IAM Roles for Service Accounts or the container
+// credentials endpoint of EKS Pod Identity. The role of the node through the
+// instance metadata service is not supported. Temporary credentials are cached
+// until shortly before they expire.
+type awsCredentials struct {
+	getenv func(string) string
+	client *http.Client
+
+	mu      sync.Mutex
+	auth    aws.Auth
+	expires time.Time
+}
+
+func newAWSCredentials(getenv func(string) string) *awsCredentials {
+	return &awsCredentials{
+		getenv: getenv,
+		client: &http.Client{Timeout: awsCredentialsTimeout},
+	}
+}
+
+// Retrieve returns the cached credentials, or resolves them again when
+// they are about to expire. It is the callback of the AWS_MSK_IAM mechanism.
+func (c *awsCredentials) Retrieve(ctx context.Context) (aws.Auth, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.auth.AccessKey != "" && (c.expires.IsZero() || time.Until(c.expires) > awsCredentialsRefreshWindow) {
+		return c.auth, nil
+	}
+
+	auth, expires, err := c.resolve(ctx)
+	if err != nil {
+		return aws.Auth{}, err
+	}
+	c.auth, c.expires = auth, expires
+
+	return auth, nil
+}
+
+func (c *awsCredentials) resolve(ctx context.Context) (aws.Auth, time.Time, error) {
+	if accessKey := c.getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return aws.Auth{
+			AccessKey:    accessKey,
+			SecretKey:    c.getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: c.getenv("AWS_SESSION_TOKEN"),
+		}, time.Time{}, nil
+	}
+
+	if c.getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && c.getenv("AWS_ROLE_ARN") != "" {
+		return c.webIdentity(ctx)
+	}
+
+	if c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" || c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" {
+		return c.container(ctx)
+	}
+
+	return aws.Auth{}, time.Time{}, ErrNoAWSCredentials
+}
+
+type assumeRoleWithWebIdentityResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:"AssumeRoleWithWebIdentityResult"`
+}
+
+// webIdentity exchanges the service account token of the pod for the
+// credentials of its role with STS.
+func (c *awsCredentials) webIdentity(ctx context.Context) (aws.Auth, time.Time, error) {
+	token, err := os.ReadFile(c.getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return aws.Auth{}, time.Time{}, fmt.Errorf("read web identity token: %w", err)
+	}
+
+	sessionName := c.getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "glassflow-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {c.getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.stsEndpoint(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return aws.Auth{}, time.Time{}, fmt.Errorf("create sts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp assumeRoleWithWebIdentityResponse
+	if err := c.do(req, func(body io.Reader) error { return xml.NewDecoder(body).Decode(&resp) }); err != nil {
+		return aws.Auth{}, time.Time{}, fmt.Errorf("assume role with web identity: %w", err)
+	}
+
+	creds := resp.Result.Credentials
+	return aws.Auth{
+		AccessKey:    creds.AccessKeyID,
+		SecretKey:    creds.SecretAccessKey,
+		SessionToken: creds.SessionToken,
+	}, creds.Expiration, nil
+}
+
+// stsEndpoint returns the regional STS endpoint of the pod, the endpoint
+// override of the SDKs first.
+func (c *awsCredentials) stsEndpoint() string {
+	if endpoint := c.getenv("AWS_ENDPOINT_URL_STS"); endpoint != "" {
+		return endpoint
+	}
+
+	region := c.getenv("AWS_REGION")
+	if region == "" {
+		region = c.getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return "https://sts.amazonaws.com/"
+	}
+	return "https://sts." + region + ".amazonaws.com/"
+}
+
+type containerCredentialsResponse struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// container gets the credentials of the pod from the container credentials
+// endpoint, the EKS Pod Identity agent. A full URI must point to loopback or
+// to one of the agent addresses.
+func (c *awsCredentials) container(ctx context.Context) (aws.Auth, time.Time, error) {
+	endpoint := c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if endpoint == "" {
+		endpoint = awsContainerCredentialsHost + c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	} else if err := checkContainerCredentialsURI(endpoint); err != nil {
+		return aws.Auth{}, time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return aws.Auth{}, time.Time{}, fmt.Errorf("create container credentials request: %w", err)
+	}
+
+	authorization := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return aws.Auth{}, time.Time{}, fmt.Errorf("read container authorization token: %w", err)
+		}
+		authorization = strings.TrimSpace(string(token))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	var resp containerCredentialsResponse
+	if err := c.do(req, func(body io.Reader) error { return json.NewDecoder(body).Decode(&resp) }); err != nil {
+		return aws.Auth{}, time.Time{}, fmt.Errorf("get container credentials: %w", err)
+	}
+
+	return aws.Auth{
+		AccessKey:    resp.AccessKeyID,
+		SecretKey:    resp.SecretAccessKey,
+		SessionToken: resp.Token,
+	}, resp.Expiration, nil
+}
+
+func checkContainerCredentialsURI(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("parse container credentials uri: %w", err)
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	addr, err := netip.ParseAddr(host)
+	if err == nil && (addr.IsLoopback() || slices.Contains(awsContainerCredentialsAddrs, addr)) {
+		return nil
+	}
+	return fmt.Errorf("container credentials uri host %q is neither loopback nor a container credentials agent", host)
+}
+
+func (c *awsCredentials) do(req *http.Request, decode func(io.Reader) error) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := decode(resp.Body); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
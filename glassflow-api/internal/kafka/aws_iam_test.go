@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFunc(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestAWSCredentials_Environment(t *testing.T) {
+	creds := newAWSCredentials(envFunc(map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	}))
+
+	auth, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "AKID", auth.AccessKey)
+	assert.Equal(t, "secret", auth.SecretKey)
+}
+
+func TestAWSCredentials_NoCredentials(t *testing.T) {
+	_, err := newAWSCredentials(envFunc(nil)).Retrieve(context.Background())
+	require.ErrorIs(t, err, ErrNoAWSCredentials)
+}
+
+func TestAWSCredentials_WebIdentity(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("jwt\n"), 0o600))
+
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	calls := 0
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.PostForm.Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123456789012:role/ingestor", r.PostForm.Get("RoleArn"))
+		assert.Equal(t, "jwt", r.PostForm.Get("WebIdentityToken"))
+
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>` +
+			`<AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>` +
+			`<Expiration>` + expiration.Format(time.RFC3339) + `</Expiration>` +
+			`</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+
+	creds := newAWSCredentials(envFunc(map[string]string{
+		"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+		"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/ingestor",
+		"AWS_ENDPOINT_URL_STS":        sts.URL,
+	}))
+
+	auth, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", auth.AccessKey)
+	assert.Equal(t, "session", auth.SessionToken)
+
+	_, err = creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "credentials are cached until they expire")
+}
+
+func TestAWSCredentials_Container(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("pod-identity"), 0o600))
+
+	calls := 0
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "pod-identity", r.Header.Get("Authorization"))
+		// Expires within the refresh window, every call resolves again
+		_, _ = w.Write([]byte(`{"AccessKeyId":"ASIA","SecretAccessKey":"secret","Token":"session","Expiration":"` +
+			time.Now().Add(time.Minute).UTC().Format(time.RFC3339) + `"}`))
+	}))
+	defer agent.Close()
+
+	creds := newAWSCredentials(envFunc(map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI":     agent.URL,
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE": tokenFile,
+	}))
+
+	auth, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", auth.AccessKey)
+	assert.Equal(t, "session", auth.SessionToken)
+
+	_, err = creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestAWSCredentials_ContainerError(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer agent.Close()
+
+	_, err := newAWSCredentials(envFunc(map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": agent.URL,
+	})).Retrieve(context.Background())
+	require.ErrorContains(t, err, "status 403")
+}
+
+func TestCheckContainerCredentialsURI(t *testing.T) {
+	for _, endpoint := range []string{
+		"http://127.0.0.1:8080/credentials",
+		"http://localhost/credentials",
+		"http://[::1]/credentials",
+		"http://169.254.170.2/v2/credentials",
+		"http://169.254.170.23/v1/credentials",
+		"http://[fd00:ec2::23]/v1/credentials",
+	} {
+		assert.NoError(t, checkContainerCredentialsURI(endpoint), endpoint)
+	}
+
+	for _, endpoint := range []string{
+		"http://169.254.169.254/latest/meta-data/iam/security-credentials/",
+		"https://credentials.example.com/",
+		"http://10.0.0.1/credentials",
+	} {
+		assert.Error(t, checkContainerCredentialsURI(endpoint), endpoint)
+	}
+}
+
+func TestAWSCredentials_ContainerForbiddenHost(t *testing.T) {
+	_, err := newAWSCredentials(envFunc(map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": "https://credentials.example.com/",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "token",
+	})).Retrieve(context.Background())
+	require.ErrorContains(t, err, "neither loopback nor a container credentials agent")
+}
@@ -5,16 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	krb5client "github.com/jcmturner/gokrb5/v8/client"
 	krb5config "github.com/jcmturner/gokrb5/v8/config"
 	krb5keytab "github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/aws"
 	"github.com/twmb/franz-go/pkg/sasl/kerberos"
 	"github.com/twmb/franz-go/pkg/sasl/plain"
 	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
@@ -27,16 +35,38 @@ type MessageProcessor interface {
 	ProcessBatch(ctx context.Context, batch []*kgo.Record) (*kgo.Record, error)
 }
 
+// FlowGate holds back polling while the components downstream of the
+// ingestor are over their flow control thresholds.
+type FlowGate interface {
+	Wait(ctx context.Context) error
+}
+
 type Consumer struct {
 	client    *kgo.Client
+	admin     *kadm.Client
 	topic     string
 	groupID   string
+	static    bool
 	timeout   time.Duration
 	batch     []*kgo.Record
 	processor MessageProcessor
+	flowGate  FlowGate
 	log       *slog.Logger
 	cancel    context.CancelFunc
 	closeCh   chan struct{}
+
+	// replicas, replicaIndex and earliest assign the partitions added to
+	// the topic while the consumer runs, partitions is the last count seen,
+	// zero when unknown
+	replicas     int
+	replicaIndex int
+	earliest     bool
+	partitions   int
+
+	// uncommittable is set while a failed batch leaves the polled cursor
+	// ahead of what was processed, it must not be committed on revocation
+	// until the cursor is rewound.
+	uncommittable atomic.Bool
 }
 
 type kgoLogger struct {
@@ -66,8 +96,25 @@ func (l *kgoLogger) Log(level kgo.LogLevel, msg string, keyvals ...any) {
 	l.log.Log(context.Background(), slogLevel, msg, keyvals...)
 }
 
-func NewConsumer(conn models.KafkaConnectionParamsConfig, topic models.KafkaTopicsConfig, log *slog.Logger) (zero *Consumer, _ error) {
-	clientOpts, err := buildClientOptions(conn, topic)
+func NewConsumer(conn models.KafkaConnectionParamsConfig, topic models.KafkaTopicsConfig, replicaIndex int, flowGate FlowGate, log *slog.Logger) (zero *Consumer, _ error) {
+	c := &Consumer{
+		topic:     topic.Name,
+		flowGate:  flowGate,
+		groupID:   topic.ConsumerGroupName,
+		static:    topic.PartitionAssignment == internal.PartitionAssignmentStatic,
+		log:       log,
+		timeout:   internal.DefaultKafkaBatchTimeout,
+		batch:     make([]*kgo.Record, 0),
+		closeCh:   make(chan struct{}),
+		processor: nil,
+		cancel:    nil,
+
+		replicas:     max(topic.Replicas, 1),
+		replicaIndex: replicaIndex,
+		earliest:     topic.ConsumerGroupInitialOffset == internal.InitialOffsetEarliest,
+	}
+
+	clientOpts, err := buildClientOptions(conn, topic, c.static, replicaIndex, c.onPartitionsRevoked)
 	if err != nil {
 		return &Consumer{}, fmt.Errorf("build client options: %w", err)
 	}
@@ -86,39 +133,66 @@ func NewConsumer(conn models.KafkaConnectionParamsConfig, topic models.KafkaTopi
 		return zero, fmt.Errorf("failed to ping kafka brokers: %w", err)
 	}
 
-	return &Consumer{
-		client:    client,
-		topic:     topic.Name,
-		groupID:   topic.ConsumerGroupName,
-		log:       log,
-		timeout:   internal.DefaultKafkaBatchTimeout,
-		batch:     make([]*kgo.Record, 0),
-		closeCh:   make(chan struct{}),
-		processor: nil,
-		cancel:    nil,
-	}, nil
+	// Committed offsets of another cluster would skip or replay records, a
+	// broker address repointed at another cluster must not be consumed
+	if conn.ClusterID != "" {
+		err = checkClusterID(ctx, client, conn.ClusterID)
+		if err != nil {
+			client.Close()
+			return zero, err
+		}
+	}
+
+	admin := kadm.NewClient(client)
+
+	if c.static {
+		c.partitions, err = assignStaticPartitions(ctx, client, admin, topic, replicaIndex, log)
+		if err != nil {
+			client.Close()
+			return zero, fmt.Errorf("assign static partitions: %w", err)
+		}
+	} else {
+		// The group member waits for a missing topic, the partitions are
+		// counted once it exists
+		c.partitions, err = partitionCount(ctx, admin, topic.Name)
+		if err != nil {
+			log.Warn("Failed to count Kafka topic partitions", slog.String("topic", topic.Name), slog.Any("error", err))
+		}
+	}
+
+	c.client = client
+	c.admin = admin
+
+	return c, nil
 }
 
-func buildClientOptions(conn models.KafkaConnectionParamsConfig, topic models.KafkaTopicsConfig) ([]kgo.Opt, error) {
+func buildClientOptions(
+	conn models.KafkaConnectionParamsConfig,
+	topic models.KafkaTopicsConfig,
+	static bool,
+	replicaIndex int,
+	onRevoked func(context.Context, *kgo.Client, map[string][]int32),
+) ([]kgo.Opt, error) {
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(conn.Brokers...),
-		kgo.ConsumerGroup(topic.ConsumerGroupName),
-		kgo.ConsumeTopics(topic.Name),
 		kgo.ClientID(internal.ClientID),
 
-		// Session configuration
-		kgo.SessionTimeout(internal.KafkaSessionTimeout),
-		kgo.HeartbeatInterval(internal.KafkaHeartbeatInterval),
-
-		// Disable auto commit - we handle commits manually
-		kgo.DisableAutoCommit(),
-
 		// Fetch configuration
 		kgo.FetchMinBytes(internal.KafkaMinFetchBytes),
 		kgo.FetchMaxBytes(internal.KafkaMaxFetchBytes),
 		kgo.FetchMaxWait(internal.KafkaMaxWait),
 	}
 
+	// Static assignment never joins the group: partitions are added after
+	// the client is created, once the partition count is known.
+	if !static {
+		groupOpts, err := buildGroupOptions(topic, replicaIndex, onRevoked)
+		if err != nil {
+			return nil, fmt.Errorf("configure consumer group: %w", err)
+		}
+		opts = append(opts, groupOpts...)
+	}
+
 	// Set initial offset
 	if topic.ConsumerGroupInitialOffset == internal.InitialOffsetEarliest {
 		opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
@@ -137,6 +211,170 @@ func buildClientOptions(conn models.KafkaConnectionParamsConfig, topic models.Ka
 	return opts, nil
 }
 
+// buildGroupOptions configures consumer-group membership: the rebalance
+// balancer and, when static membership is enabled, a stable group.instance.id
+// per replica so rolling restarts don't trigger a rebalance.
+//
+// Rebalances are blocked while a polled batch is in flight (see
+// handleBatchMessages), so revocation callbacks only ever observe fully
+// processed offsets and can safely commit them before partitions move.
+func buildGroupOptions(
+	topic models.KafkaTopicsConfig,
+	replicaIndex int,
+	onRevoked func(context.Context, *kgo.Client, map[string][]int32),
+) ([]kgo.Opt, error) {
+	opts := []kgo.Opt{
+		kgo.ConsumerGroup(topic.ConsumerGroupName),
+		kgo.ConsumeTopics(topic.Name),
+
+		// Heartbeat configuration
+		kgo.HeartbeatInterval(internal.KafkaHeartbeatInterval),
+
+		// Disable auto commit - we handle commits manually
+		kgo.DisableAutoCommit(),
+
+		kgo.BlockRebalanceOnPoll(),
+		kgo.OnPartitionsRevoked(onRevoked),
+	}
+
+	switch topic.RebalanceStrategy {
+	case internal.RebalanceStrategyEager:
+		opts = append(opts, kgo.Balancers(kgo.StickyBalancer()))
+	case internal.RebalanceStrategyCooperativeSticky, "":
+		opts = append(opts, kgo.Balancers(kgo.CooperativeStickyBalancer()))
+	default:
+		return nil, fmt.Errorf("unsupported rebalance strategy: %s", topic.RebalanceStrategy)
+	}
+
+	if topic.StaticMembership {
+		// A longer session timeout lets a restarted replica rejoin under the
+		// same instance id before the coordinator evicts it.
+		opts = append(opts,
+			kgo.InstanceID(groupInstanceID(topic, replicaIndex)),
+			kgo.SessionTimeout(internal.KafkaStaticMemberSessionTimeout),
+		)
+	} else {
+		opts = append(opts, kgo.SessionTimeout(internal.KafkaSessionTimeout))
+	}
+
+	return opts, nil
+}
+
+// groupInstanceID returns the group.instance.id for a replica. The consumer
+// group is shared by every topic of a pipeline, so the id is scoped by topic.
+func groupInstanceID(topic models.KafkaTopicsConfig, replicaIndex int) string {
+	return fmt.Sprintf("%s-%s-%d", topic.ConsumerGroupName, models.SanitizeNATSSubject(topic.Name), replicaIndex)
+}
+
+// assignStaticPartitions resolves the partitions owned by this replica
+// (partition % replicas == replicaIndex) and starts consuming them directly,
+// resuming from the offsets committed under the consumer group name. It
+// returns the partition count of the topic.
+func assignStaticPartitions(
+	ctx context.Context,
+	client *kgo.Client,
+	admin *kadm.Client,
+	topic models.KafkaTopicsConfig,
+	replicaIndex int,
+	log *slog.Logger,
+) (int, error) {
+	replicas := max(topic.Replicas, 1)
+	if replicaIndex < 0 || replicaIndex >= replicas {
+		return 0, fmt.Errorf("replica index %d out of range for %d replicas", replicaIndex, replicas)
+	}
+
+	count, err := partitionCount(ctx, admin, topic.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	owned := staticPartitions(count, replicas, replicaIndex)
+	if len(owned) == 0 {
+		log.Warn("No partitions assigned to this replica; more replicas than partitions",
+			slog.String("topic", topic.Name),
+			slog.Int("partitions", count),
+			slog.Int("replicas", replicas),
+			slog.Int("replica_index", replicaIndex))
+		return count, nil
+	}
+
+	resetOffset := kgo.NewOffset().AtEnd()
+	if topic.ConsumerGroupInitialOffset == internal.InitialOffsetEarliest {
+		resetOffset = kgo.NewOffset().AtStart()
+	}
+
+	err = consumeStaticPartitions(ctx, client, admin, topic.ConsumerGroupName, topic.Name, owned, resetOffset)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Info("Statically assigned Kafka partitions",
+		slog.String("topic", topic.Name),
+		slog.Int("replica_index", replicaIndex),
+		slog.Any("partitions", owned))
+
+	return count, nil
+}
+
+// consumeStaticPartitions starts consuming the partitions from the offsets
+// committed under the consumer group name, from resetOffset for the ones
+// without a committed offset.
+func consumeStaticPartitions(
+	ctx context.Context,
+	client *kgo.Client,
+	admin *kadm.Client,
+	group, topic string,
+	owned []int32,
+	resetOffset kgo.Offset,
+) error {
+	committed, err := admin.FetchOffsets(ctx, group)
+	if err != nil {
+		return fmt.Errorf("fetch committed offsets: %w", err)
+	}
+
+	partitions := make(map[int32]kgo.Offset, len(owned))
+	for _, p := range owned {
+		offset := resetOffset
+		if o, ok := committed.Lookup(topic, p); ok && o.Err == nil && o.At >= 0 {
+			offset = kgo.NewOffset().At(o.At)
+		}
+		partitions[p] = offset
+	}
+
+	client.AddConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions})
+
+	return nil
+}
+
+// addedPartitions returns the partitions between previous and current
+// partition counts the replica at replicaIndex owns, all of them when
+// replicas is zero.
+func addedPartitions(previous, current, replicas, replicaIndex int) []int32 {
+	if replicas == 0 {
+		replicas, replicaIndex = 1, 0
+	}
+	var added []int32
+	for _, p := range staticPartitions(current, replicas, replicaIndex) {
+		if int(p) >= previous {
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// staticPartitions returns the partitions of a topic owned by the replica at
+// replicaIndex when partitions are spread round-robin over replicas.
+func staticPartitions(partitionCount, replicas, replicaIndex int) []int32 {
+	if replicas <= 0 {
+		return nil
+	}
+	var owned []int32
+	for p := replicaIndex; p < partitionCount; p += replicas {
+		owned = append(owned, int32(p)) //nolint:gosec // partition ids fit in int32
+	}
+	return owned
+}
+
 func configureAuth(conn models.KafkaConnectionParamsConfig) ([]kgo.Opt, error) {
 	var opts []kgo.Opt
 	var auth sasl.Mechanism
@@ -187,6 +425,8 @@ func configureAuth(conn models.KafkaConnectionParamsConfig) ([]kgo.Opt, error) {
 			User: conn.SASLUsername,
 			Pass: conn.SASLPassword,
 		}.AsMechanism()
+	case internal.MechanismAWSIAM:
+		auth = aws.ManagedStreamingIAM(podAWSCredentials.Retrieve)
 	case internal.MechanismNoAuth:
 		auth = nil
 	default:
@@ -221,9 +461,137 @@ func (c *Consumer) Start(ctx context.Context, processor MessageProcessor) error
 		slog.String("topic", c.topic),
 		slog.String("group", c.groupID))
 
+	go c.watchPartitions(ctx)
+
 	return c.consumeLoop(ctx)
 }
 
+// watchPartitions checks the topic for added partitions until ctx is done.
+// Kafka only ever adds partitions to a topic.
+func (c *Consumer) watchPartitions(ctx context.Context) {
+	ticker := time.NewTicker(internal.KafkaPartitionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.checkPartitions(ctx)
+			if err != nil && ctx.Err() == nil {
+				c.log.Warn("Failed to check Kafka topic partitions", slog.String("topic", c.topic), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// checkPartitions starts consuming the partitions added to the topic since
+// the previous check. Statically assigned replicas add the ones they own,
+// group members have the group rebalance over them. Both read the added
+// partitions from their start unless an offset is committed already, so
+// that the records written before the partitions were noticed are not
+// skipped with the latest initial offset.
+func (c *Consumer) checkPartitions(ctx context.Context) error {
+	count, err := partitionCount(ctx, c.admin, c.topic)
+	if err != nil {
+		return err
+	}
+
+	previous := c.partitions
+	if previous == 0 || count <= previous {
+		c.partitions = max(count, previous)
+		return nil
+	}
+
+	if c.static {
+		added := addedPartitions(previous, count, c.replicas, c.replicaIndex)
+		if len(added) > 0 {
+			err = consumeStaticPartitions(ctx, c.client, c.admin, c.groupID, c.topic, added, kgo.NewOffset().AtStart())
+			if err != nil {
+				return err
+			}
+		}
+		c.log.Info("Kafka topic partitions added",
+			slog.String("topic", c.topic),
+			slog.Int("previous_partitions", previous),
+			slog.Int("partitions", count),
+			slog.Int("replica_index", c.replicaIndex),
+			slog.Any("assigned", added))
+	} else {
+		// One replica commits the start offsets, the group is shared by all
+		if c.replicaIndex == 0 {
+			err = c.commitStartOffsets(ctx, addedPartitions(previous, count, 0, 0))
+			if err != nil {
+				return err
+			}
+		}
+		c.client.ForceMetadataRefresh()
+		c.log.Info("Kafka topic partitions added, rebalancing the consumer group",
+			slog.String("topic", c.topic),
+			slog.Int("previous_partitions", previous),
+			slog.Int("partitions", count))
+	}
+
+	c.partitions = count
+	return nil
+}
+
+// commitStartOffsets commits the start offsets of the partitions the group
+// has no committed offset for, through the group member: the coordinator
+// rejects commits from outside an active group.
+func (c *Consumer) commitStartOffsets(ctx context.Context, partitions []int32) error {
+	// The group reads partitions without a committed offset from their
+	// start already
+	if c.earliest {
+		return nil
+	}
+
+	committed, err := c.admin.FetchOffsets(ctx, c.groupID)
+	if err != nil {
+		return fmt.Errorf("fetch committed offsets: %w", err)
+	}
+	starts, err := c.admin.ListStartOffsets(ctx, c.topic)
+	if err != nil {
+		return fmt.Errorf("list start offsets of topic %q: %w", c.topic, err)
+	}
+
+	offsets := make(map[int32]kgo.EpochOffset)
+	for _, p := range partitions {
+		if o, ok := committed.Lookup(c.topic, p); ok && o.Err == nil && o.At >= 0 {
+			continue
+		}
+		start, ok := starts.Lookup(c.topic, p)
+		if !ok || start.Err != nil {
+			return fmt.Errorf("no start offset listed for topic %q partition %d", c.topic, p)
+		}
+		offsets[p] = kgo.EpochOffset{Epoch: -1, Offset: start.Offset}
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	var commitErr error
+	c.client.CommitOffsetsSync(ctx, map[string]map[int32]kgo.EpochOffset{c.topic: offsets},
+		func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+			if err != nil {
+				commitErr = err
+				return
+			}
+			for _, t := range resp.Topics {
+				for _, p := range t.Partitions {
+					if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+						commitErr = fmt.Errorf("partition %d: %w", p.Partition, err)
+						return
+					}
+				}
+			}
+		})
+	if commitErr != nil {
+		return fmt.Errorf("commit start offsets of topic %q: %w", c.topic, commitErr)
+	}
+	return nil
+}
+
 func (c *Consumer) consumeLoop(ctx context.Context) error {
 	c.log.Debug("Consuming messages in batch mode",
 		slog.String("topic", c.topic),
@@ -261,11 +629,25 @@ func (c *Consumer) handleBatchMessages(ctx context.Context) error {
 		return c.processBatch(ctx)
 	}
 
+	// Records stay in Kafka while flow control holds the ingestion back,
+	// instead of piling up in the NATS streams
+	if c.flowGate != nil {
+		if err := c.flowGate.Wait(ctx); err != nil {
+			return nil
+		}
+	}
+
 	// Poll for messages
 	pollCtx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
+	pollStart := time.Now()
 	fetches := c.client.PollFetches(pollCtx)
+
+	// Rebalances are blocked from the poll until the polled records are
+	// processed and committed; release them on every exit path.
+	defer c.allowRebalance()
+
 	if errs := fetches.Errors(); len(errs) > 0 {
 		for _, err := range errs {
 			if errors.Is(err.Err, context.Canceled) {
@@ -281,6 +663,8 @@ func (c *Consumer) handleBatchMessages(ctx context.Context) error {
 		fetches.EachRecord(func(record *kgo.Record) {
 			c.batch = append(c.batch, record)
 		})
+		recordConsumerLag(ctx, fetches)
+		traceRecords(ctx, c.batch, pollStart)
 
 		if err := c.processBatch(ctx); err != nil {
 			return fmt.Errorf("process batch: %w", err)
@@ -291,6 +675,113 @@ func (c *Consumer) handleBatchMessages(ctx context.Context) error {
 	return nil
 }
 
+// recordConsumerLag records, per fetched partition, how many records remain
+// between the last polled record and the partition high watermark.
+func recordConsumerLag(ctx context.Context, fetches kgo.Fetches) {
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		last := p.Records[len(p.Records)-1]
+		observability.RecordKafkaConsumerLag(ctx, p.Topic, p.Partition, max(p.HighWatermark-last.Offset-1, 0))
+	})
+}
+
+// traceRecords records a kafka.fetch span per record covering the poll, as a
+// child of the trace context found in the record headers, if any. The span
+// context is kept in the record context for the ingestor to propagate.
+func traceRecords(ctx context.Context, records []*kgo.Record, pollStart time.Time) {
+	if !observability.TracingEnabled() {
+		return
+	}
+
+	for _, record := range records {
+		recordCtx := observability.ExtractTraceContext(ctx, recordHeaderCarrier{record: record})
+		recordCtx, span := observability.StartSpan(recordCtx, observability.SpanKafkaFetch,
+			trace.WithTimestamp(pollStart),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("messaging.destination.name", record.Topic),
+				attribute.Int64("messaging.kafka.destination.partition", int64(record.Partition)),
+				attribute.Int64("messaging.kafka.message.offset", record.Offset),
+			),
+		)
+		observability.EndSpan(span, nil)
+		record.Context = recordCtx
+	}
+}
+
+// recordHeaderCarrier reads the trace context that producers put in the
+// Kafka record headers.
+type recordHeaderCarrier struct {
+	record *kgo.Record
+}
+
+func (c recordHeaderCarrier) Get(key string) string {
+	for _, h := range c.record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c recordHeaderCarrier) Set(key, value string) {
+	c.record.Headers = append(c.record.Headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
+
+// onPartitionsRevoked commits processed offsets before partitions move to
+// another member, so the new owner doesn't replay them.
+func (c *Consumer) onPartitionsRevoked(ctx context.Context, cl *kgo.Client, revoked map[string][]int32) {
+	if c.uncommittable.Load() {
+		c.log.Warn("Skipping offset commit on partition revocation after failed batch", slog.Any("revoked", revoked))
+		return
+	}
+	if err := cl.CommitUncommittedOffsets(ctx); err != nil {
+		c.log.Error("Failed to commit offsets on partition revocation",
+			slog.Any("error", err),
+			slog.Any("revoked", revoked))
+		return
+	}
+	c.log.Info("Committed offsets on partition revocation", slog.Any("revoked", revoked))
+}
+
+// rewind moves the polled cursor back to the first record of the failed
+// batch that was not processed in each partition, so the records are polled
+// again and the cursor can be committed.
+func (c *Consumer) rewind(lastProcessed *kgo.Record) {
+	offsets := unprocessedOffsets(c.batch, lastProcessed)
+	if len(offsets) > 0 {
+		c.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{c.topic: offsets})
+	}
+	c.uncommittable.Store(false)
+}
+
+// unprocessedOffsets returns the offset of the first record after
+// lastProcessed of each partition of the batch. Partitions whose records
+// were all processed are left out.
+func unprocessedOffsets(batch []*kgo.Record, lastProcessed *kgo.Record) map[int32]kgo.EpochOffset {
+	unprocessed := batch
+	if lastProcessed != nil {
+		unprocessed = batch[slices.Index(batch, lastProcessed)+1:]
+	}
+
+	offsets := make(map[int32]kgo.EpochOffset)
+	for _, r := range unprocessed {
+		if _, ok := offsets[r.Partition]; !ok {
+			offsets[r.Partition] = kgo.EpochOffset{Epoch: r.LeaderEpoch, Offset: r.Offset}
+		}
+	}
+	return offsets
+}
+
+func (c *Consumer) allowRebalance() {
+	if !c.static {
+		c.client.AllowRebalance()
+	}
+}
+
 func (c *Consumer) processBatch(ctx context.Context) error {
 	size := len(c.batch)
 	if size == 0 {
@@ -315,13 +806,15 @@ func (c *Consumer) processBatch(ctx context.Context) error {
 				commitCtx, cancel = context.WithTimeout(context.Background(), internal.DefaultComponentShutdownTimeout)
 				defer cancel()
 			}
-			if commitErr := c.client.CommitRecords(commitCtx, lastProcessed); commitErr != nil {
+			if commitErr := c.commitRecords(commitCtx, lastProcessed); commitErr != nil {
 				c.log.Error("Failed to commit partial offset", slog.Any("error", commitErr))
 			} else {
 				c.log.Info("Committed partial offset", slog.Int64("offset", lastProcessed.Offset))
 			}
 		}
 
+		c.uncommittable.Store(true)
+		c.rewind(lastProcessed)
 		c.batch = c.batch[:0]
 		return fmt.Errorf("batch processing failed: %w", err)
 	}
@@ -354,6 +847,9 @@ func (c *Consumer) processBatch(ctx context.Context) error {
 // invariant guarantees the polled cursor never extends past records the
 // processor hasn't yet driven to completion.
 func (c *Consumer) commitBatch(ctx context.Context) error {
+	if c.static {
+		return c.commitRecords(ctx, c.batch...)
+	}
 	if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
 		c.log.Error("Failed to commit offsets", slog.Any("error", err))
 		return fmt.Errorf("failed to commit offsets: %w", err)
@@ -361,6 +857,32 @@ func (c *Consumer) commitBatch(ctx context.Context) error {
 	return nil
 }
 
+// commitRecords commits the offsets following the given records. Group mode
+// goes through the group member; static mode has no member and commits as
+// a simple consumer under the same group name.
+func (c *Consumer) commitRecords(ctx context.Context, records ...*kgo.Record) error {
+	if !c.static {
+		//nolint:wrapcheck // callers add context
+		return c.client.CommitRecords(ctx, records...)
+	}
+
+	offsets := make(kadm.Offsets)
+	for _, r := range records {
+		offsets.Add(kadm.Offset{
+			Topic:       r.Topic,
+			Partition:   r.Partition,
+			At:          r.Offset + 1,
+			LeaderEpoch: r.LeaderEpoch,
+		})
+	}
+
+	if err := c.admin.CommitAllOffsets(ctx, c.groupID, offsets); err != nil {
+		c.log.Error("Failed to commit offsets", slog.Any("error", err))
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+	return nil
+}
+
 func (c *Consumer) Close() error {
 	c.log.Info("Closing Kafka consumer", slog.String("group", c.groupID))
 
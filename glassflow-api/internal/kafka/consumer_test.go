@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestStaticPartitions(t *testing.T) {
+	tests := []struct {
+		name         string
+		partitions   int
+		replicas     int
+		replicaIndex int
+		want         []int32
+	}{
+		{name: "single replica owns everything", partitions: 3, replicas: 1, replicaIndex: 0, want: []int32{0, 1, 2}},
+		{name: "round robin first replica", partitions: 6, replicas: 3, replicaIndex: 0, want: []int32{0, 3}},
+		{name: "round robin last replica", partitions: 6, replicas: 3, replicaIndex: 2, want: []int32{2, 5}},
+		{name: "uneven split", partitions: 5, replicas: 2, replicaIndex: 1, want: []int32{1, 3}},
+		{name: "more replicas than partitions", partitions: 2, replicas: 4, replicaIndex: 3, want: nil},
+		{name: "zero replicas", partitions: 2, replicas: 0, replicaIndex: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, staticPartitions(tt.partitions, tt.replicas, tt.replicaIndex))
+		})
+	}
+}
+
+func TestAddedPartitions(t *testing.T) {
+	tests := []struct {
+		name         string
+		previous     int
+		current      int
+		replicas     int
+		replicaIndex int
+		want         []int32
+	}{
+		{name: "all added partitions without replicas", previous: 2, current: 5, replicas: 0, replicaIndex: 0, want: []int32{2, 3, 4}},
+		{name: "owned added partitions", previous: 4, current: 8, replicas: 3, replicaIndex: 1, want: []int32{4, 7}},
+		{name: "none owned", previous: 4, current: 5, replicas: 2, replicaIndex: 1, want: nil},
+		{name: "unchanged", previous: 4, current: 4, replicas: 1, replicaIndex: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, addedPartitions(tt.previous, tt.current, tt.replicas, tt.replicaIndex))
+		})
+	}
+}
+
+func TestGroupInstanceID(t *testing.T) {
+	topic := models.KafkaTopicsConfig{Name: "orders.v1", ConsumerGroupName: "glassflow-consumer-group-abcd1234"}
+
+	require.Equal(t, "glassflow-consumer-group-abcd1234-orders_v1-0", groupInstanceID(topic, 0))
+	require.NotEqual(t, groupInstanceID(topic, 0), groupInstanceID(topic, 1))
+}
+
+func TestUnprocessedOffsets(t *testing.T) {
+	batch := []*kgo.Record{
+		{Partition: 0, Offset: 10, LeaderEpoch: 1},
+		{Partition: 1, Offset: 20, LeaderEpoch: 1},
+		{Partition: 0, Offset: 11, LeaderEpoch: 1},
+		{Partition: 1, Offset: 21, LeaderEpoch: 1},
+		{Partition: 2, Offset: 30, LeaderEpoch: 2},
+	}
+
+	require.Equal(t, map[int32]kgo.EpochOffset{
+		0: {Epoch: 1, Offset: 10},
+		1: {Epoch: 1, Offset: 20},
+		2: {Epoch: 2, Offset: 30},
+	}, unprocessedOffsets(batch, nil), "nothing processed")
+
+	require.Equal(t, map[int32]kgo.EpochOffset{
+		1: {Epoch: 1, Offset: 21},
+		2: {Epoch: 2, Offset: 30},
+	}, unprocessedOffsets(batch, batch[2]))
+
+	require.Empty(t, unprocessedOffsets(batch, batch[4]), "everything processed")
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// GetConsumerLag queries the brokers for the committed offsets of the topics'
+// consumer groups and returns the lag of every partition they consume.
+func GetConsumerLag(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []models.KafkaTopicsConfig) ([]models.KafkaPartitionLag, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID(internal.ClientID),
+	}
+
+	authOpts, err := configureAuth(conn)
+	if err != nil {
+		return nil, fmt.Errorf("configure auth: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	admin := kadm.NewClient(client)
+
+	groups := make([]string, 0, len(topics))
+	groupTopics := make(map[string]map[string]bool, len(topics))
+	for _, topic := range topics {
+		if _, ok := groupTopics[topic.ConsumerGroupName]; !ok {
+			groups = append(groups, topic.ConsumerGroupName)
+			groupTopics[topic.ConsumerGroupName] = make(map[string]bool)
+		}
+		groupTopics[topic.ConsumerGroupName][topic.Name] = true
+	}
+
+	lags, err := admin.Lag(ctx, groups...)
+	if err != nil {
+		return nil, fmt.Errorf("get consumer group lag: %w", err)
+	}
+
+	var result []models.KafkaPartitionLag
+	for _, group := range groups {
+		lag, ok := lags[group]
+		if !ok {
+			continue
+		}
+		if err := lag.Error(); err != nil {
+			return nil, fmt.Errorf("get lag of consumer group %q: %w", group, err)
+		}
+
+		for _, l := range lag.Lag.Sorted() {
+			// The lag also lists topics the group consumed in the past
+			if !groupTopics[group][l.Topic] {
+				continue
+			}
+			if l.Err != nil {
+				return nil, fmt.Errorf("get lag of topic %q partition %d: %w", l.Topic, l.Partition, l.Err)
+			}
+			result = append(result, models.KafkaPartitionLag{
+				ConsumerGroup:   group,
+				Topic:           l.Topic,
+				Partition:       l.Partition,
+				CommittedOffset: l.Commit.At,
+				EndOffset:       l.End.Offset,
+				Lag:             l.Lag,
+			})
+		}
+	}
+
+	return result, nil
+}
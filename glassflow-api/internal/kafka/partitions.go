@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// GetPartitionCounts queries the brokers for the number of partitions of the
+// topics.
+func GetPartitionCounts(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []string) (map[string]int, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID(internal.ClientID),
+	}
+
+	authOpts, err := configureAuth(conn)
+	if err != nil {
+		return nil, fmt.Errorf("configure auth: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	admin := kadm.NewClient(client)
+
+	counts := make(map[string]int, len(topics))
+	for _, topic := range topics {
+		count, err := partitionCount(ctx, admin, topic)
+		if err != nil {
+			return nil, err
+		}
+		counts[topic] = count
+	}
+
+	return counts, nil
+}
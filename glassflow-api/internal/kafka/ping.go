@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ErrClusterChanged is returned when the brokers point at another cluster
+// than the one recorded for the pipeline.
+var ErrClusterChanged = errors.New("kafka brokers point at a different cluster")
+
+// Ping connects to a broker of the cluster with the credentials of conn.
+func Ping(ctx context.Context, conn models.KafkaConnectionParamsConfig) error {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID(internal.ClientID),
+	}
+
+	authOpts, err := configureAuth(conn)
+	if err != nil {
+		return fmt.Errorf("configure auth: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	err = client.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping kafka brokers: %w", err)
+	}
+	return nil
+}
+
+// GetClusterID returns the id of the cluster the brokers of conn belong to,
+// empty for brokers too old to report one.
+func GetClusterID(ctx context.Context, conn models.KafkaConnectionParamsConfig) (string, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID(internal.ClientID),
+	}
+
+	authOpts, err := configureAuth(conn)
+	if err != nil {
+		return "", fmt.Errorf("configure auth: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	return clusterID(ctx, client)
+}
+
+func clusterID(ctx context.Context, client *kgo.Client) (string, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	// An empty topic list asks for the brokers only
+	req.Topics = []kmsg.MetadataRequestTopic{}
+
+	resp, err := req.RequestWith(ctx, client)
+	if err != nil {
+		return "", fmt.Errorf("request cluster metadata: %w", err)
+	}
+	if resp.ClusterID == nil {
+		return "", nil
+	}
+	return *resp.ClusterID, nil
+}
+
+// checkClusterID fails with ErrClusterChanged when the client is connected
+// to another cluster than the recorded one.
+func checkClusterID(ctx context.Context, client *kgo.Client, recorded string) error {
+	current, err := clusterID(ctx, client)
+	if err != nil {
+		return err
+	}
+	if current != "" && current != recorded {
+		return fmt.Errorf("%w: recorded %q, brokers now point at %q", ErrClusterChanged, recorded, current)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ErrConsumerGroupActive is returned when offsets are committed for a
+// consumer group that still has members.
+var ErrConsumerGroupActive = errors.New("consumer group has active members")
+
+// MigrateTopicOffsets maps the committed offsets of the consumer group on the
+// topic from to the topic to, which carries the same records under a new
+// name, and commits them unless dryRun. The offsets are mapped through the
+// timestamp of the next record the group reads on every partition, see
+// models.TopicMigrationTimestamps. The mapping is at-least-once: no record is
+// skipped, but records sharing that millisecond are read twice.
+func MigrateTopicOffsets(ctx context.Context, conn models.KafkaConnectionParamsConfig, group, from, to string, dryRun bool) (models.TopicMigration, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID(internal.ClientID),
+	}
+
+	authOpts, err := configureAuth(conn)
+	if err != nil {
+		return models.TopicMigration{}, fmt.Errorf("configure auth: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return models.TopicMigration{}, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	admin := kadm.NewClient(client)
+
+	toPartitions, err := partitionCount(ctx, admin, to)
+	if err != nil {
+		return models.TopicMigration{}, err
+	}
+
+	positions, err := resumePositions(ctx, admin, opts, group, from)
+	if err != nil {
+		return models.TopicMigration{}, err
+	}
+
+	timestamps, perPartition, err := models.TopicMigrationTimestamps(positions, toPartitions)
+	if err != nil {
+		return models.TopicMigration{}, fmt.Errorf("topic %q: %w", from, err)
+	}
+
+	migration := models.TopicMigration{
+		From:          from,
+		To:            to,
+		ConsumerGroup: group,
+		PerPartition:  perPartition,
+		DryRun:        dryRun,
+	}
+	offsets := make(kadm.Offsets)
+	for p := range toPartitions {
+		partition := int32(p) //nolint:gosec // partition ids fit in int32
+		ts := timestamps[partition]
+
+		listed, err := admin.ListOffsetsAfterMilli(ctx, ts.UnixMilli(), to)
+		if err != nil {
+			return models.TopicMigration{}, fmt.Errorf("list offsets of topic %q after %s: %w", to, ts, err)
+		}
+		// Without a record at or after the timestamp the end offset is listed
+		o, ok := listed.Lookup(to, partition)
+		if !ok {
+			return models.TopicMigration{}, fmt.Errorf("no offset listed for topic %q partition %d", to, partition)
+		}
+		if o.Err != nil {
+			return models.TopicMigration{}, fmt.Errorf("list offset of topic %q partition %d: %w", to, partition, o.Err)
+		}
+
+		migration.Partitions = append(migration.Partitions, models.TopicMigrationPartition{
+			Partition: partition,
+			Timestamp: ts,
+			Offset:    o.Offset,
+		})
+		offsets.Add(kadm.Offset{Topic: to, Partition: partition, At: o.Offset, LeaderEpoch: -1})
+	}
+
+	if dryRun {
+		return migration, nil
+	}
+
+	err = ensureGroupEmpty(ctx, admin, group)
+	if err != nil {
+		return models.TopicMigration{}, err
+	}
+
+	err = admin.CommitAllOffsets(ctx, group, offsets)
+	if err != nil {
+		return models.TopicMigration{}, fmt.Errorf("commit offsets of topic %q: %w", to, err)
+	}
+
+	return migration, nil
+}
+
+func partitionCount(ctx context.Context, admin *kadm.Client, topic string) (int, error) {
+	details, err := admin.ListTopics(ctx, topic)
+	if err != nil {
+		return 0, fmt.Errorf("list topic metadata: %w", err)
+	}
+	detail, ok := details[topic]
+	if !ok {
+		return 0, fmt.Errorf("topic %s not found", topic)
+	}
+	if detail.Err != nil {
+		return 0, fmt.Errorf("topic %s metadata: %w", topic, detail.Err)
+	}
+	return len(detail.Partitions), nil
+}
+
+// resumePositions returns the timestamp of the next record the group reads
+// on every partition of topic. A group that read a partition to its end
+// resumes right after the last record.
+func resumePositions(ctx context.Context, admin *kadm.Client, opts []kgo.Opt, group, topic string) ([]models.TopicPartitionPosition, error) {
+	partitions, err := partitionCount(ctx, admin, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	committed, err := admin.FetchOffsets(ctx, group)
+	if err != nil {
+		return nil, fmt.Errorf("fetch committed offsets: %w", err)
+	}
+	starts, err := admin.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("list start offsets of topic %q: %w", topic, err)
+	}
+	ends, err := admin.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("list end offsets of topic %q: %w", topic, err)
+	}
+
+	positions := make([]models.TopicPartitionPosition, partitions)
+	// reads are the offsets of the records whose timestamp gives the position
+	reads := make(map[int32]kgo.Offset)
+	// afterLast are the partitions the group read to their end
+	afterLast := make(map[int32]bool)
+	for p := range partitions {
+		partition := int32(p) //nolint:gosec // partition ids fit in int32
+		positions[p].Partition = partition
+
+		c, ok := committed.Lookup(topic, partition)
+		if !ok || c.Err != nil || c.At < 0 {
+			continue
+		}
+		start, ok := starts.Lookup(topic, partition)
+		if !ok || start.Err != nil {
+			return nil, fmt.Errorf("no start offset listed for topic %q partition %d", topic, partition)
+		}
+		end, ok := ends.Lookup(topic, partition)
+		if !ok || end.Err != nil {
+			return nil, fmt.Errorf("no end offset listed for topic %q partition %d", topic, partition)
+		}
+
+		switch {
+		case end.Offset <= start.Offset:
+			// Nothing is left to read the timestamp of
+		case c.At < start.Offset:
+			// The records the group did not read expired, it resumes from
+			// the first one left
+			reads[partition] = kgo.NewOffset().At(start.Offset)
+		case c.At < end.Offset:
+			reads[partition] = kgo.NewOffset().At(c.At)
+		default:
+			reads[partition] = kgo.NewOffset().At(end.Offset - 1)
+			afterLast[partition] = true
+		}
+	}
+
+	if len(reads) == 0 {
+		return positions, nil
+	}
+
+	timestamps, err := recordTimestamps(ctx, opts, topic, reads)
+	if err != nil {
+		return nil, err
+	}
+	for i := range positions {
+		ts, ok := timestamps[positions[i].Partition]
+		if !ok {
+			continue
+		}
+		if afterLast[positions[i].Partition] {
+			ts = ts.Add(time.Millisecond)
+		}
+		positions[i].Resume = ts
+	}
+
+	return positions, nil
+}
+
+// recordTimestamps returns the timestamp of the first record at or after the
+// offset of every partition.
+func recordTimestamps(ctx context.Context, opts []kgo.Opt, topic string, offsets map[int32]kgo.Offset) (map[int32]time.Time, error) {
+	opts = append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: offsets}))
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	timestamps := make(map[int32]time.Time, len(offsets))
+	for len(timestamps) < len(offsets) {
+		fetches := client.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("read records of topic %q: %w", topic, err)
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			return nil, fmt.Errorf("read topic %q partition %d: %w", errs[0].Topic, errs[0].Partition, errs[0].Err)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			if _, ok := timestamps[r.Partition]; !ok {
+				timestamps[r.Partition] = r.Timestamp
+			}
+		})
+	}
+
+	return timestamps, nil
+}
+
+func ensureGroupEmpty(ctx context.Context, admin *kadm.Client, group string) error {
+	described, err := admin.DescribeGroups(ctx, group)
+	if err != nil {
+		return fmt.Errorf("describe consumer group %q: %w", group, err)
+	}
+	g, ok := described[group]
+	if !ok {
+		return nil
+	}
+	if g.Err != nil {
+		return fmt.Errorf("describe consumer group %q: %w", group, g.Err)
+	}
+	if len(g.Members) > 0 {
+		return fmt.Errorf("%w: %q has %d", ErrConsumerGroupActive, group, len(g.Members))
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package kv
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -16,8 +17,15 @@ type KeyValueStore interface {
 	GetString(ctx context.Context, key any) (string, error)
 	GetMessage(ctx context.Context, key any) (schemaVersionID string, data []byte, err error)
 	Delete(ctx context.Context, key any) error
+	// Keys returns the keys currently in the store, nil when it is empty.
+	Keys(ctx context.Context) ([]string, error)
+	// GetMessageCreated is GetMessage that also returns when the value was put.
+	GetMessageCreated(ctx context.Context, key any) (schemaVersionID string, data []byte, created time.Time, err error)
 }
 
+// ErrNotMessage is returned when a value was not stored by PutMessage.
+var ErrNotMessage = errors.New("value is not a stored message")
+
 type KeyValueStoreConfig struct {
 	StoreName string
 	TTL       time.Duration
@@ -75,12 +83,21 @@ func (k *NATSKeyValueStore) PutMessage(ctx context.Context, key any, schemaVersi
 }
 
 func (k *NATSKeyValueStore) get(ctx context.Context, key any) ([]byte, error) {
+	item, err := k.getEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Value(), nil
+}
+
+func (k *NATSKeyValueStore) getEntry(ctx context.Context, key any) (jetstream.KeyValueEntry, error) {
 	item, err := k.KVstore.Get(ctx, fmt.Sprintf("%v", key))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get value from KeyValue store: %w", err)
 	}
 
-	return item.Value(), nil
+	return item, nil
 }
 
 func (k *NATSKeyValueStore) GetString(ctx context.Context, key any) (string, error) {
@@ -99,12 +116,31 @@ func (k *NATSKeyValueStore) GetMessage(ctx context.Context, key any) (schemaVers
 		return "", nil, err
 	}
 
+	return decodeMessage(value)
+}
+
+// GetMessageCreated retrieves a message like GetMessage along with the time it was put.
+func (k *NATSKeyValueStore) GetMessageCreated(ctx context.Context, key any) (schemaVersionID string, data []byte, created time.Time, err error) {
+	item, err := k.getEntry(ctx, key)
+	if err != nil {
+		return "", nil, time.Time{}, err
+	}
+
+	schemaVersionID, data, err = decodeMessage(item.Value())
+	if err != nil {
+		return "", nil, time.Time{}, err
+	}
+
+	return schemaVersionID, data, item.Created(), nil
+}
+
+func decodeMessage(value []byte) (schemaVersionID string, data []byte, err error) {
 	if len(value) < 5 {
-		return "", nil, fmt.Errorf("invalid stored message: too short")
+		return "", nil, fmt.Errorf("invalid stored message: too short: %w", ErrNotMessage)
 	}
 
 	if value[0] != 0x00 {
-		return "", nil, fmt.Errorf("invalid magic byte: expected 0x00, got 0x%02x", value[0])
+		return "", nil, fmt.Errorf("invalid magic byte: expected 0x00, got 0x%02x: %w", value[0], ErrNotMessage)
 	}
 
 	version := int(binary.BigEndian.Uint32(value[1:5]))
@@ -114,6 +150,20 @@ func (k *NATSKeyValueStore) GetMessage(ctx context.Context, key any) (schemaVers
 	return schemaVersionID, data, nil
 }
 
+func (k *NATSKeyValueStore) Keys(ctx context.Context) ([]string, error) {
+	lister, err := k.KVstore.ListKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys of KeyValue store: %w", err)
+	}
+
+	var keys []string
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
 func (k *NATSKeyValueStore) Delete(ctx context.Context, key any) error {
 	err := k.KVstore.Delete(ctx, fmt.Sprintf("%v", key))
 	if err != nil {
@@ -0,0 +1,223 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// Edition is the edition a build runs as. Both editions ship from the same
+// tree, the license file decides which one an installation runs.
+type Edition string
+
+const (
+	EditionOpenSource Edition = "open_source"
+	EditionEnterprise Edition = "enterprise"
+)
+
+// Enterprise features, gated by the license
+const (
+	PulsarSource    = "pulsar_source"
+	MySQLSource     = "mysql_source"
+	SchemaFormats   = "schema_formats"
+	ReadOnlyStandby = "read_only_standby"
+	RBAC            = "rbac"
+)
+
+var (
+	ErrInvalidLicense     = errors.New("invalid license")
+	ErrFeatureNotLicensed = errors.New("feature is not licensed")
+)
+
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// registry lists the enterprise features. Features not listed here are part
+// of the open source edition and are never gated.
+var registry = []Feature{
+	{Name: PulsarSource, Description: "Pulsar sources"},
+	{Name: MySQLSource, Description: "MySQL binlog sources"},
+	{Name: SchemaFormats, Description: "Avro and Protobuf schemas"},
+	{Name: ReadOnlyStandby, Description: "Read-only standby API for disaster recovery"},
+	{Name: RBAC, Description: "API keys and OIDC tokens with roles scoped to teams"},
+}
+
+func Features() []Feature {
+	return slices.Clone(registry)
+}
+
+func Known(name string) bool {
+	return slices.ContainsFunc(registry, func(f Feature) bool { return f.Name == name })
+}
+
+// publicKey verifies the signature of the license files. The private key is
+// held by GlassFlow and never leaves the license issuing service.
+const publicKey = "bByXfO7QHOsM8VoeY1N7IrBzXN18hTh5M5kox08hLfA="
+
+func PublicKey() ed25519.PublicKey {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		panic(fmt.Sprintf("decode license public key: %v", err))
+	}
+	return ed25519.PublicKey(key)
+}
+
+// License is the signed payload of a license file. An enterprise license
+// without features grants every enterprise feature.
+type License struct {
+	Customer  string    `json:"customer"`
+	Edition   Edition   `json:"edition"`
+	Features  []string  `json:"features,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// File is the license file: the JSON payload and its ed25519 signature, both
+// base64 encoded.
+type File struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// OpenSource is the license of installations without a license file.
+func OpenSource() *License {
+	return &License{Edition: EditionOpenSource}
+}
+
+// Load reads and verifies the license file at path, an empty path runs the
+// open source edition.
+func Load(path string, key ed25519.PublicKey) (*License, error) {
+	if path == "" {
+		return OpenSource(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read license file: %w", err)
+	}
+	return Parse(data, key)
+}
+
+// Parse verifies the signature of a license file and decodes its payload.
+// An expired license parses, it grants no enterprise feature.
+func Parse(data []byte, key ed25519.PublicKey) (*License, error) {
+	var file File
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLicense, err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(file.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode payload: %w", ErrInvalidLicense, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(file.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode signature: %w", ErrInvalidLicense, err)
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return nil, fmt.Errorf("%w: signature does not match", ErrInvalidLicense)
+	}
+
+	var l License
+	err = json.Unmarshal(payload, &l)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode payload: %w", ErrInvalidLicense, err)
+	}
+
+	switch l.Edition {
+	case EditionOpenSource, EditionEnterprise:
+	default:
+		return nil, fmt.Errorf("%w: unknown edition %q", ErrInvalidLicense, l.Edition)
+	}
+	for _, f := range l.Features {
+		if !Known(f) {
+			return nil, fmt.Errorf("%w: unknown feature %q", ErrInvalidLicense, f)
+		}
+	}
+
+	return &l, nil
+}
+
+func (l *License) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && now.After(l.ExpiresAt)
+}
+
+// Allows reports whether the license grants the feature at now. Features of
+// the open source edition are always allowed.
+func (l *License) Allows(feature string, now time.Time) bool {
+	if !Known(feature) {
+		return true
+	}
+	if l.Edition != EditionEnterprise || l.Expired(now) {
+		return false
+	}
+	return len(l.Features) == 0 || slices.Contains(l.Features, feature)
+}
+
+// Unlicensed returns the features the license does not grant at now.
+func (l *License) Unlicensed(features []string, now time.Time) []string {
+	var unlicensed []string
+	for _, f := range features {
+		if !l.Allows(f, now) {
+			unlicensed = append(unlicensed, f)
+		}
+	}
+	return unlicensed
+}
+
+// State is the license as reported by the API.
+type State struct {
+	Edition   Edition    `json:"edition"`
+	Customer  string     `json:"customer,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired"`
+	Features  []string   `json:"features"`
+}
+
+// State returns the edition and the enterprise features granted at now.
+func (l *License) State(now time.Time) State {
+	state := State{
+		Edition:  l.Edition,
+		Customer: l.Customer,
+		Expired:  l.Expired(now),
+		Features: []string{},
+	}
+	if !l.ExpiresAt.IsZero() {
+		expiresAt := l.ExpiresAt
+		state.ExpiresAt = &expiresAt
+	}
+	for _, f := range registry {
+		if l.Allows(f.Name, now) {
+			state.Features = append(state.Features, f.Name)
+		}
+	}
+	return state
+}
+
+// Used returns the enterprise features a pipeline config uses.
+func Used(cfg models.PipelineConfig) []string {
+	var used []string
+	if cfg.SourceType.IsPulsar() || cfg.Ingestor.Type == internal.PulsarIngestorType {
+		used = append(used, PulsarSource)
+	}
+	if cfg.SourceType.IsMySQL() || cfg.Ingestor.Type == internal.MySQLIngestorType {
+		used = append(used, MySQLSource)
+	}
+	for _, v := range cfg.SchemaVersions {
+		if v.DataType == models.SchemaDataFormatAVRO || v.DataType == models.SchemaDataFormatProtobuf {
+			used = append(used, SchemaFormats)
+			break
+		}
+	}
+	return used
+}
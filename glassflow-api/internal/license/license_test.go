@@ -0,0 +1,122 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func signLicense(t *testing.T, key ed25519.PrivateKey, l License) []byte {
+	t.Helper()
+	payload, err := json.Marshal(l)
+	require.NoError(t, err)
+	data, err := json.Marshal(File{
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestParse(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	data := signLicense(t, priv, License{Customer: "acme", Edition: EditionEnterprise, Features: []string{PulsarSource}, ExpiresAt: expiresAt})
+	l, err := Parse(data, pub)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", l.Customer)
+	assert.Equal(t, EditionEnterprise, l.Edition)
+	assert.Equal(t, expiresAt, l.ExpiresAt)
+
+	// A license signed by another key or tampered with is rejected
+	_, other, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, err = Parse(signLicense(t, other, License{Edition: EditionEnterprise}), pub)
+	require.ErrorIs(t, err, ErrInvalidLicense)
+
+	var file File
+	require.NoError(t, json.Unmarshal(data, &file))
+	file.Payload = base64.StdEncoding.EncodeToString([]byte(`{"customer":"acme","edition":"enterprise"}`))
+	tampered, err := json.Marshal(file)
+	require.NoError(t, err)
+	_, err = Parse(tampered, pub)
+	require.ErrorIs(t, err, ErrInvalidLicense)
+
+	_, err = Parse(signLicense(t, priv, License{Edition: "platinum"}), pub)
+	require.ErrorIs(t, err, ErrInvalidLicense)
+	_, err = Parse(signLicense(t, priv, License{Edition: EditionEnterprise, Features: []string{"teleport"}}), pub)
+	require.ErrorIs(t, err, ErrInvalidLicense)
+	_, err = Parse([]byte("not a license"), pub)
+	require.ErrorIs(t, err, ErrInvalidLicense)
+}
+
+func TestLoad(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	l, err := Load("", pub)
+	require.NoError(t, err)
+	assert.Equal(t, EditionOpenSource, l.Edition)
+
+	path := filepath.Join(t.TempDir(), "license.json")
+	require.NoError(t, os.WriteFile(path, signLicense(t, priv, License{Edition: EditionEnterprise}), 0o600))
+	l, err = Load(path, pub)
+	require.NoError(t, err)
+	assert.Equal(t, EditionEnterprise, l.Edition)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"), pub)
+	require.Error(t, err)
+}
+
+func TestAllows(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	open := OpenSource()
+	assert.False(t, open.Allows(PulsarSource, now))
+	assert.True(t, open.Allows("join", now), "open source features are never gated")
+
+	all := &License{Edition: EditionEnterprise}
+	for _, f := range Features() {
+		assert.True(t, all.Allows(f.Name, now), f.Name)
+	}
+
+	some := &License{Edition: EditionEnterprise, Features: []string{MySQLSource}, ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, some.Allows(MySQLSource, now))
+	assert.Equal(t, []string{PulsarSource}, some.Unlicensed([]string{PulsarSource, MySQLSource}, now))
+	assert.Equal(t, []string{MySQLSource}, some.State(now).Features)
+
+	// An expired license falls back to the open source edition
+	assert.False(t, some.Allows(MySQLSource, now.Add(2*time.Hour)))
+	state := some.State(now.Add(2 * time.Hour))
+	assert.True(t, state.Expired)
+	assert.Empty(t, state.Features)
+}
+
+func TestUsed(t *testing.T) {
+	assert.Empty(t, Used(models.PipelineConfig{SourceType: internal.KafkaIngestorType}))
+
+	assert.Equal(t, []string{PulsarSource, SchemaFormats}, Used(models.PipelineConfig{
+		SourceType: internal.PulsarIngestorType,
+		SchemaVersions: map[string]models.SchemaVersion{
+			"orders": {DataType: models.SchemaDataFormatJSON},
+			"users":  {DataType: models.SchemaDataFormatAVRO},
+		},
+	}))
+	assert.Equal(t, []string{MySQLSource}, Used(models.PipelineConfig{Ingestor: models.IngestorComponentConfig{Type: internal.MySQLIngestorType}}))
+}
+
+func TestPublicKey(t *testing.T) {
+	assert.Len(t, PublicKey(), ed25519.PublicKeySize)
+}
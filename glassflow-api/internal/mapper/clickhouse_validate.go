@@ -2,6 +2,7 @@ package mapper
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
@@ -11,12 +12,33 @@ import (
 // by the sink's schema mapper (ConvertValue in types.go). Supported types include
 // exact matches for internal.CHType* constants plus pattern-based types:
 // FixedString(N), LowCardinality(FixedString(N)), DateTime64(precision, tz),
-// Map(...), and Array(...) including Array(Map(...)).
+// Decimal(P, S), Decimal32/64/128/256(S), Map(...), and Array(...) including
+// Array(Map(...)), JSON, JSON(...), Object('json') and the Point, Ring and
+// Polygon geo types. Nullable(T) and LowCardinality(Nullable(T)) are supported
+// when T is a supported scalar type, SimpleAggregateFunction(f, T) when T is
+// supported. AggregateFunction columns are not supported.
 func IsSupportedClickHouseColumnType(columnType string) bool {
 	t := strings.TrimSpace(columnType)
 	if t == "" {
 		return false
 	}
+	if innerType, ok := unwrapSimpleAggregateFunction(t); ok {
+		return IsSupportedClickHouseColumnType(innerType)
+	}
+	if innerType, ok := unwrapNullable(t); ok {
+		// ClickHouse does not allow Nullable composite types
+		if strings.HasPrefix(t, "Nullable(LowCardinality(") ||
+			strings.HasPrefix(innerType, "Nullable(") ||
+			strings.HasPrefix(innerType, "Array(") ||
+			strings.HasPrefix(innerType, "Map(") ||
+			strings.HasPrefix(innerType, "SimpleAggregateFunction(") ||
+			internal.IsJSONType(innerType) ||
+			innerType == internal.CHTypeObjectJSON ||
+			isGeoType(innerType) {
+			return false
+		}
+		return IsSupportedClickHouseColumnType(innerType)
+	}
 	// Exact matches (same set as ConvertValue switch)
 	switch t {
 	case internal.CHTypeBool,
@@ -29,7 +51,18 @@ func IsSupportedClickHouseColumnType(columnType string) bool {
 		internal.CHTypeEnum8, internal.CHTypeEnum16, internal.CHTypeUUID,
 		internal.CHTypeFString, internal.CHTypeLCString, internal.CHTypeLCFString,
 		internal.CHTypeString,
-		internal.CHTypeDateTime, internal.CHTypeDateTime64, internal.CHTypeLCDateTime:
+		internal.CHTypeDateTime, internal.CHTypeDateTime64, internal.CHTypeLCDateTime,
+		internal.CHTypeDate, internal.CHTypeDate32,
+		internal.CHTypeIPv4, internal.CHTypeIPv6, internal.CHTypeObjectJSON,
+		internal.CHTypePoint, internal.CHTypeRing, internal.CHTypePolygon:
+		return true
+	}
+	// JSON, JSON(max_dynamic_paths=N, SKIP path) etc.
+	if internal.IsJSONType(t) {
+		return true
+	}
+	// Decimal(10, 2), Decimal64(4) etc. with valid precision and scale
+	if _, _, ok := parseDecimalType(t); ok {
 		return true
 	}
 	// Pattern-based: FixedString(N), LowCardinality(FixedString(N))
@@ -57,5 +90,22 @@ func ValidateClickHouseColumnType(columnType string) error {
 	if IsSupportedClickHouseColumnType(columnType) {
 		return nil
 	}
+	if isAggregateFunctionType(columnType) {
+		return fmt.Errorf("unsupported ClickHouse column type: %q: AggregateFunction columns hold -State values "+
+			"that the sink cannot write, use a SimpleAggregateFunction column or leave the column out of the mapping", columnType)
+	}
 	return fmt.Errorf("unsupported ClickHouse column type: %q", columnType)
 }
+
+// AggregateFunctionColumns returns the columns of a table that hold aggregate
+// function states, given its column types by name.
+func AggregateFunctionColumns(columnTypes map[string]string) []string {
+	var columns []string
+	for name, columnType := range columnTypes {
+		if isAggregateFunctionType(columnType) {
+			columns = append(columns, name)
+		}
+	}
+	slices.Sort(columns)
+	return columns
+}
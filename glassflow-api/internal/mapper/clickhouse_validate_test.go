@@ -1,6 +1,8 @@
 package mapper
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
@@ -23,6 +25,10 @@ func TestIsSupportedClickHouseColumnType(t *testing.T) {
 		{"UUID", internal.CHTypeUUID, true},
 		{"LowCardinality(String)", internal.CHTypeLCString, true},
 		{"FixedString", internal.CHTypeFString, true},
+		{"Date", internal.CHTypeDate, true},
+		{"Date32", internal.CHTypeDate32, true},
+		{"IPv4", internal.CHTypeIPv4, true},
+		{"IPv6", internal.CHTypeIPv6, true},
 		// Pattern-based
 		{"DateTime64(6, 'UTC')", "DateTime64(6, 'UTC')", true},
 		{"Array(String)", "Array(String)", true},
@@ -30,6 +36,43 @@ func TestIsSupportedClickHouseColumnType(t *testing.T) {
 		{"Map(String, String)", "Map(String, String)", true},
 		{"Array(Map(String, String))", "Array(Map(String, String))", true},
 		{"FixedString(32)", "FixedString(32)", true},
+		{"Decimal(10, 2)", "Decimal(10, 2)", true},
+		{"Decimal(18)", "Decimal(18)", true},
+		{"Decimal64(4)", "Decimal64(4)", true},
+		{"Decimal256(40)", "Decimal256(40)", true},
+		{"JSON", internal.CHTypeJSON, true},
+		{"JSON(max_dynamic_paths=16)", "JSON(max_dynamic_paths=16)", true},
+		{"Object('json')", internal.CHTypeObjectJSON, true},
+		{"Point", internal.CHTypePoint, true},
+		{"Ring", internal.CHTypeRing, true},
+		{"Polygon", internal.CHTypePolygon, true},
+		{"JSONB", "JSONB", false},
+		{"Decimal(5, 6)", "Decimal(5, 6)", false},
+		{"Decimal(77, 2)", "Decimal(77, 2)", false},
+		{"Decimal32(10)", "Decimal32(10)", false},
+		{"Decimal", "Decimal", false},
+		// Nullable
+		{"Nullable(String)", "Nullable(String)", true},
+		{"Nullable(Int32)", "Nullable(Int32)", true},
+		{"Nullable(DateTime64(3, 'UTC'))", "Nullable(DateTime64(3, 'UTC'))", true},
+		{"Nullable(FixedString(2))", "Nullable(FixedString(2))", true},
+		{"LowCardinality(Nullable(String))", "LowCardinality(Nullable(String))", true},
+		{"Nullable(Decimal(10, 2))", "Nullable(Decimal(10, 2))", true},
+		{"Nullable(IPv6)", "Nullable(IPv6)", true},
+		{"Nullable(Array(String))", "Nullable(Array(String))", false},
+		{"Nullable(Map(String, String))", "Nullable(Map(String, String))", false},
+		{"Nullable(JSON)", "Nullable(JSON)", false},
+		{"Nullable(Point)", "Nullable(Point)", false},
+		{"Nullable(Nullable(String))", "Nullable(Nullable(String))", false},
+		{"Nullable(LowCardinality(String))", "Nullable(LowCardinality(String))", false},
+		{"Nullable(Unsupported)", "Nullable(Unsupported)", false},
+		// SimpleAggregateFunction(f, T) takes T values
+		{"SimpleAggregateFunction(sum, UInt64)", "SimpleAggregateFunction(sum, UInt64)", true},
+		{"SimpleAggregateFunction(anyLast, Nullable(String))", "SimpleAggregateFunction(anyLast, Nullable(String))", true},
+		{"SimpleAggregateFunction(groupUniqArrayArray(10), Array(String))", "SimpleAggregateFunction(groupUniqArrayArray(10), Array(String))", true},
+		{"SimpleAggregateFunction(sum, Unsupported)", "SimpleAggregateFunction(sum, Unsupported)", false},
+		{"Nullable(SimpleAggregateFunction(sum, UInt64))", "Nullable(SimpleAggregateFunction(sum, UInt64))", false},
+		{"AggregateFunction(uniq, String)", "AggregateFunction(uniq, String)", false},
 		// Unsupported
 		{"Unsupported", "Unsupported", false},
 		{"UnknownType", "UnknownType", false},
@@ -62,4 +105,26 @@ func TestValidateClickHouseColumnType(t *testing.T) {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
+	t.Run("aggregate function", func(t *testing.T) {
+		err := ValidateClickHouseColumnType("AggregateFunction(uniq, String)")
+		if err == nil {
+			t.Fatal("ValidateClickHouseColumnType(AggregateFunction(uniq, String)) expected error")
+		}
+		if !strings.Contains(err.Error(), "SimpleAggregateFunction") {
+			t.Errorf("expected the error to suggest SimpleAggregateFunction, got: %v", err)
+		}
+	})
+}
+
+func TestAggregateFunctionColumns(t *testing.T) {
+	got := AggregateFunctionColumns(map[string]string{
+		"id":      "String",
+		"total":   "SimpleAggregateFunction(sum, UInt64)",
+		"visits":  "AggregateFunction(count)",
+		"uniques": "AggregateFunction(uniq, String)",
+	})
+	want := []string{"uniques", "visits"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateFunctionColumns() = %v, want %v", got, want)
+	}
 }
@@ -0,0 +1,370 @@
+// Package fixture records the events a sink maps and the ClickHouse rows it
+// produces to a directory, and replays recorded events against a mapper for
+// golden-file regression tests.
+//
+// A fixture directory contains:
+//   - mappings.json: sink mapping configs, keyed by schema version ID
+//   - records.jsonl: one Record per mapped event, in mapping order
+//
+// When the mapping config of a schema version changes while recording, the
+// new config is stored under "<schema version ID>#<n>" and the records mapped
+// with it reference that key, so every record replays with the exact config
+// that produced its output.
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+const (
+	mappingsFile = "mappings.json"
+	recordsFile  = "records.jsonl"
+)
+
+// Mapper is the sink mapper contract that is recorded and replayed.
+type Mapper interface {
+	Map(data []byte, schemaVersionID string, config map[string]models.Mapping) ([]any, error)
+	GetColumnNames(schemaVersionID string) ([]string, error)
+}
+
+// Record is a single mapped event. Values holds the JSON encoding of the row
+// and Types the Go type of each value, so regressions in either are caught.
+type Record struct {
+	SchemaVersionID string          `json:"schema_version_id"`
+	Mapping         string          `json:"mapping,omitempty"` // mappings.json key, when not the schema version ID
+	Input           string          `json:"input"`
+	Columns         []string        `json:"columns,omitempty"`
+	Values          json.RawMessage `json:"values,omitempty"`
+	Types           []string        `json:"types,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// Fixture is the content of a fixture directory.
+type Fixture struct {
+	Mappings map[string]map[string]models.Mapping
+	Records  []Record
+}
+
+// NewRecord builds the record for one Map call.
+func NewRecord(m Mapper, data []byte, schemaVersionID string, values []any, mapErr error) (Record, error) {
+	rec := Record{
+		SchemaVersionID: schemaVersionID,
+		Input:           string(data),
+	}
+
+	if mapErr != nil {
+		rec.Error = mapErr.Error()
+		return rec, nil
+	}
+
+	columns, err := m.GetColumnNames(schemaVersionID)
+	if err != nil {
+		return rec, fmt.Errorf("get column names: %w", err)
+	}
+	rec.Columns = columns
+
+	rec.Values, err = json.Marshal(values)
+	if err != nil {
+		return rec, fmt.Errorf("marshal values: %w", err)
+	}
+
+	rec.Types = make([]string, len(values))
+	for i, v := range values {
+		rec.Types[i] = fmt.Sprintf("%T", v)
+	}
+
+	return rec, nil
+}
+
+// Recorder wraps a Mapper and appends every mapped event to a fixture
+// directory. Recording failures are logged and never affect mapping results.
+type Recorder struct {
+	mapper Mapper
+	dir    string
+	log    *slog.Logger
+
+	mu       sync.Mutex
+	records  *os.File
+	mappings map[string]map[string]models.Mapping
+}
+
+func NewRecorder(mapper Mapper, dir string, log *slog.Logger) (*Recorder, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("create fixture dir: %w", err)
+	}
+
+	mappings, err := readMappings(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := os.OpenFile(filepath.Join(dir, recordsFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open fixture records: %w", err)
+	}
+
+	return &Recorder{
+		mapper:   mapper,
+		dir:      dir,
+		log:      log,
+		records:  records,
+		mappings: mappings,
+	}, nil
+}
+
+func (r *Recorder) Map(data []byte, schemaVersionID string, config map[string]models.Mapping) ([]any, error) {
+	values, mapErr := r.mapper.Map(data, schemaVersionID, config)
+
+	err := r.record(data, schemaVersionID, config, values, mapErr)
+	if err != nil {
+		r.log.Error("failed to record fixture", slog.Any("error", err), slog.String("dir", r.dir))
+	}
+
+	return values, mapErr
+}
+
+func (r *Recorder) GetColumnNames(schemaVersionID string) ([]string, error) {
+	return r.mapper.GetColumnNames(schemaVersionID)
+}
+
+func (r *Recorder) record(data []byte, schemaVersionID string, config map[string]models.Mapping, values []any, mapErr error) error {
+	rec, err := NewRecord(r.mapper, data, schemaVersionID, values, mapErr)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, err := r.mappingKey(schemaVersionID, config)
+	if err != nil {
+		return err
+	}
+	if key != schemaVersionID {
+		rec.Mapping = key
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	_, err = r.records.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+
+	return nil
+}
+
+// mappingKey returns the mappings.json key config is stored under, adding it
+// when it was not recorded yet. The caller must hold r.mu.
+func (r *Recorder) mappingKey(schemaVersionID string, config map[string]models.Mapping) (string, error) {
+	key := schemaVersionID
+	for n := 2; ; n++ {
+		recorded, ok := r.mappings[key]
+		if !ok {
+			break
+		}
+		if reflect.DeepEqual(recorded, config) {
+			return key, nil
+		}
+		key = fmt.Sprintf("%s#%d", schemaVersionID, n)
+	}
+
+	r.mappings[key] = config
+	err := writeMappings(r.dir, r.mappings)
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Close flushes and closes the records file. It must only be called once the
+// wrapped mapper is no longer used.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.records.Close()
+}
+
+// Load reads a fixture directory.
+func Load(dir string) (*Fixture, error) {
+	mappings, err := readMappings(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, recordsFile))
+	if err != nil {
+		return nil, fmt.Errorf("open fixture records: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec Record
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read fixture records: %w", err)
+	}
+
+	return &Fixture{Mappings: mappings, Records: records}, nil
+}
+
+// Write replaces the content of a fixture directory, used to update golden files.
+func Write(dir string, fixture *Fixture) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("create fixture dir: %w", err)
+	}
+
+	err = writeMappings(dir, fixture.Mappings)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range fixture.Records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("marshal record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return writeFileAtomic(filepath.Join(dir, recordsFile), buf.Bytes())
+}
+
+// Replay maps every recorded input with m, using the recorded mapping config,
+// and returns the resulting records in the same order.
+func Replay(fixture *Fixture, m Mapper) ([]Record, error) {
+	records := make([]Record, 0, len(fixture.Records))
+	for i, rec := range fixture.Records {
+		key := rec.SchemaVersionID
+		if rec.Mapping != "" {
+			key = rec.Mapping
+		}
+		config, ok := fixture.Mappings[key]
+		if !ok {
+			return nil, fmt.Errorf("record %d: no mapping %s", i+1, key)
+		}
+
+		data := []byte(rec.Input)
+		values, mapErr := m.Map(data, rec.SchemaVersionID, config)
+
+		replayed, err := NewRecord(m, data, rec.SchemaVersionID, values, mapErr)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		replayed.Mapping = rec.Mapping
+		records = append(records, replayed)
+	}
+
+	return records, nil
+}
+
+// Diff compares replayed records against the recorded ones and returns a
+// description of every mismatch.
+func Diff(want, got []Record) []string {
+	var diffs []string
+	if len(want) != len(got) {
+		diffs = append(diffs, fmt.Sprintf("record count: want %d, got %d", len(want), len(got)))
+	}
+
+	for i := range min(len(want), len(got)) {
+		w, g := want[i], got[i]
+		if w.Error != g.Error {
+			diffs = append(diffs, fmt.Sprintf("record %d error: want %q, got %q", i+1, w.Error, g.Error))
+		}
+		if !reflect.DeepEqual(w.Columns, g.Columns) {
+			diffs = append(diffs, fmt.Sprintf("record %d columns: want %v, got %v", i+1, w.Columns, g.Columns))
+		}
+		if !jsonEqual(w.Values, g.Values) {
+			diffs = append(diffs, fmt.Sprintf("record %d values: want %s, got %s", i+1, w.Values, g.Values))
+		}
+		if !reflect.DeepEqual(w.Types, g.Types) {
+			diffs = append(diffs, fmt.Sprintf("record %d types: want %v, got %v", i+1, w.Types, g.Types))
+		}
+	}
+
+	return diffs
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func readMappings(dir string) (map[string]map[string]models.Mapping, error) {
+	mappings := make(map[string]map[string]models.Mapping)
+
+	data, err := os.ReadFile(filepath.Join(dir, mappingsFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return mappings, nil
+		}
+		return nil, fmt.Errorf("read fixture mappings: %w", err)
+	}
+
+	err = json.Unmarshal(data, &mappings)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal fixture mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+func writeMappings(dir string, mappings map[string]map[string]models.Mapping) error {
+	data, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixture mappings: %w", err)
+	}
+
+	return writeFileAtomic(filepath.Join(dir, mappingsFile), append(data, '\n'))
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("rename %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package fixture
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// upperMapper maps the raw input into a single column and fails on empty input.
+type upperMapper struct {
+	suffix string
+}
+
+func (m upperMapper) Map(data []byte, _ string, _ map[string]models.Mapping) ([]any, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty event")
+	}
+	return []any{string(data) + m.suffix, int64(len(data))}, nil
+}
+
+func (m upperMapper) GetColumnNames(string) ([]string, error) {
+	return []string{"value", "size"}, nil
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	config := map[string]models.Mapping{
+		"value": {SourceField: "value", SourceType: "string", DestinationField: "value", DestinationType: "String"},
+	}
+
+	recorder, err := NewRecorder(upperMapper{}, dir, slog.Default())
+	require.NoError(t, err)
+
+	values, err := recorder.Map([]byte("a"), "v1", config)
+	require.NoError(t, err)
+	require.Equal(t, []any{"a", int64(1)}, values)
+
+	_, err = recorder.Map(nil, "v1", config)
+	require.EqualError(t, err, "empty event")
+	require.NoError(t, recorder.Close())
+
+	recorded, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]models.Mapping{"v1": config}, recorded.Mappings)
+	require.Len(t, recorded.Records, 2)
+	require.Equal(t, []string{"value", "size"}, recorded.Records[0].Columns)
+	require.JSONEq(t, `["a",1]`, string(recorded.Records[0].Values))
+	require.Equal(t, []string{"string", "int64"}, recorded.Records[0].Types)
+	require.Equal(t, "empty event", recorded.Records[1].Error)
+
+	replayed, err := Replay(recorded, upperMapper{})
+	require.NoError(t, err)
+	require.Empty(t, Diff(recorded.Records, replayed))
+
+	replayed, err = Replay(recorded, upperMapper{suffix: "!"})
+	require.NoError(t, err)
+	require.Equal(t, []string{`record 1 values: want ["a",1], got ["a!",1]`}, Diff(recorded.Records, replayed))
+}
+
+func TestRecorder_MappingChange(t *testing.T) {
+	dir := t.TempDir()
+	before := map[string]models.Mapping{"value": {SourceField: "value", DestinationField: "value"}}
+	after := map[string]models.Mapping{"value": {SourceField: "value", DestinationField: "renamed"}}
+
+	recorder, err := NewRecorder(upperMapper{}, dir, slog.Default())
+	require.NoError(t, err)
+
+	for _, config := range []map[string]models.Mapping{before, after, after, before} {
+		_, err = recorder.Map([]byte("a"), "v1", config)
+		require.NoError(t, err)
+	}
+	require.NoError(t, recorder.Close())
+
+	recorded, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]models.Mapping{"v1": before, "v1#2": after}, recorded.Mappings)
+	require.Len(t, recorded.Records, 4)
+	for i, want := range []string{"", "v1#2", "v1#2", ""} {
+		require.Equal(t, want, recorded.Records[i].Mapping)
+	}
+
+	replayed, err := Replay(recorded, upperMapper{})
+	require.NoError(t, err)
+	require.Empty(t, Diff(recorded.Records, replayed))
+}
+
+func TestReplay_MissingMapping(t *testing.T) {
+	_, err := Replay(&Fixture{Records: []Record{{SchemaVersionID: "v2", Input: "a"}}}, upperMapper{})
+	require.ErrorContains(t, err, "no mapping v2")
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	want := &Fixture{
+		Mappings: map[string]map[string]models.Mapping{"v1": {}},
+		Records:  []Record{{SchemaVersionID: "v1", Input: "a", Error: "boom"}},
+	}
+
+	require.NoError(t, Write(dir, want))
+
+	got, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
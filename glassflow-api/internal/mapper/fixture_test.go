@@ -0,0 +1,47 @@
+package mapper
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper/fixture"
+)
+
+var updateFixtures = flag.Bool("update", false, "rewrite golden fixtures with the current mapper output")
+
+// TestKafkaToClickHouseMapper_Fixtures replays every fixture directory under
+// testdata/fixtures against a fresh mapper. Fixtures can be recorded from a
+// running sink with GLASSFLOW_SINK_FIXTURE_DIR.
+func TestKafkaToClickHouseMapper_Fixtures(t *testing.T) {
+	entries, err := os.ReadDir(filepath.Join("testdata", "fixtures"))
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		t.Run(entry.Name(), func(t *testing.T) {
+			dir := filepath.Join("testdata", "fixtures", entry.Name())
+
+			want, err := fixture.Load(dir)
+			require.NoError(t, err)
+
+			got, err := fixture.Replay(want, NewKafkaToClickHouseMapper())
+			require.NoError(t, err)
+
+			if *updateFixtures {
+				require.NoError(t, fixture.Write(dir, &fixture.Fixture{Mappings: want.Mappings, Records: got}))
+				return
+			}
+
+			diffs := fixture.Diff(want.Records, got)
+			require.Empty(t, diffs, "fixture %s does not match, rerun with -update if intended:\n%s", dir, strings.Join(diffs, "\n"))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"unsafe"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
@@ -21,6 +22,8 @@ type columnInfo struct {
 type columnMetadata struct {
 	columns          []string
 	columnLookUpInfo map[string]columnInfo // keyed by source field name
+	// eventColumns are mapped from the whole event (internal.SinkEventField)
+	eventColumns []columnInfo
 }
 
 type KafkaToClickHouseMapper struct {
@@ -34,6 +37,11 @@ func NewKafkaToClickHouseMapper() *KafkaToClickHouseMapper {
 	}
 }
 
+// Map converts a JSON event into the column values of schemaVersionID.
+//
+// data is borrowed rather than copied: string values in the result may share
+// memory with it, so data must not be modified until the values have been
+// consumed (appended to a ClickHouse batch, which copies them).
 func (m *KafkaToClickHouseMapper) Map(data []byte, schemaVersionID string, config map[string]models.Mapping) ([]any, error) {
 	m.mu.RLock()
 	metadata, exists := m.columnsMetadata[schemaVersionID]
@@ -48,20 +56,27 @@ func (m *KafkaToClickHouseMapper) Map(data []byte, schemaVersionID string, confi
 
 		columnsList := make([]string, len(config))
 		lookUpMap := make(map[string]columnInfo)
+		var eventColumns []columnInfo
 		for idx, key := range sortedKeys {
 			field := config[key]
 			columnsList[idx] = field.DestinationField
-			lookUpMap[field.SourceField] = columnInfo{
+			info := columnInfo{
 				idx:         idx,
 				columnType:  ClickHouseDataType(field.DestinationType),
 				sourceField: field.SourceField,
 				sourceType:  KafkaDataType(internal.NormalizeToBasicKafkaType(field.SourceType)),
 			}
+			if field.SourceField == internal.SinkEventField {
+				eventColumns = append(eventColumns, info)
+				continue
+			}
+			lookUpMap[field.SourceField] = info
 		}
 
 		metadata = columnMetadata{
 			columns:          columnsList,
 			columnLookUpInfo: lookUpMap,
+			eventColumns:     eventColumns,
 		}
 		m.mu.Lock()
 		if existing, alreadyExists := m.columnsMetadata[schemaVersionID]; alreadyExists {
@@ -74,7 +89,8 @@ func (m *KafkaToClickHouseMapper) Map(data []byte, schemaVersionID string, confi
 
 	values := make([]any, len(metadata.columns))
 
-	parsedJson := gjson.ParseBytes(data)
+	// gjson.ParseBytes copies the whole payload into a string
+	parsedJson := gjson.Parse(unsafe.String(unsafe.SliceData(data), len(data)))
 
 	var conversionErr error
 
@@ -111,10 +127,18 @@ func (m *KafkaToClickHouseMapper) Map(data []byte, schemaVersionID string, confi
 				return nil, fmt.Errorf("failed to convert field %s: %w", info.sourceField, err)
 			}
 			values[info.idx] = convertedValue
-		} else if strings.HasPrefix(string(info.columnType), "Map(") {
-			// Map types cannot be NULL in ClickHouse; use empty map for missing fields
-			values[info.idx] = map[string]string{}
+		} else {
+			// Map and JSON types cannot be NULL in ClickHouse
+			values[info.idx] = emptyValue(info.columnType)
+		}
+	}
+
+	for _, info := range metadata.eventColumns {
+		convertedValue, err := ConvertValueFromJson(info.columnType, info.sourceType, parsedJson)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert event: %w", err)
 		}
+		values[info.idx] = convertedValue
 	}
 
 	return values, nil
@@ -0,0 +1,152 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type mapperBenchCase struct {
+	name   string
+	config map[string]models.Mapping
+	data   []byte
+	// allocBudget is the maximum number of heap allocations a single Map call
+	// may make. Budgets leave headroom over the current counts so only real
+	// regressions in the hot path trip them.
+	allocBudget float64
+}
+
+func benchMapping(source, sourceType, destinationType string) models.Mapping {
+	return models.Mapping{
+		SourceField:      source,
+		SourceType:       sourceType,
+		DestinationField: source,
+		DestinationType:  destinationType,
+	}
+}
+
+func mapperBenchCases() []mapperBenchCase {
+	return []mapperBenchCase{
+		{
+			name: "scalars",
+			config: map[string]models.Mapping{
+				"id":      benchMapping("id", internal.KafkaTypeString, internal.CHTypeString),
+				"country": benchMapping("country", internal.KafkaTypeString, internal.CHTypeLCString),
+				"session": benchMapping("session", internal.KafkaTypeString, internal.CHTypeUUID),
+				"count":   benchMapping("count", "int32", internal.CHTypeInt32),
+				"total":   benchMapping("total", "int64", internal.CHTypeInt64),
+				"size":    benchMapping("size", "uint64", internal.CHTypeUInt64),
+				"score":   benchMapping("score", internal.KafkaTypeFloat, internal.CHTypeFloat64),
+				"active":  benchMapping("active", internal.KafkaTypeBool, internal.CHTypeBool),
+			},
+			data: []byte(`{"id":"evt-123456","country":"DE","session":"0b7e4c52-5a2e-4f1e-9d3c-6f1b2a3c4d5e",` +
+				`"count":123456,"total":9876543210,"size":4096,"score":98.5,"active":true,"ignored":"x"}`),
+			allocBudget: 32,
+		},
+		{
+			name: "low_cardinality",
+			config: map[string]models.Mapping{
+				"country": benchMapping("country", internal.KafkaTypeString, internal.CHTypeLCString),
+				"region":  benchMapping("region", internal.KafkaTypeString, internal.CHTypeLCString),
+				"code":    benchMapping("code", internal.KafkaTypeString, "LowCardinality(FixedString(2))"),
+				"level":   benchMapping("level", "int32", internal.CHTypeLCInt32),
+				"ratio":   benchMapping("ratio", internal.KafkaTypeFloat, internal.CHTypeLCFloat64),
+			},
+			data:        []byte(`{"country":"Germany","region":"EU","code":"DE","level":30000,"ratio":0.25}`),
+			allocBudget: 24,
+		},
+		{
+			name: "nested_fields",
+			config: map[string]models.Mapping{
+				"user.id":            benchMapping("user.id", internal.KafkaTypeString, internal.CHTypeString),
+				"user.address.city":  benchMapping("user.address.city", internal.KafkaTypeString, internal.CHTypeString),
+				"user.address.zip":   benchMapping("user.address.zip", "int32", internal.CHTypeInt32),
+				"container.image.id": benchMapping("container.image.id", internal.KafkaTypeString, internal.CHTypeString),
+			},
+			data: []byte(`{"user":{"id":"u-42","address":{"city":"Berlin","zip":10115}},` +
+				`"container.image.id":"sha256:abc"}`),
+			allocBudget: 48,
+		},
+		{
+			name: "arrays",
+			config: map[string]models.Mapping{
+				"tags":   benchMapping("tags", internal.KafkaTypeArray, "Array(String)"),
+				"scores": benchMapping("scores", internal.KafkaTypeArray, "Array(Float64)"),
+				"attrs":  benchMapping("attrs", internal.KafkaTypeMap, "Map(String, String)"),
+			},
+			data:        []byte(`{"tags":["a","b","c"],"scores":[1.5,2.5,3.5],"attrs":{"k1":"v1","k2":"v2"}}`),
+			allocBudget: 48,
+		},
+		{
+			name: "datetime",
+			config: map[string]models.Mapping{
+				"created_at": benchMapping("created_at", internal.KafkaTypeString, "DateTime64(6, 'UTC')"),
+				"updated_at": benchMapping("updated_at", "int64", internal.CHTypeDateTime),
+				"seen_at":    benchMapping("seen_at", internal.KafkaTypeFloat, internal.CHTypeDateTime64),
+			},
+			data:        []byte(`{"created_at":"2024-05-01T10:20:30.123456Z","updated_at":1714558830,"seen_at":1714558830.5}`),
+			allocBudget: 20,
+		},
+	}
+}
+
+// TestKafkaToClickHouseMapper_Map_AllocBudget fails when a Map call allocates
+// more than its case budget. Run the benchmarks to see the current numbers.
+func TestKafkaToClickHouseMapper_Map_AllocBudget(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not representative with the race detector")
+	}
+
+	for _, tc := range mapperBenchCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			mapper := NewKafkaToClickHouseMapper()
+			_, err := mapper.Map(tc.data, "v1", tc.config)
+			require.NoError(t, err)
+
+			allocs := testing.AllocsPerRun(100, func() {
+				_, _ = mapper.Map(tc.data, "v1", tc.config)
+			})
+			require.LessOrEqual(t, allocs, tc.allocBudget, "Map allocations per call exceed the budget")
+		})
+	}
+}
+
+func BenchmarkKafkaToClickHouseMapper_Map(b *testing.B) {
+	for _, tc := range mapperBenchCases() {
+		b.Run(tc.name, func(b *testing.B) {
+			mapper := NewKafkaToClickHouseMapper()
+			_, err := mapper.Map(tc.data, "v1", tc.config)
+			require.NoError(b, err)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.data)))
+			for b.Loop() {
+				_, _ = mapper.Map(tc.data, "v1", tc.config)
+			}
+		})
+	}
+}
+
+// BenchmarkKafkaToClickHouseMapper_Map_Parallel matches the sink, where worker
+// goroutines share one mapper.
+func BenchmarkKafkaToClickHouseMapper_Map_Parallel(b *testing.B) {
+	for _, tc := range mapperBenchCases() {
+		b.Run(tc.name, func(b *testing.B) {
+			mapper := NewKafkaToClickHouseMapper()
+			_, err := mapper.Map(tc.data, "v1", tc.config)
+			require.NoError(b, err)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.data)))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _ = mapper.Map(tc.data, "v1", tc.config)
+				}
+			})
+		})
+	}
+}
@@ -255,6 +255,31 @@ func TestKafkaToClickHouseMapper_Map(t *testing.T) {
 			data:     []byte(`{"name":"John"}`),
 			expected: map[string]any{"name": "John", "age": nil},
 		},
+		{
+			name: "maps Nullable columns and falls back to NULL for missing fields",
+			config: map[string]models.Mapping{
+				"email": {
+					SourceField:      "email",
+					SourceType:       string(internal.KafkaTypeString),
+					DestinationField: "email",
+					DestinationType:  "Nullable(String)",
+				},
+				"score": {
+					SourceField:      "score",
+					SourceType:       string(internal.KafkaTypeFloat),
+					DestinationField: "score",
+					DestinationType:  "Nullable(Float64)",
+				},
+				"country": {
+					SourceField:      "country",
+					SourceType:       string(internal.KafkaTypeString),
+					DestinationField: "country",
+					DestinationType:  "LowCardinality(Nullable(String))",
+				},
+			},
+			data:     []byte(`{"score":1.5,"country":null}`),
+			expected: map[string]any{"email": nil, "score": float64(1.5), "country": nil},
+		},
 		{
 			name: "maps different source and destination field names",
 			config: map[string]models.Mapping{
@@ -334,6 +359,35 @@ func TestKafkaToClickHouseMapper_Map(t *testing.T) {
 			data:     []byte(`{}`),
 			expected: map[string]any{"name": nil},
 		},
+		{
+			name: "maps the whole event and a sub-object to JSON columns",
+			config: map[string]models.Mapping{
+				"event": {
+					SourceField:      internal.SinkEventField,
+					SourceType:       string(internal.KafkaTypeMap),
+					DestinationField: "event",
+					DestinationType:  internal.CHTypeJSON,
+				},
+				"user": {
+					SourceField:      "user",
+					SourceType:       string(internal.KafkaTypeMap),
+					DestinationField: "user",
+					DestinationType:  internal.CHTypeJSON,
+				},
+				"attrs": {
+					SourceField:      "attrs",
+					SourceType:       string(internal.KafkaTypeMap),
+					DestinationField: "attrs",
+					DestinationType:  internal.CHTypeJSON,
+				},
+			},
+			data: []byte(`{"id":1,"user":{"name":"a","tags":["x"]}}`),
+			expected: map[string]any{
+				"event": `{"id":1,"user":{"name":"a","tags":["x"]}}`,
+				"user":  `{"name":"a","tags":["x"]}`,
+				"attrs": "{}",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/paulmach/orb"
 	"github.com/tidwall/gjson"
 )
 
@@ -45,12 +48,24 @@ func ExtractEventValue(dataType KafkaDataType, data any) (zero any, _ error) {
 }
 
 func ConvertValueFromJson(columnType ClickHouseDataType, fieldType KafkaDataType, result gjson.Result) (any, error) {
+	if innerType, ok := unwrapSimpleAggregateFunction(string(columnType)); ok {
+		columnType = ClickHouseDataType(innerType)
+	}
+
 	if !result.Exists() || result.Type == gjson.Null {
-		// Map types cannot be NULL in ClickHouse, return empty map instead
-		if strings.HasPrefix(string(columnType), "Map(") {
-			return map[string]string{}, nil
+		return emptyValue(columnType), nil
+	}
+
+	// The JSON type takes the raw JSON text, the server types the paths
+	if internal.IsJSONType(string(columnType)) {
+		switch {
+		case result.IsObject():
+			return result.Raw, nil
+		case result.Type == gjson.String:
+			return result.String(), nil
+		default:
+			return nil, fmt.Errorf("expected JSON object for %s type, got %s", columnType, result.Type)
 		}
-		return nil, nil
 	}
 
 	// Extract value based on field type (basic types only), directly from gjson.Result
@@ -77,14 +92,20 @@ func ConvertValueFromJson(columnType ClickHouseDataType, fieldType KafkaDataType
 }
 
 func ConvertValue(columnType ClickHouseDataType, fieldType KafkaDataType, data any) (zero any, _ error) {
+	if innerType, ok := unwrapSimpleAggregateFunction(string(columnType)); ok {
+		columnType = ClickHouseDataType(innerType)
+	}
+
 	// If data is nil, pass it through to let ClickHouse handle null validation
 	// HOTFIX: This is a temporary, will be moved up and sent to DLQ as a proper solution.
 	if data == nil {
-		// Map types cannot be NULL in ClickHouse, return empty map instead
-		if strings.HasPrefix(string(columnType), "Map(") {
-			return map[string]string{}, nil
-		}
-		return nil, nil
+		return emptyValue(columnType), nil
+	}
+
+	// Nullable columns take the same values as their inner type; missing
+	// fields are already returned as nil above and inserted as NULL.
+	if innerType, ok := unwrapNullable(string(columnType)); ok {
+		return ConvertValue(ClickHouseDataType(innerType), fieldType, data)
 	}
 
 	switch columnType {
@@ -164,12 +185,63 @@ func ConvertValue(columnType ClickHouseDataType, fieldType KafkaDataType, data a
 		default:
 			return zero, fmt.Errorf("mismatched types: expected int, float or string type for DateTime, got %s", fieldType)
 		}
+	case internal.CHTypeDate, internal.CHTypeDate32:
+		var t time.Time
+		var err error
+		switch fieldType {
+		case internal.KafkaTypeInt:
+			t, err = ParseDateTimeFromInt64(data)
+		case internal.KafkaTypeFloat:
+			t, err = ParseDateTimeFromFloat64(data)
+		case internal.KafkaTypeString:
+			t, err = ParseDateTimeFromString(data)
+		default:
+			return zero, fmt.Errorf("mismatched types: expected int, float or string type for %s, got %s", columnType, fieldType)
+		}
+		if err != nil {
+			return zero, err
+		}
+		return ParseDate(t, columnType == internal.CHTypeDate32)
+	case internal.CHTypeIPv4:
+		if fieldType != internal.KafkaTypeString {
+			return zero, fmt.Errorf("mismatched types: expected %s, got %s", internal.KafkaTypeString, fieldType)
+		}
+		return ParseIPv4(data)
+	case internal.CHTypeIPv6:
+		if fieldType != internal.KafkaTypeString {
+			return zero, fmt.Errorf("mismatched types: expected %s, got %s", internal.KafkaTypeString, fieldType)
+		}
+		return ParseIPv6(data)
+	case internal.CHTypeObjectJSON:
+		return convertJSONObject(data)
+	case internal.CHTypePoint:
+		return ParsePoint(data)
+	case internal.CHTypeRing:
+		return ParseRing(data)
+	case internal.CHTypePolygon:
+		return ParsePolygon(data)
 	default:
+		if internal.IsJSONType(string(columnType)) {
+			return convertJSONText(data)
+		}
+		// Handle Decimal(P, S) and Decimal32/64/128/256(S)
+		if precision, scale, ok := parseDecimalType(string(columnType)); ok {
+			switch fieldType {
+			case internal.KafkaTypeString, internal.KafkaTypeInt, internal.KafkaTypeUint, internal.KafkaTypeFloat:
+				return ParseDecimal(data, precision, scale)
+			default:
+				return zero, fmt.Errorf("mismatched types: expected string, int, uint or float type for Decimal, got %s", fieldType)
+			}
+		}
 		// Handle FixedString(N) and LowCardinality(FixedString(N)) like FixedString
 		if internal.IsFixedStringType(string(columnType)) {
 			if fieldType != internal.KafkaTypeString {
 				return zero, fmt.Errorf("mismatched types: expected %s, got %s", internal.KafkaTypeString, fieldType)
 			}
+			// FixedString(16) is the usual binary UUID column
+			if fixedStringLength(string(columnType)) == uuidBinarySize {
+				return ParseUUIDBinary(data)
+			}
 			return ExtractEventValue(internal.KafkaTypeString, data)
 		}
 		// Handle DateTime64 with parameters (e.g., "DateTime64(6, 'UTC')")
@@ -224,6 +296,123 @@ func ConvertValue(columnType ClickHouseDataType, fieldType KafkaDataType, data a
 	}
 }
 
+// decimalPrecisions maps the sized Decimal aliases to their precision.
+var decimalPrecisions = map[string]int{
+	"Decimal32":  9,
+	"Decimal64":  18,
+	"Decimal128": 38,
+	"Decimal256": 76,
+}
+
+// parseDecimalType returns the precision and scale of Decimal(P), Decimal(P, S)
+// and Decimal32/64/128/256(S) column types.
+func parseDecimalType(columnType string) (precision, scale int, ok bool) {
+	t := strings.TrimSpace(columnType)
+	name, args, found := strings.Cut(t, "(")
+	if !found || !strings.HasSuffix(args, ")") {
+		return 0, 0, false
+	}
+	params := strings.Split(strings.TrimSuffix(args, ")"), ",")
+	values := make([]int, 0, len(params))
+	for _, p := range params {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return 0, 0, false
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case name == "Decimal" && len(values) == 1:
+		precision = values[0]
+	case name == "Decimal" && len(values) == 2:
+		precision, scale = values[0], values[1]
+	case decimalPrecisions[name] != 0 && len(values) == 1:
+		precision, scale = decimalPrecisions[name], values[0]
+	default:
+		return 0, 0, false
+	}
+
+	if precision < 1 || precision > 76 || scale < 0 || scale > precision {
+		return 0, 0, false
+	}
+	return precision, scale, true
+}
+
+// fixedStringLength returns N of FixedString(N) and LowCardinality(FixedString(N))
+// column types, or 0 when the type has no length.
+func fixedStringLength(columnType string) int {
+	t := strings.TrimSpace(columnType)
+	if strings.HasPrefix(t, "LowCardinality(") {
+		t = strings.TrimSuffix(strings.TrimPrefix(t, "LowCardinality("), ")")
+	}
+	args, found := strings.CutPrefix(t, internal.CHTypeFString+"(")
+	if !found || !strings.HasSuffix(args, ")") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(args, ")")))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// unwrapNullable strips the Nullable wrapper from Nullable(T) and
+// LowCardinality(Nullable(T)) column types.
+func unwrapNullable(columnType string) (string, bool) {
+	t := strings.TrimSpace(columnType)
+	lowCardinality := false
+	if strings.HasPrefix(t, "LowCardinality(Nullable(") && strings.HasSuffix(t, "))") {
+		t = strings.TrimPrefix(t, "LowCardinality(")
+		t = strings.TrimSuffix(t, ")")
+		lowCardinality = true
+	}
+	if !strings.HasPrefix(t, "Nullable(") || !strings.HasSuffix(t, ")") {
+		return "", false
+	}
+
+	inner := strings.TrimSpace(t[len("Nullable(") : len(t)-1])
+	if lowCardinality {
+		inner = "LowCardinality(" + inner + ")"
+	}
+	return inner, true
+}
+
+// unwrapSimpleAggregateFunction returns T of a SimpleAggregateFunction(f, T)
+// column type. These columns are inserted with plain T values, ClickHouse
+// applies f when merging parts.
+func unwrapSimpleAggregateFunction(columnType string) (string, bool) {
+	t := strings.TrimSpace(columnType)
+	if !strings.HasPrefix(t, "SimpleAggregateFunction(") || !strings.HasSuffix(t, ")") {
+		return "", false
+	}
+
+	args := t[len("SimpleAggregateFunction(") : len(t)-1]
+	// The function can have parameters, e.g. groupUniqArrayArray(10)
+	depth := 0
+	for i, r := range args {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				inner := strings.TrimSpace(args[i+1:])
+				return inner, inner != ""
+			}
+		}
+	}
+	return "", false
+}
+
+// isAggregateFunctionType reports whether the column holds aggregate function
+// states. The sink cannot produce -State values for these columns.
+func isAggregateFunctionType(columnType string) bool {
+	t := strings.TrimSpace(columnType)
+	return strings.HasPrefix(t, "AggregateFunction(") && strings.HasSuffix(t, ")")
+}
+
 func GetDefaultValueForKafkaType(kafkaType KafkaDataType) (any, error) {
 	// we would get invalid zeroValue only if there's unknown type
 	zeroValue, err := ExtractEventValue(kafkaType, "")
@@ -234,6 +423,68 @@ func GetDefaultValueForKafkaType(kafkaType KafkaDataType) (any, error) {
 	return zeroValue, nil
 }
 
+// emptyValue returns the value inserted for a missing field. Map, JSON and geo
+// types cannot be NULL in ClickHouse, they get an empty value instead.
+func emptyValue(columnType ClickHouseDataType) any {
+	switch {
+	case strings.HasPrefix(string(columnType), "Map("):
+		return map[string]string{}
+	case internal.IsJSONType(string(columnType)):
+		return "{}"
+	case columnType == internal.CHTypeObjectJSON:
+		return map[string]any{}
+	case columnType == internal.CHTypePoint:
+		return orb.Point{}
+	case columnType == internal.CHTypeRing:
+		return orb.Ring{}
+	case columnType == internal.CHTypePolygon:
+		return orb.Polygon{}
+	default:
+		return nil
+	}
+}
+
+func isGeoType(columnType string) bool {
+	return columnType == internal.CHTypePoint ||
+		columnType == internal.CHTypeRing ||
+		columnType == internal.CHTypePolygon
+}
+
+// convertJSONText converts an object, or a string holding one, to the JSON
+// text inserted into a JSON column.
+func convertJSONText(data any) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case map[string]any:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal object to JSON: %w", err)
+		}
+		return string(jsonBytes), nil
+	default:
+		return "", fmt.Errorf("expected object data for JSON type, got %T", data)
+	}
+}
+
+// convertJSONObject converts an object, or a string holding one, to the map
+// inserted into an Object('json') column.
+func convertJSONObject(data any) (map[string]any, error) {
+	switch v := data.(type) {
+	case map[string]any:
+		return v, nil
+	case string:
+		var obj map[string]any
+		err := json.Unmarshal([]byte(v), &obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON object: %w", err)
+		}
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("expected object data for %s type, got %T", internal.CHTypeObjectJSON, data)
+	}
+}
+
 // convertMapToStringMap converts map[string]any to map[string]string for ClickHouse compatibility
 func convertMapToStringMap(data any) (map[string]string, error) {
 	if data == nil {
@@ -2,11 +2,13 @@ package mapper
 
 import (
 	"math"
+	"net/netip"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/paulmach/orb"
 )
 
 func TestExtractEventValue(t *testing.T) {
@@ -613,6 +615,294 @@ func TestConvertValue(t *testing.T) {
 			want:       []map[string]string{},
 			wantErr:    false,
 		},
+		{
+			name:       "int to Nullable(Int32)",
+			columnType: "Nullable(Int32)",
+			fieldType:  internal.KafkaTypeInt,
+			input:      42,
+			want:       int32(42),
+			wantErr:    false,
+		},
+		{
+			name:       "nil to Nullable(String)",
+			columnType: "Nullable(String)",
+			fieldType:  internal.KafkaTypeString,
+			input:      nil,
+			want:       nil,
+			wantErr:    false,
+		},
+		{
+			name:       "string to LowCardinality(Nullable(String))",
+			columnType: "LowCardinality(Nullable(String))",
+			fieldType:  internal.KafkaTypeString,
+			input:      "test",
+			want:       "test",
+			wantErr:    false,
+		},
+		{
+			name:       "int to Nullable(DateTime64(3, 'UTC'))",
+			columnType: "Nullable(DateTime64(3, 'UTC'))",
+			fieldType:  internal.KafkaTypeInt,
+			input:      int64(1714558830),
+			want:       time.Unix(1714558830, 0),
+			wantErr:    false,
+		},
+		{
+			name:       "string to Date",
+			columnType: internal.CHTypeDate,
+			fieldType:  internal.KafkaTypeString,
+			input:      "2024-05-01T23:30:00Z",
+			want:       time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			wantErr:    false,
+		},
+		{
+			name:       "int to Date",
+			columnType: internal.CHTypeDate,
+			fieldType:  internal.KafkaTypeInt,
+			input:      int64(1714558830),
+			want:       time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			wantErr:    false,
+		},
+		{
+			name:       "string before 1970 to Date",
+			columnType: internal.CHTypeDate,
+			fieldType:  internal.KafkaTypeString,
+			input:      "1969-12-31",
+			wantErr:    true,
+		},
+		{
+			name:       "string before 1970 to Date32",
+			columnType: internal.CHTypeDate32,
+			fieldType:  internal.KafkaTypeString,
+			input:      "1950-06-15",
+			want:       time.Date(1950, 6, 15, 0, 0, 0, 0, time.UTC),
+			wantErr:    false,
+		},
+		{
+			name:       "bool to Date32",
+			columnType: internal.CHTypeDate32,
+			fieldType:  internal.KafkaTypeBool,
+			input:      true,
+			wantErr:    true,
+		},
+		{
+			name:       "string to IPv4",
+			columnType: internal.CHTypeIPv4,
+			fieldType:  internal.KafkaTypeString,
+			input:      "192.168.0.1",
+			want:       netip.MustParseAddr("192.168.0.1"),
+			wantErr:    false,
+		},
+		{
+			name:       "IPv6 string to IPv4",
+			columnType: internal.CHTypeIPv4,
+			fieldType:  internal.KafkaTypeString,
+			input:      "2001:db8::1",
+			wantErr:    true,
+		},
+		{
+			name:       "string to IPv6",
+			columnType: internal.CHTypeIPv6,
+			fieldType:  internal.KafkaTypeString,
+			input:      "2001:db8::1",
+			want:       netip.MustParseAddr("2001:db8::1"),
+			wantErr:    false,
+		},
+		{
+			name:       "IPv4 string to IPv6",
+			columnType: internal.CHTypeIPv6,
+			fieldType:  internal.KafkaTypeString,
+			input:      "10.0.0.1",
+			want:       netip.MustParseAddr("::ffff:10.0.0.1"),
+			wantErr:    false,
+		},
+		{
+			name:       "invalid string to IPv6",
+			columnType: internal.CHTypeIPv6,
+			fieldType:  internal.KafkaTypeString,
+			input:      "not-an-ip",
+			wantErr:    true,
+		},
+		{
+			name:       "UUID string to FixedString(16)",
+			columnType: "FixedString(16)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "550e8400-e29b-41d4-a716-446655440000",
+			want:       []byte{0x55, 0x0e, 0x84, 0x00, 0xe2, 0x9b, 0x41, 0xd4, 0xa7, 0x16, 0x44, 0x66, 0x55, 0x44, 0x00, 0x00},
+			wantErr:    false,
+		},
+		{
+			name:       "short string to FixedString(16)",
+			columnType: "FixedString(16)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "abc",
+			want:       "abc",
+			wantErr:    false,
+		},
+		{
+			name:       "long non UUID string to FixedString(16)",
+			columnType: "FixedString(16)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "this string is too long",
+			wantErr:    true,
+		},
+		{
+			name:       "UUID string to FixedString(36)",
+			columnType: "FixedString(36)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "550e8400-e29b-41d4-a716-446655440000",
+			want:       "550e8400-e29b-41d4-a716-446655440000",
+			wantErr:    false,
+		},
+		{
+			name:       "out of range string to Decimal(5, 2)",
+			columnType: "Decimal(5, 2)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "1234.5",
+			wantErr:    true,
+		},
+		{
+			name:       "bool to Decimal64(4)",
+			columnType: "Decimal64(4)",
+			fieldType:  internal.KafkaTypeBool,
+			input:      true,
+			wantErr:    true,
+		},
+		{
+			name:       "mismatched type to Nullable(Bool)",
+			columnType: "Nullable(Bool)",
+			fieldType:  internal.KafkaTypeString,
+			input:      "true",
+			wantErr:    true,
+		},
+		{
+			name:       "map to JSON",
+			columnType: internal.CHTypeJSON,
+			fieldType:  internal.KafkaTypeMap,
+			input:      map[string]any{"a": float64(1), "b": "x"},
+			want:       `{"a":1,"b":"x"}`,
+		},
+		{
+			name:       "JSON string to JSON(max_dynamic_paths=16)",
+			columnType: "JSON(max_dynamic_paths=16)",
+			fieldType:  internal.KafkaTypeString,
+			input:      `{"a":1}`,
+			want:       `{"a":1}`,
+		},
+		{
+			name:       "nil to JSON",
+			columnType: internal.CHTypeJSON,
+			fieldType:  internal.KafkaTypeMap,
+			input:      nil,
+			want:       "{}",
+		},
+		{
+			name:       "int to JSON",
+			columnType: internal.CHTypeJSON,
+			fieldType:  internal.KafkaTypeInt,
+			input:      1,
+			wantErr:    true,
+		},
+		{
+			name:       "JSON string to Object('json')",
+			columnType: internal.CHTypeObjectJSON,
+			fieldType:  internal.KafkaTypeString,
+			input:      `{"a":"x"}`,
+			want:       map[string]any{"a": "x"},
+		},
+		{
+			name:       "invalid JSON string to Object('json')",
+			columnType: internal.CHTypeObjectJSON,
+			fieldType:  internal.KafkaTypeString,
+			input:      `not json`,
+			wantErr:    true,
+		},
+		{
+			name:       "uint to SimpleAggregateFunction(sum, UInt64)",
+			columnType: "SimpleAggregateFunction(sum, UInt64)",
+			fieldType:  internal.KafkaTypeUint,
+			input:      uint64(5),
+			want:       uint64(5),
+		},
+		{
+			name:       "nil to SimpleAggregateFunction(anyLast, Map(String, String))",
+			columnType: "SimpleAggregateFunction(anyLast, Map(String, String))",
+			fieldType:  internal.KafkaTypeMap,
+			input:      nil,
+			want:       map[string]string{},
+		},
+		{
+			name:       "lon lat array to Point",
+			columnType: internal.CHTypePoint,
+			fieldType:  internal.KafkaTypeArray,
+			input:      []any{13.4, 52.52},
+			want:       orb.Point{13.4, 52.52},
+		},
+		{
+			name:       "GeoJSON object to Point",
+			columnType: internal.CHTypePoint,
+			fieldType:  internal.KafkaTypeMap,
+			input:      map[string]any{"type": "Point", "coordinates": []any{13.4, 52.52, 34.0}},
+			want:       orb.Point{13.4, 52.52},
+		},
+		{
+			name:       "GeoJSON string to Point",
+			columnType: internal.CHTypePoint,
+			fieldType:  internal.KafkaTypeString,
+			input:      `{"type":"Point","coordinates":[13.4,52.52]}`,
+			want:       orb.Point{13.4, 52.52},
+		},
+		{
+			name:       "wrong GeoJSON type to Point",
+			columnType: internal.CHTypePoint,
+			fieldType:  internal.KafkaTypeMap,
+			input:      map[string]any{"type": "Polygon", "coordinates": []any{13.4, 52.52}},
+			wantErr:    true,
+		},
+		{
+			name:       "single coordinate to Point",
+			columnType: internal.CHTypePoint,
+			fieldType:  internal.KafkaTypeArray,
+			input:      []any{13.4},
+			wantErr:    true,
+		},
+		{
+			name:       "positions array to Ring",
+			columnType: internal.CHTypeRing,
+			fieldType:  internal.KafkaTypeArray,
+			input:      []any{[]any{0.0, 0.0}, []any{1.0, 0.0}, []any{1.0, 1.0}, []any{0.0, 0.0}},
+			want:       orb.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		},
+		{
+			name:       "GeoJSON LineString to Ring",
+			columnType: internal.CHTypeRing,
+			fieldType:  internal.KafkaTypeMap,
+			input:      map[string]any{"type": "LineString", "coordinates": []any{[]any{0.0, 0.0}, []any{1.0, 1.0}}},
+			want:       orb.Ring{{0, 0}, {1, 1}},
+		},
+		{
+			name:       "GeoJSON object to Polygon",
+			columnType: internal.CHTypePolygon,
+			fieldType:  internal.KafkaTypeMap,
+			input: map[string]any{
+				"type": "Polygon",
+				"coordinates": []any{
+					[]any{[]any{0.0, 0.0}, []any{10.0, 0.0}, []any{10.0, 10.0}, []any{0.0, 0.0}},
+					[]any{[]any{1.0, 1.0}, []any{2.0, 1.0}, []any{2.0, 2.0}, []any{1.0, 1.0}},
+				},
+			},
+			want: orb.Polygon{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 0}},
+				{{1, 1}, {2, 1}, {2, 2}, {1, 1}},
+			},
+		},
+		{
+			name:       "invalid position in Polygon",
+			columnType: internal.CHTypePolygon,
+			fieldType:  internal.KafkaTypeArray,
+			input:      []any{[]any{[]any{0.0, "north"}}},
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
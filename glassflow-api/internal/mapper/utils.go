@@ -1,11 +1,19 @@
 package mapper
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
+	"net/netip"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/paulmach/orb"
+	"github.com/shopspring/decimal"
 )
 
 func ParseString(data any) (zero string, _ error) {
@@ -572,3 +580,241 @@ func ParseDateTimeFromFloat64(data any) (zero time.Time, _ error) {
 	sec, dec := math.Modf(timestamp)
 	return time.Unix(int64(sec), int64(dec*1e9)), nil
 }
+
+// ParseDecimal parses a decimal from a JSON string or number, rounds it to
+// scale digits and checks it fits a Decimal(precision, scale) column.
+// Strings keep full precision; floats are subject to float64 rounding.
+func ParseDecimal(data any, precision, scale int) (zero decimal.Decimal, _ error) {
+	var d decimal.Decimal
+	switch value := data.(type) {
+	case string:
+		parsed, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil {
+			return zero, fmt.Errorf("failed to parse decimal: %w", err)
+		}
+		d = parsed
+	case int64:
+		d = decimal.NewFromInt(value)
+	case uint64:
+		d = decimal.NewFromBigInt(new(big.Int).SetUint64(value), 0)
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return zero, fmt.Errorf("failed to parse decimal: %v", value)
+		}
+		d = decimal.NewFromFloat(value)
+	default:
+		return zero, fmt.Errorf("failed to parse decimal: %v, type is: %v", data, reflect.TypeOf(data))
+	}
+
+	d = d.Round(int32(scale))
+	integerDigits := len(d.Truncate(0).Abs().String())
+	if !d.Truncate(0).IsZero() && integerDigits > precision-scale {
+		return zero, fmt.Errorf("decimal out of range for Decimal(%d, %d): %s", precision, scale, d.String())
+	}
+
+	return d, nil
+}
+
+// Date and Date32 column ranges, values outside them would wrap in ClickHouse.
+var (
+	dateMin   = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	dateMax   = time.Date(2149, 6, 6, 0, 0, 0, 0, time.UTC)
+	date32Min = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	date32Max = time.Date(2299, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// ParseDate truncates t to its UTC calendar day and checks it fits a Date,
+// or a Date32 column when extended is set.
+func ParseDate(t time.Time, extended bool) (zero time.Time, _ error) {
+	minDate, maxDate, typeName := dateMin, dateMax, "Date"
+	if extended {
+		minDate, maxDate, typeName = date32Min, date32Max, "Date32"
+	}
+
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(minDate) || day.After(maxDate) {
+		return zero, fmt.Errorf("date out of range for %s: %s", typeName, day.Format(time.DateOnly))
+	}
+
+	return day, nil
+}
+
+func ParseIPv4(data any) (zero netip.Addr, _ error) {
+	str, err := ParseString(data)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse IPv4: %w", err)
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(str))
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse IPv4: %w", err)
+	}
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return zero, fmt.Errorf("failed to parse IPv4: %s is not an IPv4 address", str)
+	}
+
+	return addr, nil
+}
+
+// ParseIPv6 accepts IPv6 and IPv4 addresses, IPv4 is stored IPv4-mapped.
+func ParseIPv6(data any) (zero netip.Addr, _ error) {
+	str, err := ParseString(data)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse IPv6: %w", err)
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(str))
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse IPv6: %w", err)
+	}
+	if addr.Is4() {
+		addr = netip.AddrFrom16(addr.As16())
+	}
+
+	return addr.WithZone(""), nil
+}
+
+// uuidBinarySize is the size of a UUID stored as FixedString(16).
+const uuidBinarySize = 16
+
+// ParseUUIDBinary converts a textual UUID to its 16 raw bytes for
+// FixedString(16) columns. Values that already fit the column are kept as is.
+func ParseUUIDBinary(data any) (zero any, _ error) {
+	str, err := ParseString(data)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse UUID: %w", err)
+	}
+	if len(str) <= uuidBinarySize {
+		return str, nil
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(str))
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse UUID: %q is longer than %d bytes and is not a UUID: %w", str, uuidBinarySize, err)
+	}
+
+	return id[:], nil
+}
+
+// ParsePoint accepts a [lon, lat] array or a GeoJSON Point object, either
+// decoded or as JSON text.
+func ParsePoint(data any) (zero orb.Point, _ error) {
+	coordinates, err := geoCoordinates(data, "Point")
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse Point: %w", err)
+	}
+
+	point, err := parsePosition(coordinates)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse Point: %w", err)
+	}
+
+	return point, nil
+}
+
+// ParseRing accepts an array of [lon, lat] positions or a GeoJSON LineString
+// object, either decoded or as JSON text.
+func ParseRing(data any) (zero orb.Ring, _ error) {
+	coordinates, err := geoCoordinates(data, "LineString")
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse Ring: %w", err)
+	}
+
+	ring, err := parseRing(coordinates)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse Ring: %w", err)
+	}
+
+	return ring, nil
+}
+
+// ParsePolygon accepts an array of rings, the first one being the outer
+// boundary, or a GeoJSON Polygon object, either decoded or as JSON text.
+func ParsePolygon(data any) (zero orb.Polygon, _ error) {
+	coordinates, err := geoCoordinates(data, "Polygon")
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse Polygon: %w", err)
+	}
+
+	values, ok := coordinates.([]any)
+	if !ok {
+		return zero, fmt.Errorf("failed to parse Polygon: expected array of rings, got %T", coordinates)
+	}
+
+	polygon := make(orb.Polygon, 0, len(values))
+	for i, value := range values {
+		ring, err := parseRing(value)
+		if err != nil {
+			return zero, fmt.Errorf("failed to parse Polygon ring %d: %w", i, err)
+		}
+		polygon = append(polygon, ring)
+	}
+
+	return polygon, nil
+}
+
+// geoCoordinates returns the coordinates of a GeoJSON object of the given
+// type, or the data itself when it is already a coordinates array.
+func geoCoordinates(data any, geoJSONType string) (any, error) {
+	if str, ok := data.(string); ok {
+		var decoded any
+		if err := json.Unmarshal([]byte(str), &decoded); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		data = decoded
+	}
+
+	obj, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+
+	if typ, found := obj["type"]; found && typ != geoJSONType {
+		return nil, fmt.Errorf("expected GeoJSON type %s, got %v", geoJSONType, typ)
+	}
+	coordinates, found := obj["coordinates"]
+	if !found {
+		return nil, fmt.Errorf("GeoJSON object has no coordinates")
+	}
+
+	return coordinates, nil
+}
+
+func parseRing(data any) (zero orb.Ring, _ error) {
+	values, ok := data.([]any)
+	if !ok {
+		return zero, fmt.Errorf("expected array of positions, got %T", data)
+	}
+
+	ring := make(orb.Ring, 0, len(values))
+	for i, value := range values {
+		point, err := parsePosition(value)
+		if err != nil {
+			return zero, fmt.Errorf("position %d: %w", i, err)
+		}
+		ring = append(ring, point)
+	}
+
+	return ring, nil
+}
+
+// parsePosition parses a [lon, lat] position, GeoJSON altitudes are dropped.
+func parsePosition(data any) (zero orb.Point, _ error) {
+	values, ok := data.([]any)
+	if !ok || len(values) < 2 {
+		return zero, fmt.Errorf("expected [lon, lat] position, got %v", data)
+	}
+
+	lon, err := ParseFloat64(values[0])
+	if err != nil {
+		return zero, fmt.Errorf("invalid longitude: %w", err)
+	}
+	lat, err := ParseFloat64(values[1])
+	if err != nil {
+		return zero, fmt.Errorf("invalid latitude: %w", err)
+	}
+
+	return orb.Point{lon, lat}, nil
+}
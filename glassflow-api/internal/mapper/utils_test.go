@@ -796,3 +796,114 @@ func TestParseInt32(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       any
+		precision   int
+		scale       int
+		expected    string
+		expectError bool
+	}{
+		{
+			name:      "String",
+			input:     "1234.5678",
+			precision: 10,
+			scale:     4,
+			expected:  "1234.5678",
+		},
+		{
+			name:      "String rounded to scale",
+			input:     "12.345",
+			precision: 10,
+			scale:     2,
+			expected:  "12.35",
+		},
+		{
+			name:      "Negative string",
+			input:     "-0.5",
+			precision: 5,
+			scale:     2,
+			expected:  "-0.5",
+		},
+		{
+			name:      "String beyond float64 precision",
+			input:     "123456789012345678.123456789",
+			precision: 38,
+			scale:     9,
+			expected:  "123456789012345678.123456789",
+		},
+		{
+			name:      "Int64",
+			input:     int64(-42),
+			precision: 9,
+			scale:     0,
+			expected:  "-42",
+		},
+		{
+			name:      "Uint64",
+			input:     uint64(math.MaxUint64),
+			precision: 38,
+			scale:     0,
+			expected:  "18446744073709551615",
+		},
+		{
+			name:      "Float64",
+			input:     float64(3.25),
+			precision: 9,
+			scale:     2,
+			expected:  "3.25",
+		},
+		{
+			name:        "Too many integer digits",
+			input:       "1000",
+			precision:   5,
+			scale:       2,
+			expectError: true,
+		},
+		{
+			name:        "Rounding overflows precision",
+			input:       "999.999",
+			precision:   5,
+			scale:       2,
+			expectError: true,
+		},
+		{
+			name:        "Invalid string",
+			input:       "12,5",
+			precision:   10,
+			scale:       2,
+			expectError: true,
+		},
+		{
+			name:        "NaN",
+			input:       math.NaN(),
+			precision:   10,
+			scale:       2,
+			expectError: true,
+		},
+		{
+			name:        "Bool value",
+			input:       true,
+			precision:   10,
+			scale:       2,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDecimal(tt.input, tt.precision, tt.scale)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got %s", result)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !tt.expectError && result.String() != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
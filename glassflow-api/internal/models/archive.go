@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ArchivedPipeline is a pipeline archived after being stopped for long. Its
+// definition is kept in the archive to restore it, its resources are
+// deleted.
+type ArchivedPipeline struct {
+	PipelineID string           `json:"pipeline_id"`
+	Name       string           `json:"name"`
+	Metadata   PipelineMetadata `json:"metadata"`
+	StoppedAt  time.Time        `json:"stopped_at"`
+	ArchivedAt time.Time        `json:"archived_at"`
+}
@@ -30,3 +30,33 @@ func (m ComponentSignal) ToJSON() ([]byte, error) {
 func GetComponentSignalsSubject() string {
 	return fmt.Sprintf("%s.%s", ComponentSignalsStream, ComponentSignalsSubject)
 }
+
+// PipelineConfigSchemaVersion is the version of the pipeline config the
+// components read. Bump it with every config field that an older component
+// would ignore, so that the API refuses to run it with the new field.
+const PipelineConfigSchemaVersion = 1
+
+// ComponentHandshakeSubject is the NATS request subject on which components
+// report their build at startup.
+const ComponentHandshakeSubject = "component-control.handshake"
+
+// ComponentHello is sent by a component at startup. SupportedSchemaVersion
+// is the config schema version of its build, ConfigSchemaVersion the one of
+// the pipeline config it was given and ConfigHash the hash of that config.
+// Instance tells the replicas of a component apart.
+type ComponentHello struct {
+	PipelineID             string `json:"pipeline_id"`
+	Component              string `json:"component"`
+	Instance               string `json:"instance,omitempty"`
+	Version                string `json:"version"`
+	Commit                 string `json:"commit"`
+	SupportedSchemaVersion int    `json:"supported_schema_version"`
+	ConfigSchemaVersion    int    `json:"config_schema_version"`
+	ConfigHash             string `json:"config_hash,omitempty"`
+}
+
+// ComponentHelloReply is the answer of the API to a ComponentHello.
+type ComponentHelloReply struct {
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"`
+}
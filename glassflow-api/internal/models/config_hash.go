@@ -0,0 +1,83 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// configHashLength keeps the hash short enough for a Kubernetes label value.
+const configHashLength = 32
+
+// ComputeConfigHash returns the canonical hash of the config a pipeline's
+// components run with. The name, metadata, status, timestamps and resources
+// do not change what the components do and are left out, so are the hash
+// itself and the schema version stamped on the component configs. Struct fields
+// and map keys marshal in a fixed order, which makes the hash deterministic.
+func ComputeConfigHash(cfg PipelineConfig) (string, error) {
+	cfg.Name = ""
+	cfg.Metadata = PipelineMetadata{}
+	cfg.Status = PipelineHealth{}
+	cfg.CreatedAt = time.Time{}
+	cfg.PipelineResources = PipelineResources{}
+	cfg.ConfigHash = ""
+	cfg.ConfigSchemaVersion = 0
+	cfg.PausedComponents = nil
+	// The cluster id is recorded by the API, not configured
+	cfg.Ingestor.KafkaConnectionParams.ClusterID = ""
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("marshal pipeline config: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:configHashLength], nil
+}
+
+// ComponentConfigReport is the config hash a component instance reported at
+// its last start.
+type ComponentConfigReport struct {
+	PipelineID string    `json:"pipeline_id"`
+	Component  string    `json:"component"`
+	Instance   string    `json:"instance"`
+	Version    string    `json:"version"`
+	ConfigHash string    `json:"config_hash"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// ComponentConfigDrift is a component instance of a drift check, stale when
+// it runs with another config than the stored one.
+type ComponentConfigDrift struct {
+	ComponentConfigReport
+	Stale bool `json:"stale"`
+}
+
+// ConfigDrift compares the stored config hash of a pipeline with the hashes
+// its components run with, as after an edit that failed on some of them.
+type ConfigDrift struct {
+	PipelineID string                 `json:"pipeline_id"`
+	ConfigHash string                 `json:"config_hash"`
+	Drifted    bool                   `json:"drifted"`
+	Components []ComponentConfigDrift `json:"components"`
+}
+
+// NewConfigDrift flags the reports whose hash differs from the stored one.
+func NewConfigDrift(pipelineID, configHash string, reports []ComponentConfigReport) ConfigDrift {
+	drift := ConfigDrift{
+		PipelineID: pipelineID,
+		ConfigHash: configHash,
+		Components: make([]ComponentConfigDrift, 0, len(reports)),
+	}
+	for _, report := range reports {
+		stale := report.ConfigHash != configHash
+		drift.Drifted = drift.Drifted || stale
+		drift.Components = append(drift.Components, ComponentConfigDrift{
+			ComponentConfigReport: report,
+			Stale:                 stale,
+		})
+	}
+	return drift
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeConfigHash(t *testing.T) {
+	cfg := PipelineConfig{
+		ID:   "orders",
+		Name: "Orders",
+		Sink: SinkComponentConfig{Batch: BatchConfig{MaxBatchSize: 1000}},
+	}
+
+	hash, err := ComputeConfigHash(cfg)
+	require.NoError(t, err)
+	assert.Len(t, hash, configHashLength)
+
+	renamed := cfg
+	renamed.Name = "Orders v2"
+	renamed.CreatedAt = time.Now()
+	renamed.ConfigHash = hash
+	renamedHash, err := ComputeConfigHash(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, hash, renamedHash, "the name, timestamps and hash do not change the config")
+
+	edited := cfg
+	edited.Sink.Batch.MaxBatchSize = 2000
+	editedHash, err := ComputeConfigHash(edited)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, editedHash)
+}
+
+func TestNewConfigDrift(t *testing.T) {
+	drift := NewConfigDrift("orders", "new", []ComponentConfigReport{
+		{Component: "sink", Instance: "0", ConfigHash: "new"},
+		{Component: "sink", Instance: "1", ConfigHash: "old"},
+	})
+	assert.True(t, drift.Drifted)
+	require.Len(t, drift.Components, 2)
+	assert.False(t, drift.Components[0].Stale)
+	assert.True(t, drift.Components[1].Stale)
+
+	assert.False(t, NewConfigDrift("orders", "new", nil).Drifted)
+}
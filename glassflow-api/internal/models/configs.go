@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"regexp"
 	"slices"
 	"strconv"
@@ -26,6 +27,61 @@ type PipelineHealth struct {
 	OverallStatus PipelineStatus `json:"overall_status"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
+	// ConsumerLag is queried from the Kafka brokers for running pipelines
+	ConsumerLag []KafkaPartitionLag `json:"consumer_lag,omitempty"`
+	// InFlight is read from the pipeline's NATS consumers
+	InFlight []ComponentInFlight `json:"in_flight,omitempty"`
+	// Drained is set with InFlight, true once no component holds messages
+	Drained *bool `json:"drained,omitempty"`
+	// SLA is evaluated for running pipelines with SLA objectives
+	SLA *SLAStatus `json:"sla,omitempty"`
+	// Storage is evaluated for running pipelines, Degraded is set while a
+	// stream of the pipeline is close to its storage limits
+	Storage  *StorageStatus `json:"storage,omitempty"`
+	Degraded bool           `json:"degraded,omitempty"`
+	// DiscardedMessages counts the messages the streams of the pipeline
+	// dropped at their limits before they were processed, per consumer in
+	// Discards. The messages are lost.
+	DiscardedMessages uint64           `json:"discarded_messages,omitempty"`
+	Discards          []StreamDiscards `json:"discards,omitempty"`
+	// PausedComponents are the components paused on their own
+	PausedComponents []string `json:"paused_components,omitempty"`
+	// Schedule is set for pipelines started and stopped on a schedule
+	Schedule *ScheduleStatus `json:"schedule,omitempty"`
+}
+
+// KafkaPartitionLag is the number of records of a source topic partition
+// that the pipeline's consumer group has not committed yet.
+type KafkaPartitionLag struct {
+	ConsumerGroup string `json:"consumer_group"`
+	Topic         string `json:"topic"`
+	Partition     int32  `json:"partition"`
+	// CommittedOffset is -1 until the group commits on the partition
+	CommittedOffset int64 `json:"committed_offset"`
+	EndOffset       int64 `json:"end_offset"`
+	Lag             int64 `json:"lag"`
+}
+
+// ComponentInFlight is the number of messages a component's NATS consumer
+// has not processed yet. Pending messages are not delivered yet, unacked
+// messages are delivered but not acknowledged.
+type ComponentInFlight struct {
+	Component string `json:"component"`
+	Stream    string `json:"stream"`
+	Consumer  string `json:"consumer"`
+	Pending   uint64 `json:"pending"`
+	Unacked   int    `json:"unacked"`
+}
+
+// InFlightDrained reports whether no component holds pending or unacked
+// messages, i.e. the pipeline is quiescent.
+func InFlightDrained(inFlight []ComponentInFlight) bool {
+	for _, c := range inFlight {
+		if c.Pending > 0 || c.Unacked > 0 {
+			return false
+		}
+	}
+	return true
 }
 
 type StreamDataField struct {
@@ -81,6 +137,10 @@ type KafkaConnectionParamsConfig struct {
 	KerberosRealm       string `json:"kerberos_realm,omitempty"`
 	KerberosKeytab      string `json:"kerberos_keytab,omitempty"`
 	KerberosConfig      string `json:"kerberos_config,omitempty"`
+
+	// ClusterID is the id of the cluster the brokers pointed at when the
+	// pipeline was created, the ingestor refuses to consume another cluster
+	ClusterID string `json:"cluster_id,omitempty"`
 }
 
 type ConsumerGroupOffset string
@@ -95,10 +155,75 @@ type DeduplicationConfig struct {
 	Type    string `json:"id_field_type,omitempty"`
 
 	Window JSONDuration `json:"time_window,omitempty"`
+
+	// Mode either drops the later events of a key or keeps the last one,
+	// VersionField receives the version of the events kept last
+	Mode         string `json:"mode,omitempty"`
+	VersionField string `json:"version_field,omitempty"`
+
+	// Domain shares the dedup window with the pipelines of the same domain,
+	// an event one of them ingested is dropped by the others
+	Domain string `json:"domain,omitempty"`
+}
+
+var dedupDomainPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// KeepsLast reports whether the deduplicator emits every event with a
+// version instead of dropping the duplicates.
+func (c DeduplicationConfig) KeepsLast() bool {
+	return c.Enabled && c.Mode == internal.DedupModeKeepLast
+}
+
+// normalize validates an enabled config and fills in the defaults.
+func (c DeduplicationConfig) normalize() (DeduplicationConfig, error) {
+	if !c.Enabled {
+		return c, nil
+	}
+
+	switch strings.ToLower(c.Mode) {
+	case "":
+		c.Mode = internal.DedupModeKeepFirst
+	case internal.DedupModeKeepFirst, internal.DedupModeKeepLast:
+		c.Mode = strings.ToLower(c.Mode)
+	default:
+		return c, PipelineConfigError{Msg: "invalid deduplication mode; allowed values: `keep_first` or `keep_last`"}
+	}
+
+	c.VersionField = strings.TrimSpace(c.VersionField)
+	if c.Mode == internal.DedupModeKeepLast && c.VersionField == "" {
+		c.VersionField = internal.DefaultDedupVersionField
+	}
+
+	c.Domain = strings.TrimSpace(c.Domain)
+	if c.Domain != "" {
+		if !dedupDomainPattern.MatchString(c.Domain) {
+			return c, PipelineConfigError{Msg: "invalid deduplication domain; it must be at most 63 letters, digits, `_` or `-`"}
+		}
+		if c.Mode == internal.DedupModeKeepLast {
+			return c, PipelineConfigError{Msg: "deduplication domain is not supported with mode `keep_last`"}
+		}
+		if c.Window.Duration() <= 0 {
+			return c, PipelineConfigError{Msg: "deduplication domain requires a time window"}
+		}
+	}
+
+	return c, nil
+}
+
+// DedupDomains returns the dedup domains of the pipeline's topics with their
+// time windows.
+func (pc PipelineConfig) DedupDomains() map[string]time.Duration {
+	domains := make(map[string]time.Duration)
+	for _, topic := range pc.Ingestor.KafkaTopics {
+		if topic.Deduplication.Enabled && topic.Deduplication.Domain != "" {
+			domains[topic.Deduplication.Domain] = topic.Deduplication.Window.Duration()
+		}
+	}
+	return domains
 }
 
 // SourceType represents the type of a pipeline source.
-// Valid values are: "kafka", "otlp.logs", "otlp.traces", "otlp.metrics".
+// Valid values are: "kafka", "pulsar", "mysql", "http", "otlp.logs", "otlp.traces", "otlp.metrics".
 type SourceType string
 
 func (s SourceType) String() string {
@@ -108,6 +233,9 @@ func (s SourceType) String() string {
 func (s SourceType) Valid() bool {
 	switch s {
 	case internal.KafkaIngestorType,
+		internal.PulsarIngestorType,
+		internal.MySQLIngestorType,
+		internal.HTTPSourceType,
 		internal.OTLPLogsSourceType,
 		internal.OTLPTracesSourceType,
 		internal.OTLPMetricsSourceType:
@@ -122,6 +250,33 @@ func (s SourceType) IsKafka() bool {
 	return s == internal.KafkaIngestorType
 }
 
+// IsPulsar reports whether this source type is Pulsar.
+func (s SourceType) IsPulsar() bool {
+	return s == internal.PulsarIngestorType
+}
+
+// IsMySQL reports whether this source type is a MySQL binlog source.
+func (s SourceType) IsMySQL() bool {
+	return s == internal.MySQLIngestorType
+}
+
+// UsesIngestor reports whether this source type is consumed by an ingestor
+// component, i.e. it is read from a Kafka or Pulsar topic or a MySQL binlog.
+func (s SourceType) UsesIngestor() bool {
+	return s.IsKafka() || s.IsPulsar() || s.IsMySQL()
+}
+
+// IsHTTP reports whether this source type is an HTTP push source.
+func (s SourceType) IsHTTP() bool {
+	return s == internal.HTTPSourceType
+}
+
+// UsesReceiver reports whether events of this source type are pushed to a
+// receiver instead of being consumed by an ingestor, i.e. OTLP and HTTP.
+func (s SourceType) UsesReceiver() bool {
+	return s.IsOTLP() || s.IsHTTP()
+}
+
 // IsOTLP reports whether this source type is any OTLP variant.
 func (s SourceType) IsOTLP() bool {
 	switch s {
@@ -140,16 +295,144 @@ type KafkaTopicsConfig struct {
 	ConsumerGroupInitialOffset string               `json:"consumer_group_initial_offset" default:"earliest"`
 	ConsumerGroupName          string               `json:"consumer_group_name"`
 	Replicas                   int                  `json:"replicas" default:"1"`
+	PartitionAssignment        string               `json:"partition_assignment,omitempty"`
+	RebalanceStrategy          string               `json:"rebalance_strategy,omitempty"`
+	StaticMembership           bool                 `json:"static_membership,omitempty"`
+	SubscriptionType           string               `json:"subscription_type,omitempty"`
+	DecodeErrorPolicy          string               `json:"decode_error_policy,omitempty"`
 	SchemaRegistryConfig       SchemaRegistryConfig `json:"schema_registry_config,omitempty"`
 
-	Deduplication DeduplicationConfig `json:"deduplication,omitempty"`
+	Deduplication  DeduplicationConfig  `json:"deduplication,omitempty"`
+	PublishDedup   PublishDedupConfig   `json:"publish_dedup,omitzero"`
+	DebeziumUnwrap DebeziumUnwrapConfig `json:"debezium_unwrap,omitzero"`
+	FlowControl    FlowControlConfig    `json:"flow_control,omitzero"`
+}
+
+// StreamDuplicateWindow is the duplicate window of the topic's NATS stream
+// keeping messages for maxAge, zero when they do not expire. The publish
+// dedup window takes precedence over the dedup transform window, which is
+// capped to the maxAge, that JetStream requires, and to
+// MaxStreamDuplicateWindow, as the deduplicator catches the later
+// duplicates. A dedup keeping the last event needs every event of a key and
+// sets no window.
+func (t KafkaTopicsConfig) StreamDuplicateWindow(maxAge time.Duration) time.Duration {
+	if t.PublishDedup.DuplicateWindow.Duration() > 0 {
+		return t.PublishDedup.DuplicateWindow.Duration()
+	}
+	if t.Deduplication.KeepsLast() {
+		return 0
+	}
+	window := min(t.Deduplication.Window.Duration(), internal.MaxStreamDuplicateWindow)
+	if maxAge > 0 {
+		window = min(window, maxAge)
+	}
+	return window
+}
+
+// PublishDedupConfig controls the JetStream duplicate detection applied when
+// the ingestor publishes a topic's records to NATS.
+type PublishDedupConfig struct {
+	// DuplicateWindow is how long JetStream remembers published Msg-Ids,
+	// longer windows catch later duplicates at the cost of stream storage
+	DuplicateWindow JSONDuration `json:"duplicate_window,omitzero"`
+	// MsgIDStrategy derives the Msg-Id of records without a dedup key, either
+	// from their origin offset or from a hash of their content
+	MsgIDStrategy string `json:"msg_id_strategy,omitempty"`
+}
+
+// normalize validates the config and fills in the default strategy.
+func (c PublishDedupConfig) normalize() (PublishDedupConfig, error) {
+	if c.DuplicateWindow.Duration() < 0 {
+		return c, PipelineConfigError{Msg: "publish_dedup duplicate_window cannot be negative"}
+	}
+
+	switch strings.ToLower(c.MsgIDStrategy) {
+	case "":
+		c.MsgIDStrategy = internal.MsgIDStrategyOffset
+	case internal.MsgIDStrategyOffset, internal.MsgIDStrategyContentHash:
+		c.MsgIDStrategy = strings.ToLower(c.MsgIDStrategy)
+	default:
+		return c, PipelineConfigError{Msg: "invalid publish_dedup msg_id_strategy; allowed values: `offset` or `content_hash`"}
+	}
+
+	return c, nil
+}
+
+// FlowControlConfig pauses the polling of a topic while the NATS streams the
+// ingestor publishes into, or the consumers reading them, are over the
+// thresholds. Zero thresholds are not checked.
+type FlowControlConfig struct {
+	// MaxStreamBytes bounds the bytes stored in each output stream
+	MaxStreamBytes int64 `json:"max_stream_bytes,omitempty"`
+	// MaxConsumerPending bounds the messages each consumer of the output
+	// streams has not acknowledged yet, that is the lag of the next component
+	MaxConsumerPending int64 `json:"max_consumer_pending,omitempty"`
+}
+
+// Enabled reports whether a threshold is set.
+func (c FlowControlConfig) Enabled() bool {
+	return c.MaxStreamBytes > 0 || c.MaxConsumerPending > 0
+}
+
+func (c FlowControlConfig) validate() error {
+	if c.MaxStreamBytes < 0 {
+		return PipelineConfigError{Msg: "flow_control max_stream_bytes cannot be negative"}
+	}
+	if c.MaxConsumerPending < 0 {
+		return PipelineConfigError{Msg: "flow_control max_consumer_pending cannot be negative"}
+	}
+	return nil
+}
+
+// DebeziumUnwrapConfig extracts the row image of Debezium change events
+// before the ingestor validates them against the source schema.
+type DebeziumUnwrapConfig struct {
+	Enabled bool `json:"enabled"`
+	// OpField and TimestampField name the fields that receive the change
+	// operation and the event time of the envelope
+	OpField        string `json:"op_field,omitempty"`
+	TimestampField string `json:"ts_field,omitempty"`
+	// DeleteHandling either rewrites deletes to their before image or drops them
+	DeleteHandling string `json:"delete_handling,omitempty"`
+}
+
+// normalize validates an enabled config and fills in the defaults.
+func (c DebeziumUnwrapConfig) normalize() (DebeziumUnwrapConfig, error) {
+	if !c.Enabled {
+		return DebeziumUnwrapConfig{}, nil
+	}
+
+	c.OpField = strings.TrimSpace(c.OpField)
+	if c.OpField == "" {
+		c.OpField = internal.DefaultDebeziumOpField
+	}
+	c.TimestampField = strings.TrimSpace(c.TimestampField)
+	if c.TimestampField == "" {
+		c.TimestampField = internal.DefaultDebeziumTimestampField
+	}
+	if c.OpField == c.TimestampField {
+		return c, PipelineConfigError{Msg: "debezium_unwrap op_field and ts_field must differ"}
+	}
+
+	switch strings.ToLower(c.DeleteHandling) {
+	case "":
+		c.DeleteHandling = internal.DebeziumDeleteHandlingRewrite
+	case internal.DebeziumDeleteHandlingRewrite, internal.DebeziumDeleteHandlingDrop:
+		c.DeleteHandling = strings.ToLower(c.DeleteHandling)
+	default:
+		return c, PipelineConfigError{Msg: "invalid debezium_unwrap delete_handling; allowed values: `rewrite` or `drop`"}
+	}
+
+	return c, nil
 }
 
 type IngestorComponentConfig struct {
-	Type                  string                      `json:"type"`
-	Provider              string                      `json:"provider"`
-	KafkaConnectionParams KafkaConnectionParamsConfig `json:"kafka_connection_params"`
-	KafkaTopics           []KafkaTopicsConfig         `json:"kafka_topics"`
+	Type                   string                       `json:"type"`
+	Provider               string                       `json:"provider"`
+	KafkaConnectionParams  KafkaConnectionParamsConfig  `json:"kafka_connection_params"`
+	PulsarConnectionParams PulsarConnectionParamsConfig `json:"pulsar_connection_params,omitzero"`
+	MySQLConnectionParams  MySQLConnectionParamsConfig  `json:"mysql_connection_params,omitzero"`
+	KafkaTopics            []KafkaTopicsConfig          `json:"kafka_topics"`
 }
 
 func NewIngestorComponentConfig(provider string, conn KafkaConnectionParamsConfig, topics []KafkaTopicsConfig) (zero IngestorComponentConfig, _ error) {
@@ -178,15 +461,22 @@ func NewIngestorComponentConfig(provider string, conn KafkaConnectionParamsConfi
 			len(strings.TrimSpace(conn.KerberosConfig)) == 0 {
 			return zero, PipelineConfigError{Msg: "Kerberos configuration fields cannot be empty"}
 		}
+	case internal.MechanismAWSIAM:
+		// The credentials are the role of the ingestor pod, MSK only
+		// accepts IAM over TLS
+		if conn.SASLProtocol != internal.SASLProtocolSASLSSL {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("%s requires the %s protocol", internal.MechanismAWSIAM, internal.SASLProtocolSASLSSL)}
+		}
 	case internal.MechanismNoAuth:
 	default:
-		return zero, PipelineConfigError{Msg: fmt.Sprintf("Unsupported SASL mechanism: %s; allowed: SCRAM-SHA-256, SCRAM-SHA-512, PLAIN, GSSAPI, NO_AUTH", conn.SASLMechanism)}
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("Unsupported SASL mechanism: %s; allowed: SCRAM-SHA-256, SCRAM-SHA-512, PLAIN, GSSAPI, AWS_MSK_IAM, NO_AUTH", conn.SASLMechanism)}
 	}
 
 	switch conn.SASLProtocol {
 	case internal.SASLProtocolPlaintext, internal.SASLProtocolSASLPlaintext:
 	case internal.SASLProtocolSASLSSL, internal.SASLProtocolSSL:
-		if !conn.SkipTLSVerification {
+		// MSK brokers present certificates of the public Amazon CAs
+		if !conn.SkipTLSVerification && conn.SASLMechanism != internal.MechanismAWSIAM {
 			if len(strings.TrimSpace(conn.TLSCert)) == 0 && len(strings.TrimSpace(conn.TLSKey)) == 0 && len(strings.TrimSpace(conn.TLSRoot)) == 0 {
 				return zero, PipelineConfigError{Msg: "TLS certificate cannot be empty when SASL TLS is enabled"}
 			}
@@ -212,6 +502,56 @@ func NewIngestorComponentConfig(provider string, conn KafkaConnectionParamsConfi
 		if kt.Replicas <= 0 {
 			topics[i].Replicas = 1 // Default to 1 replica
 		}
+
+		switch strings.ToLower(kt.PartitionAssignment) {
+		case "":
+			topics[i].PartitionAssignment = internal.PartitionAssignmentGroup
+		case internal.PartitionAssignmentGroup, internal.PartitionAssignmentStatic:
+			topics[i].PartitionAssignment = strings.ToLower(kt.PartitionAssignment)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid partition_assignment; allowed values: `group` or `static`"}
+		}
+
+		switch strings.ToLower(kt.RebalanceStrategy) {
+		case "":
+			topics[i].RebalanceStrategy = internal.RebalanceStrategyCooperativeSticky
+		case internal.RebalanceStrategyCooperativeSticky, internal.RebalanceStrategyEager:
+			topics[i].RebalanceStrategy = strings.ToLower(kt.RebalanceStrategy)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid rebalance_strategy; allowed values: `cooperative-sticky` or `eager`"}
+		}
+
+		switch strings.ToLower(kt.DecodeErrorPolicy) {
+		case "":
+			topics[i].DecodeErrorPolicy = internal.DecodeErrorPolicyDLQ
+		case internal.DecodeErrorPolicyDLQ, internal.DecodeErrorPolicyFail:
+			topics[i].DecodeErrorPolicy = strings.ToLower(kt.DecodeErrorPolicy)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid decode_error_policy; allowed values: `dlq` or `fail`"}
+		}
+
+		publishDedup, err := kt.PublishDedup.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].PublishDedup = publishDedup
+
+		err = kt.FlowControl.validate()
+		if err != nil {
+			return zero, err
+		}
+
+		debeziumUnwrap, err := kt.DebeziumUnwrap.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].DebeziumUnwrap = debeziumUnwrap
+
+		deduplication, err := kt.Deduplication.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].Deduplication = deduplication
 	}
 
 	return IngestorComponentConfig{
@@ -242,6 +582,9 @@ type JoinSourceConfig struct {
 	JoinKey     string       `json:"join_key"`
 	Window      JSONDuration `json:"time_window"`
 	Orientation string       `json:"orientation"`
+	// TimeField is the event time of the source in an ordered join, the
+	// time the event was stored in NATS is used when it is empty.
+	TimeField string `json:"time_field,omitempty"`
 }
 
 type JoinComponentConfig struct {
@@ -253,6 +596,230 @@ type JoinComponentConfig struct {
 
 	LeftBufferTTL  JSONDuration `json:"left_buffer_ttl"`
 	RightBufferTTL JSONDuration `json:"right_buffer_ttl"`
+
+	RightCache JoinCacheConfig `json:"right_cache,omitzero"`
+
+	Tiering JoinTieringConfig `json:"tiering,omitzero"`
+
+	Unmatched JoinUnmatchedConfig `json:"unmatched,omitzero"`
+
+	Ordering JoinOrderingConfig `json:"ordering,omitzero"`
+
+	// RightMatch is latest when a left event joins only the last right event
+	// of its key, all when it joins every right event of the key in the window
+	RightMatch string `json:"right_match,omitempty"`
+
+	// JoinType is inner when events without a match are dropped. Left, right
+	// and full_outer joins emit the events of their outer sides that found
+	// no match with NULLs for the fields of the other side, once they are
+	// older than the window of their source and the grace period.
+	JoinType string       `json:"join_type,omitempty"`
+	Grace    JSONDuration `json:"grace,omitzero"`
+
+	// Chain joins more sources to the output of the two sources, one stage
+	// per source
+	Chain []JoinChainSourceConfig `json:"chain,omitempty"`
+}
+
+// OuterLeft reports whether unmatched left events are emitted.
+func (c JoinComponentConfig) OuterLeft() bool {
+	return c.JoinType == internal.JoinTypeLeft || c.JoinType == internal.JoinTypeFullOuter
+}
+
+// OuterRight reports whether unmatched right events are emitted.
+func (c JoinComponentConfig) OuterRight() bool {
+	return c.JoinType == internal.JoinTypeRight || c.JoinType == internal.JoinTypeFullOuter
+}
+
+// RightMatchAll reports whether left events join every right event of their
+// key in the window.
+func (c JoinComponentConfig) RightMatchAll() bool {
+	return c.RightMatch == internal.JoinRightMatchAll
+}
+
+// LeftWindow returns the join window of the left source.
+func (c JoinComponentConfig) LeftWindow() time.Duration {
+	for _, source := range c.Sources {
+		if source.Orientation == internal.JoinLeft {
+			return source.Window.Duration()
+		}
+	}
+	return 0
+}
+
+// RightWindow returns the join window of the right source.
+func (c JoinComponentConfig) RightWindow() time.Duration {
+	for _, source := range c.Sources {
+		if source.Orientation == internal.JoinRight {
+			return source.Window.Duration()
+		}
+	}
+	return 0
+}
+
+// JoinUnmatchedConfig sets what happens to left events that found no match
+// within the join window. They are dropped by default, the dlq policy
+// publishes them to the pipeline DLQ and the table policy inserts them into
+// a table of the sink database using a mapping of their own.
+type JoinUnmatchedConfig struct {
+	Policy  string    `json:"policy,omitempty"`
+	Table   string    `json:"table,omitempty"`
+	Mapping []Mapping `json:"mapping,omitempty"`
+}
+
+// Enabled reports whether unmatched events are routed rather than dropped.
+func (c JoinUnmatchedConfig) Enabled() bool {
+	return c.Policy == internal.JoinUnmatchedPolicyDLQ || c.Policy == internal.JoinUnmatchedPolicyTable
+}
+
+// normalize validates the policy and clears the settings it does not use.
+func (c JoinUnmatchedConfig) normalize() (JoinUnmatchedConfig, error) {
+	switch c.Policy {
+	case "", internal.JoinUnmatchedPolicyDrop:
+		return JoinUnmatchedConfig{}, nil
+	case internal.JoinUnmatchedPolicyDLQ:
+		return JoinUnmatchedConfig{Policy: c.Policy}, nil
+	case internal.JoinUnmatchedPolicyTable:
+	default:
+		return c, PipelineConfigError{Msg: fmt.Sprintf("unsupported join unmatched policy %q; must be one of %s", c.Policy,
+			strings.Join([]string{internal.JoinUnmatchedPolicyDrop, internal.JoinUnmatchedPolicyDLQ, internal.JoinUnmatchedPolicyTable}, ", "))}
+	}
+
+	if strings.TrimSpace(c.Table) == "" {
+		return c, PipelineConfigError{Msg: "join unmatched table cannot be empty with the table policy"}
+	}
+	if len(c.Mapping) == 0 {
+		return c, PipelineConfigError{Msg: "join unmatched mapping cannot be empty with the table policy"}
+	}
+
+	columns := make(map[string]struct{}, len(c.Mapping))
+	for _, m := range c.Mapping {
+		if m.SourceField == "" || m.DestinationField == "" || m.DestinationType == "" {
+			return c, PipelineConfigError{Msg: "join unmatched mapping entries need a source field, a column name and a column type"}
+		}
+		if _, ok := columns[m.DestinationField]; ok {
+			return c, PipelineConfigError{Msg: fmt.Sprintf("join unmatched mapping has duplicate column %q", m.DestinationField)}
+		}
+		columns[m.DestinationField] = struct{}{}
+	}
+
+	return c, nil
+}
+
+// InsertQuery returns the INSERT statement of the unmatched table, with the
+// mapped columns sorted by name as the sink mapper orders its values.
+func (c JoinUnmatchedConfig) InsertQuery(database string) string {
+	columns := make([]string, 0, len(c.Mapping))
+	for _, m := range c.Mapping {
+		columns = append(columns, m.DestinationField)
+	}
+	slices.Sort(columns)
+
+	for i, column := range columns {
+		columns[i] = quoteCHIdentifier(column)
+	}
+
+	return fmt.Sprintf("INSERT INTO %s.%s (%s)",
+		quoteCHIdentifier(database),
+		quoteCHIdentifier(c.Table),
+		strings.Join(columns, ", "),
+	)
+}
+
+// JoinOrderingConfig makes the join process the events of both sources in
+// event-time order rather than arrival order. Events are held back for the
+// max skew to let earlier events arrive, so a replay after a failure joins
+// the events in the same order as the original run. Events arriving later
+// than the skew are processed on arrival.
+type JoinOrderingConfig struct {
+	Enabled bool         `json:"enabled"`
+	MaxSkew JSONDuration `json:"max_skew,omitzero"`
+}
+
+// normalize validates an enabled config and fills in the default skew.
+func (c JoinOrderingConfig) normalize() (JoinOrderingConfig, error) {
+	if !c.Enabled {
+		if c.MaxSkew.Duration() != 0 {
+			return c, PipelineConfigError{Msg: "join ordering max_skew requires ordering to be enabled"}
+		}
+		return JoinOrderingConfig{}, nil
+	}
+
+	if c.MaxSkew.Duration() < 0 {
+		return c, PipelineConfigError{Msg: "join ordering max_skew cannot be negative"}
+	}
+	if c.MaxSkew.Duration() == 0 {
+		c.MaxSkew = *NewJSONDuration(internal.DefaultJoinOrderingMaxSkew)
+	}
+	if c.MaxSkew.Duration() > internal.JoinOrderingMaxSkewLimit {
+		return c, PipelineConfigError{Msg: fmt.Sprintf("join ordering max_skew cannot exceed %s", internal.JoinOrderingMaxSkewLimit)}
+	}
+
+	return c, nil
+}
+
+// JoinCacheConfig keeps recently stored right records in memory, so left
+// events with hot keys are joined without a lookup in the right buffer.
+type JoinCacheConfig struct {
+	Enabled  bool  `json:"enabled"`
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// normalize validates an enabled config and fills in the default size.
+func (c JoinCacheConfig) normalize() (JoinCacheConfig, error) {
+	if !c.Enabled {
+		return JoinCacheConfig{}, nil
+	}
+
+	if c.MaxBytes < 0 {
+		return c, PipelineConfigError{Msg: "join right_cache max_bytes cannot be negative"}
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = internal.DefaultJoinCacheMaxBytes
+	}
+
+	return c, nil
+}
+
+// JoinTieringConfig stores the buffers of long join windows in tiers. The
+// events stored within the hot window stay in memory, up to HotMaxBytes per
+// buffer, and the events older than CompactAfter are compacted into one KV
+// entry per join key, so the buckets hold few entries and a lookup reads
+// one entry per key.
+type JoinTieringConfig struct {
+	Enabled      bool         `json:"enabled"`
+	HotWindow    JSONDuration `json:"hot_window,omitzero"`
+	HotMaxBytes  int64        `json:"hot_max_bytes,omitempty"`
+	CompactAfter JSONDuration `json:"compact_after,omitzero"`
+}
+
+// normalize validates an enabled config and fills in the defaults, events
+// are compacted once they left the hot window by default.
+func (c JoinTieringConfig) normalize() (JoinTieringConfig, error) {
+	if !c.Enabled {
+		return JoinTieringConfig{}, nil
+	}
+
+	if c.HotWindow.Duration() < 0 {
+		return c, PipelineConfigError{Msg: "join tiering hot_window cannot be negative"}
+	}
+	if c.HotWindow.Duration() == 0 {
+		c.HotWindow = *NewJSONDuration(internal.DefaultJoinHotWindow)
+	}
+	if c.HotMaxBytes < 0 {
+		return c, PipelineConfigError{Msg: "join tiering hot_max_bytes cannot be negative"}
+	}
+	if c.HotMaxBytes == 0 {
+		c.HotMaxBytes = internal.DefaultJoinHotMaxBytes
+	}
+	if c.CompactAfter.Duration() == 0 {
+		c.CompactAfter = c.HotWindow
+	}
+	if c.CompactAfter.Duration() < internal.MinJoinCompactAfter {
+		return c, PipelineConfigError{Msg: fmt.Sprintf("join tiering compact_after must be at least %s", internal.MinJoinCompactAfter)}
+	}
+
+	return c, nil
 }
 
 type JoinOrder string
@@ -272,7 +839,19 @@ func NewJoinOrder(s string) (zero JoinOrder, _ error) {
 	}
 }
 
-func NewJoinComponentConfig(kind, joinID string, sources []JoinSourceConfig, joinRules []JoinRule) (zero JoinComponentConfig, _ error) {
+func NewJoinComponentConfig(
+	kind, joinID string,
+	sources []JoinSourceConfig,
+	joinRules []JoinRule,
+	rightCache JoinCacheConfig,
+	unmatched JoinUnmatchedConfig,
+	ordering JoinOrderingConfig,
+	rightMatch string,
+	joinType string,
+	grace JSONDuration,
+	chain []JoinChainSourceConfig,
+	tiering JoinTieringConfig,
+) (zero JoinComponentConfig, _ error) {
 	if kind != strings.ToLower(strings.TrimSpace(internal.TemporalJoinType)) {
 		return zero, PipelineConfigError{Msg: "invalid join type; only temporal joins are supported"}
 	}
@@ -314,6 +893,91 @@ func NewJoinComponentConfig(kind, joinID string, sources []JoinSourceConfig, joi
 		}
 	}
 
+	rightCache, err := rightCache.normalize()
+	if err != nil {
+		return zero, err
+	}
+
+	// The hot tier caches the right events like the right cache
+	tiering, err = tiering.normalize()
+	if err != nil {
+		return zero, err
+	}
+	if tiering.Enabled && rightCache.Enabled {
+		return zero, PipelineConfigError{Msg: "join right_cache cannot be used with tiering, the hot tier keeps the recent right events in memory"}
+	}
+
+	unmatched, err = unmatched.normalize()
+	if err != nil {
+		return zero, err
+	}
+
+	ordering, err = ordering.normalize()
+	if err != nil {
+		return zero, err
+	}
+	for _, source := range sources {
+		if source.TimeField != "" && !ordering.Enabled {
+			return zero, PipelineConfigError{Msg: "join source time_field requires ordering to be enabled"}
+		}
+	}
+
+	switch joinType {
+	case "":
+		joinType = internal.JoinTypeInner
+	case internal.JoinTypeInner, internal.JoinTypeLeft, internal.JoinTypeRight, internal.JoinTypeFullOuter:
+	default:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("unsupported join_type %q; must be one of %s", joinType,
+			strings.Join([]string{internal.JoinTypeInner, internal.JoinTypeLeft, internal.JoinTypeRight, internal.JoinTypeFullOuter}, ", "))}
+	}
+	outerLeft := joinType == internal.JoinTypeLeft || joinType == internal.JoinTypeFullOuter
+	outerRight := joinType == internal.JoinTypeRight || joinType == internal.JoinTypeFullOuter
+
+	err = validateJoinChain(sources, chain, joinType, unmatched, ordering)
+	if err != nil {
+		return zero, err
+	}
+
+	if grace.Duration() < 0 {
+		return zero, PipelineConfigError{Msg: "join grace cannot be negative"}
+	}
+	if grace.Duration() != 0 && joinType == internal.JoinTypeInner {
+		return zero, PipelineConfigError{Msg: "join grace requires a left, right or full_outer join_type"}
+	}
+	if outerLeft && unmatched.Enabled() {
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("join unmatched policy %s cannot be used with join_type %s, unmatched left events are emitted by the join", unmatched.Policy, joinType)}
+	}
+	// A right event replaced by a newer one of its key could not be emitted
+	if outerRight {
+		switch rightMatch {
+		case "":
+			rightMatch = internal.JoinRightMatchAll
+		case internal.JoinRightMatchLatest:
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("join_type %s requires right_match all", joinType)}
+		}
+	}
+
+	switch rightMatch {
+	case "":
+		rightMatch = internal.JoinRightMatchLatest
+	case internal.JoinRightMatchLatest, internal.JoinRightMatchAll:
+	default:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("unsupported join right_match %q, must be latest or all", rightMatch)}
+	}
+
+	// Unmatched events are swept by the join, keep them in the left buffer
+	// past the window until a sweep routed them.
+	if unmatched.Enabled() {
+		leftBufferTTL = *NewJSONDuration(leftBufferTTL.Duration() + internal.JoinUnmatchedGrace)
+	}
+	// The outer sides are swept the same way once the grace period passed
+	if outerLeft {
+		leftBufferTTL = *NewJSONDuration(leftBufferTTL.Duration() + grace.Duration() + internal.JoinUnmatchedGrace)
+	}
+	if outerRight {
+		rightBufferTTL = *NewJSONDuration(rightBufferTTL.Duration() + grace.Duration() + internal.JoinUnmatchedGrace)
+	}
+
 	return JoinComponentConfig{
 		ID:             joinID,
 		Sources:        sources,
@@ -321,6 +985,14 @@ func NewJoinComponentConfig(kind, joinID string, sources []JoinSourceConfig, joi
 		Enabled:        true,
 		LeftBufferTTL:  leftBufferTTL,
 		RightBufferTTL: rightBufferTTL,
+		RightCache:     rightCache,
+		Tiering:        tiering,
+		Unmatched:      unmatched,
+		Ordering:       ordering,
+		RightMatch:     rightMatch,
+		JoinType:       joinType,
+		Grace:          grace,
+		Chain:          chain,
 		Config:         joinRules,
 	}, nil
 }
@@ -335,15 +1007,29 @@ type ClickHouseConnectionParamsConfig struct {
 	Table                string `json:"table"`
 	Secure               bool   `json:"secure"`
 	SkipCertificateCheck bool   `json:"skip_certificate_check"`
+	// VaultRole is the role of the Vault database secrets engine the sink
+	// gets short-lived credentials from, instead of Username and Password
+	VaultRole string `json:"vault_role,omitempty"`
+	// TLSRoot, TLSCert and TLSKey are base64 encoded PEM. The client
+	// certificate and key authenticate the sink to servers requiring mutual
+	// TLS.
+	TLSRoot string `json:"root_ca,omitempty"`
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
 }
 
 type ClickhouseQueryConfig struct {
 	WaitForAsyncInsert bool `json:"wait_for_async_insert"`
+	// ProfileEvents records the ClickHouse ProfileEvents of each batch insert
+	ProfileEvents bool `json:"profile_events"`
 }
 
 type BatchConfig struct {
 	MaxBatchSize int          `json:"max_batch_size"`
 	MaxDelayTime JSONDuration `json:"max_delay_time"`
+	// WarmUp is the window over which a starting sink ramps its batch size
+	// and consumption up to the maximum, zero disables the ramp.
+	WarmUp JSONDuration `json:"warm_up,omitzero"`
 }
 
 type SinkComponentConfig struct {
@@ -355,6 +1041,172 @@ type SinkComponentConfig struct {
 	NATSConsumerName string `json:"nats_consumer_name"`
 
 	ClickHouseConnectionParams ClickHouseConnectionParamsConfig `json:"clickhouse_connection_params"`
+
+	// CreateTable is set when the sink table is created from the mapping on
+	// pipeline creation.
+	CreateTable *CreateTableConfig `json:"create_table,omitempty"`
+
+	// ErrorTable is the table in the sink database that rejected rows are
+	// written to in addition to the DLQ, empty when disabled.
+	ErrorTable string `json:"error_table,omitempty"`
+
+	// InsertDeduplication sets an insert_deduplication_token derived from
+	// the NATS sequences of each batch, so ClickHouse drops a retry of the
+	// same batch. Redelivered messages batched differently are not covered.
+	InsertDeduplication bool `json:"insert_deduplication,omitempty"`
+
+	// Staging is set while a new pipeline writes to its staging table, it is
+	// removed once the data is confirmed through the API.
+	Staging *StagingConfig `json:"staging,omitempty"`
+
+	// AsyncInsert is set when batches are sent with async_insert=1, ClickHouse
+	// then buffers the small batches of the sink and writes them in fewer parts.
+	AsyncInsert *AsyncInsertConfig `json:"async_insert,omitempty"`
+
+	// WriterConcurrency is the number of writers that batch and insert in
+	// parallel, each one owning a share of the NATS subjects feeding the sink.
+	// Zero keeps a single writer.
+	WriterConcurrency int `json:"writer_concurrency,omitempty"`
+}
+
+type AsyncInsertConfig struct {
+	// Wait sends wait_for_async_insert=1, so a batch is acknowledged only once
+	// ClickHouse flushed it to the table. Without it events can be lost when
+	// the server fails before the flush.
+	Wait bool `json:"wait"`
+}
+
+type StagingConfig struct {
+	TableSuffix string `json:"table_suffix"`
+	// Duration is how long the sink samples into the staging table after the
+	// pipeline was created, it then pauses until confirmed. 0 never pauses.
+	Duration JSONDuration `json:"duration,omitzero"`
+}
+
+const DefaultStagingTableSuffix = "_staging"
+
+type CreateTableConfig struct {
+	Engine  string `json:"engine"`
+	OrderBy string `json:"order_by"`
+}
+
+const (
+	DefaultCreateTableEngine  = "MergeTree"
+	DefaultCreateTableOrderBy = "tuple()"
+)
+
+var createTableEngineRegex = regexp.MustCompile(`^[A-Za-z]+(\(.*\))?$`)
+
+// CreateTableQuery returns the CREATE TABLE IF NOT EXISTS statement for the
+// sink table, with one column per distinct mapping destination.
+func (s SinkComponentConfig) CreateTableQuery() (string, error) {
+	if s.CreateTable == nil {
+		return "", fmt.Errorf("create table is not enabled for the sink")
+	}
+	if len(s.Config) == 0 {
+		return "", PipelineConfigError{Msg: "auto_create_table requires a sink mapping"}
+	}
+
+	columnTypes := make(map[string]string, len(s.Config))
+	columns := make([]string, 0, len(s.Config))
+	for _, m := range s.Config {
+		if strings.ContainsRune(m.DestinationType, ';') {
+			return "", PipelineConfigError{Msg: fmt.Sprintf("invalid column type %q for column %q", m.DestinationType, m.DestinationField)}
+		}
+		existing, ok := columnTypes[m.DestinationField]
+		if ok {
+			if existing != m.DestinationType {
+				return "", PipelineConfigError{Msg: fmt.Sprintf("column %q is mapped with different types %q and %q", m.DestinationField, existing, m.DestinationType)}
+			}
+			continue
+		}
+		columnTypes[m.DestinationField] = m.DestinationType
+		columns = append(columns, quoteCHIdentifier(m.DestinationField)+" "+m.DestinationType)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (%s) ENGINE = %s ORDER BY %s",
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Database),
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Table),
+		strings.Join(columns, ", "),
+		s.CreateTable.Engine,
+		s.CreateTable.OrderBy,
+	), nil
+}
+
+// MissingColumnTypes reports whether a sink mapping omits its column type.
+func (s SinkComponentConfig) MissingColumnTypes() bool {
+	for _, m := range s.Config {
+		if m.DestinationType == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FillColumnTypes sets the column type of the mappings that omit it from the
+// column types of the sink table.
+func (s *SinkComponentConfig) FillColumnTypes(columnTypes map[string]string) error {
+	for i, m := range s.Config {
+		if m.DestinationType != "" {
+			continue
+		}
+		columnType, ok := columnTypes[m.DestinationField]
+		if !ok {
+			return PipelineConfigError{Msg: fmt.Sprintf(
+				"column_type is required for column %q, it was not found in table %q",
+				m.DestinationField, s.ClickHouseConnectionParams.Table,
+			)}
+		}
+		s.Config[i].DestinationType = columnType
+	}
+	return nil
+}
+
+// ErrorTableQuery returns the CREATE TABLE IF NOT EXISTS statement for the
+// sink error table.
+func (s SinkComponentConfig) ErrorTableQuery() (string, error) {
+	if s.ErrorTable == "" {
+		return "", fmt.Errorf("error table is not enabled for the sink")
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s ("+
+		"pipeline_id LowCardinality(String), "+
+		"component LowCardinality(String), "+
+		"reason LowCardinality(String), "+
+		"error String, "+
+		"payload String, "+
+		"timestamp DateTime64(3, 'UTC')"+
+		") ENGINE = MergeTree ORDER BY (pipeline_id, timestamp)",
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Database),
+		quoteCHIdentifier(s.ErrorTable),
+	), nil
+}
+
+// StagingTable returns the name of the sink staging table.
+func (s SinkComponentConfig) StagingTable() string {
+	if s.Staging == nil {
+		return ""
+	}
+	return s.ClickHouseConnectionParams.Table + s.Staging.TableSuffix
+}
+
+// StagingTableQuery returns the statement creating the staging table with the
+// structure and engine of the sink table.
+func (s SinkComponentConfig) StagingTableQuery() (string, error) {
+	if s.Staging == nil {
+		return "", fmt.Errorf("staging is not enabled for the sink")
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s AS %s.%s",
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Database),
+		quoteCHIdentifier(s.StagingTable()),
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Database),
+		quoteCHIdentifier(s.ClickHouseConnectionParams.Table),
+	), nil
+}
+
+func quoteCHIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
 }
 
 type ClickhouseSinkArgs struct {
@@ -369,7 +1221,24 @@ type ClickhouseSinkArgs struct {
 	MaxBatchSize         int
 	MaxDelayTime         JSONDuration
 	SkipCertificateCheck bool
+	VaultRole            string
+	TLSRoot              string
+	TLSCert              string
+	TLSKey               string
 	Mappings             []Mapping
+	AutoCreateTable      bool
+	TableEngine          string
+	TableOrderBy         string
+	ErrorTable           string
+	InsertDeduplication  bool
+	Staging              bool
+	StagingTableSuffix   string
+	StagingDuration      JSONDuration
+	AsyncInsert          bool
+	// WaitForAsyncInsert defaults to true when AsyncInsert is set
+	WaitForAsyncInsert *bool
+	WriterConcurrency  int
+	WarmUp             JSONDuration
 }
 
 func NewClickhouseSinkComponent(args ClickhouseSinkArgs) (zero SinkComponentConfig, _ error) {
@@ -397,6 +1266,13 @@ func NewClickhouseSinkComponent(args ClickhouseSinkArgs) (zero SinkComponentConf
 		return zero, PipelineConfigError{Msg: "clickhouse table cannot be empty"}
 	}
 
+	if (len(strings.TrimSpace(args.TLSCert)) == 0) != (len(strings.TrimSpace(args.TLSKey)) == 0) {
+		return zero, PipelineConfigError{Msg: "clickhouse client certificate and key must be set together"}
+	}
+	if !args.Secure && (args.TLSRoot != "" || args.TLSCert != "") {
+		return zero, PipelineConfigError{Msg: "clickhouse TLS certificates require secure"}
+	}
+
 	if args.MaxBatchSize == 0 {
 		return zero, PipelineConfigError{Msg: "clickhouse max_batch_size must be greater than 0"}
 	}
@@ -406,11 +1282,81 @@ func NewClickhouseSinkComponent(args ClickhouseSinkArgs) (zero SinkComponentConf
 		maxDelayTime = JSONDuration{t: 60 * time.Second}
 	}
 
+	var createTable *CreateTableConfig
+	if args.AutoCreateTable {
+		if len(args.Mappings) == 0 {
+			return zero, PipelineConfigError{Msg: "auto_create_table requires a sink mapping"}
+		}
+
+		createTable = &CreateTableConfig{
+			Engine:  strings.TrimSpace(args.TableEngine),
+			OrderBy: strings.TrimSpace(args.TableOrderBy),
+		}
+		if createTable.Engine == "" {
+			createTable.Engine = DefaultCreateTableEngine
+		}
+		if createTable.OrderBy == "" {
+			createTable.OrderBy = DefaultCreateTableOrderBy
+		}
+
+		if !createTableEngineRegex.MatchString(createTable.Engine) || strings.ContainsRune(createTable.Engine, ';') {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("invalid clickhouse table_engine: %q", args.TableEngine)}
+		}
+		if strings.ContainsRune(createTable.OrderBy, ';') {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("invalid clickhouse table_order_by: %q", args.TableOrderBy)}
+		}
+	} else if args.TableEngine != "" || args.TableOrderBy != "" {
+		return zero, PipelineConfigError{Msg: "clickhouse table_engine and table_order_by require auto_create_table"}
+	}
+
+	errorTable := strings.TrimSpace(args.ErrorTable)
+	if errorTable == strings.TrimSpace(args.Table) {
+		return zero, PipelineConfigError{Msg: "clickhouse error_table must differ from the sink table"}
+	}
+
+	var staging *StagingConfig
+	if args.Staging {
+		staging = &StagingConfig{
+			TableSuffix: strings.TrimSpace(args.StagingTableSuffix),
+			Duration:    args.StagingDuration,
+		}
+		if staging.TableSuffix == "" {
+			staging.TableSuffix = DefaultStagingTableSuffix
+		}
+		if staging.Duration.Duration() < 0 {
+			return zero, PipelineConfigError{Msg: "clickhouse staging_duration cannot be negative"}
+		}
+		if strings.TrimSpace(args.Table)+staging.TableSuffix == errorTable {
+			return zero, PipelineConfigError{Msg: "clickhouse error_table must differ from the staging table"}
+		}
+	} else if args.StagingTableSuffix != "" || args.StagingDuration.Duration() != 0 {
+		return zero, PipelineConfigError{Msg: "clickhouse staging_table_suffix and staging_duration require staging"}
+	}
+
+	var asyncInsert *AsyncInsertConfig
+	if args.AsyncInsert {
+		asyncInsert = &AsyncInsertConfig{Wait: true}
+		if args.WaitForAsyncInsert != nil {
+			asyncInsert.Wait = *args.WaitForAsyncInsert
+		}
+	} else if args.WaitForAsyncInsert != nil {
+		return zero, PipelineConfigError{Msg: "clickhouse wait_for_async_insert requires async_insert"}
+	}
+
+	if args.WriterConcurrency < 0 || args.WriterConcurrency > internal.SinkMaxWriterConcurrency {
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("clickhouse writer_concurrency must be between 0 and %d", internal.SinkMaxWriterConcurrency)}
+	}
+
+	if args.WarmUp.Duration() < 0 {
+		return zero, PipelineConfigError{Msg: "clickhouse warm_up cannot be negative"}
+	}
+
 	return SinkComponentConfig{
 		Type: internal.ClickHouseSinkType,
 		Batch: BatchConfig{
 			MaxBatchSize: args.MaxBatchSize,
 			MaxDelayTime: maxDelayTime,
+			WarmUp:       args.WarmUp,
 		},
 		ClickHouseConnectionParams: ClickHouseConnectionParamsConfig{
 			Host:                 args.Host,
@@ -422,7 +1368,17 @@ func NewClickhouseSinkComponent(args ClickhouseSinkArgs) (zero SinkComponentConf
 			Table:                args.Table,
 			Secure:               args.Secure,
 			SkipCertificateCheck: args.SkipCertificateCheck,
+			VaultRole:            strings.TrimSpace(args.VaultRole),
+			TLSRoot:              args.TLSRoot,
+			TLSCert:              args.TLSCert,
+			TLSKey:               args.TLSKey,
 		},
+		CreateTable:         createTable,
+		ErrorTable:          errorTable,
+		InsertDeduplication: args.InsertDeduplication,
+		Staging:             staging,
+		AsyncInsert:         asyncInsert,
+		WriterConcurrency:   args.WriterConcurrency,
 	}, nil
 }
 
@@ -442,6 +1398,12 @@ type StatelessTransformation struct {
 type StatelessTransformationsConfig struct {
 	//Source    string      `json:"source,omitempty"` // we don't need it for now
 	Transform []Transform `json:"transform"`
+	// UDF runs a user-defined function on the events instead of the
+	// expressions, the transforms then only declare its output fields
+	UDF *UDFConfig `json:"udf,omitempty"`
+	// Salt keys the tokenize function of the expressions, tokens of a value
+	// are equal within the pipeline and differ between pipelines
+	Salt string `json:"salt,omitempty"`
 }
 
 type Transform struct {
@@ -451,9 +1413,71 @@ type Transform struct {
 }
 
 type PipelineMetadata struct {
-	Tags []string `json:"tags"`
+	// Owner, Team and Contact tell on-call who to reach when the pipeline
+	// fails, e.g. a person, the team owning it and a channel or an address
+	Owner         string              `json:"owner,omitempty"`
+	Team          string              `json:"team,omitempty"`
+	Contact       string              `json:"contact,omitempty"`
+	Tags          []string            `json:"tags"`
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+	SLA           *SLAConfig          `json:"sla,omitempty"`
+	// DependsOn lists the pipelines that must be running before this
+	// pipeline is created or resumed
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Schedule starts and stops the pipeline on cron schedules
+	Schedule *ScheduleConfig `json:"schedule,omitempty"`
+}
+
+// NotificationConfig lists the webhooks notified about the pipeline lifecycle
+// events in addition to the globally configured ones. StorageThreshold is
+// the share of the stream limits the pipeline is degraded at.
+type NotificationConfig struct {
+	WebhookURLs      []string `json:"webhook_urls,omitempty"`
+	DLQThreshold     uint64   `json:"dlq_threshold,omitempty"`
+	StorageThreshold float64  `json:"storage_threshold,omitempty"`
+}
+
+func (m PipelineMetadata) Validate() error {
+	if err := validateTags(m.Tags); err != nil {
+		return err
+	}
+
+	if err := validateOwnership(m); err != nil {
+		return err
+	}
+
+	if err := validateDependsOn(m.DependsOn); err != nil {
+		return err
+	}
+
+	if m.SLA != nil {
+		if err := m.SLA.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Schedule != nil {
+		if err := m.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Notifications == nil {
+		return nil
+	}
+
+	for _, raw := range m.Notifications.WebhookURLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return PipelineConfigError{Msg: fmt.Sprintf("invalid notification webhook url %q: must be an absolute http or https url", raw)}
+		}
+	}
+
+	return ValidateStorageThreshold(m.Notifications.StorageThreshold)
 }
 
+// OTLPSourceConfig describes a receiver fed source, it is used by OTLP and
+// HTTP sources.
 type OTLPSourceConfig struct {
 	ID            string              `json:"id"`
 	Deduplication DeduplicationConfig `json:"deduplication,omitempty"`
@@ -470,14 +1494,44 @@ type PipelineConfig struct {
 	Sink                    SinkComponentConfig      `json:"sink"`
 	Filter                  FilterComponentConfig    `json:"filter"`
 	StatelessTransformation StatelessTransformation  `json:"stateless_transformation,omitempty"`
+	Explode                 ExplodeConfig            `json:"explode,omitzero"`
+	Enrichment              EnrichmentConfig         `json:"enrichment,omitzero"`
 	PipelineResources       PipelineResources        `json:"pipeline_resources,omitempty"`
 	SchemaVersions          map[string]SchemaVersion `json:"schema_versions,omitempty"`
 
+	// ConfigSchemaVersion is set on the configs handed to the components
+	ConfigSchemaVersion int `json:"config_schema_version,omitempty"`
+	// ConfigHash identifies the config the components run with, see
+	// ComputeConfigHash
+	ConfigHash string `json:"config_hash,omitempty"`
+	// PausedComponents are paused on their own while the rest of the
+	// pipeline runs, see PausableComponents
+	PausedComponents []string `json:"paused_components,omitempty"`
+
 	CreatedAt time.Time        `json:"created_at"`
 	Metadata  PipelineMetadata `json:"metadata"`
 	Status    PipelineHealth   `json:"status,omitempty"`
 }
 
+// PausableComponents are the components that can be paused on their own. A
+// paused sink leaves the events in its NATS stream, the ingestion goes on.
+var PausableComponents = []string{internal.RoleSink}
+
+// ValidatePausableComponent checks that a component can be paused on its own.
+func ValidatePausableComponent(component string) error {
+	if !slices.Contains(PausableComponents, component) {
+		return PipelineConfigError{Msg: fmt.Sprintf("component %q cannot be paused on its own, pausable components: %s",
+			component, strings.Join(PausableComponents, ", "))}
+	}
+	return nil
+}
+
+// TransformFilterEnabled reports whether the filter runs in the transform
+// component. The filter of a join pipeline runs on the output of the join.
+func (pc PipelineConfig) TransformFilterEnabled() bool {
+	return pc.Filter.Enabled && !pc.Join.Enabled
+}
+
 func (pc PipelineConfig) ToListPipeline() ListPipelineConfig {
 	transformation := internal.IngestTransformation
 
@@ -642,6 +1696,12 @@ func GetJoinedStreamName(pipelineID string) string {
 	return fmt.Sprintf("%s-%s-%s", internal.PipelineStreamPrefix, hash, "joined")
 }
 
+// GetJoinStageStreamName returns the stream of the intermediate output of a
+// chained join stage, the left input of the stage after it.
+func GetJoinStageStreamName(pipelineID string, stage int) string {
+	return fmt.Sprintf("%s-%d", GetJoinedStreamName(pipelineID), stage)
+}
+
 // NewPipelineHealth creates a new pipeline health status
 func NewPipelineHealth(pipelineID, pipelineName string) PipelineHealth {
 	now := time.Now().UTC()
@@ -726,6 +1786,26 @@ func GetNATSConsumerName(pipelineID string, componentType string, streamType str
 		GenerateStreamHash(pipelineID))
 }
 
+// GetNATSConsumerComponent returns the component type of a consumer named
+// by GetNATSConsumerName, or an empty string for other consumers.
+func GetNATSConsumerComponent(consumerName string) string {
+	rest, ok := strings.CutPrefix(consumerName, internal.NATSConsumerNamePrefix+"-")
+	if !ok || len(rest) < 2 {
+		return ""
+	}
+
+	switch rest[0] {
+	case 's':
+		return "sink"
+	case 'j':
+		return "join"
+	case 'd':
+		return "dedup"
+	default:
+		return ""
+	}
+}
+
 func GetNATSSinkConsumerName(pipelineID string) string {
 	return GetNATSConsumerName(pipelineID, "sink", "input")
 }
@@ -738,6 +1818,12 @@ func GetNATSJoinRightConsumerName(pipelineID string) string {
 	return GetNATSConsumerName(pipelineID, "join", "right")
 }
 
+// GetNATSJoinStageConsumerName returns the consumer of the left or right
+// input of a chained join stage after the first.
+func GetNATSJoinStageConsumerName(pipelineID string, stage int, streamType string) string {
+	return fmt.Sprintf("%s-%d", GetNATSConsumerName(pipelineID, "join", streamType), stage)
+}
+
 func GetNATSDedupConsumerName(pipelineID string) string {
 	return GetNATSConsumerName(pipelineID, "dedup", "input")
 }
@@ -766,6 +1852,26 @@ func GetDedupOutputStreamName(pipelineID, topicName string) string {
 	return dedupStreamName
 }
 
+// GetDedupSnapshotBucketName returns the NATS object store keeping the
+// snapshots of the pipeline's deduplicator state.
+func GetDedupSnapshotBucketName(pipelineID string) string {
+	hash := GenerateStreamHash(pipelineID)
+	return fmt.Sprintf("%s-%s-dedup-snapshots", internal.PipelineStreamPrefix, hash)
+}
+
+// GetDedupDomainBucketName returns the NATS KV bucket in which the
+// pipelines of a dedup domain claim the IDs of the events they ingest.
+func GetDedupDomainBucketName(domain string) string {
+	return fmt.Sprintf("%s-dedup-domain-%s", internal.PipelineStreamPrefix, domain)
+}
+
+// GetJoinCheckpointBucketName returns the NATS object store keeping the
+// checkpoints of the pipeline's join stages.
+func GetJoinCheckpointBucketName(pipelineID string) string {
+	hash := GenerateStreamHash(pipelineID)
+	return fmt.Sprintf("%s-%s-join-checkpoints", internal.PipelineStreamPrefix, hash)
+}
+
 func GetOTLPOutputSubjectPrefix(pipelineID string) string {
 	hash := GenerateStreamHash(pipelineID)
 	return fmt.Sprintf("%s-%s-otlp-out", internal.PipelineStreamPrefix, hash)
@@ -314,6 +314,48 @@ func TestGetKafkaConsumerGroupName(t *testing.T) {
 	}
 }
 
+func TestGetNATSConsumerComponent(t *testing.T) {
+	pipelineID := "test-pipeline-123"
+	tests := []struct {
+		consumer string
+		expected string
+	}{
+		{GetNATSSinkConsumerName(pipelineID), "sink"},
+		{GetNATSJoinLeftConsumerName(pipelineID), "join"},
+		{GetNATSJoinRightConsumerName(pipelineID), "join"},
+		{GetNATSDedupConsumerName(pipelineID), "dedup"},
+		{GetDLQStreamName(pipelineID) + "-consumer", ""},
+		{internal.NATSConsumerNamePrefix + "-", ""},
+	}
+
+	for _, tt := range tests {
+		if got := GetNATSConsumerComponent(tt.consumer); got != tt.expected {
+			t.Errorf("GetNATSConsumerComponent(%q) = %q, want %q", tt.consumer, got, tt.expected)
+		}
+	}
+}
+
+func TestInFlightDrained(t *testing.T) {
+	tests := []struct {
+		name     string
+		inFlight []ComponentInFlight
+		expected bool
+	}{
+		{"no consumers", nil, true},
+		{"all clear", []ComponentInFlight{{Component: "sink"}, {Component: "join"}}, true},
+		{"pending", []ComponentInFlight{{Component: "sink"}, {Component: "join", Pending: 3}}, false},
+		{"unacked", []ComponentInFlight{{Component: "sink", Unacked: 1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InFlightDrained(tt.inFlight); got != tt.expected {
+				t.Errorf("InFlightDrained() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetNATSSubjectName(t *testing.T) {
 	streamName := "gf-abc12345-my_topic"
 	subjectName := "input"
@@ -889,6 +931,25 @@ func TestNewIngestorComponentConfig_ErrorsAndDefaults(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "aws msk iam without tls",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismAWSIAM,
+				SASLProtocol:  internal.SASLProtocolSASLPlaintext,
+			},
+			description: "AWS_MSK_IAM requires the SASL_SSL protocol",
+			expectError: true,
+		},
+		{
+			name: "aws msk iam with sasl ssl and no certificates",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismAWSIAM,
+				SASLProtocol:  internal.SASLProtocolSASLSSL,
+			},
+			expectError: false,
+		},
 		{
 			name: "no username with plain mechanism",
 			conn: KafkaConnectionParamsConfig{
@@ -926,6 +987,110 @@ func TestNewIngestorComponentConfig_ErrorsAndDefaults(t *testing.T) {
 			description: "invalid consumer_group_initial_offset",
 			expectError: true,
 		},
+		{
+			name: "invalid partition assignment",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{PartitionAssignment: "sticky", Replicas: 1},
+			},
+			description: "invalid partition_assignment",
+			expectError: true,
+		},
+		{
+			name: "invalid rebalance strategy",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{RebalanceStrategy: "roundrobin", Replicas: 1},
+			},
+			description: "invalid rebalance_strategy",
+			expectError: true,
+		},
+		{
+			name: "invalid decode error policy",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{DecodeErrorPolicy: "drop", Replicas: 1},
+			},
+			description: "invalid decode_error_policy",
+			expectError: true,
+		},
+		{
+			name: "invalid msg id strategy",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{PublishDedup: PublishDedupConfig{MsgIDStrategy: "random"}, Replicas: 1},
+			},
+			description: "invalid publish_dedup msg_id_strategy",
+			expectError: true,
+		},
+		{
+			name: "negative duplicate window",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{PublishDedup: PublishDedupConfig{DuplicateWindow: *NewJSONDuration(-time.Minute)}, Replicas: 1},
+			},
+			description: "publish_dedup duplicate_window cannot be negative",
+			expectError: true,
+		},
+		{
+			name: "negative flow control threshold",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{FlowControl: FlowControlConfig{MaxConsumerPending: -1}, Replicas: 1},
+			},
+			description: "flow_control max_consumer_pending cannot be negative",
+			expectError: true,
+		},
+		{
+			name: "invalid debezium delete handling",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{DebeziumUnwrap: DebeziumUnwrapConfig{Enabled: true, DeleteHandling: "keep"}, Replicas: 1},
+			},
+			description: "invalid debezium_unwrap delete_handling",
+			expectError: true,
+		},
+		{
+			name: "debezium metadata fields collide",
+			conn: KafkaConnectionParamsConfig{
+				Brokers:       []string{validBroker},
+				SASLMechanism: internal.MechanismNoAuth,
+				SASLProtocol:  validProtocol,
+			},
+			topics: []KafkaTopicsConfig{
+				{DebeziumUnwrap: DebeziumUnwrapConfig{Enabled: true, OpField: "meta", TimestampField: "meta"}, Replicas: 1},
+			},
+			description: "debezium_unwrap op_field and ts_field must differ",
+			expectError: true,
+		},
 		{
 			name: "positive case with TLS and skip auth",
 			conn: KafkaConnectionParamsConfig{
@@ -983,7 +1148,13 @@ func TestNewIngestorComponentConfig_SuccessAndTopicDefaults(t *testing.T) {
 			ConsumerGroupInitialOffset: internal.InitialOffsetLatest,
 			ConsumerGroupName:          "cg2",
 			Replicas:                   3,
-			Deduplication:              DeduplicationConfig{Enabled: true},
+			PartitionAssignment:        "Static",
+			DecodeErrorPolicy:          "FAIL",
+			Deduplication:              DeduplicationConfig{Enabled: true, Window: *NewJSONDuration(time.Hour)},
+			PublishDedup: PublishDedupConfig{
+				DuplicateWindow: *NewJSONDuration(24 * time.Hour),
+				MsgIDStrategy:   "Content_Hash",
+			},
 		},
 	}
 
@@ -1028,4 +1199,820 @@ func TestNewIngestorComponentConfig_SuccessAndTopicDefaults(t *testing.T) {
 	if cfg.KafkaTopics[1].Replicas != 3 {
 		t.Fatalf("expected replicas to be 3, got %d", cfg.KafkaTopics[1].Replicas)
 	}
+	if cfg.KafkaTopics[0].PartitionAssignment != internal.PartitionAssignmentGroup {
+		t.Fatalf("expected default partition_assignment to be %q, got %q", internal.PartitionAssignmentGroup, cfg.KafkaTopics[0].PartitionAssignment)
+	}
+	if cfg.KafkaTopics[1].PartitionAssignment != internal.PartitionAssignmentStatic {
+		t.Fatalf("expected partition_assignment to be %q, got %q", internal.PartitionAssignmentStatic, cfg.KafkaTopics[1].PartitionAssignment)
+	}
+	if cfg.KafkaTopics[0].RebalanceStrategy != internal.RebalanceStrategyCooperativeSticky {
+		t.Fatalf("expected default rebalance_strategy to be %q, got %q", internal.RebalanceStrategyCooperativeSticky, cfg.KafkaTopics[0].RebalanceStrategy)
+	}
+	if cfg.KafkaTopics[0].DecodeErrorPolicy != internal.DecodeErrorPolicyDLQ {
+		t.Fatalf("expected default decode_error_policy to be %q, got %q", internal.DecodeErrorPolicyDLQ, cfg.KafkaTopics[0].DecodeErrorPolicy)
+	}
+	if cfg.KafkaTopics[1].DecodeErrorPolicy != internal.DecodeErrorPolicyFail {
+		t.Fatalf("expected decode_error_policy to be %q, got %q", internal.DecodeErrorPolicyFail, cfg.KafkaTopics[1].DecodeErrorPolicy)
+	}
+	if cfg.KafkaTopics[0].PublishDedup.MsgIDStrategy != internal.MsgIDStrategyOffset {
+		t.Fatalf("expected default msg_id_strategy to be %q, got %q", internal.MsgIDStrategyOffset, cfg.KafkaTopics[0].PublishDedup.MsgIDStrategy)
+	}
+	if cfg.KafkaTopics[1].PublishDedup.MsgIDStrategy != internal.MsgIDStrategyContentHash {
+		t.Fatalf("expected msg_id_strategy to be %q, got %q", internal.MsgIDStrategyContentHash, cfg.KafkaTopics[1].PublishDedup.MsgIDStrategy)
+	}
+	if got := cfg.KafkaTopics[0].StreamDuplicateWindow(0); got != 0 {
+		t.Fatalf("expected no stream duplicate window, got %s", got)
+	}
+	if got := cfg.KafkaTopics[1].StreamDuplicateWindow(0); got != 24*time.Hour {
+		t.Fatalf("expected publish dedup window to override the dedup window, got %s", got)
+	}
+}
+
+func TestStreamDuplicateWindow_CapsLongDedupWindows(t *testing.T) {
+	topic := KafkaTopicsConfig{
+		Deduplication: DeduplicationConfig{Enabled: true, Window: *NewJSONDuration(14 * 24 * time.Hour)},
+	}
+	if got := topic.StreamDuplicateWindow(0); got != internal.MaxStreamDuplicateWindow {
+		t.Fatalf("expected the dedup window to be capped to %s, got %s", internal.MaxStreamDuplicateWindow, got)
+	}
+
+	if got := topic.StreamDuplicateWindow(time.Hour); got != time.Hour {
+		t.Fatalf("expected the dedup window to be capped to the stream maxAge, got %s", got)
+	}
+
+	topic.PublishDedup.DuplicateWindow = *NewJSONDuration(48 * time.Hour)
+	if got := topic.StreamDuplicateWindow(0); got != 48*time.Hour {
+		t.Fatalf("expected the publish dedup window to be kept, got %s", got)
+	}
+}
+
+func TestNewJoinComponentConfig_RightCache(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(2 * time.Hour), Orientation: internal.JoinRight},
+	}
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{Enabled: true}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RightCache.MaxBytes != internal.DefaultJoinCacheMaxBytes {
+		t.Fatalf("expected default cache size %d, got %d", internal.DefaultJoinCacheMaxBytes, cfg.RightCache.MaxBytes)
+	}
+	if cfg.RightBufferTTL.Duration() != 2*time.Hour {
+		t.Fatalf("expected right buffer ttl 2h, got %s", cfg.RightBufferTTL.Duration())
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{MaxBytes: 1024}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RightCache != (JoinCacheConfig{}) {
+		t.Fatalf("expected disabled cache to be cleared, got %+v", cfg.RightCache)
+	}
+
+	_, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{Enabled: true, MaxBytes: -1}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err == nil || !strings.Contains(err.Error(), "max_bytes cannot be negative") {
+		t.Fatalf("expected negative max_bytes error, got %v", err)
+	}
+}
+
+func TestNewJoinComponentConfig_Tiering(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(72 * time.Hour), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(72 * time.Hour), Orientation: internal.JoinRight},
+	}
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Tiering.HotWindow.Duration() != internal.DefaultJoinHotWindow || cfg.Tiering.HotMaxBytes != internal.DefaultJoinHotMaxBytes {
+		t.Fatalf("expected default hot tier, got %+v", cfg.Tiering)
+	}
+	if cfg.Tiering.CompactAfter.Duration() != internal.DefaultJoinHotWindow {
+		t.Fatalf("expected events to be compacted after the hot window, got %s", cfg.Tiering.CompactAfter.Duration())
+	}
+
+	_, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil,
+		JoinTieringConfig{Enabled: true, CompactAfter: *NewJSONDuration(time.Second)})
+	if err == nil || !strings.Contains(err.Error(), "compact_after must be at least") {
+		t.Fatalf("expected compact_after error, got %v", err)
+	}
+
+	_, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{Enabled: true}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{Enabled: true})
+	if err == nil || !strings.Contains(err.Error(), "right_cache cannot be used with tiering") {
+		t.Fatalf("expected right_cache error, got %v", err)
+	}
+}
+
+func TestNewJoinComponentConfig_Unmatched(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(2 * time.Hour), Orientation: internal.JoinRight},
+	}
+	mapping := []Mapping{
+		{SourceField: "user_id", SourceType: "string", DestinationField: "user_id", DestinationType: "String"},
+		{SourceField: "amount", SourceType: "float64", DestinationField: "amount", DestinationType: "Float64"},
+	}
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{Policy: internal.JoinUnmatchedPolicyDrop}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Unmatched.Enabled() || cfg.LeftBufferTTL.Duration() != time.Hour {
+		t.Fatalf("expected dropped unmatched events with the window as ttl, got %+v ttl %s", cfg.Unmatched, cfg.LeftBufferTTL.Duration())
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{Policy: internal.JoinUnmatchedPolicyDLQ, Table: "misses"}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Unmatched.Table != "" {
+		t.Fatalf("expected the table to be cleared with the dlq policy, got %q", cfg.Unmatched.Table)
+	}
+	if cfg.LeftBufferTTL.Duration() != time.Hour+internal.JoinUnmatchedGrace {
+		t.Fatalf("expected left buffer ttl extended by the grace, got %s", cfg.LeftBufferTTL.Duration())
+	}
+	if cfg.LeftWindow() != time.Hour {
+		t.Fatalf("expected left window 1h, got %s", cfg.LeftWindow())
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{
+		Policy:  internal.JoinUnmatchedPolicyTable,
+		Table:   "order_misses",
+		Mapping: mapping,
+	}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "INSERT INTO `analytics`.`order_misses` (`amount`, `user_id`)"
+	if got := cfg.Unmatched.InsertQuery("analytics"); got != want {
+		t.Fatalf("expected insert query %q, got %q", want, got)
+	}
+
+	tests := []struct {
+		name      string
+		unmatched JoinUnmatchedConfig
+		wantErr   string
+	}{
+		{"unknown policy", JoinUnmatchedConfig{Policy: "retry"}, "unsupported join unmatched policy"},
+		{"table without name", JoinUnmatchedConfig{Policy: internal.JoinUnmatchedPolicyTable, Mapping: mapping}, "table cannot be empty"},
+		{"table without mapping", JoinUnmatchedConfig{Policy: internal.JoinUnmatchedPolicyTable, Table: "misses"}, "mapping cannot be empty"},
+		{"mapping without type", JoinUnmatchedConfig{
+			Policy:  internal.JoinUnmatchedPolicyTable,
+			Table:   "misses",
+			Mapping: []Mapping{{SourceField: "user_id", DestinationField: "user_id"}},
+		}, "need a source field, a column name and a column type"},
+		{"duplicate column", JoinUnmatchedConfig{
+			Policy:  internal.JoinUnmatchedPolicyTable,
+			Table:   "misses",
+			Mapping: []Mapping{mapping[0], mapping[0]},
+		}, "duplicate column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, tt.unmatched, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewJoinComponentConfig_Ordering(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinLeft, TimeField: "created_at"},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(2 * time.Hour), Orientation: internal.JoinRight},
+	}
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{Enabled: true}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Ordering.MaxSkew.Duration() != internal.DefaultJoinOrderingMaxSkew {
+		t.Fatalf("expected default max skew %s, got %s", internal.DefaultJoinOrderingMaxSkew, cfg.Ordering.MaxSkew.Duration())
+	}
+
+	tests := []struct {
+		name     string
+		ordering JoinOrderingConfig
+		wantErr  string
+	}{
+		{"time field without ordering", JoinOrderingConfig{}, "time_field requires ordering"},
+		{"skew without ordering", JoinOrderingConfig{MaxSkew: *NewJSONDuration(time.Second)}, "max_skew requires ordering"},
+		{"negative skew", JoinOrderingConfig{Enabled: true, MaxSkew: *NewJSONDuration(-time.Second)}, "cannot be negative"},
+		{"skew above limit", JoinOrderingConfig{Enabled: true, MaxSkew: *NewJSONDuration(time.Minute)}, "cannot exceed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, tt.ordering, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewJoinComponentConfig_RightMatch(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinRight},
+	}
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RightMatch != internal.JoinRightMatchLatest || cfg.RightMatchAll() {
+		t.Fatalf("expected right match to default to latest, got %q", cfg.RightMatch)
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, internal.JoinRightMatchAll, "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.RightMatchAll() {
+		t.Fatalf("expected right match all, got %q", cfg.RightMatch)
+	}
+
+	_, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "first", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err == nil || !strings.Contains(err.Error(), "right_match") {
+		t.Fatalf("expected right_match error, got %v", err)
+	}
+}
+
+func TestNewJoinComponentConfig_JoinType(t *testing.T) {
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: *NewJSONDuration(2 * time.Hour), Orientation: internal.JoinRight},
+	}
+	grace := *NewJSONDuration(10 * time.Minute)
+
+	cfg, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.JoinType != internal.JoinTypeInner || cfg.OuterLeft() || cfg.OuterRight() {
+		t.Fatalf("expected join type to default to inner, got %q", cfg.JoinType)
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", internal.JoinTypeLeft, grace, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.OuterLeft() || cfg.OuterRight() {
+		t.Fatalf("expected only the left side to be outer, got %q", cfg.JoinType)
+	}
+	if cfg.LeftBufferTTL.Duration() != time.Hour+10*time.Minute+internal.JoinUnmatchedGrace {
+		t.Fatalf("expected left buffer ttl extended by the grace, got %s", cfg.LeftBufferTTL.Duration())
+	}
+	if cfg.RightBufferTTL.Duration() != 2*time.Hour || cfg.RightMatchAll() {
+		t.Fatalf("expected the right side unchanged, got ttl %s match %q", cfg.RightBufferTTL.Duration(), cfg.RightMatch)
+	}
+
+	cfg, err = NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", internal.JoinTypeFullOuter, grace, nil, JoinTieringConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.OuterLeft() || !cfg.OuterRight() {
+		t.Fatalf("expected both sides to be outer, got %q", cfg.JoinType)
+	}
+	if !cfg.RightMatchAll() {
+		t.Fatalf("expected right match to default to all, got %q", cfg.RightMatch)
+	}
+	if cfg.RightBufferTTL.Duration() != 2*time.Hour+10*time.Minute+internal.JoinUnmatchedGrace {
+		t.Fatalf("expected right buffer ttl extended by the grace, got %s", cfg.RightBufferTTL.Duration())
+	}
+
+	tests := []struct {
+		name       string
+		joinType   string
+		grace      JSONDuration
+		unmatched  JoinUnmatchedConfig
+		rightMatch string
+		wantErr    string
+	}{
+		{"unknown type", "cross", JSONDuration{}, JoinUnmatchedConfig{}, "", "unsupported join_type"},
+		{"negative grace", internal.JoinTypeLeft, *NewJSONDuration(-time.Minute), JoinUnmatchedConfig{}, "", "cannot be negative"},
+		{"grace of inner join", internal.JoinTypeInner, grace, JoinUnmatchedConfig{}, "", "requires a left, right or full_outer"},
+		{"left with unmatched policy", internal.JoinTypeLeft, grace, JoinUnmatchedConfig{Policy: internal.JoinUnmatchedPolicyDLQ}, "", "cannot be used with join_type"},
+		{"right with latest match", internal.JoinTypeRight, grace, JoinUnmatchedConfig{}, internal.JoinRightMatchLatest, "requires right_match all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, tt.unmatched, JoinOrderingConfig{}, tt.rightMatch, tt.joinType, tt.grace, nil, JoinTieringConfig{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewClickhouseSinkComponent_AutoCreateTable(t *testing.T) {
+	baseArgs := func() ClickhouseSinkArgs {
+		return ClickhouseSinkArgs{
+			Host:         "localhost",
+			Port:         "9000",
+			DB:           "default",
+			User:         "default",
+			Password:     "secret",
+			Table:        "events",
+			MaxBatchSize: 100,
+			Mappings:     []Mapping{{SourceField: "id", SourceType: "string", DestinationField: "id", DestinationType: "String"}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(*ClickhouseSinkArgs)
+		expected    *CreateTableConfig
+		expectError string
+	}{
+		{
+			name:     "disabled",
+			modify:   func(*ClickhouseSinkArgs) {},
+			expected: nil,
+		},
+		{
+			name:     "defaults",
+			modify:   func(a *ClickhouseSinkArgs) { a.AutoCreateTable = true },
+			expected: &CreateTableConfig{Engine: DefaultCreateTableEngine, OrderBy: DefaultCreateTableOrderBy},
+		},
+		{
+			name: "custom engine and order by",
+			modify: func(a *ClickhouseSinkArgs) {
+				a.AutoCreateTable = true
+				a.TableEngine = "ReplacingMergeTree(version)"
+				a.TableOrderBy = "(id, ts)"
+			},
+			expected: &CreateTableConfig{Engine: "ReplacingMergeTree(version)", OrderBy: "(id, ts)"},
+		},
+		{
+			name: "no mapping",
+			modify: func(a *ClickhouseSinkArgs) {
+				a.AutoCreateTable = true
+				a.Mappings = nil
+			},
+			expectError: "auto_create_table requires a sink mapping",
+		},
+		{
+			name: "invalid engine",
+			modify: func(a *ClickhouseSinkArgs) {
+				a.AutoCreateTable = true
+				a.TableEngine = "MergeTree; DROP TABLE events"
+			},
+			expectError: "invalid clickhouse table_engine",
+		},
+		{
+			name: "invalid order by",
+			modify: func(a *ClickhouseSinkArgs) {
+				a.AutoCreateTable = true
+				a.TableOrderBy = "id; DROP TABLE events"
+			},
+			expectError: "invalid clickhouse table_order_by",
+		},
+		{
+			name:        "engine without auto create",
+			modify:      func(a *ClickhouseSinkArgs) { a.TableEngine = "MergeTree" },
+			expectError: "require auto_create_table",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := baseArgs()
+			tt.modify(&args)
+
+			cfg, err := NewClickhouseSinkComponent(args)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (cfg.CreateTable == nil) != (tt.expected == nil) ||
+				(cfg.CreateTable != nil && *cfg.CreateTable != *tt.expected) {
+				t.Errorf("CreateTable = %+v, expected %+v", cfg.CreateTable, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSinkComponentConfig_CreateTableQuery(t *testing.T) {
+	cfg := SinkComponentConfig{
+		Config: []Mapping{
+			{SourceField: "id", DestinationField: "id", DestinationType: "String"},
+			{SourceField: "user.name", DestinationField: "user`name", DestinationType: "Nullable(String)"},
+			{SourceField: "ts", DestinationField: "ts", DestinationType: "DateTime64(3, 'UTC')"},
+			{SourceField: "ts_copy", DestinationField: "ts", DestinationType: "DateTime64(3, 'UTC')"},
+		},
+		ClickHouseConnectionParams: ClickHouseConnectionParamsConfig{Database: "analytics", Table: "events"},
+		CreateTable:                &CreateTableConfig{Engine: "MergeTree", OrderBy: "(id, ts)"},
+	}
+
+	query, err := cfg.CreateTableQuery()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "CREATE TABLE IF NOT EXISTS `analytics`.`events` " +
+		"(`id` String, `user\\`name` Nullable(String), `ts` DateTime64(3, 'UTC')) " +
+		"ENGINE = MergeTree ORDER BY (id, ts)"
+	if query != expected {
+		t.Errorf("CreateTableQuery() = %q, expected %q", query, expected)
+	}
+
+	cfg.Config = append(cfg.Config, Mapping{SourceField: "id_num", DestinationField: "id", DestinationType: "Int64"})
+	_, err = cfg.CreateTableQuery()
+	if err == nil || !strings.Contains(err.Error(), "mapped with different types") {
+		t.Errorf("expected conflicting column type error, got %v", err)
+	}
+}
+
+func TestNewClickhouseSinkComponent_ErrorTable(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:         "localhost",
+		Port:         "9000",
+		DB:           "default",
+		User:         "default",
+		Password:     "secret",
+		Table:        "events",
+		MaxBatchSize: 100,
+		ErrorTable:   " events_errors ",
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ErrorTable != "events_errors" {
+		t.Errorf("ErrorTable = %q, expected %q", cfg.ErrorTable, "events_errors")
+	}
+
+	args.ErrorTable = "events"
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "error_table must differ from the sink table") {
+		t.Errorf("expected error table conflict error, got %v", err)
+	}
+}
+
+func TestNewClickhouseSinkComponent_TLS(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:         "localhost",
+		Port:         "9440",
+		DB:           "default",
+		User:         "default",
+		Password:     "secret",
+		Table:        "events",
+		MaxBatchSize: 100,
+		Secure:       true,
+		TLSRoot:      "ca-data",
+		TLSCert:      "cert-data",
+		TLSKey:       "key-data",
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn := cfg.ClickHouseConnectionParams
+	if conn.TLSRoot != "ca-data" || conn.TLSCert != "cert-data" || conn.TLSKey != "key-data" {
+		t.Errorf("ClickHouseConnectionParams = %+v, expected the TLS certificates", conn)
+	}
+
+	args.TLSKey = ""
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "certificate and key must be set together") {
+		t.Errorf("expected certificate without key error, got %v", err)
+	}
+
+	args.TLSCert = ""
+	args.Secure = false
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "require secure") {
+		t.Errorf("expected certificates without secure error, got %v", err)
+	}
+}
+
+func TestSinkComponentConfig_ErrorTableQuery(t *testing.T) {
+	cfg := SinkComponentConfig{
+		ClickHouseConnectionParams: ClickHouseConnectionParamsConfig{Database: "analytics", Table: "events"},
+	}
+
+	_, err := cfg.ErrorTableQuery()
+	if err == nil {
+		t.Fatal("expected error when the error table is disabled")
+	}
+
+	cfg.ErrorTable = "events_errors"
+	query, err := cfg.ErrorTableQuery()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "CREATE TABLE IF NOT EXISTS `analytics`.`events_errors` (" +
+		"pipeline_id LowCardinality(String), component LowCardinality(String), reason LowCardinality(String), " +
+		"error String, payload String, timestamp DateTime64(3, 'UTC')) " +
+		"ENGINE = MergeTree ORDER BY (pipeline_id, timestamp)"
+	if query != expected {
+		t.Errorf("ErrorTableQuery() = %q, expected %q", query, expected)
+	}
+}
+
+func TestNewClickhouseSinkComponent_Staging(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:         "localhost",
+		Port:         "9000",
+		DB:           "default",
+		User:         "default",
+		Password:     "secret",
+		Table:        "events",
+		MaxBatchSize: 100,
+		Staging:      true,
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StagingTable() != "events_staging" {
+		t.Errorf("StagingTable() = %q, expected %q", cfg.StagingTable(), "events_staging")
+	}
+
+	args.StagingTableSuffix = "_sample"
+	args.StagingDuration = *NewJSONDuration(time.Hour)
+	cfg, err = NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StagingTable() != "events_sample" || cfg.Staging.Duration.Duration() != time.Hour {
+		t.Errorf("Staging = %+v, expected events_sample for 1h", cfg.Staging)
+	}
+
+	args.ErrorTable = "events_sample"
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "error_table must differ from the staging table") {
+		t.Errorf("expected staging table conflict error, got %v", err)
+	}
+
+	args.ErrorTable = ""
+	args.Staging = false
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "require staging") {
+		t.Errorf("expected staging options without staging error, got %v", err)
+	}
+}
+
+func TestNewClickhouseSinkComponent_AsyncInsert(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:         "localhost",
+		Port:         "9000",
+		DB:           "default",
+		User:         "default",
+		Password:     "secret",
+		Table:        "events",
+		MaxBatchSize: 100,
+		AsyncInsert:  true,
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AsyncInsert == nil || !cfg.AsyncInsert.Wait {
+		t.Errorf("AsyncInsert = %+v, expected waiting async inserts by default", cfg.AsyncInsert)
+	}
+
+	noWait := false
+	args.WaitForAsyncInsert = &noWait
+	cfg, err = NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AsyncInsert == nil || cfg.AsyncInsert.Wait {
+		t.Errorf("AsyncInsert = %+v, expected async inserts without wait", cfg.AsyncInsert)
+	}
+
+	args.AsyncInsert = false
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "requires async_insert") {
+		t.Errorf("expected wait_for_async_insert without async_insert error, got %v", err)
+	}
+
+	args.WaitForAsyncInsert = nil
+	cfg, err = NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AsyncInsert != nil {
+		t.Errorf("AsyncInsert = %+v, expected nil when disabled", cfg.AsyncInsert)
+	}
+}
+
+func TestNewClickhouseSinkComponent_WriterConcurrency(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:              "localhost",
+		Port:              "9000",
+		DB:                "default",
+		User:              "default",
+		Password:          "secret",
+		Table:             "events",
+		MaxBatchSize:      100,
+		WriterConcurrency: 4,
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WriterConcurrency != 4 {
+		t.Errorf("WriterConcurrency = %d, expected 4", cfg.WriterConcurrency)
+	}
+
+	for _, writers := range []int{-1, internal.SinkMaxWriterConcurrency + 1} {
+		args.WriterConcurrency = writers
+		_, err = NewClickhouseSinkComponent(args)
+		if err == nil || !strings.Contains(err.Error(), "writer_concurrency") {
+			t.Errorf("expected writer_concurrency error for %d writers, got %v", writers, err)
+		}
+	}
+}
+
+func TestNewClickhouseSinkComponent_WarmUp(t *testing.T) {
+	args := ClickhouseSinkArgs{
+		Host:         "localhost",
+		Port:         "9000",
+		DB:           "default",
+		User:         "default",
+		Password:     "secret",
+		Table:        "events",
+		MaxBatchSize: 100,
+		WarmUp:       *NewJSONDuration(5 * time.Minute),
+	}
+
+	cfg, err := NewClickhouseSinkComponent(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Batch.WarmUp.Duration() != 5*time.Minute {
+		t.Errorf("WarmUp = %s, expected 5m0s", cfg.Batch.WarmUp)
+	}
+
+	args.WarmUp = *NewJSONDuration(-time.Second)
+	_, err = NewClickhouseSinkComponent(args)
+	if err == nil || !strings.Contains(err.Error(), "warm_up") {
+		t.Errorf("expected warm_up error, got %v", err)
+	}
+}
+
+func TestSinkComponentConfig_StagingTableQuery(t *testing.T) {
+	cfg := SinkComponentConfig{
+		ClickHouseConnectionParams: ClickHouseConnectionParamsConfig{Database: "analytics", Table: "events"},
+	}
+
+	_, err := cfg.StagingTableQuery()
+	if err == nil {
+		t.Fatal("expected error when staging is disabled")
+	}
+
+	cfg.Staging = &StagingConfig{TableSuffix: DefaultStagingTableSuffix}
+	query, err := cfg.StagingTableQuery()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "CREATE TABLE IF NOT EXISTS `analytics`.`events_staging` AS `analytics`.`events`"
+	if query != expected {
+		t.Errorf("StagingTableQuery() = %q, expected %q", query, expected)
+	}
+}
+
+func TestSinkComponentConfig_FillColumnTypes(t *testing.T) {
+	cfg := SinkComponentConfig{
+		ClickHouseConnectionParams: ClickHouseConnectionParamsConfig{Database: "analytics", Table: "events"},
+		Config: []Mapping{
+			{SourceField: "id", SourceType: "string", DestinationField: "id", DestinationType: "String"},
+			{SourceField: "ts", SourceType: "string", DestinationField: "created_at"},
+		},
+	}
+
+	if !cfg.MissingColumnTypes() {
+		t.Fatal("expected a missing column type")
+	}
+
+	err := cfg.FillColumnTypes(map[string]string{"id": "UUID", "created_at": "DateTime64(3, 'UTC')"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Config[0].DestinationType != "String" {
+		t.Errorf("explicit column type was overwritten with %q", cfg.Config[0].DestinationType)
+	}
+	if cfg.Config[1].DestinationType != "DateTime64(3, 'UTC')" {
+		t.Errorf("DestinationType = %q, expected %q", cfg.Config[1].DestinationType, "DateTime64(3, 'UTC')")
+	}
+	if cfg.MissingColumnTypes() {
+		t.Error("expected no missing column type")
+	}
+
+	cfg.Config = append(cfg.Config, Mapping{SourceField: "name", SourceType: "string", DestinationField: "name"})
+	err = cfg.FillColumnTypes(map[string]string{"id": "UUID"})
+	if err == nil || !strings.Contains(err.Error(), `column_type is required for column "name"`) {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+}
+
+func TestPipelineMetadata_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		urls    []string
+		wantErr bool
+	}{
+		{name: "https url", urls: []string{"https://hooks.example.com/glassflow"}},
+		{name: "http url with port", urls: []string{"http://localhost:9000/hook"}},
+		{name: "missing scheme", urls: []string{"hooks.example.com/glassflow"}, wantErr: true},
+		{name: "unsupported scheme", urls: []string{"ftp://hooks.example.com"}, wantErr: true},
+		{name: "empty url", urls: []string{""}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := PipelineMetadata{Notifications: &NotificationConfig{WebhookURLs: tt.urls}}
+			err := metadata.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (PipelineMetadata{Tags: []string{"prod"}}).Validate(); err != nil {
+		t.Errorf("unexpected error without notifications: %v", err)
+	}
+}
+
+func TestDeduplicationConfig_Normalize(t *testing.T) {
+	cfg, err := DeduplicationConfig{Enabled: true, ID: "id"}.normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != internal.DedupModeKeepFirst || cfg.VersionField != "" {
+		t.Fatalf("expected keep_first without a version field, got %q and %q", cfg.Mode, cfg.VersionField)
+	}
+
+	cfg, err = DeduplicationConfig{Enabled: true, ID: "id", Mode: "KEEP_LAST"}.normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.KeepsLast() || cfg.VersionField != internal.DefaultDedupVersionField {
+		t.Fatalf("expected keep_last with the default version field, got %q and %q", cfg.Mode, cfg.VersionField)
+	}
+
+	topic := KafkaTopicsConfig{Deduplication: cfg}
+	topic.Deduplication.Window = *NewJSONDuration(time.Hour)
+	if got := topic.StreamDuplicateWindow(0); got != 0 {
+		t.Fatalf("expected no stream duplicate window with keep_last, got %s", got)
+	}
+
+	_, err = DeduplicationConfig{Enabled: true, ID: "id", Mode: "keep_any"}.normalize()
+	if err == nil {
+		t.Fatal("expected an invalid mode to fail")
+	}
+}
+
+func TestDeduplicationConfig_NormalizeDomain(t *testing.T) {
+	window := *NewJSONDuration(time.Hour)
+	cfg, err := DeduplicationConfig{Enabled: true, ID: "id", Window: window, Domain: " orders-eu "}.normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Domain != "orders-eu" {
+		t.Fatalf("expected the domain to be trimmed, got %q", cfg.Domain)
+	}
+
+	invalid := []DeduplicationConfig{
+		{Enabled: true, ID: "id", Window: window, Domain: "orders.eu"},
+		{Enabled: true, ID: "id", Window: window, Domain: "orders", Mode: "keep_last"},
+		{Enabled: true, ID: "id", Domain: "orders"},
+	}
+	for _, c := range invalid {
+		if _, err := c.normalize(); err == nil {
+			t.Errorf("expected %+v to fail", c)
+		}
+	}
+
+	pipeline := PipelineConfig{Ingestor: IngestorComponentConfig{KafkaTopics: []KafkaTopicsConfig{
+		{Name: "orders", Deduplication: cfg},
+		{Name: "refunds", Deduplication: DeduplicationConfig{Enabled: true, ID: "id", Window: window}},
+	}}}
+	domains := pipeline.DedupDomains()
+	if len(domains) != 1 || domains["orders-eu"] != time.Hour {
+		t.Fatalf("unexpected dedup domains %v", domains)
+	}
+	if got := GetDedupDomainBucketName("orders-eu"); got != "gfm-dedup-domain-orders-eu" {
+		t.Fatalf("unexpected bucket name %q", got)
+	}
 }
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ConnectionHealthStatus string
+
+const (
+	ConnectionHealthy  ConnectionHealthStatus = "healthy"
+	ConnectionDegraded ConnectionHealthStatus = "degraded"
+	ConnectionDown     ConnectionHealthStatus = "down"
+)
+
+// Connection types with a health check
+const (
+	ConnectionTypeKafka      = "kafka"
+	ConnectionTypeClickHouse = "clickhouse"
+)
+
+// StoredConnection is the connection of a pipeline source or sink, with its
+// credentials.
+type StoredConnection struct {
+	Type       string
+	PipelineID string
+	Kafka      KafkaConnectionParamsConfig
+	ClickHouse ClickHouseConnectionParamsConfig
+}
+
+// ConnectionEndpoint is a ClickHouse server or Kafka cluster the pipelines
+// connect to. The connections of several pipelines with the same address
+// and user share an endpoint, whose health is independent of the
+// pipelines.
+type ConnectionEndpoint struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Address   string   `json:"address"`
+	Username  string   `json:"username,omitempty"`
+	Pipelines []string `json:"pipelines"`
+
+	Kafka      KafkaConnectionParamsConfig      `json:"-"`
+	ClickHouse ClickHouseConnectionParamsConfig `json:"-"`
+}
+
+// ConnectionHealth is the result of the last health check of an endpoint.
+// Since is when the endpoint entered its status.
+type ConnectionHealth struct {
+	ConnectionEndpoint
+	Status    ConnectionHealthStatus `json:"status"`
+	Since     time.Time              `json:"since"`
+	CheckedAt time.Time              `json:"checked_at"`
+	LatencyMs int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// ConnectionHealthCheck is a health check of an endpoint in its history.
+type ConnectionHealthCheck struct {
+	ConnectionID string                 `json:"connection_id"`
+	CheckedAt    time.Time              `json:"checked_at"`
+	Status       ConnectionHealthStatus `json:"status"`
+	LatencyMs    int64                  `json:"latency_ms"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// NewConnectionHealthCheck returns the status of a check that took latency
+// and failed with err: down when it failed, degraded when it was slower
+// than degradedLatency, zero never degrades.
+func NewConnectionHealthCheck(connectionID string, checkedAt time.Time, latency, degradedLatency time.Duration, err error) ConnectionHealthCheck {
+	check := ConnectionHealthCheck{
+		ConnectionID: connectionID,
+		CheckedAt:    checkedAt,
+		Status:       ConnectionHealthy,
+		LatencyMs:    latency.Milliseconds(),
+	}
+	switch {
+	case err != nil:
+		check.Status = ConnectionDown
+		check.Error = err.Error()
+	case degradedLatency > 0 && latency > degradedLatency:
+		check.Status = ConnectionDegraded
+	}
+	return check
+}
+
+// GroupConnections returns the endpoints of the Kafka and ClickHouse
+// connections, sorted by type and address. Connections of other types are
+// left out.
+func GroupConnections(conns []StoredConnection) []ConnectionEndpoint {
+	byID := make(map[string]*ConnectionEndpoint)
+	for _, conn := range conns {
+		endpoint, ok := newConnectionEndpoint(conn)
+		if !ok {
+			continue
+		}
+		existing, ok := byID[endpoint.ID]
+		if !ok {
+			byID[endpoint.ID] = &endpoint
+			existing = &endpoint
+		}
+		if conn.PipelineID != "" && !slices.Contains(existing.Pipelines, conn.PipelineID) {
+			existing.Pipelines = append(existing.Pipelines, conn.PipelineID)
+		}
+	}
+
+	endpoints := make([]ConnectionEndpoint, 0, len(byID))
+	for _, endpoint := range byID {
+		slices.Sort(endpoint.Pipelines)
+		endpoints = append(endpoints, *endpoint)
+	}
+	slices.SortFunc(endpoints, func(a, b ConnectionEndpoint) int {
+		return strings.Compare(a.Type+" "+a.Address, b.Type+" "+b.Address)
+	})
+	return endpoints
+}
+
+func newConnectionEndpoint(conn StoredConnection) (ConnectionEndpoint, bool) {
+	endpoint := ConnectionEndpoint{
+		Type:      conn.Type,
+		Pipelines: []string{},
+	}
+	switch conn.Type {
+	case ConnectionTypeKafka:
+		if len(conn.Kafka.Brokers) == 0 {
+			return ConnectionEndpoint{}, false
+		}
+		brokers := slices.Clone(conn.Kafka.Brokers)
+		slices.Sort(brokers)
+		endpoint.Address = strings.Join(brokers, ",")
+		endpoint.Username = conn.Kafka.SASLUsername
+		endpoint.Kafka = conn.Kafka
+	case ConnectionTypeClickHouse:
+		if conn.ClickHouse.Host == "" {
+			return ConnectionEndpoint{}, false
+		}
+		endpoint.Address = net.JoinHostPort(conn.ClickHouse.Host, conn.ClickHouse.Port)
+		endpoint.Username = conn.ClickHouse.Username
+		endpoint.ClickHouse = conn.ClickHouse
+	default:
+		return ConnectionEndpoint{}, false
+	}
+
+	sum := sha256.Sum256([]byte(endpoint.Type + "|" + endpoint.Address + "|" + endpoint.Username))
+	endpoint.ID = hex.EncodeToString(sum[:])[:16]
+	return endpoint, true
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupConnections(t *testing.T) {
+	clickhouse := ClickHouseConnectionParamsConfig{Host: "ch.prod-eu", Port: "9000", Username: "etl", Password: "secret"}
+	conns := []StoredConnection{
+		{Type: ConnectionTypeClickHouse, PipelineID: "orders", ClickHouse: clickhouse},
+		{Type: ConnectionTypeClickHouse, PipelineID: "payments", ClickHouse: clickhouse},
+		{Type: ConnectionTypeKafka, PipelineID: "orders", Kafka: KafkaConnectionParamsConfig{Brokers: []string{"b2:9092", "b1:9092"}}},
+		// The same brokers in another order are the same cluster
+		{Type: ConnectionTypeKafka, PipelineID: "payments", Kafka: KafkaConnectionParamsConfig{Brokers: []string{"b1:9092", "b2:9092"}}},
+		// Another user is another endpoint
+		{Type: ConnectionTypeClickHouse, PipelineID: "audit", ClickHouse: ClickHouseConnectionParamsConfig{Host: "ch.prod-eu", Port: "9000", Username: "auditor"}},
+		{Type: "pulsar", PipelineID: "events"},
+	}
+
+	endpoints := GroupConnections(conns)
+	require.Len(t, endpoints, 3)
+
+	assert.Equal(t, ConnectionTypeClickHouse, endpoints[0].Type)
+	assert.Equal(t, "ch.prod-eu:9000", endpoints[0].Address)
+	assert.Equal(t, "ch.prod-eu:9000", endpoints[1].Address)
+	assert.NotEqual(t, endpoints[0].ID, endpoints[1].ID)
+
+	var shared ConnectionEndpoint
+	for _, e := range endpoints[:2] {
+		if e.Username == "etl" {
+			shared = e
+		}
+	}
+	assert.Equal(t, []string{"orders", "payments"}, shared.Pipelines)
+	assert.Equal(t, "secret", shared.ClickHouse.Password)
+
+	assert.Equal(t, ConnectionTypeKafka, endpoints[2].Type)
+	assert.Equal(t, "b1:9092,b2:9092", endpoints[2].Address)
+	assert.Equal(t, []string{"orders", "payments"}, endpoints[2].Pipelines)
+
+	// IDs are stable across restarts
+	assert.Equal(t, endpoints[2].ID, GroupConnections(conns[2:3])[0].ID)
+}
+
+func TestNewConnectionHealthCheck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 32, 0, 0, time.UTC)
+
+	check := NewConnectionHealthCheck("c1", now, 20*time.Millisecond, time.Second, nil)
+	assert.Equal(t, ConnectionHealthy, check.Status)
+	assert.Equal(t, int64(20), check.LatencyMs)
+
+	check = NewConnectionHealthCheck("c1", now, 3*time.Second, time.Second, nil)
+	assert.Equal(t, ConnectionDegraded, check.Status)
+
+	check = NewConnectionHealthCheck("c1", now, 3*time.Second, 0, nil)
+	assert.Equal(t, ConnectionHealthy, check.Status)
+
+	check = NewConnectionHealthCheck("c1", now, 5*time.Second, time.Second, errors.New("connection refused"))
+	assert.Equal(t, ConnectionDown, check.Status)
+	assert.Equal(t, "connection refused", check.Error)
+}
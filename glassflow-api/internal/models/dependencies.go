@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Bulk operation outcomes of a pipeline
+const (
+	BulkPipelineDone    = "done"
+	BulkPipelineFailed  = "failed"
+	BulkPipelineSkipped = "skipped"
+)
+
+// BulkPipelineResult is the outcome of a bulk operation for one pipeline.
+// Group is the start group the pipeline was handled in, the pipelines of a
+// group only depend on pipelines of earlier groups.
+type BulkPipelineResult struct {
+	PipelineID string `json:"pipeline_id"`
+	Group      int    `json:"group"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+func validateDependsOn(dependsOn []string) error {
+	seen := make(map[string]struct{}, len(dependsOn))
+	for _, id := range dependsOn {
+		if strings.TrimSpace(id) == "" {
+			return PipelineConfigError{Msg: "depends_on cannot contain empty pipeline ids"}
+		}
+		if _, ok := seen[id]; ok {
+			return PipelineConfigError{Msg: fmt.Sprintf("depends_on lists pipeline %s more than once", id)}
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
+
+// StartGroups orders the pipelines of deps, which maps a pipeline to the
+// pipelines it depends on, so that every pipeline comes after its
+// dependencies. Each group holds the pipelines whose dependencies are all in
+// earlier groups, sorted by id. Dependencies that are not keys of deps are
+// ignored. A dependency cycle is an error.
+func StartGroups(deps map[string][]string) ([][]string, error) {
+	remaining := make(map[string]int, len(deps))
+	dependents := make(map[string][]string, len(deps))
+	for id, dependsOn := range deps {
+		remaining[id] = 0
+		for _, dep := range dependsOn {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			remaining[id]++
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	var groups [][]string
+	for len(remaining) > 0 {
+		var group []string
+		for id, n := range remaining {
+			if n == 0 {
+				group = append(group, id)
+			}
+		}
+		if len(group) == 0 {
+			cycle := make([]string, 0, len(remaining))
+			for id := range remaining {
+				cycle = append(cycle, id)
+			}
+			slices.Sort(cycle)
+			return nil, PipelineConfigError{Msg: fmt.Sprintf("pipeline dependencies form a cycle between %s", strings.Join(cycle, ", "))}
+		}
+
+		slices.Sort(group)
+		for _, id := range group {
+			delete(remaining, id)
+			for _, dependent := range dependents[id] {
+				remaining[dependent]--
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
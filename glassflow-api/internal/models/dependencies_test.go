@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartGroups(t *testing.T) {
+	groups, err := StartGroups(map[string][]string{
+		"facts":   {"dims", "users"},
+		"dims":    nil,
+		"users":   {"external"},
+		"reports": {"facts"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"dims", "users"}, {"facts"}, {"reports"}}, groups)
+
+	_, err = StartGroups(map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "d": nil})
+	require.ErrorContains(t, err, "cycle between a, b, c")
+
+	_, err = StartGroups(map[string][]string{"a": {"a"}})
+	require.Error(t, err)
+}
+
+func TestPipelineMetadata_ValidateDependsOn(t *testing.T) {
+	require.NoError(t, PipelineMetadata{DependsOn: []string{"dims", "users"}}.Validate())
+	require.Error(t, PipelineMetadata{DependsOn: []string{"dims", "dims"}}.Validate())
+	require.Error(t, PipelineMetadata{DependsOn: []string{" "}}.Validate())
+}
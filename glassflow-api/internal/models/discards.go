@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ConsumerPosition is where a consumer of a pipeline stream stands in the
+// stream. FirstSeq and LastSeq are the first and last messages the stream
+// holds, AckFloor the sequence up to which the consumer acknowledged all
+// messages.
+type ConsumerPosition struct {
+	Component string
+	Stream    string
+	Consumer  string
+	FirstSeq  uint64
+	LastSeq   uint64
+	AckFloor  uint64
+}
+
+// StreamDiscards counts the messages a stream of the pipeline discarded
+// before its consumer processed them, because the stream reached its
+// maxBytes, maxMsgs or maxAge limit. The messages are lost for the pipeline.
+type StreamDiscards struct {
+	Component string    `json:"component"`
+	Stream    string    `json:"stream"`
+	Consumer  string    `json:"consumer"`
+	Messages  uint64    `json:"messages"`
+	LastAt    time.Time `json:"last_at"`
+}
@@ -21,19 +21,28 @@ func GetDLQStreamSubjectName(pipelineID string) string {
 }
 
 type DLQMessage struct {
-	Component       string  `json:"component"` // TODO: make it component kind enum
-	Error           string  `json:"error"`
-	OriginalMessage Payload `json:"original_message"`
+	Component       string    `json:"component"` // TODO: make it component kind enum
+	Error           string    `json:"error"`
+	Reason          string    `json:"reason,omitempty"`
+	Timestamp       time.Time `json:"timestamp,omitzero"`
+	OriginalMessage Payload   `json:"original_message"`
 }
 
 func NewDLQMessage(component, err string, data []byte) DLQMessage {
 	return DLQMessage{
 		Component:       component,
 		Error:           err,
+		Timestamp:       time.Now().UTC(),
 		OriginalMessage: NewOriginalMessage(data),
 	}
 }
 
+// WithReason sets the failure reason, one of the observability.DLQReason* values.
+func (m DLQMessage) WithReason(reason string) DLQMessage {
+	m.Reason = reason
+	return m
+}
+
 func (m DLQMessage) ToJSON() ([]byte, error) {
 	bytes, err := json.Marshal(m)
 	if err != nil {
@@ -58,6 +67,23 @@ type DLQBatchSize struct {
 
 var ErrDLQMaxBatchSize = fmt.Errorf("DLQ batch size cannot be greater than %d", internal.DLQMaxBatchSize)
 
+var ErrDLQMaxPageLimit = fmt.Errorf("DLQ page limit cannot be greater than %d", internal.DLQMaxBatchSize)
+
+// DLQEntry is a DLQ message with its DLQ stream sequence as offset.
+type DLQEntry struct {
+	Offset    uint64
+	Timestamp time.Time
+	Message   DLQMessage
+}
+
+// DLQPage is a page of DLQ messages read without consuming them.
+type DLQPage struct {
+	Entries []DLQEntry
+	Total   uint64
+	// NextOffset is the offset after the last delivered entry, zero on the last page
+	NextOffset uint64
+}
+
 func NewDLQBatchSize(n int) (zero DLQBatchSize, _ error) {
 	switch {
 	case n == 0:
@@ -76,4 +102,6 @@ type DLQState struct {
 	LastConsumedAt     *time.Time
 	TotalMessages      uint64
 	UnconsumedMessages uint64
+	// LastSequence keeps growing when messages are purged or expire
+	LastSequence uint64
 }
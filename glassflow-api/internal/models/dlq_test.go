@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -35,15 +36,28 @@ func TestNewDLQMessage(t *testing.T) {
 	require.Equal(t, "test-component", dlqMsg.Component)
 	require.Equal(t, "test error", dlqMsg.Error)
 	require.Equal(t, Payload(data), dlqMsg.OriginalMessage)
+	require.False(t, dlqMsg.Timestamp.IsZero())
+	require.Equal(t, "parse_error", dlqMsg.WithReason("parse_error").Reason)
 }
 
 func TestDLQMessageToJSON(t *testing.T) {
 	data := []byte("test message")
-	dlqMsg := NewDLQMessage("test-component", "test error", data)
+	dlqMsg := NewDLQMessage("test-component", "test error", data).WithReason("parse_error")
+	dlqMsg.Timestamp = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
 
 	jsonData, err := dlqMsg.ToJSON()
 	require.NoError(t, err)
 
-	expectedJSON := fmt.Sprintf(`{"component":"%s","error":"%s","original_message":"%s"}`, dlqMsg.Component, dlqMsg.Error, data)
+	expectedJSON := fmt.Sprintf(
+		`{"component":"%s","error":"%s","reason":"parse_error","timestamp":"2026-01-02T03:04:05Z","original_message":"%s"}`,
+		dlqMsg.Component, dlqMsg.Error, data,
+	)
 	require.JSONEq(t, expectedJSON, string(jsonData))
 }
+
+func TestDLQMessageToJSON_OmitsEmptyEnvelopeFields(t *testing.T) {
+	jsonData, err := DLQMessage{Component: "sink", Error: "boom", OriginalMessage: "data"}.ToJSON()
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{"component":"sink","error":"boom","original_message":"data"}`, string(jsonData))
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// EnrichmentConfig adds the columns of a ClickHouse dimension table to the
+// events, from the row whose KeyColumn equals the KeyField of the event. The
+// table is read with the connection of the sink. The refresh mode loads the
+// whole table into memory every RefreshInterval, the lookup mode queries the
+// keys missing from a cache holding rows for CacheTTL.
+type EnrichmentConfig struct {
+	Enabled         bool               `json:"enabled"`
+	SourceID        string             `json:"source_id,omitempty"`
+	Database        string             `json:"database,omitempty"`
+	Table           string             `json:"table"`
+	KeyField        string             `json:"key_field"`
+	KeyColumn       string             `json:"key_column"`
+	Columns         []EnrichmentColumn `json:"columns"`
+	Mode            string             `json:"mode"`
+	RefreshInterval JSONDuration       `json:"refresh_interval,omitzero"`
+	CacheTTL        JSONDuration       `json:"cache_ttl,omitzero"`
+	CacheMaxEntries int                `json:"cache_max_entries,omitempty"`
+}
+
+// EnrichmentColumn is a column of the dimension table added to the events as
+// the field OutputName, the column name when empty.
+type EnrichmentColumn struct {
+	Column     string `json:"column"`
+	OutputName string `json:"output_name,omitempty"`
+	OutputType string `json:"output_type"`
+}
+
+// Name returns the name of the event field of the column.
+func (c EnrichmentColumn) Name() string {
+	if c.OutputName == "" {
+		return c.Column
+	}
+	return c.OutputName
+}
+
+// NewEnrichmentConfig validates cfg and fills in the defaults of its mode.
+func NewEnrichmentConfig(cfg EnrichmentConfig) (zero EnrichmentConfig, _ error) {
+	if len(strings.TrimSpace(cfg.Table)) == 0 {
+		return zero, PipelineConfigError{Msg: "enrichment table cannot be empty"}
+	}
+	if len(strings.TrimSpace(cfg.KeyField)) == 0 {
+		return zero, PipelineConfigError{Msg: "enrichment key_field cannot be empty"}
+	}
+	if len(strings.TrimSpace(cfg.KeyColumn)) == 0 {
+		return zero, PipelineConfigError{Msg: "enrichment key_column cannot be empty"}
+	}
+	if len(cfg.Columns) == 0 {
+		return zero, PipelineConfigError{Msg: "enrichment must select at least one column"}
+	}
+
+	names := make(map[string]struct{}, len(cfg.Columns))
+	for _, c := range cfg.Columns {
+		if len(strings.TrimSpace(c.Column)) == 0 {
+			return zero, PipelineConfigError{Msg: "enrichment column cannot be empty"}
+		}
+		if _, ok := names[c.Name()]; ok {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("enrichment output field %s is selected more than once", c.Name())}
+		}
+		names[c.Name()] = struct{}{}
+
+		switch internal.NormalizeToBasicKafkaType(c.OutputType) {
+		case internal.KafkaTypeString, internal.KafkaTypeBool, internal.KafkaTypeInt, internal.KafkaTypeUint,
+			internal.KafkaTypeFloat, internal.KafkaTypeArray, internal.KafkaTypeMap:
+		default:
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("enrichment column %s has unsupported output_type %q", c.Column, c.OutputType)}
+		}
+	}
+
+	switch cfg.Mode {
+	case "", internal.EnrichmentModeRefresh:
+		cfg.Mode = internal.EnrichmentModeRefresh
+		if cfg.RefreshInterval.Duration() == 0 {
+			cfg.RefreshInterval = *NewJSONDuration(internal.DefaultEnrichmentRefreshInterval)
+		}
+		if cfg.RefreshInterval.Duration() < internal.MinEnrichmentRefreshInterval {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("enrichment refresh_interval must be at least %s", internal.MinEnrichmentRefreshInterval)}
+		}
+	case internal.EnrichmentModeLookup:
+		if cfg.CacheTTL.Duration() < 0 {
+			return zero, PipelineConfigError{Msg: "enrichment cache_ttl cannot be negative"}
+		}
+		if cfg.CacheTTL.Duration() == 0 {
+			cfg.CacheTTL = *NewJSONDuration(internal.DefaultEnrichmentCacheTTL)
+		}
+		if cfg.CacheMaxEntries < 0 {
+			return zero, PipelineConfigError{Msg: "enrichment cache_max_entries cannot be negative"}
+		}
+		if cfg.CacheMaxEntries == 0 {
+			cfg.CacheMaxEntries = internal.DefaultEnrichmentCacheMaxEntries
+		}
+	default:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("unsupported enrichment mode %q, must be %s or %s", cfg.Mode, internal.EnrichmentModeRefresh, internal.EnrichmentModeLookup)}
+	}
+
+	cfg.Enabled = true
+	return cfg, nil
+}
+
+// OutputFields returns the fields the enrichment adds to the events.
+func (c EnrichmentConfig) OutputFields() []Field {
+	fields := make([]Field, 0, len(c.Columns))
+	for _, col := range c.Columns {
+		fields = append(fields, Field{Name: col.Name(), Type: col.OutputType})
+	}
+	return fields
+}
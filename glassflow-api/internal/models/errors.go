@@ -13,7 +13,7 @@ var ErrSchemaNotFound = errors.New("schema not found")
 
 func IsSchemaNotFoundErr(err error) bool { return errors.Is(err, ErrSchemaNotFound) }
 
-var ErrUnexpectedSchemaFormat = errors.New("schema is not JSON format")
+var ErrUnexpectedSchemaFormat = errors.New("unsupported schema format")
 
 func IsErrUnexpectedSchemaFormat(err error) bool { return errors.Is(err, ErrUnexpectedSchemaFormat) }
 
@@ -83,9 +83,16 @@ var ErrSignalSent = errors.New("signal to stop component is sent")
 var ErrSchemaIDIsMissingInHeader = errors.New("schema id is missing in header")
 var ErrValidateSchema = errors.New("failed to validate data")
 var ErrDeduplicateData = errors.New("failed to deduplicate data")
+var ErrDecodePayload = errors.New("failed to decode payload")
 
 // ErrReceiverOverloaded is returned when the OTLP receiver has reached its concurrency limit.
 var ErrReceiverOverloaded = errors.New("receiver overloaded, try again later")
 
 // ErrStreamBackpressure is returned when the NATS stream is full and all publish retries are exhausted.
 var ErrStreamBackpressure = errors.New("stream back-pressure, try again later")
+
+// ErrNotHTTPSource is returned when events are pushed to a pipeline without an HTTP source.
+var ErrNotHTTPSource = errors.New("pipeline does not have an http source")
+
+// ErrInvalidEventPayload is returned when pushed events are not JSON objects.
+var ErrInvalidEventPayload = errors.New("invalid event payload")
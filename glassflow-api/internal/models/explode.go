@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// ExplodeConfig emits one event per element of the array Field of the
+// events, each a copy of the event with the element in place of the array
+// under OutputName, the name of the array when empty. Fields declares the
+// fields of object elements and ElementType the type of the other ones.
+// IndexField adds the position of the element when set. Events without
+// elements are dropped.
+type ExplodeConfig struct {
+	Enabled     bool    `json:"enabled"`
+	SourceID    string  `json:"source_id,omitempty"`
+	Field       string  `json:"field"`
+	OutputName  string  `json:"output_name,omitempty"`
+	Fields      []Field `json:"fields,omitempty"`
+	ElementType string  `json:"element_type,omitempty"`
+	IndexField  string  `json:"index_field,omitempty"`
+}
+
+// NewExplodeConfig validates cfg and fills in its output name.
+func NewExplodeConfig(cfg ExplodeConfig) (zero ExplodeConfig, _ error) {
+	if len(strings.TrimSpace(cfg.Field)) == 0 {
+		return zero, PipelineConfigError{Msg: "explode field cannot be empty"}
+	}
+	if len(strings.TrimSpace(cfg.OutputName)) == 0 {
+		cfg.OutputName = cfg.Field
+	}
+
+	switch {
+	case len(cfg.Fields) > 0 && cfg.ElementType != "":
+		return zero, PipelineConfigError{Msg: "explode accepts either fields or element_type"}
+	case len(cfg.Fields) == 0 && cfg.ElementType == "":
+		return zero, PipelineConfigError{Msg: "explode must declare the fields or the element_type of the elements"}
+	}
+
+	names := make(map[string]struct{}, len(cfg.Fields))
+	for _, f := range cfg.Fields {
+		if len(strings.TrimSpace(f.Name)) == 0 {
+			return zero, PipelineConfigError{Msg: "explode field name cannot be empty"}
+		}
+		if _, ok := names[f.Name]; ok {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("explode field %s is declared more than once", f.Name)}
+		}
+		names[f.Name] = struct{}{}
+		if !isExplodeType(f.Type) {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("explode field %s has unsupported type %q", f.Name, f.Type)}
+		}
+	}
+	if cfg.ElementType != "" && !isExplodeType(cfg.ElementType) {
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("explode element_type %q is not supported", cfg.ElementType)}
+	}
+
+	if cfg.IndexField == cfg.OutputName {
+		return zero, PipelineConfigError{Msg: "explode index_field must differ from output_name"}
+	}
+
+	cfg.Enabled = true
+	return cfg, nil
+}
+
+func isExplodeType(t string) bool {
+	switch internal.NormalizeToBasicKafkaType(t) {
+	case internal.KafkaTypeString, internal.KafkaTypeBool, internal.KafkaTypeInt, internal.KafkaTypeUint,
+		internal.KafkaTypeFloat, internal.KafkaTypeArray, internal.KafkaTypeMap:
+		return true
+	default:
+		return false
+	}
+}
+
+// OutputFields returns the fields the explode adds to the events, the fields
+// of object elements nested under the output name.
+func (c ExplodeConfig) OutputFields() []Field {
+	fields := make([]Field, 0, len(c.Fields)+1)
+	if c.ElementType != "" {
+		fields = append(fields, Field{Name: c.OutputName, Type: c.ElementType})
+	}
+	for _, f := range c.Fields {
+		fields = append(fields, Field{Name: c.OutputName + "." + f.Name, Type: f.Type})
+	}
+	if c.IndexField != "" {
+		fields = append(fields, Field{Name: c.IndexField, Type: internal.KafkaTypeUint})
+	}
+	return fields
+}
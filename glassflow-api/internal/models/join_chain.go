@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// JoinChainSourceConfig joins one more source to the output of the join
+// stages before it. Its events match on JoinKey the events of LeftSourceID,
+// a source joined by an earlier stage, on LeftJoinKey.
+type JoinChainSourceConfig struct {
+	SourceID     string       `json:"source_id"`
+	JoinKey      string       `json:"join_key"`
+	Window       JSONDuration `json:"time_window"`
+	LeftSourceID string       `json:"left_source_id"`
+	LeftJoinKey  string       `json:"left_join_key"`
+}
+
+// JoinStageID returns the source ID of the intermediate output of a chained
+// join stage.
+func JoinStageID(joinID string, stage int) string {
+	return fmt.Sprintf("%s-%d", joinID, stage)
+}
+
+// JoinChainFieldName returns the name of a field of sourceID in the
+// intermediate output of a chained join. The source prefix keeps the fields
+// of the joined sources apart until the last stage names the output fields.
+func JoinChainFieldName(sourceID, field string) string {
+	return sourceID + "." + field
+}
+
+func validateJoinChain(
+	sources []JoinSourceConfig,
+	chain []JoinChainSourceConfig,
+	joinType string,
+	unmatched JoinUnmatchedConfig,
+	ordering JoinOrderingConfig,
+) error {
+	if len(chain) == 0 {
+		return nil
+	}
+
+	if len(sources)+len(chain) > internal.MaxSourcesSupportedWithJoinChain {
+		return PipelineConfigError{Msg: fmt.Sprintf("join can chain at most %d sources", internal.MaxSourcesSupportedWithJoinChain)}
+	}
+	if joinType != internal.JoinTypeInner {
+		return PipelineConfigError{Msg: fmt.Sprintf("join_type %s cannot be used with chained join sources", joinType)}
+	}
+	if unmatched.Enabled() {
+		return PipelineConfigError{Msg: "join unmatched policy cannot be used with chained join sources"}
+	}
+	if ordering.Enabled {
+		return PipelineConfigError{Msg: "join ordering cannot be used with chained join sources"}
+	}
+
+	joined := make(map[string]struct{}, len(sources)+len(chain))
+	for _, so := range sources {
+		joined[so.SourceID] = struct{}{}
+	}
+
+	for _, cs := range chain {
+		if len(strings.TrimSpace(cs.SourceID)) == 0 {
+			return PipelineConfigError{Msg: "join chain source cannot be empty"}
+		}
+		if _, ok := joined[cs.SourceID]; ok {
+			return PipelineConfigError{Msg: fmt.Sprintf("join source %s is joined more than once", cs.SourceID)}
+		}
+		if len(strings.TrimSpace(cs.JoinKey)) == 0 {
+			return PipelineConfigError{Msg: fmt.Sprintf("join key of chain source %s cannot be empty", cs.SourceID)}
+		}
+		if _, ok := joined[cs.LeftSourceID]; !ok {
+			return PipelineConfigError{Msg: fmt.Sprintf("chain source %s must join a source of an earlier stage, %q is not one", cs.SourceID, cs.LeftSourceID)}
+		}
+		if len(strings.TrimSpace(cs.LeftJoinKey)) == 0 {
+			return PipelineConfigError{Msg: fmt.Sprintf("left join key of chain source %s cannot be empty", cs.SourceID)}
+		}
+		joined[cs.SourceID] = struct{}{}
+	}
+
+	return nil
+}
+
+// Stages returns the two-source joins the join runs, the join itself unless
+// it has chained sources. A chained join has a stage for its sources and one
+// for every chained source, whose left input is the output of the stage
+// before it. Only the last stage has the ID and output fields of the join.
+func (c JoinComponentConfig) Stages() []JoinComponentConfig {
+	if len(c.Chain) == 0 {
+		return []JoinComponentConfig{c}
+	}
+
+	var left, right JoinSourceConfig
+	for _, source := range c.Sources {
+		switch source.Orientation {
+		case internal.JoinLeft:
+			left = source
+		case internal.JoinRight:
+			right = source
+		}
+	}
+
+	windows := map[string]JSONDuration{
+		left.SourceID:  left.Window,
+		right.SourceID: right.Window,
+	}
+	for _, cs := range c.Chain {
+		windows[cs.SourceID] = cs.Window
+	}
+
+	stages := make([]JoinComponentConfig, 0, len(c.Chain)+1)
+	joined := map[string]struct{}{left.SourceID: {}, right.SourceID: {}}
+	for i := 0; i <= len(c.Chain); i++ {
+		stage := c
+		stage.Chain = nil
+
+		if i > 0 {
+			cs := c.Chain[i-1]
+			left = JoinSourceConfig{
+				SourceID:    JoinStageID(c.ID, i),
+				JoinKey:     JoinChainFieldName(cs.LeftSourceID, cs.LeftJoinKey),
+				Window:      windows[cs.LeftSourceID],
+				Orientation: internal.JoinLeft,
+			}
+			right = JoinSourceConfig{
+				SourceID:    cs.SourceID,
+				JoinKey:     cs.JoinKey,
+				Window:      cs.Window,
+				Orientation: internal.JoinRight,
+			}
+			joined[cs.SourceID] = struct{}{}
+
+			stage.LeftBufferTTL = left.Window
+			stage.RightBufferTTL = right.Window
+		}
+		stage.Sources = []JoinSourceConfig{left, right}
+
+		if i < len(c.Chain) {
+			stage.ID = JoinStageID(c.ID, i+1)
+			stage.Config = c.chainStageRules(i, left, right, joined)
+		} else if i > 0 {
+			stage.Config = c.lastStageRules(left, right)
+		}
+
+		stages = append(stages, stage)
+	}
+
+	return stages
+}
+
+// chainStageRules returns the rules of the intermediate stage i, passing on
+// every output field and later left join key of the sources joined so far
+// under its prefixed name.
+func (c JoinComponentConfig) chainStageRules(i int, left, right JoinSourceConfig, joined map[string]struct{}) []JoinRule {
+	var rules []JoinRule
+	seen := make(map[string]struct{})
+	add := func(sourceID, field string) {
+		if _, ok := joined[sourceID]; !ok {
+			return
+		}
+		outputName := JoinChainFieldName(sourceID, field)
+		if _, ok := seen[outputName]; ok {
+			return
+		}
+		seen[outputName] = struct{}{}
+		rules = append(rules, stageRule(i, left, right, sourceID, field, outputName))
+	}
+
+	for _, r := range c.Config {
+		add(r.SourceID, r.SourceName)
+	}
+	for _, cs := range c.Chain[i:] {
+		add(cs.LeftSourceID, cs.LeftJoinKey)
+	}
+
+	return rules
+}
+
+// lastStageRules maps the prefixed fields of the left input and the fields of
+// the last chained source to the output fields of the join.
+func (c JoinComponentConfig) lastStageRules(left, right JoinSourceConfig) []JoinRule {
+	rules := make([]JoinRule, 0, len(c.Config))
+	for _, r := range c.Config {
+		outputName := r.OutputName
+		if outputName == "" {
+			outputName = r.SourceName
+		}
+		rules = append(rules, stageRule(len(c.Chain), left, right, r.SourceID, r.SourceName, outputName))
+	}
+	return rules
+}
+
+// stageRule returns the rule of stage i reading field of sourceID from the
+// input of the stage that carries it.
+func stageRule(i int, left, right JoinSourceConfig, sourceID, field, outputName string) JoinRule {
+	switch {
+	case sourceID == right.SourceID:
+		return JoinRule{SourceID: right.SourceID, SourceName: field, OutputName: outputName}
+	case i == 0:
+		return JoinRule{SourceID: left.SourceID, SourceName: field, OutputName: outputName}
+	default:
+		return JoinRule{SourceID: left.SourceID, SourceName: JoinChainFieldName(sourceID, field), OutputName: outputName}
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func chainedJoinConfig(t *testing.T, chain []JoinChainSourceConfig) (JoinComponentConfig, error) {
+	t.Helper()
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "customer_id", Window: *NewJSONDuration(time.Minute), Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "user_id", Window: *NewJSONDuration(time.Hour), Orientation: internal.JoinRight},
+	}
+	rules := []JoinRule{
+		{SourceID: "orders", SourceName: "order_id", OutputName: "ORDER_ID"},
+		{SourceID: "users", SourceName: "email"},
+		{SourceID: "payments", SourceName: "amount"},
+		{SourceID: "shipments", SourceName: "carrier"},
+	}
+	return NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, rules, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", "", JSONDuration{}, chain, JoinTieringConfig{})
+}
+
+func TestJoinComponentConfig_Stages(t *testing.T) {
+	cfg, err := chainedJoinConfig(t, []JoinChainSourceConfig{
+		{SourceID: "payments", JoinKey: "order_id", Window: *NewJSONDuration(2 * time.Minute), LeftSourceID: "orders", LeftJoinKey: "order_id"},
+		{SourceID: "shipments", JoinKey: "customer", Window: *NewJSONDuration(3 * time.Minute), LeftSourceID: "users", LeftJoinKey: "user_id"},
+	})
+	require.NoError(t, err)
+
+	stages := cfg.Stages()
+	require.Len(t, stages, 3)
+
+	assert.Equal(t, "p-join-1", stages[0].ID)
+	assert.Equal(t, cfg.Sources, stages[0].Sources)
+	assert.Equal(t, []JoinRule{
+		{SourceID: "orders", SourceName: "order_id", OutputName: "orders.order_id"},
+		{SourceID: "users", SourceName: "email", OutputName: "users.email"},
+		{SourceID: "users", SourceName: "user_id", OutputName: "users.user_id"},
+	}, stages[0].Config)
+
+	assert.Equal(t, "p-join-2", stages[1].ID)
+	assert.Equal(t, []JoinSourceConfig{
+		{SourceID: "p-join-1", JoinKey: "orders.order_id", Window: *NewJSONDuration(time.Minute), Orientation: internal.JoinLeft},
+		{SourceID: "payments", JoinKey: "order_id", Window: *NewJSONDuration(2 * time.Minute), Orientation: internal.JoinRight},
+	}, stages[1].Sources)
+	assert.Equal(t, 2*time.Minute, stages[1].RightBufferTTL.Duration())
+	assert.Equal(t, []JoinRule{
+		{SourceID: "p-join-1", SourceName: "orders.order_id", OutputName: "orders.order_id"},
+		{SourceID: "p-join-1", SourceName: "users.email", OutputName: "users.email"},
+		{SourceID: "payments", SourceName: "amount", OutputName: "payments.amount"},
+		{SourceID: "p-join-1", SourceName: "users.user_id", OutputName: "users.user_id"},
+	}, stages[1].Config)
+
+	// The last stage names the output fields of the join
+	assert.Equal(t, "p-join", stages[2].ID)
+	assert.Equal(t, "users.user_id", stages[2].Sources[0].JoinKey)
+	assert.Equal(t, time.Hour, stages[2].LeftBufferTTL.Duration())
+	assert.Equal(t, []JoinRule{
+		{SourceID: "p-join-2", SourceName: "orders.order_id", OutputName: "ORDER_ID"},
+		{SourceID: "p-join-2", SourceName: "users.email", OutputName: "email"},
+		{SourceID: "p-join-2", SourceName: "payments.amount", OutputName: "amount"},
+		{SourceID: "shipments", SourceName: "carrier", OutputName: "carrier"},
+	}, stages[2].Config)
+
+	for _, stage := range stages {
+		assert.Empty(t, stage.Chain)
+	}
+}
+
+func TestJoinComponentConfig_StagesWithoutChain(t *testing.T) {
+	cfg, err := chainedJoinConfig(t, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []JoinComponentConfig{cfg}, cfg.Stages())
+}
+
+func TestNewJoinComponentConfig_Chain(t *testing.T) {
+	window := *NewJSONDuration(time.Minute)
+	tests := []struct {
+		name    string
+		chain   []JoinChainSourceConfig
+		wantErr string
+	}{
+		{"source joined twice", []JoinChainSourceConfig{{SourceID: "users", JoinKey: "id", Window: window, LeftSourceID: "orders", LeftJoinKey: "id"}}, "joined more than once"},
+		{"empty join key", []JoinChainSourceConfig{{SourceID: "payments", Window: window, LeftSourceID: "orders", LeftJoinKey: "id"}}, "join key of chain source payments"},
+		{"left source of a later stage", []JoinChainSourceConfig{
+			{SourceID: "payments", JoinKey: "id", Window: window, LeftSourceID: "shipments", LeftJoinKey: "id"},
+			{SourceID: "shipments", JoinKey: "id", Window: window, LeftSourceID: "orders", LeftJoinKey: "id"},
+		}, "must join a source of an earlier stage"},
+		{"empty left join key", []JoinChainSourceConfig{{SourceID: "payments", JoinKey: "id", Window: window, LeftSourceID: "orders"}}, "left join key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := chainedJoinConfig(t, tt.chain)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	chain := []JoinChainSourceConfig{{SourceID: "payments", JoinKey: "id", Window: window, LeftSourceID: "orders", LeftJoinKey: "id"}}
+	sources := []JoinSourceConfig{
+		{SourceID: "orders", JoinKey: "id", Window: window, Orientation: internal.JoinLeft},
+		{SourceID: "users", JoinKey: "id", Window: window, Orientation: internal.JoinRight},
+	}
+	_, err := NewJoinComponentConfig(internal.TemporalJoinType, "p-join", sources, nil, JoinCacheConfig{}, JoinUnmatchedConfig{}, JoinOrderingConfig{}, "", internal.JoinTypeLeft, JSONDuration{}, chain, JoinTieringConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used with chained join sources")
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+type MySQLConnectionParamsConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	// ServerID identifies the binlog reader as a replica, it must be unique
+	// among the replicas of the server. Zero derives one from the source.
+	ServerID            uint32 `json:"server_id,omitempty"`
+	TLSRoot             string `json:"root_ca,omitempty"`
+	SkipTLSVerification bool   `json:"skip_tls_verification,omitempty"`
+}
+
+// UsesTLS reports whether the connection to the server is encrypted.
+func (c MySQLConnectionParamsConfig) UsesTLS() bool {
+	return c.TLSRoot != "" || c.SkipTLSVerification
+}
+
+// NewMySQLIngestorComponentConfig validates the MySQL connection and tables
+// of a pipeline source. The tables use the Kafka topic layout so the ingestor
+// publishes into the same NATS streams: the topic name is the qualified
+// `database.table` name and the initial offset the binlog start position.
+func NewMySQLIngestorComponentConfig(conn MySQLConnectionParamsConfig, topics []KafkaTopicsConfig) (zero IngestorComponentConfig, _ error) {
+	conn.Host = strings.TrimSpace(conn.Host)
+	if conn.Host == "" {
+		return zero, PipelineConfigError{Msg: "mysql host cannot be empty"}
+	}
+	if conn.Port == 0 {
+		conn.Port = internal.DefaultMySQLPort
+	}
+	if conn.Port < 0 || conn.Port > 65535 {
+		return zero, PipelineConfigError{Msg: "mysql port must be between 1 and 65535"}
+	}
+	if len(strings.TrimSpace(conn.Username)) == 0 {
+		return zero, PipelineConfigError{Msg: "mysql username cannot be empty"}
+	}
+
+	if len(topics) == 0 {
+		return zero, PipelineConfigError{Msg: "must have at least one mysql table"}
+	}
+
+	for i, t := range topics {
+		if _, _, err := SplitMySQLTable(t.Name); err != nil {
+			return zero, err
+		}
+
+		// Without a snapshot the binlog only holds recent changes, new
+		// sources follow the server from its current position by default
+		switch strings.ToLower(t.ConsumerGroupInitialOffset) {
+		case internal.InitialOffsetEarliest, internal.InitialOffsetLatest:
+			topics[i].ConsumerGroupInitialOffset = strings.ToLower(t.ConsumerGroupInitialOffset)
+		case "":
+			topics[i].ConsumerGroupInitialOffset = internal.InitialOffsetLatest
+		default:
+			return zero, PipelineConfigError{Msg: "invalid consumer_group_initial_offset; allowed values: `earliest` or `latest`"}
+		}
+
+		// The binlog is a single ordered stream, one reader follows it
+		if t.Replicas <= 0 {
+			topics[i].Replicas = 1
+		}
+		if topics[i].Replicas > 1 {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("mysql table %s: binlog sources support a single replica", t.Name)}
+		}
+
+		switch strings.ToLower(t.DecodeErrorPolicy) {
+		case "":
+			topics[i].DecodeErrorPolicy = internal.DecodeErrorPolicyDLQ
+		case internal.DecodeErrorPolicyDLQ, internal.DecodeErrorPolicyFail:
+			topics[i].DecodeErrorPolicy = strings.ToLower(t.DecodeErrorPolicy)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid decode_error_policy; allowed values: `dlq` or `fail`"}
+		}
+
+		publishDedup, err := t.PublishDedup.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].PublishDedup = publishDedup
+	}
+
+	return IngestorComponentConfig{
+		Type:                  internal.MySQLIngestorType,
+		MySQLConnectionParams: conn,
+		KafkaTopics:           topics,
+	}, nil
+}
+
+// SplitMySQLTable splits a qualified `database.table` name.
+func SplitMySQLTable(name string) (database, table string, _ error) {
+	database, table, ok := strings.Cut(strings.TrimSpace(name), ".")
+	if !ok || database == "" || table == "" || strings.Contains(table, ".") {
+		return "", "", PipelineConfigError{Msg: fmt.Sprintf("mysql table %q must be qualified as `database.table`", name)}
+	}
+	return database, table, nil
+}
+
+// GetMySQLCheckpointKey returns the key of a MySQL table's GTID checkpoint
+// in the MySQLCheckpointBucket.
+func GetMySQLCheckpointKey(pipelineID, tableName string) string {
+	return fmt.Sprintf("%s.%s", GenerateStreamHash(pipelineID), GenerateStreamHash(tableName))
+}
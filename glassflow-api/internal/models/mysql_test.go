@@ -0,0 +1,131 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func TestNewMySQLIngestorComponentConfig_Errors(t *testing.T) {
+	validConn := MySQLConnectionParamsConfig{Host: "mysql", Username: "repl"}
+	validTopics := func() []KafkaTopicsConfig {
+		return []KafkaTopicsConfig{{Name: "shop.orders"}}
+	}
+
+	tests := []struct {
+		name        string
+		conn        MySQLConnectionParamsConfig
+		topics      []KafkaTopicsConfig
+		description string
+	}{
+		{
+			name:        "empty host",
+			conn:        MySQLConnectionParamsConfig{Host: " ", Username: "repl"},
+			topics:      validTopics(),
+			description: "mysql host cannot be empty",
+		},
+		{
+			name:        "invalid port",
+			conn:        MySQLConnectionParamsConfig{Host: "mysql", Port: 70000, Username: "repl"},
+			topics:      validTopics(),
+			description: "mysql port must be between",
+		},
+		{
+			name:        "empty username",
+			conn:        MySQLConnectionParamsConfig{Host: "mysql"},
+			topics:      validTopics(),
+			description: "mysql username cannot be empty",
+		},
+		{
+			name:        "no tables",
+			conn:        validConn,
+			description: "must have at least one mysql table",
+		},
+		{
+			name:        "unqualified table",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "orders"}},
+			description: "must be qualified as `database.table`",
+		},
+		{
+			name:        "invalid initial position",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "shop.orders", ConsumerGroupInitialOffset: "newest"}},
+			description: "invalid consumer_group_initial_offset",
+		},
+		{
+			name:        "multiple replicas",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "shop.orders", Replicas: 2}},
+			description: "binlog sources support a single replica",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMySQLIngestorComponentConfig(tt.conn, tt.topics)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.description)
+			}
+			if !strings.Contains(err.Error(), tt.description) {
+				t.Fatalf("expected error containing %q, got %v", tt.description, err)
+			}
+		})
+	}
+}
+
+func TestNewMySQLIngestorComponentConfig_Defaults(t *testing.T) {
+	conn := MySQLConnectionParamsConfig{Host: " mysql ", Username: "repl", Password: "secret"}
+	topics := []KafkaTopicsConfig{
+		{Name: "shop.orders", ID: "orders"},
+		{Name: "shop.users", ID: "users", ConsumerGroupInitialOffset: "EARLIEST"},
+	}
+
+	cfg, err := NewMySQLIngestorComponentConfig(conn, topics)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Type != internal.MySQLIngestorType {
+		t.Errorf("Type = %q; want %q", cfg.Type, internal.MySQLIngestorType)
+	}
+	if cfg.MySQLConnectionParams.Host != "mysql" {
+		t.Errorf("Host = %q; want trimmed host", cfg.MySQLConnectionParams.Host)
+	}
+	if cfg.MySQLConnectionParams.Port != internal.DefaultMySQLPort {
+		t.Errorf("Port = %d; want %d", cfg.MySQLConnectionParams.Port, internal.DefaultMySQLPort)
+	}
+
+	orders := cfg.KafkaTopics[0]
+	if orders.ConsumerGroupInitialOffset != internal.InitialOffsetLatest {
+		t.Errorf("initial offset = %q; want %q", orders.ConsumerGroupInitialOffset, internal.InitialOffsetLatest)
+	}
+	if orders.Replicas != 1 {
+		t.Errorf("replicas = %d; want 1", orders.Replicas)
+	}
+	if orders.DecodeErrorPolicy != internal.DecodeErrorPolicyDLQ {
+		t.Errorf("decode error policy = %q; want %q", orders.DecodeErrorPolicy, internal.DecodeErrorPolicyDLQ)
+	}
+
+	users := cfg.KafkaTopics[1]
+	if users.ConsumerGroupInitialOffset != internal.InitialOffsetEarliest {
+		t.Errorf("initial offset = %q; want %q", users.ConsumerGroupInitialOffset, internal.InitialOffsetEarliest)
+	}
+}
+
+func TestSplitMySQLTable(t *testing.T) {
+	db, table, err := SplitMySQLTable("shop.orders")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db != "shop" || table != "orders" {
+		t.Errorf("SplitMySQLTable = %q, %q; want shop, orders", db, table)
+	}
+
+	for _, name := range []string{"", "orders", ".orders", "shop.", "a.b.c"} {
+		if _, _, err := SplitMySQLTable(name); err == nil {
+			t.Errorf("SplitMySQLTable(%q) expected error", name)
+		}
+	}
+}
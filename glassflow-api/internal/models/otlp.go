@@ -7,10 +7,11 @@ import (
 )
 
 type OTLPConfig struct {
-	PipelineID string        `json:"pipeline_id"`
-	SourceType SourceType    `json:"source_type"`
-	Routing    RoutingConfig `json:"routing"`
-	Status     string        `json:"status"`
+	PipelineID      string        `json:"pipeline_id"`
+	SourceType      SourceType    `json:"source_type"`
+	SchemaVersionID string        `json:"schema_version_id,omitempty"`
+	Routing         RoutingConfig `json:"routing"`
+	Status          string        `json:"status"`
 }
 
 type RoutingType string
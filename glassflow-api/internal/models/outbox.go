@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxAction is an orchestrator action on a pipeline, stored in the same
+// transaction as the pipeline change it follows so that it runs even when
+// the API stops before calling the orchestrator. Attempts counts the runs
+// claimed by the reconciler.
+type OutboxAction struct {
+	ID         uuid.UUID
+	PipelineID string
+	Action     string
+	Attempts   int
+	LastError  string
+	CreatedAt  time.Time
+}
+
+func NewOutboxAction(pipelineID, action string) OutboxAction {
+	return OutboxAction{
+		ID:         uuid.New(),
+		PipelineID: pipelineID,
+		Action:     action,
+	}
+}
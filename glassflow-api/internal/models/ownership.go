@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// OwnershipField is a field of the pipeline metadata naming who owns the
+// pipeline.
+type OwnershipField string
+
+const (
+	OwnershipOwner   OwnershipField = "owner"
+	OwnershipTeam    OwnershipField = "team"
+	OwnershipContact OwnershipField = "contact"
+)
+
+var ownershipFields = []OwnershipField{OwnershipOwner, OwnershipTeam, OwnershipContact}
+
+const maxOwnershipLength = 256
+
+// ParseOwnershipFields parses the ownership fields a policy requires.
+func ParseOwnershipFields(names []string) ([]OwnershipField, error) {
+	var fields []OwnershipField
+	for _, name := range names {
+		field := OwnershipField(strings.TrimSpace(name))
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(ownershipFields, field) {
+			return nil, fmt.Errorf("unknown ownership field %q, expected %s, %s or %s",
+				field, OwnershipOwner, OwnershipTeam, OwnershipContact)
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (m PipelineMetadata) ownership(field OwnershipField) string {
+	switch field {
+	case OwnershipOwner:
+		return m.Owner
+	case OwnershipTeam:
+		return m.Team
+	case OwnershipContact:
+		return m.Contact
+	default:
+		return ""
+	}
+}
+
+// MissingOwnership returns the required ownership fields the metadata does
+// not set.
+func (m PipelineMetadata) MissingOwnership(required []OwnershipField) []OwnershipField {
+	var missing []OwnershipField
+	for _, field := range required {
+		if strings.TrimSpace(m.ownership(field)) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+func validateOwnership(m PipelineMetadata) error {
+	for _, field := range ownershipFields {
+		value := m.ownership(field)
+		if len(value) > maxOwnershipLength {
+			return PipelineConfigError{Msg: fmt.Sprintf("pipeline %s must be at most %d characters", field, maxOwnershipLength)}
+		}
+		if strings.ContainsFunc(value, unicode.IsControl) {
+			return PipelineConfigError{Msg: fmt.Sprintf("pipeline %s must not contain control characters", field)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOwnershipFields(t *testing.T) {
+	fields, err := ParseOwnershipFields([]string{"owner", " contact", "owner", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []OwnershipField{OwnershipOwner, OwnershipContact}, fields)
+
+	fields, err = ParseOwnershipFields(nil)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = ParseOwnershipFields([]string{"manager"})
+	require.Error(t, err)
+}
+
+func TestPipelineMetadata_MissingOwnership(t *testing.T) {
+	m := PipelineMetadata{Owner: "jane", Team: "  "}
+	assert.Equal(t, []OwnershipField{OwnershipTeam, OwnershipContact},
+		m.MissingOwnership([]OwnershipField{OwnershipOwner, OwnershipTeam, OwnershipContact}))
+	assert.Empty(t, m.MissingOwnership(nil))
+}
+
+func TestPipelineMetadata_ValidateOwnership(t *testing.T) {
+	require.NoError(t, PipelineMetadata{Owner: "jane", Team: "payments", Contact: "payments-oncall@example.com"}.Validate())
+
+	var pErr PipelineConfigError
+	err := PipelineMetadata{Contact: strings.Repeat("a", maxOwnershipLength+1)}.Validate()
+	require.ErrorAs(t, err, &pErr)
+	assert.Contains(t, err.Error(), "contact")
+
+	err = PipelineMetadata{Owner: "jane\nadmin"}.Validate()
+	require.ErrorAs(t, err, &pErr)
+}
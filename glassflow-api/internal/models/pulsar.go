@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+type PulsarConnectionParamsConfig struct {
+	ServiceURL                 string `json:"service_url"`
+	AuthToken                  string `json:"auth_token,omitempty"`
+	TLSAllowInsecureConnection bool   `json:"tls_allow_insecure_connection,omitempty"`
+	TLSRoot                    string `json:"root_ca,omitempty"`
+	TLSCert                    string `json:"tls_cert,omitempty"`
+	TLSKey                     string `json:"tls_key,omitempty"`
+}
+
+// UsesTLS reports whether the service URL points to a TLS listener.
+func (c PulsarConnectionParamsConfig) UsesTLS() bool {
+	return strings.HasPrefix(c.ServiceURL, "pulsar+ssl://")
+}
+
+// NewPulsarIngestorComponentConfig validates the Pulsar connection and topics
+// of a pipeline source. The topics use the Kafka layout so the ingestor
+// publishes into the same NATS streams: the consumer group name is the
+// subscription name and the initial offset its initial position.
+func NewPulsarIngestorComponentConfig(conn PulsarConnectionParamsConfig, topics []KafkaTopicsConfig) (zero IngestorComponentConfig, _ error) {
+	serviceURL := strings.TrimSpace(conn.ServiceURL)
+	if !strings.HasPrefix(serviceURL, "pulsar://") && !strings.HasPrefix(serviceURL, "pulsar+ssl://") {
+		return zero, PipelineConfigError{Msg: "pulsar service_url must start with pulsar:// or pulsar+ssl://"}
+	}
+	conn.ServiceURL = serviceURL
+
+	if (len(strings.TrimSpace(conn.TLSCert)) == 0) != (len(strings.TrimSpace(conn.TLSKey)) == 0) {
+		return zero, PipelineConfigError{Msg: "pulsar client certificate and key must be set together"}
+	}
+	if !conn.UsesTLS() && (conn.TLSRoot != "" || conn.TLSCert != "") {
+		return zero, PipelineConfigError{Msg: "pulsar TLS certificates require a pulsar+ssl:// service_url"}
+	}
+	if conn.AuthToken != "" && conn.TLSCert != "" {
+		return zero, PipelineConfigError{Msg: "pulsar auth_token and client certificate authentication are mutually exclusive"}
+	}
+
+	if len(topics) == 0 {
+		return zero, PipelineConfigError{Msg: "must have at least one pulsar topic"}
+	}
+
+	for i, t := range topics {
+		if len(strings.TrimSpace(t.Name)) == 0 {
+			return zero, PipelineConfigError{Msg: "pulsar topic cannot be empty"}
+		}
+		if len(strings.TrimSpace(t.ConsumerGroupName)) == 0 {
+			return zero, PipelineConfigError{Msg: "pulsar subscription cannot be empty"}
+		}
+
+		switch strings.ToLower(t.ConsumerGroupInitialOffset) {
+		case internal.InitialOffsetEarliest, internal.InitialOffsetLatest:
+			topics[i].ConsumerGroupInitialOffset = strings.ToLower(t.ConsumerGroupInitialOffset)
+		case "":
+			topics[i].ConsumerGroupInitialOffset = internal.InitialOffsetEarliest
+		default:
+			return zero, PipelineConfigError{Msg: "invalid consumer_group_initial_offset; allowed values: `earliest` or `latest`"}
+		}
+
+		switch strings.ToLower(t.SubscriptionType) {
+		case "":
+			topics[i].SubscriptionType = internal.PulsarSubscriptionShared
+		case internal.PulsarSubscriptionShared, internal.PulsarSubscriptionKeyShared,
+			internal.PulsarSubscriptionFailover, internal.PulsarSubscriptionExclusive:
+			topics[i].SubscriptionType = strings.ToLower(t.SubscriptionType)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid subscription_type; allowed values: `shared`, `key_shared`, `failover` or `exclusive`"}
+		}
+
+		if t.Replicas <= 0 {
+			topics[i].Replicas = 1
+		}
+		// Only one consumer is attached to an exclusive subscription
+		if topics[i].SubscriptionType == internal.PulsarSubscriptionExclusive && topics[i].Replicas > 1 {
+			return zero, PipelineConfigError{Msg: fmt.Sprintf("pulsar topic %s: exclusive subscription supports a single replica", t.Name)}
+		}
+
+		switch strings.ToLower(t.DecodeErrorPolicy) {
+		case "":
+			topics[i].DecodeErrorPolicy = internal.DecodeErrorPolicyDLQ
+		case internal.DecodeErrorPolicyDLQ, internal.DecodeErrorPolicyFail:
+			topics[i].DecodeErrorPolicy = strings.ToLower(t.DecodeErrorPolicy)
+		default:
+			return zero, PipelineConfigError{Msg: "invalid decode_error_policy; allowed values: `dlq` or `fail`"}
+		}
+
+		publishDedup, err := t.PublishDedup.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].PublishDedup = publishDedup
+
+		debeziumUnwrap, err := t.DebeziumUnwrap.normalize()
+		if err != nil {
+			return zero, err
+		}
+		topics[i].DebeziumUnwrap = debeziumUnwrap
+	}
+
+	return IngestorComponentConfig{
+		Type:                   internal.PulsarIngestorType,
+		PulsarConnectionParams: conn,
+		KafkaTopics:            topics,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func TestNewPulsarIngestorComponentConfig_Errors(t *testing.T) {
+	validConn := PulsarConnectionParamsConfig{ServiceURL: "pulsar://pulsar:6650"}
+	validTopics := func() []KafkaTopicsConfig {
+		return []KafkaTopicsConfig{{Name: "persistent://public/default/orders", ConsumerGroupName: "sub"}}
+	}
+
+	tests := []struct {
+		name        string
+		conn        PulsarConnectionParamsConfig
+		topics      []KafkaTopicsConfig
+		description string
+	}{
+		{
+			name:        "invalid service url",
+			conn:        PulsarConnectionParamsConfig{ServiceURL: "http://pulsar:8080"},
+			topics:      validTopics(),
+			description: "pulsar service_url must start with",
+		},
+		{
+			name:        "client cert without key",
+			conn:        PulsarConnectionParamsConfig{ServiceURL: "pulsar+ssl://pulsar:6651", TLSCert: "cert"},
+			topics:      validTopics(),
+			description: "certificate and key must be set together",
+		},
+		{
+			name:        "certificates without tls",
+			conn:        PulsarConnectionParamsConfig{ServiceURL: "pulsar://pulsar:6650", TLSRoot: "ca"},
+			topics:      validTopics(),
+			description: "require a pulsar+ssl:// service_url",
+		},
+		{
+			name: "token and client certificate",
+			conn: PulsarConnectionParamsConfig{
+				ServiceURL: "pulsar+ssl://pulsar:6651",
+				AuthToken:  "token",
+				TLSCert:    "cert",
+				TLSKey:     "key",
+			},
+			topics:      validTopics(),
+			description: "mutually exclusive",
+		},
+		{
+			name:        "no topics",
+			conn:        validConn,
+			description: "must have at least one pulsar topic",
+		},
+		{
+			name:        "empty subscription",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "orders"}},
+			description: "pulsar subscription cannot be empty",
+		},
+		{
+			name:        "invalid initial position",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "sub", ConsumerGroupInitialOffset: "newest"}},
+			description: "invalid consumer_group_initial_offset",
+		},
+		{
+			name:        "invalid subscription type",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "sub", SubscriptionType: "broadcast"}},
+			description: "invalid subscription_type",
+		},
+		{
+			name:        "exclusive subscription with replicas",
+			conn:        validConn,
+			topics:      []KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "sub", SubscriptionType: "exclusive", Replicas: 2}},
+			description: "exclusive subscription supports a single replica",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPulsarIngestorComponentConfig(tt.conn, tt.topics)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.description)
+			}
+			if !strings.Contains(err.Error(), tt.description) {
+				t.Fatalf("expected error containing %q, got %v", tt.description, err)
+			}
+		})
+	}
+}
+
+func TestNewPulsarIngestorComponentConfig_Defaults(t *testing.T) {
+	conn := PulsarConnectionParamsConfig{
+		ServiceURL: " pulsar+ssl://pulsar:6651 ",
+		AuthToken:  "token",
+		TLSRoot:    "ca",
+	}
+	topics := []KafkaTopicsConfig{
+		{Name: "orders", ID: "orders", ConsumerGroupName: "sub"},
+		{Name: "users", ID: "users", ConsumerGroupName: "sub", ConsumerGroupInitialOffset: "LATEST", SubscriptionType: "Key_Shared", Replicas: 3},
+	}
+
+	cfg, err := NewPulsarIngestorComponentConfig(conn, topics)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Type != internal.PulsarIngestorType {
+		t.Errorf("Type = %q; want %q", cfg.Type, internal.PulsarIngestorType)
+	}
+	if cfg.PulsarConnectionParams.ServiceURL != "pulsar+ssl://pulsar:6651" {
+		t.Errorf("ServiceURL = %q; want trimmed url", cfg.PulsarConnectionParams.ServiceURL)
+	}
+
+	orders := cfg.KafkaTopics[0]
+	if orders.ConsumerGroupInitialOffset != internal.InitialOffsetEarliest {
+		t.Errorf("initial offset = %q; want %q", orders.ConsumerGroupInitialOffset, internal.InitialOffsetEarliest)
+	}
+	if orders.SubscriptionType != internal.PulsarSubscriptionShared {
+		t.Errorf("subscription type = %q; want %q", orders.SubscriptionType, internal.PulsarSubscriptionShared)
+	}
+	if orders.Replicas != 1 {
+		t.Errorf("replicas = %d; want 1", orders.Replicas)
+	}
+	if orders.DecodeErrorPolicy != internal.DecodeErrorPolicyDLQ {
+		t.Errorf("decode error policy = %q; want %q", orders.DecodeErrorPolicy, internal.DecodeErrorPolicyDLQ)
+	}
+
+	users := cfg.KafkaTopics[1]
+	if users.ConsumerGroupInitialOffset != internal.InitialOffsetLatest {
+		t.Errorf("initial offset = %q; want %q", users.ConsumerGroupInitialOffset, internal.InitialOffsetLatest)
+	}
+	if users.SubscriptionType != internal.PulsarSubscriptionKeyShared {
+		t.Errorf("subscription type = %q; want %q", users.SubscriptionType, internal.PulsarSubscriptionKeyShared)
+	}
+	if users.Replicas != 3 {
+		t.Errorf("replicas = %d; want 3", users.Replicas)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// ReconciliationSummary reports what the startup reconciliation of the
+// stored pipelines with the orchestrator changed, by pipeline ID.
+type ReconciliationSummary struct {
+	Checked   int
+	Restarted []string
+	Stopped   []string
+	Synced    []string
+	Orphaned  []string
+	Failed    []string
+}
@@ -103,7 +103,7 @@ func NewDefaultPipelineResources(cfg *PipelineConfig) PipelineResources {
 		},
 	}
 
-	if !cfg.SourceType.IsOTLP() {
+	if !cfg.SourceType.UsesReceiver() {
 		r.Ingestor = &IngestorResources{}
 		if cfg.Join.Enabled {
 			r.Ingestor.Left = newDefaultIngestorComponentResources(topicReplicas(cfg.Ingestor.KafkaTopics, 0))
@@ -372,7 +372,7 @@ func MergeWithDefaults(cfg *PipelineConfig, r PipelineResources, defaults Pipeli
 		r.Nats = defaults.Nats
 	}
 
-	if !cfg.SourceType.IsOTLP() {
+	if !cfg.SourceType.UsesReceiver() {
 		if r.Ingestor == nil {
 			r.Ingestor = &IngestorResources{}
 		}
@@ -404,7 +404,7 @@ func dedupEnabled(pc *PipelineConfig) bool {
 }
 
 func transformEnabled(pc *PipelineConfig) bool {
-	if pc.StatelessTransformation.Enabled || pc.Filter.Enabled {
+	if pc.StatelessTransformation.Enabled || pc.TransformFilterEnabled() || pc.Explode.Enabled || pc.Enrichment.Enabled {
 		return true
 	}
 	return dedupEnabled(pc)
@@ -0,0 +1,8 @@
+package models
+
+// ResumeOptions control how a pipeline is resumed. Force accepts the Kafka
+// cluster the brokers of the pipeline point at when it differs from the one
+// recorded at the pipeline creation, and records it in its place.
+type ResumeOptions struct {
+	Force bool
+}
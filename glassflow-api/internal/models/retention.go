@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreamMaxAge returns the time the NATS streams of the pipeline keep their
+// messages, zero when it is not set.
+func (p PipelineResources) StreamMaxAge() time.Duration {
+	if p.Nats == nil || p.Nats.Stream == nil || p.Nats.Stream.MaxAge == "" {
+		return 0
+	}
+	maxAge, err := time.ParseDuration(p.Nats.Stream.MaxAge)
+	if err != nil {
+		return 0
+	}
+	return maxAge
+}
+
+// ValidateStreamRetention checks that the windows of a pipeline fit in the
+// maxAge of its NATS streams, zero when they keep their messages. The dedup
+// windows are capped to the maxAge by StreamDuplicateWindow, a publish dedup
+// window longer than the maxAge is rejected like JetStream would reject the
+// stream. A join consumer that is recreated reads its input streams again,
+// so the buffers of the join, its windows with the grace period, cannot
+// outlive the events of the streams.
+func ValidateStreamRetention(cfg PipelineConfig, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	for _, t := range cfg.Ingestor.KafkaTopics {
+		window := t.PublishDedup.DuplicateWindow.Duration()
+		if window > maxAge {
+			return PipelineConfigError{Msg: fmt.Sprintf(
+				"publish_dedup duplicate_window %s of topic %s exceeds the nats stream maxAge %s", window, t.Name, maxAge,
+			)}
+		}
+	}
+
+	if !cfg.Join.Enabled {
+		return nil
+	}
+	for _, s := range cfg.Join.Sources {
+		if s.Window.Duration() > maxAge {
+			return PipelineConfigError{Msg: fmt.Sprintf(
+				"join time_window %s of source %s exceeds the nats stream maxAge %s", s.Window.Duration(), s.SourceID, maxAge,
+			)}
+		}
+	}
+	for _, c := range cfg.Join.Chain {
+		if c.Window.Duration() > maxAge {
+			return PipelineConfigError{Msg: fmt.Sprintf(
+				"join time_window %s of chain source %s exceeds the nats stream maxAge %s", c.Window.Duration(), c.SourceID, maxAge,
+			)}
+		}
+	}
+	if ttl := max(cfg.Join.LeftBufferTTL.Duration(), cfg.Join.RightBufferTTL.Duration()); ttl > maxAge {
+		return PipelineConfigError{Msg: fmt.Sprintf(
+			"join buffer ttl %s, the time_window with the grace period, exceeds the nats stream maxAge %s", ttl, maxAge,
+		)}
+	}
+
+	return nil
+}
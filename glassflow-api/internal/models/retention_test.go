@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamMaxAge(t *testing.T) {
+	r := PipelineResources{Nats: &NatsResources{Stream: &NatsStreamResources{MaxAge: "12h"}}}
+	if got := r.StreamMaxAge(); got != 12*time.Hour {
+		t.Errorf("StreamMaxAge() = %s, want 12h", got)
+	}
+	if got := (PipelineResources{}).StreamMaxAge(); got != 0 {
+		t.Errorf("StreamMaxAge() of unset resources = %s, want 0", got)
+	}
+}
+
+func TestValidateStreamRetention(t *testing.T) {
+	joinCfg := func(window, bufferTTL time.Duration) PipelineConfig {
+		return PipelineConfig{Join: JoinComponentConfig{
+			Enabled: true,
+			Sources: []JoinSourceConfig{
+				{SourceID: "orders", Window: *NewJSONDuration(window)},
+				{SourceID: "users", Window: *NewJSONDuration(window)},
+			},
+			LeftBufferTTL:  *NewJSONDuration(bufferTTL),
+			RightBufferTTL: *NewJSONDuration(window),
+		}}
+	}
+	publishCfg := PipelineConfig{Ingestor: IngestorComponentConfig{KafkaTopics: []KafkaTopicsConfig{{
+		Name:         "orders",
+		PublishDedup: PublishDedupConfig{DuplicateWindow: *NewJSONDuration(48 * time.Hour)},
+	}}}}
+
+	tests := []struct {
+		name    string
+		cfg     PipelineConfig
+		maxAge  time.Duration
+		wantErr string
+	}{
+		{name: "join within maxAge", cfg: joinCfg(time.Hour, 2*time.Hour), maxAge: 24 * time.Hour},
+		{name: "unlimited maxAge", cfg: joinCfg(48*time.Hour, 48*time.Hour), maxAge: 0},
+		{name: "join window", cfg: joinCfg(48*time.Hour, 48*time.Hour), maxAge: 24 * time.Hour, wantErr: "join time_window 48h0m0s of source orders"},
+		{name: "join grace", cfg: joinCfg(20*time.Hour, 30*time.Hour), maxAge: 24 * time.Hour, wantErr: "join buffer ttl 30h0m0s"},
+		{name: "publish dedup window", cfg: publishCfg, maxAge: 24 * time.Hour, wantErr: "duplicate_window 48h0m0s of topic orders"},
+		{name: "publish dedup within maxAge", cfg: publishCfg, maxAge: 72 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStreamRetention(tt.cfg, tt.maxAge)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStreamRetention() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateStreamRetention() error = %v, want %q", err, tt.wantErr)
+			}
+			var pErr PipelineConfigError
+			if !errors.As(err, &pErr) {
+				t.Errorf("ValidateStreamRetention() error %T is not a PipelineConfigError", err)
+			}
+		})
+	}
+}
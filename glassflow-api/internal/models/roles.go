@@ -6,7 +6,18 @@ type Role string
 
 func (r Role) Valid() bool {
 	switch r {
-	case internal.RoleSink, internal.RoleJoin, internal.RoleIngestor, internal.RoleETL, internal.RoleDeduplicator, internal.RoleOLTPReceiver, internal.RoleMigrateData:
+	case internal.RoleSink, internal.RoleJoin, internal.RoleIngestor, internal.RoleETL, internal.RoleDeduplicator, internal.RoleOLTPReceiver, internal.RoleHTTPIngest, internal.RoleMigrateData:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReadsSource reports whether the role reads events into the pipeline, the
+// ETL role running the components in-process in local mode included.
+func (r Role) ReadsSource() bool {
+	switch r {
+	case internal.RoleIngestor, internal.RoleOLTPReceiver, internal.RoleHTTPIngest, internal.RoleETL:
 		return true
 	default:
 		return false
@@ -27,6 +38,7 @@ func AllRoles() []string {
 		Role(internal.RoleSink).String(),
 		Role(internal.RoleDeduplicator).String(),
 		Role(internal.RoleOLTPReceiver).String(),
+		Role(internal.RoleHTTPIngest).String(),
 		Role(internal.RoleETL).String(),
 	}
 }
@@ -16,6 +16,7 @@ func TestRoleValid(t *testing.T) {
 		{"ingestor", true},
 		{"", true},      // RoleETL
 		{"dedup", true}, // RoleETL
+		{"http-ingest", true},
 		{"invalid", false},
 	}
 	for _, tt := range tests {
@@ -55,6 +56,7 @@ func TestAllRoles(t *testing.T) {
 		"sink",
 		"dedup",
 		"oltp-receiver",
+		"http-ingest",
 		"ETL Pipeline",
 	}
 	roles := AllRoles()
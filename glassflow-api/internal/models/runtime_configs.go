@@ -13,6 +13,9 @@ type IngestorRuntimeConfig struct {
 	TotalSubjectCount   int
 	DedupSubjectPrefix  string
 	DedupSubjectCount   int
+	// ReplicaIndex is the ordinal of this ingestor replica, used for static
+	// Kafka partition assignment.
+	ReplicaIndex int
 }
 
 func GetRequiredEnvVar(name string) (string, error) {
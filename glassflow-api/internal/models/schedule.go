@@ -0,0 +1,268 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleConfig starts and stops the pipeline on cron schedules, e.g. to
+// replicate in a nightly window. Start and Stop are standard five field cron
+// expressions (minute, hour, day of month, month, day of week) evaluated in
+// Timezone, UTC when empty. Either can be left empty to only start or only
+// stop the pipeline on a schedule.
+type ScheduleConfig struct {
+	Start    string `json:"start,omitempty"`
+	Stop     string `json:"stop,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ScheduleAction is the action a schedule runs on a pipeline.
+type ScheduleAction string
+
+const (
+	ScheduleActionStart ScheduleAction = "start"
+	ScheduleActionStop  ScheduleAction = "stop"
+)
+
+// ScheduleStatus reports when the schedule next starts and stops the
+// pipeline.
+type ScheduleStatus struct {
+	NextStart *time.Time `json:"next_start,omitempty"`
+	NextStop  *time.Time `json:"next_stop,omitempty"`
+	Timezone  string     `json:"timezone"`
+}
+
+func (c ScheduleConfig) Validate() error {
+	if c.Start == "" && c.Stop == "" {
+		return PipelineConfigError{Msg: "schedule must set start or stop"}
+	}
+	if _, err := c.location(); err != nil {
+		return err
+	}
+	if c.Start != "" {
+		if _, err := ParseCron(c.Start); err != nil {
+			return PipelineConfigError{Msg: fmt.Sprintf("invalid schedule start: %s", err)}
+		}
+	}
+	if c.Stop != "" {
+		if _, err := ParseCron(c.Stop); err != nil {
+			return PipelineConfigError{Msg: fmt.Sprintf("invalid schedule stop: %s", err)}
+		}
+	}
+	return nil
+}
+
+func (c ScheduleConfig) location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, PipelineConfigError{Msg: fmt.Sprintf("invalid schedule timezone %q", c.Timezone)}
+	}
+	return loc, nil
+}
+
+// Status returns the next start and stop after now.
+func (c ScheduleConfig) Status(now time.Time) (ScheduleStatus, error) {
+	loc, err := c.location()
+	if err != nil {
+		return ScheduleStatus{}, err
+	}
+
+	status := ScheduleStatus{Timezone: loc.String()}
+	next := func(expr string) (*time.Time, error) {
+		if expr == "" {
+			return nil, nil
+		}
+		cron, err := ParseCron(expr)
+		if err != nil {
+			return nil, err
+		}
+		t := cron.Next(now.In(loc))
+		if t.IsZero() {
+			return nil, nil
+		}
+		return &t, nil
+	}
+
+	if status.NextStart, err = next(c.Start); err != nil {
+		return ScheduleStatus{}, err
+	}
+	if status.NextStop, err = next(c.Stop); err != nil {
+		return ScheduleStatus{}, err
+	}
+	return status, nil
+}
+
+// Due returns the action the schedule fired last in (from, to], false when
+// it fired none. A window in which both fired ends with the later one.
+func (c ScheduleConfig) Due(from, to time.Time) (ScheduleAction, bool, error) {
+	loc, err := c.location()
+	if err != nil {
+		return "", false, err
+	}
+
+	var (
+		action ScheduleAction
+		last   time.Time
+	)
+	for _, s := range []struct {
+		expr   string
+		action ScheduleAction
+	}{{c.Start, ScheduleActionStart}, {c.Stop, ScheduleActionStop}} {
+		if s.expr == "" {
+			continue
+		}
+		cron, err := ParseCron(s.expr)
+		if err != nil {
+			return "", false, err
+		}
+		fired, ok := cron.lastIn(from.In(loc), to.In(loc))
+		if ok && fired.After(last) {
+			action, last = s.action, fired
+		}
+	}
+	return action, action != "", nil
+}
+
+// CronSchedule is a parsed five field cron expression.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// anyDay is set when the day of month or the day of week is a wildcard,
+	// otherwise a day matching either of them matches as in cron
+	anyDay bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses a five field cron expression. Fields accept *, values,
+// ranges (1-5), lists (1,15) and steps (*/15, 0-30/10). Day of week 0 and 7
+// are Sunday.
+func ParseCron(expr string) (CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return CronSchedule{}, fmt.Errorf("cron expression %q must have %d fields", expr, len(cronFields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+
+	dow := bits[4]
+	if dow&(1<<7) != 0 {
+		dow |= 1
+	}
+
+	return CronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    dow,
+		anyDay: strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepStr)
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepStr)
+			}
+			step = s
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			lo, err = strconv.Atoi(loStr)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s %q", f.name, part)
+			}
+			hi = lo
+			if isRange {
+				hi, err = strconv.Atoi(hiStr)
+				if err != nil {
+					return 0, fmt.Errorf("invalid %s %q", f.name, part)
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", f.name, part, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s CronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the schedule fires, in the location
+// of t. It returns the zero time when the schedule never fires, e.g. on
+// February 30th.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule that can fire does so within a leap year cycle
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// lastIn returns the last time the schedule fires in (from, to].
+func (s CronSchedule) lastIn(from, to time.Time) (time.Time, bool) {
+	var last time.Time
+	for next := s.Next(from); !next.IsZero() && !next.After(to); next = s.Next(next) {
+		last = next
+	}
+	return last, !last.IsZero()
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2026, time.March, 6, 10, 17, 30, 0, time.UTC) // a Friday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 6, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 6, 10, 30, 0, 0, time.UTC)},
+		{"0 1 * * *", time.Date(2026, time.March, 7, 1, 0, 0, 0, time.UTC)},
+		{"30 22 * * 1-5", time.Date(2026, time.March, 6, 22, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 6 1,15 * *", time.Date(2026, time.March, 15, 6, 0, 0, 0, time.UTC)},
+		// Day of month and day of week both restricted match either
+		{"0 0 20 * 1", time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, cron.Next(from), tt.expr)
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestScheduleConfig(t *testing.T) {
+	require.Error(t, ScheduleConfig{}.Validate())
+	require.Error(t, ScheduleConfig{Start: "0 1 * * *", Timezone: "Mars/Olympus"}.Validate())
+	require.Error(t, ScheduleConfig{Stop: "0 25 * * *"}.Validate())
+
+	schedule := ScheduleConfig{Start: "0 1 * * *", Stop: "0 5 * * *", Timezone: "Europe/Berlin"}
+	require.NoError(t, schedule.Validate())
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	now := time.Date(2026, time.March, 6, 2, 0, 0, 0, berlin)
+
+	status, err := schedule.Status(now)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", status.Timezone)
+	assert.True(t, status.NextStart.Equal(time.Date(2026, time.March, 7, 1, 0, 0, 0, berlin)))
+	assert.True(t, status.NextStop.Equal(time.Date(2026, time.March, 6, 5, 0, 0, 0, berlin)))
+
+	action, due, err := schedule.Due(now.Add(-2*time.Hour), now)
+	require.NoError(t, err)
+	require.True(t, due)
+	assert.Equal(t, ScheduleActionStart, action)
+
+	_, due, err = schedule.Due(now, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, due)
+
+	// The later action of a window wins
+	action, due, err = schedule.Due(now.Add(-2*time.Hour), now.Add(4*time.Hour))
+	require.NoError(t, err)
+	require.True(t, due)
+	assert.Equal(t, ScheduleActionStop, action)
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type SLAObjective string
+
+const (
+	SLAObjectiveLatency SLAObjective = "latency"
+	SLAObjectiveDLQRate SLAObjective = "dlq_rate"
+	SLAObjectiveLag     SLAObjective = "lag"
+)
+
+// SLAConfig holds the service level objectives of a pipeline. An objective
+// left at zero is not evaluated.
+type SLAConfig struct {
+	// MaxLatency is the longest a message may wait in the pipeline's streams
+	MaxLatency JSONDuration `json:"max_latency,omitzero"`
+	// MaxDLQRate is the number of messages per minute the DLQ may receive
+	MaxDLQRate float64 `json:"max_dlq_rate,omitempty"`
+	// MaxLag is the number of records the consumer group may lag behind the
+	// source topics, summed over their partitions
+	MaxLag int64 `json:"max_lag,omitempty"`
+}
+
+func (c SLAConfig) IsZero() bool {
+	return c.MaxLatency.Duration() == 0 && c.MaxDLQRate == 0 && c.MaxLag == 0
+}
+
+func (c SLAConfig) Validate() error {
+	if c.MaxLatency.Duration() < 0 {
+		return PipelineConfigError{Msg: "sla max_latency cannot be negative"}
+	}
+	if c.MaxDLQRate < 0 {
+		return PipelineConfigError{Msg: "sla max_dlq_rate cannot be negative"}
+	}
+	if c.MaxLag < 0 {
+		return PipelineConfigError{Msg: "sla max_lag cannot be negative"}
+	}
+	return nil
+}
+
+// SLAMeasurements are the values the SLA objectives are evaluated against,
+// nil when they could not be measured.
+type SLAMeasurements struct {
+	Latency *time.Duration
+	DLQRate *float64
+	Lag     *int64
+}
+
+// SLAStatus is the result of the last SLA evaluation of a pipeline.
+type SLAStatus struct {
+	Breached    bool        `json:"breached"`
+	Breaches    []SLABreach `json:"breaches,omitempty"`
+	EvaluatedAt time.Time   `json:"evaluated_at"`
+}
+
+// SLABreach is an objective the pipeline does not meet. Value and Threshold
+// are in the unit of the objective: seconds for the latency, messages per
+// minute for the DLQ rate and records for the lag.
+type SLABreach struct {
+	Objective SLAObjective `json:"objective"`
+	Value     float64      `json:"value"`
+	Threshold float64      `json:"threshold"`
+	Message   string       `json:"message"`
+}
+
+// Evaluate checks the measurements against the objectives. Objectives that
+// could not be measured are not breached.
+func (c SLAConfig) Evaluate(m SLAMeasurements, now time.Time) SLAStatus {
+	var breaches []SLABreach
+
+	if maxLatency := c.MaxLatency.Duration(); maxLatency > 0 && m.Latency != nil && *m.Latency > maxLatency {
+		breaches = append(breaches, SLABreach{
+			Objective: SLAObjectiveLatency,
+			Value:     m.Latency.Seconds(),
+			Threshold: maxLatency.Seconds(),
+			Message:   fmt.Sprintf("latency %s exceeds %s", m.Latency.Round(time.Second), maxLatency),
+		})
+	}
+
+	if c.MaxDLQRate > 0 && m.DLQRate != nil && *m.DLQRate > c.MaxDLQRate {
+		breaches = append(breaches, SLABreach{
+			Objective: SLAObjectiveDLQRate,
+			Value:     *m.DLQRate,
+			Threshold: c.MaxDLQRate,
+			Message:   fmt.Sprintf("dlq rate %.2f/min exceeds %.2f/min", *m.DLQRate, c.MaxDLQRate),
+		})
+	}
+
+	if c.MaxLag > 0 && m.Lag != nil && *m.Lag > c.MaxLag {
+		breaches = append(breaches, SLABreach{
+			Objective: SLAObjectiveLag,
+			Value:     float64(*m.Lag),
+			Threshold: float64(c.MaxLag),
+			Message:   fmt.Sprintf("lag of %d records exceeds %d", *m.Lag, c.MaxLag),
+		})
+	}
+
+	return SLAStatus{
+		Breached:    len(breaches) > 0,
+		Breaches:    breaches,
+		EvaluatedAt: now,
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLAConfig_Evaluate(t *testing.T) {
+	sla := SLAConfig{
+		MaxLatency: *NewJSONDuration(time.Minute),
+		MaxDLQRate: 10,
+		MaxLag:     1000,
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	latency := 2 * time.Minute
+	rate := 2.5
+	lag := int64(5000)
+
+	status := sla.Evaluate(SLAMeasurements{Latency: &latency, DLQRate: &rate, Lag: &lag}, now)
+	require.True(t, status.Breached)
+	require.Len(t, status.Breaches, 2)
+	assert.Equal(t, SLAObjectiveLatency, status.Breaches[0].Objective)
+	assert.InDelta(t, 120, status.Breaches[0].Value, 0)
+	assert.InDelta(t, 60, status.Breaches[0].Threshold, 0)
+	assert.Equal(t, SLAObjectiveLag, status.Breaches[1].Objective)
+	assert.Equal(t, now, status.EvaluatedAt)
+
+	// Objectives that could not be measured are not breached
+	status = sla.Evaluate(SLAMeasurements{}, now)
+	assert.False(t, status.Breached)
+	assert.Empty(t, status.Breaches)
+}
+
+func TestSLAConfig_Validate(t *testing.T) {
+	require.NoError(t, SLAConfig{}.Validate())
+	require.Error(t, SLAConfig{MaxLatency: *NewJSONDuration(-time.Second)}.Validate())
+	require.Error(t, SLAConfig{MaxDLQRate: -1}.Validate())
+	require.Error(t, (PipelineMetadata{SLA: &SLAConfig{MaxLag: -1}}).Validate())
+}
+
+func TestSLAConfig_JSON(t *testing.T) {
+	var metadata PipelineMetadata
+	require.NoError(t, json.Unmarshal([]byte(`{"sla":{"max_latency":"5m","max_dlq_rate":0.5,"max_lag":100}}`), &metadata))
+	require.NotNil(t, metadata.SLA)
+	assert.Equal(t, 5*time.Minute, metadata.SLA.MaxLatency.Duration())
+	assert.InDelta(t, 0.5, metadata.SLA.MaxDLQRate, 0)
+	assert.Equal(t, int64(100), metadata.SLA.MaxLag)
+	assert.False(t, metadata.SLA.IsZero())
+}
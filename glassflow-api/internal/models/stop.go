@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// StopOptions control how a pipeline is stopped. A draining stop stops the
+// ingestors first and keeps the join and sink running until their streams
+// are empty, or until DrainTimeout passed, before the pipeline resources are
+// deleted.
+type StopOptions struct {
+	Drain        bool
+	DrainTimeout time.Duration
+}
+
+// NewStopOptions validates the options of a stop, a draining stop without a
+// timeout waits up to the default drain timeout.
+func NewStopOptions(drain bool, drainTimeout time.Duration) (StopOptions, error) {
+	if !drain {
+		if drainTimeout != 0 {
+			return StopOptions{}, PipelineConfigError{Msg: "drain_timeout requires drain"}
+		}
+		return StopOptions{}, nil
+	}
+
+	switch {
+	case drainTimeout == 0:
+		drainTimeout = internal.DefaultPipelineDrainTimeout
+	case drainTimeout < 0:
+		return StopOptions{}, PipelineConfigError{Msg: "drain_timeout must be positive"}
+	case drainTimeout > internal.MaxPipelineDrainTimeout:
+		return StopOptions{}, PipelineConfigError{Msg: fmt.Sprintf("drain_timeout must not exceed %s", internal.MaxPipelineDrainTimeout)}
+	}
+
+	return StopOptions{Drain: true, DrainTimeout: drainTimeout}, nil
+}
+
+// WaitTimeout is how long the stop waits for the join and sink to empty
+// their streams.
+func (o StopOptions) WaitTimeout() time.Duration {
+	if o.Drain {
+		return o.DrainTimeout
+	}
+	return internal.PipelineStopWaitTimeout
+}
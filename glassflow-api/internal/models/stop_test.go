@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func TestNewStopOptions(t *testing.T) {
+	opts, err := NewStopOptions(false, 0)
+	require.NoError(t, err)
+	assert.Equal(t, StopOptions{}, opts)
+	assert.Equal(t, internal.PipelineStopWaitTimeout, opts.WaitTimeout())
+
+	opts, err = NewStopOptions(true, 0)
+	require.NoError(t, err)
+	assert.Equal(t, internal.DefaultPipelineDrainTimeout, opts.WaitTimeout())
+
+	opts, err = NewStopOptions(true, 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, StopOptions{Drain: true, DrainTimeout: 30 * time.Minute}, opts)
+
+	_, err = NewStopOptions(false, time.Minute)
+	assert.ErrorContains(t, err, "requires drain")
+
+	_, err = NewStopOptions(true, -time.Minute)
+	assert.ErrorContains(t, err, "must be positive")
+
+	_, err = NewStopOptions(true, 2*time.Hour)
+	assert.ErrorContains(t, err, "must not exceed 1h0m0s")
+}
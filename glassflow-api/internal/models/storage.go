@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreamStorage is the storage a NATS stream of a pipeline uses and the
+// limits of the stream, zero when unlimited. Utilization is the share of the
+// closest limit in use, the stream discards messages once it reaches 1.
+type StreamStorage struct {
+	Stream      string  `json:"stream"`
+	Bytes       uint64  `json:"bytes"`
+	MaxBytes    int64   `json:"max_bytes,omitempty"`
+	Messages    uint64  `json:"messages"`
+	MaxMessages int64   `json:"max_messages,omitempty"`
+	Utilization float64 `json:"utilization"`
+}
+
+func NewStreamStorage(stream string, bytes uint64, maxBytes int64, messages uint64, maxMessages int64) StreamStorage {
+	s := StreamStorage{
+		Stream:      stream,
+		Bytes:       bytes,
+		MaxBytes:    max(maxBytes, 0),
+		Messages:    messages,
+		MaxMessages: max(maxMessages, 0),
+	}
+	if s.MaxBytes > 0 {
+		s.Utilization = float64(bytes) / float64(s.MaxBytes)
+	}
+	if s.MaxMessages > 0 {
+		s.Utilization = max(s.Utilization, float64(messages)/float64(s.MaxMessages))
+	}
+	return s
+}
+
+// StorageStatus is the result of the last storage evaluation of a pipeline.
+// The pipeline is degraded while a stream is past the threshold.
+type StorageStatus struct {
+	Degraded    bool            `json:"degraded"`
+	Threshold   float64         `json:"threshold"`
+	Streams     []StreamStorage `json:"streams,omitempty"`
+	Alerts      []string        `json:"alerts,omitempty"`
+	EvaluatedAt time.Time       `json:"evaluated_at"`
+}
+
+// EvaluateStorage checks the utilization of the streams against threshold,
+// a share of their limits. Streams without limits are never past it.
+func EvaluateStorage(streams []StreamStorage, threshold float64, now time.Time) StorageStatus {
+	var alerts []string
+	for _, s := range streams {
+		if s.Utilization < threshold || (s.MaxBytes == 0 && s.MaxMessages == 0) {
+			continue
+		}
+		alerts = append(alerts, fmt.Sprintf("stream %s uses %.0f%% of its limit", s.Stream, s.Utilization*100))
+	}
+
+	return StorageStatus{
+		Degraded:    len(alerts) > 0,
+		Threshold:   threshold,
+		Streams:     streams,
+		Alerts:      alerts,
+		EvaluatedAt: now,
+	}
+}
+
+// ValidateStorageThreshold checks a storage threshold, a share of the stream
+// limits above zero and up to one.
+func ValidateStorageThreshold(threshold float64) error {
+	if threshold < 0 || threshold > 1 {
+		return PipelineConfigError{Msg: fmt.Sprintf("storage threshold %g must be between 0 and 1", threshold)}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStreamStorage_Utilization(t *testing.T) {
+	assert.InDelta(t, 0.5, NewStreamStorage("s", 500, 1000, 10, 0).Utilization, 0.001)
+	assert.InDelta(t, 0.9, NewStreamStorage("s", 500, 1000, 90, 100).Utilization, 0.001, "the closest limit counts")
+	assert.Zero(t, NewStreamStorage("s", 500, -1, 10, -1).Utilization, "negative limits are unlimited")
+}
+
+func TestEvaluateStorage(t *testing.T) {
+	now := time.Now()
+	streams := []StreamStorage{
+		NewStreamStorage("ingest", 850, 1000, 1, 0),
+		NewStreamStorage("sink", 100, 1000, 1, 0),
+		NewStreamStorage("unlimited", 1<<30, 0, 1, 0),
+	}
+
+	status := EvaluateStorage(streams, 0.8, now)
+	assert.True(t, status.Degraded)
+	assert.Equal(t, []string{"stream ingest uses 85% of its limit"}, status.Alerts)
+	assert.Equal(t, now, status.EvaluatedAt)
+
+	status = EvaluateStorage(streams, 0.9, now)
+	assert.False(t, status.Degraded)
+	assert.Empty(t, status.Alerts)
+}
+
+func TestPipelineMetadata_ValidateStorageThreshold(t *testing.T) {
+	m := PipelineMetadata{Notifications: &NotificationConfig{StorageThreshold: 0.75}}
+	require.NoError(t, m.Validate())
+
+	m.Notifications.StorageThreshold = 1.5
+	var cfgErr PipelineConfigError
+	require.ErrorAs(t, m.Validate(), &cfgErr)
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// tagPattern matches a tag such as prod or a key:value tag such as
+// team:payments. Keys and values are limited to 63 characters.
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}(:[A-Za-z0-9][A-Za-z0-9._/-]{0,62})?$`)
+
+// ValidateTag checks the format of a tag.
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return PipelineConfigError{Msg: fmt.Sprintf("invalid tag %q: tags are a name or a name:value pair of letters, digits, '.', '_' and '-', values may contain '/'", tag)}
+	}
+	return nil
+}
+
+func validateTags(tags []string) error {
+	if len(tags) > internal.MaxPipelineTags {
+		return PipelineConfigError{Msg: fmt.Sprintf("a pipeline can have at most %d tags, got %d", internal.MaxPipelineTags, len(tags))}
+	}
+	for i, tag := range tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+		if slices.Contains(tags[:i], tag) {
+			return PipelineConfigError{Msg: fmt.Sprintf("tag %q is set more than once", tag)}
+		}
+	}
+	return nil
+}
+
+// TagHealth aggregates the status of the pipelines carrying a tag. Failed
+// pipelines are unhealthy.
+type TagHealth struct {
+	Tag       string                 `json:"tag"`
+	Pipelines int                    `json:"pipelines"`
+	Unhealthy int                    `json:"unhealthy"`
+	Statuses  map[PipelineStatus]int `json:"statuses"`
+}
+
+// TagsHealth aggregates the pipelines by tag, sorted by tag. When tags is
+// not empty only those tags are reported, with zero pipelines when no
+// pipeline carries them.
+func TagsHealth(pipelines []PipelineConfig, tags []string) []TagHealth {
+	byTag := make(map[string]*TagHealth)
+	for _, tag := range tags {
+		byTag[tag] = &TagHealth{Tag: tag, Statuses: map[PipelineStatus]int{}}
+	}
+
+	for _, p := range pipelines {
+		status := p.Status.OverallStatus
+		if status == "" {
+			status = internal.PipelineStatusCreated
+		}
+		for _, tag := range p.Metadata.Tags {
+			h, ok := byTag[tag]
+			if !ok {
+				if len(tags) > 0 {
+					continue
+				}
+				h = &TagHealth{Tag: tag, Statuses: map[PipelineStatus]int{}}
+				byTag[tag] = h
+			}
+			h.Pipelines++
+			h.Statuses[status]++
+			if status == internal.PipelineStatusFailed {
+				h.Unhealthy++
+			}
+		}
+	}
+
+	health := make([]TagHealth, 0, len(byTag))
+	for _, h := range byTag {
+		health = append(health, *h)
+	}
+	slices.SortFunc(health, func(a, b TagHealth) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return health
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func TestValidateTags(t *testing.T) {
+	for _, tag := range []string{"prod", "team:payments", "Region:eu-west-1", "owner:data/platform", "v1.2_beta"} {
+		assert.NoError(t, ValidateTag(tag), tag)
+	}
+	for _, tag := range []string{"", "team payments", ":payments", "team:", "team:a:b", "-prod", "prod!"} {
+		assert.Error(t, ValidateTag(tag), tag)
+	}
+
+	require.Error(t, PipelineMetadata{Tags: []string{"prod", "prod"}}.Validate())
+
+	tags := make([]string, internal.MaxPipelineTags+1)
+	for i := range tags {
+		tags[i] = string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	require.Error(t, PipelineMetadata{Tags: tags}.Validate())
+	require.NoError(t, PipelineMetadata{Tags: tags[:internal.MaxPipelineTags]}.Validate())
+}
+
+func TestTagsHealth(t *testing.T) {
+	pipeline := func(status PipelineStatus, tags ...string) PipelineConfig {
+		return PipelineConfig{Status: PipelineHealth{OverallStatus: status}, Metadata: PipelineMetadata{Tags: tags}}
+	}
+	pipelines := []PipelineConfig{
+		pipeline(internal.PipelineStatusRunning, "team:payments", "prod"),
+		pipeline(internal.PipelineStatusFailed, "team:payments"),
+		pipeline("", "prod"),
+	}
+
+	assert.Equal(t, []TagHealth{
+		{Tag: "prod", Pipelines: 2, Statuses: map[PipelineStatus]int{internal.PipelineStatusRunning: 1, internal.PipelineStatusCreated: 1}},
+		{Tag: "team:payments", Pipelines: 2, Unhealthy: 1, Statuses: map[PipelineStatus]int{internal.PipelineStatusRunning: 1, internal.PipelineStatusFailed: 1}},
+	}, TagsHealth(pipelines, nil))
+
+	assert.Equal(t, []TagHealth{
+		{Tag: "team:orders", Statuses: map[PipelineStatus]int{}},
+		{Tag: "team:payments", Pipelines: 2, Unhealthy: 1, Statuses: map[PipelineStatus]int{internal.PipelineStatusRunning: 1, internal.PipelineStatusFailed: 1}},
+	}, TagsHealth(pipelines, []string{"team:payments", "team:orders"}))
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoCommittedPosition is returned when the consumer group has consumed
+// none of the partitions of the topic a pipeline is migrated from.
+var ErrNoCommittedPosition = errors.New("consumer group has no committed position on the topic")
+
+// TopicMigration is the result of migrating a pipeline from a Kafka topic to
+// a topic carrying the same records under a new name.
+type TopicMigration struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	ConsumerGroup string `json:"consumer_group"`
+	// PerPartition is set when the partitions of the topics map one to one,
+	// otherwise every partition resumes from the same timestamp
+	PerPartition bool                      `json:"per_partition"`
+	Partitions   []TopicMigrationPartition `json:"partitions"`
+	DryRun       bool                      `json:"dry_run"`
+}
+
+// TopicMigrationPartition is the offset committed on a partition of the topic
+// migrated to, the one of the first record at or after Timestamp.
+type TopicMigrationPartition struct {
+	Partition int32     `json:"partition"`
+	Timestamp time.Time `json:"timestamp"`
+	Offset    int64     `json:"offset"`
+}
+
+// TopicPartitionPosition is the position of the consumer group on a
+// partition of the topic migrated from, Resume is the timestamp of the next
+// record it reads. Resume is zero when the group has no committed offset on
+// the partition.
+type TopicPartitionPosition struct {
+	Partition int32
+	Resume    time.Time
+}
+
+// TopicMigrationTimestamps returns the timestamp every partition of the topic
+// migrated to resumes from. When both topics have the same number of
+// partitions, records are assumed to keep their partition and every partition
+// resumes from the position on its counterpart. Otherwise, and for the
+// partitions without a committed offset, they resume from the earliest
+// position, which replays records rather than skipping them.
+func TopicMigrationTimestamps(positions []TopicPartitionPosition, toPartitions int) (map[int32]time.Time, bool, error) {
+	var earliest time.Time
+	for _, pos := range positions {
+		if pos.Resume.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || pos.Resume.Before(earliest) {
+			earliest = pos.Resume
+		}
+	}
+	if earliest.IsZero() {
+		return nil, false, ErrNoCommittedPosition
+	}
+
+	perPartition := len(positions) == toPartitions
+	timestamps := make(map[int32]time.Time, toPartitions)
+	for p := range toPartitions {
+		timestamps[int32(p)] = earliest //nolint:gosec // partition ids fit in int32
+	}
+	if perPartition {
+		for _, pos := range positions {
+			if !pos.Resume.IsZero() {
+				timestamps[pos.Partition] = pos.Resume
+			}
+		}
+	}
+
+	return timestamps, perPartition, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicMigrationTimestamps(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("same partition count maps partitions one to one", func(t *testing.T) {
+		positions := []TopicPartitionPosition{
+			{Partition: 0, Resume: t0.Add(time.Minute)},
+			{Partition: 1, Resume: t0},
+			{Partition: 2},
+		}
+
+		timestamps, perPartition, err := TopicMigrationTimestamps(positions, 3)
+		require.NoError(t, err)
+		assert.True(t, perPartition)
+		assert.Equal(t, map[int32]time.Time{
+			0: t0.Add(time.Minute),
+			1: t0,
+			// Without a committed offset the partition resumes from the earliest
+			2: t0,
+		}, timestamps)
+	})
+
+	t.Run("different partition count resumes from the earliest position", func(t *testing.T) {
+		positions := []TopicPartitionPosition{
+			{Partition: 0, Resume: t0.Add(time.Minute)},
+			{Partition: 1, Resume: t0.Add(time.Second)},
+		}
+
+		timestamps, perPartition, err := TopicMigrationTimestamps(positions, 4)
+		require.NoError(t, err)
+		assert.False(t, perPartition)
+		assert.Len(t, timestamps, 4)
+		for _, ts := range timestamps {
+			assert.Equal(t, t0.Add(time.Second), ts)
+		}
+	})
+
+	t.Run("no committed position", func(t *testing.T) {
+		_, _, err := TopicMigrationTimestamps([]TopicPartitionPosition{{Partition: 0}}, 1)
+		assert.ErrorIs(t, err, ErrNoCommittedPosition)
+	})
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// UDFConfig is a user-defined function of the stateless transformation. The
+// WASM module, base64 encoded, exports its memory, an alloc(size) function
+// returning a buffer of size bytes, and Function taking the pointer and
+// length of the JSON event and returning the pointer and length of the JSON
+// output packed in an i64, the pointer in the high 32 bits. A call longer
+// than Timeout or a module growing its memory past MemoryLimitMB fails the
+// event.
+type UDFConfig struct {
+	Runtime       string       `json:"runtime"`
+	Module        string       `json:"module"`
+	Function      string       `json:"function,omitempty"`
+	Timeout       JSONDuration `json:"timeout,omitzero"`
+	MemoryLimitMB int          `json:"memory_limit_mb,omitempty"`
+}
+
+// NewUDFConfig validates cfg and fills in its defaults.
+func NewUDFConfig(cfg UDFConfig) (zero UDFConfig, _ error) {
+	switch cfg.Runtime {
+	case "", internal.UDFRuntimeWASM:
+		cfg.Runtime = internal.UDFRuntimeWASM
+	default:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("unsupported udf runtime %q, must be %s", cfg.Runtime, internal.UDFRuntimeWASM)}
+	}
+
+	module, err := cfg.DecodeModule()
+	if err != nil {
+		return zero, err
+	}
+	if len(module) == 0 {
+		return zero, PipelineConfigError{Msg: "udf module cannot be empty"}
+	}
+	if len(module) > internal.MaxUDFModuleSize {
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("udf module cannot exceed %d bytes", internal.MaxUDFModuleSize)}
+	}
+
+	if strings.TrimSpace(cfg.Function) == "" {
+		cfg.Function = internal.DefaultUDFFunction
+	}
+
+	switch {
+	case cfg.Timeout.Duration() < 0:
+		return zero, PipelineConfigError{Msg: "udf timeout cannot be negative"}
+	case cfg.Timeout.Duration() == 0:
+		cfg.Timeout = *NewJSONDuration(internal.DefaultUDFTimeout)
+	case cfg.Timeout.Duration() > internal.MaxUDFTimeout:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("udf timeout cannot exceed %s", internal.MaxUDFTimeout)}
+	}
+
+	switch {
+	case cfg.MemoryLimitMB < 0:
+		return zero, PipelineConfigError{Msg: "udf memory_limit_mb cannot be negative"}
+	case cfg.MemoryLimitMB == 0:
+		cfg.MemoryLimitMB = internal.DefaultUDFMemoryLimitMB
+	case cfg.MemoryLimitMB > internal.MaxUDFMemoryLimitMB:
+		return zero, PipelineConfigError{Msg: fmt.Sprintf("udf memory_limit_mb cannot exceed %d", internal.MaxUDFMemoryLimitMB)}
+	}
+
+	return cfg, nil
+}
+
+// DecodeModule returns the binary of the WASM module.
+func (c UDFConfig) DecodeModule() ([]byte, error) {
+	module, err := base64.StdEncoding.DecodeString(c.Module)
+	if err != nil {
+		return nil, PipelineConfigError{Msg: fmt.Sprintf("udf module must be base64 encoded: %s", err)}
+	}
+	return module, nil
+}
@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// CheckpointStore persists the GTID set of the binlog transactions a MySQL
+// source has published to NATS, so a restarted ingestor resumes after them.
+type CheckpointStore interface {
+	// Load returns the stored GTID set, or an empty string when the source
+	// has no checkpoint yet.
+	Load(ctx context.Context, key string) (string, error)
+	Save(ctx context.Context, key, gtidSet string) error
+}
+
+type KVCheckpointStore struct {
+	kv jetstream.KeyValue
+}
+
+func NewKVCheckpointStore(kv jetstream.KeyValue) *KVCheckpointStore {
+	return &KVCheckpointStore{kv: kv}
+}
+
+func (s *KVCheckpointStore) Load(ctx context.Context, key string) (string, error) {
+	entry, err := s.kv.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get checkpoint %s: %w", key, err)
+	}
+
+	return string(entry.Value()), nil
+}
+
+func (s *KVCheckpointStore) Save(ctx context.Context, key, gtidSet string) error {
+	if _, err := s.kv.PutString(ctx, key, gtidSet); err != nil {
+		return fmt.Errorf("put checkpoint %s: %w", key, err)
+	}
+
+	return nil
+}
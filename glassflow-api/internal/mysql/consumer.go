@@ -0,0 +1,354 @@
+package mysql
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// Consumer follows the MySQL binlog as a replica and hands the row changes of
+// one table to the ingestor message processor as Kafka records, so they are
+// published to NATS exactly like Kafka messages.
+//
+// Progress is tracked as the GTID set of the transactions read so far. It is
+// checkpointed once a batch is published, a restart replays the transactions
+// after the checkpoint and the deterministic record offsets let the NATS
+// deduplication window drop the rows that were already published.
+type Consumer struct {
+	conn          models.MySQLConnectionParamsConfig
+	tlsConfig     *tls.Config
+	syncer        *replication.BinlogSyncer
+	topic         string
+	database      string
+	table         string
+	initialOffset string
+	checkpoints   CheckpointStore
+	checkpointKey string
+	timeout       time.Duration
+	maxBatchSize  int
+	log           *slog.Logger
+	cancel        context.CancelFunc
+	closeCh       chan struct{}
+
+	// executed holds the transactions read up to their commit
+	executed *gomysql.MysqlGTIDSet
+	gtid     string
+	txRow    int
+}
+
+func NewConsumer(
+	conn models.MySQLConnectionParamsConfig,
+	topic models.KafkaTopicsConfig,
+	pipelineID string,
+	checkpoints CheckpointStore,
+	log *slog.Logger,
+) (zero *Consumer, _ error) {
+	if checkpoints == nil {
+		return zero, fmt.Errorf("mysql checkpoint store is required")
+	}
+
+	database, table, err := models.SplitMySQLTable(topic.Name)
+	if err != nil {
+		return zero, err
+	}
+
+	c := &Consumer{
+		conn:          conn,
+		topic:         topic.Name,
+		database:      database,
+		table:         table,
+		initialOffset: topic.ConsumerGroupInitialOffset,
+		checkpoints:   checkpoints,
+		checkpointKey: models.GetMySQLCheckpointKey(pipelineID, topic.Name),
+		timeout:       internal.DefaultMySQLBatchTimeout,
+		maxBatchSize:  internal.MySQLMaxBatchSize,
+		log:           log,
+		closeCh:       make(chan struct{}),
+	}
+
+	if conn.UsesTLS() {
+		c.tlsConfig, err = kafka.MakeTLSConfigFromStrings("", "", conn.TLSRoot)
+		if err != nil {
+			return zero, fmt.Errorf("build tls config: %w", err)
+		}
+		c.tlsConfig.ServerName = conn.Host
+		c.tlsConfig.InsecureSkipVerify = conn.SkipTLSVerification //nolint:gosec // opt-in per connection
+	}
+
+	serverID := conn.ServerID
+	if serverID == 0 {
+		serverID = deriveServerID(c.checkpointKey)
+	}
+
+	//nolint:exhaustruct // optional config
+	c.syncer = replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID:  serverID,
+		Flavor:    gomysql.MySQLFlavor,
+		Host:      conn.Host,
+		Port:      uint16(conn.Port), //nolint:gosec // port is validated in the pipeline config
+		User:      conn.Username,
+		Password:  conn.Password,
+		TLSConfig: c.tlsConfig,
+	})
+
+	return c, nil
+}
+
+// deriveServerID picks a stable replica server id for a source without one,
+// ids below 1000 are left to the user's own replicas.
+func deriveServerID(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()%(math.MaxUint32-1000) + 1000
+}
+
+func (c *Consumer) Start(ctx context.Context, processor kafka.MessageProcessor) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	defer close(c.closeCh)
+
+	start, err := c.startPosition(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve binlog start position: %w", err)
+	}
+
+	gset, err := gomysql.ParseMysqlGTIDSet(start)
+	if err != nil {
+		return fmt.Errorf("parse gtid set %q: %w", start, err)
+	}
+	c.executed = gset.(*gomysql.MysqlGTIDSet) //nolint:forcetypeassert // parsed as a mysql gtid set
+
+	c.log.Info("Starting MySQL binlog consumer",
+		slog.String("table", c.topic),
+		slog.String("gtid_set", start))
+
+	streamer, err := c.syncer.StartSyncGTID(c.executed.Clone())
+	if err != nil {
+		return fmt.Errorf("start binlog sync: %w", err)
+	}
+
+	saved := start
+	for {
+		records, err := c.collectBatch(ctx, streamer)
+		if err != nil {
+			return fmt.Errorf("read binlog: %w", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Transactions committed up to here are fully part of this batch or
+		// an earlier one
+		checkpoint := c.executed.String()
+
+		if len(records) > 0 {
+			if err := c.processBatch(ctx, processor, records); err != nil {
+				return fmt.Errorf("process batch: %w", err)
+			}
+		}
+
+		if checkpoint != saved {
+			if err := c.checkpoints.Save(ctx, c.checkpointKey, checkpoint); err != nil {
+				return fmt.Errorf("save gtid checkpoint: %w", err)
+			}
+			saved = checkpoint
+		}
+	}
+}
+
+// startPosition returns the GTID set to resume after: the checkpoint of the
+// source, or for a new source the transactions the server already executed
+// when it starts from the latest position.
+func (c *Consumer) startPosition(ctx context.Context) (string, error) {
+	checkpoint, err := c.checkpoints.Load(ctx, c.checkpointKey)
+	if err != nil {
+		return "", err
+	}
+	if checkpoint != "" {
+		return checkpoint, nil
+	}
+
+	if c.initialOffset == internal.InitialOffsetEarliest {
+		return "", nil
+	}
+
+	return c.executedGTIDSet()
+}
+
+func (c *Consumer) executedGTIDSet() (string, error) {
+	addr := c.conn.Host + ":" + strconv.Itoa(c.conn.Port)
+	conn, err := client.Connect(addr, c.conn.Username, c.conn.Password, "", func(conn *client.Conn) error {
+		if c.tlsConfig != nil {
+			conn.SetTLSConfig(c.tlsConfig)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("connect to mysql: %w", err)
+	}
+	defer conn.Close()
+
+	res, err := conn.Execute("SELECT @@GLOBAL.gtid_executed")
+	if err != nil {
+		return "", fmt.Errorf("query gtid_executed: %w", err)
+	}
+
+	executed, err := res.GetString(0, 0)
+	if err != nil {
+		return "", fmt.Errorf("read gtid_executed: %w", err)
+	}
+
+	return executed, nil
+}
+
+// collectBatch reads binlog events until the batch is full or the batch
+// timeout passes, keeping the rows of the source table.
+func (c *Consumer) collectBatch(ctx context.Context, streamer *replication.BinlogStreamer) ([]*kgo.Record, error) {
+	records := make([]*kgo.Record, 0)
+
+	batchCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	for len(records) < c.maxBatchSize {
+		ev, err := streamer.GetEvent(batchCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return records, nil
+			}
+			return nil, err
+		}
+
+		batchRecords, err := c.handleEvent(ev)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, batchRecords...)
+	}
+
+	return records, nil
+}
+
+func (c *Consumer) handleEvent(ev *replication.BinlogEvent) ([]*kgo.Record, error) {
+	switch e := ev.Event.(type) {
+	case *replication.GTIDEvent:
+		sid, err := uuid.FromBytes(e.SID)
+		if err != nil {
+			return nil, fmt.Errorf("parse gtid source id: %w", err)
+		}
+		c.gtid = fmt.Sprintf("%s:%d", sid, e.GNO)
+		c.txRow = 0
+	case *replication.QueryEvent:
+		// DDL statements commit their own transaction
+		if string(e.Query) != "BEGIN" {
+			return nil, c.commit()
+		}
+	case *replication.XIDEvent:
+		return nil, c.commit()
+	case *replication.RowsEvent:
+		if string(e.Table.Schema) != c.database || string(e.Table.Table) != c.table {
+			return nil, nil
+		}
+
+		op, ok := rowsOp(ev.Header.EventType)
+		if !ok {
+			return nil, nil
+		}
+
+		timestamp := time.Unix(int64(ev.Header.Timestamp), 0)
+		events, err := changeEvents(e, op, c.gtid, timestamp.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+
+		records := make([]*kgo.Record, len(events))
+		for i, value := range events {
+			records[i] = &kgo.Record{
+				Topic:     c.topic,
+				Offset:    rowOffset(c.gtid, c.txRow),
+				Timestamp: timestamp,
+				Value:     value,
+			}
+			c.txRow++
+		}
+		return records, nil
+	}
+
+	return nil, nil
+}
+
+func (c *Consumer) commit() error {
+	if c.gtid == "" {
+		return nil
+	}
+	if err := c.executed.Update(c.gtid); err != nil {
+		return fmt.Errorf("update gtid set with %s: %w", c.gtid, err)
+	}
+	c.gtid = ""
+	return nil
+}
+
+// rowOffset maps a row of a transaction onto a non-negative Kafka offset, so
+// the ingest sequence used for NATS deduplication stays stable when the
+// transaction is replayed.
+func rowOffset(gtid string, row int) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(gtid + "/" + strconv.Itoa(row)))
+	return int64(h.Sum64() & math.MaxInt64) //nolint:gosec // masked to int64 range
+}
+
+func (c *Consumer) processBatch(ctx context.Context, processor kafka.MessageProcessor, records []*kgo.Record) error {
+	size := len(records)
+	c.log.Info("Processing batch of messages", slog.Int("batchSize", size))
+	start := time.Now()
+
+	var totalBytes int64
+	for _, r := range records {
+		totalBytes += int64(len(r.Value))
+	}
+
+	if _, err := processor.ProcessBatch(ctx, records); err != nil {
+		c.log.Error("Batch processing failed", slog.Any("error", err), slog.Int("batchSize", size))
+		return fmt.Errorf("batch processing failed: %w", err)
+	}
+
+	c.log.Info("Batch processed successfully", slog.Int("batchSize", size), slog.Duration("duration", time.Since(start)))
+
+	observability.RecordKafkaRead(ctx, "ingestor", int64(size))
+	duration := time.Since(start).Seconds()
+	observability.RecordProcessingDurationWithStage(ctx, "ingestor", "batch", duration/float64(size))
+	observability.RecordBytesProcessed(ctx, "ingestor", "in", totalBytes)
+
+	return nil
+}
+
+func (c *Consumer) Close() error {
+	c.log.Info("Closing MySQL binlog consumer", slog.String("table", c.topic))
+
+	if c.cancel != nil {
+		c.cancel()
+		<-c.closeCh
+	}
+
+	c.syncer.Close()
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// rowsOp returns the change operation of a binlog rows event type.
+func rowsOp(eventType replication.EventType) (string, bool) {
+	switch eventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		return internal.CDCOpCreate, true
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		return internal.CDCOpUpdate, true
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		return internal.CDCOpDelete, true
+	default:
+		return "", false
+	}
+}
+
+// changeEvents converts the rows of a binlog rows event into JSON change
+// events: the row columns plus the operation, the transaction GTID and the
+// commit time in milliseconds. Updates carry the row after the change and
+// deletes the removed row.
+func changeEvents(ev *replication.RowsEvent, op, gtid string, timestampMs int64) ([][]byte, error) {
+	columns := ev.Table.ColumnNameString()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s.%s has no column names in the binlog, binlog_row_metadata must be FULL",
+			ev.Table.Schema, ev.Table.Table)
+	}
+
+	rows := ev.Rows
+	if op == internal.CDCOpUpdate {
+		// Update events hold before and after images in turns
+		rows = make([][]any, 0, len(ev.Rows)/2)
+		for i := 1; i < len(ev.Rows); i += 2 {
+			rows = append(rows, ev.Rows[i])
+		}
+	}
+
+	events := make([][]byte, 0, len(rows))
+	for _, row := range rows {
+		event := make(map[string]any, len(columns)+3)
+		for i, value := range row {
+			if i >= len(columns) {
+				break
+			}
+			event[columns[i]] = columnValue(ev.Table.ColumnType, i, value)
+		}
+		event[internal.CDCOpField] = op
+		event[internal.CDCGTIDField] = gtid
+		event[internal.CDCTimestampField] = timestampMs
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("marshal row of %s.%s: %w", ev.Table.Schema, ev.Table.Table, err)
+		}
+		events = append(events, data)
+	}
+
+	return events, nil
+}
+
+// columnValue returns a JSON encodable column value. Text and binary columns
+// are decoded as bytes, JSON columns hold the document text.
+func columnValue(columnTypes []byte, i int, value any) any {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	if i < len(columnTypes) && columnTypes[i] == gomysql.MYSQL_TYPE_JSON && json.Valid(b) {
+		return json.RawMessage(b)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func ordersTable() *replication.TableMapEvent {
+	return &replication.TableMapEvent{
+		Schema:     []byte("shop"),
+		Table:      []byte("orders"),
+		ColumnName: [][]byte{[]byte("id"), []byte("name"), []byte("attrs")},
+		ColumnType: []byte{gomysql.MYSQL_TYPE_LONG, gomysql.MYSQL_TYPE_VARCHAR, gomysql.MYSQL_TYPE_JSON},
+	}
+}
+
+func TestRowsOp(t *testing.T) {
+	op, ok := rowsOp(replication.WRITE_ROWS_EVENTv2)
+	require.True(t, ok)
+	assert.Equal(t, internal.CDCOpCreate, op)
+
+	op, ok = rowsOp(replication.UPDATE_ROWS_EVENTv1)
+	require.True(t, ok)
+	assert.Equal(t, internal.CDCOpUpdate, op)
+
+	op, ok = rowsOp(replication.DELETE_ROWS_EVENTv2)
+	require.True(t, ok)
+	assert.Equal(t, internal.CDCOpDelete, op)
+
+	_, ok = rowsOp(replication.QUERY_EVENT)
+	assert.False(t, ok)
+}
+
+func TestChangeEvents_Insert(t *testing.T) {
+	ev := &replication.RowsEvent{
+		Table: ordersTable(),
+		Rows: [][]any{
+			{int32(1), []byte("first"), []byte(`{"color":"red"}`)},
+			{int32(2), []byte("second"), nil},
+		},
+	}
+
+	events, err := changeEvents(ev, internal.CDCOpCreate, "uuid:7", 1700000000000)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	var first map[string]any
+	require.NoError(t, json.Unmarshal(events[0], &first))
+	assert.Equal(t, float64(1), first["id"])
+	assert.Equal(t, "first", first["name"])
+	assert.Equal(t, map[string]any{"color": "red"}, first["attrs"])
+	assert.Equal(t, internal.CDCOpCreate, first[internal.CDCOpField])
+	assert.Equal(t, "uuid:7", first[internal.CDCGTIDField])
+	assert.Equal(t, float64(1700000000000), first[internal.CDCTimestampField])
+
+	var second map[string]any
+	require.NoError(t, json.Unmarshal(events[1], &second))
+	assert.Nil(t, second["attrs"])
+}
+
+func TestChangeEvents_UpdateUsesAfterImage(t *testing.T) {
+	ev := &replication.RowsEvent{
+		Table: ordersTable(),
+		Rows: [][]any{
+			{int32(1), []byte("before"), nil},
+			{int32(1), []byte("after"), nil},
+		},
+	}
+
+	events, err := changeEvents(ev, internal.CDCOpUpdate, "uuid:8", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	var row map[string]any
+	require.NoError(t, json.Unmarshal(events[0], &row))
+	assert.Equal(t, "after", row["name"])
+	assert.Equal(t, internal.CDCOpUpdate, row[internal.CDCOpField])
+}
+
+func TestChangeEvents_RequiresColumnNames(t *testing.T) {
+	table := ordersTable()
+	table.ColumnName = nil
+
+	_, err := changeEvents(&replication.RowsEvent{Table: table, Rows: [][]any{{int32(1)}}}, internal.CDCOpCreate, "uuid:9", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "binlog_row_metadata must be FULL")
+}
+
+func TestRowOffset_Stable(t *testing.T) {
+	assert.Equal(t, rowOffset("uuid:1", 0), rowOffset("uuid:1", 0))
+	assert.NotEqual(t, rowOffset("uuid:1", 0), rowOffset("uuid:1", 1))
+	assert.NotEqual(t, rowOffset("uuid:1", 12), rowOffset("uuid:11", 2))
+	assert.GreaterOrEqual(t, rowOffset("uuid:1", 0), int64(0))
+}
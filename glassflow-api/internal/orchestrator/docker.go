@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,13 +14,15 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
 )
 
 type LocalOrchestrator struct {
-	nc  *client.NATSClient
-	db  service.PipelineStore
-	log *slog.Logger
+	nc      *client.NATSClient
+	db      service.PipelineStore
+	secrets secrets.Store
+	log     *slog.Logger
 
 	ingestorRunners []service.Runner
 	joinRunner      service.Runner
@@ -52,17 +55,22 @@ func (d *LocalOrchestrator) DeletePipeline(ctx context.Context, pid string) erro
 func NewLocalOrchestrator(
 	nc *client.NATSClient,
 	db service.PipelineStore,
+	secretStore secrets.Store,
 	log *slog.Logger,
 ) service.Orchestrator {
 	//nolint: exhaustruct // runners will be created on setup
 	return &LocalOrchestrator{
-		nc:  nc,
-		db:  db,
-		log: log,
+		nc:      nc,
+		db:      db,
+		secrets: secretStore,
+		log:     log,
 	}
 }
 
-var _ service.Orchestrator = (*LocalOrchestrator)(nil)
+var (
+	_ service.Orchestrator          = (*LocalOrchestrator)(nil)
+	_ service.PipelineStateReporter = (*LocalOrchestrator)(nil)
+)
 
 const localSingleReplicaPodIndex = "0"
 
@@ -76,13 +84,19 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 	d.m.Lock()
 	defer d.m.Unlock()
 
+	return d.setupPipeline(ctx, pi)
+}
+
+// setupPipeline creates the streams of the pipeline and starts its runners.
+// The caller must hold d.m.
+func (d *LocalOrchestrator) setupPipeline(ctx context.Context, pi *models.PipelineConfig) error {
 	var err error
 
 	defer func() {
 		if err != nil {
 			d.log.Info("pipeline setup failed; cleaning up pipeline")
 			//nolint: errcheck // ignore error on failed pipeline stop
-			go d.StopPipeline(ctx, pi.ID)
+			go d.StopPipeline(ctx, pi.ID, models.StopOptions{})
 		}
 	}()
 
@@ -91,6 +105,14 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 		return fmt.Errorf("setup local pipeline: %w", service.ErrPipelineQuotaReached)
 	}
 
+	// The runners connect with the credentials the secret references of the
+	// config resolve to
+	resolved := *pi
+	if err := secrets.ResolvePipeline(ctx, d.secrets, &resolved); err != nil {
+		return fmt.Errorf("resolve pipeline secrets: %w", err)
+	}
+	pi = &resolved
+
 	// FIX IT: not working anymore since local pipeline names aren't namespaced
 	// to "gf-stream"
 	if err := d.nc.CleanupOldResources(ctx); err != nil {
@@ -119,7 +141,7 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 	for _, t := range pi.Ingestor.KafkaTopics {
 		streamName := models.GetIngestorStreamName(d.id, t.Name)
 		subjectName := models.GetPipelineNATSSubject(d.id, t.Name)
-		err := d.nc.CreateOrUpdateStream(ctx, streamName, subjectName, t.Deduplication.Window.Duration())
+		err := d.nc.CreateOrUpdateStream(ctx, streamName, subjectName, t.StreamDuplicateWindow(d.nc.MaxAge()))
 		if err != nil {
 			d.log.ErrorContext(ctx, "failed to create ingestion stream", "stream_name", streamName, "subject_name", subjectName, "error", err)
 			return fmt.Errorf("setup ingestion streams for pipeline: %w", err)
@@ -138,7 +160,7 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 	if err != nil {
 		return fmt.Errorf("resolve sink input subject: %w", err)
 	}
-	sinkInputDedupWindow, err := resolveSinkInputDedupWindow(pi)
+	sinkInputDedupWindow, err := resolveSinkInputDedupWindow(pi, d.nc.MaxAge())
 	if err != nil {
 		return fmt.Errorf("resolve sink input dedup window: %w", err)
 	}
@@ -172,6 +194,7 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 				TotalSubjectCount:   1,
 				DedupSubjectPrefix:  resolveDedupSubjectPrefix(subjectPattern),
 				DedupSubjectCount:   subjectCount,
+				ReplicaIndex:        i,
 			}
 
 			ingestorRunner := service.NewIngestorRunner(
@@ -216,6 +239,11 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 		}
 		d.log.DebugContext(ctx, "created join right buffer KV store successfully")
 
+		err = d.setupJoinChain(ctx, pi)
+		if err != nil {
+			return err
+		}
+
 		// Join runner resolves these from env (operator-style contract).
 		os.Setenv("NATS_LEFT_INPUT_STREAM_PREFIX", leftInputStreamName)
 		os.Setenv("NATS_RIGHT_INPUT_STREAM_PREFIX", rightInputStreamName)
@@ -238,6 +266,7 @@ func (d *LocalOrchestrator) SetupPipeline(ctx context.Context, pi *models.Pipeli
 		d.nc,
 		*pi,
 		d.db,
+		nil,
 	)
 
 	err = d.sinkRunner.Start(ctx)
@@ -304,6 +333,43 @@ func resolveJoinStreamNames(pipeline *models.PipelineConfig) (left string, right
 	return left, right, nil
 }
 
+// setupJoinChain creates the intermediate streams of a chained join and the
+// buffers of its stages after the first. Stage i reads the intermediate
+// stream of stage i-1 on its left and the stream of its chained source on its
+// right.
+func (d *LocalOrchestrator) setupJoinChain(ctx context.Context, pi *models.PipelineConfig) error {
+	stages := pi.Join.Stages()
+	for i := 1; i < len(stages); i++ {
+		stage := stages[i]
+
+		leftStreamName := models.GetJoinStageStreamName(pi.ID, i)
+		err := d.nc.CreateOrUpdateStream(ctx, leftStreamName, models.GetWildcardNATSSubjectName(leftStreamName), 0)
+		if err != nil {
+			d.log.ErrorContext(ctx, "failed to create join stage stream", "stream_name", leftStreamName, "error", err)
+			return fmt.Errorf("setup join stage %d stream for pipeline: %w", i, err)
+		}
+
+		rightStreamName, err := resolveJoinInputStreamName(pi, stage.Sources[1].SourceID)
+		if err != nil {
+			return fmt.Errorf("resolve join stage %d input stream name: %w", i, err)
+		}
+
+		for streamName, ttl := range map[string]time.Duration{
+			leftStreamName:  stage.LeftBufferTTL.Duration(),
+			rightStreamName: stage.RightBufferTTL.Duration(),
+		} {
+			err = d.nc.CreateOrUpdateJoinKeyValueStore(ctx, streamName, ttl)
+			if err != nil {
+				d.log.ErrorContext(ctx, "failed to create join stage buffer KV store", "stream_name", streamName, "ttl", ttl, "error", err)
+				return fmt.Errorf("setup join stage %d buffer KV store for pipeline: %w", i, err)
+			}
+		}
+		d.log.DebugContext(ctx, "created join stage stream and buffers successfully", "stage", i)
+	}
+
+	return nil
+}
+
 func resolveJoinInputStreamName(pipeline *models.PipelineConfig, sourceID string) (string, error) {
 	sourceID = strings.TrimSpace(sourceID)
 	if sourceID == "" {
@@ -350,7 +416,7 @@ func resolveSinkInputSubject(pipeline *models.PipelineConfig, sinkInputStreamPre
 	return models.GetWildcardNATSSubjectName(getLocalSingleReplicaStreamName(sinkInputStreamPrefix)), nil
 }
 
-func resolveSinkInputDedupWindow(pipeline *models.PipelineConfig) (time.Duration, error) {
+func resolveSinkInputDedupWindow(pipeline *models.PipelineConfig, maxAge time.Duration) (time.Duration, error) {
 	if pipeline == nil {
 		return 0, fmt.Errorf("pipeline config is nil")
 	}
@@ -362,11 +428,12 @@ func resolveSinkInputDedupWindow(pipeline *models.PipelineConfig) (time.Duration
 		return 0, fmt.Errorf("ingestor topics are not configured")
 	}
 
-	return pipeline.Ingestor.KafkaTopics[0].Deduplication.Window.Duration(), nil
+	return pipeline.Ingestor.KafkaTopics[0].StreamDuplicateWindow(maxAge), nil
 }
 
-// StopPipeline implements Orchestrator.
-func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string) error {
+// StopPipeline implements Orchestrator. The components are always stopped in
+// order, a draining stop only waits longer for the join and sink.
+func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error {
 	d.m.Lock()
 	defer d.m.Unlock()
 
@@ -375,7 +442,7 @@ func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string) error
 		return fmt.Errorf("mismatched pipeline id: %w", service.ErrPipelineNotFound)
 	}
 
-	d.log.InfoContext(ctx, "starting pipeline stop", "pipeline_id", pid)
+	d.log.InfoContext(ctx, "starting pipeline stop", "pipeline_id", pid, "drain", opts.Drain)
 
 	if d.watcherCancel != nil {
 		d.watcherCancel()
@@ -388,7 +455,7 @@ func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string) error
 		d.log.InfoContext(ctx, "pausing pipeline before stop", "pipeline_id", pid)
 
 		// Pause the pipeline (graceful shutdown of components)
-		err := d.pausePipelineComponents(ctx)
+		err := d.pausePipelineComponents(ctx, opts.WaitTimeout())
 		if err != nil {
 			d.log.ErrorContext(ctx, "failed to pause pipeline during stop", "error", err)
 			return fmt.Errorf("pause pipeline during stop: %w", err)
@@ -397,8 +464,15 @@ func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string) error
 		d.log.InfoContext(ctx, "pipeline paused successfully, proceeding with termination", "pipeline_id", pid)
 	}
 
+	// Termination deletes the streams, so prove nothing is left in flight first
+	err := d.checkPipelineDrained(ctx, pid)
+	if err != nil {
+		d.log.ErrorContext(ctx, "pipeline is not drained, keeping its streams", "pipeline_id", pid, "error", err)
+		return fmt.Errorf("drain pipeline during stop: %w", err)
+	}
+
 	// Now terminate the pipeline (cleanup resources)
-	err := d.terminatePipelineComponents(ctx, pid)
+	err = d.terminatePipelineComponents(ctx, pid)
 	if err != nil {
 		d.log.ErrorContext(ctx, "failed to terminate pipeline during stop", "error", err)
 		return fmt.Errorf("terminate pipeline during stop: %w", err)
@@ -408,8 +482,9 @@ func (d *LocalOrchestrator) StopPipeline(ctx context.Context, pid string) error
 	return nil
 }
 
-// pausePipelineComponents gracefully shuts down pipeline components in order
-func (d *LocalOrchestrator) pausePipelineComponents(ctx context.Context) error {
+// pausePipelineComponents gracefully shuts down pipeline components in order,
+// waiting up to waitTimeout for the join and sink to clear their streams
+func (d *LocalOrchestrator) pausePipelineComponents(ctx context.Context, waitTimeout time.Duration) error {
 	// Shutdown components sequentially: Ingestor -> Join -> Sink
 	// This ensures no data loss by processing all messages in order
 
@@ -459,13 +534,33 @@ func (d *LocalOrchestrator) pausePipelineComponents(ctx context.Context) error {
 				return fmt.Errorf("right join consumer: %w", err)
 			}
 
+			// Check the consumers of the stages of a chained join
+			for i, stage := range pipeline.Join.Stages()[1:] {
+				stageLeftStreamName := models.GetJoinStageStreamName(pipeline.ID, i+1)
+				stageRightStreamName, err := resolveJoinInputStreamName(pipeline, stage.Sources[1].SourceID)
+				if err != nil {
+					return fmt.Errorf("resolve join stage stream names: %w", err)
+				}
+
+				for streamName, consumerName := range map[string]string{
+					stageLeftStreamName:  models.GetNATSJoinStageConsumerName(pipeline.ID, i+1, internal.JoinLeft),
+					stageRightStreamName: models.GetNATSJoinStageConsumerName(pipeline.ID, i+1, internal.JoinRight),
+				} {
+					err = d.checkConsumerPendingMessages(ctx, streamName, consumerName)
+					if err != nil {
+						d.log.ErrorContext(ctx, "join stage consumer has pending messages", "consumer", consumerName, "stream", streamName, "error", err)
+						return fmt.Errorf("join stage %d consumer: %w", i+1, err)
+					}
+				}
+			}
+
 			d.log.InfoContext(ctx, "join consumers are clear of pending messages",
 				"left_consumer", leftConsumerName,
 				"right_consumer", rightConsumerName)
 
 			return nil
 		}
-		err = d.waitForPendingMessagesToClear(context.Background(), pipeline, checkJoinFunc, "join")
+		err = d.waitForPendingMessagesToClear(context.Background(), pipeline, checkJoinFunc, "join", waitTimeout)
 		if err != nil {
 			d.log.ErrorContext(ctx, "waiting for join pending messages to clear failed", "error", err)
 			return fmt.Errorf("waiting for join pending messages to clear failed: %w", err)
@@ -503,7 +598,7 @@ func (d *LocalOrchestrator) pausePipelineComponents(ctx context.Context) error {
 
 			return nil
 		}
-		err = d.waitForPendingMessagesToClear(context.Background(), pipeline, checkSinkFunc, "sink")
+		err = d.waitForPendingMessagesToClear(context.Background(), pipeline, checkSinkFunc, "sink", waitTimeout)
 		if err != nil {
 			d.log.ErrorContext(ctx, "waiting for sink pending messages to clear failed", "error", err)
 			return fmt.Errorf("waiting for sink pending messages to clear failed: %w", err)
@@ -520,9 +615,9 @@ func (d *LocalOrchestrator) pausePipelineComponents(ctx context.Context) error {
 }
 
 // waitForPendingMessagesToClear waits for consumers to clear pending messages using a provided check function
-func (d *LocalOrchestrator) waitForPendingMessagesToClear(ctx context.Context, pipeline *models.PipelineConfig, checkFunc func(context.Context, *models.PipelineConfig) error, componentName string) error {
-	maxRetries := 30 // 1 minute with 2-second intervals
+func (d *LocalOrchestrator) waitForPendingMessagesToClear(ctx context.Context, pipeline *models.PipelineConfig, checkFunc func(context.Context, *models.PipelineConfig) error, componentName string, timeout time.Duration) error {
 	retryInterval := 2 * time.Second
+	maxRetries := max(int(timeout/retryInterval), 1)
 
 	for i := 0; i < maxRetries; i++ {
 		err := checkFunc(ctx, pipeline)
@@ -583,6 +678,24 @@ func (d *LocalOrchestrator) checkConsumerPendingMessages(ctx context.Context, st
 	return nil
 }
 
+// checkPipelineDrained fails if any consumer of the pipeline still holds
+// pending or unacknowledged messages
+func (d *LocalOrchestrator) checkPipelineDrained(ctx context.Context, pid string) error {
+	inFlight, err := d.nc.ConsumersInFlight(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("get in-flight messages: %w", err)
+	}
+
+	for _, c := range inFlight {
+		if c.Pending > 0 || c.Unacked > 0 {
+			return fmt.Errorf("%w: consumer %s has %d pending and %d unacknowledged messages", service.ErrPipelineNotDrained, c.Consumer, c.Pending, c.Unacked)
+		}
+	}
+
+	d.log.InfoContext(ctx, "pipeline is drained", "pipeline_id", pid, "consumers", len(inFlight))
+	return nil
+}
+
 // terminatePipelineComponents cleans up pipeline resources
 func (d *LocalOrchestrator) terminatePipelineComponents(ctx context.Context, pid string) error {
 	// Clear pipeline ID first to stop any pending restart attempts
@@ -689,8 +802,23 @@ func (d *LocalOrchestrator) cleanupNATSResources(ctx context.Context, pipeline *
 		}
 
 		// Clean up join KV stores
-		joinStores := make(map[string]struct{}, len(pipeline.Join.Sources))
-		for _, source := range pipeline.Join.Sources {
+		joinStores := make(map[string]struct{}, len(pipeline.Join.Sources)+2*len(pipeline.Join.Chain))
+		for i := range pipeline.Join.Chain {
+			stageStreamName := models.GetJoinStageStreamName(pipeline.ID, i+1)
+			joinStores[stageStreamName] = struct{}{}
+
+			d.log.DebugContext(ctx, "deleting join stage stream", "stream", stageStreamName)
+			err := d.nc.DeleteStream(ctx, stageStreamName)
+			if err != nil {
+				d.log.ErrorContext(ctx, "failed to delete join stage stream", "error", err, "stream", stageStreamName)
+				// Continue with other cleanup even if this fails
+			}
+		}
+		sources := slices.Clone(pipeline.Join.Sources)
+		for _, c := range pipeline.Join.Chain {
+			sources = append(sources, models.JoinSourceConfig{SourceID: c.SourceID})
+		}
+		for _, source := range sources {
 			streamName, resolveErr := resolveJoinInputStreamName(pipeline, source.SourceID)
 			if resolveErr != nil {
 				d.log.ErrorContext(ctx, "failed to resolve join KV store name from source", "source_id", source.SourceID, "error", resolveErr)
@@ -711,6 +839,24 @@ func (d *LocalOrchestrator) cleanupNATSResources(ctx context.Context, pipeline *
 		}
 	}
 
+	// Clean up the snapshots of the deduplicator state
+	snapshotBucket := models.GetDedupSnapshotBucketName(pipeline.ID)
+	d.log.DebugContext(ctx, "deleting dedup snapshot store", "bucket", snapshotBucket)
+	err = d.nc.DeleteObjectStore(ctx, snapshotBucket)
+	if err != nil {
+		d.log.ErrorContext(ctx, "failed to delete dedup snapshot store", "error", err, "bucket", snapshotBucket)
+		// Continue with other cleanup even if this fails
+	}
+
+	// Clean up the checkpoints of the join stages
+	checkpointBucket := models.GetJoinCheckpointBucketName(pipeline.ID)
+	d.log.DebugContext(ctx, "deleting join checkpoint store", "bucket", checkpointBucket)
+	err = d.nc.DeleteObjectStore(ctx, checkpointBucket)
+	if err != nil {
+		d.log.ErrorContext(ctx, "failed to delete join checkpoint store", "error", err, "bucket", checkpointBucket)
+		// Continue with other cleanup even if this fails
+	}
+
 	d.log.InfoContext(ctx, "NATS resources cleanup completed", "pipeline_id", pipeline.ID)
 	return nil
 }
@@ -720,6 +866,13 @@ func (d *LocalOrchestrator) ResumePipeline(ctx context.Context, pid string, pipe
 	d.m.Lock()
 	defer d.m.Unlock()
 
+	// A stopped pipeline has no streams and runners left, so it is set up
+	// again from its stored config
+	if d.id == "" {
+		d.log.InfoContext(ctx, "resuming stopped pipeline", "pipeline_id", pid)
+		return d.setupPipeline(ctx, pipelineCfg)
+	}
+
 	if d.id != pid {
 		d.log.ErrorContext(ctx, "mismatched pipeline id for resume", "expected_id", d.id, "requested_id", pid)
 		return fmt.Errorf("mismatched pipeline id: %w", service.ErrPipelineNotFound)
@@ -781,23 +934,18 @@ func (d *LocalOrchestrator) ResumePipeline(ctx context.Context, pid string, pipe
 func (d *LocalOrchestrator) EditPipeline(ctx context.Context, pid string, newCfg *models.PipelineConfig) error {
 	d.log.InfoContext(ctx, "editing local pipeline", "pipeline_id", pid)
 
-	// Check if this is the active pipeline
 	d.m.Lock()
-	activeID := d.id
-	d.m.Unlock()
+	defer d.m.Unlock()
 
-	if activeID != pid {
-		d.log.ErrorContext(ctx, "pipeline is not active", "pipeline_id", pid, "active_pipeline_id", activeID)
-		return fmt.Errorf("pipeline %s is not active, cannot edit", pid)
+	// Edits are only allowed on stopped pipelines, which the local
+	// orchestrator no longer tracks
+	if d.id != "" {
+		d.log.ErrorContext(ctx, "pipeline is still running", "pipeline_id", pid, "active_pipeline_id", d.id)
+		return fmt.Errorf("local pipeline %s is still running, cannot edit", d.id)
 	}
 
-	// Update the pipeline configuration
-	d.m.Lock()
-	d.pipelineConfig = newCfg
-	d.m.Unlock()
-
 	// Start the pipeline with new configuration
-	err := d.SetupPipeline(ctx, newCfg)
+	err := d.setupPipeline(ctx, newCfg)
 	if err != nil {
 		d.log.ErrorContext(ctx, "failed to setup pipeline with new config", "pipeline_id", pid, "error", err)
 		return fmt.Errorf("setup pipeline with new config: %w", err)
@@ -809,7 +957,7 @@ func (d *LocalOrchestrator) EditPipeline(ctx context.Context, pid string, newCfg
 
 // TerminatePipeline implements Orchestrator.
 func (d *LocalOrchestrator) TerminatePipeline(ctx context.Context, pid string) error {
-	return d.StopPipeline(ctx, pid)
+	return d.StopPipeline(ctx, pid, models.StopOptions{})
 }
 
 func (d *LocalOrchestrator) ActivePipelineID() string {
@@ -819,6 +967,26 @@ func (d *LocalOrchestrator) ActivePipelineID() string {
 	return d.id
 }
 
+// PipelineStates implements service.PipelineStateReporter. The local
+// orchestrator runs at most the active pipeline, in the API process.
+func (d *LocalOrchestrator) PipelineStates(_ context.Context) (map[string]models.PipelineStatus, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	states := make(map[string]models.PipelineStatus)
+	if d.id != "" {
+		states[d.id] = internal.PipelineStatusRunning
+	}
+	return states, nil
+}
+
+// MarkPipelineOrphaned implements service.PipelineStateReporter. Local
+// components stop with the API, so they are only reported.
+func (d *LocalOrchestrator) MarkPipelineOrphaned(ctx context.Context, pid string) error {
+	d.log.WarnContext(ctx, "local pipeline has no stored config", "pipeline_id", pid)
+	return nil
+}
+
 // startRunnerWatcher starts a goroutine that monitors all runners and restarts them on failure
 func (d *LocalOrchestrator) startRunnerWatcher(ctx context.Context) {
 	watcherCtx, cancel := context.WithCancel(ctx)
@@ -115,7 +115,10 @@ func (k *K8sOrchestrator) getPipelineConfigFromK8sResource(customResource *unstr
 	}
 }
 
-var _ service.Orchestrator = (*K8sOrchestrator)(nil)
+var (
+	_ service.Orchestrator          = (*K8sOrchestrator)(nil)
+	_ service.PipelineStateReporter = (*K8sOrchestrator)(nil)
+)
 
 // GetType implements Orchestrator.
 func (k *K8sOrchestrator) GetType() string {
@@ -141,6 +144,9 @@ func (k *K8sOrchestrator) SetupPipeline(ctx context.Context, cfg *models.Pipelin
 		Object: map[string]any{
 			"metadata": map[string]any{
 				"name": cfg.ID,
+				"labels": map[string]any{
+					internal.PipelineConfigHashLabel: cfg.ConfigHash,
+				},
 				"annotations": map[string]any{
 					internal.PipelineCreateAnnotation: "true",
 				},
@@ -163,6 +169,11 @@ func (k *K8sOrchestrator) SetupPipeline(ctx context.Context, cfg *models.Pipelin
 	}).
 		Namespace(k.namespace).
 		Create(ctx, obj, metav1.CreateOptions{})
+	// A setup retried from the outbox finds the resource of its first run
+	if errors.IsAlreadyExists(err) {
+		k.log.InfoContext(ctx, "k8s pipeline already exists", "pipeline_id", cfg.ID)
+		return nil
+	}
 	if err != nil {
 		k.log.ErrorContext(ctx, "failed to create custom resource, cleaning up secret", "pipeline_id", cfg.ID, "namespace", k.namespace, "error", err)
 		_ = k.deletePipelineConfigSecret(ctx, cfg.ID)
@@ -175,8 +186,8 @@ func (k *K8sOrchestrator) SetupPipeline(ctx context.Context, cfg *models.Pipelin
 }
 
 // StopPipeline implements Orchestrator.
-func (k *K8sOrchestrator) StopPipeline(ctx context.Context, pipelineID string) error {
-	k.log.InfoContext(ctx, "stopping k8s pipeline", "pipeline_id", pipelineID)
+func (k *K8sOrchestrator) StopPipeline(ctx context.Context, pipelineID string, opts models.StopOptions) error {
+	k.log.InfoContext(ctx, "stopping k8s pipeline", "pipeline_id", pipelineID, "drain", opts.Drain)
 
 	// Get the pipeline CRD
 	customResource, err := k.client.Resource(schema.GroupVersionResource{
@@ -208,6 +219,11 @@ func (k *K8sOrchestrator) StopPipeline(ctx context.Context, pipelineID string) e
 
 	// Add stop annotation
 	annotations[internal.PipelineStopAnnotation] = "true"
+	if opts.Drain {
+		annotations[internal.PipelineDrainTimeoutAnnotation] = opts.DrainTimeout.String()
+	} else {
+		delete(annotations, internal.PipelineDrainTimeoutAnnotation)
+	}
 	customResource.SetAnnotations(annotations)
 
 	// Update the resource with the stop annotation
@@ -264,6 +280,7 @@ func (k *K8sOrchestrator) TerminatePipeline(ctx context.Context, pipelineID stri
 		internal.PipelinePauseAnnotation,
 		internal.PipelineResumeAnnotation,
 		internal.PipelineStopAnnotation,
+		internal.PipelineDrainTimeoutAnnotation,
 		internal.PipelineEditAnnotation,
 	}
 
@@ -329,6 +346,7 @@ func (k *K8sOrchestrator) DeletePipeline(ctx context.Context, pipelineID string)
 		internal.PipelineResumeAnnotation,
 		internal.PipelineTerminateAnnotation,
 		internal.PipelineStopAnnotation,
+		internal.PipelineDrainTimeoutAnnotation,
 	}
 
 	for _, annotation := range conflictingAnnotations {
@@ -487,6 +505,13 @@ func (k *K8sOrchestrator) EditPipeline(ctx context.Context, pipelineID string, n
 	annotations["pipeline.etl.glassflow.io/edit"] = "true"
 	customResource.SetAnnotations(annotations)
 
+	labels := customResource.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[internal.PipelineConfigHashLabel] = newCfg.ConfigHash
+	customResource.SetLabels(labels)
+
 	// Update the resource with the edit annotation and new config
 	_, err = k.client.Resource(schema.GroupVersionResource{
 		Group:    k.customResource.APIGroup,
@@ -502,23 +527,84 @@ func (k *K8sOrchestrator) EditPipeline(ctx context.Context, pipelineID string, n
 	return nil
 }
 
+// PipelineStates implements service.PipelineStateReporter. It returns the
+// status of the pipeline custom resources in the namespace.
+func (k *K8sOrchestrator) PipelineStates(ctx context.Context) (map[string]models.PipelineStatus, error) {
+	list, err := k.client.Resource(schema.GroupVersionResource{
+		Group:    k.customResource.APIGroup,
+		Version:  k.customResource.Version,
+		Resource: k.customResource.Resource,
+	}).Namespace(k.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		k.log.ErrorContext(ctx, "failed to list pipeline CRDs", "namespace", k.namespace, "error", err)
+		return nil, fmt.Errorf("list pipeline CRDs: %w", err)
+	}
+
+	states := make(map[string]models.PipelineStatus, len(list.Items))
+	for i := range list.Items {
+		pipelineConfig := k.getPipelineConfigFromK8sResource(&list.Items[i])
+		states[pipelineConfig.ID] = pipelineConfig.Status.OverallStatus
+	}
+
+	return states, nil
+}
+
+// MarkPipelineOrphaned implements service.PipelineStateReporter. It
+// annotates the pipeline custom resource, which is left running for an
+// operator to inspect.
+func (k *K8sOrchestrator) MarkPipelineOrphaned(ctx context.Context, pipelineID string) error {
+	customResource, err := k.client.Resource(schema.GroupVersionResource{
+		Group:    k.customResource.APIGroup,
+		Version:  k.customResource.Version,
+		Resource: k.customResource.Resource,
+	}).Namespace(k.namespace).Get(ctx, pipelineID, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get pipeline CRD: %w", err)
+	}
+
+	annotations := customResource.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if annotations[internal.PipelineOrphanedAnnotation] == "true" {
+		return nil
+	}
+	annotations[internal.PipelineOrphanedAnnotation] = "true"
+	customResource.SetAnnotations(annotations)
+
+	_, err = k.client.Resource(schema.GroupVersionResource{
+		Group:    k.customResource.APIGroup,
+		Version:  k.customResource.Version,
+		Resource: k.customResource.Resource,
+	}).Namespace(k.namespace).Update(ctx, customResource, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("update pipeline CRD with orphaned annotation: %w", err)
+	}
+
+	k.log.WarnContext(ctx, "marked k8s pipeline orphaned", "pipeline_id", pipelineID)
+	return nil
+}
+
 // buildPipelineSpec creates a complete PipelineSpec from a PipelineConfig
 func (k *K8sOrchestrator) buildPipelineSpec(ctx context.Context, cfg *models.PipelineConfig) (map[string]any, error) {
 	var src []operator.SourceStream
 	var sourceType string
 	var isDedupEnabled bool
 
-	if cfg.SourceType.IsOTLP() {
+	if cfg.SourceType.UsesReceiver() {
 		sourceType = string(cfg.SourceType)
 		isDedupEnabled = cfg.OTLPSource.Deduplication.Enabled
 	} else {
 		sourceType = cfg.Ingestor.Type
 		src = make([]operator.SourceStream, 0, len(cfg.Ingestor.KafkaTopics))
 		for _, s := range cfg.Ingestor.KafkaTopics {
-			sDedupEnabled := s.Deduplication.Enabled || cfg.StatelessTransformation.Enabled || cfg.Filter.Enabled
+			sDedupEnabled := s.Deduplication.Enabled || cfg.StatelessTransformation.Enabled || cfg.TransformFilterEnabled() || cfg.Explode.Enabled || cfg.Enrichment.Enabled
 			src = append(src, operator.SourceStream{
 				TopicName:   s.Name,
-				DedupWindow: s.Deduplication.Window.Duration(),
+				DedupWindow: s.StreamDuplicateWindow(cfg.PipelineResources.StreamMaxAge()),
 				Deduplication: &operator.Deduplication{
 					Enabled: sDedupEnabled,
 				},
@@ -551,7 +637,7 @@ func (k *K8sOrchestrator) buildPipelineSpec(ctx context.Context, cfg *models.Pip
 		},
 		Transform: operator.Transform{
 			IsDedupEnabled:              isDedupEnabled,
-			IsFilterEnabled:             cfg.Filter.Enabled,
+			IsFilterEnabled:             cfg.TransformFilterEnabled(),
 			IsStatelessTransformEnabled: cfg.StatelessTransformation.Enabled,
 		},
 		Resources: operatorResources,
@@ -581,9 +667,16 @@ func (k *K8sOrchestrator) getPipelineConfigSecretName(pipelineID string) string
 }
 
 // createPipelineConfigSecret creates a secret in the glassflow namespace with pipeline.json
+// marshalComponentConfig returns the pipeline config read by the components,
+// stamped with the config schema version they must support.
+func marshalComponentConfig(cfg *models.PipelineConfig) ([]byte, error) {
+	componentCfg := *cfg
+	componentCfg.ConfigSchemaVersion = models.PipelineConfigSchemaVersion
+	return json.Marshal(componentCfg)
+}
+
 func (k *K8sOrchestrator) createPipelineConfigSecret(ctx context.Context, cfg *models.PipelineConfig) error {
-	// Marshal config to JSON string
-	configJSON, err := json.Marshal(cfg)
+	configJSON, err := marshalComponentConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("marshal pipeline config: %w", err)
 	}
@@ -618,8 +711,7 @@ func (k *K8sOrchestrator) createPipelineConfigSecret(ctx context.Context, cfg *m
 
 // updatePipelineConfigSecret updates the secret in the glassflow namespace with new pipeline.json
 func (k *K8sOrchestrator) updatePipelineConfigSecret(ctx context.Context, cfg *models.PipelineConfig) error {
-	// Marshal config to JSON string
-	configJSON, err := json.Marshal(cfg)
+	configJSON, err := marshalComponentConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("marshal pipeline config: %w", err)
 	}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// ProcessEvents publishes JSON events pushed to the HTTP source of a
+// pipeline into its ingest stream.
+func (p *Processor) ProcessEvents(
+	ctx context.Context,
+	pipelineID string,
+	events [][]byte,
+) error {
+	cfg, err := p.getWriterConfig(ctx, pipelineID)
+	if err != nil {
+		if errors.Is(err, service.ErrPipelineNotFound) || errors.Is(err, service.ErrPipelineNotExists) {
+			return service.ErrPipelineNotFound
+		}
+		return fmt.Errorf("getWriterConfig: %w", err)
+	}
+
+	if !cfg.routingConfig.SourceType.IsHTTP() {
+		return models.ErrNotHTTPSource
+	}
+
+	messages := make([]models.Message, len(events))
+	for i, event := range events {
+		messages[i] = models.NewNatsMessage(event, nil)
+	}
+
+	return p.sendBatch(
+		ctx,
+		observability.MetricComponentHTTPIngest,
+		pipelineID,
+		messages,
+	)
+}
@@ -0,0 +1,93 @@
+package processor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsTest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/otlp-receiver/server/processor"
+)
+
+type stubHTTPConfigFetcher struct {
+	sourceType models.SourceType
+}
+
+func (s *stubHTTPConfigFetcher) GetOTLPConfig(_ context.Context, _ string) (models.OTLPConfig, error) {
+	return models.OTLPConfig{
+		SourceType:      s.sourceType,
+		SchemaVersionID: "2",
+		Routing: models.RoutingConfig{
+			OutputSubject: "testing-http",
+			SubjectCount:  1,
+			Type:          models.RoutingTypeName,
+		},
+		Status: "active",
+	}, nil
+}
+
+func TestProcessEvents(t *testing.T) {
+	natsServer := natsTest.RunServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+	})
+	defer natsServer.Shutdown()
+
+	ctx := context.Background()
+
+	nc, err := client.NewNATSClient(ctx, natsServer.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+
+	_, err = nc.JetStream().CreateStream(ctx, jetstream.StreamConfig{
+		Name:     "testing-http-stream",
+		Subjects: []string{"testing-http"},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(t, err)
+
+	t.Run("publishes events with the source schema version", func(t *testing.T) {
+		proc := processor.NewProcessor(&stubHTTPConfigFetcher{sourceType: internal.HTTPSourceType}, nc, 50, 1000, nil)
+
+		events := [][]byte{
+			[]byte(`{"event_id": "a"}`),
+			[]byte(`{"event_id": "b"}`),
+		}
+		err := proc.ProcessEvents(ctx, "test-pipeline", events)
+		require.NoError(t, err)
+
+		consumer, err := nc.JetStream().CreateOrUpdateConsumer(ctx, "testing-http-stream", jetstream.ConsumerConfig{
+			Name:          "test-consumer",
+			FilterSubject: "testing-http",
+			AckPolicy:     jetstream.AckExplicitPolicy,
+		})
+		require.NoError(t, err)
+
+		msgBatch, err := consumer.FetchNoWait(2)
+		require.NoError(t, err)
+
+		var received []jetstream.Msg
+		for msg := range msgBatch.Messages() {
+			received = append(received, msg)
+		}
+		require.Len(t, received, 2)
+		require.JSONEq(t, `{"event_id": "a"}`, string(received[0].Data()))
+		require.Equal(t, "2", received[0].Headers().Get(internal.SchemaVersionIDHeader))
+	})
+
+	t.Run("rejects pipelines without an http source", func(t *testing.T) {
+		proc := processor.NewProcessor(&stubHTTPConfigFetcher{sourceType: internal.OTLPLogsSourceType}, nc, 50, 1000, nil)
+
+		err := proc.ProcessEvents(ctx, "test-pipeline", [][]byte{[]byte(`{}`)})
+		require.ErrorIs(t, err, models.ErrNotHTTPSource)
+	})
+}
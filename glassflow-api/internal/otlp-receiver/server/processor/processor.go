@@ -162,7 +162,7 @@ func (p *Processor) sendBatch(
 				}
 			}
 
-			messages = setupSchemaVersionHeader(messages)
+			messages = setupSchemaVersionHeader(cfg, messages)
 
 			failedMessages := cfg.nats.WriteBatch(ctx, messages)
 			if len(failedMessages) > 0 {
@@ -248,9 +248,16 @@ func setupNatsDedupHeader(cfg writerConfig, messages []models.Message) ([]models
 	return messages, nil
 }
 
-func setupSchemaVersionHeader(messages []models.Message) []models.Message {
+// setupSchemaVersionHeader stamps the schema version of the pipeline source,
+// OTLP sources have a single predefined version.
+func setupSchemaVersionHeader(cfg writerConfig, messages []models.Message) []models.Message {
+	version := cfg.routingConfig.SchemaVersionID
+	if version == "" {
+		version = "1"
+	}
+
 	for i := range messages {
-		messages[i].SetHeader(internal.SchemaVersionIDHeader, "1")
+		messages[i].SetHeader(internal.SchemaVersionIDHeader, version)
 	}
 
 	return messages
@@ -139,8 +139,19 @@ func TestSetupNatsDedupHeader(t *testing.T) {
 		msg := models.Message{Type: models.MessageTypeNatsMsg}
 		msg.SetPayload([]byte(`{"user_id": "abc123"}`))
 
-		result := setupSchemaVersionHeader([]models.Message{msg})
+		result := setupSchemaVersionHeader(cfg, []models.Message{msg})
 
 		assert.Equal(t, "1", result[0].GetHeader(internal.SchemaVersionIDHeader))
 	})
+
+	t.Run("schema version header follows the source version", func(t *testing.T) {
+		msg := models.Message{Type: models.MessageTypeNatsMsg}
+		msg.SetPayload([]byte(`{"user_id": "abc123"}`))
+
+		versioned := cfg
+		versioned.routingConfig.SchemaVersionID = "3"
+		result := setupSchemaVersionHeader(versioned, []models.Message{msg})
+
+		assert.Equal(t, "3", result[0].GetHeader(internal.SchemaVersionIDHeader))
+	})
 }
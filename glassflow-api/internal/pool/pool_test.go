@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+func TestSlicePool_PutClearsAndResets(t *testing.T) {
+	p := NewSlicePool[*int]("test", "ints")
+
+	s := p.Get(context.Background())
+	require.Empty(t, *s)
+
+	v := 1
+	*s = append(*s, &v, &v)
+	backing := (*s)[:cap(*s)]
+	p.Put(s)
+
+	require.Empty(t, *s)
+	for _, e := range backing {
+		require.Nil(t, e)
+	}
+}
+
+func TestBufferPool_DropsOversizedBuffers(t *testing.T) {
+	p := NewBufferPool("test", "buffers")
+
+	buf := p.Get(context.Background())
+	buf.WriteString("data")
+	p.Put(buf)
+	require.Zero(t, buf.Len())
+
+	large := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	large.WriteString("data")
+	p.Put(large)
+	require.Equal(t, 4, large.Len(), "oversized buffer should not be reset and pooled")
+}
+
+func TestPool_RecordsHitsAndMisses(t *testing.T) {
+	reader := observability.InitMetricsForTesting()
+
+	p := New("test", "counted", func() *int { return new(int) })
+	v := p.Get(context.Background())
+	p.Put(v)
+	p.Get(context.Background())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	results := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != observability.GfMetricPrefix+"_pool_gets_total" {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+			for _, dp := range sum.DataPoints {
+				pool, _ := dp.Attributes.Value("pool")
+				if pool.AsString() != "counted" {
+					continue
+				}
+				result, _ := dp.Attributes.Value("result")
+				results[result.AsString()] += dp.Value
+			}
+		}
+	}
+
+	// sync.Pool may drop values at any time, so only the total is deterministic
+	require.Equal(t, int64(2), results[observability.PoolResultHit]+results[observability.PoolResultMiss])
+	require.GreaterOrEqual(t, results[observability.PoolResultMiss], int64(1))
+}
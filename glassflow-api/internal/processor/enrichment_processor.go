@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+type enricher interface {
+	Enrich(ctx context.Context, payloads [][]byte) ([][]byte, []error, error)
+	Close()
+}
+
+// EnrichmentProcessor adds the columns of a ClickHouse dimension table to the
+// messages. A failed lookup fails the batch, so that it is redelivered instead
+// of written without its enrichment fields.
+type EnrichmentProcessor struct {
+	enricher enricher
+	closeFn  func() error
+}
+
+func NewEnrichmentProcessor(enricher enricher, closeFn func() error) *EnrichmentProcessor {
+	return &EnrichmentProcessor{
+		enricher: enricher,
+		closeFn:  closeFn,
+	}
+}
+
+func (ep *EnrichmentProcessor) ProcessBatch(
+	ctx context.Context,
+	batch ProcessorBatch,
+) ProcessorBatch {
+	if len(batch.Messages) == 0 {
+		return ProcessorBatch{}
+	}
+
+	start := time.Now()
+
+	var inBytes int64
+	payloads := make([][]byte, len(batch.Messages))
+	for i, msg := range batch.Messages {
+		payloads[i] = msg.Payload()
+		inBytes += int64(len(payloads[i]))
+	}
+	observability.RecordBytesProcessed(ctx, "enrichment", "in", inBytes)
+
+	enriched, errs, err := ep.enricher.Enrich(ctx, payloads)
+	if err != nil {
+		return ProcessorBatch{FatalError: err}
+	}
+
+	result := ProcessorBatch{}
+	var outBytes int64
+	for i, message := range batch.Messages {
+		if errs[i] != nil {
+			result.FailedMessages = append(result.FailedMessages, models.FailedMessage{
+				Message: message,
+				Error:   errs[i],
+			})
+			continue
+		}
+
+		message.SetPayload(enriched[i])
+		result.Messages = append(result.Messages, message)
+		outBytes += int64(len(enriched[i]))
+	}
+
+	observability.RecordProcessingDurationWithStage(ctx, "enrichment", "enrichment", time.Since(start).Seconds())
+	if len(result.Messages) > 0 {
+		observability.RecordProcessorMessages(ctx, "enrichment", "success", int64(len(result.Messages)))
+	}
+	if len(result.FailedMessages) > 0 {
+		observability.RecordProcessorMessages(ctx, "enrichment", "error", int64(len(result.FailedMessages)))
+	}
+	observability.RecordBytesProcessed(ctx, "enrichment", "out", outBytes)
+
+	return result
+}
+
+func (ep *EnrichmentProcessor) Close(_ context.Context) error {
+	ep.enricher.Close()
+	if ep.closeFn != nil {
+		return ep.closeFn()
+	}
+	return nil
+}
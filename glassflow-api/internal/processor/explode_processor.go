@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// msgIDHeader is the JetStream header deduplicating the published messages.
+const msgIDHeader = "Nats-Msg-Id"
+
+// ExplodeProcessor emits one message per element of an array field, each a
+// copy of the message with the element in place of the array. The copies
+// get their own Msg-Id, so that JetStream does not drop them as duplicates
+// of each other. Messages without elements are dropped.
+type ExplodeProcessor struct {
+	field      string
+	outputName string
+	indexField string
+}
+
+func NewExplodeProcessor(cfg models.ExplodeConfig) *ExplodeProcessor {
+	return &ExplodeProcessor{
+		field:      cfg.Field,
+		outputName: cfg.OutputName,
+		indexField: cfg.IndexField,
+	}
+}
+
+func (ep *ExplodeProcessor) Close(_ context.Context) error {
+	return nil
+}
+
+func (ep *ExplodeProcessor) ProcessBatch(
+	ctx context.Context,
+	batch ProcessorBatch,
+) ProcessorBatch {
+	if len(batch.Messages) == 0 {
+		return ProcessorBatch{}
+	}
+
+	start := time.Now()
+
+	var inBytes int64
+	for _, msg := range batch.Messages {
+		inBytes += int64(len(msg.Payload()))
+	}
+	observability.RecordBytesProcessed(ctx, "explode", "in", inBytes)
+
+	result := ProcessorBatch{}
+	var empty int64
+	for _, message := range batch.Messages {
+		exploded, err := ep.explode(message)
+		if err != nil {
+			result.FailedMessages = append(result.FailedMessages, models.FailedMessage{
+				Message: message,
+				Error:   err,
+			})
+			continue
+		}
+		if len(exploded) == 0 {
+			empty++
+			continue
+		}
+		result.Messages = append(result.Messages, exploded...)
+	}
+
+	observability.RecordProcessingDurationWithStage(ctx, "explode", "explode", time.Since(start).Seconds())
+	if len(result.Messages) > 0 {
+		observability.RecordProcessorMessages(ctx, "explode", "success", int64(len(result.Messages)))
+	}
+	if empty > 0 {
+		observability.RecordProcessorMessages(ctx, "explode", "filtered", empty)
+	}
+	if len(result.FailedMessages) > 0 {
+		observability.RecordProcessorMessages(ctx, "explode", "error", int64(len(result.FailedMessages)))
+	}
+
+	var outBytes int64
+	for _, msg := range result.Messages {
+		outBytes += int64(len(msg.Payload()))
+	}
+	observability.RecordBytesProcessed(ctx, "explode", "out", outBytes)
+
+	return result
+}
+
+// explode returns the messages of the elements of a message. A missing or
+// null array has no elements.
+func (ep *ExplodeProcessor) explode(message models.Message) ([]models.Message, error) {
+	payload := message.Payload()
+	parsed := gjson.ParseBytes(payload)
+
+	// A literal dotted key is read before a nested path, like the schema
+	// validation
+	path := ep.field
+	if strings.Contains(ep.field, ".") {
+		escaped := strings.ReplaceAll(ep.field, ".", `\.`)
+		if parsed.Get(escaped).Exists() {
+			path = escaped
+		}
+	}
+
+	value := parsed.Get(path)
+	if !value.Exists() || value.Type == gjson.Null {
+		return nil, nil
+	}
+	if !value.IsArray() {
+		return nil, fmt.Errorf("explode field %q is not an array", ep.field)
+	}
+	elements := value.Array()
+	if len(elements) == 0 {
+		return nil, nil
+	}
+
+	outputPath := ep.outputName
+	parent := payload
+	if ep.outputName == ep.field {
+		outputPath = path
+	} else {
+		var err error
+		parent, err = sjson.DeleteBytes(payload, path)
+		if err != nil {
+			return nil, fmt.Errorf("delete explode field %q: %w", ep.field, err)
+		}
+	}
+
+	msgID := message.GetHeader(msgIDHeader)
+	messages := make([]models.Message, 0, len(elements))
+	for i, element := range elements {
+		out, err := sjson.SetRawBytes(parent, outputPath, []byte(element.Raw))
+		if err != nil {
+			return nil, fmt.Errorf("set explode field %q: %w", ep.outputName, err)
+		}
+		if ep.indexField != "" {
+			out, err = sjson.SetBytes(out, ep.indexField, i)
+			if err != nil {
+				return nil, fmt.Errorf("set explode index field %q: %w", ep.indexField, err)
+			}
+		}
+
+		exploded := models.NewNatsMessage(out, message.Headers())
+		if msgID != "" {
+			exploded.SetHeader(msgIDHeader, msgID+"-"+strconv.Itoa(i))
+		}
+		messages = append(messages, exploded)
+	}
+
+	return messages, nil
+}
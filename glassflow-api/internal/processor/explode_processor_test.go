@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestExplodeProcessor_EmitsOneMessagePerElement(t *testing.T) {
+	ep := NewExplodeProcessor(models.ExplodeConfig{Field: "lines", OutputName: "line", IndexField: "line_number"})
+
+	order := models.NewNatsMessage(
+		[]byte(`{"order_id":"o-1","lines":[{"sku":"a"},{"sku":"b"}]}`),
+		map[string][]string{msgIDHeader: {"o-1"}, "trace": {"t"}},
+	)
+	result := ep.ProcessBatch(context.Background(), ProcessorBatch{Messages: []models.Message{
+		order,
+		makeMsg(`{"order_id":"o-2","lines":[]}`),
+		makeMsg(`{"order_id":"o-3"}`),
+		makeMsg(`{"order_id":"o-4","lines":"a"}`),
+	}})
+	require.NoError(t, result.FatalError)
+	require.Len(t, result.FailedMessages, 1, "a field that is not an array fails the message")
+	require.Len(t, result.Messages, 2, "messages without elements are dropped")
+
+	require.JSONEq(t, `{"order_id":"o-1","line":{"sku":"a"},"line_number":0}`, string(result.Messages[0].Payload()))
+	require.JSONEq(t, `{"order_id":"o-1","line":{"sku":"b"},"line_number":1}`, string(result.Messages[1].Payload()))
+	require.Equal(t, "o-1-0", result.Messages[0].GetHeader(msgIDHeader))
+	require.Equal(t, "o-1-1", result.Messages[1].GetHeader(msgIDHeader))
+	require.Equal(t, "t", result.Messages[1].GetHeader("trace"))
+}
+
+func TestExplodeProcessor_ReplacesArrayInPlace(t *testing.T) {
+	ep := NewExplodeProcessor(models.ExplodeConfig{Field: "order.tags", OutputName: "order.tags"})
+
+	result := ep.ProcessBatch(context.Background(), ProcessorBatch{Messages: []models.Message{
+		makeMsg(`{"order":{"id":1,"tags":["new","gift"]}}`),
+	}})
+	require.Len(t, result.Messages, 2)
+	require.JSONEq(t, `{"order":{"id":1,"tags":"new"}}`, string(result.Messages[0].Payload()))
+	require.JSONEq(t, `{"order":{"id":1,"tags":"gift"}}`, string(result.Messages[1].Payload()))
+}
@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// KeepLastProcessor is the dedup of the keep_last mode. Instead of dropping
+// the later events of a key it emits them all with an increasing version, so
+// that a ReplacingMergeTree table keeps the last one. The ingestor routes all
+// the events of a key to the same deduplicator, whose versions only grow.
+type KeepLastProcessor struct {
+	versionField string
+	lastVersion  uint64
+	now          func() time.Time
+}
+
+func NewKeepLastProcessor(versionField string) *KeepLastProcessor {
+	return &KeepLastProcessor{
+		versionField: versionField,
+		now:          time.Now,
+	}
+}
+
+func (kp *KeepLastProcessor) Close(_ context.Context) error {
+	return nil
+}
+
+func (kp *KeepLastProcessor) ProcessBatch(
+	ctx context.Context,
+	batch ProcessorBatch,
+) ProcessorBatch {
+	if len(batch.Messages) == 0 {
+		return ProcessorBatch{}
+	}
+
+	result := ProcessorBatch{}
+	for _, message := range batch.Messages {
+		if !gjson.ValidBytes(message.Payload()) {
+			result.FailedMessages = append(result.FailedMessages, models.FailedMessage{
+				Message: message,
+				Error:   fmt.Errorf("set version field %q: invalid json payload", kp.versionField),
+			})
+			continue
+		}
+
+		payload, err := sjson.SetBytes(message.Payload(), kp.versionField, kp.nextVersion())
+		if err != nil {
+			result.FailedMessages = append(result.FailedMessages, models.FailedMessage{
+				Message: message,
+				Error:   fmt.Errorf("set version field %q: %w", kp.versionField, err),
+			})
+			continue
+		}
+
+		message.SetPayload(payload)
+		result.Messages = append(result.Messages, message)
+	}
+
+	if len(result.Messages) > 0 {
+		observability.RecordProcessorMessages(ctx, "dedup", "versioned", int64(len(result.Messages)))
+	}
+	if len(result.FailedMessages) > 0 {
+		observability.RecordProcessorMessages(ctx, "dedup", "error", int64(len(result.FailedMessages)))
+	}
+
+	return result
+}
+
+// nextVersion returns the current time in nanoseconds, or one more than the
+// last version when the clock did not move forward, so that a later event of
+// a key always supersedes an earlier one, across restarts as well.
+func (kp *KeepLastProcessor) nextVersion() uint64 {
+	version := uint64(kp.now().UnixNano())
+	if version <= kp.lastVersion {
+		version = kp.lastVersion + 1
+	}
+	kp.lastVersion = version
+	return version
+}
@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestKeepLastProcessor_VersionsIncrease(t *testing.T) {
+	kp := NewKeepLastProcessor("_version")
+	now := time.Unix(100, 0)
+	kp.now = func() time.Time { return now }
+
+	result := kp.ProcessBatch(context.Background(), ProcessorBatch{Messages: []models.Message{
+		makeMsg(`{"id":"a","status":"new"}`),
+		makeMsg(`{"id":"a","status":"paid"}`),
+		makeMsg(`not json`),
+	}})
+	require.NoError(t, result.FatalError)
+	require.Len(t, result.Messages, 2)
+	require.Len(t, result.FailedMessages, 1)
+
+	first := gjson.GetBytes(result.Messages[0].Payload(), "_version").Uint()
+	second := gjson.GetBytes(result.Messages[1].Payload(), "_version").Uint()
+	require.Equal(t, uint64(now.UnixNano()), first)
+	require.Equal(t, first+1, second, "a stalled clock still gives a later event a higher version")
+	require.Equal(t, "paid", gjson.GetBytes(result.Messages[1].Payload(), "status").String())
+
+	now = now.Add(time.Second)
+	result = kp.ProcessBatch(context.Background(), ProcessorBatch{Messages: []models.Message{makeMsg(`{"id":"a"}`)}})
+	require.Equal(t, uint64(now.UnixNano()), gjson.GetBytes(result.Messages[0].Payload(), "_version").Uint())
+}
+
+func TestKeepLastProcessor_InvalidPayload(t *testing.T) {
+	kp := NewKeepLastProcessor("_version")
+
+	result := kp.ProcessBatch(context.Background(), ProcessorBatch{Messages: []models.Message{
+		makeMsg(`{"id":"a",`),
+		makeMsg(`not json`),
+	}})
+	require.NoError(t, result.FatalError)
+	require.Empty(t, result.Messages)
+	require.Len(t, result.FailedMessages, 2)
+	for _, failed := range result.FailedMessages {
+		require.ErrorContains(t, failed.Error, `set version field "_version": invalid json payload`)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/batch"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
@@ -184,6 +186,7 @@ func (sc *StreamingComponent) writeWithBackpressure(ctx context.Context, upstrea
 	backoff := internal.IngestorBackpressureInitialDelay
 	for {
 		failed := sc.writer.WriteBatch(ctx, messages)
+		observability.RecordMessagesPublished(ctx, sc.role, int64(len(messages)-len(failed)))
 		if len(failed) == 0 {
 			return nil
 		}
@@ -309,7 +312,18 @@ func (sc *StreamingComponent) ProcessBatch(ctx context.Context, batch []models.M
 		return sc.reader.Ack(ctx, batch)
 	}
 
-	if err = sc.writeWithBackpressure(ctx, batch, messages); err != nil {
+	spans := observability.StartMessageSpans(
+		ctx,
+		observability.SpanNATSPublish,
+		messageTraceCarriers(messages),
+		attribute.String("messaging.system", "nats"),
+		attribute.String("component", sc.role),
+	)
+	publishStart := time.Now()
+	err = sc.writeWithBackpressure(ctx, batch, messages)
+	observability.RecordProcessingDurationWithStage(spans.Context(ctx), sc.role, "publish", time.Since(publishStart).Seconds())
+	spans.End(err)
+	if err != nil {
 		return fmt.Errorf("write batch: %w", err)
 	}
 
@@ -358,3 +372,28 @@ func (sc *StreamingComponent) runProcessors(ctx context.Context, batch []models.
 
 	return current.Messages, commits, nil
 }
+
+// messageTraceCarrier exposes the headers of a message to the trace propagator.
+type messageTraceCarrier struct {
+	msg *models.Message
+}
+
+func (c messageTraceCarrier) Get(key string) string {
+	return c.msg.GetHeader(key)
+}
+
+func (c messageTraceCarrier) Set(key, value string) {
+	c.msg.SetHeader(key, value)
+}
+
+func messageTraceCarriers(messages []models.Message) []observability.TraceCarrier {
+	if !observability.TracingEnabled() {
+		return nil
+	}
+
+	carriers := make([]observability.TraceCarrier, len(messages))
+	for i := range messages {
+		carriers[i] = messageTraceCarrier{msg: &messages[i]}
+	}
+	return carriers
+}
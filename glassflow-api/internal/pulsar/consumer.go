@@ -0,0 +1,359 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// errConsumerClosed is returned when the Pulsar client closed the message
+// channel of the consumer, no message can be received anymore.
+var errConsumerClosed = errors.New("pulsar consumer closed")
+
+// Consumer reads a Pulsar topic through a subscription and hands the messages
+// to the ingestor message processor as Kafka records, so they are published
+// to NATS exactly like Kafka messages.
+type Consumer struct {
+	client       pulsar.Client
+	consumer     pulsar.Consumer
+	topic        string
+	subscription string
+	timeout      time.Duration
+	maxBatchSize int
+	tempFiles    []string
+	log          *slog.Logger
+	cancel       context.CancelFunc
+	closeCh      chan struct{}
+}
+
+func NewConsumer(conn models.PulsarConnectionParamsConfig, topic models.KafkaTopicsConfig, replicaIndex int, log *slog.Logger) (zero *Consumer, _ error) {
+	c := &Consumer{
+		topic:        topic.Name,
+		subscription: topic.ConsumerGroupName,
+		timeout:      internal.DefaultPulsarBatchTimeout,
+		maxBatchSize: internal.PulsarMaxBatchSize,
+		log:          log,
+		closeCh:      make(chan struct{}),
+	}
+
+	clientOpts, err := c.buildClientOptions(conn)
+	if err != nil {
+		c.removeTempFiles()
+		return zero, fmt.Errorf("build client options: %w", err)
+	}
+
+	client, err := pulsar.NewClient(clientOpts)
+	if err != nil {
+		c.removeTempFiles()
+		return zero, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	consumerOpts, err := buildConsumerOptions(topic, replicaIndex)
+	if err != nil {
+		client.Close()
+		c.removeTempFiles()
+		return zero, fmt.Errorf("build consumer options: %w", err)
+	}
+
+	consumer, err := client.Subscribe(consumerOpts)
+	if err != nil {
+		client.Close()
+		c.removeTempFiles()
+		return zero, fmt.Errorf("failed to subscribe to pulsar topic: %w", err)
+	}
+
+	c.client = client
+	c.consumer = consumer
+
+	return c, nil
+}
+
+func (c *Consumer) buildClientOptions(conn models.PulsarConnectionParamsConfig) (pulsar.ClientOptions, error) {
+	opts := pulsar.ClientOptions{
+		URL:                        conn.ServiceURL,
+		OperationTimeout:           internal.PulsarOperationTimeout,
+		TLSAllowInsecureConnection: conn.TLSAllowInsecureConnection,
+	}
+
+	if conn.TLSRoot != "" {
+		path, err := c.writeTempPEM("pulsar-ca-*.pem", conn.TLSRoot)
+		if err != nil {
+			return opts, fmt.Errorf("write tls root: %w", err)
+		}
+		opts.TLSTrustCertsFilePath = path
+	}
+
+	switch {
+	case conn.AuthToken != "":
+		opts.Authentication = pulsar.NewAuthenticationToken(conn.AuthToken)
+	case conn.TLSCert != "":
+		certPath, err := c.writeTempPEM("pulsar-cert-*.pem", conn.TLSCert)
+		if err != nil {
+			return opts, fmt.Errorf("write tls cert: %w", err)
+		}
+		keyPath, err := c.writeTempPEM("pulsar-key-*.pem", conn.TLSKey)
+		if err != nil {
+			return opts, fmt.Errorf("write tls key: %w", err)
+		}
+		opts.Authentication = pulsar.NewAuthenticationTLS(certPath, keyPath)
+	}
+
+	return opts, nil
+}
+
+func buildConsumerOptions(topic models.KafkaTopicsConfig, replicaIndex int) (pulsar.ConsumerOptions, error) {
+	opts := pulsar.ConsumerOptions{
+		Topic:            topic.Name,
+		SubscriptionName: topic.ConsumerGroupName,
+		Name:             fmt.Sprintf("%s-%s-%d", topic.ConsumerGroupName, models.SanitizeNATSSubject(topic.Name), replicaIndex),
+		// Messages are acknowledged once they are published to NATS, the
+		// receiver queue only needs to hold one batch
+		ReceiverQueueSize: internal.PulsarMaxBatchSize,
+	}
+
+	switch topic.SubscriptionType {
+	case internal.PulsarSubscriptionShared, "":
+		opts.Type = pulsar.Shared
+	case internal.PulsarSubscriptionKeyShared:
+		opts.Type = pulsar.KeyShared
+	case internal.PulsarSubscriptionFailover:
+		opts.Type = pulsar.Failover
+	case internal.PulsarSubscriptionExclusive:
+		opts.Type = pulsar.Exclusive
+	default:
+		return opts, fmt.Errorf("unsupported subscription type: %s", topic.SubscriptionType)
+	}
+
+	if topic.ConsumerGroupInitialOffset == internal.InitialOffsetEarliest {
+		opts.SubscriptionInitialPosition = pulsar.SubscriptionPositionEarliest
+	} else {
+		opts.SubscriptionInitialPosition = pulsar.SubscriptionPositionLatest
+	}
+
+	return opts, nil
+}
+
+// writeTempPEM decodes a base64 PEM and writes it to a temporary file, the
+// Pulsar client only loads certificates from files.
+func (c *Consumer) writeTempPEM(pattern, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("base64 decode: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer tmpFile.Close()
+	c.tempFiles = append(c.tempFiles, tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	return tmpFile.Name(), nil
+}
+
+func (c *Consumer) removeTempFiles() {
+	for _, path := range c.tempFiles {
+		os.Remove(path)
+	}
+	c.tempFiles = nil
+}
+
+func (c *Consumer) Start(ctx context.Context, processor kafka.MessageProcessor) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	defer close(c.closeCh)
+
+	c.log.Info("Starting Pulsar consumer",
+		slog.String("topic", c.topic),
+		slog.String("subscription", c.subscription))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		msgs, err := c.collectBatch(ctx)
+		if len(msgs) > 0 {
+			if err := c.processBatch(ctx, processor, msgs); err != nil {
+				return fmt.Errorf("process batch: %w", err)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("collect batch: %w", err)
+		}
+	}
+}
+
+// collectBatch receives messages until the batch is full or the batch timeout
+// passes. Once the message channel is closed it returns the messages received
+// so far with errConsumerClosed, instead of returning empty batches in a loop.
+func (c *Consumer) collectBatch(ctx context.Context) ([]pulsar.Message, error) {
+	msgs := make([]pulsar.Message, 0)
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	for len(msgs) < c.maxBatchSize {
+		select {
+		case <-ctx.Done():
+			c.nack(msgs)
+			return nil, nil
+		case <-timer.C:
+			return msgs, nil
+		case cm, ok := <-c.consumer.Chan():
+			if !ok {
+				return msgs, errConsumerClosed
+			}
+			msgs = append(msgs, cm.Message)
+		}
+	}
+
+	return msgs, nil
+}
+
+func (c *Consumer) processBatch(ctx context.Context, processor kafka.MessageProcessor, msgs []pulsar.Message) error {
+	size := len(msgs)
+	c.log.Info("Processing batch of messages", slog.Int("batchSize", size))
+	start := time.Now()
+
+	records := make([]*kgo.Record, size)
+	var totalBytes int64
+	for i, msg := range msgs {
+		records[i] = c.toRecord(ctx, msg)
+		totalBytes += int64(len(msg.Payload()))
+	}
+
+	lastProcessed, err := processor.ProcessBatch(ctx, records)
+	if err != nil {
+		c.log.Error("Batch processing failed", slog.Any("error", err), slog.Int("batchSize", size))
+
+		// Acknowledge what reached NATS and let the broker redeliver the rest
+		processed := 0
+		if lastProcessed != nil {
+			for i, r := range records {
+				if r == lastProcessed {
+					processed = i + 1
+					break
+				}
+			}
+		}
+		if ackErr := c.ack(msgs[:processed]); ackErr != nil {
+			c.log.Error("Failed to acknowledge processed messages", slog.Any("error", ackErr))
+		}
+		c.nack(msgs[processed:])
+
+		return fmt.Errorf("batch processing failed: %w", err)
+	}
+
+	if err := c.ack(msgs); err != nil {
+		return fmt.Errorf("batch processing failed on acknowledge: %w", err)
+	}
+
+	c.log.Info("Batch processed successfully", slog.Int("batchSize", size), slog.Duration("duration", time.Since(start)))
+
+	observability.RecordKafkaRead(ctx, "ingestor", int64(size))
+	duration := time.Since(start).Seconds()
+	observability.RecordProcessingDurationWithStage(ctx, "ingestor", "batch", duration/float64(size))
+	observability.RecordBytesProcessed(ctx, "ingestor", "in", totalBytes)
+
+	return nil
+}
+
+// toRecord translates a Pulsar message into the Kafka record layout expected
+// by the message processor. Message properties become headers and the
+// offset is derived from the message id, so the ingest sequence used for
+// NATS deduplication stays stable across redeliveries.
+func (c *Consumer) toRecord(ctx context.Context, msg pulsar.Message) *kgo.Record {
+	id := msg.ID()
+
+	timestamp := msg.EventTime()
+	if timestamp.IsZero() {
+		timestamp = msg.PublishTime()
+	}
+
+	record := &kgo.Record{
+		Topic:     c.topic,
+		Partition: id.PartitionIdx(),
+		Offset:    messageOffset(id),
+		Timestamp: timestamp,
+		Value:     msg.Payload(),
+	}
+	if msg.Key() != "" {
+		record.Key = []byte(msg.Key())
+	}
+
+	props := msg.Properties()
+	for k, v := range props {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+
+	if observability.TracingEnabled() {
+		record.Context = observability.ExtractTraceContext(ctx, propagation.MapCarrier(props))
+	}
+
+	return record
+}
+
+// messageOffset maps a Pulsar message id onto a non-negative Kafka offset.
+func messageOffset(id pulsar.MessageID) int64 {
+	var buf [20]byte
+	binary.BigEndian.PutUint64(buf[0:8], uint64(id.LedgerID()))   //nolint:gosec // ids are only hashed
+	binary.BigEndian.PutUint64(buf[8:16], uint64(id.EntryID()))   //nolint:gosec // ids are only hashed
+	binary.BigEndian.PutUint32(buf[16:20], uint32(id.BatchIdx())) //nolint:gosec // ids are only hashed
+
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return int64(h.Sum64() & math.MaxInt64) //nolint:gosec // masked to int64 range
+}
+
+func (c *Consumer) ack(msgs []pulsar.Message) error {
+	var errs []error
+	for _, msg := range msgs {
+		if err := c.consumer.Ack(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Consumer) nack(msgs []pulsar.Message) {
+	for _, msg := range msgs {
+		c.consumer.Nack(msg)
+	}
+}
+
+func (c *Consumer) Close() error {
+	c.log.Info("Closing Pulsar consumer", slog.String("subscription", c.subscription))
+
+	if c.cancel != nil {
+		c.cancel()
+		<-c.closeCh
+	}
+
+	c.consumer.Close()
+	c.client.Close()
+	c.removeTempFiles()
+
+	return nil
+}
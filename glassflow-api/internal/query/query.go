@@ -0,0 +1,502 @@
+// Package query parses the SQL-like statement a pipeline can be defined with,
+// SELECT a, upper(b) AS b2 FROM topic WHERE x > 10, into the expressions of
+// the filter and the stateless transformation.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Statement is a parsed SELECT statement. Its expressions use the syntax of
+// the filter and the transformation expressions.
+type Statement struct {
+	// From is the source the statement reads, a source_id or a topic
+	From string
+	// Columns are the selected columns in order
+	Columns []Column
+	// Where is the filter expression, empty without WHERE clause
+	Where string
+}
+
+// Column is a selected column. Star columns select all the fields of the
+// source, plain field references have Field set.
+type Column struct {
+	Star       bool
+	Field      string
+	Expression string
+	Name       string
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+func (t token) isIdent() bool {
+	return t.kind == tokenQuotedIdent || (t.kind == tokenWord && !isKeyword(t.text))
+}
+
+var keywords = map[string]bool{
+	"select": true, "from": true, "where": true, "as": true,
+	"and": true, "or": true, "not": true, "is": true, "null": true,
+	"in": true, "true": true, "false": true, "cast": true, "like": true,
+}
+
+// unsupportedKeywords are the SQL clauses a pipeline cannot run
+var unsupportedKeywords = map[string]bool{
+	"join": true, "group": true, "order": true, "limit": true, "having": true,
+	"union": true, "distinct": true, "offset": true,
+}
+
+func isKeyword(word string) bool {
+	return keywords[strings.ToLower(word)]
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// Parse parses a SELECT statement.
+func Parse(statement string) (zero Statement, _ error) {
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return zero, err
+	}
+	if len(tokens) == 0 || !tokens[0].is("select") {
+		return zero, fmt.Errorf("query must start with SELECT")
+	}
+	if err := checkBalanced(tokens); err != nil {
+		return zero, err
+	}
+
+	from := indexAtDepth(tokens, 1, func(t token) bool { return t.is("from") })
+	if from < 0 {
+		return zero, fmt.Errorf("query has no FROM clause")
+	}
+	if from+1 >= len(tokens) || !tokens[from+1].isIdent() {
+		return zero, fmt.Errorf("FROM must name the source of the query")
+	}
+
+	var out Statement
+	out.From = tokens[from+1].text
+
+	rest := tokens[from+2:]
+	if len(rest) > 0 {
+		if !rest[0].is("where") {
+			return zero, fmt.Errorf("unexpected %q after FROM %s", rest[0].text, out.From)
+		}
+		if len(rest) == 1 {
+			return zero, fmt.Errorf("WHERE clause is empty")
+		}
+		out.Where, err = translate(rest[1:])
+		if err != nil {
+			return zero, fmt.Errorf("WHERE: %w", err)
+		}
+	}
+
+	items := splitAtDepth(tokens[1:from])
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		column, err := parseColumn(item)
+		if err != nil {
+			return zero, err
+		}
+		if column.Name != "" {
+			if seen[column.Name] {
+				return zero, fmt.Errorf("column %q is selected twice", column.Name)
+			}
+			seen[column.Name] = true
+		}
+		out.Columns = append(out.Columns, column)
+	}
+
+	return out, nil
+}
+
+func parseColumn(tokens []token) (zero Column, _ error) {
+	if len(tokens) == 0 {
+		return zero, fmt.Errorf("SELECT has an empty column")
+	}
+	if len(tokens) == 1 && tokens[0].isSymbol("*") {
+		return Column{Star: true}, nil
+	}
+
+	var alias string
+	if n := len(tokens); n >= 3 && tokens[n-2].is("as") {
+		if !tokens[n-1].isIdent() || strings.Contains(tokens[n-1].text, ".") {
+			return zero, fmt.Errorf("invalid column alias %q", tokens[n-1].text)
+		}
+		alias = tokens[n-1].text
+		tokens = tokens[:n-2]
+	}
+
+	expression, err := translate(tokens)
+	if err != nil {
+		return zero, fmt.Errorf("column %s: %w", joinTokens(tokens), err)
+	}
+
+	column := Column{Expression: expression, Name: alias}
+	if len(tokens) == 1 && tokens[0].isIdent() {
+		column.Field = tokens[0].text
+		if column.Name == "" {
+			column.Name = column.Field
+		}
+	}
+	if column.Name == "" {
+		return zero, fmt.Errorf("column %s needs an alias, add AS <name>", joinTokens(tokens))
+	}
+	return column, nil
+}
+
+// translate turns a SQL expression into the expression syntax of the
+// pipeline.
+func translate(tokens []token) (string, error) {
+	var b exprBuilder
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.kind {
+		case tokenQuotedIdent:
+			b.writeIdent(t.text)
+		case tokenNumber:
+			b.write(t.text)
+		case tokenString:
+			b.write(strconv.Quote(t.text))
+		case tokenSymbol:
+			switch t.text {
+			case "=":
+				b.write("==")
+			case "<>":
+				b.write("!=")
+			case "||":
+				b.write("+")
+			default:
+				b.write(t.text)
+			}
+		case tokenWord:
+			if unsupportedKeywords[strings.ToLower(t.text)] {
+				return "", fmt.Errorf("%s is not supported", strings.ToUpper(t.text))
+			}
+			if !isKeyword(t.text) {
+				b.writeIdent(t.text)
+				continue
+			}
+			switch strings.ToLower(t.text) {
+			case "and", "or", "not", "true", "false":
+				b.write(strings.ToLower(t.text))
+			case "null":
+				b.write("nil")
+			case "is":
+				negate := i+1 < len(tokens) && tokens[i+1].is("not")
+				if negate {
+					i++
+				}
+				if i+1 >= len(tokens) || !tokens[i+1].is("null") {
+					return "", fmt.Errorf("IS must be followed by NULL or NOT NULL")
+				}
+				i++
+				if negate {
+					b.write("!= nil")
+				} else {
+					b.write("== nil")
+				}
+			case "in":
+				end, err := closingParen(tokens, i+1)
+				if err != nil {
+					return "", fmt.Errorf("IN: %w", err)
+				}
+				list, err := translate(tokens[i+2 : end])
+				if err != nil {
+					return "", err
+				}
+				b.write("in [" + list + "]")
+				i = end
+			case "like":
+				if i+1 >= len(tokens) || tokens[i+1].kind != tokenString {
+					return "", fmt.Errorf("LIKE must be followed by a string pattern")
+				}
+				i++
+				b.write("matches " + strconv.Quote(likePattern(tokens[i].text)))
+			case "cast":
+				end, err := closingParen(tokens, i+1)
+				if err != nil {
+					return "", fmt.Errorf("CAST: %w", err)
+				}
+				cast, err := translateCast(tokens[i+2 : end])
+				if err != nil {
+					return "", err
+				}
+				b.write(cast)
+				i = end
+			default:
+				return "", fmt.Errorf("unexpected %s", strings.ToUpper(t.text))
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// translateCast translates the inside of CAST(x AS type) into a type
+// conversion.
+func translateCast(tokens []token) (string, error) {
+	as := -1
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].is("as") {
+			as = i
+			break
+		}
+	}
+	if as <= 0 || as != len(tokens)-2 {
+		return "", fmt.Errorf("CAST must be written CAST(expression AS type)")
+	}
+
+	var conversion string
+	switch strings.ToLower(tokens[as+1].text) {
+	case "string", "text", "varchar", "char":
+		conversion = "string"
+	case "int", "integer", "bigint", "smallint", "int32", "int64":
+		conversion = "int"
+	case "float", "double", "real", "decimal", "float32", "float64":
+		conversion = "float"
+	default:
+		return "", fmt.Errorf("CAST to %s is not supported, use string, int or float", tokens[as+1].text)
+	}
+
+	inner, err := translate(tokens[:as])
+	if err != nil {
+		return "", err
+	}
+	return conversion + "(" + inner + ")", nil
+}
+
+// likePattern turns a LIKE pattern into an anchored regular expression.
+func likePattern(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// exprBuilder joins translated tokens with spaces, except inside
+// parentheses and brackets, before commas and between a function and its
+// arguments.
+type exprBuilder struct {
+	b     strings.Builder
+	last  string
+	ident bool
+}
+
+func (e *exprBuilder) write(s string) {
+	if e.b.Len() > 0 && e.last != "(" && e.last != "[" &&
+		s != ")" && s != "]" && s != "," && !(s == "(" && e.ident) {
+		e.b.WriteByte(' ')
+	}
+	e.b.WriteString(s)
+	e.last = s
+	e.ident = false
+}
+
+func (e *exprBuilder) writeIdent(s string) {
+	e.write(s)
+	e.ident = true
+}
+
+func (e *exprBuilder) String() string {
+	return e.b.String()
+}
+
+// checkBalanced checks that the parentheses and brackets of the statement
+// are balanced.
+func checkBalanced(tokens []token) error {
+	var open []string
+	for _, t := range tokens {
+		switch {
+		case t.isSymbol("("):
+			open = append(open, ")")
+		case t.isSymbol("["):
+			open = append(open, "]")
+		case t.isSymbol(")") || t.isSymbol("]"):
+			if len(open) == 0 || open[len(open)-1] != t.text {
+				return fmt.Errorf("unbalanced parentheses")
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+	return nil
+}
+
+// closingParen returns the index of the parenthesis closing the one at open.
+func closingParen(tokens []token, open int) (int, error) {
+	if open >= len(tokens) || !tokens[open].isSymbol("(") {
+		return 0, fmt.Errorf("expected (")
+	}
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].isSymbol("("):
+			depth++
+		case tokens[i].isSymbol(")"):
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("missing )")
+}
+
+// indexAtDepth returns the index of the first token from start outside of
+// parentheses and brackets that matches, -1 when none does.
+func indexAtDepth(tokens []token, start int, match func(token) bool) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.isSymbol("(") || t.isSymbol("["):
+			depth++
+		case t.isSymbol(")") || t.isSymbol("]"):
+			depth--
+		case depth == 0 && match(t):
+			return i
+		}
+	}
+	return -1
+}
+
+// splitAtDepth splits tokens at the commas outside of parentheses and
+// brackets.
+func splitAtDepth(tokens []token) [][]token {
+	var items [][]token
+	for {
+		comma := indexAtDepth(tokens, 0, func(t token) bool { return t.isSymbol(",") })
+		if comma < 0 {
+			return append(items, tokens)
+		}
+		items = append(items, tokens[:comma])
+		tokens = tokens[comma+1:]
+	}
+}
+
+func joinTokens(tokens []token) string {
+	texts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		texts = append(texts, t.text)
+	}
+	return strings.Join(texts, " ")
+}
+
+func tokenize(statement string) ([]token, error) {
+	var tokens []token
+	runes := []rune(statement)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			word := string(runes[start:i])
+			if !identPattern.MatchString(word) {
+				return nil, fmt.Errorf("invalid identifier %q at position %d", word, start)
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: word})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '.' || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			number := string(runes[start:i])
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", number, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: number})
+		case r == '\'':
+			start := i
+			var b strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						b.WriteRune('\'')
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String()})
+		case r == '"' || r == '`':
+			start := i
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated identifier at position %d", start)
+			}
+			ident := string(runes[start+1 : end])
+			if !identPattern.MatchString(ident) {
+				return nil, fmt.Errorf("invalid identifier %q at position %d", ident, start)
+			}
+			tokens = append(tokens, token{kind: tokenQuotedIdent, text: ident})
+			i = end + 1
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "==", "||":
+					symbol = two
+				}
+			}
+			switch symbol {
+			case "<=", ">=", "<>", "!=", "==", "||", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", "[", "]":
+			default:
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol})
+			i += len([]rune(symbol))
+		}
+	}
+	return tokens, nil
+}
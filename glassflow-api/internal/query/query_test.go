@@ -0,0 +1,74 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	stmt, err := Parse(`SELECT id, upper(name) AS name_upper, CAST(amount AS int) AS amount, "user.email"
+		FROM orders WHERE amount > 10 AND status = 'paid' AND country IN ('DE', 'FR') AND note IS NOT NULL`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "orders", stmt.From)
+	assert.Equal(t, `amount > 10 and status == "paid" and country in ["DE", "FR"] and note != nil`, stmt.Where)
+	assert.Equal(t, []Column{
+		{Field: "id", Expression: "id", Name: "id"},
+		{Expression: "upper(name)", Name: "name_upper"},
+		{Expression: "int(amount)", Name: "amount"},
+		{Field: "user.email", Expression: "user.email", Name: "user.email"},
+	}, stmt.Columns)
+}
+
+func TestParse_Expressions(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{where: `a <> 1 OR NOT b`, want: `a != 1 or not b`},
+		{where: `a IS NULL`, want: `a == nil`},
+		{where: `name LIKE 'jo_n%'`, want: `name matches "^jo.n.*$"`},
+		{where: `(a + b) * 2 >= 10`, want: `(a + b) * 2 >= 10`},
+		{where: `first || ' ' || last = 'it''s'`, want: `first + " " + last == "it's"`},
+		{where: `country NOT IN ('US')`, want: `country not in ["US"]`},
+		{where: `flag = TRUE`, want: `flag == true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			stmt, err := Parse("SELECT * FROM events WHERE " + tt.where)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stmt.Where)
+			assert.Equal(t, []Column{{Star: true}}, stmt.Columns)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		statement string
+		errMsg    string
+	}{
+		{statement: `id FROM orders`, errMsg: "must start with SELECT"},
+		{statement: `SELECT id`, errMsg: "no FROM clause"},
+		{statement: `SELECT id FROM orders ORDER BY id`, errMsg: `unexpected "ORDER"`},
+		{statement: `SELECT a + b FROM orders`, errMsg: "needs an alias"},
+		{statement: `SELECT a, b AS a FROM orders`, errMsg: "selected twice"},
+		{statement: `SELECT CAST(a AS date) AS d FROM orders`, errMsg: "CAST to date is not supported"},
+		{statement: `SELECT id FROM orders WHERE name = 'x`, errMsg: "unterminated string"},
+		{statement: `SELECT id FROM orders WHERE a IS 1`, errMsg: "IS must be followed by NULL"},
+		{statement: `SELECT upper(name AS n FROM orders`, errMsg: "unbalanced parentheses"},
+		{statement: `SELECT id FROM orders WHERE a ; b`, errMsg: "unexpected character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.statement, func(t *testing.T) {
+			_, err := Parse(tt.statement)
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tt.errMsg), "error %q should contain %q", err, tt.errMsg)
+		})
+	}
+}
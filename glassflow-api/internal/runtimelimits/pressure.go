@@ -0,0 +1,64 @@
+package runtimelimits
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+)
+
+// MemoryUsageRatio returns the memory mapped by the Go runtime, minus what was
+// returned to the OS, as a share of GOMEMLIMIT. It is 0 without a limit.
+func MemoryUsageRatio() float64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+
+	used := samples[0].Value.Uint64() - samples[1].Value.Uint64()
+	return float64(used) / float64(limit)
+}
+
+// CPUPressure returns the share of the last 10 seconds in which some tasks of
+// the cgroup were stalled waiting for CPU, read from cgroup v2 PSI. It is 0
+// when PSI is not available.
+func CPUPressure() float64 {
+	return cpuPressure(defaultCgroupRoot)
+}
+
+func cpuPressure(root string) float64 {
+	data, err := os.ReadFile(filepath.Join(root, "cpu.pressure"))
+	if err != nil {
+		return 0
+	}
+
+	// some avg10=1.23 avg60=0.50 avg300=0.10 total=12345
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 2 || string(fields[0]) != "some" {
+			continue
+		}
+		value, ok := bytes.CutPrefix(fields[1], []byte("avg10="))
+		if !ok {
+			return 0
+		}
+		percent, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return 0
+		}
+		return percent / 100
+	}
+
+	return 0
+}
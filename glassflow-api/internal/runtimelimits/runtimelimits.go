@@ -0,0 +1,116 @@
+// Package runtimelimits sizes the Go runtime to the container it runs in:
+// GOMAXPROCS from the cgroup CPU quota and the soft memory limit from the
+// cgroup memory limit. Explicit GOMAXPROCS and GOMEMLIMIT environment
+// variables, as set by the orchestrator, always take precedence.
+package runtimelimits
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"go.uber.org/automaxprocs/maxprocs"
+)
+
+const (
+	defaultCgroupRoot = "/sys/fs/cgroup"
+
+	// cgroup v1 reports "no limit" as a page-aligned value close to MaxInt64
+	cgroupV1Unlimited = int64(1) << 62
+
+	MemoryLimitSourceEnv     = "GOMEMLIMIT"
+	MemoryLimitSourceCgroup  = "cgroup"
+	MemoryLimitSourceRuntime = "runtime"
+)
+
+type Config struct {
+	// AutoMaxProcs sets GOMAXPROCS from the cgroup CPU quota.
+	AutoMaxProcs bool
+	// MemoryLimitRatio is the share of the cgroup memory limit used as the
+	// soft memory limit, 0 disables it.
+	MemoryLimitRatio float64
+}
+
+// Apply configures GOMAXPROCS and the soft memory limit and logs the
+// effective values.
+func Apply(cfg Config, log *slog.Logger) error {
+	return apply(cfg, log, defaultCgroupRoot)
+}
+
+func apply(cfg Config, log *slog.Logger, cgroupRoot string) error {
+	if cfg.MemoryLimitRatio < 0 || cfg.MemoryLimitRatio > 1 {
+		return fmt.Errorf("memory limit ratio must be between 0 and 1, got %v", cfg.MemoryLimitRatio)
+	}
+
+	if cfg.AutoMaxProcs {
+		// maxprocs leaves an explicit GOMAXPROCS untouched
+		_, err := maxprocs.Set(maxprocs.Logger(func(format string, args ...any) {
+			log.Debug(fmt.Sprintf(format, args...))
+		}))
+		if err != nil {
+			log.Warn("failed to set GOMAXPROCS from the CPU quota", slog.Any("error", err))
+		}
+	}
+
+	memoryLimitSource := MemoryLimitSourceRuntime
+	switch {
+	case os.Getenv("GOMEMLIMIT") != "":
+		// applied by the runtime at startup
+		memoryLimitSource = MemoryLimitSourceEnv
+	case cfg.MemoryLimitRatio > 0:
+		limit, ok, err := cgroupMemoryLimit(cgroupRoot)
+		if err != nil {
+			log.Warn("failed to read the cgroup memory limit", slog.Any("error", err))
+			break
+		}
+		if ok {
+			debug.SetMemoryLimit(int64(float64(limit) * cfg.MemoryLimitRatio))
+			memoryLimitSource = MemoryLimitSourceCgroup
+		}
+	}
+
+	log.Info("Runtime limits",
+		slog.Int("gomaxprocs", runtime.GOMAXPROCS(0)),
+		slog.Int("num_cpu", runtime.NumCPU()),
+		slog.Int64("gomemlimit_bytes", debug.SetMemoryLimit(-1)),
+		slog.String("gomemlimit_source", memoryLimitSource),
+	)
+
+	return nil
+}
+
+// cgroupMemoryLimit returns the memory limit of the current cgroup, reading
+// cgroup v2 first and falling back to v1. ok is false when there is no limit.
+func cgroupMemoryLimit(root string) (limit int64, ok bool, _ error) {
+	data, err := os.ReadFile(filepath.Join(root, "memory.max"))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("read cgroup memory limit: %w", err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false, nil
+	}
+
+	limit, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse cgroup memory limit %q: %w", value, err)
+	}
+	if limit <= 0 || limit >= cgroupV1Unlimited {
+		return 0, false, nil
+	}
+
+	return limit, true, nil
+}
@@ -0,0 +1,119 @@
+package runtimelimits
+
+import (
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeCgroupFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantLimit int64
+		wantOK    bool
+		wantErr   bool
+	}{
+		{
+			name:      "cgroup v2 limit",
+			files:     map[string]string{"memory.max": "1610612736\n"},
+			wantLimit: 1610612736,
+			wantOK:    true,
+		},
+		{
+			name:  "cgroup v2 unlimited",
+			files: map[string]string{"memory.max": "max\n"},
+		},
+		{
+			name:      "cgroup v1 limit",
+			files:     map[string]string{"memory/memory.limit_in_bytes": "536870912\n"},
+			wantLimit: 536870912,
+			wantOK:    true,
+		},
+		{
+			name:  "cgroup v1 unlimited",
+			files: map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"},
+		},
+		{
+			name: "no cgroup files",
+		},
+		{
+			name:    "invalid value",
+			files:   map[string]string{"memory.max": "lots"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				writeCgroupFile(t, root, name, content)
+			}
+
+			limit, ok, err := cgroupMemoryLimit(root)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func TestApply_MemoryLimitFromCgroup(t *testing.T) {
+	t.Setenv("GOMEMLIMIT", "")
+	previous := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() { debug.SetMemoryLimit(previous) })
+
+	root := t.TempDir()
+	writeCgroupFile(t, root, "memory.max", "1000000000")
+
+	err := apply(Config{MemoryLimitRatio: 0.9}, slog.Default(), root)
+	require.NoError(t, err)
+	require.Equal(t, int64(900000000), debug.SetMemoryLimit(-1))
+}
+
+func TestApply_InvalidRatio(t *testing.T) {
+	err := apply(Config{MemoryLimitRatio: 1.5}, slog.Default(), t.TempDir())
+	require.Error(t, err)
+}
+
+func TestCPUPressure(t *testing.T) {
+	root := t.TempDir()
+	require.Zero(t, cpuPressure(root))
+
+	writeCgroupFile(t, root, "cpu.pressure",
+		"some avg10=42.50 avg60=10.00 avg300=1.00 total=123456\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	require.InDelta(t, 0.425, cpuPressure(root), 1e-9)
+
+	writeCgroupFile(t, root, "cpu.pressure", "some avg10=oops\n")
+	require.Zero(t, cpuPressure(root))
+}
+
+func TestMemoryUsageRatio(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() { debug.SetMemoryLimit(previous) })
+
+	debug.SetMemoryLimit(math.MaxInt64)
+	require.Zero(t, MemoryUsageRatio())
+
+	debug.SetMemoryLimit(1 << 40)
+	ratio := MemoryUsageRatio()
+	require.Greater(t, ratio, 0.0)
+	require.Less(t, ratio, 1.0)
+}
@@ -0,0 +1,664 @@
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+const (
+	avroTypeNull    = "null"
+	avroTypeBoolean = "boolean"
+	avroTypeInt     = "int"
+	avroTypeLong    = "long"
+	avroTypeFloat   = "float"
+	avroTypeDouble  = "double"
+	avroTypeBytes   = "bytes"
+	avroTypeString  = "string"
+	avroTypeRecord  = "record"
+	avroTypeError   = "error"
+	avroTypeEnum    = "enum"
+	avroTypeArray   = "array"
+	avroTypeMap     = "map"
+	avroTypeFixed   = "fixed"
+	avroTypeUnion   = "union"
+)
+
+const (
+	avroLogicalDecimal         = "decimal"
+	avroLogicalUUID            = "uuid"
+	avroLogicalDate            = "date"
+	avroLogicalTimestampMillis = "timestamp-millis"
+	avroLogicalTimestampMicros = "timestamp-micros"
+	avroLogicalLocalMillis     = "local-timestamp-millis"
+	avroLogicalLocalMicros     = "local-timestamp-micros"
+)
+
+var errAvroShortBuffer = errors.New("unexpected end of avro data")
+
+// avroSchema is a parsed Avro writer schema node.
+type avroSchema struct {
+	typ      string
+	name     string        // full name for named types (record, enum, fixed)
+	fields   []avroField   // record
+	items    *avroSchema   // array
+	values   *avroSchema   // map
+	branches []*avroSchema // union
+	symbols  []string      // enum
+	size     int           // fixed
+
+	// logical is the logical type annotation, empty when absent or not
+	// valid for the underlying type. scale only applies to decimals.
+	logical string
+	scale   int
+}
+
+type avroField struct {
+	name   string
+	schema *avroSchema
+}
+
+type avroParser struct {
+	names map[string]*avroSchema
+}
+
+// parseAvroSchema parses an Avro schema definition as stored in the schema registry.
+func parseAvroSchema(schema string) (*avroSchema, error) {
+	var raw any
+	if err := json.Unmarshal([]byte(schema), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidSchema, err)
+	}
+
+	p := &avroParser{names: make(map[string]*avroSchema)}
+	return p.parse(raw, "")
+}
+
+func (p *avroParser) parse(raw any, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		if isAvroPrimitive(v) {
+			return &avroSchema{typ: v}, nil
+		}
+		if named, ok := p.lookup(v, namespace); ok {
+			return named, nil
+		}
+		return nil, fmt.Errorf("%w: unknown avro type %q", models.ErrInvalidSchema, v)
+	case []any:
+		union := &avroSchema{typ: avroTypeUnion, branches: make([]*avroSchema, 0, len(v))}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union.branches = append(union.branches, s)
+		}
+		return union, nil
+	case map[string]any:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("%w: unexpected avro schema node %T", models.ErrInvalidSchema, raw)
+	}
+}
+
+func (p *avroParser) parseComplex(v map[string]any, namespace string) (*avroSchema, error) {
+	typ, ok := v["type"].(string)
+	if !ok {
+		// {"type": {...}} or {"type": [...]} wraps another schema
+		return p.parse(v["type"], namespace)
+	}
+
+	switch typ {
+	case avroTypeRecord, avroTypeError:
+		s, ns, err := p.define(v, avroTypeRecord, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		rawFields, ok := v["fields"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: record %s has no fields", models.ErrInvalidSchema, s.name)
+		}
+
+		for _, rf := range rawFields {
+			f, ok := rf.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid field in record %s", models.ErrInvalidSchema, s.name)
+			}
+			name, _ := f["name"].(string)
+			if name == "" {
+				return nil, fmt.Errorf("%w: field without name in record %s", models.ErrInvalidSchema, s.name)
+			}
+			fs, err := p.parse(f["type"], ns)
+			if err != nil {
+				return nil, err
+			}
+			s.fields = append(s.fields, avroField{name: name, schema: fs})
+		}
+		return s, nil
+	case avroTypeEnum:
+		s, _, err := p.define(v, avroTypeEnum, namespace)
+		if err != nil {
+			return nil, err
+		}
+		rawSymbols, _ := v["symbols"].([]any)
+		for _, sym := range rawSymbols {
+			str, ok := sym.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid symbol in enum %s", models.ErrInvalidSchema, s.name)
+			}
+			s.symbols = append(s.symbols, str)
+		}
+		return s, nil
+	case avroTypeFixed:
+		s, _, err := p.define(v, avroTypeFixed, namespace)
+		if err != nil {
+			return nil, err
+		}
+		size, ok := v["size"].(float64)
+		if !ok || size < 0 {
+			return nil, fmt.Errorf("%w: invalid size for fixed %s", models.ErrInvalidSchema, s.name)
+		}
+		s.size = int(size)
+		annotateAvroLogical(s, v)
+		return s, nil
+	case avroTypeArray:
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{typ: avroTypeArray, items: items}, nil
+	case avroTypeMap:
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{typ: avroTypeMap, values: values}, nil
+	default:
+		// primitive types, optionally annotated with a logical type
+		s, err := p.parse(typ, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if isAvroPrimitive(typ) {
+			annotateAvroLogical(s, v)
+		}
+		return s, nil
+	}
+}
+
+// annotateAvroLogical records the logical type of a primitive or fixed
+// schema. As the specification requires, logical types that are unknown or
+// invalid for the underlying type are ignored and the value is read as the
+// underlying type.
+func annotateAvroLogical(s *avroSchema, v map[string]any) {
+	logical, _ := v["logicalType"].(string)
+
+	switch logical {
+	case avroLogicalDecimal:
+		if s.typ != avroTypeBytes && s.typ != avroTypeFixed {
+			return
+		}
+		precision, _ := v["precision"].(float64)
+		scale, _ := v["scale"].(float64)
+		if precision < 1 || scale < 0 || scale > precision {
+			return
+		}
+		s.scale = int(scale)
+	case avroLogicalUUID:
+		if s.typ != avroTypeString && (s.typ != avroTypeFixed || s.size != 16) {
+			return
+		}
+	case avroLogicalDate:
+		if s.typ != avroTypeInt {
+			return
+		}
+	case avroLogicalTimestampMillis, avroLogicalTimestampMicros, avroLogicalLocalMillis, avroLogicalLocalMicros:
+		if s.typ != avroTypeLong {
+			return
+		}
+	default:
+		return
+	}
+
+	s.logical = logical
+}
+
+// define registers a named type before its body is parsed so that
+// recursive references resolve. It returns the namespace for nested types.
+func (p *avroParser) define(v map[string]any, typ, namespace string) (*avroSchema, string, error) {
+	name, _ := v["name"].(string)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: %s without name", models.ErrInvalidSchema, typ)
+	}
+
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+
+	fullName := name
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		namespace = name[:idx]
+	} else if namespace != "" {
+		fullName = namespace + "." + name
+	}
+
+	s := &avroSchema{typ: typ, name: fullName}
+	p.names[fullName] = s
+
+	return s, namespace, nil
+}
+
+func (p *avroParser) lookup(name, namespace string) (*avroSchema, bool) {
+	if s, ok := p.names[name]; ok {
+		return s, true
+	}
+	if namespace != "" {
+		s, ok := p.names[namespace+"."+name]
+		return s, ok
+	}
+	return nil, false
+}
+
+func isAvroPrimitive(typ string) bool {
+	switch typ {
+	case avroTypeNull, avroTypeBoolean, avroTypeInt, avroTypeLong, avroTypeFloat, avroTypeDouble, avroTypeBytes, avroTypeString:
+		return true
+	default:
+		return false
+	}
+}
+
+// avroFields flattens a record schema into fields using dot notation for
+// nested records. Fields with types that have no source type equivalent
+// (non-nullable unions, null) are skipped.
+func avroFields(s *avroSchema) ([]models.Field, error) {
+	if s.typ != avroTypeRecord {
+		return nil, models.ErrInvalidSchema
+	}
+
+	fields := make([]models.Field, 0, len(s.fields))
+	appendAvroFields(&fields, s, "", map[*avroSchema]bool{})
+
+	return fields, nil
+}
+
+func appendAvroFields(fields *[]models.Field, record *avroSchema, prefix string, visiting map[*avroSchema]bool) {
+	visiting[record] = true
+	defer delete(visiting, record)
+
+	for _, f := range record.fields {
+		name := prefix + f.name
+		fs := nullableBranch(f.schema)
+		if fs == nil {
+			continue
+		}
+
+		if fs.typ == avroTypeRecord {
+			if visiting[fs] {
+				continue // recursive records cannot be flattened
+			}
+			appendAvroFields(fields, fs, name+".", visiting)
+			continue
+		}
+
+		fieldType, ok := avroSourceType(fs)
+		if !ok {
+			continue
+		}
+		*fields = append(*fields, models.Field{Name: name, Type: fieldType})
+	}
+}
+
+// nullableBranch returns the non-null branch of a ["null", T] union, the
+// schema itself for non-union types, and nil for any other union.
+func nullableBranch(s *avroSchema) *avroSchema {
+	if s.typ != avroTypeUnion {
+		return s
+	}
+
+	var branch *avroSchema
+	for _, b := range s.branches {
+		if b.typ == avroTypeNull {
+			continue
+		}
+		if branch != nil {
+			return nil
+		}
+		branch = b
+	}
+	return branch
+}
+
+func avroSourceType(s *avroSchema) (string, bool) {
+	if s.logical != "" {
+		// decimals, dates, timestamps and uuids are decoded to strings
+		return internal.KafkaTypeString, true
+	}
+
+	switch s.typ {
+	case avroTypeString, avroTypeEnum:
+		return internal.KafkaTypeString, true
+	case avroTypeBytes, avroTypeFixed:
+		return "bytes", true
+	case avroTypeBoolean:
+		return internal.KafkaTypeBool, true
+	case avroTypeInt:
+		return "int32", true
+	case avroTypeLong:
+		return "int64", true
+	case avroTypeFloat:
+		return "float32", true
+	case avroTypeDouble:
+		return "float64", true
+	case avroTypeArray:
+		return internal.KafkaTypeArray, true
+	case avroTypeMap:
+		return internal.KafkaTypeMap, true
+	default:
+		return "", false
+	}
+}
+
+func (s *avroSchema) decode(data []byte) (any, error) {
+	return decodeAvro(s, data)
+}
+
+// decodeAvro decodes a binary Avro datum written with the given schema.
+// Records and maps become map[string]any, bytes and fixed become []byte
+// (base64 in JSON) and union values are returned without the Avro JSON type
+// wrapper, so the result marshals to the JSON shape the rest of the pipeline
+// expects. Decimals, dates, timestamps and uuids are decoded to the string
+// forms the ClickHouse mapper parses.
+func decodeAvro(s *avroSchema, data []byte) (any, error) {
+	r := &avroReader{buf: data}
+
+	value, err := r.read(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.pos != len(r.buf) {
+		return nil, fmt.Errorf("%d trailing bytes after avro datum", len(r.buf)-r.pos)
+	}
+
+	return value, nil
+}
+
+type avroReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *avroReader) read(s *avroSchema) (any, error) {
+	if s.logical != "" {
+		return r.readLogical(s)
+	}
+
+	switch s.typ {
+	case avroTypeNull:
+		return nil, nil
+	case avroTypeBoolean:
+		b, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case avroTypeInt:
+		v, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("avro int out of range: %d", v)
+		}
+		return int32(v), nil
+	case avroTypeLong:
+		return r.readLong()
+	case avroTypeFloat:
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case avroTypeDouble:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case avroTypeString:
+		b, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case avroTypeBytes:
+		b, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case avroTypeFixed:
+		b, err := r.next(s.size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case avroTypeEnum:
+		idx, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= int64(len(s.symbols)) {
+			return nil, fmt.Errorf("avro enum %s index %d out of range", s.name, idx)
+		}
+		return s.symbols[idx], nil
+	case avroTypeUnion:
+		idx, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= int64(len(s.branches)) {
+			return nil, fmt.Errorf("avro union index %d out of range", idx)
+		}
+		return r.read(s.branches[idx])
+	case avroTypeRecord:
+		record := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			v, err := r.read(f.schema)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			record[f.name] = v
+		}
+		return record, nil
+	case avroTypeArray:
+		items := make([]any, 0)
+		err := r.readBlocks(func() error {
+			v, err := r.read(s.items)
+			if err != nil {
+				return err
+			}
+			items = append(items, v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return items, nil
+	case avroTypeMap:
+		values := make(map[string]any)
+		err := r.readBlocks(func() error {
+			key, err := r.readBytes()
+			if err != nil {
+				return err
+			}
+			v, err := r.read(s.values)
+			if err != nil {
+				return err
+			}
+			values[string(key)] = v
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported avro type %s", s.typ)
+	}
+}
+
+// readLogical reads a value annotated with a logical type. Timestamps are
+// returned in UTC and marshal to RFC 3339 with the full precision of the
+// datum, dates as YYYY-MM-DD and decimals as their exact decimal string.
+func (r *avroReader) readLogical(s *avroSchema) (any, error) {
+	switch s.logical {
+	case avroLogicalDecimal:
+		var b []byte
+		var err error
+		if s.typ == avroTypeFixed {
+			b, err = r.next(s.size)
+		} else {
+			b, err = r.readBytes()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return formatAvroDecimal(b, s.scale), nil
+	case avroLogicalUUID:
+		if s.typ == avroTypeString {
+			b, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			return string(b), nil
+		}
+		b, err := r.next(16)
+		if err != nil {
+			return nil, err
+		}
+		return formatUUID(b), nil
+	case avroLogicalDate:
+		days, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(0, 0).UTC().AddDate(0, 0, int(days)).Format(time.DateOnly), nil
+	case avroLogicalTimestampMillis, avroLogicalLocalMillis:
+		v, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(v).UTC(), nil
+	case avroLogicalTimestampMicros, avroLogicalLocalMicros:
+		v, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMicro(v).UTC(), nil
+	default:
+		return nil, fmt.Errorf("unsupported avro logical type %s", s.logical)
+	}
+}
+
+// formatAvroDecimal formats the big-endian two's-complement unscaled value of
+// an Avro decimal with the given scale.
+func formatAvroDecimal(b []byte, scale int) string {
+	unscaled := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+
+	digits := unscaled.String()
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// readBlocks reads the block encoding shared by arrays and maps, calling
+// readItem once per item.
+func (r *avroReader) readBlocks(readItem func() error) error {
+	for {
+		count, err := r.readLong()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// negative count is followed by the block size in bytes
+			count = -count
+			if _, err := r.readLong(); err != nil {
+				return err
+			}
+		}
+		if count > int64(len(r.buf)-r.pos) {
+			return errAvroShortBuffer // corrupt count, every item takes at least a byte in practice
+		}
+		for range count {
+			if err := readItem(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readLong reads a zig-zag encoded variable-length integer.
+func (r *avroReader) readLong() (int64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errAvroShortBuffer
+	}
+	r.pos += n
+
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (r *avroReader) readBytes() ([]byte, error) {
+	size, err := r.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("negative avro length: %d", size)
+	}
+	if size > int64(len(r.buf)-r.pos) {
+		return nil, errAvroShortBuffer
+	}
+	return r.next(int(size))
+}
+
+func (r *avroReader) next(n int) ([]byte, error) {
+	if n > len(r.buf)-r.pos {
+		return nil, errAvroShortBuffer
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+
+	return b, nil
+}
@@ -0,0 +1,220 @@
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+const testAvroSchema = `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "count", "type": "int"},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "score", "type": "double"},
+		{"name": "active", "type": "boolean"},
+		{"name": "email", "type": ["null", "string"], "default": null},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "kind", "type": "Kind"}
+		]}}
+	]
+}`
+
+func appendLong(b []byte, v int64) []byte {
+	return binary.AppendUvarint(b, uint64((v<<1)^(v>>63)))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendLong(b, int64(len(s)))
+	return append(b, s...)
+}
+
+func encodeTestEvent() []byte {
+	var b []byte
+	b = appendString(b, "evt-1")
+	b = appendLong(b, -7)
+	b = appendLong(b, 1700000000000)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(1.5))
+	b = append(b, 1)
+	b = appendLong(b, 1) // union branch: string
+	b = appendString(b, "a@example.com")
+	b = appendLong(b, 1) // enum: B
+	b = appendLong(b, 2) // array block of 2
+	b = appendString(b, "x")
+	b = appendString(b, "y")
+	b = appendLong(b, 0)
+	b = appendLong(b, -1) // map block of 1 with byte size
+	b = appendLong(b, 3)
+	b = appendString(b, "k")
+	b = appendLong(b, 42)
+	b = appendLong(b, 0)
+	b = appendString(b, "alice")
+	b = appendLong(b, 0) // enum: A
+	return b
+}
+
+func TestAvroFields(t *testing.T) {
+	writer, err := parseAvroSchema(testAvroSchema)
+	require.NoError(t, err)
+
+	fields, err := avroFields(writer)
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{
+		{Name: "id", Type: "string"},
+		{Name: "count", Type: "int32"},
+		{Name: "ts", Type: "string"},
+		{Name: "score", Type: "float64"},
+		{Name: "active", Type: "bool"},
+		{Name: "email", Type: "string"},
+		{Name: "kind", Type: "string"},
+		{Name: "tags", Type: "array"},
+		{Name: "attrs", Type: "map"},
+		{Name: "user.name", Type: "string"},
+		{Name: "user.kind", Type: "string"},
+	}, fields)
+}
+
+func TestAvroFields_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "not json", schema: `{`},
+		{name: "primitive root", schema: `"string"`},
+		{name: "unknown named type", schema: `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "Missing"}]}`},
+		{name: "record without fields", schema: `{"type": "record", "name": "R"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, err := parseAvroSchema(tt.schema)
+			if err == nil {
+				_, err = avroFields(writer)
+			}
+			require.ErrorIs(t, err, models.ErrInvalidSchema)
+		})
+	}
+}
+
+func TestAvroFields_SkipsRecursiveAndUnions(t *testing.T) {
+	writer, err := parseAvroSchema(`{
+		"type": "record",
+		"name": "Node",
+		"fields": [
+			{"name": "value", "type": "long"},
+			{"name": "choice", "type": ["int", "string"]},
+			{"name": "next", "type": ["null", "Node"]}
+		]
+	}`)
+	require.NoError(t, err)
+
+	fields, err := avroFields(writer)
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{{Name: "value", Type: "int64"}}, fields)
+}
+
+func TestDecodeAvro(t *testing.T) {
+	writer, err := parseAvroSchema(testAvroSchema)
+	require.NoError(t, err)
+
+	value, err := decodeAvro(writer, encodeTestEvent())
+	require.NoError(t, err)
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": "evt-1",
+		"count": -7,
+		"ts": "2023-11-14T22:13:20Z",
+		"score": 1.5,
+		"active": true,
+		"email": "a@example.com",
+		"kind": "B",
+		"tags": ["x", "y"],
+		"attrs": {"k": 42},
+		"user": {"name": "alice", "kind": "A"}
+	}`, string(data))
+}
+
+func TestDecodeAvro_Errors(t *testing.T) {
+	writer, err := parseAvroSchema(testAvroSchema)
+	require.NoError(t, err)
+
+	data := encodeTestEvent()
+
+	_, err = decodeAvro(writer, data[:len(data)-3])
+	require.ErrorIs(t, err, errAvroShortBuffer)
+
+	_, err = decodeAvro(writer, append(data, 0))
+	require.ErrorContains(t, err, "trailing bytes")
+}
+
+func TestDecodeAvro_LogicalTypes(t *testing.T) {
+	writer, err := parseAvroSchema(`{
+		"type": "record",
+		"name": "Payment",
+		"fields": [
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "fee", "type": {"type": "fixed", "name": "Fee", "size": 2, "logicalType": "decimal", "precision": 4, "scale": 3}},
+			{"name": "id", "type": {"type": "fixed", "name": "Id", "size": 16, "logicalType": "uuid"}},
+			{"name": "ref", "type": {"type": "string", "logicalType": "uuid"}},
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "at", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+			{"name": "raw", "type": "bytes"},
+			{"name": "bad", "type": {"type": "string", "logicalType": "decimal", "precision": 4}}
+		]
+	}`)
+	require.NoError(t, err)
+
+	fields, err := avroFields(writer)
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{
+		{Name: "amount", Type: "string"},
+		{Name: "fee", Type: "string"},
+		{Name: "id", Type: "string"},
+		{Name: "ref", Type: "string"},
+		{Name: "day", Type: "string"},
+		{Name: "at", Type: "string"},
+		{Name: "raw", Type: "bytes"},
+		{Name: "bad", Type: "string"},
+	}, fields)
+
+	var b []byte
+	b = appendLong(b, 2)
+	b = append(b, 0xcf, 0xc7) // -12345
+	b = append(b, 0x00, 0x05) // 5
+	b = append(b, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0)
+	b = appendString(b, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")
+	b = appendLong(b, 19675)
+	b = appendLong(b, 1700000000123456)
+	b = appendString(b, "\xff\x00")
+	b = appendString(b, "1.5")
+
+	value, err := decodeAvro(writer, b)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"amount": "-123.45",
+		"fee": "0.005",
+		"id": "12345678-9abc-def0-1234-56789abcdef0",
+		"ref": "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		"day": "2023-11-14",
+		"at": "2023-11-14T22:13:20.123456Z",
+		"raw": "/wA=",
+		"bad": "1.5"
+	}`, string(data))
+}
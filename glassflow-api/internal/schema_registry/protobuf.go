@@ -0,0 +1,216 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/twmb/franz-go/pkg/sr"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// protoSchemaPath is the path the registry schema is compiled under. Imports
+// use the names of the schema references, which never start with a slash.
+const protoSchemaPath = "/registry/schema.proto"
+
+const protoTimestampName protoreflect.FullName = "google.protobuf.Timestamp"
+
+// protoSchema is a compiled .proto file as stored in the schema registry.
+type protoSchema struct {
+	file protoreflect.FileDescriptor
+}
+
+// parseProtoSchema compiles a .proto schema. imports holds the text of the
+// schemas it references keyed by import path; the well-known google/protobuf
+// files resolve without being registered.
+func parseProtoSchema(ctx context.Context, schema string, imports map[string]string) (*protoSchema, error) {
+	sources := maps.Clone(imports)
+	if sources == nil {
+		sources = make(map[string]string, 1)
+	}
+	sources[protoSchemaPath] = schema
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+
+	files, err := compiler.Compile(ctx, protoSchemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidSchema, err)
+	}
+
+	return &protoSchema{file: files[0]}, nil
+}
+
+// message resolves the Confluent message indexes at the start of data, which
+// select the message type the payload was written with, and returns the
+// message descriptor with the bytes after the indexes.
+func (s *protoSchema) message(data []byte) (protoreflect.MessageDescriptor, []byte, error) {
+	// every index takes at least a byte, which bounds a corrupt count
+	indexes, rest, err := new(sr.ConfluentHeader).DecodeIndex(data, len(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode message indexes: %w", err)
+	}
+
+	var md protoreflect.MessageDescriptor
+	messages := s.file.Messages()
+	for _, idx := range indexes {
+		if idx < 0 || idx >= messages.Len() {
+			return nil, nil, fmt.Errorf("message index %d out of range", idx)
+		}
+		md = messages.Get(idx)
+		messages = md.Messages()
+	}
+
+	return md, rest, nil
+}
+
+// protoFields flattens a message into fields using dot notation for nested
+// messages.
+func protoFields(md protoreflect.MessageDescriptor) []models.Field {
+	fields := make([]models.Field, 0, md.Fields().Len())
+	appendProtoFields(&fields, md, "", map[protoreflect.FullName]bool{})
+
+	return fields
+}
+
+func appendProtoFields(fields *[]models.Field, md protoreflect.MessageDescriptor, prefix string, visiting map[protoreflect.FullName]bool) {
+	visiting[md.FullName()] = true
+	defer delete(visiting, md.FullName())
+
+	for i := range md.Fields().Len() {
+		fd := md.Fields().Get(i)
+		name := prefix + string(fd.Name())
+
+		switch {
+		case fd.IsMap():
+			*fields = append(*fields, models.Field{Name: name, Type: internal.KafkaTypeMap})
+		case fd.IsList():
+			*fields = append(*fields, models.Field{Name: name, Type: internal.KafkaTypeArray})
+		case fd.Message() != nil && fd.Message().FullName() == protoTimestampName:
+			*fields = append(*fields, models.Field{Name: name, Type: internal.KafkaTypeString})
+		case fd.Message() != nil:
+			if visiting[fd.Message().FullName()] {
+				continue // recursive messages cannot be flattened
+			}
+			appendProtoFields(fields, fd.Message(), name+".", visiting)
+		default:
+			*fields = append(*fields, models.Field{Name: name, Type: protoSourceType(fd.Kind())})
+		}
+	}
+}
+
+func protoSourceType(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.DoubleKind:
+		return "float64"
+	case protoreflect.FloatKind:
+		return "float32"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	case protoreflect.BoolKind:
+		return internal.KafkaTypeBool
+	case protoreflect.BytesKind:
+		return "bytes"
+	default:
+		// strings and enums, which decode to their value name
+		return internal.KafkaTypeString
+	}
+}
+
+// decode decodes a payload in the schema registry protobuf wire format: a list
+// of message indexes selecting the message type, followed by the message.
+func (s *protoSchema) decode(data []byte) (any, error) {
+	md, b, err := s.message(data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", md.FullName(), err)
+	}
+
+	return protoMessageValue(msg), nil
+}
+
+// protoMessageValue converts a message into a map keyed by field name. Unset
+// fields without presence tracking get their default so the result has the
+// same shape as the flattened schema fields; unset message, oneof and
+// optional fields stay absent.
+func protoMessageValue(m protoreflect.Message) map[string]any {
+	fields := m.Descriptor().Fields()
+	out := make(map[string]any, fields.Len())
+
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if fd.HasPresence() && !m.Has(fd) {
+			continue
+		}
+		out[string(fd.Name())] = protoFieldValue(fd, m.Get(fd))
+	}
+
+	return out
+}
+
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch {
+	case fd.IsMap():
+		values := make(map[string]any, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			values[k.String()] = protoSingularValue(fd.MapValue(), mv)
+			return true
+		})
+		return values
+	case fd.IsList():
+		list := v.List()
+		items := make([]any, 0, list.Len())
+		for i := range list.Len() {
+			items = append(items, protoSingularValue(fd, list.Get(i)))
+		}
+		return items
+	default:
+		return protoSingularValue(fd, v)
+	}
+}
+
+// protoSingularValue converts a single value. Enums become their value name,
+// timestamps a time in UTC and bytes []byte, which marshal to an RFC 3339
+// string and base64 respectively.
+func protoSingularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := v.Message()
+		if fd.Message().FullName() == protoTimestampName {
+			fields := fd.Message().Fields()
+			seconds := msg.Get(fields.ByName("seconds")).Int()
+			nanos := msg.Get(fields.ByName("nanos")).Int()
+			return time.Unix(seconds, nanos).UTC()
+		}
+		return protoMessageValue(msg)
+	case protoreflect.BytesKind:
+		return append([]byte(nil), v.Bytes()...)
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,254 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+const testProtoSchema = `
+syntax = "proto3";
+package com.example;
+
+import "google/protobuf/timestamp.proto";
+
+// An event with nested and repeated fields.
+message Event {
+	string id = 1;
+	int32 count = 2;
+	sint64 delta = 3;
+	double score = 4 [deprecated = true];
+	bool active = 5;
+	Kind kind = 6;
+	repeated string tags = 7;
+	repeated int64 sizes = 8;
+	map<string, int64> attrs = 9;
+	User user = 10;
+	google.protobuf.Timestamp created_at = 11;
+	optional string note = 12;
+	oneof source {
+		string url = 13;
+		string path = 14;
+	}
+
+	message User {
+		string name = 1;
+		Kind kind = 2;
+	}
+}
+
+enum Kind {
+	KIND_UNSPECIFIED = 0;
+	KIND_A = 1;
+	KIND_B = 2;
+}
+
+/* a second message selected through message indexes */
+message Other {
+	fixed32 code = 1;
+}
+`
+
+func encodeTestProtoEvent() []byte {
+	var user []byte
+	user = protowire.AppendTag(user, 1, protowire.BytesType)
+	user = protowire.AppendString(user, "alice")
+
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "k")
+	entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+	entry = protowire.AppendVarint(entry, 42)
+
+	var packed []byte
+	packed = protowire.AppendVarint(packed, 3)
+	packed = protowire.AppendVarint(packed, 4)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "evt-1")
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, 7)
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, protowire.EncodeZigZag(-5))
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(1.5))
+	b = protowire.AppendTag(b, 6, protowire.VarintType)
+	b = protowire.AppendVarint(b, 2)
+	b = protowire.AppendTag(b, 7, protowire.BytesType)
+	b = protowire.AppendString(b, "x")
+	b = protowire.AppendTag(b, 7, protowire.BytesType)
+	b = protowire.AppendString(b, "y")
+	b = protowire.AppendTag(b, 8, protowire.BytesType)
+	b = protowire.AppendBytes(b, packed)
+	b = protowire.AppendTag(b, 9, protowire.BytesType)
+	b = protowire.AppendBytes(b, entry)
+	b = protowire.AppendTag(b, 10, protowire.BytesType)
+	b = protowire.AppendBytes(b, user)
+	b = protowire.AppendTag(b, 11, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte{0x08, 0x01})
+	b = protowire.AppendTag(b, 13, protowire.BytesType)
+	b = protowire.AppendString(b, "https://example.com")
+	b = protowire.AppendTag(b, 99, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	return b
+}
+
+func TestProtoFields(t *testing.T) {
+	writer, err := parseProtoSchema(context.Background(), testProtoSchema, nil)
+	require.NoError(t, err)
+
+	md, _, err := writer.message([]byte{0})
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{
+		{Name: "id", Type: "string"},
+		{Name: "count", Type: "int32"},
+		{Name: "delta", Type: "int64"},
+		{Name: "score", Type: "float64"},
+		{Name: "active", Type: "bool"},
+		{Name: "kind", Type: "string"},
+		{Name: "tags", Type: "array"},
+		{Name: "sizes", Type: "array"},
+		{Name: "attrs", Type: "map"},
+		{Name: "user.name", Type: "string"},
+		{Name: "user.kind", Type: "string"},
+		{Name: "created_at", Type: "string"},
+		{Name: "note", Type: "string"},
+		{Name: "url", Type: "string"},
+		{Name: "path", Type: "string"},
+	}, protoFields(md))
+
+	md, _, err = writer.message([]byte{2, 2}) // indexes [1]: Other
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{{Name: "code", Type: "uint32"}}, protoFields(md))
+}
+
+func TestParseProtoSchema_Imports(t *testing.T) {
+	schema := `
+syntax = "proto3";
+package com.example;
+
+import "common/user.proto";
+
+message Order {
+	string id = 1;
+	com.example.common.User buyer = 2;
+}
+`
+	imports := map[string]string{
+		"common/user.proto": `
+syntax = "proto3";
+package com.example.common;
+
+message User {
+	string name = 1;
+	bytes avatar = 2;
+}
+`,
+	}
+
+	writer, err := parseProtoSchema(context.Background(), schema, imports)
+	require.NoError(t, err)
+
+	md, _, err := writer.message([]byte{0})
+	require.NoError(t, err)
+	require.Equal(t, []models.Field{
+		{Name: "id", Type: "string"},
+		{Name: "buyer.name", Type: "string"},
+		{Name: "buyer.avatar", Type: "bytes"},
+	}, protoFields(md))
+
+	_, err = parseProtoSchema(context.Background(), schema, nil)
+	require.ErrorIs(t, err, models.ErrInvalidSchema)
+}
+
+func TestParseProtoSchema_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "unterminated message", schema: `message A { string a = 1;`},
+		{name: "missing field number", schema: `message A { string a; }`},
+		{name: "invalid field number", schema: `message A { string a = x; }`},
+		{name: "unterminated comment", schema: `/* message A {}`},
+		{name: "unexpected token", schema: `foo`},
+		{name: "unknown type", schema: `syntax = "proto3"; message A { Missing a = 1; }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProtoSchema(context.Background(), tt.schema, nil)
+			require.ErrorIs(t, err, models.ErrInvalidSchema)
+		})
+	}
+}
+
+func TestProtoDecode(t *testing.T) {
+	writer, err := parseProtoSchema(context.Background(), testProtoSchema, nil)
+	require.NoError(t, err)
+
+	value, err := writer.decode(append([]byte{0}, encodeTestProtoEvent()...))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": "evt-1",
+		"count": 7,
+		"delta": -5,
+		"score": 1.5,
+		"active": false,
+		"kind": "KIND_B",
+		"tags": ["x", "y"],
+		"sizes": [3, 4],
+		"attrs": {"k": 42},
+		"user": {"name": "alice", "kind": "KIND_UNSPECIFIED"},
+		"created_at": "1970-01-01T00:00:01Z",
+		"url": "https://example.com"
+	}`, string(data))
+}
+
+func TestProtoDecode_MessageIndexes(t *testing.T) {
+	writer, err := parseProtoSchema(context.Background(), testProtoSchema, nil)
+	require.NoError(t, err)
+
+	var b []byte
+	b = protowire.AppendVarint(b, protowire.EncodeZigZag(1)) // one index
+	b = protowire.AppendVarint(b, protowire.EncodeZigZag(1)) // second top-level message
+	b = protowire.AppendTag(b, 1, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 9)
+
+	value, err := writer.decode(b)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"code": uint32(9)}, value)
+
+	// indexes [0, 1]: Event.User, after the AttrsEntry type the map field declares
+	b = []byte{4, 0, 2}
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "bob")
+
+	value, err = writer.decode(b)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "bob", "kind": "KIND_UNSPECIFIED"}, value)
+
+	_, err = writer.decode([]byte{2, 6})
+	require.ErrorContains(t, err, "out of range")
+}
+
+func TestProtoDecode_Errors(t *testing.T) {
+	writer, err := parseProtoSchema(context.Background(), testProtoSchema, nil)
+	require.NoError(t, err)
+
+	data := append([]byte{0}, encodeTestProtoEvent()...)
+	_, err = writer.decode(data[:len(data)-1])
+	require.Error(t, err)
+
+	_, err = writer.decode([]byte{1}) // negative index count
+	require.ErrorContains(t, err, "decode message indexes")
+}
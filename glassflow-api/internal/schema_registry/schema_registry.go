@@ -2,8 +2,10 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/tidwall/gjson"
 	"github.com/twmb/franz-go/pkg/sr"
@@ -12,8 +14,17 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
+// payloadDecoder decodes a message payload written with a registry schema
+// into values that marshal to the pipeline's JSON representation.
+type payloadDecoder interface {
+	decode(data []byte) (any, error)
+}
+
 type SchemaRegistryClient struct {
 	client *sr.Client
+
+	mu       sync.RWMutex
+	decoders map[int]payloadDecoder // cached per schema ID; nil for JSON schemas
 }
 
 func NewSchemaRegistryClient(config models.SchemaRegistryConfig) (*SchemaRegistryClient, error) {
@@ -29,24 +40,155 @@ func NewSchemaRegistryClient(config models.SchemaRegistryConfig) (*SchemaRegistr
 	}
 
 	return &SchemaRegistryClient{
-		client: client,
+		client:   client,
+		decoders: make(map[int]payloadDecoder),
 	}, nil
 }
 
-func (s *SchemaRegistryClient) GetSchema(ctx context.Context, schemaID int) ([]models.Field, error) {
+// GetSchema returns the fields of the schema with the given ID. payload is the
+// message without the wire format header; for Protobuf schemas its message
+// indexes select the message type the fields are taken from.
+func (s *SchemaRegistryClient) GetSchema(ctx context.Context, schemaID int, payload []byte) ([]models.Field, error) {
+	schema, err := s.schemaByID(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch schema.Type {
+	case sr.TypeJSON:
+		return parseJSONSchema(schema.Schema)
+	case sr.TypeAvro:
+		writer, err := parseAvroSchema(schema.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return avroFields(writer)
+	case sr.TypeProtobuf:
+		writer, err := s.protoSchema(ctx, schema)
+		if err != nil {
+			return nil, err
+		}
+		md, _, err := writer.message(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", models.ErrInvalidSchema, err)
+		}
+		return protoFields(md), nil
+	default:
+		return nil, fmt.Errorf("%w: got %s", models.ErrUnexpectedSchemaFormat, schema.Type)
+	}
+}
+
+// Decode converts a message payload (without the wire format header) written
+// with the given schema ID into JSON. JSON payloads are returned as-is, Avro
+// and Protobuf payloads are decoded with the writer schema fetched from the
+// registry.
+func (s *SchemaRegistryClient) Decode(ctx context.Context, schemaID int, data []byte) ([]byte, error) {
+	decoder, err := s.decoder(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoder == nil {
+		return data, nil
+	}
+
+	value, err := decoder.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schema %d: %w", models.ErrDecodePayload, schemaID, err)
+	}
+
+	decoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schema %d: %w", models.ErrDecodePayload, schemaID, err)
+	}
+
+	return decoded, nil
+}
+
+func (s *SchemaRegistryClient) decoder(ctx context.Context, schemaID int) (payloadDecoder, error) {
+	s.mu.RLock()
+	decoder, ok := s.decoders[schemaID]
+	s.mu.RUnlock()
+	if ok {
+		return decoder, nil
+	}
+
+	schema, err := s.schemaByID(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch schema.Type {
+	case sr.TypeJSON:
+		decoder = nil
+	case sr.TypeAvro:
+		writer, err := parseAvroSchema(schema.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("parse avro schema %d: %w", schemaID, err)
+		}
+		decoder = writer
+	case sr.TypeProtobuf:
+		writer, err := s.protoSchema(ctx, schema)
+		if err != nil {
+			return nil, fmt.Errorf("parse protobuf schema %d: %w", schemaID, err)
+		}
+		decoder = writer
+	default:
+		return nil, fmt.Errorf("%w: got %s", models.ErrUnexpectedSchemaFormat, schema.Type)
+	}
+
+	s.mu.Lock()
+	s.decoders[schemaID] = decoder
+	s.mu.Unlock()
+
+	return decoder, nil
+}
+
+func (s *SchemaRegistryClient) schemaByID(ctx context.Context, schemaID int) (sr.Schema, error) {
 	schema, err := s.client.SchemaByID(ctx, schemaID)
 	if err != nil {
 		if errors.Is(err, sr.ErrSchemaNotFound) {
-			return nil, models.ErrSchemaNotFound
+			return sr.Schema{}, models.ErrSchemaNotFound
 		}
-		return nil, fmt.Errorf("failed to get schema by id %d: %w", schemaID, err)
+		return sr.Schema{}, fmt.Errorf("failed to get schema by id %d: %w", schemaID, err)
 	}
 
-	if schema.Type != sr.TypeJSON {
-		return nil, fmt.Errorf("%w: expected %s, got %s", models.ErrUnexpectedSchemaFormat, sr.TypeJSON, schema.Type)
+	return schema, nil
+}
+
+// protoSchema compiles a protobuf schema together with the schemas it
+// references.
+func (s *SchemaRegistryClient) protoSchema(ctx context.Context, schema sr.Schema) (*protoSchema, error) {
+	imports := make(map[string]string, len(schema.References))
+	err := s.protoImports(ctx, schema.References, imports)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProtoSchema(ctx, schema.Schema, imports)
+}
+
+// protoImports fetches the referenced schemas and their own references, keyed
+// by the import path they are referenced with.
+func (s *SchemaRegistryClient) protoImports(ctx context.Context, refs []sr.SchemaReference, imports map[string]string) error {
+	for _, ref := range refs {
+		if _, ok := imports[ref.Name]; ok {
+			continue
+		}
+
+		referenced, err := s.client.SchemaByVersion(ctx, ref.Subject, ref.Version)
+		if err != nil {
+			return fmt.Errorf("failed to get referenced schema %s: %w", ref.Name, err)
+		}
+		imports[ref.Name] = referenced.Schema.Schema
+
+		err = s.protoImports(ctx, referenced.References, imports)
+		if err != nil {
+			return err
+		}
 	}
 
-	return parseJSONSchema(schema.Schema)
+	return nil
 }
 
 func parseJSONSchema(schema string) ([]models.Field, error) {
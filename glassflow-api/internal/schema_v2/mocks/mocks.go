@@ -38,16 +38,24 @@ func (m *MockDBClient) SaveNewSchemaVersion(ctx context.Context, pipelineID, sou
 }
 
 type MockSchemaRegistryClient struct {
-	GetSchemaFunc func(ctx context.Context, schemaID int) ([]models.Field, error)
+	GetSchemaFunc func(ctx context.Context, schemaID int, payload []byte) ([]models.Field, error)
+	DecodeFunc    func(ctx context.Context, schemaID int, data []byte) ([]byte, error)
 }
 
 func NewMockSchemaRegistryClient() *MockSchemaRegistryClient {
 	return &MockSchemaRegistryClient{}
 }
 
-func (m *MockSchemaRegistryClient) GetSchema(ctx context.Context, schemaID int) ([]models.Field, error) {
+func (m *MockSchemaRegistryClient) GetSchema(ctx context.Context, schemaID int, payload []byte) ([]models.Field, error) {
 	if m.GetSchemaFunc != nil {
-		return m.GetSchemaFunc(ctx, schemaID)
+		return m.GetSchemaFunc(ctx, schemaID, payload)
 	}
 	return nil, nil
 }
+
+func (m *MockSchemaRegistryClient) Decode(ctx context.Context, schemaID int, data []byte) ([]byte, error) {
+	if m.DecodeFunc != nil {
+		return m.DecodeFunc(ctx, schemaID, data)
+	}
+	return data, nil
+}
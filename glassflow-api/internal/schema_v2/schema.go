@@ -12,7 +12,8 @@ import (
 )
 
 type SchemaRegistryClient interface {
-	GetSchema(ctx context.Context, schemaID int) ([]models.Field, error)
+	GetSchema(ctx context.Context, schemaID int, payload []byte) ([]models.Field, error)
+	Decode(ctx context.Context, schemaID int, data []byte) ([]byte, error)
 }
 
 type SchemaInterface interface {
@@ -73,7 +74,7 @@ func (s *Schema) validateExternalSchema(ctx context.Context, data []byte) (strin
 	schemaVersion, err := s.store.GetSchemaVersion(ctx, fmt.Sprintf("%d", version))
 	if err != nil {
 		if errors.Is(err, models.ErrSchemaVerionNotFound) {
-			newVersion, err := s.validateAndSaveNewSchemaVersion(ctx, version)
+			newVersion, err := s.validateAndSaveNewSchemaVersion(ctx, version, data[5:])
 			if err != nil {
 				return fmt.Sprintf("%d", version), err
 			}
@@ -86,8 +87,8 @@ func (s *Schema) validateExternalSchema(ctx context.Context, data []byte) (strin
 	return schemaVersion.VersionID, nil
 }
 
-func (s *Schema) validateAndSaveNewSchemaVersion(ctx context.Context, version int) (zero string, nil error) {
-	schemaFields, err := s.srClient.GetSchema(ctx, version)
+func (s *Schema) validateAndSaveNewSchemaVersion(ctx context.Context, version int, payload []byte) (zero string, nil error) {
+	schemaFields, err := s.srClient.GetSchema(ctx, version, payload)
 	if err != nil {
 		if errors.Is(err, models.ErrSchemaNotFound) {
 			return zero, models.ErrSchemaNotFound
@@ -173,6 +174,31 @@ func (s *Schema) Get(ctx context.Context, versionID, key string, data []byte) (a
 	return result.Value(), nil
 }
 
+// Decode returns the message payload in the JSON representation used by the
+// rest of the pipeline. External payloads have the wire format header stripped
+// and are decoded with their writer schema from the schema registry.
+func (s *Schema) Decode(ctx context.Context, data []byte) ([]byte, error) {
+	if !s.external {
+		return data, nil
+	}
+
+	version, err := extractSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.srClient.Decode(ctx, version, data[5:])
+}
+
+// LatestVersionID returns the ID of the latest schema version of the source.
+func (s *Schema) LatestVersionID(ctx context.Context) (string, error) {
+	version, err := s.store.GetLatestSchemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return version.VersionID, nil
+}
+
 func (s *Schema) IsExternal() bool {
 	return s.external
 }
@@ -173,7 +173,7 @@ func TestSchema_ValidateExternalSchema(t *testing.T) {
 		}
 
 		// Get schema from registry
-		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int) ([]models.Field, error) {
+		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int, _ []byte) ([]models.Field, error) {
 			return newFields, nil
 		}
 
@@ -203,7 +203,7 @@ func TestSchema_ValidateExternalSchema(t *testing.T) {
 			return nil, models.ErrSchemaVerionNotFound
 		}
 
-		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int) ([]models.Field, error) {
+		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int, _ []byte) ([]models.Field, error) {
 			return nil, models.ErrSchemaNotFound
 		}
 
@@ -224,7 +224,7 @@ func TestSchema_ValidateExternalSchema(t *testing.T) {
 			return nil, models.ErrSchemaVerionNotFound
 		}
 
-		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int) ([]models.Field, error) {
+		mockSR.GetSchemaFunc = func(ctx context.Context, schemaIDArg int, _ []byte) ([]models.Field, error) {
 			return []models.Field{}, nil
 		}
 
@@ -432,6 +432,52 @@ func TestSchema_Get(t *testing.T) {
 	})
 }
 
+func TestSchema_Decode(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"field1":"value1"}`)
+
+	t.Run("returns internal payload unchanged", func(t *testing.T) {
+		schema, _ := NewSchema("test-pipeline", "test-source", mocks.NewMockDBClient(), nil)
+
+		decoded, err := schema.Decode(ctx, payload)
+
+		assert.NoError(t, err)
+		assert.Equal(t, payload, decoded)
+	})
+
+	t.Run("strips header and decodes external payload", func(t *testing.T) {
+		data := make([]byte, 5, 5+len(payload))
+		binary.BigEndian.PutUint32(data[1:5], 100)
+		data = append(data, payload...)
+
+		mockSR := mocks.NewMockSchemaRegistryClient()
+		var gotSchemaID int
+		var gotData []byte
+		mockSR.DecodeFunc = func(ctx context.Context, schemaID int, data []byte) ([]byte, error) {
+			gotSchemaID = schemaID
+			gotData = data
+			return []byte(`{"decoded":true}`), nil
+		}
+
+		schema, _ := NewSchema("test-pipeline", "test-source", mocks.NewMockDBClient(), mockSR)
+
+		decoded, err := schema.Decode(ctx, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 100, gotSchemaID)
+		assert.Equal(t, payload, gotData)
+		assert.JSONEq(t, `{"decoded":true}`, string(decoded))
+	})
+
+	t.Run("returns error for external payload without header", func(t *testing.T) {
+		schema, _ := NewSchema("test-pipeline", "test-source", mocks.NewMockDBClient(), mocks.NewMockSchemaRegistryClient())
+
+		_, err := schema.Decode(ctx, []byte{0, 1})
+
+		assert.ErrorIs(t, err, models.ErrMessageIsTooShort)
+	})
+}
+
 func TestExtractSchemaVersion(t *testing.T) {
 	t.Run("extracts schema version from valid data", func(t *testing.T) {
 		data := make([]byte, 10)
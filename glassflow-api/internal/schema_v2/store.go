@@ -81,8 +81,13 @@ func (s *SchemaStore) SaveSchemaVersion(ctx context.Context, latestSchemaVersion
 		return fmt.Errorf("save schema version: %w", err)
 	}
 
-	s.versions[newSchemaVersionID] = s.versions[latestSchemaVersionID]
-	s.versions[newSchemaVersionID].VersionID = newSchemaVersionID
+	// Copy the cached version, messages already stamped with the latest
+	// version must keep resolving to it
+	if latest, ok := s.versions[latestSchemaVersionID]; ok {
+		newVersion := *latest
+		newVersion.VersionID = newSchemaVersionID
+		s.versions[newSchemaVersionID] = &newVersion
+	}
 	s.latestVersion = newSchemaVersionID
 
 	return nil
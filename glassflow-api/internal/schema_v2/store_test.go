@@ -215,7 +215,8 @@ func TestSchemaStore_SaveSchemaVersion(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, newVersionID, store.latestVersion)
-		assert.Equal(t, oldVersion, store.versions[newVersionID])
+		assert.Equal(t, newVersionID, store.versions[newVersionID].VersionID)
+		assert.Equal(t, oldVersion.Fields, store.versions[newVersionID].Fields)
 	})
 
 	t.Run("returns error on database failure", func(t *testing.T) {
@@ -249,6 +250,27 @@ func TestSchemaStore_SaveSchemaVersion(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, newVersionID, store.latestVersion)
 		assert.NotNil(t, store.versions[newVersionID])
-		assert.Equal(t, store.versions[oldVersionID], store.versions[newVersionID])
+		assert.Equal(t, store.versions[oldVersionID].Fields, store.versions[newVersionID].Fields)
+	})
+
+	t.Run("keeps the latest version unchanged", func(t *testing.T) {
+		mockDB := mocks.NewMockDBClient()
+		mockDB.SaveNewSchemaVersionFunc = func(ctx context.Context, pipelineIDArg, sourceIDArg, oldVersionIDArg, newVersionIDArg string) error {
+			return nil
+		}
+
+		store := NewSchemaStore(mockDB, pipelineID, sourceID).(*SchemaStore)
+		store.versions[oldVersionID] = &models.SchemaVersion{
+			SourceID:  sourceID,
+			VersionID: oldVersionID,
+			DataType:  models.SchemaDataFormatJSON,
+			Fields:    oldVersion.Fields,
+		}
+
+		err := store.SaveSchemaVersion(ctx, oldVersionID, newVersionID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, oldVersionID, store.versions[oldVersionID].VersionID)
+		assert.Equal(t, newVersionID, store.versions[newVersionID].VersionID)
 	})
 }
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables of the env store.
+const EnvPrefix = "GLASSFLOW_SECRET_"
+
+// EnvStore reads the secrets from environment variables, the ref
+// secretRef:clickhouse-prod from GLASSFLOW_SECRET_CLICKHOUSE_PROD and the
+// ref secretRef:kafka-prod#password from GLASSFLOW_SECRET_KAFKA_PROD_PASSWORD.
+type EnvStore struct {
+	lookup func(string) (string, bool)
+}
+
+func NewEnvStore() *EnvStore {
+	return &EnvStore{lookup: os.LookupEnv}
+}
+
+func (s *EnvStore) Get(_ context.Context, ref Ref) (string, error) {
+	name := EnvVar(ref)
+	value, ok := s.lookup(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// EnvVar returns the environment variable holding the secret of the ref.
+func EnvVar(ref Ref) string {
+	name := ref.Name
+	if ref.Key != "" {
+		name += "_" + ref.Key
+	}
+	return EnvPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
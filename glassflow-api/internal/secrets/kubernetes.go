@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// KubernetesStore reads the secrets from the Kubernetes Secrets of a
+// namespace, the ref secretRef:clickhouse-prod from the only key of the
+// Secret clickhouse-prod.
+type KubernetesStore struct {
+	clientSet kubernetes.Interface
+	namespace string
+}
+
+func NewKubernetesStore(namespace string) (*KubernetesStore, error) {
+	kcfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("get kubernetes config: %w", err)
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kcfg)
+	if err != nil {
+		return nil, fmt.Errorf("create kubernetes clientset: %w", err)
+	}
+
+	return &KubernetesStore{clientSet: clientSet, namespace: namespace}, nil
+}
+
+func (s *KubernetesStore) Get(ctx context.Context, ref Ref) (string, error) {
+	secret, err := s.clientSet.CoreV1().Secrets(s.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("%w: secret %q in namespace %q", ErrNotFound, ref.Name, s.namespace)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get secret %q: %w", ref.Name, err)
+	}
+
+	values := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		values[key] = string(value)
+	}
+	for key, value := range secret.StringData {
+		values[key] = value
+	}
+
+	return singleValue(ref, values)
+}
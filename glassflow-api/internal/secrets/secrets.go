@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// RefPrefix marks a credential field holding a reference to a secret instead
+// of the credential, e.g. "secretRef:clickhouse-prod" or
+// "secretRef:kafka-prod#password".
+const RefPrefix = "secretRef:"
+
+const (
+	BackendEnv        = "env"
+	BackendKubernetes = "kubernetes"
+	BackendVault      = "vault"
+)
+
+var (
+	ErrNotFound   = errors.New("secret not found")
+	ErrInvalidRef = errors.New("invalid secret reference")
+)
+
+var refNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]*[a-z0-9])?$`)
+
+// Ref references the key of a secret. An empty key references the only key
+// of the secret.
+type Ref struct {
+	Name string
+	Key  string
+}
+
+func (r Ref) String() string {
+	if r.Key == "" {
+		return RefPrefix + r.Name
+	}
+	return RefPrefix + r.Name + "#" + r.Key
+}
+
+// IsRef reports whether the value references a secret.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// ParseRef parses a secretRef:<name>[#<key>] value.
+func ParseRef(value string) (Ref, error) {
+	if !IsRef(value) {
+		return Ref{}, fmt.Errorf("%w: must start with %q", ErrInvalidRef, RefPrefix)
+	}
+
+	name, key, _ := strings.Cut(strings.TrimPrefix(value, RefPrefix), "#")
+	if !refNamePattern.MatchString(name) {
+		return Ref{}, fmt.Errorf("%w %q: the name must be lowercase alphanumerics, '-', '.' or '_'", ErrInvalidRef, value)
+	}
+	if strings.Contains(value, "#") && key == "" {
+		return Ref{}, fmt.Errorf("%w %q: empty key", ErrInvalidRef, value)
+	}
+
+	return Ref{Name: name, Key: key}, nil
+}
+
+// Store returns the value of the secrets.
+type Store interface {
+	Get(ctx context.Context, ref Ref) (string, error)
+}
+
+// Resolve returns the value of the secret the value references, or the
+// value when it is not a reference.
+func Resolve(ctx context.Context, store Store, value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+
+	ref, err := ParseRef(value)
+	if err != nil {
+		return "", err
+	}
+	if store == nil {
+		return "", fmt.Errorf("resolve %s: no secret store configured", ref)
+	}
+
+	secret, err := store.Get(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	return secret, nil
+}
+
+// ResolvePipeline replaces the secret references of the credentials of the
+// pipeline config with their values. The configs stored and exported keep
+// the references, only the components and the connections of the API
+// resolve them.
+func ResolvePipeline(ctx context.Context, store Store, cfg *models.PipelineConfig) error {
+	err := ResolveKafkaConnection(ctx, store, &cfg.Ingestor.KafkaConnectionParams)
+	if err != nil {
+		return fmt.Errorf("kafka connection: %w", err)
+	}
+
+	err = ResolveClickHouseConnection(ctx, store, &cfg.Sink.ClickHouseConnectionParams)
+	if err != nil {
+		return fmt.Errorf("clickhouse connection: %w", err)
+	}
+
+	err = resolveFields(ctx, store,
+		&cfg.Ingestor.PulsarConnectionParams.AuthToken,
+		&cfg.Ingestor.PulsarConnectionParams.TLSKey,
+		&cfg.Ingestor.MySQLConnectionParams.Password,
+	)
+	if err != nil {
+		return fmt.Errorf("ingestor connection: %w", err)
+	}
+
+	// The topics are shared with the config the reference was read from
+	cfg.Ingestor.KafkaTopics = slices.Clone(cfg.Ingestor.KafkaTopics)
+	for i := range cfg.Ingestor.KafkaTopics {
+		registry := &cfg.Ingestor.KafkaTopics[i].SchemaRegistryConfig
+		if err := resolveFields(ctx, store, &registry.APISecret); err != nil {
+			return fmt.Errorf("schema registry of topic %q: %w", cfg.Ingestor.KafkaTopics[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ResolveKafkaConnection replaces the secret references of the Kafka
+// credentials with their values.
+func ResolveKafkaConnection(ctx context.Context, store Store, conn *models.KafkaConnectionParamsConfig) error {
+	return resolveFields(ctx, store, &conn.SASLPassword, &conn.TLSKey, &conn.KerberosKeytab)
+}
+
+// ResolveClickHouseConnection replaces the secret references of the
+// ClickHouse credentials with their values.
+func ResolveClickHouseConnection(ctx context.Context, store Store, conn *models.ClickHouseConnectionParamsConfig) error {
+	return resolveFields(ctx, store, &conn.Password, &conn.TLSKey)
+}
+
+func resolveFields(ctx context.Context, store Store, fields ...*string) error {
+	for _, field := range fields {
+		value, err := Resolve(ctx, store, *field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
+
+// singleValue returns the value of the key of the ref among the keys of a
+// secret, or its only value when the ref has no key.
+func singleValue(ref Ref, values map[string]string) (string, error) {
+	if ref.Key != "" {
+		value, ok := values[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("%w: secret %q has no key %q", ErrNotFound, ref.Name, ref.Key)
+		}
+		return value, nil
+	}
+
+	if len(values) != 1 {
+		return "", fmt.Errorf("secret %q has %d keys, the reference must name one as %s#<key>", ref.Name, len(values), ref)
+	}
+	for _, value := range values {
+		return value, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Ref
+		wantErr bool
+	}{
+		{value: "secretRef:clickhouse-prod", want: Ref{Name: "clickhouse-prod"}},
+		{value: "secretRef:kafka-prod#sasl_password", want: Ref{Name: "kafka-prod", Key: "sasl_password"}},
+		{value: "secretRef:", wantErr: true},
+		{value: "secretRef:Prod", wantErr: true},
+		{value: "secretRef:prod#", wantErr: true},
+		{value: "password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ref, err := ParseRef(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRef)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+			assert.Equal(t, tt.value, ref.String())
+		})
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	assert.Equal(t, "GLASSFLOW_SECRET_CLICKHOUSE_PROD", EnvVar(Ref{Name: "clickhouse-prod"}))
+	assert.Equal(t, "GLASSFLOW_SECRET_KAFKA_PROD_SASL_PASSWORD", EnvVar(Ref{Name: "kafka.prod", Key: "sasl-password"}))
+}
+
+func TestResolvePipeline(t *testing.T) {
+	store := &EnvStore{lookup: func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"GLASSFLOW_SECRET_CLICKHOUSE_PROD":         "ch-password",
+			"GLASSFLOW_SECRET_CLICKHOUSE_MTLS":         "ch-tls-key",
+			"GLASSFLOW_SECRET_KAFKA_PROD_PASSWORD":     "kafka-password",
+			"GLASSFLOW_SECRET_SCHEMA_REGISTRY_PROD":    "registry-secret",
+			"GLASSFLOW_SECRET_KAFKA_PROD_TLS_KEY":      "tls-key",
+			"GLASSFLOW_SECRET_UNUSED_SECRET_TO_IGNORE": "unused",
+		}[name]
+		return value, ok
+	}}
+
+	topics := []models.KafkaTopicsConfig{{
+		Name:                 "orders",
+		SchemaRegistryConfig: models.SchemaRegistryConfig{APISecret: "secretRef:schema-registry-prod"},
+	}}
+	cfg := models.PipelineConfig{
+		Ingestor: models.IngestorComponentConfig{
+			KafkaConnectionParams: models.KafkaConnectionParamsConfig{
+				SASLPassword: "secretRef:kafka-prod#password",
+				TLSKey:       "secretRef:kafka-prod#tls_key",
+			},
+			KafkaTopics: topics,
+		},
+		Sink: models.SinkComponentConfig{
+			ClickHouseConnectionParams: models.ClickHouseConnectionParamsConfig{
+				Username: "default",
+				Password: "secretRef:clickhouse-prod",
+				TLSKey:   "secretRef:clickhouse-mtls",
+			},
+		},
+	}
+
+	require.NoError(t, ResolvePipeline(context.Background(), store, &cfg))
+	assert.Equal(t, "kafka-password", cfg.Ingestor.KafkaConnectionParams.SASLPassword)
+	assert.Equal(t, "tls-key", cfg.Ingestor.KafkaConnectionParams.TLSKey)
+	assert.Equal(t, "ch-password", cfg.Sink.ClickHouseConnectionParams.Password)
+	assert.Equal(t, "ch-tls-key", cfg.Sink.ClickHouseConnectionParams.TLSKey)
+	assert.Equal(t, "default", cfg.Sink.ClickHouseConnectionParams.Username)
+	assert.Equal(t, "registry-secret", cfg.Ingestor.KafkaTopics[0].SchemaRegistryConfig.APISecret)
+	// The config the references were read from keeps them
+	assert.Equal(t, "secretRef:schema-registry-prod", topics[0].SchemaRegistryConfig.APISecret)
+
+	cfg.Sink.ClickHouseConnectionParams.Password = "secretRef:clickhouse-staging"
+	err := ResolvePipeline(context.Background(), store, &cfg)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Inline credentials are kept without a store
+	cfg.Sink.ClickHouseConnectionParams.Password = "inline"
+	cfg.Ingestor = models.IngestorComponentConfig{}
+	require.NoError(t, ResolvePipeline(context.Background(), nil, &cfg))
+	assert.Equal(t, "inline", cfg.Sink.ClickHouseConnectionParams.Password)
+}
+
+func TestVaultStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/clickhouse-prod" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"data": map[string]any{"password": "ch-password", "username": "default"}},
+		})
+	}))
+	defer srv.Close()
+
+	vault, err := NewVaultClient(srv.URL, "token")
+	require.NoError(t, err)
+	store := NewVaultStore(vault, "secret")
+
+	value, err := store.Get(context.Background(), Ref{Name: "clickhouse-prod", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "ch-password", value)
+
+	// A secret with several keys must be referenced with a key
+	_, err = store.Get(context.Background(), Ref{Name: "clickhouse-prod"})
+	assert.Error(t, err)
+
+	_, err = store.Get(context.Background(), Ref{Name: "kafka-prod"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVaultDatabaseCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/database/creds/clickhouse-sink" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"lease_id":       "database/creds/clickhouse-sink/abc",
+			"lease_duration": 3600,
+			"data":           map[string]any{"username": "v-sink-abc", "password": "issued"},
+		})
+	}))
+	defer srv.Close()
+
+	vault, err := NewVaultClient(srv.URL, "token")
+	require.NoError(t, err)
+	issuer, err := NewVaultDatabaseCredentials(vault, "database", "clickhouse-sink")
+	require.NoError(t, err)
+
+	creds, err := issuer.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DatabaseCredentials{
+		Username:      "v-sink-abc",
+		Password:      "issued",
+		LeaseID:       "database/creds/clickhouse-sink/abc",
+		LeaseDuration: time.Hour,
+	}, creds)
+
+	issuer, err = NewVaultDatabaseCredentials(vault, "database", "unknown")
+	require.NoError(t, err)
+	_, err = issuer.Credentials(context.Background())
+	assert.Error(t, err)
+}
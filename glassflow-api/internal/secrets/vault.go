@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// VaultStore reads the secrets from a HashiCorp Vault KV version 2 secrets
+// engine, the ref secretRef:clickhouse-prod from the only key of the secret
+// <mount>/clickhouse-prod.
+type VaultStore struct {
+	vault *VaultClient
+	mount string
+}
+
+func NewVaultStore(vault *VaultClient, mount string) *VaultStore {
+	return &VaultStore{
+		vault: vault,
+		mount: strings.Trim(mount, "/"),
+	}
+}
+
+func (s *VaultStore) Get(ctx context.Context, ref Ref) (string, error) {
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	err := s.vault.Get(ctx, s.mount+"/data/"+url.PathEscape(ref.Name), &body)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%w: secret %q in vault mount %q", ErrNotFound, ref.Name, s.mount)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read secret %q from vault: %w", ref.Name, err)
+	}
+
+	values := make(map[string]string, len(body.Data.Data))
+	for key, value := range body.Data.Data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+
+	return singleValue(ref, values)
+}
@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultClient calls the HTTP API of a HashiCorp Vault server with a token.
+// It is shared by the secret store, the database credentials and the
+// transit key of the credential encryption.
+type VaultClient struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+func NewVaultClient(addr, token string) (*VaultClient, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault token is required")
+	}
+
+	return &VaultClient{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Get reads the API path, relative to /v1/, into out.
+func (c *VaultClient) Get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// Post sends body as JSON to the API path, relative to /v1/, and reads the
+// response into out.
+func (c *VaultClient) Post(ctx context.Context, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode vault request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, path, bytes.NewReader(payload), out)
+}
+
+// do returns ErrNotFound for a path Vault has nothing at.
+func (c *VaultClient) do(ctx context.Context, method, path string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+path, body)
+	if err != nil {
+		return fmt.Errorf("create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: vault path %q", ErrNotFound, path)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode vault response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DatabaseCredentials are short-lived database credentials, valid for
+// LeaseDuration after they were issued. A zero LeaseDuration never expires.
+type DatabaseCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+}
+
+// VaultDatabaseCredentials issues credentials from a role of a HashiCorp
+// Vault database secrets engine. Every call creates a database user that
+// Vault drops when its lease expires.
+type VaultDatabaseCredentials struct {
+	vault *VaultClient
+	mount string
+	role  string
+}
+
+func NewVaultDatabaseCredentials(vault *VaultClient, mount, role string) (*VaultDatabaseCredentials, error) {
+	if role == "" {
+		return nil, fmt.Errorf("vault database role is required")
+	}
+
+	return &VaultDatabaseCredentials{
+		vault: vault,
+		mount: strings.Trim(mount, "/"),
+		role:  role,
+	}, nil
+}
+
+func (c *VaultDatabaseCredentials) Credentials(ctx context.Context) (DatabaseCredentials, error) {
+	var body struct {
+		LeaseID       string `json:"lease_id"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Data          struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"data"`
+	}
+	err := c.vault.Get(ctx, c.mount+"/creds/"+url.PathEscape(c.role), &body)
+	if err != nil {
+		return DatabaseCredentials{}, fmt.Errorf("issue credentials of vault role %q: %w", c.role, err)
+	}
+	if body.Data.Username == "" {
+		return DatabaseCredentials{}, fmt.Errorf("vault role %q issued no username", c.role)
+	}
+
+	return DatabaseCredentials{
+		Username:      body.Data.Username,
+		Password:      body.Data.Password,
+		LeaseID:       body.LeaseID,
+		LeaseDuration: time.Duration(body.LeaseDuration) * time.Second,
+	}, nil
+}
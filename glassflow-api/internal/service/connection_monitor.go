@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+)
+
+// ConnectionHealthStore stores the health of the endpoints the pipelines
+// connect to.
+type ConnectionHealthStore interface {
+	ListStoredConnections(ctx context.Context) ([]models.StoredConnection, error)
+	RecordConnectionHealth(ctx context.Context, endpoint models.ConnectionEndpoint, check models.ConnectionHealthCheck) error
+	PruneConnectionHealth(ctx context.Context, active []string, before time.Time) error
+}
+
+// ConnectionProber connects to an endpoint with its credentials.
+type ConnectionProber interface {
+	Probe(ctx context.Context, endpoint models.ConnectionEndpoint) error
+}
+
+const (
+	// connectionCheckTimeout bounds a health check, a check timing out
+	// reports the endpoint down
+	connectionCheckTimeout = 10 * time.Second
+	// connectionCheckConcurrency bounds the endpoints checked at once
+	connectionCheckConcurrency = 8
+)
+
+// ConnectionMonitor periodically checks the ClickHouse servers and Kafka
+// clusters of the stored connections and records their health and its
+// history, so that an unreachable endpoint is reported once for all its
+// pipelines, whether they are running or not.
+type ConnectionMonitor struct {
+	store           ConnectionHealthStore
+	prober          ConnectionProber
+	interval        time.Duration
+	degradedLatency time.Duration
+	retention       time.Duration
+	log             *slog.Logger
+
+	mu       sync.Mutex
+	statuses map[string]models.ConnectionHealthStatus
+}
+
+// NewConnectionMonitor creates a monitor checking the endpoints every
+// interval. An endpoint answering slower than degradedLatency is degraded,
+// and the checks are kept for retention. The credentials referencing a
+// secret are resolved from secretStore.
+func NewConnectionMonitor(
+	store ConnectionHealthStore,
+	secretStore secrets.Store,
+	interval, degradedLatency, retention time.Duration,
+	log *slog.Logger,
+) *ConnectionMonitor {
+	return &ConnectionMonitor{
+		store:           store,
+		prober:          connectionProber{secrets: secretStore},
+		interval:        interval,
+		degradedLatency: degradedLatency,
+		retention:       retention,
+		log:             log,
+		statuses:        make(map[string]models.ConnectionHealthStatus),
+	}
+}
+
+func (m *ConnectionMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.check(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *ConnectionMonitor) check(ctx context.Context) {
+	conns, err := m.store.ListStoredConnections(ctx)
+	if err != nil {
+		m.log.WarnContext(ctx, "connection health: failed to list connections", "error", err)
+		return
+	}
+	endpoints := models.GroupConnections(conns)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, connectionCheckConcurrency)
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.checkEndpoint(ctx, endpoint)
+		}()
+	}
+	wg.Wait()
+
+	active := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		active = append(active, endpoint.ID)
+	}
+	err = m.store.PruneConnectionHealth(ctx, active, time.Now().UTC().Add(-m.retention))
+	if err != nil {
+		m.log.WarnContext(ctx, "connection health: failed to prune health checks", "error", err)
+	}
+	m.forget(active)
+}
+
+func (m *ConnectionMonitor) checkEndpoint(ctx context.Context, endpoint models.ConnectionEndpoint) {
+	probeCtx, cancel := context.WithTimeout(ctx, connectionCheckTimeout)
+	defer cancel()
+
+	started := time.Now()
+	err := m.prober.Probe(probeCtx, endpoint)
+	check := models.NewConnectionHealthCheck(endpoint.ID, started.UTC(), time.Since(started), m.degradedLatency, err)
+	if ctx.Err() != nil {
+		// Shutting down, the endpoint was not checked
+		return
+	}
+
+	err = m.store.RecordConnectionHealth(ctx, endpoint, check)
+	if err != nil {
+		m.log.WarnContext(ctx, "connection health: failed to record health check",
+			"connection_id", endpoint.ID, "error", err)
+		return
+	}
+	m.logTransition(ctx, endpoint, check)
+}
+
+// logTransition logs the endpoints changing status, a restarted API logs
+// the status of every unhealthy endpoint once.
+func (m *ConnectionMonitor) logTransition(ctx context.Context, endpoint models.ConnectionEndpoint, check models.ConnectionHealthCheck) {
+	m.mu.Lock()
+	previous, seen := m.statuses[endpoint.ID]
+	m.statuses[endpoint.ID] = check.Status
+	m.mu.Unlock()
+
+	if previous == check.Status || (!seen && check.Status == models.ConnectionHealthy) {
+		return
+	}
+
+	attrs := []any{
+		"connection_id", endpoint.ID,
+		"type", endpoint.Type,
+		"address", endpoint.Address,
+		"status", check.Status,
+		"latency_ms", check.LatencyMs,
+	}
+	if check.Status == models.ConnectionHealthy {
+		m.log.InfoContext(ctx, "connection health: endpoint recovered", attrs...)
+		return
+	}
+	if check.Error != "" {
+		attrs = append(attrs, "error", check.Error)
+	}
+	m.log.WarnContext(ctx, "connection health: endpoint unhealthy", attrs...)
+}
+
+func (m *ConnectionMonitor) forget(active []string) {
+	keep := make(map[string]struct{}, len(active))
+	for _, id := range active {
+		keep[id] = struct{}{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.statuses {
+		if _, ok := keep[id]; !ok {
+			delete(m.statuses, id)
+		}
+	}
+}
+
+type connectionProber struct {
+	secrets secrets.Store
+}
+
+func (p connectionProber) Probe(ctx context.Context, endpoint models.ConnectionEndpoint) error {
+	switch endpoint.Type {
+	case models.ConnectionTypeKafka:
+		err := secrets.ResolveKafkaConnection(ctx, p.secrets, &endpoint.Kafka)
+		if err != nil {
+			return err
+		}
+		return kafka.Ping(ctx, endpoint.Kafka)
+	case models.ConnectionTypeClickHouse:
+		err := secrets.ResolveClickHouseConnection(ctx, p.secrets, &endpoint.ClickHouse)
+		if err != nil {
+			return err
+		}
+		chClient, err := client.NewClickHouseClient(ctx, endpoint.ClickHouse)
+		if err != nil {
+			return err
+		}
+		return chClient.Close()
+	default:
+		return fmt.Errorf("unsupported connection type %q", endpoint.Type)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeConnectionHealthStore struct {
+	mu     sync.Mutex
+	conns  []models.StoredConnection
+	checks map[string][]models.ConnectionHealthCheck
+	active []string
+}
+
+func (f *fakeConnectionHealthStore) ListStoredConnections(_ context.Context) ([]models.StoredConnection, error) {
+	return f.conns, nil
+}
+
+func (f *fakeConnectionHealthStore) RecordConnectionHealth(_ context.Context, endpoint models.ConnectionEndpoint, check models.ConnectionHealthCheck) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checks[endpoint.Address] = append(f.checks[endpoint.Address], check)
+	return nil
+}
+
+func (f *fakeConnectionHealthStore) PruneConnectionHealth(_ context.Context, active []string, _ time.Time) error {
+	f.active = active
+	return nil
+}
+
+type fakeConnectionProber struct {
+	errs map[string]error
+}
+
+func (f fakeConnectionProber) Probe(_ context.Context, endpoint models.ConnectionEndpoint) error {
+	return f.errs[endpoint.Address]
+}
+
+func TestConnectionMonitor_Check(t *testing.T) {
+	store := &fakeConnectionHealthStore{
+		conns: []models.StoredConnection{
+			{Type: models.ConnectionTypeClickHouse, PipelineID: "orders", ClickHouse: models.ClickHouseConnectionParamsConfig{Host: "ch.prod-eu", Port: "9000"}},
+			{Type: models.ConnectionTypeClickHouse, PipelineID: "payments", ClickHouse: models.ClickHouseConnectionParamsConfig{Host: "ch.prod-eu", Port: "9000"}},
+			{Type: models.ConnectionTypeKafka, PipelineID: "orders", Kafka: models.KafkaConnectionParamsConfig{Brokers: []string{"kafka:9092"}}},
+		},
+		checks: make(map[string][]models.ConnectionHealthCheck),
+	}
+	monitor := NewConnectionMonitor(store, nil, time.Minute, time.Second, time.Hour, slog.Default())
+	monitor.prober = fakeConnectionProber{errs: map[string]error{
+		"kafka:9092": errors.New("connection refused"),
+	}}
+
+	monitor.check(context.Background())
+
+	// Pipelines sharing a server check it once
+	require.Len(t, store.checks["ch.prod-eu:9000"], 1)
+	assert.Equal(t, models.ConnectionHealthy, store.checks["ch.prod-eu:9000"][0].Status)
+	require.Len(t, store.checks["kafka:9092"], 1)
+	assert.Equal(t, models.ConnectionDown, store.checks["kafka:9092"][0].Status)
+	assert.Equal(t, "connection refused", store.checks["kafka:9092"][0].Error)
+	assert.Len(t, store.active, 2)
+
+	// Endpoints of deleted pipelines are pruned
+	store.conns = store.conns[:1]
+	monitor.check(context.Background())
+	assert.Len(t, store.active, 1)
+	assert.Len(t, monitor.statuses, 1)
+}
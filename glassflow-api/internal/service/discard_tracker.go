@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ConsumerPositionReader returns where the consumers of the pipeline's
+// streams stand in their streams.
+type ConsumerPositionReader interface {
+	ConsumerPositions(ctx context.Context, pipelineID string) ([]models.ConsumerPosition, error)
+}
+
+// discardTimeout bounds the queries of a discard check
+const discardTimeout = 5 * time.Second
+
+type discardState struct {
+	// counted is the last stream sequence counted as discarded
+	counted  uint64
+	discards models.StreamDiscards
+}
+
+// DiscardTracker counts the messages the streams of a pipeline discarded
+// before their consumers processed them. JetStream drops the oldest messages
+// of a stream at its limits without telling the consumers, a consumer that
+// did not acknowledge the messages before the first one the stream still
+// holds lost them. The tracker is shared by the health API and the
+// notification watcher, so that the drops are counted once. Drops a consumer
+// skipped over between two checks are not seen.
+type DiscardTracker struct {
+	reader ConsumerPositionReader
+
+	mu     sync.Mutex
+	states map[string]map[string]*discardState
+}
+
+func NewDiscardTracker(reader ConsumerPositionReader) *DiscardTracker {
+	return &DiscardTracker{
+		reader: reader,
+		states: make(map[string]map[string]*discardState),
+	}
+}
+
+// Check reads the consumer positions of the pipeline and returns the
+// messages discarded since the tracker started, per consumer.
+func (t *DiscardTracker) Check(ctx context.Context, pipelineID string) ([]models.StreamDiscards, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, discardTimeout)
+	defer cancel()
+
+	positions, err := t.reader.ConsumerPositions(ctx, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("get consumer positions: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	states := t.states[pipelineID]
+	if states == nil {
+		states = make(map[string]*discardState)
+		t.states[pipelineID] = states
+	}
+
+	now := time.Now().UTC()
+	for _, p := range positions {
+		key := p.Stream + "/" + p.Consumer
+		state, ok := states[key]
+		if !ok {
+			state = &discardState{discards: models.StreamDiscards{
+				Component: p.Component,
+				Stream:    p.Stream,
+				Consumer:  p.Consumer,
+			}}
+			states[key] = state
+		}
+		if p.LastSeq < state.counted {
+			// The stream was recreated
+			state.counted = 0
+		}
+
+		from := max(p.AckFloor, state.counted) + 1
+		if p.FirstSeq <= from {
+			continue
+		}
+		discarded := p.FirstSeq - from
+		state.counted = p.FirstSeq - 1
+		state.discards.Messages += discarded
+		state.discards.LastAt = now
+	}
+
+	var discards []models.StreamDiscards
+	for _, state := range states {
+		if state.discards.Messages > 0 {
+			discards = append(discards, state.discards)
+		}
+	}
+	sort.Slice(discards, func(i, j int) bool {
+		if discards[i].Stream != discards[j].Stream {
+			return discards[i].Stream < discards[j].Stream
+		}
+		return discards[i].Consumer < discards[j].Consumer
+	})
+
+	return discards, nil
+}
+
+// Forget drops the counts of a pipeline that no longer exists.
+func (t *DiscardTracker) Forget(pipelineID string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, pipelineID)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type mockPositionReader struct {
+	positions []models.ConsumerPosition
+}
+
+func (m *mockPositionReader) ConsumerPositions(_ context.Context, _ string) ([]models.ConsumerPosition, error) {
+	return m.positions, nil
+}
+
+func TestDiscardTracker_CountsMessagesDroppedBeforeTheirAck(t *testing.T) {
+	ctx := context.Background()
+	reader := &mockPositionReader{positions: []models.ConsumerPosition{
+		{Component: "sink", Stream: "gf-sink", Consumer: "gf-s-sink", FirstSeq: 1, LastSeq: 100, AckFloor: 90},
+	}}
+	tracker := NewDiscardTracker(reader)
+
+	discards, err := tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	assert.Empty(t, discards)
+
+	// The stream dropped messages 1-120 while the consumer acknowledged 100
+	reader.positions[0].FirstSeq, reader.positions[0].LastSeq, reader.positions[0].AckFloor = 121, 300, 100
+	discards, err = tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	require.Len(t, discards, 1)
+	assert.Equal(t, uint64(20), discards[0].Messages)
+	assert.Equal(t, "sink", discards[0].Component)
+
+	// The same drops are not counted again, later ones are added
+	discards, err = tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), discards[0].Messages)
+
+	reader.positions[0].FirstSeq = 131
+	discards, err = tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), discards[0].Messages)
+
+	// A recreated stream starts over
+	reader.positions[0].FirstSeq, reader.positions[0].LastSeq, reader.positions[0].AckFloor = 1, 10, 0
+	discards, err = tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), discards[0].Messages)
+
+	tracker.Forget("pipeline")
+	discards, err = tracker.Check(ctx, "pipeline")
+	require.NoError(t, err)
+	assert.Empty(t, discards)
+}
@@ -6,10 +6,15 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/component"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mysql"
 	sr "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_registry"
 	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
@@ -135,6 +140,26 @@ func (i *IngestorRunner) Start(ctx context.Context) error {
 		return fmt.Errorf("create schema for ingestor: %w", err)
 	}
 
+	var mysqlCheckpoints mysql.CheckpointStore
+	if i.pipelineCfg.Ingestor.Type == internal.MySQLIngestorType {
+		mysqlCheckpoints, err = i.mysqlCheckpointStore(ctx)
+		if err != nil {
+			return fmt.Errorf("create mysql checkpoint store: %w", err)
+		}
+	}
+
+	streams := i.outputStreams(ctx)
+
+	// Flow control holds back the polling of Kafka sources only
+	var (
+		flow     *stream.FlowController
+		flowGate kafka.FlowGate
+	)
+	if topicCfg.FlowControl.Enabled() && i.pipelineCfg.Ingestor.Type == internal.KafkaIngestorType {
+		flow = stream.NewFlowController(i.nc.JetStream(), streams, i.topicName, topicCfg.FlowControl, i.log)
+		flowGate = flow
+	}
+
 	component, err := component.NewIngestorComponent(
 		i.pipelineCfg,
 		i.topicName,
@@ -143,6 +168,8 @@ func (i *IngestorRunner) Start(ctx context.Context) error {
 		dlqStreamPublisher,
 		schema,
 		signalPublisher,
+		mysqlCheckpoints,
+		flowGate,
 		i.doneCh,
 		i.log,
 	)
@@ -153,7 +180,7 @@ func (i *IngestorRunner) Start(ctx context.Context) error {
 
 	i.component = component
 
-	i.startStreamSamplers(ctx)
+	i.startStreamSamplers(ctx, streams, flow)
 
 	go func() {
 		component.Start(ctx, i.c)
@@ -166,43 +193,79 @@ func (i *IngestorRunner) Start(ctx context.Context) error {
 	return nil
 }
 
-// startStreamSamplers resolves the streams the ingestor publishes into from
-// its runtime config, then spawns one StreamSampler per unique stream. Each
-// sampler runs until samplerCancel is called from Shutdown.
+// mysqlCheckpointStore returns the KV bucket holding the GTID checkpoints of
+// MySQL sources, creating it on first use.
+func (i *IngestorRunner) mysqlCheckpointStore(ctx context.Context) (mysql.CheckpointStore, error) {
+	//nolint:exhaustruct // optional config
+	kv, err := i.nc.JetStream().CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      internal.MySQLCheckpointBucket,
+		Description: "GTID checkpoints of MySQL sources",
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create nats key value store %s: %w", internal.MySQLCheckpointBucket, err)
+	}
+
+	return mysql.NewKVCheckpointStore(kv), nil
+}
+
+// outputStreams resolves the streams the ingestor publishes into from its
+// runtime config.
 //
 // Subjects map onto streams differently across orchestrators (local: one
 // stream covers all sharded subjects; K8s: one stream per replica), so the
 // stream set is discovered via JetStream's StreamNameBySubject rather than
 // inferred from naming conventions.
-func (i *IngestorRunner) startStreamSamplers(ctx context.Context) {
+func (i *IngestorRunner) outputStreams(ctx context.Context) []string {
 	subjects := ingestorOutputSubjects(i.runtimeCfg)
-	if len(subjects) == 0 {
-		return
-	}
-
-	samplerCtx, cancel := context.WithCancel(ctx)
-	i.samplerCancel = cancel
 
 	js := i.nc.JetStream()
-	streams := make(map[string]struct{}, len(subjects))
+	seen := make(map[string]struct{}, len(subjects))
+	streams := make([]string, 0, len(subjects))
 	for _, subj := range subjects {
-		name, err := js.StreamNameBySubject(samplerCtx, subj)
+		name, err := js.StreamNameBySubject(ctx, subj)
 		if err != nil {
 			i.log.WarnContext(ctx, "stream sampler: skipping subject (no stream bound)",
 				slog.String("subject", subj),
 				slog.Any("error", err))
 			continue
 		}
-		streams[name] = struct{}{}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		streams = append(streams, name)
+	}
+
+	return streams
+}
+
+// startStreamSamplers spawns one StreamSampler per output stream, and the
+// flow controller when flow control is enabled. They run until
+// samplerCancel is called from Shutdown.
+func (i *IngestorRunner) startStreamSamplers(ctx context.Context, streams []string, flow *stream.FlowController) {
+	if len(streams) == 0 && flow == nil {
+		return
 	}
 
-	for name := range streams {
+	samplerCtx, cancel := context.WithCancel(ctx)
+	i.samplerCancel = cancel
+
+	js := i.nc.JetStream()
+	for _, name := range streams {
 		s := stream.NewStreamSampler(js, name, i.log)
 		go s.Run(samplerCtx)
 		i.log.InfoContext(ctx, "stream sampler started",
 			slog.String("stream", name),
 			slog.String("pipeline_id", i.pipelineCfg.Status.PipelineID))
 	}
+
+	if flow != nil {
+		go flow.Run(samplerCtx)
+		i.log.InfoContext(ctx, "flow control started",
+			slog.String("topic", i.topicName),
+			slog.Any("streams", streams))
+	}
 }
 
 // ingestorOutputSubjects returns every distinct subject the ingestor will
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Background jobs of the API that must run on one replica at a time.
+const (
+	JobPipelineScheduler   = "pipeline_scheduler"
+	JobNotificationWatcher = "notification_watcher"
+	JobPipelineArchiver    = "pipeline_archiver"
+)
+
+// JobLeaseStore leases background jobs to one API replica at a time.
+type JobLeaseStore interface {
+	// ClaimJobLease takes the lease of the job for holder when it is free or
+	// expired, or renews it when holder has it already, and reports whether
+	// holder has the lease.
+	ClaimJobLease(ctx context.Context, job, holder string, lease time.Duration) (bool, error)
+}
+
+// JobLease makes a periodic job run on the replica holding its lease row.
+// Every check claims or renews the lease, which lasts a few check intervals,
+// so the other replicas skip the job until the holder stops renewing it.
+type JobLease struct {
+	store  JobLeaseStore
+	job    string
+	holder string
+	lease  time.Duration
+	log    *slog.Logger
+}
+
+// jobLeaseIntervals is the number of check intervals a lease lasts, the holder
+// can miss a renewal before another replica takes over.
+const jobLeaseIntervals = 3
+
+func NewJobLease(store JobLeaseStore, job, holder string, interval time.Duration, log *slog.Logger) *JobLease {
+	return &JobLease{
+		store:  store,
+		job:    job,
+		holder: holder,
+		lease:  jobLeaseIntervals * interval,
+		log:    log,
+	}
+}
+
+// Held claims the lease and reports whether this replica runs the job. A nil
+// lease is always held, for a single API replica. A failed claim is not held.
+func (l *JobLease) Held(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+
+	held, err := l.store.ClaimJobLease(ctx, l.job, l.holder, l.lease)
+	if err != nil {
+		l.log.WarnContext(ctx, "failed to claim background job lease", "job", l.job, "error", err)
+		return false
+	}
+	return held
+}
@@ -6,12 +6,15 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"sync"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/component"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/componentsignals"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
+	filterJSON "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/filter/json"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/join"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kv"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	schemav2 "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/schema_v2"
@@ -27,9 +30,11 @@ type JoinRunner struct {
 	cfg     models.PipelineConfig
 	db      PipelineStore
 
-	component component.Component
-	c         chan error
-	doneCh    chan struct{}
+	// components are the join stages, one unless the join is chained
+	components []component.Component
+	// chClient writes unmatched events to their table, nil unless enabled
+	chClient *client.ClickHouseClient
+	doneCh   chan struct{}
 }
 
 func NewJoinRunner(log *slog.Logger, nc *client.NATSClient, pipelineCfg models.PipelineConfig, db PipelineStore) *JoinRunner {
@@ -40,24 +45,23 @@ func NewJoinRunner(log *slog.Logger, nc *client.NATSClient, pipelineCfg models.P
 		joinCfg: pipelineCfg.Join,
 		db:      db,
 
-		component: nil,
+		components: nil,
 	}
 }
 
-func (j *JoinRunner) Start(ctx context.Context) error {
-	j.doneCh = make(chan struct{})
-	j.c = make(chan error, 1)
+// joinStageStreams are the NATS resources of a join stage.
+type joinStageStreams struct {
+	leftInputStream   string
+	rightInputStream  string
+	leftConsumerName  string
+	rightConsumerName string
 
-	var (
-		leftSource  models.JoinSourceConfig
-		rightSource models.JoinSourceConfig
+	outputSubject      string
+	outputSubjectCount int
+}
 
-		leftConsumer  jetstream.Consumer
-		rightConsumer jetstream.Consumer
-		leftBuffer    kv.KeyValueStore
-		rightBuffer   kv.KeyValueStore
-		err           error
-	)
+func (j *JoinRunner) Start(ctx context.Context) error {
+	j.doneCh = make(chan struct{})
 
 	leftInputStreamName, err := getJoinLeftInputStreamName()
 	if err != nil {
@@ -77,142 +81,290 @@ func (j *JoinRunner) Start(ctx context.Context) error {
 		return fmt.Errorf("resolve join output config: %w", err)
 	}
 
+	signalPublisher, err := componentsignals.NewPublisher(j.nc)
+	if err != nil {
+		j.log.ErrorContext(ctx, "failed to create component signal publisher", "error", err)
+		return fmt.Errorf("create signal publisher: %w", err)
+	}
+
+	unmatched, err := j.newUnmatchedHandler(ctx)
+	if err != nil {
+		j.log.ErrorContext(ctx, "failed to create join unmatched handler", "policy", j.joinCfg.Unmatched.Policy, "error", err)
+		return fmt.Errorf("create unmatched handler: %w", err)
+	}
+
+	checkpointStore, err := j.nc.CreateOrUpdateObjectStore(ctx, models.GetJoinCheckpointBucketName(j.cfg.ID), "Checkpoints of the join stages")
+	if err != nil {
+		j.log.ErrorContext(ctx, "failed to create join checkpoint store", "error", err)
+		return fmt.Errorf("create join checkpoint store: %w", err)
+	}
+	checkpoints := join.NewNATSCheckpointStore(checkpointStore)
+
+	// The filter of a join pipeline reads the output fields of the join, it
+	// runs on the output of the last stage
+	var outputFilter *filterJSON.Filter
+	if j.cfg.Filter.Enabled {
+		outputFilter, err = filterJSON.New(j.cfg.Filter.Expression, true)
+		if err != nil {
+			j.log.ErrorContext(ctx, "failed to create join output filter", "error", err)
+			return fmt.Errorf("create join output filter: %w", err)
+		}
+	}
+
+	// A chained join runs a stage per chained source. The first stage reads
+	// the streams of the orchestrator contract, the stages after it read the
+	// intermediate stream of the stage before them and the stream of their
+	// chained source. Only the last stage publishes to the join output.
+	stages := j.joinCfg.Stages()
+	components := make([]component.Component, 0, len(stages))
+	for i, stageCfg := range stages {
+		streams := joinStageStreams{
+			leftInputStream:    leftInputStreamName,
+			rightInputStream:   rightInputStreamName,
+			leftConsumerName:   models.GetNATSJoinLeftConsumerName(j.cfg.ID),
+			rightConsumerName:  models.GetNATSJoinRightConsumerName(j.cfg.ID),
+			outputSubject:      outputSubject,
+			outputSubjectCount: outputSubjectCount,
+		}
+		if i > 0 {
+			streams.leftInputStream = models.GetJoinStageStreamName(j.cfg.ID, i)
+			streams.rightInputStream = models.GetIngestorStreamName(j.cfg.ID, stageCfg.Sources[1].SourceID)
+			streams.leftConsumerName = models.GetNATSJoinStageConsumerName(j.cfg.ID, i, internal.JoinLeft)
+			streams.rightConsumerName = models.GetNATSJoinStageConsumerName(j.cfg.ID, i, internal.JoinRight)
+		}
+		if i < len(stages)-1 {
+			streams.outputSubject = models.GetNATSSubjectNameDefault(models.GetJoinStageStreamName(j.cfg.ID, i+1))
+			streams.outputSubjectCount = 1
+		}
+
+		// Unmatched events are the left events of the first stage
+		stageUnmatched := unmatched
+		if i > 0 {
+			stageUnmatched = nil
+		}
+		stageFilter := outputFilter
+		if i < len(stages)-1 {
+			stageFilter = nil
+		}
+
+		jComponent, err := j.newStageComponent(
+			ctx, stageCfg, streams, stageUnmatched, stageFilter, signalPublisher,
+			component.WithJoinCheckpoints(checkpoints, "stage-"+strconv.Itoa(i)),
+		)
+		if err != nil {
+			return err
+		}
+		components = append(components, jComponent)
+	}
+
+	j.components = components
+	j.runStages(ctx)
+
+	return nil
+}
+
+// newStageComponent creates the join component of a join stage. The stage
+// publishes only the output events matching outputFilter when it is set.
+func (j *JoinRunner) newStageComponent(
+	ctx context.Context,
+	stageCfg models.JoinComponentConfig,
+	streams joinStageStreams,
+	unmatched join.UnmatchedHandler,
+	outputFilter *filterJSON.Filter,
+	signalPublisher *componentsignals.ComponentSignalPublisher,
+	opts ...component.JoinOption,
+) (component.Component, error) {
+	var leftSource, rightSource models.JoinSourceConfig
+
 	// Determine left and right streams based on orientation
-	if j.cfg.Join.Sources[0].Orientation == "left" {
-		leftSource = j.cfg.Join.Sources[0]
-		rightSource = j.cfg.Join.Sources[1]
+	if stageCfg.Sources[0].Orientation == "left" {
+		leftSource = stageCfg.Sources[0]
+		rightSource = stageCfg.Sources[1]
 	} else {
-		leftSource = j.cfg.Join.Sources[1]
-		rightSource = j.cfg.Join.Sources[0]
+		leftSource = stageCfg.Sources[1]
+		rightSource = stageCfg.Sources[0]
 	}
 
 	j.log.InfoContext(ctx, "Join will read/write NATS resources",
-		"left_input_stream", leftInputStreamName,
-		"right_input_stream", rightInputStreamName,
-		"output_subject", outputSubject,
-		"output_subject_count", outputSubjectCount,
+		"join_id", stageCfg.ID,
+		"left_input_stream", streams.leftInputStream,
+		"right_input_stream", streams.rightInputStream,
+		"output_subject", streams.outputSubject,
+		"output_subject_count", streams.outputSubjectCount,
 		"left_source", leftSource.SourceID,
 		"right_source", rightSource.SourceID)
 
-	leftConsumer, err = stream.NewNATSConsumer(
+	leftConsumer, err := stream.NewNATSConsumer(
 		ctx,
 		j.nc.JetStream(),
 		jetstream.ConsumerConfig{
-			Name:          models.GetNATSJoinLeftConsumerName(j.cfg.ID),
-			Durable:       models.GetNATSJoinLeftConsumerName(j.cfg.ID),
+			Name:          streams.leftConsumerName,
+			Durable:       streams.leftConsumerName,
 			AckPolicy:     jetstream.AckExplicitPolicy,
 			AckWait:       internal.NatsConsumerAckWait,
 			MaxAckPending: -1,
 			MaxDeliver:    internal.NatsConsumerMaxDeliver,
 		},
-		leftInputStreamName,
+		streams.leftInputStream,
 	)
 	if err != nil {
-		j.log.ErrorContext(ctx, "failed to create left consumer", "left_stream", leftInputStreamName, "error", err)
-		return fmt.Errorf("create left consumer: %w", err)
+		j.log.ErrorContext(ctx, "failed to create left consumer", "left_stream", streams.leftInputStream, "error", err)
+		return nil, fmt.Errorf("create left consumer: %w", err)
 	}
 
-	rightConsumer, err = stream.NewNATSConsumer(
+	rightConsumer, err := stream.NewNATSConsumer(
 		ctx,
 		j.nc.JetStream(),
 		jetstream.ConsumerConfig{
-			Name:          models.GetNATSJoinRightConsumerName(j.cfg.ID),
-			Durable:       models.GetNATSJoinRightConsumerName(j.cfg.ID),
+			Name:          streams.rightConsumerName,
+			Durable:       streams.rightConsumerName,
 			AckPolicy:     jetstream.AckExplicitPolicy,
 			AckWait:       internal.NatsConsumerAckWait,
 			MaxAckPending: -1,
 			MaxDeliver:    internal.NatsConsumerMaxDeliver,
 		},
-		rightInputStreamName,
+		streams.rightInputStream,
 	)
 	if err != nil {
-		j.log.ErrorContext(ctx, "failed to create right consumer", "right_stream", rightInputStreamName, "error", err)
-		return fmt.Errorf("create right consumer: %w", err)
+		j.log.ErrorContext(ctx, "failed to create right consumer", "right_stream", streams.rightInputStream, "error", err)
+		return nil, fmt.Errorf("create right consumer: %w", err)
 	}
 
 	// Get existing KV stores (created by orchestrator)
-	leftKVStore, err := j.nc.GetKeyValueStore(ctx, leftInputStreamName)
+	leftKVStore, err := j.nc.GetKeyValueStore(ctx, streams.leftInputStream)
 	if err != nil {
 		j.log.ErrorContext(ctx, "failed to get left stream buffer: ", "error", err)
-		return fmt.Errorf("get left buffer: %w", err)
+		return nil, fmt.Errorf("get left buffer: %w", err)
 	}
 
-	rightKVStore, err := j.nc.GetKeyValueStore(ctx, rightInputStreamName)
+	rightKVStore, err := j.nc.GetKeyValueStore(ctx, streams.rightInputStream)
 	if err != nil {
 		j.log.ErrorContext(ctx, "failed to get right stream buffer: ", "error", err)
-		return fmt.Errorf("get right buffer: %w", err)
+		return nil, fmt.Errorf("get right buffer: %w", err)
 	}
 
-	// Wrap the NATS KeyValue stores in our interface
-	leftBuffer = &kv.NATSKeyValueStore{KVstore: leftKVStore}
-	rightBuffer = &kv.NATSKeyValueStore{KVstore: rightKVStore}
-
 	leftSchema, err := schemav2.NewSchema(j.cfg.ID, leftSource.SourceID, j.db, nil)
 	if err != nil {
 		j.log.ErrorContext(ctx, "failed to create left schema mapper: ", "error", err)
-		return fmt.Errorf("create left schema mapper: %w", err)
+		return nil, fmt.Errorf("create left schema mapper: %w", err)
 	}
 
 	rightSchema, err := schemav2.NewSchema(j.cfg.ID, rightSource.SourceID, j.db, nil)
 	if err != nil {
 		j.log.ErrorContext(ctx, "failed to create right schema mapper: ", "error", err)
-		return fmt.Errorf("create right schema mapper: %w", err)
+		return nil, fmt.Errorf("create right schema mapper: %w", err)
 	}
 
-	resultsPublisher := stream.NewNATSPublisher(j.nc.JetStream(), stream.PublisherConfig{
-		Subject:           outputSubject,
-		TotalSubjectCount: outputSubjectCount,
+	var resultsPublisher stream.Publisher = stream.NewNATSPublisher(j.nc.JetStream(), stream.PublisherConfig{
+		Subject:           streams.outputSubject,
+		TotalSubjectCount: streams.outputSubjectCount,
 	})
-
-	signalPublisher, err := componentsignals.NewPublisher(j.nc)
-	if err != nil {
-		j.log.ErrorContext(ctx, "failed to create component signal publisher", "error", err)
-		return fmt.Errorf("create signal publisher: %w", err)
+	if outputFilter != nil {
+		j.log.InfoContext(ctx, "Join will filter its output", "expression", outputFilter.Expression)
+		resultsPublisher = join.NewFilteredPublisher(resultsPublisher, outputFilter, stream.NewNATSPublisher(j.nc.JetStream(), stream.PublisherConfig{
+			Subject: models.GetDLQStreamSubjectName(j.cfg.ID),
+		}))
 	}
 
 	jComponent, err := component.NewJoinComponent(
-		j.joinCfg,
+		stageCfg,
 		leftConsumer,
 		rightConsumer,
 		resultsPublisher,
 		leftSchema,
 		rightSchema,
 		configs.NewConfigStore(j.db, j.cfg.ID, ""),
-		leftBuffer,
-		rightBuffer,
+		// Wrap the NATS KeyValue stores in our interface
+		&kv.NATSKeyValueStore{KVstore: leftKVStore},
+		&kv.NATSKeyValueStore{KVstore: rightKVStore},
+		unmatched,
 		leftSource.SourceID,
 		rightSource.SourceID,
 		leftSource.JoinKey,
 		rightSource.JoinKey,
-		j.doneCh,
-		j.log,
+		make(chan struct{}),
+		j.log.With("join_id", stageCfg.ID),
 		j.cfg.ID,
 		signalPublisher,
+		opts...,
 	)
 	if err != nil {
 		j.log.ErrorContext(ctx, "failed to join: ", "error", err)
-		return fmt.Errorf("create join: %w", err)
+		return nil, fmt.Errorf("create join: %w", err)
 	}
 
-	j.component = jComponent
+	return jComponent, nil
+}
+
+// runStages starts the join stages. Once a stage stops by itself the others
+// are stopped as well, the runner is done when all stages stopped.
+func (j *JoinRunner) runStages(ctx context.Context) {
+	var wg sync.WaitGroup
+	stopped := make(chan struct{}, len(j.components))
 
-	go func() {
-		jComponent.Start(ctx, j.c)
+	for _, jComponent := range j.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-		close(j.c)
+			errCh := make(chan error, 1)
+			jComponent.Start(ctx, errCh)
 
-		for err := range j.c {
-			j.log.ErrorContext(ctx, "Error in the join component", "error", err)
+			close(errCh)
+
+			for err := range errCh {
+				j.log.ErrorContext(ctx, "Error in the join component", "error", err)
+			}
+			stopped <- struct{}{}
+		}()
+	}
+
+	components := j.components
+	go func() {
+		<-stopped
+		for _, jComponent := range components {
+			jComponent.Stop(component.WithNoWait(true))
 		}
+		wg.Wait()
+		close(j.doneCh)
 	}()
-
-	return nil
 }
 
 func (j *JoinRunner) Shutdown() {
 	j.log.Info("Shutting down JoinRunner")
-	if j.component != nil {
-		j.component.Stop(component.WithNoWait(true))
+	// Stages are stopped upstream first, like the pipeline components
+	for _, jComponent := range j.components {
+		jComponent.Stop(component.WithNoWait(true))
+	}
+	if j.chClient != nil {
+		err := j.chClient.Close()
+		if err != nil {
+			j.log.Error("failed to close unmatched table ClickHouse client", "error", err)
+		}
+		j.chClient = nil
+	}
+}
+
+// newUnmatchedHandler returns the handler of the configured unmatched policy,
+// nil when unmatched events are dropped on expiry.
+func (j *JoinRunner) newUnmatchedHandler(ctx context.Context) (join.UnmatchedHandler, error) {
+	switch j.joinCfg.Unmatched.Policy {
+	case internal.JoinUnmatchedPolicyDLQ:
+		dlqSubject := models.GetDLQStreamSubjectName(j.cfg.ID)
+		j.log.InfoContext(ctx, "Join will write unmatched events to DLQ subject", "dlq_subject", dlqSubject)
+		return join.NewDLQUnmatchedHandler(stream.NewNATSPublisher(j.nc.JetStream(), stream.PublisherConfig{
+			Subject: dlqSubject,
+		})), nil
+	case internal.JoinUnmatchedPolicyTable:
+		chClient, err := client.NewClickHouseClient(ctx, j.cfg.Sink.ClickHouseConnectionParams)
+		if err != nil {
+			return nil, fmt.Errorf("create clickhouse client: %w", err)
+		}
+		j.chClient = chClient
+		j.log.InfoContext(ctx, "Join will write unmatched events to table", "table", j.joinCfg.Unmatched.Table)
+		return join.NewTableUnmatchedHandler(chClient, j.joinCfg.Unmatched, j.cfg.Sink.ClickHouseConnectionParams.Database), nil
+	default:
+		return nil, nil
 	}
 }
 
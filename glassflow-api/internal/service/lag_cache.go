@@ -0,0 +1,66 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type cachedConsumerLag struct {
+	lag []models.KafkaPartitionLag
+	at  time.Time
+}
+
+// consumerLagCache keeps the consumer lag last read for each pipeline for a
+// short TTL. A nil cache never holds a lag.
+type consumerLagCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedConsumerLag
+}
+
+func newConsumerLagCache(ttl time.Duration) *consumerLagCache {
+	return &consumerLagCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedConsumerLag),
+	}
+}
+
+// get returns the lag of the pipeline unless it is missing or expired.
+func (c *consumerLagCache) get(pipelineID string) ([]models.KafkaPartitionLag, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[pipelineID]
+	if !ok || c.now().Sub(entry.at) >= c.ttl {
+		return nil, false
+	}
+	return entry.lag, true
+}
+
+// set stores the lag of the pipeline and drops the expired entries, so the
+// cache doesn't keep the lag of deleted pipelines.
+func (c *consumerLagCache) set(pipelineID string, lag []models.KafkaPartitionLag) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for id, entry := range c.entries {
+		if now.Sub(entry.at) >= c.ttl {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[pipelineID] = cachedConsumerLag{lag: lag, at: now}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
+)
+
+type DLQStateGetter interface {
+	GetDLQState(ctx context.Context, streamName string) (models.DLQState, error)
+}
+
+type Notifier interface {
+	NotifyPipeline(cfg models.PipelineConfig, eventType notification.EventType, details map[string]any)
+	DLQThreshold(cfg *models.PipelineConfig) uint64
+}
+
+// NotificationWatcher polls the pipelines and notifies the webhooks when a
+// pipeline starts running, crashes, its DLQ grows past the threshold, it
+// breaches its SLA, its streams near their storage limits or discard
+// messages, or its source topics change partition count. Created and
+// terminated events are sent by the API handlers.
+type NotificationWatcher struct {
+	db         PipelineStore
+	dlq        DLQStateGetter
+	sla        *SLAEvaluator
+	storage    *StorageMonitor
+	discards   *DiscardTracker
+	partitions *PartitionMonitor
+	notifier   Notifier
+	log        *slog.Logger
+	interval   time.Duration
+	lease      *JobLease
+
+	statuses    map[string]models.PipelineStatus
+	dlqExceeded map[string]bool
+	slaBreached map[string]bool
+	degraded    map[string]bool
+	discarded   map[string]uint64
+	topics      map[string]map[string]int
+	seeded      bool
+}
+
+func NewNotificationWatcher(
+	db PipelineStore,
+	dlq DLQStateGetter,
+	sla *SLAEvaluator,
+	storage *StorageMonitor,
+	discards *DiscardTracker,
+	partitions *PartitionMonitor,
+	notifier Notifier,
+	log *slog.Logger,
+	interval time.Duration,
+) *NotificationWatcher {
+	return &NotificationWatcher{
+		db:          db,
+		dlq:         dlq,
+		sla:         sla,
+		storage:     storage,
+		discards:    discards,
+		partitions:  partitions,
+		notifier:    notifier,
+		log:         log,
+		interval:    interval,
+		statuses:    make(map[string]models.PipelineStatus),
+		dlqExceeded: make(map[string]bool),
+		slaBreached: make(map[string]bool),
+		degraded:    make(map[string]bool),
+		discarded:   make(map[string]uint64),
+		topics:      make(map[string]map[string]int),
+	}
+}
+
+// UseLease sends the notifications only from the API replica holding the
+// lease.
+func (w *NotificationWatcher) UseLease(lease *JobLease) {
+	w.lease = lease
+}
+
+func (w *NotificationWatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.check(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+func (w *NotificationWatcher) check(ctx context.Context) {
+	if !w.lease.Held(ctx) {
+		// Statuses that change until the lease is taken over are recorded
+		// again instead of being notified late
+		w.seeded = false
+		return
+	}
+
+	pipelines, err := w.db.GetPipelines(ctx)
+	if err != nil {
+		w.log.Debug("failed to get pipelines for notifications", "error", err)
+		return
+	}
+
+	seen := make(map[string]struct{}, len(pipelines))
+	for _, pipeline := range pipelines {
+		seen[pipeline.ID] = struct{}{}
+		w.checkStatus(pipeline)
+		w.checkDLQ(ctx, pipeline)
+		w.checkSLA(ctx, pipeline)
+		w.checkStorage(ctx, pipeline)
+		w.checkDiscards(ctx, pipeline)
+		w.checkPartitions(ctx, pipeline)
+	}
+
+	for id := range w.statuses {
+		if _, ok := seen[id]; !ok {
+			delete(w.statuses, id)
+			delete(w.dlqExceeded, id)
+			delete(w.slaBreached, id)
+			delete(w.degraded, id)
+			delete(w.discarded, id)
+			delete(w.topics, id)
+			w.sla.Forget(id)
+			w.discards.Forget(id)
+		}
+	}
+
+	w.seeded = true
+}
+
+// checkStatus notifies about status transitions. The first poll only records
+// the statuses so that a restart doesn't resend events for every pipeline.
+func (w *NotificationWatcher) checkStatus(pipeline models.PipelineConfig) {
+	current := pipeline.Status.OverallStatus
+	previous, known := w.statuses[pipeline.ID]
+	w.statuses[pipeline.ID] = current
+
+	if !w.seeded || (known && previous == current) {
+		return
+	}
+
+	details := map[string]any{
+		"previous_status": string(previous),
+	}
+
+	switch current {
+	case internal.PipelineStatusRunning:
+		w.notifier.NotifyPipeline(pipeline, notification.EventPipelineRunning, details)
+	case internal.PipelineStatusFailed:
+		w.notifier.NotifyPipeline(pipeline, notification.EventPipelineCrashed, details)
+	}
+}
+
+// checkDLQ notifies once when the unconsumed DLQ messages go past the
+// threshold and again only after they dropped back below it.
+func (w *NotificationWatcher) checkDLQ(ctx context.Context, pipeline models.PipelineConfig) {
+	threshold := w.notifier.DLQThreshold(&pipeline)
+	if threshold == 0 || w.dlq == nil {
+		return
+	}
+
+	state, err := w.dlq.GetDLQState(ctx, models.GetDLQStreamName(pipeline.ID))
+	if err != nil {
+		w.log.Debug("failed to get dlq state for notifications", "pipeline_id", pipeline.ID, "error", err)
+		return
+	}
+
+	exceeded := state.UnconsumedMessages > threshold
+	if exceeded && !w.dlqExceeded[pipeline.ID] {
+		w.notifier.NotifyPipeline(pipeline, notification.EventDLQThresholdExceeded, map[string]any{
+			"unconsumed_messages": state.UnconsumedMessages,
+			"threshold":           threshold,
+		})
+	}
+	w.dlqExceeded[pipeline.ID] = exceeded
+}
+
+// checkSLA notifies once when the pipeline breaches its SLA and once when it
+// meets it again. A pipeline that stops running is no longer considered
+// breaching, without a recovered event.
+func (w *NotificationWatcher) checkSLA(ctx context.Context, pipeline models.PipelineConfig) {
+	if w.sla == nil {
+		return
+	}
+
+	status := w.sla.Evaluate(ctx, pipeline, nil)
+	breached := status != nil && status.Breached
+
+	switch {
+	case breached && !w.slaBreached[pipeline.ID]:
+		w.notifier.NotifyPipeline(pipeline, notification.EventSLABreached, map[string]any{
+			"breaches": status.Breaches,
+		})
+	case !breached && w.slaBreached[pipeline.ID] && status != nil:
+		w.notifier.NotifyPipeline(pipeline, notification.EventSLARecovered, nil)
+	}
+	w.slaBreached[pipeline.ID] = breached
+}
+
+// checkStorage notifies once when a stream of the pipeline goes past the
+// storage threshold and once when all are below it again. Like the SLA, a
+// pipeline that stops running is no longer degraded, without a recovered
+// event.
+func (w *NotificationWatcher) checkStorage(ctx context.Context, pipeline models.PipelineConfig) {
+	if w.storage == nil {
+		return
+	}
+
+	status := w.storage.Evaluate(ctx, pipeline)
+	degraded := status != nil && status.Degraded
+
+	switch {
+	case degraded && !w.degraded[pipeline.ID]:
+		w.notifier.NotifyPipeline(pipeline, notification.EventStorageDegraded, map[string]any{
+			"threshold": status.Threshold,
+			"alerts":    status.Alerts,
+		})
+	case !degraded && w.degraded[pipeline.ID] && status != nil:
+		w.notifier.NotifyPipeline(pipeline, notification.EventStorageRecovered, nil)
+	}
+	w.degraded[pipeline.ID] = degraded
+}
+
+// checkDiscards notifies every time the streams of the pipeline discarded
+// messages since the previous check. The health API counts the discards it
+// sees too, the watcher compares the totals.
+func (w *NotificationWatcher) checkDiscards(ctx context.Context, pipeline models.PipelineConfig) {
+	if w.discards == nil {
+		return
+	}
+
+	discards, err := w.discards.Check(ctx, pipeline.ID)
+	if err != nil {
+		w.log.Debug("failed to get discarded messages for notifications", "pipeline_id", pipeline.ID, "error", err)
+		return
+	}
+
+	var total uint64
+	for _, d := range discards {
+		total += d.Messages
+	}
+	previous := w.discarded[pipeline.ID]
+	w.discarded[pipeline.ID] = total
+	if total <= previous {
+		return
+	}
+
+	w.log.WarnContext(ctx, "pipeline streams discarded unprocessed messages", "pipeline_id", pipeline.ID, "messages", total-previous)
+	w.notifier.NotifyPipeline(pipeline, notification.EventMessagesDiscarded, map[string]any{
+		"discarded_messages": total - previous,
+		"total":              total,
+		"discards":           discards,
+	})
+}
+
+// checkPartitions notifies when a source topic of the pipeline has a
+// different partition count than at the previous check. The first count of a
+// pipeline is only recorded, like the statuses, and the counts of a pipeline
+// that is not running are kept to compare against once it runs again.
+func (w *NotificationWatcher) checkPartitions(ctx context.Context, pipeline models.PipelineConfig) {
+	if w.partitions == nil {
+		return
+	}
+
+	counts := w.partitions.Counts(ctx, pipeline)
+	if counts == nil {
+		return
+	}
+	previous, known := w.topics[pipeline.ID]
+	w.topics[pipeline.ID] = counts
+	if !known {
+		return
+	}
+
+	for _, topic := range pipeline.Ingestor.KafkaTopics {
+		before, ok := previous[topic.Name]
+		if !ok || before == counts[topic.Name] {
+			continue
+		}
+
+		w.log.InfoContext(ctx, "pipeline source topic partitions changed",
+			"pipeline_id", pipeline.ID,
+			"topic", topic.Name,
+			"previous_partitions", before,
+			"partitions", counts[topic.Name])
+		w.notifier.NotifyPipeline(pipeline, notification.EventPartitionsChanged, map[string]any{
+			"topic":               topic.Name,
+			"previous_partitions": before,
+			"partitions":          counts[topic.Name],
+			"replicas":            max(topic.Replicas, 1),
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/notification"
+)
+
+type mockNotifier struct {
+	threshold uint64
+	events    []notification.EventType
+}
+
+func (m *mockNotifier) NotifyPipeline(_ models.PipelineConfig, eventType notification.EventType, _ map[string]any) {
+	m.events = append(m.events, eventType)
+}
+
+func (m *mockNotifier) DLQThreshold(_ *models.PipelineConfig) uint64 {
+	return m.threshold
+}
+
+type mockDLQState struct {
+	unconsumed uint64
+}
+
+func (m *mockDLQState) GetDLQState(_ context.Context, _ string) (models.DLQState, error) {
+	return models.DLQState{UnconsumedMessages: m.unconsumed}, nil
+}
+
+func setPipelineStatus(store *mockPipelineStore, id, status string) {
+	pipeline := store.pipelines[id]
+	pipeline.ID = id
+	pipeline.Status.OverallStatus = models.PipelineStatus(status)
+	store.pipelines[id] = pipeline
+}
+
+func TestNotificationWatcher_StatusTransitions(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	notifier := &mockNotifier{}
+	watcher := NewNotificationWatcher(store, nil, nil, nil, nil, nil, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	// statuses present at startup are only recorded
+	setPipelineStatus(store, "running-pipeline", internal.PipelineStatusRunning)
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	setPipelineStatus(store, "new-pipeline", internal.PipelineStatusCreated)
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	setPipelineStatus(store, "new-pipeline", internal.PipelineStatusRunning)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventPipelineRunning}, notifier.events)
+
+	// unchanged statuses are not notified again
+	watcher.check(ctx)
+	assert.Len(t, notifier.events, 1)
+
+	setPipelineStatus(store, "running-pipeline", internal.PipelineStatusFailed)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{
+		notification.EventPipelineRunning,
+		notification.EventPipelineCrashed,
+	}, notifier.events)
+}
+
+func TestNotificationWatcher_DLQThreshold(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+	dlqState := &mockDLQState{}
+	notifier := &mockNotifier{threshold: 10}
+	watcher := NewNotificationWatcher(store, dlqState, nil, nil, nil, nil, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	dlqState.unconsumed = 11
+	watcher.check(ctx)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventDLQThresholdExceeded}, notifier.events)
+
+	// notified again only after the dlq was drained below the threshold
+	dlqState.unconsumed = 5
+	watcher.check(ctx)
+	dlqState.unconsumed = 20
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{
+		notification.EventDLQThresholdExceeded,
+		notification.EventDLQThresholdExceeded,
+	}, notifier.events)
+}
+
+type mockLatencyReader struct {
+	latency time.Duration
+}
+
+func (m *mockLatencyReader) PipelineLatency(_ context.Context, _ string) (time.Duration, error) {
+	return m.latency, nil
+}
+
+func TestNotificationWatcher_SLA(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+	pipeline := store.pipelines["test-pipeline"]
+	pipeline.Metadata.SLA = &models.SLAConfig{MaxLatency: *models.NewJSONDuration(time.Minute)}
+	store.pipelines["test-pipeline"] = pipeline
+
+	latency := &mockLatencyReader{}
+	notifier := &mockNotifier{}
+	watcher := NewNotificationWatcher(store, nil, NewSLAEvaluator(latency, nil, nil, slog.Default()), nil, nil, nil, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	latency.latency = 2 * time.Minute
+	watcher.check(ctx)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventSLABreached}, notifier.events)
+
+	latency.latency = time.Second
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{
+		notification.EventSLABreached,
+		notification.EventSLARecovered,
+	}, notifier.events)
+}
+
+type mockStorageReader struct {
+	bytes uint64
+}
+
+func (m *mockStorageReader) StreamsStorage(_ context.Context, _ string) ([]models.StreamStorage, error) {
+	return []models.StreamStorage{
+		models.NewStreamStorage("gf-stream", m.bytes, 1000, 10, 0),
+		models.NewStreamStorage("gf-dlq", 0, 0, 0, 0),
+	}, nil
+}
+
+func TestNotificationWatcher_Storage(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+
+	reader := &mockStorageReader{bytes: 500}
+	notifier := &mockNotifier{}
+	watcher := NewNotificationWatcher(store, nil, nil, NewStorageMonitor(reader, 0.8, slog.Default()), nil, nil, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	reader.bytes = 900
+	watcher.check(ctx)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventStorageDegraded}, notifier.events)
+
+	reader.bytes = 100
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{
+		notification.EventStorageDegraded,
+		notification.EventStorageRecovered,
+	}, notifier.events)
+
+	// The threshold of the pipeline takes precedence
+	pipeline := store.pipelines["test-pipeline"]
+	pipeline.Metadata.Notifications = &models.NotificationConfig{StorageThreshold: 0.05}
+	store.pipelines["test-pipeline"] = pipeline
+	watcher.check(ctx)
+	assert.Len(t, notifier.events, 3)
+	assert.Equal(t, notification.EventStorageDegraded, notifier.events[2])
+}
+
+func TestNotificationWatcher_Discards(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+
+	reader := &mockPositionReader{positions: []models.ConsumerPosition{
+		{Stream: "gf-sink", Consumer: "gf-s-sink", FirstSeq: 1, LastSeq: 100, AckFloor: 50},
+	}}
+	tracker := NewDiscardTracker(reader)
+	notifier := &mockNotifier{}
+	watcher := NewNotificationWatcher(store, nil, nil, nil, tracker, nil, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	// Drops seen by a health request first are still notified
+	reader.positions[0].FirstSeq = 61
+	_, err := tracker.Check(ctx, "test-pipeline")
+	assert.NoError(t, err)
+	watcher.check(ctx)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventMessagesDiscarded}, notifier.events)
+}
+
+type mockPartitionReader struct {
+	counts map[string]int
+}
+
+func (m *mockPartitionReader) PartitionCounts(_ context.Context, _ models.KafkaConnectionParamsConfig, _ []string) (map[string]int, error) {
+	counts := make(map[string]int, len(m.counts))
+	for topic, count := range m.counts {
+		counts[topic] = count
+	}
+	return counts, nil
+}
+
+func TestNotificationWatcher_Partitions(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{}}
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+	pipeline := store.pipelines["test-pipeline"]
+	pipeline.Ingestor.KafkaTopics = []models.KafkaTopicsConfig{{Name: "orders", Replicas: 2}}
+	store.pipelines["test-pipeline"] = pipeline
+
+	reader := &mockPartitionReader{counts: map[string]int{"orders": 4}}
+	monitor := &PartitionMonitor{reader: reader, log: slog.Default()}
+	notifier := &mockNotifier{}
+	watcher := NewNotificationWatcher(store, nil, nil, nil, nil, monitor, notifier, slog.Default(), time.Minute)
+	ctx := context.Background()
+
+	// The first count is only recorded
+	watcher.check(ctx)
+	assert.Empty(t, notifier.events)
+
+	reader.counts["orders"] = 8
+	watcher.check(ctx)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{notification.EventPartitionsChanged}, notifier.events)
+
+	// Changes while the pipeline is stopped are notified once it runs again
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusStopped)
+	reader.counts["orders"] = 12
+	watcher.check(ctx)
+	assert.Len(t, notifier.events, 1)
+
+	setPipelineStatus(store, "test-pipeline", internal.PipelineStatusRunning)
+	watcher.check(ctx)
+	assert.Equal(t, []notification.EventType{
+		notification.EventPartitionsChanged,
+		notification.EventPipelineRunning,
+		notification.EventPartitionsChanged,
+	}, notifier.events)
+}
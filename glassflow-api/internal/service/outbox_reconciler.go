@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// OutboxReconciler runs the orchestrator actions the API stored but did not
+// complete, because it stopped or the orchestrator call failed. An action
+// failing OutboxMaxAttempts times is given up on and its pipeline marked
+// Failed.
+type OutboxReconciler struct {
+	orchestrator Orchestrator
+	db           PipelineStore
+	outbox       OutboxStore
+	log          *slog.Logger
+	interval     time.Duration
+}
+
+func NewOutboxReconciler(
+	orch Orchestrator,
+	db PipelineStore,
+	outbox OutboxStore,
+	log *slog.Logger,
+	interval time.Duration,
+) *OutboxReconciler {
+	return &OutboxReconciler{
+		orchestrator: orch,
+		db:           db,
+		outbox:       outbox,
+		log:          log,
+		interval:     interval,
+	}
+}
+
+func (r *OutboxReconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.reconcile(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *OutboxReconciler) reconcile(ctx context.Context) {
+	actions, err := r.outbox.ClaimOutboxActions(ctx, internal.OutboxActionLease, internal.OutboxClaimLimit)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to claim outbox actions", "error", err)
+		return
+	}
+
+	for _, action := range actions {
+		r.log.InfoContext(ctx, "running outbox action",
+			"pipeline_id", action.PipelineID,
+			"action", action.Action,
+			"attempt", action.Attempts)
+
+		err := r.run(ctx, action)
+		switch {
+		case err == nil:
+			err = r.outbox.CompleteOutboxAction(ctx, action.ID)
+			if err != nil {
+				r.log.WarnContext(ctx, "failed to complete outbox action", "pipeline_id", action.PipelineID, "action", action.Action, "error", err)
+			}
+		case action.Attempts >= internal.OutboxMaxAttempts:
+			r.giveUp(ctx, action, err)
+		default:
+			r.log.WarnContext(ctx, "outbox action failed, will retry",
+				"pipeline_id", action.PipelineID,
+				"action", action.Action,
+				"attempt", action.Attempts,
+				"error", err)
+			err = r.outbox.FailOutboxAction(ctx, action.ID, err.Error())
+			if err != nil {
+				r.log.WarnContext(ctx, "failed to record outbox action error", "pipeline_id", action.PipelineID, "action", action.Action, "error", err)
+			}
+		}
+	}
+}
+
+// run runs the action against the pipeline as stored, so that a retried
+// setup or edit applies the latest config. Actions of a deleted pipeline
+// have nothing left to do.
+func (r *OutboxReconciler) run(ctx context.Context, action models.OutboxAction) error {
+	switch action.Action {
+	case internal.OutboxActionSetup, internal.OutboxActionEdit:
+		pipeline, err := r.db.GetPipeline(ctx, action.PipelineID)
+		if errors.Is(err, ErrPipelineNotExists) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get pipeline: %w", err)
+		}
+		if action.Action == internal.OutboxActionSetup {
+			return r.orchestrator.SetupPipeline(ctx, pipeline)
+		}
+		return r.orchestrator.EditPipeline(ctx, action.PipelineID, pipeline)
+	case internal.OutboxActionDelete:
+		err := r.orchestrator.DeletePipeline(ctx, action.PipelineID)
+		// Without orchestrator resources nothing deletes the pipeline row
+		if errors.Is(err, ErrPipelineNotFound) || (err == nil && r.orchestrator.GetType() == "local") {
+			err = r.db.DeletePipeline(ctx, action.PipelineID)
+			if errors.Is(err, ErrPipelineNotExists) {
+				return nil
+			}
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown outbox action %q", action.Action)
+	}
+}
+
+// giveUp marks the pipeline Failed and drops the action.
+func (r *OutboxReconciler) giveUp(ctx context.Context, action models.OutboxAction, cause error) {
+	r.log.ErrorContext(ctx, "outbox action failed too many times, marking pipeline failed",
+		"pipeline_id", action.PipelineID,
+		"action", action.Action,
+		"attempts", action.Attempts,
+		"error", cause)
+
+	pipeline, err := r.db.GetPipeline(ctx, action.PipelineID)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to get pipeline of failed outbox action", "pipeline_id", action.PipelineID, "error", err)
+		return
+	}
+
+	pipeline.Status.OverallStatus = internal.PipelineStatusFailed
+	err = r.db.UpdatePipelineStatus(ctx, action.PipelineID, pipeline.Status)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to mark pipeline failed", "pipeline_id", action.PipelineID, "error", err)
+		return
+	}
+
+	err = r.outbox.CompleteOutboxAction(ctx, action.ID)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to complete outbox action", "pipeline_id", action.PipelineID, "action", action.Action, "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeOutbox struct {
+	actions map[uuid.UUID]models.OutboxAction
+	pending map[uuid.UUID]bool
+}
+
+func newFakeOutbox() *fakeOutbox {
+	return &fakeOutbox{
+		actions: make(map[uuid.UUID]models.OutboxAction),
+		pending: make(map[uuid.UUID]bool),
+	}
+}
+
+func (f *fakeOutbox) InsertPipelineWithAction(_ context.Context, _ models.PipelineConfig, action models.OutboxAction) error {
+	f.actions[action.ID] = action
+	return nil
+}
+
+func (f *fakeOutbox) UpdatePipelineWithAction(_ context.Context, _ string, _ models.PipelineConfig, action models.OutboxAction) error {
+	f.actions[action.ID] = action
+	return nil
+}
+
+func (f *fakeOutbox) EnqueueOutboxAction(_ context.Context, action models.OutboxAction) error {
+	f.actions[action.ID] = action
+	return nil
+}
+
+// ClaimOutboxActions claims the actions made due by expire
+func (f *fakeOutbox) ClaimOutboxActions(_ context.Context, _ time.Duration, _ int) ([]models.OutboxAction, error) {
+	var claimed []models.OutboxAction
+	for id, action := range f.actions {
+		if !f.pending[id] {
+			continue
+		}
+		f.pending[id] = false
+		action.Attempts++
+		f.actions[id] = action
+		claimed = append(claimed, action)
+	}
+	return claimed, nil
+}
+
+func (f *fakeOutbox) CompleteOutboxAction(_ context.Context, id uuid.UUID) error {
+	delete(f.actions, id)
+	return nil
+}
+
+func (f *fakeOutbox) FailOutboxAction(_ context.Context, id uuid.UUID, reason string) error {
+	action := f.actions[id]
+	action.LastError = reason
+	f.actions[id] = action
+	return nil
+}
+
+func (f *fakeOutbox) expire() {
+	for id := range f.actions {
+		f.pending[id] = true
+	}
+}
+
+func TestEditPipeline_WithOutbox(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	outbox := newFakeOutbox()
+	pipelineService := NewPipelineService(mockOrchestrator, mockStore, slog.Default(), WithOutbox(outbox))
+
+	pipelineID := "test-pipeline-123"
+	current := &models.PipelineConfig{ID: pipelineID, Status: models.PipelineHealth{OverallStatus: internal.PipelineStatusStopped}}
+	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(current, nil)
+	mockStore.On("UpsertPipelineResources", mock.Anything, pipelineID, mock.Anything).Return(&models.PipelineResourcesRow{PipelineID: pipelineID}, nil)
+	mockOrchestrator.On("EditPipeline", mock.Anything, pipelineID, mock.Anything).Return(errors.New("api server unavailable")).Once()
+
+	err := pipelineService.EditPipeline(context.Background(), pipelineID, &models.PipelineConfig{ID: pipelineID})
+	require.Error(t, err)
+	require.Len(t, outbox.actions, 1, "the failed edit stays in the outbox")
+	for _, action := range outbox.actions {
+		assert.Equal(t, internal.OutboxActionEdit, action.Action)
+		assert.Equal(t, "api server unavailable", action.LastError)
+	}
+
+	// The reconciler retries the edit once the lease expired
+	mockOrchestrator.On("EditPipeline", mock.Anything, pipelineID, current).Return(nil).Once()
+	reconciler := NewOutboxReconciler(mockOrchestrator, mockStore, outbox, slog.Default(), time.Minute)
+	outbox.expire()
+	reconciler.reconcile(context.Background())
+
+	assert.Empty(t, outbox.actions)
+	mockOrchestrator.AssertExpectations(t)
+}
+
+func TestOutboxReconciler_SetupAfterCrash(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	outbox := newFakeOutbox()
+
+	pipeline := &models.PipelineConfig{ID: "test-pipeline-123"}
+	action := models.NewOutboxAction(pipeline.ID, internal.OutboxActionSetup)
+	require.NoError(t, outbox.InsertPipelineWithAction(context.Background(), *pipeline, action))
+
+	mockStore.On("GetPipeline", mock.Anything, pipeline.ID).Return(pipeline, nil)
+	mockOrchestrator.On("SetupPipeline", mock.Anything, pipeline).Return(nil).Once()
+
+	reconciler := NewOutboxReconciler(mockOrchestrator, mockStore, outbox, slog.Default(), time.Minute)
+	reconciler.reconcile(context.Background())
+	assert.Len(t, outbox.actions, 1, "actions within their lease are left to the API")
+
+	outbox.expire()
+	reconciler.reconcile(context.Background())
+	assert.Empty(t, outbox.actions)
+	mockOrchestrator.AssertExpectations(t)
+}
+
+func TestOutboxReconciler_MarksPipelineFailed(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	outbox := newFakeOutbox()
+
+	pipeline := &models.PipelineConfig{ID: "test-pipeline-123"}
+	action := models.NewOutboxAction(pipeline.ID, internal.OutboxActionSetup)
+	require.NoError(t, outbox.InsertPipelineWithAction(context.Background(), *pipeline, action))
+
+	mockStore.On("GetPipeline", mock.Anything, pipeline.ID).Return(pipeline, nil)
+	mockOrchestrator.On("SetupPipeline", mock.Anything, pipeline).Return(errors.New("quota exceeded"))
+	mockStore.On("UpdatePipelineStatus", mock.Anything, pipeline.ID, mock.MatchedBy(func(h models.PipelineHealth) bool {
+		return h.OverallStatus == internal.PipelineStatusFailed
+	})).Return(nil).Once()
+
+	reconciler := NewOutboxReconciler(mockOrchestrator, mockStore, outbox, slog.Default(), time.Minute)
+	for range internal.OutboxMaxAttempts {
+		outbox.expire()
+		reconciler.reconcile(context.Background())
+	}
+
+	assert.Empty(t, outbox.actions)
+	mockOrchestrator.AssertNumberOfCalls(t, "SetupPipeline", internal.OutboxMaxAttempts)
+	mockStore.AssertExpectations(t)
+}
+
+func TestOutboxReconciler_DeleteWithoutResources(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	outbox := newFakeOutbox()
+
+	action := models.NewOutboxAction("test-pipeline-123", internal.OutboxActionDelete)
+	require.NoError(t, outbox.EnqueueOutboxAction(context.Background(), action))
+
+	mockOrchestrator.On("DeletePipeline", mock.Anything, action.PipelineID).Return(ErrPipelineNotFound)
+	mockStore.On("DeletePipeline", mock.Anything, action.PipelineID).Return(nil).Once()
+
+	reconciler := NewOutboxReconciler(mockOrchestrator, mockStore, outbox, slog.Default(), time.Minute)
+	outbox.expire()
+	reconciler.reconcile(context.Background())
+
+	assert.Empty(t, outbox.actions)
+	mockStore.AssertExpectations(t)
+}
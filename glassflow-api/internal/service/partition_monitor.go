@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+)
+
+// PartitionReader returns the number of partitions of Kafka topics.
+type PartitionReader interface {
+	PartitionCounts(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []string) (map[string]int, error)
+}
+
+// partitionTimeout bounds the queries of a partition count
+const partitionTimeout = 5 * time.Second
+
+// PartitionMonitor counts the partitions of the source topics of running
+// Kafka pipelines, so that a change is reported to the owners. The ingestors
+// start consuming the added partitions on their own, but a topic that grew
+// past the ingestor replicas leaves replicas reading several partitions.
+type PartitionMonitor struct {
+	reader PartitionReader
+	log    *slog.Logger
+}
+
+func NewPartitionMonitor(secretStore secrets.Store, log *slog.Logger) *PartitionMonitor {
+	return &PartitionMonitor{
+		reader: kafkaPartitionReader{secrets: secretStore},
+		log:    log,
+	}
+}
+
+// Counts returns the partition count of every source topic of the pipeline,
+// nil when it is not a running Kafka pipeline or the topics could not be
+// read.
+func (m *PartitionMonitor) Counts(ctx context.Context, pipeline models.PipelineConfig) map[string]int {
+	if m == nil || m.reader == nil || pipeline.Status.OverallStatus != internal.PipelineStatusRunning {
+		return nil
+	}
+	if pipeline.SourceType.IsPulsar() || pipeline.SourceType.IsMySQL() || len(pipeline.Ingestor.KafkaTopics) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, partitionTimeout)
+	defer cancel()
+
+	topics := make([]string, 0, len(pipeline.Ingestor.KafkaTopics))
+	for _, topic := range pipeline.Ingestor.KafkaTopics {
+		topics = append(topics, topic.Name)
+	}
+
+	counts, err := m.reader.PartitionCounts(ctx, pipeline.Ingestor.KafkaConnectionParams, topics)
+	if err != nil {
+		m.log.WarnContext(ctx, "partitions: failed to count topic partitions", "pipeline_id", pipeline.ID, "error", err)
+		return nil
+	}
+	return counts
+}
+
+type kafkaPartitionReader struct {
+	secrets secrets.Store
+}
+
+func (r kafkaPartitionReader) PartitionCounts(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []string) (map[string]int, error) {
+	err := secrets.ResolveKafkaConnection(ctx, r.secrets, &conn)
+	if err != nil {
+		return nil, err
+	}
+	return kafka.GetPartitionCounts(ctx, conn, topics)
+}
@@ -5,17 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/featureflags"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
 )
 
 type Orchestrator interface {
 	GetType() string
 	SetupPipeline(ctx context.Context, cfg *models.PipelineConfig) error
-	StopPipeline(ctx context.Context, pid string) error
+	StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error
 	TerminatePipeline(ctx context.Context, pid string) error
 	DeletePipeline(ctx context.Context, pid string) error
 	ResumePipeline(ctx context.Context, pid string, newCfg *models.PipelineConfig) error
@@ -29,6 +41,7 @@ type PipelineStore interface {
 	GetPipeline(ctx context.Context, pid string) (*models.PipelineConfig, error)
 	GetPipelineWithSchemaVersions(ctx context.Context, pid string, sourceSchemaVersions map[string]string) (*models.PipelineConfig, error)
 	GetPipelines(ctx context.Context) ([]models.PipelineConfig, error)
+	GetPipelinesByTags(ctx context.Context, tags []string) ([]models.PipelineConfig, error)
 	PatchPipelineName(ctx context.Context, pid string, name string) error
 	PatchPipelineMetadata(ctx context.Context, pid string, metadata models.PipelineMetadata) error
 	UpdatePipelineStatus(ctx context.Context, pid string, status models.PipelineHealth) error
@@ -38,22 +51,311 @@ type PipelineStore interface {
 	GetSchemaVersion(ctx context.Context, pipelineID, sourceID, versionID string) (*models.SchemaVersion, error)
 	GetLatestSchemaVersion(ctx context.Context, pipelineID, sourceID string) (*models.SchemaVersion, error)
 	SaveNewSchemaVersion(ctx context.Context, pipelineID, sourceID, oldVersionID, newVersionID string) error
+	ConfirmSinkStaging(ctx context.Context, pid string) error
+	SetPausedComponents(ctx context.Context, pid string, components []string) error
+}
+
+// OutboxStore stores the orchestrator actions in the transaction of the
+// pipeline change they follow, so that an API crash in between cannot leave
+// a pipeline the orchestrator never heard of.
+type OutboxStore interface {
+	InsertPipelineWithAction(ctx context.Context, pi models.PipelineConfig, action models.OutboxAction) error
+	UpdatePipelineWithAction(ctx context.Context, pid string, cfg models.PipelineConfig, action models.OutboxAction) error
+	EnqueueOutboxAction(ctx context.Context, action models.OutboxAction) error
+	ClaimOutboxActions(ctx context.Context, lease time.Duration, limit int) ([]models.OutboxAction, error)
+	CompleteOutboxAction(ctx context.Context, id uuid.UUID) error
+	FailOutboxAction(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// TableCreator creates the ClickHouse sink table of pipelines that set
+// auto_create_table, and the staging table of pipelines that set staging.
+type TableCreator interface {
+	CreateTable(ctx context.Context, cfg models.SinkComponentConfig) error
+}
+
+// TableInspector returns the column types of an existing ClickHouse sink
+// table, used to fill the column types that sink mappings omit.
+type TableInspector interface {
+	ColumnTypes(ctx context.Context, params models.ClickHouseConnectionParamsConfig) (map[string]string, error)
+}
+
+// ConsumerLagReader returns the lag of the consumer groups reading the
+// pipeline's source topics, reported in the pipeline health.
+type ConsumerLagReader interface {
+	ConsumerLag(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []models.KafkaTopicsConfig) ([]models.KafkaPartitionLag, error)
+}
+
+// TopicOffsetMigrator maps the committed offsets of a consumer group from a
+// Kafka topic to a topic carrying the same records under a new name, and
+// commits them unless dryRun.
+type TopicOffsetMigrator interface {
+	MigrateOffsets(ctx context.Context, conn models.KafkaConnectionParamsConfig, group, from, to string, dryRun bool) (models.TopicMigration, error)
+}
+
+// KafkaClusterReader returns the id of the Kafka cluster the brokers of a
+// connection point at.
+type KafkaClusterReader interface {
+	ClusterID(ctx context.Context, conn models.KafkaConnectionParamsConfig) (string, error)
+}
+
+// InFlightReader returns the messages the pipeline's components have
+// received from NATS but not processed yet.
+type InFlightReader interface {
+	ConsumersInFlight(ctx context.Context, pipelineID string) ([]models.ComponentInFlight, error)
 }
 
 type PipelineService struct {
-	orchestrator Orchestrator
-	db           PipelineStore
-	log          *slog.Logger
+	orchestrator   Orchestrator
+	db             PipelineStore
+	tableCreator   TableCreator
+	tableInspector TableInspector
+	lagReader      ConsumerLagReader
+	lagCache       *consumerLagCache
+	topicMigrator  TopicOffsetMigrator
+	clusterReader  KafkaClusterReader
+	inFlightReader InFlightReader
+	slaEvaluator   *SLAEvaluator
+	storageMonitor *StorageMonitor
+	discardTracker *DiscardTracker
+	outbox         OutboxStore
+	featureFlags   *featureflags.Flags
+	license        *license.License
+	ownership      []models.OwnershipField
+	streamMaxAge   *time.Duration
+	log            *slog.Logger
+}
+
+type PipelineServiceOption func(*PipelineService)
+
+// WithInFlightReader reports the in-flight messages in the pipeline health
+// and requires a drained pipeline before it is edited.
+func WithInFlightReader(reader InFlightReader) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.inFlightReader = reader
+	}
+}
+
+// WithSLAEvaluator reports the SLA status of running pipelines with SLA
+// objectives in the pipeline health.
+func WithSLAEvaluator(evaluator *SLAEvaluator) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.slaEvaluator = evaluator
+	}
+}
+
+// WithStorageMonitor reports the storage of the streams of running
+// pipelines in the pipeline health.
+func WithStorageMonitor(monitor *StorageMonitor) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.storageMonitor = monitor
+	}
+}
+
+// WithDiscardTracker reports the messages the streams of the pipeline
+// discarded before they were processed in the pipeline health.
+func WithDiscardTracker(tracker *DiscardTracker) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.discardTracker = tracker
+	}
+}
+
+// WithOutbox stores the orchestrator actions of pipeline creates, edits and
+// deletes with the pipeline change, an OutboxReconciler runs those the API
+// did not complete.
+func WithOutbox(outbox OutboxStore) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.outbox = outbox
+	}
+}
+
+// WithFeatureFlags rejects creating or editing pipelines that use a
+// capability whose feature flag is disabled for them.
+func WithFeatureFlags(flags *featureflags.Flags) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.featureFlags = flags
+	}
+}
+
+// WithLicense rejects creating or editing pipelines that use an enterprise
+// feature the license does not grant.
+func WithLicense(l *license.License) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.license = l
+	}
+}
+
+// WithRequiredOwnership requires the ownership fields in the metadata of the
+// pipelines created, and keeps edits and metadata updates from clearing
+// them. Pipelines created before the policy keep running without them.
+func WithRequiredOwnership(fields []models.OwnershipField) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.ownership = fields
+	}
+}
+
+// WithStreamMaxAge checks the windows of the pipelines against the maxAge of
+// the NATS streams instead of the one of their resources, for orchestrators
+// creating all the streams with the same maxAge.
+func WithStreamMaxAge(maxAge time.Duration) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.streamMaxAge = &maxAge
+	}
+}
+
+// WithSecretStore resolves the secret references of the credentials of the
+// pipelines when the API connects to their ClickHouse server or Kafka
+// cluster, the stored configs keep the references.
+func WithSecretStore(store secrets.Store) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.tableCreator = clickhouseTableCreator{secrets: store}
+		p.tableInspector = clickhouseTableInspector{secrets: store}
+		p.lagReader = kafkaConsumerLagReader{secrets: store}
+		p.topicMigrator = kafkaTopicOffsetMigrator{secrets: store}
+	}
+}
+
+// WithKafkaClusterCheck records the Kafka cluster the brokers of a pipeline
+// point at when it is created, and refuses to resume it or restart it with
+// an edit once they point at another cluster, unless the resume is forced.
+func WithKafkaClusterCheck(store secrets.Store) PipelineServiceOption {
+	return func(p *PipelineService) {
+		p.clusterReader = kafkaClusterReader{secrets: store}
+	}
+}
+
+func NewPipelineService(orch Orchestrator, db PipelineStore, log *slog.Logger, opts ...PipelineServiceOption) *PipelineService {
+	p := &PipelineService{
+		orchestrator:   orch,
+		db:             db,
+		tableCreator:   clickhouseTableCreator{},
+		tableInspector: clickhouseTableInspector{},
+		lagReader:      kafkaConsumerLagReader{},
+		lagCache:       newConsumerLagCache(consumerLagCacheTTL),
+		topicMigrator:  kafkaTopicOffsetMigrator{},
+		log:            log,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type clickhouseTableCreator struct {
+	secrets secrets.Store
 }
 
-func NewPipelineService(orch Orchestrator, db PipelineStore, log *slog.Logger) *PipelineService {
-	return &PipelineService{
-		orchestrator: orch,
-		db:           db,
-		log:          log,
+func (c clickhouseTableCreator) CreateTable(ctx context.Context, cfg models.SinkComponentConfig) error {
+	var queries []string
+	if cfg.CreateTable != nil {
+		query, err := cfg.CreateTableQuery()
+		if err != nil {
+			return err
+		}
+		queries = append(queries, query)
+	}
+	// The staging table copies the sink table, so it is created second
+	if cfg.Staging != nil {
+		query, err := cfg.StagingTableQuery()
+		if err != nil {
+			return err
+		}
+		queries = append(queries, query)
+	}
+
+	err := secrets.ResolveClickHouseConnection(ctx, c.secrets, &cfg.ClickHouseConnectionParams)
+	if err != nil {
+		return err
+	}
+
+	chClient, err := client.NewClickHouseClient(ctx, cfg.ClickHouseConnectionParams)
+	if err != nil {
+		return err
+	}
+	defer chClient.Close()
+
+	for _, query := range queries {
+		err = chClient.Exec(ctx, query)
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
+}
+
+type clickhouseTableInspector struct {
+	secrets secrets.Store
 }
 
+func (c clickhouseTableInspector) ColumnTypes(ctx context.Context, params models.ClickHouseConnectionParamsConfig) (map[string]string, error) {
+	err := secrets.ResolveClickHouseConnection(ctx, c.secrets, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	chClient, err := client.NewClickHouseClient(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	defer chClient.Close()
+
+	return chClient.ColumnTypes(ctx)
+}
+
+type kafkaConsumerLagReader struct {
+	secrets secrets.Store
+}
+
+func (r kafkaConsumerLagReader) ConsumerLag(ctx context.Context, conn models.KafkaConnectionParamsConfig, topics []models.KafkaTopicsConfig) ([]models.KafkaPartitionLag, error) {
+	err := secrets.ResolveKafkaConnection(ctx, r.secrets, &conn)
+	if err != nil {
+		return nil, err
+	}
+	return kafka.GetConsumerLag(ctx, conn, topics)
+}
+
+type kafkaTopicOffsetMigrator struct {
+	secrets secrets.Store
+}
+
+func (m kafkaTopicOffsetMigrator) MigrateOffsets(ctx context.Context, conn models.KafkaConnectionParamsConfig, group, from, to string, dryRun bool) (models.TopicMigration, error) {
+	err := secrets.ResolveKafkaConnection(ctx, m.secrets, &conn)
+	if err != nil {
+		return models.TopicMigration{}, err
+	}
+	return kafka.MigrateTopicOffsets(ctx, conn, group, from, to, dryRun)
+}
+
+type kafkaClusterReader struct {
+	secrets secrets.Store
+}
+
+func (r kafkaClusterReader) ClusterID(ctx context.Context, conn models.KafkaConnectionParamsConfig) (string, error) {
+	err := secrets.ResolveKafkaConnection(ctx, r.secrets, &conn)
+	if err != nil {
+		return "", err
+	}
+	return kafka.GetClusterID(ctx, conn)
+}
+
+// consumerLagTimeout bounds the broker queries of a health request
+const consumerLagTimeout = 5 * time.Second
+
+// consumerLagCacheTTL is how long health requests reuse the consumer lag of a
+// pipeline before the brokers are queried again
+const consumerLagCacheTTL = 10 * time.Second
+
+// topicMigrationTimeout bounds the broker queries mapping the offsets of a
+// topic migration
+const topicMigrationTimeout = 30 * time.Second
+
+// clusterIDTimeout bounds the broker query of the Kafka cluster id
+const clusterIDTimeout = 5 * time.Second
+
+// inFlightTimeout bounds the NATS consumer queries of a health request or
+// of the drain check before an edit
+const inFlightTimeout = 5 * time.Second
+
 var (
 	ErrIDExists                    = errors.New("pipeline with this ID already exists")
 	ErrPipelineNotFound            = errors.New("no active pipeline found")
@@ -62,8 +364,177 @@ var (
 	ErrPipelineQuotaReached        = errors.New("pipeline quota reached; shutdown active pipeline(s)")
 	ErrPipelineResourcesValidation = errors.New("invalid pipeline resources")
 	ErrInvalidSchemaSelection      = errors.New("invalid schema selection")
+	ErrCreateSinkTable             = errors.New("failed to create sink table")
+	ErrPipelineNotStaging          = errors.New("pipeline sink is not writing to a staging table")
+	ErrSinkColumnTypes             = errors.New("failed to resolve sink column types")
+	ErrPipelineNotDrained          = errors.New("pipeline components still hold in-flight messages")
+	ErrInvalidDependencies         = errors.New("invalid pipeline dependencies")
+	ErrDependencyNotRunning        = errors.New("pipeline dependency is not running")
+	ErrFeatureDisabled             = errors.New("pipeline uses a disabled feature")
+	ErrFeatureNotLicensed          = errors.New("pipeline uses an unlicensed feature")
+	ErrOwnershipRequired           = errors.New("pipeline metadata is missing required ownership")
+	ErrDedupDomainConflict         = errors.New("dedup domain is used with another time window")
+	ErrTopicMigrationSource        = errors.New("topic migration needs a pipeline reading the topic from kafka")
+	ErrTopicMigrationTarget        = errors.New("pipeline already reads the topic migrated to")
+	ErrConsumerGroupActive         = errors.New("consumer group of the pipeline still has active members")
+	ErrKafkaClusterChanged         = errors.New("kafka brokers point at a different cluster than at pipeline creation")
+	ErrPipelineNotStopped          = errors.New("pipeline is not stopped")
+	ErrPipelineHasDependents       = errors.New("other pipelines depend on the pipeline")
+	ErrPipelineNotArchived         = errors.New("no archived pipeline with given id exists")
+	ErrPipelineForbidden           = errors.New("pipeline is not owned by a team of the caller")
 )
 
+// checkFeatureFlags fails when the pipeline config uses a capability whose
+// feature flag is disabled for the pipeline. Running pipelines are not
+// affected by a flag turned off.
+func (p *PipelineService) checkFeatureFlags(ctx context.Context, pid string, cfg *models.PipelineConfig) error {
+	if p.featureFlags == nil {
+		return nil
+	}
+
+	disabled, err := p.featureFlags.Disabled(ctx, pid, featureflags.Used(*cfg))
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to resolve feature flags", "pipeline_id", pid, "error", err)
+		return fmt.Errorf("resolve feature flags: %w", err)
+	}
+	if len(disabled) > 0 {
+		return fmt.Errorf("%w: %s", ErrFeatureDisabled, strings.Join(disabled, ", "))
+	}
+	return nil
+}
+
+// checkLicense fails when the pipeline config uses an enterprise feature the
+// license does not grant. Like the feature flags, it does not stop running
+// pipelines when the license expires.
+func (p *PipelineService) checkLicense(cfg *models.PipelineConfig) error {
+	if p.license == nil {
+		return nil
+	}
+
+	unlicensed := p.license.Unlicensed(license.Used(*cfg), time.Now())
+	if len(unlicensed) > 0 {
+		return fmt.Errorf("%w: %s", ErrFeatureNotLicensed, strings.Join(unlicensed, ", "))
+	}
+	return nil
+}
+
+func (p *PipelineService) checkOwnership(metadata models.PipelineMetadata) error {
+	missing := metadata.MissingOwnership(p.ownership)
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, field := range missing {
+			names[i] = string(field)
+		}
+		return fmt.Errorf("%w: %s", ErrOwnershipRequired, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// checkDedupDomains fails when the pipeline joins a dedup domain with
+// another time window than the pipelines already in it, the domain shares
+// one bucket whose TTL is the window.
+func (p *PipelineService) checkDedupDomains(ctx context.Context, pid string, cfg *models.PipelineConfig) error {
+	domains := cfg.DedupDomains()
+	if len(domains) == 0 {
+		return nil
+	}
+
+	pipelines, err := p.db.GetPipelines(ctx)
+	if err != nil {
+		return fmt.Errorf("load pipelines: %w", err)
+	}
+
+	for _, pipeline := range pipelines {
+		if pipeline.ID == pid {
+			continue
+		}
+		for domain, window := range pipeline.DedupDomains() {
+			if want, ok := domains[domain]; ok && want != window {
+				return fmt.Errorf("%w: domain %s has a window of %s in pipeline %s", ErrDedupDomainConflict, domain, window, pipeline.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// fillSinkColumnTypes learns the column types that the sink mapping omits
+// from the existing sink table.
+// kafkaClusterID returns the id of the Kafka cluster the brokers of the
+// pipeline point at, empty when the check is disabled, the pipeline does not
+// read Kafka or the brokers could not be reached: the cluster is then
+// checked by the next resume.
+func (p *PipelineService) kafkaClusterID(ctx context.Context, cfg *models.PipelineConfig) string {
+	if p.clusterReader == nil || cfg.SourceType.IsPulsar() || cfg.SourceType.IsMySQL() || len(cfg.Ingestor.KafkaConnectionParams.Brokers) == 0 {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterIDTimeout)
+	defer cancel()
+
+	id, err := p.clusterReader.ClusterID(ctx, cfg.Ingestor.KafkaConnectionParams)
+	if err != nil {
+		p.log.WarnContext(ctx, "failed to get kafka cluster id", "pipeline_id", cfg.ID, "error", err)
+		return ""
+	}
+	return id
+}
+
+// checkKafkaCluster compares the Kafka cluster the brokers of the pipeline
+// point at with the one recorded for it, and records it in cfg when none
+// was or force is set. It returns whether cfg changed.
+func (p *PipelineService) checkKafkaCluster(ctx context.Context, cfg *models.PipelineConfig, force bool) (bool, error) {
+	current := p.kafkaClusterID(ctx, cfg)
+	recorded := cfg.Ingestor.KafkaConnectionParams.ClusterID
+	if current == "" || current == recorded {
+		return false, nil
+	}
+
+	if recorded != "" {
+		if !force {
+			return false, fmt.Errorf("%w: recorded %q, brokers now point at %q", ErrKafkaClusterChanged, recorded, current)
+		}
+		p.log.WarnContext(ctx, "accepting the kafka cluster the brokers now point at",
+			"pipeline_id", cfg.ID,
+			"recorded_cluster_id", recorded,
+			"cluster_id", current)
+	}
+
+	cfg.Ingestor.KafkaConnectionParams.ClusterID = current
+	return true, nil
+}
+
+func (p *PipelineService) fillSinkColumnTypes(ctx context.Context, cfg *models.PipelineConfig) error {
+	if !cfg.Sink.MissingColumnTypes() {
+		return nil
+	}
+
+	columnTypes, err := p.tableInspector.ColumnTypes(ctx, cfg.Sink.ClickHouseConnectionParams)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to get sink table column types", "pipeline_id", cfg.ID, "table", cfg.Sink.ClickHouseConnectionParams.Table, "error", err)
+		return fmt.Errorf("%w: %w", ErrSinkColumnTypes, err)
+	}
+
+	err = cfg.Sink.FillColumnTypes(columnTypes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSinkColumnTypes, err)
+	}
+	// Mapped AggregateFunction columns fail the validation below, the others
+	// are left out of the inserts and keep their default state
+	if columns := mapper.AggregateFunctionColumns(columnTypes); len(columns) > 0 {
+		p.log.WarnContext(ctx, "sink table has AggregateFunction columns that the pipeline cannot write",
+			"pipeline_id", cfg.ID, "table", cfg.Sink.ClickHouseConnectionParams.Table, "columns", columns)
+	}
+	for _, m := range cfg.Sink.Config {
+		err = mapper.ValidateClickHouseColumnType(m.DestinationType)
+		if err != nil {
+			return fmt.Errorf("%w: column %q: %w", ErrSinkColumnTypes, m.DestinationField, err)
+		}
+	}
+
+	p.log.InfoContext(ctx, "sink column types learned from ClickHouse", "pipeline_id", cfg.ID, "table", cfg.Sink.ClickHouseConnectionParams.Table)
+	return nil
+}
+
 func (p *PipelineService) NewPipelineResources(ctx context.Context, cfg *models.PipelineConfig) (models.PipelineResources, error) {
 	defaults := models.NewDefaultPipelineResources(cfg)
 
@@ -104,6 +575,21 @@ func (p *PipelineService) NewPipelineResources(ctx context.Context, cfg *models.
 	return models.MergeWithDefaults(cfg, cfg.PipelineResources, defaults), nil
 }
 
+// validateStreamRetention checks the windows of a pipeline against the maxAge
+// of the NATS streams it is deployed with.
+func (p *PipelineService) validateStreamRetention(cfg *models.PipelineConfig, resources models.PipelineResources) error {
+	maxAge := resources.StreamMaxAge()
+	if p.streamMaxAge != nil {
+		maxAge = *p.streamMaxAge
+	}
+
+	err := models.ValidateStreamRetention(*cfg, maxAge)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPipelineResourcesValidation, err)
+	}
+	return nil
+}
+
 // CreatePipeline implements PipelineService.
 func (p *PipelineService) CreatePipeline(ctx context.Context, cfg *models.PipelineConfig) error {
 	existing, err := p.db.GetPipeline(ctx, cfg.ID)
@@ -117,6 +603,37 @@ func (p *PipelineService) CreatePipeline(ctx context.Context, cfg *models.Pipeli
 		return fmt.Errorf("create pipeline: %w", ErrIDExists)
 	}
 
+	err = p.validateDependencies(ctx, cfg.ID, cfg.Metadata.DependsOn)
+	if err != nil {
+		return err
+	}
+	err = p.ensureDependenciesRunning(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkFeatureFlags(ctx, cfg.ID, cfg)
+	if err != nil {
+		return err
+	}
+	err = p.checkLicense(cfg)
+	if err != nil {
+		return err
+	}
+	err = p.checkOwnership(cfg.Metadata)
+	if err != nil {
+		return err
+	}
+	err = p.checkDedupDomains(ctx, cfg.ID, cfg)
+	if err != nil {
+		return err
+	}
+
+	// The operator deploys joins of two sources only
+	if len(cfg.Join.Chain) > 0 && p.orchestrator.GetType() != "local" {
+		return fmt.Errorf("chained join sources: %w", ErrNotImplemented)
+	}
+
 	// Set initial status to Created
 	cfg.Status = models.NewPipelineHealth(cfg.ID, cfg.Name)
 	if p.orchestrator.GetType() == "local" {
@@ -128,10 +645,41 @@ func (p *PipelineService) CreatePipeline(ctx context.Context, cfg *models.Pipeli
 	if err != nil {
 		return fmt.Errorf("validate pipeline resources: %w", err)
 	}
+	err = p.validateStreamRetention(cfg, newResources)
+	if err != nil {
+		return err
+	}
 	cfg.PipelineResources = newResources
 
+	err = p.fillSinkColumnTypes(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.Ingestor.KafkaConnectionParams.ClusterID = p.kafkaClusterID(ctx, cfg)
+
+	if cfg.Sink.CreateTable != nil || cfg.Sink.Staging != nil {
+		err = p.tableCreator.CreateTable(ctx, cfg.Sink)
+		if err != nil {
+			p.log.ErrorContext(ctx, "failed to create sink table", "pipeline_id", cfg.ID, "table", cfg.Sink.ClickHouseConnectionParams.Table, "error", err)
+			return fmt.Errorf("%w: %w", ErrCreateSinkTable, err)
+		}
+	}
+
+	err = p.setConfigHash(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
 	// Insert pipeline to database FIRST so schema versions and configs are available before components start
-	err = p.db.InsertPipeline(ctx, *cfg)
+	var action *models.OutboxAction
+	if p.outbox != nil {
+		setup := models.NewOutboxAction(cfg.ID, internal.OutboxActionSetup)
+		action = &setup
+		err = p.outbox.InsertPipelineWithAction(ctx, *cfg, setup)
+	} else {
+		err = p.db.InsertPipeline(ctx, *cfg)
+	}
 	if err != nil {
 		p.log.ErrorContext(ctx, "failed to insert pipeline to database", "pipeline_id", cfg.ID, "error", err)
 		return fmt.Errorf("insert pipeline: %w", err)
@@ -151,16 +699,31 @@ func (p *PipelineService) CreatePipeline(ctx context.Context, cfg *models.Pipeli
 		}
 		return fmt.Errorf("create pipeline: %w", err)
 	}
+	p.completeAction(ctx, action)
 
 	return nil
 }
 
 // DeletePipeline implements PipelineService.
 func (p *PipelineService) DeletePipeline(ctx context.Context, pid string) error {
+	// A pipeline missing from the database has nothing to keep consistent
+	var action *models.OutboxAction
+	if p.outbox != nil {
+		del := models.NewOutboxAction(pid, internal.OutboxActionDelete)
+		err := p.outbox.EnqueueOutboxAction(ctx, del)
+		if err != nil && !errors.Is(err, ErrPipelineNotExists) {
+			return fmt.Errorf("enqueue delete action: %w", err)
+		}
+		if err == nil {
+			action = &del
+		}
+	}
+
 	// First call orchestrator to handle resource cleanup
 	err := p.orchestrator.DeletePipeline(ctx, pid)
 	if err != nil {
 		p.log.ErrorContext(ctx, "failed to delete pipeline from orchestrator", "pipeline_id", pid, "error", err)
+		p.failAction(ctx, action, err)
 		return fmt.Errorf("delete pipeline from orchestrator: %w", err)
 	}
 
@@ -172,7 +735,10 @@ func (p *PipelineService) DeletePipeline(ctx context.Context, pid string) error
 			p.log.ErrorContext(ctx, "failed to delete pipeline from database", "pipeline_id", pid, "error", err)
 			return fmt.Errorf("delete pipeline from database: %w", err)
 		}
+		// Deleting the pipeline deleted its actions
+		return nil
 	}
+	p.completeAction(ctx, action)
 
 	return nil
 }
@@ -299,10 +865,11 @@ func (p *PipelineService) GetOTLPConfig(ctx context.Context, pid string) (models
 	}
 
 	return models.OTLPConfig{
-		PipelineID: pipeline.ID,
-		SourceType: pipeline.SourceType,
-		Routing:    routing,
-		Status:     string(pipeline.Status.OverallStatus),
+		PipelineID:      pipeline.ID,
+		SourceType:      pipeline.SourceType,
+		SchemaVersionID: pipeline.SchemaVersions[pipeline.OTLPSource.ID].VersionID,
+		Routing:         routing,
+		Status:          string(pipeline.Status.OverallStatus),
 	}, nil
 }
 
@@ -361,11 +928,10 @@ func (p *PipelineService) UpdatePipelineResources(ctx context.Context, pid strin
 }
 
 // GetPipelines implements PipelineService.
-func (p *PipelineService) GetPipelines(ctx context.Context) ([]models.ListPipelineConfig, error) {
-	pipelines, err := p.db.GetPipelines(ctx)
+func (p *PipelineService) GetPipelines(ctx context.Context, tags []string) ([]models.ListPipelineConfig, error) {
+	pipelines, err := p.pipelinesByTags(ctx, tags)
 	if err != nil {
-		p.log.ErrorContext(ctx, "failed to load pipelines from database", "error", err)
-		return nil, fmt.Errorf("load pipelines: %w", err)
+		return nil, err
 	}
 
 	ps := make([]models.ListPipelineConfig, 0, len(pipelines))
@@ -376,6 +942,49 @@ func (p *PipelineService) GetPipelines(ctx context.Context) ([]models.ListPipeli
 	return ps, nil
 }
 
+// GetTagsHealth implements PipelineService.
+func (p *PipelineService) GetTagsHealth(ctx context.Context, tags []string) ([]models.TagHealth, error) {
+	pipelines, err := p.pipelinesByTags(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.TagsHealth(pipelines, tags), nil
+}
+
+// pipelinesByTags returns the pipelines carrying all the tags, all the
+// pipelines when tags is empty. A caller scoped to teams only gets the
+// pipelines of its teams.
+func (p *PipelineService) pipelinesByTags(ctx context.Context, tags []string) ([]models.PipelineConfig, error) {
+	for _, tag := range tags {
+		if err := models.ValidateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		pipelines []models.PipelineConfig
+		err       error
+	)
+	if len(tags) == 0 {
+		pipelines, err = p.db.GetPipelines(ctx)
+	} else {
+		pipelines, err = p.db.GetPipelinesByTags(ctx, tags)
+	}
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to load pipelines from database", "error", err)
+		return nil, fmt.Errorf("load pipelines: %w", err)
+	}
+
+	if principal, ok := auth.PrincipalFrom(ctx); ok && principal.Scoped() {
+		pipelines = slices.DeleteFunc(pipelines, func(cfg models.PipelineConfig) bool {
+			return !principal.CanAccess(cfg.Metadata.Team)
+		})
+	}
+
+	return pipelines, nil
+}
+
 // UpdatePipelineName implements PipelineService.
 func (p *PipelineService) UpdatePipelineName(ctx context.Context, id string, name string) error {
 	err := p.db.PatchPipelineName(ctx, id, name)
@@ -389,7 +998,17 @@ func (p *PipelineService) UpdatePipelineName(ctx context.Context, id string, nam
 
 // UpdatePipelineMetadata implements PipelineService.
 func (p *PipelineService) UpdatePipelineMetadata(ctx context.Context, id string, metadata models.PipelineMetadata) error {
-	err := p.db.PatchPipelineMetadata(ctx, id, metadata)
+	err := p.checkOwnership(metadata)
+	if err != nil {
+		return err
+	}
+
+	err = p.validateDependencies(ctx, id, metadata.DependsOn)
+	if err != nil {
+		return err
+	}
+
+	err = p.db.PatchPipelineMetadata(ctx, id, metadata)
 	if err != nil {
 		return fmt.Errorf("update pipeline metadata: %w", err)
 	}
@@ -397,6 +1016,143 @@ func (p *PipelineService) UpdatePipelineMetadata(ctx context.Context, id string,
 	return nil
 }
 
+// ConfirmStaging implements PipelineService. The running sink notices the
+// confirmation and switches from the staging table to the sink table.
+func (p *PipelineService) ConfirmStaging(ctx context.Context, pid string) error {
+	pipeline, err := p.db.GetPipeline(ctx, pid)
+	if err != nil {
+		if errors.Is(err, ErrPipelineNotExists) {
+			return ErrPipelineNotExists
+		}
+		p.log.ErrorContext(ctx, "failed to get pipeline for staging confirmation", "pipeline_id", pid, "error", err)
+		return fmt.Errorf("get pipeline failed for staging confirmation: %w", err)
+	}
+
+	if pipeline.Sink.Staging == nil {
+		return ErrPipelineNotStaging
+	}
+
+	err = p.db.ConfirmSinkStaging(ctx, pid)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to confirm sink staging", "pipeline_id", pid, "error", err)
+		return fmt.Errorf("confirm sink staging: %w", err)
+	}
+
+	p.log.InfoContext(ctx, "pipeline staging confirmed", "pipeline_id", pid, "staging_table", pipeline.Sink.StagingTable())
+	return nil
+}
+
+// MigrateTopic implements PipelineService. It moves a stopped pipeline from
+// the Kafka topic from to the topic to, which carries the same records under
+// a new name. The committed offsets of the pipeline's consumer group are
+// mapped to to by timestamp before the edit switches the ingestor to it. The
+// mapping is at-least-once: no record is skipped, but the records sharing a
+// millisecond with the position the pipeline stopped at are read again. A dry
+// run only returns the offsets.
+func (p *PipelineService) MigrateTopic(ctx context.Context, pid, from, to string, dryRun bool) (models.TopicMigration, error) {
+	pipeline, err := p.db.GetPipeline(ctx, pid)
+	if err != nil {
+		if errors.Is(err, ErrPipelineNotExists) {
+			return models.TopicMigration{}, ErrPipelineNotExists
+		}
+		return models.TopicMigration{}, fmt.Errorf("get pipeline: %w", err)
+	}
+
+	if pipeline.SourceType.IsPulsar() || pipeline.SourceType.IsMySQL() {
+		return models.TopicMigration{}, ErrTopicMigrationSource
+	}
+	idx := slices.IndexFunc(pipeline.Ingestor.KafkaTopics, func(t models.KafkaTopicsConfig) bool { return t.Name == from })
+	if idx < 0 {
+		return models.TopicMigration{}, fmt.Errorf("%w: pipeline does not read topic %q", ErrTopicMigrationSource, from)
+	}
+	if slices.ContainsFunc(pipeline.Ingestor.KafkaTopics, func(t models.KafkaTopicsConfig) bool { return t.Name == to }) {
+		return models.TopicMigration{}, fmt.Errorf("%w: %q", ErrTopicMigrationTarget, to)
+	}
+
+	// The consumer group must not move while the offsets are mapped
+	if pipeline.Status.OverallStatus != internal.PipelineStatusStopped {
+		return models.TopicMigration{}, status.NewPipelineNotStoppedForEditError(models.PipelineStatus(pipeline.Status.OverallStatus))
+	}
+	err = p.ensureDrained(ctx, pid)
+	if err != nil {
+		return models.TopicMigration{}, err
+	}
+
+	topic := pipeline.Ingestor.KafkaTopics[idx]
+	migrateCtx, cancel := context.WithTimeout(ctx, topicMigrationTimeout)
+	defer cancel()
+
+	migration, err := p.topicMigrator.MigrateOffsets(migrateCtx, pipeline.Ingestor.KafkaConnectionParams, topic.ConsumerGroupName, from, to, dryRun)
+	if err != nil {
+		if errors.Is(err, kafka.ErrConsumerGroupActive) {
+			return models.TopicMigration{}, fmt.Errorf("%w: %w", ErrConsumerGroupActive, err)
+		}
+		p.log.ErrorContext(ctx, "failed to map consumer group offsets", "pipeline_id", pid, "from", from, "to", to, "error", err)
+		return models.TopicMigration{}, fmt.Errorf("map consumer group offsets: %w", err)
+	}
+	if dryRun {
+		return migration, nil
+	}
+
+	// The offsets of to are committed, the edit switches the ingestor to it
+	newCfg := *pipeline
+	newCfg.Ingestor.KafkaTopics = slices.Clone(pipeline.Ingestor.KafkaTopics)
+	newCfg.Ingestor.KafkaTopics[idx].Name = to
+	err = p.EditPipeline(ctx, pid, &newCfg)
+	if err != nil {
+		return models.TopicMigration{}, fmt.Errorf("switch pipeline to topic %q: %w", to, err)
+	}
+
+	p.log.InfoContext(ctx, "pipeline migrated to topic",
+		"pipeline_id", pid,
+		"from", from,
+		"to", to,
+		"consumer_group", migration.ConsumerGroup,
+		"per_partition", migration.PerPartition)
+	return migration, nil
+}
+
+// PauseComponent implements PipelineService. The running component notices
+// the pause and stops consuming, the rest of the pipeline keeps running and
+// the events wait in the NATS streams.
+func (p *PipelineService) PauseComponent(ctx context.Context, pid, component string) error {
+	return p.setComponentPaused(ctx, pid, component, true)
+}
+
+// ResumeComponent implements PipelineService.
+func (p *PipelineService) ResumeComponent(ctx context.Context, pid, component string) error {
+	return p.setComponentPaused(ctx, pid, component, false)
+}
+
+func (p *PipelineService) setComponentPaused(ctx context.Context, pid, component string, paused bool) error {
+	err := models.ValidatePausableComponent(component)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := p.db.GetPipeline(ctx, pid)
+	if err != nil {
+		if errors.Is(err, ErrPipelineNotExists) {
+			return ErrPipelineNotExists
+		}
+		return fmt.Errorf("get pipeline: %w", err)
+	}
+
+	components := slices.DeleteFunc(slices.Clone(pipeline.PausedComponents), func(c string) bool { return c == component })
+	if paused {
+		components = append(components, component)
+	}
+
+	err = p.db.SetPausedComponents(ctx, pid, components)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to update paused components", "pipeline_id", pid, "component", component, "error", err)
+		return fmt.Errorf("update paused components: %w", err)
+	}
+
+	p.log.InfoContext(ctx, "pipeline component paused state changed", "pipeline_id", pid, "component", component, "paused", paused)
+	return nil
+}
+
 // GetPipelineHealth implements PipelineService.
 func (p *PipelineService) GetPipelineHealth(ctx context.Context, pid string) (models.PipelineHealth, error) {
 	pipeline, err := p.db.GetPipeline(ctx, pid)
@@ -408,7 +1164,105 @@ func (p *PipelineService) GetPipelineHealth(ctx context.Context, pid string) (mo
 		return models.PipelineHealth{}, fmt.Errorf("get pipeline health: %w", err)
 	}
 
-	return pipeline.Status, nil
+	health := pipeline.Status
+	health.PausedComponents = pipeline.PausedComponents
+	if schedule := pipeline.Metadata.Schedule; schedule != nil {
+		scheduleStatus, err := schedule.Status(time.Now())
+		if err != nil {
+			p.log.WarnContext(ctx, "failed to evaluate pipeline schedule", "pipeline_id", pid, "error", err)
+		} else {
+			health.Schedule = &scheduleStatus
+		}
+	}
+	if health.OverallStatus == internal.PipelineStatusRunning && !pipeline.SourceType.IsPulsar() && !pipeline.SourceType.IsMySQL() && len(pipeline.Ingestor.KafkaTopics) > 0 {
+		health.ConsumerLag = p.consumerLag(ctx, *pipeline)
+	}
+
+	if p.inFlightReader != nil {
+		// Like the lag, in-flight messages are best effort here
+		inFlightCtx, cancel := context.WithTimeout(ctx, inFlightTimeout)
+		defer cancel()
+
+		inFlight, err := p.inFlightReader.ConsumersInFlight(inFlightCtx, pid)
+		if err != nil {
+			p.log.WarnContext(ctx, "failed to get in-flight messages", "pipeline_id", pid, "error", err)
+		} else {
+			drained := models.InFlightDrained(inFlight)
+			health.InFlight = inFlight
+			health.Drained = &drained
+		}
+	}
+
+	health.SLA = p.slaEvaluator.Evaluate(ctx, *pipeline, health.ConsumerLag)
+	health.Storage = p.storageMonitor.Evaluate(ctx, *pipeline)
+	health.Degraded = health.Storage != nil && health.Storage.Degraded
+
+	if p.discardTracker != nil {
+		// Like the lag, the discards are best effort here
+		discards, err := p.discardTracker.Check(ctx, pid)
+		if err != nil {
+			p.log.WarnContext(ctx, "failed to get discarded messages", "pipeline_id", pid, "error", err)
+		}
+		for _, d := range discards {
+			health.DiscardedMessages += d.Messages
+		}
+		health.Discards = discards
+	}
+
+	return health, nil
+}
+
+// consumerLag returns the consumer lag of a running Kafka pipeline, reusing
+// the lag read by a recent health request so polling the health doesn't
+// create a Kafka client per request. The lag is best effort, unreachable
+// brokers don't fail the health check.
+func (p *PipelineService) consumerLag(ctx context.Context, pipeline models.PipelineConfig) []models.KafkaPartitionLag {
+	if lag, ok := p.lagCache.get(pipeline.ID); ok {
+		return lag
+	}
+
+	lagCtx, cancel := context.WithTimeout(ctx, consumerLagTimeout)
+	defer cancel()
+
+	lag, err := p.lagReader.ConsumerLag(lagCtx, pipeline.Ingestor.KafkaConnectionParams, pipeline.Ingestor.KafkaTopics)
+	if err != nil {
+		p.log.WarnContext(ctx, "failed to get kafka consumer lag", "pipeline_id", pipeline.ID, "error", err)
+		return nil
+	}
+
+	p.lagCache.set(pipeline.ID, lag)
+	return lag
+}
+
+// ensureDrained fails unless no component of the pipeline holds in-flight
+// messages. Without an in-flight reader the pipeline is assumed drained.
+func (p *PipelineService) ensureDrained(ctx context.Context, pid string) error {
+	if p.inFlightReader == nil {
+		return nil
+	}
+
+	inFlightCtx, cancel := context.WithTimeout(ctx, inFlightTimeout)
+	defer cancel()
+
+	inFlight, err := p.inFlightReader.ConsumersInFlight(inFlightCtx, pid)
+	if err != nil {
+		return fmt.Errorf("get in-flight messages: %w", err)
+	}
+
+	for _, c := range inFlight {
+		if c.Pending > 0 || c.Unacked > 0 {
+			p.log.WarnContext(ctx, "pipeline component has in-flight messages",
+				"pipeline_id", pid,
+				"component", c.Component,
+				"consumer", c.Consumer,
+				"stream", c.Stream,
+				"pending", c.Pending,
+				"unacknowledged", c.Unacked)
+			return fmt.Errorf("%w: consumer %s has %d pending and %d unacknowledged messages", ErrPipelineNotDrained, c.Consumer, c.Pending, c.Unacked)
+		}
+	}
+
+	return nil
 }
 
 // UpdatePipelineStatus implements PipelineService.
@@ -423,7 +1277,7 @@ func (p *PipelineService) UpdatePipelineStatus(ctx context.Context, pid string,
 }
 
 // ResumePipeline implements PipelineService.
-func (p *PipelineService) ResumePipeline(ctx context.Context, pid string) error {
+func (p *PipelineService) ResumePipeline(ctx context.Context, pid string, opts models.ResumeOptions) error {
 	// Get current pipeline to update status
 	pipeline, err := p.db.GetPipeline(ctx, pid)
 	if err != nil {
@@ -447,6 +1301,22 @@ func (p *PipelineService) ResumePipeline(ctx context.Context, pid string) error
 		return err
 	}
 
+	err = p.ensureDependenciesRunning(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := p.checkKafkaCluster(ctx, pipeline, opts.Force)
+	if err != nil {
+		return err
+	}
+	if recorded {
+		err = p.db.UpdatePipeline(ctx, pid, *pipeline)
+		if err != nil {
+			return fmt.Errorf("record kafka cluster id: %w", err)
+		}
+	}
+
 	// Set status to Resuming
 	pipeline.Status.OverallStatus = internal.PipelineStatusResuming
 
@@ -481,7 +1351,7 @@ func (p *PipelineService) ResumePipeline(ctx context.Context, pid string) error
 }
 
 // StopPipeline implements PipelineService.
-func (p *PipelineService) StopPipeline(ctx context.Context, pid string) error {
+func (p *PipelineService) StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error {
 	// Get current pipeline to update status
 	pipeline, err := p.db.GetPipeline(ctx, pid)
 	if err != nil {
@@ -510,7 +1380,7 @@ func (p *PipelineService) StopPipeline(ctx context.Context, pid string) error {
 	// For Docker orchestrator, mark as failed if stop fails
 	if p.orchestrator.GetType() == "local" {
 
-		err := p.orchestrator.StopPipeline(ctx, pid)
+		err := p.orchestrator.StopPipeline(ctx, pid, opts)
 		if err != nil {
 			pipeline.Status.OverallStatus = internal.PipelineStatusFailed
 			err := p.db.UpdatePipelineStatus(context.Background(), pid, pipeline.Status)
@@ -530,7 +1400,7 @@ func (p *PipelineService) StopPipeline(ctx context.Context, pid string) error {
 	}
 
 	// For k8 orchestrator, the operator controller-manager takes care of updating this status
-	err = p.orchestrator.StopPipeline(ctx, pid)
+	err = p.orchestrator.StopPipeline(ctx, pid, opts)
 	if err != nil {
 		p.log.ErrorContext(ctx, "failed to stop pipeline in orchestrator", "pipeline_id", pid, "error", err)
 		return fmt.Errorf("failed to stop k8 pipeline: %w", err)
@@ -557,10 +1427,66 @@ func (p *PipelineService) EditPipeline(ctx context.Context, pid string, newCfg *
 		return status.NewPipelineNotStoppedForEditError(models.PipelineStatus(currentPipeline.Status.OverallStatus))
 	}
 
+	// Editing replaces the stream layout, in-flight messages would be lost.
+	// Failed pipelines may never drain, editing them is how they recover.
+	if currentPipeline.Status.OverallStatus == internal.PipelineStatusStopped {
+		err = p.ensureDrained(ctx, pid)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = p.validateDependencies(ctx, pid, newCfg.Metadata.DependsOn)
+	if err != nil {
+		return err
+	}
+
+	// Edited brokers are a deliberate move to their cluster, the same brokers
+	// must still point at the recorded one
+	newCfg.Ingestor.KafkaConnectionParams.ClusterID = ""
+	if slices.Equal(newCfg.Ingestor.KafkaConnectionParams.Brokers, currentPipeline.Ingestor.KafkaConnectionParams.Brokers) {
+		newCfg.Ingestor.KafkaConnectionParams.ClusterID = currentPipeline.Ingestor.KafkaConnectionParams.ClusterID
+	}
+	_, err = p.checkKafkaCluster(ctx, newCfg, false)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkDedupDomains(ctx, pid, newCfg)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkFeatureFlags(ctx, pid, newCfg)
+	if err != nil {
+		return err
+	}
+	err = p.checkLicense(newCfg)
+	if err != nil {
+		return err
+	}
+	err = p.checkOwnership(newCfg.Metadata)
+	if err != nil {
+		return err
+	}
+
+	if len(newCfg.Join.Chain) > 0 && p.orchestrator.GetType() != "local" {
+		return fmt.Errorf("chained join sources: %w", ErrNotImplemented)
+	}
+
+	err = p.fillSinkColumnTypes(ctx, newCfg)
+	if err != nil {
+		return err
+	}
+
 	newResources, err := p.NewPipelineResources(ctx, newCfg)
 	if err != nil {
 		return fmt.Errorf("validate pipeline resources: %w", err)
 	}
+	err = p.validateStreamRetention(newCfg, newResources)
+	if err != nil {
+		return err
+	}
 	if _, err = p.db.UpsertPipelineResources(ctx, pid, newResources); err != nil {
 		return fmt.Errorf("upsert pipeline resources: %w", err)
 	}
@@ -569,8 +1495,20 @@ func (p *PipelineService) EditPipeline(ctx context.Context, pid string, newCfg *
 	// Preserve the original created_at timestamp
 	newCfg.CreatedAt = currentPipeline.CreatedAt
 
+	err = p.setConfigHash(ctx, newCfg)
+	if err != nil {
+		return err
+	}
+
 	// Update pipeline in NATS KV
-	err = p.db.UpdatePipeline(ctx, pid, *newCfg)
+	var action *models.OutboxAction
+	if p.outbox != nil {
+		edit := models.NewOutboxAction(pid, internal.OutboxActionEdit)
+		action = &edit
+		err = p.outbox.UpdatePipelineWithAction(ctx, pid, *newCfg, edit)
+	} else {
+		err = p.db.UpdatePipeline(ctx, pid, *newCfg)
+	}
 	if err != nil {
 		p.log.ErrorContext(ctx, "failed to update pipeline in database", "pipeline_id", pid, "error", err)
 		return fmt.Errorf("update pipeline in database: %w", err)
@@ -580,44 +1518,61 @@ func (p *PipelineService) EditPipeline(ctx context.Context, pid string, newCfg *
 	err = p.orchestrator.EditPipeline(ctx, pid, newCfg)
 	if err != nil {
 		p.log.ErrorContext(ctx, "failed to edit pipeline in orchestrator", "pipeline_id", pid, "error", err)
+		p.failAction(ctx, action, err)
 		return fmt.Errorf("edit pipeline: %w", err)
 	}
+	p.completeAction(ctx, action)
+
+	// in case of k8 orchestrator the operator controller-manager takes care of updating this status
+	if p.orchestrator.GetType() == "local" {
+		currentPipeline.Status.OverallStatus = internal.PipelineStatusRunning
+		err = p.db.UpdatePipelineStatus(ctx, pid, currentPipeline.Status)
+		if err != nil {
+			p.log.ErrorContext(ctx, "failed to update pipeline status to running", "pipeline_id", pid, "error", err)
+			return fmt.Errorf("update pipeline status: %w", err)
+		}
+	}
 
 	p.log.InfoContext(ctx, "pipeline edit initiated successfully", "pipeline_id", pid)
 	return nil
 }
 
-// GetOrchestratorType implements PipelineService.
-func (p *PipelineService) GetOrchestratorType() string {
-	return p.orchestrator.GetType()
+// setConfigHash stamps the config with its hash, which the components
+// report back at startup.
+func (p *PipelineService) setConfigHash(ctx context.Context, cfg *models.PipelineConfig) error {
+	hash, err := models.ComputeConfigHash(*cfg)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to compute config hash", "pipeline_id", cfg.ID, "error", err)
+		return fmt.Errorf("compute config hash: %w", err)
+	}
+	cfg.ConfigHash = hash
+	return nil
 }
 
-// CleanUpPipelines implements PipelineService.
-func (p *PipelineService) CleanUpPipelines(ctx context.Context) error {
-	p.log.Info("cleaning up pipelines", slog.String("orchestrator", p.GetOrchestratorType()))
-
-	if p.GetOrchestratorType() != "local" {
-		return nil
+// completeAction removes an outbox action that ran. Failing to remove it
+// only makes the reconciler run it again.
+func (p *PipelineService) completeAction(ctx context.Context, action *models.OutboxAction) {
+	if action == nil {
+		return
 	}
-
-	pipelines, err := p.db.GetPipelines(ctx)
+	err := p.outbox.CompleteOutboxAction(ctx, action.ID)
 	if err != nil {
-		p.log.ErrorContext(ctx, "failed to load pipelines for cleanup", "error", err)
-		return fmt.Errorf("load pipelines: %w", err)
+		p.log.WarnContext(ctx, "failed to complete outbox action", "pipeline_id", action.PipelineID, "action", action.Action, "error", err)
 	}
+}
 
-	for _, pi := range pipelines {
-		if pi.Status.OverallStatus != internal.PipelineStatusStopped &&
-			pi.Status.OverallStatus != internal.PipelineStatusFailed {
-			p.log.Debug("cleaning pipeline...", slog.String("pipelineID", pi.ID), slog.Any("status", pi.Status.OverallStatus))
-			pi.Status.OverallStatus = internal.PipelineStatusStopped
-			err := p.db.UpdatePipelineStatus(ctx, pi.ID, pi.Status)
-			if err != nil {
-				p.log.ErrorContext(ctx, "failed to update pipeline status during cleanup", "pipeline_id", pi.ID, "error", err)
-				return fmt.Errorf("update pipeline with %s failed: %w", pi.ID, err)
-			}
-		}
+// failAction records why an outbox action failed, the reconciler retries it.
+func (p *PipelineService) failAction(ctx context.Context, action *models.OutboxAction, cause error) {
+	if action == nil {
+		return
 	}
+	err := p.outbox.FailOutboxAction(ctx, action.ID, cause.Error())
+	if err != nil {
+		p.log.WarnContext(ctx, "failed to record outbox action error", "pipeline_id", action.PipelineID, "action", action.Action, "error", err)
+	}
+}
 
-	return nil
+// GetOrchestratorType implements PipelineService.
+func (p *PipelineService) GetOrchestratorType() string {
+	return p.orchestrator.GetType()
 }
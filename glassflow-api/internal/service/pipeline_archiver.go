@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ArchiveStore keeps the definitions of the archived pipelines.
+type ArchiveStore interface {
+	ArchivePipeline(ctx context.Context, p models.PipelineConfig) error
+	ListArchivedPipelines(ctx context.Context) ([]models.ArchivedPipeline, error)
+	GetArchivedPipeline(ctx context.Context, id string) (models.PipelineConfig, error)
+	DeleteArchivedPipeline(ctx context.Context, id string) error
+}
+
+// PipelineArchiver moves the pipelines stopped for longer than the archive
+// age to the archive: their definition is stored in the archive and the
+// pipeline is deleted with its NATS streams and KV entries. A restored
+// pipeline is created again from its definition and starts running.
+// Pipelines other pipelines depend on are not archived.
+type PipelineArchiver struct {
+	pipelines    *PipelineService
+	store        ArchiveStore
+	log          *slog.Logger
+	interval     time.Duration
+	archiveAfter time.Duration
+	lease        *JobLease
+	now          func() time.Time
+}
+
+func NewPipelineArchiver(pipelines *PipelineService, store ArchiveStore, log *slog.Logger, interval, archiveAfter time.Duration) *PipelineArchiver {
+	return &PipelineArchiver{
+		pipelines:    pipelines,
+		store:        store,
+		log:          log,
+		interval:     interval,
+		archiveAfter: archiveAfter,
+		now:          time.Now,
+	}
+}
+
+// UseLease archives the pipelines only on the API replica holding the lease.
+func (a *PipelineArchiver) UseLease(lease *JobLease) {
+	a.lease = lease
+}
+
+func (a *PipelineArchiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(ctx)
+		}
+	}
+}
+
+func (a *PipelineArchiver) check(ctx context.Context) {
+	if !a.lease.Held(ctx) {
+		return
+	}
+
+	pipelines, err := a.pipelines.db.GetPipelines(ctx)
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to list pipelines for archival", "error", err)
+		return
+	}
+
+	cutoff := a.now().Add(-a.archiveAfter)
+	for _, pipeline := range pipelines {
+		if pipeline.Status.OverallStatus != internal.PipelineStatusStopped || pipeline.Status.UpdatedAt.After(cutoff) {
+			continue
+		}
+		if len(dependentsOf(pipeline.ID, pipelines)) > 0 {
+			continue
+		}
+
+		err = a.archive(ctx, pipeline)
+		if err != nil {
+			a.log.ErrorContext(ctx, "failed to archive pipeline", "pipeline_id", pipeline.ID, "error", err)
+			continue
+		}
+		a.log.InfoContext(ctx, "archived stopped pipeline",
+			"pipeline_id", pipeline.ID,
+			"stopped_at", pipeline.Status.UpdatedAt)
+	}
+}
+
+// Archive archives a stopped pipeline without waiting for the archive age.
+func (a *PipelineArchiver) Archive(ctx context.Context, pid string) error {
+	pipeline, err := a.pipelines.db.GetPipeline(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("get pipeline: %w", err)
+	}
+	if pipeline.Status.OverallStatus != internal.PipelineStatusStopped {
+		return fmt.Errorf("%w: current status %s", ErrPipelineNotStopped, pipeline.Status.OverallStatus)
+	}
+
+	pipelines, err := a.pipelines.db.GetPipelines(ctx)
+	if err != nil {
+		return fmt.Errorf("list pipelines: %w", err)
+	}
+	if dependents := dependentsOf(pid, pipelines); len(dependents) > 0 {
+		return fmt.Errorf("%w: %v", ErrPipelineHasDependents, dependents)
+	}
+
+	return a.archive(ctx, *pipeline)
+}
+
+// archive stores the definition before deleting the pipeline, a pipeline
+// that failed to be deleted is removed from the archive again.
+func (a *PipelineArchiver) archive(ctx context.Context, pipeline models.PipelineConfig) error {
+	err := a.pipelines.ensureDrained(ctx, pipeline.ID)
+	if err != nil {
+		return err
+	}
+
+	err = a.store.ArchivePipeline(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("archive pipeline: %w", err)
+	}
+
+	err = a.pipelines.DeletePipeline(ctx, pipeline.ID)
+	if err != nil {
+		if delErr := a.store.DeleteArchivedPipeline(ctx, pipeline.ID); delErr != nil {
+			a.log.WarnContext(ctx, "failed to remove pipeline from the archive after delete failure", "pipeline_id", pipeline.ID, "error", delErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Restore creates an archived pipeline again and removes it from the
+// archive. The pipeline gets a new status and resources, like a new one.
+// A scoped caller can only restore the pipelines of its teams.
+func (a *PipelineArchiver) Restore(ctx context.Context, pid string) error {
+	pipeline, err := a.store.GetArchivedPipeline(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if principal, ok := auth.PrincipalFrom(ctx); ok && !principal.CanAccess(pipeline.Metadata.Team) {
+		return ErrPipelineForbidden
+	}
+
+	pipeline.Status = models.PipelineHealth{}
+	pipeline.ConfigHash = ""
+	err = a.pipelines.CreatePipeline(ctx, &pipeline)
+	if err != nil {
+		return err
+	}
+
+	err = a.store.DeleteArchivedPipeline(ctx, pid)
+	if err != nil && !errors.Is(err, ErrPipelineNotArchived) {
+		a.log.WarnContext(ctx, "failed to remove restored pipeline from the archive", "pipeline_id", pid, "error", err)
+	}
+	a.log.InfoContext(ctx, "restored archived pipeline", "pipeline_id", pid)
+
+	return nil
+}
+
+// List returns the archived pipelines, only those of its teams to a scoped
+// caller.
+func (a *PipelineArchiver) List(ctx context.Context) ([]models.ArchivedPipeline, error) {
+	archived, err := a.store.ListArchivedPipelines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal, ok := auth.PrincipalFrom(ctx); ok && principal.Scoped() {
+		archived = slices.DeleteFunc(archived, func(p models.ArchivedPipeline) bool {
+			return !principal.CanAccess(p.Metadata.Team)
+		})
+	}
+
+	return archived, nil
+}
+
+// dependentsOf returns the pipelines depending on pid.
+func dependentsOf(pid string, pipelines []models.PipelineConfig) []string {
+	var dependents []string
+	for _, pipeline := range pipelines {
+		if slices.Contains(pipeline.Metadata.DependsOn, pid) {
+			dependents = append(dependents, pipeline.ID)
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type mockArchiveStore struct {
+	archived map[string]models.PipelineConfig
+}
+
+func (m *mockArchiveStore) ArchivePipeline(_ context.Context, p models.PipelineConfig) error {
+	m.archived[p.ID] = p
+	return nil
+}
+
+func (m *mockArchiveStore) ListArchivedPipelines(context.Context) ([]models.ArchivedPipeline, error) {
+	var archived []models.ArchivedPipeline
+	for _, p := range m.archived {
+		archived = append(archived, models.ArchivedPipeline{PipelineID: p.ID, Name: p.Name, Metadata: p.Metadata, StoppedAt: p.Status.UpdatedAt})
+	}
+	return archived, nil
+}
+
+func (m *mockArchiveStore) GetArchivedPipeline(_ context.Context, id string) (models.PipelineConfig, error) {
+	p, ok := m.archived[id]
+	if !ok {
+		return models.PipelineConfig{}, ErrPipelineNotArchived
+	}
+	return p, nil
+}
+
+func (m *mockArchiveStore) DeleteArchivedPipeline(_ context.Context, id string) error {
+	if _, ok := m.archived[id]; !ok {
+		return ErrPipelineNotArchived
+	}
+	delete(m.archived, id)
+	return nil
+}
+
+func TestPipelineArchiver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 6, 12, 0, 0, 0, time.UTC)
+	store := newDependencyStore(map[string]models.PipelineStatus{
+		"old":     internal.PipelineStatusStopped,
+		"recent":  internal.PipelineStatusStopped,
+		"running": internal.PipelineStatusRunning,
+		"dims":    internal.PipelineStatusStopped,
+		"facts":   internal.PipelineStatusStopped,
+	}, map[string][]string{"facts": {"dims"}})
+	for id, pipeline := range store.pipelines {
+		pipeline.Status.UpdatedAt = now.Add(-30 * 24 * time.Hour)
+		if id == "recent" {
+			pipeline.Status.UpdatedAt = now.Add(-time.Hour)
+		}
+		store.pipelines[id] = pipeline
+	}
+
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	archive := &mockArchiveStore{archived: make(map[string]models.PipelineConfig)}
+	archiver := NewPipelineArchiver(svc, archive, slog.Default(), time.Hour, 7*24*time.Hour)
+	archiver.now = func() time.Time { return now }
+
+	archiver.check(ctx)
+	assert.Contains(t, archive.archived, "old")
+	assert.Contains(t, archive.archived, "facts")
+	assert.NotContains(t, store.pipelines, "old")
+	assert.NotContains(t, store.pipelines, "facts")
+	// Stopped recently, running or depended on
+	assert.Contains(t, store.pipelines, "recent")
+	assert.Contains(t, store.pipelines, "running")
+	assert.Contains(t, store.pipelines, "dims")
+
+	// The dependent was archived on the previous check
+	archiver.check(ctx)
+	assert.Contains(t, archive.archived, "dims")
+}
+
+func TestPipelineArchiver_Archive(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(map[string]models.PipelineStatus{
+		"dims":    internal.PipelineStatusStopped,
+		"facts":   internal.PipelineStatusStopped,
+		"running": internal.PipelineStatusRunning,
+	}, map[string][]string{"facts": {"dims"}})
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	archive := &mockArchiveStore{archived: make(map[string]models.PipelineConfig)}
+	archiver := NewPipelineArchiver(svc, archive, slog.Default(), time.Hour, 0)
+
+	require.ErrorIs(t, archiver.Archive(ctx, "running"), ErrPipelineNotStopped)
+	require.ErrorIs(t, archiver.Archive(ctx, "dims"), ErrPipelineHasDependents)
+	require.ErrorIs(t, archiver.Archive(ctx, "missing"), ErrPipelineNotExists)
+	assert.Empty(t, archive.archived)
+
+	require.NoError(t, archiver.Archive(ctx, "facts"))
+	assert.NotContains(t, store.pipelines, "facts")
+	assert.Contains(t, archive.archived, "facts")
+}
+
+func TestPipelineArchiver_Restore(t *testing.T) {
+	ctx := context.Background()
+	store := &mockPipelineStore{pipelines: make(map[string]models.PipelineConfig)}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	archive := &mockArchiveStore{archived: map[string]models.PipelineConfig{
+		"pipeline-1": {
+			ID:         "pipeline-1",
+			Name:       "orders",
+			ConfigHash: "stale",
+			Status:     models.PipelineHealth{OverallStatus: internal.PipelineStatusStopped},
+			Sink: models.SinkComponentConfig{
+				Type:  internal.ClickHouseSinkType,
+				Batch: models.BatchConfig{MaxBatchSize: 100},
+			},
+		},
+	}}
+	archiver := NewPipelineArchiver(svc, archive, slog.Default(), time.Hour, 0)
+
+	require.ErrorIs(t, archiver.Restore(ctx, "missing"), ErrPipelineNotArchived)
+
+	require.NoError(t, archiver.Restore(ctx, "pipeline-1"))
+	assert.Empty(t, archive.archived)
+	restored := store.pipelines["pipeline-1"]
+	assert.Equal(t, "orders", restored.Name)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusRunning), restored.Status.OverallStatus)
+
+	// A pipeline with the ID was created in the meantime
+	archive.archived["pipeline-1"] = restored
+	require.ErrorIs(t, archiver.Restore(ctx, "pipeline-1"), ErrIDExists)
+	assert.Contains(t, archive.archived, "pipeline-1")
+}
+
+func TestPipelineArchiver_TeamScope(t *testing.T) {
+	store := &mockPipelineStore{pipelines: make(map[string]models.PipelineConfig)}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	archive := &mockArchiveStore{archived: map[string]models.PipelineConfig{}}
+	for id, team := range map[string]string{"orders": "sales", "clicks": "marketing"} {
+		archive.archived[id] = models.PipelineConfig{
+			ID:       id,
+			Name:     id,
+			Metadata: models.PipelineMetadata{Team: team},
+			Sink: models.SinkComponentConfig{
+				Type:  internal.ClickHouseSinkType,
+				Batch: models.BatchConfig{MaxBatchSize: 100},
+			},
+		}
+	}
+	archiver := NewPipelineArchiver(svc, archive, slog.Default(), time.Hour, 0)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Role: auth.RoleOperator, Teams: []string{"sales"}})
+
+	archived, err := archiver.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, "orders", archived[0].PipelineID)
+
+	all, err := archiver.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.ErrorIs(t, archiver.Restore(ctx, "clicks"), ErrPipelineForbidden)
+	assert.Contains(t, archive.archived, "clicks")
+
+	require.NoError(t, archiver.Restore(ctx, "orders"))
+	assert.NotContains(t, archive.archived, "orders")
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// validateDependencies checks that the pipelines pid depends on exist and
+// that depending on them does not form a cycle.
+func (p *PipelineService) validateDependencies(ctx context.Context, pid string, dependsOn []string) error {
+	if len(dependsOn) == 0 {
+		return nil
+	}
+
+	pipelines, err := p.db.GetPipelines(ctx)
+	if err != nil {
+		return fmt.Errorf("load pipelines: %w", err)
+	}
+
+	deps := make(map[string][]string, len(pipelines)+1)
+	for _, pipeline := range pipelines {
+		deps[pipeline.ID] = pipeline.Metadata.DependsOn
+	}
+
+	for _, dep := range dependsOn {
+		if dep == pid {
+			return fmt.Errorf("%w: pipeline %s cannot depend on itself", ErrInvalidDependencies, pid)
+		}
+		if _, ok := deps[dep]; !ok {
+			return fmt.Errorf("%w: pipeline %s does not exist", ErrInvalidDependencies, dep)
+		}
+	}
+
+	deps[pid] = dependsOn
+	_, err = models.StartGroups(deps)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDependencies, err)
+	}
+
+	return nil
+}
+
+// ensureDependenciesRunning fails unless every pipeline the pipeline depends
+// on is running.
+func (p *PipelineService) ensureDependenciesRunning(ctx context.Context, pipeline *models.PipelineConfig) error {
+	for _, dep := range pipeline.Metadata.DependsOn {
+		dependency, err := p.db.GetPipeline(ctx, dep)
+		if err != nil {
+			if errors.Is(err, ErrPipelineNotExists) {
+				return fmt.Errorf("%w: pipeline %s does not exist", ErrInvalidDependencies, dep)
+			}
+			return fmt.Errorf("get dependency %s: %w", dep, err)
+		}
+
+		if dependency.Status.OverallStatus != internal.PipelineStatusRunning {
+			p.log.WarnContext(ctx, "pipeline dependency is not running",
+				"pipeline_id", pipeline.ID,
+				"dependency_id", dep,
+				"dependency_status", dependency.Status.OverallStatus)
+			return fmt.Errorf("%w: pipeline %s is %s", ErrDependencyNotRunning, dep, dependency.Status.OverallStatus)
+		}
+	}
+
+	return nil
+}
+
+// bulkGroups loads the pipelines of a bulk operation and orders them in
+// start groups by their dependencies between each other.
+func (p *PipelineService) bulkGroups(ctx context.Context, ids []string) ([][]string, map[string]models.PipelineConfig, error) {
+	pipelines, err := p.db.GetPipelines(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load pipelines: %w", err)
+	}
+
+	byID := make(map[string]models.PipelineConfig, len(pipelines))
+	for _, pipeline := range pipelines {
+		byID[pipeline.ID] = pipeline
+	}
+
+	selected := make(map[string]models.PipelineConfig, len(ids))
+	deps := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		pipeline, ok := byID[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrPipelineNotExists, id)
+		}
+		selected[id] = pipeline
+		deps[id] = pipeline.Metadata.DependsOn
+	}
+
+	groups, err := models.StartGroups(deps)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidDependencies, err)
+	}
+
+	return groups, selected, nil
+}
+
+// ResumePipelines implements PipelineService. The pipelines are resumed in
+// start groups, a group once the pipelines of the previous groups are
+// running. Pipelines whose dependencies failed to start are skipped.
+func (p *PipelineService) ResumePipelines(ctx context.Context, ids []string) ([]models.BulkPipelineResult, error) {
+	groups, pipelines, err := p.bulkGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BulkPipelineResult, 0, len(pipelines))
+	resultIdx := make(map[string]int, len(pipelines))
+	failed := make(map[string]struct{})
+
+	for g, group := range groups {
+		var resumed []string
+		for _, id := range group {
+			result := models.BulkPipelineResult{PipelineID: id, Group: g, Status: models.BulkPipelineDone}
+
+			pipeline := pipelines[id]
+			if dep, ok := firstFailed(pipeline.Metadata.DependsOn, failed); ok {
+				result.Status = models.BulkPipelineSkipped
+				result.Error = fmt.Sprintf("dependency %s did not start", dep)
+			} else if pipeline.Status.OverallStatus != internal.PipelineStatusRunning {
+				err = p.ResumePipeline(ctx, id, models.ResumeOptions{})
+				if err != nil {
+					result.Status = models.BulkPipelineFailed
+					result.Error = err.Error()
+				} else {
+					resumed = append(resumed, id)
+				}
+			}
+
+			if result.Status != models.BulkPipelineDone {
+				failed[id] = struct{}{}
+			}
+			resultIdx[id] = len(results)
+			results = append(results, result)
+		}
+
+		if g == len(groups)-1 {
+			break
+		}
+
+		for id, err := range p.waitRunning(ctx, resumed) {
+			failed[id] = struct{}{}
+			results[resultIdx[id]].Status = models.BulkPipelineFailed
+			results[resultIdx[id]].Error = err.Error()
+		}
+	}
+
+	p.log.InfoContext(ctx, "bulk resume finished", "pipelines", len(results), "groups", len(groups), "failed", len(failed))
+	return results, nil
+}
+
+// StopPipelines implements PipelineService. The pipelines are stopped in the
+// reverse order of their start groups, so a pipeline is stopped after the
+// pipelines depending on it. Pipelines whose dependents failed to stop are
+// skipped.
+func (p *PipelineService) StopPipelines(ctx context.Context, ids []string) ([]models.BulkPipelineResult, error) {
+	groups, pipelines, err := p.bulkGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := make(map[string][]string, len(pipelines))
+	for id, pipeline := range pipelines {
+		for _, dep := range pipeline.Metadata.DependsOn {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	results := make([]models.BulkPipelineResult, 0, len(pipelines))
+	failed := make(map[string]struct{})
+
+	for g := len(groups) - 1; g >= 0; g-- {
+		for _, id := range groups[g] {
+			result := models.BulkPipelineResult{PipelineID: id, Group: g, Status: models.BulkPipelineDone}
+
+			pipeline := pipelines[id]
+			if dependent, ok := firstFailed(dependents[id], failed); ok {
+				result.Status = models.BulkPipelineSkipped
+				result.Error = fmt.Sprintf("dependent %s did not stop", dependent)
+			} else if pipeline.Status.OverallStatus != internal.PipelineStatusStopped {
+				err = p.StopPipeline(ctx, id, models.StopOptions{})
+				if err != nil {
+					result.Status = models.BulkPipelineFailed
+					result.Error = err.Error()
+				}
+			}
+
+			if result.Status != models.BulkPipelineDone {
+				failed[id] = struct{}{}
+			}
+			results = append(results, result)
+		}
+	}
+
+	p.log.InfoContext(ctx, "bulk stop finished", "pipelines", len(results), "groups", len(groups), "failed", len(failed))
+	return results, nil
+}
+
+// waitRunning waits until the pipelines are running and returns the ones
+// that failed or did not start in time.
+func (p *PipelineService) waitRunning(ctx context.Context, ids []string) map[string]error {
+	notRunning := make(map[string]error)
+	if len(ids) == 0 {
+		return notRunning
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, internal.PipelineDependencyStartTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(internal.PipelineDependencyPollInterval)
+	defer ticker.Stop()
+
+	pending := slices.Clone(ids)
+	for {
+		pending = slices.DeleteFunc(pending, func(id string) bool {
+			pipeline, err := p.db.GetPipeline(ctx, id)
+			if err != nil {
+				p.log.WarnContext(ctx, "failed to get pipeline status", "pipeline_id", id, "error", err)
+				return false
+			}
+
+			switch pipeline.Status.OverallStatus {
+			case internal.PipelineStatusRunning:
+				return true
+			case internal.PipelineStatusFailed:
+				notRunning[id] = errors.New("pipeline failed to start")
+				return true
+			default:
+				return false
+			}
+		})
+		if len(pending) == 0 {
+			return notRunning
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, id := range pending {
+				notRunning[id] = fmt.Errorf("pipeline did not start: %w", ctx.Err())
+			}
+			return notRunning
+		case <-ticker.C:
+		}
+	}
+}
+
+// firstFailed returns the first of ids that is in failed.
+func firstFailed(ids []string, failed map[string]struct{}) (string, bool) {
+	for _, id := range ids {
+		if _, ok := failed[id]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
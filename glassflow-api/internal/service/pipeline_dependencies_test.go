@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func newDependencyStore(statuses map[string]models.PipelineStatus, deps map[string][]string) *mockPipelineStore {
+	store := &mockPipelineStore{pipelines: make(map[string]models.PipelineConfig)}
+	for id, s := range statuses {
+		store.pipelines[id] = models.PipelineConfig{
+			ID:       id,
+			Status:   models.PipelineHealth{PipelineID: id, OverallStatus: s},
+			Metadata: models.PipelineMetadata{DependsOn: deps[id]},
+		}
+	}
+	return store
+}
+
+func TestPipelineService_ResumePipeline_DependencyNotRunning(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(
+		map[string]models.PipelineStatus{"dims": internal.PipelineStatusStopped, "facts": internal.PipelineStatusStopped},
+		map[string][]string{"facts": {"dims"}},
+	)
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+	err := svc.ResumePipeline(ctx, "facts", models.ResumeOptions{})
+	require.ErrorIs(t, err, ErrDependencyNotRunning)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusStopped), store.pipelines["facts"].Status.OverallStatus)
+
+	require.NoError(t, svc.ResumePipeline(ctx, "dims", models.ResumeOptions{}))
+	require.NoError(t, svc.ResumePipeline(ctx, "facts", models.ResumeOptions{}))
+}
+
+func TestPipelineService_ValidateDependencies(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(
+		map[string]models.PipelineStatus{"dims": internal.PipelineStatusRunning, "facts": internal.PipelineStatusRunning},
+		map[string][]string{"facts": {"dims"}},
+	)
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+	require.NoError(t, svc.validateDependencies(ctx, "report", []string{"facts"}))
+	require.ErrorIs(t, svc.validateDependencies(ctx, "dims", []string{"facts"}), ErrInvalidDependencies, "cycle")
+	require.ErrorIs(t, svc.validateDependencies(ctx, "dims", []string{"dims"}), ErrInvalidDependencies, "self dependency")
+	require.ErrorIs(t, svc.validateDependencies(ctx, "report", []string{"missing"}), ErrInvalidDependencies, "missing dependency")
+}
+
+func TestPipelineService_ResumePipelines(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(
+		map[string]models.PipelineStatus{
+			"dims":    internal.PipelineStatusStopped,
+			"users":   internal.PipelineStatusRunning,
+			"facts":   internal.PipelineStatusStopped,
+			"reports": internal.PipelineStatusStopped,
+		},
+		map[string][]string{"facts": {"dims", "users"}, "reports": {"facts"}},
+	)
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+	results, err := svc.ResumePipelines(ctx, []string{"reports", "facts", "dims", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.BulkPipelineResult{
+		{PipelineID: "dims", Group: 0, Status: models.BulkPipelineDone},
+		{PipelineID: "users", Group: 0, Status: models.BulkPipelineDone},
+		{PipelineID: "facts", Group: 1, Status: models.BulkPipelineDone},
+		{PipelineID: "reports", Group: 2, Status: models.BulkPipelineDone},
+	}, results)
+	for id, pipeline := range store.pipelines {
+		assert.Equal(t, models.PipelineStatus(internal.PipelineStatusRunning), pipeline.Status.OverallStatus, id)
+	}
+
+	_, err = svc.ResumePipelines(ctx, []string{"facts", "missing"})
+	require.ErrorIs(t, err, ErrPipelineNotExists)
+}
+
+func TestPipelineService_ResumePipelines_SkipsDependentsOfFailed(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(
+		map[string]models.PipelineStatus{"dims": internal.PipelineStatusStopped, "facts": internal.PipelineStatusStopped},
+		map[string][]string{"facts": {"dims"}},
+	)
+	orch := &mockOrchestrator{orchestratorType: "local", resumeError: errors.New("resume failed")}
+	svc := NewPipelineService(orch, store, slog.Default())
+
+	results, err := svc.ResumePipelines(ctx, []string{"dims", "facts"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, models.BulkPipelineFailed, results[0].Status)
+	assert.Equal(t, models.BulkPipelineSkipped, results[1].Status)
+	assert.Equal(t, "dims", orch.resumePipelineID, "the dependent is not resumed")
+}
+
+func TestPipelineService_StopPipelines(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(
+		map[string]models.PipelineStatus{"dims": internal.PipelineStatusRunning, "facts": internal.PipelineStatusRunning},
+		map[string][]string{"facts": {"dims"}},
+	)
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+	results, err := svc.StopPipelines(ctx, []string{"dims", "facts"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.BulkPipelineResult{
+		{PipelineID: "facts", Group: 1, Status: models.BulkPipelineDone},
+		{PipelineID: "dims", Group: 0, Status: models.BulkPipelineDone},
+	}, results)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusStopped), store.pipelines["dims"].Status.OverallStatus)
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/featureflags"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/license"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
 )
@@ -29,8 +31,8 @@ func (m *MockOrchestrator) SetupPipeline(ctx context.Context, cfg *models.Pipeli
 	return args.Error(0)
 }
 
-func (m *MockOrchestrator) StopPipeline(ctx context.Context, pid string) error {
-	args := m.Called(ctx, pid)
+func (m *MockOrchestrator) StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error {
+	args := m.Called(ctx, pid, opts)
 	return args.Error(0)
 }
 
@@ -64,6 +66,16 @@ func (m *MockPipelineStore) PatchPipelineMetadata(ctx context.Context, pid strin
 	panic("implement me")
 }
 
+func (m *MockPipelineStore) ConfirmSinkStaging(ctx context.Context, pid string) error {
+	args := m.Called(ctx, pid)
+	return args.Error(0)
+}
+
+func (m *MockPipelineStore) SetPausedComponents(ctx context.Context, pid string, components []string) error {
+	args := m.Called(ctx, pid, components)
+	return args.Error(0)
+}
+
 func (m *MockPipelineStore) InsertPipeline(ctx context.Context, pi models.PipelineConfig) error {
 	args := m.Called(ctx, pi)
 	return args.Error(0)
@@ -95,6 +107,11 @@ func (m *MockPipelineStore) GetPipelines(ctx context.Context) ([]models.Pipeline
 	return args.Get(0).([]models.PipelineConfig), args.Error(1)
 }
 
+func (m *MockPipelineStore) GetPipelinesByTags(ctx context.Context, tags []string) ([]models.PipelineConfig, error) {
+	args := m.Called(ctx, tags)
+	return args.Get(0).([]models.PipelineConfig), args.Error(1)
+}
+
 func (m *MockPipelineStore) PatchPipelineName(ctx context.Context, pid string, name string) error {
 	args := m.Called(ctx, pid, name)
 	return args.Error(0)
@@ -152,6 +169,16 @@ func (m *MockPipelineStore) GetSinkConfig(ctx context.Context, pipelineID, sourc
 	panic("implement me")
 }
 
+// configHash returns the hash EditPipeline stores with the config.
+func configHash(t *testing.T, cfg models.PipelineConfig) string {
+	t.Helper()
+	hash, err := models.ComputeConfigHash(cfg)
+	if err != nil {
+		t.Fatalf("compute config hash: %v", err)
+	}
+	return hash
+}
+
 func TestEditPipeline_Success(t *testing.T) {
 	// Setup
 	mockOrchestrator := new(MockOrchestrator)
@@ -181,16 +208,18 @@ func TestEditPipeline_Success(t *testing.T) {
 		},
 	}
 
-	// expectedConfig is what UpdatePipeline will receive: newConfig with defaults applied and CreatedAt preserved.
+	// expectedConfig is what UpdatePipeline will receive: newConfig with defaults applied, CreatedAt preserved and its config hash.
 	expectedConfig := *newConfig
 	expectedConfig.PipelineResources = models.NewDefaultPipelineResources(newConfig)
 	expectedConfig.CreatedAt = currentPipeline.CreatedAt
+	expectedConfig.ConfigHash = configHash(t, expectedConfig)
 
 	// Setup mock expectations
 	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(currentPipeline, nil)
 	mockStore.On("UpsertPipelineResources", mock.Anything, pipelineID, mock.Anything).Return(&models.PipelineResourcesRow{PipelineID: pipelineID}, nil)
 	mockStore.On("UpdatePipeline", mock.Anything, pipelineID, expectedConfig).Return(nil)
 	mockOrchestrator.On("EditPipeline", mock.Anything, pipelineID, newConfig).Return(nil)
+	mockOrchestrator.On("GetType").Return("k8s")
 
 	// Execute
 	err := pipelineService.EditPipeline(context.Background(), pipelineID, newConfig)
@@ -201,6 +230,41 @@ func TestEditPipeline_Success(t *testing.T) {
 	mockOrchestrator.AssertExpectations(t)
 }
 
+func TestEditPipeline_LocalSetsRunning(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+
+	pipelineService := &PipelineService{
+		orchestrator: mockOrchestrator,
+		db:           mockStore,
+		log:          slog.Default(),
+	}
+
+	pipelineID := "test-pipeline-123"
+	currentPipeline := &models.PipelineConfig{
+		ID: pipelineID,
+		Status: models.PipelineHealth{
+			OverallStatus: internal.PipelineStatusStopped,
+		},
+	}
+	newConfig := &models.PipelineConfig{ID: pipelineID}
+
+	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(currentPipeline, nil)
+	mockStore.On("UpsertPipelineResources", mock.Anything, pipelineID, mock.Anything).Return(&models.PipelineResourcesRow{PipelineID: pipelineID}, nil)
+	mockStore.On("UpdatePipeline", mock.Anything, pipelineID, mock.Anything).Return(nil)
+	mockStore.On("UpdatePipelineStatus", mock.Anything, pipelineID, mock.MatchedBy(func(status models.PipelineHealth) bool {
+		return status.OverallStatus == internal.PipelineStatusRunning
+	})).Return(nil)
+	mockOrchestrator.On("EditPipeline", mock.Anything, pipelineID, newConfig).Return(nil)
+	mockOrchestrator.On("GetType").Return("local")
+
+	err := pipelineService.EditPipeline(context.Background(), pipelineID, newConfig)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockOrchestrator.AssertExpectations(t)
+}
+
 func TestEditPipeline_PipelineNotExists(t *testing.T) {
 	// Setup
 	mockOrchestrator := new(MockOrchestrator)
@@ -278,6 +342,48 @@ func TestEditPipeline_PipelineNotStopped(t *testing.T) {
 	mockOrchestrator.AssertNotCalled(t, "EditPipeline")
 }
 
+func TestEditPipeline_PipelineNotDrained(t *testing.T) {
+	// Setup
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	logger := slog.Default()
+
+	pipelineID := "test-pipeline-123"
+	pipelineService := &PipelineService{
+		orchestrator: mockOrchestrator,
+		db:           mockStore,
+		inFlightReader: &mockInFlightReader{inFlight: []models.ComponentInFlight{
+			{Component: "sink", Consumer: models.GetNATSSinkConsumerName(pipelineID), Pending: 2, Unacked: 1},
+		}},
+		log: logger,
+	}
+
+	currentPipeline := &models.PipelineConfig{
+		ID:   pipelineID,
+		Name: "Current Pipeline",
+		Status: models.PipelineHealth{
+			OverallStatus: internal.PipelineStatusStopped,
+		},
+	}
+
+	newConfig := &models.PipelineConfig{
+		ID:   pipelineID,
+		Name: "Updated Pipeline",
+	}
+
+	// Setup mock expectations
+	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(currentPipeline, nil)
+
+	// Execute
+	err := pipelineService.EditPipeline(context.Background(), pipelineID, newConfig)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrPipelineNotDrained)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdatePipeline")
+	mockOrchestrator.AssertNotCalled(t, "EditPipeline")
+}
+
 func TestEditPipeline_UpdatePipelineFails(t *testing.T) {
 	// Setup
 	mockOrchestrator := new(MockOrchestrator)
@@ -307,6 +413,7 @@ func TestEditPipeline_UpdatePipelineFails(t *testing.T) {
 	expectedConfig := *newConfig
 	expectedConfig.PipelineResources = models.NewDefaultPipelineResources(newConfig)
 	expectedConfig.CreatedAt = currentPipeline.CreatedAt
+	expectedConfig.ConfigHash = configHash(t, expectedConfig)
 
 	// Setup mock expectations
 	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(currentPipeline, nil)
@@ -352,6 +459,7 @@ func TestEditPipeline_OrchestratorFails(t *testing.T) {
 	expectedConfig := *newConfig
 	expectedConfig.PipelineResources = models.NewDefaultPipelineResources(newConfig)
 	expectedConfig.CreatedAt = currentPipeline.CreatedAt
+	expectedConfig.ConfigHash = configHash(t, expectedConfig)
 
 	// Setup mock expectations
 	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(currentPipeline, nil)
@@ -368,3 +476,63 @@ func TestEditPipeline_OrchestratorFails(t *testing.T) {
 	mockStore.AssertExpectations(t)
 	mockOrchestrator.AssertExpectations(t)
 }
+
+type pipelineFeatureFlags map[string]bool
+
+func (f pipelineFeatureFlags) GetFeatureFlags(_ context.Context, pipelineID string) (map[string]bool, error) {
+	if pipelineID == "" {
+		return nil, nil
+	}
+	return f, nil
+}
+
+func (f pipelineFeatureFlags) SetFeatureFlag(_ context.Context, _, _ string, _ bool) error {
+	return nil
+}
+
+func (f pipelineFeatureFlags) DeleteFeatureFlag(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func TestEditPipeline_FeatureDisabled(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	flags := featureflags.New(nil, pipelineFeatureFlags{featureflags.Enrichment: false})
+	pipelineService := NewPipelineService(mockOrchestrator, mockStore, slog.Default(), WithFeatureFlags(flags))
+
+	pipelineID := "test-pipeline-123"
+	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(&models.PipelineConfig{
+		ID:     pipelineID,
+		Status: models.PipelineHealth{OverallStatus: internal.PipelineStatusStopped},
+	}, nil)
+
+	err := pipelineService.EditPipeline(context.Background(), pipelineID, &models.PipelineConfig{
+		ID:         pipelineID,
+		Enrichment: models.EnrichmentConfig{Enabled: true},
+	})
+
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	assert.Contains(t, err.Error(), featureflags.Enrichment)
+	mockOrchestrator.AssertNotCalled(t, "EditPipeline", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEditPipeline_FeatureNotLicensed(t *testing.T) {
+	mockOrchestrator := new(MockOrchestrator)
+	mockStore := new(MockPipelineStore)
+	pipelineService := NewPipelineService(mockOrchestrator, mockStore, slog.Default(), WithLicense(license.OpenSource()))
+
+	pipelineID := "test-pipeline-123"
+	mockStore.On("GetPipeline", mock.Anything, pipelineID).Return(&models.PipelineConfig{
+		ID:     pipelineID,
+		Status: models.PipelineHealth{OverallStatus: internal.PipelineStatusStopped},
+	}, nil)
+
+	err := pipelineService.EditPipeline(context.Background(), pipelineID, &models.PipelineConfig{
+		ID:         pipelineID,
+		SourceType: internal.PulsarIngestorType,
+	})
+
+	assert.ErrorIs(t, err, ErrFeatureNotLicensed)
+	assert.Contains(t, err.Error(), license.PulsarSource)
+	mockOrchestrator.AssertNotCalled(t, "EditPipeline", mock.Anything, mock.Anything, mock.Anything)
+}
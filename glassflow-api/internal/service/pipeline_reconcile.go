@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// PipelineStateReporter is implemented by orchestrators that can list the
+// pipelines they run, compared with the stored pipelines on API start.
+type PipelineStateReporter interface {
+	PipelineStates(ctx context.Context) (map[string]models.PipelineStatus, error)
+	MarkPipelineOrphaned(ctx context.Context, pid string) error
+}
+
+// ReconcilePipelines implements PipelineService. It compares the stored
+// pipelines with those the orchestrator runs and fixes the drift: pipelines
+// that should run but have no components are set up again, stopping ones
+// without components are marked Stopped, settled statuses reported by the
+// orchestrator are stored and components without a stored pipeline are marked
+// orphaned. A pipeline failing to reconcile does not stop the others.
+func (p *PipelineService) ReconcilePipelines(ctx context.Context) (models.ReconciliationSummary, error) {
+	var summary models.ReconciliationSummary
+
+	reporter, ok := p.orchestrator.(PipelineStateReporter)
+	if !ok {
+		p.log.InfoContext(ctx, "orchestrator does not report its pipelines, skipping reconciliation", slog.String("orchestrator", p.GetOrchestratorType()))
+		return summary, nil
+	}
+
+	pipelines, err := p.db.GetPipelines(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("load pipelines: %w", err)
+	}
+
+	actual, err := reporter.PipelineStates(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("get orchestrator pipelines: %w", err)
+	}
+
+	stored := make(map[string]struct{}, len(pipelines))
+	for i := range pipelines {
+		stored[pipelines[i].ID] = struct{}{}
+		summary.Checked++
+		p.reconcilePipeline(ctx, &pipelines[i], actual, &summary)
+	}
+
+	for _, pid := range slices.Sorted(maps.Keys(actual)) {
+		if _, ok := stored[pid]; ok {
+			continue
+		}
+
+		p.log.WarnContext(ctx, "orchestrator runs a pipeline that is not stored", "pipeline_id", pid)
+		err := reporter.MarkPipelineOrphaned(ctx, pid)
+		if err != nil {
+			p.log.ErrorContext(ctx, "failed to mark pipeline orphaned", "pipeline_id", pid, "error", err)
+			summary.Failed = append(summary.Failed, pid)
+			continue
+		}
+		summary.Orphaned = append(summary.Orphaned, pid)
+	}
+
+	return summary, nil
+}
+
+func (p *PipelineService) reconcilePipeline(
+	ctx context.Context,
+	pi *models.PipelineConfig,
+	actual map[string]models.PipelineStatus,
+	summary *models.ReconciliationSummary,
+) {
+	state, running := actual[pi.ID]
+
+	switch {
+	case running:
+		if state == pi.Status.OverallStatus || !isSettledStatus(state) {
+			return
+		}
+
+		p.log.InfoContext(ctx, "syncing pipeline status with orchestrator",
+			"pipeline_id", pi.ID,
+			"stored_status", pi.Status.OverallStatus,
+			"orchestrator_status", state)
+		err := p.setReconciledStatus(ctx, pi, state)
+		if err != nil {
+			summary.Failed = append(summary.Failed, pi.ID)
+			return
+		}
+		summary.Synced = append(summary.Synced, pi.ID)
+
+	case pi.Status.OverallStatus == internal.PipelineStatusCreated ||
+		pi.Status.OverallStatus == internal.PipelineStatusRunning ||
+		pi.Status.OverallStatus == internal.PipelineStatusResuming:
+		p.log.InfoContext(ctx, "restarting pipeline without components", "pipeline_id", pi.ID, "status", pi.Status.OverallStatus)
+
+		err := p.orchestrator.SetupPipeline(ctx, pi)
+		switch {
+		case errors.Is(err, ErrPipelineQuotaReached):
+			// Only one local pipeline runs at a time
+			if p.setReconciledStatus(ctx, pi, internal.PipelineStatusStopped) != nil {
+				summary.Failed = append(summary.Failed, pi.ID)
+				return
+			}
+			summary.Stopped = append(summary.Stopped, pi.ID)
+		case err != nil:
+			p.log.ErrorContext(ctx, "failed to restart pipeline", "pipeline_id", pi.ID, "error", err)
+			//nolint: errcheck // the pipeline is reported failed either way
+			p.setReconciledStatus(ctx, pi, internal.PipelineStatusFailed)
+			summary.Failed = append(summary.Failed, pi.ID)
+		default:
+			restarted := models.PipelineStatus(internal.PipelineStatusCreated)
+			if p.orchestrator.GetType() == "local" {
+				restarted = internal.PipelineStatusRunning
+			}
+			if p.setReconciledStatus(ctx, pi, restarted) != nil {
+				summary.Failed = append(summary.Failed, pi.ID)
+				return
+			}
+			summary.Restarted = append(summary.Restarted, pi.ID)
+		}
+
+	case pi.Status.OverallStatus == internal.PipelineStatusStopping ||
+		pi.Status.OverallStatus == internal.PipelineStatusTerminating:
+		p.log.InfoContext(ctx, "marking pipeline without components stopped", "pipeline_id", pi.ID, "status", pi.Status.OverallStatus)
+		if p.setReconciledStatus(ctx, pi, internal.PipelineStatusStopped) != nil {
+			summary.Failed = append(summary.Failed, pi.ID)
+			return
+		}
+		summary.Stopped = append(summary.Stopped, pi.ID)
+	}
+}
+
+func (p *PipelineService) setReconciledStatus(ctx context.Context, pi *models.PipelineConfig, status models.PipelineStatus) error {
+	pi.Status.OverallStatus = status
+	err := p.db.UpdatePipelineStatus(ctx, pi.ID, pi.Status)
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to update pipeline status during reconciliation", "pipeline_id", pi.ID, "error", err)
+		return fmt.Errorf("update pipeline status: %w", err)
+	}
+	return nil
+}
+
+// isSettledStatus reports whether an orchestrator status is final rather
+// than a transition the orchestrator still stores itself.
+func isSettledStatus(status models.PipelineStatus) bool {
+	return status == internal.PipelineStatusRunning ||
+		status == internal.PipelineStatusStopped ||
+		status == internal.PipelineStatusFailed
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type mockStateReporter struct {
+	*MockOrchestrator
+	states   map[string]models.PipelineStatus
+	orphaned []string
+}
+
+func (m *mockStateReporter) PipelineStates(_ context.Context) (map[string]models.PipelineStatus, error) {
+	return m.states, nil
+}
+
+func (m *mockStateReporter) MarkPipelineOrphaned(_ context.Context, pid string) error {
+	m.orphaned = append(m.orphaned, pid)
+	return nil
+}
+
+func storedPipeline(id, status string) models.PipelineConfig {
+	return models.PipelineConfig{ID: id, Status: models.PipelineHealth{OverallStatus: models.PipelineStatus(status)}}
+}
+
+func hasStatus(status string) any {
+	return mock.MatchedBy(func(h models.PipelineHealth) bool {
+		return h.OverallStatus == models.PipelineStatus(status)
+	})
+}
+
+func TestReconcilePipelines(t *testing.T) {
+	mockStore := new(MockPipelineStore)
+	orch := &mockStateReporter{
+		MockOrchestrator: new(MockOrchestrator),
+		states: map[string]models.PipelineStatus{
+			"running":  internal.PipelineStatusRunning,
+			"drifted":  internal.PipelineStatusFailed,
+			"resuming": internal.PipelineStatusResuming,
+			"orphan":   internal.PipelineStatusRunning,
+		},
+	}
+	pipelineService := NewPipelineService(orch, mockStore, slog.Default())
+
+	mockStore.On("GetPipelines", mock.Anything).Return([]models.PipelineConfig{
+		storedPipeline("running", internal.PipelineStatusRunning),
+		storedPipeline("drifted", internal.PipelineStatusRunning),
+		storedPipeline("resuming", internal.PipelineStatusStopped),
+		storedPipeline("missing", internal.PipelineStatusRunning),
+		storedPipeline("stopping", internal.PipelineStatusStopping),
+		storedPipeline("stopped", internal.PipelineStatusStopped),
+	}, nil)
+	orch.On("GetType").Return("k8s")
+	orch.On("SetupPipeline", mock.Anything, mock.MatchedBy(func(cfg *models.PipelineConfig) bool {
+		return cfg.ID == "missing"
+	})).Return(nil).Once()
+	mockStore.On("UpdatePipelineStatus", mock.Anything, "drifted", hasStatus(internal.PipelineStatusFailed)).Return(nil).Once()
+	mockStore.On("UpdatePipelineStatus", mock.Anything, "missing", hasStatus(internal.PipelineStatusCreated)).Return(nil).Once()
+	mockStore.On("UpdatePipelineStatus", mock.Anything, "stopping", hasStatus(internal.PipelineStatusStopped)).Return(nil).Once()
+
+	summary, err := pipelineService.ReconcilePipelines(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ReconciliationSummary{
+		Checked:   6,
+		Restarted: []string{"missing"},
+		Stopped:   []string{"stopping"},
+		Synced:    []string{"drifted"},
+		Orphaned:  []string{"orphan"},
+	}, summary)
+	assert.Equal(t, []string{"orphan"}, orch.orphaned)
+	orch.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+func TestReconcilePipelines_LocalQuota(t *testing.T) {
+	mockStore := new(MockPipelineStore)
+	orch := &mockStateReporter{MockOrchestrator: new(MockOrchestrator)}
+	pipelineService := NewPipelineService(orch, mockStore, slog.Default())
+
+	mockStore.On("GetPipelines", mock.Anything).Return([]models.PipelineConfig{
+		storedPipeline("first", internal.PipelineStatusRunning),
+		storedPipeline("second", internal.PipelineStatusRunning),
+	}, nil)
+	orch.On("GetType").Return("local")
+	orch.On("SetupPipeline", mock.Anything, mock.Anything).Return(nil).Once()
+	orch.On("SetupPipeline", mock.Anything, mock.Anything).Return(ErrPipelineQuotaReached).Once()
+	mockStore.On("UpdatePipelineStatus", mock.Anything, "first", hasStatus(internal.PipelineStatusRunning)).Return(nil).Once()
+	mockStore.On("UpdatePipelineStatus", mock.Anything, "second", hasStatus(internal.PipelineStatusStopped)).Return(nil).Once()
+
+	summary, err := pipelineService.ReconcilePipelines(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first"}, summary.Restarted)
+	assert.Equal(t, []string{"second"}, summary.Stopped)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// PipelineScheduler starts and stops the pipelines with a schedule in their
+// metadata. Every interval it runs the last action each schedule fired since
+// the previous check: a stopped pipeline is resumed on start and a running
+// one stopped on stop. Schedules that fired while the API was down are not
+// caught up.
+type PipelineScheduler struct {
+	pipelines *PipelineService
+	log       *slog.Logger
+	interval  time.Duration
+	lease     *JobLease
+	now       func() time.Time
+
+	lastCheck time.Time
+}
+
+func NewPipelineScheduler(pipelines *PipelineService, log *slog.Logger, interval time.Duration) *PipelineScheduler {
+	return &PipelineScheduler{
+		pipelines: pipelines,
+		log:       log,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// UseLease runs the schedules only on the API replica holding the lease.
+func (s *PipelineScheduler) UseLease(lease *JobLease) {
+	s.lease = lease
+}
+
+func (s *PipelineScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.lastCheck = s.now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *PipelineScheduler) check(ctx context.Context) {
+	now := s.now()
+	from := s.lastCheck
+	s.lastCheck = now
+
+	// The replica taking over the lease doesn't catch up the schedules that
+	// fired before its previous check
+	if !s.lease.Held(ctx) {
+		return
+	}
+
+	pipelines, err := s.pipelines.db.GetPipelines(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to list pipelines for schedules", "error", err)
+		return
+	}
+
+	for _, pipeline := range pipelines {
+		schedule := pipeline.Metadata.Schedule
+		if schedule == nil {
+			continue
+		}
+
+		action, due, err := schedule.Due(from, now)
+		if err != nil {
+			s.log.WarnContext(ctx, "invalid pipeline schedule", "pipeline_id", pipeline.ID, "error", err)
+			continue
+		}
+		if !due {
+			continue
+		}
+
+		s.run(ctx, pipeline, action)
+	}
+}
+
+// run applies the action unless the pipeline is not in the state the action
+// applies to, e.g. a pipeline already stopped by hand.
+func (s *PipelineScheduler) run(ctx context.Context, pipeline models.PipelineConfig, action models.ScheduleAction) {
+	var err error
+	switch action {
+	case models.ScheduleActionStart:
+		if pipeline.Status.OverallStatus != internal.PipelineStatusStopped {
+			s.log.InfoContext(ctx, "skipping scheduled pipeline start", "pipeline_id", pipeline.ID, "status", pipeline.Status.OverallStatus)
+			return
+		}
+		err = s.pipelines.ResumePipeline(ctx, pipeline.ID, models.ResumeOptions{})
+	case models.ScheduleActionStop:
+		if pipeline.Status.OverallStatus != internal.PipelineStatusRunning {
+			s.log.InfoContext(ctx, "skipping scheduled pipeline stop", "pipeline_id", pipeline.ID, "status", pipeline.Status.OverallStatus)
+			return
+		}
+		err = s.pipelines.StopPipeline(ctx, pipeline.ID, models.StopOptions{})
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "scheduled pipeline action failed", "pipeline_id", pipeline.ID, "action", action, "error", err)
+		return
+	}
+
+	s.log.InfoContext(ctx, "ran scheduled pipeline action", "pipeline_id", pipeline.ID, "action", action)
+}
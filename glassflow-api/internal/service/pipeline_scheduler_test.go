@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestPipelineScheduler(t *testing.T) {
+	ctx := context.Background()
+	schedule := &models.ScheduleConfig{Start: "0 1 * * *", Stop: "0 5 * * *"}
+	store := newDependencyStore(map[string]models.PipelineStatus{
+		"nightly": internal.PipelineStatusStopped,
+		"failed":  internal.PipelineStatusFailed,
+		"always":  internal.PipelineStatusStopped,
+	}, nil)
+	for _, id := range []string{"nightly", "failed"} {
+		pipeline := store.pipelines[id]
+		pipeline.Metadata.Schedule = schedule
+		store.pipelines[id] = pipeline
+	}
+
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	scheduler := NewPipelineScheduler(svc, slog.Default(), time.Minute)
+	now := time.Date(2026, time.March, 6, 0, 59, 30, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	scheduler.lastCheck = now
+
+	status := func(id string) models.PipelineStatus {
+		return store.pipelines[id].Status.OverallStatus
+	}
+
+	now = now.Add(time.Minute)
+	scheduler.check(ctx)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusRunning), status("nightly"))
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusFailed), status("failed"))
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusStopped), status("always"))
+
+	// Nothing fires within the window
+	now = now.Add(time.Hour)
+	scheduler.check(ctx)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusRunning), status("nightly"))
+
+	now = time.Date(2026, time.March, 6, 5, 0, 10, 0, time.UTC)
+	scheduler.check(ctx)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusStopped), status("nightly"))
+}
+
+// mockJobLeaseStore hands the lease of a job to the first holder claiming it.
+type mockJobLeaseStore struct {
+	holders map[string]string
+}
+
+func (m *mockJobLeaseStore) ClaimJobLease(_ context.Context, job, holder string, _ time.Duration) (bool, error) {
+	if current, ok := m.holders[job]; ok {
+		return current == holder, nil
+	}
+	m.holders[job] = holder
+	return true, nil
+}
+
+func TestPipelineScheduler_Lease(t *testing.T) {
+	ctx := context.Background()
+	store := newDependencyStore(map[string]models.PipelineStatus{
+		"nightly": internal.PipelineStatusStopped,
+	}, nil)
+	pipeline := store.pipelines["nightly"]
+	pipeline.Metadata.Schedule = &models.ScheduleConfig{Start: "0 1 * * *"}
+	store.pipelines["nightly"] = pipeline
+
+	leases := &mockJobLeaseStore{holders: map[string]string{JobPipelineScheduler: "other-replica"}}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	scheduler := NewPipelineScheduler(svc, slog.Default(), time.Minute)
+	scheduler.UseLease(NewJobLease(leases, JobPipelineScheduler, "this-replica", time.Minute, slog.Default()))
+	now := time.Date(2026, time.March, 6, 0, 59, 30, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	scheduler.lastCheck = now
+
+	// Another replica holds the lease and runs the schedule
+	now = now.Add(time.Minute)
+	scheduler.check(ctx)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusStopped), store.pipelines["nightly"].Status.OverallStatus)
+
+	// Once this replica took the lease over, it runs the next start
+	leases.holders[JobPipelineScheduler] = "this-replica"
+	now = now.Add(24 * time.Hour)
+	scheduler.check(ctx)
+	assert.Equal(t, models.PipelineStatus(internal.PipelineStatusRunning), store.pipelines["nightly"].Status.OverallStatus)
+}
@@ -3,13 +3,18 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/kafka"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/status"
 )
 
 // mockOrchestrator is a mock implementation of the Orchestrator interface
@@ -37,7 +42,7 @@ func (m *mockOrchestrator) SetupPipeline(ctx context.Context, cfg *models.Pipeli
 	return nil
 }
 
-func (m *mockOrchestrator) StopPipeline(ctx context.Context, pid string) error {
+func (m *mockOrchestrator) StopPipeline(ctx context.Context, pid string, opts models.StopOptions) error {
 	return nil
 }
 
@@ -73,6 +78,36 @@ func (m *mockPipelineStore) PatchPipelineMetadata(ctx context.Context, pid strin
 	panic("implement me")
 }
 
+func (m *mockPipelineStore) ConfirmSinkStaging(ctx context.Context, pid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updateError != nil {
+		return m.updateError
+	}
+	pipeline, ok := m.pipelines[pid]
+	if !ok {
+		return ErrPipelineNotExists
+	}
+	pipeline.Sink.Staging = nil
+	m.pipelines[pid] = pipeline
+	return nil
+}
+
+func (m *mockPipelineStore) SetPausedComponents(ctx context.Context, pid string, components []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updateError != nil {
+		return m.updateError
+	}
+	pipeline, ok := m.pipelines[pid]
+	if !ok {
+		return ErrPipelineNotExists
+	}
+	pipeline.PausedComponents = components
+	m.pipelines[pid] = pipeline
+	return nil
+}
+
 func (m *mockPipelineStore) InsertPipeline(ctx context.Context, pi models.PipelineConfig) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -127,6 +162,16 @@ func (m *mockPipelineStore) GetPipelines(ctx context.Context) ([]models.Pipeline
 	return pipelines, nil
 }
 
+func (m *mockPipelineStore) GetPipelinesByTags(ctx context.Context, tags []string) ([]models.PipelineConfig, error) {
+	var pipelines []models.PipelineConfig
+	for _, pipeline := range m.pipelines {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return !slices.Contains(pipeline.Metadata.Tags, tag) }) {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return pipelines, nil
+}
+
 func (m *mockPipelineStore) PatchPipelineName(ctx context.Context, pid string, name string) error {
 	if pipeline, exists := m.pipelines[pid]; exists {
 		pipeline.Name = name
@@ -287,7 +332,7 @@ func TestPipelineService_ResumePipeline(t *testing.T) {
 			}
 
 			// Execute
-			err := manager.ResumePipeline(ctx, tt.pipelineID)
+			err := manager.ResumePipeline(ctx, tt.pipelineID, models.ResumeOptions{})
 
 			// Verify error
 			if tt.expectedError != "" {
@@ -493,3 +538,716 @@ func TestPipelineService_GetPipeline_InvalidSelection(t *testing.T) {
 		t.Fatalf("expected ErrInvalidSchemaSelection, got %v", err)
 	}
 }
+
+// mockTableCreator is a mock implementation of the TableCreator interface
+type mockTableCreator struct {
+	err     error
+	created []models.SinkComponentConfig
+}
+
+func (m *mockTableCreator) CreateTable(_ context.Context, cfg models.SinkComponentConfig) error {
+	m.created = append(m.created, cfg)
+	return m.err
+}
+
+// mockTableInspector is a mock implementation of the TableInspector interface
+type mockTableInspector struct {
+	columnTypes map[string]string
+	err         error
+	calls       int
+}
+
+func (m *mockTableInspector) ColumnTypes(_ context.Context, _ models.ClickHouseConnectionParamsConfig) (map[string]string, error) {
+	m.calls++
+	return m.columnTypes, m.err
+}
+
+func TestPipelineService_CreatePipeline_AutoCreateTable(t *testing.T) {
+	newPipeline := func(createTable *models.CreateTableConfig) *models.PipelineConfig {
+		return &models.PipelineConfig{
+			ID: "pipeline-1",
+			Sink: models.SinkComponentConfig{
+				Type:        internal.ClickHouseSinkType,
+				Batch:       models.BatchConfig{MaxBatchSize: 100},
+				CreateTable: createTable,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		createTable   *models.CreateTableConfig
+		creatorErr    error
+		expectCreated bool
+		expectErr     error
+	}{
+		{
+			name:          "creates table before inserting the pipeline",
+			createTable:   &models.CreateTableConfig{Engine: "MergeTree", OrderBy: "tuple()"},
+			expectCreated: true,
+		},
+		{
+			name:          "does not create table when not enabled",
+			createTable:   nil,
+			expectCreated: false,
+		},
+		{
+			name:          "create table failure aborts pipeline creation",
+			createTable:   &models.CreateTableConfig{Engine: "MergeTree", OrderBy: "tuple()"},
+			creatorErr:    errors.New("connection refused"),
+			expectCreated: true,
+			expectErr:     ErrCreateSinkTable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{}
+			creator := &mockTableCreator{err: tt.creatorErr}
+			svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+			svc.tableCreator = creator
+
+			err := svc.CreatePipeline(context.Background(), newPipeline(tt.createTable))
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				if _, exists := store.pipelines["pipeline-1"]; exists {
+					t.Error("pipeline was inserted despite the table creation failure")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := len(creator.created) > 0; got != tt.expectCreated {
+				t.Errorf("table created = %v, expected %v", got, tt.expectCreated)
+			}
+		})
+	}
+}
+
+func TestPipelineService_CreatePipeline_RequiredOwnership(t *testing.T) {
+	newPipeline := func(metadata models.PipelineMetadata) *models.PipelineConfig {
+		return &models.PipelineConfig{
+			ID: "pipeline-1",
+			Sink: models.SinkComponentConfig{
+				Type:  internal.ClickHouseSinkType,
+				Batch: models.BatchConfig{MaxBatchSize: 100},
+			},
+			Metadata: metadata,
+		}
+	}
+	required := []models.OwnershipField{models.OwnershipOwner, models.OwnershipContact}
+
+	store := &mockPipelineStore{}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default(), WithRequiredOwnership(required))
+
+	err := svc.CreatePipeline(context.Background(), newPipeline(models.PipelineMetadata{Team: "payments"}))
+	if !errors.Is(err, ErrOwnershipRequired) {
+		t.Fatalf("expected %v, got %v", ErrOwnershipRequired, err)
+	}
+	if !strings.Contains(err.Error(), "owner, contact") {
+		t.Errorf("error %q does not name the missing fields", err)
+	}
+	if _, exists := store.pipelines["pipeline-1"]; exists {
+		t.Error("pipeline was inserted without the required ownership")
+	}
+
+	err = svc.CreatePipeline(context.Background(), newPipeline(models.PipelineMetadata{Owner: "jane", Contact: "#payments-oncall"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = svc.UpdatePipelineMetadata(context.Background(), "pipeline-1", models.PipelineMetadata{Owner: "jane"})
+	if !errors.Is(err, ErrOwnershipRequired) {
+		t.Fatalf("expected %v when clearing the contact, got %v", ErrOwnershipRequired, err)
+	}
+}
+
+func TestPipelineService_ConfirmStaging(t *testing.T) {
+	staging := &models.StagingConfig{TableSuffix: models.DefaultStagingTableSuffix}
+
+	tests := []struct {
+		name      string
+		pipelines map[string]models.PipelineConfig
+		expectErr error
+	}{
+		{
+			name: "removes staging from the sink",
+			pipelines: map[string]models.PipelineConfig{
+				"pipeline-1": {ID: "pipeline-1", Sink: models.SinkComponentConfig{Staging: staging}},
+			},
+		},
+		{
+			name: "pipeline without staging",
+			pipelines: map[string]models.PipelineConfig{
+				"pipeline-1": {ID: "pipeline-1"},
+			},
+			expectErr: ErrPipelineNotStaging,
+		},
+		{
+			name:      "pipeline does not exist",
+			expectErr: ErrPipelineNotExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{pipelines: tt.pipelines}
+			svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+			err := svc.ConfirmStaging(context.Background(), "pipeline-1")
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if store.pipelines["pipeline-1"].Sink.Staging != nil {
+				t.Error("staging was not removed from the sink config")
+			}
+		})
+	}
+}
+
+func TestPipelineService_PauseComponent(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+		"pipeline-1": {ID: "pipeline-1"},
+	}}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	ctx := context.Background()
+
+	if err := svc.PauseComponent(ctx, "pipeline-1", internal.RoleSink); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Pausing twice keeps a single entry
+	if err := svc.PauseComponent(ctx, "pipeline-1", internal.RoleSink); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.pipelines["pipeline-1"].PausedComponents; len(got) != 1 || got[0] != internal.RoleSink {
+		t.Errorf("PausedComponents = %v, expected [sink]", got)
+	}
+
+	health, err := svc.GetPipelineHealth(ctx, "pipeline-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(health.PausedComponents) != 1 {
+		t.Errorf("health PausedComponents = %v, expected [sink]", health.PausedComponents)
+	}
+
+	if err := svc.ResumeComponent(ctx, "pipeline-1", internal.RoleSink); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.pipelines["pipeline-1"].PausedComponents; len(got) != 0 {
+		t.Errorf("PausedComponents = %v, expected none", got)
+	}
+
+	var cfgErr models.PipelineConfigError
+	if err := svc.PauseComponent(ctx, "pipeline-1", internal.RoleIngestor); !errors.As(err, &cfgErr) {
+		t.Errorf("expected a config error pausing the ingestor, got %v", err)
+	}
+	if err := svc.PauseComponent(ctx, "pipeline-2", internal.RoleSink); !errors.Is(err, ErrPipelineNotExists) {
+		t.Errorf("expected %v, got %v", ErrPipelineNotExists, err)
+	}
+}
+
+func TestPipelineService_CreatePipeline_LearnsColumnTypes(t *testing.T) {
+	newPipeline := func(columnType string) *models.PipelineConfig {
+		return &models.PipelineConfig{
+			ID: "pipeline-1",
+			Sink: models.SinkComponentConfig{
+				Type:  internal.ClickHouseSinkType,
+				Batch: models.BatchConfig{MaxBatchSize: 100},
+				Config: []models.Mapping{
+					{SourceField: "id", SourceType: "string", DestinationField: "id", DestinationType: columnType},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		columnType   string
+		columnTypes  map[string]string
+		inspectorErr error
+		expectType   string
+		expectCalls  int
+		expectErr    error
+	}{
+		{
+			name:        "fills the omitted column type from the table",
+			columnTypes: map[string]string{"id": "UUID"},
+			expectType:  "UUID",
+			expectCalls: 1,
+		},
+		{
+			name:        "keeps the explicit column type",
+			columnType:  "String",
+			expectType:  "String",
+			expectCalls: 0,
+		},
+		{
+			name:        "column missing from the table",
+			columnTypes: map[string]string{},
+			expectCalls: 1,
+			expectErr:   ErrSinkColumnTypes,
+		},
+		{
+			name:        "unsupported learned column type",
+			columnTypes: map[string]string{"id": "Tuple(String, String)"},
+			expectCalls: 1,
+			expectErr:   ErrSinkColumnTypes,
+		},
+		{
+			name:         "table inspection failure",
+			inspectorErr: errors.New("connection refused"),
+			expectCalls:  1,
+			expectErr:    ErrSinkColumnTypes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{}
+			inspector := &mockTableInspector{columnTypes: tt.columnTypes, err: tt.inspectorErr}
+			svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+			svc.tableInspector = inspector
+
+			err := svc.CreatePipeline(context.Background(), newPipeline(tt.columnType))
+			if inspector.calls != tt.expectCalls {
+				t.Errorf("table inspected %d times, expected %d", inspector.calls, tt.expectCalls)
+			}
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := store.pipelines["pipeline-1"].Sink.Config[0].DestinationType
+			if got != tt.expectType {
+				t.Errorf("column type = %q, expected %q", got, tt.expectType)
+			}
+		})
+	}
+}
+
+// mockConsumerLagReader is a mock implementation of the ConsumerLagReader interface
+type mockConsumerLagReader struct {
+	lag   []models.KafkaPartitionLag
+	err   error
+	calls int
+}
+
+func (m *mockConsumerLagReader) ConsumerLag(_ context.Context, _ models.KafkaConnectionParamsConfig, _ []models.KafkaTopicsConfig) ([]models.KafkaPartitionLag, error) {
+	m.calls++
+	return m.lag, m.err
+}
+
+func TestPipelineService_GetPipelineHealth_ConsumerLag(t *testing.T) {
+	lag := []models.KafkaPartitionLag{
+		{ConsumerGroup: "gf-pipeline-1", Topic: "orders", Partition: 0, CommittedOffset: 90, EndOffset: 100, Lag: 10},
+	}
+
+	tests := []struct {
+		name       string
+		status     string
+		sourceType models.SourceType
+		lagErr     error
+		wantCalls  int
+		wantLag    []models.KafkaPartitionLag
+	}{
+		{name: "running pipeline reports lag", status: internal.PipelineStatusRunning, wantCalls: 1, wantLag: lag},
+		{name: "stopped pipeline skips brokers", status: internal.PipelineStatusStopped, wantCalls: 0},
+		{name: "broker error keeps health", status: internal.PipelineStatusRunning, lagErr: errors.New("broker down"), wantCalls: 1},
+		{name: "pulsar pipeline skips kafka lag", status: internal.PipelineStatusRunning, sourceType: internal.PulsarIngestorType, wantCalls: 0},
+		{name: "mysql pipeline skips kafka lag", status: internal.PipelineStatusRunning, sourceType: internal.MySQLIngestorType, wantCalls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+				"pipeline-1": {
+					ID:         "pipeline-1",
+					SourceType: tt.sourceType,
+					Ingestor: models.IngestorComponentConfig{
+						KafkaTopics: []models.KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "gf-pipeline-1"}},
+					},
+					Status: models.PipelineHealth{PipelineID: "pipeline-1", OverallStatus: models.PipelineStatus(tt.status)},
+				},
+			}}
+			reader := &mockConsumerLagReader{lag: lag, err: tt.lagErr}
+			svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default())
+			svc.lagReader = reader
+
+			health, err := svc.GetPipelineHealth(context.Background(), "pipeline-1")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if health.OverallStatus != models.PipelineStatus(tt.status) {
+				t.Errorf("expected status %s, got %s", tt.status, health.OverallStatus)
+			}
+			if reader.calls != tt.wantCalls {
+				t.Errorf("expected %d lag queries, got %d", tt.wantCalls, reader.calls)
+			}
+			if len(health.ConsumerLag) != len(tt.wantLag) {
+				t.Fatalf("expected lag %v, got %v", tt.wantLag, health.ConsumerLag)
+			}
+			for i := range tt.wantLag {
+				if health.ConsumerLag[i] != tt.wantLag[i] {
+					t.Errorf("expected lag %v, got %v", tt.wantLag[i], health.ConsumerLag[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineService_GetPipelineHealth_ConsumerLagCached(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+		"pipeline-1": {
+			ID: "pipeline-1",
+			Ingestor: models.IngestorComponentConfig{
+				KafkaTopics: []models.KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "gf-pipeline-1"}},
+			},
+			Status: models.PipelineHealth{PipelineID: "pipeline-1", OverallStatus: internal.PipelineStatusRunning},
+		},
+	}}
+	reader := &mockConsumerLagReader{lag: []models.KafkaPartitionLag{{Topic: "orders", Lag: 10}}}
+	now := time.Now()
+	svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default())
+	svc.lagReader = reader
+	svc.lagCache.now = func() time.Time { return now }
+
+	for range 2 {
+		health, err := svc.GetPipelineHealth(context.Background(), "pipeline-1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(health.ConsumerLag) != 1 || health.ConsumerLag[0].Lag != 10 {
+			t.Fatalf("expected cached lag, got %v", health.ConsumerLag)
+		}
+	}
+	if reader.calls != 1 {
+		t.Errorf("expected 1 lag query within the TTL, got %d", reader.calls)
+	}
+
+	now = now.Add(consumerLagCacheTTL)
+	_, err := svc.GetPipelineHealth(context.Background(), "pipeline-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if reader.calls != 2 {
+		t.Errorf("expected the lag to be queried again after the TTL, got %d queries", reader.calls)
+	}
+}
+
+// mockInFlightReader is a mock implementation of the InFlightReader interface
+type mockInFlightReader struct {
+	inFlight []models.ComponentInFlight
+	err      error
+}
+
+func (m *mockInFlightReader) ConsumersInFlight(_ context.Context, _ string) ([]models.ComponentInFlight, error) {
+	return m.inFlight, m.err
+}
+
+func TestPipelineService_GetPipelineHealth_InFlight(t *testing.T) {
+	drained := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name         string
+		reader       InFlightReader
+		wantInFlight int
+		wantDrained  *bool
+	}{
+		{name: "no reader", reader: nil},
+		{
+			name: "in-flight messages",
+			reader: &mockInFlightReader{inFlight: []models.ComponentInFlight{
+				{Component: "join", Consumer: "gf-nats-jl-1", Pending: 4},
+				{Component: "sink", Consumer: "gf-nats-si-1"},
+			}},
+			wantInFlight: 2,
+			wantDrained:  drained(false),
+		},
+		{
+			name:         "drained",
+			reader:       &mockInFlightReader{inFlight: []models.ComponentInFlight{{Component: "sink", Consumer: "gf-nats-si-1"}}},
+			wantInFlight: 1,
+			wantDrained:  drained(true),
+		},
+		{name: "nats error keeps health", reader: &mockInFlightReader{err: errors.New("nats down")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+				"pipeline-1": {
+					ID:     "pipeline-1",
+					Status: models.PipelineHealth{PipelineID: "pipeline-1", OverallStatus: internal.PipelineStatusStopping},
+				},
+			}}
+			svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default(), WithInFlightReader(tt.reader))
+
+			health, err := svc.GetPipelineHealth(context.Background(), "pipeline-1")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(health.InFlight) != tt.wantInFlight {
+				t.Errorf("expected %d in-flight consumers, got %v", tt.wantInFlight, health.InFlight)
+			}
+			if (health.Drained == nil) != (tt.wantDrained == nil) {
+				t.Fatalf("expected drained %v, got %v", tt.wantDrained, health.Drained)
+			}
+			if tt.wantDrained != nil && *health.Drained != *tt.wantDrained {
+				t.Errorf("expected drained %v, got %v", *tt.wantDrained, *health.Drained)
+			}
+		})
+	}
+}
+
+func TestPipelineService_GetPipelineHealth_SLA(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+		"pipeline-1": {
+			ID: "pipeline-1",
+			Ingestor: models.IngestorComponentConfig{
+				KafkaTopics: []models.KafkaTopicsConfig{{Name: "orders", ConsumerGroupName: "gf-pipeline-1"}},
+			},
+			Metadata: models.PipelineMetadata{SLA: &models.SLAConfig{MaxLag: 100}},
+			Status:   models.PipelineHealth{PipelineID: "pipeline-1", OverallStatus: internal.PipelineStatusRunning},
+		},
+	}}
+	reader := &mockConsumerLagReader{lag: []models.KafkaPartitionLag{
+		{Topic: "orders", Partition: 0, Lag: 80},
+		{Topic: "orders", Partition: 1, Lag: 40},
+	}}
+	evaluator := NewSLAEvaluator(nil, nil, nil, slog.Default())
+	evaluator.lagReader = reader
+	svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default(), WithSLAEvaluator(evaluator))
+	svc.lagReader = reader
+
+	health, err := svc.GetPipelineHealth(context.Background(), "pipeline-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if reader.calls != 1 {
+		t.Errorf("expected the lag of the health to be reused, got %d lag queries", reader.calls)
+	}
+	if health.SLA == nil || !health.SLA.Breached {
+		t.Fatalf("expected an sla breach, got %+v", health.SLA)
+	}
+	if len(health.SLA.Breaches) != 1 || health.SLA.Breaches[0].Objective != models.SLAObjectiveLag || health.SLA.Breaches[0].Value != 120 {
+		t.Errorf("expected the lag breach, got %+v", health.SLA.Breaches)
+	}
+}
+
+func TestPipelineService_GetPipelines_ByTags(t *testing.T) {
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+		"orders":   {ID: "orders", Metadata: models.PipelineMetadata{Tags: []string{"team:payments", "prod"}}},
+		"refunds":  {ID: "refunds", Metadata: models.PipelineMetadata{Tags: []string{"team:payments"}}},
+		"sessions": {ID: "sessions", Metadata: models.PipelineMetadata{Tags: []string{"prod"}}},
+	}}
+	svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default())
+
+	pipelines, err := svc.GetPipelines(context.Background(), []string{"team:payments", "prod"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(pipelines) != 1 || pipelines[0].ID != "orders" {
+		t.Errorf("expected only the orders pipeline, got %+v", pipelines)
+	}
+
+	_, err = svc.GetPipelines(context.Background(), []string{"team payments"})
+	var pErr models.PipelineConfigError
+	if !errors.As(err, &pErr) {
+		t.Errorf("expected an invalid tag error, got %v", err)
+	}
+}
+
+func TestPipelineService_CheckDedupDomains(t *testing.T) {
+	ctx := context.Background()
+	withDomain := func(id, domain string, window time.Duration) models.PipelineConfig {
+		return models.PipelineConfig{ID: id, Ingestor: models.IngestorComponentConfig{KafkaTopics: []models.KafkaTopicsConfig{{
+			Name: "orders",
+			Deduplication: models.DeduplicationConfig{
+				Enabled: true,
+				ID:      "order_id",
+				Window:  *models.NewJSONDuration(window),
+				Domain:  domain,
+			},
+		}}}}
+	}
+	store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+		"orders-eu": withDomain("orders-eu", "orders", time.Hour),
+	}}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+
+	backfill := withDomain("orders-backfill", "orders", time.Hour)
+	if err := svc.checkDedupDomains(ctx, backfill.ID, &backfill); err != nil {
+		t.Fatalf("unexpected error joining the domain with its window: %v", err)
+	}
+
+	longer := withDomain("orders-backfill", "orders", 2*time.Hour)
+	if err := svc.checkDedupDomains(ctx, longer.ID, &longer); !errors.Is(err, ErrDedupDomainConflict) {
+		t.Fatalf("expected ErrDedupDomainConflict, got %v", err)
+	}
+
+	// The pipeline itself is not a conflict when its window is edited
+	edited := withDomain("orders-eu", "orders", 2*time.Hour)
+	if err := svc.checkDedupDomains(ctx, edited.ID, &edited); err != nil {
+		t.Fatalf("unexpected error editing the window of the only pipeline of the domain: %v", err)
+	}
+}
+
+// mockTopicOffsetMigrator is a mock implementation of the TopicOffsetMigrator interface
+type mockTopicOffsetMigrator struct {
+	migration models.TopicMigration
+	err       error
+	calls     int
+	group     string
+}
+
+func (m *mockTopicOffsetMigrator) MigrateOffsets(_ context.Context, _ models.KafkaConnectionParamsConfig, group, _, _ string, _ bool) (models.TopicMigration, error) {
+	m.calls++
+	m.group = group
+	return m.migration, m.err
+}
+
+func TestPipelineService_MigrateTopic(t *testing.T) {
+	migration := models.TopicMigration{From: "orders", To: "orders-v2", ConsumerGroup: "gf-pipeline-1", DryRun: true}
+
+	tests := []struct {
+		name          string
+		status        string
+		sourceType    models.SourceType
+		to            string
+		migratorErr   error
+		wantErr       error
+		wantStatusErr bool
+		wantCalls     int
+	}{
+		{name: "dry run maps offsets", status: internal.PipelineStatusStopped, to: "orders-v2", wantCalls: 1},
+		{name: "running pipeline", status: internal.PipelineStatusRunning, to: "orders-v2", wantStatusErr: true},
+		{name: "topic already read", status: internal.PipelineStatusStopped, to: "payments", wantErr: ErrTopicMigrationTarget},
+		{name: "pulsar pipeline", status: internal.PipelineStatusStopped, sourceType: internal.PulsarIngestorType, to: "orders-v2", wantErr: ErrTopicMigrationSource},
+		{
+			name:        "consumer group active",
+			status:      internal.PipelineStatusStopped,
+			to:          "orders-v2",
+			migratorErr: fmt.Errorf("commit: %w", kafka.ErrConsumerGroupActive),
+			wantErr:     ErrConsumerGroupActive,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockPipelineStore{pipelines: map[string]models.PipelineConfig{
+				"pipeline-1": {
+					ID:         "pipeline-1",
+					SourceType: tt.sourceType,
+					Ingestor: models.IngestorComponentConfig{
+						KafkaTopics: []models.KafkaTopicsConfig{
+							{Name: "orders", ConsumerGroupName: "gf-pipeline-1"},
+							{Name: "payments", ConsumerGroupName: "gf-pipeline-1"},
+						},
+					},
+					Status: models.PipelineHealth{PipelineID: "pipeline-1", OverallStatus: models.PipelineStatus(tt.status)},
+				},
+			}}
+			migrator := &mockTopicOffsetMigrator{migration: migration, err: tt.migratorErr}
+			svc := NewPipelineService(&mockOrchestrator{}, store, slog.Default())
+			svc.topicMigrator = migrator
+
+			got, err := svc.MigrateTopic(context.Background(), "pipeline-1", "orders", tt.to, true)
+			switch {
+			case tt.wantStatusErr:
+				if _, ok := status.GetStatusValidationError(err); !ok {
+					t.Fatalf("expected status validation error, got %v", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if got.ConsumerGroup != migration.ConsumerGroup {
+					t.Errorf("expected migration %v, got %v", migration, got)
+				}
+				if migrator.group != "gf-pipeline-1" {
+					t.Errorf("expected consumer group gf-pipeline-1, got %q", migrator.group)
+				}
+			}
+			if migrator.calls != tt.wantCalls {
+				t.Errorf("expected %d offset migrations, got %d", tt.wantCalls, migrator.calls)
+			}
+		})
+	}
+}
+
+type mockKafkaClusterReader struct {
+	id string
+}
+
+func (m *mockKafkaClusterReader) ClusterID(_ context.Context, _ models.KafkaConnectionParamsConfig) (string, error) {
+	return m.id, nil
+}
+
+func TestPipelineService_ResumePipeline_KafkaCluster(t *testing.T) {
+	ctx := context.Background()
+	store := &mockPipelineStore{}
+	reader := &mockKafkaClusterReader{id: "cluster-a"}
+	svc := NewPipelineService(&mockOrchestrator{orchestratorType: "local"}, store, slog.Default())
+	svc.clusterReader = reader
+
+	stopped := models.PipelineConfig{
+		ID:     "test-pipeline",
+		Status: models.PipelineHealth{OverallStatus: internal.PipelineStatusStopped},
+	}
+	stopped.Ingestor.KafkaConnectionParams.Brokers = []string{"kafka:9092"}
+	store.InsertPipeline(ctx, stopped)
+
+	clusterID := func() string {
+		pipeline, err := store.GetPipeline(ctx, "test-pipeline")
+		if err != nil {
+			t.Fatalf("failed to get pipeline: %v", err)
+		}
+		return pipeline.Ingestor.KafkaConnectionParams.ClusterID
+	}
+
+	// Pipelines without a recorded cluster record it on resume
+	if err := svc.ResumePipeline(ctx, "test-pipeline", models.ResumeOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := clusterID(); got != "cluster-a" {
+		t.Errorf("expected recorded cluster %q, got %q", "cluster-a", got)
+	}
+
+	// Brokers repointed at another cluster are refused
+	reader.id = "cluster-b"
+	store.UpdatePipelineStatus(ctx, "test-pipeline", stopped.Status)
+	err := svc.ResumePipeline(ctx, "test-pipeline", models.ResumeOptions{})
+	if !errors.Is(err, ErrKafkaClusterChanged) {
+		t.Fatalf("expected ErrKafkaClusterChanged, got %v", err)
+	}
+	if got := clusterID(); got != "cluster-a" {
+		t.Errorf("expected recorded cluster %q, got %q", "cluster-a", got)
+	}
+
+	// Forcing accepts the new cluster
+	if err := svc.ResumePipeline(ctx, "test-pipeline", models.ResumeOptions{Force: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := clusterID(); got != "cluster-b" {
+		t.Errorf("expected recorded cluster %q, got %q", "cluster-b", got)
+	}
+}
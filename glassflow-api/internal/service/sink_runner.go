@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/nats-io/nats.go/jetstream"
 
@@ -12,7 +15,9 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/component"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/configs"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper/fixture"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/sink"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
 )
 
@@ -22,8 +27,12 @@ type SinkRunner struct {
 
 	pipelineCfg models.PipelineConfig
 	db          PipelineStore
+	// credentials issues the ClickHouse credentials of a sink with a Vault
+	// role, nil when the sink connects with the static ones
+	credentials sink.CredentialsSource
 
 	component component.Component
+	recorder  *fixture.Recorder
 	c         chan error
 	doneCh    chan struct{}
 }
@@ -50,11 +59,40 @@ func getSinkConsumerNameFromEnv() (string, error) {
 	return models.GetNATSSinkConsumerName(pipelineID), nil
 }
 
+// getSinkDecodeWorkersFromEnv returns the optional decode worker pool size,
+// 0 selects the sink default.
+func getSinkDecodeWorkersFromEnv() (int, error) {
+	val := strings.TrimSpace(os.Getenv("GLASSFLOW_SINK_DECODE_WORKERS"))
+	if val == "" {
+		return 0, nil
+	}
+	workers, err := strconv.Atoi(val)
+	if err != nil || workers < 0 {
+		return 0, fmt.Errorf("invalid GLASSFLOW_SINK_DECODE_WORKERS %q: must be a non-negative integer", val)
+	}
+	return workers, nil
+}
+
+// getSinkProfileEventsFromEnv reports whether the ClickHouse ProfileEvents of
+// the inserts are recorded.
+func getSinkProfileEventsFromEnv() (bool, error) {
+	val := strings.TrimSpace(os.Getenv("GLASSFLOW_SINK_PROFILE_EVENTS"))
+	if val == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid GLASSFLOW_SINK_PROFILE_EVENTS %q: must be a boolean", val)
+	}
+	return enabled, nil
+}
+
 func NewSinkRunner(
 	log *slog.Logger,
 	nc *client.NATSClient,
 	pipelineCfg models.PipelineConfig,
 	db PipelineStore,
+	credentials sink.CredentialsSource,
 ) *SinkRunner {
 	return &SinkRunner{
 		nc:  nc,
@@ -62,6 +100,7 @@ func NewSinkRunner(
 
 		pipelineCfg: pipelineCfg,
 		db:          db,
+		credentials: credentials,
 
 		component: nil,
 	}
@@ -72,11 +111,14 @@ func (s *SinkRunner) Start(ctx context.Context) error {
 	s.c = make(chan error, 1)
 
 	maxBatchSize := s.pipelineCfg.Sink.Batch.MaxBatchSize
-	maxAckPending := maxBatchSize * 4
+	// Every writer buffers its own batch
+	writers := max(s.pipelineCfg.Sink.WriterConcurrency, 1)
+	maxAckPending := maxBatchSize * 4 * writers
 
 	s.log.InfoContext(ctx, "Setting MaxAckPending limit",
 		"max_ack_pending", maxAckPending,
-		"max_batch_size", maxBatchSize)
+		"max_batch_size", maxBatchSize,
+		"writers", writers)
 
 	inputStreamName, err := getSinkInputStreamNameFromEnv()
 	if err != nil {
@@ -124,29 +166,63 @@ func (s *SinkRunner) Start(ctx context.Context) error {
 		streamSourceID = s.pipelineCfg.StatelessTransformation.ID
 	}
 
+	decodeWorkers, err := getSinkDecodeWorkersFromEnv()
+	if err != nil {
+		return fmt.Errorf("resolve sink decode workers from env: %w", err)
+	}
+
+	profileEvents, err := getSinkProfileEventsFromEnv()
+	if err != nil {
+		return fmt.Errorf("resolve sink profile events from env: %w", err)
+	}
+
+	var fieldMapper sink.FieldMapper = mapper.NewKafkaToClickHouseMapper()
+	if dir := os.Getenv("GLASSFLOW_SINK_FIXTURE_DIR"); dir != "" {
+		s.recorder, err = fixture.NewRecorder(fieldMapper, dir, s.log)
+		if err != nil {
+			return fmt.Errorf("create sink fixture recorder: %w", err)
+		}
+		fieldMapper = s.recorder
+		s.log.InfoContext(ctx, "Sink will record mapped events to fixture dir", "dir", dir)
+	}
+
+	if s.pipelineCfg.Sink.ClickHouseConnectionParams.VaultRole != "" && s.credentials == nil {
+		return fmt.Errorf("sink vault_role %q requires a vault address and token", s.pipelineCfg.Sink.ClickHouseConnectionParams.VaultRole)
+	}
+
 	sinkComponent, err := component.NewSinkComponent(
 		s.pipelineCfg.Sink,
 		consumer,
-		mapper.NewKafkaToClickHouseMapper(),
+		fieldMapper,
 		configs.NewConfigStore(s.db, s.pipelineCfg.ID, s.pipelineCfg.Sink.SourceID),
 		s.doneCh,
 		s.log,
 		dlqStreamPublisher,
+		s.pipelineCfg.ID,
 		streamSourceID,
+		decodeWorkers,
+		profileEvents,
+		s.db,
+		s.credentials,
 	)
 	if err != nil {
+		s.closeRecorder(s.recorder)
 		s.log.ErrorContext(ctx, "failed to create ClickHouse sink: ", "error", err)
 		return fmt.Errorf("create sink: %w", err)
 	}
 
 	s.component = sinkComponent
 
+	// The recorder is closed once the component has stopped mapping, which
+	// is only after Start returned
+	recorder := s.recorder
 	go func() {
 		sinkComponent.Start(ctx, s.c)
 		close(s.c)
 		for err := range s.c {
 			s.log.ErrorContext(ctx, "Error in the sink component", "error", err)
 		}
+		s.closeRecorder(recorder)
 	}()
 
 	return nil
@@ -158,6 +234,16 @@ func (s *SinkRunner) Shutdown() {
 	}
 }
 
+func (s *SinkRunner) closeRecorder(recorder *fixture.Recorder) {
+	if recorder == nil {
+		return
+	}
+	err := recorder.Close()
+	if err != nil {
+		s.log.Error("failed to close sink fixture recorder", "error", err)
+	}
+}
+
 // Done returns a channel that signals when the component stops by itself
 func (s *SinkRunner) Done() <-chan struct{} {
 	return s.doneCh
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
+)
+
+// LatencyReader returns the age of the oldest message the pipeline's
+// components have not processed yet.
+type LatencyReader interface {
+	PipelineLatency(ctx context.Context, pipelineID string) (time.Duration, error)
+}
+
+// slaTimeout bounds the queries of an SLA evaluation
+const slaTimeout = 5 * time.Second
+
+type dlqSample struct {
+	sequence uint64
+	at       time.Time
+}
+
+// SLAEvaluator measures running pipelines against their SLA objectives. The
+// DLQ rate is computed from the DLQ sequences sampled by the previous
+// evaluations, so the evaluator is shared by the health API and the
+// notification watcher.
+type SLAEvaluator struct {
+	lagReader     ConsumerLagReader
+	latencyReader LatencyReader
+	dlq           DLQStateGetter
+	log           *slog.Logger
+	window        time.Duration
+
+	mu         sync.Mutex
+	dlqSamples map[string][]dlqSample
+}
+
+func NewSLAEvaluator(latencyReader LatencyReader, dlq DLQStateGetter, secretStore secrets.Store, log *slog.Logger) *SLAEvaluator {
+	return &SLAEvaluator{
+		lagReader:     kafkaConsumerLagReader{secrets: secretStore},
+		latencyReader: latencyReader,
+		dlq:           dlq,
+		log:           log,
+		window:        internal.SLADLQRateWindow,
+		dlqSamples:    make(map[string][]dlqSample),
+	}
+}
+
+// Evaluate returns the SLA status of the pipeline, nil when it has no SLA
+// objectives or is not running. lag is the consumer lag when the caller read
+// it already, it is read from the brokers otherwise.
+func (e *SLAEvaluator) Evaluate(ctx context.Context, pipeline models.PipelineConfig, lag []models.KafkaPartitionLag) *models.SLAStatus {
+	if e == nil {
+		return nil
+	}
+
+	sla := pipeline.Metadata.SLA
+	if sla == nil || sla.IsZero() || pipeline.Status.OverallStatus != internal.PipelineStatusRunning {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, slaTimeout)
+	defer cancel()
+
+	var m models.SLAMeasurements
+	if sla.MaxLatency.Duration() > 0 {
+		m.Latency = e.latency(ctx, pipeline.ID)
+	}
+	if sla.MaxDLQRate > 0 {
+		m.DLQRate = e.dlqRate(ctx, pipeline.ID)
+	}
+	if sla.MaxLag > 0 {
+		m.Lag = e.lag(ctx, pipeline, lag)
+	}
+
+	status := sla.Evaluate(m, time.Now().UTC())
+	return &status
+}
+
+// Forget drops the DLQ samples of a pipeline that no longer exists.
+func (e *SLAEvaluator) Forget(pipelineID string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.dlqSamples, pipelineID)
+}
+
+func (e *SLAEvaluator) latency(ctx context.Context, pipelineID string) *time.Duration {
+	if e.latencyReader == nil {
+		return nil
+	}
+
+	latency, err := e.latencyReader.PipelineLatency(ctx, pipelineID)
+	if err != nil {
+		e.log.WarnContext(ctx, "sla: failed to get pipeline latency", "pipeline_id", pipelineID, "error", err)
+		return nil
+	}
+	return &latency
+}
+
+// dlqRate returns the messages per minute the DLQ received over the sampling
+// window. It is unknown until a sample at least a tenth of the window old
+// exists. The DLQ sequence is sampled rather than its size, which drops when
+// the DLQ is consumed or purged.
+func (e *SLAEvaluator) dlqRate(ctx context.Context, pipelineID string) *float64 {
+	if e.dlq == nil {
+		return nil
+	}
+
+	state, err := e.dlq.GetDLQState(ctx, models.GetDLQStreamName(pipelineID))
+	if err != nil {
+		e.log.WarnContext(ctx, "sla: failed to get dlq state", "pipeline_id", pipelineID, "error", err)
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	samples := e.dlqSamples[pipelineID]
+	if len(samples) > 0 && state.LastSequence < samples[len(samples)-1].sequence {
+		// The DLQ stream was recreated
+		samples = nil
+	}
+	for len(samples) > 1 && now.Sub(samples[1].at) >= e.window {
+		samples = samples[1:]
+	}
+	samples = append(samples, dlqSample{sequence: state.LastSequence, at: now})
+	e.dlqSamples[pipelineID] = samples
+
+	oldest := samples[0]
+	elapsed := now.Sub(oldest.at)
+	if elapsed < e.window/10 {
+		return nil
+	}
+
+	rate := float64(state.LastSequence-oldest.sequence) / elapsed.Minutes()
+	return &rate
+}
+
+func (e *SLAEvaluator) lag(ctx context.Context, pipeline models.PipelineConfig, lag []models.KafkaPartitionLag) *int64 {
+	if lag == nil {
+		if pipeline.SourceType.IsPulsar() || pipeline.SourceType.IsMySQL() || len(pipeline.Ingestor.KafkaTopics) == 0 {
+			return nil
+		}
+
+		var err error
+		lag, err = e.lagReader.ConsumerLag(ctx, pipeline.Ingestor.KafkaConnectionParams, pipeline.Ingestor.KafkaTopics)
+		if err != nil {
+			e.log.WarnContext(ctx, "sla: failed to get kafka consumer lag", "pipeline_id", pipeline.ID, "error", err)
+			return nil
+		}
+	}
+
+	var total int64
+	for _, p := range lag {
+		total += p.Lag
+	}
+	return &total
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// StorageReader returns the storage the pipeline's NATS streams use.
+type StorageReader interface {
+	StreamsStorage(ctx context.Context, pipelineID string) ([]models.StreamStorage, error)
+}
+
+// storageTimeout bounds the queries of a storage evaluation
+const storageTimeout = 5 * time.Second
+
+// StorageMonitor measures the streams of running pipelines against their
+// storage limits, so that a pipeline is reported degraded before its streams
+// start discarding messages.
+type StorageMonitor struct {
+	reader    StorageReader
+	threshold float64
+	log       *slog.Logger
+}
+
+// NewStorageMonitor creates a monitor alerting at threshold, a share of the
+// stream limits, zero disables it unless a pipeline sets its own.
+func NewStorageMonitor(reader StorageReader, threshold float64, log *slog.Logger) *StorageMonitor {
+	return &StorageMonitor{
+		reader:    reader,
+		threshold: threshold,
+		log:       log,
+	}
+}
+
+// Threshold returns the share of the stream limits the pipeline is degraded
+// at. The pipeline metadata takes precedence over the global setting.
+func (m *StorageMonitor) Threshold(pipeline models.PipelineConfig) float64 {
+	if nc := pipeline.Metadata.Notifications; nc != nil && nc.StorageThreshold > 0 {
+		return nc.StorageThreshold
+	}
+	return m.threshold
+}
+
+// Evaluate returns the storage status of the pipeline, nil when it is not
+// running, the check is disabled or the streams could not be read.
+func (m *StorageMonitor) Evaluate(ctx context.Context, pipeline models.PipelineConfig) *models.StorageStatus {
+	if m == nil || m.reader == nil || pipeline.Status.OverallStatus != internal.PipelineStatusRunning {
+		return nil
+	}
+
+	threshold := m.Threshold(pipeline)
+	if threshold <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, storageTimeout)
+	defer cancel()
+
+	streams, err := m.reader.StreamsStorage(ctx, pipeline.ID)
+	if err != nil {
+		m.log.WarnContext(ctx, "storage: failed to get stream storage", "pipeline_id", pipeline.ID, "error", err)
+		return nil
+	}
+
+	status := models.EvaluateStorage(streams, threshold, time.Now().UTC())
+	return &status
+}
@@ -4,36 +4,54 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/avast/retry-go"
 
 	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/batch/clickhouse"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/pool"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/secrets"
 	sinkerrors "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/sink/errors"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/stream"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 )
 
+// Flushed message slices and worker results are reused across flushes.
+var (
+	flushMessagesPool    = pool.NewSlicePool[jetstream.Msg]("sink", "flush_messages")
+	processedMessagePool = pool.NewSlicePool[processedMessage]("sink", "processed_messages")
+)
+
 // workerJob represents a chunk of messages to be processed by a worker
 type workerJob struct {
 	messages       []jetstream.Msg
 	jobID          int
 	streamSourceID string
+	// results receives the result of the job, each flush collects its own
+	// jobs as writers share the worker pool
+	results chan<- workerResult
 }
 
 // workerResult contains the processed results from a worker
 type workerResult struct {
 	jobID     int
 	processed []processedMessage
-	err       error
+	// processedBuf owns processed and is returned to processedMessagePool
+	// once the results have been appended to the ClickHouse batches
+	processedBuf *[]processedMessage
+	err          error
 }
 
 // processedMessage contains the metadata and values for a processed message
@@ -42,16 +60,24 @@ type processedMessage struct {
 	values          []any
 	msg             jetstream.Msg
 	schemaVersionID string
-	err             error
+	// shard holds the values at row in the column types of the batch, nil
+	// when the flush appends the values itself
+	shard *clickhouse.Shard
+	row   int
+	err   error
 }
 
 type schemaBatch struct {
 	batch    clickhouse.Batch
 	messages []jetstream.Msg
+	insert   batchInsert
 }
 
 // ClickHouseSink uses Consume() callback pattern
 type FieldMapper interface {
+	// Map may return values that share memory with data. The sink passes
+	// msg.Data() without copying; payloads are never modified and messages
+	// are acked only after their values were appended and the batch sent.
 	Map(data []byte, schemaVersionID string, config map[string]models.Mapping) ([]any, error)
 	GetColumnNames(schemaVersionID string) ([]string, error)
 }
@@ -60,6 +86,19 @@ type ConfigStore interface {
 	GetSinkConfig(ctx context.Context, sourceSchemaVersion string) (map[string]models.Mapping, error)
 }
 
+// StagingStore returns the stored pipeline config, the sink polls it to learn
+// when the staging of a new pipeline is confirmed and when the sink is paused
+// through the API.
+type StagingStore interface {
+	GetPipeline(ctx context.Context, pid string) (*models.PipelineConfig, error)
+}
+
+// CredentialsSource issues short-lived ClickHouse credentials, the sink
+// connects with new ones before the lease of the current ones expires.
+type CredentialsSource interface {
+	Credentials(ctx context.Context) (secrets.DatabaseCredentials, error)
+}
+
 type ClickHouseSink struct {
 	client                *client.ClickHouseClient
 	streamConsumer        jetstream.Consumer
@@ -72,23 +111,99 @@ type ClickHouseSink struct {
 	streamSourceID        string
 	log                   *slog.Logger
 	dlqPublisher          stream.Publisher
+	// errorTable also writes DLQ messages to ClickHouse, nil when disabled
+	errorTable *errorTableWriter
+
+	// Batch accumulation, the consumed messages are buffered by writer
+	writers           []*sinkWriter
+	bufferFlushTicker *time.Ticker
+	maxBatchSize      int
+	maxDelayTime      time.Duration
+	consumeContext    jetstream.ConsumeContext
+	consumeMu         sync.Mutex
+	messageHandler    jetstream.MessageHandler
+	pullSize          int
+	// pausedBy holds the reasons consuming is paused for, see pauseConsume
+	pausedBy map[string]bool
+	// warmUp ramps the batch and pull sizes after the start, nil when disabled
+	warmUp *warmUp
+
+	// Worker pool for parallel PrepareValues processing
+	workerPoolSize int
+	workerJobChan  chan workerJob
+	workerWg       sync.WaitGroup
+	workerCtx      context.Context
+	workerCancel   context.CancelFunc
+	// jobsInFlight counts jobs whose messages may still be read by a worker
+	jobsInFlight atomic.Int64
+	// shardLayouts holds the column layout of the last batch of each schema
+	// version, the workers append the rows of a version into column shards
+	// of its layout that the flush merges into the batch
+	shardLayoutsMu sync.RWMutex
+	shardLayouts   map[string]*clickhouse.ShardLayout
+
+	// shedder switches to pulling a single batch under memory or CPU pressure
+	shedder *loadShedder
+
+	// staging is set while batches go to the staging table of a new pipeline
+	staging      atomic.Bool
+	stagingStore StagingStore
+	pipelineID   string
+
+	// credentials issues the ClickHouse credentials, nil when they are static
+	credentials CredentialsSource
+	// credentialsLease is the lease of the credentials the client connected
+	// with, only read by the credentials loop after the start
+	credentialsLease time.Duration
+}
+
+// sinkWriter buffers and flushes the messages of the subjects assigned to it.
+// Writers flush concurrently: the messages of a subject are inserted in order
+// by one writer while the subjects of different writers are inserted in
+// parallel.
+type sinkWriter struct {
+	id int
 
-	// Batch accumulation
-	messageBuffer      []jetstream.Msg
 	bufferMu           sync.Mutex
-	bufferFlushTicker  *time.Ticker
-	maxBatchSize       int
-	maxDelayTime       time.Duration
-	consumeContext     jetstream.ConsumeContext
+	messageBuffer      []jetstream.Msg
 	lastBatchStartTime time.Time
 
-	// Worker pool for parallel PrepareValues processing
-	workerPoolSize   int
-	workerJobChan    chan workerJob
-	workerResultChan chan workerResult
-	workerWg         sync.WaitGroup
-	workerCtx        context.Context
-	workerCancel     context.CancelFunc
+	// flushMu keeps the flushes of the writer in order
+	flushMu sync.Mutex
+	// lastFlush paces the flushes while the sink warms up, under flushMu
+	lastFlush time.Time
+	// flushCh requests a flush from the writer loop, with several writers
+	flushCh chan struct{}
+}
+
+func newSinkWriter(id, maxBatchSize int) *sinkWriter {
+	return &sinkWriter{
+		id:            id,
+		messageBuffer: make([]jetstream.Msg, 0, maxBatchSize),
+		flushCh:       make(chan struct{}, 1),
+	}
+}
+
+// add buffers msg and reports whether the buffer reached maxBatchSize.
+func (w *sinkWriter) add(msg jetstream.Msg, maxBatchSize int) bool {
+	w.bufferMu.Lock()
+	defer w.bufferMu.Unlock()
+
+	if len(w.messageBuffer) == 0 {
+		w.lastBatchStartTime = time.Now()
+	}
+	w.messageBuffer = append(w.messageBuffer, msg)
+	return len(w.messageBuffer) >= maxBatchSize
+}
+
+// writerIndex returns the writer of a NATS subject.
+func writerIndex(subject string, writers int) int {
+	if writers <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(subject))
+	return int(h.Sum32() % uint32(writers))
 }
 
 func NewClickHouseSink(
@@ -99,8 +214,23 @@ func NewClickHouseSink(
 	log *slog.Logger,
 	dlqPublisher stream.Publisher,
 	clickhouseQueryConfig models.ClickhouseQueryConfig,
+	pipelineID string,
 	streamSourceID string,
+	decodeWorkers int,
+	stagingStore StagingStore,
+	credentials CredentialsSource,
 ) (*ClickHouseSink, error) {
+	var credentialsLease time.Duration
+	if credentials != nil {
+		creds, err := credentials.Credentials(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get clickhouse credentials: %w", err)
+		}
+		sinkConfig.ClickHouseConnectionParams.Username = creds.Username
+		sinkConfig.ClickHouseConnectionParams.Password = creds.Password
+		credentialsLease = creds.LeaseDuration
+	}
+
 	clickhouseClient, err := client.NewClickHouseClient(context.Background(), sinkConfig.ClickHouseConnectionParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clickhouse client: %w", err)
@@ -110,18 +240,35 @@ func NewClickHouseSink(
 		return nil, fmt.Errorf("invalid max batch size, should be > 0: %d", sinkConfig.Batch.MaxBatchSize)
 	}
 
+	if sinkConfig.Batch.WarmUp.Duration() < 0 {
+		return nil, fmt.Errorf("invalid warm-up, should be >= 0: %s", sinkConfig.Batch.WarmUp)
+	}
+
 	maxDelayTime := internal.SinkDefaultBatchMaxDelayTime
 	if sinkConfig.Batch.MaxDelayTime.Duration() != 0 {
 		maxDelayTime = sinkConfig.Batch.MaxDelayTime.Duration()
 	}
 
-	// Set worker pool size to GOMAXPROCS
-	workerPoolSize := runtime.GOMAXPROCS(0) - 2 // leave 2 for the main thread, IO, etc
-	if workerPoolSize < 1 {
-		workerPoolSize = 1
+	var errorTable *errorTableWriter
+	if sinkConfig.ErrorTable != "" {
+		errorTable, err = newErrorTableWriter(clickhouseClient, sinkConfig, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create error table writer: %w", err)
+		}
+	}
+
+	if sinkConfig.Staging != nil && stagingStore == nil {
+		return nil, errors.New("staging store is required for sink staging")
+	}
+
+	workerPoolSize := decodeWorkerCount(decodeWorkers)
+
+	writers := make([]*sinkWriter, max(sinkConfig.WriterConcurrency, 1))
+	for i := range writers {
+		writers[i] = newSinkWriter(i, sinkConfig.Batch.MaxBatchSize)
 	}
 
-	return &ClickHouseSink{
+	chSink := &ClickHouseSink{
 		client:                clickhouseClient,
 		streamConsumer:        streamConsumer,
 		mapper:                mapper,
@@ -129,20 +276,42 @@ func NewClickHouseSink(
 		sinkConfig:            sinkConfig,
 		log:                   log,
 		dlqPublisher:          dlqPublisher,
+		errorTable:            errorTable,
 		clickhouseQueryConfig: clickhouseQueryConfig,
 		streamSourceID:        streamSourceID,
 		maxBatchSize:          sinkConfig.Batch.MaxBatchSize,
 		maxDelayTime:          maxDelayTime,
-		messageBuffer:         make([]jetstream.Msg, 0, sinkConfig.Batch.MaxBatchSize),
+		warmUp:                newWarmUp(sinkConfig.Batch.WarmUp.Duration()),
+		writers:               writers,
 		workerPoolSize:        workerPoolSize,
-	}, nil
+		shedder:               newLoadShedder(),
+		stagingStore:          stagingStore,
+		pipelineID:            pipelineID,
+		credentials:           credentials,
+		credentialsLease:      credentialsLease,
+	}
+	chSink.staging.Store(sinkConfig.Staging != nil)
+
+	return chSink, nil
+}
+
+// decodeWorkerCount returns the size of the worker pool that parses, maps and
+// appends messages into column shards. It defaults to GOMAXPROCS: the flush goroutine only waits while
+// the workers run, so no core has to be kept free for it.
+func decodeWorkerCount(configured int) int {
+	if configured > 0 {
+		return configured
+	}
+	return max(runtime.GOMAXPROCS(0), 1)
 }
 
 func (ch *ClickHouseSink) Start(ctx context.Context) error {
 	ch.log.InfoContext(ctx, "ClickHouse sink started",
 		"max_batch_size", ch.maxBatchSize,
 		"max_delay_time", ch.maxDelayTime,
-		"worker_pool_size", ch.workerPoolSize)
+		"worker_pool_size", ch.workerPoolSize,
+		"writers", len(ch.writers),
+		"warm_up", ch.sinkConfig.Batch.WarmUp.Duration())
 
 	defer ch.log.InfoContext(ctx, "ClickHouse sink stopped")
 	defer ch.clearConn()
@@ -151,10 +320,19 @@ func (ch *ClickHouseSink) Start(ctx context.Context) error {
 	ch.cancel = cancel
 	defer cancel()
 
+	ch.warmUp.begin(time.Now())
+
+	if ch.errorTable != nil {
+		// Rejected rows still reach the DLQ, so a missing error table is not fatal
+		err := ch.errorTable.createTable(ctx)
+		if err != nil {
+			ch.log.WarnContext(ctx, "failed to create sink error table", "table", ch.sinkConfig.ErrorTable, "error", err)
+		}
+	}
+
 	// Initialize and start a worker pool
 	ch.workerCtx, ch.workerCancel = context.WithCancel(context.Background())
 	ch.workerJobChan = make(chan workerJob, ch.workerPoolSize)
-	ch.workerResultChan = make(chan workerResult, ch.workerPoolSize)
 	ch.startWorkerPool()
 	defer ch.stopWorkerPool()
 
@@ -163,38 +341,64 @@ func (ch *ClickHouseSink) Start(ctx context.Context) error {
 	defer ch.bufferFlushTicker.Stop()
 	go ch.flushTickerLoop(ctx)
 
+	// With several writers full buffers are flushed by the writer loops, so
+	// the consume callback keeps dispatching to the other writers meanwhile
+	if len(ch.writers) > 1 {
+		for _, w := range ch.writers {
+			go ch.writerLoop(ctx, w)
+		}
+	}
+
 	// Message handler
-	messageHandler := func(msg jetstream.Msg) {
-		ch.bufferMu.Lock()
-		wasEmpty := len(ch.messageBuffer) == 0
-		ch.messageBuffer = append(ch.messageBuffer, msg)
-		bufferSize := len(ch.messageBuffer)
-		shouldFlush := bufferSize >= ch.maxBatchSize
-		ch.bufferMu.Unlock()
+	ch.messageHandler = func(msg jetstream.Msg) {
+		w := ch.writers[writerIndex(msg.Subject(), len(ch.writers))]
 
-		// Track batch start time
-		if wasEmpty {
-			ch.bufferMu.Lock()
-			ch.lastBatchStartTime = time.Now()
-			ch.bufferMu.Unlock()
+		// Flush immediately if batch size reached
+		if w.add(msg, ch.batchSize()) {
+			if len(ch.writers) == 1 {
+				ch.flushWriter(ctx, w)
+				return
+			}
+			select {
+			case w.flushCh <- struct{}{}:
+			default:
+			}
 		}
+	}
 
-		// Flush immediately if batch size reached
-		if shouldFlush {
-			ch.flushBuffer(ctx)
+	// A sink paused through the API starts paused
+	var paused bool
+	if ch.stagingStore != nil {
+		var err error
+		paused, err = ch.pausedThroughAPI(ctx)
+		if err != nil {
+			ch.log.WarnContext(ctx, "failed to check whether the sink is paused", "error", err)
+		}
+		if paused {
+			ch.pauseConsume(ctx, pauseReasonAPI)
+			ch.log.InfoContext(ctx, "Sink starts paused")
 		}
+		go ch.pauseLoop(ctx, paused)
 	}
 
 	// Durable pull consumer
-	cc, err := ch.streamConsumer.Consume(
-		messageHandler,
-		jetstream.PullMaxMessages(ch.maxBatchSize*ch.workerPoolSize), // Pull in batches
-	)
+	err := ch.consume(ctx, ch.pullMaxMessages(false))
 	if err != nil {
 		return fmt.Errorf("failed to start consuming: %w", err)
 	}
-	ch.consumeContext = cc
-	defer cc.Stop()
+	defer ch.stopConsume()
+
+	go ch.sheddingLoop(ctx)
+
+	if ch.credentials != nil {
+		go ch.credentialsLoop(ctx, ch.credentialsLease)
+	}
+
+	if ch.staging.Load() {
+		ch.log.InfoContext(ctx, "Sink writes to the staging table until confirmed",
+			"staging_table", ch.sinkConfig.StagingTable())
+		go ch.stagingLoop(ctx)
+	}
 
 	// Wait for shutdown
 	<-ctx.Done()
@@ -217,24 +421,102 @@ func (ch *ClickHouseSink) flushTickerLoop(ctx context.Context) {
 	}
 }
 
-// flushBuffer atomically extracts and processes buffered messages
+// writerLoop flushes the buffer of a writer whenever it is full.
+func (ch *ClickHouseSink) writerLoop(ctx context.Context, w *sinkWriter) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.flushCh:
+			ch.flushWriter(ctx, w)
+		}
+	}
+}
+
+// flushBuffer flushes the buffers of all writers, in parallel when there are
+// several of them.
 func (ch *ClickHouseSink) flushBuffer(ctx context.Context) {
-	ch.bufferMu.Lock()
-	if len(ch.messageBuffer) == 0 {
-		ch.bufferMu.Unlock()
+	if len(ch.writers) == 1 {
+		ch.flushWriter(ctx, ch.writers[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range ch.writers {
+		wg.Go(func() { ch.flushWriter(ctx, w) })
+	}
+	wg.Wait()
+}
+
+// flushWriter atomically extracts and processes the buffered messages of a writer
+func (ch *ClickHouseSink) flushWriter(ctx context.Context, w *sinkWriter) {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	ch.paceFlush(ctx, w)
+
+	w.bufferMu.Lock()
+	if len(w.messageBuffer) == 0 {
+		w.bufferMu.Unlock()
 		return
 	}
 
-	// Extract messages atomically
-	messages := make([]jetstream.Msg, len(ch.messageBuffer))
-	copy(messages, ch.messageBuffer)
-	ch.messageBuffer = ch.messageBuffer[:0] // Clear buffer
-	ch.bufferMu.Unlock()
+	// Extract messages atomically. While warming up the batch is capped, the
+	// rest of the buffer goes with the next flushes.
+	n := len(w.messageBuffer)
+	if ch.warmUp.active(time.Now()) {
+		n = min(n, ch.batchSize())
+	}
+	messagesBuf := flushMessagesPool.Get(ctx)
+	*messagesBuf = append(*messagesBuf, w.messageBuffer[:n]...)
+	messages := *messagesBuf
+	remaining := copy(w.messageBuffer, w.messageBuffer[n:])
+	clear(w.messageBuffer[remaining:])
+	w.messageBuffer = w.messageBuffer[:remaining]
+
+	// Calculate NATS read time if we tracked the start time
+	var natsReadDuration time.Duration
+	if !w.lastBatchStartTime.IsZero() {
+		natsReadDuration = time.Since(w.lastBatchStartTime)
+		w.lastBatchStartTime = time.Time{} // Reset
+		if remaining > 0 {
+			w.lastBatchStartTime = time.Now()
+		}
+	}
+	w.bufferMu.Unlock()
 
 	// Process batch
-	err := ch.flushEvents(ctx, messages)
+	w.lastFlush = time.Now()
+	err := ch.flushEvents(ctx, w.id, messages, natsReadDuration)
 	if err != nil {
-		ch.log.ErrorContext(ctx, "failed to flush buffer", "error", err, "batch_size", len(messages))
+		ch.log.ErrorContext(ctx, "failed to flush buffer", "error", err, "writer", w.id, "batch_size", len(messages))
+	}
+
+	// A failed or timed out flush can leave workers reading the slice,
+	// in that case it is left to the GC instead of being reused.
+	if ch.jobsInFlight.Load() == 0 {
+		flushMessagesPool.Put(messagesBuf)
+	}
+}
+
+// paceFlush waits, while the sink warms up, until the last flush of the
+// writer is a flush interval old. flushMu must be held.
+func (ch *ClickHouseSink) paceFlush(ctx context.Context, w *sinkWriter) {
+	now := time.Now()
+	if w.lastFlush.IsZero() || !ch.warmUp.active(now) {
+		return
+	}
+
+	wait := internal.SinkWarmUpFlushInterval - now.Sub(w.lastFlush)
+	if wait <= 0 {
+		return
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
 	}
 }
 
@@ -242,9 +524,10 @@ func (ch *ClickHouseSink) handleShutdown(ctx context.Context) error {
 	ch.log.InfoContext(ctx, "ClickHouse sink shutting down")
 
 	// Stop consuming new messages
-	if ch.consumeContext != nil {
-		ch.consumeContext.Stop()
-	}
+	ch.stopConsume()
+
+	// The remaining messages are flushed at once, without pacing
+	ch.warmUp.finish()
 
 	// Flush any remaining messages
 	ch.flushBuffer(ctx)
@@ -252,6 +535,358 @@ func (ch *ClickHouseSink) handleShutdown(ctx context.Context) error {
 	return nil
 }
 
+// pullMaxMessages returns how many messages the consumer keeps buffered: a
+// batch per worker normally, a single batch while shedding load.
+func (ch *ClickHouseSink) pullMaxMessages(shedding bool) int {
+	if shedding {
+		return ch.batchSize()
+	}
+	return ch.batchSize() * ch.workerPoolSize
+}
+
+// batchSize returns the size a writer flushes at, ramped up while the sink
+// warms up.
+func (ch *ClickHouseSink) batchSize() int {
+	return ch.warmUp.scale(ch.maxBatchSize, time.Now())
+}
+
+// consume starts consuming with the given pull size. A running consume
+// context is drained first, so its buffered messages are still handled.
+// While consuming is paused the pull size is only kept for resumeConsume.
+func (ch *ClickHouseSink) consume(ctx context.Context, pullMaxMessages int) error {
+	ch.consumeMu.Lock()
+	defer ch.consumeMu.Unlock()
+
+	ch.pullSize = pullMaxMessages
+	if ctx.Err() != nil || len(ch.pausedBy) > 0 {
+		return nil
+	}
+
+	if !ch.drainConsume(ctx) {
+		return nil
+	}
+
+	cc, err := ch.streamConsumer.Consume(ch.messageHandler, jetstream.PullMaxMessages(pullMaxMessages))
+	if err != nil {
+		return fmt.Errorf("consume: %w", err)
+	}
+	ch.consumeContext = cc
+
+	return nil
+}
+
+// drainConsume drains the running consume context and waits until it is
+// closed. It reports false when ctx is done first. consumeMu must be held.
+func (ch *ClickHouseSink) drainConsume(ctx context.Context) bool {
+	if ch.consumeContext == nil {
+		return true
+	}
+
+	ch.consumeContext.Drain()
+	select {
+	case <-ch.consumeContext.Closed():
+		ch.consumeContext = nil
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Reasons consuming is paused for
+const (
+	pauseReasonStaging = "staging"
+	pauseReasonAPI     = "api"
+)
+
+// pauseConsume stops pulling messages, unacked messages stay in the stream.
+// Consuming stays paused until resumed for every reason it was paused for.
+func (ch *ClickHouseSink) pauseConsume(ctx context.Context, reason string) {
+	ch.consumeMu.Lock()
+	defer ch.consumeMu.Unlock()
+
+	if ch.pausedBy == nil {
+		ch.pausedBy = make(map[string]bool)
+	}
+	ch.pausedBy[reason] = true
+	ch.drainConsume(ctx)
+}
+
+// resumeConsume restarts consuming with the last requested pull size once no
+// other reason keeps it paused.
+func (ch *ClickHouseSink) resumeConsume(ctx context.Context, reason string) error {
+	ch.consumeMu.Lock()
+	delete(ch.pausedBy, reason)
+	pullSize := ch.pullSize
+	ch.consumeMu.Unlock()
+
+	return ch.consume(ctx, pullSize)
+}
+
+func (ch *ClickHouseSink) stopConsume() {
+	ch.consumeMu.Lock()
+	defer ch.consumeMu.Unlock()
+
+	if ch.consumeContext != nil {
+		ch.consumeContext.Stop()
+	}
+}
+
+// sheddingLoop reduces the pull size while the process is under memory or
+// CPU pressure, so the sink slows down instead of being OOM-killed.
+func (ch *ClickHouseSink) sheddingLoop(ctx context.Context) {
+	ticker := time.NewTicker(internal.SinkSheddingCheckInterval)
+	defer ticker.Stop()
+
+	var sheddingStart time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if !sheddingStart.IsZero() {
+				observability.RecordSinkSheddingStop(ctx)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		changed, reason := ch.shedder.update()
+		if !changed {
+			ch.rampConsume(ctx)
+			continue
+		}
+
+		shedding := ch.shedder.shedding
+		err := ch.consume(ctx, ch.pullMaxMessages(shedding))
+		if err != nil {
+			ch.log.ErrorContext(ctx, "failed to restart consuming after shedding state change", "error", err)
+			ch.Stop(false)
+			return
+		}
+
+		if shedding {
+			sheddingStart = time.Now()
+			observability.RecordSinkSheddingStart(ctx, reason)
+			ch.log.WarnContext(ctx, "Sink degraded, shedding load",
+				"reason", reason,
+				"pull_max_messages", ch.pullMaxMessages(true))
+			continue
+		}
+
+		observability.RecordSinkSheddingStop(ctx)
+		ch.log.InfoContext(ctx, "Sink recovered, stopped shedding load",
+			"shedding_duration", time.Since(sheddingStart),
+			"pull_max_messages", ch.pullMaxMessages(false))
+		sheddingStart = time.Time{}
+	}
+}
+
+// rampConsume grows the pull size while the sink warms up. It runs on the
+// shedding loop, which owns the shedding state.
+func (ch *ClickHouseSink) rampConsume(ctx context.Context) {
+	if ch.warmUp == nil {
+		return
+	}
+
+	pullSize := ch.pullMaxMessages(ch.shedder.shedding)
+	ch.consumeMu.Lock()
+	current := ch.pullSize
+	ch.consumeMu.Unlock()
+	if pullSize == current {
+		return
+	}
+
+	err := ch.consume(ctx, pullSize)
+	if err != nil {
+		ch.log.ErrorContext(ctx, "failed to restart consuming while warming up", "error", err)
+		ch.Stop(false)
+		return
+	}
+
+	if !ch.warmUp.active(time.Now()) {
+		ch.log.InfoContext(ctx, "Sink warmed up", "pull_max_messages", pullSize)
+	}
+}
+
+// stagingLoop polls the pipeline config until the staging is confirmed and
+// then switches the sink to the sink table. Once the staging duration has
+// passed, consuming is paused until the confirmation.
+func (ch *ClickHouseSink) stagingLoop(ctx context.Context) {
+	ticker := time.NewTicker(internal.SinkStagingCheckInterval)
+	defer ticker.Stop()
+
+	paused := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cfg, err := ch.stagingStore.GetPipeline(ctx, ch.pipelineID)
+		if err != nil {
+			ch.log.WarnContext(ctx, "failed to check pipeline staging", "error", err)
+			continue
+		}
+
+		if cfg.Sink.Staging == nil {
+			ch.staging.Store(false)
+			ch.log.InfoContext(ctx, "Pipeline staging confirmed, sink switched to the sink table",
+				"table", ch.client.GetTableName())
+			if !paused {
+				return
+			}
+			err = ch.resumeConsume(ctx, pauseReasonStaging)
+			if err != nil {
+				ch.log.ErrorContext(ctx, "failed to resume consuming after staging confirmation", "error", err)
+				ch.Stop(false)
+			}
+			return
+		}
+
+		duration := cfg.Sink.Staging.Duration.Duration()
+		if !paused && duration > 0 && time.Since(cfg.CreatedAt) >= duration {
+			ch.pauseConsume(ctx, pauseReasonStaging)
+			// Messages pulled before the pause are still written to staging
+			ch.flushBuffer(ctx)
+			paused = true
+			ch.log.InfoContext(ctx, "Staging duration reached, sink paused until the staging is confirmed",
+				"staging_table", ch.sinkConfig.StagingTable(),
+				"staging_duration", duration)
+		}
+	}
+}
+
+// credentialsLoop connects the ClickHouse client with new credentials once
+// the credentials it connected with reach SinkCredentialsRefreshRatio of
+// their lease. A failed refresh is retried until the lease expires, inserts
+// then fail and the batches are retried as on any connection error.
+func (ch *ClickHouseSink) credentialsLoop(ctx context.Context, lease time.Duration) {
+	if lease <= 0 {
+		return
+	}
+
+	issued := time.Now()
+	timer := time.NewTimer(credentialsRefreshDelay(lease))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		next, err := ch.refreshCredentials(ctx)
+		if err != nil {
+			ch.log.ErrorContext(ctx, "failed to refresh clickhouse credentials",
+				"error", err,
+				"lease_expires_in", time.Until(issued.Add(lease)).Round(time.Second))
+			timer.Reset(internal.SinkCredentialsRetryInterval)
+			continue
+		}
+		if next <= 0 {
+			return
+		}
+
+		issued, lease = time.Now(), next
+		timer.Reset(credentialsRefreshDelay(lease))
+	}
+}
+
+// refreshCredentials connects the client with new credentials and returns
+// their lease. The flushes of all writers wait meanwhile, so no batch is in
+// flight on the connection of the previous credentials when it is closed.
+func (ch *ClickHouseSink) refreshCredentials(ctx context.Context) (time.Duration, error) {
+	creds, err := ch.credentials.Credentials(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get credentials: %w", err)
+	}
+
+	for _, w := range ch.writers {
+		w.flushMu.Lock()
+	}
+	defer func() {
+		for _, w := range ch.writers {
+			w.flushMu.Unlock()
+		}
+	}()
+
+	err = ch.client.UpdateCredentials(ctx, creds.Username, creds.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	ch.log.InfoContext(ctx, "ClickHouse credentials refreshed",
+		"username", creds.Username,
+		"lease", creds.LeaseDuration)
+	return creds.LeaseDuration, nil
+}
+
+// credentialsRefreshDelay returns how long after they were issued credentials
+// with the given lease are refreshed.
+func credentialsRefreshDelay(lease time.Duration) time.Duration {
+	return max(time.Duration(float64(lease)*internal.SinkCredentialsRefreshRatio), internal.SinkCredentialsRetryInterval)
+}
+
+// pausedThroughAPI reports whether the sink is paused on its own through the
+// API.
+func (ch *ClickHouseSink) pausedThroughAPI(ctx context.Context) (bool, error) {
+	cfg, err := ch.stagingStore.GetPipeline(ctx, ch.pipelineID)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(cfg.PausedComponents, internal.RoleSink), nil
+}
+
+// pauseLoop polls the pipeline config and pauses consuming while the sink is
+// paused through the API, for ClickHouse maintenance windows. The messages
+// wait in the NATS stream meanwhile and the rest of the pipeline keeps
+// running.
+func (ch *ClickHouseSink) pauseLoop(ctx context.Context, paused bool) {
+	ticker := time.NewTicker(internal.SinkPauseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pause, err := ch.pausedThroughAPI(ctx)
+		if err != nil {
+			ch.log.WarnContext(ctx, "failed to check whether the sink is paused", "error", err)
+			continue
+		}
+		if pause == paused {
+			continue
+		}
+
+		if pause {
+			ch.pauseConsume(ctx, pauseReasonAPI)
+			// Messages pulled before the pause are still written
+			ch.flushBuffer(ctx)
+			ch.log.InfoContext(ctx, "Sink paused")
+		} else {
+			err = ch.resumeConsume(ctx, pauseReasonAPI)
+			if err != nil {
+				ch.log.ErrorContext(ctx, "failed to resume consuming", "error", err)
+				ch.Stop(false)
+				return
+			}
+			ch.log.InfoContext(ctx, "Sink resumed")
+		}
+		paused = pause
+	}
+}
+
+// tableName returns the table batches are inserted into.
+func (ch *ClickHouseSink) tableName() string {
+	if ch.staging.Load() {
+		return ch.sinkConfig.StagingTable()
+	}
+	return ch.client.GetTableName()
+}
+
 // startWorkerPool starts N worker goroutines to process PrepareValues in parallel
 func (ch *ClickHouseSink) startWorkerPool() {
 	ch.log.Info("Starting worker pool", "worker_count", ch.workerPoolSize)
@@ -270,9 +905,6 @@ func (ch *ClickHouseSink) stopWorkerPool() {
 		close(ch.workerJobChan)
 	}
 	ch.workerWg.Wait()
-	if ch.workerResultChan != nil {
-		close(ch.workerResultChan)
-	}
 	ch.log.Info("Worker pool stopped")
 }
 
@@ -287,11 +919,13 @@ func (ch *ClickHouseSink) worker() {
 				return
 			}
 
-			processed := make([]processedMessage, 0, len(job.messages))
+			processedBuf := processedMessagePool.Get(ch.workerCtx)
+			processed := (*processedBuf)[:0]
 			var jobErr error
 
 			// Cache config per schema version to avoid repeated GetSinkConfig calls
 			workerConfigsCache := make(map[string]map[string]models.Mapping, 1)
+			shards := make(map[string]*clickhouse.Shard, 1)
 
 			for _, msg := range job.messages {
 				// Get metadata
@@ -337,40 +971,96 @@ func (ch *ClickHouseSink) worker() {
 					continue
 				}
 
+				shard, row := ch.appendToShard(shards, schemaVersionID, values)
 				processed = append(processed, processedMessage{
 					metadata:        metadata,
 					values:          values,
 					msg:             msg,
 					schemaVersionID: schemaVersionID,
+					shard:           shard,
+					row:             row,
 					err:             nil,
 				})
 			}
 
-			// Send a result back to the parent routine
-			ch.workerResultChan <- workerResult{
-				jobID:     job.jobID,
-				processed: processed,
-				err:       jobErr,
+			ch.jobsInFlight.Add(-1)
+
+			// Send a result back to the flush of the job
+			*processedBuf = processed
+			job.results <- workerResult{
+				jobID:        job.jobID,
+				processed:    processed,
+				processedBuf: processedBuf,
+				err:          jobErr,
 			}
 		}
 	}
 }
 
-func (ch *ClickHouseSink) flushEvents(ctx context.Context, messages []jetstream.Msg) error {
-	// Calculate NATS read time if we tracked the start time
-	var natsReadDuration time.Duration
-	ch.bufferMu.Lock()
-	if !ch.lastBatchStartTime.IsZero() {
-		natsReadDuration = time.Since(ch.lastBatchStartTime)
-		ch.lastBatchStartTime = time.Time{} // Reset
+// appendToShard appends values into the column shard of the job for the
+// schema version. It returns a nil shard when no batch of the version was
+// created yet or the values do not fit the columns: the flush appends the
+// values itself then, so that a bad row gets the DLQ handling of the flush.
+func (ch *ClickHouseSink) appendToShard(shards map[string]*clickhouse.Shard, schemaVersionID string, values []any) (*clickhouse.Shard, int) {
+	shard, ok := shards[schemaVersionID]
+	if !ok {
+		if layout := ch.shardLayout(schemaVersionID); layout != nil {
+			var err error
+			shard, err = layout.NewShard()
+			if err != nil {
+				ch.log.Debug("failed to create column shard", "schema_version_id", schemaVersionID, "error", err)
+				shard = nil
+			}
+		}
+		shards[schemaVersionID] = shard
+	}
+	if shard == nil {
+		return nil, 0
+	}
+
+	row, err := shard.Append(values...)
+	if err != nil {
+		return nil, 0
 	}
-	ch.bufferMu.Unlock()
 
+	return shard, row
+}
+
+func (ch *ClickHouseSink) shardLayout(schemaVersionID string) *clickhouse.ShardLayout {
+	ch.shardLayoutsMu.RLock()
+	defer ch.shardLayoutsMu.RUnlock()
+
+	return ch.shardLayouts[schemaVersionID]
+}
+
+func (ch *ClickHouseSink) setShardLayout(schemaVersionID string, layout *clickhouse.ShardLayout) {
+	ch.shardLayoutsMu.Lock()
+	defer ch.shardLayoutsMu.Unlock()
+
+	if ch.shardLayouts == nil {
+		ch.shardLayouts = make(map[string]*clickhouse.ShardLayout)
+	}
+	ch.shardLayouts[schemaVersionID] = layout
+}
+
+// appendToBatch appends the row of a processed message to the batch, from
+// the column shard of its worker when it has one.
+func appendToBatch(batch clickhouse.Batch, procMsg *processedMessage) error {
+	if procMsg.shard != nil {
+		return batch.AppendShardRow(procMsg.metadata.Sequence.Stream, procMsg.shard, procMsg.row)
+	}
+	return batch.Append(procMsg.metadata.Sequence.Stream, procMsg.values...)
+}
+
+func (ch *ClickHouseSink) flushEvents(ctx context.Context, writer int, messages []jetstream.Msg, natsReadDuration time.Duration) error {
 	ch.log.InfoContext(ctx, "Starting batch processing",
+		"writer", writer,
 		"message_count", len(messages),
 		"nats_read_duration_ms", natsReadDuration.Milliseconds())
 
+	flushStart := time.Now()
 	err := ch.sendBatch(ctx, messages)
+	observability.RecordSinkFlushDuration(ctx, time.Since(flushStart).Seconds())
 	if err != nil {
 		ch.log.ErrorContext(ctx, "failed to send batch to ClickHouse", "error", err)
 		dlqFlushErr := ch.flushFailedBatch(ctx, messages, err)
@@ -379,9 +1069,31 @@ func (ch *ClickHouseSink) flushEvents(ctx context.Context, messages []jetstream.
 		}
 	}
 
+	ch.flushErrorTable(ctx)
+
 	return nil
 }
 
+// flushErrorTable writes the DLQ messages of the last flush to the error table.
+func (ch *ClickHouseSink) flushErrorTable(ctx context.Context) {
+	if ch.errorTable == nil {
+		return
+	}
+
+	written, err := ch.errorTable.flush(ctx)
+	if err != nil {
+		ch.log.WarnContext(ctx, "failed to write rejected rows to the error table",
+			"table", ch.sinkConfig.ErrorTable,
+			"error", err)
+		return
+	}
+	if written > 0 {
+		ch.log.DebugContext(ctx, "Rejected rows written to the error table",
+			"table", ch.sinkConfig.ErrorTable,
+			"row_count", written)
+	}
+}
+
 // write failed batch to dlq
 func (ch *ClickHouseSink) flushFailedBatch(
 	ctx context.Context,
@@ -411,6 +1123,23 @@ func (ch *ClickHouseSink) flushFailedBatch(
 	return nil
 }
 
+// jetstreamTraceCarriers returns the headers of the messages for the trace
+// propagator, nil when tracing is disabled.
+func jetstreamTraceCarriers(messages []jetstream.Msg) []observability.TraceCarrier {
+	if !observability.TracingEnabled() {
+		return nil
+	}
+
+	carriers := make([]observability.TraceCarrier, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Headers() == nil {
+			continue
+		}
+		carriers = append(carriers, msg.Headers())
+	}
+	return carriers
+}
+
 func (ch *ClickHouseSink) sendBatch(ctx context.Context, messages []jetstream.Msg) error {
 	if len(messages) == 0 {
 		return nil
@@ -446,7 +1175,18 @@ func (ch *ClickHouseSink) sendBatch(ctx context.Context, messages []jetstream.Ms
 			continue
 		}
 
+		spans := observability.StartMessageSpans(
+			ctx,
+			observability.SpanClickHouseInsert,
+			jetstreamTraceCarriers(schemaData.messages),
+			attribute.String("db.system", "clickhouse"),
+			attribute.String("db.collection.name", ch.tableName()),
+			attribute.Int("db.operation.batch.size", size),
+		)
+		insertStart := time.Now()
 		err = schemaData.batch.Send(ctx)
+		observability.RecordProcessingDurationWithStage(spans.Context(ctx), "sink", "insert", time.Since(insertStart).Seconds())
+		spans.End(err)
 		if err != nil {
 			classification := sinkerrors.Classify(err)
 			errorName := sinkerrors.ErrorName(err)
@@ -455,6 +1195,9 @@ func (ch *ClickHouseSink) sendBatch(ctx context.Context, messages []jetstream.Ms
 			if classification == sinkerrors.Retryable {
 				ch.log.WarnContext(ctx, "retryable ClickHouse error, NACKing batch",
 					"schema_version_id", schemaVersionID,
+					"query_id", schemaData.insert.queryID,
+					"first_sequence", schemaData.insert.firstSequence,
+					"last_sequence", schemaData.insert.lastSequence,
 					"error", err,
 					"batch_size", len(schemaData.messages))
 				ch.nakMessages(ctx, schemaData.messages)
@@ -466,6 +1209,9 @@ func (ch *ClickHouseSink) sendBatch(ctx context.Context, messages []jetstream.Ms
 
 			ch.log.ErrorContext(ctx, "failed to send schema batch, writing to dlq",
 				"schema_version_id", schemaVersionID,
+				"query_id", schemaData.insert.queryID,
+				"first_sequence", schemaData.insert.firstSequence,
+				"last_sequence", schemaData.insert.lastSequence,
 				"error", err,
 				"classification", classification.String(),
 				"batch_size", len(schemaData.messages))
@@ -488,7 +1234,11 @@ func (ch *ClickHouseSink) sendBatch(ctx context.Context, messages []jetstream.Ms
 		totalSent += size
 		ch.log.DebugContext(ctx, "Data sent successfully to ClickHouse",
 			"schema_version_id", schemaVersionID,
+			"query_id", schemaData.insert.queryID,
+			"first_sequence", schemaData.insert.firstSequence,
+			"last_sequence", schemaData.insert.lastSequence,
 			"message_count", size)
+		ch.recordProfileEvents(ctx, schemaData.insert)
 
 		observability.RecordClickHouseWrite(ctx, "sink", int64(size))
 		observability.RecordProcessorMessages(ctx, "sink", "success", int64(size))
@@ -555,6 +1305,10 @@ func (ch *ClickHouseSink) createCHBatches(
 		chunkSize = 1
 	}
 
+	// Results are buffered so that workers never block on a flush that
+	// stopped collecting them
+	results := make(chan workerResult, (len(messages)+chunkSize-1)/chunkSize)
+
 	// Send jobs to workers
 	numJobs := 0
 	for i := 0; i < len(messages); i += chunkSize {
@@ -567,10 +1321,13 @@ func (ch *ClickHouseSink) createCHBatches(
 			messages:       messages[i:end],
 			jobID:          numJobs,
 			streamSourceID: ch.streamSourceID,
+			results:        results,
 		}
 
+		ch.jobsInFlight.Add(1)
 		select {
 		case <-ctx.Done():
+			ch.jobsInFlight.Add(-1)
 			return nil, ctx.Err()
 		case ch.workerJobChan <- job:
 			numJobs++
@@ -585,16 +1342,21 @@ func (ch *ClickHouseSink) createCHBatches(
 		defer cancel()
 	}
 
-	results := make(map[int]workerResult, numJobs)
+	jobResults := make(map[int]workerResult, numJobs)
+	defer func() {
+		for _, result := range jobResults {
+			processedMessagePool.Put(result.processedBuf)
+		}
+	}()
 	for i := 0; i < numJobs; i++ {
 		select {
 		case <-collectCtx.Done():
 			return nil, fmt.Errorf("timeout waiting for worker results: %w", collectCtx.Err())
-		case result := <-ch.workerResultChan:
+		case result := <-results:
 			if result.err != nil {
 				return nil, fmt.Errorf("worker job %d failed: %w", result.jobID, result.err)
 			}
-			results[result.jobID] = result
+			jobResults[result.jobID] = result
 		}
 	}
 
@@ -602,10 +1364,12 @@ func (ch *ClickHouseSink) createCHBatches(
 
 	schemaMappingTotalTime := time.Since(schemaMappingStartTime)
 
+	inserts := ch.batchInserts(jobResults)
+
 	// Process results in order and append to batch
 	appendedBySchema := make(map[string][]*processedMessage)
 	for jobID := 0; jobID < numJobs; jobID++ {
-		result := results[jobID]
+		result := jobResults[jobID]
 		for _, procMsg := range result.processed {
 			// If there was an error during processing, push to DLQ and skip
 			if procMsg.err != nil {
@@ -622,7 +1386,8 @@ func (ch *ClickHouseSink) createCHBatches(
 			// Append to batch for the corresponding schema version
 			batchedData, exists := batches[procMsg.schemaVersionID]
 			if !exists {
-				batch, err := ch.createBatchForSchemaVersion(ctx, procMsg.schemaVersionID)
+				insert := inserts[procMsg.schemaVersionID]
+				batch, err := ch.createBatchForSchemaVersion(ctx, procMsg.schemaVersionID, insert)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create batch for schema version %s: %w", procMsg.schemaVersionID, err)
 				}
@@ -630,11 +1395,12 @@ func (ch *ClickHouseSink) createCHBatches(
 				batchedData = &schemaBatch{
 					batch:    batch,
 					messages: make([]jetstream.Msg, 0),
+					insert:   insert,
 				}
 				batches[procMsg.schemaVersionID] = batchedData
 			}
 
-			err := batchedData.batch.Append(procMsg.metadata.Sequence.Stream, procMsg.values...)
+			err := appendToBatch(batchedData.batch, &procMsg)
 			if err != nil {
 				if !errors.Is(err, clickhouse.ErrAlreadyExists) {
 					ch.log.Warn("Failed to append message to batch, pushing to DLQ",
@@ -646,14 +1412,14 @@ func (ch *ClickHouseSink) createCHBatches(
 					}
 
 					// try to recreate the batch and replay appended messages to avoid losing the whole batch due to one bad message
-					batch, err := ch.createBatchForSchemaVersion(ctx, procMsg.schemaVersionID)
+					batch, err := ch.createBatchForSchemaVersion(ctx, procMsg.schemaVersionID, batchedData.insert)
 					if err != nil {
 						return nil, fmt.Errorf("failed to recreate CH batch after append error: %w", err)
 					}
 					batchedData.batch = batch
 
 					for _, appended := range appendedBySchema[procMsg.schemaVersionID] {
-						err = batchedData.batch.Append(appended.metadata.Sequence.Stream, appended.values...)
+						err = appendToBatch(batchedData.batch, appended)
 						if err != nil {
 							return nil, fmt.Errorf("failed to replay CH batch after append error: %w", err)
 						}
@@ -698,7 +1464,25 @@ func (ch *ClickHouseSink) createCHBatches(
 	return batches, nil
 }
 
-func (ch *ClickHouseSink) createBatchForSchemaVersion(ctx context.Context, schemaVersionID string) (clickhouse.Batch, error) {
+// recordProfileEvents records the captured ProfileEvents of an insert.
+func (ch *ClickHouseSink) recordProfileEvents(ctx context.Context, insert batchInsert) {
+	if insert.profileEvents == nil {
+		return
+	}
+
+	for _, name := range insertProfileEvents {
+		observability.RecordClickHouseProfileEvent(ctx, name, insert.profileEvents[name])
+	}
+	ch.log.DebugContext(ctx, "ClickHouse insert profile events",
+		"query_id", insert.queryID,
+		"profile_events", insert.profileEvents)
+}
+
+// createBatchForSchemaVersion prepares an insert batch for the schema version
+// with the query_id of the insert. A non-empty dedupToken is sent as the
+// insert_deduplication_token, and the batch is sent as an async insert when
+// the sink is configured so.
+func (ch *ClickHouseSink) createBatchForSchemaVersion(ctx context.Context, schemaVersionID string, insert batchInsert) (clickhouse.Batch, error) {
 	columns, err := ch.mapper.GetColumnNames(schemaVersionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get column names for schema version %s: %w", schemaVersionID, err)
@@ -706,14 +1490,33 @@ func (ch *ClickHouseSink) createBatchForSchemaVersion(ctx context.Context, schem
 	query := fmt.Sprintf(
 		"INSERT INTO %s.%s (%s)",
 		quoteIdentifier(ch.client.GetDatabase()),
-		quoteIdentifier(ch.client.GetTableName()),
+		quoteIdentifier(ch.tableName()),
 		quoteIdentifiers(columns),
 	)
+	if insert.queryID != "" {
+		ctx = client.WithQueryID(ctx, insert.queryID)
+	}
+	settings := client.InsertSettings{DeduplicationToken: insert.dedupToken}
+	if ch.sinkConfig.AsyncInsert != nil {
+		settings.AsyncInsert = true
+		settings.WaitForAsyncInsert = ch.sinkConfig.AsyncInsert.Wait
+	}
+	ctx = client.WithInsertSettings(ctx, settings)
+	if insert.profileEvents != nil {
+		ctx = client.WithProfileEvents(ctx, insert.addProfileEvents)
+	}
 	batch, err := clickhouse.NewClickHouseBatch(ctx, ch.client, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create batch for schema version %s: %w", schemaVersionID, err)
 	}
 
+	// The workers of the next flushes append into shards of the columns
+	layout, err := batch.Layout()
+	if err != nil {
+		ch.log.WarnContext(ctx, "failed to get column layout of batch", "schema_version_id", schemaVersionID, "error", err)
+	}
+	ch.setShardLayout(schemaVersionID, layout)
+
 	return batch, nil
 }
 
@@ -736,7 +1539,8 @@ func (ch *ClickHouseSink) Stop(noWait bool) {
 }
 
 func (ch *ClickHouseSink) pushMsgToDLQ(ctx context.Context, orgMsg []byte, err error, reason string) error {
-	data, err := models.NewDLQMessage(internal.RoleSink, err.Error(), orgMsg).ToJSON()
+	dlqMsg := models.NewDLQMessage(internal.RoleSink, err.Error(), orgMsg).WithReason(reason)
+	data, err := dlqMsg.ToJSON()
 	if err != nil {
 		return fmt.Errorf("convert DLQ message to JSON: %w", err)
 	}
@@ -748,5 +1552,9 @@ func (ch *ClickHouseSink) pushMsgToDLQ(ctx context.Context, orgMsg []byte, err e
 
 	observability.RecordDLQWrite(ctx, "sink", reason, 1)
 
+	if ch.errorTable != nil {
+		ch.errorTable.add(dlqMsg)
+	}
+
 	return nil
 }
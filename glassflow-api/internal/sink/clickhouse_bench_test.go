@@ -0,0 +1,103 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/mapper"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// benchMsg is a jetstream.Msg with just enough behaviour for the worker path
+type benchMsg struct {
+	jetstream.Msg
+	data     []byte
+	headers  nats.Header
+	metadata *jetstream.MsgMetadata
+}
+
+func (m *benchMsg) Data() []byte                              { return m.data }
+func (m *benchMsg) Headers() nats.Header                      { return m.headers }
+func (m *benchMsg) Metadata() (*jetstream.MsgMetadata, error) { return m.metadata, nil }
+
+type benchConfigStore struct {
+	config map[string]models.Mapping
+}
+
+func (s benchConfigStore) GetSinkConfig(context.Context, string) (map[string]models.Mapping, error) {
+	return s.config, nil
+}
+
+// BenchmarkClickHouseSink_Workers measures the NATS message to mapped values
+// path of the worker pool for growing payloads. Only a few fields are mapped,
+// so bytes allocated per op should not grow with the payload size.
+func BenchmarkClickHouseSink_Workers(b *testing.B) {
+	const batchSize = 256
+
+	config := map[string]models.Mapping{
+		"id":      {SourceField: "id", SourceType: internal.KafkaTypeString, DestinationField: "id", DestinationType: internal.CHTypeString},
+		"country": {SourceField: "country", SourceType: internal.KafkaTypeString, DestinationField: "country", DestinationType: internal.CHTypeLCString},
+		"count":   {SourceField: "count", SourceType: internal.KafkaTypeInt, DestinationField: "count", DestinationType: internal.CHTypeInt64},
+	}
+
+	for _, padding := range []int{0, 4 << 10, 64 << 10} {
+		b.Run(fmt.Sprintf("payload_padding_%dKiB", padding>>10), func(b *testing.B) {
+			data := []byte(`{"id":"evt-123456","country":"DE","count":42,"blob":"` + strings.Repeat("x", padding) + `"}`)
+			headers := nats.Header{}
+			headers.Set(internal.SchemaVersionIDHeader, "v1")
+
+			messages := make([]jetstream.Msg, batchSize)
+			for i := range messages {
+				messages[i] = &benchMsg{
+					data:     data,
+					headers:  headers,
+					metadata: &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: uint64(i + 1)}},
+				}
+			}
+
+			ch := &ClickHouseSink{
+				mapper:         mapper.NewKafkaToClickHouseMapper(),
+				cfgStore:       benchConfigStore{config: config},
+				log:            slog.Default(),
+				workerPoolSize: 4,
+			}
+			ch.workerCtx, ch.workerCancel = context.WithCancel(context.Background())
+			ch.workerJobChan = make(chan workerJob, ch.workerPoolSize)
+			ch.startWorkerPool()
+			defer ch.stopWorkerPool()
+
+			chunkSize := batchSize / ch.workerPoolSize
+			results := make(chan workerResult, ch.workerPoolSize)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data) * batchSize))
+			for b.Loop() {
+				numJobs := 0
+				for i := 0; i < batchSize; i += chunkSize {
+					ch.jobsInFlight.Add(1)
+					ch.workerJobChan <- workerJob{messages: messages[i : i+chunkSize], jobID: numJobs, results: results}
+					numJobs++
+				}
+				for range numJobs {
+					result := <-results
+					if result.err != nil {
+						b.Fatal(result.err)
+					}
+					for _, processed := range result.processed {
+						if processed.err != nil {
+							b.Fatal(processed.err)
+						}
+					}
+					processedMessagePool.Put(result.processedBuf)
+				}
+			}
+		})
+	}
+}
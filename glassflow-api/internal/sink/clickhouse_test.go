@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+func TestDecodeWorkerCount(t *testing.T) {
+	require.Equal(t, runtime.GOMAXPROCS(0), decodeWorkerCount(0))
+	require.Equal(t, 3, decodeWorkerCount(3))
+}
+
+func TestWriterIndex(t *testing.T) {
+	require.Equal(t, 0, writerIndex("pipeline.input.1", 1))
+	require.Equal(t, 0, writerIndex("pipeline.input.1", 0))
+
+	subjects := []string{"pipeline.input.0", "pipeline.input.1", "pipeline.input.2", "pipeline.input.3"}
+	for _, subject := range subjects {
+		idx := writerIndex(subject, 4)
+		require.GreaterOrEqual(t, idx, 0)
+		require.Less(t, idx, 4)
+		require.Equal(t, idx, writerIndex(subject, 4), "a subject always goes to the same writer")
+	}
+}
+
+func TestCredentialsRefreshDelay(t *testing.T) {
+	require.Equal(t, time.Duration(0.67*float64(time.Hour)), credentialsRefreshDelay(time.Hour))
+	// Short leases are not refreshed in a busy loop
+	require.Equal(t, internal.SinkCredentialsRetryInterval, credentialsRefreshDelay(time.Second))
+}
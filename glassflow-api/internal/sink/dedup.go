@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"slices"
+)
+
+// insertDeduplicationToken returns the insert_deduplication_token of a batch.
+// It only depends on the schema version and the NATS stream sequences of the
+// batch, so a batch redelivered with the same messages after a crash or a
+// failed ack gets the same token and ClickHouse drops the repeated insert.
+// Only a retry of the same batch is covered: when redelivered messages are
+// batched differently, for example together with newer messages or after a
+// batch size change, the token differs and the rows are inserted again.
+func insertDeduplicationToken(schemaVersionID string, sequences []uint64) string {
+	sorted := slices.Clone(sequences)
+	slices.Sort(sorted)
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, seq := range sorted {
+		binary.BigEndian.PutUint64(buf[:], seq)
+		h.Write(buf[:])
+	}
+
+	return fmt.Sprintf("%s-%d-%d-%016x", schemaVersionID, sorted[0], sorted[len(sorted)-1], h.Sum64())
+}
+
+// batchSequences returns the NATS stream sequences of each schema version
+// batch, for the messages that were mapped without error.
+func batchSequences(results map[int]workerResult) map[string][]uint64 {
+	sequences := make(map[string][]uint64)
+	for _, result := range results {
+		for _, procMsg := range result.processed {
+			if procMsg.err != nil {
+				continue
+			}
+			sequences[procMsg.schemaVersionID] = append(sequences[procMsg.schemaVersionID], procMsg.metadata.Sequence.Stream)
+		}
+	}
+	return sequences
+}
@@ -0,0 +1,22 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertDeduplicationToken(t *testing.T) {
+	token := insertDeduplicationToken("v1", []uint64{12, 10, 11})
+	require.Regexp(t, `^v1-10-12-[0-9a-f]{16}$`, token)
+
+	// Order of the messages in the batch does not matter
+	require.Equal(t, token, insertDeduplicationToken("v1", []uint64{10, 11, 12}))
+
+	// Same range and size with different messages must not collide
+	require.NotEqual(t,
+		insertDeduplicationToken("v1", []uint64{1, 2, 4}),
+		insertDeduplicationToken("v1", []uint64{1, 3, 4}),
+	)
+	require.NotEqual(t, token, insertDeduplicationToken("v2", []uint64{10, 11, 12}))
+}
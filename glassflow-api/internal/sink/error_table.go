@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type errorTableClient interface {
+	Exec(ctx context.Context, query string, args ...any) error
+	PrepareBatch(ctx context.Context, query string) (driver.Batch, error)
+}
+
+// errorTableWriter collects the DLQ messages of a flush and inserts them into
+// the sink error table in one batch. The NATS DLQ stays the source of truth:
+// rows are written after the messages were published there.
+type errorTableWriter struct {
+	client      errorTableClient
+	createQuery string
+	insertQuery string
+	pipelineID  string
+
+	mu   sync.Mutex
+	rows []models.DLQMessage
+}
+
+func newErrorTableWriter(chClient errorTableClient, sinkConfig models.SinkComponentConfig, pipelineID string) (*errorTableWriter, error) {
+	createQuery, err := sinkConfig.ErrorTableQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return &errorTableWriter{
+		client:      chClient,
+		createQuery: createQuery,
+		insertQuery: fmt.Sprintf(
+			"INSERT INTO %s.%s (pipeline_id, component, reason, error, payload, timestamp)",
+			quoteIdentifier(sinkConfig.ClickHouseConnectionParams.Database),
+			quoteIdentifier(sinkConfig.ErrorTable),
+		),
+		pipelineID: pipelineID,
+	}, nil
+}
+
+// createTable creates the error table if it does not exist yet.
+func (w *errorTableWriter) createTable(ctx context.Context) error {
+	err := w.client.Exec(ctx, w.createQuery)
+	if err != nil {
+		return fmt.Errorf("create error table: %w", err)
+	}
+	return nil
+}
+
+func (w *errorTableWriter) add(msg models.DLQMessage) {
+	w.mu.Lock()
+	w.rows = append(w.rows, msg)
+	w.mu.Unlock()
+}
+
+// flush inserts the collected rows and returns how many were written. Rows
+// are dropped on failure, they can still be read from the DLQ.
+func (w *errorTableWriter) flush(ctx context.Context) (int, error) {
+	w.mu.Lock()
+	rows := w.rows
+	w.rows = nil
+	w.mu.Unlock()
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	batch, err := w.client.PrepareBatch(ctx, w.insertQuery)
+	if err != nil {
+		return 0, fmt.Errorf("prepare error table batch: %w", err)
+	}
+
+	for _, row := range rows {
+		err = batch.Append(
+			w.pipelineID,
+			row.Component,
+			row.Reason,
+			row.Error,
+			row.OriginalMessage.String(),
+			row.Timestamp,
+		)
+		if err != nil {
+			_ = batch.Abort()
+			return 0, fmt.Errorf("append to error table batch: %w", err)
+		}
+	}
+
+	err = batch.Send()
+	if err != nil {
+		return 0, fmt.Errorf("send error table batch: %w", err)
+	}
+
+	return len(rows), nil
+}
@@ -0,0 +1,106 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type fakeErrorTableBatch struct {
+	driver.Batch
+	rows    [][]any
+	sendErr error
+	sent    bool
+}
+
+func (b *fakeErrorTableBatch) Append(v ...any) error {
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *fakeErrorTableBatch) Abort() error { return nil }
+
+func (b *fakeErrorTableBatch) Send() error {
+	b.sent = true
+	return b.sendErr
+}
+
+type fakeErrorTableClient struct {
+	execQueries  []string
+	batchQueries []string
+	batch        *fakeErrorTableBatch
+}
+
+func (c *fakeErrorTableClient) Exec(_ context.Context, query string, _ ...any) error {
+	c.execQueries = append(c.execQueries, query)
+	return nil
+}
+
+func (c *fakeErrorTableClient) PrepareBatch(_ context.Context, query string) (driver.Batch, error) {
+	c.batchQueries = append(c.batchQueries, query)
+	return c.batch, nil
+}
+
+func newTestErrorTableWriter(t *testing.T, chClient errorTableClient) *errorTableWriter {
+	t.Helper()
+	w, err := newErrorTableWriter(chClient, models.SinkComponentConfig{
+		ClickHouseConnectionParams: models.ClickHouseConnectionParamsConfig{Database: "analytics", Table: "events"},
+		ErrorTable:                 "events_errors",
+	}, "pipeline-1")
+	require.NoError(t, err)
+	return w
+}
+
+func TestErrorTableWriter_Flush(t *testing.T) {
+	chClient := &fakeErrorTableClient{batch: &fakeErrorTableBatch{}}
+	w := newTestErrorTableWriter(t, chClient)
+
+	require.NoError(t, w.createTable(context.Background()))
+	require.Len(t, chClient.execQueries, 1)
+	require.Contains(t, chClient.execQueries[0], "CREATE TABLE IF NOT EXISTS `analytics`.`events_errors`")
+
+	// Nothing collected, no batch
+	written, err := w.flush(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, written)
+	require.Empty(t, chClient.batchQueries)
+
+	msg := models.NewDLQMessage(internal.RoleSink, "bad value", []byte(`{"id":1}`)).WithReason("schema_mismatch")
+	w.add(msg)
+	w.add(msg)
+
+	written, err = w.flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+	require.Equal(t, []string{
+		"INSERT INTO `analytics`.`events_errors` (pipeline_id, component, reason, error, payload, timestamp)",
+	}, chClient.batchQueries)
+	require.True(t, chClient.batch.sent)
+	require.Equal(t, []any{"pipeline-1", internal.RoleSink, "schema_mismatch", "bad value", `{"id":1}`, msg.Timestamp}, chClient.batch.rows[0])
+
+	// Rows are not written twice
+	written, err = w.flush(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, written)
+}
+
+func TestErrorTableWriter_FlushErrorDropsRows(t *testing.T) {
+	chClient := &fakeErrorTableClient{batch: &fakeErrorTableBatch{sendErr: errors.New("table is missing")}}
+	w := newTestErrorTableWriter(t, chClient)
+
+	w.add(models.NewDLQMessage(internal.RoleSink, "bad value", []byte(`{}`)))
+
+	_, err := w.flush(context.Background())
+	require.Error(t, err)
+
+	chClient.batch = &fakeErrorTableBatch{}
+	written, err := w.flush(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, written)
+}
@@ -0,0 +1,81 @@
+package sink
+
+import (
+	"fmt"
+	"slices"
+)
+
+// insertProfileEvents are the ClickHouse ProfileEvents of a batch insert that
+// are recorded as metrics. MergeTreeDataWriterBlocks counts the parts created
+// by the insert, each of them adds work for the background merges.
+var insertProfileEvents = []string{
+	"InsertedRows",
+	"InsertedBytes",
+	"MergeTreeDataWriterBlocks",
+	"DelayedInserts",
+}
+
+// batchInsert identifies the insert of a schema version batch, so it can be
+// found in the ClickHouse query log from the sink logs and the other way round.
+type batchInsert struct {
+	queryID       string
+	dedupToken    string
+	firstSequence uint64
+	lastSequence  uint64
+
+	// profileEvents collects the ProfileEvents of the insert, nil when they
+	// are not captured
+	profileEvents map[string]int64
+}
+
+// insertQueryID returns the query_id of a batch insert. It is built from the
+// pipeline, the deduplication token of the batch and the delivery attempt, so
+// a redelivered batch is found under the same prefix but doesn't reuse the
+// query_id of an attempt that may still be running in ClickHouse.
+func insertQueryID(pipelineID, schemaVersionID string, sequences []uint64, attempt uint64) string {
+	return fmt.Sprintf("glassflow-%s-%s-%d", pipelineID, insertDeduplicationToken(schemaVersionID, sequences), attempt)
+}
+
+// batchAttempts returns the delivery attempt of each schema version batch,
+// the highest number of deliveries of its messages that were mapped without
+// error.
+func batchAttempts(results map[int]workerResult) map[string]uint64 {
+	attempts := make(map[string]uint64)
+	for _, result := range results {
+		for _, procMsg := range result.processed {
+			if procMsg.err != nil {
+				continue
+			}
+			attempts[procMsg.schemaVersionID] = max(attempts[procMsg.schemaVersionID], procMsg.metadata.NumDelivered)
+		}
+	}
+	return attempts
+}
+
+// batchInserts returns the insert of each schema version batch.
+func (ch *ClickHouseSink) batchInserts(results map[int]workerResult) map[string]batchInsert {
+	sequences := batchSequences(results)
+	attempts := batchAttempts(results)
+	inserts := make(map[string]batchInsert, len(sequences))
+	for schemaVersionID, seqs := range sequences {
+		insert := batchInsert{
+			queryID:       insertQueryID(ch.pipelineID, schemaVersionID, seqs, attempts[schemaVersionID]),
+			firstSequence: slices.Min(seqs),
+			lastSequence:  slices.Max(seqs),
+		}
+		if ch.sinkConfig.InsertDeduplication {
+			insert.dedupToken = insertDeduplicationToken(schemaVersionID, seqs)
+		}
+		if ch.clickhouseQueryConfig.ProfileEvents {
+			insert.profileEvents = make(map[string]int64)
+		}
+		inserts[schemaVersionID] = insert
+	}
+	return inserts
+}
+
+func (b batchInsert) addProfileEvents(counters map[string]int64) {
+	for name, value := range counters {
+		b.profileEvents[name] += value
+	}
+}
@@ -0,0 +1,59 @@
+package sink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestInsertQueryID(t *testing.T) {
+	queryID := insertQueryID("pipeline-1", "v1", []uint64{12, 10, 11}, 1)
+	require.Regexp(t, `^glassflow-pipeline-1-v1-10-12-[0-9a-f]{16}-1$`, queryID)
+
+	require.Equal(t, queryID, insertQueryID("pipeline-1", "v1", []uint64{10, 11, 12}, 1))
+	require.NotEqual(t, queryID, insertQueryID("pipeline-2", "v1", []uint64{10, 11, 12}, 1))
+
+	// A redelivered batch keeps the prefix but gets a new query_id
+	retried := insertQueryID("pipeline-1", "v1", []uint64{10, 11, 12}, 2)
+	require.NotEqual(t, queryID, retried)
+	require.Equal(t, strings.TrimSuffix(queryID, "-1"), strings.TrimSuffix(retried, "-2"))
+}
+
+func TestBatchInserts(t *testing.T) {
+	meta := func(seq, delivered uint64) *jetstream.MsgMetadata {
+		return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: seq}, NumDelivered: delivered}
+	}
+
+	results := map[int]workerResult{
+		0: {processed: []processedMessage{
+			{metadata: meta(5, 1), schemaVersionID: "v1"},
+			{metadata: meta(2, 1), schemaVersionID: "v2"},
+		}},
+		1: {processed: []processedMessage{
+			{metadata: meta(3, 2), schemaVersionID: "v1"},
+			{schemaVersionID: "v1", err: errors.New("mapping failed")},
+		}},
+	}
+
+	ch := &ClickHouseSink{pipelineID: "pipeline-1"}
+	require.Equal(t, map[string]batchInsert{
+		"v1": {queryID: insertQueryID("pipeline-1", "v1", []uint64{3, 5}, 2), firstSequence: 3, lastSequence: 5},
+		"v2": {queryID: insertQueryID("pipeline-1", "v2", []uint64{2}, 1), firstSequence: 2, lastSequence: 2},
+	}, ch.batchInserts(results))
+
+	ch.sinkConfig = models.SinkComponentConfig{InsertDeduplication: true}
+	ch.clickhouseQueryConfig = models.ClickhouseQueryConfig{ProfileEvents: true}
+	inserts := ch.batchInserts(results)
+	require.Equal(t, insertDeduplicationToken("v1", []uint64{3, 5}), inserts["v1"].dedupToken)
+	require.Equal(t, insertDeduplicationToken("v2", []uint64{2}), inserts["v2"].dedupToken)
+
+	inserts["v1"].addProfileEvents(map[string]int64{"InsertedRows": 2})
+	inserts["v1"].addProfileEvents(map[string]int64{"InsertedRows": 1, "InsertedBytes": 40})
+	require.Equal(t, map[string]int64{"InsertedRows": 3, "InsertedBytes": 40}, inserts["v1"].profileEvents)
+	require.Empty(t, inserts["v2"].profileEvents)
+}
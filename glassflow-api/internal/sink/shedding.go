@@ -0,0 +1,53 @@
+package sink
+
+import (
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/runtimelimits"
+)
+
+const (
+	sheddingReasonMemory = "memory"
+	sheddingReasonCPU    = "cpu"
+)
+
+// loadShedder decides when the sink is degraded and should pull less from
+// NATS, with hysteresis between the high and low pressure marks.
+type loadShedder struct {
+	memoryUsage func() float64
+	cpuPressure func() float64
+	shedding    bool
+}
+
+func newLoadShedder() *loadShedder {
+	return &loadShedder{
+		memoryUsage: runtimelimits.MemoryUsageRatio,
+		cpuPressure: runtimelimits.CPUPressure,
+	}
+}
+
+// update samples the pressure and reports whether the shedding state changed.
+// reason is set when shedding starts.
+func (s *loadShedder) update() (changed bool, reason string) {
+	memory := s.memoryUsage()
+	cpu := s.cpuPressure()
+
+	if !s.shedding {
+		switch {
+		case memory >= internal.SinkSheddingMemoryHigh:
+			reason = sheddingReasonMemory
+		case cpu >= internal.SinkSheddingCPUHigh:
+			reason = sheddingReasonCPU
+		default:
+			return false, ""
+		}
+		s.shedding = true
+		return true, reason
+	}
+
+	if memory < internal.SinkSheddingMemoryLow && cpu < internal.SinkSheddingCPULow {
+		s.shedding = false
+		return true, ""
+	}
+
+	return false, ""
+}
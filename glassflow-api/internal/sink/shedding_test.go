@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadShedder(t *testing.T) {
+	var memory, cpu float64
+	s := &loadShedder{
+		memoryUsage: func() float64 { return memory },
+		cpuPressure: func() float64 { return cpu },
+	}
+
+	changed, _ := s.update()
+	require.False(t, changed)
+
+	memory = 0.9
+	changed, reason := s.update()
+	require.True(t, changed)
+	require.Equal(t, sheddingReasonMemory, reason)
+
+	// Between the low and high marks the sink keeps shedding
+	memory = 0.8
+	changed, _ = s.update()
+	require.False(t, changed)
+	require.True(t, s.shedding)
+
+	memory = 0.5
+	cpu = 0.3
+	changed, _ = s.update()
+	require.False(t, changed)
+
+	cpu = 0.1
+	changed, _ = s.update()
+	require.True(t, changed)
+	require.False(t, s.shedding)
+
+	cpu = 0.6
+	changed, reason = s.update()
+	require.True(t, changed)
+	require.Equal(t, sheddingReasonCPU, reason)
+}
@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+)
+
+// warmUp ramps the batch and pull sizes of a starting sink from the initial
+// ratio of their maximum to the maximum over the warm-up window, so that a
+// backlog doesn't hit ClickHouse with full batches right away. A nil warmUp
+// is warmed up already.
+type warmUp struct {
+	start    time.Time
+	duration time.Duration
+	// finished ends the warm-up early, on shutdown
+	finished atomic.Bool
+}
+
+func newWarmUp(duration time.Duration) *warmUp {
+	if duration <= 0 {
+		return nil
+	}
+	return &warmUp{duration: duration}
+}
+
+// begin starts the warm-up window.
+func (w *warmUp) begin(now time.Time) {
+	if w == nil {
+		return
+	}
+	w.start = now
+}
+
+func (w *warmUp) finish() {
+	if w == nil {
+		return
+	}
+	w.finished.Store(true)
+}
+
+// active reports whether the sink is still warming up at now.
+func (w *warmUp) active(now time.Time) bool {
+	return w.ratio(now) < 1
+}
+
+// ratio returns the share of the maximum sizes used at now.
+func (w *warmUp) ratio(now time.Time) float64 {
+	if w == nil || w.finished.Load() {
+		return 1
+	}
+
+	elapsed := now.Sub(w.start)
+	if elapsed >= w.duration {
+		return 1
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return internal.SinkWarmUpInitialRatio + (1-internal.SinkWarmUpInitialRatio)*float64(elapsed)/float64(w.duration)
+}
+
+// scale returns size scaled by the ratio at now, at least 1.
+func (w *warmUp) scale(size int, now time.Time) int {
+	return max(int(float64(size)*w.ratio(now)), 1)
+}
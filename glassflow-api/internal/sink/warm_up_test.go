@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUp(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := newWarmUp(10 * time.Minute)
+	w.begin(start)
+
+	assert.True(t, w.active(start))
+	assert.Equal(t, 100, w.scale(1000, start))
+	assert.InDelta(t, 0.55, w.ratio(start.Add(5*time.Minute)), 1e-9)
+	assert.Equal(t, 1, w.scale(5, start), "scaled sizes are at least 1")
+
+	assert.False(t, w.active(start.Add(10*time.Minute)))
+	assert.Equal(t, 1000, w.scale(1000, start.Add(time.Hour)))
+
+	w.finish()
+	assert.False(t, w.active(start))
+	assert.Equal(t, 1000, w.scale(1000, start))
+}
+
+func TestWarmUp_Disabled(t *testing.T) {
+	w := newWarmUp(0)
+	assert.Nil(t, w)
+
+	w.begin(time.Now())
+	w.finish()
+	assert.False(t, w.active(time.Now()))
+	assert.Equal(t, 1000, w.scale(1000, time.Now()))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/client"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/encryption"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/storage/postgres"
@@ -24,8 +25,8 @@ func MigratePipelinesFromNATSKV(
 }
 
 // NewPipelineStore creates a new PipelineStore implementation.
-func NewPipelineStore(ctx context.Context, dsn string, logger *slog.Logger, encryptionKey []byte, role models.Role) (service.PipelineStore, error) {
-	return postgres.NewPostgres(ctx, dsn, logger, encryptionKey, role)
+func NewPipelineStore(ctx context.Context, dsn string, logger *slog.Logger, kek encryption.KeyEncryptionKey, role models.Role, opts ...postgres.Option) (service.PipelineStore, error) {
+	return postgres.NewPostgres(ctx, dsn, logger, kek, role, opts...)
 }
 
 // NewPool creates a bare connection pool for lightweight use cases such as
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/auth"
+)
+
+// CreateAPIKey stores an API key, with the hash of the key only.
+func (s *PostgresStorage) CreateAPIKey(ctx context.Context, key auth.APIKey) error {
+	teams, err := json.Marshal(key.Teams)
+	if err != nil {
+		return fmt.Errorf("marshal api key teams: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO api_keys (id, name, role, teams, key_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, key.ID, key.Name, string(key.Role), teams, key.Hash, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey returns the API key with the given ID.
+func (s *PostgresStorage) GetAPIKey(ctx context.Context, id string) (auth.APIKey, error) {
+	row := s.pool.QueryRow(ctx, `
+		SELECT id, name, role, teams, key_hash, created_at FROM api_keys
+		WHERE id = $1
+	`, id)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.APIKey{}, auth.ErrKeyNotFound
+		}
+		return auth.APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys, the oldest first.
+func (s *PostgresStorage) ListAPIKeys(ctx context.Context) ([]auth.APIKey, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, name, role, teams, key_hash, created_at FROM api_keys
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []auth.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey revokes an API key.
+func (s *PostgresStorage) DeleteAPIKey(ctx context.Context, id string) error {
+	commandTag, err := s.pool.Exec(ctx, `DELETE FROM api_keys WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return auth.ErrKeyNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (auth.APIKey, error) {
+	var (
+		key   auth.APIKey
+		role  string
+		teams []byte
+	)
+	err := row.Scan(&key.ID, &key.Name, &role, &teams, &key.Hash, &key.CreatedAt)
+	if err != nil {
+		return auth.APIKey{}, err
+	}
+	key.Role = auth.Role(role)
+
+	err = json.Unmarshal(teams, &key.Teams)
+	if err != nil {
+		return auth.APIKey{}, fmt.Errorf("unmarshal api key teams: %w", err)
+	}
+	return key, nil
+}
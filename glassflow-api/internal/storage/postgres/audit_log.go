@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/audit"
+)
+
+// InsertAuditEntry appends an entry to the audit log.
+func (s *PostgresStorage) InsertAuditEntry(ctx context.Context, entry audit.Entry) error {
+	if entry.Changes == nil {
+		entry.Changes = []audit.Change{}
+	}
+	if entry.Details == nil {
+		entry.Details = map[string]any{}
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("marshal audit changes: %w", err)
+	}
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("marshal audit details: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO audit_log (occurred_at, actor, source_ip, action, pipeline_id, status, changes, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entry.Time, entry.Actor, entry.SourceIP, entry.Action, entry.PipelineID, entry.Status, changes, details)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns the entries of the audit log matching the
+// filter, the latest first.
+func (s *PostgresStorage) ListAuditEntries(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, occurred_at, actor, source_ip, action, pipeline_id, status, changes, details
+		FROM audit_log
+		WHERE ($1::text = '' OR actor = $1)
+			AND ($2::text = '' OR action = $2)
+			AND ($3::text = '' OR pipeline_id = $3)
+			AND ($4::timestamptz IS NULL OR occurred_at >= $4)
+			AND ($5::timestamptz IS NULL OR occurred_at < $5)
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $6
+	`, filter.Actor, filter.Action, filter.PipelineID, nullTime(filter.Since), nullTime(filter.Until), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []audit.Entry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+func scanAuditEntry(row pgx.Row) (audit.Entry, error) {
+	var (
+		entry   audit.Entry
+		changes []byte
+		details []byte
+	)
+	err := row.Scan(&entry.ID, &entry.Time, &entry.Actor, &entry.SourceIP, &entry.Action,
+		&entry.PipelineID, &entry.Status, &changes, &details)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	err = json.Unmarshal(changes, &entry.Changes)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("unmarshal audit changes: %w", err)
+	}
+	err = json.Unmarshal(details, &entry.Details)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("unmarshal audit details: %w", err)
+	}
+	return entry, nil
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -28,7 +28,7 @@ func (s *PostgresStorage) insertStatelessTransformationConfig(ctx context.Contex
 	return nil
 }
 
-func (s *PostgresStorage) updateStatelessTransformationConfig(ctx context.Context, tx pgx.Tx, pipelineID, sourceID, sourceSchemaVersionID string, config []models.Transform) error {
+func (s *PostgresStorage) updateStatelessTransformationConfig(ctx context.Context, tx pgx.Tx, pipelineID, sourceID, sourceSchemaVersionID, outputSchemaVersionID string, config []models.Transform) error {
 	configJSON, err := json.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("marshal transformation config: %w", err)
@@ -36,9 +36,9 @@ func (s *PostgresStorage) updateStatelessTransformationConfig(ctx context.Contex
 
 	_, err = tx.Exec(ctx, `
 		UPDATE transformation_configs
-		SET config = $1, updated_at = NOW()
-		WHERE pipeline_id = $2 AND source_id = $3 AND schema_version_id = $4
-	`, string(configJSON), pipelineID, sourceID, sourceSchemaVersionID)
+		SET config = $1, output_schema_version_id = $2, updated_at = NOW()
+		WHERE pipeline_id = $3 AND source_id = $4 AND schema_version_id = $5
+	`, string(configJSON), outputSchemaVersionID, pipelineID, sourceID, sourceSchemaVersionID)
 	if err != nil {
 		return fmt.Errorf("update transformation config: %w", err)
 	}
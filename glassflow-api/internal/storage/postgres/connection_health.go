@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// ListStoredConnections returns the Kafka and ClickHouse connections of the
+// pipeline sources and sinks, with their credentials decrypted.
+func (s *PostgresStorage) ListStoredConnections(ctx context.Context) ([]models.StoredConnection, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.type, c.config, COALESCE(so.pipeline_id, si.pipeline_id, '')
+		FROM connections c
+		LEFT JOIN sources so ON so.connection_id = c.id
+		LEFT JOIN sinks si ON si.connection_id = c.id
+		WHERE c.type IN ('kafka', 'clickhouse')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query connections: %w", err)
+	}
+	defer rows.Close()
+
+	var conns []models.StoredConnection
+	for rows.Next() {
+		var (
+			conn       models.StoredConnection
+			configJSON []byte
+		)
+		err := rows.Scan(&conn.Type, &configJSON, &conn.PipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("scan connection: %w", err)
+		}
+
+		decrypted, err := decryptSensitiveFields(s.encryptionService, conn.Type, configJSON)
+		if err != nil {
+			// Connections stored before encryption was enabled are plaintext
+			s.logger.WarnContext(ctx, "failed to decrypt sensitive fields, treating as unencrypted",
+				slog.String("pipeline_id", conn.PipelineID),
+				slog.String("error", err.Error()))
+			decrypted = configJSON
+		}
+
+		switch conn.Type {
+		case models.ConnectionTypeKafka:
+			var config models.IngestorComponentConfig
+			if err := json.Unmarshal(decrypted, &config); err != nil {
+				return nil, fmt.Errorf("unmarshal kafka connection config: %w", err)
+			}
+			conn.Kafka = config.KafkaConnectionParams
+		case models.ConnectionTypeClickHouse:
+			var config models.SinkComponentConfig
+			if err := json.Unmarshal(decrypted, &config); err != nil {
+				return nil, fmt.Errorf("unmarshal clickhouse connection config: %w", err)
+			}
+			conn.ClickHouse = config.ClickHouseConnectionParams
+		}
+		conns = append(conns, conn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read connections: %w", err)
+	}
+
+	return conns, nil
+}
+
+// RecordConnectionHealth stores the check of an endpoint as its current
+// health and in its history. The endpoint keeps the time it entered its
+// status while the status does not change.
+func (s *PostgresStorage) RecordConnectionHealth(ctx context.Context, endpoint models.ConnectionEndpoint, check models.ConnectionHealthCheck) error {
+	pipelines, err := json.Marshal(endpoint.Pipelines)
+	if err != nil {
+		return fmt.Errorf("marshal connection pipelines: %w", err)
+	}
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO connection_health (id, type, address, username, pipelines, status, since, checked_at, latency_ms, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			pipelines = EXCLUDED.pipelines,
+			since = CASE WHEN connection_health.status = EXCLUDED.status
+				THEN connection_health.since ELSE EXCLUDED.since END,
+			status = EXCLUDED.status,
+			checked_at = EXCLUDED.checked_at,
+			latency_ms = EXCLUDED.latency_ms,
+			error = EXCLUDED.error
+	`, endpoint.ID, endpoint.Type, endpoint.Address, endpoint.Username, pipelines,
+		string(check.Status), check.CheckedAt, check.LatencyMs, check.Error)
+	if err != nil {
+		return fmt.Errorf("upsert connection health: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO connection_health_checks (connection_id, checked_at, status, latency_ms, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`, endpoint.ID, check.CheckedAt, string(check.Status), check.LatencyMs, check.Error)
+	if err != nil {
+		return fmt.Errorf("insert connection health check: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListConnectionHealth returns the current health of the endpoints, sorted
+// by type and address.
+func (s *PostgresStorage) ListConnectionHealth(ctx context.Context) ([]models.ConnectionHealth, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, type, address, username, pipelines, status, since, checked_at, latency_ms, error
+		FROM connection_health
+		ORDER BY type, address, username
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query connection health: %w", err)
+	}
+	defer rows.Close()
+
+	health := []models.ConnectionHealth{}
+	for rows.Next() {
+		h, err := scanConnectionHealth(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan connection health: %w", err)
+		}
+		health = append(health, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read connection health: %w", err)
+	}
+
+	return health, nil
+}
+
+// GetConnectionHealth returns the current health of the endpoint.
+func (s *PostgresStorage) GetConnectionHealth(ctx context.Context, id string) (models.ConnectionHealth, error) {
+	row := s.pool.QueryRow(ctx, `
+		SELECT id, type, address, username, pipelines, status, since, checked_at, latency_ms, error
+		FROM connection_health
+		WHERE id = $1
+	`, id)
+
+	h, err := scanConnectionHealth(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ConnectionHealth{}, models.ErrRecordNotFound
+		}
+		return models.ConnectionHealth{}, fmt.Errorf("get connection health: %w", err)
+	}
+	return h, nil
+}
+
+// ListConnectionHealthChecks returns the checks of the endpoint at or after
+// since, the latest first.
+func (s *PostgresStorage) ListConnectionHealthChecks(ctx context.Context, id string, since time.Time, limit int) ([]models.ConnectionHealthCheck, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT connection_id, checked_at, status, latency_ms, error
+		FROM connection_health_checks
+		WHERE connection_id = $1
+			AND ($2::timestamptz IS NULL OR checked_at >= $2)
+		ORDER BY checked_at DESC, id DESC
+		LIMIT $3
+	`, id, nullTime(since), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query connection health checks: %w", err)
+	}
+	defer rows.Close()
+
+	checks := []models.ConnectionHealthCheck{}
+	for rows.Next() {
+		var (
+			check  models.ConnectionHealthCheck
+			status string
+		)
+		err := rows.Scan(&check.ConnectionID, &check.CheckedAt, &status, &check.LatencyMs, &check.Error)
+		if err != nil {
+			return nil, fmt.Errorf("scan connection health check: %w", err)
+		}
+		check.Status = models.ConnectionHealthStatus(status)
+		checks = append(checks, check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read connection health checks: %w", err)
+	}
+
+	return checks, nil
+}
+
+// PruneConnectionHealth removes the endpoints no pipeline connects to
+// anymore and the checks older than before.
+func (s *PostgresStorage) PruneConnectionHealth(ctx context.Context, active []string, before time.Time) error {
+	if active == nil {
+		active = []string{}
+	}
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM connection_health WHERE NOT (id = ANY($1))
+	`, active)
+	if err != nil {
+		return fmt.Errorf("delete stale connection health: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		DELETE FROM connection_health_checks WHERE checked_at < $1
+	`, before)
+	if err != nil {
+		return fmt.Errorf("delete old connection health checks: %w", err)
+	}
+	return nil
+}
+
+func scanConnectionHealth(row pgx.Row) (models.ConnectionHealth, error) {
+	var (
+		h         models.ConnectionHealth
+		pipelines []byte
+		status    string
+	)
+	err := row.Scan(&h.ID, &h.Type, &h.Address, &h.Username, &pipelines,
+		&status, &h.Since, &h.CheckedAt, &h.LatencyMs, &h.Error)
+	if err != nil {
+		return models.ConnectionHealth{}, err
+	}
+	h.Status = models.ConnectionHealthStatus(status)
+
+	err = json.Unmarshal(pipelines, &h.Pipelines)
+	if err != nil {
+		return models.ConnectionHealth{}, fmt.Errorf("unmarshal connection pipelines: %w", err)
+	}
+	return h, nil
+}
@@ -92,6 +92,24 @@ func encryptSensitiveFields(encryptionService *encryption.Service, connType stri
 			return nil, err
 		}
 		return json.Marshal(config)
+	case "pulsar":
+		var config models.IngestorComponentConfig
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("unmarshal pulsar config: %w", err)
+		}
+		if err := encryptPulsarFields(encryptionService, &config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
+	case "mysql":
+		var config models.IngestorComponentConfig
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("unmarshal mysql config: %w", err)
+		}
+		if err := encryptMySQLFields(encryptionService, &config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
 	case "clickhouse":
 		var config models.SinkComponentConfig
 		if err := json.Unmarshal(configJSON, &config); err != nil {
@@ -106,6 +124,60 @@ func encryptSensitiveFields(encryptionService *encryption.Service, connType stri
 	}
 }
 
+// marshalPipelineEncrypted marshals a pipeline with the credentials of its
+// source and sink encrypted, the pipeline itself is not changed.
+func marshalPipelineEncrypted(encryptionService *encryption.Service, pipeline models.PipelineConfig) ([]byte, error) {
+	raw, err := json.Marshal(pipeline)
+	if err != nil || encryptionService == nil {
+		return raw, err
+	}
+
+	var copy models.PipelineConfig
+	if err := json.Unmarshal(raw, &copy); err != nil {
+		return nil, fmt.Errorf("copy pipeline: %w", err)
+	}
+	if err := encryptKafkaFields(encryptionService, &copy.Ingestor); err != nil {
+		return nil, fmt.Errorf("encrypt kafka fields: %w", err)
+	}
+	if err := encryptPulsarFields(encryptionService, &copy.Ingestor); err != nil {
+		return nil, fmt.Errorf("encrypt pulsar fields: %w", err)
+	}
+	if err := encryptMySQLFields(encryptionService, &copy.Ingestor); err != nil {
+		return nil, fmt.Errorf("encrypt mysql fields: %w", err)
+	}
+	if err := encryptClickHouseFields(encryptionService, &copy.Sink); err != nil {
+		return nil, fmt.Errorf("encrypt clickhouse fields: %w", err)
+	}
+
+	return json.Marshal(copy)
+}
+
+// unmarshalPipelineDecrypted reverses marshalPipelineEncrypted.
+func unmarshalPipelineDecrypted(encryptionService *encryption.Service, raw []byte) (models.PipelineConfig, error) {
+	var pipeline models.PipelineConfig
+	if err := json.Unmarshal(raw, &pipeline); err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("unmarshal pipeline: %w", err)
+	}
+	if encryptionService == nil {
+		return pipeline, nil
+	}
+
+	if err := decryptKafkaFields(encryptionService, &pipeline.Ingestor); err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("decrypt kafka fields: %w", err)
+	}
+	if err := decryptPulsarFields(encryptionService, &pipeline.Ingestor); err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("decrypt pulsar fields: %w", err)
+	}
+	if err := decryptMySQLFields(encryptionService, &pipeline.Ingestor); err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("decrypt mysql fields: %w", err)
+	}
+	if err := decryptClickHouseFields(encryptionService, &pipeline.Sink); err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("decrypt clickhouse fields: %w", err)
+	}
+
+	return pipeline, nil
+}
+
 // decryptSensitiveFields decrypts sensitive fields in the connection config JSON
 func decryptSensitiveFields(encryptionService *encryption.Service, connType string, configJSON []byte) ([]byte, error) {
 	if encryptionService == nil {
@@ -122,6 +194,24 @@ func decryptSensitiveFields(encryptionService *encryption.Service, connType stri
 			return nil, err
 		}
 		return json.Marshal(config)
+	case "pulsar":
+		var config models.IngestorComponentConfig
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("unmarshal pulsar config: %w", err)
+		}
+		if err := decryptPulsarFields(encryptionService, &config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
+	case "mysql":
+		var config models.IngestorComponentConfig
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("unmarshal mysql config: %w", err)
+		}
+		if err := decryptMySQLFields(encryptionService, &config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
 	case "clickhouse":
 		var config models.SinkComponentConfig
 		if err := json.Unmarshal(configJSON, &config); err != nil {
@@ -179,6 +269,43 @@ func encryptKafkaFields(encryptionService *encryption.Service, config *models.In
 	return nil
 }
 
+// encryptPulsarFields encrypts sensitive fields in Pulsar connection config
+func encryptPulsarFields(encryptionService *encryption.Service, config *models.IngestorComponentConfig) error {
+	// Encrypt auth token
+	if config.PulsarConnectionParams.AuthToken != "" {
+		encrypted, err := encryptionService.Encrypt([]byte(config.PulsarConnectionParams.AuthToken))
+		if err != nil {
+			return fmt.Errorf("encrypt auth_token: %w", err)
+		}
+		config.PulsarConnectionParams.AuthToken = base64.StdEncoding.EncodeToString(encrypted)
+	}
+
+	// Encrypt TLS key
+	if config.PulsarConnectionParams.TLSKey != "" {
+		encrypted, err := encryptionService.Encrypt([]byte(config.PulsarConnectionParams.TLSKey))
+		if err != nil {
+			return fmt.Errorf("encrypt tls_key: %w", err)
+		}
+		config.PulsarConnectionParams.TLSKey = base64.StdEncoding.EncodeToString(encrypted)
+	}
+
+	return nil
+}
+
+// encryptMySQLFields encrypts sensitive fields in MySQL connection config
+func encryptMySQLFields(encryptionService *encryption.Service, config *models.IngestorComponentConfig) error {
+	// Encrypt password
+	if config.MySQLConnectionParams.Password != "" {
+		encrypted, err := encryptionService.Encrypt([]byte(config.MySQLConnectionParams.Password))
+		if err != nil {
+			return fmt.Errorf("encrypt password: %w", err)
+		}
+		config.MySQLConnectionParams.Password = base64.StdEncoding.EncodeToString(encrypted)
+	}
+
+	return nil
+}
+
 // encryptClickHouseFields encrypts sensitive fields in ClickHouse connection config
 func encryptClickHouseFields(encryptionService *encryption.Service, config *models.SinkComponentConfig) error {
 	// Encrypt password
@@ -190,6 +317,15 @@ func encryptClickHouseFields(encryptionService *encryption.Service, config *mode
 		config.ClickHouseConnectionParams.Password = base64.StdEncoding.EncodeToString(encrypted)
 	}
 
+	// Encrypt TLS key
+	if config.ClickHouseConnectionParams.TLSKey != "" {
+		encrypted, err := encryptionService.Encrypt([]byte(config.ClickHouseConnectionParams.TLSKey))
+		if err != nil {
+			return fmt.Errorf("encrypt tls_key: %w", err)
+		}
+		config.ClickHouseConnectionParams.TLSKey = base64.StdEncoding.EncodeToString(encrypted)
+	}
+
 	return nil
 }
 
@@ -229,6 +365,37 @@ func decryptKafkaFields(encryptionService *encryption.Service, config *models.In
 	return nil
 }
 
+// decryptPulsarFields decrypts sensitive fields in Pulsar connection config
+func decryptPulsarFields(encryptionService *encryption.Service, config *models.IngestorComponentConfig) error {
+	// Decrypt auth token
+	if config.PulsarConnectionParams.AuthToken != "" {
+		if decrypted, err := attemptDecryptField(encryptionService, config.PulsarConnectionParams.AuthToken); err == nil {
+			config.PulsarConnectionParams.AuthToken = decrypted
+		}
+	}
+
+	// Decrypt TLS key
+	if config.PulsarConnectionParams.TLSKey != "" {
+		if decrypted, err := attemptDecryptField(encryptionService, config.PulsarConnectionParams.TLSKey); err == nil {
+			config.PulsarConnectionParams.TLSKey = decrypted
+		}
+	}
+
+	return nil
+}
+
+// decryptMySQLFields decrypts sensitive fields in MySQL connection config
+func decryptMySQLFields(encryptionService *encryption.Service, config *models.IngestorComponentConfig) error {
+	// Decrypt password
+	if config.MySQLConnectionParams.Password != "" {
+		if decrypted, err := attemptDecryptField(encryptionService, config.MySQLConnectionParams.Password); err == nil {
+			config.MySQLConnectionParams.Password = decrypted
+		}
+	}
+
+	return nil
+}
+
 // decryptClickHouseFields decrypts sensitive fields in ClickHouse connection config
 func decryptClickHouseFields(encryptionService *encryption.Service, config *models.SinkComponentConfig) error {
 	// Decrypt password
@@ -239,6 +406,13 @@ func decryptClickHouseFields(encryptionService *encryption.Service, config *mode
 		// If decryption fails, assume it's plaintext (backward compatibility)
 	}
 
+	// Decrypt TLS key
+	if config.ClickHouseConnectionParams.TLSKey != "" {
+		if decrypted, err := attemptDecryptField(encryptionService, config.ClickHouseConnectionParams.TLSKey); err == nil {
+			config.ClickHouseConnectionParams.TLSKey = decrypted
+		}
+	}
+
 	return nil
 }
 
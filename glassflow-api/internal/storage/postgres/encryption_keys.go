@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/encryption"
+)
+
+// dataKeyID is the row of the data key the connection credentials are
+// encrypted with
+const dataKeyID = "connections"
+
+// loadEncryptionService unwraps the data key of the connection credentials
+// with kek, generating and storing it wrapped on first use. Only the wrapped
+// data key is stored, a database dump does not decrypt the credentials
+// without kek.
+func (s *PostgresStorage) loadEncryptionService(ctx context.Context, kek encryption.KeyEncryptionKey) (*encryption.Service, error) {
+	kekID, wrapped, err := s.getDataKey(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = s.createDataKey(ctx, kek)
+		if err != nil {
+			return nil, err
+		}
+		// Another replica may have created it in between
+		kekID, wrapped, err = s.getDataKey(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get data key: %w", err)
+	}
+
+	if kekID != kek.ID() {
+		return nil, fmt.Errorf("data key is wrapped by key %q, the configured key is %q", kekID, kek.ID())
+	}
+
+	dataKey, err := kek.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	// The credentials stored before envelope encryption were encrypted with
+	// the local key directly
+	var previousKeys [][]byte
+	if local, ok := kek.(*encryption.LocalKEK); ok {
+		previousKeys = append(previousKeys, local.Key())
+	}
+
+	return encryption.NewService(dataKey, previousKeys...)
+}
+
+func (s *PostgresStorage) getDataKey(ctx context.Context) (kekID string, wrapped []byte, _ error) {
+	err := s.pool.QueryRow(ctx, `
+		SELECT kek_id, wrapped_key
+		FROM encryption_keys
+		WHERE id = $1
+	`, dataKeyID).Scan(&kekID, &wrapped)
+	return kekID, wrapped, err
+}
+
+func (s *PostgresStorage) createDataKey(ctx context.Context, kek encryption.KeyEncryptionKey) error {
+	dataKey, err := encryption.NewDataKey()
+	if err != nil {
+		return err
+	}
+
+	wrapped, err := kek.Wrap(ctx, dataKey)
+	if err != nil {
+		return fmt.Errorf("wrap data key: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO encryption_keys (id, kek_id, wrapped_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING
+	`, dataKeyID, kek.ID(), wrapped)
+	if err != nil {
+		return fmt.Errorf("store data key: %w", err)
+	}
+
+	return nil
+}
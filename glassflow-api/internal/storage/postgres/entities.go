@@ -10,7 +10,6 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/encryption"
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
@@ -29,7 +28,7 @@ func (s *PostgresStorage) getSink(ctx context.Context, sinkID uuid.UUID) (json.R
 // getEntityWithConnection is a generic helper to get an entity (source/sink) and its connection
 func getEntityWithConnection(
 	ctx context.Context,
-	pool *pgxpool.Pool,
+	pool *retryPool,
 	logger *slog.Logger,
 	entityTable, entityIDColumn string,
 	entityID uuid.UUID,
@@ -390,31 +389,31 @@ func (s *PostgresStorage) upsertStatelessTransformationSchemaAndConfig(
 	} else if err != nil {
 		return fmt.Errorf("get existing stateless transformation config: %w", err)
 	} else {
-		// Config exists - UPDATE using existing output schema version ID
-		outputSchemaVersionID = existingConfig.OutputSchemaVersionID
-
-		err = s.updateStatelessTransformationConfig(
+		// Config exists - UPDATE, the output schema gets a new version when
+		// its fields changed so in-flight messages keep the old one
+		outputSchemaVersionID, err = s.upsertSchemaVersion(
 			ctx,
 			tx,
 			p.ID,
-			p.StatelessTransformation.SourceID,
-			sourceSchema.VersionID,
-			p.StatelessTransformation.Config.Transform,
+			p.StatelessTransformation.ID,
+			existingConfig.OutputSchemaVersionID,
+			outputSchema.Fields,
 		)
 		if err != nil {
-			return fmt.Errorf("update stateless transformation config: %w", err)
+			return fmt.Errorf("upsert schema version for stateless transformation: %w", err)
 		}
 
-		_, err = s.upsertSchemaVersion(
+		err = s.updateStatelessTransformationConfig(
 			ctx,
 			tx,
 			p.ID,
-			p.StatelessTransformation.ID,
+			p.StatelessTransformation.SourceID,
+			sourceSchema.VersionID,
 			outputSchemaVersionID,
-			outputSchema.Fields,
+			p.StatelessTransformation.Config.Transform,
 		)
 		if err != nil {
-			return fmt.Errorf("upsert schema version for stateless transformation: %w", err)
+			return fmt.Errorf("update stateless transformation config: %w", err)
 		}
 	}
 
@@ -435,45 +434,49 @@ func (s *PostgresStorage) upsertJoinTransformationSchemaAndConfig(
 		return nil
 	}
 
-	outputSchema, found := p.SchemaVersions[p.Join.ID]
-	if !found {
-		return fmt.Errorf("find output schema version for join transformation")
-	}
-
-	// Compute target join output schema version once for this edit.
-	outputSchemaVersionID, err := s.upsertSchemaVersion(
-		ctx,
-		tx,
-		p.ID,
-		p.Join.ID,
-		outputSchema.VersionID,
-		outputSchema.Fields,
-	)
-	if err != nil {
-		return fmt.Errorf("upsert schema version for join transformation: %w", err)
-	}
-
-	outputSchema.VersionID = outputSchemaVersionID
-	p.SchemaVersions[p.Join.ID] = outputSchema
-
-	// Upsert config for every join source against the SAME output schema version.
-	for _, src := range p.Join.Sources {
-		sourceSchema, found := p.SchemaVersions[src.SourceID]
+	// The stages of a chained join in order, each reads the output schema
+	// version the stage before it computed
+	for _, stage := range p.Join.Stages() {
+		outputSchema, found := p.SchemaVersions[stage.ID]
 		if !found {
-			return fmt.Errorf("schema version for join transformation source '%s' not found", src.SourceID)
+			return fmt.Errorf("find output schema version for join transformation")
 		}
 
-		if err := s.upsertJoinConfig(
+		// Compute target join output schema version once for this edit.
+		outputSchemaVersionID, err := s.upsertSchemaVersion(
 			ctx,
 			tx,
 			p.ID,
-			src.SourceID,
-			sourceSchema.VersionID,
-			p.Join.ID,
-			outputSchemaVersionID,
-			p.Join.Config,
-		); err != nil {
-			return err
+			stage.ID,
+			outputSchema.VersionID,
+			outputSchema.Fields,
+		)
+		if err != nil {
+			return fmt.Errorf("upsert schema version for join transformation: %w", err)
+		}
+
+		outputSchema.VersionID = outputSchemaVersionID
+		p.SchemaVersions[stage.ID] = outputSchema
+
+		// Upsert config for every join source against the SAME output schema version.
+		for _, src := range stage.Sources {
+			sourceSchema, found := p.SchemaVersions[src.SourceID]
+			if !found {
+				return fmt.Errorf("schema version for join transformation source '%s' not found", src.SourceID)
+			}
+
+			if err := s.upsertJoinConfig(
+				ctx,
+				tx,
+				p.ID,
+				src.SourceID,
+				sourceSchema.VersionID,
+				stage.ID,
+				outputSchemaVersionID,
+				stage.Config,
+			); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -579,6 +582,28 @@ func (s *PostgresStorage) updateTransformationsFromPipeline(
 		}
 	}
 
+	// Process explode transformation
+	if p.Explode.Enabled {
+		explodeID, err := s.upsertTransformationEntity(
+			ctx, tx, pipelineID, "explode", p.Explode, oldByType, updatedIDs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		newTransformationIDs = append(newTransformationIDs, explodeID)
+	}
+
+	// Process enrichment transformation
+	if p.Enrichment.Enabled {
+		enrichmentID, err := s.upsertTransformationEntity(
+			ctx, tx, pipelineID, "enrichment", p.Enrichment, oldByType, updatedIDs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		newTransformationIDs = append(newTransformationIDs, enrichmentID)
+	}
+
 	// Process join transformation
 	if p.Join.Enabled {
 		joinID, err := s.upsertTransformationEntity(
@@ -677,6 +702,30 @@ func (s *PostgresStorage) insertTransformationsFromPipeline(ctx context.Context,
 		transformationIDs = append(transformationIDs, statelessID)
 	}
 
+	// Explode transformation
+	if p.Explode.Enabled {
+		explodeID, err := s.insertTransformation(ctx, tx, p.ID, "explode", p.Explode)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to insert explode transformation",
+				slog.String("pipeline_id", p.ID),
+				slog.String("error", err.Error()))
+			return nil, fmt.Errorf("insert explode transformation: %w", err)
+		}
+		transformationIDs = append(transformationIDs, explodeID)
+	}
+
+	// Enrichment transformation
+	if p.Enrichment.Enabled {
+		enrichmentID, err := s.insertTransformation(ctx, tx, p.ID, "enrichment", p.Enrichment)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to insert enrichment transformation",
+				slog.String("pipeline_id", p.ID),
+				slog.String("error", err.Error()))
+			return nil, fmt.Errorf("insert enrichment transformation: %w", err)
+		}
+		transformationIDs = append(transformationIDs, enrichmentID)
+	}
+
 	// Join transformation
 	if p.Join.Enabled {
 		joinID, err := s.insertTransformation(ctx, tx, p.ID, "join", p.Join)
@@ -755,7 +804,25 @@ func (s *PostgresStorage) insertJoinSchemaAndConfig(
 		return nil
 	}
 
-	outputSchemaVersion, found := p.SchemaVersions[p.Join.ID]
+	// A chained join stores every stage like a join of its own, in order so
+	// that the intermediate output of a stage is stored before its reader
+	for _, stage := range p.Join.Stages() {
+		err := s.insertJoinStageSchemaAndConfig(ctx, tx, p, stage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) insertJoinStageSchemaAndConfig(
+	ctx context.Context,
+	tx pgx.Tx,
+	p models.PipelineConfig,
+	stage models.JoinComponentConfig,
+) error {
+	outputSchemaVersion, found := p.SchemaVersions[stage.ID]
 	if !found {
 		return fmt.Errorf("find output schema version for join transformation")
 	}
@@ -764,7 +831,7 @@ func (s *PostgresStorage) insertJoinSchemaAndConfig(
 		ctx,
 		tx,
 		p.ID,
-		p.Join.ID,
+		stage.ID,
 		outputSchemaVersion.VersionID,
 		outputSchemaVersion.Fields,
 	)
@@ -773,9 +840,9 @@ func (s *PostgresStorage) insertJoinSchemaAndConfig(
 	}
 
 	outputSchemaVersion.VersionID = outputSchemaVersionID
-	p.SchemaVersions[p.Join.ID] = outputSchemaVersion
+	p.SchemaVersions[stage.ID] = outputSchemaVersion
 
-	for _, src := range p.Join.Sources {
+	for _, src := range stage.Sources {
 		sourceSchemaVersion, found := p.SchemaVersions[src.SourceID]
 		if !found {
 			return fmt.Errorf("schema version for source ID '%s' not found", src.SourceID)
@@ -804,9 +871,9 @@ func (s *PostgresStorage) insertJoinSchemaAndConfig(
 			p.ID,
 			sourceSchemaVersion.SourceID,
 			sourceSchemaVersionID,
-			p.Join.ID,
+			stage.ID,
 			outputSchemaVersionID,
-			p.Join.Config,
+			stage.Config,
 		)
 		if err != nil {
 			return fmt.Errorf("insert join config: %w", err)
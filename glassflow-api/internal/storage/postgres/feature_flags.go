@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// GetFeatureFlags returns the flags set for a pipeline, or for the
+// installation when pipelineID is empty.
+func (s *PostgresStorage) GetFeatureFlags(ctx context.Context, pipelineID string) (map[string]bool, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT name, enabled FROM feature_flags
+		WHERE COALESCE(pipeline_id, '') = $1
+	`, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("query feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make(map[string]bool)
+	for rows.Next() {
+		var (
+			name    string
+			enabled bool
+		)
+		err = rows.Scan(&name, &enabled)
+		if err != nil {
+			return nil, fmt.Errorf("scan feature flag: %w", err)
+		}
+		flags[name] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read feature flags: %w", err)
+	}
+
+	return flags, nil
+}
+
+// SetFeatureFlag sets a flag for a pipeline, or for the installation when
+// pipelineID is empty.
+func (s *PostgresStorage) SetFeatureFlag(ctx context.Context, pipelineID, name string, enabled bool) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO feature_flags (name, pipeline_id, enabled)
+		VALUES ($1, NULLIF($2, ''), $3)
+		ON CONFLICT (name, COALESCE(pipeline_id, ''))
+		DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()
+	`, name, pipelineID, enabled)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return service.ErrPipelineNotExists
+		}
+		return fmt.Errorf("upsert feature flag: %w", err)
+	}
+	return nil
+}
+
+// DeleteFeatureFlag removes a flag set for a pipeline, or for the
+// installation when pipelineID is empty.
+func (s *PostgresStorage) DeleteFeatureFlag(ctx context.Context, pipelineID, name string) error {
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM feature_flags
+		WHERE name = $1 AND COALESCE(pipeline_id, '') = $2
+	`, name, pipelineID)
+	if err != nil {
+		return fmt.Errorf("delete feature flag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimJobLease takes the lease row of the job when it is missing, expired or
+// held by holder already. Concurrent claims serialize on the row, so only one
+// API replica holds a lease at a time.
+func (s *PostgresStorage) ClaimJobLease(ctx context.Context, job, holder string, lease time.Duration) (bool, error) {
+	var claimed string
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO job_leases (job, holder, expires_at)
+		VALUES ($1, $2, NOW() + $3::interval)
+		ON CONFLICT (job) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE job_leases.holder = EXCLUDED.holder OR job_leases.expires_at <= NOW()
+		RETURNING holder
+	`, job, holder, lease.String()).Scan(&claimed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("claim job lease: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// pgForeignKeyViolation is the error code of an insert referencing a row
+// that does not exist.
+const pgForeignKeyViolation = "23503"
+
+// insertOutboxAction stores an orchestrator action in tx. The reconciler
+// leaves it to the API request that stored it for the lease.
+func insertOutboxAction(ctx context.Context, tx pgx.Tx, action models.OutboxAction) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO orchestrator_outbox (id, pipeline_id, action, next_attempt_at)
+		VALUES ($1, $2, $3, NOW() + $4::interval)
+	`, action.ID, action.PipelineID, action.Action, internal.OutboxActionLease.String())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return service.ErrPipelineNotExists
+		}
+		return fmt.Errorf("insert outbox action: %w", err)
+	}
+	return nil
+}
+
+// EnqueueOutboxAction stores an orchestrator action of an existing pipeline.
+func (s *PostgresStorage) EnqueueOutboxAction(ctx context.Context, action models.OutboxAction) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertOutboxAction(ctx, tx, action)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxActions returns the actions whose lease expired, oldest first,
+// and leases them for another lease. API replicas claim different actions.
+func (s *PostgresStorage) ClaimOutboxActions(ctx context.Context, lease time.Duration, limit int) ([]models.OutboxAction, error) {
+	rows, err := s.pool.Query(ctx, `
+		UPDATE orchestrator_outbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + $1::interval, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM orchestrator_outbox
+			WHERE next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, pipeline_id, action, attempts, COALESCE(last_error, ''), created_at
+	`, lease.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []models.OutboxAction
+	for rows.Next() {
+		var action models.OutboxAction
+		err = rows.Scan(&action.ID, &action.PipelineID, &action.Action, &action.Attempts, &action.LastError, &action.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan outbox action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read outbox actions: %w", err)
+	}
+
+	return actions, nil
+}
+
+// CompleteOutboxAction removes an action that ran or was given up on.
+func (s *PostgresStorage) CompleteOutboxAction(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM orchestrator_outbox WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete outbox action: %w", err)
+	}
+	return nil
+}
+
+// FailOutboxAction records the error of a failed run, the action is retried
+// once its lease expires.
+func (s *PostgresStorage) FailOutboxAction(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE orchestrator_outbox SET last_error = $1, updated_at = NOW() WHERE id = $2
+	`, reason, id)
+	if err != nil {
+		return fmt.Errorf("update outbox action: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/service"
+)
+
+// ArchivePipeline stores the definition of a stopped pipeline in the
+// archive, with its credentials encrypted. A pipeline archived again
+// replaces its previous definition.
+func (s *PostgresStorage) ArchivePipeline(ctx context.Context, p models.PipelineConfig) error {
+	pipelineJSON, err := marshalPipelineEncrypted(s.encryptionService, p)
+	if err != nil {
+		return fmt.Errorf("marshal archived pipeline: %w", err)
+	}
+	metadata, err := json.Marshal(p.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal archived pipeline metadata: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO pipeline_archive (pipeline_id, name, metadata, pipeline, stopped_at, archived_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (pipeline_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			metadata = EXCLUDED.metadata,
+			pipeline = EXCLUDED.pipeline,
+			stopped_at = EXCLUDED.stopped_at,
+			archived_at = EXCLUDED.archived_at
+	`, p.ID, p.Name, metadata, pipelineJSON, p.Status.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert archived pipeline: %w", err)
+	}
+	return nil
+}
+
+// ListArchivedPipelines returns the archived pipelines, the last archived
+// first.
+func (s *PostgresStorage) ListArchivedPipelines(ctx context.Context) ([]models.ArchivedPipeline, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT pipeline_id, name, metadata, stopped_at, archived_at FROM pipeline_archive
+		ORDER BY archived_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query archived pipelines: %w", err)
+	}
+	defer rows.Close()
+
+	archived := []models.ArchivedPipeline{}
+	for rows.Next() {
+		var (
+			a        models.ArchivedPipeline
+			metadata []byte
+		)
+		err := rows.Scan(&a.PipelineID, &a.Name, &metadata, &a.StoppedAt, &a.ArchivedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan archived pipeline: %w", err)
+		}
+		if err := json.Unmarshal(metadata, &a.Metadata); err != nil {
+			return nil, fmt.Errorf("unmarshal archived pipeline metadata: %w", err)
+		}
+		archived = append(archived, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read archived pipelines: %w", err)
+	}
+
+	return archived, nil
+}
+
+// GetArchivedPipeline returns the definition of an archived pipeline, with
+// its credentials decrypted.
+func (s *PostgresStorage) GetArchivedPipeline(ctx context.Context, id string) (models.PipelineConfig, error) {
+	var pipelineJSON []byte
+	err := s.pool.QueryRow(ctx, `SELECT pipeline FROM pipeline_archive WHERE pipeline_id = $1`, id).Scan(&pipelineJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PipelineConfig{}, service.ErrPipelineNotArchived
+		}
+		return models.PipelineConfig{}, fmt.Errorf("get archived pipeline: %w", err)
+	}
+
+	pipeline, err := unmarshalPipelineDecrypted(s.encryptionService, pipelineJSON)
+	if err != nil {
+		return models.PipelineConfig{}, fmt.Errorf("read archived pipeline: %w", err)
+	}
+	return pipeline, nil
+}
+
+// DeleteArchivedPipeline removes a pipeline from the archive.
+func (s *PostgresStorage) DeleteArchivedPipeline(ctx context.Context, id string) error {
+	commandTag, err := s.pool.Exec(ctx, `DELETE FROM pipeline_archive WHERE pipeline_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete archived pipeline: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return service.ErrPipelineNotArchived
+	}
+	return nil
+}
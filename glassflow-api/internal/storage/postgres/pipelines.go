@@ -35,6 +35,9 @@ type HistoryEntry struct {
 	Type     string          // "history", "error", or "status"
 	Pipeline json.RawMessage // Full pipeline JSON
 	Errors   []string        // Array of error messages (for error type)
+	Owner    string          `json:",omitempty"` // Ownership of the pipeline at the event
+	Team     string          `json:",omitempty"`
+	Contact  string          `json:",omitempty"`
 }
 
 // pipelineData holds all the data needed to reconstruct a PipelineConfig
@@ -49,8 +52,11 @@ type pipelineData struct {
 	chConn          json.RawMessage
 	transformations map[string]Transformation
 	metadataJSON    []byte
-	createdAt       time.Time
-	updatedAt       time.Time
+	configHash      string
+	// pausedComponents are the components paused on their own
+	pausedComponents []string
+	createdAt        time.Time
+	updatedAt        time.Time
 }
 
 // GetPipeline retrieves a pipeline by ID and reconstructs PipelineConfig
@@ -118,11 +124,26 @@ func (s *PostgresStorage) GetPipelineWithSchemaVersions(
 
 // GetPipelines retrieves all pipelines
 func (s *PostgresStorage) GetPipelines(ctx context.Context) ([]models.PipelineConfig, error) {
+	return s.queryPipelines(ctx, "")
+}
+
+// GetPipelinesByTags returns the pipelines carrying all the tags, looked up
+// in the tags index.
+func (s *PostgresStorage) GetPipelinesByTags(ctx context.Context, tags []string) ([]models.PipelineConfig, error) {
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tags: %w", err)
+	}
+	return s.queryPipelines(ctx, "WHERE metadata -> 'tags' @> $1::jsonb", string(tagsJSON))
+}
+
+func (s *PostgresStorage) queryPipelines(ctx context.Context, where string, args ...any) ([]models.PipelineConfig, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT id, name, status, source_id, sink_id, transformation_ids, metadata, created_at, updated_at
+		SELECT id, name, status, source_id, sink_id, transformation_ids, metadata, config_hash, paused_components, created_at, updated_at
 		FROM pipelines
+		`+where+`
 		ORDER BY created_at DESC
-	`)
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query pipelines: %w", err)
 	}
@@ -141,6 +162,8 @@ func (s *PostgresStorage) GetPipelines(ctx context.Context) ([]models.PipelineCo
 			&row.sinkID,
 			&transformationIDsArray,
 			&row.metadataJSON,
+			&row.configHash,
+			&row.pausedComponents,
 			&row.createdAt,
 			&row.updatedAt,
 		); err != nil {
@@ -195,6 +218,16 @@ func (s *PostgresStorage) reconstructPipelineFromData(ctx context.Context, data
 
 // InsertPipeline inserts a new pipeline and its related entities
 func (s *PostgresStorage) InsertPipeline(ctx context.Context, p models.PipelineConfig) error {
+	return s.insertPipeline(ctx, p, nil)
+}
+
+// InsertPipelineWithAction inserts the pipeline and the orchestrator action
+// to run for it in one transaction.
+func (s *PostgresStorage) InsertPipelineWithAction(ctx context.Context, p models.PipelineConfig, action models.OutboxAction) error {
+	return s.insertPipeline(ctx, p, &action)
+}
+
+func (s *PostgresStorage) insertPipeline(ctx context.Context, p models.PipelineConfig, action *models.OutboxAction) error {
 	s.logger.InfoContext(ctx, "inserting pipeline",
 		slog.String("pipeline_id", p.ID),
 		slog.String("pipeline_name", p.Name))
@@ -205,9 +238,9 @@ func (s *PostgresStorage) InsertPipeline(ctx context.Context, p models.PipelineC
 	}
 	defer tx.Rollback(ctx)
 
-	// Insert source (Kafka or OTLP)
+	// Insert source (Kafka, Pulsar or receiver fed)
 	var sourceID uuid.UUID
-	if p.SourceType.IsOTLP() {
+	if p.SourceType.UsesReceiver() {
 		sourceID, err = s.insertOTLPSource(ctx, tx, p)
 	} else {
 		sourceID, err = s.insertKafkaSource(ctx, tx, p)
@@ -236,9 +269,9 @@ func (s *PostgresStorage) InsertPipeline(ctx context.Context, p models.PipelineC
 
 	// Insert pipeline record FIRST (required for foreign key constraints)
 	_, err = tx.Exec(ctx, `
-		INSERT INTO pipelines (id, name, status, source_id, sink_id, transformation_ids, metadata, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`, insertData.pipelineID, insertData.name, insertData.status, insertData.sourceID, insertData.sinkID, insertData.transformationIDsArg, string(insertData.metadataJSON), "v3", insertData.createdAt, insertData.updatedAt)
+		INSERT INTO pipelines (id, name, status, source_id, sink_id, transformation_ids, metadata, config_hash, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, insertData.pipelineID, insertData.name, insertData.status, insertData.sourceID, insertData.sinkID, insertData.transformationIDsArg, string(insertData.metadataJSON), insertData.configHash, "v3", insertData.createdAt, insertData.updatedAt)
 	if err != nil {
 		return fmt.Errorf("insert pipeline: %w", err)
 	}
@@ -295,6 +328,13 @@ func (s *PostgresStorage) InsertPipeline(ctx context.Context, p models.PipelineC
 		return fmt.Errorf("insert pipeline history event: %w", err)
 	}
 
+	if action != nil {
+		err = insertOutboxAction(ctx, tx, *action)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit transaction: %w", err)
 	}
@@ -390,6 +430,16 @@ func (s *PostgresStorage) UpdatePipelineStatus(ctx context.Context, id string, s
 
 // UpdatePipeline updates an existing pipeline
 func (s *PostgresStorage) UpdatePipeline(ctx context.Context, id string, newCfg models.PipelineConfig) error {
+	return s.updatePipeline(ctx, id, newCfg, nil)
+}
+
+// UpdatePipelineWithAction updates the pipeline and inserts the orchestrator
+// action to run for it in one transaction.
+func (s *PostgresStorage) UpdatePipelineWithAction(ctx context.Context, id string, newCfg models.PipelineConfig, action models.OutboxAction) error {
+	return s.updatePipeline(ctx, id, newCfg, &action)
+}
+
+func (s *PostgresStorage) updatePipeline(ctx context.Context, id string, newCfg models.PipelineConfig, action *models.OutboxAction) error {
 	s.logger.InfoContext(ctx, "updating pipeline",
 		slog.String("pipeline_id", id),
 		slog.String("pipeline_name", newCfg.Name))
@@ -452,7 +502,7 @@ func (s *PostgresStorage) UpdatePipeline(ctx context.Context, id string, newCfg
 	}
 
 	// Update source
-	if sourceType == internal.KafkaIngestorType {
+	if models.SourceType(sourceType).UsesIngestor() {
 		err = s.updateKafkaSource(ctx, tx, uuid.UUID(kafkaConnID.Bytes), oldRow.sourceID, newCfg)
 		if err != nil {
 			return err
@@ -486,9 +536,9 @@ func (s *PostgresStorage) UpdatePipeline(ctx context.Context, id string, newCfg
 	// Update pipeline
 	_, err = tx.Exec(ctx, `
 		UPDATE pipelines
-		SET name = $1, status = $2, transformation_ids = $3, metadata = $4, version = $5, updated_at = $6
-		WHERE id = $7
-	`, updateData.name, updateData.status, updateData.transformationIDsArg, string(updateData.metadataJSON), "v3", updateData.updatedAt, updateData.pipelineID)
+		SET name = $1, status = $2, transformation_ids = $3, metadata = $4, config_hash = $5, version = $6, updated_at = $7
+		WHERE id = $8
+	`, updateData.name, updateData.status, updateData.transformationIDsArg, string(updateData.metadataJSON), updateData.configHash, "v3", updateData.updatedAt, updateData.pipelineID)
 	if err != nil {
 		return fmt.Errorf("update pipeline: %w", err)
 	}
@@ -510,6 +560,13 @@ func (s *PostgresStorage) UpdatePipeline(ctx context.Context, id string, newCfg
 		return fmt.Errorf("insert pipeline history event: %w", err)
 	}
 
+	if action != nil {
+		err = insertOutboxAction(ctx, tx, *action)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit transaction: %w", err)
 	}
@@ -548,6 +605,70 @@ func (s *PostgresStorage) PatchPipelineName(ctx context.Context, id, name string
 	return nil
 }
 
+// ConfirmSinkStaging removes the staging config from the pipeline sink, which
+// switches a running sink from the staging table to the sink table.
+func (s *PostgresStorage) ConfirmSinkStaging(ctx context.Context, id string) error {
+	pipelineID, err := parsePipelineID(id)
+	if err != nil {
+		return err
+	}
+
+	commandTag, err := s.pool.Exec(ctx, `
+		UPDATE connections
+		SET config = config - 'staging', updated_at = NOW()
+		WHERE id = (
+			SELECT s.connection_id
+			FROM pipelines p
+			JOIN sinks s ON s.id = p.sink_id
+			WHERE p.id = $1
+		)
+	`, pipelineID)
+	if err != nil {
+		return fmt.Errorf("confirm sink staging: %w", err)
+	}
+
+	if err := checkRowsAffected(commandTag.RowsAffected()); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "pipeline sink staging confirmed",
+		slog.String("pipeline_id", id))
+
+	return nil
+}
+
+// SetPausedComponents replaces the components of the pipeline paused on
+// their own, the running components notice the change.
+func (s *PostgresStorage) SetPausedComponents(ctx context.Context, id string, components []string) error {
+	pipelineID, err := parsePipelineID(id)
+	if err != nil {
+		return err
+	}
+
+	if components == nil {
+		components = []string{}
+	}
+
+	commandTag, err := s.pool.Exec(ctx, `
+		UPDATE pipelines
+		SET paused_components = $1, updated_at = NOW()
+		WHERE id = $2
+	`, components, pipelineID)
+	if err != nil {
+		return fmt.Errorf("update paused components: %w", err)
+	}
+
+	if err := checkRowsAffected(commandTag.RowsAffected()); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "pipeline paused components updated",
+		slog.String("pipeline_id", id),
+		slog.Any("paused_components", components))
+
+	return nil
+}
+
 // PatchPipelineMetadata updates only the pipeline metadata
 func (s *PostgresStorage) PatchPipelineMetadata(ctx context.Context, id string, metadata models.PipelineMetadata) error {
 	pipelineID, err := parsePipelineID(id)
@@ -586,27 +707,8 @@ func (s *PostgresStorage) insertPipelineHistoryEvent(ctx context.Context, tx pgx
 		eventType = "history"
 	}
 
-	pipelineForHistory := pipeline
-	if s.encryptionService != nil {
-		var copy models.PipelineConfig
-		raw, err := json.Marshal(pipeline)
-		if err != nil {
-			return fmt.Errorf("copy pipeline for history: %w", err)
-		}
-		if err := json.Unmarshal(raw, &copy); err != nil {
-			return fmt.Errorf("copy pipeline for history: %w", err)
-		}
-		if err := encryptKafkaFields(s.encryptionService, &copy.Ingestor); err != nil {
-			return fmt.Errorf("encrypt kafka fields for history: %w", err)
-		}
-		if err := encryptClickHouseFields(s.encryptionService, &copy.Sink); err != nil {
-			return fmt.Errorf("encrypt clickhouse fields for history: %w", err)
-		}
-		pipelineForHistory = copy
-	}
-
-	// Marshal pipeline to JSON for history (credentials already encrypted if encryption enabled)
-	pipelineJSON, err := json.Marshal(pipelineForHistory)
+	// Marshal pipeline to JSON for history (credentials encrypted if encryption enabled)
+	pipelineJSON, err := marshalPipelineEncrypted(s.encryptionService, pipeline)
 	if err != nil {
 		return fmt.Errorf("marshal pipeline for history: %w", err)
 	}
@@ -616,6 +718,9 @@ func (s *PostgresStorage) insertPipelineHistoryEvent(ctx context.Context, tx pgx
 		Type:     eventType,
 		Pipeline: pipelineJSON,
 		Errors:   errors,
+		Owner:    pipeline.Metadata.Owner,
+		Team:     pipeline.Metadata.Team,
+		Contact:  pipeline.Metadata.Contact,
 	}
 
 	eventJSON, err := json.Marshal(event)
@@ -754,13 +859,16 @@ func (s *PostgresStorage) DeletePipeline(ctx context.Context, id string) error {
 
 // ------------------------------------------------------------------------------------------------
 
-// insertKafkaSource inserts Kafka connection and source
+// insertKafkaSource inserts the connection and source of an ingestor source.
+// Pulsar and MySQL sources share the Kafka layout, only the connection and source type differ.
 func (s *PostgresStorage) insertKafkaSource(ctx context.Context, tx pgx.Tx, p models.PipelineConfig) (uuid.UUID, error) {
 	kafkaConnConfig := models.IngestorComponentConfig{
-		Provider:              p.Ingestor.Provider,
-		KafkaTopics:           p.Ingestor.KafkaTopics,
-		KafkaConnectionParams: p.Ingestor.KafkaConnectionParams,
-		Type:                  p.Ingestor.Type,
+		Provider:               p.Ingestor.Provider,
+		KafkaTopics:            p.Ingestor.KafkaTopics,
+		KafkaConnectionParams:  p.Ingestor.KafkaConnectionParams,
+		PulsarConnectionParams: p.Ingestor.PulsarConnectionParams,
+		MySQLConnectionParams:  p.Ingestor.MySQLConnectionParams,
+		Type:                   p.Ingestor.Type,
 	}
 
 	connBytes, err := json.Marshal(kafkaConnConfig)
@@ -768,12 +876,12 @@ func (s *PostgresStorage) insertKafkaSource(ctx context.Context, tx pgx.Tx, p mo
 		return uuid.Nil, fmt.Errorf("marshal kafka connection config: %w", err)
 	}
 
-	kafkaConnID, err := s.insertConnectionWithConfig(ctx, tx, "kafka", connBytes)
+	kafkaConnID, err := s.insertConnectionWithConfig(ctx, tx, ingestorConnectionType(p), connBytes)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("insert kafka connection: %w", err)
 	}
 
-	sourceID, err := s.insertSource(ctx, tx, p.ID, "kafka", &kafkaConnID, p.Mapper.Streams)
+	sourceID, err := s.insertSource(ctx, tx, p.ID, ingestorConnectionType(p), &kafkaConnID, p.Mapper.Streams)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("insert source: %w", err)
 	}
@@ -781,6 +889,18 @@ func (s *PostgresStorage) insertKafkaSource(ctx context.Context, tx pgx.Tx, p mo
 	return sourceID, nil
 }
 
+// ingestorConnectionType returns the connection and source type of an ingestor source.
+func ingestorConnectionType(p models.PipelineConfig) string {
+	switch {
+	case p.SourceType.IsPulsar():
+		return internal.PulsarIngestorType
+	case p.SourceType.IsMySQL():
+		return internal.MySQLIngestorType
+	default:
+		return internal.KafkaIngestorType
+	}
+}
+
 // insertOTLPSource inserts an OTLP source (no Kafka connection)
 func (s *PostgresStorage) insertOTLPSource(ctx context.Context, tx pgx.Tx, p models.PipelineConfig) (uuid.UUID, error) {
 	configJSON, err := json.Marshal(p.OTLPSource)
@@ -807,17 +927,19 @@ func (s *PostgresStorage) insertOTLPSource(ctx context.Context, tx pgx.Tx, p mod
 // updateKafkaSource updates Kafka connection and source
 func (s *PostgresStorage) updateKafkaSource(ctx context.Context, tx pgx.Tx, kafkaConnID uuid.UUID, sourceID uuid.UUID, p models.PipelineConfig) error {
 	ingestorConnConfig := models.IngestorComponentConfig{
-		KafkaConnectionParams: p.Ingestor.KafkaConnectionParams,
-		KafkaTopics:           p.Ingestor.KafkaTopics,
-		Provider:              p.Ingestor.Provider,
-		Type:                  p.Ingestor.Type,
+		KafkaConnectionParams:  p.Ingestor.KafkaConnectionParams,
+		PulsarConnectionParams: p.Ingestor.PulsarConnectionParams,
+		MySQLConnectionParams:  p.Ingestor.MySQLConnectionParams,
+		KafkaTopics:            p.Ingestor.KafkaTopics,
+		Provider:               p.Ingestor.Provider,
+		Type:                   p.Ingestor.Type,
 	}
 	connBytes, err := json.Marshal(ingestorConnConfig)
 	if err != nil {
 		return fmt.Errorf("marshal kafka connection config: %w", err)
 	}
 
-	err = s.updateConnectionWithConfig(ctx, tx, kafkaConnID, "kafka", connBytes)
+	err = s.updateConnectionWithConfig(ctx, tx, kafkaConnID, ingestorConnectionType(p), connBytes)
 	if err != nil {
 		return fmt.Errorf("update kafka connection: %w", err)
 	}
@@ -835,13 +957,13 @@ func (s *PostgresStorage) upsertPipelineSourceSchemaVersions(ctx context.Context
 		return nil
 	}
 
-	if p.SourceType.IsKafka() {
+	if p.SourceType.UsesIngestor() {
 		for _, topic := range p.Ingestor.KafkaTopics {
 			if err := s.upsertSourceSchemaVersion(ctx, tx, pipelineID, p, topic.ID); err != nil {
 				return err
 			}
 		}
-	} else if p.SourceType.IsOTLP() {
+	} else if p.SourceType.UsesReceiver() {
 		return s.upsertSourceSchemaVersion(ctx, tx, pipelineID, p, p.OTLPSource.ID)
 	} else {
 		return fmt.Errorf("unsupported source type '%s' for schema version upsert", p.SourceType)
@@ -874,6 +996,12 @@ func (s *PostgresStorage) insertClickHouseSink(ctx context.Context, tx pgx.Tx, p
 		Batch:                      p.Sink.Batch,
 		Type:                       p.Sink.Type,
 		NATSConsumerName:           p.Sink.NATSConsumerName,
+		CreateTable:                p.Sink.CreateTable,
+		ErrorTable:                 p.Sink.ErrorTable,
+		InsertDeduplication:        p.Sink.InsertDeduplication,
+		Staging:                    p.Sink.Staging,
+		AsyncInsert:                p.Sink.AsyncInsert,
+		WriterConcurrency:          p.Sink.WriterConcurrency,
 	}
 
 	connBytes, err := json.Marshal(sinkConnConfig)
@@ -901,6 +1029,12 @@ func (s *PostgresStorage) updateClickHouseSink(ctx context.Context, tx pgx.Tx, c
 		Batch:                      p.Sink.Batch,
 		NATSConsumerName:           p.Sink.NATSConsumerName,
 		Type:                       p.Sink.Type,
+		CreateTable:                p.Sink.CreateTable,
+		ErrorTable:                 p.Sink.ErrorTable,
+		InsertDeduplication:        p.Sink.InsertDeduplication,
+		Staging:                    p.Sink.Staging,
+		AsyncInsert:                p.Sink.AsyncInsert,
+		WriterConcurrency:          p.Sink.WriterConcurrency,
 	}
 
 	connBytes, err := json.Marshal(sinkConnConfig)
@@ -944,6 +1078,7 @@ type pipelineInsertData struct {
 	sinkID               uuid.UUID
 	transformationIDsArg pgtype.Array[pgtype.UUID]
 	metadataJSON         []byte
+	configHash           string
 	createdAt            time.Time
 	updatedAt            time.Time
 }
@@ -975,6 +1110,7 @@ func (s *PostgresStorage) preparePipelineUpdateData(p models.PipelineConfig, sou
 		sinkID:               sinkID,
 		transformationIDsArg: transformationIDsArg,
 		metadataJSON:         metadataJSON,
+		configHash:           p.ConfigHash,
 		createdAt:            oldCreatedAt, // Preserve original created_at
 		updatedAt:            time.Now().UTC(),
 	}, nil
@@ -1007,6 +1143,7 @@ func (s *PostgresStorage) preparePipelineInsertData(p models.PipelineConfig, sou
 		sinkID:               sinkID,
 		transformationIDsArg: transformationIDsArg,
 		metadataJSON:         metadataJSON,
+		configHash:           p.ConfigHash,
 		createdAt:            p.CreatedAt,
 		updatedAt:            time.Now().UTC(),
 	}, nil
@@ -1041,6 +1178,8 @@ type pipelineRow struct {
 	sinkID               uuid.UUID
 	transformationIDsPtr *[]uuid.UUID
 	metadataJSON         []byte
+	configHash           string
+	pausedComponents     []string
 	createdAt            time.Time
 	updatedAt            time.Time
 }
@@ -1051,7 +1190,7 @@ func (s *PostgresStorage) loadPipelineRow(ctx context.Context, pipelineID string
 	var transformationIDsArray pgtype.Array[pgtype.UUID]
 
 	err := s.pool.QueryRow(ctx, `
-		SELECT id, name, status, source_id, sink_id, transformation_ids, metadata, created_at, updated_at
+		SELECT id, name, status, source_id, sink_id, transformation_ids, metadata, config_hash, paused_components, created_at, updated_at
 		FROM pipelines
 		WHERE id = $1
 	`, pipelineID).Scan(
@@ -1062,6 +1201,8 @@ func (s *PostgresStorage) loadPipelineRow(ctx context.Context, pipelineID string
 		&row.sinkID,
 		&transformationIDsArray,
 		&row.metadataJSON,
+		&row.configHash,
+		&row.pausedComponents,
 		&row.createdAt,
 		&row.updatedAt,
 	)
@@ -1137,18 +1278,20 @@ func (s *PostgresStorage) buildPipelineData(ctx context.Context, row *pipelineRo
 	}
 
 	return &pipelineData{
-		pipelineID:      row.pipelineID,
-		name:            row.name,
-		status:          row.status,
-		sourceType:      sourceType,
-		source:          source,
-		kafkaConn:       kafkaConn,
-		sink:            sink,
-		chConn:          chConn,
-		transformations: transformations,
-		metadataJSON:    row.metadataJSON,
-		createdAt:       row.createdAt,
-		updatedAt:       row.updatedAt,
+		pipelineID:       row.pipelineID,
+		name:             row.name,
+		status:           row.status,
+		sourceType:       sourceType,
+		source:           source,
+		kafkaConn:        kafkaConn,
+		sink:             sink,
+		chConn:           chConn,
+		transformations:  transformations,
+		metadataJSON:     row.metadataJSON,
+		configHash:       row.configHash,
+		pausedComponents: row.pausedComponents,
+		createdAt:        row.createdAt,
+		updatedAt:        row.updatedAt,
 	}, nil
 }
 
@@ -1205,7 +1348,7 @@ func (s *PostgresStorage) loadConfigsAndSchemaVersionsWithSelection(
 		pipelineCfg.SchemaVersions[topic.ID] = schemaVersion
 	}
 
-	if pipelineCfg.SourceType.IsOTLP() {
+	if pipelineCfg.SourceType.UsesReceiver() {
 		otlpSourceID := pipelineCfg.OTLPSource.ID
 		var schemaVersion models.SchemaVersion
 
@@ -1255,67 +1398,19 @@ func (s *PostgresStorage) loadConfigsAndSchemaVersionsWithSelection(
 			return fmt.Errorf("join has not enought sources")
 		}
 
-		var leftSource, rightSource string
-		if pipelineCfg.Join.Sources[0].Orientation == internal.JoinLeft {
-			leftSource = pipelineCfg.Join.Sources[0].SourceID
-			rightSource = pipelineCfg.Join.Sources[1].SourceID
-		} else {
-			leftSource = pipelineCfg.Join.Sources[1].SourceID
-			rightSource = pipelineCfg.Join.Sources[0].SourceID
-		}
-
-		leftSchemaVersionID, found := pipelineCfg.SchemaVersions[leftSource]
-		if !found {
-			return fmt.Errorf("not found schema version for left source '%s'", leftSource)
-		}
-
-		rightSchemaVersionID, found := pipelineCfg.SchemaVersions[rightSource]
-		if !found {
-			return fmt.Errorf("not found schema version for right source '%s'", rightSource)
-		}
-
-		joinID, joinSchemaVersionID, err := s.getJoinIDAndOutputSchemaID(
-			ctx,
-			tx,
-			pipelineCfg.ID,
-			leftSource,
-			leftSchemaVersionID.VersionID,
-			rightSource,
-			rightSchemaVersionID.VersionID)
-		if err != nil {
-			return fmt.Errorf("was not found common join id and join schema version for pipline ID %s %s:%s %s:%s",
-				pipelineCfg.ID, leftSource, leftSchemaVersionID, rightSource, rightSchemaVersionID)
-		}
-		if joinID != pipelineCfg.Join.ID {
-			return fmt.Errorf("join id is not matched with stored one")
-		}
-
-		jCfgs, err := s.getJoinConfigsByOutputVersion(ctx, tx, pipelineCfg.ID, pipelineCfg.Join.ID, joinSchemaVersionID)
-		if err != nil {
-			return fmt.Errorf("get join configs by output version: %w", err)
-		}
-
-		joinRules := make(map[string]struct{})
-		uniqueRules := make([]models.JoinRule, 0)
-
-		for _, jCfg := range jCfgs {
-			for _, rule := range jCfg.Config {
-				_, exists := joinRules[rule.OutputName]
-				if !exists {
-					joinRules[rule.OutputName] = struct{}{}
-					uniqueRules = append(uniqueRules, rule)
-				}
+		// The stages of a chained join are loaded in order, each reads the
+		// output schema version of the stage before it. Their rules read
+		// prefixed intermediate fields, the output fields of a chained join
+		// stay the ones of its stored config.
+		for _, stage := range pipelineCfg.Join.Stages() {
+			rules, err := s.loadJoinStage(ctx, tx, pipelineCfg, stage)
+			if err != nil {
+				return err
+			}
+			if len(pipelineCfg.Join.Chain) == 0 {
+				pipelineCfg.Join.Config = rules
 			}
 		}
-
-		pipelineCfg.Join.Config = uniqueRules
-
-		outputSchema, err := s.getSchemaVersion(ctx, tx, pipelineCfg.ID, pipelineCfg.Join.ID, joinSchemaVersionID)
-		if err != nil {
-			return fmt.Errorf("get output schema version for join: %w", err)
-		}
-
-		pipelineCfg.SchemaVersions[pipelineCfg.Join.ID] = outputSchema
 	}
 
 	sinkSourceID, err := s.getSinkSourceID(ctx, tx, pipelineCfg.ID)
@@ -1342,3 +1437,74 @@ func (s *PostgresStorage) loadConfigsAndSchemaVersionsWithSelection(
 
 	return nil
 }
+
+// loadJoinStage loads the output schema version of a join stage for the
+// schema versions of its sources and returns its join rules.
+func (s *PostgresStorage) loadJoinStage(
+	ctx context.Context,
+	tx pgx.Tx,
+	pipelineCfg *models.PipelineConfig,
+	stage models.JoinComponentConfig,
+) ([]models.JoinRule, error) {
+	var leftSource, rightSource string
+	if stage.Sources[0].Orientation == internal.JoinLeft {
+		leftSource = stage.Sources[0].SourceID
+		rightSource = stage.Sources[1].SourceID
+	} else {
+		leftSource = stage.Sources[1].SourceID
+		rightSource = stage.Sources[0].SourceID
+	}
+
+	leftSchemaVersionID, found := pipelineCfg.SchemaVersions[leftSource]
+	if !found {
+		return nil, fmt.Errorf("not found schema version for left source '%s'", leftSource)
+	}
+
+	rightSchemaVersionID, found := pipelineCfg.SchemaVersions[rightSource]
+	if !found {
+		return nil, fmt.Errorf("not found schema version for right source '%s'", rightSource)
+	}
+
+	joinID, joinSchemaVersionID, err := s.getJoinIDAndOutputSchemaID(
+		ctx,
+		tx,
+		pipelineCfg.ID,
+		leftSource,
+		leftSchemaVersionID.VersionID,
+		rightSource,
+		rightSchemaVersionID.VersionID)
+	if err != nil {
+		return nil, fmt.Errorf("was not found common join id and join schema version for pipline ID %s %s:%s %s:%s",
+			pipelineCfg.ID, leftSource, leftSchemaVersionID, rightSource, rightSchemaVersionID)
+	}
+	if joinID != stage.ID {
+		return nil, fmt.Errorf("join id is not matched with stored one")
+	}
+
+	jCfgs, err := s.getJoinConfigsByOutputVersion(ctx, tx, pipelineCfg.ID, stage.ID, joinSchemaVersionID)
+	if err != nil {
+		return nil, fmt.Errorf("get join configs by output version: %w", err)
+	}
+
+	joinRules := make(map[string]struct{})
+	uniqueRules := make([]models.JoinRule, 0)
+
+	for _, jCfg := range jCfgs {
+		for _, rule := range jCfg.Config {
+			_, exists := joinRules[rule.OutputName]
+			if !exists {
+				joinRules[rule.OutputName] = struct{}{}
+				uniqueRules = append(uniqueRules, rule)
+			}
+		}
+	}
+
+	outputSchema, err := s.getSchemaVersion(ctx, tx, pipelineCfg.ID, stage.ID, joinSchemaVersionID)
+	if err != nil {
+		return nil, fmt.Errorf("get output schema version for join: %w", err)
+	}
+
+	pipelineCfg.SchemaVersions[stage.ID] = outputSchema
+
+	return uniqueRules, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/avast/retry-go/v4"
@@ -16,17 +17,52 @@ import (
 
 // PostgresStorage implements PipelineStore using PostgreSQL
 type PostgresStorage struct {
-	pool              *pgxpool.Pool
+	pool              *retryPool
 	logger            *slog.Logger
 	encryptionService *encryption.Service
 }
 
-// NewPostgres creates a new PostgresStorage instance with retry logic
-func NewPostgres(ctx context.Context, dsn string, logger *slog.Logger, encryptionKey []byte, role models.Role) (*PostgresStorage, error) {
+// PoolConfig tunes the connection pool of PostgresStorage and the retries of
+// statements failing with a transient error. Zero pool sizes and connection
+// lifetime keep the defaults of the role, a zero statement timeout or idle
+// time leaves them unlimited.
+type PoolConfig struct {
+	MaxConns         int32
+	MinConns         int32
+	MaxConnLifetime  time.Duration
+	MaxConnIdleTime  time.Duration
+	StatementTimeout time.Duration
+
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+// Option configures optional behaviour of PostgresStorage.
+type Option func(*PoolConfig)
+
+// WithPoolConfig replaces the default pool and retry configuration.
+func WithPoolConfig(cfg PoolConfig) Option {
+	return func(c *PoolConfig) {
+		*c = cfg
+	}
+}
+
+// NewPostgres creates a new PostgresStorage instance with retry logic. The
+// connection credentials are encrypted with a data key wrapped by kek, they
+// are stored in plaintext when kek is nil.
+func NewPostgres(ctx context.Context, dsn string, logger *slog.Logger, kek encryption.KeyEncryptionKey, role models.Role, opts ...Option) (*PostgresStorage, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	poolCfg := PoolConfig{
+		MaxRetries: internal.PostgresQueryRetries,
+		RetryDelay: internal.PostgresQueryRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(&poolCfg)
+	}
+
 	connCtx, cancel := context.WithTimeout(ctx, internal.PostgresMaxConnectionWait)
 	defer cancel()
 
@@ -50,6 +86,19 @@ func NewPostgres(ctx context.Context, dsn string, logger *slog.Logger, encryptio
 	}
 	config.MaxConnLifetime = 5 * time.Minute
 
+	if poolCfg.MaxConns > 0 {
+		config.MaxConns = poolCfg.MaxConns
+	}
+	if poolCfg.MinConns > 0 {
+		config.MinConns = min(poolCfg.MinConns, config.MaxConns)
+	}
+	if poolCfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = poolCfg.MaxConnLifetime
+	}
+	if poolCfg.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = poolCfg.MaxConnIdleTime
+	}
+
 	// Since we use pgbouncer pool, prepared statements might fail
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
 
@@ -57,6 +106,11 @@ func NewPostgres(ctx context.Context, dsn string, logger *slog.Logger, encryptio
 	config.ConnConfig.RuntimeParams = map[string]string{
 		"application_name": "glassflow_" + role.String(),
 	}
+	// Behind PGBouncer the startup parameter must be allowed by its
+	// track_extra_parameters, ignore_startup_parameters drops it
+	if poolCfg.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(poolCfg.StatementTimeout.Milliseconds(), 10)
+	}
 
 	var pool *pgxpool.Pool
 
@@ -108,23 +162,29 @@ func NewPostgres(ctx context.Context, dsn string, logger *slog.Logger, encryptio
 
 	logger.InfoContext(ctx, "postgres connection established",
 		slog.Int("max_conns", int(config.MaxConns)),
-		slog.Int("min_conns", int(config.MinConns)))
+		slog.Int("min_conns", int(config.MinConns)),
+		slog.Duration("statement_timeout", poolCfg.StatementTimeout),
+		slog.Int("max_retries", poolCfg.MaxRetries))
 
-	var encService *encryption.Service
-	if len(encryptionKey) > 0 {
-		var err error
-		encService, err = encryption.NewService(encryptionKey)
+	s := &PostgresStorage{
+		pool: &retryPool{
+			Pool:       pool,
+			maxRetries: poolCfg.MaxRetries,
+			retryDelay: poolCfg.RetryDelay,
+		},
+		logger: logger,
+	}
+
+	if kek != nil {
+		s.encryptionService, err = s.loadEncryptionService(ctx, kek)
 		if err != nil {
+			pool.Close()
 			return nil, fmt.Errorf("initialize encryption service: %w", err)
 		}
-		logger.InfoContext(ctx, "encryption enabled for connection credentials")
+		logger.InfoContext(ctx, "encryption enabled for connection credentials", slog.String("key_encryption_key", kek.ID()))
 	}
 
-	return &PostgresStorage{
-		pool:              pool,
-		logger:            logger,
-		encryptionService: encService,
-	}, nil
+	return s, nil
 }
 
 // Close closes the database connection pool
@@ -133,6 +193,23 @@ func (s *PostgresStorage) Close() error {
 	return nil
 }
 
+// HealthCheck reports whether the database answers a query within
+// PostgresHealthTimeout. It is not retried, a failing check must show.
+func (s *PostgresStorage) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, internal.PostgresHealthTimeout)
+	defer cancel()
+
+	if err := s.pool.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("ping postgres: %w", err)
+	}
+	return nil
+}
+
+// PoolStats returns the statistics of the connection pool.
+func (s *PostgresStorage) PoolStats() *pgxpool.Stat {
+	return s.pool.Stat()
+}
+
 // NewPool creates a bare pgxpool.Pool for use cases that don't need the full
 // PostgresStorage (e.g. running data migrations as an init container).
 func NewPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
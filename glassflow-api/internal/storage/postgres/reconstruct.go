@@ -64,6 +64,14 @@ func (s *PostgresStorage) reconstructPipelineConfig(ctx context.Context, data *p
 	if err != nil {
 		return nil, fmt.Errorf("reconstruct stateless transformation config: %w", err)
 	}
+	explodeConfig, err := reconstructExplodeConfig(data.transformations)
+	if err != nil {
+		return nil, fmt.Errorf("reconstruct explode config: %w", err)
+	}
+	enrichmentConfig, err := reconstructEnrichmentConfig(data.transformations)
+	if err != nil {
+		return nil, fmt.Errorf("reconstruct enrichment config: %w", err)
+	}
 
 	id := data.pipelineID
 	cfg := &models.PipelineConfig{
@@ -77,8 +85,12 @@ func (s *PostgresStorage) reconstructPipelineConfig(ctx context.Context, data *p
 		Sink:                    sinkComponentConfig,
 		Filter:                  filterConfig,
 		StatelessTransformation: statelessTransformationConfig,
+		Explode:                 explodeConfig,
+		Enrichment:              enrichmentConfig,
 		CreatedAt:               data.createdAt,
 		Metadata:                metadata,
+		ConfigHash:              data.configHash,
+		PausedComponents:        data.pausedComponents,
 		Status: models.PipelineHealth{
 			PipelineID:    id,
 			PipelineName:  data.name,
@@ -93,7 +105,7 @@ func (s *PostgresStorage) reconstructPipelineConfig(ctx context.Context, data *p
 
 // reconstructOTLPSourceConfig reconstructs OTLPSourceConfig from JSONB
 func reconstructOTLPSourceConfig(data *pipelineData) (zero models.OTLPSourceConfig, _ error) {
-	if !models.SourceType(data.sourceType).IsOTLP() {
+	if !models.SourceType(data.sourceType).UsesReceiver() {
 		return zero, nil
 	}
 
@@ -110,7 +122,7 @@ func reconstructOTLPSourceConfig(data *pipelineData) (zero models.OTLPSourceConf
 
 // reconstructKafkaConfig reconstructs Kafka connection config from JSONB
 func reconstructKafkaConfig(data *pipelineData) (zero models.IngestorComponentConfig, _ error) {
-	if data.sourceType != internal.KafkaIngestorType {
+	if !models.SourceType(data.sourceType).UsesIngestor() {
 		return zero, nil
 	}
 
@@ -191,6 +203,28 @@ func reconstructFilterConfig(transformations map[string]Transformation) (models.
 	return filterConfig, nil
 }
 
+// reconstructExplodeConfig reconstructs ExplodeConfig from transformations
+func reconstructExplodeConfig(transformations map[string]Transformation) (models.ExplodeConfig, error) {
+	var explodeConfig models.ExplodeConfig
+	if explodeTrans, ok := transformations["explode"]; ok {
+		if err := json.Unmarshal(explodeTrans.Config, &explodeConfig); err != nil {
+			return explodeConfig, fmt.Errorf("unmarshal explode config: %w", err)
+		}
+	}
+	return explodeConfig, nil
+}
+
+// reconstructEnrichmentConfig reconstructs EnrichmentConfig from transformations
+func reconstructEnrichmentConfig(transformations map[string]Transformation) (models.EnrichmentConfig, error) {
+	var enrichmentConfig models.EnrichmentConfig
+	if enrichmentTrans, ok := transformations["enrichment"]; ok {
+		if err := json.Unmarshal(enrichmentTrans.Config, &enrichmentConfig); err != nil {
+			return enrichmentConfig, fmt.Errorf("unmarshal enrichment config: %w", err)
+		}
+	}
+	return enrichmentConfig, nil
+}
+
 // reconstructStatelessTransformationConfig reconstructs StatelessTransformation from transformations
 func reconstructStatelessTransformationConfig(transformations map[string]Transformation) (models.StatelessTransformation, error) {
 	statelessConfig := models.StatelessTransformation{Enabled: false}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// retryPool retries the statements and transaction starts of the pool that
+// fail with a transient error, like a dropped connection or a restarting
+// PGBouncer. A statement is only retried when it did not run: either it was
+// never sent, or the server failed it outside of a transaction.
+type retryPool struct {
+	*pgxpool.Pool
+
+	maxRetries int
+	retryDelay time.Duration
+}
+
+func (p *retryPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	var tx pgx.Tx
+	err := p.retry(ctx, func() error {
+		var err error
+		tx, err = p.Pool.Begin(ctx)
+		return err
+	})
+	return tx, err
+}
+
+func (p *retryPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	var tx pgx.Tx
+	err := p.retry(ctx, func() error {
+		var err error
+		tx, err = p.Pool.BeginTx(ctx, txOptions)
+		return err
+	})
+	return tx, err
+}
+
+func (p *retryPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := p.retry(ctx, func() error {
+		var err error
+		tag, err = p.Pool.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+func (p *retryPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	var rows pgx.Rows
+	err := p.retry(ctx, func() error {
+		var err error
+		rows, err = p.Pool.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (p *retryPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return retryRow{pool: p, ctx: ctx, sql: sql, args: args}
+}
+
+// retryRow runs the query of QueryRow on Scan, where pgx reports its error.
+type retryRow struct {
+	pool *retryPool
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r retryRow) Scan(dest ...any) error {
+	return r.pool.retry(r.ctx, func() error {
+		return r.pool.Pool.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
+
+// retry runs fn until it succeeds, fails with an error that is not
+// transient or maxRetries retries were made. The delay between the attempts
+// doubles from retryDelay and is jittered to spread the retries of the
+// components reconnecting at once.
+func (p *retryPool) retry(ctx context.Context, fn func() error) error {
+	delay := p.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.maxRetries || !isTransientError(err) {
+			return err
+		}
+
+		jittered := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(jittered):
+		}
+		delay = min(2*delay, internal.PostgresQueryMaxRetryDelay)
+	}
+}
+
+// isTransientError reports whether err may succeed on a retry. pgx reports
+// errors of statements that were never sent as safe to retry. Among the
+// errors of the server, the connection errors, the ones of a server shutting
+// down, starting up or out of connections, and serialization failures and
+// deadlocks, which roll the statement back, are transient.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03", // cannot_connect_now
+			"53300", // too_many_connections
+			"40001", // serialization_failure
+			"40P01": // deadlock_detected
+			return true
+		}
+		// Class 08, connection exception
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -12,54 +13,110 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 )
 
+// upsertSchemaVersion stores the fields under the given version and returns the
+// version ID that holds them. Stored versions are immutable: messages already
+// stamped with a version must keep resolving to the fields they were produced
+// with, so changed fields are saved under a new version instead of
+// overwriting the requested one. An empty version always creates a new one.
 func (s *PostgresStorage) upsertSchemaVersion(ctx context.Context, tx pgx.Tx, pipelineID, sourceID, version string, fields []models.Field) (string, error) {
 	s.logger.DebugContext(ctx, "upsert schema version", "pipeline_id", pipelineID, "source_id", sourceID, "version", version)
-	// If version is empty, auto-increment from the latest version
-	if version == "" {
-		var latestVersion string
-		err := tx.QueryRow(ctx, `
-			SELECT version_id FROM schema_versions 
-			WHERE pipeline_id = $1 AND source_id = $2 
-			ORDER BY created_at DESC LIMIT 1
-		`, pipelineID, sourceID).Scan(&latestVersion)
-
-		if err != nil && err != pgx.ErrNoRows {
-			return "", fmt.Errorf("get latest version: %w", err)
-		}
 
-		// If no previous version exists, start with "1"
-		if errors.Is(err, pgx.ErrNoRows) || latestVersion == "" {
-			version = "1"
-		} else {
-			// Parse and increment
-			versionNum, parseErr := strconv.Atoi(latestVersion)
-			if parseErr != nil {
-				return "", fmt.Errorf("parse version '%s' as integer: %w", latestVersion, parseErr)
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("marshal fields: %w", err)
+	}
+
+	if version != "" {
+		existing, err := s.getSchemaVersion(ctx, tx, pipelineID, sourceID, version)
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+		case err != nil:
+			return "", err
+		default:
+			same, err := sameSchemaFields(existing.Fields, fieldsJSON)
+			if err != nil {
+				return "", err
+			}
+			if same {
+				return existing.VersionID, nil
+			}
+
+			// The fields changed, reuse the latest version if it already
+			// holds them, otherwise save them under a new version
+			latest, err := s.getLatestSchemaVersion(ctx, tx, pipelineID, sourceID)
+			if err != nil {
+				return "", err
+			}
+			same, err = sameSchemaFields(latest.Fields, fieldsJSON)
+			if err != nil {
+				return "", err
 			}
-			version = strconv.Itoa(versionNum + 1)
+			if same {
+				return latest.VersionID, nil
+			}
+
+			s.logger.InfoContext(ctx, "schema version fields changed, saving a new version",
+				"pipeline_id", pipelineID, "source_id", sourceID, "version", version)
+			version = ""
 		}
 	}
 
-	fieldsJSON, err := json.Marshal(fields)
-	if err != nil {
-		return "", fmt.Errorf("marshal fields: %w", err)
+	// If version is empty, auto-increment from the latest version
+	if version == "" {
+		version, err = s.nextSchemaVersionID(ctx, tx, pipelineID, sourceID)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	var versionID string
 	err = tx.QueryRow(ctx, `
 		INSERT INTO schema_versions (pipeline_id, source_id, version_id, data_format, fields)
 		VALUES ($1, $2, $3, 'json', $4)
-		ON CONFLICT (pipeline_id, source_id, version_id)
-		DO UPDATE SET fields = EXCLUDED.fields, updated_at = NOW()
 		RETURNING version_id
 	`, pipelineID, sourceID, version, string(fieldsJSON)).Scan(&versionID)
 	if err != nil {
-		return "", fmt.Errorf("upsert schema version: %w", err)
+		return "", fmt.Errorf("insert schema version: %w", err)
 	}
 
 	return versionID, nil
 }
 
+func (s *PostgresStorage) nextSchemaVersionID(ctx context.Context, tx pgx.Tx, pipelineID, sourceID string) (string, error) {
+	var latestVersion string
+	err := tx.QueryRow(ctx, `
+		SELECT version_id FROM schema_versions 
+		WHERE pipeline_id = $1 AND source_id = $2 
+		ORDER BY created_at DESC LIMIT 1
+	`, pipelineID, sourceID).Scan(&latestVersion)
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("get latest version: %w", err)
+	}
+
+	// If no previous version exists, start with "1"
+	if errors.Is(err, pgx.ErrNoRows) || latestVersion == "" {
+		return "1", nil
+	}
+
+	versionNum, err := strconv.Atoi(latestVersion)
+	if err != nil {
+		return "", fmt.Errorf("parse version '%s' as integer: %w", latestVersion, err)
+	}
+
+	return strconv.Itoa(versionNum + 1), nil
+}
+
+// sameSchemaFields compares stored fields with the marshalled new fields.
+func sameSchemaFields(existing []models.Field, fieldsJSON []byte) (bool, error) {
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		return false, fmt.Errorf("marshal fields: %w", err)
+	}
+
+	return bytes.Equal(existingJSON, fieldsJSON), nil
+}
+
 func (s *PostgresStorage) getSchemaVersion(ctx context.Context, tx pgx.Tx, pipelineID, sourceID, version string) (zero models.SchemaVersion, _ error) {
 	var (
 		schemaVersion models.SchemaVersion
@@ -0,0 +1,239 @@
+// Package schemamigrations applies the versioned migrations of the Postgres
+// schema embedded in the binary, with golang-migrate.
+package schemamigrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/migrations"
+)
+
+// lockTimeout is how long a migration waits for the Postgres advisory lock
+// golang-migrate holds while migrating, API replicas starting together wait
+// for the first one.
+const lockTimeout = 2 * time.Minute
+
+// pgUndefinedTable is the error code of a query on a missing table.
+const pgUndefinedTable = "42P01"
+
+// ErrDirty is returned when a migration failed half way: the schema must be
+// fixed by hand and its version forced before migrating again.
+var ErrDirty = errors.New("schema is dirty")
+
+// Migration is a versioned change of the schema.
+type Migration struct {
+	Version uint   `json:"version" doc:"Version of the migration"`
+	Name    string `json:"name" doc:"Name of the migration"`
+	Applied bool   `json:"applied" doc:"Whether the schema includes the migration"`
+}
+
+// Status is the version of the schema and the changelog of this release.
+type Status struct {
+	Version       uint        `json:"version" doc:"Version of the last applied migration, 0 before the first"`
+	Dirty         bool        `json:"dirty" doc:"Whether the last migration failed and the schema must be fixed by hand"`
+	LatestVersion uint        `json:"latest_version" doc:"Version of the latest migration of this release"`
+	Pending       int         `json:"pending" doc:"Number of migrations of this release not applied"`
+	Migrations    []Migration `json:"migrations" doc:"Migrations of this release by version"`
+}
+
+// Changelog returns the migrations embedded in the binary by version.
+func Changelog() ([]Migration, error) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	changelog := make([]Migration, 0, len(files))
+	for _, file := range files {
+		m, err := source.DefaultParse(file)
+		if err != nil {
+			return nil, fmt.Errorf("parse migration %s: %w", file, err)
+		}
+		changelog = append(changelog, Migration{Version: m.Version, Name: m.Identifier})
+	}
+	sort.Slice(changelog, func(i, j int) bool { return changelog[i].Version < changelog[j].Version })
+
+	return changelog, nil
+}
+
+// newStatus marks the migrations of the changelog the schema version
+// includes, a dirty version is not applied.
+func newStatus(changelog []Migration, version uint, dirty bool) Status {
+	status := Status{
+		Version:    version,
+		Dirty:      dirty,
+		Migrations: make([]Migration, 0, len(changelog)),
+	}
+
+	for _, m := range changelog {
+		m.Applied = m.Version < version || (m.Version == version && !dirty)
+		if !m.Applied {
+			status.Pending++
+		}
+		status.LatestVersion = max(status.LatestVersion, m.Version)
+		status.Migrations = append(status.Migrations, m)
+	}
+
+	return status
+}
+
+// Migrator migrates the schema of a database. golang-migrate locks the
+// database, one migration runs at a time.
+type Migrator struct {
+	databaseURL string
+	log         *slog.Logger
+}
+
+func NewMigrator(databaseURL string, log *slog.Logger) *Migrator {
+	return &Migrator{
+		databaseURL: databaseURL,
+		log:         log,
+	}
+}
+
+// Up applies the pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Up() })
+}
+
+// Down rolls back the last steps migrations with their down files.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Steps(-steps) })
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Migrate(version) })
+}
+
+// Force records version as applied and clean without migrating, after a
+// dirty schema was fixed by hand. -1 records no version.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Force(version) })
+}
+
+// Status reads the schema version. It only reads, unlike the migrations it
+// does not create the version table.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	changelog, err := Changelog()
+	if err != nil {
+		return Status{}, err
+	}
+
+	conn, err := pgx.Connect(ctx, m.databaseURL)
+	if err != nil {
+		return Status{}, fmt.Errorf("connect to postgres: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err = conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable, errors.Is(err, pgx.ErrNoRows):
+		return newStatus(changelog, 0, false), nil
+	case err != nil:
+		return Status{}, fmt.Errorf("read schema version: %w", err)
+	}
+
+	// A forced version of -1 records no migration
+	if version < 0 {
+		return newStatus(changelog, 0, dirty), nil
+	}
+	return newStatus(changelog, uint(version), dirty), nil
+}
+
+func (m *Migrator) run(ctx context.Context, fn func(*migrate.Migrate) error) error {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("open migrations: %w", err)
+	}
+
+	mg, err := migrate.NewWithSourceInstance("iofs", src, m.databaseURL)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() {
+		srcErr, dbErr := mg.Close()
+		if err := errors.Join(srcErr, dbErr); err != nil {
+			m.log.Warn("failed to close migrations", slog.Any("error", err))
+		}
+	}()
+	mg.Log = migrateLogger{log: m.log}
+	mg.LockTimeout = lockTimeout
+
+	// The running migration completes, the next ones are not started
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			mg.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	from, _, err := mg.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	err = fn(mg)
+	var dirtyErr migrate.ErrDirty
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		m.log.Info("schema is up to date", slog.Uint64("version", uint64(from)))
+		return nil
+	case errors.As(err, &dirtyErr):
+		return fmt.Errorf("%w: migration %d failed, fix the schema and force its version", ErrDirty, dirtyErr.Version)
+	case err != nil:
+		return fmt.Errorf("migrate schema: %w", err)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("migrate schema: %w", ctx.Err())
+	}
+
+	to, dirty, err := mg.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	m.log.Info("schema migrated",
+		slog.Uint64("from_version", uint64(from)),
+		slog.Uint64("to_version", uint64(to)),
+		slog.Bool("dirty", dirty))
+
+	return nil
+}
+
+// migrateLogger logs the migrations golang-migrate applies.
+type migrateLogger struct {
+	log *slog.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...any) {
+	l.log.Info("schema migration", slog.String("step", strings.TrimSpace(fmt.Sprintf(format, v...))))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
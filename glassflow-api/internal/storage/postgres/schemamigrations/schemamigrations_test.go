@@ -0,0 +1,66 @@
+package schemamigrations
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/migrations"
+)
+
+func TestChangelog(t *testing.T) {
+	changelog, err := Changelog()
+	require.NoError(t, err)
+	require.NotEmpty(t, changelog)
+
+	assert.Equal(t, Migration{Version: 1, Name: "initial_schema"}, changelog[0])
+	for i, m := range changelog {
+		assert.Equal(t, uint(i+1), m.Version, "migrations are numbered without gaps")
+	}
+}
+
+func TestChangelog_DownMigrations(t *testing.T) {
+	changelog, err := Changelog()
+	require.NoError(t, err)
+
+	downs, err := fs.Glob(migrations.FS, "*.down.sql")
+	require.NoError(t, err)
+	assert.Len(t, downs, len(changelog), "every migration can be rolled back")
+}
+
+func TestNewStatus(t *testing.T) {
+	changelog := []Migration{
+		{Version: 1, Name: "initial_schema"},
+		{Version: 2, Name: "pipeline_tags"},
+		{Version: 3, Name: "audit_log"},
+	}
+
+	tests := []struct {
+		name    string
+		version uint
+		dirty   bool
+		applied []bool
+		pending int
+	}{
+		{name: "empty database", version: 0, applied: []bool{false, false, false}, pending: 3},
+		{name: "pending migration", version: 2, applied: []bool{true, true, false}, pending: 1},
+		{name: "up to date", version: 3, applied: []bool{true, true, true}, pending: 0},
+		{name: "dirty migration", version: 2, dirty: true, applied: []bool{true, false, false}, pending: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newStatus(changelog, tt.version, tt.dirty)
+
+			assert.Equal(t, tt.version, status.Version)
+			assert.Equal(t, tt.dirty, status.Dirty)
+			assert.Equal(t, uint(3), status.LatestVersion)
+			assert.Equal(t, tt.pending, status.Pending)
+			for i, m := range status.Migrations {
+				assert.Equal(t, tt.applied[i], m.Applied, m.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
+)
+
+// FlowController pauses the ingestion of a topic while the streams the
+// ingestor publishes into, or the consumers reading them, are over the flow
+// control thresholds. Ingestion resumes once they are back below the resume
+// ratio of the thresholds, so it does not flap around them.
+type FlowController struct {
+	js       jetstream.JetStream
+	streams  []string
+	topic    string
+	cfg      models.FlowControlConfig
+	interval time.Duration
+	log      *slog.Logger
+
+	paused atomic.Bool
+}
+
+func NewFlowController(js jetstream.JetStream, streams []string, topic string, cfg models.FlowControlConfig, log *slog.Logger) *FlowController {
+	return &FlowController{
+		js:       js,
+		streams:  streams,
+		topic:    topic,
+		cfg:      cfg,
+		interval: internal.FlowControlCheckInterval,
+		log:      log,
+	}
+}
+
+// Run blocks until ctx is cancelled, checking the streams on every tick.
+func (f *FlowController) Run(ctx context.Context) {
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			f.check(ctx)
+		}
+	}
+}
+
+// Wait blocks while the ingestion is paused. It returns the context error
+// when ctx is cancelled before the ingestion resumed.
+func (f *FlowController) Wait(ctx context.Context) error {
+	if !f.paused.Load() {
+		return nil
+	}
+
+	t := time.NewTicker(internal.FlowControlCheckInterval / 5)
+	defer t.Stop()
+	for f.paused.Load() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // callers match context errors
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// Paused reports whether the ingestion is paused.
+func (f *FlowController) Paused() bool {
+	return f.paused.Load()
+}
+
+// check reads the streams and their consumers and updates the paused state.
+// The state is kept when they cannot be read, a NATS outage must neither
+// pause nor resume the ingestion by itself.
+func (f *FlowController) check(ctx context.Context) {
+	streamBytes, consumerPending, err := f.usage(ctx)
+	if err != nil {
+		f.log.WarnContext(ctx, "flow control: failed to read stream usage",
+			slog.String("topic", f.topic),
+			slog.Any("error", err))
+		return
+	}
+
+	if f.paused.Load() {
+		if f.overLimit(streamBytes, consumerPending, internal.FlowControlResumeRatio) {
+			return
+		}
+		f.paused.Store(false)
+		observability.RecordIngestorFlowControlPaused(ctx, f.topic, false)
+		f.log.InfoContext(ctx, "flow control: resuming ingestion",
+			slog.String("topic", f.topic),
+			slog.Uint64("stream_bytes", streamBytes),
+			slog.Uint64("consumer_pending", consumerPending))
+		return
+	}
+
+	if !f.overLimit(streamBytes, consumerPending, 1) {
+		return
+	}
+	f.paused.Store(true)
+	observability.RecordIngestorFlowControlPaused(ctx, f.topic, true)
+	f.log.WarnContext(ctx, "flow control: pausing ingestion",
+		slog.String("topic", f.topic),
+		slog.Uint64("stream_bytes", streamBytes),
+		slog.Int64("max_stream_bytes", f.cfg.MaxStreamBytes),
+		slog.Uint64("consumer_pending", consumerPending),
+		slog.Int64("max_consumer_pending", f.cfg.MaxConsumerPending))
+}
+
+// usage returns the largest stream size and the largest consumer backlog
+// over the output streams.
+func (f *FlowController) usage(ctx context.Context) (streamBytes, consumerPending uint64, _ error) {
+	for _, name := range f.streams {
+		str, err := f.js.Stream(ctx, name)
+		if err != nil {
+			return 0, 0, fmt.Errorf("get stream %s: %w", name, err)
+		}
+
+		info, err := str.Info(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("get stream %s info: %w", name, err)
+		}
+		streamBytes = max(streamBytes, info.State.Bytes)
+
+		if f.cfg.MaxConsumerPending <= 0 {
+			continue
+		}
+
+		consumers := str.ListConsumers(ctx)
+		for c := range consumers.Info() {
+			pending := c.NumPending + uint64(c.NumAckPending) //nolint:gosec // ack pending is never negative
+			consumerPending = max(consumerPending, pending)
+		}
+		if err := consumers.Err(); err != nil {
+			return 0, 0, fmt.Errorf("list consumers of stream %s: %w", name, err)
+		}
+	}
+
+	return streamBytes, consumerPending, nil
+}
+
+// overLimit reports whether the usage exceeds ratio of a threshold.
+func (f *FlowController) overLimit(streamBytes, consumerPending uint64, ratio float64) bool {
+	if f.cfg.MaxStreamBytes > 0 && float64(streamBytes) > ratio*float64(f.cfg.MaxStreamBytes) {
+		return true
+	}
+	if f.cfg.MaxConsumerPending > 0 && float64(consumerPending) > ratio*float64(f.cfg.MaxConsumerPending) {
+		return true
+	}
+	return false
+}
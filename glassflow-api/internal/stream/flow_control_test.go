@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestFlowController_PausesAndResumesOnStreamBytes(t *testing.T) {
+	js, _ := runEmbeddedNATSForSampler(t)
+	ctx := context.Background()
+
+	const subject = "flow.test"
+	str, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     "flow-bytes",
+		Subjects: []string{subject},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(t, err)
+
+	f := NewFlowController(js, []string{"flow-bytes"}, "orders",
+		models.FlowControlConfig{MaxStreamBytes: 1024},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	f.check(ctx)
+	require.False(t, f.Paused())
+
+	for range 20 {
+		_, err = js.Publish(ctx, subject, make([]byte, 100))
+		require.NoError(t, err)
+	}
+	f.check(ctx)
+	require.True(t, f.Paused())
+
+	require.NoError(t, str.Purge(ctx))
+	f.check(ctx)
+	require.False(t, f.Paused())
+}
+
+func TestFlowController_PausesOnConsumerPending(t *testing.T) {
+	js, _ := runEmbeddedNATSForSampler(t)
+	ctx := context.Background()
+
+	const subject = "flow.pending"
+	str, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     "flow-pending",
+		Subjects: []string{subject},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(t, err)
+	_, err = str.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{Durable: "sink", AckPolicy: jetstream.AckExplicitPolicy})
+	require.NoError(t, err)
+
+	for range 5 {
+		_, err = js.Publish(ctx, subject, []byte("x"))
+		require.NoError(t, err)
+	}
+
+	f := NewFlowController(js, []string{"flow-pending"}, "orders",
+		models.FlowControlConfig{MaxConsumerPending: 3},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.check(ctx)
+	require.True(t, f.Paused())
+}
+
+// A stream that cannot be read keeps the current state.
+func TestFlowController_KeepsStateOnMissingStream(t *testing.T) {
+	js, _ := runEmbeddedNATSForSampler(t)
+
+	f := NewFlowController(js, []string{"does-not-exist"}, "orders",
+		models.FlowControlConfig{MaxStreamBytes: 1},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.check(context.Background())
+	require.False(t, f.Paused())
+}
+
+func TestFlowController_WaitReturnsOnCancel(t *testing.T) {
+	f := NewFlowController(nil, nil, "orders", models.FlowControlConfig{MaxStreamBytes: 1},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, f.Wait(context.Background()))
+
+	f.paused.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, f.Wait(ctx), context.DeadlineExceeded)
+}
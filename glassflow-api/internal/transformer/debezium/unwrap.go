@@ -0,0 +1,104 @@
+package debezium
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// Debezium change operations
+const (
+	opCreate   = "c"
+	opRead     = "r"
+	opUpdate   = "u"
+	opDelete   = "d"
+	opTruncate = "t"
+	opMessage  = "m"
+)
+
+var ErrInvalidEnvelope = errors.New("invalid debezium envelope")
+
+type envelope struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	Op     string          `json:"op"`
+	TsMs   json.RawMessage `json:"ts_ms"`
+	// Payload holds the envelope when the JSON converter embeds the schema
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Unwrapper extracts the row image of Debezium change events, like the
+// ExtractNewRecordState SMT does on the Kafka Connect side.
+type Unwrapper struct {
+	cfg models.DebeziumUnwrapConfig
+}
+
+func NewUnwrapper(cfg models.DebeziumUnwrapConfig) *Unwrapper {
+	return &Unwrapper{cfg: cfg}
+}
+
+// Unwrap returns the row of a change event with the operation and event time
+// added as metadata fields. Creates, snapshot reads and updates carry the
+// after image, deletes the before image unless they are dropped. A nil row
+// without error means the event has no row to ingest: tombstones, dropped
+// deletes, truncates and logical messages.
+func (u *Unwrapper) Unwrap(data []byte) ([]byte, error) {
+	// Tombstones follow deletes so Kafka compaction can remove the key
+	if len(bytes.TrimSpace(data)) == 0 || bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	if env.Op == "" && len(env.Payload) > 0 {
+		payload := env.Payload
+		env = envelope{}
+		if err := json.Unmarshal(payload, &env); err != nil {
+			return nil, fmt.Errorf("%w: payload: %w", ErrInvalidEnvelope, err)
+		}
+	}
+
+	var image json.RawMessage
+	switch env.Op {
+	case opCreate, opRead, opUpdate:
+		image = env.After
+	case opDelete:
+		if u.cfg.DeleteHandling == internal.DebeziumDeleteHandlingDrop {
+			return nil, nil
+		}
+		image = env.Before
+	case opTruncate, opMessage:
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("%w: missing op", ErrInvalidEnvelope)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidEnvelope, env.Op)
+	}
+
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(image, &row); err != nil || row == nil {
+		return nil, fmt.Errorf("%w: op %q has no row image", ErrInvalidEnvelope, env.Op)
+	}
+
+	op, err := json.Marshal(env.Op)
+	if err != nil {
+		return nil, fmt.Errorf("marshal op: %w", err)
+	}
+	row[u.cfg.OpField] = op
+	if len(env.TsMs) > 0 {
+		row[u.cfg.TimestampField] = env.TsMs
+	}
+
+	out, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("marshal row: %w", err)
+	}
+
+	return out, nil
+}
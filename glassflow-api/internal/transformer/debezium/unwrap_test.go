@@ -0,0 +1,130 @@
+package debezium
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestUnwrap(t *testing.T) {
+	rewrite := models.DebeziumUnwrapConfig{
+		Enabled:        true,
+		OpField:        "_op",
+		TimestampField: "_ts",
+		DeleteHandling: internal.DebeziumDeleteHandlingRewrite,
+	}
+	drop := rewrite
+	drop.DeleteHandling = internal.DebeziumDeleteHandlingDrop
+
+	tests := []struct {
+		name  string
+		cfg   models.DebeziumUnwrapConfig
+		input string
+		want  string
+	}{
+		{
+			name:  "create",
+			cfg:   rewrite,
+			input: `{"before":null,"after":{"id":1,"name":"a"},"op":"c","ts_ms":1700000000000}`,
+			want:  `{"id":1,"name":"a","_op":"c","_ts":1700000000000}`,
+		},
+		{
+			name:  "snapshot read",
+			cfg:   rewrite,
+			input: `{"before":null,"after":{"id":1},"op":"r","ts_ms":1}`,
+			want:  `{"id":1,"_op":"r","_ts":1}`,
+		},
+		{
+			name:  "update uses after image",
+			cfg:   rewrite,
+			input: `{"before":{"id":1,"name":"a"},"after":{"id":1,"name":"b"},"op":"u","ts_ms":2}`,
+			want:  `{"id":1,"name":"b","_op":"u","_ts":2}`,
+		},
+		{
+			name:  "delete rewritten to before image",
+			cfg:   rewrite,
+			input: `{"before":{"id":1,"name":"b"},"after":null,"op":"d","ts_ms":3}`,
+			want:  `{"id":1,"name":"b","_op":"d","_ts":3}`,
+		},
+		{
+			name:  "delete dropped",
+			cfg:   drop,
+			input: `{"before":{"id":1},"after":null,"op":"d","ts_ms":3}`,
+		},
+		{
+			name:  "tombstone",
+			cfg:   rewrite,
+			input: ``,
+		},
+		{
+			name:  "null tombstone",
+			cfg:   rewrite,
+			input: `null`,
+		},
+		{
+			name:  "truncate",
+			cfg:   rewrite,
+			input: `{"before":null,"after":null,"op":"t","ts_ms":4}`,
+		},
+		{
+			name:  "schema and payload",
+			cfg:   rewrite,
+			input: `{"schema":{"type":"struct"},"payload":{"before":null,"after":{"id":2},"op":"c","ts_ms":5}}`,
+			want:  `{"id":2,"_op":"c","_ts":5}`,
+		},
+		{
+			name:  "custom metadata fields",
+			cfg:   models.DebeziumUnwrapConfig{Enabled: true, OpField: "__op", TimestampField: "__ts"},
+			input: `{"after":{"id":3},"op":"c","ts_ms":6}`,
+			want:  `{"id":3,"__op":"c","__ts":6}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewUnwrapper(tt.cfg).Unwrap([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("Unwrap = %s; want no row", got)
+				}
+				return
+			}
+
+			var gotMap, wantMap map[string]any
+			if err := json.Unmarshal(got, &gotMap); err != nil {
+				t.Fatalf("unmarshal result %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantMap); err != nil {
+				t.Fatalf("unmarshal want: %v", err)
+			}
+			if !reflect.DeepEqual(gotMap, wantMap) {
+				t.Errorf("Unwrap = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnwrap_Errors(t *testing.T) {
+	cfg := models.DebeziumUnwrapConfig{Enabled: true, OpField: "_op", TimestampField: "_ts"}
+
+	for name, input := range map[string]string{
+		"not json":           `not json`,
+		"missing op":         `{"after":{"id":1}}`,
+		"unknown op":         `{"after":{"id":1},"op":"x"}`,
+		"create without row": `{"before":null,"after":null,"op":"c"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewUnwrapper(cfg).Unwrap([]byte(input))
+			if !errors.Is(err, ErrInvalidEnvelope) {
+				t.Fatalf("Unwrap error = %v; want ErrInvalidEnvelope", err)
+			}
+		})
+	}
+}
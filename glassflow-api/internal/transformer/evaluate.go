@@ -10,8 +10,8 @@ import (
 
 // Evaluate evaluates transformation expressions against sample data
 // Takes raw JSON bytes as input and returns transformed JSON bytes
-func Evaluate(ctx context.Context, transformations []models.Transform, sampleJSON []byte) ([]byte, error) {
-	transformer, err := json.NewTransformer(transformations)
+func Evaluate(ctx context.Context, transformations []models.Transform, sampleJSON []byte, opts ...json.Option) ([]byte, error) {
+	transformer, err := json.NewTransformer(transformations, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("compile transformations: %w", err)
 	}
@@ -0,0 +1,124 @@
+package json
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+	"github.com/spf13/cast"
+)
+
+// errNoSalt is returned for expressions calling tokenize in a pipeline
+// without a salt.
+var errNoSalt = errors.New("tokenize is only available when the pipeline has a salt")
+
+// The functions below anonymize personal data before it reaches ClickHouse.
+// Empty values stay empty, so a missing field is not turned into a hash.
+
+// hashSHA256 returns the hex encoded SHA-256 of a value
+func hashSHA256(args ...any) (any, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("hashSHA256 requires 1 argument, got %d", len(args))
+	}
+	input := cast.ToString(args[0])
+	if input == "" {
+		return "", nil
+	}
+
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// maskEmail keeps the first character of the local part and the domain of an
+// email address, "jane.doe@example.com" becomes "j*******@example.com". A
+// value that is not an address is masked entirely.
+func maskEmail(args ...any) (any, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("maskEmail requires 1 argument, got %d", len(args))
+	}
+	input := cast.ToString(args[0])
+	if input == "" {
+		return "", nil
+	}
+
+	local, domain, ok := strings.Cut(input, "@")
+	if !ok || local == "" || domain == "" {
+		return strings.Repeat("*", utf8.RuneCountInString(input)), nil
+	}
+
+	first, size := utf8.DecodeRuneInString(local)
+	masked := string(first) + strings.Repeat("*", utf8.RuneCountInString(local[size:]))
+	return masked + "@" + domain, nil
+}
+
+// truncate keeps the first n characters of a value
+func truncate(args ...any) (any, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("truncate requires 2 arguments, got %d", len(args))
+	}
+	input := cast.ToString(args[0])
+	n, err := cast.ToIntE(args[1])
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("truncate length must be a non-negative integer, got %v", args[1])
+	}
+
+	if utf8.RuneCountInString(input) <= n {
+		return input, nil
+	}
+	return string([]rune(input)[:n]), nil
+}
+
+// tokenize returns the tokenize function of a pipeline. A token is the hex
+// encoded HMAC-SHA256 of a value keyed by the salt of the pipeline, so equal
+// values get equal tokens within the pipeline and cannot be looked up
+// without the salt. It is only available when the pipeline has a salt.
+func tokenize(salt string) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("tokenize requires 1 argument, got %d", len(args))
+		}
+		input := cast.ToString(args[0])
+		if input == "" {
+			return "", nil
+		}
+
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte(input))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	}
+}
+
+// noSaltTokenize is the tokenize function of a pipeline without a salt.
+func noSaltTokenize(...any) (any, error) {
+	return "", errNoSalt
+}
+
+// callsFunction reports whether a compiled expression calls the function
+// name.
+func callsFunction(program *vm.Program, name string) bool {
+	node := program.Node()
+	f := &callFinder{name: name}
+	ast.Walk(&node, f)
+	return f.found
+}
+
+type callFinder struct {
+	name  string
+	found bool
+}
+
+func (f *callFinder) Visit(node *ast.Node) {
+	call, ok := (*node).(*ast.CallNode)
+	if !ok {
+		return
+	}
+	if ident, ok := call.Callee.(*ast.IdentifierNode); ok && ident.Value == f.name {
+		f.found = true
+	}
+}
@@ -0,0 +1,94 @@
+package json
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func TestPIIFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		salt       string
+		input      string
+		expected   string
+	}{
+		{
+			name:       "hashSHA256",
+			expression: `hashSHA256(email)`,
+			input:      `{"email":"jane.doe@example.com"}`,
+			expected:   `{"result":"86e0b9e56c17cc4d12387e1949b85053fbe73bc3ce5a1188713a9d300cc6133d"}`,
+		},
+		{
+			name:       "hashSHA256 - missing field",
+			expression: `hashSHA256(email)`,
+			input:      `{}`,
+			expected:   `{"result":""}`,
+		},
+		{
+			name:       "maskEmail",
+			expression: `maskEmail(email)`,
+			input:      `{"email":"jane.doe@example.com"}`,
+			expected:   `{"result":"j*******@example.com"}`,
+		},
+		{
+			name:       "maskEmail - not an address",
+			expression: `maskEmail(email)`,
+			input:      `{"email":"jane"}`,
+			expected:   `{"result":"****"}`,
+		},
+		{
+			name:       "truncate",
+			expression: `truncate(phone, 6)`,
+			input:      `{"phone":"+4915112345678"}`,
+			expected:   `{"result":"+49151"}`,
+		},
+		{
+			name:       "truncate - shorter value",
+			expression: `truncate(zip, 3)`,
+			input:      `{"zip":"10"}`,
+			expected:   `{"result":"10"}`,
+		},
+		{
+			name:       "tokenize",
+			expression: `tokenize(email)`,
+			salt:       "0123456789abcdef",
+			input:      `{"email":"jane.doe@example.com"}`,
+			expected:   `{"result":"8706b335df2909c4450fa41aa0259e25360ef6c0abf87138d9bfb692c3db7f33"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer, err := NewTransformer(
+				[]models.Transform{{Expression: tt.expression, OutputName: "result", OutputType: "string"}},
+				WithSalt(tt.salt),
+			)
+			if err != nil {
+				t.Fatalf("NewTransformer() error = %v", err)
+			}
+
+			outputMessage, err := transformer.Transform(context.TODO(), models.NewNatsMessage([]byte(tt.input), nil))
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+
+			diff := cmp.Diff(string(outputMessage.Payload()), tt.expected)
+			if diff != "" {
+				t.Errorf("Transform() %s", diff)
+			}
+		})
+	}
+}
+
+func TestTokenizeRequiresSalt(t *testing.T) {
+	_, err := NewTransformer([]models.Transform{{Expression: `tokenize(email)`, OutputName: "result", OutputType: "string"}})
+	if !errors.Is(err, errNoSalt) {
+		t.Fatalf("NewTransformer() error = %v, want %v", err, errNoSalt)
+	}
+}
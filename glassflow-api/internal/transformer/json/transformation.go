@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,25 +18,39 @@ import (
 type Transformer struct {
 	Transformations     []models.Transform
 	compiledExpressions []*vm.Program
+	salt                string
 }
 
+type Option func(*Transformer)
+
+// WithSalt makes the tokenize function available to the expressions, keyed
+// by the salt of the pipeline.
+func WithSalt(salt string) Option {
+	return func(t *Transformer) {
+		t.salt = salt
+	}
+}
+
+// predefinedTransformations are the functions available to the expressions.
+// The string helpers declare their signature so that the output type of an
+// expression calling them can be inferred.
 var predefinedTransformations = []expr.Option{
 	expr.Function("parseQuery", parseQueryString),
 	expr.Function("getQueryParam", getQueryParam),
 	expr.Function("getNestedParam", getNestedParam),
 	expr.Function("parseISO8601", parseISO8601),
 	expr.Function("toDate", toDate),
-	expr.Function("urlDecode", urlDecode),
-	expr.Function("toString", toString),
-	expr.Function("containsStr", containsStr),
-	expr.Function("hasPrefix", hasPrefix),
-	expr.Function("hasSuffix", hasSuffix),
-	expr.Function("upper", upper),
-	expr.Function("lower", lower),
-	expr.Function("trim", trimSpaces),
+	expr.Function("urlDecode", urlDecode, new(func(...any) string)),
+	expr.Function("toString", toString, new(func(...any) string)),
+	expr.Function("containsStr", containsStr, new(func(...any) bool)),
+	expr.Function("hasPrefix", hasPrefix, new(func(...any) bool)),
+	expr.Function("hasSuffix", hasSuffix, new(func(...any) bool)),
+	expr.Function("upper", upper, new(func(...any) string)),
+	expr.Function("lower", lower, new(func(...any) string)),
+	expr.Function("trim", trimSpaces, new(func(...any) string)),
 	expr.Function("split", splitStr),
-	expr.Function("join", join),
-	expr.Function("replace", replace),
+	expr.Function("join", join, new(func(...any) string)),
+	expr.Function("replace", replace, new(func(...any) string)),
 	expr.Function("toInt", toInt),
 	expr.Function("toFloat", toFloat),
 	expr.Function("parseUserAgent", parseUserAgent),
@@ -44,26 +59,50 @@ var predefinedTransformations = []expr.Option{
 	expr.Function("hasKeyPrefix", hasKeyPrefix),
 	expr.Function("hasAnyKey", hasAnyKey),
 	expr.Function("keys", keys),
+	expr.Function("hashSHA256", hashSHA256, new(func(...any) string)),
+	expr.Function("maskEmail", maskEmail, new(func(...any) string)),
+	expr.Function("truncate", truncate, new(func(...any) string)),
+}
+
+// exprFunctions returns the functions available to the expressions of a
+// pipeline with the given salt. Without a salt tokenize fails with
+// errNoSalt.
+func exprFunctions(salt string) []expr.Option {
+	if salt == "" {
+		return slices.Concat(predefinedTransformations, []expr.Option{
+			expr.Function("tokenize", noSaltTokenize, new(func(...any) string)),
+		})
+	}
+	return slices.Concat(predefinedTransformations, []expr.Option{
+		expr.Function("tokenize", tokenize(salt), new(func(...any) string)),
+	})
 }
 
 // NewTransformer creates a new Transformer and compiles all expressions
-func NewTransformer(transformations []models.Transform) (*Transformer, error) {
-	compiledExpressions := make([]*vm.Program, len(transformations))
+func NewTransformer(transformations []models.Transform, opts ...Option) (*Transformer, error) {
+	t := &Transformer{
+		Transformations: transformations,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.compiledExpressions = make([]*vm.Program, len(transformations))
 	for i, transformation := range transformations {
 		program, err := expr.Compile(
 			transformation.Expression,
-			predefinedTransformations...,
+			exprFunctions(t.salt)...,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("%w: compile transformation %d expression: %w", models.ErrCompileTransformation, i, err)
 		}
-		compiledExpressions[i] = program
+		if t.salt == "" && callsFunction(program, "tokenize") {
+			return nil, fmt.Errorf("%w: compile transformation %d expression: %w", models.ErrCompileTransformation, i, errNoSalt)
+		}
+		t.compiledExpressions[i] = program
 	}
 
-	return &Transformer{
-		Transformations:     transformations,
-		compiledExpressions: compiledExpressions,
-	}, nil
+	return t, nil
 }
 
 // Transform applies transformations to input bytes and returns transformed bytes
@@ -83,7 +122,7 @@ func (t *Transformer) Transform(_ context.Context, inputMessage models.Message)
 			return models.Message{}, fmt.Errorf("run transformation %d: %w", i, err)
 		}
 
-		convertedValue, err := convertType(result, transformation.OutputType)
+		convertedValue, err := ConvertType(result, transformation.OutputType)
 		if err != nil {
 			return models.Message{}, fmt.Errorf("convert result for column %s: %w", transformation.OutputName, err)
 		}
@@ -99,7 +138,9 @@ func (t *Transformer) Transform(_ context.Context, inputMessage models.Message)
 	return models.NewNatsMessage(outputBytes, inputMessage.Headers()), nil
 }
 
-func convertType(value any, targetType string) (any, error) {
+// ConvertType converts a transformation result to the output type of its
+// field.
+func ConvertType(value any, targetType string) (any, error) {
 	switch targetType {
 	case "string":
 		return cast.ToStringE(value)
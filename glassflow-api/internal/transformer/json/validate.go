@@ -1,7 +1,9 @@
 package json
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/expr-lang/expr"
@@ -20,20 +22,16 @@ func ValidateTransformationAgainstSchema(
 		return nil
 	}
 
-	env := make(map[string]any)
-	for _, field := range fields {
-		zero, err := zeroValueForKafkaType(field.Type)
-		if err != nil {
-			return fmt.Errorf("field %q: %w", field.Name, err)
-		}
-		if err := setNestedField(env, strings.Split(field.Name, "."), zero); err != nil {
-			return fmt.Errorf("field %q: %w", field.Name, err)
-		}
+	env, err := schemaEnv(fields)
+	if err != nil {
+		return err
 	}
 
 	var options []expr.Option
 	options = append(options, expr.Env(env))
-	options = append(options, predefinedTransformations...)
+	// the salt does not change the type of tokens, any salt compiles the
+	// expressions like the one of the pipeline
+	options = append(options, exprFunctions("validation")...)
 
 	for i, transformation := range transformations {
 		_, err := expr.Compile(
@@ -54,6 +52,66 @@ func ValidateTransformationAgainstSchema(
 	return nil
 }
 
+// ErrUnknownOutputType is returned for expressions whose output type is only
+// known at runtime.
+var ErrUnknownOutputType = errors.New("output type is only known at runtime")
+
+// InferOutputType returns the kafka type of the values expression produces
+// on events of the given schema. Expressions whose type is only known at
+// runtime, like the arithmetic on nested fields, need a type conversion
+// such as int() or string().
+func InferOutputType(expression string, fields []models.Field) (string, error) {
+	env, err := schemaEnv(fields)
+	if err != nil {
+		return "", err
+	}
+
+	program, err := expr.Compile(expression, append([]expr.Option{expr.Env(env)}, exprFunctions("validation")...)...)
+	if err != nil {
+		return "", fmt.Errorf("expression %q: %w", expression, err)
+	}
+
+	t := program.Node().Type()
+	if t == nil {
+		return "", fmt.Errorf("expression %q: %w", expression, ErrUnknownOutputType)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return internal.KafkaTypeString, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return internal.KafkaTypeInt, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return internal.KafkaTypeUint, nil
+	case reflect.Float32, reflect.Float64:
+		return internal.KafkaTypeFloat, nil
+	case reflect.Bool:
+		return internal.KafkaTypeBool, nil
+	case reflect.Slice, reflect.Array:
+		return internal.KafkaTypeArray, nil
+	case reflect.Map:
+		return internal.KafkaTypeMap, nil
+	default:
+		return "", fmt.Errorf("expression %q: %w", expression, ErrUnknownOutputType)
+	}
+}
+
+// schemaEnv builds an expression environment holding the zero value of each
+// schema field.
+func schemaEnv(fields []models.Field) (map[string]any, error) {
+	env := make(map[string]any)
+	for _, field := range fields {
+		zero, err := zeroValueForKafkaType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		if err := setNestedField(env, strings.Split(field.Name, "."), zero); err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+	}
+	return env, nil
+}
+
 func zeroValueForKafkaType(kafkaType string) (any, error) {
 	switch internal.NormalizeToBasicKafkaType(kafkaType) {
 	case internal.KafkaTypeString:
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -480,3 +481,45 @@ func TestValidateTransformationAgainstSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestInferOutputType(t *testing.T) {
+	schema := []models.Field{
+		{Name: "name", Type: internal.KafkaTypeString},
+		{Name: "age", Type: internal.KafkaTypeInt},
+		{Name: "score", Type: internal.KafkaTypeFloat},
+		{Name: "tags", Type: internal.KafkaTypeArray},
+		{Name: "user.id", Type: internal.KafkaTypeString},
+	}
+
+	tests := []struct {
+		expression string
+		want       string
+		wantErr    error
+	}{
+		{expression: `upper(name)`, want: internal.KafkaTypeString},
+		{expression: `age * 365`, want: internal.KafkaTypeInt},
+		{expression: `score / 2`, want: internal.KafkaTypeFloat},
+		{expression: `age > 18 and name != ""`, want: internal.KafkaTypeBool},
+		{expression: `tags`, want: internal.KafkaTypeArray},
+		{expression: `user.id`, wantErr: ErrUnknownOutputType},
+		{expression: `string(user.id)`, want: internal.KafkaTypeString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := InferOutputType(tt.expression, schema)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("InferOutputType() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InferOutputType() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InferOutputType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package udf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	jsonTransformer "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/json"
+)
+
+const (
+	allocFunction   = "alloc"
+	deallocFunction = "dealloc"
+	// wasmPageSize is the size of a WASM memory page
+	wasmPageSize = 64 << 10
+)
+
+// Transformer runs a WASM user-defined function on the events. The module
+// has no access to the filesystem, the network or the clock beyond WASI
+// defaults. A call that traps or times out discards the instance, the next
+// event runs on a fresh one. A Transformer is not safe for concurrent use.
+type Transformer struct {
+	cfg      models.UDFConfig
+	outputs  []models.Transform
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	instance api.Module
+}
+
+// NewTransformer compiles the module of cfg and checks its exports. The
+// output of the function is reduced to the fields of outputs, converted to
+// their type.
+func NewTransformer(ctx context.Context, cfg models.UDFConfig, outputs []models.Transform) (*Transformer, error) {
+	binary, err := cfg.DecodeModule()
+	if err != nil {
+		return nil, err
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(cfg.MemoryLimitMB*(1<<20)/wasmPageSize)).
+		WithCloseOnContextDone(true))
+
+	_, err = wasi_snapshot_preview1.Instantiate(ctx, runtime)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("instantiate wasi: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("%w: compile udf module: %w", models.ErrCompileTransformation, err)
+	}
+
+	err = checkExports(compiled, cfg.Function)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("%w: %w", models.ErrCompileTransformation, err)
+	}
+
+	return &Transformer{
+		cfg:      cfg,
+		outputs:  outputs,
+		runtime:  runtime,
+		compiled: compiled,
+	}, nil
+}
+
+// Validate compiles the module of cfg and checks its exports.
+func Validate(ctx context.Context, cfg models.UDFConfig) error {
+	t, err := NewTransformer(ctx, cfg, nil)
+	if err != nil {
+		return err
+	}
+	return t.Close(ctx)
+}
+
+func checkExports(compiled wazero.CompiledModule, function string) error {
+	if len(compiled.ExportedMemories()) == 0 {
+		return errors.New("udf module must export its memory")
+	}
+
+	exports := compiled.ExportedFunctions()
+	i32 := []api.ValueType{api.ValueTypeI32}
+	pair := []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}
+	i64 := []api.ValueType{api.ValueTypeI64}
+
+	alloc, ok := exports[allocFunction]
+	if !ok || !slices.Equal(alloc.ParamTypes(), i32) || !slices.Equal(alloc.ResultTypes(), i32) {
+		return fmt.Errorf("udf module must export %s(i32) i32", allocFunction)
+	}
+	fn, ok := exports[function]
+	if !ok || !slices.Equal(fn.ParamTypes(), pair) || !slices.Equal(fn.ResultTypes(), i64) {
+		return fmt.Errorf("udf module must export %s(i32, i32) i64", function)
+	}
+	if dealloc, ok := exports[deallocFunction]; ok && !slices.Equal(dealloc.ParamTypes(), pair) {
+		return fmt.Errorf("udf module export %s must take (i32, i32)", deallocFunction)
+	}
+	return nil
+}
+
+func (t *Transformer) Close(ctx context.Context) error {
+	return t.runtime.Close(ctx)
+}
+
+// Transform runs the function on the payload of inputMessage.
+func (t *Transformer) Transform(ctx context.Context, inputMessage models.Message) (models.Message, error) {
+	output, err := t.call(ctx, inputMessage.Payload())
+	if err != nil {
+		// The memory of a trapped or interrupted call is left inconsistent
+		t.discard(ctx)
+		return models.Message{}, err
+	}
+
+	var outputData map[string]any
+	err = json.Unmarshal(output, &outputData)
+	if err != nil {
+		return models.Message{}, fmt.Errorf("unmarshal udf output: %w", err)
+	}
+
+	result := make(map[string]any, len(t.outputs))
+	for _, field := range t.outputs {
+		value, ok := outputData[field.OutputName]
+		if !ok {
+			continue
+		}
+		converted, err := jsonTransformer.ConvertType(value, field.OutputType)
+		if err != nil {
+			return models.Message{}, fmt.Errorf("convert udf output for column %s: %w", field.OutputName, err)
+		}
+		result[field.OutputName] = converted
+	}
+
+	outputBytes, err := json.Marshal(result)
+	if err != nil {
+		return models.Message{}, fmt.Errorf("marshal output data: %w", err)
+	}
+
+	return models.NewNatsMessage(outputBytes, inputMessage.Headers()), nil
+}
+
+func (t *Transformer) call(ctx context.Context, input []byte) ([]byte, error) {
+	callCtx, cancel := context.WithTimeout(ctx, t.cfg.Timeout.Duration())
+	defer cancel()
+
+	instance, err := t.instantiate(callCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := instance.ExportedFunction(allocFunction).Call(callCtx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("udf alloc: %w", err)
+	}
+	inPtr := uint32(res[0])
+	defer t.dealloc(callCtx, instance, inPtr, uint32(len(input)))
+
+	if !instance.Memory().Write(inPtr, input) {
+		return nil, fmt.Errorf("udf alloc returned an out of range buffer")
+	}
+
+	res, err = instance.ExportedFunction(t.cfg.Function).Call(callCtx, uint64(inPtr), uint64(len(input)))
+	if err != nil {
+		if errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("udf %s exceeded its timeout of %s", t.cfg.Function, t.cfg.Timeout.Duration())
+		}
+		return nil, fmt.Errorf("udf %s: %w", t.cfg.Function, err)
+	}
+
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	output, ok := instance.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("udf %s returned an out of range output", t.cfg.Function)
+	}
+	// The view is invalidated by the next call into the module
+	output = slices.Clone(output)
+	t.dealloc(callCtx, instance, outPtr, outLen)
+
+	return output, nil
+}
+
+func (t *Transformer) instantiate(ctx context.Context) (api.Module, error) {
+	if t.instance != nil {
+		return t.instance, nil
+	}
+
+	instance, err := t.runtime.InstantiateModule(ctx, t.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("instantiate udf module: %w", err)
+	}
+	t.instance = instance
+	return instance, nil
+}
+
+// dealloc frees a buffer when the module exports a dealloc function, modules
+// without one reuse their memory on their own.
+func (t *Transformer) dealloc(ctx context.Context, instance api.Module, ptr, size uint32) {
+	fn := instance.ExportedFunction(deallocFunction)
+	if fn == nil || size == 0 {
+		return
+	}
+	_, _ = fn.Call(ctx, uint64(ptr), uint64(size))
+}
+
+func (t *Transformer) discard(ctx context.Context) {
+	if t.instance == nil {
+		return
+	}
+	_ = t.instance.Close(ctx)
+	t.instance = nil
+}
@@ -0,0 +1,86 @@
+package udf
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// testModule exports memory, alloc returning a buffer at 1024, transform
+// echoing its input and spin looping forever.
+var testModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	// types: (i32) -> i32, (i32, i32) -> i64
+	0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+	// functions
+	0x03, 0x04, 0x03, 0x00, 0x01, 0x01,
+	// memory of one page
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	// exports
+	0x07, 0x25, 0x04,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+	0x09, 't', 'r', 'a', 'n', 's', 'f', 'o', 'r', 'm', 0x00, 0x01,
+	0x04, 's', 'p', 'i', 'n', 0x00, 0x02,
+	// code
+	0x0a, 0x1d, 0x03,
+	// alloc: i32.const 1024
+	0x05, 0x00, 0x41, 0x80, 0x08, 0x0b,
+	// transform: i64(ptr) << 32 | i64(len)
+	0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b,
+	// spin: loop br 0 end unreachable
+	0x08, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b,
+}
+
+func udfConfig(t *testing.T, function string, timeout time.Duration) models.UDFConfig {
+	t.Helper()
+
+	cfg, err := models.NewUDFConfig(models.UDFConfig{
+		Module:   base64.StdEncoding.EncodeToString(testModule),
+		Function: function,
+		Timeout:  *models.NewJSONDuration(timeout),
+	})
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestTransformer_Transform(t *testing.T) {
+	tr, err := NewTransformer(t.Context(), udfConfig(t, "transform", 0), []models.Transform{
+		{OutputName: "id", OutputType: "string"},
+		{OutputName: "amount", OutputType: "int"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tr.Close(t.Context()) })
+
+	out, err := tr.Transform(t.Context(), models.NewNatsMessage([]byte(`{"id":1,"amount":"5","other":true}`), nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","amount":5}`, string(out.Payload()), "the output is reduced to the declared fields")
+}
+
+func TestTransformer_Timeout(t *testing.T) {
+	tr, err := NewTransformer(t.Context(), udfConfig(t, "spin", 10*time.Millisecond), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tr.Close(t.Context()) })
+
+	_, err = tr.Transform(t.Context(), models.NewNatsMessage([]byte(`{}`), nil))
+	require.ErrorContains(t, err, "exceeded its timeout")
+
+	_, err = tr.Transform(t.Context(), models.NewNatsMessage([]byte(`{}`), nil))
+	require.ErrorContains(t, err, "exceeded its timeout", "the next event runs on a fresh instance")
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(t.Context(), udfConfig(t, "transform", 0)))
+
+	err := Validate(t.Context(), udfConfig(t, "missing", 0))
+	require.ErrorIs(t, err, models.ErrCompileTransformation)
+	assert.ErrorContains(t, err, "must export missing(i32, i32) i64")
+
+	err = Validate(t.Context(), udfConfig(t, "alloc", 0))
+	require.ErrorIs(t, err, models.ErrCompileTransformation)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 
 	jsonTransformer "github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/json"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/transformer/udf"
 )
 
 type storage interface {
@@ -40,6 +41,25 @@ type Transformer struct {
 	pipelineID               string
 	sourceID                 string
 	versionedTransformations map[SourceSchemaVersionID]VersionedTransformer
+	udf                      *models.UDFConfig
+	salt                     string
+}
+
+type Option func(*Transformer)
+
+// WithUDF runs the user-defined function on the events instead of the
+// expressions of the schema versions, which then only declare its output.
+func WithUDF(cfg models.UDFConfig) Option {
+	return func(t *Transformer) {
+		t.udf = &cfg
+	}
+}
+
+// WithSalt keys the tokenize function of the expressions.
+func WithSalt(salt string) Option {
+	return func(t *Transformer) {
+		t.salt = salt
+	}
 }
 
 func New(
@@ -47,14 +67,19 @@ func New(
 	componentSignal componentSignal,
 	pipelineID string,
 	sourceID string,
+	opts ...Option,
 ) *Transformer {
-	return &Transformer{
+	t := &Transformer{
 		storage:                  storage,
 		componentSignal:          componentSignal,
 		pipelineID:               pipelineID,
 		sourceID:                 sourceID,
 		versionedTransformations: make(map[SourceSchemaVersionID]VersionedTransformer),
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 func (t *Transformer) Transform(ctx context.Context, inputMessage models.Message) (models.Message, error) {
@@ -128,7 +153,12 @@ func (t *Transformer) getNewVersionTransformer(
 		return VersionedTransformer{}, err
 	}
 
-	transformer, err := jsonTransformer.NewTransformer(transformationConfig.Config)
+	var transformer statelessTransformer
+	if t.udf != nil {
+		transformer, err = udf.NewTransformer(ctx, *t.udf, transformationConfig.Config)
+	} else {
+		transformer, err = jsonTransformer.NewTransformer(transformationConfig.Config, jsonTransformer.WithSalt(t.salt))
+	}
 	if err != nil {
 		return VersionedTransformer{}, err
 	}
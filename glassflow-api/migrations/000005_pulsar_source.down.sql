@@ -0,0 +1,7 @@
+ALTER TABLE sources DROP CONSTRAINT sources_type_check;
+ALTER TABLE sources ADD CONSTRAINT sources_type_check
+    CHECK (type IN ('kafka', 'otlp.logs', 'otlp.traces', 'otlp.metrics'));
+
+ALTER TABLE connections DROP CONSTRAINT connections_type_check;
+ALTER TABLE connections ADD CONSTRAINT connections_type_check
+    CHECK (type IN ('kafka', 'clickhouse'));
@@ -0,0 +1,3 @@
+ALTER TABLE sources DROP CONSTRAINT sources_type_check;
+ALTER TABLE sources ADD CONSTRAINT sources_type_check
+    CHECK (type IN ('kafka', 'pulsar', 'otlp.logs', 'otlp.traces', 'otlp.metrics'));
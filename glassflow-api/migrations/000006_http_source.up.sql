@@ -0,0 +1,4 @@
+-- Allow HTTP push sources
+ALTER TABLE sources DROP CONSTRAINT sources_type_check;
+ALTER TABLE sources ADD CONSTRAINT sources_type_check
+    CHECK (type IN ('kafka', 'pulsar', 'http', 'otlp.logs', 'otlp.traces', 'otlp.metrics'));
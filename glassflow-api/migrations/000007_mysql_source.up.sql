@@ -0,0 +1,8 @@
+-- Allow MySQL binlog connections and sources
+ALTER TABLE connections DROP CONSTRAINT connections_type_check;
+ALTER TABLE connections ADD CONSTRAINT connections_type_check
+    CHECK (type IN ('kafka', 'clickhouse', 'pulsar', 'mysql'));
+
+ALTER TABLE sources DROP CONSTRAINT sources_type_check;
+ALTER TABLE sources ADD CONSTRAINT sources_type_check
+    CHECK (type IN ('kafka', 'pulsar', 'mysql', 'http', 'otlp.logs', 'otlp.traces', 'otlp.metrics'));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS orchestrator_outbox;
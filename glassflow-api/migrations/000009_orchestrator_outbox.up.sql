@@ -0,0 +1,14 @@
+-- Orchestrator actions stored with the pipeline change they follow, run by
+-- the API and retried by its reconciler until they succeed
+CREATE TABLE orchestrator_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pipeline_id TEXT NOT NULL REFERENCES pipelines(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('setup', 'edit', 'delete')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_orchestrator_outbox_next_attempt_at ON orchestrator_outbox(next_attempt_at);
@@ -0,0 +1,11 @@
+-- Feature flags set through the API, for the installation when pipeline_id
+-- is NULL or for a single pipeline
+CREATE TABLE feature_flags (
+    name TEXT NOT NULL,
+    pipeline_id TEXT REFERENCES pipelines(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_feature_flags_scope ON feature_flags(name, COALESCE(pipeline_id, ''));
@@ -0,0 +1,3 @@
+-- Hash of the effective config the components of a pipeline run with, empty
+-- for pipelines stored before it was computed
+ALTER TABLE pipelines ADD COLUMN config_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Allow explode transformations emitting one event per array element
+ALTER TABLE transformations DROP CONSTRAINT transformations_type_check;
+ALTER TABLE transformations ADD CONSTRAINT transformations_type_check
+    CHECK (type IN ('deduplication', 'join', 'filter', 'stateless_transformation', 'enrichment', 'explode'));
@@ -0,0 +1,3 @@
+-- Components paused on their own while the rest of the pipeline runs, such
+-- as the sink during a ClickHouse maintenance window
+ALTER TABLE pipelines ADD COLUMN paused_components TEXT[] NOT NULL DEFAULT '{}';
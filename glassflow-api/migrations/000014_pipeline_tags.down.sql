@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_pipelines_metadata_tags;
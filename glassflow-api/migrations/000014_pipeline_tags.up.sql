@@ -0,0 +1,3 @@
+-- Tags are stored in the metadata, the index serves the tag filters of the
+-- pipeline list
+CREATE INDEX IF NOT EXISTS idx_pipelines_metadata_tags ON pipelines USING GIN ((metadata -> 'tags'));
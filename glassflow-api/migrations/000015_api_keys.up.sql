@@ -0,0 +1,10 @@
+-- API keys of the API, only the SHA-256 of a key is stored. A key with
+-- teams only acts on the pipelines owned by these teams.
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL,
+    teams JSONB NOT NULL DEFAULT '[]',
+    key_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
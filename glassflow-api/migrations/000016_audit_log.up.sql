@@ -0,0 +1,17 @@
+-- Control-plane actions of the API, appended and never updated. Changes
+-- holds the config fields changed by the action, with secrets redacted.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor TEXT NOT NULL,
+    source_ip TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    pipeline_id TEXT NOT NULL DEFAULT '',
+    status INTEGER NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_audit_log_occurred_at ON audit_log (occurred_at DESC);
+CREATE INDEX idx_audit_log_pipeline_id ON audit_log (pipeline_id, occurred_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor, occurred_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS connection_health_checks;
+DROP TABLE IF EXISTS connection_health;
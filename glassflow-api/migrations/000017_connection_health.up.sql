@@ -0,0 +1,26 @@
+-- Health of the ClickHouse servers and Kafka clusters the pipelines connect
+-- to, one row per endpoint. Since is when the endpoint entered its status.
+CREATE TABLE connection_health (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    address TEXT NOT NULL,
+    username TEXT NOT NULL DEFAULT '',
+    pipelines JSONB NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL,
+    since TIMESTAMPTZ NOT NULL,
+    checked_at TIMESTAMPTZ NOT NULL,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+-- History of the health checks, pruned after the retention
+CREATE TABLE connection_health_checks (
+    id BIGSERIAL PRIMARY KEY,
+    connection_id TEXT NOT NULL REFERENCES connection_health(id) ON DELETE CASCADE,
+    checked_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_connection_health_checks_connection ON connection_health_checks (connection_id, checked_at DESC);
@@ -0,0 +1,8 @@
+-- Data keys the connection credentials are encrypted with, stored wrapped by
+-- the key encryption key (a local key or a KMS key) identified by kek_id.
+CREATE TABLE encryption_keys (
+    id TEXT PRIMARY KEY,
+    kek_id TEXT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
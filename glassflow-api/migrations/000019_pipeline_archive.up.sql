@@ -0,0 +1,11 @@
+-- Definitions of the pipelines archived after being stopped for long, with
+-- the credentials encrypted like the pipeline history. Restoring a pipeline
+-- creates it again from its definition and deletes its row.
+CREATE TABLE pipeline_archive (
+    pipeline_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    pipeline JSONB NOT NULL,
+    stopped_at TIMESTAMPTZ NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- Leases of the periodic API jobs that run on one replica at a time, claimed
+-- and renewed by the replica running the job
+CREATE TABLE job_leases (
+    job TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations of the Postgres
+// schema, the up and down files golang-migrate reads.
+package migrations
+
+import "embed"
+
+// FS holds the *.up.sql and *.down.sql files of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
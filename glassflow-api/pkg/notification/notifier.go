@@ -0,0 +1,260 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+type EventType string
+
+const (
+	EventPipelineCreated      EventType = "pipeline.created"
+	EventPipelineRunning      EventType = "pipeline.running"
+	EventPipelineCrashed      EventType = "pipeline.crashed"
+	EventPipelineTerminated   EventType = "pipeline.terminated"
+	EventDLQThresholdExceeded EventType = "pipeline.dlq_threshold_exceeded"
+	EventSLABreached          EventType = "pipeline.sla_breached"
+	EventSLARecovered         EventType = "pipeline.sla_recovered"
+	EventStorageDegraded      EventType = "pipeline.storage_degraded"
+	EventStorageRecovered     EventType = "pipeline.storage_recovered"
+	EventMessagesDiscarded    EventType = "pipeline.messages_discarded"
+	EventPartitionsChanged    EventType = "pipeline.partitions_changed"
+)
+
+const (
+	HeaderEvent     = "X-Glassflow-Event"
+	HeaderTimestamp = "X-Glassflow-Timestamp"
+	HeaderSignature = "X-Glassflow-Signature"
+
+	signaturePrefix           = "sha256="
+	defaultDeliveryAttempts   = 3
+	defaultDeliveryRetryDelay = time.Second
+	defaultDeliveryTimeout    = time.Minute
+)
+
+// PipelineGetter is a minimal interface for fetching pipeline configurations
+type PipelineGetter interface {
+	GetPipeline(ctx context.Context, pid string) (*models.PipelineConfig, error)
+}
+
+// Event is the JSON payload posted to every webhook. Owner, Team and
+// Contact come from the pipeline metadata so that on-call knows who owns a
+// failing pipeline.
+type Event struct {
+	Type         EventType      `json:"type"`
+	PipelineID   string         `json:"pipeline_id"`
+	PipelineName string         `json:"pipeline_name,omitempty"`
+	Status       string         `json:"status,omitempty"`
+	Owner        string         `json:"owner,omitempty"`
+	Team         string         `json:"team,omitempty"`
+	Contact      string         `json:"contact,omitempty"`
+	Timestamp    string         `json:"timestamp"`
+	Details      map[string]any `json:"details,omitempty"`
+}
+
+// Notifier posts pipeline lifecycle events to the globally configured
+// webhooks and to the webhooks listed in the pipeline metadata.
+type Notifier struct {
+	webhookURLs   []string
+	secret        string
+	dlqThreshold  uint64
+	httpClient    *http.Client
+	log           *slog.Logger
+	pipelineStore PipelineGetter
+
+	attempts   uint
+	retryDelay time.Duration
+}
+
+func NewNotifier(webhookURLs []string, secret string, dlqThreshold uint64, log *slog.Logger, pipelineStore PipelineGetter) *Notifier {
+	return &Notifier{
+		webhookURLs:  webhookURLs,
+		secret:       secret,
+		dlqThreshold: dlqThreshold,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		log:           log,
+		pipelineStore: pipelineStore,
+		attempts:      defaultDeliveryAttempts,
+		retryDelay:    defaultDeliveryRetryDelay,
+	}
+}
+
+// DLQThreshold returns the number of unconsumed DLQ messages above which
+// EventDLQThresholdExceeded is sent. The pipeline metadata takes precedence
+// over the global setting, zero disables the check.
+func (n *Notifier) DLQThreshold(cfg *models.PipelineConfig) uint64 {
+	if n == nil {
+		return 0
+	}
+	if nc := cfg.Metadata.Notifications; nc != nil && nc.DLQThreshold > 0 {
+		return nc.DLQThreshold
+	}
+	return n.dlqThreshold
+}
+
+// Notify looks the pipeline up and sends the event asynchronously. If the
+// pipeline can't be loaded the event is still sent to the global webhooks.
+func (n *Notifier) Notify(pipelineID string, eventType EventType, details map[string]any) {
+	if n == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDeliveryTimeout)
+		defer cancel()
+
+		cfg := &models.PipelineConfig{ID: pipelineID}
+		if n.pipelineStore != nil {
+			pipeline, err := n.pipelineStore.GetPipeline(ctx, pipelineID)
+			if err != nil {
+				n.log.Debug("notification: failed to get pipeline for event", "pipeline_id", pipelineID, "event", eventType, "error", err)
+			} else {
+				cfg = pipeline
+			}
+		}
+
+		n.send(ctx, cfg, eventType, details)
+	}()
+}
+
+// NotifyPipeline sends the event for an already loaded pipeline asynchronously.
+func (n *Notifier) NotifyPipeline(cfg models.PipelineConfig, eventType EventType, details map[string]any) {
+	if n == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDeliveryTimeout)
+		defer cancel()
+
+		n.send(ctx, &cfg, eventType, details)
+	}()
+}
+
+func (n *Notifier) send(ctx context.Context, cfg *models.PipelineConfig, eventType EventType, details map[string]any) {
+	urls := n.urlsFor(cfg)
+	if len(urls) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(Event{
+		Type:         eventType,
+		PipelineID:   cfg.ID,
+		PipelineName: cfg.Name,
+		Status:       string(cfg.Status.OverallStatus),
+		Owner:        cfg.Metadata.Owner,
+		Team:         cfg.Metadata.Team,
+		Contact:      cfg.Metadata.Contact,
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Details:      details,
+	})
+	if err != nil {
+		n.log.Error("notification: failed to marshal event", "pipeline_id", cfg.ID, "event", eventType, "error", err)
+		return
+	}
+
+	for _, url := range urls {
+		err := n.deliver(ctx, url, eventType, body)
+		if err != nil {
+			n.log.Warn("notification: webhook delivery failed", "pipeline_id", cfg.ID, "event", eventType, "url", url, "error", err)
+			continue
+		}
+		n.log.Debug("notification: webhook delivered", "pipeline_id", cfg.ID, "event", eventType, "url", url)
+	}
+}
+
+// urlsFor returns the global webhooks followed by the pipeline ones, without duplicates.
+func (n *Notifier) urlsFor(cfg *models.PipelineConfig) []string {
+	urls := make([]string, 0, len(n.webhookURLs))
+	seen := make(map[string]struct{})
+
+	add := func(list []string) {
+		for _, url := range list {
+			if url == "" {
+				continue
+			}
+			if _, ok := seen[url]; ok {
+				continue
+			}
+			seen[url] = struct{}{}
+			urls = append(urls, url)
+		}
+	}
+
+	add(n.webhookURLs)
+	if nc := cfg.Metadata.Notifications; nc != nil {
+		add(nc.WebhookURLs)
+	}
+
+	return urls
+}
+
+func (n *Notifier) deliver(ctx context.Context, url string, eventType EventType, body []byte) error {
+	return retry.Do(
+		func() error {
+			return n.post(ctx, url, eventType, body)
+		},
+		retry.Context(ctx),
+		retry.Attempts(n.attempts),
+		retry.Delay(n.retryDelay),
+		retry.LastErrorOnly(true),
+	)
+}
+
+func (n *Notifier) post(ctx context.Context, url string, eventType EventType, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return retry.Unrecoverable(fmt.Errorf("create request: %w", err))
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(eventType))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if n.secret != "" {
+		req.Header.Set(HeaderSignature, Sign(n.secret, timestamp, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	// client errors won't go away on retry, except for rate limiting
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Unrecoverable(err)
+	}
+
+	return err
+}
+
+// Sign returns the value of the signature header: the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+func newTestNotifier(urls []string, secret string) *Notifier {
+	n := NewNotifier(urls, secret, 0, slog.Default(), nil)
+	n.retryDelay = 0
+	return n
+}
+
+func TestNotifier_SendSignsEvent(t *testing.T) {
+	var (
+		body    []byte
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier([]string{server.URL}, "s3cret")
+	cfg := &models.PipelineConfig{
+		ID:       "test-pipeline",
+		Name:     "Test pipeline",
+		Metadata: models.PipelineMetadata{Owner: "jane", Team: "payments", Contact: "#payments-oncall"},
+	}
+	n.send(context.Background(), cfg, EventPipelineCreated, map[string]any{"key": "value"})
+
+	require.NotEmpty(t, body)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, EventPipelineCreated, event.Type)
+	assert.Equal(t, "test-pipeline", event.PipelineID)
+	assert.Equal(t, "Test pipeline", event.PipelineName)
+	assert.Equal(t, "jane", event.Owner)
+	assert.Equal(t, "payments", event.Team)
+	assert.Equal(t, "#payments-oncall", event.Contact)
+	assert.Equal(t, "value", event.Details["key"])
+
+	assert.Equal(t, string(EventPipelineCreated), headers.Get(HeaderEvent))
+	assert.Equal(t, Sign("s3cret", headers.Get(HeaderTimestamp), body), headers.Get(HeaderSignature))
+}
+
+func TestNotifier_SendWithoutSecret(t *testing.T) {
+	var signature atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature.Store(r.Header.Get(HeaderSignature))
+	}))
+	defer server.Close()
+
+	n := newTestNotifier([]string{server.URL}, "")
+	n.send(context.Background(), &models.PipelineConfig{ID: "test-pipeline"}, EventPipelineRunning, nil)
+
+	assert.Equal(t, "", signature.Load())
+}
+
+func TestNotifier_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+	}{
+		{name: "server error is retried", status: http.StatusBadGateway, wantCalls: defaultDeliveryAttempts},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, wantCalls: defaultDeliveryAttempts},
+		{name: "client error is not retried", status: http.StatusNotFound, wantCalls: 1},
+		{name: "success is not retried", status: http.StatusOK, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			n := newTestNotifier([]string{server.URL}, "")
+			n.send(context.Background(), &models.PipelineConfig{ID: "test-pipeline"}, EventPipelineCrashed, nil)
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestNotifier_PipelineWebhooks(t *testing.T) {
+	var global, perPipeline atomic.Int32
+	globalServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		global.Add(1)
+	}))
+	defer globalServer.Close()
+	pipelineServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		perPipeline.Add(1)
+	}))
+	defer pipelineServer.Close()
+
+	n := newTestNotifier([]string{globalServer.URL}, "")
+	cfg := &models.PipelineConfig{
+		ID: "test-pipeline",
+		Metadata: models.PipelineMetadata{
+			Notifications: &models.NotificationConfig{
+				// the global webhook is only notified once
+				WebhookURLs: []string{pipelineServer.URL, globalServer.URL},
+			},
+		},
+	}
+	n.send(context.Background(), cfg, EventPipelineTerminated, nil)
+
+	assert.Equal(t, int32(1), global.Load())
+	assert.Equal(t, int32(1), perPipeline.Load())
+}
+
+func TestNotifier_DLQThreshold(t *testing.T) {
+	n := NewNotifier(nil, "", 100, slog.Default(), nil)
+
+	assert.Equal(t, uint64(100), n.DLQThreshold(&models.PipelineConfig{}))
+	assert.Equal(t, uint64(5), n.DLQThreshold(&models.PipelineConfig{
+		Metadata: models.PipelineMetadata{
+			Notifications: &models.NotificationConfig{DLQThreshold: 5},
+		},
+	}))
+
+	var disabled *Notifier
+	assert.Equal(t, uint64(0), disabled.DLQThreshold(&models.PipelineConfig{}))
+}
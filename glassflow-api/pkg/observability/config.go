@@ -17,4 +17,15 @@ type Config struct {
 	ServiceNamespace  string
 	PipelineID        string
 	ServiceInstanceID string
+
+	// PrometheusEnabled collects the metrics for the /metrics scrape endpoint,
+	// independently of the OTLP export.
+	PrometheusEnabled bool
+
+	// TracesEnabled exports spans over OTLP, TraceSampleRatio is the share of
+	// events traced by the ingestor. TraceRoot is set for the components
+	// reading the source, the only ones starting traces.
+	TracesEnabled    bool
+	TraceSampleRatio float64
+	TraceRoot        bool
 }
@@ -0,0 +1,259 @@
+package observability
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/otlptranslator"
+)
+
+// MetricKind is the Prometheus type a metric is exposed as.
+type MetricKind string
+
+const (
+	MetricKindCounter   MetricKind = "counter"
+	MetricKindGauge     MetricKind = "gauge"
+	MetricKindHistogram MetricKind = "histogram"
+)
+
+// MetricDescriptor describes an instrument created by the build.
+type MetricDescriptor struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Unit        string     `json:"unit"`
+	Kind        MetricKind `json:"kind"`
+}
+
+// metricCatalog lists the instruments in the order they are created, it is
+// filled when the instruments are initialised.
+var metricCatalog []MetricDescriptor
+
+func registerMetric(name, description, unit string, kind MetricKind) {
+	metricCatalog = append(metricCatalog, MetricDescriptor{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Kind:        kind,
+	})
+}
+
+// Metrics returns the metrics the build emits.
+func Metrics() []MetricDescriptor {
+	return slices.Clone(metricCatalog)
+}
+
+// Dashboards generated by NewGrafanaDashboard.
+const (
+	DashboardPipeline = "pipeline"
+	DashboardSystem   = "system"
+)
+
+// GrafanaDashboard is the JSON model of a Grafana dashboard.
+type GrafanaDashboard struct {
+	UID           string           `json:"uid"`
+	Title         string           `json:"title"`
+	Tags          []string         `json:"tags"`
+	Timezone      string           `json:"timezone"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Refresh       string           `json:"refresh"`
+	Time          GrafanaTimeRange `json:"time"`
+	Templating    GrafanaTemplates `json:"templating"`
+	Panels        []GrafanaPanel   `json:"panels"`
+}
+
+type GrafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type GrafanaTemplates struct {
+	List []GrafanaVariable `json:"list"`
+}
+
+type GrafanaVariable struct {
+	Name       string             `json:"name"`
+	Label      string             `json:"label"`
+	Type       string             `json:"type"`
+	Query      string             `json:"query"`
+	Datasource *GrafanaDatasource `json:"datasource,omitempty"`
+	Refresh    int                `json:"refresh,omitempty"`
+}
+
+type GrafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type GrafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	GridPos     GrafanaGridPos     `json:"gridPos"`
+	Datasource  *GrafanaDatasource `json:"datasource,omitempty"`
+	Targets     []GrafanaTarget    `json:"targets,omitempty"`
+	FieldConfig *GrafanaFieldConf  `json:"fieldConfig,omitempty"`
+}
+
+type GrafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type GrafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+type GrafanaFieldConf struct {
+	Defaults GrafanaFieldDefaults `json:"defaults"`
+}
+
+type GrafanaFieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+// httpMetricPrefix marks the metrics of the API server, which carry no
+// pipeline labels.
+const httpMetricPrefix = GfMetricPrefix + "_http_"
+
+var prometheusDatasource = &GrafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+
+// NewGrafanaDashboard generates a dashboard over the metrics of the build,
+// one panel per metric. The pipeline dashboard shows a single pipeline split
+// by component, the system dashboard all pipelines and the API. prefix is
+// prepended to the metric names, e.g. the namespace added by the collector.
+func NewGrafanaDashboard(name, prefix string) (GrafanaDashboard, error) {
+	var (
+		title   string
+		filter  string
+		groupBy string
+		legend  string
+	)
+	switch name {
+	case DashboardPipeline:
+		title = "GlassFlow Pipeline"
+		filter = `pipeline_id="$pipeline_id"`
+		groupBy = "component"
+		legend = "{{component}}"
+	case DashboardSystem:
+		title = "GlassFlow System"
+		groupBy = "pipeline_id"
+		legend = "{{pipeline_id}}"
+	default:
+		return GrafanaDashboard{}, fmt.Errorf("unknown dashboard %q, expected %s or %s", name, DashboardPipeline, DashboardSystem)
+	}
+
+	variables := []GrafanaVariable{{
+		Name:  "datasource",
+		Label: "Data source",
+		Type:  "datasource",
+		Query: "prometheus",
+	}}
+	if name == DashboardPipeline {
+		variables = append(variables, GrafanaVariable{
+			Name:       "pipeline_id",
+			Label:      "Pipeline",
+			Type:       "query",
+			Datasource: prometheusDatasource,
+			Query:      fmt.Sprintf("label_values(%s, pipeline_id)", prometheusMetricName(prefix, MetricDescriptor{Name: GfMetricPrefix + "_processor_messages_total", Kind: MetricKindCounter})),
+			Refresh:    2,
+		})
+	}
+
+	dashboard := GrafanaDashboard{
+		UID:           "glassflow-" + name,
+		Title:         title,
+		Tags:          []string{"glassflow"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Refresh:       "30s",
+		Time:          GrafanaTimeRange{From: "now-1h", To: "now"},
+		Templating:    GrafanaTemplates{List: variables},
+	}
+
+	for _, m := range metricCatalog {
+		panelFilter, panelGroupBy, panelLegend := filter, groupBy, legend
+		if strings.HasPrefix(m.Name, httpMetricPrefix) {
+			if name == DashboardPipeline {
+				continue
+			}
+			panelFilter, panelGroupBy, panelLegend = "", "path", "{{path}}"
+		}
+
+		id := len(dashboard.Panels) + 1
+		dashboard.Panels = append(dashboard.Panels, GrafanaPanel{
+			ID:          id,
+			Type:        "timeseries",
+			Title:       strings.TrimPrefix(m.Name, GfMetricPrefix+"_"),
+			Description: m.Description,
+			GridPos:     GrafanaGridPos{H: 8, W: 12, X: (id - 1) % 2 * 12, Y: (id - 1) / 2 * 8},
+			Datasource:  prometheusDatasource,
+			Targets: []GrafanaTarget{{
+				RefID:        "A",
+				Expr:         panelQuery(prometheusMetricName(prefix, m), m.Kind, panelFilter, panelGroupBy),
+				LegendFormat: panelLegend,
+			}},
+			FieldConfig: &GrafanaFieldConf{Defaults: GrafanaFieldDefaults{Unit: grafanaUnit(m)}},
+		})
+	}
+
+	return dashboard, nil
+}
+
+// prometheusMetricName is the name a metric is scraped as, translated the way
+// the Prometheus exporter does without units, so counters end in _total.
+func prometheusMetricName(prefix string, m MetricDescriptor) string {
+	var metricType otlptranslator.MetricType = otlptranslator.MetricTypeGauge
+	switch m.Kind {
+	case MetricKindCounter:
+		metricType = otlptranslator.MetricTypeMonotonicCounter
+	case MetricKindHistogram:
+		metricType = otlptranslator.MetricTypeHistogram
+	}
+
+	namer := otlptranslator.MetricNamer{WithMetricSuffixes: true}
+	name, err := namer.Build(otlptranslator.Metric{Name: m.Name, Type: metricType})
+	if err != nil {
+		// only empty names fail to translate
+		return prefix + m.Name
+	}
+	return prefix + name
+}
+
+// panelQuery charts the rate of counters, the value of gauges and the p95 of
+// histograms.
+func panelQuery(metric string, kind MetricKind, filter, groupBy string) string {
+	selector := func(name string) string {
+		if filter == "" {
+			return name
+		}
+		return name + "{" + filter + "}"
+	}
+
+	switch kind {
+	case MetricKindCounter:
+		return fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", groupBy, selector(metric))
+	case MetricKindHistogram:
+		return fmt.Sprintf("histogram_quantile(0.95, sum by (le, %s) (rate(%s[$__rate_interval])))", groupBy, selector(metric+"_bucket"))
+	default:
+		return fmt.Sprintf("max by (%s) (%s)", groupBy, selector(metric))
+	}
+}
+
+func grafanaUnit(m MetricDescriptor) string {
+	switch {
+	case m.Unit == "s":
+		return "s"
+	case m.Unit == "By":
+		return "bytes"
+	case m.Kind == MetricKindCounter:
+		return "ops"
+	default:
+		return "short"
+	}
+}
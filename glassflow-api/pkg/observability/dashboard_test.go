@@ -0,0 +1,50 @@
+package observability
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGrafanaDashboard(t *testing.T) {
+	InitMetricsForTesting()
+
+	metrics := Metrics()
+	require.NotEmpty(t, metrics)
+	assert.Contains(t, metrics, MetricDescriptor{
+		Name:        GfMetricPrefix + "_sink_flush_duration_seconds",
+		Description: "Duration of each sink flush to ClickHouse in seconds",
+		Unit:        "s",
+		Kind:        MetricKindHistogram,
+	})
+
+	queries := func(d GrafanaDashboard) map[string]string {
+		out := make(map[string]string)
+		for _, p := range d.Panels {
+			out[p.Title] = p.Targets[0].Expr
+		}
+		return out
+	}
+
+	pipeline, err := NewGrafanaDashboard(DashboardPipeline, "glassflow_")
+	require.NoError(t, err)
+	got := queries(pipeline)
+	assert.Equal(t, `sum by (component) (rate(glassflow_gfm_kafka_records_read_total{pipeline_id="$pipeline_id"}[$__rate_interval]))`,
+		got["kafka_records_read_total"])
+	assert.Equal(t, `histogram_quantile(0.95, sum by (le, component) (rate(glassflow_gfm_sink_flush_duration_seconds_bucket{pipeline_id="$pipeline_id"}[$__rate_interval])))`,
+		got["sink_flush_duration_seconds"])
+	assert.Equal(t, `max by (component) (glassflow_gfm_stream_depth{pipeline_id="$pipeline_id"})`, got["stream_depth"])
+	assert.NotContains(t, got, "http_server_request_count_total", "the API metrics carry no pipeline")
+	assert.Len(t, pipeline.Templating.List, 2)
+
+	system, err := NewGrafanaDashboard(DashboardSystem, "")
+	require.NoError(t, err)
+	got = queries(system)
+	assert.Equal(t, `sum by (pipeline_id) (rate(gfm_kafka_records_read_total[$__rate_interval]))`, got["kafka_records_read_total"])
+	assert.Equal(t, `sum by (path) (rate(gfm_http_server_request_count_total[$__rate_interval]))`, got["http_server_request_count_total"])
+	assert.Len(t, system.Panels, len(metrics))
+
+	_, err = NewGrafanaDashboard("unknown", "")
+	require.Error(t, err)
+}
@@ -23,6 +23,16 @@ func ConfigureLogger(cfg *Config, logOut io.Writer) *slog.Logger {
 	return createStandardLogger(cfg, logOut)
 }
 
+// ConfigureAccessLogger returns the logger of the API access log. With
+// export the access log is sent as OTel logs even when the other logs are
+// not.
+func ConfigureAccessLogger(cfg *Config, log *slog.Logger, logOut io.Writer, export bool) *slog.Logger {
+	if !export || cfg.LogsEnabled {
+		return log
+	}
+	return configureOTelLogger(cfg, logOut)
+}
+
 // configureOTelLogger sets up OpenTelemetry logging with fallback to standard logging
 func configureOTelLogger(cfg *Config, logOut io.Writer) *slog.Logger {
 	// Create resource with service information
@@ -3,6 +3,7 @@ package observability
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 )
@@ -22,6 +24,7 @@ const (
 	MetricComponentOTLPLogs    MetricComponent = "otlp.logs"
 	MetricComponentOTLPMetrics MetricComponent = "otlp.metrics"
 	MetricComponentOTLPTraces  MetricComponent = "otlp.traces"
+	MetricComponentHTTPIngest  MetricComponent = "http"
 )
 
 func (mc MetricComponent) String() string {
@@ -33,6 +36,7 @@ var (
 	KafkaRecordsRead         metric.Int64Counter
 	DLQRecordsWritten        metric.Int64Counter
 	ClickHouseRecordsWritten metric.Int64Counter
+	ClickHouseProfileEvents  metric.Int64Counter
 	ProcessorMessages        metric.Int64Counter
 	ProcessingDuration       metric.Float64Histogram
 	HTTPRequestCount         metric.Int64Counter
@@ -46,10 +50,13 @@ var (
 	SinkBatchSizeRecords       metric.Int64Histogram
 	SinkBatchSizeBytes         metric.Int64Histogram
 	SinkRetriesTotal           metric.Int64Counter
+	SinkSheddingActive         metric.Int64Gauge
+	SinkSheddingEvents         metric.Int64Counter
 
 	IngestorBackpressureActive   metric.Int64Gauge
 	IngestorBackpressureEvents   metric.Int64Counter
 	IngestorBackpressureDuration metric.Float64Histogram
+	IngestorFlowControlPaused    metric.Int64Gauge
 
 	ComponentBackpressureActive   metric.Int64Gauge
 	ComponentBackpressureEvents   metric.Int64Counter
@@ -57,49 +64,119 @@ var (
 
 	StreamDepth      metric.Int64Gauge
 	StreamDepthRatio metric.Float64Gauge
+
+	PoolGets metric.Int64Counter
+
+	MessagesPublished metric.Int64Counter
+	SinkFlushDuration metric.Float64Histogram
+	KafkaConsumerLag  metric.Int64Gauge
+
+	JoinCacheLookups    metric.Int64Counter
+	JoinCacheBytes      metric.Int64Gauge
+	JoinUnmatched       metric.Int64Counter
+	JoinLateEvents      metric.Int64Counter
+	JoinBufferEvents    metric.Int64Gauge
+	JoinBufferEvictions metric.Int64Counter
+	JoinCompactedEvents metric.Int64Counter
+
+	UsageStatsEventsDropped metric.Int64Counter
+	UsageStatsQueuedEvents  metric.Int64Gauge
 )
 
 // pipelineID is set once at component startup (not used by the API which handles multiple pipelines).
 var pipelineID string
 
+// componentLabels are set once at component startup with the pipeline ID and
+// added to all the metrics of the component.
+var componentLabels ComponentLabels
+
+// ComponentLabels describe the component recording the metrics. Topic is
+// only set for the components reading a single topic.
+type ComponentLabels struct {
+	PipelineName string
+	Component    string
+	Topic        string
+}
+
 // SetPipelineID stores the pipeline ID for use in all metric recording functions.
 // Call this once at component startup before recording any metrics.
 func SetPipelineID(id string) {
 	pipelineID = id
 }
 
+// SetComponentLabels stores the labels added to all the metrics recorded by
+// the component. Call this once at component startup with SetPipelineID.
+func SetComponentLabels(labels ComponentLabels) {
+	componentLabels = labels
+}
+
+// pipelineAttributes returns the pipeline_id, pipeline_name, component and
+// topic attributes followed by attrs. The component of the process is used
+// when component is empty, the topic of the process when attrs don't carry
+// one.
+func pipelineAttributes(pid, component string, attrs ...attribute.KeyValue) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(attrs)+4)
+	kvs = append(kvs, attribute.String("pipeline_id", pid))
+	if componentLabels.PipelineName != "" {
+		kvs = append(kvs, attribute.String("pipeline_name", componentLabels.PipelineName))
+	}
+	if component == "" {
+		component = componentLabels.Component
+	}
+	if component != "" {
+		kvs = append(kvs, attribute.String("component", component))
+	}
+	hasTopic := slices.ContainsFunc(attrs, func(kv attribute.KeyValue) bool { return kv.Key == "topic" })
+	if componentLabels.Topic != "" && !hasTopic {
+		kvs = append(kvs, attribute.String("topic", componentLabels.Topic))
+	}
+	return metric.WithAttributes(append(kvs, attrs...)...)
+}
+
 // GetPipelineID returns the pipeline ID set via SetPipelineID.
 func GetPipelineID() string {
 	return pipelineID
 }
 
 // InitMetrics sets up the OTel provider and initialises all instrument vars.
-// Returns nil immediately when metrics are disabled; vars remain nil and all
-// wrapper functions become no-ops.
+// When both the OTLP export and the Prometheus endpoint are disabled the
+// instruments are no-ops, created only to list the metrics of the build.
 func InitMetrics(cfg *Config) error {
-	if !cfg.MetricsEnabled {
+	if !cfg.MetricsEnabled && !cfg.PrometheusEnabled {
+		initMetricInstruments(noop.Meter{})
 		return nil
 	}
 
 	ctx := context.Background()
 
-	exporter, err := otlpmetrichttp.New(ctx)
-	if err != nil {
-		return fmt.Errorf("create OTLP metrics exporter: %w", err)
-	}
-
 	attrs := buildResourceAttributes(cfg)
 	res, err := resource.New(ctx, resource.WithAttributes(attrs...))
 	if err != nil {
 		return fmt.Errorf("create resource: %w", err)
 	}
 
-	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	if cfg.MetricsEnabled {
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return fmt.Errorf("create OTLP metrics exporter: %w", err)
+		}
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
 			sdkmetric.WithInterval(10*time.Second),
-		)),
-	)
+		)))
+	}
+
+	if cfg.PrometheusEnabled {
+		reader, handler, err := newPrometheusReader()
+		if err != nil {
+			return err
+		}
+		prometheusHandler = handler
+		opts = append(opts, sdkmetric.WithReader(reader))
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(opts...)
 	otel.SetMeterProvider(meterProvider)
 
 	initMetricInstruments(otel.Meter("glassflow-etl"))
@@ -119,12 +196,16 @@ func InitMetricsForTesting() *sdkmetric.ManualReader {
 }
 
 func initMetricInstruments(m metric.Meter) {
+	metricCatalog = nil
+
 	KafkaRecordsRead = mustCreateCounter(m, GfMetricPrefix+"_"+"kafka_records_read_total",
 		"Total number of records read from Kafka")
 	DLQRecordsWritten = mustCreateCounter(m, GfMetricPrefix+"_"+"dlq_records_written_total",
 		"Total number of records written to dead letter queue")
 	ClickHouseRecordsWritten = mustCreateCounter(m, GfMetricPrefix+"_"+"clickhouse_records_written_total",
 		"Total number of records written to ClickHouse")
+	ClickHouseProfileEvents = mustCreateCounter(m, GfMetricPrefix+"_"+"clickhouse_insert_profile_events_total",
+		"ClickHouse ProfileEvents counters of the sink inserts; labelled by event")
 	ProcessingDuration = mustCreateHistogram(m, GfMetricPrefix+"_"+"processing_duration_seconds",
 		"Processing duration in seconds")
 	HTTPRequestCount = mustCreateCounter(m, GfMetricPrefix+"_"+"http_server_request_count_total",
@@ -157,6 +238,10 @@ func initMetricInstruments(m metric.Meter) {
 	SinkRetriesTotal = mustCreateCounter(m,
 		GfMetricPrefix+"_"+"sink_retries_total",
 		"Sink batch retry attempts labelled by outcome (exhausted|retry)")
+	SinkSheddingActive = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"sink_shedding_active",
+		"1 while the sink is degraded and sheds load under memory or CPU pressure, 0 otherwise")
+	SinkSheddingEvents = mustCreateCounter(m, GfMetricPrefix+"_"+"sink_shedding_events_total",
+		"Total number of times the sink started shedding load; labelled by reason (memory|cpu)")
 
 	IngestorBackpressureActive = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"ingestor_backpressure_active",
 		"1 while the ingestor is in back-pressure, 0 otherwise")
@@ -166,6 +251,9 @@ func initMetricInstruments(m metric.Meter) {
 		GfMetricPrefix+"_"+"ingestor_backpressure_duration_seconds",
 		"Duration of each ingestor back-pressure episode in seconds")
 
+	IngestorFlowControlPaused = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"ingestor_flow_control_paused",
+		"1 while flow control pauses the polling of the ingestor, 0 otherwise; labelled by topic")
+
 	ComponentBackpressureActive = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"component_backpressure_active",
 		"1 while the component is in back-pressure, 0 otherwise; labelled by component")
 	ComponentBackpressureEvents = mustCreateCounter(m, GfMetricPrefix+"_"+"component_backpressure_events_total",
@@ -178,6 +266,36 @@ func initMetricInstruments(m metric.Meter) {
 		"Number of messages currently stored in a JetStream stream")
 	StreamDepthRatio = mustCreateGauge(m, GfMetricPrefix+"_"+"stream_depth_ratio",
 		"Stream depth divided by max_messages, 0.0-1.0")
+
+	PoolGets = mustCreateCounter(m, GfMetricPrefix+"_"+"pool_gets_total",
+		"Buffer pool gets labelled by component, pool and result (hit|miss)")
+
+	MessagesPublished = mustCreateCounter(m, GfMetricPrefix+"_"+"messages_published_total",
+		"Total number of messages published to the next NATS stream; labelled by component")
+	SinkFlushDuration = mustCreateHistogram(m, GfMetricPrefix+"_"+"sink_flush_duration_seconds",
+		"Duration of each sink flush to ClickHouse in seconds")
+	KafkaConsumerLag = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"kafka_consumer_lag",
+		"Records between the last consumed offset and the partition high watermark; labelled by topic and partition")
+
+	JoinCacheLookups = mustCreateCounter(m, GfMetricPrefix+"_"+"join_cache_lookups_total",
+		"Lookups of left events in the join right record cache labelled by result (hit|miss)")
+	JoinCacheBytes = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"join_cache_bytes",
+		"Approximate memory held by the join right record cache in bytes")
+	JoinUnmatched = mustCreateCounter(m, GfMetricPrefix+"_"+"join_unmatched_total",
+		"Left events that found no match within the join window labelled by policy (dlq|table)")
+	JoinLateEvents = mustCreateCounter(m, GfMetricPrefix+"_"+"join_late_events_total",
+		"Events of an ordered join that arrived after later events were processed, labelled by orientation (left|right)")
+	JoinBufferEvents = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"join_buffer_events",
+		"Events held in the join buffers labelled by orientation (left|right)")
+	JoinBufferEvictions = mustCreateCounter(m, GfMetricPrefix+"_"+"join_buffer_evictions_total",
+		"Events dropped from the join buffers without being joined, labelled by orientation (left|right) and reason (expired|replaced)")
+	JoinCompactedEvents = mustCreateCounter(m, GfMetricPrefix+"_"+"join_compacted_events_total",
+		"Events of the join buffers moved into per key segments by compaction, labelled by orientation (left|right)")
+
+	UsageStatsEventsDropped = mustCreateCounter(m, GfMetricPrefix+"_"+"usage_stats_events_dropped_total",
+		"Usage stats events dropped without being sent, labelled by reason (queue_full|rejected)")
+	UsageStatsQueuedEvents = mustCreateInt64Gauge(m, GfMetricPrefix+"_"+"usage_stats_queued_events",
+		"Usage stats events waiting to be sent")
 }
 
 func mustCreateCounter(m metric.Meter, name, description string) metric.Int64Counter {
@@ -185,6 +303,7 @@ func mustCreateCounter(m metric.Meter, name, description string) metric.Int64Cou
 	if err != nil {
 		panic(fmt.Sprintf("failed to create counter %s: %v", name, err))
 	}
+	registerMetric(name, description, "1", MetricKindCounter)
 	return counter
 }
 
@@ -193,6 +312,7 @@ func mustCreateGauge(m metric.Meter, name, description string) metric.Float64Gau
 	if err != nil {
 		panic(fmt.Sprintf("failed to create gauge %s: %v", name, err))
 	}
+	registerMetric(name, description, "1/s", MetricKindGauge)
 	return gauge
 }
 
@@ -201,6 +321,7 @@ func mustCreateInt64Gauge(m metric.Meter, name, description string) metric.Int64
 	if err != nil {
 		panic(fmt.Sprintf("failed to create int64 gauge %s: %v", name, err))
 	}
+	registerMetric(name, description, "1", MetricKindGauge)
 	return gauge
 }
 
@@ -218,6 +339,7 @@ func mustCreateBackpressureDurationHistogram(m metric.Meter, name, description s
 	if err != nil {
 		panic(fmt.Sprintf("failed to create histogram %s: %v", name, err))
 	}
+	registerMetric(name, description, "s", MetricKindHistogram)
 	return histogram
 }
 
@@ -229,6 +351,7 @@ func mustCreateInt64Histogram(m metric.Meter, name, description, unit string, bu
 	if err != nil {
 		panic(fmt.Sprintf("failed to create histogram %s: %v", name, err))
 	}
+	registerMetric(name, description, unit, MetricKindHistogram)
 	return h
 }
 
@@ -256,6 +379,7 @@ func mustCreateHistogram(m metric.Meter, name, description string) metric.Float6
 	if err != nil {
 		panic(fmt.Sprintf("failed to create histogram %s: %v", name, err))
 	}
+	registerMetric(name, description, "s", MetricKindHistogram)
 	return histogram
 }
 
@@ -263,10 +387,7 @@ func RecordKafkaRead(ctx context.Context, component string, count int64) {
 	if KafkaRecordsRead == nil {
 		return
 	}
-	KafkaRecordsRead.Add(ctx, count, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
-	))
+	KafkaRecordsRead.Add(ctx, count, pipelineAttributes(pipelineID, component))
 }
 
 // DLQ reason constants — keep cardinality bounded, never pass free-form strings.
@@ -277,15 +398,14 @@ const (
 	DLQReasonRetryExhausted = "retry_exhausted"
 	DLQReasonDedupOverflow  = "dedup_overflow"
 	DLQReasonUnrecoverable  = "unrecoverable"
+	DLQReasonJoinUnmatched  = "join_unmatched"
 )
 
 func RecordDLQWrite(ctx context.Context, component, reason string, count int64) {
 	if DLQRecordsWritten == nil {
 		return
 	}
-	DLQRecordsWritten.Add(ctx, count, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
+	DLQRecordsWritten.Add(ctx, count, pipelineAttributes(pipelineID, component,
 		attribute.String("reason", reason),
 	))
 }
@@ -294,9 +414,15 @@ func RecordClickHouseWrite(ctx context.Context, component string, count int64) {
 	if ClickHouseRecordsWritten == nil {
 		return
 	}
-	ClickHouseRecordsWritten.Add(ctx, count, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
+	ClickHouseRecordsWritten.Add(ctx, count, pipelineAttributes(pipelineID, component))
+}
+
+func RecordClickHouseProfileEvent(ctx context.Context, event string, value int64) {
+	if ClickHouseProfileEvents == nil {
+		return
+	}
+	ClickHouseProfileEvents.Add(ctx, value, pipelineAttributes(pipelineID, "",
+		attribute.String("event", event),
 	))
 }
 
@@ -304,9 +430,7 @@ func RecordProcessingDurationWithStage(ctx context.Context, component, stage str
 	if ProcessingDuration == nil {
 		return
 	}
-	ProcessingDuration.Record(ctx, duration, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
+	ProcessingDuration.Record(ctx, duration, pipelineAttributes(pipelineID, component,
 		attribute.String("stage", stage),
 	))
 }
@@ -315,9 +439,7 @@ func RecordProcessorMessages(ctx context.Context, component, status string, coun
 	if ProcessorMessages == nil {
 		return
 	}
-	ProcessorMessages.Add(ctx, count, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
+	ProcessorMessages.Add(ctx, count, pipelineAttributes(pipelineID, component,
 		attribute.String("status", status),
 	))
 }
@@ -326,9 +448,7 @@ func RecordBytesProcessed(ctx context.Context, component, direction string, byte
 	if BytesProcessed == nil {
 		return
 	}
-	BytesProcessed.Add(ctx, bytes, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pipelineID),
+	BytesProcessed.Add(ctx, bytes, pipelineAttributes(pipelineID, component,
 		attribute.String("direction", direction),
 	))
 }
@@ -337,9 +457,7 @@ func RecordProcessorMessagesByPipelineID(ctx context.Context, component, status,
 	if ProcessorMessages == nil {
 		return
 	}
-	ProcessorMessages.Add(ctx, count, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pid),
+	ProcessorMessages.Add(ctx, count, pipelineAttributes(pid, component,
 		attribute.String("status", status),
 	))
 }
@@ -348,9 +466,7 @@ func RecordBytesProcessedByPipelineID(ctx context.Context, component, direction,
 	if BytesProcessed == nil {
 		return
 	}
-	BytesProcessed.Add(ctx, bytes, metric.WithAttributes(
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pid),
+	BytesProcessed.Add(ctx, bytes, pipelineAttributes(pid, component,
 		attribute.String("direction", direction),
 	))
 }
@@ -370,17 +486,15 @@ func RecordHTTPRequest(ctx context.Context, method, route string, status int, du
 }
 
 func RecordReceiverRequest(ctx context.Context, component, transport, status, pid string, duration float64) {
-	attrs := []attribute.KeyValue{
-		attribute.String("component", component),
-		attribute.String("pipeline_id", pid),
+	attrs := pipelineAttributes(pid, component,
 		attribute.String("transport", transport),
 		attribute.String("status", status),
-	}
+	)
 	if ReceiverRequestCount != nil {
-		ReceiverRequestCount.Add(ctx, 1, metric.WithAttributes(attrs...))
+		ReceiverRequestCount.Add(ctx, 1, attrs)
 	}
 	if ReceiverRequestDuration != nil {
-		ReceiverRequestDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
+		ReceiverRequestDuration.Record(ctx, duration, attrs)
 	}
 }
 
@@ -388,10 +502,7 @@ func RecordBackpressureStart(ctx context.Context, component string) {
 	if ComponentBackpressureActive == nil {
 		return
 	}
-	attrs := metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
-		attribute.String("component", component),
-	)
+	attrs := pipelineAttributes(pipelineID, component)
 	ComponentBackpressureActive.Record(ctx, 1, attrs)
 	ComponentBackpressureEvents.Add(ctx, 1, attrs)
 }
@@ -400,10 +511,7 @@ func RecordBackpressureStop(ctx context.Context, component string, duration floa
 	if ComponentBackpressureActive == nil {
 		return
 	}
-	attrs := metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
-		attribute.String("component", component),
-	)
+	attrs := pipelineAttributes(pipelineID, component)
 	ComponentBackpressureActive.Record(ctx, 0, attrs)
 	ComponentBackpressureDuration.Record(ctx, duration, attrs)
 }
@@ -412,7 +520,7 @@ func RecordIngestorBackpressureStart(ctx context.Context) {
 	if IngestorBackpressureActive == nil {
 		return
 	}
-	attrs := metric.WithAttributes(attribute.String("pipeline_id", pipelineID))
+	attrs := pipelineAttributes(pipelineID, "")
 	IngestorBackpressureActive.Record(ctx, 1, attrs)
 	IngestorBackpressureEvents.Add(ctx, 1, attrs)
 	RecordBackpressureStart(ctx, "ingestor")
@@ -422,18 +530,30 @@ func RecordIngestorBackpressureStop(ctx context.Context, duration float64) {
 	if IngestorBackpressureActive == nil {
 		return
 	}
-	attrs := metric.WithAttributes(attribute.String("pipeline_id", pipelineID))
+	attrs := pipelineAttributes(pipelineID, "")
 	IngestorBackpressureActive.Record(ctx, 0, attrs)
 	IngestorBackpressureDuration.Record(ctx, duration, attrs)
 	RecordBackpressureStop(ctx, "ingestor", duration)
 }
 
+func RecordIngestorFlowControlPaused(ctx context.Context, topic string, paused bool) {
+	if IngestorFlowControlPaused == nil {
+		return
+	}
+	var value int64
+	if paused {
+		value = 1
+	}
+	IngestorFlowControlPaused.Record(ctx, value, pipelineAttributes(pipelineID, "",
+		attribute.String("topic", topic),
+	))
+}
+
 func RecordStreamDepth(ctx context.Context, streamName string, depth int64) {
 	if StreamDepth == nil {
 		return
 	}
-	StreamDepth.Record(ctx, depth, metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
+	StreamDepth.Record(ctx, depth, pipelineAttributes(pipelineID, "",
 		attribute.String("stream", streamName),
 	))
 }
@@ -442,8 +562,7 @@ func RecordSinkErrorClassification(ctx context.Context, classification, errorNam
 	if SinkErrorsByClassification == nil {
 		return
 	}
-	SinkErrorsByClassification.Add(ctx, 1, metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
+	SinkErrorsByClassification.Add(ctx, 1, pipelineAttributes(pipelineID, "",
 		attribute.String("classification", classification),
 		attribute.String("error_name", errorName),
 	))
@@ -453,23 +572,20 @@ func RecordSinkNackMessages(ctx context.Context, count int64) {
 	if SinkNackMessagesTotal == nil {
 		return
 	}
-	SinkNackMessagesTotal.Add(ctx, count, metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
-	))
+	SinkNackMessagesTotal.Add(ctx, count, pipelineAttributes(pipelineID, ""))
 }
 
 func RecordStreamDepthRatio(ctx context.Context, streamName string, ratio float64) {
 	if StreamDepthRatio == nil {
 		return
 	}
-	StreamDepthRatio.Record(ctx, ratio, metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
+	StreamDepthRatio.Record(ctx, ratio, pipelineAttributes(pipelineID, "",
 		attribute.String("stream", streamName),
 	))
 }
 
 func RecordSinkBatchSize(ctx context.Context, records, bytes int64) {
-	attrs := metric.WithAttributes(attribute.String("pipeline_id", pipelineID))
+	attrs := pipelineAttributes(pipelineID, "")
 	if SinkBatchSizeRecords != nil {
 		SinkBatchSizeRecords.Record(ctx, records, attrs)
 	}
@@ -482,8 +598,160 @@ func RecordSinkRetry(ctx context.Context, outcome string, count int64) {
 	if SinkRetriesTotal == nil {
 		return
 	}
-	SinkRetriesTotal.Add(ctx, count, metric.WithAttributes(
-		attribute.String("pipeline_id", pipelineID),
+	SinkRetriesTotal.Add(ctx, count, pipelineAttributes(pipelineID, "",
 		attribute.String("outcome", outcome),
 	))
 }
+
+// Pool get result constants for RecordPoolGet.
+const (
+	PoolResultHit  = "hit"
+	PoolResultMiss = "miss"
+)
+
+func RecordPoolGet(ctx context.Context, component, pool, result string) {
+	if PoolGets == nil {
+		return
+	}
+	PoolGets.Add(ctx, 1, pipelineAttributes(pipelineID, component,
+		attribute.String("pool", pool),
+		attribute.String("result", result),
+	))
+}
+
+func RecordSinkSheddingStart(ctx context.Context, reason string) {
+	if SinkSheddingActive == nil {
+		return
+	}
+	SinkSheddingActive.Record(ctx, 1, pipelineAttributes(pipelineID, ""))
+	SinkSheddingEvents.Add(ctx, 1, pipelineAttributes(pipelineID, "",
+		attribute.String("reason", reason),
+	))
+}
+
+func RecordSinkSheddingStop(ctx context.Context) {
+	if SinkSheddingActive == nil {
+		return
+	}
+	SinkSheddingActive.Record(ctx, 0, pipelineAttributes(pipelineID, ""))
+}
+
+func RecordMessagesPublished(ctx context.Context, component string, count int64) {
+	if MessagesPublished == nil {
+		return
+	}
+	MessagesPublished.Add(ctx, count, pipelineAttributes(pipelineID, component))
+}
+
+func RecordSinkFlushDuration(ctx context.Context, duration float64) {
+	if SinkFlushDuration == nil {
+		return
+	}
+	SinkFlushDuration.Record(ctx, duration, pipelineAttributes(pipelineID, ""))
+}
+
+func RecordKafkaConsumerLag(ctx context.Context, topic string, partition int32, lag int64) {
+	if KafkaConsumerLag == nil {
+		return
+	}
+	KafkaConsumerLag.Record(ctx, lag, pipelineAttributes(pipelineID, "",
+		attribute.String("topic", topic),
+		attribute.String("partition", strconv.Itoa(int(partition))),
+	))
+}
+
+// Join cache lookup result constants for RecordJoinCacheLookup.
+const (
+	JoinCacheResultHit  = "hit"
+	JoinCacheResultMiss = "miss"
+)
+
+func RecordJoinCacheLookup(ctx context.Context, result string) {
+	if JoinCacheLookups == nil {
+		return
+	}
+	JoinCacheLookups.Add(ctx, 1, pipelineAttributes(pipelineID, "",
+		attribute.String("result", result),
+	))
+}
+
+func RecordJoinCacheBytes(ctx context.Context, bytes int64) {
+	if JoinCacheBytes == nil {
+		return
+	}
+	JoinCacheBytes.Record(ctx, bytes, pipelineAttributes(pipelineID, ""))
+}
+
+func RecordJoinUnmatched(ctx context.Context, policy string, count int64) {
+	if JoinUnmatched == nil {
+		return
+	}
+	JoinUnmatched.Add(ctx, count, pipelineAttributes(pipelineID, "",
+		attribute.String("policy", policy),
+	))
+}
+
+// Join buffer eviction reason constants for RecordJoinBufferEvictions.
+const (
+	JoinBufferEvictionExpired  = "expired"
+	JoinBufferEvictionReplaced = "replaced"
+)
+
+func RecordJoinBufferEvents(ctx context.Context, orientation string, events int64) {
+	if JoinBufferEvents == nil {
+		return
+	}
+	JoinBufferEvents.Record(ctx, events, pipelineAttributes(pipelineID, "",
+		attribute.String("orientation", orientation),
+	))
+}
+
+func RecordJoinBufferEvictions(ctx context.Context, orientation, reason string, count int64) {
+	if JoinBufferEvictions == nil {
+		return
+	}
+	JoinBufferEvictions.Add(ctx, count, pipelineAttributes(pipelineID, "",
+		attribute.String("orientation", orientation),
+		attribute.String("reason", reason),
+	))
+}
+
+func RecordJoinCompactedEvents(ctx context.Context, orientation string, count int64) {
+	if JoinCompactedEvents == nil {
+		return
+	}
+	JoinCompactedEvents.Add(ctx, count, pipelineAttributes(pipelineID, "",
+		attribute.String("orientation", orientation),
+	))
+}
+
+func RecordJoinLateEvent(ctx context.Context, orientation string) {
+	if JoinLateEvents == nil {
+		return
+	}
+	JoinLateEvents.Add(ctx, 1, pipelineAttributes(pipelineID, "",
+		attribute.String("orientation", orientation),
+	))
+}
+
+// Usage stats drop reason constants for RecordUsageStatsEventDropped.
+const (
+	UsageStatsDropQueueFull = "queue_full"
+	UsageStatsDropRejected  = "rejected"
+)
+
+func RecordUsageStatsEventDropped(ctx context.Context, reason string) {
+	if UsageStatsEventsDropped == nil {
+		return
+	}
+	UsageStatsEventsDropped.Add(ctx, 1, pipelineAttributes(pipelineID, "",
+		attribute.String("reason", reason),
+	))
+}
+
+func RecordUsageStatsQueuedEvents(ctx context.Context, events int64) {
+	if UsageStatsQueuedEvents == nil {
+		return
+	}
+	UsageStatsQueuedEvents.Record(ctx, events, pipelineAttributes(pipelineID, ""))
+}
@@ -0,0 +1,79 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPipelineAttributes(t *testing.T) {
+	reader := InitMetricsForTesting()
+	SetPipelineID("orders")
+	SetComponentLabels(ComponentLabels{PipelineName: "Orders", Component: "ingestor", Topic: "orders-topic"})
+	t.Cleanup(func() {
+		SetPipelineID("")
+		SetComponentLabels(ComponentLabels{})
+	})
+
+	ctx := context.Background()
+	RecordSinkNackMessages(ctx, 1)
+	RecordKafkaConsumerLag(ctx, "other-topic", 0, 5)
+
+	// The latency of a batch keeps the trace of a traced message as exemplar
+	setupTestTracer(t)
+	msgCtx, root := StartSpan(ctx, SpanKafkaFetch)
+	traced := propagation.HeaderCarrier{}
+	InjectTraceContext(msgCtx, traced)
+	root.End()
+	spans := StartMessageSpans(ctx, SpanClickHouseInsert, []TraceCarrier{traced})
+	require.Len(t, spans, 1)
+	RecordProcessingDurationWithStage(spans.Context(ctx), "sink", "insert", 0.2)
+	spans.End(nil)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	attrs := make(map[string]attribute.Set)
+	var exemplars []metricdata.Exemplar[float64]
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				attrs[m.Name] = data.DataPoints[0].Attributes
+			case metricdata.Gauge[int64]:
+				attrs[m.Name] = data.DataPoints[0].Attributes
+			case metricdata.Histogram[float64]:
+				attrs[m.Name] = data.DataPoints[0].Attributes
+				exemplars = data.DataPoints[0].Exemplars
+			}
+		}
+	}
+
+	value := func(metric, key string) string {
+		set := attrs[metric]
+		v, ok := set.Value(attribute.Key(key))
+		require.True(t, ok, "%s has no %s attribute", metric, key)
+		return v.AsString()
+	}
+
+	nack := GfMetricPrefix + "_sink_nack_messages_total"
+	assert.Equal(t, "orders", value(nack, "pipeline_id"))
+	assert.Equal(t, "Orders", value(nack, "pipeline_name"))
+	assert.Equal(t, "ingestor", value(nack, "component"))
+	assert.Equal(t, "orders-topic", value(nack, "topic"))
+
+	// Attributes given by the caller win over the process ones
+	assert.Equal(t, "other-topic", value(GfMetricPrefix+"_kafka_consumer_lag", "topic"))
+
+	duration := GfMetricPrefix + "_processing_duration_seconds"
+	assert.Equal(t, "sink", value(duration, "component"))
+	require.Len(t, exemplars, 1)
+	assert.Equal(t, trace.SpanContextFromContext(spans.Context(ctx)).TraceID().String(),
+		trace.TraceID(exemplars[0].TraceID).String())
+}
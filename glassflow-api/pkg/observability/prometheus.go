@@ -0,0 +1,45 @@
+package observability
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+)
+
+// prometheusHandler serves the registry of the Prometheus exporter, which is
+// registered next to the OTLP reader when the Prometheus endpoint is enabled.
+var prometheusHandler http.Handler
+
+// newPrometheusReader creates the Prometheus exporter, collected on every
+// scrape, and the handler serving it. Units and scope labels are left out so
+// the metrics keep the names used in the source; counters end in _total.
+func newPrometheusReader() (*otelprom.Exporter, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprom.New(
+		otelprom.WithRegisterer(registry),
+		otelprom.WithoutUnits(),
+		otelprom.WithoutScopeInfo(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create prometheus exporter: %w", err)
+	}
+
+	return exporter, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
+
+// PrometheusHandler serves the metrics recorded by the component in the
+// Prometheus text exposition format, for users scraping /metrics instead of
+// running an OTel collector.
+func PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if prometheusHandler == nil {
+			http.Error(w, "prometheus metrics are disabled", http.StatusNotFound)
+			return
+		}
+
+		prometheusHandler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package observability
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestPrometheusReader(t *testing.T) {
+	reader, handler, err := newPrometheusReader()
+	require.NoError(t, err)
+	m := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	ctx := context.Background()
+
+	counter, err := m.Int64Counter("gfm_records", metric.WithDescription("Records read"), metric.WithUnit("1"))
+	require.NoError(t, err)
+	counter.Add(ctx, 3, metric.WithAttributes(
+		attribute.String("component", "ingestor"),
+		attribute.String("topic.name", `a"b`),
+	))
+
+	gauge, err := m.Int64Gauge("gfm_lag")
+	require.NoError(t, err)
+	gauge.Record(ctx, 42)
+
+	histogram, err := m.Float64Histogram("gfm_flush_seconds", metric.WithUnit("s"), metric.WithExplicitBucketBoundaries(0.5, 1))
+	require.NoError(t, err)
+	histogram.Record(ctx, 0.25)
+	histogram.Record(ctx, 0.75)
+	histogram.Record(ctx, 5)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	got := string(body)
+
+	for _, want := range []string{
+		"# HELP gfm_records_total Records read\n",
+		"# TYPE gfm_records_total counter\n",
+		`gfm_records_total{component="ingestor",topic_name="a\"b"} 3` + "\n",
+		"# TYPE gfm_lag gauge\n",
+		"gfm_lag 42\n",
+		"# TYPE gfm_flush_seconds histogram\n",
+		`gfm_flush_seconds_bucket{le="0.5"} 1` + "\n",
+		`gfm_flush_seconds_bucket{le="1"} 2` + "\n",
+		`gfm_flush_seconds_bucket{le="+Inf"} 3` + "\n",
+		"gfm_flush_seconds_sum 6\n",
+		"gfm_flush_seconds_count 3\n",
+	} {
+		assert.Contains(t, got, want)
+	}
+	assert.NotContains(t, got, "otel_scope_name")
+}
+
+func TestPrometheusHandler_Disabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,194 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span names of the event path, in pipeline order.
+const (
+	SpanKafkaFetch       = "kafka.fetch"
+	SpanNATSPublish      = "nats.publish"
+	SpanJoinBufferLookup = "join.buffer_lookup"
+	SpanClickHouseInsert = "clickhouse.insert"
+)
+
+const defaultTraceSampleRatio = 1.0
+
+// tracer is nil when tracing is disabled; all tracing helpers become no-ops.
+var (
+	tracer         trace.Tracer
+	tracerProvider *sdktrace.TracerProvider
+	propagator     = propagation.TraceContext{}
+)
+
+// TraceCarrier reads and writes the trace context of a message, e.g. the
+// headers of a NATS message.
+type TraceCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// textMapCarrier adapts a TraceCarrier to the OTel propagator, the trace
+// context propagator doesn't need the keys.
+type textMapCarrier struct {
+	TraceCarrier
+}
+
+func (textMapCarrier) Keys() []string {
+	return nil
+}
+
+// InitTracing sets up the trace provider exporting spans over OTLP/HTTP, to
+// the endpoint of the standard OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and
+// OTEL_EXPORTER_OTLP_ENDPOINT variables like the metrics and logs exporters.
+// Returns nil immediately when tracing is disabled.
+func InitTracing(cfg *Config) error {
+	if !cfg.TracesEnabled {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	attrs := buildResourceAttributes(cfg)
+	res, err := resource.New(ctx, resource.WithAttributes(attrs...))
+	if err != nil {
+		return fmt.Errorf("create resource: %w", err)
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(traceSampler(cfg)),
+	)
+	tracer = tracerProvider.Tracer("glassflow-etl")
+
+	return nil
+}
+
+// traceSampler follows the sampling decision carried by the messages. Only
+// the components reading the source start traces, for the configured ratio of
+// events; the others never start a trace of their own, so a message without
+// trace context, e.g. one the ingestor did not sample, stays untraced.
+func traceSampler(cfg *Config) sdktrace.Sampler {
+	if !cfg.TraceRoot {
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	}
+
+	ratio := cfg.TraceSampleRatio
+	if ratio <= 0 {
+		ratio = defaultTraceSampleRatio
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// ShutdownTracing exports the buffered spans and stops the trace provider.
+func ShutdownTracing(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+
+	err := tracerProvider.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("shutdown tracer provider: %w", err)
+	}
+	return nil
+}
+
+// TracingEnabled reports whether spans are recorded, for callers that would
+// otherwise prepare span data for nothing.
+func TracingEnabled() bool {
+	return tracer != nil
+}
+
+// StartSpan starts a span as a child of the span in ctx.
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name, opts...)
+}
+
+// EndSpan records the error, if any, and ends the span.
+func EndSpan(span trace.Span, err error) {
+	if tracer == nil || span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ExtractTraceContext returns ctx with the trace context carried by the message.
+func ExtractTraceContext(ctx context.Context, carrier TraceCarrier) context.Context {
+	if tracer == nil {
+		return ctx
+	}
+	return propagator.Extract(ctx, textMapCarrier{carrier})
+}
+
+// InjectTraceContext writes the sampled span context of ctx to the message so
+// the next component continues the trace.
+func InjectTraceContext(ctx context.Context, carrier TraceCarrier) {
+	if tracer == nil || !trace.SpanContextFromContext(ctx).IsSampled() {
+		return
+	}
+	propagator.Inject(ctx, textMapCarrier{carrier})
+}
+
+// MessageSpans are the spans of an operation done on a whole batch, one per
+// traced message of the batch.
+type MessageSpans []trace.Span
+
+// StartMessageSpans starts a span for every message carrying a sampled trace
+// context, so the batch operation shows up in the trace of each message. The
+// new span contexts are written back to the messages.
+func StartMessageSpans(ctx context.Context, name string, carriers []TraceCarrier, attrs ...attribute.KeyValue) MessageSpans {
+	if tracer == nil {
+		return nil
+	}
+
+	var spans MessageSpans
+	for _, carrier := range carriers {
+		msgCtx := propagator.Extract(ctx, textMapCarrier{carrier})
+		if !trace.SpanContextFromContext(msgCtx).IsSampled() {
+			continue
+		}
+
+		msgCtx, span := tracer.Start(msgCtx, name, trace.WithAttributes(attrs...))
+		propagator.Inject(msgCtx, textMapCarrier{carrier})
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// Context returns ctx carrying the first span, so a latency recorded for the
+// batch keeps the trace of one of its messages as exemplar.
+func (s MessageSpans) Context(ctx context.Context) context.Context {
+	if len(s) == 0 {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, s[0])
+}
+
+// End records the error, if any, and ends all spans.
+func (s MessageSpans) End(err error) {
+	for _, span := range s {
+		EndSpan(span, err)
+	}
+}
@@ -0,0 +1,155 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer = tracerProvider.Tracer("test")
+	t.Cleanup(func() {
+		tracer = nil
+		tracerProvider = nil
+	})
+
+	return exporter
+}
+
+func TestStartMessageSpans(t *testing.T) {
+	exporter := setupTestTracer(t)
+	ctx := context.Background()
+
+	ctx, root := StartSpan(ctx, SpanKafkaFetch)
+	traced := propagation.HeaderCarrier{}
+	InjectTraceContext(ctx, traced)
+	root.End()
+	require.NotEmpty(t, traced.Get("traceparent"))
+
+	untraced := propagation.HeaderCarrier{}
+	spans := StartMessageSpans(context.Background(), SpanClickHouseInsert,
+		[]TraceCarrier{traced, untraced},
+		attribute.String("db.system", "clickhouse"),
+	)
+	require.Len(t, spans, 1)
+	spans.End(errors.New("insert failed"))
+
+	assert.Empty(t, untraced.Get("traceparent"))
+
+	got := exporter.GetSpans()
+	require.Len(t, got, 2)
+	insert := got[1]
+	assert.Equal(t, SpanClickHouseInsert, insert.Name)
+	assert.Equal(t, root.SpanContext().TraceID(), insert.SpanContext.TraceID())
+	assert.Equal(t, root.SpanContext().SpanID(), insert.Parent.SpanID())
+	assert.Equal(t, codes.Error, insert.Status.Code)
+
+	// The message now carries the insert span for the next component
+	next := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), traced))
+	assert.Equal(t, insert.SpanContext.SpanID(), next.SpanID())
+}
+
+func TestTracingDisabled(t *testing.T) {
+	header := propagation.HeaderCarrier{}
+	ctx, span := StartSpan(context.Background(), SpanNATSPublish)
+	InjectTraceContext(ctx, header)
+	EndSpan(span, nil)
+
+	assert.False(t, TracingEnabled())
+	assert.Empty(t, header)
+	assert.Nil(t, StartMessageSpans(ctx, SpanNATSPublish, []TraceCarrier{header}))
+}
+
+func TestInitTracing_ExportsOverOTLP(t *testing.T) {
+	received := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var req coltracepb.ExportTraceServiceRequest
+		assert.NoError(t, proto.Unmarshal(body, &req))
+		received <- &req
+	}))
+	defer server.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	require.NoError(t, InitTracing(&Config{TracesEnabled: true, TraceRoot: true, ServiceName: "ingestor"}))
+	t.Cleanup(func() {
+		tracer = nil
+		tracerProvider = nil
+	})
+
+	ctx, parent := StartSpan(context.Background(), SpanNATSPublish)
+	_, child := StartSpan(ctx, SpanJoinBufferLookup, trace.WithAttributes(attribute.Bool("join.buffer_hit", true)))
+	EndSpan(child, errors.New("lookup failed"))
+	EndSpan(parent, nil)
+	require.NoError(t, ShutdownTracing(context.Background()))
+
+	req := <-received
+	require.Len(t, req.ResourceSpans, 1)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	lookup, publish := spans[0], spans[1]
+	assert.Equal(t, SpanJoinBufferLookup, lookup.Name)
+	assert.Equal(t, publish.SpanId, lookup.ParentSpanId)
+	assert.Empty(t, publish.ParentSpanId)
+	assert.Equal(t, tracev1.Status_STATUS_CODE_ERROR, lookup.Status.Code)
+	assert.Equal(t, "lookup failed", lookup.Status.Message)
+}
+
+func TestTraceSampler(t *testing.T) {
+	sampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	unsampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{2},
+		SpanID:  trace.SpanID{2},
+	})
+
+	tests := []struct {
+		name   string
+		cfg    Config
+		parent trace.SpanContext
+		want   bool
+	}{
+		{name: "ingestor starts traces", cfg: Config{TraceRoot: true, TraceSampleRatio: 1}, want: true},
+		{name: "downstream component never starts a trace", cfg: Config{TraceSampleRatio: 1}, want: false},
+		{name: "downstream component follows a sampled message", cfg: Config{}, parent: sampled, want: true},
+		{name: "downstream component follows an unsampled message", cfg: Config{TraceSampleRatio: 1}, parent: unsampled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(traceSampler(&tt.cfg)))
+			ctx := trace.ContextWithRemoteSpanContext(context.Background(), tt.parent)
+			_, span := provider.Tracer("test").Start(ctx, SpanJoinBufferLookup)
+			defer span.End()
+
+			assert.Equal(t, tt.want, span.SpanContext().IsSampled())
+		})
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
-
-	"github.com/avast/retry-go/v4"
 )
 
 func (c *Client) authenticate(ctx context.Context) error {
@@ -62,79 +61,73 @@ func (c *Client) authenticate(ctx context.Context) error {
 	return nil
 }
 
-func (c *Client) sendEventSync(ctx context.Context, eventName, eventSource string, properties map[string]interface{}) error {
-
-	if properties == nil {
-		properties = make(map[string]interface{})
-	}
-
-	event := Event{
-		InstallationID: c.installationID,
-		EventName:      eventName,
-		EventSource:    eventSource,
-		Timestamp:      time.Now().UTC().Format(time.RFC3339),
-		Properties:     properties,
-	}
+// errEventRejected marks events the endpoint refused, sending them again
+// would fail the same way.
+var errEventRejected = errors.New("usage stats event rejected")
 
+func (c *Client) sendEventSync(ctx context.Context, event Event) error {
 	jsonData, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("marshal event: %s", err)
+		return fmt.Errorf("%w: marshal event: %s", errEventRejected, err)
 	}
 
 	url := fmt.Sprintf("%s/track", c.endpoint)
 
 	c.log.Debug("usage stats: sending event request")
 
-	err = retry.Do(func() error {
-		token, err := c.getToken(ctx)
-		if err != nil {
-			return fmt.Errorf("get token: %s", err)
-		}
-
-		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return fmt.Errorf("create request: %s", err)
-		}
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get token: %s", err)
+	}
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("accept", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("create request: %s", err)
+	}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("request failed: %s", err)
-		}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("usage stats failed with status %d: %s", resp.StatusCode, string(body))
-		}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
 
-		var trackResp TrackResponse
-		if err := json.NewDecoder(resp.Body).Decode(&trackResp); err != nil {
-			return fmt.Errorf("decode response: %s", err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err = fmt.Errorf("usage stats failed with status %d: %s", resp.StatusCode, string(body))
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			// The token is authenticated again on the next attempt
+			c.tokenMu.Lock()
+			c.token = ""
+			c.tokenMu.Unlock()
+		case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+			err = fmt.Errorf("%w: %s", errEventRejected, err)
 		}
+		return err
+	}
 
-		c.log.Debug("usage stats: event sent successfully", "event", eventName, "response", trackResp, "status", resp.StatusCode)
+	var trackResp TrackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&trackResp); err != nil {
+		return fmt.Errorf("decode response: %s", err)
+	}
 
-		return nil
-	},
-		retry.OnRetry(func(n uint, err error) {
-			c.log.Debug("usage stats: retrying event send", "event", eventName, "attempt", n+1, "error", err)
-		}),
-		retry.Attempts(retryAttempts),
-		retry.Delay(retryDelay),
-		retry.DelayType(retry.BackOffDelay),
-		retry.LastErrorOnly(true),
-	)
+	c.log.Debug("usage stats: event sent successfully", "event", event.EventName, "response", trackResp, "status", resp.StatusCode)
 
-	return err
+	return nil
 }
 
 const (
-	retryAttempts = 3
-	retryDelay    = 10 * time.Second
+	// maxQueuedEvents bounds the events kept while the endpoint is
+	// unreachable, newer events are dropped once it is reached
+	maxQueuedEvents = 1000
+	sendTimeout     = 30 * time.Second
+	minRetryDelay   = time.Second
+	maxRetryDelay   = 5 * time.Minute
 )
 
 type AuthResponse struct {
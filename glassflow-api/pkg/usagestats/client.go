@@ -3,6 +3,7 @@ package usagestats
 import (
 	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/pkg/observability"
 )
 
 // PipelineGetter is a minimal interface for fetching pipeline configurations
@@ -38,6 +40,15 @@ type Client struct {
 	enabled       bool
 	pipelineStore PipelineGetter
 	eventChan     chan PipelineEvent
+
+	// queue holds the events until the sender goroutine, started with the
+	// first event, delivered them
+	queue         chan Event
+	senderOnce    sync.Once
+	minRetryDelay time.Duration
+	maxRetryDelay time.Duration
+
+	topologyFields []TopologyField
 }
 
 type Event struct {
@@ -48,7 +59,7 @@ type Event struct {
 	Properties     map[string]interface{} `json:"properties"`
 }
 
-func NewClient(endpoint, username, password, installationID string, enabled bool, log *slog.Logger, pipelineStore PipelineGetter) *Client {
+func NewClient(endpoint, username, password, installationID string, enabled bool, log *slog.Logger, pipelineStore PipelineGetter, opts ...ClientOption) *Client {
 	if !enabled {
 		return &Client{enabled: false}
 	}
@@ -65,7 +76,7 @@ func NewClient(endpoint, username, password, installationID string, enabled bool
 		return &Client{enabled: false}
 	}
 
-	return &Client{
+	c := &Client{
 		endpoint:       endpoint,
 		username:       username,
 		password:       password,
@@ -73,11 +84,19 @@ func NewClient(endpoint, username, password, installationID string, enabled bool
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		log:           log,
-		enabled:       true,
-		pipelineStore: pipelineStore,
-		eventChan:     make(chan PipelineEvent, 100), // buffered channel
+		log:            log,
+		enabled:        true,
+		pipelineStore:  pipelineStore,
+		eventChan:      make(chan PipelineEvent, 100), // buffered channel
+		queue:          make(chan Event, maxQueuedEvents),
+		minRetryDelay:  minRetryDelay,
+		maxRetryDelay:  maxRetryDelay,
+		topologyFields: DefaultTopologyFields,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // GetEventChannel returns the event channel for external consumption
@@ -94,6 +113,10 @@ func MaskPipelineID(pipelineID string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
+// SendEvent queues the event without blocking. The events are sent in order
+// by a single goroutine, retried with backoff while the endpoint is
+// unreachable so that an outage is sent once it ends. Events are dropped when
+// the queue is full or the endpoint rejects them.
 func (c *Client) SendEvent(eventName, eventSource string, properties map[string]interface{}) {
 	if c == nil || !c.enabled {
 		return
@@ -101,17 +124,58 @@ func (c *Client) SendEvent(eventName, eventSource string, properties map[string]
 
 	c.log.Debug("usage stats: sending event", "event", eventName, "source", eventSource, "properties", properties)
 
-	go func() {
-		usageStatsCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+
+	event := Event{
+		InstallationID: c.installationID,
+		EventName:      eventName,
+		EventSource:    eventSource,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		Properties:     properties,
+	}
+
+	c.senderOnce.Do(func() {
+		go c.sendQueuedEvents()
+	})
+
+	select {
+	case c.queue <- event:
+		observability.RecordUsageStatsQueuedEvents(context.Background(), int64(len(c.queue)))
+	default:
+		observability.RecordUsageStatsEventDropped(context.Background(), observability.UsageStatsDropQueueFull)
+		c.log.Debug("usage stats: queue full, dropping event", "event", eventName, "source", eventSource)
+	}
+}
 
-		if err := c.sendEventSync(usageStatsCtx, eventName, eventSource, properties); err != nil {
-			c.log.Debug("usage stats event send failed", "event", eventName, "source", eventSource, "error", err)
-			return
+// sendQueuedEvents sends the queued events one by one, an event failing to
+// send is retried until it is delivered or rejected.
+func (c *Client) sendQueuedEvents() {
+	delay := c.minRetryDelay
+	for event := range c.queue {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			err := c.sendEventSync(ctx, event)
+			cancel()
+
+			if err == nil {
+				delay = c.minRetryDelay
+				break
+			}
+			if errors.Is(err, errEventRejected) {
+				observability.RecordUsageStatsEventDropped(context.Background(), observability.UsageStatsDropRejected)
+				c.log.Debug("usage stats: event rejected, dropping it", "event", event.EventName, "source", event.EventSource, "error", err)
+				break
+			}
+
+			c.log.Debug("usage stats: event send failed, retrying", "event", event.EventName, "source", event.EventSource, "retry_in", delay, "error", err)
+			time.Sleep(delay)
+			delay = min(delay*2, c.maxRetryDelay)
 		}
 
-		c.log.Debug("usage stats: event sent successfully", "event", eventName, "source", eventSource)
-	}()
+		observability.RecordUsageStatsQueuedEvents(context.Background(), int64(len(c.queue)))
+	}
 }
 
 func (c *Client) getToken(ctx context.Context) (string, error) {
@@ -167,38 +231,6 @@ func (c *Client) checkTransformations(cfg *models.PipelineConfig) (hasDedup, has
 	return hasDedup, hasJoin, hasFilter, hasStatelessTransform
 }
 
-// buildPipelineEventProperties builds the properties map for a pipeline event
-func (c *Client) buildPipelineEventProperties(cfg *models.PipelineConfig, pipelineID string) map[string]interface{} {
-	hasDedup, hasJoin, hasFilter, hasStatelessTransform := c.checkTransformations(cfg)
-
-	chBatchSize := 0
-	chSyncDelay := ""
-	if cfg.Sink.Batch.MaxBatchSize > 0 {
-		chBatchSize = cfg.Sink.Batch.MaxBatchSize
-	}
-	if cfg.Sink.Batch.MaxDelayTime.Duration() > 0 {
-		chSyncDelay = cfg.Sink.Batch.MaxDelayTime.String()
-	}
-
-	properties := map[string]interface{}{
-		"pipeline_id_hash":        MaskPipelineID(pipelineID),
-		"has_dedup":               hasDedup,
-		"has_join":                hasJoin,
-		"has_filter":              hasFilter,
-		"has_stateless_transform": hasStatelessTransform,
-		"ch_batch_size":           chBatchSize,
-		"ch_sync_delay":           chSyncDelay,
-	}
-
-	// Add ingestor replica counts for each topic
-	for i, topic := range cfg.Ingestor.KafkaTopics {
-		replicaKey := fmt.Sprintf("ingestor_replicas_t%d", i+1)
-		properties[replicaKey] = topic.Replicas
-	}
-
-	return properties
-}
-
 // ProcessPipelineEvent processes a pipeline event by fetching pipeline data and sending usage stats
 func (c *Client) ProcessPipelineEvent(ctx context.Context, event PipelineEvent) {
 	if c == nil || !c.enabled {
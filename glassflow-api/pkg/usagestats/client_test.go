@@ -2,13 +2,18 @@ package usagestats
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockPipelineGetter is a mock implementation of PipelineGetter
@@ -369,3 +374,108 @@ func TestMaskPipelineID_Consistency(t *testing.T) {
 	// Same input should produce same output
 	assert.Equal(t, masked1, masked2)
 }
+
+func TestClient_SendEvent_RetriesInOrder(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK, http.StatusBadRequest, http.StatusOK}
+		received []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/login" {
+			_ = json.NewEncoder(w).Encode(AuthResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+
+		mu.Lock()
+		status := statuses[0]
+		statuses = statuses[1:]
+		if status == http.StatusOK {
+			received = append(received, event.EventName)
+		}
+		mu.Unlock()
+
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(TrackResponse{Success: status == http.StatusOK})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-user", "test-password", "test-installation-id", true, slog.Default(), nil)
+	client.minRetryDelay = time.Millisecond
+	client.maxRetryDelay = time.Millisecond
+
+	// The first event waits out the outage, the second one is rejected
+	client.SendEvent("ready", "api", nil)
+	client.SendEvent("rejected", "api", nil)
+	client.SendEvent("terminated", "api", nil)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"ready", "terminated"}, received)
+}
+
+func TestClient_SendEvent_QueueFull(t *testing.T) {
+	client := NewClient("http://test-endpoint", "test-user", "test-password", "test-installation-id", true, slog.Default(), nil)
+	client.queue = make(chan Event, 1)
+	// No sender drains the queue
+	client.senderOnce.Do(func() {})
+
+	client.SendEvent("ready", "api", nil)
+	client.SendEvent("terminated", "api", nil)
+
+	require.Len(t, client.queue, 1)
+	assert.Equal(t, "ready", (<-client.queue).EventName)
+}
+
+func TestParseTopologyFields(t *testing.T) {
+	fields, err := ParseTopologyFields("counts, batch_sizes,counts")
+	require.NoError(t, err)
+	assert.Equal(t, []TopologyField{TopologyCounts, TopologyBatchSizes}, fields)
+
+	fields, err = ParseTopologyFields("none")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = ParseTopologyFields("component_types,hosts")
+	assert.Error(t, err)
+}
+
+func TestClient_PreviewPipelineEvent(t *testing.T) {
+	cfg := &models.PipelineConfig{
+		ID:   "test-pipeline",
+		Name: "Secret Pipeline",
+		Ingestor: models.IngestorComponentConfig{
+			KafkaConnectionParams: models.KafkaConnectionParamsConfig{Brokers: []string{"kafka.internal:9092"}},
+			KafkaTopics:           []models.KafkaTopicsConfig{{Name: "orders", Replicas: 2}},
+		},
+		Sink: models.SinkComponentConfig{Batch: models.BatchConfig{MaxBatchSize: 500}},
+	}
+	store := new(MockPipelineGetter)
+	store.On("GetPipeline", mock.Anything, "test-pipeline").Return(cfg, nil)
+
+	client := NewClient("http://test-endpoint", "test-user", "test-password", "test-installation-id", true, slog.Default(), store,
+		WithTopologyFields([]TopologyField{TopologyCounts}))
+
+	preview, err := client.PreviewPipelineEvent(context.Background(), "test-pipeline", "create-pipeline")
+	require.NoError(t, err)
+	assert.True(t, preview.Enabled)
+	require.NotNil(t, preview.Event)
+	assert.Equal(t, "create-pipeline", preview.Event.EventName)
+	assert.Equal(t, map[string]interface{}{
+		"pipeline_id_hash":     MaskPipelineID("test-pipeline"),
+		"topic_count":          1,
+		"ingestor_replicas_t1": 2,
+	}, preview.Event.Properties)
+
+	disabled := NewClient("", "", "", "", false, slog.Default(), nil)
+	preview, err = disabled.PreviewPipelineEvent(context.Background(), "test-pipeline", "create-pipeline")
+	require.NoError(t, err)
+	assert.False(t, preview.Enabled)
+	assert.Nil(t, preview.Event)
+}
@@ -0,0 +1,146 @@
+package usagestats
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glassflow/clickhouse-etl-internal/glassflow-api/internal/models"
+)
+
+// TopologyField is a group of pipeline topology properties included in the
+// usage stats of the pipeline events. No group includes names, hosts or
+// other identifying values, the pipeline ID is only sent hashed.
+type TopologyField string
+
+const (
+	// TopologyComponentTypes reports which components the pipeline uses
+	TopologyComponentTypes TopologyField = "component_types"
+	// TopologyCounts reports the number of topics and the replicas
+	TopologyCounts TopologyField = "counts"
+	// TopologyBatchSizes reports the batch size and delay of the sink
+	TopologyBatchSizes TopologyField = "batch_sizes"
+)
+
+// DefaultTopologyFields are reported unless configured otherwise.
+var DefaultTopologyFields = []TopologyField{TopologyComponentTypes, TopologyCounts, TopologyBatchSizes}
+
+// ParseTopologyFields parses a comma separated list of topology fields.
+// "none" reports no topology, only the hashed pipeline ID.
+func ParseTopologyFields(s string) ([]TopologyField, error) {
+	s = strings.TrimSpace(s)
+	if s == "none" {
+		return []TopologyField{}, nil
+	}
+
+	var fields []TopologyField
+	for part := range strings.SplitSeq(s, ",") {
+		field := TopologyField(strings.TrimSpace(part))
+		if !slices.Contains(DefaultTopologyFields, field) {
+			return nil, fmt.Errorf("unknown usage stats topology field %q, expected none or a list of %s, %s and %s",
+				field, TopologyComponentTypes, TopologyCounts, TopologyBatchSizes)
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+type ClientOption func(*Client)
+
+// WithTopologyFields limits the topology reported in the pipeline events to
+// fields.
+func WithTopologyFields(fields []TopologyField) ClientOption {
+	return func(c *Client) {
+		c.topologyFields = fields
+	}
+}
+
+// Preview is the payload sent for a pipeline event, Event is nil when usage
+// stats are disabled and nothing is sent.
+type Preview struct {
+	Enabled        bool            `json:"enabled"`
+	TopologyFields []TopologyField `json:"topology_fields"`
+	Event          *Event          `json:"event,omitempty"`
+}
+
+// PreviewPipelineEvent returns the event sent for the pipeline on eventName,
+// e.g. create-pipeline, without sending it.
+func (c *Client) PreviewPipelineEvent(ctx context.Context, pipelineID, eventName string) (Preview, error) {
+	if c == nil || !c.enabled {
+		return Preview{TopologyFields: []TopologyField{}}, nil
+	}
+
+	properties := map[string]interface{}{
+		"pipeline_id_hash": MaskPipelineID(pipelineID),
+	}
+	if eventName != "delete-pipeline" {
+		if c.pipelineStore == nil {
+			return Preview{}, fmt.Errorf("pipeline store not available")
+		}
+		pipeline, err := c.pipelineStore.GetPipeline(ctx, pipelineID)
+		if err != nil {
+			return Preview{}, err
+		}
+		properties = c.buildPipelineEventProperties(pipeline, pipelineID)
+	}
+
+	return Preview{
+		Enabled:        true,
+		TopologyFields: c.topologyFields,
+		Event: &Event{
+			InstallationID: c.installationID,
+			EventName:      eventName,
+			EventSource:    "api",
+			Timestamp:      time.Now().UTC().Format(time.RFC3339),
+			Properties:     properties,
+		},
+	}, nil
+}
+
+func (c *Client) reportsTopology(field TopologyField) bool {
+	return slices.Contains(c.topologyFields, field)
+}
+
+// buildPipelineEventProperties builds the properties map for a pipeline
+// event from the configured topology fields
+func (c *Client) buildPipelineEventProperties(cfg *models.PipelineConfig, pipelineID string) map[string]interface{} {
+	properties := map[string]interface{}{
+		"pipeline_id_hash": MaskPipelineID(pipelineID),
+	}
+
+	if c.reportsTopology(TopologyComponentTypes) {
+		hasDedup, hasJoin, hasFilter, hasStatelessTransform := c.checkTransformations(cfg)
+		properties["has_dedup"] = hasDedup
+		properties["has_join"] = hasJoin
+		properties["has_filter"] = hasFilter
+		properties["has_stateless_transform"] = hasStatelessTransform
+	}
+
+	if c.reportsTopology(TopologyBatchSizes) {
+		chBatchSize := 0
+		chSyncDelay := ""
+		if cfg.Sink.Batch.MaxBatchSize > 0 {
+			chBatchSize = cfg.Sink.Batch.MaxBatchSize
+		}
+		if cfg.Sink.Batch.MaxDelayTime.Duration() > 0 {
+			chSyncDelay = cfg.Sink.Batch.MaxDelayTime.String()
+		}
+		properties["ch_batch_size"] = chBatchSize
+		properties["ch_sync_delay"] = chSyncDelay
+	}
+
+	if c.reportsTopology(TopologyCounts) {
+		properties["topic_count"] = len(cfg.Ingestor.KafkaTopics)
+		// Add ingestor replica counts for each topic
+		for i, topic := range cfg.Ingestor.KafkaTopics {
+			replicaKey := fmt.Sprintf("ingestor_replicas_t%d", i+1)
+			properties[replicaKey] = topic.Replicas
+		}
+	}
+
+	return properties
+}
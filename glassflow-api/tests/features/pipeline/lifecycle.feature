@@ -0,0 +1,269 @@
+@pipeline
+Feature: Pipeline lifecycle
+
+    Scenario: Stopped pipeline resumes from the committed Kafka offsets
+        Given a Kafka topic "test_topic" with 1 partition
+        And the ClickHouse table "events_test" on database "default" already exists with schema
+            | column_name | data_type |
+            | id          | String    |
+            | name        | String    |
+
+        And I write these events to Kafka topic "test_topic":
+            | key | value                               |
+            | 1   | {"id": "123", "name": "John Doe"}   |
+            | 2   | {"id": "456", "name": "Jane Smith"} |
+
+        And a glassflow pipeline with next configuration:
+            """json
+            {
+                "pipeline_id": "kafka-to-clickhouse-pipeline-r00001",
+                "source_type": "kafka",
+                "name": "kafka-to-clickhouse-pipeline-r00001",
+                "ingestor": {
+                    "type": "kafka",
+                    "kafka_topics": [
+                        {
+                            "id": "test_topic",
+                            "consumer_group_initial_offset": "earliest",
+                            "consumer_group_name": "glassflow-consumer-group-kafka-to-clickhouse-pipeline-r00001",
+                            "name": "test_topic",
+                            "replicas": 1,
+                            "deduplication": {
+                                "enabled": false
+                            },
+                            "output_stream_id": "gfm-5a11c0de-test_topic",
+                            "output_stream_subject": "gfm-5a11c0de-test_topic.input"
+                        }
+                    ]
+                },
+                "sink": {
+                    "type": "clickhouse",
+                    "source_id": "test_topic",
+                    "stream_id": "gfm-5a11c0de-test_topic",
+                    "batch": {
+                        "max_batch_size": 1000,
+                        "max_delay_time": "1s"
+                    },
+                    "clickhouse_connection_params": {
+                        "database": "default",
+                        "secure": false,
+                        "table": "events_test"
+                    },
+                    "nats_consumer_name": "gf-nats-si-5a11c0de",
+                    "config": [
+                        {
+                            "source_field": "id",
+                            "source_type": "string",
+                            "destination_field": "id",
+                            "destination_type": "String"
+                        },
+                        {
+                            "source_field": "name",
+                            "source_type": "string",
+                            "destination_field": "name",
+                            "destination_type": "String"
+                        }
+                    ]
+                },
+                "schema_versions": {
+                    "test_topic": {
+                        "source_id": "test_topic",
+                        "version_id": "1",
+                        "data_type": "json",
+                        "fields": [
+                            {
+                                "name": "id",
+                                "type": "string"
+                            },
+                            {
+                                "name": "name",
+                                "type": "string"
+                            }
+                        ]
+                    }
+                }
+            }
+            """
+        And I stop the glassflow pipeline after "4s"
+        Then the pipeline status should be "Stopped"
+        And the ClickHouse table "default.events_test" should contain 2 rows
+
+        When I write these events to Kafka topic "test_topic":
+            | key | value                                |
+            | 3   | {"id": "789", "name": "Bob Johnson"} |
+            | 4   | {"id": "007", "name": "James Bond"}  |
+
+        And I resume the glassflow pipeline
+        Then the pipeline status should be "Running"
+
+        When I shutdown the glassflow pipeline after "4s"
+        Then the ClickHouse table "default.events_test" should contain:
+            | id  | name        | COUNT |
+            | 123 | John Doe    | 1     |
+            | 456 | Jane Smith  | 1     |
+            | 789 | Bob Johnson | 1     |
+            | 007 | James Bond  | 1     |
+
+    Scenario: Edited pipeline runs with the new sink mapping
+        Given a Kafka topic "test_topic" with 1 partition
+        And the ClickHouse table "events_test" on database "default" already exists with schema
+            | column_name | data_type |
+            | id          | String    |
+            | name        | String    |
+
+        And I write these events to Kafka topic "test_topic":
+            | key | value                               |
+            | 1   | {"id": "123", "name": "John Doe"}   |
+            | 2   | {"id": "456", "name": "Jane Smith"} |
+
+        And a glassflow pipeline with next configuration:
+            """json
+            {
+                "pipeline_id": "kafka-to-clickhouse-pipeline-e00001",
+                "source_type": "kafka",
+                "name": "kafka-to-clickhouse-pipeline-e00001",
+                "ingestor": {
+                    "type": "kafka",
+                    "kafka_topics": [
+                        {
+                            "id": "test_topic",
+                            "consumer_group_initial_offset": "earliest",
+                            "consumer_group_name": "glassflow-consumer-group-kafka-to-clickhouse-pipeline-e00001",
+                            "name": "test_topic",
+                            "replicas": 1,
+                            "deduplication": {
+                                "enabled": false
+                            },
+                            "output_stream_id": "gfm-6b22d1ef-test_topic",
+                            "output_stream_subject": "gfm-6b22d1ef-test_topic.input"
+                        }
+                    ]
+                },
+                "sink": {
+                    "type": "clickhouse",
+                    "source_id": "test_topic",
+                    "stream_id": "gfm-6b22d1ef-test_topic",
+                    "batch": {
+                        "max_batch_size": 1000,
+                        "max_delay_time": "1s"
+                    },
+                    "clickhouse_connection_params": {
+                        "database": "default",
+                        "secure": false,
+                        "table": "events_test"
+                    },
+                    "nats_consumer_name": "gf-nats-si-6b22d1ef",
+                    "config": [
+                        {
+                            "source_field": "id",
+                            "source_type": "string",
+                            "destination_field": "id",
+                            "destination_type": "String"
+                        }
+                    ]
+                },
+                "schema_versions": {
+                    "test_topic": {
+                        "source_id": "test_topic",
+                        "version_id": "1",
+                        "data_type": "json",
+                        "fields": [
+                            {
+                                "name": "id",
+                                "type": "string"
+                            },
+                            {
+                                "name": "name",
+                                "type": "string"
+                            }
+                        ]
+                    }
+                }
+            }
+            """
+        And I stop the glassflow pipeline after "4s"
+        Then the pipeline status should be "Stopped"
+
+        When I write these events to Kafka topic "test_topic":
+            | key | value                                |
+            | 3   | {"id": "789", "name": "Bob Johnson"} |
+
+        And I edit the glassflow pipeline with next configuration:
+            """json
+            {
+                "pipeline_id": "kafka-to-clickhouse-pipeline-e00001",
+                "source_type": "kafka",
+                "name": "kafka-to-clickhouse-pipeline-e00001",
+                "ingestor": {
+                    "type": "kafka",
+                    "kafka_topics": [
+                        {
+                            "id": "test_topic",
+                            "consumer_group_initial_offset": "earliest",
+                            "consumer_group_name": "glassflow-consumer-group-kafka-to-clickhouse-pipeline-e00001",
+                            "name": "test_topic",
+                            "replicas": 1,
+                            "deduplication": {
+                                "enabled": false
+                            },
+                            "output_stream_id": "gfm-6b22d1ef-test_topic",
+                            "output_stream_subject": "gfm-6b22d1ef-test_topic.input"
+                        }
+                    ]
+                },
+                "sink": {
+                    "type": "clickhouse",
+                    "source_id": "test_topic",
+                    "stream_id": "gfm-6b22d1ef-test_topic",
+                    "batch": {
+                        "max_batch_size": 1000,
+                        "max_delay_time": "1s"
+                    },
+                    "clickhouse_connection_params": {
+                        "database": "default",
+                        "secure": false,
+                        "table": "events_test"
+                    },
+                    "nats_consumer_name": "gf-nats-si-6b22d1ef",
+                    "config": [
+                        {
+                            "source_field": "id",
+                            "source_type": "string",
+                            "destination_field": "id",
+                            "destination_type": "String"
+                        },
+                        {
+                            "source_field": "name",
+                            "source_type": "string",
+                            "destination_field": "name",
+                            "destination_type": "String"
+                        }
+                    ]
+                },
+                "schema_versions": {
+                    "test_topic": {
+                        "source_id": "test_topic",
+                        "version_id": "1",
+                        "data_type": "json",
+                        "fields": [
+                            {
+                                "name": "id",
+                                "type": "string"
+                            },
+                            {
+                                "name": "name",
+                                "type": "string"
+                            }
+                        ]
+                    }
+                }
+            }
+            """
+        Then the pipeline status should be "Running"
+
+        When I shutdown the glassflow pipeline after "4s"
+        Then the ClickHouse table "default.events_test" should contain:
+            | id  | name        | COUNT |
+            | 123 |             | 1     |
+            | 456 |             | 1     |
+            | 789 | Bob Johnson | 1     |
@@ -49,6 +49,6 @@ func (a *APISteps) CleanupResources() error {
 func (a *APISteps) aRunningGlassflowAPIServer() error {
 	// Create a minimal router for API-only tests
 	usageStatsClient := usagestats.NewClient("", "", "", "", false, a.log, nil)
-	a.httpRouter = api.NewRouter(a.log, nil, nil, usageStatsClient)
+	a.httpRouter = api.NewRouter(a.log, nil, nil, usageStatsClient, nil, nil)
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -581,10 +582,12 @@ func (b *BaseTestSuite) publishEvents(count int, dataTable *godog.Table, subject
 
 // ValidateEventsFromStream fetches and validates events from a NATS stream against expected data from a Gherkin table.
 // It uses a signature-based matching approach that's order-independent and handles NATS headers.
+// Keys in ignoredKeys are dropped from received events unless the table has a column for them.
 func (b *BaseTestSuite) ValidateEventsFromStream(
 	consumer jetstream.Consumer,
 	dataTable *godog.Table,
 	streamName, subject string,
+	ignoredKeys ...string,
 ) error {
 	expectedCount := len(dataTable.Rows) - 1
 	if expectedCount < 1 {
@@ -641,6 +644,12 @@ func (b *BaseTestSuite) ValidateEventsFromStream(
 			return fmt.Errorf("unmarshal message data: %w", err)
 		}
 
+		for _, key := range ignoredKeys {
+			if !slices.Contains(headers, key) {
+				delete(actual, key)
+			}
+		}
+
 		// Build signature from actual event data
 		sign := make([]string, 0)
 		for _, header := range headers {
@@ -261,6 +261,7 @@ func (j *JoinTestSuite) iRunJoinComponent(leftTTL, rightTTL string) error {
 		configStore,
 		leftKVStore,
 		rightKVStore,
+		nil, // unmatched events are dropped
 		leftSource.SourceID,
 		rightSource.SourceID,
 		leftSource.JoinKey,
@@ -1,13 +1,10 @@
 package steps
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
 	"strings"
 	"time"
 
@@ -174,10 +171,10 @@ func (p *PipelineSteps) fastCleanup() error {
 	}
 
 	if p.pipelineService != nil {
-		pipelineID := p.orchestrator.ActivePipelineID()
+		pipelineID := p.currentPipelineID
 
 		// Try to stop the pipeline first
-		err = p.pipelineService.StopPipeline(context.Background(), pipelineID)
+		err = p.pipelineService.StopPipeline(context.Background(), pipelineID, models.StopOptions{})
 		if err != nil {
 			// Log the error but continue - pipeline might already be stopped or not exist
 			p.log.Info("stop pipeline failed (might already be stopped)", slog.Any("error", err))
@@ -388,7 +385,7 @@ func (p *PipelineSteps) setupPipelineService() error {
 	}
 	p.pipelineStore = db
 
-	orch := orchestrator.NewLocalOrchestrator(natsClient, db, p.log)
+	orch := orchestrator.NewLocalOrchestrator(natsClient, db, nil, p.log)
 	p.orchestrator = orch.(*orchestrator.LocalOrchestrator)
 
 	usageStatsClient := usagestats.NewClient("", "", "", "", false, p.log, db)
@@ -399,7 +396,7 @@ func (p *PipelineSteps) setupPipelineService() error {
 		p.log,
 	)
 
-	p.httpRouter = api.NewRouter(p.log, p.pipelineService, dlq.NewClient(natsClient), usageStatsClient)
+	p.httpRouter = api.NewRouter(p.log, p.pipelineService, dlq.NewClient(natsClient), usageStatsClient, nil, nil)
 
 	return nil
 }
@@ -453,7 +450,7 @@ func (p *PipelineSteps) shutdownPipeline() error {
 	pipelineID := p.orchestrator.ActivePipelineID()
 
 	// Try to stop the pipeline first
-	err := p.pipelineService.StopPipeline(context.Background(), pipelineID)
+	err := p.pipelineService.StopPipeline(context.Background(), pipelineID, models.StopOptions{})
 	if err != nil {
 		// Log the error but continue - pipeline might already be stopped or not exist
 		p.log.Info("stop pipeline failed (might already be stopped)", slog.Any("error", err))
@@ -483,13 +480,36 @@ func (p *PipelineSteps) shutdownPipelineWithDelay(delay string) error {
 	return nil
 }
 
+// stopPipelineWithDelay stops the pipeline through the pipeline service and,
+// unlike shutdown, keeps the service so the pipeline can be resumed or edited
+func (p *PipelineSteps) stopPipelineWithDelay(delay string) error {
+	p.log.Info("Stopping pipeline with delay", slog.String("delay", delay))
+	if p.pipelineService == nil {
+		return fmt.Errorf("pipeline manager not initialized")
+	}
+
+	dur, err := time.ParseDuration(delay)
+	if err != nil {
+		return fmt.Errorf("parse duration: %w", err)
+	}
+	time.Sleep(dur)
+
+	err = p.pipelineService.StopPipeline(context.Background(), p.currentPipelineID, models.StopOptions{})
+	if err != nil {
+		return fmt.Errorf("stop pipeline: %w", err)
+	}
+
+	p.log.Info("Pipeline stopped", slog.String("pipeline_id", p.currentPipelineID))
+	return nil
+}
+
 func (p *PipelineSteps) resumePipeline() error {
 	p.log.Info("Resuming pipeline")
 	if p.pipelineService == nil {
 		return fmt.Errorf("pipeline manager not initialized")
 	}
 
-	err := p.pipelineService.ResumePipeline(context.Background(), p.orchestrator.ActivePipelineID())
+	err := p.pipelineService.ResumePipeline(context.Background(), p.currentPipelineID, models.ResumeOptions{})
 	if err != nil {
 		return fmt.Errorf("resume pipeline: %w", err)
 	}
@@ -534,39 +554,23 @@ func (p *PipelineSteps) waitForPauseOperationComplete(duration string) error {
 	return p.waitFor(duration)
 }
 
-// editGlassflowPipeline edits the glassflow pipeline with the given configuration
+// editGlassflowPipeline edits the stopped glassflow pipeline with the given configuration
 func (p *PipelineSteps) editGlassflowPipeline(configJSON *godog.DocString) error {
 	if p.currentPipelineID == "" {
 		return fmt.Errorf("no current pipeline to edit")
 	}
 
-	// Parse the configuration JSON
-	var config map[string]interface{}
-	err := json.Unmarshal([]byte(configJSON.Content), &config)
-	if err != nil {
-		return fmt.Errorf("failed to parse pipeline configuration JSON: %w", err)
-	}
-
-	// Make HTTP request to edit pipeline endpoint
-	url := fmt.Sprintf("http://localhost:8080/api/v1/pipeline/%s/edit", p.currentPipelineID)
-
-	reqBody, err := json.Marshal(config)
+	pipelineConfig, err := p.preparePipelineConfig(configJSON.Content)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
+		return fmt.Errorf("prepare pipeline config: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqBody))
+	err = p.pipelineService.EditPipeline(context.Background(), p.currentPipelineID, pipelineConfig)
 	if err != nil {
-		return fmt.Errorf("failed to make edit pipeline request: %w", err)
+		return fmt.Errorf("edit pipeline: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("edit pipeline request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	p.log.Info("Pipeline edit request sent successfully", slog.String("pipeline_id", p.currentPipelineID))
+	p.log.Info("Pipeline edited successfully", slog.String("pipeline_id", p.currentPipelineID))
 	return nil
 }
 
@@ -576,47 +580,23 @@ func (p *PipelineSteps) editGlassflowPipelineAndExpectError(configJSON *godog.Do
 		return fmt.Errorf("no current pipeline to edit")
 	}
 
-	// Parse the configuration JSON
-	var config map[string]interface{}
-	err := json.Unmarshal([]byte(configJSON.Content), &config)
+	pipelineConfig, err := p.preparePipelineConfig(configJSON.Content)
 	if err != nil {
-		return fmt.Errorf("failed to parse pipeline configuration JSON: %w", err)
-	}
-
-	// Make HTTP request to edit pipeline endpoint
-	url := fmt.Sprintf("http://localhost:8080/api/v1/pipeline/%s/edit", p.currentPipelineID)
-
-	reqBody, err := json.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
-	}
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return fmt.Errorf("failed to make edit pipeline request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return fmt.Errorf("prepare pipeline config: %w", err)
 	}
 
-	// Check if we got the expected error
-	if resp.StatusCode == http.StatusNoContent {
-		return fmt.Errorf("expected error but got success (status 204)")
+	err = p.pipelineService.EditPipeline(context.Background(), p.currentPipelineID, pipelineConfig)
+	if err == nil {
+		return fmt.Errorf("expected error but pipeline edit succeeded")
 	}
 
-	// Check if the error message contains the expected text
-	if !strings.Contains(string(body), expectedError.Content) {
-		return fmt.Errorf("expected error message '%s' but got: %s", expectedError.Content, string(body))
+	if !strings.Contains(err.Error(), expectedError.Content) {
+		return fmt.Errorf("expected error message '%s' but got: %w", expectedError.Content, err)
 	}
 
-	p.log.Info("Pipeline edit request failed as expected",
+	p.log.Info("Pipeline edit failed as expected",
 		slog.String("pipeline_id", p.currentPipelineID),
-		slog.String("expected_error", expectedError.Content),
-		slog.Int("status_code", resp.StatusCode))
+		slog.String("expected_error", expectedError.Content))
 	return nil
 }
 
@@ -635,6 +615,7 @@ func (p *PipelineSteps) RegisterSteps(sc *godog.ScenarioContext) {
 
 	sc.Step(`^a glassflow pipeline with next configuration:$`, p.aGlassflowPipelineWithNextConfiguration)
 	sc.Step(`^I shutdown the glassflow pipeline after "([^"]*)"$`, p.shutdownPipelineWithDelay)
+	sc.Step(`^I stop the glassflow pipeline after "([^"]*)"$`, p.stopPipelineWithDelay)
 	sc.Step(`^I resume the glassflow pipeline$`, p.resumePipeline)
 	sc.Step(`^I resume the glassflow pipeline after "([^"]*)"$`, p.resumePipelineWithDelay)
 	sc.Step(`^I edit the glassflow pipeline with next configuration:$`, p.editGlassflowPipeline)
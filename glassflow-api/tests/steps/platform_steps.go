@@ -104,7 +104,7 @@ func (p *PlatformSteps) setupServices() error {
 
 	// Create orchestrator based on type
 	if p.orchestratorType == "local" {
-		p.orchestrator = orchestrator.NewLocalOrchestrator(p.natsClient, db, p.log)
+		p.orchestrator = orchestrator.NewLocalOrchestrator(p.natsClient, db, nil, p.log)
 	} else {
 		// For k8s orchestrator, we'll create a mock one for testing
 		p.orchestrator = &MockK8sOrchestrator{log: p.log}
@@ -115,7 +115,7 @@ func (p *PlatformSteps) setupServices() error {
 	p.pipelineService = service.NewPipelineService(p.orchestrator, db, p.log)
 
 	// Create HTTP router
-	p.httpRouter = api.NewRouter(p.log, p.pipelineService, nil, usageStatsClient)
+	p.httpRouter = api.NewRouter(p.log, p.pipelineService, nil, usageStatsClient, nil, nil)
 
 	return nil
 }
@@ -182,7 +182,7 @@ func (m *MockK8sOrchestrator) SetupPipeline(_ context.Context, _ *models.Pipelin
 	return fmt.Errorf("not implemented for testing")
 }
 
-func (m *MockK8sOrchestrator) StopPipeline(_ context.Context, _ string) error {
+func (m *MockK8sOrchestrator) StopPipeline(_ context.Context, _ string, _ models.StopOptions) error {
 	return fmt.Errorf("not implemented for testing")
 }
 
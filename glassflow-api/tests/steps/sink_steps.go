@@ -330,7 +330,12 @@ func (s *SinkTestSuite) iRunClickHouseSink() error {
 		make(chan struct{}),
 		logger,
 		dlqStreamPublisher,
+		s.pipelineConfig.ID,
 		"",
+		0,
+		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		return fmt.Errorf("create ClickHouse sink: %w", err)
@@ -876,7 +881,12 @@ func (s *SinkTestSuite) iRunSecondClickHouseSink() error {
 		make(chan struct{}),
 		logger,
 		dlqPublisher,
+		s.pipelineConfigB.ID,
 		"",
+		0,
+		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		return fmt.Errorf("create second ClickHouse sink: %w", err)
@@ -191,7 +191,7 @@ func (m *MockSchemaRegistryClient) AddSchema(schemaID int, fields []models.Field
 }
 
 // GetSchema implements SchemaRegistryClient interface
-func (m *MockSchemaRegistryClient) GetSchema(ctx context.Context, schemaID int) ([]models.Field, error) {
+func (m *MockSchemaRegistryClient) GetSchema(ctx context.Context, schemaID int, _ []byte) ([]models.Field, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -214,6 +214,12 @@ func (m *MockSchemaRegistryClient) GetSchema(ctx context.Context, schemaID int)
 	return nil, models.ErrSchemaNotFound
 }
 
+// Decode implements SchemaRegistryClient interface; mock schemas are JSON so
+// payloads are passed through unchanged
+func (m *MockSchemaRegistryClient) Decode(ctx context.Context, schemaID int, data []byte) ([]byte, error) {
+	return data, nil
+}
+
 // Clear removes all stored schemas
 func (m *MockSchemaRegistryClient) Clear() {
 	m.mu.Lock()
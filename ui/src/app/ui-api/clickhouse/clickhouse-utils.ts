@@ -22,6 +22,10 @@ export interface ClickHouseConfig {
   proxyUrl?: string
   connectionString?: string
   skipCertificateVerification?: boolean
+  // PEM encoded, the client certificate and key are for mutual TLS
+  caCertificate?: string
+  clientCert?: string
+  clientKey?: string
 }
 
 export async function createClickHouseConnection(config: ClickHouseConfig): Promise<ClickHouseConnection> {
@@ -34,6 +38,9 @@ export async function createClickHouseConnection(config: ClickHouseConfig): Prom
     database,
     useSSL = true,
     skipCertificateVerification = false,
+    caCertificate,
+    clientCert,
+    clientKey,
   } = config
 
   // Direct connection logic
@@ -57,6 +64,8 @@ export async function createClickHouseConnection(config: ClickHouseConfig): Prom
     const dispatcher = new Agent({
       connect: {
         rejectUnauthorized: !skipCertificateVerification,
+        ...(caCertificate && { ca: caCertificate }),
+        ...(clientCert && clientKey && { cert: clientCert, key: clientKey }),
       },
     })
 
@@ -96,6 +105,12 @@ export async function createClickHouseConnection(config: ClickHouseConfig): Prom
   }
 }
 
+// The pipeline config stores the TLS certificates as base64 encoded PEM
+export function decodePem(value: unknown): string | undefined {
+  if (typeof value !== 'string' || value === '') return undefined
+  return Buffer.from(value, 'base64').toString('utf-8')
+}
+
 // Helper functions for parsing responses
 export function parseTabSeparated(data: string): string[] {
   return data
@@ -24,6 +24,9 @@ export async function POST(request: Request): Promise<NextResponse> {
       proxyUrl: requestBody.proxyUrl,
       connectionString: requestBody.connectionString,
       skipCertificateVerification: requestBody.skipCertificateVerification ?? false,
+      caCertificate: requestBody.caCertificate,
+      clientCert: requestBody.clientCert,
+      clientKey: requestBody.clientKey,
     }
     const clickhouseService = new ClickhouseService()
     const result = await clickhouseService.dropTable(config, database, table)
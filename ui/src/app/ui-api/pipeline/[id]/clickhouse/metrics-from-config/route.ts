@@ -2,6 +2,7 @@ import { NextResponse } from 'next/server'
 import {
   createClickHouseConnection,
   closeConnection,
+  decodePem,
   quoteTableRef,
 } from '../../../../clickhouse/clickhouse-utils'
 import { validatePipelineIdOrError } from '../../../validation'
@@ -84,6 +85,9 @@ export async function POST(request: Request, { params }: { params: Promise<{ id:
       database,
       useSSL: sink.secure ?? cp.secure ?? false,
       skipCertificateVerification: sink.skip_certificate_verification ?? cp.skip_certificate_verification ?? false,
+      caCertificate: decodePem(cp.root_ca),
+      clientCert: decodePem(cp.client_cert),
+      clientKey: decodePem(cp.client_key),
     }
 
     // Connect to ClickHouse and fetch metrics
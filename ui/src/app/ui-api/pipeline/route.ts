@@ -3,7 +3,7 @@ import axios from 'axios'
 import { runtimeConfig } from '../config'
 import { structuredLogger } from '@/src/observability'
 import { ClickhouseService } from '@/src/services/clickhouse-service'
-import type { ClickHouseConfig } from '../clickhouse/clickhouse-utils'
+import { decodePem, type ClickHouseConfig } from '../clickhouse/clickhouse-utils'
 
 // Get API URL from runtime config
 const API_URL = runtimeConfig.apiUrl
@@ -88,6 +88,9 @@ function buildCreateTableParams(config: {
     database,
     useSSL: Boolean(sink.secure ?? cp.secure ?? true),
     skipCertificateVerification: Boolean(sink.skip_certificate_verification ?? cp.skip_certificate_verification ?? false),
+    caCertificate: decodePem(cp.root_ca),
+    clientCert: decodePem(cp.client_cert),
+    clientKey: decodePem(cp.client_key),
   }
   return {
     chConfig,
@@ -114,12 +114,18 @@ export function getKafkaConfig(requestBody: any) {
 
     case 'AWS_MSK_IAM':
       const { awsRegion, awsAccessKey, awsAccessKeySecret, awsIAMRoleArn } = requestBody
-      if (!awsRegion || !awsAccessKey || !awsAccessKeySecret) {
+      if (!awsRegion) {
         return { success: false, error: 'Missing required AWS_MSK_IAM parameters' }
       }
+      // Without keys the role of the UI pod is used
+      if (Boolean(awsAccessKey) !== Boolean(awsAccessKeySecret)) {
+        return { success: false, error: 'AWS access key and secret must be set together' }
+      }
       kafkaConfig.awsRegion = awsRegion
-      kafkaConfig.awsAccessKey = awsAccessKey
-      kafkaConfig.awsAccessKeySecret = awsAccessKeySecret
+      if (awsAccessKey) {
+        kafkaConfig.awsAccessKey = awsAccessKey
+        kafkaConfig.awsAccessKeySecret = awsAccessKeySecret
+      }
       if (awsIAMRoleArn) kafkaConfig.awsIAMRoleArn = awsIAMRoleArn
       break
 
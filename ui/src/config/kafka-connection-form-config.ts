@@ -267,15 +267,15 @@ export const KafkaFormConfig = {
       awsAccessKey: {
         name: 'awsIam.awsAccessKey',
         label: 'AWS Access Key ID',
-        placeholder: 'Enter AWS access key ID',
-        required: 'AWS Access Key ID is required',
+        placeholder: 'Leave empty to use the role of the pod',
+        optional: 'AWS Access Key ID is optional',
         type: 'textarea',
       },
       awsAccessKeySecret: {
         name: 'awsIam.awsAccessKeySecret',
         label: 'AWS Access Key Secret',
-        placeholder: 'Enter AWS access key secret',
-        required: 'AWS Access Key Secret is required',
+        placeholder: 'Leave empty to use the role of the pod',
+        optional: 'AWS Access Key Secret is optional',
         type: 'textarea',
       },
       awsRegion: {
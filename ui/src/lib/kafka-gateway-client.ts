@@ -143,6 +143,9 @@ interface GatewayRequest {
   keytab: string // base64 encoded
   krb5Conf: string // base64 encoded
   caCertificate?: string // base64 encoded
+  clientCertificate?: string // base64 encoded
+  clientKey?: string // base64 encoded
+  skipTlsVerification?: boolean
 }
 
 interface GatewayResponse {
@@ -234,8 +237,7 @@ export class KafkaGatewayClient implements IKafkaClient {
       krb5ConfBase64 = Buffer.from(krb5ConfBase64, 'utf-8').toString('base64')
     }
 
-    // Extract CA certificate from truststore and encode as base64
-    let caCertificateBase64: string | undefined
+    // Extract CA certificate from truststore
     let caCertificate: string | undefined
 
     if (this.config.truststore?.certificates) {
@@ -249,28 +251,6 @@ export class KafkaGatewayClient implements IKafkaClient {
       caCertificate = this.config.certificate
     }
 
-    // Process CA certificate: convert to base64 if needed
-    if (caCertificate) {
-      if (caCertificate.startsWith('data:')) {
-        const parts = caCertificate.split(',')
-        if (parts.length > 1) {
-          if (parts[0].includes('base64')) {
-            // Already base64
-            caCertificateBase64 = parts[1]
-          } else {
-            // URL-encoded, decode then re-encode
-            const text = decodeURIComponent(parts[1])
-            caCertificateBase64 = Buffer.from(text, 'utf-8').toString('base64')
-          }
-        }
-      } else if (!this.isBase64(caCertificate)) {
-        // If it's plain text (PEM format), encode to base64
-        caCertificateBase64 = Buffer.from(caCertificate, 'utf-8').toString('base64')
-      } else {
-        caCertificateBase64 = caCertificate
-      }
-    }
-
     return {
       bootstrapServers: this.config.brokers.join(','),
       securityProtocol: this.config.securityProtocol || 'SASL_SSL',
@@ -279,8 +259,36 @@ export class KafkaGatewayClient implements IKafkaClient {
       principal: this.config.kerberosPrincipal,
       keytab: keytabBase64,
       krb5Conf: krb5ConfBase64,
-      caCertificate: caCertificateBase64,
+      caCertificate: caCertificate ? this.encodePem(caCertificate) : undefined,
+      clientCertificate: this.config.clientCert ? this.encodePem(this.config.clientCert) : undefined,
+      clientKey: this.config.clientKey ? this.encodePem(this.config.clientKey) : undefined,
+      skipTlsVerification: this.config.skipTlsVerification,
+    }
+  }
+
+  /**
+   * Encode a PEM certificate or key as base64, accepting data URLs, plain PEM
+   * text and values that are already base64
+   */
+  private encodePem(value: string): string {
+    if (value.startsWith('data:')) {
+      const parts = value.split(',')
+      if (parts.length > 1) {
+        if (parts[0].includes('base64')) {
+          // Already base64
+          return parts[1]
+        }
+        // URL-encoded, decode then re-encode
+        const text = decodeURIComponent(parts[1])
+        return Buffer.from(text, 'utf-8').toString('base64')
+      }
+      return value
+    }
+    if (!this.isBase64(value)) {
+      // If it's plain text (PEM format), encode to base64
+      return Buffer.from(value, 'utf-8').toString('base64')
     }
+    return value
   }
 
   /**
@@ -312,6 +312,8 @@ export const buildInternalPipelineConfig = ({
     mechanism = 'SCRAM-SHA-512'
   } else if (authMethod === 'SASL/GSSAPI') {
     mechanism = 'GSSAPI'
+  } else if (authMethod === 'AWS_MSK_IAM') {
+    mechanism = 'AWS_MSK_IAM'
   }
 
   const connectionParams: any = {
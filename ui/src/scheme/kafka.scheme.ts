@@ -84,14 +84,21 @@ const LdapFormSchema = z.object({
   ldapBaseDn: z.optional(z.string().min(1, 'LDAP base DN is required')),
 })
 
-const AwsIamFormSchema = z.object({
-  awsRegion: z.string().min(1, 'AWS region is required'),
-  awsAccessKey: z.string().min(1, 'AWS access key is required'),
-  awsAccessKeySecret: z.string().min(1, 'AWS access key secret is required'),
-  awsAuthorizationIdentity: z.string().optional(), // UserId or RoleId
-  awsIAMRoleArn: z.string().optional(),
-  awsSessionToken: z.string().optional(),
-})
+// Access keys are optional: pipelines always authenticate with the role of
+// the ingestor pod, the keys only test the connection from the UI
+const AwsIamFormSchema = z
+  .object({
+    awsRegion: z.string().min(1, 'AWS region is required'),
+    awsAccessKey: z.string().optional(),
+    awsAccessKeySecret: z.string().optional(),
+    awsAuthorizationIdentity: z.string().optional(), // UserId or RoleId
+    awsIAMRoleArn: z.string().optional(),
+    awsSessionToken: z.string().optional(),
+  })
+  .refine((data) => Boolean(data.awsAccessKey) === Boolean(data.awsAccessKeySecret), {
+    message: 'AWS access key and secret must be set together',
+    path: ['awsAccessKeySecret'],
+  })
 
 const MtlsFormSchema = z.object({
   clientCert: z.string().min(1, 'Client certificate is required'),
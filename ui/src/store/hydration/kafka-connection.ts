@@ -24,10 +24,12 @@ function mapBackendKafkaConfigToStore(connection_params: any): any {
                     ? 'SASL/LDAP'
                     : mech === 'MTLS'
                       ? 'mTLS'
-                      : // Fallback: check deprecated skip_auth field for backward compatibility
-                        Boolean(connection_params.skip_auth)
-                        ? 'NO_AUTH'
-                        : 'NO_AUTH'
+                      : mech === 'AWS_MSK_IAM'
+                        ? 'AWS_MSK_IAM'
+                        : // Fallback: check deprecated skip_auth field for backward compatibility
+                          Boolean(connection_params.skip_auth)
+                          ? 'NO_AUTH'
+                          : 'NO_AUTH'
 
   return {
     // base values
@@ -22,11 +22,16 @@ export function createAwsIamMechanism(region: string, accessKey: string, secretA
   // Use the built-in mechanism creator
   const mechanism = createMechanism({
     region: region || '',
-    // Pass credentials directly
-    credentials: {
-      accessKeyId: accessKey || '',
-      secretAccessKey: secretAccessKey || '',
-    },
+    // Pass credentials directly, without keys the default AWS provider chain
+    // resolves the role of the pod like the ingestor does
+    ...(accessKey
+      ? {
+          credentials: {
+            accessKeyId: accessKey,
+            secretAccessKey: secretAccessKey || '',
+          },
+        }
+      : {}),
   })
 
   return mechanism